@@ -2,7 +2,17 @@ package main
 
 import (
 	"fmt"
+	"github.com/egv/yolo-runner/v2/internal/agent"
 	"github.com/egv/yolo-runner/v2/internal/codingagents"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/escalation"
+	"github.com/egv/yolo-runner/v2/internal/kubernetes"
+	"github.com/egv/yolo-runner/v2/internal/prompt"
+	"github.com/egv/yolo-runner/v2/internal/repocontext"
+	"github.com/egv/yolo-runner/v2/internal/retention"
+	"github.com/egv/yolo-runner/v2/internal/ssh"
+	"net/url"
+	"sort"
 	"strings"
 	"time"
 )
@@ -15,7 +25,65 @@ type yoloAgentConfigDefaults struct {
 	RunnerTimeout    *time.Duration
 	WatchdogTimeout  *time.Duration
 	WatchdogInterval *time.Duration
-	RetryBudget      *int
+	// WatchdogHooks run when the watchdog detects a stall, before the kill.
+	WatchdogHooks       []string
+	WatchdogHookTimeout *time.Duration
+	RateLimitBackoff    *time.Duration
+	EpicProgress        *time.Duration
+	TaskDiscovery       *time.Duration
+	StatusReconcile     *time.Duration
+	ShutdownGrace       *time.Duration
+	RetryBudget         *int
+	ReviewConcurrency   *int
+	ResumeSessions      *bool
+	SkipReview          *bool
+	DiffSummary         *bool
+	StallNudge          *bool
+	StallNudgePrompt    string
+	LocalStore          string
+	TrackerCacheTTL     *time.Duration
+	StallPolicies       map[contracts.StallCategory]contracts.StallPolicy
+	FallbackChain       []agent.ModelTarget
+	// BackendCapabilities holds agent.backend_capabilities overrides keyed
+	// by backend name.
+	BackendCapabilities map[string]backendCapabilityOverride
+	PromptTemplates     *prompt.Templates
+	CommitMessages      agent.CommitMessageConfig
+	LandingStrategy     agent.LandingStrategy
+	PreLandingHooks     []string
+	LandingHookTimeout  *time.Duration
+	MergeQueueBatchSize int
+	PushRetries         *int
+	NoVCS               bool
+	SyncRemote          string
+	SyncBranch          string
+	PathScope           agent.PathScopeConfig
+	DiffGuardrails      agent.DiffGuardrailConfig
+	SecretScan          agent.SecretScanConfig
+	DependencyPolicy    agent.DependencyPolicyConfig
+	RepoContext         *repocontext.Options
+	ClonePool           agent.ClonePoolOptions
+	CloneStrategy       agent.CloneStrategy
+	OrphanedTasks       agent.OrphanedTaskPolicy
+	ReviewMode          agent.ReviewMode
+	Retention           retention.Config
+	Escalation          escalation.Policy
+	BlockedRetry        map[string]agent.BlockedRetryPolicy
+	// EventSinks holds agent.event_sinks filters keyed by sink name.
+	EventSinks map[string]contracts.EventFilter
+	EventLog   contracts.FileEventSinkOptions
+	// Redactor masks agent.redaction patterns in events; nil redacts nothing.
+	Redactor *contracts.Redactor
+	// ControlAPI holds the agent.control_api roles; token values are read
+	// when --serve starts.
+	ControlAPI controlAPIAccessConfig
+	// Executor holds the agent.executor that runs runner commands on
+	// Kubernetes or a remote host.
+	Executor remoteExecutorConfig
+	// Executors holds the agent.executors tasks can be pinned to, keyed by
+	// name, and WorkerAffinity the agent.affinity rules that pin them.
+	Executors      map[string]remoteExecutorConfig
+	WorkerAffinity agent.WorkerAffinityConfig
 }
 
 func loadYoloAgentConfigDefaults(repoRoot string) (yoloAgentConfigDefaults, error) {
@@ -27,7 +95,50 @@ func loadYoloAgentConfigDefaults(repoRoot string) (yoloAgentConfigDefaults, erro
 	if err != nil {
 		return yoloAgentConfigDefaults{}, err
 	}
-	return resolveYoloAgentConfigDefaults(model.Agent, catalog)
+	defaults, err := resolveYoloAgentConfigDefaults(model.Agent, catalog)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	defaults.PromptTemplates, err = loadAgentPromptTemplates(repoRoot, model.Agent.Prompts)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	return defaults, nil
+}
+
+// loadAgentPromptTemplates parses agent.prompts overrides, falling back to
+// .yolo-runner/prompts/<kind>.tmpl when a kind is not configured.
+func loadAgentPromptTemplates(repoRoot string, model yoloAgentPromptsModel) (*prompt.Templates, error) {
+	templates, err := prompt.LoadTemplates(repoRoot, map[prompt.TemplateKind]string{
+		prompt.TemplateImplement:   model.Implement,
+		prompt.TemplateReview:      model.Review,
+		prompt.TemplateRemediation: model.Remediation,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("agent.prompts in %s is invalid: %w", trackerConfigRelPath, err)
+	}
+	return templates, nil
+}
+
+// resolveCommitMessages parses agent.commit_messages. GitHub profiles get a
+// default tracker_url pointing at the task's issue.
+func resolveCommitMessages(cfg agent.CommitMessageConfig, profile resolvedTrackerProfile) (*agent.CommitMessages, error) {
+	if strings.TrimSpace(cfg.TrackerURL) == "" && profile.Tracker.Type == trackerTypeGitHub && profile.Tracker.GitHub != nil {
+		owner := strings.TrimSpace(profile.Tracker.GitHub.Scope.Owner)
+		repo := strings.TrimSpace(profile.Tracker.GitHub.Scope.Repo)
+		if owner != "" && repo != "" {
+			cfg.TrackerURL = "https://github.com/" + owner + "/" + repo + "/issues/{{.Task.ID}}"
+		}
+	}
+	if strings.TrimSpace(cfg.AutoCommit) == "" && strings.TrimSpace(cfg.Merge) == "" && strings.TrimSpace(cfg.CoAuthoredBy) == "" {
+		// A tracker URL alone changes nothing; keep the built-in messages.
+		return nil, nil
+	}
+	messages, err := agent.ParseCommitMessages(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("agent.commit_messages in %s is invalid: %w", trackerConfigRelPath, err)
+	}
+	return messages, nil
 }
 
 func resolveYoloAgentConfigDefaults(model yoloAgentConfigModel, catalog codingagents.Catalog) (yoloAgentConfigDefaults, error) {
@@ -63,6 +174,43 @@ func resolveYoloAgentConfigDefaults(model yoloAgentConfigModel, catalog codingag
 		}
 		defaults.RetryBudget = &value
 	}
+	if model.ReviewConcurrency != nil {
+		value := *model.ReviewConcurrency
+		if value < 0 {
+			return yoloAgentConfigDefaults{}, fmt.Errorf("agent.review_concurrency in %s must be greater than or equal to 0", trackerConfigRelPath)
+		}
+		defaults.ReviewConcurrency = &value
+	}
+	if model.ResumeSessions != nil {
+		value := *model.ResumeSessions
+		defaults.ResumeSessions = &value
+	}
+	if model.SkipReview != nil {
+		value := *model.SkipReview
+		defaults.SkipReview = &value
+	}
+	if model.DiffSummary != nil {
+		value := *model.DiffSummary
+		defaults.DiffSummary = &value
+	}
+	if model.StallNudge != nil {
+		value := *model.StallNudge
+		defaults.StallNudge = &value
+	}
+	defaults.StallNudgePrompt = strings.TrimSpace(model.StallNudgePrompt)
+	defaults.LocalStore = strings.TrimSpace(model.LocalStore)
+	defaults.StallPolicies, err = resolveAgentStallPolicies(model.StallPolicies)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	defaults.FallbackChain, err = resolveAgentFallbackChain(model.FallbackChain, catalog)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	defaults.BackendCapabilities, err = resolveAgentBackendCapabilities(model.BackendCapabilities, catalog)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
 
 	durationValue, err := parseAgentDuration("runner_timeout", model.RunnerTimeout)
 	if err != nil {
@@ -91,9 +239,938 @@ func resolveYoloAgentConfigDefaults(model yoloAgentConfigModel, catalog codingag
 	}
 	defaults.WatchdogInterval = durationValue
 
+	for i, command := range model.WatchdogHooks {
+		if strings.TrimSpace(command) == "" {
+			return yoloAgentConfigDefaults{}, fmt.Errorf("agent.watchdog_hooks[%d] in %s must not be empty", i, trackerConfigRelPath)
+		}
+		defaults.WatchdogHooks = append(defaults.WatchdogHooks, strings.TrimSpace(command))
+	}
+	durationValue, err = parseAgentDuration("watchdog_hook_timeout", model.WatchdogHookTimeout)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	if durationValue != nil && *durationValue <= 0 {
+		return yoloAgentConfigDefaults{}, fmt.Errorf("agent.watchdog_hook_timeout in %s must be greater than 0", trackerConfigRelPath)
+	}
+	defaults.WatchdogHookTimeout = durationValue
+
+	durationValue, err = parseAgentDuration("rate_limit_backoff", model.RateLimitBackoff)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	if durationValue != nil && *durationValue < 0 {
+		return yoloAgentConfigDefaults{}, fmt.Errorf("agent.rate_limit_backoff in %s must be greater than or equal to 0", trackerConfigRelPath)
+	}
+	defaults.RateLimitBackoff = durationValue
+
+	durationValue, err = parseAgentDuration("epic_progress_interval", model.EpicProgress)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	if durationValue != nil && *durationValue < 0 {
+		return yoloAgentConfigDefaults{}, fmt.Errorf("agent.epic_progress_interval in %s must be greater than or equal to 0", trackerConfigRelPath)
+	}
+	defaults.EpicProgress = durationValue
+
+	durationValue, err = parseAgentDuration("task_discovery_interval", model.TaskDiscovery)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	if durationValue != nil && *durationValue < 0 {
+		return yoloAgentConfigDefaults{}, fmt.Errorf("agent.task_discovery_interval in %s must be greater than or equal to 0", trackerConfigRelPath)
+	}
+	defaults.TaskDiscovery = durationValue
+
+	durationValue, err = parseAgentDuration("status_reconcile_interval", model.StatusReconcile)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	if durationValue != nil && *durationValue < 0 {
+		return yoloAgentConfigDefaults{}, fmt.Errorf("agent.status_reconcile_interval in %s must be greater than or equal to 0", trackerConfigRelPath)
+	}
+	defaults.StatusReconcile = durationValue
+
+	durationValue, err = parseAgentDuration("shutdown_grace_period", model.ShutdownGrace)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	if durationValue != nil && *durationValue < 0 {
+		return yoloAgentConfigDefaults{}, fmt.Errorf("agent.shutdown_grace_period in %s must be greater than or equal to 0", trackerConfigRelPath)
+	}
+	defaults.ShutdownGrace = durationValue
+
+	durationValue, err = parseAgentDuration("tracker_cache_ttl", model.TrackerCacheTTL)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	if durationValue != nil && *durationValue < 0 {
+		return yoloAgentConfigDefaults{}, fmt.Errorf("agent.tracker_cache_ttl in %s must be greater than or equal to 0", trackerConfigRelPath)
+	}
+	defaults.TrackerCacheTTL = durationValue
+
+	switch strings.ToLower(strings.TrimSpace(model.VCS)) {
+	case "", "git":
+	case "none":
+		defaults.NoVCS = true
+	default:
+		return yoloAgentConfigDefaults{}, fmt.Errorf("agent.vcs in %s must be git or none", trackerConfigRelPath)
+	}
+
+	defaults.RepoContext, err = resolveAgentRepoContext(model.RepoContext)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+
+	defaults.ClonePool, err = resolveAgentClonePool(model.ClonePool)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	defaults.CloneStrategy, err = agent.ParseCloneStrategy(model.CloneStrategy)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, fmt.Errorf("agent.clone_strategy in %s must be clone or overlay", trackerConfigRelPath)
+	}
+	defaults.OrphanedTasks, err = agent.ParseOrphanedTaskPolicy(model.OrphanedTasks)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, fmt.Errorf("agent.orphaned_tasks in %s must be reopen or block", trackerConfigRelPath)
+	}
+	defaults.ReviewMode, err = agent.ParseReviewMode(model.ReviewMode)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, fmt.Errorf("agent.review_mode in %s must be single or two_phase", trackerConfigRelPath)
+	}
+
+	defaults.Retention, err = resolveAgentRetention(model.Retention)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+
+	defaults.Escalation, err = resolveAgentEscalation(model.Escalation)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+
+	defaults.BlockedRetry, err = resolveAgentBlockedRetry(model.BlockedRetry)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+
+	defaults.EventSinks, err = resolveAgentEventSinks(model.EventSinks)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+
+	defaults.EventLog, err = resolveAgentEventLog(model.EventLog)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+
+	defaults.Redactor, err = resolveAgentRedaction(model.Redaction)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+
+	defaults.ControlAPI, err = resolveAgentControlAPI(model.ControlAPI)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	defaults.Executor, err = resolveAgentExecutor("agent.executor", model.Executor)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	defaults.Executors, defaults.WorkerAffinity, err = resolveAgentAffinity(model.Executors, model.Affinity)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+
+	defaults.CommitMessages = agent.CommitMessageConfig{
+		AutoCommit:   model.CommitMessages.AutoCommit,
+		Merge:        model.CommitMessages.Merge,
+		Squash:       model.CommitMessages.Squash,
+		TrackerURL:   model.CommitMessages.TrackerURL,
+		CoAuthoredBy: model.CommitMessages.CoAuthoredBy,
+	}
+	if _, err := agent.ParseCommitMessages(defaults.CommitMessages); err != nil {
+		return yoloAgentConfigDefaults{}, fmt.Errorf("agent.commit_messages in %s is invalid: %w", trackerConfigRelPath, err)
+	}
+
+	defaults.LandingStrategy, err = agent.ParseLandingStrategy(model.Landing.Strategy)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, fmt.Errorf("agent.landing.strategy in %s is invalid: %w", trackerConfigRelPath, err)
+	}
+	for i, command := range model.Landing.PreMerge {
+		if strings.TrimSpace(command) == "" {
+			return yoloAgentConfigDefaults{}, fmt.Errorf("agent.landing.pre_merge[%d] in %s must not be empty", i, trackerConfigRelPath)
+		}
+		defaults.PreLandingHooks = append(defaults.PreLandingHooks, strings.TrimSpace(command))
+	}
+	durationValue, err = parseAgentDuration("landing.hook_timeout", model.Landing.HookTimeout)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	if durationValue != nil && *durationValue <= 0 {
+		return yoloAgentConfigDefaults{}, fmt.Errorf("agent.landing.hook_timeout in %s must be greater than 0", trackerConfigRelPath)
+	}
+	defaults.LandingHookTimeout = durationValue
+	if model.Landing.BatchSize != nil {
+		if *model.Landing.BatchSize <= 0 {
+			return yoloAgentConfigDefaults{}, fmt.Errorf("agent.landing.batch_size in %s must be greater than 0", trackerConfigRelPath)
+		}
+		defaults.MergeQueueBatchSize = *model.Landing.BatchSize
+	}
+	if model.Landing.PushRetries != nil {
+		value := *model.Landing.PushRetries
+		if value < 0 {
+			return yoloAgentConfigDefaults{}, fmt.Errorf("agent.landing.push_retries in %s must be greater than or equal to 0", trackerConfigRelPath)
+		}
+		defaults.PushRetries = &value
+	}
+	defaults.SyncRemote = strings.TrimSpace(model.Sync.Remote)
+	defaults.SyncBranch = strings.TrimSpace(model.Sync.Branch)
+
+	defaults.PathScope, err = resolveAgentPathScope(model.PathScope)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+
+	defaults.DiffGuardrails, err = resolveAgentDiffGuardrails(model.DiffGuardrails)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+
+	defaults.SecretScan = agent.SecretScanConfig{Enabled: model.SecretScan.Enabled, Command: strings.TrimSpace(model.SecretScan.Command)}
+	durationValue, err = parseAgentDuration("secret_scan.timeout", model.SecretScan.Timeout)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+	if durationValue != nil {
+		if *durationValue <= 0 {
+			return yoloAgentConfigDefaults{}, fmt.Errorf("agent.secret_scan.timeout in %s must be greater than 0", trackerConfigRelPath)
+		}
+		defaults.SecretScan.Timeout = *durationValue
+	}
+
+	defaults.DependencyPolicy, err = resolveAgentDependencyPolicy(model.DependencyPolicy)
+	if err != nil {
+		return yoloAgentConfigDefaults{}, err
+	}
+
 	return defaults, nil
 }
 
+func resolveAgentPathScope(model yoloAgentPathScopeModel) (agent.PathScopeConfig, error) {
+	config := agent.PathScopeConfig{CodeOwners: model.CodeOwners}
+	for rawLabel, rawGlobs := range model.Labels {
+		label := strings.ToLower(strings.TrimSpace(rawLabel))
+		if label == "" {
+			return agent.PathScopeConfig{}, fmt.Errorf("agent.path_scope.labels in %s must not contain an empty label", trackerConfigRelPath)
+		}
+		if len(rawGlobs) == 0 {
+			return agent.PathScopeConfig{}, fmt.Errorf("agent.path_scope.labels.%s in %s must list at least one path glob", label, trackerConfigRelPath)
+		}
+		globs := make([]string, 0, len(rawGlobs))
+		for i, glob := range rawGlobs {
+			if strings.TrimSpace(glob) == "" {
+				return agent.PathScopeConfig{}, fmt.Errorf("agent.path_scope.labels.%s[%d] in %s must not be empty", label, i, trackerConfigRelPath)
+			}
+			globs = append(globs, strings.TrimSpace(glob))
+		}
+		if config.Labels == nil {
+			config.Labels = make(map[string][]string, len(model.Labels))
+		}
+		config.Labels[label] = append(config.Labels[label], globs...)
+	}
+	return config, nil
+}
+
+func resolveAgentDiffGuardrails(model yoloAgentDiffGuardrailsModel) (agent.DiffGuardrailConfig, error) {
+	config := agent.DiffGuardrailConfig{BlockBinaryFiles: model.BlockBinaryFiles}
+	if model.MaxChangedFiles != nil {
+		if *model.MaxChangedFiles <= 0 {
+			return agent.DiffGuardrailConfig{}, fmt.Errorf("agent.diff_guardrails.max_changed_files in %s must be greater than 0", trackerConfigRelPath)
+		}
+		config.MaxChangedFiles = *model.MaxChangedFiles
+	}
+	if model.MaxChangedLines != nil {
+		if *model.MaxChangedLines <= 0 {
+			return agent.DiffGuardrailConfig{}, fmt.Errorf("agent.diff_guardrails.max_changed_lines in %s must be greater than 0", trackerConfigRelPath)
+		}
+		config.MaxChangedLines = *model.MaxChangedLines
+	}
+	for i, glob := range model.ForbiddenPaths {
+		if strings.TrimSpace(glob) == "" {
+			return agent.DiffGuardrailConfig{}, fmt.Errorf("agent.diff_guardrails.forbidden_paths[%d] in %s must not be empty", i, trackerConfigRelPath)
+		}
+		config.ForbiddenPaths = append(config.ForbiddenPaths, strings.TrimSpace(glob))
+	}
+	action, err := agent.ParseDiffGuardrailAction(model.OnViolation)
+	if err != nil {
+		return agent.DiffGuardrailConfig{}, fmt.Errorf("agent.diff_guardrails.on_violation in %s is invalid: %w", trackerConfigRelPath, err)
+	}
+	config.Action = action
+	return config, nil
+}
+
+func resolveAgentDependencyPolicy(model yoloAgentDependencyPolicyModel) (agent.DependencyPolicyConfig, error) {
+	config := agent.DependencyPolicyConfig{LicenseCommand: strings.TrimSpace(model.LicenseCommand)}
+	for _, list := range []struct {
+		field  string
+		values []string
+		target *[]string
+	}{
+		{"allow", model.Allow, &config.Allow},
+		{"deny", model.Deny, &config.Deny},
+		{"licenses.allow", model.Licenses.Allow, &config.AllowLicenses},
+		{"licenses.deny", model.Licenses.Deny, &config.DenyLicenses},
+	} {
+		for i, value := range list.values {
+			if strings.TrimSpace(value) == "" {
+				return agent.DependencyPolicyConfig{}, fmt.Errorf("agent.dependency_policy.%s[%d] in %s must not be empty", list.field, i, trackerConfigRelPath)
+			}
+			*list.target = append(*list.target, strings.TrimSpace(value))
+		}
+	}
+	if (len(config.AllowLicenses) > 0 || len(config.DenyLicenses) > 0) && config.LicenseCommand == "" {
+		return agent.DependencyPolicyConfig{}, fmt.Errorf("agent.dependency_policy.license_command in %s must be set when agent.dependency_policy.licenses is configured", trackerConfigRelPath)
+	}
+	timeout, err := parseAgentDuration("dependency_policy.timeout", model.Timeout)
+	if err != nil {
+		return agent.DependencyPolicyConfig{}, err
+	}
+	if timeout != nil {
+		if *timeout <= 0 {
+			return agent.DependencyPolicyConfig{}, fmt.Errorf("agent.dependency_policy.timeout in %s must be greater than 0", trackerConfigRelPath)
+		}
+		config.Timeout = *timeout
+	}
+	return config, nil
+}
+
+func resolveAgentStallPolicies(model map[string]string) (map[contracts.StallCategory]contracts.StallPolicy, error) {
+	if len(model) == 0 {
+		return nil, nil
+	}
+	policies := make(map[contracts.StallCategory]contracts.StallPolicy, len(model))
+	for rawCategory, rawPolicy := range model {
+		category, err := contracts.ParseStallCategory(rawCategory)
+		if err != nil {
+			return nil, fmt.Errorf("agent.stall_policies in %s is invalid: %w", trackerConfigRelPath, err)
+		}
+		policy, err := contracts.ParseStallPolicy(rawPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("agent.stall_policies.%s in %s is invalid: %w", category, trackerConfigRelPath, err)
+		}
+		policies[category] = policy
+	}
+	return policies, nil
+}
+
+// defaultBlockedRetryAttempts applies to agent.blocked_retry entries without
+// max_attempts.
+const defaultBlockedRetryAttempts = 3
+
+// resolveAgentBlockedRetry validates agent.blocked_retry. Keys name a
+// failure category.
+func resolveAgentBlockedRetry(model map[string]yoloAgentBlockedRetryModel) (map[string]agent.BlockedRetryPolicy, error) {
+	if len(model) == 0 {
+		return nil, nil
+	}
+	categories := agent.BlockedRetryCategories()
+	policies := make(map[string]agent.BlockedRetryPolicy, len(model))
+	for rawCategory, entry := range model {
+		category := strings.ToLower(strings.TrimSpace(rawCategory))
+		if !containsStringIgnoreCase(categories, category) {
+			return nil, fmt.Errorf("agent.blocked_retry.%s in %s must name a category, one of: %s", rawCategory, trackerConfigRelPath, strings.Join(categories, ", "))
+		}
+		policy := agent.BlockedRetryPolicy{MaxAttempts: defaultBlockedRetryAttempts}
+		if entry.MaxAttempts != nil {
+			if *entry.MaxAttempts < 0 {
+				return nil, fmt.Errorf("agent.blocked_retry.%s.max_attempts in %s must be greater than or equal to 0", category, trackerConfigRelPath)
+			}
+			policy.MaxAttempts = *entry.MaxAttempts
+		}
+		for _, field := range []struct {
+			name   string
+			raw    string
+			target *time.Duration
+		}{
+			{name: "backoff", raw: entry.Backoff, target: &policy.Backoff},
+			{name: "max_backoff", raw: entry.MaxBackoff, target: &policy.MaxBackoff},
+		} {
+			value, err := parseAgentDuration("blocked_retry."+category+"."+field.name, field.raw)
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				if *value < 0 {
+					return nil, fmt.Errorf("agent.blocked_retry.%s.%s in %s must be greater than or equal to 0", category, field.name, trackerConfigRelPath)
+				}
+				*field.target = *value
+			}
+		}
+		policies[category] = policy
+	}
+	return policies, nil
+}
+
+// formatBlockedRetryPolicies renders policies as category=max_attempts
+// pairs, with the backoff when one is set, sorted by category for run
+// metadata.
+func formatBlockedRetryPolicies(policies map[string]agent.BlockedRetryPolicy) string {
+	pairs := make([]string, 0, len(policies))
+	for category, policy := range policies {
+		pair := fmt.Sprintf("%s=%d", category, policy.MaxAttempts)
+		if policy.Backoff > 0 {
+			pair += "/" + policy.Backoff.String()
+		}
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// resolveAgentFallbackChain validates agent.fallback_chain. An entry without a
+// model uses the default model of its backend; an entry without a backend
+// keeps the task's backend.
+func resolveAgentFallbackChain(model []yoloAgentFallbackModel, catalog codingagents.Catalog) ([]agent.ModelTarget, error) {
+	if len(model) == 0 {
+		return nil, nil
+	}
+	chain := make([]agent.ModelTarget, 0, len(model))
+	for i, entry := range model {
+		target := agent.ModelTarget{Model: strings.TrimSpace(entry.Model)}
+		if backend := strings.TrimSpace(entry.Backend); backend != "" {
+			target.Backend = normalizeBackend(backend)
+			if _, ok := catalog.Backend(target.Backend); !ok {
+				return nil, fmt.Errorf("agent.fallback_chain[%d].backend in %s must be one of: %s", i, trackerConfigRelPath, strings.Join(catalog.Names(), ", "))
+			}
+			if target.Model == "" {
+				target.Model = catalogBackendDefaultModel(catalog, target.Backend)
+			}
+		}
+		if target.Model == "" {
+			return nil, fmt.Errorf("agent.fallback_chain[%d] in %s must set a model", i, trackerConfigRelPath)
+		}
+		chain = append(chain, target)
+	}
+	return chain, nil
+}
+
+// resolveAgentBackendCapabilities validates agent.backend_capabilities. Every
+// key must name a backend from the coding agents catalog.
+func resolveAgentBackendCapabilities(model map[string]yoloAgentBackendCapabilitiesModel, catalog codingagents.Catalog) (map[string]backendCapabilityOverride, error) {
+	if len(model) == 0 {
+		return nil, nil
+	}
+	overrides := make(map[string]backendCapabilityOverride, len(model))
+	for rawName, entry := range model {
+		name := strings.ToLower(strings.TrimSpace(rawName))
+		if _, ok := catalog.Backend(name); !ok {
+			return nil, fmt.Errorf("agent.backend_capabilities.%s in %s must name a backend, one of: %s", rawName, trackerConfigRelPath, strings.Join(catalog.Names(), ", "))
+		}
+		if _, ok := overrides[name]; ok {
+			return nil, fmt.Errorf("agent.backend_capabilities.%s in %s is configured more than once", rawName, trackerConfigRelPath)
+		}
+		overrides[name] = backendCapabilityOverride{
+			SupportsReview: entry.Review,
+			SupportsStream: entry.Stream,
+			SupportsResume: entry.SessionResume,
+			SupportsACP:    entry.ACP,
+		}
+	}
+	return overrides, nil
+}
+
+// formatFallbackChain renders the chain as backend:model entries for run
+// metadata.
+func formatFallbackChain(chain []agent.ModelTarget) string {
+	entries := make([]string, 0, len(chain))
+	for _, target := range chain {
+		entry := target.Model
+		if target.Backend != "" {
+			entry = target.Backend + ":" + target.Model
+		}
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, ",")
+}
+
+// formatStallPolicies renders policies as category=policy pairs in taxonomy
+// order for run metadata.
+func formatStallPolicies(policies map[contracts.StallCategory]contracts.StallPolicy) string {
+	pairs := make([]string, 0, len(policies))
+	for _, category := range contracts.StallCategories {
+		if policy, ok := policies[category]; ok {
+			pairs = append(pairs, string(category)+"="+string(policy))
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
+func resolveAgentRepoContext(model *yoloAgentRepoContextModel) (*repocontext.Options, error) {
+	if model == nil || !model.Enabled {
+		return nil, nil
+	}
+	options := repocontext.Options{RecentCommits: repocontext.DefaultRecentCommits}
+	if len(model.Files) > 0 {
+		options.Files = append([]string{}, model.Files...)
+	}
+	if model.RecentCommits != nil {
+		if *model.RecentCommits < 0 {
+			return nil, fmt.Errorf("agent.repo_context.recent_commits in %s must be greater than or equal to 0", trackerConfigRelPath)
+		}
+		options.RecentCommits = *model.RecentCommits
+	}
+	if model.TreeDepth != nil {
+		if *model.TreeDepth <= 0 {
+			return nil, fmt.Errorf("agent.repo_context.tree_depth in %s must be greater than 0", trackerConfigRelPath)
+		}
+		options.TreeDepth = *model.TreeDepth
+	}
+	if model.MaxBytes != nil {
+		if *model.MaxBytes <= 0 {
+			return nil, fmt.Errorf("agent.repo_context.max_bytes in %s must be greater than 0", trackerConfigRelPath)
+		}
+		options.MaxBytes = *model.MaxBytes
+	}
+	return &options, nil
+}
+
+// resolveAgentClonePool validates agent.clone_pool. Without a size the pool
+// is off.
+func resolveAgentClonePool(model *yoloAgentClonePoolModel) (agent.ClonePoolOptions, error) {
+	if model == nil {
+		return agent.ClonePoolOptions{}, nil
+	}
+	options := agent.ClonePoolOptions{}
+	if model.Size != nil {
+		if *model.Size < 0 {
+			return agent.ClonePoolOptions{}, fmt.Errorf("agent.clone_pool.size in %s must be greater than or equal to 0", trackerConfigRelPath)
+		}
+		options.Size = *model.Size
+	}
+	refresh, err := agent.ParseClonePoolRefresh(model.Refresh)
+	if err != nil {
+		return agent.ClonePoolOptions{}, fmt.Errorf("agent.clone_pool.refresh in %s must be reset or recreate", trackerConfigRelPath)
+	}
+	options.Refresh = refresh
+	if model.MaxUses != nil {
+		if *model.MaxUses < 0 {
+			return agent.ClonePoolOptions{}, fmt.Errorf("agent.clone_pool.max_uses in %s must be greater than or equal to 0", trackerConfigRelPath)
+		}
+		options.MaxUses = *model.MaxUses
+	}
+	return options, nil
+}
+
+// resolveAgentRetention validates agent.retention. Areas without a block
+// have no cap.
+func resolveAgentRetention(model *yoloAgentRetentionModel) (retention.Config, error) {
+	config := retention.Config{}
+	if model == nil {
+		return config, nil
+	}
+	for _, area := range []struct {
+		name   string
+		model  *yoloAgentRetentionPolicyModel
+		target *retention.Policy
+	}{
+		{name: "runner_logs", model: model.RunnerLogs, target: &config.RunnerLogs},
+		{name: "clones", model: model.Clones, target: &config.Clones},
+		{name: "artifacts", model: model.Artifacts, target: &config.Artifacts},
+	} {
+		if area.model == nil {
+			continue
+		}
+		if area.model.MaxSizeMB != nil {
+			if *area.model.MaxSizeMB < 0 {
+				return config, fmt.Errorf("agent.retention.%s.max_size_mb in %s must be greater than or equal to 0", area.name, trackerConfigRelPath)
+			}
+			area.target.MaxBytes = int64(*area.model.MaxSizeMB) << 20
+		}
+		maxAge, err := parseAgentDuration("retention."+area.name+".max_age", area.model.MaxAge)
+		if err != nil {
+			return config, err
+		}
+		if maxAge != nil {
+			if *maxAge < 0 {
+				return config, fmt.Errorf("agent.retention.%s.max_age in %s must be greater than or equal to 0", area.name, trackerConfigRelPath)
+			}
+			area.target.MaxAge = *maxAge
+		}
+	}
+	minAge, err := parseAgentDuration("retention.min_age", model.MinAge)
+	if err != nil {
+		return config, err
+	}
+	if minAge != nil {
+		if *minAge < 0 {
+			return config, fmt.Errorf("agent.retention.min_age in %s must be greater than or equal to 0", trackerConfigRelPath)
+		}
+		config.MinAge = *minAge
+	}
+	return config, nil
+}
+
+// resolveAgentEscalation validates agent.escalation. Without an after delay
+// blocked tasks are not escalated.
+func resolveAgentEscalation(model *yoloAgentEscalationModel) (escalation.Policy, error) {
+	policy := escalation.Policy{}
+	if model == nil {
+		return policy, nil
+	}
+	after, err := parseAgentDuration("escalation.after", model.After)
+	if err != nil {
+		return policy, err
+	}
+	if after != nil {
+		if *after < 0 {
+			return policy, fmt.Errorf("agent.escalation.after in %s must be greater than or equal to 0", trackerConfigRelPath)
+		}
+		policy.After = *after
+	}
+	policy.Assignee = strings.TrimSpace(model.Assignee)
+	policy.Label = strings.TrimSpace(model.Label)
+	policy.WebhookURL = strings.TrimSpace(model.WebhookURL)
+	if policy.WebhookURL != "" {
+		parsed, err := url.Parse(policy.WebhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return policy, fmt.Errorf("agent.escalation.webhook_url in %s must be an http or https URL", trackerConfigRelPath)
+		}
+	}
+	return policy, nil
+}
+
+// resolveAgentEventSinks validates agent.event_sinks. Keys name a sink and
+// sample rates are percentages.
+func resolveAgentEventSinks(model map[string]yoloAgentEventSinkModel) (map[string]contracts.EventFilter, error) {
+	if len(model) == 0 {
+		return nil, nil
+	}
+	filters := make(map[string]contracts.EventFilter, len(model))
+	for rawName, entry := range model {
+		name := strings.ToLower(strings.TrimSpace(rawName))
+		if name != eventSinkFile && name != eventSinkStream {
+			return nil, fmt.Errorf("agent.event_sinks.%s in %s must name a sink, one of: %s, %s", rawName, trackerConfigRelPath, eventSinkFile, eventSinkStream)
+		}
+		filter := contracts.EventFilter{}
+		for _, raw := range entry.Include {
+			eventType := contracts.EventType(strings.TrimSpace(raw))
+			if eventType == "" {
+				return nil, fmt.Errorf("agent.event_sinks.%s.include in %s must not contain empty event types", name, trackerConfigRelPath)
+			}
+			filter.Include = append(filter.Include, eventType)
+		}
+		for _, raw := range entry.Exclude {
+			eventType := contracts.EventType(strings.TrimSpace(raw))
+			if eventType == "" {
+				return nil, fmt.Errorf("agent.event_sinks.%s.exclude in %s must not contain empty event types", name, trackerConfigRelPath)
+			}
+			filter.Exclude = append(filter.Exclude, eventType)
+		}
+		for raw, percent := range entry.Sample {
+			eventType := contracts.EventType(strings.TrimSpace(raw))
+			if eventType == "" || percent < 0 || percent > 100 {
+				return nil, fmt.Errorf("agent.event_sinks.%s.sample in %s must map event types to a percentage between 0 and 100", name, trackerConfigRelPath)
+			}
+			if filter.SamplePercent == nil {
+				filter.SamplePercent = map[contracts.EventType]int{}
+			}
+			filter.SamplePercent[eventType] = percent
+		}
+		filters[name] = filter
+	}
+	return filters, nil
+}
+
+// resolveAgentRedaction builds the event redactor from agent.redaction.
+func resolveAgentRedaction(model *yoloAgentRedactionModel) (*contracts.Redactor, error) {
+	if model == nil {
+		return nil, nil
+	}
+	for name := range model.Patterns {
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("agent.redaction.patterns in %s must not contain an empty name", trackerConfigRelPath)
+		}
+	}
+	redactor, err := contracts.NewRedactor(model.Presets, model.Patterns)
+	if err != nil {
+		return nil, fmt.Errorf("agent.redaction in %s is invalid: %w", trackerConfigRelPath, err)
+	}
+	return redactor, nil
+}
+
+// resolveAgentExecutor validates the executor at field, agent.executor or
+// one of agent.executors. The zero config runs runner commands as local
+// processes.
+func resolveAgentExecutor(field string, model *yoloAgentExecutorModel) (remoteExecutorConfig, error) {
+	if model == nil {
+		return remoteExecutorConfig{}, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(model.Type)) {
+	case "", "local":
+		return remoteExecutorConfig{}, nil
+	case "kubernetes":
+		config, err := resolveAgentKubernetesExecutor(field, model.Kubernetes)
+		return remoteExecutorConfig{Kubernetes: config}, err
+	case "ssh":
+		config, err := resolveAgentSSHExecutor(field, model.SSH)
+		return remoteExecutorConfig{SSH: config}, err
+	default:
+		return remoteExecutorConfig{}, fmt.Errorf("%s.type in %s must be local, kubernetes or ssh, got %q", field, trackerConfigRelPath, model.Type)
+	}
+}
+
+// resolveAgentAffinity validates agent.executors and the agent.affinity
+// rules that pin labeled tasks to them.
+func resolveAgentAffinity(executors map[string]yoloAgentNamedExecutorModel, rules []yoloAgentAffinityModel) (map[string]remoteExecutorConfig, agent.WorkerAffinityConfig, error) {
+	affinity := agent.WorkerAffinityConfig{}
+	resolved := map[string]remoteExecutorConfig{}
+	for name, model := range executors {
+		field := "agent.executors." + name
+		if strings.TrimSpace(name) != name || name == "" {
+			return nil, affinity, fmt.Errorf("agent.executors in %s has an invalid name %q", trackerConfigRelPath, name)
+		}
+		config, err := resolveAgentExecutor(field, &yoloAgentExecutorModel{Type: model.Type, Kubernetes: model.Kubernetes, SSH: model.SSH})
+		if err != nil {
+			return nil, affinity, err
+		}
+		resolved[name] = config
+		if model.MaxTasks != nil {
+			if *model.MaxTasks <= 0 {
+				return nil, affinity, fmt.Errorf("%s.max_tasks in %s must be greater than 0", field, trackerConfigRelPath)
+			}
+			if affinity.Slots == nil {
+				affinity.Slots = map[string]int{}
+			}
+			affinity.Slots[name] = *model.MaxTasks
+		}
+	}
+	for i, rule := range rules {
+		executor := strings.TrimSpace(rule.Executor)
+		if _, ok := resolved[executor]; !ok {
+			return nil, affinity, fmt.Errorf("agent.affinity[%d].executor in %s must name an agent.executors entry, got %q", i, trackerConfigRelPath, rule.Executor)
+		}
+		labels := []string{}
+		for _, label := range rule.Labels {
+			if label = strings.TrimSpace(label); label != "" {
+				labels = append(labels, label)
+			}
+		}
+		if len(labels) == 0 {
+			return nil, affinity, fmt.Errorf("agent.affinity[%d].labels in %s must list at least one label", i, trackerConfigRelPath)
+		}
+		affinity.Rules = append(affinity.Rules, agent.WorkerAffinityRule{Labels: labels, Executor: executor})
+	}
+	if len(resolved) == 0 {
+		resolved = nil
+	}
+	return resolved, affinity, nil
+}
+
+// resolveAgentSSHExecutor validates the ssh block of the executor at field.
+func resolveAgentSSHExecutor(field string, model *yoloAgentSSHModel) (*ssh.Config, error) {
+	if model == nil {
+		return nil, fmt.Errorf("%s.ssh in %s is required when %s.type is ssh", field, trackerConfigRelPath, field)
+	}
+	config := &ssh.Config{
+		SSH:          strings.TrimSpace(model.SSH),
+		Git:          strings.TrimSpace(model.Git),
+		Host:         strings.TrimSpace(model.Host),
+		IdentityFile: strings.TrimSpace(model.IdentityFile),
+		RemoteDir:    strings.TrimSpace(model.RemoteDir),
+	}
+	if config.Host == "" || strings.HasPrefix(config.Host, "-") {
+		return nil, fmt.Errorf("%s.ssh.host in %s is required", field, trackerConfigRelPath)
+	}
+	if !strings.HasPrefix(config.RemoteDir, "/") {
+		return nil, fmt.Errorf("%s.ssh.remote_dir in %s must be an absolute path on the host", field, trackerConfigRelPath)
+	}
+	if model.Port != nil {
+		if *model.Port <= 0 || *model.Port > 65535 {
+			return nil, fmt.Errorf("%s.ssh.port in %s must be between 1 and 65535", field, trackerConfigRelPath)
+		}
+		config.Port = *model.Port
+	}
+	for _, option := range model.Options {
+		if option = strings.TrimSpace(option); option != "" {
+			config.Options = append(config.Options, option)
+		}
+	}
+	return config, nil
+}
+
+// resolveAgentKubernetesExecutor validates the kubernetes block of the
+// executor at field.
+func resolveAgentKubernetesExecutor(field string, k8s *yoloAgentKubernetesModel) (*kubernetes.Config, error) {
+	if k8s == nil {
+		return nil, fmt.Errorf("%s.kubernetes in %s is required when %s.type is kubernetes", field, trackerConfigRelPath, field)
+	}
+	config := &kubernetes.Config{
+		Kubectl:         strings.TrimSpace(k8s.Kubectl),
+		Context:         strings.TrimSpace(k8s.Context),
+		Namespace:       strings.TrimSpace(k8s.Namespace),
+		Image:           strings.TrimSpace(k8s.Image),
+		ImagePullPolicy: strings.TrimSpace(k8s.ImagePullPolicy),
+		ServiceAccount:  strings.TrimSpace(k8s.ServiceAccount),
+		Requests:        k8s.Resources.Requests,
+		Limits:          k8s.Resources.Limits,
+		Workspace: kubernetes.Workspace{
+			Claim:     strings.TrimSpace(k8s.Workspace.Claim),
+			LocalPath: strings.TrimSpace(k8s.Workspace.LocalPath),
+			MountPath: strings.TrimSpace(k8s.Workspace.MountPath),
+		},
+	}
+	if config.Image == "" {
+		return nil, fmt.Errorf("%s.kubernetes.image in %s is required", field, trackerConfigRelPath)
+	}
+	if config.Workspace.Claim == "" {
+		return nil, fmt.Errorf("%s.kubernetes.workspace.claim in %s is required", field, trackerConfigRelPath)
+	}
+	if config.Workspace.MountPath != "" && !strings.HasPrefix(config.Workspace.MountPath, "/") {
+		return nil, fmt.Errorf("%s.kubernetes.workspace.mount_path in %s must be an absolute path", field, trackerConfigRelPath)
+	}
+	switch config.ImagePullPolicy {
+	case "", "Always", "IfNotPresent", "Never":
+	default:
+		return nil, fmt.Errorf("%s.kubernetes.image_pull_policy in %s must be Always, IfNotPresent or Never, got %q", field, trackerConfigRelPath, config.ImagePullPolicy)
+	}
+	for _, secret := range k8s.EnvFromSecrets {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			config.EnvFromSecrets = append(config.EnvFromSecrets, secret)
+		}
+	}
+	if raw := strings.TrimSpace(k8s.StartTimeout); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("%s.kubernetes.start_timeout in %s must be a duration greater than 0", field, trackerConfigRelPath)
+		}
+		config.StartTimeout = timeout
+	}
+	return config, nil
+}
+
+// resolveAgentControlAPI validates agent.control_api.
+func resolveAgentControlAPI(model *yoloAgentControlAPIModel) (controlAPIAccessConfig, error) {
+	config := controlAPIAccessConfig{}
+	if model == nil {
+		return config, nil
+	}
+	names := map[string]struct{}{}
+	for i, token := range model.Tokens {
+		name := strings.TrimSpace(token.Name)
+		if name == "" {
+			return config, fmt.Errorf("agent.control_api.tokens[%d].name in %s is required", i, trackerConfigRelPath)
+		}
+		if _, ok := names[name]; ok {
+			return config, fmt.Errorf("agent.control_api.tokens in %s names %q more than once", trackerConfigRelPath, name)
+		}
+		names[name] = struct{}{}
+		role, err := parseControlRole(token.Role)
+		if err != nil {
+			return config, fmt.Errorf("agent.control_api.tokens[%d].role in %s is invalid: %w", i, trackerConfigRelPath, err)
+		}
+		tokenEnv := strings.TrimSpace(token.TokenEnv)
+		if tokenEnv == "" {
+			return config, fmt.Errorf("agent.control_api.tokens[%d].token_env in %s is required", i, trackerConfigRelPath)
+		}
+		config.Tokens = append(config.Tokens, controlAPITokenConfig{Name: name, Role: role, TokenEnv: tokenEnv})
+	}
+	if model.Slack != nil {
+		roles, err := resolveControlRoleMap("agent.control_api.slack.roles", model.Slack.Roles)
+		if err != nil {
+			return config, err
+		}
+		config.SlackRoles = roles
+	}
+	if model.OIDC == nil {
+		return config, nil
+	}
+	issuer := strings.TrimSpace(model.OIDC.Issuer)
+	parsed, err := url.Parse(issuer)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return config, fmt.Errorf("agent.control_api.oidc.issuer in %s must be an http or https URL", trackerConfigRelPath)
+	}
+	audience := strings.TrimSpace(model.OIDC.Audience)
+	if audience == "" {
+		return config, fmt.Errorf("agent.control_api.oidc.audience in %s is required", trackerConfigRelPath)
+	}
+	claim := strings.TrimSpace(model.OIDC.Claim)
+	if claim == "" {
+		claim = controlOIDCDefaultClaim
+	}
+	roles, err := resolveControlRoleMap("agent.control_api.oidc.roles", model.OIDC.Roles)
+	if err != nil {
+		return config, err
+	}
+	config.OIDC = &controlAPIOIDCConfig{Issuer: issuer, Audience: audience, Claim: claim, Roles: roles}
+	return config, nil
+}
+
+// resolveControlRoleMap turns a role -> values mapping into the highest role
+// of each value.
+func resolveControlRoleMap(field string, model map[string][]string) (map[string]controlRole, error) {
+	if len(model) == 0 {
+		return nil, fmt.Errorf("%s in %s must grant at least one role", field, trackerConfigRelPath)
+	}
+	roles := map[string]controlRole{}
+	for rawRole, values := range model {
+		role, err := parseControlRole(rawRole)
+		if err != nil {
+			return nil, fmt.Errorf("%s in %s is invalid: %w", field, trackerConfigRelPath, err)
+		}
+		for _, value := range values {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				return nil, fmt.Errorf("%s.%s in %s must not contain an empty value", field, role, trackerConfigRelPath)
+			}
+			if role > roles[value] {
+				roles[value] = role
+			}
+		}
+	}
+	return roles, nil
+}
+
+func resolveAgentEventLog(model *yoloAgentEventLogModel) (contracts.FileEventSinkOptions, error) {
+	options := contracts.FileEventSinkOptions{}
+	if model == nil {
+		return options, nil
+	}
+	options.Compress = model.Compress
+	if model.MaxSizeMB != nil {
+		if *model.MaxSizeMB < 0 {
+			return options, fmt.Errorf("agent.event_log.max_size_mb in %s must be greater than or equal to 0", trackerConfigRelPath)
+		}
+		options.MaxBytes = int64(*model.MaxSizeMB) << 20
+	}
+	if model.Keep != nil {
+		if *model.Keep < 0 {
+			return options, fmt.Errorf("agent.event_log.keep in %s must be greater than or equal to 0", trackerConfigRelPath)
+		}
+		options.MaxSegments = *model.Keep
+	}
+	for _, field := range []struct {
+		name   string
+		raw    string
+		target *time.Duration
+	}{
+		{name: "event_log.max_age", raw: model.MaxAge, target: &options.MaxAge},
+		{name: "event_log.retain_for", raw: model.RetainFor, target: &options.RetainFor},
+	} {
+		value, err := parseAgentDuration(field.name, field.raw)
+		if err != nil {
+			return options, err
+		}
+		if value == nil {
+			continue
+		}
+		if *value < 0 {
+			return options, fmt.Errorf("agent.%s in %s must be greater than or equal to 0", field.name, trackerConfigRelPath)
+		}
+		*field.target = *value
+	}
+	return options, nil
+}
+
 func catalogBackendDefaultModel(catalog codingagents.Catalog, backend string) string {
 	definition, ok := catalog.Backend(backend)
 	if !ok {
@@ -1,7 +1,13 @@
 package main
 
 import (
+	"github.com/egv/yolo-runner/v2/internal/agent"
 	"github.com/egv/yolo-runner/v2/internal/codingagents"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/prompt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -193,3 +199,905 @@ func TestResolveYoloAgentConfigDefaultsRejectsNonPositiveWatchdogInterval(t *tes
 		t.Fatalf("expected field-specific error, got %q", err.Error())
 	}
 }
+
+func TestResolveYoloAgentConfigDefaultsRejectsNegativeRateLimitBackoff(t *testing.T) {
+	_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		RateLimitBackoff: "-1s",
+	}, testCatalog(t))
+	if err == nil {
+		t.Fatalf("expected negative rate limit backoff to fail")
+	}
+	if !strings.Contains(err.Error(), "agent.rate_limit_backoff") {
+		t.Fatalf("expected field-specific error, got %q", err.Error())
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsNegativeEpicProgressInterval(t *testing.T) {
+	_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		EpicProgress: "-1m",
+	}, testCatalog(t))
+	if err == nil {
+		t.Fatalf("expected negative epic progress interval to fail")
+	}
+	if !strings.Contains(err.Error(), "agent.epic_progress_interval") {
+		t.Fatalf("expected field-specific error, got %q", err.Error())
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsNegativeTaskDiscoveryInterval(t *testing.T) {
+	_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		TaskDiscovery: "-1m",
+	}, testCatalog(t))
+	if err == nil {
+		t.Fatalf("expected negative task discovery interval to fail")
+	}
+	if !strings.Contains(err.Error(), "agent.task_discovery_interval") {
+		t.Fatalf("expected field-specific error, got %q", err.Error())
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsNegativeStatusReconcileInterval(t *testing.T) {
+	_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		StatusReconcile: "-1m",
+	}, testCatalog(t))
+	if err == nil {
+		t.Fatalf("expected negative status reconcile interval to fail")
+	}
+	if !strings.Contains(err.Error(), "agent.status_reconcile_interval") {
+		t.Fatalf("expected field-specific error, got %q", err.Error())
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsNegativeShutdownGracePeriod(t *testing.T) {
+	_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		ShutdownGrace: "-5s",
+	}, testCatalog(t))
+	if err == nil {
+		t.Fatalf("expected negative shutdown grace period to fail")
+	}
+	if !strings.Contains(err.Error(), "agent.shutdown_grace_period") {
+		t.Fatalf("expected field-specific error, got %q", err.Error())
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesEventSinkFilters(t *testing.T) {
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		EventSinks: map[string]yoloAgentEventSinkModel{
+			"File": {Exclude: []string{"runner_heartbeat"}, Sample: map[string]int{"runner_output": 10}},
+		},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	filter, ok := defaults.EventSinks[eventSinkFile]
+	if !ok {
+		t.Fatalf("expected file sink filter, got %#v", defaults.EventSinks)
+	}
+	if len(filter.Exclude) != 1 || filter.Exclude[0] != contracts.EventTypeRunnerHeartbeat || filter.SamplePercent[contracts.EventTypeRunnerOutput] != 10 {
+		t.Fatalf("unexpected file sink filter %#v", filter)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsInvalidEventSinks(t *testing.T) {
+	cases := []map[string]yoloAgentEventSinkModel{
+		{"comments": {Exclude: []string{"runner_output"}}},
+		{"file": {Include: []string{" "}}},
+		{"stream": {Sample: map[string]int{"runner_output": 150}}},
+	}
+	for _, sinks := range cases {
+		_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{EventSinks: sinks}, testCatalog(t))
+		if err == nil || !strings.Contains(err.Error(), "agent.event_sinks") {
+			t.Fatalf("expected agent.event_sinks error for %#v, got %v", sinks, err)
+		}
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsBuildsRedactor(t *testing.T) {
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		Redaction: &yoloAgentRedactionModel{
+			Presets:  []string{"emails"},
+			Patterns: map[string]string{"internal_host": `[a-z0-9-]+\.corp\.example\.com`},
+		},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := defaults.Redactor.Redact("ci@example.com deployed to db-1.corp.example.com")
+	if got != "[redacted:emails] deployed to [redacted:internal_host]" {
+		t.Fatalf("unexpected redaction %q", got)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsInvalidRedaction(t *testing.T) {
+	cases := []*yoloAgentRedactionModel{
+		{Presets: []string{"phone_numbers"}},
+		{Patterns: map[string]string{"broken": "[a-z"}},
+		{Patterns: map[string]string{" ": "secret"}},
+	}
+	for _, redaction := range cases {
+		_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{Redaction: redaction}, testCatalog(t))
+		if err == nil || !strings.Contains(err.Error(), "agent.redaction") {
+			t.Fatalf("expected agent.redaction error for %#v, got %v", redaction, err)
+		}
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesControlAPIRoles(t *testing.T) {
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		ControlAPI: &yoloAgentControlAPIModel{
+			Tokens: []yoloAgentControlAPITokenModel{{Name: "dashboard", Role: "Viewer", TokenEnv: "DASHBOARD_TOKEN"}},
+			OIDC: &yoloAgentControlAPIOIDCModel{
+				Issuer:   "https://accounts.example.com",
+				Audience: "yolo-agent",
+				Roles: map[string][]string{
+					"approver": {"alice@example.com"},
+					"operator": {"alice@example.com", "bob@example.com"},
+				},
+			},
+			Slack: &yoloAgentControlAPISlackModel{Roles: map[string][]string{
+				"viewer":   {"*"},
+				"approver": {"U0ALICE"},
+			}},
+		},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	control := defaults.ControlAPI
+	if len(control.Tokens) != 1 || control.Tokens[0].Role != controlRoleViewer || control.Tokens[0].TokenEnv != "DASHBOARD_TOKEN" {
+		t.Fatalf("unexpected tokens %#v", control.Tokens)
+	}
+	if control.OIDC == nil || control.OIDC.Claim != "email" || control.OIDC.Roles["alice@example.com"] != controlRoleApprover || control.OIDC.Roles["bob@example.com"] != controlRoleOperator {
+		t.Fatalf("unexpected oidc config %#v", control.OIDC)
+	}
+	if len(control.SlackRoles) != 2 || control.SlackRoles["*"] != controlRoleViewer || control.SlackRoles["U0ALICE"] != controlRoleApprover {
+		t.Fatalf("unexpected slack roles %#v", control.SlackRoles)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsInvalidControlAPI(t *testing.T) {
+	cases := []*yoloAgentControlAPIModel{
+		{Tokens: []yoloAgentControlAPITokenModel{{Name: "ci", Role: "admin", TokenEnv: "CI_TOKEN"}}},
+		{Tokens: []yoloAgentControlAPITokenModel{{Role: "viewer", TokenEnv: "CI_TOKEN"}}},
+		{Tokens: []yoloAgentControlAPITokenModel{{Name: "ci", Role: "viewer"}}},
+		{Tokens: []yoloAgentControlAPITokenModel{{Name: "ci", Role: "viewer", TokenEnv: "A"}, {Name: "ci", Role: "operator", TokenEnv: "B"}}},
+		{OIDC: &yoloAgentControlAPIOIDCModel{Issuer: "accounts.example.com", Audience: "yolo-agent", Roles: map[string][]string{"viewer": {"*"}}}},
+		{OIDC: &yoloAgentControlAPIOIDCModel{Issuer: "https://accounts.example.com", Roles: map[string][]string{"viewer": {"*"}}}},
+		{OIDC: &yoloAgentControlAPIOIDCModel{Issuer: "https://accounts.example.com", Audience: "yolo-agent"}},
+		{OIDC: &yoloAgentControlAPIOIDCModel{Issuer: "https://accounts.example.com", Audience: "yolo-agent", Roles: map[string][]string{"owner": {"*"}}}},
+		{Slack: &yoloAgentControlAPISlackModel{Roles: map[string][]string{"admin": {"U0ALICE"}}}},
+		{Slack: &yoloAgentControlAPISlackModel{Roles: map[string][]string{"viewer": {" "}}}},
+	}
+	for _, controlAPI := range cases {
+		_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{ControlAPI: controlAPI}, testCatalog(t))
+		if err == nil || !strings.Contains(err.Error(), "agent.control_api") {
+			t.Fatalf("expected agent.control_api error for %#v, got %v", controlAPI, err)
+		}
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesEventLogRotation(t *testing.T) {
+	maxSize := 64
+	keep := 5
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		EventLog: &yoloAgentEventLogModel{MaxSizeMB: &maxSize, MaxAge: "24h", Compress: true, Keep: &keep, RetainFor: "168h"},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := contracts.FileEventSinkOptions{MaxBytes: 64 << 20, MaxAge: 24 * time.Hour, Compress: true, MaxSegments: 5, RetainFor: 168 * time.Hour}
+	if defaults.EventLog != want {
+		t.Fatalf("expected %#v, got %#v", want, defaults.EventLog)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsInvalidEventLog(t *testing.T) {
+	negative := -1
+	cases := []*yoloAgentEventLogModel{
+		{MaxSizeMB: &negative},
+		{Keep: &negative},
+		{MaxAge: "soon"},
+		{RetainFor: "-1h"},
+	}
+	for _, model := range cases {
+		_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{EventLog: model}, testCatalog(t))
+		if err == nil || !strings.Contains(err.Error(), "agent.event_log") {
+			t.Fatalf("expected agent.event_log error for %#v, got %v", model, err)
+		}
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesTrackerCacheTTL(t *testing.T) {
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		TrackerCacheTTL: "45s",
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if defaults.TrackerCacheTTL == nil || *defaults.TrackerCacheTTL != 45*time.Second {
+		t.Fatalf("expected tracker cache ttl 45s, got %v", defaults.TrackerCacheTTL)
+	}
+
+	_, err = resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		TrackerCacheTTL: "-1s",
+	}, testCatalog(t))
+	if err == nil || !strings.Contains(err.Error(), "agent.tracker_cache_ttl") {
+		t.Fatalf("expected negative tracker cache ttl to fail, got %v", err)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesFallbackChain(t *testing.T) {
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		FallbackChain: []yoloAgentFallbackModel{
+			{Model: "openai/gpt-5.3-codex"},
+			{Backend: "Claude", Model: "claude-sonnet"},
+			{Backend: "kimi", Model: "kimi-k2"},
+		},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("expected fallback chain to resolve, got %v", err)
+	}
+	if got := formatFallbackChain(defaults.FallbackChain); got != "openai/gpt-5.3-codex,claude:claude-sonnet,kimi:kimi-k2" {
+		t.Fatalf("unexpected fallback chain %q", got)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsInvalidFallbackChain(t *testing.T) {
+	for _, chain := range [][]yoloAgentFallbackModel{
+		{{Backend: "not-a-backend", Model: "x"}},
+		{{Model: " "}},
+	} {
+		_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{FallbackChain: chain}, testCatalog(t))
+		if err == nil {
+			t.Fatalf("expected %#v to fail", chain)
+		}
+		if !strings.Contains(err.Error(), "agent.fallback_chain[0]") {
+			t.Fatalf("expected field-specific error, got %q", err.Error())
+		}
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesStallPolicies(t *testing.T) {
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		StallPolicies: map[string]string{"rate_limit": "retry", "Silence": "extend_timeout"},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("expected stall policies to resolve, got %v", err)
+	}
+	if got := formatStallPolicies(defaults.StallPolicies); got != "rate_limit=retry,silence=extend_timeout" {
+		t.Fatalf("unexpected stall policies %q", got)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsInvalidStallPolicies(t *testing.T) {
+	for _, policies := range []map[string]string{
+		{"no_output": "retry"},
+		{"question": "ignore"},
+	} {
+		_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{StallPolicies: policies}, testCatalog(t))
+		if err == nil {
+			t.Fatalf("expected %v to fail", policies)
+		}
+		if !strings.Contains(err.Error(), "agent.stall_policies") {
+			t.Fatalf("expected field-specific error, got %q", err.Error())
+		}
+	}
+}
+
+func TestLoadYoloAgentConfigDefaultsLoadsPromptTemplates(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  prompts:
+    review: house/review.tmpl
+`)
+	for path, content := range map[string]string{
+		filepath.Join(repoRoot, "house", "review.tmpl"):                      "house review {{.Task.ID}}",
+		filepath.Join(repoRoot, prompt.DefaultTemplateDir, "implement.tmpl"): "house implement {{.Task.ID}}",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write template: %v", err)
+		}
+	}
+
+	defaults, err := loadYoloAgentConfigDefaults(repoRoot)
+	if err != nil {
+		t.Fatalf("expected config defaults to load, got %v", err)
+	}
+	if !defaults.PromptTemplates.Has(prompt.TemplateReview) || !defaults.PromptTemplates.Has(prompt.TemplateImplement) {
+		t.Fatalf("expected configured and discovered templates, got %#v", defaults.PromptTemplates)
+	}
+	if defaults.PromptTemplates.Has(prompt.TemplateRemediation) {
+		t.Fatalf("did not expect remediation template")
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesRepoContext(t *testing.T) {
+	recentCommits := 0
+	maxBytes := 2048
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		RepoContext: &yoloAgentRepoContextModel{
+			Enabled:       true,
+			Files:         []string{"docs/house-rules.md"},
+			RecentCommits: &recentCommits,
+			MaxBytes:      &maxBytes,
+		},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("expected repo context to parse, got %v", err)
+	}
+	if defaults.RepoContext == nil {
+		t.Fatalf("expected repo context options")
+	}
+	if got := defaults.RepoContext; len(got.Files) != 1 || got.Files[0] != "docs/house-rules.md" || got.RecentCommits != 0 || got.MaxBytes != 2048 {
+		t.Fatalf("unexpected repo context options %#v", got)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsLeavesRepoContextDisabledByDefault(t *testing.T) {
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{RepoContext: &yoloAgentRepoContextModel{}}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("expected config defaults to parse, got %v", err)
+	}
+	if defaults.RepoContext != nil {
+		t.Fatalf("expected repo context disabled, got %#v", defaults.RepoContext)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsNonPositiveRepoContextBudget(t *testing.T) {
+	maxBytes := 0
+	_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		RepoContext: &yoloAgentRepoContextModel{Enabled: true, MaxBytes: &maxBytes},
+	}, testCatalog(t))
+	if err == nil {
+		t.Fatalf("expected max_bytes validation error")
+	}
+	if !strings.Contains(err.Error(), "agent.repo_context.max_bytes") {
+		t.Fatalf("expected field-specific error, got %q", err.Error())
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesClonePool(t *testing.T) {
+	size := 3
+	maxUses := 10
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		ClonePool: &yoloAgentClonePoolModel{Size: &size, Refresh: "recreate", MaxUses: &maxUses},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("expected clone pool to parse, got %v", err)
+	}
+	if got := defaults.ClonePool; got.Size != 3 || got.Refresh != agent.ClonePoolRefreshRecreate || got.MaxUses != 10 {
+		t.Fatalf("unexpected clone pool options %#v", got)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsUnknownClonePoolRefresh(t *testing.T) {
+	_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		ClonePool: &yoloAgentClonePoolModel{Refresh: "rsync"},
+	}, testCatalog(t))
+	if err == nil || !strings.Contains(err.Error(), "agent.clone_pool.refresh") {
+		t.Fatalf("expected field-specific refresh error, got %v", err)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesRetention(t *testing.T) {
+	logsMB := 512
+	clonesMB := 10240
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		Retention: &yoloAgentRetentionModel{
+			RunnerLogs: &yoloAgentRetentionPolicyModel{MaxSizeMB: &logsMB, MaxAge: "720h"},
+			Clones:     &yoloAgentRetentionPolicyModel{MaxSizeMB: &clonesMB},
+			MinAge:     "30m",
+		},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("expected retention to parse, got %v", err)
+	}
+	got := defaults.Retention
+	if got.RunnerLogs.MaxBytes != 512<<20 || got.RunnerLogs.MaxAge != 720*time.Hour || got.Clones.MaxBytes != 10240<<20 || got.Artifacts.MaxBytes != 0 || got.MinAge != 30*time.Minute {
+		t.Fatalf("unexpected retention config %#v", got)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsNegativeRetentionCap(t *testing.T) {
+	size := -1
+	_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		Retention: &yoloAgentRetentionModel{Artifacts: &yoloAgentRetentionPolicyModel{MaxSizeMB: &size}},
+	}, testCatalog(t))
+	if err == nil || !strings.Contains(err.Error(), "agent.retention.artifacts.max_size_mb") {
+		t.Fatalf("expected field-specific retention error, got %v", err)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesEscalation(t *testing.T) {
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		Escalation: &yoloAgentEscalationModel{After: "30m", Assignee: " alice ", WebhookURL: "https://hooks.example.com/T1"},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("expected escalation to parse, got %v", err)
+	}
+	got := defaults.Escalation
+	if got.After != 30*time.Minute || got.Assignee != "alice" || got.LabelOrDefault() != "needs-human" || got.WebhookURL != "https://hooks.example.com/T1" {
+		t.Fatalf("unexpected escalation policy %#v", got)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsInvalidEscalation(t *testing.T) {
+	for field, model := range map[string]*yoloAgentEscalationModel{
+		"agent.escalation.after":       {After: "-1m"},
+		"agent.escalation.webhook_url": {After: "30m", WebhookURL: "hooks.example.com"},
+	} {
+		_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{Escalation: model}, testCatalog(t))
+		if err == nil || !strings.Contains(err.Error(), field) {
+			t.Fatalf("expected %s error, got %v", field, err)
+		}
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesBlockedRetry(t *testing.T) {
+	attempts := 5
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		BlockedRetry: map[string]yoloAgentBlockedRetryModel{
+			"Lock_Contention":      {MaxAttempts: &attempts, Backoff: "30s", MaxBackoff: "10m"},
+			"merge_queue_conflict": {},
+		},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("expected blocked retry to parse, got %v", err)
+	}
+	got := defaults.BlockedRetry
+	if got["lock_contention"] != (agent.BlockedRetryPolicy{MaxAttempts: 5, Backoff: 30 * time.Second, MaxBackoff: 10 * time.Minute}) || got["merge_queue_conflict"].MaxAttempts != defaultBlockedRetryAttempts {
+		t.Fatalf("unexpected blocked retry policies %#v", got)
+	}
+	if formatted := formatBlockedRetryPolicies(got); formatted != "lock_contention=5/30s,merge_queue_conflict=3" {
+		t.Fatalf("unexpected run metadata %q", formatted)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsInvalidBlockedRetry(t *testing.T) {
+	negative := -1
+	for field, model := range map[string]map[string]yoloAgentBlockedRetryModel{
+		"agent.blocked_retry.flaky_network":           {"flaky_network": {}},
+		"agent.blocked_retry.rate_limit.max_attempts": {"rate_limit": {MaxAttempts: &negative}},
+		"agent.blocked_retry.lock_contention.backoff": {"lock_contention": {Backoff: "-1m"}},
+		"agent.blocked_retry.silence.max_backoff":     {"silence": {MaxBackoff: "soon"}},
+	} {
+		_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{BlockedRetry: model}, testCatalog(t))
+		if err == nil || !strings.Contains(err.Error(), field) {
+			t.Fatalf("expected %s error, got %v", field, err)
+		}
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesBackendCapabilities(t *testing.T) {
+	disabled := false
+	enabled := true
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		BackendCapabilities: map[string]yoloAgentBackendCapabilitiesModel{
+			"Kimi": {Stream: &disabled, SessionResume: &enabled},
+		},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("expected backend capabilities to resolve, got %v", err)
+	}
+	override, ok := defaults.BackendCapabilities[backendKimi]
+	if !ok {
+		t.Fatalf("expected override keyed by normalized backend, got %#v", defaults.BackendCapabilities)
+	}
+	if override.SupportsReview != nil || override.SupportsStream == nil || *override.SupportsStream || override.SupportsResume == nil || !*override.SupportsResume {
+		t.Fatalf("unexpected override %#v", override)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsUnknownCapabilityBackend(t *testing.T) {
+	_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		BackendCapabilities: map[string]yoloAgentBackendCapabilitiesModel{"not-a-backend": {}},
+	}, testCatalog(t))
+	if err == nil {
+		t.Fatalf("expected unknown backend to fail")
+	}
+	if !strings.Contains(err.Error(), "agent.backend_capabilities.not-a-backend") {
+		t.Fatalf("expected field-specific error, got %q", err.Error())
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsInvalidCommitMessageTemplate(t *testing.T) {
+	_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		CommitMessages: yoloAgentCommitMessagesModel{Merge: "{{.Task.Assignee}}"},
+	}, testCatalog(t))
+	if err == nil || !strings.Contains(err.Error(), "agent.commit_messages") {
+		t.Fatalf("expected agent.commit_messages error, got %v", err)
+	}
+	if diagnostic := classifyConfigValidationError(err); diagnostic.Field != "agent.commit_messages" {
+		t.Fatalf("expected commit_messages diagnostic, got %#v", diagnostic)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsUnknownLandingStrategy(t *testing.T) {
+	_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		Landing: yoloAgentLandingModel{Strategy: "octopus"},
+	}, testCatalog(t))
+	if err == nil || !strings.Contains(err.Error(), "rebase_ff") {
+		t.Fatalf("expected agent.landing.strategy error listing supported strategies, got %v", err)
+	}
+	if diagnostic := classifyConfigValidationError(err); diagnostic.Field != "agent.landing.strategy" {
+		t.Fatalf("expected landing strategy diagnostic, got %#v", diagnostic)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesVCSMode(t *testing.T) {
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{VCS: " None "}, testCatalog(t))
+	if err != nil || !defaults.NoVCS {
+		t.Fatalf("expected agent.vcs none to disable VCS, got %#v err=%v", defaults.NoVCS, err)
+	}
+	_, err = resolveYoloAgentConfigDefaults(yoloAgentConfigModel{VCS: "svn"}, testCatalog(t))
+	if err == nil {
+		t.Fatalf("expected unsupported agent.vcs to be rejected")
+	}
+	if diagnostic := classifyConfigValidationError(err); diagnostic.Field != "agent.vcs" {
+		t.Fatalf("expected agent.vcs diagnostic, got %#v", diagnostic)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsLoadsPathScope(t *testing.T) {
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		PathScope: yoloAgentPathScopeModel{Labels: map[string][]string{" Web ": {" web/** ", "packages/ui/**"}}, CodeOwners: true},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !defaults.PathScope.CodeOwners || !reflect.DeepEqual(defaults.PathScope.Labels, map[string][]string{"web": {"web/**", "packages/ui/**"}}) {
+		t.Fatalf("unexpected path scope %#v", defaults.PathScope)
+	}
+
+	_, err = resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		PathScope: yoloAgentPathScopeModel{Labels: map[string][]string{"api": {}}},
+	}, testCatalog(t))
+	if err == nil {
+		t.Fatalf("expected a label without globs to be rejected")
+	}
+	if diagnostic := classifyConfigValidationError(err); diagnostic.Field != "agent.path_scope" {
+		t.Fatalf("expected agent.path_scope diagnostic, got %#v", diagnostic)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsLoadsDiffGuardrails(t *testing.T) {
+	maxFiles, maxLines, zero := 20, 800, 0
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		DiffGuardrails: yoloAgentDiffGuardrailsModel{
+			MaxChangedFiles:  &maxFiles,
+			MaxChangedLines:  &maxLines,
+			ForbiddenPaths:   []string{" .github/workflows/** ", "**/*.pem"},
+			BlockBinaryFiles: true,
+			OnViolation:      "approve",
+		},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := agent.DiffGuardrailConfig{
+		MaxChangedFiles:  20,
+		MaxChangedLines:  800,
+		ForbiddenPaths:   []string{".github/workflows/**", "**/*.pem"},
+		BlockBinaryFiles: true,
+		Action:           agent.DiffGuardrailApprove,
+	}
+	if !reflect.DeepEqual(defaults.DiffGuardrails, want) {
+		t.Fatalf("unexpected diff guardrails %#v", defaults.DiffGuardrails)
+	}
+
+	for field, model := range map[string]yoloAgentDiffGuardrailsModel{
+		"agent.diff_guardrails.max_changed_lines": {MaxChangedLines: &zero},
+		"agent.diff_guardrails.forbidden_paths":   {ForbiddenPaths: []string{" "}},
+		"agent.diff_guardrails.on_violation":      {OnViolation: "warn"},
+	} {
+		_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{DiffGuardrails: model}, testCatalog(t))
+		if err == nil {
+			t.Fatalf("expected %s to be rejected", field)
+		}
+		if diagnostic := classifyConfigValidationError(err); diagnostic.Field != field {
+			t.Fatalf("expected %s diagnostic, got %#v", field, diagnostic)
+		}
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsLoadsSecretScan(t *testing.T) {
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		SecretScan: yoloAgentSecretScanModel{Enabled: true, Command: " gitleaks protect --no-banner ", Timeout: "2m"},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := agent.SecretScanConfig{Enabled: true, Command: "gitleaks protect --no-banner", Timeout: 2 * time.Minute}
+	if defaults.SecretScan != want {
+		t.Fatalf("unexpected secret scan %#v", defaults.SecretScan)
+	}
+
+	_, err = resolveYoloAgentConfigDefaults(yoloAgentConfigModel{SecretScan: yoloAgentSecretScanModel{Enabled: true, Timeout: "0s"}}, testCatalog(t))
+	if err == nil {
+		t.Fatalf("expected a zero timeout to be rejected")
+	}
+	if diagnostic := classifyConfigValidationError(err); diagnostic.Field != "agent.secret_scan.timeout" {
+		t.Fatalf("expected agent.secret_scan.timeout diagnostic, got %#v", diagnostic)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsLoadsDependencyPolicy(t *testing.T) {
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		DependencyPolicy: yoloAgentDependencyPolicyModel{
+			Allow:          []string{" github.com/acme/** "},
+			Deny:           []string{"left-pad"},
+			Licenses:       yoloAgentDependencyLicensesModel{Allow: []string{"MIT", "Apache-2.0"}, Deny: []string{"GPL-3.0"}},
+			LicenseCommand: " go-licenses report ./... ",
+			Timeout:        "5m",
+		},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := agent.DependencyPolicyConfig{
+		Allow:          []string{"github.com/acme/**"},
+		Deny:           []string{"left-pad"},
+		AllowLicenses:  []string{"MIT", "Apache-2.0"},
+		DenyLicenses:   []string{"GPL-3.0"},
+		LicenseCommand: "go-licenses report ./...",
+		Timeout:        5 * time.Minute,
+	}
+	if !reflect.DeepEqual(defaults.DependencyPolicy, want) {
+		t.Fatalf("unexpected dependency policy %#v", defaults.DependencyPolicy)
+	}
+
+	for field, model := range map[string]yoloAgentDependencyPolicyModel{
+		"agent.dependency_policy.license_command": {Licenses: yoloAgentDependencyLicensesModel{Deny: []string{"GPL-3.0"}}},
+		"agent.dependency_policy.timeout":         {Deny: []string{"left-pad"}, Timeout: "-1m"},
+		"agent.dependency_policy":                 {Allow: []string{""}},
+	} {
+		_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{DependencyPolicy: model}, testCatalog(t))
+		if err == nil {
+			t.Fatalf("expected %s to be rejected", field)
+		}
+		if diagnostic := classifyConfigValidationError(err); diagnostic.Field != field {
+			t.Fatalf("expected %s diagnostic, got %#v", field, diagnostic)
+		}
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsLoadsPreLandingHooks(t *testing.T) {
+	batchSize, zero, negative := 3, 0, -1
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		Landing: yoloAgentLandingModel{PreMerge: []string{" make generate && git diff --exit-code "}, HookTimeout: "5m", BatchSize: &batchSize, PushRetries: &zero},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("resolve defaults: %v", err)
+	}
+	if len(defaults.PreLandingHooks) != 1 || defaults.PreLandingHooks[0] != "make generate && git diff --exit-code" {
+		t.Fatalf("unexpected hooks %#v", defaults.PreLandingHooks)
+	}
+	if defaults.LandingHookTimeout == nil || *defaults.LandingHookTimeout != 5*time.Minute {
+		t.Fatalf("unexpected hook timeout %v", defaults.LandingHookTimeout)
+	}
+	if defaults.MergeQueueBatchSize != 3 {
+		t.Fatalf("unexpected merge queue batch size %d", defaults.MergeQueueBatchSize)
+	}
+	if defaults.PushRetries == nil || *defaults.PushRetries != 0 {
+		t.Fatalf("expected push retries to be disabled, got %v", defaults.PushRetries)
+	}
+
+	for field, landing := range map[string]yoloAgentLandingModel{
+		"agent.landing.pre_merge":    {PreMerge: []string{"make lint", " "}},
+		"agent.landing.hook_timeout": {HookTimeout: "0s"},
+		"agent.landing.batch_size":   {BatchSize: &zero},
+		"agent.landing.push_retries": {PushRetries: &negative},
+	} {
+		_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{Landing: landing}, testCatalog(t))
+		if err == nil {
+			t.Fatalf("expected %s to be rejected", field)
+		}
+		if diagnostic := classifyConfigValidationError(err); diagnostic.Field != field {
+			t.Fatalf("expected %s diagnostic, got %#v", field, diagnostic)
+		}
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsLoadsWatchdogHooks(t *testing.T) {
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		WatchdogHooks:       []string{" kill -QUIT $YOLO_WATCHDOG_PID "},
+		WatchdogHookTimeout: "30s",
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("resolve defaults: %v", err)
+	}
+	if len(defaults.WatchdogHooks) != 1 || defaults.WatchdogHooks[0] != "kill -QUIT $YOLO_WATCHDOG_PID" {
+		t.Fatalf("unexpected watchdog hooks %#v", defaults.WatchdogHooks)
+	}
+	if defaults.WatchdogHookTimeout == nil || *defaults.WatchdogHookTimeout != 30*time.Second {
+		t.Fatalf("unexpected watchdog hook timeout %v", defaults.WatchdogHookTimeout)
+	}
+
+	for field, model := range map[string]yoloAgentConfigModel{
+		"agent.watchdog_hooks":        {WatchdogHooks: []string{"true", " "}},
+		"agent.watchdog_hook_timeout": {WatchdogHookTimeout: "0s"},
+	} {
+		_, err := resolveYoloAgentConfigDefaults(model, testCatalog(t))
+		if err == nil {
+			t.Fatalf("expected %s to be rejected", field)
+		}
+		if diagnostic := classifyConfigValidationError(err); diagnostic.Field != field {
+			t.Fatalf("expected %s diagnostic, got %#v", field, diagnostic)
+		}
+	}
+}
+
+func TestResolveCommitMessagesDefaultsTrackerURLForGitHub(t *testing.T) {
+	github := resolvedTrackerProfile{Tracker: trackerModel{
+		Type:   trackerTypeGitHub,
+		GitHub: &githubTrackerModel{Scope: githubScopeModel{Owner: "acme", Repo: "app"}},
+	}}
+	messages, err := resolveCommitMessages(agent.CommitMessageConfig{AutoCommit: "#{{.Task.ID}} {{.Task.Title}}\n\n{{.TrackerURL}}"}, github)
+	if err != nil {
+		t.Fatalf("resolve commit messages: %v", err)
+	}
+	message, err := messages.AutoCommit(contracts.Task{ID: "42", Title: "Fix login"}, "task/42", "", "", "")
+	if err != nil || message != "#42 Fix login\n\nhttps://github.com/acme/app/issues/42" {
+		t.Fatalf("unexpected message %q err=%v", message, err)
+	}
+
+	if messages, err := resolveCommitMessages(agent.CommitMessageConfig{}, github); err != nil || messages != nil {
+		t.Fatalf("expected built-in messages without templates, got %#v err=%v", messages, err)
+	}
+	tk := resolvedTrackerProfile{Tracker: trackerModel{Type: trackerTypeTK}}
+	messages, err = resolveCommitMessages(agent.CommitMessageConfig{AutoCommit: "{{.Task.ID}} {{.TrackerURL}}"}, tk)
+	if err != nil {
+		t.Fatalf("resolve commit messages: %v", err)
+	}
+	if message, _ := messages.AutoCommit(contracts.Task{ID: "t-1"}, "task/t-1", "", "", ""); message != "t-1" {
+		t.Fatalf("expected empty tracker URL without a default, got %q", message)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesKubernetesExecutor(t *testing.T) {
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		Executor: &yoloAgentExecutorModel{
+			Type: "Kubernetes",
+			Kubernetes: &yoloAgentKubernetesModel{
+				Namespace:      "yolo",
+				Image:          "ghcr.io/acme/worker:1",
+				EnvFromSecrets: []string{"agent-keys", " "},
+				Resources:      yoloAgentKubernetesResourcesModel{Limits: map[string]string{"memory": "4Gi"}},
+				Workspace:      yoloAgentKubernetesWorkspaceModel{Claim: "yolo-clones", LocalPath: "/mnt/clones"},
+				StartTimeout:   "10m",
+			},
+		},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config := defaults.Executor.Kubernetes
+	if config == nil || config.Namespace != "yolo" || config.Image != "ghcr.io/acme/worker:1" || config.Workspace.Claim != "yolo-clones" || config.Workspace.LocalPath != "/mnt/clones" {
+		t.Fatalf("unexpected kubernetes config %#v", config)
+	}
+	if config.StartTimeout != 10*time.Minute || len(config.EnvFromSecrets) != 1 || config.Limits["memory"] != "4Gi" {
+		t.Fatalf("unexpected kubernetes config %#v", config)
+	}
+
+	local, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{Executor: &yoloAgentExecutorModel{Type: "local"}}, testCatalog(t))
+	if err != nil || local.Executor.enabled() {
+		t.Fatalf("expected the local executor, got %#v err=%v", local.Executor, err)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesSSHExecutor(t *testing.T) {
+	port := 2222
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		Executor: &yoloAgentExecutorModel{
+			Type: "ssh",
+			SSH: &yoloAgentSSHModel{
+				Host:         "builder@build-1.example.com",
+				Port:         &port,
+				IdentityFile: "~/.ssh/build",
+				Options:      []string{"StrictHostKeyChecking=yes", " "},
+				RemoteDir:    "/srv/yolo",
+			},
+		},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config := defaults.Executor.SSH
+	if config == nil || config.Host != "builder@build-1.example.com" || config.Port != 2222 || config.RemoteDir != "/srv/yolo" || len(config.Options) != 1 {
+		t.Fatalf("unexpected ssh config %#v", config)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsInvalidExecutor(t *testing.T) {
+	valid := func() *yoloAgentKubernetesModel {
+		return &yoloAgentKubernetesModel{Image: "worker", Workspace: yoloAgentKubernetesWorkspaceModel{Claim: "clones"}}
+	}
+	withImagePolicy := valid()
+	withImagePolicy.ImagePullPolicy = "sometimes"
+	withoutImage := valid()
+	withoutImage.Image = ""
+	withoutClaim := valid()
+	withoutClaim.Workspace.Claim = ""
+	withRelativeMount := valid()
+	withRelativeMount.Workspace.MountPath = "workspace"
+	withBadTimeout := valid()
+	withBadTimeout.StartTimeout = "0s"
+	badPort := 0
+	cases := []*yoloAgentExecutorModel{
+		{Type: "docker"},
+		{Type: "ssh"},
+		{Type: "ssh", SSH: &yoloAgentSSHModel{RemoteDir: "/srv/yolo"}},
+		{Type: "ssh", SSH: &yoloAgentSSHModel{Host: "build-1", RemoteDir: "yolo"}},
+		{Type: "ssh", SSH: &yoloAgentSSHModel{Host: "build-1", RemoteDir: "/srv/yolo", Port: &badPort}},
+		{Type: "kubernetes"},
+		{Type: "kubernetes", Kubernetes: withImagePolicy},
+		{Type: "kubernetes", Kubernetes: withoutImage},
+		{Type: "kubernetes", Kubernetes: withoutClaim},
+		{Type: "kubernetes", Kubernetes: withRelativeMount},
+		{Type: "kubernetes", Kubernetes: withBadTimeout},
+	}
+	for _, executor := range cases {
+		_, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{Executor: executor}, testCatalog(t))
+		if err == nil || !strings.Contains(err.Error(), "agent.executor") {
+			t.Fatalf("expected agent.executor error for %#v, got %v", executor, err)
+		}
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsParsesAffinity(t *testing.T) {
+	one := 1
+	defaults, err := resolveYoloAgentConfigDefaults(yoloAgentConfigModel{
+		Executors: map[string]yoloAgentNamedExecutorModel{
+			"gpu": {Type: "ssh", SSH: &yoloAgentSSHModel{Host: "gpu-1", RemoteDir: "/srv/yolo"}, MaxTasks: &one},
+			"mac": {Type: "ssh", SSH: &yoloAgentSSHModel{Host: "mac-1", RemoteDir: "/Users/ci/yolo"}},
+		},
+		Affinity: []yoloAgentAffinityModel{
+			{Labels: []string{" gpu ", ""}, Executor: "gpu"},
+			{Labels: []string{"ios", "macos"}, Executor: " mac "},
+		},
+	}, testCatalog(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if defaults.Executors["gpu"].SSH == nil || defaults.Executors["gpu"].SSH.Host != "gpu-1" || defaults.Executors["mac"].SSH == nil {
+		t.Fatalf("unexpected executors %#v", defaults.Executors)
+	}
+	want := agent.WorkerAffinityConfig{
+		Rules: []agent.WorkerAffinityRule{
+			{Labels: []string{"gpu"}, Executor: "gpu"},
+			{Labels: []string{"ios", "macos"}, Executor: "mac"},
+		},
+		Slots: map[string]int{"gpu": 1},
+	}
+	if !reflect.DeepEqual(defaults.WorkerAffinity, want) {
+		t.Fatalf("expected affinity %#v, got %#v", want, defaults.WorkerAffinity)
+	}
+}
+
+func TestResolveYoloAgentConfigDefaultsRejectsInvalidAffinity(t *testing.T) {
+	zero := 0
+	gpu := map[string]yoloAgentNamedExecutorModel{"gpu": {Type: "ssh", SSH: &yoloAgentSSHModel{Host: "gpu-1", RemoteDir: "/srv/yolo"}}}
+	cases := []struct {
+		model yoloAgentConfigModel
+		field string
+	}{
+		{yoloAgentConfigModel{Executors: map[string]yoloAgentNamedExecutorModel{"gpu": {Type: "ssh"}}}, "agent.executors.gpu.ssh"},
+		{yoloAgentConfigModel{Executors: map[string]yoloAgentNamedExecutorModel{"gpu": {MaxTasks: &zero}}}, "agent.executors.gpu.max_tasks"},
+		{yoloAgentConfigModel{Executors: gpu, Affinity: []yoloAgentAffinityModel{{Labels: []string{"gpu"}, Executor: "tpu"}}}, "agent.affinity[0].executor"},
+		{yoloAgentConfigModel{Executors: gpu, Affinity: []yoloAgentAffinityModel{{Labels: []string{" "}, Executor: "gpu"}}}, "agent.affinity[0].labels"},
+	}
+	for _, tc := range cases {
+		_, err := resolveYoloAgentConfigDefaults(tc.model, testCatalog(t))
+		if err == nil || !strings.Contains(err.Error(), tc.field) {
+			t.Fatalf("expected %s error, got %v", tc.field, err)
+		}
+	}
+}
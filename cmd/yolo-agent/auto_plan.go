@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// confirmAutoPlan asks the operator to approve the proposed subtasks before
+// they are created in the tracker.
+var confirmAutoPlan = func(out io.Writer) (bool, error) {
+	fmt.Fprint(out, "Create these tasks? [y/N]: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
+
+// runAutoPlan decomposes an epic without child tasks into subtasks proposed by
+// the agent backend. It is a no-op unless --auto-plan is set.
+func runAutoPlan(ctx context.Context, cfg runConfig, storage contracts.StorageBackend, runner contracts.AgentRunner, out io.Writer) error {
+	if !cfg.autoPlan {
+		return nil
+	}
+	planner := agent.NewAutoPlanner(storage, runner, agent.AutoPlanOptions{
+		RepoRoot: cfg.repoRoot,
+		Model:    cfg.model,
+		Timeout:  cfg.runnerTimeout,
+	})
+	needsPlan, err := planner.NeedsPlan(ctx, cfg.rootID)
+	if err != nil {
+		return fmt.Errorf("auto-plan: %w", err)
+	}
+	if !needsPlan {
+		return nil
+	}
+	proposal, err := planner.Propose(ctx, cfg.rootID)
+	if err != nil {
+		return fmt.Errorf("auto-plan: %w", err)
+	}
+	if err := agent.WriteAutoPlanProposal(out, proposal); err != nil {
+		return err
+	}
+	if cfg.dryRun {
+		fmt.Fprintln(out, "Dry run: planned tasks were not created")
+		return nil
+	}
+	if !cfg.autoPlanYes {
+		confirmed, err := confirmAutoPlan(out)
+		if err != nil {
+			return fmt.Errorf("auto-plan confirmation: %w", err)
+		}
+		if !confirmed {
+			return fmt.Errorf("auto-plan for %s was not confirmed; rerun with --auto-plan-yes to skip confirmation", cfg.rootID)
+		}
+	}
+	created, err := planner.Apply(ctx, proposal)
+	if err != nil {
+		return fmt.Errorf("auto-plan: %w", err)
+	}
+	fmt.Fprintf(out, "Created %d task(s) under %s\n", len(created), cfg.rootID)
+	return nil
+}
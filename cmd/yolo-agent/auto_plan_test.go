@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+type autoPlanTestStorage struct {
+	tasks   map[string]contracts.Task
+	created []contracts.TaskCreateRequest
+}
+
+func (s *autoPlanTestStorage) GetTaskTree(_ context.Context, rootID string) (*contracts.TaskTree, error) {
+	return &contracts.TaskTree{Root: s.tasks[rootID], Tasks: s.tasks}, nil
+}
+
+func (s *autoPlanTestStorage) GetTask(_ context.Context, taskID string) (*contracts.Task, error) {
+	task := s.tasks[taskID]
+	return &task, nil
+}
+
+func (s *autoPlanTestStorage) SetTaskStatus(context.Context, string, contracts.TaskStatus) error {
+	return nil
+}
+
+func (s *autoPlanTestStorage) SetTaskData(context.Context, string, map[string]string) error {
+	return nil
+}
+
+func (s *autoPlanTestStorage) CreateTask(_ context.Context, request contracts.TaskCreateRequest) (string, error) {
+	s.created = append(s.created, request)
+	return request.Title, nil
+}
+
+type autoPlanTestRunner struct{}
+
+func (autoPlanTestRunner) Run(_ context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	plan := `{"tasks":[{"key":"a","title":"First"},{"key":"b","title":"Second","depends_on":["a"]}]}`
+	path := filepath.Join(request.RepoRoot, agent.DefaultAutoPlanDir, request.TaskID+".json")
+	if err := os.WriteFile(path, []byte(plan), 0o644); err != nil {
+		return contracts.RunnerResult{}, err
+	}
+	return contracts.RunnerResult{Status: contracts.RunnerResultCompleted}, nil
+}
+
+func newAutoPlanTestStorage() *autoPlanTestStorage {
+	return &autoPlanTestStorage{tasks: map[string]contracts.Task{
+		"root": {ID: "root", Title: "Epic", Status: contracts.TaskStatusOpen},
+	}}
+}
+
+func TestRunMainParsesAutoPlanFlags(t *testing.T) {
+	var got runConfig
+	code := RunMain([]string{"--repo", t.TempDir(), "--root", "root", "--auto-plan", "--auto-plan-yes"}, func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if !got.autoPlan || !got.autoPlanYes {
+		t.Fatalf("expected auto-plan flags to be set, got %#v", got)
+	}
+}
+
+func TestRunAutoPlanCreatesTasksAfterConfirmation(t *testing.T) {
+	originalConfirm := confirmAutoPlan
+	t.Cleanup(func() { confirmAutoPlan = originalConfirm })
+	asked := false
+	confirmAutoPlan = func(io.Writer) (bool, error) {
+		asked = true
+		return true, nil
+	}
+
+	storage := newAutoPlanTestStorage()
+	var out bytes.Buffer
+	cfg := runConfig{repoRoot: t.TempDir(), rootID: "root", autoPlan: true}
+	if err := runAutoPlan(context.Background(), cfg, storage, autoPlanTestRunner{}, &out); err != nil {
+		t.Fatalf("runAutoPlan failed: %v", err)
+	}
+	if !asked {
+		t.Fatalf("expected confirmation prompt")
+	}
+	if len(storage.created) != 2 || storage.created[1].DependsOn[0] != "First" {
+		t.Fatalf("unexpected created tasks %#v", storage.created)
+	}
+	if !strings.Contains(out.String(), "Created 2 task(s) under root") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
+
+func TestRunAutoPlanStopsWhenNotConfirmed(t *testing.T) {
+	originalConfirm := confirmAutoPlan
+	t.Cleanup(func() { confirmAutoPlan = originalConfirm })
+	confirmAutoPlan = func(io.Writer) (bool, error) { return false, nil }
+
+	storage := newAutoPlanTestStorage()
+	cfg := runConfig{repoRoot: t.TempDir(), rootID: "root", autoPlan: true}
+	err := runAutoPlan(context.Background(), cfg, storage, autoPlanTestRunner{}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "not confirmed") {
+		t.Fatalf("expected not confirmed error, got %v", err)
+	}
+	if len(storage.created) != 0 {
+		t.Fatalf("expected no tasks to be created, got %#v", storage.created)
+	}
+}
+
+func TestRunAutoPlanSkipsConfirmationWithYesAndSkipsCreationInDryRun(t *testing.T) {
+	originalConfirm := confirmAutoPlan
+	t.Cleanup(func() { confirmAutoPlan = originalConfirm })
+	confirmAutoPlan = func(io.Writer) (bool, error) {
+		t.Fatalf("confirmation should be skipped")
+		return false, nil
+	}
+
+	storage := newAutoPlanTestStorage()
+	cfg := runConfig{repoRoot: t.TempDir(), rootID: "root", autoPlan: true, autoPlanYes: true}
+	if err := runAutoPlan(context.Background(), cfg, storage, autoPlanTestRunner{}, io.Discard); err != nil {
+		t.Fatalf("runAutoPlan failed: %v", err)
+	}
+	if len(storage.created) != 2 {
+		t.Fatalf("expected 2 created tasks, got %#v", storage.created)
+	}
+
+	dryRunStorage := newAutoPlanTestStorage()
+	cfg.dryRun = true
+	if err := runAutoPlan(context.Background(), cfg, dryRunStorage, autoPlanTestRunner{}, io.Discard); err != nil {
+		t.Fatalf("runAutoPlan dry run failed: %v", err)
+	}
+	if len(dryRunStorage.created) != 0 {
+		t.Fatalf("expected dry run to skip creation, got %#v", dryRunStorage.created)
+	}
+}
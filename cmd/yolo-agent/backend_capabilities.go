@@ -9,6 +9,17 @@ import (
 type backendCapabilities struct {
 	SupportsReview bool
 	SupportsStream bool
+	SupportsResume bool
+	SupportsACP    bool
+}
+
+// backendCapabilityOverride is a partial backendCapabilities from
+// agent.backend_capabilities; nil fields keep the built-in value.
+type backendCapabilityOverride struct {
+	SupportsReview *bool
+	SupportsStream *bool
+	SupportsResume *bool
+	SupportsACP    *bool
 }
 
 type backendSelectionOptions struct {
@@ -27,18 +38,22 @@ func defaultBackendCapabilityMatrix() map[string]backendCapabilities {
 		backendOpenCode: {
 			SupportsReview: true,
 			SupportsStream: true,
+			SupportsACP:    true,
 		},
 		backendCodex: {
 			SupportsReview: true,
 			SupportsStream: true,
+			SupportsResume: true,
 		},
 		backendCodexCLI: {
 			SupportsReview: true,
 			SupportsStream: true,
+			SupportsResume: true,
 		},
 		backendClaude: {
 			SupportsReview: true,
 			SupportsStream: true,
+			SupportsResume: true,
 		},
 		backendKimi: {
 			SupportsReview: true,
@@ -51,6 +66,53 @@ func defaultBackendCapabilityMatrix() map[string]backendCapabilities {
 	}
 }
 
+// applyBackendCapabilityOverrides returns a copy of matrix with the
+// configured overrides applied. A backend missing from matrix starts from no
+// capabilities.
+func applyBackendCapabilityOverrides(matrix map[string]backendCapabilities, overrides map[string]backendCapabilityOverride) map[string]backendCapabilities {
+	merged := make(map[string]backendCapabilities, len(matrix)+len(overrides))
+	for name, caps := range matrix {
+		merged[name] = caps
+	}
+	for name, override := range overrides {
+		caps := merged[name]
+		if override.SupportsReview != nil {
+			caps.SupportsReview = *override.SupportsReview
+		}
+		if override.SupportsStream != nil {
+			caps.SupportsStream = *override.SupportsStream
+		}
+		if override.SupportsResume != nil {
+			caps.SupportsResume = *override.SupportsResume
+		}
+		if override.SupportsACP != nil {
+			caps.SupportsACP = *override.SupportsACP
+		}
+		merged[name] = caps
+	}
+	return merged
+}
+
+// formatBackendCapabilities renders the capabilities as a comma-separated
+// list for run metadata.
+func formatBackendCapabilities(caps backendCapabilities) string {
+	names := []string{}
+	for _, capability := range []struct {
+		name      string
+		supported bool
+	}{
+		{"review", caps.SupportsReview},
+		{"stream", caps.SupportsStream},
+		{"session_resume", caps.SupportsResume},
+		{"acp", caps.SupportsACP},
+	} {
+		if capability.supported {
+			names = append(names, capability.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
 func selectBackend(raw string, options backendSelectionOptions, matrix map[string]backendCapabilities) (string, backendCapabilities, error) {
 	name := normalizeBackend(raw)
 	caps, ok := matrix[name]
@@ -58,7 +120,7 @@ func selectBackend(raw string, options backendSelectionOptions, matrix map[strin
 		return "", backendCapabilities{}, fmt.Errorf("unsupported backend %q (supported: %s)", name, strings.Join(supportedBackends(matrix), ", "))
 	}
 	if options.RequireReview && !caps.SupportsReview {
-		return "", backendCapabilities{}, fmt.Errorf("backend %q does not support review mode", name)
+		return "", backendCapabilities{}, fmt.Errorf("backend %q does not support review mode; use --skip-review or agent.skip_review to run it without review", name)
 	}
 	if options.Stream && !caps.SupportsStream {
 		return "", backendCapabilities{}, fmt.Errorf("backend %q does not support stream mode", name)
@@ -101,3 +101,33 @@ func TestResolveBackendSelectionPolicyDefaultsToOpencode(t *testing.T) {
 		t.Fatalf("expected fallback backend %q, got %q", backendOpenCode, got)
 	}
 }
+
+func TestApplyBackendCapabilityOverridesMergesConfiguredFields(t *testing.T) {
+	disabled := false
+	enabled := true
+	base := defaultBackendCapabilityMatrix()
+	matrix := applyBackendCapabilityOverrides(base, map[string]backendCapabilityOverride{
+		backendCodex: {SupportsStream: &disabled},
+		backendKimi:  {SupportsResume: &enabled, SupportsACP: &enabled},
+	})
+
+	if got := matrix[backendCodex]; got.SupportsStream || !got.SupportsReview || !got.SupportsResume {
+		t.Fatalf("expected only stream to be overridden for codex, got %#v", got)
+	}
+	if got := matrix[backendKimi]; !got.SupportsResume || !got.SupportsACP || !got.SupportsReview {
+		t.Fatalf("expected kimi to gain resume and acp, got %#v", got)
+	}
+	if !base[backendCodex].SupportsStream {
+		t.Fatalf("expected base matrix to stay untouched")
+	}
+	if _, _, err := selectBackend(backendCodex, backendSelectionOptions{Stream: true}, matrix); err == nil || !strings.Contains(err.Error(), "does not support stream mode") {
+		t.Fatalf("expected overridden stream capability to be enforced, got %v", err)
+	}
+}
+
+func TestFormatBackendCapabilities(t *testing.T) {
+	got := formatBackendCapabilities(backendCapabilities{SupportsReview: true, SupportsResume: true, SupportsACP: true})
+	if got != "review,session_resume,acp" {
+		t.Fatalf("unexpected capabilities %q", got)
+	}
+}
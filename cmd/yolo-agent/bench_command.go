@@ -0,0 +1,530 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/engine"
+	gitvcs "github.com/egv/yolo-runner/v2/internal/vcs/git"
+)
+
+const benchRelDir = ".yolo-runner/bench"
+
+// benchCostMetadataKey is the runner artifact a backend reports its spend
+// in, in US dollars. Targets whose backend never reports it show no cost.
+const benchCostMetadataKey = "cost_usd"
+
+// benchTarget is one backend and model the bench runs the task set with.
+type benchTarget struct {
+	Backend string `json:"backend"`
+	Model   string `json:"model,omitempty"`
+}
+
+func (t benchTarget) String() string {
+	if t.Model == "" {
+		return t.Backend
+	}
+	return t.Backend + ":" + t.Model
+}
+
+type benchConfig struct {
+	repoRoot      string
+	profile       string
+	rootID        string
+	taskIDs       []string
+	targets       []benchTarget
+	concurrency   int
+	retryBudget   int
+	runnerTimeout time.Duration
+}
+
+// benchResult is one target's row of the comparison report.
+type benchResult struct {
+	Target         string  `json:"target"`
+	RunID          string  `json:"run_id"`
+	Tasks          int     `json:"tasks"`
+	Succeeded      int     `json:"succeeded"`
+	SuccessRate    float64 `json:"success_rate"`
+	Reviews        int     `json:"reviews"`
+	ReviewsPassed  int     `json:"reviews_passed"`
+	ReviewPassRate float64 `json:"review_pass_rate"`
+	// TaskDuration is the mean time from task_started to task_finished.
+	TaskDuration time.Duration `json:"task_duration_ns"`
+	// Duration is the wall time of the target's whole run.
+	Duration time.Duration `json:"duration_ns"`
+	// CostUSD is nil when the backend reported no cost.
+	CostUSD *float64 `json:"cost_usd,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// benchReport is written to .yolo-runner/bench/<bench-id>/report.json.
+type benchReport struct {
+	ID      string        `json:"id"`
+	RootID  string        `json:"root_id"`
+	TaskIDs []string      `json:"task_ids,omitempty"`
+	Results []benchResult `json:"results"`
+}
+
+// benchRunnerFactory builds the runner and resolves the model of a target.
+type benchRunnerFactory func(target benchTarget) (contracts.AgentRunner, string, error)
+
+func runBenchCommand(args []string) int {
+	fs := flag.NewFlagSet("yolo-agent bench", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	repoRoot := fs.String("repo", ".", "Repository root")
+	profile := fs.String("profile", "", "Tracker profile name from .yolo-runner/config.yaml")
+	root := fs.String("root", "", "Root task ID whose tasks are benchmarked")
+	tasks := fs.String("tasks", "", "Comma-separated task IDs to benchmark, reopened in the bench copy even when done (default: the open tasks under --root)")
+	targets := fs.String("targets", "", "Comma-separated backends to compare, each backend or backend:model, e.g. codex,claude:opus")
+	concurrency := fs.Int("concurrency", 1, "Maximum number of active task workers per target")
+	retryBudget := fs.Int("retry-budget", 5, "Maximum retry attempts per task for remediation loop")
+	runnerTimeout := fs.Duration("runner-timeout", 0, "Per runner execution timeout")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments for bench: %s\n", strings.Join(fs.Args(), " "))
+		return 1
+	}
+	parsedTargets, err := parseBenchTargets(*targets)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	cfg := benchConfig{
+		repoRoot:      *repoRoot,
+		profile:       strings.TrimSpace(*profile),
+		rootID:        strings.TrimSpace(*root),
+		taskIDs:       parseQualityGateTools(*tasks),
+		targets:       parsedTargets,
+		concurrency:   *concurrency,
+		retryBudget:   *retryBudget,
+		runnerTimeout: *runnerTimeout,
+	}
+	if cfg.rootID == "" {
+		fmt.Fprintln(os.Stderr, "--root is required")
+		return 1
+	}
+	if cfg.concurrency <= 0 {
+		fmt.Fprintln(os.Stderr, "--concurrency must be greater than 0")
+		return 1
+	}
+	if err := runBenchFromConfig(context.Background(), cfg, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// runBenchFromConfig reads the task tree once from the profile's tracker and
+// benchmarks every target against its own in-memory copy of it.
+func runBenchFromConfig(ctx context.Context, cfg benchConfig, out io.Writer) error {
+	trackerProfile, err := resolveTrackerProfile(cfg.repoRoot, cfg.profile, cfg.rootID, os.Getenv)
+	if err != nil {
+		return err
+	}
+	storage, err := buildStorageBackendForTracker(cfg.repoRoot, trackerProfile)
+	if err != nil {
+		return err
+	}
+	runCfg := runConfig{repoRoot: cfg.repoRoot, rootID: cfg.rootID}
+	if err := resolveRunConfigCodingAgents(&runCfg); err != nil {
+		return err
+	}
+	newRunner := func(target benchTarget) (contracts.AgentRunner, string, error) {
+		targetCfg := runCfg
+		targetCfg.backend = target.Backend
+		runner, err := buildRunnerAdapter(targetCfg)
+		if err != nil {
+			return nil, "", err
+		}
+		model := target.Model
+		if definition, ok := runCfg.codingAgents.Backend(normalizeBackend(target.Backend)); ok && model == "" {
+			model = definition.Model
+		}
+		return runner, model, nil
+	}
+	return runBench(ctx, cfg, storage, trackerProfile.Pipeline, newRunner, out)
+}
+
+// runBench runs the task set once per target. Each target gets a fresh copy
+// of the tree, so tracker statuses and task data are never written, and its
+// own run ID, so its task branches and clones are kept apart. Tasks are never
+// landed; their clones are kept under .yolo-runner/bench/<bench-id>/clones for
+// inspection.
+func runBench(ctx context.Context, cfg benchConfig, storage contracts.StorageBackend, pipeline agent.Pipeline, newRunner benchRunnerFactory, out io.Writer) error {
+	if len(cfg.targets) == 0 {
+		return errors.New("--targets needs at least one backend")
+	}
+	tree, err := storage.GetTaskTree(ctx, cfg.rootID)
+	if err != nil {
+		return fmt.Errorf("read task tree %q: %w", cfg.rootID, err)
+	}
+	tree, err = benchTaskTree(tree, cfg.taskIDs)
+	if err != nil {
+		return err
+	}
+	report := benchReport{ID: newRunID(time.Now()), RootID: cfg.rootID, TaskIDs: cfg.taskIDs}
+	benchDir := filepath.Join(cfg.repoRoot, benchRelDir, report.ID)
+	for _, target := range cfg.targets {
+		fmt.Fprintf(out, "bench: running %s\n", target)
+		result := runBenchTarget(ctx, cfg, tree, pipeline, target, newRunner, benchDir)
+		report.Results = append(report.Results, result)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	writeBenchReport(out, report)
+	reportPath := filepath.Join(benchDir, "report.json")
+	if err := saveBenchReport(reportPath, report); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "report: %s\n", reportPath)
+	return ctx.Err()
+}
+
+func runBenchTarget(ctx context.Context, cfg benchConfig, tree *contracts.TaskTree, pipeline agent.Pipeline, target benchTarget, newRunner benchRunnerFactory, benchDir string) benchResult {
+	runID := newRunID(time.Now())
+	result := benchResult{Target: target.String(), RunID: runID}
+	runner, model, err := newRunner(target)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	var vcs contracts.VCS
+	var vcsFactory agent.VCSFactory
+	var cloneManager agent.CloneManager
+	if isGitRepository(cfg.repoRoot) {
+		vcs = gitvcs.NewVCSAdapter(localGitRunner{dir: cfg.repoRoot}).WithRunID(runID)
+		vcsFactory = func(repoRoot string) contracts.VCS {
+			return gitvcs.NewVCSAdapter(localGitRunner{dir: repoRoot}).WithRunID(runID)
+		}
+		cloneManager = keptCloneManager{agent.NewGitCloneManager(filepath.Join(benchDir, "clones")).WithRunID(runID)}
+	}
+	recorder := &benchEventRecorder{}
+	loop := agent.NewLoopWithTaskEngine(newBenchStorage(tree), engine.NewTaskEngine(), runner, contracts.NewSequencedEventSink(runID, recorder), agent.LoopOptions{
+		ParentID:       cfg.rootID,
+		MaxRetries:     cfg.retryBudget,
+		Concurrency:    cfg.concurrency,
+		RepoRoot:       cfg.repoRoot,
+		Backend:        target.Backend,
+		Model:          model,
+		RunnerTimeout:  cfg.runnerTimeout,
+		VCS:            vcs,
+		VCSFactory:     vcsFactory,
+		CloneManager:   cloneManager,
+		RequireReview:  true,
+		MergeOnSuccess: false,
+		Pipeline:       pipeline,
+		RunID:          runID,
+	})
+	started := time.Now()
+	_, err = loop.Run(ctx)
+	result = summarizeBenchEvents(result, recorder.Events())
+	result.Duration = time.Since(started)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// parseBenchTargets reads backend or backend:model entries separated by
+// commas.
+func parseBenchTargets(raw string) ([]benchTarget, error) {
+	targets := []benchTarget{}
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		backend, model, _ := strings.Cut(entry, ":")
+		target := benchTarget{Backend: strings.ToLower(strings.TrimSpace(backend)), Model: strings.TrimSpace(model)}
+		if target.Backend == "" {
+			return nil, fmt.Errorf("bench target %q names no backend", entry)
+		}
+		if seen[target.String()] {
+			return nil, fmt.Errorf("bench target %q is listed twice", entry)
+		}
+		seen[target.String()] = true
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("--targets needs at least one backend")
+	}
+	return targets, nil
+}
+
+// benchTaskTree narrows tree to taskIDs, hung directly under the root and
+// reopened, keeping only the dependencies among them. No taskIDs keeps the
+// whole tree.
+func benchTaskTree(tree *contracts.TaskTree, taskIDs []string) (*contracts.TaskTree, error) {
+	if tree == nil {
+		return nil, errors.New("tracker returned no task tree")
+	}
+	if len(taskIDs) == 0 {
+		return cloneBenchTree(tree), nil
+	}
+	rootID := tree.Root.ID
+	narrowed := &contracts.TaskTree{Root: tree.Root, Tasks: map[string]contracts.Task{}}
+	if root, ok := tree.Tasks[rootID]; ok {
+		narrowed.Tasks[rootID] = cloneBenchTask(root)
+	}
+	selected := map[string]bool{}
+	for _, id := range taskIDs {
+		task, ok := tree.Tasks[id]
+		if !ok || id == rootID {
+			return nil, fmt.Errorf("task %q is not under root %q", id, rootID)
+		}
+		task = cloneBenchTask(task)
+		task.ParentID = rootID
+		task.Status = contracts.TaskStatusOpen
+		narrowed.Tasks[id] = task
+		narrowed.Relations = append(narrowed.Relations, contracts.TaskRelation{FromID: rootID, ToID: id, Type: contracts.RelationParent})
+		selected[id] = true
+	}
+	for _, relation := range tree.Relations {
+		if relation.Type != contracts.RelationParent && selected[relation.FromID] && selected[relation.ToID] {
+			narrowed.Relations = append(narrowed.Relations, relation)
+		}
+	}
+	return narrowed, nil
+}
+
+func cloneBenchTree(tree *contracts.TaskTree) *contracts.TaskTree {
+	clone := &contracts.TaskTree{
+		Root:                 cloneBenchTask(tree.Root),
+		Tasks:                make(map[string]contracts.Task, len(tree.Tasks)),
+		Relations:            append([]contracts.TaskRelation(nil), tree.Relations...),
+		MissingDependencyIDs: append([]string(nil), tree.MissingDependencyIDs...),
+	}
+	for id, task := range tree.Tasks {
+		clone.Tasks[id] = cloneBenchTask(task)
+	}
+	if tree.MissingDependenciesByTask != nil {
+		clone.MissingDependenciesByTask = make(map[string][]string, len(tree.MissingDependenciesByTask))
+		for id, deps := range tree.MissingDependenciesByTask {
+			clone.MissingDependenciesByTask[id] = append([]string(nil), deps...)
+		}
+	}
+	return clone
+}
+
+func cloneBenchTask(task contracts.Task) contracts.Task {
+	if task.Metadata != nil {
+		metadata := make(map[string]string, len(task.Metadata))
+		for key, value := range task.Metadata {
+			metadata[key] = value
+		}
+		task.Metadata = metadata
+	}
+	return task
+}
+
+// benchStorage serves one target's copy of the task tree. Status and data
+// writes stay in memory, so a bench never touches the tracker.
+type benchStorage struct {
+	mu   sync.Mutex
+	tree *contracts.TaskTree
+}
+
+func newBenchStorage(tree *contracts.TaskTree) *benchStorage {
+	return &benchStorage{tree: cloneBenchTree(tree)}
+}
+
+func (s *benchStorage) GetTaskTree(_ context.Context, rootID string) (*contracts.TaskTree, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rootID != s.tree.Root.ID {
+		return nil, fmt.Errorf("bench task tree has root %q, not %q", s.tree.Root.ID, rootID)
+	}
+	return cloneBenchTree(s.tree), nil
+}
+
+func (s *benchStorage) GetTask(_ context.Context, taskID string) (*contracts.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tree.Tasks[taskID]
+	if !ok {
+		return nil, fmt.Errorf("task %q not found", taskID)
+	}
+	task = cloneBenchTask(task)
+	return &task, nil
+}
+
+func (s *benchStorage) SetTaskStatus(_ context.Context, taskID string, status contracts.TaskStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tree.Tasks[taskID]
+	if !ok {
+		return fmt.Errorf("task %q not found", taskID)
+	}
+	task.Status = status
+	s.tree.Tasks[taskID] = task
+	if taskID == s.tree.Root.ID {
+		s.tree.Root.Status = status
+	}
+	return nil
+}
+
+func (s *benchStorage) SetTaskData(_ context.Context, taskID string, data map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tree.Tasks[taskID]
+	if !ok {
+		return fmt.Errorf("task %q not found", taskID)
+	}
+	if task.Metadata == nil {
+		task.Metadata = map[string]string{}
+	}
+	for key, value := range data {
+		task.Metadata[key] = value
+	}
+	s.tree.Tasks[taskID] = task
+	return nil
+}
+
+// keptCloneManager leaves task clones in place when a task finishes, so the
+// bench branches can be compared afterwards.
+type keptCloneManager struct {
+	agent.CloneManager
+}
+
+func (keptCloneManager) Cleanup(string) error {
+	return nil
+}
+
+type benchEventRecorder struct {
+	mu     sync.Mutex
+	events []contracts.Event
+}
+
+func (r *benchEventRecorder) Emit(_ context.Context, event contracts.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *benchEventRecorder) Events() []contracts.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]contracts.Event(nil), r.events...)
+}
+
+// summarizeBenchEvents fills result from a target's events. A task counts as
+// succeeded when its last task_finished is closed; each review_finished with
+// a verdict counts as a review.
+func summarizeBenchEvents(result benchResult, events []contracts.Event) benchResult {
+	started := map[string]time.Time{}
+	finished := map[string]contracts.Event{}
+	cost := 0.0
+	costReported := false
+	for _, event := range events {
+		switch event.Type {
+		case contracts.EventTypeTaskStarted:
+			if _, ok := started[event.TaskID]; !ok {
+				started[event.TaskID] = event.Timestamp
+			}
+		case contracts.EventTypeTaskFinished:
+			finished[event.TaskID] = event
+		case contracts.EventTypeReviewFinished:
+			switch event.Metadata["review_verdict"] {
+			case "pass":
+				result.Reviews++
+				result.ReviewsPassed++
+			case "fail":
+				result.Reviews++
+			}
+		case contracts.EventTypeRunnerFinished:
+			if value, err := strconv.ParseFloat(strings.TrimSpace(event.Metadata[benchCostMetadataKey]), 64); err == nil {
+				cost += value
+				costReported = true
+			}
+		}
+	}
+	var total time.Duration
+	timed := 0
+	for taskID, event := range finished {
+		result.Tasks++
+		if event.Message == string(contracts.TaskStatusClosed) {
+			result.Succeeded++
+		}
+		if start, ok := started[taskID]; ok && !event.Timestamp.Before(start) {
+			total += event.Timestamp.Sub(start)
+			timed++
+		}
+	}
+	if result.Tasks > 0 {
+		result.SuccessRate = float64(result.Succeeded) / float64(result.Tasks)
+	}
+	if result.Reviews > 0 {
+		result.ReviewPassRate = float64(result.ReviewsPassed) / float64(result.Reviews)
+	}
+	if timed > 0 {
+		result.TaskDuration = total / time.Duration(timed)
+	}
+	if costReported {
+		result.CostUSD = &cost
+	}
+	return result
+}
+
+func writeBenchReport(out io.Writer, report benchReport) {
+	results := append([]benchResult(nil), report.Results...)
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].SuccessRate > results[j].SuccessRate
+	})
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "TARGET\tSUCCESS\tREVIEW PASS\tAVG TASK\tTOTAL\tCOST\tRUN")
+	for _, result := range results {
+		cost := "-"
+		if result.CostUSD != nil {
+			cost = fmt.Sprintf("$%.2f", *result.CostUSD)
+		}
+		review := "-"
+		if result.Reviews > 0 {
+			review = fmt.Sprintf("%d/%d (%.0f%%)", result.ReviewsPassed, result.Reviews, result.ReviewPassRate*100)
+		}
+		fmt.Fprintf(table, "%s\t%d/%d (%.0f%%)\t%s\t%s\t%s\t%s\t%s\n", result.Target, result.Succeeded, result.Tasks, result.SuccessRate*100, review, result.TaskDuration.Round(time.Second), result.Duration.Round(time.Second), cost, result.RunID)
+	}
+	_ = table.Flush()
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Fprintf(out, "%s: %s\n", result.Target, result.Error)
+		}
+	}
+}
+
+func saveBenchReport(path string, report benchReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func isGitRepository(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+type benchTestTracker struct {
+	tree   *contracts.TaskTree
+	writes int
+}
+
+func (s *benchTestTracker) GetTaskTree(context.Context, string) (*contracts.TaskTree, error) {
+	return s.tree, nil
+}
+
+func (s *benchTestTracker) GetTask(_ context.Context, taskID string) (*contracts.Task, error) {
+	task := s.tree.Tasks[taskID]
+	return &task, nil
+}
+
+func (s *benchTestTracker) SetTaskStatus(context.Context, string, contracts.TaskStatus) error {
+	s.writes++
+	return nil
+}
+
+func (s *benchTestTracker) SetTaskData(context.Context, string, map[string]string) error {
+	s.writes++
+	return nil
+}
+
+type benchTestRunner struct {
+	mu     sync.Mutex
+	pass   bool
+	models []string
+}
+
+func (r *benchTestRunner) Run(_ context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	r.mu.Lock()
+	r.models = append(r.models, request.Model)
+	r.mu.Unlock()
+	if !r.pass {
+		return contracts.RunnerResult{Status: contracts.RunnerResultFailed, Reason: "tests fail"}, nil
+	}
+	if request.Mode == contracts.RunnerModeReview {
+		return contracts.RunnerResult{Status: contracts.RunnerResultCompleted, ReviewReady: true, Artifacts: map[string]string{"review_verdict": "pass", benchCostMetadataKey: "0.25"}}, nil
+	}
+	return contracts.RunnerResult{Status: contracts.RunnerResultCompleted, Artifacts: map[string]string{benchCostMetadataKey: "0.50"}}, nil
+}
+
+func TestParseBenchTargetsReadsBackendsAndModels(t *testing.T) {
+	targets, err := parseBenchTargets(" codex, Claude:opus ,")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []benchTarget{{Backend: "codex"}, {Backend: "claude", Model: "opus"}}
+	if len(targets) != len(want) || targets[0] != want[0] || targets[1] != want[1] {
+		t.Fatalf("expected %#v, got %#v", want, targets)
+	}
+	for _, raw := range []string{"", ":opus", "codex,codex"} {
+		if _, err := parseBenchTargets(raw); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestRunBenchComparesTargetsWithoutWritingToTracker(t *testing.T) {
+	repoRoot := t.TempDir()
+	tracker := &benchTestTracker{tree: &contracts.TaskTree{
+		Root: contracts.Task{ID: "root", Title: "Epic", Status: contracts.TaskStatusOpen},
+		Tasks: map[string]contracts.Task{
+			"root": {ID: "root", Title: "Epic", Status: contracts.TaskStatusOpen},
+			"t-1":  {ID: "t-1", Title: "Done already", Status: contracts.TaskStatusClosed, ParentID: "epic"},
+			"t-2":  {ID: "t-2", Title: "Open", Status: contracts.TaskStatusOpen, ParentID: "epic"},
+			"t-3":  {ID: "t-3", Title: "Not benchmarked", Status: contracts.TaskStatusOpen, ParentID: "root"},
+		},
+		Relations: []contracts.TaskRelation{{FromID: "t-2", ToID: "t-1", Type: contracts.RelationDependsOn}, {FromID: "t-3", ToID: "t-2", Type: contracts.RelationDependsOn}},
+	}}
+	runners := map[string]*benchTestRunner{"good": {pass: true}, "bad": {}}
+	newRunner := func(target benchTarget) (contracts.AgentRunner, string, error) {
+		return runners[target.Backend], target.Model, nil
+	}
+	out := &bytes.Buffer{}
+	err := runBench(context.Background(), benchConfig{
+		repoRoot:    repoRoot,
+		rootID:      "root",
+		taskIDs:     []string{"t-1", "t-2"},
+		targets:     []benchTarget{{Backend: "bad"}, {Backend: "good", Model: "large"}},
+		concurrency: 1,
+		retryBudget: 0,
+	}, tracker, agent.Pipeline{}, newRunner, out)
+	if err != nil {
+		t.Fatalf("bench: %v\n%s", err, out.String())
+	}
+	if tracker.writes != 0 {
+		t.Fatalf("expected no tracker writes, got %d", tracker.writes)
+	}
+	for _, model := range runners["good"].models {
+		if model != "large" {
+			t.Fatalf("expected the target model on every request, got %q", model)
+		}
+	}
+
+	reports, err := filepath.Glob(filepath.Join(repoRoot, benchRelDir, "*", "report.json"))
+	if err != nil || len(reports) != 1 {
+		t.Fatalf("expected one report, got %v (%v)", reports, err)
+	}
+	data, err := os.ReadFile(reports[0])
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	report := benchReport{}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if len(report.Results) != 2 {
+		t.Fatalf("expected a result per target, got %#v", report.Results)
+	}
+	good := report.Results[1]
+	if good.Target != "good:large" || good.Tasks != 2 || good.Succeeded != 2 || good.SuccessRate != 1 {
+		t.Fatalf("expected both tasks to succeed for good:large, got %#v", good)
+	}
+	if good.Reviews != 2 || good.ReviewsPassed != 2 || good.ReviewPassRate != 1 {
+		t.Fatalf("expected two passed reviews, got %#v", good)
+	}
+	if good.CostUSD == nil || *good.CostUSD != 1.5 {
+		t.Fatalf("expected reported cost summed to 1.5, got %v", good.CostUSD)
+	}
+	bad := report.Results[0]
+	if bad.Target != "bad" || bad.Succeeded != 0 || bad.Reviews != 0 || bad.CostUSD != nil {
+		t.Fatalf("expected no successes, reviews or cost for bad, got %#v", bad)
+	}
+	if bad.RunID == good.RunID {
+		t.Fatalf("expected each target to run under its own run ID")
+	}
+	for _, want := range []string{"TARGET", "good:large", "2/2 (100%)", "$1.50", "report: "} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in output, got:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	defaultChatOpsAddr      = "127.0.0.1:7430"
+	slackSigningSecretEnv   = "SLACK_SIGNING_SECRET"
+	slackSignatureMaxSkew   = 5 * time.Minute
+	slackCommandBodyLimit   = 64 << 10
+	chatOpsAPITimeout       = 2 * time.Second
+	chatOpsStatusTaskLimit  = 15
+	slackResponseInChannel  = "in_channel"
+	slackResponseEphemeral  = "ephemeral"
+	chatOpsCommandUsageText = "Usage: `/yolo run <issue>`, `/yolo status`, `/yolo approve <task>`"
+)
+
+type chatOpsConfig struct {
+	repoRoot      string
+	listenAddr    string
+	signingSecret string
+	apiAddr       string
+	apiToken      string
+	runArgs       []string
+	// roles maps Slack user IDs, or "*", to the role they act with.
+	roles map[string]controlRole
+}
+
+// runChatOpsCommand serves Slack slash commands and maps them to runs and the
+// --serve control API. Flags after "--" are passed to every run it starts.
+func runChatOpsCommand(args []string) int {
+	fs := flag.NewFlagSet("yolo-agent chatops", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	repoRoot := fs.String("repo", ".", "Repository root for runs started with /yolo run")
+	listen := fs.String("listen", defaultChatOpsAddr, "Listen address for Slack slash command requests")
+	signingSecret := fs.String("signing-secret", "", "Slack app signing secret (default: $"+slackSigningSecretEnv+")")
+	apiAddr := fs.String("api-addr", defaultServeAddr, "Address the runs serve their control API on")
+	apiToken := fs.String("api-token", "", "Control API token (default: $"+serveTokenEnv+")")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	cfg := chatOpsConfig{
+		repoRoot:      *repoRoot,
+		listenAddr:    strings.TrimSpace(*listen),
+		signingSecret: strings.TrimSpace(*signingSecret),
+		apiAddr:       strings.TrimSpace(*apiAddr),
+		runArgs:       fs.Args(),
+	}
+	if cfg.signingSecret == "" {
+		cfg.signingSecret = strings.TrimSpace(os.Getenv(slackSigningSecretEnv))
+	}
+	if cfg.signingSecret == "" {
+		fmt.Fprintf(os.Stderr, "chatops requires --signing-secret or %s\n", slackSigningSecretEnv)
+		return 1
+	}
+	token, err := resolveServeToken(*apiToken, os.Getenv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chatops requires --api-token or %s\n", serveTokenEnv)
+		return 1
+	}
+	cfg.apiToken = token
+	defaults, err := loadYoloAgentConfigDefaults(cfg.repoRoot)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if len(defaults.ControlAPI.SlackRoles) == 0 {
+		fmt.Fprintf(os.Stderr, "chatops requires agent.control_api.slack.roles in %s\n", trackerConfigRelPath)
+		return 1
+	}
+	cfg.roles = defaults.ControlAPI.SlackRoles
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := serveChatOps(ctx, cfg, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func serveChatOps(ctx context.Context, cfg chatOpsConfig, logOut io.Writer) error {
+	service := &chatOpsService{
+		signingSecret: cfg.signingSecret,
+		api:           newControlAPIClient("http://"+cfg.apiAddr, cfg.apiToken),
+		launch:        newRunLauncher(cfg, logOut),
+		roles:         cfg.roles,
+		now:           time.Now,
+	}
+	mux := http.NewServeMux()
+	mux.Handle("POST /slack/commands", service)
+	server := &http.Server{Addr: cfg.listenAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	fmt.Fprintf(logOut, "ChatOps listening on http://%s/slack/commands\n", cfg.listenAddr)
+	select {
+	case err := <-errs:
+		return fmt.Errorf("serve chatops: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), controlAPIShutdownGrace)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+// runLauncher starts a run rooted at issue in the background.
+type runLauncher func(issue string) error
+
+// newRunLauncher starts runs as child yolo-agent processes with --serve on
+// cfg.apiAddr, so the same service can reach them through the control API.
+func newRunLauncher(cfg chatOpsConfig, logOut io.Writer) runLauncher {
+	return func(issue string) error {
+		executable, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		args := append([]string{"--repo", cfg.repoRoot, "--root", issue, "--serve", "--serve-addr", cfg.apiAddr}, cfg.runArgs...)
+		cmd := exec.Command(executable, args...)
+		cmd.Env = append(os.Environ(), serveTokenEnv+"="+cfg.apiToken)
+		cmd.Stdout = logOut
+		cmd.Stderr = logOut
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		go func() {
+			if err := cmd.Wait(); err != nil {
+				fmt.Fprintf(logOut, "run for %s exited: %v\n", issue, err)
+			}
+		}()
+		return nil
+	}
+}
+
+// chatOpsService acts on the control API with one approver token, so it
+// checks each Slack user's own role from roles before forwarding a command.
+type chatOpsService struct {
+	signingSecret string
+	api           *controlAPIClient
+	launch        runLauncher
+	roles         map[string]controlRole
+	now           func() time.Time
+}
+
+type slackCommandResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+func (s *chatOpsService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, slackCommandBodyLimit))
+	if err != nil {
+		http.Error(w, "read request", http.StatusBadRequest)
+		return
+	}
+	if err := verifySlackSignature(s.signingSecret, r.Header, body, s.now()); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form body", http.StatusBadRequest)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), chatOpsAPITimeout)
+	defer cancel()
+	response := s.handleCommand(ctx, form.Get("text"), slackUser{id: form.Get("user_id"), name: form.Get("user_name")})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// verifySlackSignature checks Slack's v0 request signature: an HMAC-SHA256 of
+// "v0:<timestamp>:<body>" keyed with the signing secret. Old timestamps are
+// rejected so captured requests cannot be replayed.
+func verifySlackSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	rawTimestamp := header.Get("X-Slack-Request-Timestamp")
+	timestamp, err := strconv.ParseInt(rawTimestamp, 10, 64)
+	if err != nil {
+		return errors.New("missing or invalid request timestamp")
+	}
+	if skew := now.Sub(time.Unix(timestamp, 0)); skew > slackSignatureMaxSkew || skew < -slackSignatureMaxSkew {
+		return errors.New("stale request timestamp")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte("v0:" + rawTimestamp + ":"))
+	_, _ = mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return errors.New("invalid request signature")
+	}
+	return nil
+}
+
+// slackUser is the Slack user behind a command: roles are keyed by the
+// stable id, replies mention the name.
+type slackUser struct {
+	id   string
+	name string
+}
+
+func (s *chatOpsService) handleCommand(ctx context.Context, text string, user slackUser) slackCommandResponse {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return slackEphemeral(chatOpsCommandUsageText)
+	}
+	switch strings.ToLower(fields[0]) {
+	case "run":
+		if len(fields) != 2 {
+			return slackEphemeral("Usage: `/yolo run <issue>`")
+		}
+		if denied, ok := s.authorize(user, controlRoleOperator); !ok {
+			return denied
+		}
+		return s.startRun(ctx, fields[1], user.name)
+	case "status":
+		if denied, ok := s.authorize(user, controlRoleViewer); !ok {
+			return denied
+		}
+		return s.status(ctx)
+	case "approve":
+		if len(fields) != 2 {
+			return slackEphemeral("Usage: `/yolo approve <task>`")
+		}
+		if denied, ok := s.authorize(user, controlRoleApprover); !ok {
+			return denied
+		}
+		return s.approve(ctx, fields[1], user.name)
+	default:
+		return slackEphemeral(chatOpsCommandUsageText)
+	}
+}
+
+// authorize reports whether user holds role, and otherwise the reply that
+// refuses the command.
+func (s *chatOpsService) authorize(user slackUser, role controlRole) (slackCommandResponse, bool) {
+	principal := controlPrincipal{name: user.id, role: s.roles[controlOIDCWildcard]}
+	if id := strings.TrimSpace(user.id); id != "" && s.roles[id] > principal.role {
+		principal.role = s.roles[id]
+	}
+	if principal.role >= role {
+		return slackCommandResponse{}, true
+	}
+	if user.name != "" {
+		principal.name = user.name
+	}
+	return slackEphemeral(controlAPIRoleError(principal, role)), false
+}
+
+func (s *chatOpsService) startRun(ctx context.Context, issue string, user string) slackCommandResponse {
+	if run, err := s.api.run(ctx); err == nil && runIsActive(run.Status) {
+		return slackEphemeral(fmt.Sprintf("A run is already active on `%s` (%s). Check it with `/yolo status`.", run.RootID, run.Status))
+	}
+	if err := s.launch(issue); err != nil {
+		return slackEphemeral(fmt.Sprintf("Could not start a run for `%s`: %v", issue, err))
+	}
+	return slackCommandResponse{
+		ResponseType: slackResponseInChannel,
+		Text:         fmt.Sprintf("%sStarting a run for `%s`. Follow it with `/yolo status`.", slackMention(user), issue),
+	}
+}
+
+func runIsActive(status string) bool {
+	switch status {
+	case "starting", "running", "paused":
+		return true
+	}
+	return false
+}
+
+func (s *chatOpsService) status(ctx context.Context) slackCommandResponse {
+	run, err := s.api.run(ctx)
+	if err != nil {
+		return slackEphemeral("No run is reachable: " + err.Error())
+	}
+	tasks, err := s.api.tasks(ctx)
+	if err != nil {
+		return slackEphemeral("Could not list tasks: " + err.Error())
+	}
+	return slackEphemeral(formatChatOpsStatus(run, tasks))
+}
+
+// formatChatOpsStatus renders the run as Slack mrkdwn: a header, the counts,
+// then tasks needing attention first.
+func formatChatOpsStatus(run controlAPIRun, tasks []controlAPITask) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*Run* `%s`", run.RootID)
+	if run.RunID != "" {
+		fmt.Fprintf(&b, " (%s)", run.RunID)
+	}
+	fmt.Fprintf(&b, ": *%s*\n", run.Status)
+	if run.Error != "" {
+		fmt.Fprintf(&b, "Error: %s\n", run.Error)
+	}
+	fmt.Fprintf(&b, "%d tasks: %d running, %d completed, %d blocked, %d failed\n",
+		run.Counts.Total, run.Counts.Running, run.Counts.Completed, run.Counts.Blocked, run.Counts.Failed)
+
+	ordered := make([]controlAPITask, 0, len(tasks))
+	for _, task := range tasks {
+		if task.PendingApproval != nil {
+			ordered = append(ordered, task)
+		}
+	}
+	for _, task := range tasks {
+		if task.PendingApproval == nil && task.Status != string(contracts.TaskStatusClosed) && task.Status != "completed" {
+			ordered = append(ordered, task)
+		}
+	}
+	for i, task := range ordered {
+		if i == chatOpsStatusTaskLimit {
+			fmt.Fprintf(&b, "…and %d more\n", len(ordered)-i)
+			break
+		}
+		fmt.Fprintf(&b, "• `%s`", task.ID)
+		if task.Title != "" {
+			fmt.Fprintf(&b, " %s", task.Title)
+		}
+		fmt.Fprintf(&b, ": %s", task.Status)
+		if task.Reason != "" {
+			fmt.Fprintf(&b, " (%s)", task.Reason)
+		}
+		if pending := task.PendingApproval; pending != nil {
+			fmt.Fprintf(&b, "\n    waiting for approval: %s `%s`. Reply `/yolo approve %s`", pending.Kind, pending.Title, task.ID)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (s *chatOpsService) approve(ctx context.Context, taskID string, user string) slackCommandResponse {
+	found, err := s.api.approve(ctx, taskID)
+	if err != nil {
+		return slackEphemeral("Could not approve: " + err.Error())
+	}
+	if !found {
+		return slackEphemeral(fmt.Sprintf("Task `%s` has no pending approval.", taskID))
+	}
+	return slackCommandResponse{
+		ResponseType: slackResponseInChannel,
+		Text:         fmt.Sprintf("%sApproved the pending tool call for `%s`.", slackMention(user), taskID),
+	}
+}
+
+func slackEphemeral(text string) slackCommandResponse {
+	return slackCommandResponse{ResponseType: slackResponseEphemeral, Text: text}
+}
+
+func slackMention(user string) string {
+	if user = strings.TrimSpace(user); user != "" {
+		return "@" + user + ": "
+	}
+	return ""
+}
+
+// controlAPIClient calls the REST API served by yolo-agent --serve.
+type controlAPIClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func newControlAPIClient(baseURL string, token string) *controlAPIClient {
+	return &controlAPIClient{baseURL: strings.TrimRight(baseURL, "/"), token: token, client: &http.Client{Timeout: chatOpsAPITimeout}}
+}
+
+func (c *controlAPIClient) run(ctx context.Context) (controlAPIRun, error) {
+	run := controlAPIRun{}
+	_, err := c.do(ctx, http.MethodGet, "/run", &run)
+	return run, err
+}
+
+func (c *controlAPIClient) tasks(ctx context.Context) ([]controlAPITask, error) {
+	listing := struct {
+		Tasks []controlAPITask `json:"tasks"`
+	}{}
+	_, err := c.do(ctx, http.MethodGet, "/tasks", &listing)
+	return listing.Tasks, err
+}
+
+// approve reports false when the task has no pending approval.
+func (c *controlAPIClient) approve(ctx context.Context, taskID string) (bool, error) {
+	status, err := c.do(ctx, http.MethodPost, "/tasks/"+url.PathEscape(taskID)+"/approve", nil)
+	if status == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (c *controlAPIClient) do(ctx context.Context, method string, path string, out any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		failure := controlAPIResponse{}
+		_ = json.NewDecoder(resp.Body).Decode(&failure)
+		if failure.Error == "" {
+			failure.Error = resp.Status
+		}
+		return resp.StatusCode, fmt.Errorf("control API %s %s: %s", method, path, failure.Error)
+	}
+	if out == nil {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+}
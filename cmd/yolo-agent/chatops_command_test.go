@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/acp"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/engine"
+)
+
+var chatOpsTestNow = time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+
+func signSlackRequest(t *testing.T, req *http.Request, secret string, body string, at time.Time) {
+	t.Helper()
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte("v0:" + timestamp + ":" + body))
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+}
+
+func slackCommand(t *testing.T, service *chatOpsService, text string) (int, slackCommandResponse) {
+	t.Helper()
+	return slackCommandAs(t, service, text, "U0DANA", "dana")
+}
+
+func slackCommandAs(t *testing.T, service *chatOpsService, text string, userID string, userName string) (int, slackCommandResponse) {
+	t.Helper()
+	body := url.Values{"command": {"/yolo"}, "text": {text}, "user_id": {userID}, "user_name": {userName}}.Encode()
+	req := httptest.NewRequest(http.MethodPost, "/slack/commands", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	signSlackRequest(t, req, "shh", body, chatOpsTestNow)
+	rec := httptest.NewRecorder()
+	service.ServeHTTP(rec, req)
+	response := slackCommandResponse{}
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("decode slack response %q: %v", rec.Body.String(), err)
+		}
+	}
+	return rec.Code, response
+}
+
+// newChatOpsTestService points the service at a real control API handler.
+func newChatOpsTestService(t *testing.T, api *controlAPI, launch runLauncher) *chatOpsService {
+	t.Helper()
+	server := httptest.NewServer(api.handler())
+	t.Cleanup(server.Close)
+	return &chatOpsService{
+		signingSecret: "shh",
+		api:           newControlAPIClient(server.URL, "secret"),
+		launch:        launch,
+		roles:         map[string]controlRole{"U0DANA": controlRoleApprover},
+		now:           func() time.Time { return chatOpsTestNow },
+	}
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	body := "text=status"
+	req := httptest.NewRequest(http.MethodPost, "/slack/commands", nil)
+	signSlackRequest(t, req, "shh", body, chatOpsTestNow)
+
+	if err := verifySlackSignature("shh", req.Header, []byte(body), chatOpsTestNow.Add(time.Minute)); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+	if err := verifySlackSignature("other", req.Header, []byte(body), chatOpsTestNow); err == nil {
+		t.Fatalf("expected a different secret to be rejected")
+	}
+	if err := verifySlackSignature("shh", req.Header, []byte(body+"&x=1"), chatOpsTestNow); err == nil {
+		t.Fatalf("expected a tampered body to be rejected")
+	}
+	if err := verifySlackSignature("shh", req.Header, []byte(body), chatOpsTestNow.Add(10*time.Minute)); err == nil || !strings.Contains(err.Error(), "stale") {
+		t.Fatalf("expected a replayed request to be rejected as stale, got %v", err)
+	}
+}
+
+func TestChatOpsRejectsUnsignedRequests(t *testing.T) {
+	service := newChatOpsTestService(t, newControlAPI("secret"), nil)
+	req := httptest.NewRequest(http.MethodPost, "/slack/commands", strings.NewReader("text=status"))
+	rec := httptest.NewRecorder()
+	service.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for an unsigned request, got %d", rec.Code)
+	}
+}
+
+func TestChatOpsStatusSummarizesRun(t *testing.T) {
+	api := newControlAPI("secret")
+	api.attach(&fakeRunController{})
+	ctx := context.Background()
+	_ = api.Emit(ctx, contracts.Event{Type: contracts.EventTypeRunStarted, RunID: "run-1", TaskID: "root-1"})
+	_ = api.Emit(ctx, contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: "task-1", TaskTitle: "Done", Message: "closed"})
+	_ = api.Emit(ctx, contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: "task-2", TaskTitle: "Fix login", Message: "blocked", Metadata: map[string]string{"triage_reason": "needs credentials"}})
+	_ = api.Emit(ctx, contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-3", TaskTitle: "Clean build"})
+	go func() {
+		_, _ = api.AskPermission(context.Background(), acp.PermissionRequest{TaskID: "task-3", Kind: "execute", Title: "rm -rf build"})
+	}()
+	waitForPendingApproval(t, api, "task-3")
+	service := newChatOpsTestService(t, api, nil)
+
+	code, response := slackCommand(t, service, "status")
+	if code != http.StatusOK || response.ResponseType != slackResponseEphemeral {
+		t.Fatalf("unexpected status response: %d %#v", code, response)
+	}
+	want := strings.Join([]string{
+		"*Run* `root-1` (run-1): *running*",
+		"3 tasks: 1 running, 1 completed, 1 blocked, 0 failed",
+		"• `task-3` Clean build: running",
+		"    waiting for approval: execute `rm -rf build`. Reply `/yolo approve task-3`",
+		"• `task-2` Fix login: blocked (needs credentials)",
+	}, "\n")
+	if response.Text != want {
+		t.Fatalf("unexpected status text:\n%s\nwant:\n%s", response.Text, want)
+	}
+
+	code, response = slackCommand(t, service, "approve task-3")
+	if code != http.StatusOK || response.ResponseType != slackResponseInChannel || response.Text != "@dana: Approved the pending tool call for `task-3`." {
+		t.Fatalf("unexpected approve response: %d %#v", code, response)
+	}
+	_, response = slackCommand(t, service, "approve task-3")
+	if response.Text != "Task `task-3` has no pending approval." {
+		t.Fatalf("expected second approval to report nothing pending, got %#v", response)
+	}
+}
+
+func TestChatOpsRunStartsOnlyWithoutActiveRun(t *testing.T) {
+	api := newControlAPI("secret")
+	launched := []string{}
+	service := newChatOpsTestService(t, api, func(issue string) error {
+		launched = append(launched, issue)
+		return nil
+	})
+
+	_ = api.Emit(context.Background(), contracts.Event{Type: contracts.EventTypeRunStarted, TaskID: "root-1"})
+	_, response := slackCommand(t, service, "run root-2")
+	if len(launched) != 0 || !strings.Contains(response.Text, "already active on `root-1`") {
+		t.Fatalf("expected an active run to block a new one, launched=%v response=%#v", launched, response)
+	}
+
+	_ = api.Emit(context.Background(), contracts.Event{Type: contracts.EventTypeRunFinished, Metadata: map[string]string{"status": "completed"}})
+	_, response = slackCommand(t, service, "run root-2")
+	if len(launched) != 1 || launched[0] != "root-2" || response.ResponseType != slackResponseInChannel {
+		t.Fatalf("expected run to start after the previous one finished, launched=%v response=%#v", launched, response)
+	}
+
+	// Nothing listening on the control API also means no active run.
+	service.api = newControlAPIClient("http://127.0.0.1:1", "secret")
+	service.launch = func(string) error { return errors.New("boom") }
+	_, response = slackCommand(t, service, "run root-3")
+	if response.Text != "Could not start a run for `root-3`: boom" {
+		t.Fatalf("expected launch failure to be reported, got %#v", response)
+	}
+}
+
+// probingRunner runs probe before delegating its first request, while the
+// loop has the task in flight.
+type probingRunner struct {
+	contracts.AgentRunner
+	probe  func()
+	probed bool
+}
+
+func (r *probingRunner) Run(ctx context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	if !r.probed {
+		r.probed = true
+		r.probe()
+	}
+	return r.AgentRunner.Run(ctx, request)
+}
+
+func TestChatOpsFollowsRunThroughProductionRunPath(t *testing.T) {
+	api := newControlAPI("secret")
+	launched := []string{}
+	service := newChatOpsTestService(t, api, func(issue string) error {
+		launched = append(launched, issue)
+		return nil
+	})
+	var duringStatus, duringRun slackCommandResponse
+	runner := &probingRunner{
+		AgentRunner: &fakeAgentRunner{results: []contracts.RunnerResult{
+			{Status: contracts.RunnerResultCompleted},
+			{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+		}},
+		probe: func() {
+			_, duringStatus = slackCommand(t, service, "status")
+			_, duringRun = slackCommand(t, service, "run root-2")
+		},
+	}
+	err := runWithStorageComponents(context.Background(), runConfig{
+		repoRoot:   t.TempDir(),
+		rootID:     "root-1",
+		maxTasks:   1,
+		noVCS:      true,
+		serve:      true,
+		serveAddr:  "127.0.0.1:0",
+		controlAPI: api,
+	}, newControlRunTestStorage("root-1", contracts.Task{ID: "task-1", Title: "Update runbook", ParentID: "root-1"}), engine.NewTaskEngine(), runner, &fakeVCS{})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	if !strings.Contains(duringStatus.Text, "`root-1`") || !strings.Contains(duringStatus.Text, ": *running*") || !strings.Contains(duringStatus.Text, "• `task-1` Update runbook: running") {
+		t.Fatalf("expected status to show the task in flight, got %q", duringStatus.Text)
+	}
+	if !strings.Contains(duringRun.Text, "already active on `root-1`") {
+		t.Fatalf("expected the active run to block a second one, got %#v", duringRun)
+	}
+	_, response := slackCommand(t, service, "status")
+	if !strings.Contains(response.Text, ": *completed*") || !strings.Contains(response.Text, "1 tasks: 0 running, 1 completed") {
+		t.Fatalf("expected status to show the finished run, got %q", response.Text)
+	}
+	_, response = slackCommand(t, service, "run root-2")
+	if len(launched) != 1 || launched[0] != "root-2" || response.ResponseType != slackResponseInChannel {
+		t.Fatalf("expected a run to start once the previous one finished, launched=%v response=%#v", launched, response)
+	}
+}
+
+func TestChatOpsChecksSlackUserRoles(t *testing.T) {
+	api := newControlAPI("secret")
+	launched := []string{}
+	service := newChatOpsTestService(t, api, func(issue string) error {
+		launched = append(launched, issue)
+		return nil
+	})
+	service.roles = map[string]controlRole{"*": controlRoleViewer, "U0OPS": controlRoleOperator}
+	go func() {
+		_, _ = api.AskPermission(context.Background(), acp.PermissionRequest{TaskID: "task-1", Kind: "execute", Title: "rm -rf build"})
+	}()
+	waitForPendingApproval(t, api, "task-1")
+
+	if _, response := slackCommandAs(t, service, "status", "U0ANYONE", "sam"); strings.Contains(response.Text, "forbidden") {
+		t.Fatalf("expected the wildcard viewer role to allow status, got %#v", response)
+	}
+	_, response := slackCommandAs(t, service, "run root-1", "U0ANYONE", "sam")
+	if len(launched) != 0 || response.ResponseType != slackResponseEphemeral || response.Text != "forbidden: sam has role viewer, operator required" {
+		t.Fatalf("expected a viewer to be refused a run, launched=%v response=%#v", launched, response)
+	}
+	_, response = slackCommandAs(t, service, "approve task-1", "U0OPS", "olga")
+	if response.Text != "forbidden: olga has role operator, approver required" {
+		t.Fatalf("expected an operator to be refused an approval, got %#v", response)
+	}
+	api.mu.Lock()
+	_, pending := api.approvals["task-1"]
+	api.mu.Unlock()
+	if !pending {
+		t.Fatalf("expected the refused approval not to reach the control API")
+	}
+	if _, response = slackCommandAs(t, service, "run root-1", "U0OPS", "olga"); strings.Contains(response.Text, "forbidden") {
+		t.Fatalf("expected an operator to be allowed a run, got %#v", response)
+	}
+}
+
+func TestChatOpsReportsUsage(t *testing.T) {
+	service := newChatOpsTestService(t, newControlAPI("secret"), nil)
+	for _, text := range []string{"", "deploy", "run", "approve"} {
+		_, response := slackCommand(t, service, text)
+		if response.ResponseType != slackResponseEphemeral || !strings.Contains(response.Text, "Usage:") {
+			t.Fatalf("expected usage for %q, got %#v", text, response)
+		}
+	}
+}
+
+func waitForPendingApproval(t *testing.T, api *controlAPI, taskID string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		api.mu.Lock()
+		_, ok := api.approvals[taskID]
+		api.mu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("no pending approval for %s", taskID)
+}
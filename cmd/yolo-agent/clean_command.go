@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/retention"
+)
+
+func runCleanCommand(args []string) int {
+	fs := flag.NewFlagSet("yolo-agent clean", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	repoRoot := fs.String("repo", ".", "Repository root")
+	all := fs.Bool("all", false, "Remove every runner log, task clone and artifact older than --min-age, ignoring the agent.retention caps")
+	dryRun := fs.Bool("dry-run", false, "Print what would be removed without removing anything")
+	minAge := fs.Duration("min-age", retention.DefaultMinAge, "Keep entries modified within this long, since a run may still use them (default: agent.retention.min_age, or 1h)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments for clean: %s\n", strings.Join(fs.Args(), " "))
+		return 1
+	}
+	if *minAge < 0 {
+		fmt.Fprintln(os.Stderr, "--min-age must be greater than or equal to 0")
+		return 1
+	}
+	defaults, err := loadYoloAgentConfigDefaults(*repoRoot)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	config := defaults.Retention
+	selectedMinAge := config.MinAgeOrDefault()
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "min-age" {
+			selectedMinAge = *minAge
+		}
+	})
+	if !*all && !config.Enabled() {
+		fmt.Fprintln(os.Stdout, "agent.retention sets no caps; showing disk usage only (pass --all to remove everything older than --min-age)")
+		*dryRun = true
+	}
+	options := retention.Options{MinAge: selectedMinAge, All: *all, DryRun: *dryRun}
+	if err := cleanDiskUsage(*repoRoot, config, options, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// cleanDiskUsage applies the retention caps to every area under repoRoot
+// and writes what it removed, or would remove, to out.
+func cleanDiskUsage(repoRoot string, config retention.Config, options retention.Options, out io.Writer) error {
+	if out == nil {
+		out = io.Discard
+	}
+	var errs []error
+	for _, area := range config.Areas(repoRoot) {
+		result, err := retention.Clean(area, options)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("clean %s: %w", area.Name, err))
+		}
+		writeCleanResult(out, repoRoot, result, options.DryRun)
+	}
+	return errors.Join(errs...)
+}
+
+func writeCleanResult(out io.Writer, repoRoot string, result retention.Result, dryRun bool) {
+	verb := "removed"
+	if dryRun {
+		verb = "would remove"
+	}
+	fmt.Fprintf(out, "%s: %s %d of %d entries, %s of %s\n", result.Area, verb, len(result.Removed), result.Entries, formatDiskBytes(result.Freed), formatDiskBytes(result.Bytes))
+	for _, entry := range result.Removed {
+		path := entry.Path
+		if rel, err := filepath.Rel(repoRoot, path); err == nil {
+			path = rel
+		}
+		fmt.Fprintf(out, "  %s (%s, last modified %s)\n", path, formatDiskBytes(entry.Bytes), entry.ModTime.UTC().Format(time.RFC3339))
+	}
+}
+
+// runRetentionOnStart applies the configured caps before a run, reporting
+// only when something was removed.
+func runRetentionOnStart(cfg runConfig, out io.Writer) {
+	if !cfg.retention.Enabled() || cfg.dryRun {
+		return
+	}
+	for _, area := range cfg.retention.Areas(cfg.repoRoot) {
+		result, err := retention.Clean(area, retention.Options{MinAge: cfg.retention.MinAgeOrDefault()})
+		if err != nil {
+			fmt.Fprintf(out, "retention: clean %s: %v\n", area.Name, err)
+		}
+		if len(result.Removed) > 0 {
+			fmt.Fprintf(out, "retention: removed %d %s entries, freed %s\n", len(result.Removed), result.Area, formatDiskBytes(result.Freed))
+		}
+	}
+}
+
+// markRunClonesLive keeps the retention of other runs away from this run's
+// clones until the returned func is called.
+func markRunClonesLive(cfg runConfig, out io.Writer) func() {
+	if cfg.noVCS {
+		return func() {}
+	}
+	unmark, err := retention.MarkRunLive(cfg.repoRoot, cfg.runID)
+	if err != nil {
+		fmt.Fprintf(out, "retention: mark run %s live: %v\n", cfg.runID, err)
+		return func() {}
+	}
+	return unmark
+}
+
+func formatDiskBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value := float64(bytes)
+	suffixes := []string{"KiB", "MiB", "GiB", "TiB"}
+	suffix := ""
+	for _, next := range suffixes {
+		value /= unit
+		suffix = next
+		if value < unit {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
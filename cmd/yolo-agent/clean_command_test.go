@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/retention"
+)
+
+func TestCleanDiskUsageAppliesCapsAndReportsRemovals(t *testing.T) {
+	repoRoot := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	writeCleanTestFile(t, filepath.Join(repoRoot, ".yolo-runner", "clones", "t-1", "README.md"), 2048, old)
+	writeCleanTestFile(t, filepath.Join(repoRoot, "runner-logs", "t-1", "codex", "t-1.jsonl"), 10, old)
+	writeCleanTestFile(t, filepath.Join(repoRoot, "runner-logs", "agent.events.jsonl"), 10, old)
+	ageCleanTestTree(t, filepath.Join(repoRoot, ".yolo-runner", "clones", "t-1"), old)
+
+	out := &bytes.Buffer{}
+	config := retention.Config{Clones: retention.Policy{MaxBytes: 1024}}
+	if err := cleanDiskUsage(repoRoot, config, retention.Options{}, out); err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoRoot, ".yolo-runner", "clones", "t-1")); !os.IsNotExist(err) {
+		t.Fatalf("expected the clone over the cap removed, got err=%v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoRoot, "runner-logs", "t-1", "codex", "t-1.jsonl")); err != nil {
+		t.Fatalf("expected uncapped runner logs kept: %v", err)
+	}
+	for _, want := range []string{
+		"clones: removed 1 of 1 entries, 2.0 KiB of 2.0 KiB",
+		filepath.Join(".yolo-runner", "clones", "t-1") + " (2.0 KiB",
+		"runner-logs: removed 0 of 1 entries",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in output, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunRetentionOnStartSkipsDryRunsAndUncappedConfigs(t *testing.T) {
+	repoRoot := t.TempDir()
+	clone := filepath.Join(repoRoot, ".yolo-runner", "clones", "t-1", "README.md")
+	writeCleanTestFile(t, clone, 10, time.Now().Add(-48*time.Hour))
+	ageCleanTestTree(t, filepath.Dir(clone), time.Now().Add(-48*time.Hour))
+
+	out := &bytes.Buffer{}
+	runRetentionOnStart(runConfig{repoRoot: repoRoot}, out)
+	runRetentionOnStart(runConfig{repoRoot: repoRoot, dryRun: true, retention: retention.Config{Clones: retention.Policy{MaxAge: time.Hour}}}, out)
+	if _, err := os.Stat(clone); err != nil {
+		t.Fatalf("expected clone kept without caps or in a dry run: %v", err)
+	}
+
+	runRetentionOnStart(runConfig{repoRoot: repoRoot, retention: retention.Config{Clones: retention.Policy{MaxAge: 24 * time.Hour}}}, out)
+	if _, err := os.Stat(clone); !os.IsNotExist(err) {
+		t.Fatalf("expected the stale clone removed, got err=%v", err)
+	}
+	if !strings.Contains(out.String(), "retention: removed 1 clones entries") {
+		t.Fatalf("expected removal reported, got %q", out.String())
+	}
+}
+
+func TestFormatDiskBytes(t *testing.T) {
+	for bytes, expected := range map[int64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 5 << 30: "5.0 GiB"} {
+		if got := formatDiskBytes(bytes); got != expected {
+			t.Fatalf("formatDiskBytes(%d) = %q, expected %q", bytes, got, expected)
+		}
+	}
+}
+
+func writeCleanTestFile(t *testing.T, path string, size int, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, bytes.Repeat([]byte("x"), size), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+}
+
+// ageCleanTestTree backdates dir and everything in it.
+func ageCleanTestTree(t *testing.T, dir string, modTime time.Time) {
+	t.Helper()
+	err := filepath.WalkDir(dir, func(path string, _ os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(path, modTime, modTime)
+	})
+	if err != nil {
+		t.Fatalf("age %s: %v", dir, err)
+	}
+}
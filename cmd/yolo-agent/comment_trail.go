@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// commentTrailEventSink returns a sink that posts lifecycle comments on the
+// tracker when --comment-trail is set, and the func that flushes it. Dry
+// runs never touch the tracker.
+func commentTrailEventSink(cfg runConfig, tracker any, out io.Writer) (contracts.EventSink, func()) {
+	if !cfg.commentTrail || cfg.dryRun {
+		return nil, nil
+	}
+	commenter, ok := tracker.(contracts.TaskCommenter)
+	if !ok {
+		fmt.Fprintln(out, "warning: --comment-trail ignored: tracker does not support task comments")
+		return nil, nil
+	}
+	sink := contracts.NewTrackerCommentEventSink(commenter, out)
+	return sink, sink.Close
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+type commentTrailTestTracker struct{}
+
+func (commentTrailTestTracker) AddTaskComment(context.Context, string, string) error {
+	return nil
+}
+
+func TestRunMainParsesCommentTrailFlag(t *testing.T) {
+	var got runConfig
+	code := RunMain([]string{"--repo", t.TempDir(), "--root", "root", "--comment-trail"}, func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if !got.commentTrail {
+		t.Fatalf("expected comment trail to be enabled, got %#v", got)
+	}
+}
+
+func TestCommentTrailEventSinkRequiresFlagCommenterAndRealRun(t *testing.T) {
+	var out bytes.Buffer
+	if sink, _ := commentTrailEventSink(runConfig{}, commentTrailTestTracker{}, &out); sink != nil {
+		t.Fatalf("expected no sink without --comment-trail")
+	}
+	if sink, _ := commentTrailEventSink(runConfig{commentTrail: true, dryRun: true}, commentTrailTestTracker{}, &out); sink != nil {
+		t.Fatalf("expected no sink in dry run")
+	}
+	sink, closeFn := commentTrailEventSink(runConfig{commentTrail: true}, commentTrailTestTracker{}, &out)
+	if sink == nil || closeFn == nil {
+		t.Fatalf("expected sink for commenting tracker")
+	}
+	closeFn()
+	if sink, _ := commentTrailEventSink(runConfig{commentTrail: true}, struct{}{}, &out); sink != nil {
+		t.Fatalf("expected no sink for tracker without comments")
+	}
+	if !strings.Contains(out.String(), "does not support task comments") {
+		t.Fatalf("expected unsupported tracker warning, got %q", out.String())
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configVarPattern matches $${ (a literal "${"), ${VAR}, ${VAR:-default} and
+// ${VAR:?message}.
+var configVarPattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:-|:\?)([^}]*))?\}`)
+
+// unexpandedConfigFields are left as written: shell commands expand ${VAR}
+// themselves when they run, and prompt and commit message text is used as is.
+var unexpandedConfigFields = []string{
+	"agent.landing.pre_merge",
+	"agent.secret_scan.command",
+	"agent.dependency_policy.license_command",
+	"agent.stall_nudge_prompt",
+	"agent.commit_messages",
+}
+
+// expandConfigVars expands environment variable references in the scalar
+// values of the config file named file. It returns content unchanged when
+// nothing is expanded, so decode errors keep their line numbers.
+func expandConfigVars(content []byte, file string, getenv func(string) string) ([]byte, error) {
+	if !strings.Contains(string(content), "${") {
+		return content, nil
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		// Leave syntax errors to the strict decoder.
+		return content, nil
+	}
+	expanded, err := expandConfigNode(&document, "", file, getenv)
+	if err != nil || !expanded {
+		return content, err
+	}
+	return yaml.Marshal(&document)
+}
+
+func expandConfigNode(node *yaml.Node, path string, file string, getenv func(string) string) (bool, error) {
+	for _, field := range unexpandedConfigFields {
+		if path == field || strings.HasPrefix(path, field+".") || strings.HasPrefix(path, field+"[") {
+			return false, nil
+		}
+	}
+	expanded := false
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			changed, err := expandConfigNode(child, path, file, getenv)
+			if err != nil {
+				return false, err
+			}
+			expanded = expanded || changed
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			childPath := node.Content[i].Value
+			if path != "" {
+				childPath = path + "." + childPath
+			}
+			changed, err := expandConfigNode(node.Content[i+1], childPath, file, getenv)
+			if err != nil {
+				return false, err
+			}
+			expanded = expanded || changed
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			changed, err := expandConfigNode(child, path+"["+strconv.Itoa(i)+"]", file, getenv)
+			if err != nil {
+				return false, err
+			}
+			expanded = expanded || changed
+		}
+	case yaml.ScalarNode:
+		value, err := expandConfigValue(path, file, node.Value, getenv)
+		if err != nil {
+			return false, err
+		}
+		if value != node.Value {
+			node.Value = value
+			if node.Style == 0 {
+				// Let the expanded text resolve as if it had been written
+				// in place, so ${WORKERS} can set an int.
+				node.Tag = ""
+			}
+			expanded = true
+		}
+	}
+	return expanded, nil
+}
+
+// expandConfigValue expands ${VAR}, ${VAR:-default} (default when VAR is
+// unset or empty) and ${VAR:?message} (error when VAR is unset or empty) in
+// the value of field in file. $${ is a literal "${".
+func expandConfigValue(field string, file string, value string, getenv func(string) string) (string, error) {
+	var expandErr error
+	expanded := configVarPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if expandErr != nil {
+			return match
+		}
+		if match == "$${" {
+			return "${"
+		}
+		parts := configVarPattern.FindStringSubmatch(match)
+		name, operator, argument := parts[1], parts[2], parts[3]
+		if resolved := getenv(name); resolved != "" {
+			return resolved
+		}
+		switch operator {
+		case ":-":
+			return argument
+		case ":?":
+			expandErr = fmt.Errorf("%s in %s requires %s: %s", field, file, name, argument)
+		default:
+			expandErr = fmt.Errorf("%s in %s uses ${%s}, which is not set; export %s or give a default with ${%s:-value}", field, file, name, name, name)
+		}
+		return match
+	})
+	return expanded, expandErr
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandConfigValue(t *testing.T) {
+	getenv := func(name string) string {
+		return map[string]string{"MODEL": "gpt-5", "HOST": "ci.internal"}[name]
+	}
+	cases := map[string]string{
+		"${MODEL}":                       "gpt-5",
+		"https://${HOST}:8443/api":       "https://ci.internal:8443/api",
+		"${UNSET:-runner-logs}":          "runner-logs",
+		"${MODEL:-fallback}":             "gpt-5",
+		"literal $${MODEL} and ${MODEL}": "literal ${MODEL} and gpt-5",
+		"no references":                  "no references",
+	}
+	for value, want := range cases {
+		got, err := expandConfigValue("agent.model", trackerConfigRelPath, value, getenv)
+		if err != nil {
+			t.Fatalf("expandConfigValue(%q): %v", value, err)
+		}
+		if got != want {
+			t.Fatalf("expandConfigValue(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestExpandConfigValueReportsUnsetVariables(t *testing.T) {
+	getenv := func(string) string { return "" }
+	_, err := expandConfigValue("agent.model", trackerConfigRelPath, "${MODEL}", getenv)
+	if err == nil || err.Error() != "agent.model in .yolo-runner/config.yaml uses ${MODEL}, which is not set; export MODEL or give a default with ${MODEL:-value}" {
+		t.Fatalf("expected unset variable error, got %v", err)
+	}
+	_, err = expandConfigValue("agent.model", trackerConfigRelPath, "${MODEL:?pick the model for this runner}", getenv)
+	if err == nil || err.Error() != "agent.model in .yolo-runner/config.yaml requires MODEL: pick the model for this runner" {
+		t.Fatalf("expected required variable error, got %v", err)
+	}
+}
+
+func TestTrackerConfigServiceLoadModelExpandsEnvVars(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: github
+      github:
+        scope:
+          owner: ${GH_OWNER}
+          repo: "${GH_REPO:-yolo-runner}"
+        auth:
+          token_env: GITHUB_TOKEN
+agent:
+  model: ${MODEL}
+  concurrency: ${WORKERS}
+  landing:
+    pre_merge:
+      - test -n "${DB_URL}"
+`)
+	svc := newTrackerConfigService()
+	svc.getenv = func(name string) string {
+		return map[string]string{"GH_OWNER": "egv", "MODEL": "gpt-5", "WORKERS": "4"}[name]
+	}
+
+	model, err := svc.LoadModel(repoRoot)
+	if err != nil {
+		t.Fatalf("load model: %v", err)
+	}
+	github := model.Profiles["default"].Tracker.GitHub
+	if github == nil || github.Scope.Owner != "egv" || github.Scope.Repo != "yolo-runner" {
+		t.Fatalf("expected expanded github scope, got %#v", github)
+	}
+	if model.Agent.Model != "gpt-5" {
+		t.Fatalf("expected expanded model, got %q", model.Agent.Model)
+	}
+	if model.Agent.Concurrency == nil || *model.Agent.Concurrency != 4 {
+		t.Fatalf("expected expanded concurrency 4, got %v", model.Agent.Concurrency)
+	}
+	if got := model.Agent.Landing.PreMerge; len(got) != 1 || got[0] != `test -n "${DB_URL}"` {
+		t.Fatalf("expected pre-landing hooks left for the shell to expand, got %q", got)
+	}
+}
+
+func TestRunConfigValidateCommandReportsUnsetConfigVar(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  model: ${YOLO_TEST_UNSET_MODEL}
+`)
+
+	_, stderrText := captureOutput(t, func() {
+		if code := runConfigValidateCommand([]string{"--repo", repoRoot}); code != 1 {
+			t.Fatalf("expected exit code 1, got %d", code)
+		}
+	})
+
+	for _, want := range []string{
+		"field: agent.model",
+		"reason: uses ${YOLO_TEST_UNSET_MODEL}, which is not set",
+		"remediation: Export YOLO_TEST_UNSET_MODEL before running yolo-agent, or give it a default with ${YOLO_TEST_UNSET_MODEL:-value}",
+	} {
+		if !strings.Contains(stderrText, want) {
+			t.Fatalf("expected %q in output, got %q", want, stderrText)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+const (
+	configSchemaID      = "https://yolo-runner.github.io/schemas/config.schema.json"
+	configSchemaRelPath = "docs/config-schema.json"
+)
+
+// configVarSchema accepts a string with a ${VAR} reference where a number or
+// boolean is expected, since expandConfigVars resolves it at load time.
+var configVarSchema = map[string]any{"type": "string", "pattern": `\$\{`}
+
+func defaultRunConfigSchemaCommand(args []string) int {
+	fs := flag.NewFlagSet("yolo-agent config schema", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	output := fs.String("output", "", "Write the schema to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments for config schema: %s\n", strings.Join(fs.Args(), " "))
+		return 1
+	}
+	schema, err := configSchemaJSON()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if strings.TrimSpace(*output) == "" {
+		_, _ = os.Stdout.Write(schema)
+		return 0
+	}
+	if err := os.WriteFile(*output, schema, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot write config schema: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// configSchemaJSON renders the JSON Schema of .yolo-runner/config.yaml. It is
+// derived from trackerProfilesModel, the struct the strict decoder fills, so
+// it always accepts exactly the keys yolo-agent does.
+func configSchemaJSON() ([]byte, error) {
+	schema := configTypeSchema(reflect.TypeOf(trackerProfilesModel{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = configSchemaID
+	schema["title"] = "yolo-runner config (" + trackerConfigRelPath + ")"
+	content, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("cannot render config schema: %w", err)
+	}
+	return append(content, '\n'), nil
+}
+
+func configTypeSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := map[string]any{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			properties[name] = configTypeSchema(field.Type)
+		}
+		return map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": configTypeSchema(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": configTypeSchema(t.Elem())}
+	case reflect.Bool:
+		return map[string]any{"anyOf": []any{map[string]any{"type": "boolean"}, configVarSchema}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"anyOf": []any{map[string]any{"type": "integer"}, configVarSchema}}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"anyOf": []any{map[string]any{"type": "number"}, configVarSchema}}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
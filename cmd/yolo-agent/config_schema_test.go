@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+)
+
+func compileConfigSchema(t *testing.T) *jsonschema.Schema {
+	t.Helper()
+	schema, err := configSchemaJSON()
+	if err != nil {
+		t.Fatalf("render schema: %v", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(configSchemaID, bytes.NewReader(schema)); err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	compiled, err := compiler.Compile(configSchemaID)
+	if err != nil {
+		t.Fatalf("compile schema: %v", err)
+	}
+	return compiled
+}
+
+func validateConfigAgainstSchema(t *testing.T, schema *jsonschema.Schema, config string) error {
+	t.Helper()
+	var document any
+	if err := yaml.Unmarshal([]byte(config), &document); err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	// Round-trip through JSON so the validator sees JSON types.
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		t.Fatalf("encode config: %v", err)
+	}
+	var value any
+	if err := json.Unmarshal(encoded, &value); err != nil {
+		t.Fatalf("decode config: %v", err)
+	}
+	return schema.Validate(value)
+}
+
+func TestConfigSchemaMatchesShippedFile(t *testing.T) {
+	want, err := configSchemaJSON()
+	if err != nil {
+		t.Fatalf("render schema: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join("..", "..", filepath.FromSlash(configSchemaRelPath)))
+	if err != nil {
+		t.Fatalf("read %s: %v", configSchemaRelPath, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s is out of date; regenerate it with `go run ./cmd/yolo-agent config schema --output %s`", configSchemaRelPath, configSchemaRelPath)
+	}
+}
+
+func TestConfigSchemaAcceptsConfigsTheDecoderAccepts(t *testing.T) {
+	schema := compileConfigSchema(t)
+	config := `
+default_profile: github
+profiles:
+  github:
+    tracker:
+      type: github
+      github:
+        scope:
+          owner: egv
+          repo: yolo-runner
+        auth:
+          token_env: GITHUB_TOKEN
+    env:
+      DB_PASSWORD:
+        value_env: CI_DB_PASSWORD
+        labels: [db]
+agent:
+  backend: codex
+  concurrency: ${YOLO_WORKERS:-2}
+  skip_review: true
+  landing:
+    pre_merge:
+      - make generate && git diff --exit-code
+    batch_size: 4
+  path_scope:
+    labels:
+      docs: [docs/**]
+  blocked_retry:
+    rate_limit:
+      max_attempts: 3
+      backoff: 1m
+tui:
+  theme: dark
+`
+	if err := validateConfigAgainstSchema(t, schema, config); err != nil {
+		t.Fatalf("expected config to satisfy the schema: %v", err)
+	}
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, config)
+	if _, err := newTrackerConfigService().LoadModel(repoRoot); err != nil {
+		t.Fatalf("expected the decoder to accept the config too: %v", err)
+	}
+}
+
+func TestConfigSchemaRejectsUnknownKeysAndWrongTypes(t *testing.T) {
+	schema := compileConfigSchema(t)
+	for name, config := range map[string]string{
+		"unknown key":     "agent:\n  concurency: 2\n",
+		"wrong type":      "agent:\n  concurrency: two\n",
+		"unknown profile": "profiles:\n  default:\n    tracker:\n      type: tk\n      jira: {}\n",
+	} {
+		if err := validateConfigAgainstSchema(t, schema, config); err == nil {
+			t.Fatalf("%s: expected schema validation to fail", name)
+		}
+	}
+}
+
+func TestRunMainRoutesConfigSchemaSubcommand(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "config-schema.json")
+	if code := RunMain([]string{"config", "schema", "--output", output}, nil); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	if !strings.Contains(string(content), `"$id": "`+configSchemaID+`"`) {
+		t.Fatalf("expected schema output, got %q", content)
+	}
+}
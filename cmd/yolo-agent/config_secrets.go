@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/secrets"
+)
+
+// configSecrets resolves the secret references config values may use in
+// place of an env var name. It is shared so each reference is read once per
+// process.
+var configSecrets = secrets.NewResolver()
+
+// lookupConfigSecret returns the value a token_env or value_env setting
+// names: an env var read with getenv, or a vault:, aws-sm: or op: secret
+// reference.
+func lookupConfigSecret(getenv func(string) string, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if secrets.IsReference(name) {
+		return configSecrets.Resolve(context.Background(), name)
+	}
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	return getenv(name), nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/linear"
+	"github.com/egv/yolo-runner/v2/internal/secrets"
+)
+
+func stubConfigSecrets(t *testing.T, run secrets.CommandFunc) {
+	t.Helper()
+	original := configSecrets
+	configSecrets = secrets.NewResolverWithCommand(run)
+	t.Cleanup(func() { configSecrets = original })
+}
+
+func TestLinearTokenEnvResolvesVaultReferenceOnce(t *testing.T) {
+	calls := 0
+	stubConfigSecrets(t, func(_ context.Context, name string, args ...string) ([]byte, error) {
+		calls++
+		if name != "vault" || strings.Join(args, " ") != "kv get -field=linear_token secret/yolo" {
+			t.Fatalf("unexpected provider call %s %v", name, args)
+		}
+		return []byte("lin_api_vault\n"), nil
+	})
+	originalFactory := newLinearTaskManager
+	t.Cleanup(func() { newLinearTaskManager = originalFactory })
+	var got linear.Config
+	newLinearTaskManager = func(cfg linear.Config) (contracts.TaskManager, error) {
+		got = cfg
+		return staticTaskManager{}, nil
+	}
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: linear
+      linear:
+        scope:
+          workspace: anomaly
+        auth:
+          token_env: vault:secret/yolo#linear_token
+`)
+
+	profile, err := resolveTrackerProfile(repoRoot, "", "root-1", func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("expected vault reference to satisfy token validation, got %v", err)
+	}
+	if _, err := buildTaskManagerForTracker(repoRoot, profile); err != nil {
+		t.Fatalf("build task manager: %v", err)
+	}
+	if got.Token != "lin_api_vault" {
+		t.Fatalf("expected token from vault, got %q", got.Token)
+	}
+	if calls != 1 {
+		t.Fatalf("expected the reference to be resolved once, got %d calls", calls)
+	}
+}
+
+func TestResolveTrackerProfileReportsUnresolvableTokenReference(t *testing.T) {
+	stubConfigSecrets(t, func(context.Context, string, ...string) ([]byte, error) {
+		return nil, errors.New("exit status 1: not signed in")
+	})
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: linear
+      linear:
+        scope:
+          workspace: anomaly
+        auth:
+          token_env: op://Shared/Linear/api-token
+`)
+
+	_, err := resolveTrackerProfile(repoRoot, "", "root-1", func(string) string { return "" })
+	if err == nil || !strings.Contains(err.Error(), linearTokenEnvVarLabel) || !strings.Contains(err.Error(), "op failed: exit status 1: not signed in") {
+		t.Fatalf("expected unresolvable reference error, got %v", err)
+	}
+}
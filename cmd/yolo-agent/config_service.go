@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,11 +13,14 @@ import (
 
 type trackerConfigService struct {
 	readFile func(string) ([]byte, error)
+	// getenv expands ${VAR} references in config values.
+	getenv func(string) string
 }
 
 func newTrackerConfigService() trackerConfigService {
 	return trackerConfigService{
 		readFile: os.ReadFile,
+		getenv:   os.Getenv,
 	}
 }
 
@@ -59,26 +64,47 @@ func (s trackerConfigService) ResolveTrackerProfile(repoRoot string, selectedPro
 	if err != nil {
 		return resolvedTrackerProfile{}, err
 	}
+	env, err := resolveProfileEnv(profileName, profile.Env, repoRoot, getenv, s.readFile)
+	if err != nil {
+		return resolvedTrackerProfile{}, err
+	}
+	tools, err := resolveProfileTools(profileName, profile.Tools)
+	if err != nil {
+		return resolvedTrackerProfile{}, err
+	}
+	pipeline, err := resolveProfilePipeline(profileName, profile.Pipeline)
+	if err != nil {
+		return resolvedTrackerProfile{}, err
+	}
 	return resolvedTrackerProfile{
-		Name:    profileName,
-		Tracker: validated,
+		Name:     profileName,
+		Tracker:  validated,
+		Env:      env,
+		Tools:    tools,
+		Pipeline: pipeline,
 	}, nil
 }
 
+// loadModelFromPath loads the repo config at path on top of the user config,
+// so settings the repo leaves out fall back to the user's. Nested settings
+// merge key by key, while lists and profiles of the same name are replaced
+// as a whole.
 func (s trackerConfigService) loadModelFromPath(path string) (trackerProfilesModel, error) {
-	content, err := s.readFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return defaultTrackerProfilesModel(), nil
-		}
-		return trackerProfilesModel{}, fmt.Errorf("cannot read config file at %s: %w", trackerConfigRelPath, err)
+	getenv := s.getenv
+	if getenv == nil {
+		getenv = os.Getenv
 	}
-
 	var model trackerProfilesModel
-	decoder := yaml.NewDecoder(strings.NewReader(string(content)))
-	decoder.KnownFields(true)
-	if err := decoder.Decode(&model); err != nil {
-		return trackerProfilesModel{}, fmt.Errorf("cannot parse config file at %s: %w", trackerConfigRelPath, err)
+	userLoaded, err := s.decodeConfigFile(&model, userConfigPath(getenv), getenv)
+	if err != nil {
+		return trackerProfilesModel{}, err
+	}
+	repoLoaded, err := s.decodeConfigFile(&model, path, getenv)
+	if err != nil {
+		return trackerProfilesModel{}, err
+	}
+	if !userLoaded && !repoLoaded {
+		return defaultTrackerProfilesModel(), nil
 	}
 
 	if len(model.Profiles) == 0 && strings.TrimSpace(model.Tracker.Type) != "" {
@@ -86,9 +112,65 @@ func (s trackerConfigService) loadModelFromPath(path string) (trackerProfilesMod
 			defaultProfileName: {Tracker: model.Tracker},
 		}
 	}
+	if len(model.Profiles) == 0 && !repoLoaded {
+		defaults := defaultTrackerProfilesModel()
+		model.Profiles = defaults.Profiles
+		if strings.TrimSpace(model.DefaultProfile) == "" {
+			model.DefaultProfile = defaults.DefaultProfile
+		}
+	}
 
 	if len(model.Profiles) == 0 {
 		return trackerProfilesModel{}, fmt.Errorf("config file at %s must define at least one profile", trackerConfigRelPath)
 	}
 	return model, nil
 }
+
+// decodeConfigFile decodes the config file at path into model, over any
+// values already there. It reports false when path is empty or missing.
+func (s trackerConfigService) decodeConfigFile(model *trackerProfilesModel, path string, getenv func(string) string) (bool, error) {
+	if path == "" {
+		return false, nil
+	}
+	label := configFileLabel(path)
+	content, err := s.readFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("cannot read config file at %s: %w", label, err)
+	}
+	content, err = expandConfigVars(content, label, getenv)
+	if err != nil {
+		return false, err
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(string(content)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(model); err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("cannot parse config file at %s: %w", label, err)
+	}
+	return true, nil
+}
+
+// userConfigPath returns the user config, $XDG_CONFIG_HOME/yolo-runner/config.yaml
+// or ~/.config/yolo-runner/config.yaml, or "" when neither can be located.
+func userConfigPath(getenv func(string) string) string {
+	if dir := strings.TrimSpace(getenv("XDG_CONFIG_HOME")); filepath.IsAbs(dir) {
+		return filepath.Join(dir, userConfigRelPath)
+	}
+	home := strings.TrimSpace(getenv("HOME"))
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config", userConfigRelPath)
+}
+
+// configFileLabel names a config file in errors: the repo config by its
+// relative path, anything else by its full path.
+func configFileLabel(path string) string {
+	if filepath.ToSlash(path) == trackerConfigRelPath || strings.HasSuffix(filepath.ToSlash(path), "/"+trackerConfigRelPath) {
+		return trackerConfigRelPath
+	}
+	return path
+}
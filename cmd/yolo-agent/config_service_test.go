@@ -1,8 +1,15 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
 )
 
 func TestTrackerConfigServiceLoadModelDefaultsWhenConfigMissing(t *testing.T) {
@@ -150,3 +157,276 @@ profiles:
 		t.Fatalf("expected auth token guidance, got %q", err.Error())
 	}
 }
+
+func TestTrackerConfigServiceLoadModelAcceptsTUISection(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+tui:
+  theme: light
+  color_profile: "256"
+  notifications:
+    run_finished: true
+`)
+
+	svc := newTrackerConfigService()
+	model, err := svc.LoadModel(repoRoot)
+	if err != nil {
+		t.Fatalf("expected tui section to be accepted, got %v", err)
+	}
+	if model.TUI.Theme != "light" || !model.TUI.Notifications.RunFinished {
+		t.Fatalf("expected tui.theme to be decoded, got %#v", model.TUI)
+	}
+}
+
+func TestTrackerConfigServiceResolveTrackerProfileReadsEnv(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+    env:
+      APP_ENV:
+        value: test
+      DB_PASSWORD:
+        value_env: CI_DB_PASSWORD
+        labels: [db]
+      DB_CERT:
+        value_file: .secrets/db.pem
+`)
+	if err := os.MkdirAll(filepath.Join(repoRoot, ".secrets"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoRoot, ".secrets", "db.pem"), []byte("cert-body\n"), 0o600); err != nil {
+		t.Fatalf("write secret file: %v", err)
+	}
+
+	svc := newTrackerConfigService()
+	profile, err := svc.ResolveTrackerProfile(repoRoot, "", "root-1", func(name string) string {
+		if name == "CI_DB_PASSWORD" {
+			return "hunter2"
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatalf("resolve profile: %v", err)
+	}
+	want := []agent.TaskEnvVar{
+		{Name: "APP_ENV", Value: "test"},
+		{Name: "DB_CERT", Value: "cert-body", Secret: true},
+		{Name: "DB_PASSWORD", Value: "hunter2", Secret: true, Labels: []string{"db"}},
+	}
+	if !reflect.DeepEqual(profile.Env, want) {
+		t.Fatalf("expected env %#v, got %#v", want, profile.Env)
+	}
+}
+
+func TestTrackerConfigServiceResolveTrackerProfileRejectsUnsetEnvSecret(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+    env:
+      DB_PASSWORD:
+        value_env: CI_DB_PASSWORD
+`)
+
+	svc := newTrackerConfigService()
+	_, err := svc.ResolveTrackerProfile(repoRoot, "", "root-1", func(string) string { return "" })
+	if err == nil || !strings.Contains(err.Error(), `profile.env entry "DB_PASSWORD" in profile "default" reads CI_DB_PASSWORD, which is not set`) {
+		t.Fatalf("expected unset secret source error, got %v", err)
+	}
+}
+
+func TestTrackerConfigServiceResolveTrackerProfileReadsTools(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+    tools:
+      allow: ["go  test", git, make]
+      deny: [docker, curl, git push]
+`)
+
+	svc := newTrackerConfigService()
+	profile, err := svc.ResolveTrackerProfile(repoRoot, "", "root-1", func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("resolve profile: %v", err)
+	}
+	want := contracts.ToolPolicy{Allow: []string{"go test", "git", "make"}, Deny: []string{"docker", "curl", "git push"}}
+	if !reflect.DeepEqual(profile.Tools, want) {
+		t.Fatalf("expected tools %#v, got %#v", want, profile.Tools)
+	}
+}
+
+func TestTrackerConfigServiceResolveTrackerProfileRejectsShellSyntaxInTools(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+    tools:
+      deny: ["curl | sh"]
+`)
+
+	svc := newTrackerConfigService()
+	_, err := svc.ResolveTrackerProfile(repoRoot, "", "root-1", func(string) string { return "" })
+	if err == nil || !strings.Contains(err.Error(), `profile.tools.deny[0] in profile "default" must be a command and its leading arguments`) {
+		t.Fatalf("expected shell syntax error, got %v", err)
+	}
+}
+
+func TestTrackerConfigServiceResolveTrackerProfileReadsPipeline(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+    pipeline:
+      stages:
+        - {name: implement, type: agent}
+        - {name: changelog, type: agent, prompt: Add a CHANGELOG.md entry.}
+        - {name: review, type: agent}
+        - {name: security-scan, type: gate, command: make scan, timeout: 5m}
+        - {name: land, type: land}
+`)
+
+	svc := newTrackerConfigService()
+	profile, err := svc.ResolveTrackerProfile(repoRoot, "", "root-1", func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("resolve profile: %v", err)
+	}
+	stages := profile.Pipeline.Stages
+	if len(stages) != 5 || stages[1].Prompt != "Add a CHANGELOG.md entry." || stages[3].Type != agent.PipelineStageGate || stages[3].Timeout != 5*time.Minute {
+		t.Fatalf("unexpected pipeline %#v", stages)
+	}
+}
+
+func TestTrackerConfigServiceResolveTrackerProfileRejectsInvalidPipeline(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+    pipeline:
+      stages:
+        - {name: implement, type: agent}
+        - {name: land, type: land}
+        - {name: docs, type: command, command: make docs}
+`)
+
+	svc := newTrackerConfigService()
+	_, err := svc.ResolveTrackerProfile(repoRoot, "", "root-1", func(string) string { return "" })
+	if err == nil || !strings.Contains(err.Error(), `profile.pipeline in profile "default" is invalid: land stage "land" must be the last stage`) {
+		t.Fatalf("expected land ordering error, got %v", err)
+	}
+}
+
+func TestTrackerConfigServiceLoadModelMergesUserConfigBeneathRepoConfig(t *testing.T) {
+	configHome := t.TempDir()
+	writeUserConfigYAML(t, configHome, `
+agent:
+  backend: claude
+  model: claude-sonnet
+  concurrency: 3
+tui:
+  notifications:
+    run_finished: true
+    task_blocked: true
+`)
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  model: claude-opus
+tui:
+  notifications:
+    task_blocked: false
+`)
+	svc := newTrackerConfigService()
+	svc.getenv = func(name string) string {
+		return map[string]string{"XDG_CONFIG_HOME": configHome}[name]
+	}
+
+	model, err := svc.LoadModel(repoRoot)
+	if err != nil {
+		t.Fatalf("load model: %v", err)
+	}
+	if model.Agent.Backend != "claude" || model.Agent.Model != "claude-opus" {
+		t.Fatalf("expected backend from user config and model from repo config, got %q/%q", model.Agent.Backend, model.Agent.Model)
+	}
+	if model.Agent.Concurrency == nil || *model.Agent.Concurrency != 3 {
+		t.Fatalf("expected concurrency 3 from user config, got %v", model.Agent.Concurrency)
+	}
+	if !model.TUI.Notifications.RunFinished || model.TUI.Notifications.TaskBlocked {
+		t.Fatalf("expected repo config to override task_blocked only, got %#v", model.TUI.Notifications)
+	}
+}
+
+func TestTrackerConfigServiceLoadModelUsesUserConfigWithoutRepoConfig(t *testing.T) {
+	home := t.TempDir()
+	writeUserConfigYAML(t, filepath.Join(home, ".config"), `
+agent:
+  backend: codex
+`)
+	svc := newTrackerConfigService()
+	svc.getenv = func(name string) string {
+		return map[string]string{"HOME": home}[name]
+	}
+
+	model, err := svc.LoadModel(t.TempDir())
+	if err != nil {
+		t.Fatalf("load model: %v", err)
+	}
+	if model.Agent.Backend != "codex" {
+		t.Fatalf("expected backend from user config, got %q", model.Agent.Backend)
+	}
+	if model.DefaultProfile != defaultProfileName || model.Profiles[defaultProfileName].Tracker.Type != trackerTypeTK {
+		t.Fatalf("expected the default tk profile, got %q %#v", model.DefaultProfile, model.Profiles)
+	}
+}
+
+func TestTrackerConfigServiceLoadModelNamesUserConfigInErrors(t *testing.T) {
+	configHome := t.TempDir()
+	writeUserConfigYAML(t, configHome, `
+agent:
+  backnd: codex
+`)
+	svc := newTrackerConfigService()
+	svc.getenv = func(name string) string {
+		return map[string]string{"XDG_CONFIG_HOME": configHome}[name]
+	}
+
+	_, err := svc.LoadModel(t.TempDir())
+	if err == nil {
+		t.Fatalf("expected unknown field in user config to fail")
+	}
+	if want := filepath.Join(configHome, userConfigRelPath); !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected error to name %s, got %q", want, err.Error())
+	}
+}
+
+func writeUserConfigYAML(t *testing.T, configHome string, payload string) {
+	t.Helper()
+	configPath := filepath.Join(configHome, userConfigRelPath)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		t.Fatalf("mkdir user config dir: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte(strings.TrimSpace(payload)+"\n"), 0o644); err != nil {
+		t.Fatalf("write user config: %v", err)
+	}
+}
@@ -13,6 +13,9 @@ const configValidateSchemaVersion = "v1"
 
 var configFieldPattern = regexp.MustCompile(`[a-z][a-z0-9_]*(?:\.[a-z][a-z0-9_]*)+`)
 
+// unsetConfigVarPattern matches the errors of expandConfigValue.
+var unsetConfigVarPattern = regexp.MustCompile(`uses \$\{([A-Za-z_][A-Za-z0-9_]*)\}, which is not set|requires ([A-Za-z_][A-Za-z0-9_]*): `)
+
 type configValidateOutputFormat string
 
 const (
@@ -68,6 +71,9 @@ func defaultRunConfigValidateCommand(args []string) int {
 	if _, err := resolveYoloAgentConfigDefaults(model.Agent, catalog); err != nil {
 		return reportInvalidConfig(err, format)
 	}
+	if _, err := loadAgentPromptTemplates(*repo, model.Agent.Prompts); err != nil {
+		return reportInvalidConfig(err, format)
+	}
 
 	profileName := resolveProfileSelectionPolicy(profileSelectionInput{
 		FlagValue: *profile,
@@ -97,6 +103,12 @@ func defaultRunConfigValidateCommand(args []string) int {
 	if _, err := validateTrackerModel(profileName, profileDef.Tracker, rootID, os.Getenv); err != nil {
 		return reportInvalidConfig(err, format)
 	}
+	if _, err := resolveProfileTools(profileName, profileDef.Tools); err != nil {
+		return reportInvalidConfig(err, format)
+	}
+	if _, err := resolveProfilePipeline(profileName, profileDef.Pipeline); err != nil {
+		return reportInvalidConfig(err, format)
+	}
 
 	if format == configValidateOutputFormatJSON {
 		emitConfigValidateJSON(configValidateResultPayload{
@@ -166,12 +178,72 @@ func inferConfigField(message string) string {
 		"agent.runner_timeout",
 		"agent.watchdog_timeout",
 		"agent.watchdog_interval",
+		"agent.watchdog_hooks",
+		"agent.watchdog_hook_timeout",
+		"agent.stall_policies",
+		"agent.rate_limit_backoff",
+		"agent.epic_progress_interval",
+		"agent.task_discovery_interval",
+		"agent.status_reconcile_interval",
+		"agent.shutdown_grace_period",
+		"agent.event_sinks",
+		"agent.redaction",
+		"agent.event_log",
+		"agent.control_api",
+		"agent.affinity",
+		"agent.executors",
+		"agent.executor",
+		"agent.tracker_cache_ttl",
+		"agent.fallback_chain",
+		"agent.backend_capabilities",
+		"agent.prompts",
+		"agent.commit_messages",
+		"agent.vcs",
+		"agent.landing.strategy",
+		"agent.landing.pre_merge",
+		"agent.landing.hook_timeout",
+		"agent.landing.batch_size",
+		"agent.landing.push_retries",
+		"agent.path_scope",
+		"agent.diff_guardrails.max_changed_files",
+		"agent.diff_guardrails.max_changed_lines",
+		"agent.diff_guardrails.forbidden_paths",
+		"agent.diff_guardrails.on_violation",
+		"agent.secret_scan.timeout",
+		"agent.dependency_policy.license_command",
+		"agent.dependency_policy.timeout",
+		"agent.dependency_policy",
+		"agent.repo_context.recent_commits",
+		"agent.repo_context.tree_depth",
+		"agent.repo_context.max_bytes",
+		"agent.clone_pool.size",
+		"agent.clone_pool.refresh",
+		"agent.clone_pool.max_uses",
+		"agent.clone_strategy",
+		"agent.orphaned_tasks",
+		"agent.review_mode",
+		"agent.review_concurrency",
+		"agent.retention.runner_logs",
+		"agent.retention.clones",
+		"agent.retention.artifacts",
+		"agent.retention.min_age",
+		"agent.escalation.after",
+		"agent.escalation.webhook_url",
+		"agent.blocked_retry",
+		profileEnvFieldLabel,
+		profileToolsFieldLabel,
+		profilePipelineFieldLabel,
 		"tracker.type",
 		"linear.scope.workspace",
 		linearTokenEnvVarLabel,
 		"github.scope.owner",
 		"github.scope.repo",
 		githubTokenEnvVarLabel,
+		"azure_devops.scope.organization",
+		"azure_devops.scope.project",
+		azureDevOpsTokenEnvVarLabel,
+		"notion.scope.database_id",
+		notionTokenEnvVarLabel,
 	}
 	for _, field := range knownFields {
 		if strings.Contains(message, field) {
@@ -185,6 +257,12 @@ func inferConfigField(message string) string {
 		if strings.Contains(message, "<github-personal-access-token>") {
 			return githubTokenEnvVarLabel
 		}
+		if strings.Contains(message, "<azure-devops-personal-access-token>") {
+			return azureDevOpsTokenEnvVarLabel
+		}
+		if strings.Contains(message, "<notion-integration-token>") {
+			return notionTokenEnvVarLabel
+		}
 		return "auth.token_env"
 	}
 	if strings.Contains(message, "tracker profile") && strings.Contains(message, "not found") {
@@ -239,6 +317,10 @@ func inferConfigReason(message string, field string) string {
 }
 
 func inferConfigRemediation(field string, message string) string {
+	if match := unsetConfigVarPattern.FindStringSubmatch(message); match != nil {
+		name := match[1] + match[2]
+		return fmt.Sprintf("Export %s before running yolo-agent, or give it a default with ${%s:-value} in .yolo-runner/config.yaml.", name, name)
+	}
 	switch field {
 	case "agent.backend":
 		return "Set agent.backend to a configured coding backend in .yolo-runner/config.yaml."
@@ -252,8 +334,104 @@ func inferConfigRemediation(field string, message string) string {
 		return "Set agent.watchdog_timeout to a valid duration greater than 0 in .yolo-runner/config.yaml."
 	case "agent.watchdog_interval":
 		return "Set agent.watchdog_interval to a valid duration greater than 0 in .yolo-runner/config.yaml."
+	case "agent.watchdog_hooks":
+		return "Remove empty entries from agent.watchdog_hooks in .yolo-runner/config.yaml."
+	case "agent.watchdog_hook_timeout":
+		return "Set agent.watchdog_hook_timeout to a valid duration greater than 0 in .yolo-runner/config.yaml."
+	case "agent.fallback_chain":
+		return "Give every agent.fallback_chain entry a model, and a backend from the coding agents catalog when it switches backends, in .yolo-runner/config.yaml."
+	case "agent.backend_capabilities":
+		return "Key agent.backend_capabilities by backends from the coding agents catalog and set review, stream, session_resume or acp to true or false in .yolo-runner/config.yaml."
+	case "agent.tracker_cache_ttl":
+		return "Set agent.tracker_cache_ttl to a valid duration greater than or equal to 0 (0 disables the tracker cache) in .yolo-runner/config.yaml."
+	case "agent.rate_limit_backoff":
+		return "Set agent.rate_limit_backoff to a valid duration greater than or equal to 0 (0 disables the backoff) in .yolo-runner/config.yaml."
+	case "agent.epic_progress_interval":
+		return "Set agent.epic_progress_interval to a valid duration greater than or equal to 0 (0 disables epic progress reports) in .yolo-runner/config.yaml."
+	case "agent.task_discovery_interval":
+		return "Set agent.task_discovery_interval to a valid duration greater than or equal to 0 (0 disables task discovery) in .yolo-runner/config.yaml."
+	case "agent.status_reconcile_interval":
+		return "Set agent.status_reconcile_interval to a valid duration greater than or equal to 0 (0 checks only when a runner finishes) in .yolo-runner/config.yaml."
+	case "agent.shutdown_grace_period":
+		return "Set agent.shutdown_grace_period to a valid duration greater than or equal to 0 (0 waits for in-flight tasks to finish) in .yolo-runner/config.yaml."
+	case "agent.event_sinks":
+		return "Key agent.event_sinks by file or stream, list event types under include/exclude, and map sample event types to a percentage between 0 and 100 in .yolo-runner/config.yaml."
+	case "agent.event_log":
+		return "Set agent.event_log max_size_mb and keep to integers and max_age and retain_for to durations, all greater than or equal to 0, in .yolo-runner/config.yaml."
+	case "agent.executor":
+		return "Set agent.executor.type to local, kubernetes or ssh; for kubernetes, give agent.executor.kubernetes an image, a workspace.claim, an absolute workspace.mount_path and a positive start_timeout; for ssh, give agent.executor.ssh a host and an absolute remote_dir, in .yolo-runner/config.yaml."
+	case "agent.executors":
+		return "Give each agent.executors entry a type of local, kubernetes or ssh with the settings agent.executor needs for it, and a max_tasks greater than 0 if set, in .yolo-runner/config.yaml."
+	case "agent.affinity":
+		return "Give each agent.affinity rule at least one label and an executor naming an agent.executors entry in .yolo-runner/config.yaml."
+	case "agent.control_api":
+		return "Give each agent.control_api token a unique name, a role of viewer, operator or approver, and a token_env; give agent.control_api.oidc an http(s) issuer, an audience and roles mapping viewer, operator or approver to claim values; give agent.control_api.slack roles mapping them to Slack user IDs, in .yolo-runner/config.yaml."
+	case "agent.stall_policies":
+		return "Map agent.stall_policies categories (question, waiting_on_tool, rate_limit, silence) to block, retry, nudge or extend_timeout in .yolo-runner/config.yaml."
+	case "agent.prompts":
+		return "Point agent.prompts entries at readable Go text/template files (or fix .yolo-runner/prompts/*.tmpl) so they parse."
+	case "agent.commit_messages":
+		return "Fix the agent.commit_messages templates so they parse as Go text/template and only use .Task, .Branch, .TrackerURL, .ReviewVerdict, .Backend, .Model, .CoAuthoredBy and .Default."
+	case "agent.vcs":
+		return "Set agent.vcs to git or none in .yolo-runner/config.yaml."
+	case "agent.landing.strategy":
+		return "Set agent.landing.strategy to merge, rebase_ff or squash in .yolo-runner/config.yaml."
+	case "agent.landing.pre_merge":
+		return "Remove empty entries from agent.landing.pre_merge in .yolo-runner/config.yaml."
+	case "agent.landing.hook_timeout":
+		return "Set agent.landing.hook_timeout to a positive Go duration (for example 10m) in .yolo-runner/config.yaml."
+	case "agent.landing.batch_size":
+		return "Set agent.landing.batch_size to an integer greater than 0 in .yolo-runner/config.yaml."
+	case "agent.landing.push_retries":
+		return "Set agent.landing.push_retries to an integer greater than or equal to 0 in .yolo-runner/config.yaml."
+	case "agent.diff_guardrails.max_changed_files", "agent.diff_guardrails.max_changed_lines":
+		return "Set " + field + " to an integer greater than 0, or remove it to disable the limit, in .yolo-runner/config.yaml."
+	case "agent.diff_guardrails.forbidden_paths":
+		return "Remove empty entries from agent.diff_guardrails.forbidden_paths in .yolo-runner/config.yaml."
+	case "agent.diff_guardrails.on_violation":
+		return "Set agent.diff_guardrails.on_violation to block or approve in .yolo-runner/config.yaml."
+	case "agent.secret_scan.timeout":
+		return "Set agent.secret_scan.timeout to a positive Go duration (for example 2m) in .yolo-runner/config.yaml."
+	case "agent.dependency_policy.license_command":
+		return "Set agent.dependency_policy.license_command to a command that prints one \"<dependency> <license>\" line per dependency, or remove agent.dependency_policy.licenses, in .yolo-runner/config.yaml."
+	case "agent.dependency_policy.timeout":
+		return "Set agent.dependency_policy.timeout to a positive Go duration (for example 5m) in .yolo-runner/config.yaml."
+	case "agent.dependency_policy":
+		return "Remove empty entries from the agent.dependency_policy lists in .yolo-runner/config.yaml."
+	case "agent.path_scope":
+		return "Map each agent.path_scope.labels entry to a non-empty list of path globs in .yolo-runner/config.yaml."
+	case "agent.repo_context.recent_commits":
+		return "Set agent.repo_context.recent_commits to an integer greater than or equal to 0 in .yolo-runner/config.yaml."
+	case "agent.repo_context.tree_depth":
+		return "Set agent.repo_context.tree_depth to an integer greater than 0 in .yolo-runner/config.yaml."
+	case "agent.repo_context.max_bytes":
+		return "Set agent.repo_context.max_bytes to an integer greater than 0 in .yolo-runner/config.yaml."
+	case "agent.clone_pool.size":
+		return "Set agent.clone_pool.size to an integer greater than or equal to 0 in .yolo-runner/config.yaml."
+	case "agent.clone_pool.refresh":
+		return "Set agent.clone_pool.refresh to reset or recreate in .yolo-runner/config.yaml."
+	case "agent.clone_pool.max_uses":
+		return "Set agent.clone_pool.max_uses to an integer greater than or equal to 0 in .yolo-runner/config.yaml."
+	case "agent.clone_strategy":
+		return "Set agent.clone_strategy to clone or overlay in .yolo-runner/config.yaml."
+	case "agent.orphaned_tasks":
+		return "Set agent.orphaned_tasks to reopen or block in .yolo-runner/config.yaml."
+	case "agent.review_mode":
+		return "Set agent.review_mode to single or two_phase in .yolo-runner/config.yaml."
+	case "agent.review_concurrency":
+		return "Set agent.review_concurrency to an integer greater than or equal to 0 in .yolo-runner/config.yaml."
+	case "agent.retention.runner_logs", "agent.retention.clones", "agent.retention.artifacts":
+		return "Set max_size_mb to an integer greater than or equal to 0 and max_age to a duration like 168h under agent.retention in .yolo-runner/config.yaml."
+	case "agent.retention.min_age":
+		return "Set agent.retention.min_age to a duration like 1h in .yolo-runner/config.yaml."
+	case "agent.escalation.after":
+		return "Set agent.escalation.after to a duration like 30m in .yolo-runner/config.yaml."
+	case "agent.escalation.webhook_url":
+		return "Set agent.escalation.webhook_url to an http or https URL in .yolo-runner/config.yaml."
+	case "agent.blocked_retry":
+		return "Key agent.blocked_retry by a stall or triage category (e.g. rate_limit, lock_contention, merge_queue_conflict) with max_attempts of at least 0 and backoff durations like 1m in .yolo-runner/config.yaml."
 	case "tracker.type":
-		return "Set tracker.type to a supported tracker (tk, linear, github) in .yolo-runner/config.yaml."
+		return "Set tracker.type to a supported tracker (tk, beads, linear, github, azure_devops, notion) in .yolo-runner/config.yaml."
 	case "linear.scope.workspace":
 		return "Set linear.scope.workspace to exactly one workspace slug in .yolo-runner/config.yaml."
 	case linearTokenEnvVarLabel:
@@ -264,6 +442,22 @@ func inferConfigRemediation(field string, message string) string {
 		return "Set github.scope.repo to a single repository name (without owner) in .yolo-runner/config.yaml."
 	case githubTokenEnvVarLabel:
 		return "Set github.auth.token_env to an env var name and export that variable with your GitHub personal access token."
+	case "azure_devops.scope.organization":
+		return "Set azure_devops.scope.organization to a single Azure DevOps organization name in .yolo-runner/config.yaml."
+	case "azure_devops.scope.project":
+		return "Set azure_devops.scope.project to a single Azure DevOps project name in .yolo-runner/config.yaml."
+	case azureDevOpsTokenEnvVarLabel:
+		return "Set azure_devops.auth.token_env to an env var name and export that variable with an Azure DevOps personal access token (Work Items read & write)."
+	case "notion.scope.database_id":
+		return "Set notion.scope.database_id to the ID or URL of a single Notion database in .yolo-runner/config.yaml."
+	case notionTokenEnvVarLabel:
+		return "Set notion.auth.token_env to an env var name and export that variable with a Notion integration token that has the database shared with it."
+	case profileEnvFieldLabel:
+		return "Give each profile env entry exactly one of value, value_env or value_file, and export the variables and create the files it reads."
+	case profileToolsFieldLabel:
+		return "List each profile tools.allow or tools.deny entry as a command name with optional leading arguments, e.g. docker or go test."
+	case profilePipelineFieldLabel:
+		return "Start the profile pipeline with the implement stage, give every stage a unique name and a type of agent, command, gate, approval or land, and put land last."
 	case "default_profile":
 		return "Set default_profile to an existing entry under profiles, or pass --profile with a valid profile name."
 	case "config.file":
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -78,6 +79,65 @@ agent:
 	}
 }
 
+func TestRunConfigValidateCommandRejectsUnparseablePromptTemplate(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  prompts:
+    implement: prompts/house.tmpl
+`)
+	if err := os.MkdirAll(filepath.Join(repoRoot, "prompts"), 0o755); err != nil {
+		t.Fatalf("mkdir prompts: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoRoot, "prompts", "house.tmpl"), []byte("{{.Task.ID"), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+
+	_, stderrText := captureOutput(t, func() {
+		code := runConfigValidateCommand([]string{"--repo", repoRoot})
+		if code != 1 {
+			t.Fatalf("expected exit code 1, got %d", code)
+		}
+	})
+
+	if !strings.Contains(stderrText, "field: agent.prompts") {
+		t.Fatalf("expected prompts field in output, got %q", stderrText)
+	}
+	if !strings.Contains(stderrText, "cannot parse implement prompt template") {
+		t.Fatalf("expected parse reason in output, got %q", stderrText)
+	}
+}
+
+func TestRunConfigValidateCommandRejectsInvalidToolPattern(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+    tools:
+      deny: [/usr/bin/docker]
+`)
+
+	_, stderrText := captureOutput(t, func() {
+		code := runConfigValidateCommand([]string{"--repo", repoRoot})
+		if code != 1 {
+			t.Fatalf("expected exit code 1, got %d", code)
+		}
+	})
+
+	if !strings.Contains(stderrText, "field: profile.tools") {
+		t.Fatalf("expected tools field in output, got %q", stderrText)
+	}
+	if !strings.Contains(stderrText, "must start with a command name") {
+		t.Fatalf("expected command name reason in output, got %q", stderrText)
+	}
+}
+
 func TestRunConfigValidateCommandInvalidConfigJSONOutputIsMachineReadable(t *testing.T) {
 	repoRoot := t.TempDir()
 	writeTrackerConfigYAML(t, repoRoot, `
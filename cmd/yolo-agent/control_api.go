@@ -0,0 +1,562 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/acp"
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	defaultServeAddr        = "127.0.0.1:7420"
+	serveTokenEnv           = "YOLO_AGENT_API_TOKEN"
+	controlAPIEventsPerTask = 1000
+	controlAPISubscriberBuf = 256
+	controlAPIShutdownGrace = 5 * time.Second
+)
+
+// runController is the part of agent.Loop the control API drives.
+type runController interface {
+	Pause()
+	Resume()
+	Paused() bool
+	CancelTask(ctx context.Context, taskID string) (bool, error)
+	RetryTask(ctx context.Context, taskID string) error
+}
+
+// controlAPI serves the REST API enabled by --serve. It records the run's
+// events as an event sink, drives the loop through runController, and answers
+// "ask" permission decisions from POST /tasks/{id}/approve.
+type controlAPI struct {
+	access *controlAccess
+
+	mu        sync.Mutex
+	loop      runController
+	run       controlAPIRun
+	tasks     map[string]*controlAPITask
+	events    map[string][]contracts.Event
+	approvals map[string]*pendingApproval
+	subs      map[*eventSubscription]struct{}
+}
+
+// eventSubscription receives events live. A subscriber that falls more than
+// controlAPISubscriberBuf events behind is dropped with lagged set.
+type eventSubscription struct {
+	taskID string
+	events chan contracts.Event
+	lagged bool
+}
+
+type controlAPIRun struct {
+	RunID      string     `json:"run_id,omitempty"`
+	RootID     string     `json:"root_id,omitempty"`
+	Status     string     `json:"status"`
+	Paused     bool       `json:"paused"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Counts     struct {
+		Total     int `json:"total"`
+		Running   int `json:"running"`
+		Completed int `json:"completed"`
+		Blocked   int `json:"blocked"`
+		Failed    int `json:"failed"`
+	} `json:"counts"`
+}
+
+type controlAPITask struct {
+	ID              string           `json:"id"`
+	Title           string           `json:"title,omitempty"`
+	Status          string           `json:"status"`
+	WorkerID        string           `json:"worker_id,omitempty"`
+	StartedAt       *time.Time       `json:"started_at,omitempty"`
+	FinishedAt      *time.Time       `json:"finished_at,omitempty"`
+	Reason          string           `json:"reason,omitempty"`
+	Category        string           `json:"category,omitempty"`
+	PendingApproval *approvalRequest `json:"pending_approval,omitempty"`
+}
+
+type approvalRequest struct {
+	ToolCallID  string    `json:"tool_call_id,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Kind        string    `json:"kind,omitempty"`
+	Locations   []string  `json:"locations,omitempty"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+type pendingApproval struct {
+	request approvalRequest
+	answer  chan bool
+}
+
+type controlAPIResponse struct {
+	Status  string `json:"status"`
+	Running bool   `json:"running,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// newControlAPI serves the API to one token with every role.
+func newControlAPI(token string) *controlAPI {
+	return newControlAPIWithAccess(tokenControlAccess(token))
+}
+
+func newControlAPIWithAccess(access *controlAccess) *controlAPI {
+	return &controlAPI{
+		access:    access,
+		run:       controlAPIRun{Status: "starting"},
+		tasks:     map[string]*controlAPITask{},
+		events:    map[string][]contracts.Event{},
+		approvals: map[string]*pendingApproval{},
+		subs:      map[*eventSubscription]struct{}{},
+	}
+}
+
+func resolveServeToken(flagToken string, getenv func(string) string) (string, error) {
+	token := strings.TrimSpace(flagToken)
+	if token == "" && getenv != nil {
+		token = strings.TrimSpace(getenv(serveTokenEnv))
+	}
+	if token == "" {
+		return "", fmt.Errorf("--serve requires --serve-token or %s", serveTokenEnv)
+	}
+	return token, nil
+}
+
+func (a *controlAPI) attach(loop runController) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.loop = loop
+}
+
+// serveControlAPI attaches loop to the API and starts listening, on
+// cfg.serveGRPCAddr as well when it is set. The returned function shuts the
+// servers down.
+func serveControlAPI(cfg runConfig, loop runController) (func(), error) {
+	if cfg.controlAPI == nil {
+		return func() {}, nil
+	}
+	cfg.controlAPI.attach(loop)
+	listener, err := net.Listen("tcp", cfg.serveAddr)
+	if err != nil {
+		return nil, fmt.Errorf("start control API: %w", err)
+	}
+	stopGRPC := func() {}
+	if cfg.serveGRPCAddr != "" {
+		stopGRPC, err = serveControlGRPC(cfg.controlAPI, cfg.serveGRPCAddr, os.Stderr)
+		if err != nil {
+			_ = listener.Close()
+			return nil, err
+		}
+	}
+	server := &http.Server{Handler: cfg.controlAPI.handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	fmt.Fprintf(os.Stderr, "Control API listening on http://%s\n", listener.Addr())
+	return func() {
+		stopGRPC()
+		ctx, cancel := context.WithTimeout(context.Background(), controlAPIShutdownGrace)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}, nil
+}
+
+func (a *controlAPI) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /run", requireRole(controlRoleViewer, a.handleRun))
+	mux.Handle("POST /run/pause", requireRole(controlRoleOperator, a.handlePause))
+	mux.Handle("POST /run/resume", requireRole(controlRoleOperator, a.handleResume))
+	mux.Handle("GET /tasks", requireRole(controlRoleViewer, a.handleTasks))
+	mux.Handle("GET /tasks/{id}/events", requireRole(controlRoleViewer, a.handleTaskEvents))
+	mux.Handle("POST /tasks/{id}/retry", requireRole(controlRoleOperator, a.handleRetry))
+	mux.Handle("POST /tasks/{id}/cancel", requireRole(controlRoleOperator, a.handleCancel))
+	mux.Handle("POST /tasks/{id}/approve", requireRole(controlRoleApprover, a.handleApprove))
+	return a.requireAuth(mux)
+}
+
+func (a *controlAPI) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := a.access.authenticate(r.Context(), r.Header.Get("Authorization"))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeControlAPIJSON(w, http.StatusUnauthorized, controlAPIResponse{Status: "error", Error: err.Error()})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), controlAPIPrincipalKey, principal)))
+	})
+}
+
+// requireRole answers 403 to callers whose role does not include role.
+func requireRole(role controlRole, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := r.Context().Value(controlAPIPrincipalKey).(controlPrincipal)
+		if principal.role < role {
+			writeControlAPIJSON(w, http.StatusForbidden, controlAPIResponse{Status: "error", Error: controlAPIRoleError(principal, role)})
+			return
+		}
+		next(w, r)
+	})
+}
+
+func (a *controlAPI) attachedLoop() runController {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.loop
+}
+
+func (a *controlAPI) controller(w http.ResponseWriter) (runController, bool) {
+	loop := a.attachedLoop()
+	if loop == nil {
+		writeControlAPIJSON(w, http.StatusServiceUnavailable, controlAPIResponse{Status: "error", Error: "run is not active"})
+		return nil, false
+	}
+	return loop, true
+}
+
+func (a *controlAPI) handleRun(w http.ResponseWriter, _ *http.Request) {
+	writeControlAPIJSON(w, http.StatusOK, a.runSnapshot())
+}
+
+// runSnapshot returns the run with counts derived from the recorded tasks.
+func (a *controlAPI) runSnapshot() controlAPIRun {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	run := a.run
+	if a.loop != nil {
+		run.Paused = a.loop.Paused()
+		if run.Paused && run.Status == "running" {
+			run.Status = "paused"
+		}
+	}
+	for _, task := range a.tasks {
+		run.Counts.Total++
+		switch task.Status {
+		case "running":
+			run.Counts.Running++
+		case string(contracts.TaskStatusClosed), "completed":
+			run.Counts.Completed++
+		case string(contracts.TaskStatusBlocked):
+			run.Counts.Blocked++
+		case string(contracts.TaskStatusFailed):
+			run.Counts.Failed++
+		}
+	}
+	return run
+}
+
+func (a *controlAPI) handlePause(w http.ResponseWriter, _ *http.Request) {
+	loop, ok := a.controller(w)
+	if !ok {
+		return
+	}
+	loop.Pause()
+	writeControlAPIJSON(w, http.StatusOK, controlAPIResponse{Status: "paused"})
+}
+
+func (a *controlAPI) handleResume(w http.ResponseWriter, _ *http.Request) {
+	loop, ok := a.controller(w)
+	if !ok {
+		return
+	}
+	loop.Resume()
+	writeControlAPIJSON(w, http.StatusOK, controlAPIResponse{Status: "running"})
+}
+
+func (a *controlAPI) handleTasks(w http.ResponseWriter, _ *http.Request) {
+	writeControlAPIJSON(w, http.StatusOK, map[string]any{"tasks": a.taskSnapshots()})
+}
+
+// taskSnapshots returns the recorded tasks sorted by ID.
+func (a *controlAPI) taskSnapshots() []controlAPITask {
+	a.mu.Lock()
+	tasks := make([]controlAPITask, 0, len(a.tasks))
+	for id, task := range a.tasks {
+		copy := *task
+		if pending, ok := a.approvals[id]; ok {
+			request := pending.request
+			copy.PendingApproval = &request
+		}
+		tasks = append(tasks, copy)
+	}
+	a.mu.Unlock()
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks
+}
+
+// handleTaskEvents writes the task's recorded events as NDJSON, in the same
+// format as the --stream output and the events file.
+func (a *controlAPI) handleTaskEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	a.mu.Lock()
+	_, known := a.tasks[id]
+	events := append([]contracts.Event(nil), a.events[id]...)
+	a.mu.Unlock()
+	if !known {
+		writeControlAPIJSON(w, http.StatusNotFound, controlAPIResponse{Status: "error", Error: "unknown task"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	for _, event := range events {
+		line, err := contracts.MarshalEventJSONL(event)
+		if err != nil {
+			continue
+		}
+		if _, err := w.Write([]byte(line)); err != nil {
+			return
+		}
+	}
+}
+
+func (a *controlAPI) handleRetry(w http.ResponseWriter, r *http.Request) {
+	loop, ok := a.controller(w)
+	if !ok {
+		return
+	}
+	if err := loop.RetryTask(r.Context(), r.PathValue("id")); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, agent.ErrTaskRunning) {
+			status = http.StatusConflict
+		}
+		writeControlAPIJSON(w, status, controlAPIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	writeControlAPIJSON(w, http.StatusAccepted, controlAPIResponse{Status: "accepted"})
+}
+
+func (a *controlAPI) handleCancel(w http.ResponseWriter, r *http.Request) {
+	loop, ok := a.controller(w)
+	if !ok {
+		return
+	}
+	running, err := loop.CancelTask(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeControlAPIJSON(w, http.StatusBadRequest, controlAPIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	writeControlAPIJSON(w, http.StatusAccepted, controlAPIResponse{Status: "accepted", Running: running})
+}
+
+// handleApprove answers the task's pending permission question. The body is
+// optional; {"allow": false} rejects instead of approving.
+func (a *controlAPI) handleApprove(w http.ResponseWriter, r *http.Request) {
+	body := struct {
+		Allow *bool `json:"allow"`
+	}{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeControlAPIJSON(w, http.StatusBadRequest, controlAPIResponse{Status: "error", Error: "invalid body: " + err.Error()})
+			return
+		}
+	}
+	allow := body.Allow == nil || *body.Allow
+	if !a.answerApproval(r.PathValue("id"), allow) {
+		writeControlAPIJSON(w, http.StatusNotFound, controlAPIResponse{Status: "error", Error: "no pending approval for task"})
+		return
+	}
+	status := "approved"
+	if !allow {
+		status = "rejected"
+	}
+	writeControlAPIJSON(w, http.StatusOK, controlAPIResponse{Status: status})
+}
+
+// answerApproval answers the task's pending permission request and reports
+// whether there was one.
+func (a *controlAPI) answerApproval(taskID string, allow bool) bool {
+	a.mu.Lock()
+	pending, ok := a.approvals[taskID]
+	if ok {
+		delete(a.approvals, taskID)
+	}
+	a.mu.Unlock()
+	if ok {
+		pending.answer <- allow
+	}
+	return ok
+}
+
+// subscribe registers for live events, of one task when taskID is set. With
+// history it also returns the task events already recorded, in emit order.
+func (a *controlAPI) subscribe(taskID string, history bool) (*eventSubscription, []contracts.Event) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	sub := &eventSubscription{taskID: taskID, events: make(chan contracts.Event, controlAPISubscriberBuf)}
+	a.subs[sub] = struct{}{}
+	if !history {
+		return sub, nil
+	}
+	if taskID != "" {
+		return sub, append([]contracts.Event(nil), a.events[taskID]...)
+	}
+	recorded := []contracts.Event{}
+	for _, events := range a.events {
+		recorded = append(recorded, events...)
+	}
+	sort.SliceStable(recorded, func(i, j int) bool {
+		if recorded[i].Seq != recorded[j].Seq {
+			return recorded[i].Seq < recorded[j].Seq
+		}
+		return recorded[i].Timestamp.Before(recorded[j].Timestamp)
+	})
+	return sub, recorded
+}
+
+func (a *controlAPI) unsubscribe(sub *eventSubscription) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.subs[sub]; ok {
+		delete(a.subs, sub)
+		close(sub.events)
+	}
+}
+
+// closeSubscriptions ends every live event stream.
+func (a *controlAPI) closeSubscriptions() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for sub := range a.subs {
+		delete(a.subs, sub)
+		close(sub.events)
+	}
+}
+
+// publish hands event to live subscribers. Callers hold a.mu.
+func (a *controlAPI) publish(event contracts.Event) {
+	for sub := range a.subs {
+		if sub.taskID != "" && sub.taskID != event.TaskID {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			sub.lagged = true
+			delete(a.subs, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// AskPermission implements acp.PermissionAsker: the question waits until it
+// is answered through POST /tasks/{id}/approve or ctx ends.
+func (a *controlAPI) AskPermission(ctx context.Context, request acp.PermissionRequest) (bool, error) {
+	taskID := strings.TrimSpace(request.TaskID)
+	if taskID == "" {
+		return false, errors.New("permission request has no task id")
+	}
+	pending := &pendingApproval{
+		request: approvalRequest{
+			ToolCallID:  request.ToolCallID,
+			Title:       request.Title,
+			Kind:        request.Kind,
+			Locations:   request.Locations,
+			RequestedAt: time.Now().UTC(),
+		},
+		answer: make(chan bool, 1),
+	}
+	a.mu.Lock()
+	if _, busy := a.approvals[taskID]; busy {
+		a.mu.Unlock()
+		return false, fmt.Errorf("task %s already has a pending approval", taskID)
+	}
+	a.approvals[taskID] = pending
+	a.mu.Unlock()
+
+	select {
+	case allow := <-pending.answer:
+		return allow, nil
+	case <-ctx.Done():
+		a.mu.Lock()
+		if a.approvals[taskID] == pending {
+			delete(a.approvals, taskID)
+		}
+		a.mu.Unlock()
+		return false, ctx.Err()
+	}
+}
+
+// Emit implements contracts.EventSink so the API sees the run's events.
+func (a *controlAPI) Emit(_ context.Context, event contracts.Event) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.publish(event)
+	switch event.Type {
+	case contracts.EventTypeRunStarted:
+		a.run.RunID = event.RunID
+		a.run.RootID = event.TaskID
+		a.run.Status = "running"
+		a.run.StartedAt = eventTime(event)
+		return nil
+	case contracts.EventTypeRunFinished:
+		a.run.Status = "finished"
+		if status := strings.TrimSpace(event.Metadata["status"]); status != "" {
+			a.run.Status = status
+		}
+		a.run.Error = strings.TrimSpace(event.Metadata["error"])
+		a.run.FinishedAt = eventTime(event)
+		return nil
+	}
+	id := strings.TrimSpace(event.TaskID)
+	if id == "" || id == a.run.RootID {
+		return nil
+	}
+	task, ok := a.tasks[id]
+	if !ok {
+		task = &controlAPITask{ID: id, Status: string(contracts.TaskStatusOpen)}
+		a.tasks[id] = task
+	}
+	if title := strings.TrimSpace(event.TaskTitle); title != "" {
+		task.Title = title
+	}
+	if worker := strings.TrimSpace(event.WorkerID); worker != "" {
+		task.WorkerID = worker
+	}
+	switch event.Type {
+	case contracts.EventTypeTaskStarted:
+		task.Status = "running"
+		task.StartedAt = eventTime(event)
+		task.FinishedAt = nil
+		task.Reason = ""
+		task.Category = ""
+	case contracts.EventTypeTaskFinished, contracts.EventTypeTaskStatusSet:
+		if status := strings.TrimSpace(event.Message); status != "" {
+			task.Status = status
+		}
+		task.Reason = strings.TrimSpace(event.Metadata["triage_reason"])
+		task.Category = string(contracts.FailureCategoryFromMetadata(event.Metadata))
+		if event.Type == contracts.EventTypeTaskFinished {
+			task.FinishedAt = eventTime(event)
+		}
+	}
+	events := append(a.events[id], event)
+	if len(events) > controlAPIEventsPerTask {
+		events = events[len(events)-controlAPIEventsPerTask:]
+	}
+	a.events[id] = events
+	return nil
+}
+
+func eventTime(event contracts.Event) *time.Time {
+	ts := event.Timestamp
+	if ts.IsZero() {
+		ts = time.Now().UTC()
+	}
+	return &ts
+}
+
+func writeControlAPIJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
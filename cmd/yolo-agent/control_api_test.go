@@ -0,0 +1,461 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/acp"
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/engine"
+)
+
+type fakeRunController struct {
+	paused    bool
+	canceled  []string
+	retried   []string
+	retryErr  error
+	inFlight  bool
+	cancelErr error
+}
+
+func (f *fakeRunController) Pause()       { f.paused = true }
+func (f *fakeRunController) Resume()      { f.paused = false }
+func (f *fakeRunController) Paused() bool { return f.paused }
+
+func (f *fakeRunController) CancelTask(_ context.Context, taskID string) (bool, error) {
+	f.canceled = append(f.canceled, taskID)
+	return f.inFlight, f.cancelErr
+}
+
+func (f *fakeRunController) RetryTask(_ context.Context, taskID string) error {
+	f.retried = append(f.retried, taskID)
+	return f.retryErr
+}
+
+func newControlAPITestServer(t *testing.T, loop runController) (*controlAPI, *httptest.Server) {
+	t.Helper()
+	api := newControlAPI("secret")
+	if loop != nil {
+		api.attach(loop)
+	}
+	server := httptest.NewServer(api.handler())
+	t.Cleanup(server.Close)
+	return api, server
+}
+
+func controlAPIRequest(t *testing.T, server *httptest.Server, method string, path string, body string) (int, string) {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, server.URL+path, reader)
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(raw)
+}
+
+func TestResolveServeTokenPrefersFlagThenEnv(t *testing.T) {
+	env := func(key string) string {
+		if key == serveTokenEnv {
+			return " from-env "
+		}
+		return ""
+	}
+	if token, err := resolveServeToken("from-flag", env); err != nil || token != "from-flag" {
+		t.Fatalf("expected flag token, got %q err=%v", token, err)
+	}
+	if token, err := resolveServeToken("", env); err != nil || token != "from-env" {
+		t.Fatalf("expected env token, got %q err=%v", token, err)
+	}
+	if _, err := resolveServeToken("", func(string) string { return "" }); err == nil || !strings.Contains(err.Error(), serveTokenEnv) {
+		t.Fatalf("expected missing token error, got %v", err)
+	}
+}
+
+func TestRunMainRejectsServeWithoutToken(t *testing.T) {
+	t.Setenv(serveTokenEnv, "")
+	called := false
+	code := RunMain([]string{"--repo", t.TempDir(), "--root", "root", "--serve"}, func(context.Context, runConfig) error {
+		called = true
+		return nil
+	})
+	if code != 1 || called {
+		t.Fatalf("expected --serve without a token to fail before running, code=%d called=%v", code, called)
+	}
+}
+
+func TestControlAPIRequiresBearerToken(t *testing.T) {
+	_, server := newControlAPITestServer(t, &fakeRunController{})
+
+	for _, header := range []string{"", "Bearer wrong", "secret"} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/run", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatalf("GET /run: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for Authorization %q, got %d", header, resp.StatusCode)
+		}
+	}
+	if status, _ := controlAPIRequest(t, server, http.MethodGet, "/run", ""); status != http.StatusOK {
+		t.Fatalf("expected 200 with the token, got %d", status)
+	}
+}
+
+func TestControlAPIReportsRunAndTasksFromEvents(t *testing.T) {
+	loop := &fakeRunController{}
+	api, server := newControlAPITestServer(t, loop)
+	ctx := context.Background()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, event := range []contracts.Event{
+		{Type: contracts.EventTypeRunStarted, RunID: "run-1", TaskID: "root", Timestamp: now},
+		{Type: contracts.EventTypeTaskStarted, TaskID: "task-1", TaskTitle: "First", WorkerID: "worker-0", Timestamp: now},
+		{Type: contracts.EventTypeTaskFinished, TaskID: "task-1", Message: "closed", Timestamp: now},
+		{Type: contracts.EventTypeTaskStarted, TaskID: "task-2", TaskTitle: "Second", Timestamp: now},
+		{Type: contracts.EventTypeTaskFinished, TaskID: "task-2", Message: "blocked", Metadata: map[string]string{"triage_reason": "needs input", "triage_category": "question"}, Timestamp: now},
+		{Type: contracts.EventTypeTaskStarted, TaskID: "task-3", Timestamp: now},
+	} {
+		if err := api.Emit(ctx, event); err != nil {
+			t.Fatalf("emit: %v", err)
+		}
+	}
+	loop.paused = true
+
+	status, body := controlAPIRequest(t, server, http.MethodGet, "/run", "")
+	if status != http.StatusOK {
+		t.Fatalf("GET /run: %d %s", status, body)
+	}
+	run := controlAPIRun{}
+	if err := json.Unmarshal([]byte(body), &run); err != nil {
+		t.Fatalf("decode run: %v", err)
+	}
+	if run.RunID != "run-1" || run.RootID != "root" || run.Status != "paused" || !run.Paused {
+		t.Fatalf("unexpected run: %#v", run)
+	}
+	if run.Counts.Total != 3 || run.Counts.Completed != 1 || run.Counts.Blocked != 1 || run.Counts.Running != 1 {
+		t.Fatalf("unexpected counts: %#v", run.Counts)
+	}
+
+	status, body = controlAPIRequest(t, server, http.MethodGet, "/tasks", "")
+	if status != http.StatusOK {
+		t.Fatalf("GET /tasks: %d %s", status, body)
+	}
+	listing := struct {
+		Tasks []controlAPITask `json:"tasks"`
+	}{}
+	if err := json.Unmarshal([]byte(body), &listing); err != nil {
+		t.Fatalf("decode tasks: %v", err)
+	}
+	if len(listing.Tasks) != 3 || listing.Tasks[0].ID != "task-1" || listing.Tasks[0].WorkerID != "worker-0" {
+		t.Fatalf("unexpected tasks: %#v", listing.Tasks)
+	}
+	if listing.Tasks[1].Status != "blocked" || listing.Tasks[1].Reason != "needs input" || listing.Tasks[1].Category != "question" {
+		t.Fatalf("expected blocked task with reason and category, got %#v", listing.Tasks[1])
+	}
+
+	status, body = controlAPIRequest(t, server, http.MethodGet, "/tasks/task-1/events", "")
+	if status != http.StatusOK {
+		t.Fatalf("GET /tasks/task-1/events: %d %s", status, body)
+	}
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected two NDJSON events, got %q", body)
+	}
+	event, err := contracts.ParseEventJSONLLine([]byte(lines[1]))
+	if err != nil || event.Type != contracts.EventTypeTaskFinished || event.TaskID != "task-1" {
+		t.Fatalf("unexpected event line %q: %#v err=%v", lines[1], event, err)
+	}
+	if status, _ := controlAPIRequest(t, server, http.MethodGet, "/tasks/missing/events", ""); status != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown task, got %d", status)
+	}
+}
+
+// controlRunTestStorage is a tracker holding one root and its open
+// children that records the statuses a run writes.
+type controlRunTestStorage struct {
+	mu   sync.Mutex
+	tree contracts.TaskTree
+}
+
+func newControlRunTestStorage(rootID string, children ...contracts.Task) *controlRunTestStorage {
+	tree := contracts.TaskTree{
+		Root:  contracts.Task{ID: rootID, Title: "Root", Status: contracts.TaskStatusOpen},
+		Tasks: map[string]contracts.Task{},
+	}
+	for _, child := range children {
+		child.Status = contracts.TaskStatusOpen
+		tree.Tasks[child.ID] = child
+		tree.Relations = append(tree.Relations, contracts.TaskRelation{FromID: rootID, ToID: child.ID, Type: contracts.RelationParent})
+	}
+	return &controlRunTestStorage{tree: tree}
+}
+
+func (s *controlRunTestStorage) GetTaskTree(context.Context, string) (*contracts.TaskTree, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tree := s.tree
+	tree.Tasks = map[string]contracts.Task{}
+	for id, task := range s.tree.Tasks {
+		tree.Tasks[id] = task
+	}
+	return &tree, nil
+}
+
+func (s *controlRunTestStorage) GetTask(_ context.Context, taskID string) (*contracts.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if taskID == s.tree.Root.ID {
+		root := s.tree.Root
+		return &root, nil
+	}
+	task, ok := s.tree.Tasks[taskID]
+	if !ok {
+		return nil, nil
+	}
+	return &task, nil
+}
+
+func (s *controlRunTestStorage) SetTaskStatus(_ context.Context, taskID string, status contracts.TaskStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if task, ok := s.tree.Tasks[taskID]; ok {
+		task.Status = status
+		s.tree.Tasks[taskID] = task
+	}
+	return nil
+}
+
+func (s *controlRunTestStorage) SetTaskData(context.Context, string, map[string]string) error {
+	return nil
+}
+
+func TestRunWithStorageComponentsFeedsControlAPI(t *testing.T) {
+	api := newControlAPI("secret")
+	runner := &fakeAgentRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+	}}
+	err := runWithStorageComponents(context.Background(), runConfig{
+		repoRoot:   t.TempDir(),
+		rootID:     "root",
+		maxTasks:   1,
+		noVCS:      true,
+		serve:      true,
+		serveAddr:  "127.0.0.1:0",
+		controlAPI: api,
+	}, newControlRunTestStorage("root", contracts.Task{ID: "t-1", Title: "Update runbook", ParentID: "root"}), engine.NewTaskEngine(), runner, &fakeVCS{})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	server := httptest.NewServer(api.handler())
+	t.Cleanup(server.Close)
+
+	status, body := controlAPIRequest(t, server, http.MethodGet, "/run", "")
+	run := controlAPIRun{}
+	if err := json.Unmarshal([]byte(body), &run); status != http.StatusOK || err != nil {
+		t.Fatalf("GET /run: %d %s", status, body)
+	}
+	if run.RunID == "" || run.RootID != "root" || run.Status != "completed" || run.Counts.Completed != 1 {
+		t.Fatalf("expected the finished run to be reported, got %#v", run)
+	}
+	status, body = controlAPIRequest(t, server, http.MethodGet, "/tasks", "")
+	listing := struct {
+		Tasks []controlAPITask `json:"tasks"`
+	}{}
+	if err := json.Unmarshal([]byte(body), &listing); status != http.StatusOK || err != nil {
+		t.Fatalf("GET /tasks: %d %s", status, body)
+	}
+	if len(listing.Tasks) != 1 || listing.Tasks[0].ID != "t-1" || listing.Tasks[0].Status != "closed" {
+		t.Fatalf("expected the run's task to be reported, got %#v", listing.Tasks)
+	}
+}
+
+func TestControlAPIDrivesRunController(t *testing.T) {
+	loop := &fakeRunController{}
+	_, server := newControlAPITestServer(t, loop)
+
+	if status, _ := controlAPIRequest(t, server, http.MethodPost, "/run/pause", ""); status != http.StatusOK || !loop.paused {
+		t.Fatalf("expected pause, status=%d paused=%v", status, loop.paused)
+	}
+	if status, _ := controlAPIRequest(t, server, http.MethodPost, "/run/resume", ""); status != http.StatusOK || loop.paused {
+		t.Fatalf("expected resume, status=%d paused=%v", status, loop.paused)
+	}
+
+	loop.inFlight = true
+	status, body := controlAPIRequest(t, server, http.MethodPost, "/tasks/task-1/cancel", "")
+	if status != http.StatusAccepted || !strings.Contains(body, `"running":true`) || len(loop.canceled) != 1 || loop.canceled[0] != "task-1" {
+		t.Fatalf("unexpected cancel: %d %s %#v", status, body, loop.canceled)
+	}
+
+	if status, _ := controlAPIRequest(t, server, http.MethodPost, "/tasks/task-2/retry", ""); status != http.StatusAccepted || len(loop.retried) != 1 {
+		t.Fatalf("expected retry to be accepted, status=%d retried=%#v", status, loop.retried)
+	}
+	loop.retryErr = agent.ErrTaskRunning
+	if status, _ := controlAPIRequest(t, server, http.MethodPost, "/tasks/task-2/retry", ""); status != http.StatusConflict {
+		t.Fatalf("expected 409 for retrying a running task, got %d", status)
+	}
+}
+
+func TestControlAPIRejectsControlBeforeRunIsAttached(t *testing.T) {
+	_, server := newControlAPITestServer(t, nil)
+	if status, _ := controlAPIRequest(t, server, http.MethodPost, "/run/pause", ""); status != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without an attached loop, got %d", status)
+	}
+}
+
+func TestControlAPIApproveAnswersPendingPermission(t *testing.T) {
+	api, server := newControlAPITestServer(t, &fakeRunController{})
+	_ = api.Emit(context.Background(), contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1"})
+
+	if status, _ := controlAPIRequest(t, server, http.MethodPost, "/tasks/task-1/approve", ""); status != http.StatusNotFound {
+		t.Fatalf("expected 404 without a pending approval, got %d", status)
+	}
+
+	answers := make(chan bool, 2)
+	ask := func() {
+		allow, err := api.AskPermission(context.Background(), acp.PermissionRequest{TaskID: "task-1", Kind: "execute", Title: "rm -rf build"})
+		if err != nil {
+			t.Errorf("ask permission: %v", err)
+		}
+		answers <- allow
+	}
+	waitPending := func() {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			_, body := controlAPIRequest(t, server, http.MethodGet, "/tasks", "")
+			if strings.Contains(body, `"pending_approval"`) {
+				if !strings.Contains(body, `"kind":"execute"`) {
+					t.Fatalf("expected pending approval details, got %s", body)
+				}
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("permission request never became pending: %s", body)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	go ask()
+	waitPending()
+	if status, body := controlAPIRequest(t, server, http.MethodPost, "/tasks/task-1/approve", ""); status != http.StatusOK || !strings.Contains(body, "approved") {
+		t.Fatalf("unexpected approve response: %d %s", status, body)
+	}
+	if allow := <-answers; !allow {
+		t.Fatalf("expected approval to allow the tool call")
+	}
+
+	go ask()
+	waitPending()
+	if status, body := controlAPIRequest(t, server, http.MethodPost, "/tasks/task-1/approve", `{"allow":false}`); status != http.StatusOK || !strings.Contains(body, "rejected") {
+		t.Fatalf("unexpected reject response: %d %s", status, body)
+	}
+	if allow := <-answers; allow {
+		t.Fatalf("expected rejection to deny the tool call")
+	}
+}
+
+func TestControlAPIAskPermissionStopsWithContext(t *testing.T) {
+	api := newControlAPI("secret")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := api.AskPermission(ctx, acp.PermissionRequest{TaskID: "task-1"}); err == nil {
+		t.Fatalf("expected canceled context to end the permission request")
+	}
+	if len(api.approvals) != 0 {
+		t.Fatalf("expected pending approval to be cleared, got %#v", api.approvals)
+	}
+}
+
+func TestDiffApproverAsksThroughControlAPI(t *testing.T) {
+	if diffApprover(runConfig{}) != nil {
+		t.Fatalf("expected no diff approver without --serve")
+	}
+	api := newControlAPI("secret")
+	approve := diffApprover(runConfig{controlAPI: api})
+	answers := make(chan bool, 1)
+	go func() {
+		allow, _ := approve(context.Background(), contracts.Task{ID: "task-1"}, "diff guardrail: changed 900 lines (max 500)")
+		answers <- allow
+	}()
+	waitForPendingApproval(t, api, "task-1")
+	api.mu.Lock()
+	request := api.approvals["task-1"].request
+	api.mu.Unlock()
+	if request.Kind != "diff_guardrail" || request.Title != "diff guardrail: changed 900 lines (max 500)" {
+		t.Fatalf("unexpected approval request %#v", request)
+	}
+	if !api.answerApproval("task-1", true) || !<-answers {
+		t.Fatalf("expected the approval to reach the guardrail")
+	}
+}
+
+func newRoleControlAccess() *controlAccess {
+	return &controlAccess{tokens: []controlAPIToken{
+		{name: "dashboard", role: controlRoleViewer, value: "viewer-token"},
+		{name: "oncall", role: controlRoleOperator, value: "operator-token"},
+		{name: "release", role: controlRoleApprover, value: "approver-token"},
+	}}
+}
+
+func TestControlAPIEnforcesRoles(t *testing.T) {
+	api := newControlAPIWithAccess(newRoleControlAccess())
+	api.attach(&fakeRunController{})
+	server := httptest.NewServer(api.handler())
+	t.Cleanup(server.Close)
+
+	cases := []struct {
+		token  string
+		method string
+		path   string
+		want   int
+	}{
+		{"viewer-token", http.MethodGet, "/run", http.StatusOK},
+		{"viewer-token", http.MethodGet, "/tasks", http.StatusOK},
+		{"viewer-token", http.MethodPost, "/run/pause", http.StatusForbidden},
+		{"viewer-token", http.MethodPost, "/tasks/task-1/cancel", http.StatusForbidden},
+		{"operator-token", http.MethodPost, "/run/pause", http.StatusOK},
+		{"operator-token", http.MethodPost, "/tasks/task-1/cancel", http.StatusAccepted},
+		{"operator-token", http.MethodPost, "/tasks/task-1/approve", http.StatusForbidden},
+		{"approver-token", http.MethodPost, "/tasks/task-1/approve", http.StatusNotFound},
+		{"approver-token", http.MethodPost, "/run/resume", http.StatusOK},
+	}
+	for _, tc := range cases {
+		req, _ := http.NewRequest(tc.method, server.URL+tc.path, nil)
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", tc.method, tc.path, err)
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Fatalf("%s %s with %s: expected %d, got %d %s", tc.method, tc.path, tc.token, tc.want, resp.StatusCode, raw)
+		}
+		if tc.want == http.StatusForbidden && !strings.Contains(string(raw), "required") {
+			t.Fatalf("expected forbidden response to name the required role, got %s", raw)
+		}
+	}
+}
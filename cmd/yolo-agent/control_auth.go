@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// controlRole is what a control API caller may do. Each role includes the
+// ones below it: viewers read the run and its events, operators also pause,
+// resume, retry and cancel, and approvers also answer approvals.
+type controlRole int
+
+const (
+	controlRoleNone controlRole = iota
+	controlRoleViewer
+	controlRoleOperator
+	controlRoleApprover
+)
+
+const (
+	controlRoleNames        = "viewer, operator, approver"
+	controlOIDCDefaultClaim = "email"
+	controlOIDCWildcard     = "*"
+	controlServeTokenName   = "serve-token"
+	controlAPIPrincipalKey  = controlAPIContextKey("principal")
+)
+
+type controlAPIContextKey string
+
+func parseControlRole(raw string) (controlRole, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "viewer":
+		return controlRoleViewer, nil
+	case "operator":
+		return controlRoleOperator, nil
+	case "approver":
+		return controlRoleApprover, nil
+	}
+	return controlRoleNone, fmt.Errorf("unknown role %q (supported: %s)", raw, controlRoleNames)
+}
+
+func (r controlRole) String() string {
+	switch r {
+	case controlRoleViewer:
+		return "viewer"
+	case controlRoleOperator:
+		return "operator"
+	case controlRoleApprover:
+		return "approver"
+	}
+	return "none"
+}
+
+// controlAPIAccessConfig is agent.control_api with its roles checked. Token
+// values are read by newControlAccess when --serve starts.
+type controlAPIAccessConfig struct {
+	Tokens []controlAPITokenConfig
+	OIDC   *controlAPIOIDCConfig
+	// SlackRoles maps Slack user IDs, or "*", to the role yolo-agent chatops
+	// lets them act with. It grants no access to the APIs themselves.
+	SlackRoles map[string]controlRole
+}
+
+type controlAPITokenConfig struct {
+	Name     string
+	Role     controlRole
+	TokenEnv string
+}
+
+// controlAPIOIDCConfig maps values of Claim in verified ID tokens to roles.
+type controlAPIOIDCConfig struct {
+	Issuer   string
+	Audience string
+	Claim    string
+	Roles    map[string]controlRole
+}
+
+func (c controlAPIAccessConfig) IsZero() bool {
+	return len(c.Tokens) == 0 && c.OIDC == nil
+}
+
+// controlPrincipal is an authenticated control API caller.
+type controlPrincipal struct {
+	name string
+	role controlRole
+}
+
+type controlAPIToken struct {
+	name  string
+	role  controlRole
+	value string
+}
+
+// controlAccess authenticates control API callers by bearer token: an API
+// token with a fixed role, or an OIDC ID token whose claim maps to a role.
+type controlAccess struct {
+	tokens    []controlAPIToken
+	oidc      *oidcVerifier
+	oidcClaim string
+	oidcRoles map[string]controlRole
+}
+
+var errControlAPIUnauthenticated = errors.New("unauthorized")
+
+// tokenControlAccess lets one token do everything, as --serve-token does.
+func tokenControlAccess(token string) *controlAccess {
+	return &controlAccess{tokens: []controlAPIToken{{name: controlServeTokenName, role: controlRoleApprover, value: token}}}
+}
+
+// newControlAccess reads the token values of config. The --serve-token (or
+// $YOLO_AGENT_API_TOKEN) token, when set, keeps the approver role, so it is
+// only required when agent.control_api grants no access.
+func newControlAccess(flagToken string, config controlAPIAccessConfig, getenv func(string) string) (*controlAccess, error) {
+	access := &controlAccess{}
+	if token, err := resolveServeToken(flagToken, getenv); err == nil {
+		access.tokens = append(access.tokens, controlAPIToken{name: controlServeTokenName, role: controlRoleApprover, value: token})
+	} else if config.IsZero() {
+		return nil, fmt.Errorf("--serve requires --serve-token, %s or agent.control_api in %s", serveTokenEnv, trackerConfigRelPath)
+	}
+	seen := map[string]string{}
+	for _, token := range access.tokens {
+		seen[token.value] = token.name
+	}
+	for _, configured := range config.Tokens {
+		value, err := lookupConfigSecret(getenv, configured.TokenEnv)
+		if err != nil {
+			return nil, fmt.Errorf("agent.control_api token %q: %w", configured.Name, err)
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil, fmt.Errorf("agent.control_api token %q: %s is not set", configured.Name, configured.TokenEnv)
+		}
+		if other, ok := seen[value]; ok {
+			return nil, fmt.Errorf("agent.control_api token %q has the same value as %q", configured.Name, other)
+		}
+		seen[value] = configured.Name
+		access.tokens = append(access.tokens, controlAPIToken{name: configured.Name, role: configured.Role, value: value})
+	}
+	if config.OIDC != nil {
+		access.oidc = newOIDCVerifier(config.OIDC.Issuer, config.OIDC.Audience, http.DefaultClient)
+		access.oidcClaim = config.OIDC.Claim
+		access.oidcRoles = config.OIDC.Roles
+	}
+	return access, nil
+}
+
+// authenticate returns the caller behind an Authorization header value. An
+// OIDC identity without a role is returned with controlRoleNone.
+func (a *controlAccess) authenticate(ctx context.Context, authorization string) (controlPrincipal, error) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(authorization), " ")
+	token = strings.TrimSpace(token)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return controlPrincipal{}, errControlAPIUnauthenticated
+	}
+	for _, candidate := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(candidate.value)) == 1 {
+			return controlPrincipal{name: candidate.name, role: candidate.role}, nil
+		}
+	}
+	if a.oidc == nil {
+		return controlPrincipal{}, errControlAPIUnauthenticated
+	}
+	claims, err := a.oidc.verify(ctx, token)
+	if err != nil {
+		return controlPrincipal{}, errControlAPIUnauthenticated
+	}
+	principal := controlPrincipal{name: oidcClaimString(claims["sub"]), role: a.oidcRoles[controlOIDCWildcard]}
+	if name := oidcClaimString(claims[a.oidcClaim]); name != "" {
+		principal.name = name
+	}
+	for _, value := range oidcClaimStrings(claims[a.oidcClaim]) {
+		if role := a.oidcRoles[value]; role > principal.role {
+			principal.role = role
+		}
+	}
+	return principal, nil
+}
+
+// controlAPIRoleError is the message for a caller whose role is below role.
+func controlAPIRoleError(principal controlPrincipal, role controlRole) string {
+	return fmt.Sprintf("forbidden: %s has role %s, %s required", principal.name, principal.role, role)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewControlAccessReadsConfiguredTokens(t *testing.T) {
+	env := map[string]string{"DASHBOARD_TOKEN": "view-me", "RELEASE_TOKEN": "ship-it"}
+	config := controlAPIAccessConfig{Tokens: []controlAPITokenConfig{
+		{Name: "dashboard", Role: controlRoleViewer, TokenEnv: "DASHBOARD_TOKEN"},
+		{Name: "release", Role: controlRoleApprover, TokenEnv: "RELEASE_TOKEN"},
+	}}
+	access, err := newControlAccess("", config, func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	principal, err := access.authenticate(context.Background(), "Bearer view-me")
+	if err != nil || principal.name != "dashboard" || principal.role != controlRoleViewer {
+		t.Fatalf("unexpected principal %#v err=%v", principal, err)
+	}
+	if _, err := access.authenticate(context.Background(), "Bearer nope"); err == nil {
+		t.Fatalf("expected an unknown token to be rejected")
+	}
+
+	access, err = newControlAccess("root-token", config, func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if principal, _ := access.authenticate(context.Background(), "Bearer root-token"); principal.role != controlRoleApprover {
+		t.Fatalf("expected --serve-token to keep the approver role, got %#v", principal)
+	}
+}
+
+func TestNewControlAccessRejectsMissingTokens(t *testing.T) {
+	getenv := func(string) string { return "" }
+	if _, err := newControlAccess("", controlAPIAccessConfig{}, getenv); err == nil || !strings.Contains(err.Error(), "agent.control_api") {
+		t.Fatalf("expected missing access error, got %v", err)
+	}
+	config := controlAPIAccessConfig{Tokens: []controlAPITokenConfig{{Name: "dashboard", Role: controlRoleViewer, TokenEnv: "DASHBOARD_TOKEN"}}}
+	if _, err := newControlAccess("", config, getenv); err == nil || !strings.Contains(err.Error(), "DASHBOARD_TOKEN is not set") {
+		t.Fatalf("expected unset token env error, got %v", err)
+	}
+	if _, err := newControlAccess("same", config, func(string) string { return "same" }); err == nil || !strings.Contains(err.Error(), "same value") {
+		t.Fatalf("expected shared token value error, got %v", err)
+	}
+}
+
+type testOIDCIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+}
+
+func newTestOIDCIssuer(t *testing.T) *testOIDCIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	issuer := &testOIDCIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": issuer.server.URL, "jwks_uri": issuer.server.URL + "/keys"})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (i *testOIDCIssuer) token(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "key-1", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestControlAccessMapsOIDCClaimsToRoles(t *testing.T) {
+	issuer := newTestOIDCIssuer(t)
+	access, err := newControlAccess("", controlAPIAccessConfig{OIDC: &controlAPIOIDCConfig{
+		Issuer:   issuer.server.URL,
+		Audience: "yolo-agent",
+		Claim:    "email",
+		Roles: map[string]controlRole{
+			"alice@example.com": controlRoleApprover,
+			controlOIDCWildcard: controlRoleViewer,
+		},
+	}}, func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := float64(time.Now().Add(time.Hour).Unix())
+	claims := func(email string, audience string, exp float64) map[string]any {
+		return map[string]any{"iss": issuer.server.URL, "aud": audience, "exp": exp, "sub": "user-" + email, "email": email}
+	}
+
+	principal, err := access.authenticate(context.Background(), "Bearer "+issuer.token(t, claims("alice@example.com", "yolo-agent", exp)))
+	if err != nil || principal.name != "alice@example.com" || principal.role != controlRoleApprover {
+		t.Fatalf("expected alice to be an approver, got %#v err=%v", principal, err)
+	}
+	principal, err = access.authenticate(context.Background(), "Bearer "+issuer.token(t, claims("bob@example.com", "yolo-agent", exp)))
+	if err != nil || principal.role != controlRoleViewer {
+		t.Fatalf("expected the wildcard to make bob a viewer, got %#v err=%v", principal, err)
+	}
+	bob := strings.Split(issuer.token(t, claims("bob@example.com", "yolo-agent", exp)), ".")
+	alice := strings.Split(issuer.token(t, claims("alice@example.com", "yolo-agent", exp)), ".")
+	for name, token := range map[string]string{
+		"wrong audience": issuer.token(t, claims("alice@example.com", "other", exp)),
+		"expired":        issuer.token(t, claims("alice@example.com", "yolo-agent", float64(time.Now().Add(-time.Hour).Unix()))),
+		"tampered":       bob[0] + "." + alice[1] + "." + bob[2],
+	} {
+		if _, err := access.authenticate(context.Background(), "Bearer "+token); err == nil {
+			t.Fatalf("expected %s token to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/pkg/controlpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// controlGRPCServer is the gRPC variant of the --serve API. It shares the
+// controlAPI's run state, access and pending approvals.
+type controlGRPCServer struct {
+	controlpb.UnimplementedRunControlServer
+	api *controlAPI
+}
+
+// serveControlGRPC starts the RunControl service on addr. The returned
+// function ends open Events streams and stops the server.
+func serveControlGRPC(api *controlAPI, addr string, out io.Writer) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("start control gRPC API: %w", err)
+	}
+	server := newControlGRPCServer(api)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	fmt.Fprintf(out, "Control gRPC API listening on %s\n", listener.Addr())
+	return func() {
+		api.closeSubscriptions()
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(controlAPIShutdownGrace):
+			server.Stop()
+		}
+	}, nil
+}
+
+func newControlGRPCServer(api *controlAPI) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := api.authorizeGRPC(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := api.authorizeGRPC(stream.Context(), info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	)
+	controlpb.RegisterRunControlServer(server, &controlGRPCServer{api: api})
+	return server
+}
+
+// controlGRPCMethodRoles is the role each RunControl method requires, as on
+// the matching REST route. Methods not listed require approver.
+var controlGRPCMethodRoles = map[string]controlRole{
+	controlpb.RunControl_GetRun_FullMethodName:      controlRoleViewer,
+	controlpb.RunControl_ListTasks_FullMethodName:   controlRoleViewer,
+	controlpb.RunControl_Events_FullMethodName:      controlRoleViewer,
+	controlpb.RunControl_PauseRun_FullMethodName:    controlRoleOperator,
+	controlpb.RunControl_ResumeRun_FullMethodName:   controlRoleOperator,
+	controlpb.RunControl_CancelTask_FullMethodName:  controlRoleOperator,
+	controlpb.RunControl_RetryTask_FullMethodName:   controlRoleOperator,
+	controlpb.RunControl_ApproveTask_FullMethodName: controlRoleApprover,
+}
+
+func (a *controlAPI) authorizeGRPC(ctx context.Context, method string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		principal, err := a.access.authenticate(ctx, value)
+		if err != nil {
+			continue
+		}
+		role, ok := controlGRPCMethodRoles[method]
+		if !ok {
+			role = controlRoleApprover
+		}
+		if principal.role < role {
+			return status.Error(codes.PermissionDenied, controlAPIRoleError(principal, role))
+		}
+		return nil
+	}
+	return status.Error(codes.Unauthenticated, "unauthorized")
+}
+
+func (s *controlGRPCServer) controller() (runController, error) {
+	loop := s.api.attachedLoop()
+	if loop == nil {
+		return nil, status.Error(codes.Unavailable, "run is not active")
+	}
+	return loop, nil
+}
+
+func (s *controlGRPCServer) GetRun(context.Context, *controlpb.GetRunRequest) (*controlpb.Run, error) {
+	return runToProto(s.api.runSnapshot()), nil
+}
+
+func (s *controlGRPCServer) ListTasks(context.Context, *controlpb.ListTasksRequest) (*controlpb.ListTasksResponse, error) {
+	tasks := s.api.taskSnapshots()
+	resp := &controlpb.ListTasksResponse{Tasks: make([]*controlpb.Task, 0, len(tasks))}
+	for _, task := range tasks {
+		resp.Tasks = append(resp.Tasks, taskToProto(task))
+	}
+	return resp, nil
+}
+
+func (s *controlGRPCServer) PauseRun(context.Context, *controlpb.PauseRunRequest) (*controlpb.Run, error) {
+	loop, err := s.controller()
+	if err != nil {
+		return nil, err
+	}
+	loop.Pause()
+	return runToProto(s.api.runSnapshot()), nil
+}
+
+func (s *controlGRPCServer) ResumeRun(context.Context, *controlpb.ResumeRunRequest) (*controlpb.Run, error) {
+	loop, err := s.controller()
+	if err != nil {
+		return nil, err
+	}
+	loop.Resume()
+	return runToProto(s.api.runSnapshot()), nil
+}
+
+func (s *controlGRPCServer) CancelTask(ctx context.Context, req *controlpb.CancelTaskRequest) (*controlpb.CancelTaskResponse, error) {
+	taskID, err := requiredTaskID(req.GetTaskId())
+	if err != nil {
+		return nil, err
+	}
+	loop, err := s.controller()
+	if err != nil {
+		return nil, err
+	}
+	running, err := loop.CancelTask(ctx, taskID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &controlpb.CancelTaskResponse{Running: running}, nil
+}
+
+func (s *controlGRPCServer) RetryTask(ctx context.Context, req *controlpb.RetryTaskRequest) (*controlpb.RetryTaskResponse, error) {
+	taskID, err := requiredTaskID(req.GetTaskId())
+	if err != nil {
+		return nil, err
+	}
+	loop, err := s.controller()
+	if err != nil {
+		return nil, err
+	}
+	if err := loop.RetryTask(ctx, taskID); err != nil {
+		code := codes.InvalidArgument
+		if errors.Is(err, agent.ErrTaskRunning) {
+			code = codes.FailedPrecondition
+		}
+		return nil, status.Error(code, err.Error())
+	}
+	return &controlpb.RetryTaskResponse{}, nil
+}
+
+func (s *controlGRPCServer) ApproveTask(_ context.Context, req *controlpb.ApproveTaskRequest) (*controlpb.ApproveTaskResponse, error) {
+	taskID, err := requiredTaskID(req.GetTaskId())
+	if err != nil {
+		return nil, err
+	}
+	allow := !req.GetReject()
+	if !s.api.answerApproval(taskID, allow) {
+		return nil, status.Error(codes.NotFound, "no pending approval for task")
+	}
+	return &controlpb.ApproveTaskResponse{Allowed: allow}, nil
+}
+
+// Events sends the recorded history when asked, then live events until the
+// client goes away or the server stops. A client that cannot keep up is cut
+// off with RESOURCE_EXHAUSTED rather than silently missing events.
+func (s *controlGRPCServer) Events(req *controlpb.EventsRequest, stream grpc.ServerStreamingServer[controlpb.Event]) error {
+	sub, history := s.api.subscribe(strings.TrimSpace(req.GetTaskId()), req.GetHistory())
+	defer s.api.unsubscribe(sub)
+	for _, event := range history {
+		if err := stream.Send(eventToProto(event)); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-sub.events:
+			if !ok {
+				if sub.lagged {
+					return status.Error(codes.ResourceExhausted, "event stream fell behind")
+				}
+				return nil
+			}
+			if err := stream.Send(eventToProto(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func requiredTaskID(raw string) (string, error) {
+	taskID := strings.TrimSpace(raw)
+	if taskID == "" {
+		return "", status.Error(codes.InvalidArgument, "task_id is required")
+	}
+	return taskID, nil
+}
+
+func runToProto(run controlAPIRun) *controlpb.Run {
+	return &controlpb.Run{
+		RunId:      run.RunID,
+		RootId:     run.RootID,
+		Status:     run.Status,
+		Paused:     run.Paused,
+		StartedAt:  timeToProto(run.StartedAt),
+		FinishedAt: timeToProto(run.FinishedAt),
+		Error:      run.Error,
+		Counts: &controlpb.RunCounts{
+			Total:     int32(run.Counts.Total),
+			Running:   int32(run.Counts.Running),
+			Completed: int32(run.Counts.Completed),
+			Blocked:   int32(run.Counts.Blocked),
+			Failed:    int32(run.Counts.Failed),
+		},
+	}
+}
+
+func taskToProto(task controlAPITask) *controlpb.Task {
+	out := &controlpb.Task{
+		Id:         task.ID,
+		Title:      task.Title,
+		Status:     task.Status,
+		WorkerId:   task.WorkerID,
+		StartedAt:  timeToProto(task.StartedAt),
+		FinishedAt: timeToProto(task.FinishedAt),
+		Reason:     task.Reason,
+	}
+	if pending := task.PendingApproval; pending != nil {
+		out.PendingApproval = &controlpb.ApprovalRequest{
+			ToolCallId:  pending.ToolCallID,
+			Title:       pending.Title,
+			Kind:        pending.Kind,
+			Locations:   pending.Locations,
+			RequestedAt: timestamppb.New(pending.RequestedAt),
+		}
+	}
+	return out
+}
+
+func eventToProto(event contracts.Event) *controlpb.Event {
+	out := &controlpb.Event{
+		Type:      string(event.Type),
+		TaskId:    event.TaskID,
+		TaskTitle: event.TaskTitle,
+		WorkerId:  event.WorkerID,
+		ClonePath: event.ClonePath,
+		QueuePos:  int32(event.QueuePos),
+		Priority:  int32(event.Priority),
+		Message:   event.Message,
+		Metadata:  event.Metadata,
+		RunId:     event.RunID,
+		Seq:       event.Seq,
+	}
+	if !event.Timestamp.IsZero() {
+		out.Timestamp = timestamppb.New(event.Timestamp)
+	}
+	return out
+}
+
+func timeToProto(value *time.Time) *timestamppb.Timestamp {
+	if value == nil {
+		return nil
+	}
+	return timestamppb.New(*value)
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/acp"
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/engine"
+	"github.com/egv/yolo-runner/v2/pkg/controlpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newControlGRPCTestClient(t *testing.T, api *controlAPI) controlpb.RunControlClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := newControlGRPCServer(api)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+		api.closeSubscriptions()
+		server.Stop()
+	})
+	return controlpb.NewRunControlClient(conn)
+}
+
+func authorizedContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+}
+
+func TestControlGRPCRequiresBearerToken(t *testing.T) {
+	api := newControlAPI("secret")
+	client := newControlGRPCTestClient(t, api)
+
+	_, err := client.GetRun(context.Background(), &controlpb.GetRunRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated without a token, got %v", err)
+	}
+	wrong := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong")
+	stream, err := client.Events(wrong, &controlpb.EventsRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated stream with a wrong token, got %v", err)
+	}
+	if _, err := client.GetRun(authorizedContext(t), &controlpb.GetRunRequest{}); err != nil {
+		t.Fatalf("expected GetRun with the token to succeed: %v", err)
+	}
+}
+
+func TestControlGRPCReportsRunAndDrivesLoop(t *testing.T) {
+	api := newControlAPI("secret")
+	loop := &fakeRunController{}
+	client := newControlGRPCTestClient(t, api)
+	ctx := authorizedContext(t)
+
+	if _, err := client.PauseRun(ctx, &controlpb.PauseRunRequest{}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable before the loop is attached, got %v", err)
+	}
+	api.attach(loop)
+	_ = api.Emit(ctx, contracts.Event{Type: contracts.EventTypeRunStarted, RunID: "run-1", TaskID: "root"})
+	_ = api.Emit(ctx, contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1", TaskTitle: "First", WorkerID: "worker-0"})
+	_ = api.Emit(ctx, contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: "task-2", Message: "blocked", Metadata: map[string]string{"triage_reason": "needs input"}})
+
+	run, err := client.PauseRun(ctx, &controlpb.PauseRunRequest{})
+	if err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if !loop.paused || run.GetStatus() != "paused" || run.GetRunId() != "run-1" || run.GetCounts().GetRunning() != 1 || run.GetCounts().GetBlocked() != 1 {
+		t.Fatalf("unexpected run after pause: %v", run)
+	}
+	if run, err := client.ResumeRun(ctx, &controlpb.ResumeRunRequest{}); err != nil || loop.paused || run.GetStatus() != "running" {
+		t.Fatalf("unexpected resume: %v err=%v", run, err)
+	}
+
+	tasks, err := client.ListTasks(ctx, &controlpb.ListTasksRequest{})
+	if err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	if len(tasks.GetTasks()) != 2 || tasks.GetTasks()[0].GetWorkerId() != "worker-0" || tasks.GetTasks()[1].GetReason() != "needs input" {
+		t.Fatalf("unexpected tasks: %v", tasks.GetTasks())
+	}
+
+	loop.inFlight = true
+	cancel, err := client.CancelTask(ctx, &controlpb.CancelTaskRequest{TaskId: "task-1"})
+	if err != nil || !cancel.GetRunning() || len(loop.canceled) != 1 {
+		t.Fatalf("unexpected cancel: %v err=%v canceled=%v", cancel, err, loop.canceled)
+	}
+	if _, err := client.CancelTask(ctx, &controlpb.CancelTaskRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument without task_id, got %v", err)
+	}
+	if _, err := client.RetryTask(ctx, &controlpb.RetryTaskRequest{TaskId: "task-2"}); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	loop.retryErr = agent.ErrTaskRunning
+	if _, err := client.RetryTask(ctx, &controlpb.RetryTaskRequest{TaskId: "task-2"}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition for a running task, got %v", err)
+	}
+}
+
+func TestControlGRPCApproveAnswersPendingPermission(t *testing.T) {
+	api := newControlAPI("secret")
+	client := newControlGRPCTestClient(t, api)
+	ctx := authorizedContext(t)
+
+	if _, err := client.ApproveTask(ctx, &controlpb.ApproveTaskRequest{TaskId: "task-1"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound without a pending approval, got %v", err)
+	}
+	answers := make(chan bool, 1)
+	go func() {
+		allow, _ := api.AskPermission(context.Background(), acp.PermissionRequest{TaskID: "task-1", Kind: "execute"})
+		answers <- allow
+	}()
+	for {
+		tasks, err := client.ListTasks(ctx, &controlpb.ListTasksRequest{})
+		if err != nil {
+			t.Fatalf("list tasks: %v", err)
+		}
+		if len(tasks.GetTasks()) == 0 {
+			_ = api.Emit(ctx, contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1"})
+			continue
+		}
+		if pending := tasks.GetTasks()[0].GetPendingApproval(); pending != nil {
+			if pending.GetKind() != "execute" {
+				t.Fatalf("unexpected pending approval: %v", pending)
+			}
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	resp, err := client.ApproveTask(ctx, &controlpb.ApproveTaskRequest{TaskId: "task-1", Reject: true})
+	if err != nil || resp.GetAllowed() {
+		t.Fatalf("unexpected reject response: %v err=%v", resp, err)
+	}
+	if <-answers {
+		t.Fatalf("expected reject to deny the tool call")
+	}
+}
+
+func TestControlGRPCEventsStreamsHistoryThenLiveEvents(t *testing.T) {
+	api := newControlAPI("secret")
+	client := newControlGRPCTestClient(t, api)
+	ctx := authorizedContext(t)
+	_ = api.Emit(ctx, contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1", Seq: 1})
+	_ = api.Emit(ctx, contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-2", Seq: 2})
+
+	stream, err := client.Events(ctx, &controlpb.EventsRequest{TaskId: "task-1", History: true})
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	first, err := stream.Recv()
+	if err != nil || first.GetTaskId() != "task-1" || first.GetSeq() != 1 {
+		t.Fatalf("expected recorded task-1 event first, got %v err=%v", first, err)
+	}
+
+	// The subscription is registered before history is sent, so these
+	// events reach the stream.
+	_ = api.Emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerOutput, TaskID: "task-2", Message: "other task", Seq: 3})
+	_ = api.Emit(ctx, contracts.Event{
+		Type:      contracts.EventTypeTaskFinished,
+		TaskID:    "task-1",
+		Message:   "closed",
+		Metadata:  map[string]string{"reason": "done"},
+		Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Seq:       4,
+	})
+	live, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("recv live event: %v", err)
+	}
+	if live.GetType() != string(contracts.EventTypeTaskFinished) || live.GetSeq() != 4 || live.GetMetadata()["reason"] != "done" || !live.GetTimestamp().AsTime().Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("unexpected live event: %v", live)
+	}
+
+	api.closeSubscriptions()
+	if _, err := stream.Recv(); err == nil {
+		t.Fatalf("expected the stream to end when subscriptions close")
+	}
+}
+
+func TestControlGRPCEventsStreamsEventsOfProductionRun(t *testing.T) {
+	api := newControlAPI("secret")
+	client := newControlGRPCTestClient(t, api)
+	ctx := authorizedContext(t)
+
+	runner := &fakeAgentRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+	}}
+	err := runWithStorageComponents(context.Background(), runConfig{
+		repoRoot:   t.TempDir(),
+		rootID:     "root",
+		maxTasks:   1,
+		noVCS:      true,
+		serve:      true,
+		serveAddr:  "127.0.0.1:0",
+		controlAPI: api,
+	}, newControlRunTestStorage("root", contracts.Task{ID: "t-1", Title: "Update runbook", ParentID: "root"}), engine.NewTaskEngine(), runner, &fakeVCS{})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	stream, err := client.Events(ctx, &controlpb.EventsRequest{TaskId: "t-1", History: true})
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("expected the run's events for t-1, stream ended: %v", err)
+		}
+		if event.GetTaskId() != "t-1" || event.GetRunId() == "" {
+			t.Fatalf("expected sequenced events for t-1 only, got %v", event)
+		}
+		if event.GetType() == string(contracts.EventTypeTaskFinished) {
+			if event.GetMessage() != string(contracts.TaskStatusClosed) {
+				t.Fatalf("expected t-1 to finish closed, got %v", event)
+			}
+			return
+		}
+	}
+}
+
+func TestControlGRPCEventsCutsOffLaggingSubscriber(t *testing.T) {
+	api := newControlAPI("secret")
+	sub, _ := api.subscribe("", false)
+	for i := 0; i <= controlAPISubscriberBuf; i++ {
+		_ = api.Emit(context.Background(), contracts.Event{Type: contracts.EventTypeRunnerOutput, TaskID: "task-1"})
+	}
+	drained := 0
+	for range sub.events {
+		drained++
+	}
+	if !sub.lagged || drained != controlAPISubscriberBuf {
+		t.Fatalf("expected lagging subscriber to be dropped after %d events, lagged=%v drained=%d", controlAPISubscriberBuf, sub.lagged, drained)
+	}
+	api.unsubscribe(sub)
+}
+
+func TestRunMainRejectsGRPCAddrWithoutServe(t *testing.T) {
+	code := RunMain([]string{"--repo", t.TempDir(), "--root", "root", "--serve-grpc-addr", "127.0.0.1:0"}, func(context.Context, runConfig) error {
+		t.Fatalf("did not expect the run to start")
+		return nil
+	})
+	if code != 1 {
+		t.Fatalf("expected --serve-grpc-addr without --serve to fail, got %d", code)
+	}
+}
+
+func TestControlGRPCEnforcesRoles(t *testing.T) {
+	api := newControlAPIWithAccess(newRoleControlAccess())
+	api.attach(&fakeRunController{})
+	client := newControlGRPCTestClient(t, api)
+	withToken := func(token string) context.Context {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		t.Cleanup(cancel)
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+
+	if _, err := client.GetRun(withToken("viewer-token"), &controlpb.GetRunRequest{}); err != nil {
+		t.Fatalf("expected viewer to read the run: %v", err)
+	}
+	if _, err := client.PauseRun(withToken("viewer-token"), &controlpb.PauseRunRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied for a viewer pausing, got %v", err)
+	}
+	if _, err := client.CancelTask(withToken("operator-token"), &controlpb.CancelTaskRequest{TaskId: "task-1"}); err != nil {
+		t.Fatalf("expected operator to cancel: %v", err)
+	}
+	if _, err := client.ApproveTask(withToken("operator-token"), &controlpb.ApproveTaskRequest{TaskId: "task-1"}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied for an operator approving, got %v", err)
+	}
+	if _, err := client.ApproveTask(withToken("approver-token"), &controlpb.ApproveTaskRequest{TaskId: "task-1"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected approver to reach ApproveTask, got %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	oidcClockSkew      = time.Minute
+	oidcKeysRefetch    = time.Minute
+	oidcFetchTimeout   = 10 * time.Second
+	oidcMaxDocumentLen = 1 << 20
+)
+
+// oidcVerifier checks ID tokens issued by one issuer for one audience. The
+// signing keys are discovered from the issuer's openid-configuration on first
+// use and fetched again, at most once a minute, when a token names a key that
+// is not known yet.
+type oidcVerifier struct {
+	issuer   string
+	audience string
+	client   *http.Client
+	now      func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func newOIDCVerifier(issuer string, audience string, client *http.Client) *oidcVerifier {
+	return &oidcVerifier{issuer: issuer, audience: audience, client: client, now: time.Now}
+}
+
+// verify checks the signature, issuer, audience and lifetime of an RS256 or
+// ES256 signed token and returns its claims.
+func (v *oidcVerifier) verify(ctx context.Context, token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token is not a JWT")
+	}
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("token signature: %w", err)
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+	claims := map[string]any{}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("token claims: %w", err)
+	}
+	if oidcClaimString(claims["iss"]) != v.issuer {
+		return nil, fmt.Errorf("token issuer %q is not %q", oidcClaimString(claims["iss"]), v.issuer)
+	}
+	audienceMatched := false
+	for _, audience := range oidcClaimStrings(claims["aud"]) {
+		audienceMatched = audienceMatched || audience == v.audience
+	}
+	if !audienceMatched {
+		return nil, fmt.Errorf("token is not issued for audience %q", v.audience)
+	}
+	now := v.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(oidcClockSkew)) {
+		return nil, errors.New("token is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(oidcClockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return nil, errors.New("token is not valid yet")
+	}
+	return claims, nil
+}
+
+func decodeJWTSegment(segment string, value any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, value)
+}
+
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("token signing key is not an RSA key")
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("token signature is invalid")
+		}
+		return nil
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return errors.New("token signature is invalid")
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return errors.New("token signature is invalid")
+		}
+		return nil
+	}
+	return fmt.Errorf("token algorithm %q is not supported", alg)
+}
+
+// key returns the signing key kid names. A token without kid may use the
+// issuer's only key.
+func (v *oidcVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if key, ok := lookupOIDCKey(v.keys, kid); ok {
+		return key, nil
+	}
+	if v.keys != nil && v.now().Sub(v.fetchedAt) < oidcKeysRefetch {
+		return nil, fmt.Errorf("unknown token signing key %q", kid)
+	}
+	keys, err := v.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v.keys, v.fetchedAt = keys, v.now()
+	if key, ok := lookupOIDCKey(keys, kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown token signing key %q", kid)
+}
+
+func lookupOIDCKey(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if key, ok := keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+type oidcJSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchKeys reads the issuer's JWKS. Keys this verifier cannot use are
+// skipped.
+func (v *oidcVerifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	discovery := struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}{}
+	if err := v.getJSON(ctx, strings.TrimRight(v.issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if discovery.Issuer != v.issuer {
+		return nil, fmt.Errorf("OIDC discovery issuer %q is not %q", discovery.Issuer, v.issuer)
+	}
+	if strings.TrimSpace(discovery.JWKSURI) == "" {
+		return nil, fmt.Errorf("OIDC discovery for %s has no jwks_uri", v.issuer)
+	}
+	set := struct {
+		Keys []oidcJSONWebKey `json:"keys"`
+	}{}
+	if err := v.getJSON(ctx, discovery.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+func (k oidcJSONWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 || len(n) == 0 {
+			return nil, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("curve %q is not supported", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid P-256 key")
+		}
+		return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), append(append([]byte{4}, x...), y...))
+	}
+	return nil, fmt.Errorf("key type %q is not supported", k.Kty)
+}
+
+func (v *oidcVerifier) getJSON(ctx context.Context, url string, value any) error {
+	ctx, cancel := context.WithTimeout(ctx, oidcFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("OIDC request %s: %w", url, err)
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("OIDC request %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("OIDC request %s: %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oidcMaxDocumentLen)).Decode(value); err != nil {
+		return fmt.Errorf("OIDC response %s: %w", url, err)
+	}
+	return nil
+}
+
+func oidcClaimString(value any) string {
+	text, _ := value.(string)
+	return text
+}
+
+// oidcClaimStrings returns a string claim, or the strings of a list claim
+// such as groups.
+func oidcClaimStrings(value any) []string {
+	switch typed := value.(type) {
+	case string:
+		return []string{typed}
+	case []any:
+		values := make([]string, 0, len(typed))
+		for _, item := range typed {
+			if text, ok := item.(string); ok {
+				values = append(values, text)
+			}
+		}
+		return values
+	}
+	return nil
+}
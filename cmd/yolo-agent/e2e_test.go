@@ -71,6 +71,33 @@ func TestE2E_YoloAgentRunCompletesSeededTKTask(t *testing.T) {
 	}
 }
 
+func TestE2E_NoVCSRunsTaskInRepoDirectoryWithoutGit(t *testing.T) {
+	workDir := t.TempDir()
+	taskManager := newInMemoryTaskManager(contracts.Task{ID: "t-1", Title: "Update runbook", ParentID: "root", Status: contracts.TaskStatusOpen})
+	runner := &fakeAgentRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+	}}
+	vcs := &fakeVCS{}
+
+	err := runWithComponents(context.Background(), runConfig{repoRoot: workDir, rootID: "root", maxTasks: 1, noVCS: true}, taskManager, runner, vcs)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if got := taskManager.statusOf("t-1"); got != contracts.TaskStatusClosed {
+		t.Fatalf("expected task status closed, got %q", got)
+	}
+	if len(runner.requests) == 0 || runner.requests[0].RepoRoot != workDir {
+		t.Fatalf("expected runner to work directly in %q, got %#v", workDir, runner.requests)
+	}
+	if vcs.mergeCalls != 0 {
+		t.Fatalf("expected no merges without VCS, got %d", vcs.mergeCalls)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, ".yolo-runner", "clones")); !os.IsNotExist(err) {
+		t.Fatalf("expected no task clones without VCS, got err=%v", err)
+	}
+}
+
 func TestE2E_QualityGateBlocksLowQualityTaskAndCreatesComment(t *testing.T) {
 	repo := initSeededRepo(t)
 
@@ -486,6 +513,13 @@ profiles:
 					},
 				},
 			})
+		case strings.Contains(query, "CreateIssueCommentForTaskData"):
+			if !strings.Contains(query, `run_id=`) {
+				t.Fatalf("unexpected task data comment: %q", query)
+			}
+			writeResponse(t, w, map[string]any{
+				"commentCreate": map[string]any{"success": true},
+			})
 		case strings.Contains(query, "ReadIssueWorkflowStatesForWrite"):
 			writeResponse(t, w, map[string]any{
 				"issue": map[string]any{
@@ -1036,6 +1070,13 @@ profiles:
 					},
 				},
 			})
+		case strings.Contains(query, "CreateIssueCommentForTaskData"):
+			if !strings.Contains(query, `run_id=`) {
+				t.Fatalf("unexpected task data comment: %q", query)
+			}
+			writeResponse(t, w, map[string]any{
+				"commentCreate": map[string]any{"success": true},
+			})
 		case strings.Contains(query, "ReadIssueWorkflowStatesForWrite"):
 			writeResponse(t, w, map[string]any{
 				"issue": map[string]any{
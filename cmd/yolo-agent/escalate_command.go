@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/escalation"
+)
+
+func runEscalateCommand(args []string) int {
+	fs := flag.NewFlagSet("yolo-agent escalate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	repoRoot := fs.String("repo", ".", "Repository root")
+	profile := fs.String("profile", "", "Tracker profile name from .yolo-runner/config.yaml")
+	dryRun := fs.Bool("dry-run", false, "List the blocked tasks awaiting escalation without touching the tracker")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments for escalate: %s\n", strings.Join(fs.Args(), " "))
+		return 1
+	}
+	if err := escalateBlockedTasks(context.Background(), *repoRoot, strings.TrimSpace(*profile), *dryRun, os.Getenv, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// escalateBlockedTasks escalates the blocked tasks a run recorded under
+// .yolo-runner/ once agent.escalation.after has passed, for use from cron
+// between runs.
+func escalateBlockedTasks(ctx context.Context, repoRoot string, profileName string, dryRun bool, getenv func(string) string, out io.Writer) error {
+	defaults, err := loadYoloAgentConfigDefaults(repoRoot)
+	if err != nil {
+		return err
+	}
+	policy := defaults.Escalation
+	if !policy.Enabled() {
+		return errors.New("agent.escalation.after is not set in " + trackerConfigRelPath)
+	}
+	statePath := filepath.Join(repoRoot, escalation.StateRelPath)
+	if dryRun {
+		escalator, err := escalation.New(policy, statePath, nil, nil)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, task := range escalator.Pending() {
+			due := "due"
+			if wait := policy.After - now.Sub(task.BlockedAt); wait > 0 {
+				due = "due in " + wait.Round(time.Minute).String()
+			}
+			fmt.Fprintf(out, "%s blocked since %s, %s\n", task.TaskID, task.BlockedAt.UTC().Format(time.RFC3339), due)
+		}
+		return nil
+	}
+
+	profile, err := resolveTrackerProfile(repoRoot, profileName, "", getenv)
+	if err != nil {
+		return err
+	}
+	tracker, err := buildStorageBackendForTracker(repoRoot, profile)
+	if err != nil {
+		return fmt.Errorf("build tracker %q: %w", profile.Name, err)
+	}
+	escalator, err := escalation.New(policy, statePath, tracker, trackerStatusFunc(tracker))
+	if err != nil {
+		return err
+	}
+	results, err := escalator.EscalateDue(ctx)
+	writeEscalationResults(out, results, nil)
+	return err
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/escalation"
+)
+
+// maxEscalationCheckInterval bounds how late a blocked task is escalated
+// while a run lasts.
+const maxEscalationCheckInterval = time.Minute
+
+// escalationEventSink returns a sink that records blocked tasks when
+// agent.escalation is set, and escalates the due ones while the run lasts.
+// The close function makes a last check and stops. Dry runs never touch the
+// tracker.
+func escalationEventSink(cfg runConfig, tracker any, out io.Writer) (contracts.EventSink, func()) {
+	if !cfg.escalation.Enabled() || cfg.dryRun {
+		return nil, nil
+	}
+	escalator, err := escalation.New(cfg.escalation, filepath.Join(cfg.repoRoot, escalation.StateRelPath), tracker, trackerStatusFunc(tracker))
+	if err != nil {
+		fmt.Fprintf(out, "warning: agent.escalation ignored: %v\n", err)
+		return nil, nil
+	}
+	interval := cfg.escalation.After
+	if interval > maxEscalationCheckInterval {
+		interval = maxEscalationCheckInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				results, err := escalator.EscalateDue(ctx)
+				writeEscalationResults(out, results, err)
+			}
+		}
+	}()
+	closeFn := func() {
+		cancel()
+		<-done
+		results, err := escalator.EscalateDue(context.Background())
+		writeEscalationResults(out, results, err)
+	}
+	return escalator, closeFn
+}
+
+// trackerStatusFunc reads task statuses through a task manager or storage
+// backend, or returns nil when tracker is neither.
+func trackerStatusFunc(tracker any) escalation.StatusFunc {
+	switch t := tracker.(type) {
+	case contracts.TaskManager:
+		return func(ctx context.Context, taskID string) (contracts.TaskStatus, error) {
+			task, err := t.GetTask(ctx, taskID)
+			return task.Status, err
+		}
+	case contracts.StorageBackend:
+		return func(ctx context.Context, taskID string) (contracts.TaskStatus, error) {
+			task, err := t.GetTask(ctx, taskID)
+			if err != nil {
+				return "", err
+			}
+			if task == nil {
+				return "", fmt.Errorf("task %q not found", taskID)
+			}
+			return task.Status, nil
+		}
+	default:
+		return nil
+	}
+}
+
+func writeEscalationResults(out io.Writer, results []escalation.Result, err error) {
+	for _, result := range results {
+		steps := append([]string{}, result.Actions...)
+		for _, note := range result.Notes {
+			steps = append(steps, "skipped: "+note)
+		}
+		fmt.Fprintf(out, "escalated %s (blocked since %s): %s\n", result.TaskID, result.BlockedAt.UTC().Format(time.RFC3339), strings.Join(steps, ", "))
+	}
+	if err != nil {
+		fmt.Fprintf(out, "warning: escalation failed, will retry: %v\n", err)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/escalation"
+)
+
+func TestEscalationEventSinkRequiresPolicyAndRealRun(t *testing.T) {
+	repoRoot := t.TempDir()
+	var out bytes.Buffer
+	if sink, _ := escalationEventSink(runConfig{repoRoot: repoRoot}, struct{}{}, &out); sink != nil {
+		t.Fatalf("expected no sink without agent.escalation.after")
+	}
+	policy := escalation.Policy{After: time.Hour}
+	if sink, _ := escalationEventSink(runConfig{repoRoot: repoRoot, dryRun: true, escalation: policy}, struct{}{}, &out); sink != nil {
+		t.Fatalf("expected no sink in dry run")
+	}
+	sink, closeFn := escalationEventSink(runConfig{repoRoot: repoRoot, escalation: policy}, struct{}{}, &out)
+	if sink == nil {
+		t.Fatalf("expected sink for an escalation policy")
+	}
+	if err := sink.Emit(context.Background(), contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: "t-1", Message: string(contracts.TaskStatusBlocked), Timestamp: time.Now()}); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+	closeFn()
+	if _, err := os.Stat(filepath.Join(repoRoot, escalation.StateRelPath)); err != nil {
+		t.Fatalf("expected the blocked task recorded for a later escalation: %v", err)
+	}
+}
+
+func TestEscalateBlockedTasksDryRunListsPendingTasks(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  escalation:
+    after: 30m
+`)
+	escalator, err := escalation.New(escalation.Policy{After: 30 * time.Minute}, filepath.Join(repoRoot, escalation.StateRelPath), nil, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	blockedAt := time.Now().Add(-time.Hour).UTC()
+	if err := escalator.Emit(context.Background(), contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: "t-1", Message: string(contracts.TaskStatusBlocked), Timestamp: blockedAt}); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := escalateBlockedTasks(context.Background(), repoRoot, "", true, func(string) string { return "" }, &out); err != nil {
+		t.Fatalf("escalate: %v", err)
+	}
+	if want := "t-1 blocked since " + blockedAt.Format(time.RFC3339) + ", due"; !strings.Contains(out.String(), want) {
+		t.Fatalf("expected %q, got %q", want, out.String())
+	}
+}
+
+func TestEscalateBlockedTasksRequiresPolicy(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+`)
+	err := escalateBlockedTasks(context.Background(), repoRoot, "", true, func(string) string { return "" }, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "agent.escalation.after") {
+		t.Fatalf("expected a missing policy error, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+type eventReplayOptions struct {
+	// speed divides the recorded gaps between events; 0 replays without delay.
+	speed float64
+	// maxGap caps a single recorded gap before speed is applied; 0 keeps it.
+	maxGap time.Duration
+}
+
+func runEventsCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: yolo-agent events <replay|query|junit> [flags]")
+		return 1
+	}
+
+	switch args[0] {
+	case "replay":
+		return runEventsReplayCommand(args[1:])
+	case "query":
+		return runEventsQueryCommand(args[1:])
+	case "junit":
+		return runEventsJUnitCommand(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown events command: %s\n", args[0])
+		fmt.Fprintln(os.Stderr, "usage: yolo-agent events <replay|query|junit> [flags]")
+		return 1
+	}
+}
+
+func runEventsReplayCommand(args []string) int {
+	fs := flag.NewFlagSet("yolo-agent events replay", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	repoRoot := fs.String("repo", ".", "Repository root")
+	file := fs.String("file", "", "Events log to replay (default: runner-logs/agent.events.jsonl under --repo); rotated segments are included")
+	speed := fs.Float64("speed", 1, "Replay speed multiplier; 0 emits every event without delay")
+	maxGap := fs.Duration("max-gap", 0, "Longest recorded pause to reproduce before --speed is applied (0 keeps every pause)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments for events replay: %s\n", strings.Join(fs.Args(), " "))
+		return 1
+	}
+	if *speed < 0 {
+		fmt.Fprintln(os.Stderr, "--speed must be greater than or equal to 0")
+		return 1
+	}
+	if *maxGap < 0 {
+		fmt.Fprintln(os.Stderr, "--max-gap must be greater than or equal to 0")
+		return 1
+	}
+
+	path := strings.TrimSpace(*file)
+	if path == "" {
+		path = filepath.Join(*repoRoot, "runner-logs", "agent.events.jsonl")
+	}
+	log, err := contracts.OpenEventLog(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer log.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	skipped, err := replayEvents(ctx, log, os.Stdout, eventReplayOptions{speed: *speed, maxGap: *maxGap}, sleepWithContext)
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "skipped %d malformed event lines\n", skipped)
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// replayEvents re-emits the events in reader as NDJSON, waiting between them
+// for the recorded gap scaled by the options. Malformed lines are skipped and
+// counted so a truncated capture still replays.
+func replayEvents(ctx context.Context, reader io.Reader, out io.Writer, options eventReplayOptions, sleep func(context.Context, time.Duration) error) (int, error) {
+	decoder := contracts.NewEventDecoder(reader)
+	stream := contracts.NewEventStream(out)
+	skipped := 0
+	previous := time.Time{}
+	for {
+		event, err := decoder.Next()
+		if err == io.EOF {
+			return skipped, nil
+		}
+		if err != nil {
+			if !isMalformedEventLine(err) {
+				return skipped, err
+			}
+			skipped++
+			continue
+		}
+		if wait := replayDelay(previous, event.Timestamp, options); wait > 0 {
+			if err := sleep(ctx, wait); err != nil {
+				return skipped, err
+			}
+		}
+		if !event.Timestamp.IsZero() {
+			previous = event.Timestamp
+		}
+		if err := stream.Write(event); err != nil {
+			return skipped, err
+		}
+	}
+}
+
+func isMalformedEventLine(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var timeErr *time.ParseError
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.As(err, &timeErr)
+}
+
+func replayDelay(previous time.Time, next time.Time, options eventReplayOptions) time.Duration {
+	if options.speed <= 0 || previous.IsZero() || next.IsZero() {
+		return 0
+	}
+	gap := next.Sub(previous)
+	if gap <= 0 {
+		return 0
+	}
+	if options.maxGap > 0 && gap > options.maxGap {
+		gap = options.maxGap
+	}
+	return time.Duration(float64(gap) / options.speed)
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
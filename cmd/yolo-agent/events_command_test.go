@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestReplayEventsScalesRecordedGaps(t *testing.T) {
+	input := strings.Join([]string{
+		`{"type":"task_started","task_id":"t-1","ts":"2026-02-10T12:00:00Z"}`,
+		`{"type":"runner_output","task_id":"t-1","message":"hi","ts":"2026-02-10T12:00:04Z"}`,
+		`{"type":"runner_output","task_id":`,
+		`{"type":"runner_heartbeat","task_id":"t-1","ts":"2026-02-10T12:10:04Z"}`,
+		`{"type":"task_finished","task_id":"t-1","message":"completed","ts":"2026-02-10T12:10:05Z"}`,
+	}, "\n") + "\n"
+	out := &bytes.Buffer{}
+	waits := []time.Duration{}
+	sleep := func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	skipped, err := replayEvents(context.Background(), strings.NewReader(input), out, eventReplayOptions{speed: 2, maxGap: time.Minute}, sleep)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if skipped != 1 {
+		t.Fatalf("expected one malformed line skipped, got %d", skipped)
+	}
+	if len(waits) != 3 || waits[0] != 2*time.Second || waits[1] != 30*time.Second || waits[2] != 500*time.Millisecond {
+		t.Fatalf("expected gaps halved and capped at max-gap, got %v", waits)
+	}
+
+	decoder := contracts.NewEventDecoder(out)
+	types := []string{}
+	for {
+		event, err := decoder.Next()
+		if err != nil {
+			break
+		}
+		types = append(types, string(event.Type))
+	}
+	if got := strings.Join(types, ","); got != "task_started,runner_output,runner_heartbeat,task_finished" {
+		t.Fatalf("unexpected replayed events %q", got)
+	}
+}
+
+func TestReplayEventsWithoutDelayAtSpeedZero(t *testing.T) {
+	input := `{"type":"task_started","ts":"2026-02-10T12:00:00Z"}` + "\n" + `{"type":"task_finished","ts":"2026-02-10T13:00:00Z"}` + "\n"
+	sleep := func(context.Context, time.Duration) error {
+		t.Fatalf("expected no sleep at speed 0")
+		return nil
+	}
+	out := &bytes.Buffer{}
+	if _, err := replayEvents(context.Background(), strings.NewReader(input), out, eventReplayOptions{}, sleep); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if strings.Count(out.String(), "\n") != 2 {
+		t.Fatalf("expected both events replayed, got %q", out.String())
+	}
+}
+
+func TestReplayEventsStopsWhenSleepIsCancelled(t *testing.T) {
+	input := `{"type":"task_started","ts":"2026-02-10T12:00:00Z"}` + "\n" + `{"type":"task_finished","ts":"2026-02-10T12:00:01Z"}` + "\n"
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	out := &bytes.Buffer{}
+	_, err := replayEvents(ctx, strings.NewReader(input), out, eventReplayOptions{speed: 1}, sleepWithContext)
+	if err != context.Canceled {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+	if strings.Count(out.String(), "\n") != 1 {
+		t.Fatalf("expected only the first event before cancellation, got %q", out.String())
+	}
+}
+
+func TestRunEventsCommandRejectsUnknownSubcommand(t *testing.T) {
+	if code := runEventsCommand([]string{"tail"}); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if code := runEventsCommand(nil); code != 1 {
+		t.Fatalf("expected exit code 1 without a subcommand, got %d", code)
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// junitTestSuites is the JUnit XML report: one suite per run in the events
+// log and one test case per task the run worked on.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitOutcome `xml:"failure,omitempty"`
+	Error     *junitOutcome `xml:"error,omitempty"`
+	Skipped   *junitOutcome `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+
+	started  time.Time
+	finished *contracts.Event
+}
+
+type junitOutcome struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+func runEventsJUnitCommand(args []string) int {
+	fs := flag.NewFlagSet("yolo-agent events junit", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	repoRoot := fs.String("repo", ".", "Repository root")
+	file := fs.String("file", "", "Events log to convert (default: runner-logs/agent.events.jsonl under --repo); rotated segments are included")
+	output := fs.String("output", "", "Write the JUnit XML report to this path instead of stdout")
+	runID := fs.String("run", "", "Only report the run with this ID")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments for events junit: %s\n", strings.Join(fs.Args(), " "))
+		return 1
+	}
+
+	path := strings.TrimSpace(*file)
+	if path == "" {
+		path = filepath.Join(*repoRoot, "runner-logs", "agent.events.jsonl")
+	}
+	log, err := contracts.OpenEventLog(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer log.Close()
+
+	events, skipped, err := queryEvents(log, eventQuery{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "skipped %d malformed event lines\n", skipped)
+	}
+	report := buildJUnitReport(events, strings.TrimSpace(*runID))
+	if strings.TrimSpace(*runID) != "" && len(report.Suites) == 0 {
+		fmt.Fprintf(os.Stderr, "no events for run %q in %s\n", *runID, path)
+		return 1
+	}
+
+	target := strings.TrimSpace(*output)
+	if target == "" {
+		if err := writeJUnitReport(os.Stdout, report); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := writeJUnitReport(out, report); err != nil {
+		out.Close()
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := out.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// junitRun collects the task outcomes of one run.
+type junitRun struct {
+	id       string
+	rootID   string
+	started  time.Time
+	finished time.Time
+	cases    map[string]*junitTestCase
+}
+
+// buildJUnitReport turns the task outcomes in events into test cases. A
+// closed task passes, a failed task is a failure, a blocked task is an error
+// and a task that started but never finished is skipped. A task finished more
+// than once in a run is reported with its last outcome. Events without a run
+// ID, from logs written before runs were sequenced, form one suite.
+func buildJUnitReport(events []contracts.Event, onlyRunID string) junitTestSuites {
+	runs := []*junitRun{}
+	byID := map[string]*junitRun{}
+	for _, event := range events {
+		if onlyRunID != "" && event.RunID != onlyRunID {
+			continue
+		}
+		run, ok := byID[event.RunID]
+		if !ok {
+			run = &junitRun{id: event.RunID, cases: map[string]*junitTestCase{}}
+			byID[event.RunID] = run
+			runs = append(runs, run)
+		}
+		if !event.Timestamp.IsZero() {
+			if run.started.IsZero() || event.Timestamp.Before(run.started) {
+				run.started = event.Timestamp
+			}
+			if event.Timestamp.After(run.finished) {
+				run.finished = event.Timestamp
+			}
+		}
+		switch event.Type {
+		case contracts.EventTypeRunStarted:
+			run.rootID = event.TaskID
+		case contracts.EventTypeTaskStarted, contracts.EventTypeTaskFinished:
+			if strings.TrimSpace(event.TaskID) == "" {
+				continue
+			}
+			testCase, ok := run.cases[event.TaskID]
+			if !ok {
+				testCase = &junitTestCase{Name: event.TaskID}
+				run.cases[event.TaskID] = testCase
+			}
+			if title := strings.TrimSpace(event.TaskTitle); title != "" {
+				testCase.Name = event.TaskID + " " + title
+			}
+			if event.Type == contracts.EventTypeTaskStarted {
+				if testCase.started.IsZero() {
+					testCase.started = event.Timestamp
+				}
+				continue
+			}
+			finished := event
+			testCase.finished = &finished
+		}
+	}
+
+	report := junitTestSuites{Name: "yolo-agent"}
+	var total time.Duration
+	for _, run := range runs {
+		suite := junitTestSuite{Name: "yolo-agent"}
+		if run.id != "" {
+			suite.Name = "yolo-agent run " + run.id
+		}
+		classname := run.rootID
+		if classname == "" {
+			classname = "yolo-agent"
+		}
+		ordered := make([]*junitTestCase, 0, len(run.cases))
+		for _, testCase := range run.cases {
+			ordered = append(ordered, testCase)
+		}
+		sort.Slice(ordered, func(i, j int) bool {
+			if !ordered[i].started.Equal(ordered[j].started) {
+				return ordered[i].started.Before(ordered[j].started)
+			}
+			return ordered[i].Name < ordered[j].Name
+		})
+		for _, testCase := range ordered {
+			finished := finishJUnitTestCase(*testCase, classname)
+			suite.Tests++
+			switch {
+			case finished.Failure != nil:
+				suite.Failures++
+			case finished.Error != nil:
+				suite.Errors++
+			case finished.Skipped != nil:
+				suite.Skipped++
+			}
+			suite.Cases = append(suite.Cases, finished)
+		}
+		elapsed := time.Duration(0)
+		if !run.started.IsZero() {
+			elapsed = run.finished.Sub(run.started)
+			suite.Timestamp = run.started.UTC().Format("2006-01-02T15:04:05")
+		}
+		suite.Time = junitSeconds(elapsed)
+		total += elapsed
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+	report.Time = junitSeconds(total)
+	return report
+}
+
+func finishJUnitTestCase(testCase junitTestCase, classname string) junitTestCase {
+	testCase.Classname = classname
+	if testCase.finished == nil {
+		testCase.Time = junitSeconds(0)
+		testCase.Skipped = &junitOutcome{Message: "task did not finish in this run"}
+		return testCase
+	}
+	event := *testCase.finished
+	elapsed := time.Duration(0)
+	if !testCase.started.IsZero() && event.Timestamp.After(testCase.started) {
+		elapsed = event.Timestamp.Sub(testCase.started)
+	}
+	testCase.Time = junitSeconds(elapsed)
+	testCase.SystemOut = junitMetadataText(event.Metadata)
+	reason := strings.TrimSpace(event.Metadata["triage_reason"])
+	switch contracts.TaskStatus(event.Message) {
+	case contracts.TaskStatusClosed:
+	case contracts.TaskStatusFailed:
+		testCase.Failure = &junitOutcome{Message: reason, Type: string(contracts.TaskStatusFailed), Text: reason}
+	case contracts.TaskStatusBlocked:
+		testCase.Error = &junitOutcome{Message: reason, Type: string(contracts.TaskStatusBlocked), Text: reason}
+	default:
+		testCase.Error = &junitOutcome{Message: fmt.Sprintf("task finished as %q", event.Message), Type: event.Message}
+	}
+	return testCase
+}
+
+// junitMetadataText lists the task_finished metadata, such as triage
+// details and who completed the task, one key=value per line.
+func junitMetadataText(metadata map[string]string) string {
+	if len(metadata) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, key+"="+metadata[key])
+	}
+	return strings.Join(lines, "\n")
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func writeJUnitReport(out io.Writer, report junitTestSuites) error {
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
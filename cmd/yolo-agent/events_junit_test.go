@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const eventsJUnitFixture = `{"type":"run_started","task_id":"root","task_title":"run","run_id":"r-1","seq":1,"ts":"2026-02-10T12:00:00Z"}
+{"type":"task_started","task_id":"yr-1","task_title":"Add login","run_id":"r-1","seq":2,"ts":"2026-02-10T12:00:10Z"}
+{"type":"task_started","task_id":"yr-2","task_title":"Fix <nav> & footer","run_id":"r-1","seq":3,"ts":"2026-02-10T12:00:20Z"}
+{"type":"task_started","task_id":"yr-3","task_title":"Flaky task","run_id":"r-1","seq":4,"ts":"2026-02-10T12:00:30Z"}
+{"type":"task_started","task_id":"yr-4","task_title":"Interrupted","run_id":"r-1","seq":5,"ts":"2026-02-10T12:00:40Z"}
+{"type":"task_finished","task_id":"yr-1","task_title":"Add login","run_id":"r-1","seq":6,"message":"closed","metadata":{"completed_by_backend":"codex"},"ts":"2026-02-10T12:01:10Z"}
+{"type":"task_finished","task_id":"yr-2","task_title":"Fix <nav> & footer","run_id":"r-1","seq":7,"message":"failed","metadata":{"triage_status":"failed","triage_reason":"tests fail"},"ts":"2026-02-10T12:02:20Z"}
+{"type":"task_finished","task_id":"yr-3","task_title":"Flaky task","run_id":"r-1","seq":8,"message":"blocked","metadata":{"triage_status":"blocked","triage_reason":"runner timeout"},"ts":"2026-02-10T12:03:30Z"}
+{"type":"run_finished","task_id":"root","task_title":"run","run_id":"r-1","seq":9,"ts":"2026-02-10T12:05:00Z"}
+{"type":"run_started","task_id":"root","task_title":"run","run_id":"r-2","seq":1,"ts":"2026-02-11T09:00:00Z"}
+{"type":"task_started","task_id":"yr-3","task_title":"Flaky task","run_id":"r-2","seq":2,"ts":"2026-02-11T09:00:05Z"}
+{"type":"task_finished","task_id":"yr-3","task_title":"Flaky task","run_id":"r-2","seq":3,"message":"closed","ts":"2026-02-11T09:01:05Z"}
+{"type":"run_finished","task_id":"root","task_title":"run","run_id":"r-2","seq":4,"ts":"2026-02-11T09:02:00Z"}
+`
+
+func TestBuildJUnitReportMapsTaskOutcomesPerRun(t *testing.T) {
+	events, _, err := queryEvents(strings.NewReader(eventsJUnitFixture), eventQuery{})
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	report := buildJUnitReport(events, "")
+	if len(report.Suites) != 2 || report.Tests != 5 || report.Failures != 1 || report.Errors != 1 || report.Skipped != 1 {
+		t.Fatalf("unexpected report totals: %#v", report)
+	}
+	first := report.Suites[0]
+	if first.Name != "yolo-agent run r-1" || first.Time != "300.000" || first.Timestamp != "2026-02-10T12:00:00" {
+		t.Fatalf("unexpected first suite: %#v", first)
+	}
+	want := []struct {
+		name    string
+		time    string
+		outcome string
+	}{
+		{name: "yr-1 Add login", time: "60.000", outcome: "pass"},
+		{name: "yr-2 Fix <nav> & footer", time: "120.000", outcome: "failure"},
+		{name: "yr-3 Flaky task", time: "180.000", outcome: "error"},
+		{name: "yr-4 Interrupted", time: "0.000", outcome: "skipped"},
+	}
+	if len(first.Cases) != len(want) {
+		t.Fatalf("expected %d cases, got %#v", len(want), first.Cases)
+	}
+	for i, testCase := range first.Cases {
+		outcome := "pass"
+		switch {
+		case testCase.Failure != nil:
+			outcome = "failure"
+		case testCase.Error != nil:
+			outcome = "error"
+		case testCase.Skipped != nil:
+			outcome = "skipped"
+		}
+		if testCase.Name != want[i].name || testCase.Time != want[i].time || outcome != want[i].outcome || testCase.Classname != "root" {
+			t.Fatalf("case %d: expected %+v, got %#v (%s)", i, want[i], testCase, outcome)
+		}
+	}
+	if first.Cases[1].Failure.Message != "tests fail" || !strings.Contains(first.Cases[1].SystemOut, "triage_status=failed") {
+		t.Fatalf("expected triage details on the failed case, got %#v", first.Cases[1])
+	}
+
+	only := buildJUnitReport(events, "r-2")
+	if len(only.Suites) != 1 || only.Tests != 1 || only.Failures+only.Errors+only.Skipped != 0 {
+		t.Fatalf("expected only the passing second run, got %#v", only)
+	}
+}
+
+func TestRunEventsJUnitCommandWritesValidXML(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "agent.events.jsonl")
+	if err := os.WriteFile(logPath, []byte(eventsJUnitFixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "reports", "junit.xml")
+	if code := runEventsCommand([]string{"junit", "--file", logPath, "--output", output}); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte(xml.Header)) || !bytes.Contains(data, []byte(`name="yr-2 Fix &lt;nav&gt; &amp; footer"`)) {
+		t.Fatalf("expected escaped JUnit XML, got %s", data)
+	}
+	decoded := junitTestSuites{}
+	if err := xml.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("expected the report to parse as XML: %v", err)
+	}
+	if decoded.Tests != 5 || len(decoded.Suites) != 2 {
+		t.Fatalf("unexpected decoded report: %#v", decoded)
+	}
+
+	if code := runEventsCommand([]string{"junit", "--file", logPath, "--run", "r-9"}); code != 1 {
+		t.Fatalf("expected an unknown run to fail, got %d", code)
+	}
+}
+
+func TestFinishJUnitTestCaseReportsUnknownStatusAsError(t *testing.T) {
+	testCase := finishJUnitTestCase(junitTestCase{Name: "yr-1", finished: &contracts.Event{Message: "cancelled"}}, "root")
+	if testCase.Error == nil || !strings.Contains(testCase.Error.Message, "cancelled") {
+		t.Fatalf("expected an unknown status to be an error, got %#v", testCase)
+	}
+}
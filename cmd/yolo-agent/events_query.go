@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	eventsQueryFormatTable = "table"
+	eventsQueryFormatJSON  = "json"
+	eventsQueryFormatCSV   = "csv"
+)
+
+type eventQuery struct {
+	taskIDs   map[string]struct{}
+	workerIDs map[string]struct{}
+	types     map[contracts.EventType]struct{}
+	since     time.Time
+	until     time.Time
+	limit     int
+}
+
+func (q eventQuery) matches(event contracts.Event) bool {
+	if len(q.taskIDs) > 0 {
+		if _, ok := q.taskIDs[event.TaskID]; !ok {
+			return false
+		}
+	}
+	if len(q.workerIDs) > 0 {
+		if _, ok := q.workerIDs[event.WorkerID]; !ok {
+			return false
+		}
+	}
+	if len(q.types) > 0 {
+		if _, ok := q.types[event.Type]; !ok {
+			return false
+		}
+	}
+	if !q.since.IsZero() && (event.Timestamp.IsZero() || event.Timestamp.Before(q.since)) {
+		return false
+	}
+	if !q.until.IsZero() && (event.Timestamp.IsZero() || event.Timestamp.After(q.until)) {
+		return false
+	}
+	return true
+}
+
+func runEventsQueryCommand(args []string) int {
+	fs := flag.NewFlagSet("yolo-agent events query", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	repoRoot := fs.String("repo", ".", "Repository root")
+	file := fs.String("file", "", "Events log to query (default: runner-logs/agent.events.jsonl under --repo); rotated segments are included")
+	task := fs.String("task", "", "Comma-separated task IDs to keep")
+	worker := fs.String("worker", "", "Comma-separated worker IDs to keep")
+	eventType := fs.String("type", "", "Comma-separated event types to keep")
+	since := fs.String("since", "", "Keep events at or after this RFC3339 time, or this long ago (for example 2h)")
+	until := fs.String("until", "", "Keep events at or before this RFC3339 time, or this long ago")
+	limit := fs.Int("limit", 0, "Keep only the last N matching events (0 keeps all)")
+	format := fs.String("format", eventsQueryFormatTable, "Output format: table, json (one event per line) or csv")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments for events query: %s\n", strings.Join(fs.Args(), " "))
+		return 1
+	}
+	now := time.Now().UTC()
+	query, err := buildEventQuery(*task, *worker, *eventType, *since, *until, *limit, now)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	selectedFormat := strings.ToLower(strings.TrimSpace(*format))
+	switch selectedFormat {
+	case eventsQueryFormatTable, eventsQueryFormatJSON, eventsQueryFormatCSV:
+	default:
+		fmt.Fprintf(os.Stderr, "--format must be one of: %s, %s, %s\n", eventsQueryFormatTable, eventsQueryFormatJSON, eventsQueryFormatCSV)
+		return 1
+	}
+
+	path := strings.TrimSpace(*file)
+	if path == "" {
+		path = filepath.Join(*repoRoot, "runner-logs", "agent.events.jsonl")
+	}
+	log, err := contracts.OpenEventLog(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer log.Close()
+
+	events, skipped, err := queryEvents(log, query)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "skipped %d malformed event lines\n", skipped)
+	}
+	if err := writeEventQueryResult(os.Stdout, selectedFormat, events); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func buildEventQuery(task string, worker string, eventType string, since string, until string, limit int, now time.Time) (eventQuery, error) {
+	query := eventQuery{limit: limit}
+	if limit < 0 {
+		return query, errors.New("--limit must be greater than or equal to 0")
+	}
+	for _, id := range splitQueryList(task) {
+		if query.taskIDs == nil {
+			query.taskIDs = map[string]struct{}{}
+		}
+		query.taskIDs[id] = struct{}{}
+	}
+	for _, id := range splitQueryList(worker) {
+		if query.workerIDs == nil {
+			query.workerIDs = map[string]struct{}{}
+		}
+		query.workerIDs[id] = struct{}{}
+	}
+	for _, name := range splitQueryList(eventType) {
+		if query.types == nil {
+			query.types = map[contracts.EventType]struct{}{}
+		}
+		query.types[contracts.EventType(name)] = struct{}{}
+	}
+	var err error
+	if query.since, err = parseEventQueryTime("--since", since, now); err != nil {
+		return query, err
+	}
+	if query.until, err = parseEventQueryTime("--until", until, now); err != nil {
+		return query, err
+	}
+	if !query.since.IsZero() && !query.until.IsZero() && query.until.Before(query.since) {
+		return query, errors.New("--until must not be before --since")
+	}
+	return query, nil
+}
+
+func splitQueryList(raw string) []string {
+	values := []string{}
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// parseEventQueryTime accepts an RFC3339 timestamp or a duration counted back
+// from now.
+func parseEventQueryTime(flagName string, raw string, now time.Time) (time.Time, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	ago, err := time.ParseDuration(value)
+	if err != nil || ago < 0 {
+		return time.Time{}, fmt.Errorf("%s must be an RFC3339 time or a duration such as 30m", flagName)
+	}
+	return now.Add(-ago), nil
+}
+
+// queryEvents returns matching events in log order, skipping malformed lines.
+func queryEvents(reader io.Reader, query eventQuery) ([]contracts.Event, int, error) {
+	decoder := contracts.NewEventDecoder(reader)
+	events := []contracts.Event{}
+	skipped := 0
+	for {
+		event, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if !isMalformedEventLine(err) {
+				return nil, skipped, err
+			}
+			skipped++
+			continue
+		}
+		if !query.matches(event) {
+			continue
+		}
+		events = append(events, event)
+		if query.limit > 0 && len(events) > query.limit {
+			events = events[1:]
+		}
+	}
+	return events, skipped, nil
+}
+
+func writeEventQueryResult(out io.Writer, format string, events []contracts.Event) error {
+	switch format {
+	case eventsQueryFormatJSON:
+		stream := contracts.NewEventStream(out)
+		for _, event := range events {
+			if err := stream.Write(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	case eventsQueryFormatCSV:
+		writer := csv.NewWriter(out)
+		if err := writer.Write([]string{"ts", "type", "task_id", "task_title", "worker_id", "message", "metadata"}); err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := writer.Write([]string{formatEventQueryTime(event.Timestamp), string(event.Type), event.TaskID, event.TaskTitle, event.WorkerID, event.Message, formatEventQueryMetadata(event.Metadata)}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "TIME\tTYPE\tTASK\tWORKER\tMESSAGE")
+		for _, event := range events {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", formatEventQueryTime(event.Timestamp), event.Type, event.TaskID, event.WorkerID, singleLine(event.Message))
+		}
+		return writer.Flush()
+	}
+}
+
+func formatEventQueryTime(ts time.Time) string {
+	if ts.IsZero() {
+		return ""
+	}
+	return ts.UTC().Format(time.RFC3339)
+}
+
+func formatEventQueryMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+metadata[key])
+	}
+	return strings.Join(pairs, ";")
+}
+
+func singleLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
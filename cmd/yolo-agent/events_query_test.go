@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const eventsQueryFixture = `{"type":"task_started","task_id":"yr-1","worker_id":"worker-0","ts":"2026-02-10T12:00:00Z"}
+{"type":"runner_warning","task_id":"yr-1","worker_id":"worker-0","message":"no output\nfor 2m","ts":"2026-02-10T12:05:00Z"}
+{"type":"runner_warning","task_id":"yr-2","worker_id":"worker-1","message":"slow","ts":"2026-02-10T12:06:00Z"}
+{"type":"runner_warning","task_id":"yr-1","worker_id":"worker-0","message":"still slow","metadata":{"b":"2","a":"1"},"ts":"2026-02-10T12:20:00Z"}
+{"type":"task_finished","task_id":"yr-1",
+`
+
+func TestQueryEventsFiltersByTaskTypeWorkerAndTime(t *testing.T) {
+	now := time.Date(2026, 2, 10, 12, 30, 0, 0, time.UTC)
+	query, err := buildEventQuery("yr-1", "worker-0", "runner_warning", "2026-02-10T12:01:00Z", "15m", 0, now)
+	if err != nil {
+		t.Fatalf("build query: %v", err)
+	}
+	events, skipped, err := queryEvents(strings.NewReader(eventsQueryFixture), query)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if skipped != 1 {
+		t.Fatalf("expected truncated line to be skipped, got %d", skipped)
+	}
+	if len(events) != 1 || events[0].Message != "no output\nfor 2m" {
+		t.Fatalf("expected only the first yr-1 warning before 12:15, got %#v", events)
+	}
+}
+
+func TestQueryEventsLimitKeepsLastMatches(t *testing.T) {
+	query, err := buildEventQuery("", "", "runner_warning", "", "", 2, time.Now())
+	if err != nil {
+		t.Fatalf("build query: %v", err)
+	}
+	events, _, err := queryEvents(strings.NewReader(eventsQueryFixture), query)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(events) != 2 || events[0].TaskID != "yr-2" || events[1].Message != "still slow" {
+		t.Fatalf("expected the last two warnings, got %#v", events)
+	}
+}
+
+func TestBuildEventQueryRejectsInvalidInput(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		since string
+		until string
+		limit int
+	}{
+		{since: "yesterday"},
+		{since: "1h", until: "2h"},
+		{limit: -1},
+	}
+	for _, tc := range cases {
+		if _, err := buildEventQuery("", "", "", tc.since, tc.until, tc.limit, now); err == nil {
+			t.Fatalf("expected error for %#v", tc)
+		}
+	}
+}
+
+func TestWriteEventQueryResultFormats(t *testing.T) {
+	events := []contracts.Event{{
+		Type:      contracts.EventTypeRunnerWarning,
+		TaskID:    "yr-1",
+		TaskTitle: "Fix, then ship",
+		WorkerID:  "worker-0",
+		Message:   "no output\nfor 2m",
+		Metadata:  map[string]string{"b": "2", "a": "1"},
+		Timestamp: time.Date(2026, 2, 10, 12, 5, 0, 0, time.UTC),
+	}}
+
+	table := &bytes.Buffer{}
+	if err := writeEventQueryResult(table, eventsQueryFormatTable, events); err != nil {
+		t.Fatalf("table: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "TIME") || !strings.Contains(lines[1], "runner_warning") || !strings.HasSuffix(lines[1], "no output for 2m") {
+		t.Fatalf("unexpected table output:\n%s", table.String())
+	}
+
+	csvOut := &bytes.Buffer{}
+	if err := writeEventQueryResult(csvOut, eventsQueryFormatCSV, events); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	if !strings.Contains(csvOut.String(), `2026-02-10T12:05:00Z,runner_warning,yr-1,"Fix, then ship",worker-0,"no output`) || !strings.Contains(csvOut.String(), "a=1;b=2") {
+		t.Fatalf("unexpected csv output:\n%s", csvOut.String())
+	}
+
+	jsonOut := &bytes.Buffer{}
+	if err := writeEventQueryResult(jsonOut, eventsQueryFormatJSON, events); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	decoded, err := contracts.NewEventDecoder(jsonOut).Next()
+	if err != nil || decoded.TaskID != "yr-1" || decoded.Metadata["a"] != "1" {
+		t.Fatalf("expected round-trippable json output, got %#v err=%v", decoded, err)
+	}
+}
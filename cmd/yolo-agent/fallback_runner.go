@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// backendRouterRunner sends each request to the runner of the backend named
+// in its metadata, so model fallbacks can move a task to another backend.
+// Requests for other backends go to the primary runner.
+type backendRouterRunner struct {
+	primary  contracts.AgentRunner
+	backends map[string]contracts.AgentRunner
+}
+
+func (r backendRouterRunner) Run(ctx context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	if runner, ok := r.backends[strings.ToLower(strings.TrimSpace(request.Metadata["backend"]))]; ok {
+		return runner.Run(ctx, request)
+	}
+	return r.primary.Run(ctx, request)
+}
+
+// withFallbackBackends builds runners for the backends the fallback chain
+// switches to. The primary runner is returned unchanged when the chain stays
+// on the configured backend.
+func withFallbackBackends(cfg runConfig, primary contracts.AgentRunner) (contracts.AgentRunner, error) {
+	primaryBackend := normalizeBackend(cfg.backend)
+	backends := map[string]contracts.AgentRunner{}
+	for _, target := range cfg.fallbackChain {
+		backend := strings.ToLower(strings.TrimSpace(target.Backend))
+		if backend == "" || backend == primaryBackend {
+			continue
+		}
+		if _, ok := backends[backend]; ok {
+			continue
+		}
+		backendCfg := cfg
+		backendCfg.backend = backend
+		runner, err := buildRunnerAdapter(backendCfg)
+		if err != nil {
+			return nil, fmt.Errorf("build fallback runner for backend %q: %w", strings.TrimSpace(target.Backend), err)
+		}
+		backends[backend] = runner
+	}
+	if len(backends) == 0 {
+		return primary, nil
+	}
+	return backendRouterRunner{primary: primary, backends: backends}, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+type namedRunner struct{ name string }
+
+func (r namedRunner) Run(_ context.Context, _ contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	return contracts.RunnerResult{Status: contracts.RunnerResultCompleted, Reason: r.name}, nil
+}
+
+func TestBackendRouterRunnerRoutesByRequestBackend(t *testing.T) {
+	router := backendRouterRunner{
+		primary:  namedRunner{name: "codex"},
+		backends: map[string]contracts.AgentRunner{"claude": namedRunner{name: "claude"}},
+	}
+	for backend, want := range map[string]string{"claude": "claude", "Claude": "claude", "codex": "codex", "": "codex", "kimi": "codex"} {
+		result, err := router.Run(context.Background(), contracts.RunnerRequest{Metadata: map[string]string{"backend": backend}})
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if result.Reason != want {
+			t.Fatalf("backend %q: expected %q runner, got %q", backend, want, result.Reason)
+		}
+	}
+}
+
+func TestWithFallbackBackendsKeepsPrimaryRunnerForSameBackendChain(t *testing.T) {
+	primary := namedRunner{name: "codex"}
+	runner, err := withFallbackBackends(runConfig{
+		backend:       backendCodex,
+		codingAgents:  testCatalog(t),
+		fallbackChain: []agent.ModelTarget{{Model: "gpt-5.3-mini"}, {Backend: backendCodex, Model: "o4"}},
+	}, primary)
+	if err != nil {
+		t.Fatalf("with fallback backends: %v", err)
+	}
+	if _, ok := runner.(namedRunner); !ok {
+		t.Fatalf("expected primary runner unchanged, got %T", runner)
+	}
+
+	runner, err = withFallbackBackends(runConfig{
+		backend:       backendCodex,
+		codingAgents:  testCatalog(t),
+		fallbackChain: []agent.ModelTarget{{Backend: "claude", Model: "claude-sonnet"}},
+	}, primary)
+	if err != nil {
+		t.Fatalf("with fallback backends: %v", err)
+	}
+	router, ok := runner.(backendRouterRunner)
+	if !ok || router.backends["claude"] == nil {
+		t.Fatalf("expected router with claude runner, got %#v", runner)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	githubtracker "github.com/egv/yolo-runner/v2/internal/github"
+)
+
+var newGitHubCheckRunSink = func(cfg githubtracker.Config, headSHA string) (contracts.EventSink, error) {
+	return githubtracker.NewCheckRunSink(cfg, headSHA, os.Stderr)
+}
+
+// githubChecksEventSink returns a sink that reports the run and each task as
+// GitHub check runs on the commit the run starts from when --github-checks
+// is set. Dry runs never touch GitHub. GitHub failures, such as a token
+// without checks:write, are printed as warnings and do not stop the run.
+func githubChecksEventSink(cfg runConfig, profile resolvedTrackerProfile) (contracts.EventSink, error) {
+	if !cfg.githubChecks || cfg.dryRun {
+		return nil, nil
+	}
+	if profile.Tracker.Type != trackerTypeGitHub || profile.Tracker.GitHub == nil {
+		return nil, fmt.Errorf("--github-checks requires a github tracker profile; profile %q uses %q", profile.Name, profile.Tracker.Type)
+	}
+	settings := profile.Tracker.GitHub
+	token, err := lookupConfigSecret(os.Getenv, strings.TrimSpace(settings.Auth.TokenEnv))
+	if err != nil {
+		return nil, fmt.Errorf("reading auth token for profile %q: %w", profile.Name, err)
+	}
+	out, err := localGitRunner{dir: cfg.repoRoot}.Run("git", "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("--github-checks needs a commit to report on: git rev-parse HEAD failed: %s", strings.TrimSpace(out))
+	}
+	sink, err := newGitHubCheckRunSink(githubtracker.Config{
+		Owner: strings.TrimSpace(settings.Scope.Owner),
+		Repo:  strings.TrimSpace(settings.Scope.Repo),
+		Token: strings.TrimSpace(token),
+	}, strings.TrimSpace(out))
+	if err != nil {
+		return nil, fmt.Errorf("--github-checks for profile %q: %w", profile.Name, err)
+	}
+	return sink, nil
+}
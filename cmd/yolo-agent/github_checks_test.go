@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	githubtracker "github.com/egv/yolo-runner/v2/internal/github"
+)
+
+type githubChecksTestSink struct{}
+
+func (githubChecksTestSink) Emit(context.Context, contracts.Event) error {
+	return nil
+}
+
+func TestGitHubChecksEventSinkReportsOnRepositoryHead(t *testing.T) {
+	repoRoot := initGitRepo(t)
+	commit := exec.Command("git", "-c", "user.name=yolo", "-c", "user.email=yolo@example.com", "commit", "--allow-empty", "-m", "seed")
+	commit.Dir = repoRoot
+	if out, err := commit.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v output=%s", err, out)
+	}
+	head, err := localGitRunner{dir: repoRoot}.Run("git", "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatalf("rev-parse: %v", err)
+	}
+	t.Setenv("YOLO_TEST_GITHUB_TOKEN", "ghs_test")
+
+	original := newGitHubCheckRunSink
+	t.Cleanup(func() { newGitHubCheckRunSink = original })
+	var gotConfig githubtracker.Config
+	gotSHA := ""
+	newGitHubCheckRunSink = func(cfg githubtracker.Config, headSHA string) (contracts.EventSink, error) {
+		gotConfig, gotSHA = cfg, headSHA
+		return githubChecksTestSink{}, nil
+	}
+
+	profile := resolvedTrackerProfile{Name: "default", Tracker: trackerModel{Type: trackerTypeGitHub, GitHub: &githubTrackerModel{
+		Scope: githubScopeModel{Owner: "egv", Repo: "yolo-runner"},
+		Auth:  githubAuthModel{TokenEnv: "YOLO_TEST_GITHUB_TOKEN"},
+	}}}
+	if sink, err := githubChecksEventSink(runConfig{repoRoot: repoRoot}, profile); sink != nil || err != nil {
+		t.Fatalf("expected no sink without --github-checks, got %v %v", sink, err)
+	}
+	if sink, err := githubChecksEventSink(runConfig{repoRoot: repoRoot, githubChecks: true, dryRun: true}, profile); sink != nil || err != nil {
+		t.Fatalf("expected no sink for a dry run, got %v %v", sink, err)
+	}
+	sink, err := githubChecksEventSink(runConfig{repoRoot: repoRoot, githubChecks: true}, profile)
+	if err != nil || sink == nil {
+		t.Fatalf("expected a check run sink, got %v %v", sink, err)
+	}
+	if gotConfig.Owner != "egv" || gotConfig.Repo != "yolo-runner" || gotConfig.Token != "ghs_test" || gotSHA != strings.TrimSpace(head) {
+		t.Fatalf("unexpected check run sink config %#v on %q", gotConfig, gotSHA)
+	}
+
+	_, err = githubChecksEventSink(runConfig{repoRoot: repoRoot, githubChecks: true}, resolvedTrackerProfile{Name: "local", Tracker: trackerModel{Type: trackerTypeTK}})
+	if err == nil || !strings.Contains(err.Error(), "requires a github tracker profile") {
+		t.Fatalf("expected non-GitHub profiles to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/sqlite"
+)
+
+// maybeWrapWithLocalStore returns a SQLite store seeded from tracker when
+// --local-store is set. Local writes sync to the tracker in the background and
+// are flushed once more by the returned close function.
+func maybeWrapWithLocalStore(ctx context.Context, cfg runConfig, tracker contracts.StorageBackend, out io.Writer) (contracts.StorageBackend, func(), error) {
+	path := strings.TrimSpace(cfg.localStorePath)
+	if path == "" {
+		return tracker, nil, nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cfg.repoRoot, path)
+	}
+	store, err := sqlite.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Replay changes left by an interrupted run before the tracker snapshot
+	// overwrites them.
+	if _, err := store.Sync(ctx, tracker); err != nil {
+		_ = store.Close()
+		return nil, nil, fmt.Errorf("local store: %w", err)
+	}
+	if err := store.ImportFrom(ctx, tracker, cfg.rootID); err != nil {
+		_ = store.Close()
+		return nil, nil, fmt.Errorf("local store: %w", err)
+	}
+	if creator, ok := tracker.(contracts.TaskCreator); ok {
+		store.WithTaskCreator(creator)
+	}
+	if reader, ok := tracker.(contracts.TaskCommentReader); ok {
+		store.WithTaskCommentReader(reader)
+	}
+	if assigner, ok := tracker.(contracts.TaskAssigner); ok {
+		store.WithTaskAssigner(assigner)
+	}
+	if labeler, ok := tracker.(contracts.TaskLabeler); ok {
+		store.WithTaskLabeler(labeler)
+	}
+
+	syncCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sqlite.NewSyncer(store, tracker, sqlite.DefaultSyncInterval, func(err error) {
+			fmt.Fprintf(out, "warning: local store sync failed, will retry: %v\n", err)
+		}).Run(syncCtx)
+	}()
+	closeFn := func() {
+		cancel()
+		<-done
+		if _, err := store.Sync(context.Background(), tracker); err != nil {
+			fmt.Fprintf(out, "warning: local store changes not synced to tracker; they will be replayed on the next run: %v\n", err)
+		}
+		_ = store.Close()
+	}
+	return store, closeFn, nil
+}
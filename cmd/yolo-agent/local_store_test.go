@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestMaybeWrapWithLocalStoreIsNoopWithoutPath(t *testing.T) {
+	tracker := staticStorageBackend{}
+	backend, closeFn, err := maybeWrapWithLocalStore(context.Background(), runConfig{}, tracker, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if closeFn != nil || backend != tracker {
+		t.Fatalf("expected tracker backend to pass through, got %#v", backend)
+	}
+}
+
+func TestMaybeWrapWithLocalStoreServesTrackerSnapshotAndFlushesOnClose(t *testing.T) {
+	repoRoot := t.TempDir()
+	tracker := &recordingStorageBackend{tree: &contracts.TaskTree{
+		Root: contracts.Task{ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+		Tasks: map[string]contracts.Task{
+			"root": {ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+			"t-1":  {ID: "t-1", Title: "Task", Status: contracts.TaskStatusOpen, ParentID: "root"},
+		},
+	}}
+	cfg := runConfig{repoRoot: repoRoot, rootID: "root", localStorePath: ".yolo-runner/tasks.db"}
+
+	backend, closeFn, err := maybeWrapWithLocalStore(context.Background(), cfg, tracker, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("wrap with local store: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoRoot, ".yolo-runner", "tasks.db")); err != nil {
+		t.Fatalf("expected store under repo root: %v", err)
+	}
+	task, err := backend.GetTask(context.Background(), "t-1")
+	if err != nil || task.Title != "Task" {
+		t.Fatalf("expected imported task, got %#v %v", task, err)
+	}
+	if err := backend.SetTaskStatus(context.Background(), "t-1", contracts.TaskStatusClosed); err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	closeFn()
+
+	if got := tracker.statusCalls(); len(got) != 1 || got[0] != "t-1=closed" {
+		t.Fatalf("expected status change flushed to tracker, got %v", got)
+	}
+}
+
+type recordingStorageBackend struct {
+	staticStorageBackend
+	mu       sync.Mutex
+	tree     *contracts.TaskTree
+	statuses []string
+}
+
+func (b *recordingStorageBackend) GetTaskTree(context.Context, string) (*contracts.TaskTree, error) {
+	return b.tree, nil
+}
+
+func (b *recordingStorageBackend) SetTaskStatus(_ context.Context, taskID string, status contracts.TaskStatus) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.statuses = append(b.statuses, taskID+"="+string(status))
+	return nil
+}
+
+func (b *recordingStorageBackend) statusCalls() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string{}, b.statuses...)
+}
@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -14,6 +17,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/egv/yolo-runner/v2/internal/acp"
 	"github.com/egv/yolo-runner/v2/internal/agent"
 	"github.com/egv/yolo-runner/v2/internal/claude"
 	"github.com/egv/yolo-runner/v2/internal/codex"
@@ -21,10 +25,17 @@ import (
 	"github.com/egv/yolo-runner/v2/internal/contracts"
 	"github.com/egv/yolo-runner/v2/internal/distributed"
 	"github.com/egv/yolo-runner/v2/internal/engine"
+	"github.com/egv/yolo-runner/v2/internal/escalation"
 	"github.com/egv/yolo-runner/v2/internal/kimi"
+	"github.com/egv/yolo-runner/v2/internal/ollama"
 	"github.com/egv/yolo-runner/v2/internal/opencode"
+	"github.com/egv/yolo-runner/v2/internal/prompt"
+	"github.com/egv/yolo-runner/v2/internal/qwen"
+	"github.com/egv/yolo-runner/v2/internal/repocontext"
+	"github.com/egv/yolo-runner/v2/internal/retention"
 	gitvcs "github.com/egv/yolo-runner/v2/internal/vcs/git"
 	"github.com/egv/yolo-runner/v2/internal/version"
+	"github.com/egv/yolo-runner/v2/pkg/registry"
 )
 
 const (
@@ -44,6 +55,8 @@ const (
 	distributedBusNATS  = "nats"
 	inboxAuthTokenEnv   = "YOLO_INBOX_WRITE_TOKEN"
 	monitorSourceIDEnv  = "YOLO_MONITOR_SOURCE_ID"
+	eventSinkFile       = "file"
+	eventSinkStream     = "stream"
 )
 
 var taskGraphSyncInterval = 5 * time.Second
@@ -58,11 +71,40 @@ type runConfig struct {
 	qualityThreshold                int
 	qualityGateTools                []string
 	qcGateTools                     []string
+	qcGateTestReruns                int
 	allowLowQuality                 bool
 	maxTasks                        int
 	retryBudget                     int
+	resumeSessions                  bool
+	skipReview                      bool
+	diffSummary                     bool
+	skipPreflight                   bool
+	recordFixtures                  string
+	replayFixtures                  string
+	replayRealtime                  bool
+	stallNudgePrompt                string
+	stallPolicies                   map[contracts.StallCategory]contracts.StallPolicy
+	rateLimitBackoff                time.Duration
+	epicProgressInterval            time.Duration
+	taskDiscoveryInterval           time.Duration
+	statusReconcileInterval         time.Duration
+	shutdownGrace                   time.Duration
+	eventSinkFilters                map[string]contracts.EventFilter
+	eventLog                        contracts.FileEventSinkOptions
+	redactor                        *contracts.Redactor
+	executor                        remoteExecutorConfig
+	executors                       map[string]remoteExecutorConfig
+	workerAffinity                  agent.WorkerAffinityConfig
+	fallbackChain                   []agent.ModelTarget
+	backendCapabilities             backendCapabilities
 	concurrency                     int
 	dryRun                          bool
+	dryRunEvents                    bool
+	autoPlan                        bool
+	autoPlanYes                     bool
+	followUpIssues                  bool
+	commentTrail                    bool
+	githubChecks                    bool
 	mode                            string
 	stream                          bool
 	verboseStream                   bool
@@ -73,6 +115,8 @@ type runConfig struct {
 	watchdogTimeout                 time.Duration
 	watchdogInterval                time.Duration
 	eventsPath                      string
+	localStorePath                  string
+	trackerCacheTTL                 time.Duration
 	role                            string
 	distributedBusBackend           string
 	distributedBusAddress           string
@@ -89,6 +133,44 @@ type runConfig struct {
 	distributedRewriteDefaultModel  string
 	distributedRewriteLargerModel   string
 	distributedEventBus             distributed.Bus
+	serve                           bool
+	serveAddr                       string
+	serveAccess                     *controlAccess
+	serveGRPCAddr                   string
+	controlAPI                      *controlAPI
+	checkRunSink                    contracts.EventSink
+	promptTemplates                 *prompt.Templates
+	commitMessageConfig             agent.CommitMessageConfig
+	commitMessages                  *agent.CommitMessages
+	taskEnv                         []agent.TaskEnvVar
+	toolPolicy                      contracts.ToolPolicy
+	pipeline                        agent.Pipeline
+	landingStrategy                 agent.LandingStrategy
+	preLandingHooks                 []string
+	landingHookTimeout              time.Duration
+	watchdogHooks                   []string
+	watchdogHookTimeout             time.Duration
+	mergeQueueBatchSize             int
+	pushRetries                     int
+	noVCS                           bool
+	pathScope                       agent.PathScopeConfig
+	diffGuardrails                  agent.DiffGuardrailConfig
+	secretScan                      agent.SecretScanConfig
+	dependencyPolicy                agent.DependencyPolicyConfig
+	syncRemote                      string
+	syncBranch                      string
+	repoContext                     *repocontext.Options
+	clonePool                       agent.ClonePoolOptions
+	cloneStrategy                   agent.CloneStrategy
+	orphanedTasks                   agent.OrphanedTaskPolicy
+	reviewMode                      agent.ReviewMode
+	reviewConcurrency               int
+	retention                       retention.Config
+	escalation                      escalation.Policy
+	blockedRetryPolicies            map[string]agent.BlockedRetryPolicy
+
+	// runID names this run on its events, task data, branches and clones.
+	runID string
 }
 
 var newDistributedBus = func(backend string, address string, opts distributed.BusBackendOptions) (distributed.Bus, error) {
@@ -125,15 +207,42 @@ var launchYoloTUI = func() (io.WriteCloser, func() error, error) {
 
 var runConfigInitCommand = defaultRunConfigInitCommand
 
+var runConfigSchemaCommand = defaultRunConfigSchemaCommand
+
 func RunMain(args []string, run func(context.Context, runConfig) error) int {
 	if version.IsVersionRequest(args) {
 		version.Print(os.Stdout, "yolo-agent")
 		return 0
 	}
+	if err := loadAgentPlugins(os.Getenv); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
 
 	if len(args) > 0 && args[0] == "config" {
 		return runConfigCommand(args[1:])
 	}
+	if len(args) > 0 && args[0] == "sync" {
+		return runSyncCommand(args[1:])
+	}
+	if len(args) > 0 && args[0] == "events" {
+		return runEventsCommand(args[1:])
+	}
+	if len(args) > 0 && args[0] == "chatops" {
+		return runChatOpsCommand(args[1:])
+	}
+	if len(args) > 0 && args[0] == "clean" {
+		return runCleanCommand(args[1:])
+	}
+	if len(args) > 0 && args[0] == "escalate" {
+		return runEscalateCommand(args[1:])
+	}
+	if len(args) > 0 && args[0] == "bench" {
+		return runBenchCommand(args[1:])
+	}
+	if len(args) > 0 && args[0] == "simulate" {
+		return runSimulateCommand(args[1:])
+	}
 
 	fs := flag.NewFlagSet("yolo-agent", flag.ContinueOnError)
 	repo := fs.String("repo", ".", "Repository root")
@@ -145,10 +254,17 @@ func RunMain(args []string, run func(context.Context, runConfig) error) int {
 	qualityThreshold := fs.Int("quality-threshold", 0, "Minimum quality score required to run a task")
 	qualityGateTools := fs.String("quality-gate-tools", "", "Comma-separated quality tools to run in quality gate")
 	qcGateTools := fs.String("qc-gate-tools", "", "Comma-separated quality tools to run in quality-control gate")
+	qcGateTestReruns := fs.Int("qc-gate-test-reruns", 0, "Rerun a failing quality-control test suite up to N times and report tests that pass on a rerun as flaky")
 	allowLowQuality := fs.Bool("allow-low-quality", false, "Proceed with warning when quality score is below threshold")
 	max := fs.Int("max", 0, "Maximum tasks to execute")
 	concurrency := fs.Int("concurrency", 1, "Maximum number of active task workers")
-	dryRun := fs.Bool("dry-run", false, "Dry run task loop")
+	dryRun := fs.Bool("dry-run", false, "Dry run task loop and print the planned execution order")
+	dryRunEvents := fs.Bool("dry-run-events", false, "Emit dry_run_planned events for each planned task when --dry-run is set")
+	autoPlan := fs.Bool("auto-plan", false, "When the root task has no child tasks, ask the agent backend to propose subtasks and create them before running")
+	autoPlanYes := fs.Bool("auto-plan-yes", false, "Create --auto-plan tasks without asking for confirmation")
+	followUpIssues := fs.Bool("follow-up-issues", false, "File follow-up tasks in the tracker for unresolved review feedback and FOLLOW_UP items reported by the agent")
+	commentTrail := fs.Bool("comment-trail", false, "Post a tracker comment on each task at lifecycle milestones (started, review, landed, blocked)")
+	githubChecks := fs.Bool("github-checks", false, "Report the run and each task as GitHub check runs on the starting commit (github tracker profiles only)")
 	stream := fs.Bool("stream", false, "Emit NDJSON events to stdout for piping into yolo-tui")
 	verboseStream := fs.Bool("verbose-stream", false, "Emit every runner_output event without coalescing")
 	tddMode := fs.Bool("tdd", false, "Enable strict test-first Red/Green/Refactor workflow")
@@ -159,7 +275,36 @@ func RunMain(args []string, run func(context.Context, runConfig) error) int {
 	watchdogTimeout := fs.Duration("watchdog-timeout", 10*time.Minute, "No-output watchdog timeout for each runner execution")
 	watchdogInterval := fs.Duration("watchdog-interval", 5*time.Second, "Polling interval used by the no-output watchdog")
 	retryBudget := fs.Int("retry-budget", 5, "Maximum retry attempts per task for remediation loop")
+	rateLimitBackoff := fs.Duration("rate-limit-backoff", 30*time.Second, "Initial pause for all workers when a provider rate-limits a run; doubles per incident (0 disables)")
+	epicProgressInterval := fs.Duration("epic-progress-interval", 0, "Emit epic_progress rollup events and update the root task this often (0 disables)")
+	taskDiscoveryInterval := fs.Duration("task-discovery-interval", 0, "Poll the tracker this often for tasks added under the root during the run and schedule them (0 disables)")
+	statusReconcileInterval := fs.Duration("status-reconcile-interval", time.Minute, "Check in-flight tasks this often for a status closed, blocked or failed in the tracker and cancel their runners (0 checks only when a runner finishes)")
+	shutdownGrace := fs.Duration("shutdown-grace-period", 2*time.Minute, "On SIGINT/SIGTERM, stop dispatching and give in-flight tasks this long to finish before interrupting and reopening them (0 waits for them to finish)")
+	stallNudge := fs.Bool("stall-nudge", false, "When the stall detector finds the agent waiting on a question, rerun it once with a nudge prompt before blocking the task")
+	stallNudgePrompt := fs.String("stall-nudge-prompt", "", "Nudge prompt used by --stall-nudge (default: \""+agent.DefaultStallNudgePrompt+"\")")
+	resumeSessions := fs.Bool("resume-sessions", false, "Resume the backend session of an interrupted implement run instead of restarting it from scratch")
+	skipReview := fs.Bool("skip-review", false, "Land completed tasks without a review pass; required for backends without review support")
+	diffSummary := fs.Bool("diff-summary", false, "Ask the backend for a short summary of each completed task's changes and record it on the task for human reviewers")
+	skipPreflight := fs.Bool("skip-preflight", false, "Start without checking the tracker token and each backend's binary, version and sign-in first")
+	recordFixtures := fs.String("record-fixtures", "", "Record each runner run's prompt, progress, output log and result as replay fixtures in this directory")
+	replayFixtures := fs.String("replay-fixtures", "", "Serve runner runs from fixtures recorded with --record-fixtures instead of calling the backend")
+	replayRealtime := fs.Bool("replay-realtime", false, "Space replayed progress as it was recorded instead of sending it at once")
 	events := fs.String("events", "", "Path to JSONL events log")
+	serve := fs.Bool("serve", false, "Serve the run control REST API while the run is active")
+	serveAddr := fs.String("serve-addr", defaultServeAddr, "Listen address for --serve")
+	serveToken := fs.String("serve-token", "", "Bearer token required by the --serve API (default: $"+serveTokenEnv+")")
+	serveGRPCAddr := fs.String("serve-grpc-addr", "", "Also serve the run control API over gRPC on this address (requires --serve)")
+	trackerCacheTTL := fs.Duration("tracker-cache-ttl", 0, "Serve the task tree from a cached snapshot for this long and flush tracker writes in the background; keeps running on the snapshot while the tracker is unreachable (0 disables)")
+	reviewConcurrency := fs.Int("review-concurrency", 0, "Let tasks in review hand their --concurrency slot to the next task, running up to this many reviews alongside implementation (0 keeps each task on one slot)")
+	reviewMode := fs.String("review-mode", "", "How completed tasks are reviewed: single (one review prompt) or two_phase (spec compliance, then code quality, each with its own verdict and retries)")
+	orphanedTasks := fs.String("orphaned-tasks", "", "What startup does with tasks a crashed run left in_progress: reopen (run them again) or block (with an orphaned triage for an operator)")
+	cloneStrategy := fs.String("clone-strategy", "", "How tasks get their copy of the repository: clone (a git clone each) or overlay (layers on one shared checkout via overlayfs or copy-on-write, falling back to clones)")
+	clonePoolSize := fs.Int("clone-pool-size", 0, "Keep this many clones of the repository ready so tasks start without waiting for git clone (0 disables)")
+	noVCS := fs.Bool("no-vcs", false, "Run tasks directly in --repo without branches, merges or pushes, for work that does not live in a git repository")
+	syncRemote := fs.String("sync-remote", "", "Remote that main is fast-forwarded from before each task and pushed to after landing (default: origin)")
+	syncBranch := fs.String("sync-branch", "", "Branch tasks start from and land on (default: main)")
+	landingStrategy := fs.String("landing-strategy", "", "How task branches land on main: merge (--no-ff merge commit), rebase_ff (rebase onto main and fast-forward) or squash (one commit on main)")
+	localStore := fs.String("local-store", "", "Path to a SQLite task store the engine runs against; tracker writes sync in the background")
 	role := fs.String("role", "", "Distributed execution role: local, mastermind, executor")
 	distributedBusBackend := fs.String("distributed-bus-backend", "", "Distributed bus backend (redis, nats)")
 	distributedBusAddress := fs.String("distributed-bus-address", "", "Distributed bus address")
@@ -242,10 +387,132 @@ func RunMain(args []string, run func(context.Context, runConfig) error) int {
 	if !flagWasSet("watchdog-interval") && configDefaults.WatchdogInterval != nil {
 		selectedWatchdogInterval = *configDefaults.WatchdogInterval
 	}
+	selectedRateLimitBackoff := *rateLimitBackoff
+	if !flagWasSet("rate-limit-backoff") && configDefaults.RateLimitBackoff != nil {
+		selectedRateLimitBackoff = *configDefaults.RateLimitBackoff
+	}
+	selectedEpicProgressInterval := *epicProgressInterval
+	if !flagWasSet("epic-progress-interval") && configDefaults.EpicProgress != nil {
+		selectedEpicProgressInterval = *configDefaults.EpicProgress
+	}
+	selectedTaskDiscoveryInterval := *taskDiscoveryInterval
+	if !flagWasSet("task-discovery-interval") && configDefaults.TaskDiscovery != nil {
+		selectedTaskDiscoveryInterval = *configDefaults.TaskDiscovery
+	}
+	selectedStatusReconcileInterval := *statusReconcileInterval
+	if !flagWasSet("status-reconcile-interval") && configDefaults.StatusReconcile != nil {
+		selectedStatusReconcileInterval = *configDefaults.StatusReconcile
+	}
+	selectedShutdownGrace := *shutdownGrace
+	if !flagWasSet("shutdown-grace-period") && configDefaults.ShutdownGrace != nil {
+		selectedShutdownGrace = *configDefaults.ShutdownGrace
+	}
 	selectedRetryBudget := *retryBudget
 	if !flagWasSet("retry-budget") && configDefaults.RetryBudget != nil {
 		selectedRetryBudget = *configDefaults.RetryBudget
 	}
+	selectedReviewConcurrency := *reviewConcurrency
+	if !flagWasSet("review-concurrency") && configDefaults.ReviewConcurrency != nil {
+		selectedReviewConcurrency = *configDefaults.ReviewConcurrency
+	}
+	selectedResumeSessions := *resumeSessions
+	if !flagWasSet("resume-sessions") && configDefaults.ResumeSessions != nil {
+		selectedResumeSessions = *configDefaults.ResumeSessions
+	}
+	selectedDiffSummary := *diffSummary
+	if !flagWasSet("diff-summary") && configDefaults.DiffSummary != nil {
+		selectedDiffSummary = *configDefaults.DiffSummary
+	}
+	selectedSkipReview := *skipReview
+	if !flagWasSet("skip-review") && configDefaults.SkipReview != nil {
+		selectedSkipReview = *configDefaults.SkipReview
+	}
+	selectedStallNudge := *stallNudge
+	if !flagWasSet("stall-nudge") && configDefaults.StallNudge != nil {
+		selectedStallNudge = *configDefaults.StallNudge
+	}
+	selectedStallNudgePrompt := ""
+	if selectedStallNudge {
+		selectedStallNudgePrompt = strings.TrimSpace(*stallNudgePrompt)
+		if selectedStallNudgePrompt == "" {
+			selectedStallNudgePrompt = configDefaults.StallNudgePrompt
+		}
+		if selectedStallNudgePrompt == "" {
+			selectedStallNudgePrompt = agent.DefaultStallNudgePrompt
+		}
+	}
+	selectedTrackerCacheTTL := *trackerCacheTTL
+	if !flagWasSet("tracker-cache-ttl") && configDefaults.TrackerCacheTTL != nil {
+		selectedTrackerCacheTTL = *configDefaults.TrackerCacheTTL
+	}
+	selectedLandingStrategy := configDefaults.LandingStrategy
+	if strings.TrimSpace(*landingStrategy) != "" {
+		parsed, err := agent.ParseLandingStrategy(*landingStrategy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "--landing-strategy: %v\n", err)
+			return 1
+		}
+		selectedLandingStrategy = parsed
+	}
+	selectedClonePool := configDefaults.ClonePool
+	if flagWasSet("clone-pool-size") {
+		selectedClonePool.Size = *clonePoolSize
+	}
+	selectedCloneStrategy := configDefaults.CloneStrategy
+	if strings.TrimSpace(*cloneStrategy) != "" {
+		parsed, err := agent.ParseCloneStrategy(*cloneStrategy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "--clone-strategy: %v\n", err)
+			return 1
+		}
+		selectedCloneStrategy = parsed
+	}
+	selectedOrphanedTasks := configDefaults.OrphanedTasks
+	if strings.TrimSpace(*orphanedTasks) != "" {
+		parsed, err := agent.ParseOrphanedTaskPolicy(*orphanedTasks)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "--orphaned-tasks: %v\n", err)
+			return 1
+		}
+		selectedOrphanedTasks = parsed
+	}
+	selectedReviewMode := configDefaults.ReviewMode
+	if strings.TrimSpace(*reviewMode) != "" {
+		parsed, err := agent.ParseReviewMode(*reviewMode)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "--review-mode: %v\n", err)
+			return 1
+		}
+		selectedReviewMode = parsed
+	}
+	selectedNoVCS := *noVCS
+	if !flagWasSet("no-vcs") {
+		selectedNoVCS = configDefaults.NoVCS
+	}
+	selectedSyncRemote := strings.TrimSpace(*syncRemote)
+	if selectedSyncRemote == "" {
+		selectedSyncRemote = configDefaults.SyncRemote
+	}
+	selectedSyncBranch := strings.TrimSpace(*syncBranch)
+	if selectedSyncBranch == "" {
+		selectedSyncBranch = configDefaults.SyncBranch
+	}
+	selectedWatchdogHookTimeout := time.Duration(0)
+	if configDefaults.WatchdogHookTimeout != nil {
+		selectedWatchdogHookTimeout = *configDefaults.WatchdogHookTimeout
+	}
+	selectedLandingHookTimeout := time.Duration(0)
+	if configDefaults.LandingHookTimeout != nil {
+		selectedLandingHookTimeout = *configDefaults.LandingHookTimeout
+	}
+	selectedPushRetries := agent.DefaultPushRetries
+	if configDefaults.PushRetries != nil {
+		selectedPushRetries = *configDefaults.PushRetries
+	}
+	selectedLocalStore := strings.TrimSpace(*localStore)
+	if selectedLocalStore == "" {
+		selectedLocalStore = configDefaults.LocalStore
+	}
 	selectedMode := strings.TrimSpace(configDefaults.Mode)
 	if *mode != "" {
 		selectedMode = strings.TrimSpace(*mode)
@@ -259,14 +526,18 @@ func RunMain(args []string, run func(context.Context, runConfig) error) int {
 		return 1
 	}
 	selectedStream := selectedMode == agentModeStream || selectedMode == agentModeUI
-	selectedBackend, _, err := selectBackend(selectedBackendRaw, backendSelectionOptions{
-		RequireReview: true,
+	selectedBackend, selectedCapabilities, err := selectBackend(selectedBackendRaw, backendSelectionOptions{
+		RequireReview: !selectedSkipReview,
 		Stream:        selectedStream,
-	}, catalogBackendCapabilities(codingAgents))
+	}, applyBackendCapabilityOverrides(catalogBackendCapabilities(codingAgents), configDefaults.BackendCapabilities))
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return 1
 	}
+	if selectedResumeSessions && !selectedCapabilities.SupportsResume {
+		fmt.Fprintf(os.Stderr, "warning: backend %q does not support session resume; resume_sessions is ignored\n", selectedBackend)
+		selectedResumeSessions = false
+	}
 	if selectedModel == "" {
 		selectedModel = catalogBackendDefaultModel(codingAgents, selectedBackend)
 	}
@@ -290,6 +561,10 @@ func RunMain(args []string, run func(context.Context, runConfig) error) int {
 		fmt.Fprintln(os.Stderr, "--quality-threshold must be greater than or equal to 0")
 		return 1
 	}
+	if *qcGateTestReruns < 0 {
+		fmt.Fprintln(os.Stderr, "--qc-gate-test-reruns must be greater than or equal to 0")
+		return 1
+	}
 	selectedQualityGateTools := parseQualityGateTools(*qualityGateTools)
 	selectedQCGateTools := parseQualityGateTools(*qcGateTools)
 	if selectedWatchdogTimeout <= 0 {
@@ -304,6 +579,55 @@ func RunMain(args []string, run func(context.Context, runConfig) error) int {
 		fmt.Fprintln(os.Stderr, "--retry-budget must be greater than or equal to 0")
 		return 1
 	}
+	if selectedReviewConcurrency < 0 {
+		fmt.Fprintln(os.Stderr, "--review-concurrency must be greater than or equal to 0")
+		return 1
+	}
+	if selectedClonePool.Size < 0 {
+		fmt.Fprintln(os.Stderr, "--clone-pool-size must be greater than or equal to 0")
+		return 1
+	}
+	if selectedRateLimitBackoff < 0 {
+		fmt.Fprintln(os.Stderr, "--rate-limit-backoff must be greater than or equal to 0")
+		return 1
+	}
+	if selectedEpicProgressInterval < 0 {
+		fmt.Fprintln(os.Stderr, "--epic-progress-interval must be greater than or equal to 0")
+		return 1
+	}
+	if selectedTaskDiscoveryInterval < 0 {
+		fmt.Fprintln(os.Stderr, "--task-discovery-interval must be greater than or equal to 0")
+		return 1
+	}
+	if selectedStatusReconcileInterval < 0 {
+		fmt.Fprintln(os.Stderr, "--status-reconcile-interval must be greater than or equal to 0")
+		return 1
+	}
+	if selectedShutdownGrace < 0 {
+		fmt.Fprintln(os.Stderr, "--shutdown-grace-period must be greater than or equal to 0")
+		return 1
+	}
+	if selectedTrackerCacheTTL < 0 {
+		fmt.Fprintln(os.Stderr, "--tracker-cache-ttl must be greater than or equal to 0")
+		return 1
+	}
+	if strings.TrimSpace(*recordFixtures) != "" && strings.TrimSpace(*replayFixtures) != "" {
+		fmt.Fprintln(os.Stderr, "--record-fixtures and --replay-fixtures cannot be used together")
+		return 1
+	}
+	if strings.TrimSpace(*serveGRPCAddr) != "" && !*serve {
+		fmt.Fprintln(os.Stderr, "--serve-grpc-addr requires --serve")
+		return 1
+	}
+	var selectedServeAccess *controlAccess
+	if *serve {
+		access, err := newControlAccess(*serveToken, configDefaults.ControlAPI, os.Getenv)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		selectedServeAccess = access
+	}
 	selectedDistributedBusConfig, err := resolveAgentDistributedBusConfig(
 		*repo,
 		*distributedBusBackend,
@@ -364,8 +688,41 @@ func RunMain(args []string, run func(context.Context, runConfig) error) int {
 		model:                           selectedModel,
 		maxTasks:                        *max,
 		retryBudget:                     selectedRetryBudget,
+		resumeSessions:                  selectedResumeSessions,
+		diffSummary:                     selectedDiffSummary,
+		skipReview:                      selectedSkipReview,
+		skipPreflight:                   *skipPreflight,
+		recordFixtures:                  strings.TrimSpace(*recordFixtures),
+		replayFixtures:                  strings.TrimSpace(*replayFixtures),
+		replayRealtime:                  *replayRealtime,
+		stallNudgePrompt:                selectedStallNudgePrompt,
+		stallPolicies:                   configDefaults.StallPolicies,
+		rateLimitBackoff:                selectedRateLimitBackoff,
+		epicProgressInterval:            selectedEpicProgressInterval,
+		taskDiscoveryInterval:           selectedTaskDiscoveryInterval,
+		statusReconcileInterval:         selectedStatusReconcileInterval,
+		shutdownGrace:                   selectedShutdownGrace,
+		runID:                           newRunID(time.Now()),
+		serve:                           *serve,
+		serveAddr:                       strings.TrimSpace(*serveAddr),
+		serveAccess:                     selectedServeAccess,
+		serveGRPCAddr:                   strings.TrimSpace(*serveGRPCAddr),
+		eventSinkFilters:                configDefaults.EventSinks,
+		eventLog:                        configDefaults.EventLog,
+		redactor:                        configDefaults.Redactor,
+		executor:                        configDefaults.Executor,
+		executors:                       configDefaults.Executors,
+		workerAffinity:                  configDefaults.WorkerAffinity,
+		fallbackChain:                   configDefaults.FallbackChain,
+		backendCapabilities:             selectedCapabilities,
 		concurrency:                     selectedConcurrency,
 		dryRun:                          *dryRun,
+		dryRunEvents:                    *dryRunEvents,
+		autoPlan:                        *autoPlan,
+		autoPlanYes:                     *autoPlanYes,
+		followUpIssues:                  *followUpIssues,
+		commentTrail:                    *commentTrail,
+		githubChecks:                    *githubChecks,
 		stream:                          selectedStream,
 		mode:                            selectedMode,
 		verboseStream:                   *verboseStream,
@@ -375,11 +732,14 @@ func RunMain(args []string, run func(context.Context, runConfig) error) int {
 		qualityThreshold:                *qualityThreshold,
 		qualityGateTools:                selectedQualityGateTools,
 		qcGateTools:                     selectedQCGateTools,
+		qcGateTestReruns:                *qcGateTestReruns,
 		allowLowQuality:                 *allowLowQuality,
 		runnerTimeout:                   selectedRunnerTimeout,
 		watchdogTimeout:                 selectedWatchdogTimeout,
 		watchdogInterval:                selectedWatchdogInterval,
 		eventsPath:                      *events,
+		localStorePath:                  selectedLocalStore,
+		trackerCacheTTL:                 selectedTrackerCacheTTL,
 		role:                            selectedRole,
 		distributedBusBackend:           selectedDistributedBusConfig.Backend,
 		distributedBusAddress:           selectedDistributedBusConfig.Address,
@@ -395,7 +755,36 @@ func RunMain(args []string, run func(context.Context, runConfig) error) int {
 		distributedReviewLargerModel:    selectedDistributedReviewLargerModel,
 		distributedRewriteDefaultModel:  selectedDistributedRewriteDefaultModel,
 		distributedRewriteLargerModel:   selectedDistributedRewriteLargerModel,
+		promptTemplates:                 configDefaults.PromptTemplates,
+		commitMessageConfig:             configDefaults.CommitMessages,
+		landingStrategy:                 selectedLandingStrategy,
+		preLandingHooks:                 configDefaults.PreLandingHooks,
+		landingHookTimeout:              selectedLandingHookTimeout,
+		watchdogHooks:                   configDefaults.WatchdogHooks,
+		watchdogHookTimeout:             selectedWatchdogHookTimeout,
+		mergeQueueBatchSize:             configDefaults.MergeQueueBatchSize,
+		pushRetries:                     selectedPushRetries,
+		noVCS:                           selectedNoVCS,
+		pathScope:                       configDefaults.PathScope,
+		diffGuardrails:                  configDefaults.DiffGuardrails,
+		secretScan:                      configDefaults.SecretScan,
+		dependencyPolicy:                configDefaults.DependencyPolicy,
+		syncRemote:                      selectedSyncRemote,
+		syncBranch:                      selectedSyncBranch,
+		repoContext:                     configDefaults.RepoContext,
+		clonePool:                       selectedClonePool,
+		cloneStrategy:                   selectedCloneStrategy,
+		orphanedTasks:                   selectedOrphanedTasks,
+		reviewMode:                      selectedReviewMode,
+		reviewConcurrency:               selectedReviewConcurrency,
+		retention:                       configDefaults.Retention,
+		escalation:                      configDefaults.Escalation,
+		blockedRetryPolicies:            configDefaults.BlockedRetry,
 	}); err != nil {
+		if errors.Is(err, agent.ErrRunInterrupted) {
+			fmt.Fprintln(os.Stderr, "Run interrupted; interrupted tasks were reopened. Run yolo-agent again to resume.")
+			return exitCodeInterrupted
+		}
 		fmt.Fprintln(os.Stderr, agent.FormatActionableError(err))
 		return 1
 	}
@@ -404,7 +793,7 @@ func RunMain(args []string, run func(context.Context, runConfig) error) int {
 
 func runConfigCommand(args []string) int {
 	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "usage: yolo-agent config <validate|init> [flags]")
+		fmt.Fprintln(os.Stderr, "usage: yolo-agent config <validate|init|schema> [flags]")
 		return 1
 	}
 
@@ -413,9 +802,11 @@ func runConfigCommand(args []string) int {
 		return runConfigValidateCommand(args[1:])
 	case "init":
 		return runConfigInitCommand(args[1:])
+	case "schema":
+		return runConfigSchemaCommand(args[1:])
 	default:
 		fmt.Fprintf(os.Stderr, "unknown config command: %s\n", args[0])
-		fmt.Fprintln(os.Stderr, "usage: yolo-agent config <validate|init> [flags]")
+		fmt.Fprintln(os.Stderr, "usage: yolo-agent config <validate|init|schema> [flags]")
 		return 1
 	}
 }
@@ -456,6 +847,7 @@ func defaultRun(ctx context.Context, cfg runConfig) error {
 		}()
 	}
 	cfg.eventsPath = resolveEventsPath(cfg)
+	runRetentionOnStart(cfg, os.Stderr)
 
 	trackerProfile, err := resolveTrackerProfile(cfg.repoRoot, cfg.profile, cfg.rootID, os.Getenv)
 	if err != nil {
@@ -463,19 +855,61 @@ func defaultRun(ctx context.Context, cfg runConfig) error {
 	}
 	cfg.profile = trackerProfile.Name
 	cfg.trackerType = trackerProfile.Tracker.Type
+	cfg.taskEnv = trackerProfile.Env
+	cfg.toolPolicy = trackerProfile.Tools
+	cfg.pipeline = trackerProfile.Pipeline
+	cfg.commitMessages, err = resolveCommitMessages(cfg.commitMessageConfig, trackerProfile)
+	if err != nil {
+		return err
+	}
 	storageBackend, err := buildStorageBackendForTracker(cfg.repoRoot, trackerProfile)
+	if shouldRunPreflight(cfg) {
+		if preflightErr := runPreflight(ctx, cfg, err, os.Stderr); preflightErr != nil {
+			return preflightErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	cfg.checkRunSink, err = githubChecksEventSink(cfg, trackerProfile)
 	if err != nil {
 		return err
 	}
+	storageBackend, closeTrackerCache := maybeWrapWithTrackerCache(ctx, cfg, storageBackend, os.Stderr)
+	if closeTrackerCache != nil {
+		defer closeTrackerCache()
+	}
+	storageBackend, closeLocalStore, err := maybeWrapWithLocalStore(ctx, cfg, storageBackend, os.Stderr)
+	if err != nil {
+		return err
+	}
+	if closeLocalStore != nil {
+		defer closeLocalStore()
+	}
 	taskStatusBackends := map[string]contracts.StorageBackend{}
 	if strings.TrimSpace(cfg.trackerType) != "" {
 		taskStatusBackends[strings.ToLower(strings.TrimSpace(cfg.trackerType))] = storageBackend
 	}
-	vcsAdapter := gitvcs.NewVCSAdapter(localGitRunner{dir: cfg.repoRoot})
+	vcsAdapter := contracts.VCS(nil)
+	if !cfg.noVCS {
+		vcsAdapter = gitvcs.NewVCSAdapter(localGitRunner{dir: cfg.repoRoot}).WithRemote(cfg.syncRemote, cfg.syncBranch).WithRunID(cfg.runID)
+	}
+	if cfg.serve {
+		cfg.controlAPI = newControlAPIWithAccess(cfg.serveAccess)
+	}
 	runnerAdapter, err := buildRunnerAdapter(cfg)
 	if err != nil {
 		return err
 	}
+	runnerAdapter, err = withFallbackBackends(cfg, runnerAdapter)
+	if err != nil {
+		return err
+	}
+	runnerAdapter = withReplayFixtures(cfg, runnerAdapter)
+	runnerAdapter, err = withAffinityExecutors(cfg, runnerAdapter)
+	if err != nil {
+		return err
+	}
 	runnerAdapter, distributedBus, closeDistributed, err := maybeWrapWithMastermind(ctx, cfg, runnerAdapter, taskStatusBackends)
 	if err != nil {
 		return err
@@ -500,6 +934,9 @@ func buildRunnerAdapter(cfg runConfig) (contracts.AgentRunner, error) {
 	if !ok {
 		return nil, fmt.Errorf("unsupported runner backend %q", cfg.backend)
 	}
+	if cfg.executor.enabled() {
+		return buildRemoteRunnerAdapter(cfg, definition)
+	}
 
 	switch definition.Adapter {
 	case "opencode":
@@ -507,17 +944,80 @@ func buildRunnerAdapter(cfg runConfig) (contracts.AgentRunner, error) {
 		return opencode.NewCLIRunnerAdapter(opencode.CommandRunner{}, nil, defaultConfigRoot(), defaultConfigDir(), definition.Binary, command...), nil
 	case "opencode-serve":
 		return opencode.NewServeRunnerAdapter(definition.Binary, definition.Args...), nil
+	case "ollama":
+		endpoint, _ := definition.Config["endpoint"].(string)
+		maxTurns, _ := definition.Config["max_turns"].(int)
+		return ollama.NewRunnerAdapter(endpoint, maxTurns, nil), nil
+	case "opencode-server":
+		serverURL, _ := definition.Config["url"].(string)
+		return opencode.NewServerRunnerAdapter(serverURL, nil), nil
 	case "codex", "codex-app-server":
 		return codex.NewCLIRunnerAdapter(definition.Binary, nil, definition.Args...), nil
 	case "claude":
 		return claude.NewSessionRunnerAdapter(definition.Binary), nil
 	case "kimi":
 		return kimi.NewCLIRunnerAdapter(definition.Binary, nil, definition.Args...), nil
+	case "qwen":
+		return qwen.NewCLIRunnerAdapter(definition.Binary, nil, definition.Args...), nil
+	case "acp":
+		var asker acp.PermissionAsker
+		if cfg.controlAPI != nil {
+			asker = cfg.controlAPI
+		}
+		return buildACPRunnerAdapter(definition, asker)
 	case "command":
 		return codingagents.NewGenericCLIRunnerAdapter(definition.Name, definition.Binary, definition.Args, nil).WithHealthConfig(definition.Health), nil
+	case "amazonq":
+		return codingagents.NewAmazonQRunnerAdapter(definition.Name, definition.Binary, definition.Args, codingagents.AmazonQAuthFromConfig(definition.Config), nil), nil
+	case "exec":
+		return codingagents.NewExecRunnerAdapter(definition.Name, definition.Binary, definition.Args, nil), nil
 	default:
-		return nil, fmt.Errorf("unsupported runner backend adapter %q", definition.Adapter)
+		factory, ok := registry.Backend(definition.Adapter)
+		if !ok {
+			return nil, fmt.Errorf("unsupported runner backend adapter %q", definition.Adapter)
+		}
+		runner, err := factory(registry.BackendConfig{
+			Name:   definition.Name,
+			Model:  definition.Model,
+			Binary: definition.Binary,
+			Args:   append([]string(nil), definition.Args...),
+			Config: definition.Config,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("build registered runner backend %q: %w", definition.Name, err)
+		}
+		return runner, nil
+	}
+}
+
+// buildACPRunnerAdapter reads the permission policy from the backend
+// definition: config.permissions maps tool kinds to allow/deny/ask and
+// config.control_socket names the Unix socket that answers "ask". Without a
+// control socket, "ask" goes to fallbackAsker when one is given.
+func buildACPRunnerAdapter(definition codingagents.BackendDefinition, fallbackAsker acp.PermissionAsker) (contracts.AgentRunner, error) {
+	rules := map[string]string{}
+	if raw, ok := definition.Config["permissions"]; ok {
+		entries, ok := raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("backend %q config.permissions must be a map of tool kind to allow, deny or ask", definition.Name)
+		}
+		for kind, value := range entries {
+			decision, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("backend %q config.permissions.%s must be allow, deny or ask", definition.Name, kind)
+			}
+			rules[kind] = decision
+		}
+	}
+	policy, err := acp.ParsePermissionPolicy(rules)
+	if err != nil {
+		return nil, fmt.Errorf("backend %q config.permissions: %w", definition.Name, err)
+	}
+	asker := fallbackAsker
+	if socketPath, ok := definition.Config["control_socket"].(string); ok && strings.TrimSpace(socketPath) != "" {
+		asker = acp.NewControlSocketAsker(socketPath, 0)
 	}
+	return acp.NewStdioRunnerAdapter(definition.Binary, nil, definition.Args...).WithPermissionPolicy(policy, asker), nil
 }
 
 func maybeWrapWithMastermind(ctx context.Context, cfg runConfig, localRunner contracts.AgentRunner, taskStatusBackends map[string]contracts.StorageBackend) (contracts.AgentRunner, distributed.Bus, func() error, error) {
@@ -663,6 +1163,7 @@ func runDistributedExecutor(ctx context.Context, cfg runConfig) error {
 		MaxConcurrency:     cfg.concurrency,
 		HeartbeatInterval:  cfg.distributedHeartbeatInterval,
 		RequestTimeout:     cfg.distributedRequestTimeout,
+		Redactor:           cfg.redactor,
 	})
 	return worker.Start(ctx)
 }
@@ -1119,51 +1620,22 @@ func resolveEventsPath(cfg runConfig) string {
 }
 
 func runWithComponents(ctx context.Context, cfg runConfig, taskManager contracts.TaskManager, runner contracts.AgentRunner, vcs contracts.VCS) error {
-	sinks := []contracts.EventSink{}
-	closers := []func(){}
-	if sink := monitorEventSink(cfg); sink != nil {
-		sinks = append(sinks, sink)
-	}
-	if cfg.stream {
-		streamWriter := io.Writer(os.Stdout)
-		if cfg.mode == agentModeUI {
-			stdin, closeFn, err := launchYoloTUI()
-			if err != nil {
-				return fmt.Errorf("start yolo-tui: %w", err)
-			}
-			streamWriter = stdin
-			closers = append(closers, func() {
-				_ = closeFn()
-			})
-		}
-		sinks = append(sinks, contracts.NewStreamEventSinkWithOptions(streamWriter, contracts.StreamEventSinkOptions{
-			VerboseOutput:  cfg.verboseStream,
-			OutputInterval: cfg.streamOutputInterval,
-			MaxPending:     cfg.streamOutputBuffer,
-		}))
+	if cfg.runID == "" {
+		cfg.runID = newRunID(time.Now())
 	}
-	if cfg.eventsPath != "" {
-		fileSink := contracts.NewFileEventSink(cfg.eventsPath)
-		if cfg.stream {
-			mirror := newMirrorEventSink(fileSink, cfg.streamOutputBuffer)
-			closers = append(closers, mirror.Close)
-			sinks = append(sinks, mirror)
-		} else {
-			sinks = append(sinks, fileSink)
-		}
+	eventSink, closeSinks, err := runEventSink(cfg, taskManager)
+	if err != nil {
+		return err
 	}
-	defer func() {
-		for _, closeFn := range closers {
-			closeFn()
-		}
-	}()
-	eventSink := contracts.EventSink(nil)
-	if len(sinks) == 1 {
-		eventSink = sinks[0]
-	} else if len(sinks) > 1 {
-		eventSink = contracts.NewFanoutEventSink(sinks...)
+	defer closeSinks()
+	if cfg.noVCS {
+		vcs = nil
 	}
 	vcsFactory := cloneScopedVCSFactory(cfg, vcs)
+	defer markRunClonesLive(cfg, os.Stderr)()
+	cloneManager := taskCloneManager(cfg)
+	defer closeCloneManager(cloneManager)
+	shutdown := newShutdownSignals()
 	loop := agent.NewLoop(taskManager, runner, eventSink, agent.LoopOptions{
 		ParentID:             cfg.rootID,
 		MaxRetries:           cfg.retryBudget,
@@ -1174,20 +1646,68 @@ func runWithComponents(ctx context.Context, cfg runConfig, taskManager contracts
 		QCGateTools:          cfg.qcGateTools,
 		AllowLowQuality:      cfg.allowLowQuality,
 		SchedulerStatePath:   filepath.Join(cfg.repoRoot, ".yolo-runner", "scheduler-state.json"),
+		DurationHistoryPath:  filepath.Join(cfg.repoRoot, ".yolo-runner", "durations.json"),
 		DryRun:               cfg.dryRun,
+		DryRunPlanOutput:     dryRunPlanOutput(cfg),
+		DryRunEmitPlan:       cfg.dryRun && cfg.dryRunEvents,
 		RepoRoot:             cfg.repoRoot,
 		Backend:              cfg.backend,
 		Model:                cfg.model,
 		RunnerTimeout:        cfg.runnerTimeout,
 		WatchdogTimeout:      cfg.watchdogTimeout,
 		WatchdogInterval:     cfg.watchdogInterval,
+		WatchdogHooks:        cfg.watchdogHooks,
+		WatchdogHookTimeout:  cfg.watchdogHookTimeout,
 		TDDMode:              cfg.tddMode,
+		PromptTemplates:      cfg.promptTemplates,
+		CommitMessages:       cfg.commitMessages,
+		LandingStrategy:      cfg.landingStrategy,
+		PreLandingHooks:      cfg.preLandingHooks,
+		LandingHookTimeout:   cfg.landingHookTimeout,
+		PathScope:            cfg.pathScope,
+		DiffGuardrails:       cfg.diffGuardrails,
+		DiffApprover:         diffApprover(cfg),
+		SecretScan:           cfg.secretScan,
+		DependencyPolicy:     cfg.dependencyPolicy,
+		MergeQueueBatchSize:  cfg.mergeQueueBatchSize,
+		PushRetries:          cfg.pushRetries,
+		PromptContext:        promptContextBuilder(cfg),
+		FollowUpIssues:       cfg.followUpIssues,
+		ResumeSessions:       cfg.resumeSessions,
+		DiffSummary:          cfg.diffSummary,
+		StallNudgePrompt:     cfg.stallNudgePrompt,
+		StallPolicies:        cfg.stallPolicies,
+		RateLimitBackoff:     cfg.rateLimitBackoff,
+		EpicProgressInterval: cfg.epicProgressInterval,
+		FallbackChain:        cfg.fallbackChain,
 		VCS:                  vcs,
-		RequireReview:        true,
+		RequireReview:        !cfg.skipReview,
 		MergeOnSuccess:       true,
-		CloneManager:         agent.NewGitCloneManager(filepath.Join(cfg.repoRoot, ".yolo-runner", "clones")),
+		CloneManager:         cloneManager,
 		VCSFactory:           vcsFactory,
+		WorkspaceVCSFactory:  workspaceVCSFactory(cfg, vcs),
+
+		TaskDiscoveryInterval:   cfg.taskDiscoveryInterval,
+		StatusReconcileInterval: cfg.statusReconcileInterval,
+		Stop:                    shutdown.stop,
+		ShutdownGrace:           cfg.shutdownGrace,
+		OrphanedTasks:           cfg.orphanedTasks,
+		ReviewMode:              cfg.reviewMode,
+		ReviewConcurrency:       cfg.reviewConcurrency,
+		RunID:                   cfg.runID,
+		BlockedRetryPolicies:    cfg.blockedRetryPolicies,
+		QCGateTestReruns:        cfg.qcGateTestReruns,
+		TaskEnv:                 cfg.taskEnv,
+		ToolPolicy:              cfg.toolPolicy,
+		WorkerAffinity:          cfg.workerAffinity,
+		Pipeline:                cfg.pipeline,
+		StageApprover:           stageApprover(cfg),
 	})
+	stopControlAPI, err := serveControlAPI(cfg, loop)
+	if err != nil {
+		return err
+	}
+	defer stopControlAPI()
 	if eventSink != nil {
 		_ = eventSink.Emit(ctx, contracts.Event{
 			Type:      contracts.EventTypeRunStarted,
@@ -1198,13 +1718,18 @@ func runWithComponents(ctx context.Context, cfg runConfig, taskManager contracts
 		})
 	}
 
+	stopShutdownSignals := shutdown.watch(loop, os.Stderr)
 	summary, err := loop.Run(ctx)
+	stopShutdownSignals()
+	if err == nil && shutdown.requested() {
+		err = agent.ErrRunInterrupted
+	}
 	if eventSink != nil {
 		_ = eventSink.Emit(ctx, contracts.Event{
 			Type:      contracts.EventTypeRunFinished,
 			TaskID:    cfg.rootID,
 			TaskTitle: "run",
-			Metadata:  buildRunFinishedMetadata(cfg, summary, err),
+			Metadata:  withCloneManagerStats(buildRunFinishedMetadata(cfg, summary, err), cloneManager),
 			Timestamp: time.Now().UTC(),
 		})
 	}
@@ -1212,51 +1737,26 @@ func runWithComponents(ctx context.Context, cfg runConfig, taskManager contracts
 }
 
 func runWithStorageComponents(ctx context.Context, cfg runConfig, storage contracts.StorageBackend, taskEngine contracts.TaskEngine, runner contracts.AgentRunner, vcs contracts.VCS) error {
-	sinks := []contracts.EventSink{}
-	closers := []func(){}
-	if sink := monitorEventSink(cfg); sink != nil {
-		sinks = append(sinks, sink)
+	if cfg.runID == "" {
+		cfg.runID = newRunID(time.Now())
 	}
-	if cfg.stream {
-		streamWriter := io.Writer(os.Stdout)
-		if cfg.mode == agentModeUI {
-			stdin, closeFn, err := launchYoloTUI()
-			if err != nil {
-				return fmt.Errorf("start yolo-tui: %w", err)
-			}
-			streamWriter = stdin
-			closers = append(closers, func() {
-				_ = closeFn()
-			})
-		}
-		sinks = append(sinks, contracts.NewStreamEventSinkWithOptions(streamWriter, contracts.StreamEventSinkOptions{
-			VerboseOutput:  cfg.verboseStream,
-			OutputInterval: cfg.streamOutputInterval,
-			MaxPending:     cfg.streamOutputBuffer,
-		}))
+	// Plan output goes to stderr so stream mode keeps stdout for NDJSON events.
+	if err := runAutoPlan(ctx, cfg, storage, runner, os.Stderr); err != nil {
+		return err
 	}
-	if cfg.eventsPath != "" {
-		fileSink := contracts.NewFileEventSink(cfg.eventsPath)
-		if cfg.stream {
-			mirror := newMirrorEventSink(fileSink, cfg.streamOutputBuffer)
-			closers = append(closers, mirror.Close)
-			sinks = append(sinks, mirror)
-		} else {
-			sinks = append(sinks, fileSink)
-		}
+	eventSink, closeSinks, err := runEventSink(cfg, storage)
+	if err != nil {
+		return err
 	}
-	defer func() {
-		for _, closeFn := range closers {
-			closeFn()
-		}
-	}()
-	eventSink := contracts.EventSink(nil)
-	if len(sinks) == 1 {
-		eventSink = sinks[0]
-	} else if len(sinks) > 1 {
-		eventSink = contracts.NewFanoutEventSink(sinks...)
+	defer closeSinks()
+	if cfg.noVCS {
+		vcs = nil
 	}
 	vcsFactory := cloneScopedVCSFactory(cfg, vcs)
+	defer markRunClonesLive(cfg, os.Stderr)()
+	cloneManager := taskCloneManager(cfg)
+	defer closeCloneManager(cloneManager)
+	shutdown := newShutdownSignals()
 	loop := agent.NewLoopWithTaskEngine(storage, taskEngine, runner, eventSink, agent.LoopOptions{
 		ParentID:             cfg.rootID,
 		MaxRetries:           cfg.retryBudget,
@@ -1267,20 +1767,68 @@ func runWithStorageComponents(ctx context.Context, cfg runConfig, storage contra
 		QCGateTools:          cfg.qcGateTools,
 		AllowLowQuality:      cfg.allowLowQuality,
 		SchedulerStatePath:   filepath.Join(cfg.repoRoot, ".yolo-runner", "scheduler-state.json"),
+		DurationHistoryPath:  filepath.Join(cfg.repoRoot, ".yolo-runner", "durations.json"),
 		DryRun:               cfg.dryRun,
+		DryRunPlanOutput:     dryRunPlanOutput(cfg),
+		DryRunEmitPlan:       cfg.dryRun && cfg.dryRunEvents,
 		RepoRoot:             cfg.repoRoot,
 		Backend:              cfg.backend,
 		Model:                cfg.model,
 		RunnerTimeout:        cfg.runnerTimeout,
 		WatchdogTimeout:      cfg.watchdogTimeout,
 		WatchdogInterval:     cfg.watchdogInterval,
+		WatchdogHooks:        cfg.watchdogHooks,
+		WatchdogHookTimeout:  cfg.watchdogHookTimeout,
 		TDDMode:              cfg.tddMode,
+		PromptTemplates:      cfg.promptTemplates,
+		CommitMessages:       cfg.commitMessages,
+		LandingStrategy:      cfg.landingStrategy,
+		PreLandingHooks:      cfg.preLandingHooks,
+		LandingHookTimeout:   cfg.landingHookTimeout,
+		PathScope:            cfg.pathScope,
+		DiffGuardrails:       cfg.diffGuardrails,
+		DiffApprover:         diffApprover(cfg),
+		SecretScan:           cfg.secretScan,
+		DependencyPolicy:     cfg.dependencyPolicy,
+		MergeQueueBatchSize:  cfg.mergeQueueBatchSize,
+		PushRetries:          cfg.pushRetries,
+		PromptContext:        promptContextBuilder(cfg),
+		FollowUpIssues:       cfg.followUpIssues,
+		ResumeSessions:       cfg.resumeSessions,
+		DiffSummary:          cfg.diffSummary,
+		StallNudgePrompt:     cfg.stallNudgePrompt,
+		StallPolicies:        cfg.stallPolicies,
+		RateLimitBackoff:     cfg.rateLimitBackoff,
+		EpicProgressInterval: cfg.epicProgressInterval,
+		FallbackChain:        cfg.fallbackChain,
 		VCS:                  vcs,
-		RequireReview:        true,
+		RequireReview:        !cfg.skipReview,
 		MergeOnSuccess:       true,
-		CloneManager:         agent.NewGitCloneManager(filepath.Join(cfg.repoRoot, ".yolo-runner", "clones")),
+		CloneManager:         cloneManager,
 		VCSFactory:           vcsFactory,
+		WorkspaceVCSFactory:  workspaceVCSFactory(cfg, vcs),
+
+		TaskDiscoveryInterval:   cfg.taskDiscoveryInterval,
+		StatusReconcileInterval: cfg.statusReconcileInterval,
+		Stop:                    shutdown.stop,
+		ShutdownGrace:           cfg.shutdownGrace,
+		OrphanedTasks:           cfg.orphanedTasks,
+		ReviewMode:              cfg.reviewMode,
+		ReviewConcurrency:       cfg.reviewConcurrency,
+		RunID:                   cfg.runID,
+		BlockedRetryPolicies:    cfg.blockedRetryPolicies,
+		QCGateTestReruns:        cfg.qcGateTestReruns,
+		TaskEnv:                 cfg.taskEnv,
+		ToolPolicy:              cfg.toolPolicy,
+		WorkerAffinity:          cfg.workerAffinity,
+		Pipeline:                cfg.pipeline,
+		StageApprover:           stageApprover(cfg),
 	})
+	stopControlAPI, err := serveControlAPI(cfg, loop)
+	if err != nil {
+		return err
+	}
+	defer stopControlAPI()
 	if eventSink != nil {
 		_ = eventSink.Emit(ctx, contracts.Event{
 			Type:      contracts.EventTypeRunStarted,
@@ -1291,19 +1839,139 @@ func runWithStorageComponents(ctx context.Context, cfg runConfig, storage contra
 		})
 	}
 
+	stopShutdownSignals := shutdown.watch(loop, os.Stderr)
 	summary, err := loop.Run(ctx)
+	stopShutdownSignals()
+	if err == nil && shutdown.requested() {
+		err = agent.ErrRunInterrupted
+	}
 	if eventSink != nil {
 		_ = eventSink.Emit(ctx, contracts.Event{
 			Type:      contracts.EventTypeRunFinished,
 			TaskID:    cfg.rootID,
 			TaskTitle: "run",
-			Metadata:  buildRunFinishedMetadata(cfg, summary, err),
+			Metadata:  withCloneManagerStats(buildRunFinishedMetadata(cfg, summary, err), cloneManager),
 			Timestamp: time.Now().UTC(),
 		})
 	}
 	return err
 }
 
+// promptContextBuilder returns a per-run repo context builder, or nil when
+// agent.repo_context is not enabled.
+func promptContextBuilder(cfg runConfig) agent.PromptContextBuilder {
+	if cfg.repoContext == nil {
+		return nil
+	}
+	return repocontext.New(cfg.repoRoot, *cfg.repoContext)
+}
+
+// diffApprover asks diff guardrail questions through the control API, so
+// they are answered like tool call approvals. Without --serve there is no
+// approver and tripped guardrails block the task.
+func diffApprover(cfg runConfig) agent.DiffApprover {
+	if cfg.controlAPI == nil {
+		return nil
+	}
+	return func(ctx context.Context, task contracts.Task, reason string) (bool, error) {
+		return cfg.controlAPI.AskPermission(ctx, acp.PermissionRequest{TaskID: task.ID, Kind: "diff_guardrail", Title: reason})
+	}
+}
+
+// stageApprover asks pipeline approval stages through the control API, like
+// diff guardrail approvals. Without --serve approval stages block the task.
+func stageApprover(cfg runConfig) agent.StageApprover {
+	if cfg.controlAPI == nil {
+		return nil
+	}
+	return func(ctx context.Context, task contracts.Task, stage string) (bool, error) {
+		return cfg.controlAPI.AskPermission(ctx, acp.PermissionRequest{TaskID: task.ID, Kind: "pipeline_approval", Title: "pipeline stage " + stage})
+	}
+}
+
+func dryRunPlanOutput(cfg runConfig) io.Writer {
+	if !cfg.dryRun {
+		return nil
+	}
+	if cfg.stream {
+		// stdout carries NDJSON events in stream mode.
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// runEventSink builds the sink chain a run reports its events to. tracker is
+// the task manager or storage backend the comment trail and escalation sinks
+// write to. The returned func closes the sinks once the run is over.
+func runEventSink(cfg runConfig, tracker any) (contracts.EventSink, func(), error) {
+	sinks := []contracts.EventSink{}
+	closers := []func(){}
+	closeAll := func() {
+		for _, closeFn := range closers {
+			closeFn()
+		}
+	}
+	if sink := monitorEventSink(cfg); sink != nil {
+		sinks = append(sinks, sink)
+	}
+	if cfg.stream {
+		streamWriter := io.Writer(os.Stdout)
+		if cfg.mode == agentModeUI {
+			stdin, closeFn, err := launchYoloTUI()
+			if err != nil {
+				return nil, nil, fmt.Errorf("start yolo-tui: %w", err)
+			}
+			streamWriter = stdin
+			closers = append(closers, func() {
+				_ = closeFn()
+			})
+		}
+		streamSink := contracts.NewStreamEventSinkWithOptions(streamWriter, contracts.StreamEventSinkOptions{
+			VerboseOutput:  cfg.verboseStream,
+			OutputInterval: cfg.streamOutputInterval,
+			MaxPending:     cfg.streamOutputBuffer,
+		})
+		sinks = append(sinks, contracts.NewFilteredEventSink(streamSink, cfg.eventSinkFilters[eventSinkStream]))
+	}
+	if cfg.eventsPath != "" {
+		fileSink := contracts.EventSink(contracts.NewFileEventSinkWithOptions(cfg.eventsPath, cfg.eventLog))
+		if cfg.stream {
+			mirror := newMirrorEventSink(fileSink, cfg.streamOutputBuffer)
+			closers = append(closers, mirror.Close)
+			fileSink = mirror
+		}
+		sinks = append(sinks, contracts.NewFilteredEventSink(fileSink, cfg.eventSinkFilters[eventSinkFile]))
+	}
+	if sink, closeFn := commentTrailEventSink(cfg, tracker, os.Stderr); sink != nil {
+		sinks = append(sinks, sink)
+		closers = append(closers, closeFn)
+	}
+	if cfg.checkRunSink != nil {
+		sinks = append(sinks, cfg.checkRunSink)
+		if closer, ok := cfg.checkRunSink.(interface{ Close() }); ok {
+			closers = append(closers, closer.Close)
+		}
+	}
+	if sink, closeFn := escalationEventSink(cfg, tracker, os.Stderr); sink != nil {
+		sinks = append(sinks, sink)
+		closers = append(closers, closeFn)
+	}
+	if cfg.controlAPI != nil {
+		sinks = append(sinks, cfg.controlAPI)
+	}
+	eventSink := contracts.EventSink(nil)
+	if len(sinks) == 1 {
+		eventSink = sinks[0]
+	} else if len(sinks) > 1 {
+		eventSink = contracts.NewFanoutEventSink(sinks...)
+	}
+	if eventSink != nil {
+		eventSink = contracts.NewRedactingEventSink(eventSink, cfg.redactor)
+		eventSink = contracts.NewSequencedEventSink(cfg.runID, contracts.NewSecretMaskingEventSink(eventSink, agent.TaskEnvSecrets(cfg.taskEnv)))
+	}
+	return eventSink, closeAll, nil
+}
+
 func monitorEventSink(cfg runConfig) contracts.EventSink {
 	if cfg.distributedEventBus == nil {
 		return nil
@@ -1358,6 +2026,56 @@ func (sink *distributedMonitorEventSink) Emit(ctx context.Context, event contrac
 	return sink.bus.Publish(ctx, sink.subject, envelope)
 }
 
+// taskCloneManager gives each task its own clone of the repository, except
+// in --no-vcs mode where tasks run directly in the repo directory. The
+// overlay strategy layers tasks on one shared checkout instead, and with a
+// clone pool the clones are created ahead of the tasks.
+func taskCloneManager(cfg runConfig) agent.CloneManager {
+	if cfg.noVCS {
+		return nil
+	}
+	baseDir := filepath.Join(cfg.repoRoot, ".yolo-runner", "clones")
+	if cfg.cloneStrategy == agent.CloneStrategyOverlay {
+		return agent.NewOverlayCloneManager(baseDir, cfg.repoRoot).WithRunID(cfg.runID)
+	}
+	if cfg.clonePool.Size > 0 {
+		pool := agent.NewClonePool(baseDir, cfg.repoRoot, cfg.clonePool).WithRunID(cfg.runID)
+		pool.Warm()
+		return pool
+	}
+	return agent.NewGitCloneManager(baseDir).WithRunID(cfg.runID)
+}
+
+// closeCloneManager removes the pooled clones or the shared overlay base a
+// clone manager still holds when the run ends.
+func closeCloneManager(manager agent.CloneManager) {
+	if closer, ok := manager.(interface{ Close() error }); ok {
+		_ = closer.Close()
+	}
+}
+
+// withCloneManagerStats adds the clone pool's hit rate or the overlay
+// method in use to run_finished metadata.
+func withCloneManagerStats(metadata map[string]string, manager agent.CloneManager) map[string]string {
+	if overlay, ok := manager.(*agent.OverlayCloneManager); ok {
+		metadata["clone_strategy"] = string(agent.CloneStrategyOverlay)
+		if method := overlay.Method(); method != "" {
+			metadata["clone_overlay_method"] = method
+		}
+		return metadata
+	}
+	pool, ok := manager.(*agent.ClonePool)
+	if !ok {
+		return metadata
+	}
+	stats := pool.Stats()
+	metadata["clone_pool_size"] = strconv.Itoa(stats.Size)
+	metadata["clone_pool_hits"] = strconv.Itoa(stats.Hits)
+	metadata["clone_pool_misses"] = strconv.Itoa(stats.Misses)
+	metadata["clone_pool_hit_rate"] = strconv.FormatFloat(stats.HitRate(), 'f', 2, 64)
+	return metadata
+}
+
 func cloneScopedVCSFactory(cfg runConfig, vcs contracts.VCS) agent.VCSFactory {
 	if _, ok := vcs.(*gitvcs.VCSAdapter); !ok {
 		return nil
@@ -1367,10 +2085,39 @@ func cloneScopedVCSFactory(cfg runConfig, vcs contracts.VCS) agent.VCSFactory {
 		if targetRoot == "" {
 			targetRoot = cfg.repoRoot
 		}
-		return gitvcs.NewVCSAdapter(localGitRunner{dir: targetRoot})
+		return gitvcs.NewVCSAdapter(localGitRunner{dir: targetRoot}).WithRemote(cfg.syncRemote, cfg.syncBranch).WithRunID(cfg.runID)
 	}
 }
 
+// workspaceVCSFactory scopes git to a task workspace clone: its origin is
+// the workspace repository and the workspace ref is the branch tasks start
+// from and land on.
+func workspaceVCSFactory(cfg runConfig, vcs contracts.VCS) agent.WorkspaceVCSFactory {
+	if _, ok := vcs.(*gitvcs.VCSAdapter); !ok {
+		return nil
+	}
+	return func(repoRoot string, workspace agent.Workspace) contracts.VCS {
+		return gitvcs.NewVCSAdapter(localGitRunner{dir: repoRoot}).WithRemote("origin", workspace.Ref).WithRunID(cfg.runID)
+	}
+}
+
+// newRunID names one yolo-agent run in the event stream. The timestamp keeps
+// IDs sortable; the random suffix separates runs started in the same second.
+func newRunID(now time.Time) string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return now.UTC().Format("20060102T150405Z")
+	}
+	return now.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
+
+func runVCSMode(cfg runConfig) string {
+	if cfg.noVCS {
+		return "none"
+	}
+	return "git"
+}
+
 func buildRunStartedMetadata(cfg runConfig) map[string]string {
 	return map[string]string{
 		"root_id":                cfg.rootID,
@@ -1379,6 +2126,21 @@ func buildRunStartedMetadata(cfg runConfig) map[string]string {
 		"tracker":                strings.TrimSpace(cfg.trackerType),
 		"quality_threshold":      strconv.Itoa(cfg.qualityThreshold),
 		"retry_budget":           strconv.Itoa(cfg.retryBudget),
+		"review_concurrency":     strconv.Itoa(cfg.reviewConcurrency),
+		"resume_sessions":        strconv.FormatBool(cfg.resumeSessions),
+		"skip_review":            strconv.FormatBool(cfg.skipReview),
+		"stall_nudge":            strconv.FormatBool(cfg.stallNudgePrompt != ""),
+		"stall_policies":         formatStallPolicies(cfg.stallPolicies),
+		"blocked_retry":          formatBlockedRetryPolicies(cfg.blockedRetryPolicies),
+		"rate_limit_backoff":     cfg.rateLimitBackoff.String(),
+		"epic_progress_interval": cfg.epicProgressInterval.String(),
+		"fallback_chain":         formatFallbackChain(cfg.fallbackChain),
+		"backend_capabilities":   formatBackendCapabilities(cfg.backendCapabilities),
+		"local_store":            cfg.localStorePath,
+		"vcs":                    runVCSMode(cfg),
+		"sync_remote":            cfg.syncRemote,
+		"sync_branch":            cfg.syncBranch,
+		"tracker_cache_ttl":      cfg.trackerCacheTTL.String(),
 		"concurrency":            strconv.Itoa(cfg.concurrency),
 		"model":                  cfg.model,
 		"allow_low_quality":      strconv.FormatBool(cfg.allowLowQuality),
@@ -1405,6 +2167,9 @@ func buildRunFinishedMetadata(cfg runConfig, summary contracts.LoopSummary, runE
 	}
 	if runErr != nil {
 		metadata["status"] = "failed"
+		if errors.Is(runErr, agent.ErrRunInterrupted) {
+			metadata["status"] = "interrupted"
+		}
 		metadata["error"] = runErr.Error()
 	}
 	return metadata
@@ -1425,10 +2190,14 @@ func catalogBackendCapabilities(catalog codingagents.Catalog) map[string]backend
 		if !ok {
 			continue
 		}
-		capabilities[name] = backendCapabilities{
+		caps := backendCapabilities{
 			SupportsReview: profile.SupportsReview,
 			SupportsStream: profile.SupportsStream,
 		}
+		if definition, ok := catalog.Backend(name); ok {
+			caps.SupportsResume, caps.SupportsACP = adapterSessionCapabilities(definition.Adapter)
+		}
+		capabilities[name] = caps
 	}
 	if len(capabilities) == 0 {
 		return defaultBackendCapabilityMatrix()
@@ -1436,6 +2205,21 @@ func catalogBackendCapabilities(catalog codingagents.Catalog) map[string]backend
 	return capabilities
 }
 
+// adapterSessionCapabilities reports whether runners built for adapter can
+// resume an interrupted session and whether they speak ACP.
+func adapterSessionCapabilities(adapter string) (resume bool, speaksACP bool) {
+	switch strings.ToLower(strings.TrimSpace(adapter)) {
+	case "codex", "codex-app-server", "claude":
+		return true, false
+	case "acp":
+		return true, true
+	case "opencode", "opencode-serve":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
 func distributedExecutorCapabilitiesForBackend(catalog codingagents.Catalog, backend string, explicitRaw string, explicit bool) ([]distributed.Capability, error) {
 	if explicit {
 		return parseDistributedExecutorCapabilities(explicitRaw)
@@ -15,13 +15,18 @@ import (
 	"testing"
 	"time"
 
+	"github.com/egv/yolo-runner/v2/internal/acp"
+	"github.com/egv/yolo-runner/v2/internal/agent"
 	"github.com/egv/yolo-runner/v2/internal/codex"
 	"github.com/egv/yolo-runner/v2/internal/codingagents"
 	"github.com/egv/yolo-runner/v2/internal/contracts"
 	"github.com/egv/yolo-runner/v2/internal/distributed"
 	"github.com/egv/yolo-runner/v2/internal/github"
 	"github.com/egv/yolo-runner/v2/internal/linear"
+	"github.com/egv/yolo-runner/v2/internal/ollama"
 	"github.com/egv/yolo-runner/v2/internal/opencode"
+	"github.com/egv/yolo-runner/v2/internal/qwen"
+	"github.com/egv/yolo-runner/v2/internal/replay"
 )
 
 type runnerTransportRequest struct {
@@ -145,6 +150,7 @@ func TestRunMainParsesQCGateTools(t *testing.T) {
 		"--repo", "/repo",
 		"--root", "root-1",
 		"--qc-gate-tools", "test_runner, linter, coverage_checker",
+		"--qc-gate-test-reruns", "2",
 	}, run)
 	if code != 0 {
 		t.Fatalf("expected exit code 0, got %d", code)
@@ -158,6 +164,9 @@ func TestRunMainParsesQCGateTools(t *testing.T) {
 	if got.qcGateTools[0] != "test_runner" || got.qcGateTools[1] != "linter" || got.qcGateTools[2] != "coverage_checker" {
 		t.Fatalf("unexpected qc gate tools ordering/values: %#v", got.qcGateTools)
 	}
+	if got.qcGateTestReruns != 2 {
+		t.Fatalf("expected two qc gate test reruns, got %d", got.qcGateTestReruns)
+	}
 }
 
 func TestRunMainRoutesConfigValidateSubcommand(t *testing.T) {
@@ -234,7 +243,7 @@ func TestRunMainConfigCommandRequiresSubcommand(t *testing.T) {
 		}
 	})
 
-	if !strings.Contains(errText, "usage: yolo-agent config <validate|init> [flags]") {
+	if !strings.Contains(errText, "usage: yolo-agent config <validate|init|schema> [flags]") {
 		t.Fatalf("expected config usage guidance, got %q", errText)
 	}
 }
@@ -250,7 +259,7 @@ func TestRunMainRejectsUnknownConfigSubcommand(t *testing.T) {
 	if !strings.Contains(errText, "unknown config command: unknown") {
 		t.Fatalf("expected unknown config command message, got %q", errText)
 	}
-	if !strings.Contains(errText, "usage: yolo-agent config <validate|init> [flags]") {
+	if !strings.Contains(errText, "usage: yolo-agent config <validate|init|schema> [flags]") {
 		t.Fatalf("expected config usage guidance, got %q", errText)
 	}
 }
@@ -345,6 +354,78 @@ func TestBuildRunnerAdapterUsesCLIAdapterForOpencodeACPBackend(t *testing.T) {
 	}
 }
 
+func TestBuildRunnerAdapterUsesAmazonQAdapterForAmazonQBackend(t *testing.T) {
+	catalog, err := codingagents.LoadCatalog("")
+	if err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+
+	runner, err := buildRunnerAdapter(runConfig{
+		backend:      "amazonq",
+		codingAgents: catalog,
+	})
+	if err != nil {
+		t.Fatalf("build amazonq adapter: %v", err)
+	}
+	if _, ok := runner.(*codingagents.AmazonQRunnerAdapter); !ok {
+		t.Fatalf("expected *codingagents.AmazonQRunnerAdapter, got %T", runner)
+	}
+}
+
+func TestBuildRunnerAdapterUsesQwenAdapterForQwenBackend(t *testing.T) {
+	catalog, err := codingagents.LoadCatalog("")
+	if err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+
+	runner, err := buildRunnerAdapter(runConfig{
+		backend:      "qwen",
+		codingAgents: catalog,
+	})
+	if err != nil {
+		t.Fatalf("build qwen adapter: %v", err)
+	}
+	if _, ok := runner.(*qwen.CLIRunnerAdapter); !ok {
+		t.Fatalf("expected *qwen.CLIRunnerAdapter, got %T", runner)
+	}
+}
+
+func TestBuildRunnerAdapterUsesOllamaAdapterForOllamaBackend(t *testing.T) {
+	catalog, err := codingagents.LoadCatalog("")
+	if err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+
+	runner, err := buildRunnerAdapter(runConfig{
+		backend:      "ollama",
+		codingAgents: catalog,
+	})
+	if err != nil {
+		t.Fatalf("build ollama adapter: %v", err)
+	}
+	if _, ok := runner.(*ollama.RunnerAdapter); !ok {
+		t.Fatalf("expected *ollama.RunnerAdapter, got %T", runner)
+	}
+}
+
+func TestBuildRunnerAdapterUsesServerAdapterForOpencodeServerBackend(t *testing.T) {
+	catalog, err := codingagents.LoadCatalog("")
+	if err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+
+	runner, err := buildRunnerAdapter(runConfig{
+		backend:      "opencode-server",
+		codingAgents: catalog,
+	})
+	if err != nil {
+		t.Fatalf("build opencode-server adapter: %v", err)
+	}
+	if _, ok := runner.(*opencode.ServerRunnerAdapter); !ok {
+		t.Fatalf("expected *opencode.ServerRunnerAdapter, got %T", runner)
+	}
+}
+
 func TestBuildRunnerAdapterUsesServeAdapterForOpencodeServeBackend(t *testing.T) {
 	catalog, err := codingagents.LoadCatalog("")
 	if err != nil {
@@ -363,6 +444,86 @@ func TestBuildRunnerAdapterUsesServeAdapterForOpencodeServeBackend(t *testing.T)
 	}
 }
 
+func TestBuildRunnerAdapterUsesStdioAdapterForCustomACPBackend(t *testing.T) {
+	repoRoot := t.TempDir()
+	customDir := filepath.Join(repoRoot, ".yolo-runner", "coding-agents")
+	if err := os.MkdirAll(customDir, 0o755); err != nil {
+		t.Fatalf("create custom backend directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(customDir, "my-acp.yaml"), []byte("name: my-acp\nadapter: acp\nbinary: my-agent\nargs:\n  - --acp\n"), 0o644); err != nil {
+		t.Fatalf("write custom backend definition: %v", err)
+	}
+	catalog, err := codingagents.LoadCatalog(repoRoot)
+	if err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+
+	runner, err := buildRunnerAdapter(runConfig{
+		backend:      "my-acp",
+		codingAgents: catalog,
+	})
+	if err != nil {
+		t.Fatalf("build acp adapter: %v", err)
+	}
+	if _, ok := runner.(*acp.StdioRunnerAdapter); !ok {
+		t.Fatalf("expected *acp.StdioRunnerAdapter, got %T", runner)
+	}
+}
+
+func TestBuildRunnerAdapterUsesExecAdapterForExecBackend(t *testing.T) {
+	repoRoot := t.TempDir()
+	customDir := filepath.Join(repoRoot, ".yolo-runner", "coding-agents")
+	if err := os.MkdirAll(customDir, 0o755); err != nil {
+		t.Fatalf("create custom backend directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(customDir, "in-house.yaml"), []byte("name: in-house\nadapter: exec\nbinary: ./scripts/agent.sh\n"), 0o644); err != nil {
+		t.Fatalf("write custom backend definition: %v", err)
+	}
+	catalog, err := codingagents.LoadCatalog(repoRoot)
+	if err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+
+	runner, err := buildRunnerAdapter(runConfig{
+		backend:      "in-house",
+		codingAgents: catalog,
+	})
+	if err != nil {
+		t.Fatalf("build exec adapter: %v", err)
+	}
+	if _, ok := runner.(*codingagents.ExecRunnerAdapter); !ok {
+		t.Fatalf("expected *codingagents.ExecRunnerAdapter, got %T", runner)
+	}
+}
+
+func TestBuildACPRunnerAdapterValidatesPermissionConfig(t *testing.T) {
+	_, err := buildACPRunnerAdapter(codingagents.BackendDefinition{
+		Name:    "my-acp",
+		Adapter: "acp",
+		Binary:  "my-agent",
+		Config:  map[string]any{"permissions": map[string]any{"execute": "sometimes"}},
+	}, nil)
+	if err == nil || !strings.Contains(err.Error(), "config.permissions") {
+		t.Fatalf("expected invalid permission rule error, got %v", err)
+	}
+
+	runner, err := buildACPRunnerAdapter(codingagents.BackendDefinition{
+		Name:    "my-acp",
+		Adapter: "acp",
+		Binary:  "my-agent",
+		Config: map[string]any{
+			"permissions":    map[string]any{"execute": "allow", "default": "deny"},
+			"control_socket": "/tmp/yolo-control.sock",
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("build acp adapter: %v", err)
+	}
+	if _, ok := runner.(*acp.StdioRunnerAdapter); !ok {
+		t.Fatalf("expected *acp.StdioRunnerAdapter, got %T", runner)
+	}
+}
+
 func TestBuildRunnerAdapterUsesCodexAppServerAndCodexCLIFallback(t *testing.T) {
 	repoRoot := t.TempDir()
 	binDir := t.TempDir()
@@ -703,6 +864,25 @@ agent:
   watchdog_timeout: 2m
   watchdog_interval: 3s
   retry_budget: 4
+  resume_sessions: true
+  rate_limit_backoff: 1m
+  epic_progress_interval: 5m
+  task_discovery_interval: 30s
+  status_reconcile_interval: 2m
+  shutdown_grace_period: 30s
+  escalation:
+    after: 45m
+    assignee: alice
+  blocked_retry:
+    rate_limit:
+      max_attempts: 2
+      backoff: 5m
+  event_sinks:
+    file:
+      exclude: [runner_heartbeat]
+  fallback_chain:
+    - backend: claude
+      model: claude-sonnet
 `)
 
 	called := false
@@ -741,6 +921,586 @@ agent:
 	if got.retryBudget != 4 {
 		t.Fatalf("expected retry budget from config=4, got %d", got.retryBudget)
 	}
+	if !got.resumeSessions {
+		t.Fatalf("expected resume_sessions from config")
+	}
+	if got.rateLimitBackoff != time.Minute {
+		t.Fatalf("expected rate limit backoff from config 1m, got %s", got.rateLimitBackoff)
+	}
+	if got.epicProgressInterval != 5*time.Minute {
+		t.Fatalf("expected epic progress interval from config 5m, got %s", got.epicProgressInterval)
+	}
+	if got.taskDiscoveryInterval != 30*time.Second {
+		t.Fatalf("expected task discovery interval from config 30s, got %s", got.taskDiscoveryInterval)
+	}
+	if got.statusReconcileInterval != 2*time.Minute {
+		t.Fatalf("expected status reconcile interval from config 2m, got %s", got.statusReconcileInterval)
+	}
+	if got.shutdownGrace != 30*time.Second {
+		t.Fatalf("expected shutdown grace period from config 30s, got %s", got.shutdownGrace)
+	}
+	if got.escalation.After != 45*time.Minute || got.escalation.Assignee != "alice" {
+		t.Fatalf("expected escalation policy from config, got %#v", got.escalation)
+	}
+	if policy := got.blockedRetryPolicies["rate_limit"]; policy.MaxAttempts != 2 || policy.Backoff != 5*time.Minute {
+		t.Fatalf("expected blocked retry policy from config, got %#v", got.blockedRetryPolicies)
+	}
+	if filter := got.eventSinkFilters[eventSinkFile]; len(filter.Exclude) != 1 || filter.Exclude[0] != contracts.EventTypeRunnerHeartbeat {
+		t.Fatalf("expected file sink filter from config, got %#v", got.eventSinkFilters)
+	}
+	if len(got.fallbackChain) != 1 || got.fallbackChain[0] != (agent.ModelTarget{Backend: "claude", Model: "claude-sonnet"}) {
+		t.Fatalf("expected fallback chain from config, got %#v", got.fallbackChain)
+	}
+}
+
+func TestRunMainRequiresSkipReviewForBackendWithoutReview(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  backend: ollama
+`)
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1"}, func(context.Context, runConfig) error { return nil }); code == 0 {
+		t.Fatalf("expected a backend without review support to fail without skip_review")
+	}
+
+	var got runConfig
+	code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--skip-review"}, func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	})
+	if code != 0 {
+		t.Fatalf("expected exit code 0 with --skip-review, got %d", code)
+	}
+	if !got.skipReview || got.backendCapabilities.SupportsReview {
+		t.Fatalf("expected review to be skipped for ollama, got skipReview=%v capabilities=%#v", got.skipReview, got.backendCapabilities)
+	}
+	if got.model != "qwen2.5-coder:14b" {
+		t.Fatalf("expected catalog default model, got %q", got.model)
+	}
+}
+
+func TestRunMainAppliesBackendCapabilityOverridesFromConfig(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  backend: codex
+  resume_sessions: true
+  backend_capabilities:
+    codex:
+      session_resume: false
+      acp: true
+`)
+
+	var got runConfig
+	code := RunMain([]string{"--repo", repoRoot, "--root", "root-1"}, func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.resumeSessions {
+		t.Fatalf("expected resume_sessions to be dropped for a backend without session resume")
+	}
+	if !got.backendCapabilities.SupportsACP || got.backendCapabilities.SupportsResume || !got.backendCapabilities.SupportsReview {
+		t.Fatalf("expected configured capabilities, got %#v", got.backendCapabilities)
+	}
+}
+
+func TestRunMainRejectsStreamWhenConfigDisablesBackendStreaming(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  backend: codex
+  backend_capabilities:
+    codex:
+      stream: false
+`)
+
+	code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--stream"}, func(context.Context, runConfig) error {
+		t.Fatalf("did not expect run function to be called")
+		return nil
+	})
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+}
+
+func TestRunMainStallNudgeUsesConfiguredPrompt(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  stall_nudge: true
+  stall_nudge_prompt: Pick the option that keeps the public API unchanged.
+`)
+
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.stallNudgePrompt != "Pick the option that keeps the public API unchanged." {
+		t.Fatalf("expected configured nudge prompt, got %q", got.stallNudgePrompt)
+	}
+
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--stall-nudge=false"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.stallNudgePrompt != "" {
+		t.Fatalf("expected --stall-nudge=false to disable the nudge, got %q", got.stallNudgePrompt)
+	}
+}
+
+func TestRunMainStallNudgeFlagUsesDefaultPrompt(t *testing.T) {
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	if code := RunMain([]string{"--repo", "/repo", "--root", "root-1", "--stall-nudge"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.stallNudgePrompt != agent.DefaultStallNudgePrompt {
+		t.Fatalf("expected default nudge prompt, got %q", got.stallNudgePrompt)
+	}
+}
+
+func TestRunMainLoadsStallPoliciesFromConfig(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  stall_policies:
+    rate_limit: retry
+    waiting_on_tool: block
+`)
+
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.stallPolicies[contracts.StallCategoryRateLimit] != contracts.StallPolicyRetry || got.stallPolicies[contracts.StallCategoryWaitingOnTool] != contracts.StallPolicyBlock {
+		t.Fatalf("expected stall policies from config, got %#v", got.stallPolicies)
+	}
+	if metadata := buildRunStartedMetadata(got); metadata["stall_policies"] != "waiting_on_tool=block,rate_limit=retry" {
+		t.Fatalf("expected stall policies in run metadata, got %q", metadata["stall_policies"])
+	}
+}
+
+func TestRunMainResumeSessionsFlagOverridesConfig(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  resume_sessions: true
+`)
+
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--resume-sessions=false"}, run)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.resumeSessions {
+		t.Fatalf("expected --resume-sessions=false to override config")
+	}
+}
+
+func TestRunMainDiffSummaryFromConfigAndFlag(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  diff_summary: true
+`)
+
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if !got.diffSummary {
+		t.Fatalf("expected agent.diff_summary to enable diff summaries")
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--diff-summary=false"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.diffSummary {
+		t.Fatalf("expected --diff-summary=false to override config")
+	}
+}
+
+func TestRunMainLandingStrategyFromConfigAndFlag(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  landing:
+    strategy: rebase_ff
+`)
+
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.landingStrategy != agent.LandingStrategyRebaseFF {
+		t.Fatalf("expected rebase_ff from config, got %q", got.landingStrategy)
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--landing-strategy", "merge"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.landingStrategy != agent.LandingStrategyMerge {
+		t.Fatalf("expected --landing-strategy to override config, got %q", got.landingStrategy)
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--landing-strategy", "octopus"}, run); code != 1 {
+		t.Fatalf("expected unknown --landing-strategy to fail, got %d", code)
+	}
+}
+
+func TestRunMainSyncRemoteFromConfigAndFlags(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  sync:
+    remote: upstream
+    branch: develop
+`)
+
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.syncRemote != "upstream" || got.syncBranch != "develop" {
+		t.Fatalf("expected sync target from config, got %q/%q", got.syncRemote, got.syncBranch)
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--sync-remote", "origin"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.syncRemote != "origin" || got.syncBranch != "develop" {
+		t.Fatalf("expected --sync-remote to override only the remote, got %q/%q", got.syncRemote, got.syncBranch)
+	}
+	if metadata := buildRunStartedMetadata(got); metadata["sync_remote"] != "origin" || metadata["sync_branch"] != "develop" {
+		t.Fatalf("expected sync target in run metadata, got %#v", metadata)
+	}
+}
+
+func TestRunMainNoVCSFromConfigAndFlag(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  vcs: none
+`)
+
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if !got.noVCS || buildRunStartedMetadata(got)["vcs"] != "none" {
+		t.Fatalf("expected agent.vcs: none to disable VCS, got noVCS=%v", got.noVCS)
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--no-vcs=false"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.noVCS || buildRunStartedMetadata(got)["vcs"] != "git" {
+		t.Fatalf("expected --no-vcs=false to override config, got noVCS=%v", got.noVCS)
+	}
+}
+
+func TestRunMainClonePoolFromConfigAndFlag(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  clone_pool:
+    size: 2
+    refresh: recreate
+    max_uses: 5
+`)
+
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.clonePool != (agent.ClonePoolOptions{Size: 2, Refresh: agent.ClonePoolRefreshRecreate, MaxUses: 5}) {
+		t.Fatalf("expected clone pool from config, got %#v", got.clonePool)
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--clone-pool-size", "0"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.clonePool.Size != 0 {
+		t.Fatalf("expected --clone-pool-size to override config, got %#v", got.clonePool)
+	}
+	if _, ok := taskCloneManager(got).(*agent.GitCloneManager); !ok {
+		t.Fatalf("expected plain clones without a pool")
+	}
+}
+
+func TestRunMainCloneStrategyFromConfigAndFlag(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  clone_strategy: overlay
+`)
+
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	manager := taskCloneManager(got)
+	if _, ok := manager.(*agent.OverlayCloneManager); !ok {
+		t.Fatalf("expected overlay clone manager from config, got %T", manager)
+	}
+	if metadata := withCloneManagerStats(map[string]string{}, manager); metadata["clone_strategy"] != "overlay" {
+		t.Fatalf("expected clone_strategy in run metadata, got %#v", metadata)
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--clone-strategy", "clone"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.cloneStrategy != agent.CloneStrategyClone {
+		t.Fatalf("expected --clone-strategy to override config, got %q", got.cloneStrategy)
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--clone-strategy", "worktree"}, run); code != 1 {
+		t.Fatalf("expected exit code 1 for an unknown clone strategy, got %d", code)
+	}
+}
+
+func TestRunMainOrphanedTasksFromConfigAndFlag(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  orphaned_tasks: block
+`)
+
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.orphanedTasks != agent.OrphanedTaskBlock {
+		t.Fatalf("expected orphaned_tasks block from config, got %q", got.orphanedTasks)
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--orphaned-tasks", "reopen"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.orphanedTasks != agent.OrphanedTaskReopen {
+		t.Fatalf("expected --orphaned-tasks to override config, got %q", got.orphanedTasks)
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--orphaned-tasks", "fail"}, run); code != 1 {
+		t.Fatalf("expected exit code 1 for an unknown orphaned task policy, got %d", code)
+	}
+}
+
+func TestRunMainReviewModeFromConfigAndFlag(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  review_mode: two_phase
+`)
+
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.reviewMode != agent.ReviewModeTwoPhase {
+		t.Fatalf("expected review_mode two_phase from config, got %q", got.reviewMode)
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--review-mode", "single"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.reviewMode != agent.ReviewModeSingle {
+		t.Fatalf("expected --review-mode to override config, got %q", got.reviewMode)
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--review-mode", "strict"}, run); code != 1 {
+		t.Fatalf("expected exit code 1 for an unknown review mode, got %d", code)
+	}
+}
+
+func TestRunMainReviewConcurrencyFromConfigAndFlag(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+agent:
+  review_concurrency: 2
+`)
+
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.reviewConcurrency != 2 {
+		t.Fatalf("expected review_concurrency 2 from config, got %d", got.reviewConcurrency)
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--review-concurrency", "0"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.reviewConcurrency != 0 {
+		t.Fatalf("expected --review-concurrency to override config, got %d", got.reviewConcurrency)
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--review-concurrency", "-1"}, run); code != 1 {
+		t.Fatalf("expected exit code 1 for a negative review concurrency, got %d", code)
+	}
+}
+
+func TestRunMainReplayFixtureFlagsSelectRunner(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: tk
+`)
+
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--replay-fixtures", "fixtures", "--replay-realtime"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.replayFixtures != "fixtures" || !got.replayRealtime {
+		t.Fatalf("expected replay flags in run config, got %q realtime=%v", got.replayFixtures, got.replayRealtime)
+	}
+	if _, ok := withReplayFixtures(got, testRunner{}).(*replay.Runner); !ok {
+		t.Fatalf("expected --replay-fixtures to replace the backend runner")
+	}
+	if backends := preflightBackends(got); len(backends) != 0 {
+		t.Fatalf("expected replayed runs to skip backend preflight, got %#v", backends)
+	}
+	if _, ok := withReplayFixtures(runConfig{recordFixtures: "fixtures"}, testRunner{}).(*replay.Recorder); !ok {
+		t.Fatalf("expected --record-fixtures to wrap the backend runner")
+	}
+	if code := RunMain([]string{"--repo", repoRoot, "--root", "root-1", "--replay-fixtures", "a", "--record-fixtures", "b"}, run); code != 1 {
+		t.Fatalf("expected recording and replaying together to be rejected, got %d", code)
+	}
+}
+
+func TestWithCloneManagerStatsAddsClonePoolHitRate(t *testing.T) {
+	cfg := runConfig{repoRoot: t.TempDir(), clonePool: agent.ClonePoolOptions{Size: 1}}
+	manager := taskCloneManager(cfg)
+	defer closeCloneManager(manager)
+	if _, ok := manager.(*agent.ClonePool); !ok {
+		t.Fatalf("expected a clone pool, got %T", manager)
+	}
+
+	metadata := withCloneManagerStats(map[string]string{}, manager)
+	if metadata["clone_pool_size"] != "1" || metadata["clone_pool_hits"] != "0" || metadata["clone_pool_misses"] != "0" || metadata["clone_pool_hit_rate"] != "0.00" {
+		t.Fatalf("unexpected clone pool metadata %#v", metadata)
+	}
+	if metadata := withCloneManagerStats(map[string]string{}, agent.NewGitCloneManager(t.TempDir())); len(metadata) != 0 {
+		t.Fatalf("expected no clone pool metadata without a pool, got %#v", metadata)
+	}
 }
 
 func TestRunMainFlagAndEnvPrecedenceOverAgentConfigDefaults(t *testing.T) {
@@ -2181,6 +2941,9 @@ func TestRunMainUsesDefaultRetryBudget(t *testing.T) {
 	if got.retryBudget != 5 {
 		t.Fatalf("expected default retryBudget=5, got %d", got.retryBudget)
 	}
+	if got.rateLimitBackoff != 30*time.Second {
+		t.Fatalf("expected default rate limit backoff 30s, got %s", got.rateLimitBackoff)
+	}
 }
 
 func TestRunMainParsesVerboseStreamFlag(t *testing.T) {
@@ -2321,6 +3084,9 @@ func TestRunWithComponentsStreamEmitsRunStartedWithParameters(t *testing.T) {
 	if !strings.Contains(out, `"root_id":"yr-2y0b"`) {
 		t.Fatalf("expected root_id in run_started metadata, got %q", out)
 	}
+	if !strings.Contains(out, `"seq":1}`) || !strings.Contains(out, `"run_id":"`) {
+		t.Fatalf("expected sequenced events with a run_id, got %q", out)
+	}
 	if !strings.Contains(out, `"concurrency":"2"`) {
 		t.Fatalf("expected concurrency in run_started metadata, got %q", out)
 	}
@@ -3048,3 +3814,29 @@ func (m *countingNoReadyTaskManager) SetTaskStatus(context.Context, string, cont
 func (m *countingNoReadyTaskManager) SetTaskData(context.Context, string, map[string]string) error {
 	return nil
 }
+
+func TestRunMainParsesFollowUpIssuesFlag(t *testing.T) {
+	var got runConfig
+	code := RunMain([]string{"--repo", t.TempDir(), "--root", "root", "--follow-up-issues"}, func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if !got.followUpIssues {
+		t.Fatalf("expected follow-up issues to be enabled, got %#v", got)
+	}
+}
+
+func TestNewRunIDIsTimestampedAndUnique(t *testing.T) {
+	now := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	first := newRunID(now)
+	second := newRunID(now)
+	if !strings.HasPrefix(first, "20260210T120000Z-") || len(first) != len("20260210T120000Z-")+8 {
+		t.Fatalf("unexpected run id %q", first)
+	}
+	if first == second {
+		t.Fatalf("expected distinct run ids, got %q twice", first)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+	"strings"
+)
+
+const agentPluginsEnvVar = "YOLO_AGENT_PLUGINS"
+
+var openAgentPlugin = func(path string) error {
+	_, err := plugin.Open(path)
+	return err
+}
+
+// loadAgentPlugins opens each Go plugin listed in YOLO_AGENT_PLUGINS
+// (separated like PATH). Plugins register backends and trackers with
+// pkg/registry from their init functions, so opening them is enough.
+func loadAgentPlugins(getenv func(string) string) error {
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	for _, path := range filepath.SplitList(getenv(agentPluginsEnvVar)) {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if err := openAgentPlugin(path); err != nil {
+			return fmt.Errorf("load plugin %q from %s: %w", path, agentPluginsEnvVar, err)
+		}
+	}
+	return nil
+}
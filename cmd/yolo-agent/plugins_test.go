@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/codingagents"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/pkg/registry"
+)
+
+type registeredTestRunner struct {
+	config registry.BackendConfig
+}
+
+func (r *registeredTestRunner) Run(context.Context, contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	return contracts.RunnerResult{Status: contracts.RunnerResultCompleted}, nil
+}
+
+func TestLoadAgentPluginsOpensEachListedPlugin(t *testing.T) {
+	original := openAgentPlugin
+	t.Cleanup(func() { openAgentPlugin = original })
+	opened := []string{}
+	openAgentPlugin = func(path string) error {
+		opened = append(opened, path)
+		if path == "broken.so" {
+			return errors.New("plugin was built with a different version")
+		}
+		return nil
+	}
+	getenv := func(value string) func(string) string {
+		return func(key string) string {
+			if key == agentPluginsEnvVar {
+				return value
+			}
+			return ""
+		}
+	}
+
+	if err := loadAgentPlugins(getenv("jira.so" + string(os.PathListSeparator) + " " + string(os.PathListSeparator) + "agent.so")); err != nil {
+		t.Fatalf("load plugins: %v", err)
+	}
+	if !reflect.DeepEqual(opened, []string{"jira.so", "agent.so"}) {
+		t.Fatalf("unexpected opened plugins %v", opened)
+	}
+	err := loadAgentPlugins(getenv("broken.so"))
+	if err == nil || !strings.Contains(err.Error(), `load plugin "broken.so" from YOLO_AGENT_PLUGINS`) {
+		t.Fatalf("expected plugin load error, got %v", err)
+	}
+}
+
+func TestBuildRunnerAdapterUsesRegisteredBackend(t *testing.T) {
+	registry.RegisterBackend("plugins-test-agent", func(cfg registry.BackendConfig) (registry.AgentRunner, error) {
+		return &registeredTestRunner{config: cfg}, nil
+	})
+	repoRoot := t.TempDir()
+	customDir := filepath.Join(repoRoot, ".yolo-runner", "coding-agents")
+	if err := os.MkdirAll(customDir, 0o755); err != nil {
+		t.Fatalf("create custom backend directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(customDir, "wrapped.yaml"), []byte("name: wrapped\nadapter: plugins-test-agent\nbinary: in-house\nargs: [--fast]\nconfig:\n  region: eu\n"), 0o644); err != nil {
+		t.Fatalf("write custom backend definition: %v", err)
+	}
+	catalog, err := codingagents.LoadCatalog(repoRoot)
+	if err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+
+	runner, err := buildRunnerAdapter(runConfig{backend: "plugins-test-agent", codingAgents: catalog})
+	if err != nil {
+		t.Fatalf("build registered backend: %v", err)
+	}
+	if got, ok := runner.(*registeredTestRunner); !ok || got.config.Name != "plugins-test-agent" {
+		t.Fatalf("expected registered runner, got %#v", runner)
+	}
+
+	runner, err = buildRunnerAdapter(runConfig{backend: "wrapped", codingAgents: catalog})
+	if err != nil {
+		t.Fatalf("build wrapped backend: %v", err)
+	}
+	got, ok := runner.(*registeredTestRunner)
+	if !ok || got.config.Name != "wrapped" || got.config.Binary != "in-house" || !reflect.DeepEqual(got.config.Args, []string{"--fast"}) || got.config.Config["region"] != "eu" {
+		t.Fatalf("expected definition passed to registered factory, got %#v", runner)
+	}
+}
+
+func TestBuildStorageBackendForTrackerUsesRegisteredTracker(t *testing.T) {
+	var received registry.TrackerConfig
+	backend := &testStorageBackend{}
+	registry.RegisterTracker("plugins-test-tracker", func(cfg registry.TrackerConfig) (registry.StorageBackend, error) {
+		received = cfg
+		return backend, nil
+	})
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: plugins-test-tracker
+      settings:
+        project: OPS
+`)
+
+	profile, err := resolveTrackerProfile(repoRoot, "", "OPS-1", nil)
+	if err != nil {
+		t.Fatalf("resolve registered tracker profile: %v", err)
+	}
+	got, err := buildStorageBackendForTracker(repoRoot, profile)
+	if err != nil {
+		t.Fatalf("build registered tracker: %v", err)
+	}
+	if got != backend {
+		t.Fatalf("expected registered backend, got %#v", got)
+	}
+	if received.RepoRoot != repoRoot || received.Profile != "default" || received.Type != "plugins-test-tracker" || received.Settings["project"] != "OPS" {
+		t.Fatalf("unexpected tracker config %#v", received)
+	}
+
+	profile.Tracker.Type = "plugins-test-unknown"
+	if _, err := buildStorageBackendForTracker(repoRoot, profile); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Fatalf("expected unknown tracker error, got %v", err)
+	}
+}
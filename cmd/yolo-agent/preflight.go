@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/codingagents"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	preflightAuthTimeout = 2 * time.Minute
+	preflightAuthPrompt  = "This is a connectivity check before a yolo-agent run. Reply with the single word OK. Do not run tools or change files."
+)
+
+// buildPreflightRunner builds the runner that sends a backend's test request.
+var buildPreflightRunner = buildRunnerAdapter
+
+type preflightCheck struct {
+	name   string
+	detail string
+	err    error
+}
+
+type preflightBackend struct {
+	name  string
+	model string
+}
+
+func shouldRunPreflight(cfg runConfig) bool {
+	return !cfg.skipPreflight && !cfg.dryRun && cfg.role != agentRoleMaster
+}
+
+// runPreflight checks the tracker and every backend the run may use before
+// any task starts, writes one line per check to out and fails with all
+// failed checks at once. trackerErr is the error from building the
+// tracker, whose constructor already probes the token.
+func runPreflight(ctx context.Context, cfg runConfig, trackerErr error, out io.Writer) error {
+	if out == nil {
+		out = io.Discard
+	}
+	tracker := strings.TrimSpace(cfg.trackerType)
+	if tracker == "" {
+		tracker = "tk"
+	}
+	checks := []preflightCheck{{name: "tracker " + tracker, err: trackerErr}}
+
+	backends := preflightBackends(cfg)
+	results := make([]preflightCheck, len(backends))
+	var wg sync.WaitGroup
+	for i, backend := range backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = checkBackendPreflight(ctx, cfg, backend)
+		}()
+	}
+	wg.Wait()
+	checks = append(checks, results...)
+
+	failures := []string{}
+	for _, check := range checks {
+		if check.err != nil {
+			fmt.Fprintf(out, "preflight: %s: FAIL: %v\n", check.name, check.err)
+			failures = append(failures, check.name+": "+check.err.Error())
+			continue
+		}
+		if check.detail != "" {
+			fmt.Fprintf(out, "preflight: %s: ok (%s)\n", check.name, check.detail)
+			continue
+		}
+		fmt.Fprintf(out, "preflight: %s: ok\n", check.name)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("preflight failed for %d of %d checks:\n%s", len(failures), len(checks), strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// preflightBackends lists the selected backend followed by the fallback
+// chain's backends, each once. Replayed runs call no backend.
+func preflightBackends(cfg runConfig) []preflightBackend {
+	if cfg.replayFixtures != "" {
+		return nil
+	}
+	primary := normalizeBackend(cfg.backend)
+	backends := []preflightBackend{{name: primary, model: strings.TrimSpace(cfg.model)}}
+	seen := map[string]struct{}{primary: {}}
+	for _, target := range cfg.fallbackChain {
+		name := normalizeBackend(target.Backend)
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		model := strings.TrimSpace(target.Model)
+		if model == "" {
+			model = catalogBackendDefaultModel(cfg.codingAgents, name)
+		}
+		backends = append(backends, preflightBackend{name: name, model: model})
+	}
+	return backends
+}
+
+// checkBackendPreflight checks that the backend's binary exists, that its
+// version is at least min_version and that a tiny test request completes,
+// which proves the backend is signed in.
+func checkBackendPreflight(ctx context.Context, cfg runConfig, backend preflightBackend) preflightCheck {
+	check := preflightCheck{name: "backend " + backend.name}
+	definition, ok := cfg.codingAgents.Backend(backend.name)
+	if !ok {
+		check.err = fmt.Errorf("backend %q is not defined", backend.name)
+		return check
+	}
+	if err := cfg.codingAgents.ValidateBackendUsage(backend.name, backend.model, os.Getenv); err != nil {
+		check.err = err
+		return check
+	}
+	if err := codingagents.CheckBackendBinary(definition); err != nil {
+		check.err = err
+		return check
+	}
+	version, err := codingagents.CheckBackendVersion(ctx, definition, nil)
+	if err != nil {
+		check.err = err
+		return check
+	}
+	if version != "" {
+		check.detail = "version " + version
+	}
+	if err := sendPreflightRequest(ctx, cfg, backend); err != nil {
+		check.err = err
+	}
+	return check
+}
+
+// sendPreflightRequest runs the backend once on a tiny prompt in an empty
+// scratch repository, so the check cannot touch the real one.
+func sendPreflightRequest(ctx context.Context, cfg runConfig, backend preflightBackend) error {
+	backendCfg := cfg
+	backendCfg.backend = backend.name
+	runner, err := buildPreflightRunner(backendCfg)
+	if err != nil {
+		return err
+	}
+	scratch, err := os.MkdirTemp("", "yolo-agent-preflight-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratch)
+	// Some agent CLIs refuse to run outside a git repository.
+	_ = exec.CommandContext(ctx, "git", "-C", scratch, "init", "-q").Run()
+
+	result, err := runner.Run(ctx, contracts.RunnerRequest{
+		TaskID:   "preflight",
+		RepoRoot: scratch,
+		Prompt:   preflightAuthPrompt,
+		Mode:     contracts.RunnerModeImplement,
+		Model:    backend.model,
+		Timeout:  preflightAuthTimeout,
+		Metadata: map[string]string{"backend": backend.name},
+	})
+	if err != nil {
+		return fmt.Errorf("test request failed: %w", err)
+	}
+	if result.Status != contracts.RunnerResultCompleted {
+		reason := strings.TrimSpace(result.Reason)
+		if reason == "" {
+			reason = "no reason given"
+		}
+		return fmt.Errorf("test request %s: %s", result.Status, reason)
+	}
+	return nil
+}
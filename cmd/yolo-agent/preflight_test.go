@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/codingagents"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+type preflightStubRunner struct {
+	result contracts.RunnerResult
+	mu     sync.Mutex
+	calls  []contracts.RunnerRequest
+}
+
+func (r *preflightStubRunner) Run(_ context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, request)
+	return r.result, nil
+}
+
+func loadPreflightTestCatalog(t *testing.T, definitions map[string]string) codingagents.Catalog {
+	t.Helper()
+	repoRoot := t.TempDir()
+	customDir := filepath.Join(repoRoot, ".yolo-runner", "coding-agents")
+	if err := os.MkdirAll(customDir, 0o755); err != nil {
+		t.Fatalf("create custom backend directory: %v", err)
+	}
+	for name, definition := range definitions {
+		if err := os.WriteFile(filepath.Join(customDir, name+".yaml"), []byte(definition), 0o644); err != nil {
+			t.Fatalf("write custom backend definition: %v", err)
+		}
+	}
+	catalog, err := codingagents.LoadCatalog(repoRoot)
+	if err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+	return catalog
+}
+
+func stubPreflightRunner(t *testing.T, runner contracts.AgentRunner) {
+	t.Helper()
+	original := buildPreflightRunner
+	t.Cleanup(func() {
+		buildPreflightRunner = original
+	})
+	buildPreflightRunner = func(runConfig) (contracts.AgentRunner, error) {
+		return runner, nil
+	}
+}
+
+func TestRunPreflightReportsEveryFailureTogether(t *testing.T) {
+	catalog := loadPreflightTestCatalog(t, map[string]string{
+		"good":    "name: good\nadapter: command\nbinary: sh\nmodel: small\n",
+		"missing": "name: missing\nadapter: command\nbinary: yolo-preflight-missing-agent\n",
+	})
+	runner := &preflightStubRunner{result: contracts.RunnerResult{Status: contracts.RunnerResultCompleted}}
+	stubPreflightRunner(t, runner)
+
+	var out bytes.Buffer
+	err := runPreflight(context.Background(), runConfig{
+		backend:       "good",
+		model:         "small",
+		trackerType:   "github",
+		codingAgents:  catalog,
+		fallbackChain: []agent.ModelTarget{{Backend: "missing"}, {Backend: "good"}},
+	}, errors.New("github auth validation failed: token scope check failed"), &out)
+	if err == nil {
+		t.Fatalf("expected preflight to fail")
+	}
+	for _, want := range []string{
+		"preflight failed for 2 of 3 checks",
+		"tracker github: github auth validation failed",
+		"backend missing: binary \"yolo-preflight-missing-agent\" not found in PATH",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error to contain %q, got %q", want, err.Error())
+		}
+	}
+	if !strings.Contains(out.String(), "preflight: backend good: ok") {
+		t.Fatalf("expected report to list the passing backend, got %q", out.String())
+	}
+	if len(runner.calls) != 1 {
+		t.Fatalf("expected one test request for the installed backend, got %d", len(runner.calls))
+	}
+	request := runner.calls[0]
+	if request.Model != "small" || request.Mode != contracts.RunnerModeImplement || request.Metadata["backend"] != "good" {
+		t.Fatalf("unexpected test request: %#v", request)
+	}
+	if _, statErr := os.Stat(request.RepoRoot); !os.IsNotExist(statErr) {
+		t.Fatalf("expected scratch repository %q to be removed, got %v", request.RepoRoot, statErr)
+	}
+}
+
+func TestRunPreflightFailsWhenTestRequestDoesNotComplete(t *testing.T) {
+	catalog := loadPreflightTestCatalog(t, map[string]string{
+		"agent": "name: agent\nadapter: command\nbinary: sh\n",
+	})
+	stubPreflightRunner(t, &preflightStubRunner{result: contracts.RunnerResult{Status: contracts.RunnerResultFailed, Reason: "not signed in"}})
+
+	err := runPreflight(context.Background(), runConfig{backend: "agent", trackerType: "tk", codingAgents: catalog}, nil, nil)
+	if err == nil {
+		t.Fatalf("expected preflight to fail")
+	}
+	if !strings.Contains(err.Error(), "backend agent: test request failed: not signed in") {
+		t.Fatalf("expected test request failure, got %q", err.Error())
+	}
+}
+
+func TestRunPreflightChecksMinVersion(t *testing.T) {
+	catalog := loadPreflightTestCatalog(t, map[string]string{
+		"old":     "name: old\nadapter: command\nbinary: sh\nmin_version: 2.0.0\nversion_args: [\"-c\", \"echo old-agent 1.4.2\"]\n",
+		"current": "name: current\nadapter: command\nbinary: sh\nmin_version: 1.4\nversion_args: [\"-c\", \"echo current-agent 1.4.2\"]\n",
+	})
+	stubPreflightRunner(t, &preflightStubRunner{result: contracts.RunnerResult{Status: contracts.RunnerResultCompleted}})
+
+	var out bytes.Buffer
+	err := runPreflight(context.Background(), runConfig{
+		backend:       "current",
+		trackerType:   "tk",
+		codingAgents:  catalog,
+		fallbackChain: []agent.ModelTarget{{Backend: "old"}},
+	}, nil, &out)
+	if err == nil {
+		t.Fatalf("expected preflight to fail")
+	}
+	if !strings.Contains(err.Error(), "backend old: version 1.4.2 is older than the required min_version 2.0.0") {
+		t.Fatalf("expected version failure, got %q", err.Error())
+	}
+	if !strings.Contains(out.String(), "preflight: backend current: ok (version 1.4.2)") {
+		t.Fatalf("expected report to show the detected version, got %q", out.String())
+	}
+}
+
+func TestShouldRunPreflightSkipsDryRunsMastermindAndOptOut(t *testing.T) {
+	if !shouldRunPreflight(runConfig{role: agentRoleLocal}) {
+		t.Fatalf("expected preflight for a local run")
+	}
+	for _, cfg := range []runConfig{
+		{role: agentRoleLocal, dryRun: true},
+		{role: agentRoleLocal, skipPreflight: true},
+		{role: agentRoleMaster},
+	} {
+		if shouldRunPreflight(cfg) {
+			t.Fatalf("expected preflight to be skipped for %#v", cfg)
+		}
+	}
+}
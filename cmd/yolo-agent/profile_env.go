@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/agent"
+)
+
+const profileEnvFieldLabel = "profile.env"
+
+// profileEnvVarModel is one entry of profiles.<name>.env. Exactly one of
+// value, value_env and value_file sets the value. value_env names an env var
+// or a secret reference. Values read from value_env or value_file are always
+// treated as secrets.
+type profileEnvVarModel struct {
+	Value     string   `yaml:"value,omitempty"`
+	ValueEnv  string   `yaml:"value_env,omitempty"`
+	ValueFile string   `yaml:"value_file,omitempty"`
+	Secret    bool     `yaml:"secret,omitempty"`
+	Labels    []string `yaml:"labels,omitempty"`
+}
+
+// resolveProfileEnv reads the task environment of a profile, sorted by
+// name. A relative value_file is read from repoRoot, and a trailing newline
+// in the file is dropped.
+func resolveProfileEnv(profileName string, env map[string]profileEnvVarModel, repoRoot string, getenv func(string) string, readFile func(string) ([]byte, error)) ([]agent.TaskEnvVar, error) {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	vars := make([]agent.TaskEnvVar, 0, len(names))
+	for _, name := range names {
+		model := env[name]
+		field := fmt.Sprintf("%s entry %q in profile %q", profileEnvFieldLabel, name, profileName)
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, "= \t") {
+			return nil, fmt.Errorf("%s is not a valid environment variable name", field)
+		}
+		variable := agent.TaskEnvVar{Name: name, Value: model.Value, Secret: model.Secret, Labels: model.Labels}
+		sources := 0
+		for _, source := range []string{model.Value, model.ValueEnv, model.ValueFile} {
+			if strings.TrimSpace(source) != "" {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return nil, fmt.Errorf("%s must set exactly one of value, value_env or value_file", field)
+		}
+		switch {
+		case strings.TrimSpace(model.ValueEnv) != "":
+			envName := strings.TrimSpace(model.ValueEnv)
+			value, err := lookupConfigSecret(getenv, envName)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", field, err)
+			}
+			variable.Value = value
+			if variable.Value == "" {
+				return nil, fmt.Errorf("%s reads %s, which is not set", field, envName)
+			}
+			variable.Secret = true
+		case strings.TrimSpace(model.ValueFile) != "":
+			path := strings.TrimSpace(model.ValueFile)
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(repoRoot, path)
+			}
+			content, err := readFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("%s cannot read value_file: %w", field, err)
+			}
+			variable.Value = strings.TrimRight(string(content), "\r\n")
+			variable.Secret = true
+		}
+		vars = append(vars, variable)
+	}
+	return vars, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/agent"
+)
+
+const profilePipelineFieldLabel = "profile.pipeline"
+
+// profilePipelineModel is profiles.<name>.pipeline. Without stages tasks go
+// through the built-in implement, review, land flow.
+type profilePipelineModel struct {
+	Stages []profilePipelineStageModel `yaml:"stages,omitempty"`
+}
+
+type profilePipelineStageModel struct {
+	Name string `yaml:"name"`
+	// Type is agent, command, gate, approval or land.
+	Type    string `yaml:"type"`
+	Prompt  string `yaml:"prompt,omitempty"`
+	Command string `yaml:"command,omitempty"`
+	Timeout string `yaml:"timeout,omitempty"`
+}
+
+// resolveProfilePipeline validates the pipeline stages of a profile.
+func resolveProfilePipeline(profileName string, model profilePipelineModel) (agent.Pipeline, error) {
+	stages := make([]agent.PipelineStage, 0, len(model.Stages))
+	for i, stageModel := range model.Stages {
+		field := fmt.Sprintf("%s.stages[%d] in profile %q", profilePipelineFieldLabel, i, profileName)
+		stageType, err := agent.ParsePipelineStageType(stageModel.Type)
+		if err != nil {
+			return agent.Pipeline{}, fmt.Errorf("%s: %w", field, err)
+		}
+		stage := agent.PipelineStage{
+			Name:    stageModel.Name,
+			Type:    stageType,
+			Prompt:  strings.TrimSpace(stageModel.Prompt),
+			Command: strings.TrimSpace(stageModel.Command),
+		}
+		if raw := strings.TrimSpace(stageModel.Timeout); raw != "" {
+			timeout, err := time.ParseDuration(raw)
+			if err != nil || timeout <= 0 {
+				return agent.Pipeline{}, fmt.Errorf("%s timeout must be a positive duration", field)
+			}
+			stage.Timeout = timeout
+		}
+		stages = append(stages, stage)
+	}
+	pipeline, err := agent.NewPipeline(stages)
+	if err != nil {
+		return agent.Pipeline{}, fmt.Errorf("%s in profile %q is invalid: %w", profilePipelineFieldLabel, profileName, err)
+	}
+	return pipeline, nil
+}
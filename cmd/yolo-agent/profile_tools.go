@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const profileToolsFieldLabel = "profile.tools"
+
+// toolCommandNamePattern matches the command name that starts a tool
+// pattern. Paths are not accepted, since commands are matched by name.
+var toolCommandNamePattern = regexp.MustCompile(`^[A-Za-z0-9._+-]+$`)
+
+// profileToolsModel is profiles.<name>.tools. Each pattern is a command name
+// optionally followed by its leading arguments, e.g. "docker" or "go test".
+type profileToolsModel struct {
+	Allow []string `yaml:"allow,omitempty"`
+	Deny  []string `yaml:"deny,omitempty"`
+}
+
+// resolveProfileTools validates the tool patterns of a profile and returns
+// them as a tool policy with their whitespace normalized.
+func resolveProfileTools(profileName string, model profileToolsModel) (contracts.ToolPolicy, error) {
+	allow, err := normalizeToolPatterns(profileName, "allow", model.Allow)
+	if err != nil {
+		return contracts.ToolPolicy{}, err
+	}
+	deny, err := normalizeToolPatterns(profileName, "deny", model.Deny)
+	if err != nil {
+		return contracts.ToolPolicy{}, err
+	}
+	return contracts.ToolPolicy{Allow: allow, Deny: deny}, nil
+}
+
+func normalizeToolPatterns(profileName string, key string, patterns []string) ([]string, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(patterns))
+	for i, pattern := range patterns {
+		field := fmt.Sprintf("%s.%s[%d] in profile %q", profileToolsFieldLabel, key, i, profileName)
+		words := strings.Fields(pattern)
+		if len(words) == 0 {
+			return nil, fmt.Errorf("%s must not be empty", field)
+		}
+		if !toolCommandNamePattern.MatchString(words[0]) {
+			return nil, fmt.Errorf("%s must start with a command name, got %q", field, words[0])
+		}
+		if strings.ContainsAny(pattern, "|&;<>()$`\"'\\") {
+			return nil, fmt.Errorf("%s must be a command and its leading arguments, without shell syntax", field)
+		}
+		normalized = append(normalized, strings.Join(words, " "))
+	}
+	return normalized, nil
+}
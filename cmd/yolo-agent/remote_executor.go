@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/codingagents"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/kimi"
+	"github.com/egv/yolo-runner/v2/internal/kubernetes"
+	"github.com/egv/yolo-runner/v2/internal/qwen"
+	"github.com/egv/yolo-runner/v2/internal/ssh"
+)
+
+// remoteExecutorConfig is the agent.executor that runs runner commands
+// away from this machine. The zero value runs them as local processes.
+type remoteExecutorConfig struct {
+	// Kubernetes runs each command as a Job. A relative workspace local
+	// path is relative to the repo root.
+	Kubernetes *kubernetes.Config
+	// SSH runs each command on a remote host.
+	SSH *ssh.Config
+}
+
+func (c remoteExecutorConfig) enabled() bool {
+	return c.Kubernetes != nil || c.SSH != nil
+}
+
+// remoteCommand is one runner command. It has the fields of
+// kubernetes.Command and ssh.Command, so it converts to both.
+type remoteCommand struct {
+	Binary  string
+	Args    []string
+	Env     []string
+	Secrets []string
+	Dir     string
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+type remoteCommandFunc func(ctx context.Context, command remoteCommand) error
+
+// newRemoteCommandFunc builds the executor selected by cfg.executor.
+func newRemoteCommandFunc(cfg runConfig) (remoteCommandFunc, error) {
+	if cfg.executor.SSH != nil {
+		executor, err := ssh.NewExecutor(*cfg.executor.SSH)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, command remoteCommand) error {
+			return executor.Run(ctx, ssh.Command(command))
+		}, nil
+	}
+	config := *cfg.executor.Kubernetes
+	repoRoot, err := filepath.Abs(cfg.repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	if config.Workspace.LocalPath == "" {
+		config.Workspace.LocalPath = repoRoot
+	} else if !filepath.IsAbs(config.Workspace.LocalPath) {
+		config.Workspace.LocalPath = filepath.Join(repoRoot, config.Workspace.LocalPath)
+	}
+	executor, err := kubernetes.NewExecutor(config)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, command remoteCommand) error {
+		return executor.Run(ctx, kubernetes.Command(command))
+	}, nil
+}
+
+// buildRemoteRunnerAdapter builds the backend's adapter with a command
+// runner that runs each runner command on the remote executor. Only
+// adapters that run one command per task without stdin can run there; ACP,
+// session and server backends talk to a long-lived local process.
+func buildRemoteRunnerAdapter(cfg runConfig, definition codingagents.BackendDefinition) (contracts.AgentRunner, error) {
+	switch definition.Adapter {
+	case "command", "amazonq", "kimi", "qwen":
+	default:
+		return nil, fmt.Errorf("runner backend %q (adapter %s) cannot run on the %s executor; use a command, amazonq, kimi or qwen backend", definition.Name, definition.Adapter, cfg.executor.name())
+	}
+	run, err := newRemoteCommandFunc(cfg)
+	if err != nil {
+		return nil, err
+	}
+	switch definition.Adapter {
+	case "amazonq":
+		return codingagents.NewAmazonQRunnerAdapter(definition.Name, definition.Binary, definition.Args, codingagents.AmazonQAuthFromConfig(definition.Config), codingAgentsRemoteRunner{run: run}), nil
+	case "kimi":
+		return kimi.NewCLIRunnerAdapter(definition.Binary, kimiRemoteRunner{run: run}, definition.Args...), nil
+	case "qwen":
+		return qwen.NewCLIRunnerAdapter(definition.Binary, qwenRemoteRunner{run: run}, definition.Args...), nil
+	default:
+		return codingagents.NewGenericCLIRunnerAdapter(definition.Name, definition.Binary, definition.Args, codingAgentsRemoteRunner{run: run}).WithHealthConfig(definition.Health), nil
+	}
+}
+
+// executorRouterRunner sends each request to the runner of the
+// agent.executors entry its task is pinned to. Requests of other tasks go to
+// the primary runner.
+type executorRouterRunner struct {
+	primary   contracts.AgentRunner
+	executors map[string]contracts.AgentRunner
+}
+
+func (r executorRouterRunner) Run(ctx context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	if runner, ok := r.executors[strings.TrimSpace(request.Metadata[agent.ExecutorMetadataKey])]; ok {
+		return runner.Run(ctx, request)
+	}
+	return r.primary.Run(ctx, request)
+}
+
+// withAffinityExecutors builds a runner, with its fallback backends, for each
+// agent.executors entry. The primary runner is returned unchanged when there
+// are none.
+func withAffinityExecutors(cfg runConfig, primary contracts.AgentRunner) (contracts.AgentRunner, error) {
+	if len(cfg.executors) == 0 {
+		return primary, nil
+	}
+	runners := make(map[string]contracts.AgentRunner, len(cfg.executors))
+	for name, executor := range cfg.executors {
+		executorCfg := cfg
+		executorCfg.executor = executor
+		runner, err := buildRunnerAdapter(executorCfg)
+		if err == nil {
+			runner, err = withFallbackBackends(executorCfg, runner)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("build runner for executor %q: %w", name, err)
+		}
+		runners[name] = runner
+	}
+	return executorRouterRunner{primary: primary, executors: runners}, nil
+}
+
+func (c remoteExecutorConfig) name() string {
+	if c.SSH != nil {
+		return "ssh"
+	}
+	return "kubernetes"
+}
+
+type codingAgentsRemoteRunner struct {
+	run remoteCommandFunc
+}
+
+func (r codingAgentsRemoteRunner) Run(ctx context.Context, spec codingagents.CommandSpec) error {
+	if spec.Stdin != nil {
+		return errors.New("a remote executor cannot pass stdin to a runner")
+	}
+	return r.run(ctx, remoteCommand{Binary: spec.Binary, Args: spec.Args, Env: spec.Env, Secrets: spec.Secrets, Dir: spec.Dir, Stdout: spec.Stdout, Stderr: spec.Stderr})
+}
+
+type kimiRemoteRunner struct {
+	run remoteCommandFunc
+}
+
+func (r kimiRemoteRunner) Run(ctx context.Context, spec kimi.CommandSpec) error {
+	return r.run(ctx, remoteCommand(spec))
+}
+
+type qwenRemoteRunner struct {
+	run remoteCommandFunc
+}
+
+func (r qwenRemoteRunner) Run(ctx context.Context, spec qwen.CommandSpec) error {
+	return r.run(ctx, remoteCommand(spec))
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/codingagents"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/kubernetes"
+	"github.com/egv/yolo-runner/v2/internal/qwen"
+	"github.com/egv/yolo-runner/v2/internal/ssh"
+)
+
+func kubernetesTestConfig() *kubernetes.Config {
+	return &kubernetes.Config{Image: "ghcr.io/acme/worker:1", Workspace: kubernetes.Workspace{Claim: "yolo-clones"}}
+}
+
+func TestBuildRunnerAdapterRunsCommandBackendsOnKubernetes(t *testing.T) {
+	catalog, err := codingagents.LoadCatalog("")
+	if err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+	runner, err := buildRunnerAdapter(runConfig{backend: "codex-cli", codingAgents: catalog, repoRoot: t.TempDir(), executor: remoteExecutorConfig{Kubernetes: kubernetesTestConfig()}})
+	if err != nil {
+		t.Fatalf("build codex-cli adapter: %v", err)
+	}
+	if _, ok := runner.(*codingagents.GenericCLIRunnerAdapter); !ok {
+		t.Fatalf("expected *codingagents.GenericCLIRunnerAdapter, got %T", runner)
+	}
+	runner, err = buildRunnerAdapter(runConfig{backend: "qwen", codingAgents: catalog, repoRoot: t.TempDir(), executor: remoteExecutorConfig{Kubernetes: kubernetesTestConfig()}})
+	if err != nil {
+		t.Fatalf("build qwen adapter: %v", err)
+	}
+	if _, ok := runner.(*qwen.CLIRunnerAdapter); !ok {
+		t.Fatalf("expected *qwen.CLIRunnerAdapter, got %T", runner)
+	}
+}
+
+func TestBuildRunnerAdapterRejectsSessionBackendsOnRemoteExecutors(t *testing.T) {
+	catalog, err := codingagents.LoadCatalog("")
+	if err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+	for _, backend := range []string{"claude", "codex", "opencode-acp"} {
+		_, err := buildRunnerAdapter(runConfig{backend: backend, codingAgents: catalog, repoRoot: t.TempDir(), executor: remoteExecutorConfig{Kubernetes: kubernetesTestConfig()}})
+		if err == nil || !strings.Contains(err.Error(), "cannot run on the kubernetes executor") {
+			t.Fatalf("expected %s to be rejected, got %v", backend, err)
+		}
+		_, err = buildRunnerAdapter(runConfig{backend: backend, codingAgents: catalog, repoRoot: t.TempDir(), executor: remoteExecutorConfig{SSH: &ssh.Config{Host: "build-1", RemoteDir: "/srv/yolo"}}})
+		if err == nil || !strings.Contains(err.Error(), "cannot run on the ssh executor") {
+			t.Fatalf("expected %s to be rejected, got %v", backend, err)
+		}
+	}
+}
+
+func TestBuildRunnerAdapterRunsCommandBackendsOverSSH(t *testing.T) {
+	catalog, err := codingagents.LoadCatalog("")
+	if err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+	runner, err := buildRunnerAdapter(runConfig{backend: "codex-cli", codingAgents: catalog, executor: remoteExecutorConfig{SSH: &ssh.Config{Host: "build-1", RemoteDir: "/srv/yolo"}}})
+	if err != nil {
+		t.Fatalf("build codex-cli adapter: %v", err)
+	}
+	if _, ok := runner.(*codingagents.GenericCLIRunnerAdapter); !ok {
+		t.Fatalf("expected *codingagents.GenericCLIRunnerAdapter, got %T", runner)
+	}
+}
+
+func TestCodingAgentsRemoteRunnerRejectsStdin(t *testing.T) {
+	called := false
+	runner := codingAgentsRemoteRunner{run: func(context.Context, remoteCommand) error {
+		called = true
+		return nil
+	}}
+	err := runner.Run(context.Background(), codingagents.CommandSpec{Binary: "agent", Dir: "/srv", Stdin: strings.NewReader("{}")})
+	if called {
+		t.Fatalf("expected the command not to run")
+	}
+	if err == nil || !strings.Contains(err.Error(), "stdin") {
+		t.Fatalf("expected a stdin error, got %v", err)
+	}
+}
+
+func TestExecutorRouterRunnerRoutesByPinnedExecutor(t *testing.T) {
+	router := executorRouterRunner{
+		primary:   namedRunner{name: "local"},
+		executors: map[string]contracts.AgentRunner{"gpu": namedRunner{name: "gpu"}},
+	}
+	for executor, want := range map[string]string{"gpu": "gpu", "": "local", "mac": "local"} {
+		result, err := router.Run(context.Background(), contracts.RunnerRequest{Metadata: map[string]string{agent.ExecutorMetadataKey: executor}})
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if result.Reason != want {
+			t.Fatalf("executor %q: expected %q runner, got %q", executor, want, result.Reason)
+		}
+	}
+}
+
+func TestWithAffinityExecutorsBuildsRunnerPerExecutor(t *testing.T) {
+	primary := namedRunner{name: "local"}
+	runner, err := withAffinityExecutors(runConfig{backend: "codex-cli", codingAgents: testCatalog(t)}, primary)
+	if err != nil || runner != contracts.AgentRunner(primary) {
+		t.Fatalf("expected the primary runner without executors, got %T err=%v", runner, err)
+	}
+	runner, err = withAffinityExecutors(runConfig{backend: "codex-cli", codingAgents: testCatalog(t), executors: map[string]remoteExecutorConfig{
+		"gpu": {SSH: &ssh.Config{Host: "gpu-1", RemoteDir: "/srv/yolo"}},
+	}}, primary)
+	if err != nil {
+		t.Fatalf("with affinity executors: %v", err)
+	}
+	router, ok := runner.(executorRouterRunner)
+	if !ok || router.executors["gpu"] == nil {
+		t.Fatalf("expected router with a gpu runner, got %#v", runner)
+	}
+	_, err = withAffinityExecutors(runConfig{backend: "claude", codingAgents: testCatalog(t), executors: map[string]remoteExecutorConfig{
+		"gpu": {SSH: &ssh.Config{Host: "gpu-1", RemoteDir: "/srv/yolo"}},
+	}}, primary)
+	if err == nil || !strings.Contains(err.Error(), `executor "gpu"`) {
+		t.Fatalf("expected the gpu executor to reject claude, got %v", err)
+	}
+}
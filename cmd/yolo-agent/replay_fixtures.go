@@ -0,0 +1,19 @@
+package main
+
+import (
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/replay"
+)
+
+// withReplayFixtures records the runner's runs into --record-fixtures, or
+// replaces the runner with the fixtures in --replay-fixtures.
+func withReplayFixtures(cfg runConfig, runner contracts.AgentRunner) contracts.AgentRunner {
+	switch {
+	case cfg.replayFixtures != "":
+		return replay.NewRunner(cfg.replayFixtures).WithRealtime(cfg.replayRealtime)
+	case cfg.recordFixtures != "":
+		return replay.NewRecorder(runner, cfg.recordFixtures)
+	default:
+		return runner
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// exitCodeInterrupted is returned when a signal stopped the run. It is
+// EX_TEMPFAIL: the tracker was left consistent and running yolo-agent again
+// resumes where this run stopped.
+const exitCodeInterrupted = 75
+
+type interrupter interface {
+	Interrupt()
+}
+
+// shutdownSignals turns SIGINT/SIGTERM into a graceful loop shutdown. The
+// first signal closes stop so the loop dispatches nothing new and waits out
+// its grace period; a second one interrupts the in-flight tasks at once.
+type shutdownSignals struct {
+	stop chan struct{}
+
+	mu       sync.Mutex
+	received int
+}
+
+func newShutdownSignals() *shutdownSignals {
+	return &shutdownSignals{stop: make(chan struct{})}
+}
+
+// watch forwards SIGINT and SIGTERM to handle until the returned function is
+// called.
+func (s *shutdownSignals) watch(loop interrupter, out io.Writer) func() {
+	signals := make(chan os.Signal, 2)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				s.handle(sig, loop, out)
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
+
+func (s *shutdownSignals) handle(sig os.Signal, loop interrupter, out io.Writer) {
+	s.mu.Lock()
+	s.received++
+	received := s.received
+	s.mu.Unlock()
+	if received == 1 {
+		close(s.stop)
+		fmt.Fprintf(out, "Received %s; finishing in-flight tasks before exiting. Send it again to interrupt them now.\n", sig)
+		return
+	}
+	fmt.Fprintf(out, "Received %s again; interrupting in-flight tasks.\n", sig)
+	loop.Interrupt()
+}
+
+// requested reports whether a signal asked the run to stop.
+func (s *shutdownSignals) requested() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.received > 0
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+type countingInterrupter struct {
+	calls int
+}
+
+func (i *countingInterrupter) Interrupt() {
+	i.calls++
+}
+
+func TestShutdownSignalsStopFirstAndInterruptOnSecondSignal(t *testing.T) {
+	shutdown := newShutdownSignals()
+	loop := &countingInterrupter{}
+	out := &bytes.Buffer{}
+
+	if shutdown.requested() {
+		t.Fatalf("expected no shutdown before a signal")
+	}
+	shutdown.handle(syscall.SIGTERM, loop, out)
+	select {
+	case <-shutdown.stop:
+	default:
+		t.Fatalf("expected the first signal to close stop")
+	}
+	if loop.calls != 0 || !shutdown.requested() {
+		t.Fatalf("expected the first signal to only stop dispatching, got %d interrupts", loop.calls)
+	}
+
+	shutdown.handle(syscall.SIGTERM, loop, out)
+	if loop.calls != 1 {
+		t.Fatalf("expected the second signal to interrupt in-flight tasks, got %d interrupts", loop.calls)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("Send it again to interrupt them now")) {
+		t.Fatalf("expected shutdown progress on output, got %q", out.String())
+	}
+}
+
+func TestShutdownSignalsWatchHandlesDeliveredSignal(t *testing.T) {
+	shutdown := newShutdownSignals()
+	stopWatching := shutdown.watch(&countingInterrupter{}, &bytes.Buffer{})
+	defer stopWatching()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("send SIGTERM: %v", err)
+	}
+	select {
+	case <-shutdown.stop:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected SIGTERM to stop the loop")
+	}
+}
+
+func TestRunMainReturnsResumableExitCodeWhenInterrupted(t *testing.T) {
+	run := func(context.Context, runConfig) error {
+		return fmt.Errorf("run: %w", agent.ErrRunInterrupted)
+	}
+
+	code := RunMain([]string{"--repo", t.TempDir(), "--root", "root-1"}, run)
+	if code != exitCodeInterrupted {
+		t.Fatalf("expected exit code %d, got %d", exitCodeInterrupted, code)
+	}
+}
+
+func TestRunMainDefaultsShutdownGracePeriod(t *testing.T) {
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	if code := RunMain([]string{"--repo", t.TempDir(), "--root", "root-1"}, run); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if got.shutdownGrace != 2*time.Minute {
+		t.Fatalf("expected default shutdown grace period 2m, got %s", got.shutdownGrace)
+	}
+	if code := RunMain([]string{"--repo", t.TempDir(), "--root", "root-1", "--shutdown-grace-period", "-1s"}, run); code != 1 {
+		t.Fatalf("expected negative shutdown grace period to be rejected, got exit code %d", code)
+	}
+}
+
+func TestBuildRunFinishedMetadataReportsInterruptedRun(t *testing.T) {
+	metadata := buildRunFinishedMetadata(runConfig{rootID: "root-1"}, contracts.LoopSummary{Completed: 1}, agent.ErrRunInterrupted)
+	if metadata["status"] != "interrupted" {
+		t.Fatalf("expected status interrupted, got %#v", metadata)
+	}
+}
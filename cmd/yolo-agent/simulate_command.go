@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/engine"
+	"github.com/egv/yolo-runner/v2/internal/simulation"
+)
+
+func runSimulateCommand(args []string) int {
+	defaults := simulation.DefaultConfig()
+	fs := flag.NewFlagSet("yolo-agent simulate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	tasks := fs.Int("tasks", defaults.Tasks, "Number of runnable simulated tasks")
+	depth := fs.Int("depth", defaults.Depth, "Levels below the root; levels above the tasks are epics")
+	dependencyRate := fs.Float64("dependency-rate", defaults.DependencyRate, "Chance that a task depends on an earlier task")
+	failureRate := fs.Float64("failure-rate", defaults.FailureRate, "Chance that an implement run fails")
+	blockRate := fs.Float64("block-rate", defaults.BlockRate, "Chance that an implement run blocks")
+	reviewFailRate := fs.Float64("review-fail-rate", defaults.ReviewFailRate, "Chance that a review fails")
+	minDuration := fs.Duration("min-duration", defaults.MinDuration, "Shortest simulated runner run")
+	maxDuration := fs.Duration("max-duration", defaults.MaxDuration, "Longest simulated runner run")
+	outputLines := fs.Int("output-lines", defaults.OutputLines, "runner_output updates per simulated run")
+	seed := fs.Int64("seed", defaults.Seed, "Seed for the task graph and the run outcomes")
+	concurrency := fs.Int("concurrency", 32, "Maximum number of active task workers")
+	retryBudget := fs.Int("retry-budget", 5, "Maximum retry attempts per task for remediation loop")
+	skipReview := fs.Bool("skip-review", false, "Close tasks without a simulated review")
+	stream := fs.Bool("stream", false, "Emit NDJSON events to stdout for piping into yolo-tui")
+	verboseStream := fs.Bool("verbose-stream", false, "Emit every runner_output event without coalescing")
+	mode := fs.String("mode", "", "Output mode for runner events (stream, ui)")
+	events := fs.String("events", "", "Path to JSONL events log")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments for simulate: %s\n", strings.Join(fs.Args(), " "))
+		return 1
+	}
+	if *concurrency <= 0 {
+		fmt.Fprintln(os.Stderr, "--concurrency must be greater than 0")
+		return 1
+	}
+	if *retryBudget < 0 {
+		fmt.Fprintln(os.Stderr, "--retry-budget must be greater than or equal to 0")
+		return 1
+	}
+	selectedMode := strings.TrimSpace(*mode)
+	switch selectedMode {
+	case "", agentModeStream, agentModeUI:
+	default:
+		fmt.Fprintf(os.Stderr, "unsupported --mode %q (supported: %s, %s)\n", selectedMode, agentModeStream, agentModeUI)
+		return 1
+	}
+	simCfg := simulation.Config{
+		Tasks:          *tasks,
+		Depth:          *depth,
+		DependencyRate: *dependencyRate,
+		FailureRate:    *failureRate,
+		BlockRate:      *blockRate,
+		ReviewFailRate: *reviewFailRate,
+		MinDuration:    *minDuration,
+		MaxDuration:    *maxDuration,
+		OutputLines:    *outputLines,
+		Seed:           *seed,
+	}
+	storage, err := simulation.NewStorage(simCfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	// Scheduler state and duration history go to a scratch directory, so a
+	// simulation never changes what a real run estimates or resumes.
+	scratch, err := os.MkdirTemp("", "yolo-agent-simulate-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(scratch)
+
+	cfg := runConfig{
+		repoRoot:             scratch,
+		rootID:               simulation.RootID,
+		backend:              "simulation",
+		concurrency:          *concurrency,
+		retryBudget:          *retryBudget,
+		skipReview:           *skipReview,
+		stream:               *stream || selectedMode != "",
+		mode:                 selectedMode,
+		verboseStream:        *verboseStream,
+		streamOutputInterval: 150 * time.Millisecond,
+		streamOutputBuffer:   64,
+		eventsPath:           *events,
+		noVCS:                true,
+	}
+	started := time.Now()
+	err = runWithStorageComponents(context.Background(), cfg, storage, engine.NewTaskEngine(), simulation.NewRunner(simCfg), nil)
+	fmt.Fprintf(os.Stderr, "simulated %d tasks on %d workers in %s\n", simCfg.Tasks, cfg.concurrency, time.Since(started).Round(time.Millisecond))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSimulateCommandRunsGeneratedGraphAndWritesEvents(t *testing.T) {
+	eventsPath := filepath.Join(t.TempDir(), "events.jsonl")
+	code := runSimulateCommand([]string{"--tasks", "40", "--depth", "2", "--concurrency", "8", "--min-duration", "0s", "--max-duration", "1ms", "--failure-rate", "0", "--block-rate", "0", "--events", eventsPath})
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	data, err := os.ReadFile(eventsPath)
+	if err != nil {
+		t.Fatalf("read events: %v", err)
+	}
+	if got := strings.Count(string(data), `"type":"task_finished"`); got != 40 {
+		t.Fatalf("expected a task_finished event per simulated task, got %d", got)
+	}
+	if !strings.Contains(string(data), `"completed":"40"`) {
+		t.Fatalf("expected run_finished to report 40 completed tasks, got:\n%s", data[len(data)-400:])
+	}
+}
+
+func TestRunSimulateCommandRejectsInvalidShape(t *testing.T) {
+	for _, args := range [][]string{
+		{"--tasks", "0"},
+		{"--failure-rate", "2"},
+		{"--concurrency", "0"},
+		{"--mode", "tui"},
+	} {
+		if code := runSimulateCommand(args); code != 1 {
+			t.Fatalf("expected %v to be rejected, got exit code %d", args, code)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/trackersync"
+)
+
+const syncMappingRelDir = ".yolo-runner/sync"
+
+type syncCommandConfig struct {
+	repoRoot     string
+	fromProfile  string
+	toProfile    string
+	rootID       string
+	targetRootID string
+	dryRun       bool
+}
+
+func runSyncCommand(args []string) int {
+	fs := flag.NewFlagSet("yolo-agent sync", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	repoRoot := fs.String("repo", ".", "Repository root")
+	from := fs.String("from", "", "Source tracker profile from .yolo-runner/config.yaml")
+	to := fs.String("to", "", "Target tracker profile from .yolo-runner/config.yaml")
+	root := fs.String("root", "", "Root task ID in the source tracker")
+	targetRoot := fs.String("target-root", "", "Existing target task to mirror the source root onto (default: create one)")
+	dryRun := fs.Bool("dry-run", false, "Print the planned changes without writing to either tracker")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "unexpected arguments for sync: %s\n", strings.Join(fs.Args(), " "))
+		return 1
+	}
+	cfg := syncCommandConfig{
+		repoRoot:     *repoRoot,
+		fromProfile:  strings.TrimSpace(*from),
+		toProfile:    strings.TrimSpace(*to),
+		rootID:       strings.TrimSpace(*root),
+		targetRootID: strings.TrimSpace(*targetRoot),
+		dryRun:       *dryRun,
+	}
+	if err := runTrackerSync(context.Background(), cfg, os.Getenv, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// runTrackerSync mirrors the source tree into the target tracker. The ID map
+// lives under .yolo-runner/sync/ so repeated runs update the same target tasks.
+func runTrackerSync(ctx context.Context, cfg syncCommandConfig, getenv func(string) string, out io.Writer) error {
+	if cfg.fromProfile == "" || cfg.toProfile == "" {
+		return errors.New("--from and --to profiles are required")
+	}
+	if cfg.fromProfile == cfg.toProfile {
+		return errors.New("--from and --to must name different profiles")
+	}
+	if cfg.rootID == "" {
+		return errors.New("--root is required")
+	}
+
+	sourceProfile, err := resolveTrackerProfile(cfg.repoRoot, cfg.fromProfile, cfg.rootID, getenv)
+	if err != nil {
+		return err
+	}
+	targetProfile, err := resolveTrackerProfile(cfg.repoRoot, cfg.toProfile, cfg.targetRootID, getenv)
+	if err != nil {
+		return err
+	}
+	source, err := buildStorageBackendForTracker(cfg.repoRoot, sourceProfile)
+	if err != nil {
+		return fmt.Errorf("build source tracker %q: %w", sourceProfile.Name, err)
+	}
+	target, err := buildStorageBackendForTracker(cfg.repoRoot, targetProfile)
+	if err != nil {
+		return fmt.Errorf("build target tracker %q: %w", targetProfile.Name, err)
+	}
+
+	mappingPath := syncMappingPath(cfg.repoRoot, sourceProfile.Name, targetProfile.Name)
+	mapping, err := trackersync.LoadMapping(mappingPath)
+	if err != nil {
+		return err
+	}
+	actions, err := trackersync.Mirror(ctx, source, target, cfg.rootID, mapping, trackersync.Options{
+		TargetRootID: cfg.targetRootID,
+		DryRun:       cfg.dryRun,
+		Save: func(m *trackersync.Mapping) error {
+			return m.Save(mappingPath)
+		},
+	})
+	for _, action := range actions {
+		fmt.Fprintln(out, formatSyncAction(action, sourceProfile.Name, targetProfile.Name))
+	}
+	if err != nil {
+		return err
+	}
+	if len(actions) == 0 {
+		fmt.Fprintf(out, "%s and %s are in sync\n", sourceProfile.Name, targetProfile.Name)
+	}
+	return nil
+}
+
+func syncMappingPath(repoRoot string, fromProfile string, toProfile string) string {
+	return filepath.Join(repoRoot, syncMappingRelDir, fromProfile+"-to-"+toProfile+".json")
+}
+
+func formatSyncAction(action trackersync.Action, fromProfile string, toProfile string) string {
+	targetID := action.TargetID
+	if targetID == "" {
+		targetID = "(new)"
+	}
+	switch action.Kind {
+	case trackersync.ActionCreate:
+		return fmt.Sprintf("create %s:%s -> %s:%s (%s)", fromProfile, action.SourceID, toProfile, targetID, action.Status)
+	case trackersync.ActionPushStatus:
+		return fmt.Sprintf("status %s:%s -> %s:%s = %s", fromProfile, action.SourceID, toProfile, targetID, action.Status)
+	case trackersync.ActionPullStatus:
+		return fmt.Sprintf("status %s:%s <- %s:%s = %s", fromProfile, action.SourceID, toProfile, targetID, action.Status)
+	default:
+		return fmt.Sprintf("conflict %s:%s vs %s:%s, keeping %s", fromProfile, action.SourceID, toProfile, targetID, action.Status)
+	}
+}
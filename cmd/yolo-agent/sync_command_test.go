@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/github"
+	"github.com/egv/yolo-runner/v2/internal/trackersync"
+)
+
+func TestRunTrackerSyncRequiresDistinctProfilesAndRoot(t *testing.T) {
+	cases := []syncCommandConfig{
+		{toProfile: "github", rootID: "root"},
+		{fromProfile: "tk", toProfile: "tk", rootID: "root"},
+		{fromProfile: "tk", toProfile: "github"},
+	}
+	for _, cfg := range cases {
+		if err := runTrackerSync(context.Background(), cfg, func(string) string { return "" }, &bytes.Buffer{}); err == nil {
+			t.Fatalf("expected validation error for %#v", cfg)
+		}
+	}
+}
+
+func TestRunTrackerSyncMirrorsTreeAndPersistsMapping(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  local:
+    tracker:
+      type: tk
+  github:
+    tracker:
+      type: github
+      github:
+        scope:
+          owner: egv
+          repo: yolo-runner
+        auth:
+          token_env: GITHUB_TOKEN
+`)
+	t.Setenv("GITHUB_TOKEN", "ghp_test")
+
+	source := &syncTestTracker{tasks: map[string]contracts.Task{
+		"root": {ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+		"t-1":  {ID: "t-1", Title: "Task", ParentID: "root", Status: contracts.TaskStatusOpen},
+	}}
+	target := &syncTestTracker{tasks: map[string]contracts.Task{}}
+	originalTKStorage := newTKStorageBackend
+	originalGitHubStorage := newGitHubStorageBackend
+	t.Cleanup(func() {
+		newTKStorageBackend = originalTKStorage
+		newGitHubStorageBackend = originalGitHubStorage
+	})
+	newTKStorageBackend = func(string) (contracts.StorageBackend, error) { return source, nil }
+	newGitHubStorageBackend = func(github.Config) (contracts.StorageBackend, error) { return target, nil }
+
+	cfg := syncCommandConfig{repoRoot: repoRoot, fromProfile: "local", toProfile: "github", rootID: "root"}
+	out := &bytes.Buffer{}
+	if err := runTrackerSync(context.Background(), cfg, os.Getenv, out); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if !strings.Contains(out.String(), "create local:root -> github:1 (open)") || !strings.Contains(out.String(), "create local:t-1 -> github:2 (open)") {
+		t.Fatalf("unexpected sync output:\n%s", out.String())
+	}
+	mapping, err := trackersync.LoadMapping(syncMappingPath(repoRoot, "local", "github"))
+	if err != nil {
+		t.Fatalf("load mapping: %v", err)
+	}
+	if id, _ := mapping.TargetID("t-1"); id != "2" {
+		t.Fatalf("expected persisted mapping for t-1, got %#v", mapping.Tasks)
+	}
+
+	target.setStatus("2", contracts.TaskStatusClosed)
+	out.Reset()
+	if err := runTrackerSync(context.Background(), cfg, os.Getenv, out); err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "status local:t-1 <- github:2 = closed" {
+		t.Fatalf("expected status to flow back, got:\n%s", out.String())
+	}
+	if source.tasks["t-1"].Status != contracts.TaskStatusClosed {
+		t.Fatalf("expected source task closed")
+	}
+}
+
+type syncTestTracker struct {
+	staticStorageBackend
+	tasks map[string]contracts.Task
+	next  int
+}
+
+func (s *syncTestTracker) setStatus(id string, status contracts.TaskStatus) {
+	task := s.tasks[id]
+	task.Status = status
+	s.tasks[id] = task
+}
+
+func (s *syncTestTracker) GetTaskTree(_ context.Context, rootID string) (*contracts.TaskTree, error) {
+	tasks := map[string]contracts.Task{}
+	for id, task := range s.tasks {
+		if id == rootID || task.ParentID == rootID {
+			tasks[id] = task
+		}
+	}
+	return &contracts.TaskTree{Root: s.tasks[rootID], Tasks: tasks}, nil
+}
+
+func (s *syncTestTracker) SetTaskStatus(_ context.Context, taskID string, status contracts.TaskStatus) error {
+	s.setStatus(taskID, status)
+	return nil
+}
+
+func (s *syncTestTracker) CreateTask(_ context.Context, request contracts.TaskCreateRequest) (string, error) {
+	s.next++
+	id := fmt.Sprint(s.next)
+	s.tasks[id] = contracts.Task{ID: id, Title: request.Title, ParentID: request.ParentID, Status: contracts.TaskStatusOpen}
+	return id, nil
+}
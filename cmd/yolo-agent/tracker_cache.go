@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/trackercache"
+)
+
+// maybeWrapWithTrackerCache puts a write-behind cache in front of the tracker
+// when --tracker-cache-ttl is set. The returned close function stops the
+// background flusher and flushes what is still queued.
+func maybeWrapWithTrackerCache(ctx context.Context, cfg runConfig, tracker contracts.StorageBackend, out io.Writer) (contracts.StorageBackend, func()) {
+	if cfg.trackerCacheTTL <= 0 {
+		return tracker, nil
+	}
+	cache := trackercache.NewStorageBackend(tracker, trackercache.Options{
+		TTL: cfg.trackerCacheTTL,
+		OnError: func(err error) {
+			fmt.Fprintf(out, "warning: tracker cache: %v\n", err)
+		},
+	})
+	flushCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.Run(flushCtx)
+	}()
+	return cache.Backend(), func() {
+		cancel()
+		<-done
+		if err := cache.Flush(context.Background()); err != nil {
+			fmt.Fprintf(out, "warning: %d tracker writes were not flushed: %v\n", cache.Pending(), err)
+		}
+	}
+}
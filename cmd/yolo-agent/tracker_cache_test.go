@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestMaybeWrapWithTrackerCacheIsNoopWhenDisabled(t *testing.T) {
+	tracker := staticStorageBackend{}
+	backend, closeFn := maybeWrapWithTrackerCache(context.Background(), runConfig{}, tracker, &bytes.Buffer{})
+	if closeFn != nil || backend != tracker {
+		t.Fatalf("expected tracker backend to pass through, got %#v", backend)
+	}
+}
+
+func TestMaybeWrapWithTrackerCacheFlushesQueuedWritesOnClose(t *testing.T) {
+	tracker := &recordingStorageBackend{tree: &contracts.TaskTree{
+		Root:  contracts.Task{ID: "root"},
+		Tasks: map[string]contracts.Task{"root": {ID: "root"}},
+	}}
+	backend, closeFn := maybeWrapWithTrackerCache(context.Background(), runConfig{trackerCacheTTL: time.Minute}, tracker, &bytes.Buffer{})
+	if closeFn == nil || backend == contracts.StorageBackend(tracker) {
+		t.Fatalf("expected cache to wrap tracker")
+	}
+	if err := backend.SetTaskStatus(context.Background(), "root", contracts.TaskStatusClosed); err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	closeFn()
+
+	if got := tracker.statusCalls(); len(got) != 1 || got[0] != "root=closed" {
+		t.Fatalf("expected queued status flushed on close, got %v", got)
+	}
+}
@@ -8,11 +8,15 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/azuredevops"
 	"github.com/egv/yolo-runner/v2/internal/beads"
 	"github.com/egv/yolo-runner/v2/internal/contracts"
 	githubtracker "github.com/egv/yolo-runner/v2/internal/github"
 	"github.com/egv/yolo-runner/v2/internal/linear"
+	"github.com/egv/yolo-runner/v2/internal/notion"
 	"github.com/egv/yolo-runner/v2/internal/tk"
+	"github.com/egv/yolo-runner/v2/pkg/registry"
 )
 
 const (
@@ -21,10 +25,16 @@ const (
 	trackerTypeGitHub = "github"
 	trackerTypeBeads  = "beads"
 
-	defaultProfileName     = "default"
-	trackerConfigRelPath   = ".yolo-runner/config.yaml"
-	linearTokenEnvVarLabel = "linear.auth.token_env"
-	githubTokenEnvVarLabel = "github.auth.token_env"
+	trackerTypeAzureDevOps = "azure_devops"
+	trackerTypeNotion      = "notion"
+
+	defaultProfileName          = "default"
+	trackerConfigRelPath        = ".yolo-runner/config.yaml"
+	userConfigRelPath           = "yolo-runner/config.yaml"
+	linearTokenEnvVarLabel      = "linear.auth.token_env"
+	githubTokenEnvVarLabel      = "github.auth.token_env"
+	azureDevOpsTokenEnvVarLabel = "azure_devops.auth.token_env"
+	notionTokenEnvVarLabel      = "notion.auth.token_env"
 )
 
 type profileSelectionInput struct {
@@ -37,18 +47,44 @@ type trackerProfilesModel struct {
 	Profiles       map[string]trackerProfileDef `yaml:"profiles"`
 	Agent          yoloAgentConfigModel         `yaml:"agent,omitempty"`
 	Tracker        trackerModel                 `yaml:"tracker,omitempty"`
+	// TUI is read by yolo-tui; it is declared here so the strict decoder
+	// accepts a shared config file.
+	TUI yoloTUIConfigModel `yaml:"tui,omitempty"`
+}
+
+type yoloTUIConfigModel struct {
+	Theme         string                          `yaml:"theme,omitempty"`
+	ColorProfile  string                          `yaml:"color_profile,omitempty"`
+	Notifications yoloTUINotificationsConfigModel `yaml:"notifications,omitempty"`
+}
+
+type yoloTUINotificationsConfigModel struct {
+	RunFinished       bool `yaml:"run_finished,omitempty"`
+	TaskBlocked       bool `yaml:"task_blocked,omitempty"`
+	ApprovalRequested bool `yaml:"approval_requested,omitempty"`
 }
 
 type trackerProfileDef struct {
 	Tracker trackerModel `yaml:"tracker"`
+	// Env is injected into the runner and pre-landing hook processes of
+	// each task.
+	Env map[string]profileEnvVarModel `yaml:"env,omitempty"`
+	// Tools restricts the commands the agent may run in each task.
+	Tools profileToolsModel `yaml:"tools,omitempty"`
+	// Pipeline lists the stages each task goes through.
+	Pipeline profilePipelineModel `yaml:"pipeline,omitempty"`
 }
 
 type trackerModel struct {
-	Type   string              `yaml:"type"`
-	TK     *tkTrackerModel     `yaml:"tk,omitempty"`
-	Linear *linearTrackerModel `yaml:"linear,omitempty"`
-	GitHub *githubTrackerModel `yaml:"github,omitempty"`
-	Beads  *beadsTrackerModel  `yaml:"beads,omitempty"`
+	Type        string                   `yaml:"type"`
+	TK          *tkTrackerModel          `yaml:"tk,omitempty"`
+	Linear      *linearTrackerModel      `yaml:"linear,omitempty"`
+	GitHub      *githubTrackerModel      `yaml:"github,omitempty"`
+	Beads       *beadsTrackerModel       `yaml:"beads,omitempty"`
+	AzureDevOps *azureDevOpsTrackerModel `yaml:"azure_devops,omitempty"`
+	Notion      *notionTrackerModel      `yaml:"notion,omitempty"`
+	// Settings configures trackers registered through pkg/registry.
+	Settings map[string]any `yaml:"settings,omitempty"`
 }
 
 type tkTrackerModel struct {
@@ -86,6 +122,65 @@ type githubAuthModel struct {
 	TokenEnv string `yaml:"token_env"`
 }
 
+type azureDevOpsTrackerModel struct {
+	Endpoint     string                 `yaml:"endpoint,omitempty"`
+	Scope        azureDevOpsScopeModel  `yaml:"scope"`
+	Auth         azureDevOpsAuthModel   `yaml:"auth"`
+	States       azureDevOpsStatesModel `yaml:"states,omitempty"`
+	WorkItemType string                 `yaml:"work_item_type,omitempty"`
+}
+
+type azureDevOpsScopeModel struct {
+	Organization  string `yaml:"organization"`
+	Project       string `yaml:"project"`
+	AreaPath      string `yaml:"area_path,omitempty"`
+	IterationPath string `yaml:"iteration_path,omitempty"`
+}
+
+type azureDevOpsAuthModel struct {
+	TokenEnv string `yaml:"token_env"`
+}
+
+type azureDevOpsStatesModel struct {
+	Open       string `yaml:"open,omitempty"`
+	InProgress string `yaml:"in_progress,omitempty"`
+	Blocked    string `yaml:"blocked,omitempty"`
+	Failed     string `yaml:"failed,omitempty"`
+	Closed     string `yaml:"closed,omitempty"`
+}
+
+type notionTrackerModel struct {
+	Endpoint   string                `yaml:"endpoint,omitempty"`
+	Scope      notionScopeModel      `yaml:"scope"`
+	Auth       notionAuthModel       `yaml:"auth"`
+	Properties notionPropertiesModel `yaml:"properties,omitempty"`
+	States     notionStatesModel     `yaml:"states,omitempty"`
+}
+
+type notionScopeModel struct {
+	DatabaseID string `yaml:"database_id"`
+}
+
+type notionAuthModel struct {
+	TokenEnv string `yaml:"token_env"`
+}
+
+type notionPropertiesModel struct {
+	Title        string `yaml:"title,omitempty"`
+	Status       string `yaml:"status,omitempty"`
+	Parent       string `yaml:"parent,omitempty"`
+	Priority     string `yaml:"priority,omitempty"`
+	Dependencies string `yaml:"dependencies,omitempty"`
+}
+
+type notionStatesModel struct {
+	Open       string `yaml:"open,omitempty"`
+	InProgress string `yaml:"in_progress,omitempty"`
+	Blocked    string `yaml:"blocked,omitempty"`
+	Failed     string `yaml:"failed,omitempty"`
+	Closed     string `yaml:"closed,omitempty"`
+}
+
 type beadsTrackerModel struct {
 	// beads_rust doesn't require additional configuration
 	// It auto-discovers the .beads directory
@@ -99,12 +194,321 @@ type yoloAgentConfigModel struct {
 	RunnerTimeout    string `yaml:"runner_timeout,omitempty"`
 	WatchdogTimeout  string `yaml:"watchdog_timeout,omitempty"`
 	WatchdogInterval string `yaml:"watchdog_interval,omitempty"`
-	RetryBudget      *int   `yaml:"retry_budget,omitempty"`
+	// WatchdogHooks lists shell commands run when the watchdog detects a
+	// stall, before it kills the backend.
+	WatchdogHooks       []string `yaml:"watchdog_hooks,omitempty"`
+	WatchdogHookTimeout string   `yaml:"watchdog_hook_timeout,omitempty"`
+	RateLimitBackoff    string   `yaml:"rate_limit_backoff,omitempty"`
+	EpicProgress        string   `yaml:"epic_progress_interval,omitempty"`
+	TaskDiscovery       string   `yaml:"task_discovery_interval,omitempty"`
+	StatusReconcile     string   `yaml:"status_reconcile_interval,omitempty"`
+	ShutdownGrace       string   `yaml:"shutdown_grace_period,omitempty"`
+	RetryBudget         *int     `yaml:"retry_budget,omitempty"`
+	ReviewConcurrency   *int     `yaml:"review_concurrency,omitempty"`
+	ResumeSessions      *bool    `yaml:"resume_sessions,omitempty"`
+	SkipReview          *bool    `yaml:"skip_review,omitempty"`
+	DiffSummary         *bool    `yaml:"diff_summary,omitempty"`
+	StallNudge          *bool    `yaml:"stall_nudge,omitempty"`
+	StallNudgePrompt    string   `yaml:"stall_nudge_prompt,omitempty"`
+	LocalStore          string   `yaml:"local_store,omitempty"`
+	TrackerCacheTTL     string   `yaml:"tracker_cache_ttl,omitempty"`
+	// VCS is git (default) or none; none runs tasks in the repo directory
+	// without branches, merges or pushes.
+	VCS string `yaml:"vcs,omitempty"`
+	// CloneStrategy is clone (default) or overlay; overlay layers each
+	// task on one shared checkout where the platform supports it.
+	CloneStrategy string `yaml:"clone_strategy,omitempty"`
+	// OrphanedTasks is reopen (default) or block: what startup does with
+	// tasks a crashed run left in_progress.
+	OrphanedTasks string `yaml:"orphaned_tasks,omitempty"`
+	// ReviewMode is single (default) or two_phase: a spec compliance
+	// review followed by a code quality review.
+	ReviewMode string `yaml:"review_mode,omitempty"`
+
+	StallPolicies       map[string]string                            `yaml:"stall_policies,omitempty"`
+	FallbackChain       []yoloAgentFallbackModel                     `yaml:"fallback_chain,omitempty"`
+	BackendCapabilities map[string]yoloAgentBackendCapabilitiesModel `yaml:"backend_capabilities,omitempty"`
+	Prompts             yoloAgentPromptsModel                        `yaml:"prompts,omitempty"`
+	CommitMessages      yoloAgentCommitMessagesModel                 `yaml:"commit_messages,omitempty"`
+	Landing             yoloAgentLandingModel                        `yaml:"landing,omitempty"`
+	Sync                yoloAgentSyncModel                           `yaml:"sync,omitempty"`
+	PathScope           yoloAgentPathScopeModel                      `yaml:"path_scope,omitempty"`
+	DiffGuardrails      yoloAgentDiffGuardrailsModel                 `yaml:"diff_guardrails,omitempty"`
+	SecretScan          yoloAgentSecretScanModel                     `yaml:"secret_scan,omitempty"`
+	DependencyPolicy    yoloAgentDependencyPolicyModel               `yaml:"dependency_policy,omitempty"`
+	RepoContext         *yoloAgentRepoContextModel                   `yaml:"repo_context,omitempty"`
+	ClonePool           *yoloAgentClonePoolModel                     `yaml:"clone_pool,omitempty"`
+	Retention           *yoloAgentRetentionModel                     `yaml:"retention,omitempty"`
+	Escalation          *yoloAgentEscalationModel                    `yaml:"escalation,omitempty"`
+	BlockedRetry        map[string]yoloAgentBlockedRetryModel        `yaml:"blocked_retry,omitempty"`
+	EventSinks          map[string]yoloAgentEventSinkModel           `yaml:"event_sinks,omitempty"`
+	EventLog            *yoloAgentEventLogModel                      `yaml:"event_log,omitempty"`
+	Redaction           *yoloAgentRedactionModel                     `yaml:"redaction,omitempty"`
+	ControlAPI          *yoloAgentControlAPIModel                    `yaml:"control_api,omitempty"`
+	Executor            *yoloAgentExecutorModel                      `yaml:"executor,omitempty"`
+	Executors           map[string]yoloAgentNamedExecutorModel       `yaml:"executors,omitempty"`
+	Affinity            []yoloAgentAffinityModel                     `yaml:"affinity,omitempty"`
+}
+
+// yoloAgentExecutorModel picks where runner commands run: local processes
+// (the default), Kubernetes Jobs or a remote host over SSH.
+type yoloAgentExecutorModel struct {
+	Type       string                    `yaml:"type,omitempty"`
+	Kubernetes *yoloAgentKubernetesModel `yaml:"kubernetes,omitempty"`
+	SSH        *yoloAgentSSHModel        `yaml:"ssh,omitempty"`
+}
+
+// yoloAgentNamedExecutorModel is an executor tasks can be pinned to with
+// agent.affinity. MaxTasks caps how many tasks run on it at once.
+type yoloAgentNamedExecutorModel struct {
+	Type       string                    `yaml:"type,omitempty"`
+	Kubernetes *yoloAgentKubernetesModel `yaml:"kubernetes,omitempty"`
+	SSH        *yoloAgentSSHModel        `yaml:"ssh,omitempty"`
+	MaxTasks   *int                      `yaml:"max_tasks,omitempty"`
+}
+
+// yoloAgentAffinityModel pins tasks with one of Labels to the agent.executors
+// entry named Executor.
+type yoloAgentAffinityModel struct {
+	Labels   []string `yaml:"labels,omitempty"`
+	Executor string   `yaml:"executor,omitempty"`
+}
+
+type yoloAgentSSHModel struct {
+	Host         string   `yaml:"host,omitempty"`
+	Port         *int     `yaml:"port,omitempty"`
+	IdentityFile string   `yaml:"identity_file,omitempty"`
+	Options      []string `yaml:"options,omitempty"`
+	RemoteDir    string   `yaml:"remote_dir,omitempty"`
+	SSH          string   `yaml:"ssh,omitempty"`
+	Git          string   `yaml:"git,omitempty"`
+}
+
+type yoloAgentKubernetesModel struct {
+	Kubectl         string                            `yaml:"kubectl,omitempty"`
+	Context         string                            `yaml:"context,omitempty"`
+	Namespace       string                            `yaml:"namespace,omitempty"`
+	Image           string                            `yaml:"image,omitempty"`
+	ImagePullPolicy string                            `yaml:"image_pull_policy,omitempty"`
+	ServiceAccount  string                            `yaml:"service_account,omitempty"`
+	EnvFromSecrets  []string                          `yaml:"env_from_secrets,omitempty"`
+	Resources       yoloAgentKubernetesResourcesModel `yaml:"resources,omitempty"`
+	Workspace       yoloAgentKubernetesWorkspaceModel `yaml:"workspace,omitempty"`
+	StartTimeout    string                            `yaml:"start_timeout,omitempty"`
+}
+
+type yoloAgentKubernetesResourcesModel struct {
+	Requests map[string]string `yaml:"requests,omitempty"`
+	Limits   map[string]string `yaml:"limits,omitempty"`
+}
+
+// yoloAgentKubernetesWorkspaceModel is the volume that holds the clones:
+// claim is mounted at local_path here and at mount_path in the pods.
+type yoloAgentKubernetesWorkspaceModel struct {
+	Claim     string `yaml:"claim,omitempty"`
+	LocalPath string `yaml:"local_path,omitempty"`
+	MountPath string `yaml:"mount_path,omitempty"`
+}
+
+// yoloAgentControlAPIModel grants roles on the --serve API to API tokens
+// and OIDC identities.
+type yoloAgentControlAPIModel struct {
+	Tokens []yoloAgentControlAPITokenModel `yaml:"tokens,omitempty"`
+	OIDC   *yoloAgentControlAPIOIDCModel   `yaml:"oidc,omitempty"`
+	Slack  *yoloAgentControlAPISlackModel  `yaml:"slack,omitempty"`
+}
+
+type yoloAgentControlAPITokenModel struct {
+	Name     string `yaml:"name,omitempty"`
+	Role     string `yaml:"role,omitempty"`
+	TokenEnv string `yaml:"token_env,omitempty"`
+}
+
+// yoloAgentControlAPIOIDCModel accepts ID tokens from one issuer. Roles maps
+// a role to the values of Claim (email by default) that hold it; "*" matches
+// every verified identity.
+type yoloAgentControlAPIOIDCModel struct {
+	Issuer   string              `yaml:"issuer,omitempty"`
+	Audience string              `yaml:"audience,omitempty"`
+	Claim    string              `yaml:"claim,omitempty"`
+	Roles    map[string][]string `yaml:"roles,omitempty"`
+}
+
+// yoloAgentControlAPISlackModel grants roles to Slack users of yolo-agent
+// chatops. Roles maps a role to the Slack user IDs that hold it; "*" matches
+// every user of the workspace.
+type yoloAgentControlAPISlackModel struct {
+	Roles map[string][]string `yaml:"roles,omitempty"`
+}
+
+// yoloAgentRedactionModel masks sensitive values in events before any sink
+// sees them. Patterns are regular expressions keyed by a name that appears in
+// the [redacted:<name>] marker.
+type yoloAgentRedactionModel struct {
+	Presets  []string          `yaml:"presets,omitempty"`
+	Patterns map[string]string `yaml:"patterns,omitempty"`
+}
+
+// yoloAgentEventLogModel configures rotation of the --events file.
+type yoloAgentEventLogModel struct {
+	MaxSizeMB *int   `yaml:"max_size_mb,omitempty"`
+	MaxAge    string `yaml:"max_age,omitempty"`
+	Compress  bool   `yaml:"compress,omitempty"`
+	Keep      *int   `yaml:"keep,omitempty"`
+	RetainFor string `yaml:"retain_for,omitempty"`
+}
+
+// yoloAgentEventSinkModel filters the events written by one sink (file or
+// stream).
+type yoloAgentEventSinkModel struct {
+	Include []string       `yaml:"include,omitempty"`
+	Exclude []string       `yaml:"exclude,omitempty"`
+	Sample  map[string]int `yaml:"sample,omitempty"`
+}
+
+type yoloAgentRepoContextModel struct {
+	Enabled       bool     `yaml:"enabled,omitempty"`
+	Files         []string `yaml:"files,omitempty"`
+	RecentCommits *int     `yaml:"recent_commits,omitempty"`
+	TreeDepth     *int     `yaml:"tree_depth,omitempty"`
+	MaxBytes      *int     `yaml:"max_bytes,omitempty"`
+}
+
+// yoloAgentClonePoolModel configures the pool of ready task clones.
+type yoloAgentClonePoolModel struct {
+	Size    *int   `yaml:"size,omitempty"`
+	Refresh string `yaml:"refresh,omitempty"`
+	MaxUses *int   `yaml:"max_uses,omitempty"`
+}
+
+// yoloAgentRetentionModel caps the disk space of runner logs, task clones
+// and artifacts.
+type yoloAgentRetentionModel struct {
+	RunnerLogs *yoloAgentRetentionPolicyModel `yaml:"runner_logs,omitempty"`
+	Clones     *yoloAgentRetentionPolicyModel `yaml:"clones,omitempty"`
+	Artifacts  *yoloAgentRetentionPolicyModel `yaml:"artifacts,omitempty"`
+	MinAge     string                         `yaml:"min_age,omitempty"`
+}
+
+type yoloAgentRetentionPolicyModel struct {
+	MaxSizeMB *int   `yaml:"max_size_mb,omitempty"`
+	MaxAge    string `yaml:"max_age,omitempty"`
+}
+
+// yoloAgentEscalationModel hands tasks that stay blocked to a human.
+type yoloAgentEscalationModel struct {
+	After      string `yaml:"after,omitempty"`
+	Assignee   string `yaml:"assignee,omitempty"`
+	Label      string `yaml:"label,omitempty"`
+	WebhookURL string `yaml:"webhook_url,omitempty"`
+}
+
+// yoloAgentBlockedRetryModel re-queues tasks blocked in one category.
+type yoloAgentBlockedRetryModel struct {
+	MaxAttempts *int   `yaml:"max_attempts,omitempty"`
+	Backoff     string `yaml:"backoff,omitempty"`
+	MaxBackoff  string `yaml:"max_backoff,omitempty"`
+}
+
+type yoloAgentFallbackModel struct {
+	Backend string `yaml:"backend,omitempty"`
+	Model   string `yaml:"model,omitempty"`
+}
+
+// yoloAgentBackendCapabilitiesModel overrides the built-in capabilities of
+// one backend. Unset fields keep the built-in value.
+type yoloAgentBackendCapabilitiesModel struct {
+	Review        *bool `yaml:"review,omitempty"`
+	Stream        *bool `yaml:"stream,omitempty"`
+	SessionResume *bool `yaml:"session_resume,omitempty"`
+	ACP           *bool `yaml:"acp,omitempty"`
+}
+
+type yoloAgentPromptsModel struct {
+	Implement   string `yaml:"implement,omitempty"`
+	Review      string `yaml:"review,omitempty"`
+	Remediation string `yaml:"remediation,omitempty"`
+}
+
+// yoloAgentCommitMessagesModel holds inline text/template sources for the
+// landing commit messages.
+type yoloAgentCommitMessagesModel struct {
+	AutoCommit   string `yaml:"auto_commit,omitempty"`
+	Merge        string `yaml:"merge,omitempty"`
+	Squash       string `yaml:"squash,omitempty"`
+	TrackerURL   string `yaml:"tracker_url,omitempty"`
+	CoAuthoredBy string `yaml:"co_authored_by,omitempty"`
+}
+
+// yoloAgentLandingModel configures how task branches land on main.
+type yoloAgentLandingModel struct {
+	Strategy string `yaml:"strategy,omitempty"`
+	// PreMerge lists shell commands run in the clone before landing; any
+	// failure blocks the task.
+	PreMerge    []string `yaml:"pre_merge,omitempty"`
+	HookTimeout string   `yaml:"hook_timeout,omitempty"`
+	// BatchSize lets the merge queue land up to this many tasks with one
+	// push; 1 (the default) lands tasks one at a time.
+	BatchSize *int `yaml:"batch_size,omitempty"`
+	// PushRetries bounds re-landing after a non-fast-forward push rejection.
+	PushRetries *int `yaml:"push_retries,omitempty"`
+}
+
+// yoloAgentSyncModel names the remote branch main is fast-forwarded from
+// before each task and pushed to after landing.
+type yoloAgentSyncModel struct {
+	Remote string `yaml:"remote,omitempty"`
+	Branch string `yaml:"branch,omitempty"`
+}
+
+// yoloAgentPathScopeModel restricts the files a task may change. Labels maps
+// a task label to the path globs it allows; CodeOwners also allows the paths
+// CODEOWNERS assigns to owners named in the task's labels.
+type yoloAgentPathScopeModel struct {
+	Labels     map[string][]string `yaml:"labels,omitempty"`
+	CodeOwners bool                `yaml:"codeowners,omitempty"`
+}
+
+// yoloAgentDiffGuardrailsModel bounds the diff a task may produce before
+// review and landing.
+type yoloAgentDiffGuardrailsModel struct {
+	MaxChangedFiles  *int     `yaml:"max_changed_files,omitempty"`
+	MaxChangedLines  *int     `yaml:"max_changed_lines,omitempty"`
+	ForbiddenPaths   []string `yaml:"forbidden_paths,omitempty"`
+	BlockBinaryFiles bool     `yaml:"block_binary_files,omitempty"`
+	// OnViolation is block (default) or approve.
+	OnViolation string `yaml:"on_violation,omitempty"`
+}
+
+// yoloAgentSecretScanModel enables secret scanning of task diffs with the
+// built-in rules, an external command, or both.
+type yoloAgentSecretScanModel struct {
+	Enabled bool   `yaml:"enabled,omitempty"`
+	Command string `yaml:"command,omitempty"`
+	Timeout string `yaml:"timeout,omitempty"`
+}
+
+// yoloAgentDependencyPolicyModel validates dependencies a task adds or
+// changes against name globs and a license policy.
+type yoloAgentDependencyPolicyModel struct {
+	Allow          []string                         `yaml:"allow,omitempty"`
+	Deny           []string                         `yaml:"deny,omitempty"`
+	Licenses       yoloAgentDependencyLicensesModel `yaml:"licenses,omitempty"`
+	LicenseCommand string                           `yaml:"license_command,omitempty"`
+	Timeout        string                           `yaml:"timeout,omitempty"`
+}
+
+type yoloAgentDependencyLicensesModel struct {
+	Allow []string `yaml:"allow,omitempty"`
+	Deny  []string `yaml:"deny,omitempty"`
 }
 
 type resolvedTrackerProfile struct {
-	Name    string
-	Tracker trackerModel
+	Name     string
+	Tracker  trackerModel
+	Env      []agent.TaskEnvVar
+	Tools    contracts.ToolPolicy
+	Pipeline agent.Pipeline
 }
 
 var newLinearTaskManager = func(cfg linear.Config) (contracts.TaskManager, error) {
@@ -131,6 +535,22 @@ var newGitHubStorageBackend = func(cfg githubtracker.Config) (contracts.StorageB
 	return githubtracker.NewStorageBackend(cfg)
 }
 
+var newAzureDevOpsTaskManager = func(cfg azuredevops.Config) (contracts.TaskManager, error) {
+	return azuredevops.NewTaskManager(cfg)
+}
+
+var newAzureDevOpsStorageBackend = func(cfg azuredevops.Config) (contracts.StorageBackend, error) {
+	return azuredevops.NewStorageBackend(cfg)
+}
+
+var newNotionTaskManager = func(cfg notion.Config) (contracts.TaskManager, error) {
+	return notion.NewTaskManager(cfg)
+}
+
+var newNotionStorageBackend = func(cfg notion.Config) (contracts.StorageBackend, error) {
+	return notion.NewStorageBackend(cfg)
+}
+
 var newBeadsTaskManager = func(repoRoot string) (contracts.TaskManager, error) {
 	return beads.NewTaskManager(localRunner{dir: repoRoot}, repoRoot), nil
 }
@@ -171,7 +591,11 @@ func buildTaskManagerForTracker(repoRoot string, profile resolvedTrackerProfile)
 		if tokenEnv == "" {
 			return nil, fmt.Errorf("%s is required for profile %q", linearTokenEnvVarLabel, profile.Name)
 		}
-		tokenValue := strings.TrimSpace(os.Getenv(tokenEnv))
+		tokenValue, err := lookupConfigSecret(os.Getenv, tokenEnv)
+		if err != nil {
+			return nil, fmt.Errorf("reading auth token for profile %q: %w", profile.Name, err)
+		}
+		tokenValue = strings.TrimSpace(tokenValue)
 		if tokenValue == "" {
 			return nil, fmt.Errorf("missing auth token from %s for profile %q", tokenEnv, profile.Name)
 		}
@@ -199,7 +623,11 @@ func buildTaskManagerForTracker(repoRoot string, profile resolvedTrackerProfile)
 		if tokenEnv == "" {
 			return nil, fmt.Errorf("%s is required for profile %q", githubTokenEnvVarLabel, profile.Name)
 		}
-		tokenValue := strings.TrimSpace(os.Getenv(tokenEnv))
+		tokenValue, err := lookupConfigSecret(os.Getenv, tokenEnv)
+		if err != nil {
+			return nil, fmt.Errorf("reading auth token for profile %q: %w", profile.Name, err)
+		}
+		tokenValue = strings.TrimSpace(tokenValue)
 		if tokenValue == "" {
 			return nil, fmt.Errorf("missing auth token from %s for profile %q", tokenEnv, profile.Name)
 		}
@@ -212,6 +640,26 @@ func buildTaskManagerForTracker(repoRoot string, profile resolvedTrackerProfile)
 			return nil, fmt.Errorf("github auth validation failed for profile %q using %s: %w", profile.Name, tokenEnv, err)
 		}
 		return manager, nil
+	case trackerTypeAzureDevOps:
+		cfg, tokenEnv, err := azureDevOpsConfigForProfile(profile)
+		if err != nil {
+			return nil, err
+		}
+		manager, err := newAzureDevOpsTaskManager(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("azure devops auth validation failed for profile %q using %s: %w", profile.Name, tokenEnv, err)
+		}
+		return manager, nil
+	case trackerTypeNotion:
+		cfg, tokenEnv, err := notionConfigForProfile(profile)
+		if err != nil {
+			return nil, err
+		}
+		manager, err := newNotionTaskManager(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("notion auth validation failed for profile %q using %s: %w", profile.Name, tokenEnv, err)
+		}
+		return manager, nil
 	case trackerTypeBeads:
 		return newBeadsTaskManager(repoRoot)
 	default:
@@ -243,7 +691,11 @@ func buildStorageBackendForTracker(repoRoot string, profile resolvedTrackerProfi
 		if tokenEnv == "" {
 			return nil, fmt.Errorf("%s is required for profile %q", githubTokenEnvVarLabel, profile.Name)
 		}
-		tokenValue := strings.TrimSpace(os.Getenv(tokenEnv))
+		tokenValue, err := lookupConfigSecret(os.Getenv, tokenEnv)
+		if err != nil {
+			return nil, fmt.Errorf("reading auth token for profile %q: %w", profile.Name, err)
+		}
+		tokenValue = strings.TrimSpace(tokenValue)
 		if tokenValue == "" {
 			return nil, fmt.Errorf("missing auth token from %s for profile %q", tokenEnv, profile.Name)
 		}
@@ -269,7 +721,11 @@ func buildStorageBackendForTracker(repoRoot string, profile resolvedTrackerProfi
 		if tokenEnv == "" {
 			return nil, fmt.Errorf("%s is required for profile %q", linearTokenEnvVarLabel, profile.Name)
 		}
-		tokenValue := strings.TrimSpace(os.Getenv(tokenEnv))
+		tokenValue, err := lookupConfigSecret(os.Getenv, tokenEnv)
+		if err != nil {
+			return nil, fmt.Errorf("reading auth token for profile %q: %w", profile.Name, err)
+		}
+		tokenValue = strings.TrimSpace(tokenValue)
 		if tokenValue == "" {
 			return nil, fmt.Errorf("missing auth token from %s for profile %q", tokenEnv, profile.Name)
 		}
@@ -281,11 +737,141 @@ func buildStorageBackendForTracker(repoRoot string, profile resolvedTrackerProfi
 			return nil, fmt.Errorf("linear auth validation failed for profile %q using %s: %w", profile.Name, tokenEnv, err)
 		}
 		return backend, nil
+	case trackerTypeAzureDevOps:
+		cfg, tokenEnv, err := azureDevOpsConfigForProfile(profile)
+		if err != nil {
+			return nil, err
+		}
+		backend, err := newAzureDevOpsStorageBackend(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("azure devops auth validation failed for profile %q using %s: %w", profile.Name, tokenEnv, err)
+		}
+		return backend, nil
+	case trackerTypeNotion:
+		cfg, tokenEnv, err := notionConfigForProfile(profile)
+		if err != nil {
+			return nil, err
+		}
+		backend, err := newNotionStorageBackend(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("notion auth validation failed for profile %q using %s: %w", profile.Name, tokenEnv, err)
+		}
+		return backend, nil
 	case trackerTypeBeads:
 		return newBeadsStorageBackend(repoRoot)
 	default:
+		return buildRegisteredTracker(repoRoot, profile)
+	}
+}
+
+// buildRegisteredTracker builds a tracker type registered through
+// pkg/registry, passing it the profile's tracker.settings.
+func buildRegisteredTracker(repoRoot string, profile resolvedTrackerProfile) (contracts.StorageBackend, error) {
+	factory, ok := registry.Tracker(profile.Tracker.Type)
+	if !ok {
 		return nil, fmt.Errorf("tracker type %q is not supported yet", profile.Tracker.Type)
 	}
+	backend, err := factory(registry.TrackerConfig{
+		RepoRoot: repoRoot,
+		Profile:  profile.Name,
+		Type:     profile.Tracker.Type,
+		Settings: profile.Tracker.Settings,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("build registered tracker %q for profile %q: %w", profile.Tracker.Type, profile.Name, err)
+	}
+	return backend, nil
+}
+
+// azureDevOpsConfigForProfile builds the Azure DevOps client config and
+// returns the env var that holds the personal access token.
+func azureDevOpsConfigForProfile(profile resolvedTrackerProfile) (azuredevops.Config, string, error) {
+	model := profile.Tracker.AzureDevOps
+	if model == nil {
+		return azuredevops.Config{}, "", fmt.Errorf("tracker.azure_devops settings are required for profile %q", profile.Name)
+	}
+	organization := strings.TrimSpace(model.Scope.Organization)
+	if organization == "" {
+		return azuredevops.Config{}, "", fmt.Errorf("%s is required for profile %q", "azure_devops.scope.organization", profile.Name)
+	}
+	project := strings.TrimSpace(model.Scope.Project)
+	if project == "" {
+		return azuredevops.Config{}, "", fmt.Errorf("%s is required for profile %q", "azure_devops.scope.project", profile.Name)
+	}
+	tokenEnv := strings.TrimSpace(model.Auth.TokenEnv)
+	if tokenEnv == "" {
+		return azuredevops.Config{}, "", fmt.Errorf("%s is required for profile %q", azureDevOpsTokenEnvVarLabel, profile.Name)
+	}
+	tokenValue, err := lookupConfigSecret(os.Getenv, tokenEnv)
+	if err != nil {
+		return azuredevops.Config{}, "", fmt.Errorf("reading auth token for profile %q: %w", profile.Name, err)
+	}
+	tokenValue = strings.TrimSpace(tokenValue)
+	if tokenValue == "" {
+		return azuredevops.Config{}, "", fmt.Errorf("missing auth token from %s for profile %q", tokenEnv, profile.Name)
+	}
+	return azuredevops.Config{
+		Organization: organization,
+		Project:      project,
+		Token:        tokenValue,
+		Scope: azuredevops.Scope{
+			AreaPath:      model.Scope.AreaPath,
+			IterationPath: model.Scope.IterationPath,
+		},
+		States: azuredevops.StateMapping{
+			Open:       model.States.Open,
+			InProgress: model.States.InProgress,
+			Blocked:    model.States.Blocked,
+			Failed:     model.States.Failed,
+			Closed:     model.States.Closed,
+		},
+		WorkItemType: model.WorkItemType,
+		APIEndpoint:  model.Endpoint,
+	}, tokenEnv, nil
+}
+
+// notionConfigForProfile builds the Notion client config and returns the env
+// var that holds the integration token.
+func notionConfigForProfile(profile resolvedTrackerProfile) (notion.Config, string, error) {
+	model := profile.Tracker.Notion
+	if model == nil {
+		return notion.Config{}, "", fmt.Errorf("tracker.notion settings are required for profile %q", profile.Name)
+	}
+	databaseID := strings.TrimSpace(model.Scope.DatabaseID)
+	if databaseID == "" {
+		return notion.Config{}, "", fmt.Errorf("%s is required for profile %q", "notion.scope.database_id", profile.Name)
+	}
+	tokenEnv := strings.TrimSpace(model.Auth.TokenEnv)
+	if tokenEnv == "" {
+		return notion.Config{}, "", fmt.Errorf("%s is required for profile %q", notionTokenEnvVarLabel, profile.Name)
+	}
+	tokenValue, err := lookupConfigSecret(os.Getenv, tokenEnv)
+	if err != nil {
+		return notion.Config{}, "", fmt.Errorf("reading auth token for profile %q: %w", profile.Name, err)
+	}
+	tokenValue = strings.TrimSpace(tokenValue)
+	if tokenValue == "" {
+		return notion.Config{}, "", fmt.Errorf("missing auth token from %s for profile %q", tokenEnv, profile.Name)
+	}
+	return notion.Config{
+		DatabaseID: databaseID,
+		Token:      tokenValue,
+		Properties: notion.PropertyMapping{
+			Title:        model.Properties.Title,
+			Status:       model.Properties.Status,
+			Parent:       model.Properties.Parent,
+			Priority:     model.Properties.Priority,
+			Dependencies: model.Properties.Dependencies,
+		},
+		States: notion.StateMapping{
+			Open:       model.States.Open,
+			InProgress: model.States.InProgress,
+			Blocked:    model.States.Blocked,
+			Failed:     model.States.Failed,
+			Closed:     model.States.Closed,
+		},
+		APIEndpoint: model.Endpoint,
+	}, tokenEnv, nil
 }
 
 type taskManagerStorageBackend struct {
@@ -483,8 +1069,14 @@ func validateTrackerModel(profileName string, model trackerModel, rootID string,
 		if tokenEnv == "" {
 			return trackerModel{}, fmt.Errorf("%s is required for profile %q in %s; set it to the env var that stores your Linear API token", linearTokenEnvVarLabel, profileName, trackerConfigRelPath)
 		}
-		if getenv != nil && strings.TrimSpace(getenv(tokenEnv)) == "" {
-			return trackerModel{}, fmt.Errorf("missing auth token from %s for profile %q configured in %s; set it in your shell (for example: export %s=<linear-api-token>)", tokenEnv, profileName, trackerConfigRelPath, tokenEnv)
+		if getenv != nil {
+			token, err := lookupConfigSecret(getenv, tokenEnv)
+			if err != nil {
+				return trackerModel{}, fmt.Errorf("%s for profile %q in %s: %w", linearTokenEnvVarLabel, profileName, trackerConfigRelPath, err)
+			}
+			if strings.TrimSpace(token) == "" {
+				return trackerModel{}, fmt.Errorf("missing auth token from %s for profile %q configured in %s; set it in your shell (for example: export %s=<linear-api-token>)", tokenEnv, profileName, trackerConfigRelPath, tokenEnv)
+			}
 		}
 		model.Linear.Scope.Workspace = workspace
 		model.Linear.Auth.TokenEnv = tokenEnv
@@ -514,17 +1106,88 @@ func validateTrackerModel(profileName string, model trackerModel, rootID string,
 		if tokenEnv == "" {
 			return trackerModel{}, fmt.Errorf("%s is required for profile %q in %s; set it to the env var that stores your GitHub personal access token", githubTokenEnvVarLabel, profileName, trackerConfigRelPath)
 		}
-		if getenv != nil && strings.TrimSpace(getenv(tokenEnv)) == "" {
-			return trackerModel{}, fmt.Errorf("missing auth token from %s for profile %q configured in %s; set it in your shell (for example: export %s=<github-personal-access-token>)", tokenEnv, profileName, trackerConfigRelPath, tokenEnv)
+		if getenv != nil {
+			token, err := lookupConfigSecret(getenv, tokenEnv)
+			if err != nil {
+				return trackerModel{}, fmt.Errorf("%s for profile %q in %s: %w", githubTokenEnvVarLabel, profileName, trackerConfigRelPath, err)
+			}
+			if strings.TrimSpace(token) == "" {
+				return trackerModel{}, fmt.Errorf("missing auth token from %s for profile %q configured in %s; set it in your shell (for example: export %s=<github-personal-access-token>)", tokenEnv, profileName, trackerConfigRelPath, tokenEnv)
+			}
 		}
 		model.GitHub.Scope.Owner = owner
 		model.GitHub.Scope.Repo = repo
 		model.GitHub.Auth.TokenEnv = tokenEnv
 		return model, nil
+	case trackerTypeAzureDevOps:
+		if model.AzureDevOps == nil {
+			return trackerModel{}, fmt.Errorf("tracker.azure_devops settings are required for profile %q", profileName)
+		}
+		organization := strings.TrimSpace(model.AzureDevOps.Scope.Organization)
+		if organization == "" {
+			return trackerModel{}, fmt.Errorf("%s is required for profile %q in %s; set it to your Azure DevOps organization name", "azure_devops.scope.organization", profileName, trackerConfigRelPath)
+		}
+		if hasMultipleScopeValues(organization) || strings.Contains(organization, "/") {
+			return trackerModel{}, fmt.Errorf("%s must contain exactly one organization name for profile %q in %s; got %q", "azure_devops.scope.organization", profileName, trackerConfigRelPath, organization)
+		}
+		project := strings.TrimSpace(model.AzureDevOps.Scope.Project)
+		if project == "" {
+			return trackerModel{}, fmt.Errorf("%s is required for profile %q in %s; set it to your Azure DevOps project name", "azure_devops.scope.project", profileName, trackerConfigRelPath)
+		}
+		if strings.ContainsAny(project, ",;/") {
+			return trackerModel{}, fmt.Errorf("%s must contain exactly one project name for profile %q in %s; got %q", "azure_devops.scope.project", profileName, trackerConfigRelPath, project)
+		}
+		tokenEnv := strings.TrimSpace(model.AzureDevOps.Auth.TokenEnv)
+		if tokenEnv == "" {
+			return trackerModel{}, fmt.Errorf("%s is required for profile %q in %s; set it to the env var that stores your Azure DevOps personal access token", azureDevOpsTokenEnvVarLabel, profileName, trackerConfigRelPath)
+		}
+		if getenv != nil {
+			token, err := lookupConfigSecret(getenv, tokenEnv)
+			if err != nil {
+				return trackerModel{}, fmt.Errorf("%s for profile %q in %s: %w", azureDevOpsTokenEnvVarLabel, profileName, trackerConfigRelPath, err)
+			}
+			if strings.TrimSpace(token) == "" {
+				return trackerModel{}, fmt.Errorf("missing auth token from %s for profile %q configured in %s; set it in your shell (for example: export %s=<azure-devops-personal-access-token>)", tokenEnv, profileName, trackerConfigRelPath, tokenEnv)
+			}
+		}
+		model.AzureDevOps.Scope.Organization = organization
+		model.AzureDevOps.Scope.Project = project
+		model.AzureDevOps.Auth.TokenEnv = tokenEnv
+		return model, nil
+	case trackerTypeNotion:
+		if model.Notion == nil {
+			return trackerModel{}, fmt.Errorf("tracker.notion settings are required for profile %q", profileName)
+		}
+		databaseID := strings.TrimSpace(model.Notion.Scope.DatabaseID)
+		if databaseID == "" {
+			return trackerModel{}, fmt.Errorf("%s is required for profile %q in %s; set it to the ID or URL of your Notion task database", "notion.scope.database_id", profileName, trackerConfigRelPath)
+		}
+		if hasMultipleScopeValues(databaseID) {
+			return trackerModel{}, fmt.Errorf("%s must contain exactly one database for profile %q in %s; got %q", "notion.scope.database_id", profileName, trackerConfigRelPath, databaseID)
+		}
+		tokenEnv := strings.TrimSpace(model.Notion.Auth.TokenEnv)
+		if tokenEnv == "" {
+			return trackerModel{}, fmt.Errorf("%s is required for profile %q in %s; set it to the env var that stores your Notion integration token", notionTokenEnvVarLabel, profileName, trackerConfigRelPath)
+		}
+		if getenv != nil {
+			token, err := lookupConfigSecret(getenv, tokenEnv)
+			if err != nil {
+				return trackerModel{}, fmt.Errorf("%s for profile %q in %s: %w", notionTokenEnvVarLabel, profileName, trackerConfigRelPath, err)
+			}
+			if strings.TrimSpace(token) == "" {
+				return trackerModel{}, fmt.Errorf("missing auth token from %s for profile %q configured in %s; set it in your shell (for example: export %s=<notion-integration-token>)", tokenEnv, profileName, trackerConfigRelPath, tokenEnv)
+			}
+		}
+		model.Notion.Scope.DatabaseID = databaseID
+		model.Notion.Auth.TokenEnv = tokenEnv
+		return model, nil
 	case trackerTypeBeads:
 		// beads_rust auto-discovers the .beads directory, no additional validation needed
 		return model, nil
 	default:
+		if _, ok := registry.Tracker(model.Type); ok {
+			return model, nil
+		}
 		return trackerModel{}, fmt.Errorf("unsupported tracker type %q for profile %q", model.Type, profileName)
 	}
 }
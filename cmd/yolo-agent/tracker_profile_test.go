@@ -9,10 +9,12 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/egv/yolo-runner/v2/internal/azuredevops"
 	"github.com/egv/yolo-runner/v2/internal/contracts"
 	enginepkg "github.com/egv/yolo-runner/v2/internal/engine"
 	githubtracker "github.com/egv/yolo-runner/v2/internal/github"
 	"github.com/egv/yolo-runner/v2/internal/linear"
+	"github.com/egv/yolo-runner/v2/internal/notion"
 )
 
 func TestResolveTrackerProfileDefaultsToTKWhenConfigMissing(t *testing.T) {
@@ -517,6 +519,182 @@ func TestBuildStorageBackendForTrackerSupportsGitHub(t *testing.T) {
 	}
 }
 
+func TestResolveTrackerProfileValidatesAzureDevOps(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: azure_devops
+      azure_devops:
+        scope:
+          organization: contoso
+        auth:
+          token_env: AZURE_DEVOPS_PAT
+`)
+
+	_, err := resolveTrackerProfile(repoRoot, "", "42", func(string) string { return "pat" })
+	if err == nil || !strings.Contains(err.Error(), "azure_devops.scope.project") {
+		t.Fatalf("expected missing project to fail, got %v", err)
+	}
+
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: azure_devops
+      azure_devops:
+        scope:
+          organization: contoso
+          project: Boards
+          area_path: Boards\Web
+        auth:
+          token_env: AZURE_DEVOPS_PAT
+        states:
+          closed: Done
+`)
+	_, err = resolveTrackerProfile(repoRoot, "", "42", func(string) string { return "" })
+	if err == nil || !strings.Contains(err.Error(), "<azure-devops-personal-access-token>") {
+		t.Fatalf("expected missing token to fail, got %v", err)
+	}
+
+	profile, err := resolveTrackerProfile(repoRoot, "", "42", func(string) string { return "pat" })
+	if err != nil {
+		t.Fatalf("expected azure devops profile to resolve, got %v", err)
+	}
+	if profile.Tracker.AzureDevOps.Scope.AreaPath != `Boards\Web` || profile.Tracker.AzureDevOps.States.Closed != "Done" {
+		t.Fatalf("unexpected azure devops settings %#v", profile.Tracker.AzureDevOps)
+	}
+}
+
+func TestBuildStorageBackendForTrackerSupportsAzureDevOps(t *testing.T) {
+	t.Setenv("AZURE_DEVOPS_PAT", "pat-test")
+	originalFactory := newAzureDevOpsStorageBackend
+	t.Cleanup(func() {
+		newAzureDevOpsStorageBackend = originalFactory
+	})
+
+	var got azuredevops.Config
+	newAzureDevOpsStorageBackend = func(cfg azuredevops.Config) (contracts.StorageBackend, error) {
+		got = cfg
+		return staticStorageBackend{}, nil
+	}
+
+	backend, err := buildStorageBackendForTracker(t.TempDir(), resolvedTrackerProfile{
+		Name: "ado",
+		Tracker: trackerModel{
+			Type: trackerTypeAzureDevOps,
+			AzureDevOps: &azureDevOpsTrackerModel{
+				Scope: azureDevOpsScopeModel{
+					Organization:  "contoso",
+					Project:       "Boards",
+					IterationPath: `Boards\Sprint 4`,
+				},
+				Auth:         azureDevOpsAuthModel{TokenEnv: "AZURE_DEVOPS_PAT"},
+				States:       azureDevOpsStatesModel{Blocked: "On Hold"},
+				WorkItemType: "Bug",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected azure devops storage backend to build, got %v", err)
+	}
+	if backend == nil {
+		t.Fatalf("expected non-nil azure devops storage backend")
+	}
+	if got.Organization != "contoso" || got.Project != "Boards" || got.Token != "pat-test" {
+		t.Fatalf("expected scope and token to be wired, got %#v", got)
+	}
+	if got.Scope.IterationPath != `Boards\Sprint 4` || got.States.Blocked != "On Hold" || got.WorkItemType != "Bug" {
+		t.Fatalf("expected iteration, states and work item type to be wired, got %#v", got)
+	}
+}
+
+func TestResolveTrackerProfileValidatesNotion(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: notion
+      notion:
+        auth:
+          token_env: NOTION_TOKEN
+`)
+
+	_, err := resolveTrackerProfile(repoRoot, "", "root", func(string) string { return "token" })
+	if err == nil || !strings.Contains(err.Error(), "notion.scope.database_id") {
+		t.Fatalf("expected missing database to fail, got %v", err)
+	}
+
+	writeTrackerConfigYAML(t, repoRoot, `
+profiles:
+  default:
+    tracker:
+      type: notion
+      notion:
+        scope:
+          database_id: 0123abcd0123456789ab0123456789ab
+        auth:
+          token_env: NOTION_TOKEN
+        properties:
+          parent: Epic
+        states:
+          closed: Shipped
+`)
+	_, err = resolveTrackerProfile(repoRoot, "", "root", func(string) string { return "" })
+	if err == nil || !strings.Contains(err.Error(), "<notion-integration-token>") {
+		t.Fatalf("expected missing token to fail, got %v", err)
+	}
+
+	profile, err := resolveTrackerProfile(repoRoot, "", "root", func(string) string { return "token" })
+	if err != nil {
+		t.Fatalf("expected notion profile to resolve, got %v", err)
+	}
+	if profile.Tracker.Notion.Properties.Parent != "Epic" || profile.Tracker.Notion.States.Closed != "Shipped" {
+		t.Fatalf("unexpected notion settings %#v", profile.Tracker.Notion)
+	}
+}
+
+func TestBuildStorageBackendForTrackerSupportsNotion(t *testing.T) {
+	t.Setenv("NOTION_TOKEN", "secret-test")
+	originalFactory := newNotionStorageBackend
+	t.Cleanup(func() {
+		newNotionStorageBackend = originalFactory
+	})
+
+	var got notion.Config
+	newNotionStorageBackend = func(cfg notion.Config) (contracts.StorageBackend, error) {
+		got = cfg
+		return staticStorageBackend{}, nil
+	}
+
+	backend, err := buildStorageBackendForTracker(t.TempDir(), resolvedTrackerProfile{
+		Name: "notion",
+		Tracker: trackerModel{
+			Type: trackerTypeNotion,
+			Notion: &notionTrackerModel{
+				Scope:      notionScopeModel{DatabaseID: "0123abcd0123456789ab0123456789ab"},
+				Auth:       notionAuthModel{TokenEnv: "NOTION_TOKEN"},
+				Properties: notionPropertiesModel{Status: "Stage", Priority: "Urgency"},
+				States:     notionStatesModel{InProgress: "Building"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected notion storage backend to build, got %v", err)
+	}
+	if backend == nil {
+		t.Fatalf("expected non-nil notion storage backend")
+	}
+	if got.DatabaseID != "0123abcd0123456789ab0123456789ab" || got.Token != "secret-test" {
+		t.Fatalf("expected database and token to be wired, got %#v", got)
+	}
+	if got.Properties.Status != "Stage" || got.Properties.Priority != "Urgency" || got.States.InProgress != "Building" {
+		t.Fatalf("expected property and state mapping to be wired, got %#v", got)
+	}
+}
+
 func TestBuildStorageBackendForTrackerSupportsTK(t *testing.T) {
 	originalFactory := newTKStorageBackend
 	t.Cleanup(func() {
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/daemon"
+)
+
+const (
+	daemonRequestBodyLimit = 64 << 10
+	eventsFollowInterval   = 500 * time.Millisecond
+)
+
+// daemonAPI serves the yolo-agentd HTTP API. Every request is made as the
+// tenant whose token it sends, and only sees that tenant's runs.
+type daemonAPI struct {
+	manager *daemon.Manager
+	tenants []tenant
+	// followInterval is how often a followed events stream polls the file.
+	followInterval time.Duration
+}
+
+type daemonAPIResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func newDaemonAPI(manager *daemon.Manager, tenants []tenant) *daemonAPI {
+	return &daemonAPI{manager: manager, tenants: tenants, followInterval: eventsFollowInterval}
+}
+
+func (a *daemonAPI) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /runs", a.handleSubmit)
+	mux.HandleFunc("GET /runs", a.handleList)
+	mux.HandleFunc("GET /runs/{id}", a.handleGet)
+	mux.HandleFunc("GET /runs/{id}/events", a.handleEvents)
+	mux.HandleFunc("POST /runs/{id}/cancel", a.handleCancel)
+	return mux
+}
+
+// tenantFor returns the tenant whose token the request sends, answering 401
+// when there is none.
+func (a *daemonAPI) tenantFor(w http.ResponseWriter, r *http.Request) (tenant, bool) {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	for _, candidate := range a.tenants {
+		if subtle.ConstantTimeCompare([]byte(header), []byte("Bearer "+candidate.token)) == 1 {
+			return candidate, true
+		}
+	}
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	writeDaemonJSON(w, http.StatusUnauthorized, daemonAPIResponse{Status: "error", Error: "unauthorized"})
+	return tenant{}, false
+}
+
+// ownRun returns the run named in the path when it belongs to tenant. Runs
+// of other tenants are reported as unknown.
+func (a *daemonAPI) ownRun(w http.ResponseWriter, r *http.Request, owner tenant) (daemon.Run, bool) {
+	run, err := a.manager.Get(r.PathValue("id"))
+	if err != nil || run.Tenant != owner.name {
+		writeDaemonJSON(w, http.StatusNotFound, daemonAPIResponse{Status: "error", Error: daemon.ErrRunNotFound.Error()})
+		return daemon.Run{}, false
+	}
+	return run, true
+}
+
+func (a *daemonAPI) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	owner, ok := a.tenantFor(w, r)
+	if !ok {
+		return
+	}
+	request := daemon.RunRequest{}
+	if err := json.NewDecoder(io.LimitReader(r.Body, daemonRequestBodyLimit)).Decode(&request); err != nil {
+		writeDaemonJSON(w, http.StatusBadRequest, daemonAPIResponse{Status: "error", Error: "invalid body: " + err.Error()})
+		return
+	}
+	request.Tenant = owner.name
+	request.Repo = strings.TrimSpace(request.Repo)
+	if !filepath.IsAbs(request.Repo) {
+		writeDaemonJSON(w, http.StatusBadRequest, daemonAPIResponse{Status: "error", Error: "repo must be an absolute path"})
+		return
+	}
+	request.Repo = filepath.Clean(request.Repo)
+	if !owner.allowsRepo(request.Repo) {
+		writeDaemonJSON(w, http.StatusForbidden, daemonAPIResponse{Status: "error", Error: "repo " + request.Repo + " is not allowed for tenant " + owner.name})
+		return
+	}
+	if !owner.allowsProfile(strings.TrimSpace(request.Profile)) {
+		writeDaemonJSON(w, http.StatusForbidden, daemonAPIResponse{Status: "error", Error: "profile " + request.Profile + " is not allowed for tenant " + owner.name})
+		return
+	}
+	run, err := a.manager.Submit(request)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, daemon.ErrQuotaExceeded) {
+			status = http.StatusTooManyRequests
+		}
+		writeDaemonJSON(w, status, daemonAPIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	writeDaemonJSON(w, http.StatusAccepted, run)
+}
+
+func (a *daemonAPI) handleList(w http.ResponseWriter, r *http.Request) {
+	owner, ok := a.tenantFor(w, r)
+	if !ok {
+		return
+	}
+	writeDaemonJSON(w, http.StatusOK, map[string]any{"runs": a.manager.List(owner.name)})
+}
+
+func (a *daemonAPI) handleGet(w http.ResponseWriter, r *http.Request) {
+	owner, ok := a.tenantFor(w, r)
+	if !ok {
+		return
+	}
+	if run, ok := a.ownRun(w, r, owner); ok {
+		writeDaemonJSON(w, http.StatusOK, run)
+	}
+}
+
+func (a *daemonAPI) handleCancel(w http.ResponseWriter, r *http.Request) {
+	owner, ok := a.tenantFor(w, r)
+	if !ok {
+		return
+	}
+	run, ok := a.ownRun(w, r, owner)
+	if !ok {
+		return
+	}
+	run, err := a.manager.Cancel(run.ID)
+	if errors.Is(err, daemon.ErrRunFinished) {
+		writeDaemonJSON(w, http.StatusConflict, daemonAPIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	if err != nil {
+		writeDaemonJSON(w, http.StatusBadRequest, daemonAPIResponse{Status: "error", Error: err.Error()})
+		return
+	}
+	writeDaemonJSON(w, http.StatusAccepted, run)
+}
+
+// handleEvents writes the run's events file as NDJSON. With ?follow=1 it
+// keeps writing new events until the run finishes or the client goes away.
+func (a *daemonAPI) handleEvents(w http.ResponseWriter, r *http.Request) {
+	owner, ok := a.tenantFor(w, r)
+	if !ok {
+		return
+	}
+	run, ok := a.ownRun(w, r, owner)
+	if !ok {
+		return
+	}
+	follow := r.URL.Query().Get("follow") == "1" || r.URL.Query().Get("follow") == "true"
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	var offset int64
+	for {
+		written, err := copyEventsFrom(w, a.manager.EventsPath(run.ID), offset)
+		offset += written
+		if err != nil {
+			return
+		}
+		if flusher != nil && written > 0 {
+			flusher.Flush()
+		}
+		if !follow || run.Status.Finished() {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(a.followInterval):
+		}
+		if run, err = a.manager.Get(run.ID); err != nil {
+			return
+		}
+	}
+}
+
+// copyEventsFrom copies the complete lines of the events file after offset.
+// A missing file has no events yet.
+func copyEventsFrom(w io.Writer, path string, offset int64) (int64, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return 0, err
+	}
+	end := strings.LastIndexByte(string(content), '\n') + 1
+	if end == 0 {
+		return 0, nil
+	}
+	written, err := w.Write(content[:end])
+	return int64(written), err
+}
+
+func writeDaemonJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
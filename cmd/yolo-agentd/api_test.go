@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/daemon"
+)
+
+// newDaemonAPITestServer serves two tenants whose runs write one event and
+// then wait until release is closed or they are canceled.
+func newDaemonAPITestServer(t *testing.T, release chan struct{}) *httptest.Server {
+	t.Helper()
+	tenants := []tenant{
+		{name: "platform", token: "platform-secret", repos: []string{"/srv/api"}, quota: daemon.Quota{MaxQueuedRuns: 1}},
+		{name: "web", token: "web-secret", profiles: []string{"default"}},
+	}
+	manager, err := daemon.NewManager(daemon.ManagerOptions{
+		StateDir:      t.TempDir(),
+		MaxActiveRuns: 1,
+		Quotas:        tenantQuotas(tenants),
+		Execute: func(ctx context.Context, run daemon.Run, dir string) error {
+			event := `{"type":"run_started","metadata":{"root_id":"` + run.Root + `"}}` + "\n"
+			if err := os.WriteFile(filepath.Join(dir, "events.jsonl"), []byte(event), 0o644); err != nil {
+				return err
+			}
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("new manager: %v", err)
+	}
+	manager.Start()
+	api := newDaemonAPI(manager, tenants)
+	api.followInterval = 5 * time.Millisecond
+	server := httptest.NewServer(api.handler())
+	t.Cleanup(func() {
+		server.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = manager.Shutdown(ctx)
+	})
+	return server
+}
+
+func daemonAPIRequest(t *testing.T, server *httptest.Server, token string, method string, path string, body string) (int, string) {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, server.URL+path, reader)
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	return resp.StatusCode, string(content)
+}
+
+func submitDaemonRun(t *testing.T, server *httptest.Server, token string, body string) daemon.Run {
+	t.Helper()
+	status, content := daemonAPIRequest(t, server, token, http.MethodPost, "/runs", body)
+	if status != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", status, content)
+	}
+	run := daemon.Run{}
+	if err := json.Unmarshal([]byte(content), &run); err != nil {
+		t.Fatalf("decode run: %v", err)
+	}
+	return run
+}
+
+func TestDaemonAPIRequiresTenantToken(t *testing.T) {
+	server := newDaemonAPITestServer(t, make(chan struct{}))
+	for _, token := range []string{"", "wrong"} {
+		if status, _ := daemonAPIRequest(t, server, token, http.MethodGet, "/runs", ""); status != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for token %q, got %d", token, status)
+		}
+	}
+}
+
+func TestDaemonAPIEnforcesTenantRestrictions(t *testing.T) {
+	server := newDaemonAPITestServer(t, make(chan struct{}))
+	tests := []struct {
+		token string
+		body  string
+		want  int
+	}{
+		{token: "platform-secret", body: `{"repo":"/srv/web","root":"root-1"}`, want: http.StatusForbidden},
+		{token: "platform-secret", body: `{"repo":"srv/api","root":"root-1"}`, want: http.StatusBadRequest},
+		{token: "web-secret", body: `{"repo":"/srv/web","root":"root-1","profile":"fast"}`, want: http.StatusForbidden},
+		{token: "web-secret", body: `{"repo":"/srv/web","profile":"default"}`, want: http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		if status, content := daemonAPIRequest(t, server, tc.token, http.MethodPost, "/runs", tc.body); status != tc.want {
+			t.Fatalf("expected %d for %s, got %d: %s", tc.want, tc.body, status, content)
+		}
+	}
+
+	submitDaemonRun(t, server, "platform-secret", `{"repo":"/srv/api","root":"root-1"}`)
+	submitDaemonRun(t, server, "platform-secret", `{"repo":"/srv/api","root":"root-2"}`)
+	if status, content := daemonAPIRequest(t, server, "platform-secret", http.MethodPost, "/runs", `{"repo":"/srv/api","root":"root-3"}`); status != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 over the queue quota, got %d: %s", status, content)
+	}
+}
+
+func TestDaemonAPIIsolatesRunsBetweenTenants(t *testing.T) {
+	server := newDaemonAPITestServer(t, make(chan struct{}))
+	run := submitDaemonRun(t, server, "platform-secret", `{"repo":"/srv/api","root":"root-1"}`)
+	if run.Tenant != "platform" || run.Status.Finished() {
+		t.Fatalf("unexpected submitted run %#v", run)
+	}
+
+	for _, path := range []string{"/runs/" + run.ID, "/runs/" + run.ID + "/events"} {
+		if status, _ := daemonAPIRequest(t, server, "web-secret", http.MethodGet, path, ""); status != http.StatusNotFound {
+			t.Fatalf("expected another tenant to get 404 for %s, got %d", path, status)
+		}
+	}
+	if status, _ := daemonAPIRequest(t, server, "web-secret", http.MethodPost, "/runs/"+run.ID+"/cancel", ""); status != http.StatusNotFound {
+		t.Fatalf("expected another tenant not to cancel the run, got %d", status)
+	}
+	if _, content := daemonAPIRequest(t, server, "web-secret", http.MethodGet, "/runs", ""); strings.Contains(content, run.ID) {
+		t.Fatalf("expected another tenant's list to omit the run, got %s", content)
+	}
+	if _, content := daemonAPIRequest(t, server, "platform-secret", http.MethodGet, "/runs", ""); !strings.Contains(content, run.ID) {
+		t.Fatalf("expected the owner's list to include the run, got %s", content)
+	}
+}
+
+func TestDaemonAPIFollowsRunEventsUntilCanceled(t *testing.T) {
+	server := newDaemonAPITestServer(t, make(chan struct{}))
+	run := submitDaemonRun(t, server, "platform-secret", `{"repo":"/srv/api","root":"root-1"}`)
+
+	events := make(chan string, 1)
+	go func() {
+		_, content := daemonAPIRequest(t, server, "platform-secret", http.MethodGet, "/runs/"+run.ID+"/events?follow=1", "")
+		events <- content
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, content := daemonAPIRequest(t, server, "platform-secret", http.MethodGet, "/runs/"+run.ID+"/events", "")
+		if strings.Contains(content, "run_started") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run never wrote its event")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if status, content := daemonAPIRequest(t, server, "platform-secret", http.MethodPost, "/runs/"+run.ID+"/cancel", ""); status != http.StatusAccepted {
+		t.Fatalf("expected cancel to be accepted, got %d: %s", status, content)
+	}
+	select {
+	case content := <-events:
+		if strings.Count(content, "\n") != 1 || !strings.Contains(content, `"root_id":"root-1"`) {
+			t.Fatalf("expected the run's single event, got %q", content)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("followed events stream did not end after cancel")
+	}
+	if status, _ := daemonAPIRequest(t, server, "platform-secret", http.MethodPost, "/runs/"+run.ID+"/cancel", ""); status != http.StatusConflict {
+		t.Fatalf("expected canceling a finished run to conflict, got %d", status)
+	}
+}
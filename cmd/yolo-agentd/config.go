@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/daemon"
+	"github.com/egv/yolo-runner/v2/internal/secrets"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultTenantName = "default"
+
+// daemonConfigModel is the --config file.
+type daemonConfigModel struct {
+	MaxActiveRuns *int                         `yaml:"max_active_runs,omitempty"`
+	Tenants       map[string]daemonTenantModel `yaml:"tenants,omitempty"`
+}
+
+// daemonTenantModel is one tenant: the token that identifies it, the repos
+// and profiles it may run, and its quota.
+type daemonTenantModel struct {
+	TokenEnv       string   `yaml:"token_env,omitempty"`
+	Repos          []string `yaml:"repos,omitempty"`
+	Profiles       []string `yaml:"profiles,omitempty"`
+	MaxActiveRuns  *int     `yaml:"max_active_runs,omitempty"`
+	MaxQueuedRuns  *int     `yaml:"max_queued_runs,omitempty"`
+	MaxConcurrency *int     `yaml:"max_concurrency,omitempty"`
+	MaxDuration    string   `yaml:"max_duration,omitempty"`
+}
+
+// tenant is a resolved daemonTenantModel. Empty repos or profiles allow
+// any.
+type tenant struct {
+	name     string
+	token    string
+	repos    []string
+	profiles []string
+	quota    daemon.Quota
+}
+
+func (t tenant) allowsRepo(repo string) bool {
+	if len(t.repos) == 0 {
+		return true
+	}
+	for _, allowed := range t.repos {
+		if allowed == repo {
+			return true
+		}
+	}
+	return false
+}
+
+func (t tenant) allowsProfile(profile string) bool {
+	if len(t.profiles) == 0 {
+		return true
+	}
+	for _, allowed := range t.profiles {
+		if allowed == profile {
+			return true
+		}
+	}
+	return false
+}
+
+func loadDaemonConfig(path string) (daemonConfigModel, error) {
+	model := daemonConfigModel{}
+	if strings.TrimSpace(path) == "" {
+		return model, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return model, fmt.Errorf("read %s: %w", path, err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&model); err != nil && !errors.Is(err, io.EOF) {
+		return model, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return model, nil
+}
+
+// resolveTenants reads each tenant's token. adminToken, when set, is the
+// token of an unrestricted tenant named "default".
+func resolveTenants(model daemonConfigModel, adminToken string, getenv func(string) string) ([]tenant, error) {
+	names := make([]string, 0, len(model.Tenants))
+	for name := range model.Tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tenants := []tenant{}
+	if adminToken = strings.TrimSpace(adminToken); adminToken != "" {
+		if _, ok := model.Tenants[defaultTenantName]; ok {
+			return nil, fmt.Errorf("tenant %q is reserved for --token", defaultTenantName)
+		}
+		tenants = append(tenants, tenant{name: defaultTenantName, token: adminToken})
+	}
+	resolver := secrets.NewResolver()
+	for _, name := range names {
+		resolved, err := resolveTenant(name, model.Tenants[name], resolver, getenv)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, resolved)
+	}
+	if len(tenants) == 0 {
+		return nil, fmt.Errorf("yolo-agentd requires --token, %s or tenants in --config", daemonTokenEnv)
+	}
+	seen := map[string]string{}
+	for _, resolved := range tenants {
+		if other, ok := seen[resolved.token]; ok {
+			return nil, fmt.Errorf("tenants %q and %q have the same token", other, resolved.name)
+		}
+		seen[resolved.token] = resolved.name
+	}
+	return tenants, nil
+}
+
+func resolveTenant(name string, model daemonTenantModel, resolver *secrets.Resolver, getenv func(string) string) (tenant, error) {
+	resolved := tenant{name: name}
+	tokenEnv := strings.TrimSpace(model.TokenEnv)
+	if tokenEnv == "" {
+		return resolved, fmt.Errorf("tenants.%s.token_env is required", name)
+	}
+	token := ""
+	if secrets.IsReference(tokenEnv) {
+		value, err := resolver.Resolve(context.Background(), tokenEnv)
+		if err != nil {
+			return resolved, fmt.Errorf("tenants.%s.token_env: %w", name, err)
+		}
+		token = value
+	} else {
+		token = getenv(tokenEnv)
+	}
+	if resolved.token = strings.TrimSpace(token); resolved.token == "" {
+		return resolved, fmt.Errorf("tenants.%s.token_env: %s is not set", name, tokenEnv)
+	}
+	for _, repo := range model.Repos {
+		if !filepath.IsAbs(strings.TrimSpace(repo)) {
+			return resolved, fmt.Errorf("tenants.%s.repos must be absolute paths, got %q", name, repo)
+		}
+		resolved.repos = append(resolved.repos, filepath.Clean(strings.TrimSpace(repo)))
+	}
+	for _, profile := range model.Profiles {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			resolved.profiles = append(resolved.profiles, profile)
+		}
+	}
+	limits := []struct {
+		field string
+		value *int
+		set   *int
+	}{
+		{"max_active_runs", model.MaxActiveRuns, &resolved.quota.MaxActiveRuns},
+		{"max_queued_runs", model.MaxQueuedRuns, &resolved.quota.MaxQueuedRuns},
+		{"max_concurrency", model.MaxConcurrency, &resolved.quota.MaxConcurrency},
+	}
+	for _, limit := range limits {
+		if limit.value == nil {
+			continue
+		}
+		if *limit.value < 0 {
+			return resolved, fmt.Errorf("tenants.%s.%s must be greater than or equal to 0", name, limit.field)
+		}
+		*limit.set = *limit.value
+	}
+	if raw := strings.TrimSpace(model.MaxDuration); raw != "" {
+		duration, err := time.ParseDuration(raw)
+		if err != nil || duration < 0 {
+			return resolved, fmt.Errorf("tenants.%s.max_duration must be a duration greater than or equal to 0, got %q", name, raw)
+		}
+		resolved.quota.MaxDuration = duration
+	}
+	return resolved, nil
+}
+
+func tenantQuotas(tenants []tenant) map[string]daemon.Quota {
+	quotas := make(map[string]daemon.Quota, len(tenants))
+	for _, resolved := range tenants {
+		quotas[resolved.name] = resolved.quota
+	}
+	return quotas
+}
+
+// defaultStateDir is $XDG_STATE_HOME/yolo-agentd, falling back to
+// ~/.local/state/yolo-agentd.
+func defaultStateDir(getenv func(string) string) string {
+	if base := strings.TrimSpace(getenv("XDG_STATE_HOME")); filepath.IsAbs(base) {
+		return filepath.Join(base, "yolo-agentd")
+	}
+	if home := strings.TrimSpace(getenv("HOME")); home != "" {
+		return filepath.Join(home, ".local", "state", "yolo-agentd")
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveTenantsReadsTokensAndQuotas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agentd.yaml")
+	config := `max_active_runs: 4
+tenants:
+  platform:
+    token_env: PLATFORM_TOKEN
+    repos: [/srv/api, /srv/web/]
+    profiles: [default]
+    max_active_runs: 2
+    max_queued_runs: 10
+    max_concurrency: 3
+    max_duration: 2h
+`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	model, err := loadDaemonConfig(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	env := map[string]string{"PLATFORM_TOKEN": "platform-secret"}
+	tenants, err := resolveTenants(model, "admin-secret", func(name string) string { return env[name] })
+	if err != nil {
+		t.Fatalf("resolve tenants: %v", err)
+	}
+	if len(tenants) != 2 || tenants[0].name != defaultTenantName || tenants[0].token != "admin-secret" {
+		t.Fatalf("expected the --token tenant first, got %#v", tenants)
+	}
+	platform := tenants[1]
+	if platform.token != "platform-secret" || !platform.allowsRepo("/srv/web") || platform.allowsRepo("/srv/other") {
+		t.Fatalf("unexpected platform tenant %#v", platform)
+	}
+	if !platform.allowsProfile("default") || platform.allowsProfile("fast") {
+		t.Fatalf("expected only the default profile to be allowed, got %v", platform.profiles)
+	}
+	if platform.quota.MaxActiveRuns != 2 || platform.quota.MaxQueuedRuns != 10 || platform.quota.MaxConcurrency != 3 || platform.quota.MaxDuration != 2*time.Hour {
+		t.Fatalf("unexpected quota %#v", platform.quota)
+	}
+	if !tenants[0].allowsRepo("/anything") || !tenants[0].allowsProfile("fast") {
+		t.Fatalf("expected the default tenant to be unrestricted")
+	}
+}
+
+func TestResolveTenantsRejectsInvalidConfig(t *testing.T) {
+	negative := -1
+	tests := []struct {
+		name    string
+		model   daemonConfigModel
+		admin   string
+		wantErr string
+	}{
+		{name: "no tenants", wantErr: "requires --token"},
+		{name: "unset token", model: daemonConfigModel{Tenants: map[string]daemonTenantModel{"web": {TokenEnv: "MISSING"}}}, wantErr: "MISSING is not set"},
+		{name: "reserved name", admin: "admin", model: daemonConfigModel{Tenants: map[string]daemonTenantModel{"default": {TokenEnv: "WEB_TOKEN"}}}, wantErr: "reserved"},
+		{name: "shared token", admin: "web-secret", model: daemonConfigModel{Tenants: map[string]daemonTenantModel{"web": {TokenEnv: "WEB_TOKEN"}}}, wantErr: "same token"},
+		{name: "relative repo", model: daemonConfigModel{Tenants: map[string]daemonTenantModel{"web": {TokenEnv: "WEB_TOKEN", Repos: []string{"web"}}}}, wantErr: "absolute"},
+		{name: "negative quota", model: daemonConfigModel{Tenants: map[string]daemonTenantModel{"web": {TokenEnv: "WEB_TOKEN", MaxQueuedRuns: &negative}}}, wantErr: "tenants.web.max_queued_runs"},
+		{name: "bad duration", model: daemonConfigModel{Tenants: map[string]daemonTenantModel{"web": {TokenEnv: "WEB_TOKEN", MaxDuration: "soon"}}}, wantErr: "tenants.web.max_duration"},
+	}
+	getenv := func(name string) string {
+		if name == "WEB_TOKEN" {
+			return "web-secret"
+		}
+		return ""
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := resolveTenants(tc.model, tc.admin, getenv)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadDaemonConfigRejectsUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agentd.yaml")
+	if err := os.WriteFile(path, []byte("tenants:\n  web:\n    token: inline\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if _, err := loadDaemonConfig(path); err == nil || !strings.Contains(err.Error(), "field token not found") {
+		t.Fatalf("expected an unknown field error, got %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/daemon"
+	"github.com/egv/yolo-runner/v2/internal/version"
+)
+
+const (
+	defaultListenAddr     = "127.0.0.1:7440"
+	daemonTokenEnv        = "YOLO_AGENTD_TOKEN"
+	defaultMaxActiveRuns  = 2
+	runStopGrace          = 30 * time.Second
+	daemonShutdownTimeout = time.Minute
+)
+
+type runConfig struct {
+	listenAddr    string
+	stateDir      string
+	maxActiveRuns int
+	agentBinary   string
+	agentArgs     []string
+	tenants       []tenant
+}
+
+func main() {
+	os.Exit(RunMain(os.Args[1:], nil))
+}
+
+// RunMain parses the daemon flags and calls run, or serveDaemon when run is
+// nil. Flags after "--" are passed to every yolo-agent run.
+func RunMain(args []string, run func(context.Context, runConfig) error) int {
+	if version.IsVersionRequest(args) {
+		version.Print(os.Stdout, "yolo-agentd")
+		return 0
+	}
+
+	fs := flag.NewFlagSet("yolo-agentd", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	listen := fs.String("listen", defaultListenAddr, "HTTP listen address for the run API")
+	configPath := fs.String("config", "", "Daemon config file with tenants and quotas")
+	stateDir := fs.String("state-dir", "", "Directory for run records, events and logs (default: $XDG_STATE_HOME/yolo-agentd)")
+	maxActiveRuns := fs.Int("max-active-runs", 0, "Maximum runs executing at once across tenants (default: config max_active_runs or 2)")
+	agentBinary := fs.String("agent-binary", "", "yolo-agent executable (default: next to yolo-agentd, then $PATH)")
+	token := fs.String("token", "", "Token of the unrestricted default tenant (default: $"+daemonTokenEnv+")")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+
+	model, err := loadDaemonConfig(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	adminToken := strings.TrimSpace(*token)
+	if adminToken == "" {
+		adminToken = strings.TrimSpace(os.Getenv(daemonTokenEnv))
+	}
+	tenants, err := resolveTenants(model, adminToken, os.Getenv)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	cfg := runConfig{
+		listenAddr:    strings.TrimSpace(*listen),
+		stateDir:      strings.TrimSpace(*stateDir),
+		maxActiveRuns: defaultMaxActiveRuns,
+		agentBinary:   strings.TrimSpace(*agentBinary),
+		agentArgs:     fs.Args(),
+		tenants:       tenants,
+	}
+	if model.MaxActiveRuns != nil {
+		cfg.maxActiveRuns = *model.MaxActiveRuns
+	}
+	if *maxActiveRuns > 0 {
+		cfg.maxActiveRuns = *maxActiveRuns
+	}
+	if cfg.maxActiveRuns <= 0 {
+		fmt.Fprintln(os.Stderr, "max_active_runs must be greater than 0")
+		return 1
+	}
+	if cfg.stateDir == "" {
+		cfg.stateDir = defaultStateDir(os.Getenv)
+	}
+	if cfg.stateDir == "" {
+		fmt.Fprintln(os.Stderr, "cannot determine a state dir; pass --state-dir")
+		return 1
+	}
+	if cfg.agentBinary == "" {
+		cfg.agentBinary, err = findAgentBinary()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	if run == nil {
+		run = serveDaemon
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// findAgentBinary prefers the yolo-agent installed next to yolo-agentd.
+func findAgentBinary() (string, error) {
+	if executable, err := os.Executable(); err == nil {
+		candidate := filepath.Join(filepath.Dir(executable), "yolo-agent")
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	path, err := exec.LookPath("yolo-agent")
+	if err != nil {
+		return "", errors.New("cannot find yolo-agent; pass --agent-binary")
+	}
+	return path, nil
+}
+
+func serveDaemon(ctx context.Context, cfg runConfig) error {
+	manager, err := daemon.NewManager(daemon.ManagerOptions{
+		StateDir:      cfg.stateDir,
+		MaxActiveRuns: cfg.maxActiveRuns,
+		Quotas:        tenantQuotas(cfg.tenants),
+		Execute:       agentExecutor(cfg.agentBinary, cfg.agentArgs),
+	})
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", cfg.listenAddr)
+	if err != nil {
+		return fmt.Errorf("start yolo-agentd API: %w", err)
+	}
+	manager.Start()
+	server := &http.Server{Handler: newDaemonAPI(manager, cfg.tenants).handler(), ReadHeaderTimeout: 10 * time.Second}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(listener)
+	}()
+	fmt.Fprintf(os.Stderr, "yolo-agentd listening on http://%s (state: %s)\n", listener.Addr(), cfg.stateDir)
+
+	var serveErr error
+	select {
+	case serveErr = <-errs:
+		serveErr = fmt.Errorf("serve yolo-agentd API: %w", serveErr)
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), daemonShutdownTimeout)
+	defer cancel()
+	_ = server.Shutdown(shutdownCtx)
+	if err := manager.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("stop runs: %w", err)
+	}
+	return serveErr
+}
+
+// agentExecutor runs each run as a yolo-agent child process that writes its
+// events to the run's own file and its output to the run's log. Stopping a
+// run interrupts the process and kills it after runStopGrace.
+func agentExecutor(binary string, extraArgs []string) daemon.Executor {
+	return func(ctx context.Context, run daemon.Run, dir string) error {
+		logFile, err := os.OpenFile(filepath.Join(dir, "output.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("open run log: %w", err)
+		}
+		defer logFile.Close()
+		cmd := exec.CommandContext(ctx, binary, agentArgs(run, dir, extraArgs)...)
+		cmd.Dir = run.Repo
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+		cmd.Cancel = func() error {
+			return cmd.Process.Signal(os.Interrupt)
+		}
+		cmd.WaitDelay = runStopGrace
+		fmt.Fprintf(logFile, "yolo-agentd: starting %s %s\n", binary, strings.Join(cmd.Args[1:], " "))
+		return cmd.Run()
+	}
+}
+
+func agentArgs(run daemon.Run, dir string, extraArgs []string) []string {
+	args := []string{"--repo", run.Repo, "--root", run.Root, "--events", filepath.Join(dir, "events.jsonl")}
+	if run.Profile != "" {
+		args = append(args, "--profile", run.Profile)
+	}
+	if run.Concurrency > 0 {
+		args = append(args, "--concurrency", strconv.Itoa(run.Concurrency))
+	}
+	return append(args, extraArgs...)
+}
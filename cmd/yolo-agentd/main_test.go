@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/daemon"
+)
+
+func TestRunMainBuildsRunConfig(t *testing.T) {
+	t.Setenv(daemonTokenEnv, "env-secret")
+	stateDir := t.TempDir()
+	var got runConfig
+	code := RunMain([]string{"--state-dir", stateDir, "--max-active-runs", "3", "--agent-binary", "/opt/yolo-agent", "--", "--stream"}, func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	})
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d", code)
+	}
+	if got.listenAddr != defaultListenAddr || got.stateDir != stateDir || got.maxActiveRuns != 3 || got.agentBinary != "/opt/yolo-agent" {
+		t.Fatalf("unexpected run config %#v", got)
+	}
+	if !reflect.DeepEqual(got.agentArgs, []string{"--stream"}) {
+		t.Fatalf("expected args after -- to reach yolo-agent, got %v", got.agentArgs)
+	}
+	if len(got.tenants) != 1 || got.tenants[0].token != "env-secret" {
+		t.Fatalf("expected the env token tenant, got %#v", got.tenants)
+	}
+}
+
+func TestRunMainRequiresATenant(t *testing.T) {
+	t.Setenv(daemonTokenEnv, "")
+	called := false
+	code := RunMain([]string{"--state-dir", t.TempDir(), "--agent-binary", "/opt/yolo-agent"}, func(context.Context, runConfig) error {
+		called = true
+		return nil
+	})
+	if code != 1 || called {
+		t.Fatalf("expected exit 1 without a token, got %d (called=%v)", code, called)
+	}
+}
+
+func TestAgentArgsIsolateRunEvents(t *testing.T) {
+	run := daemon.Run{RunRequest: daemon.RunRequest{Repo: "/srv/api", Root: "root-1", Profile: "fast", Concurrency: 2}}
+	dir := filepath.Join("/state", "runs", "run-1")
+	got := agentArgs(run, dir, []string{"--stream"})
+	want := []string{"--repo", "/srv/api", "--root", "root-1", "--events", filepath.Join(dir, "events.jsonl"), "--profile", "fast", "--concurrency", "2", "--stream"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected args %v", got)
+	}
+}
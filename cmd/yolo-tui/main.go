@@ -56,25 +56,67 @@ func RunMain(args []string, in io.Reader, out io.Writer, errOut io.Writer) int {
 	fs.SetOutput(errOut)
 	repoRoot := fs.String("repo", ".", "Repository root")
 	eventsStdin := fs.Bool("events-stdin", false, "Read NDJSON events from stdin")
+	eventsFile := fs.String("events-file", "", "Replay an events log, including its rotated and gzipped segments")
 	eventsBus := fs.Bool("events-bus", false, "Read monitor events from distributed bus")
 	busBackend := fs.String("events-bus-backend", "", "Distributed bus backend (redis, nats)")
 	busAddress := fs.String("events-bus-address", "", "Distributed bus address")
 	busPrefix := fs.String("events-bus-prefix", "", "Distributed bus subject prefix")
 	busSource := fs.String("events-bus-source", "", "Monitor source filter")
 	demoState := fs.Bool("demo-state", false, "Render seeded demo state and stay open")
+	renderFinal := fs.Bool("render-final", false, "Consume the whole event stream and print a plain-text run summary")
+	snapshotInterval := fs.Duration("snapshot-interval", 0, "Write the aggregated monitor state as JSON this often (0 disables)")
+	snapshotFile := fs.String("snapshot-file", "", "State snapshot path (default: runner-logs/monitor-snapshot.json under --repo)")
+	themeName := fs.String("theme", "", "Color theme: dark, light or high-contrast (default: tui.theme from config, else dark)")
+	notify := fs.String("notify", "", "Desktop notifications: comma-separated run_finished, task_blocked, approval_requested, or all/none (default: tui.notifications from config, else none)")
+	colorProfile := fs.String("color-profile", "", "Color depth: auto, truecolor, 256, 16 or none (default: tui.color_profile from config, else auto; NO_COLOR selects none)")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
 
-	if *eventsBus && *eventsStdin {
-		fmt.Fprintln(errOut, "set exactly one event input mode: --events-stdin or --events-bus")
-		return 1
-	}
 	setFlags := map[string]struct{}{}
 	fs.Visit(func(f *flag.Flag) {
 		setFlags[f.Name] = struct{}{}
 	})
-	if _, eventsStdinSet := setFlags["events-stdin"]; !eventsStdinSet && !*eventsBus {
+	_, eventsStdinSet := setFlags["events-stdin"]
+	if (*eventsBus && *eventsStdin) || (strings.TrimSpace(*eventsFile) != "" && (*eventsBus || eventsStdinSet)) {
+		fmt.Fprintln(errOut, "set exactly one event input mode: --events-stdin, --events-file or --events-bus")
+		return 1
+	}
+	if *renderFinal && (*eventsBus || *demoState) {
+		fmt.Fprintln(errOut, "--render-final reads a finished event stream; use --events-stdin or --events-file")
+		return 1
+	}
+	snapshots, err := resolveSnapshotOptions(*repoRoot, *snapshotFile, *snapshotInterval)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+	notifications, err := resolveTUINotifications(*repoRoot, *notify)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+	taps := streamTaps{snapshots: snapshots, notifications: notifications}
+	if notifications.enabled() {
+		taps.notify = defaultDesktopNotifier(errOut)
+	}
+	display, err := resolveTUIDisplay(*repoRoot, *themeName, *colorProfile, os.Getenv)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+	display.apply()
+	if path := strings.TrimSpace(*eventsFile); path != "" {
+		log, err := contracts.OpenEventLog(path)
+		if err != nil {
+			fmt.Fprintln(errOut, err)
+			return 1
+		}
+		defer log.Close()
+		in = log
+		*eventsStdin = true
+	}
+	if !eventsStdinSet && !*eventsBus {
 		*eventsStdin = true
 	}
 	if _, eventsBusSet := setFlags["events-bus"]; !eventsBusSet && !*eventsStdin {
@@ -84,7 +126,7 @@ func RunMain(args []string, in io.Reader, out io.Writer, errOut io.Writer) int {
 
 	if *demoState {
 		if shouldUseFullscreen(out) {
-			if err := runFullscreenDemo(out, errOut); err != nil {
+			if err := runFullscreenDemo(display.theme, out, errOut); err != nil {
 				fmt.Fprintln(errOut, err)
 				return 1
 			}
@@ -108,15 +150,23 @@ func RunMain(args []string, in io.Reader, out io.Writer, errOut io.Writer) int {
 		}
 	}
 
+	if *renderFinal {
+		if err := renderFinalFromReader(in, taps, out, errOut); err != nil {
+			fmt.Fprintln(errOut, err)
+			return 1
+		}
+		return 0
+	}
+
 	if !*eventsBus {
 		if shouldUseFullscreen(out) {
-			if err := runFullscreenFromReader(in, out, errOut); err != nil {
+			if err := runFullscreenFromReader(in, taps, display.theme, out, errOut); err != nil {
 				fmt.Fprintln(errOut, err)
 				return 1
 			}
 			return 0
 		}
-		if err := renderFromReader(in, out, errOut); err != nil {
+		if err := renderFromReader(in, taps, out, errOut); err != nil {
 			fmt.Fprintln(errOut, err)
 			return 1
 		}
@@ -143,6 +193,8 @@ func RunMain(args []string, in io.Reader, out io.Writer, errOut io.Writer) int {
 			selectedBusConfig.Prefix,
 			selectedBusConfig.Source,
 			selectedBusConfig.BackendOptions(),
+			taps,
+			display.theme,
 			out,
 			errOut,
 		); err != nil {
@@ -158,6 +210,7 @@ func RunMain(args []string, in io.Reader, out io.Writer, errOut io.Writer) int {
 		selectedBusConfig.Prefix,
 		selectedBusConfig.Source,
 		selectedBusConfig.BackendOptions(),
+		taps,
 		out,
 		errOut,
 	); err != nil {
@@ -273,12 +326,21 @@ type fullscreenModel struct {
 	activityCollapsed bool
 	statusLine        string
 	keyHint           string
+	search            tuiSearch
+	theme             tuiTheme
 }
 
 type displayLine struct {
 	text     string
 	tone     string
 	selected bool
+	match    bool
+}
+
+type tuiPane struct {
+	title string
+	lines []displayLine
+	bg    lipgloss.TerminalColor
 }
 
 func newFullscreenModel(stream <-chan streamMsg, seed []contracts.Event, holdOpen bool) fullscreenModel {
@@ -297,7 +359,8 @@ func newFullscreenModel(stream <-chan streamMsg, seed []contracts.Event, holdOpe
 		detailsCollapsed:  true,
 		historyCollapsed:  true,
 		activityCollapsed: false,
-		keyHint:           "🧭 jk/↑↓ move  h/l collapse  enter/space toggle  f queue filter  d details  a activity  H history  q quit",
+		theme:             darkTUITheme(),
+		keyHint:           "🧭 jk/↑↓ move  h/l collapse  enter/space toggle  f queue filter  d details  a activity  H history  / search  q quit",
 	}
 	model.resizeViewport()
 	model.viewport.SetContent(model.renderBody())
@@ -345,6 +408,29 @@ func (m fullscreenModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		rawKey := typed.String()
 		normalizedKey := strings.ToLower(strings.TrimSpace(rawKey))
+		if m.search.editing && rawKey != "ctrl+c" {
+			if m.search.handleEditKey(typed) {
+				m.resizeViewport()
+				m.viewport.SetContent(m.renderBody())
+				m.scrollToSearchMatch(0)
+			}
+			return m, nil
+		}
+		switch rawKey {
+		case "/":
+			m.search.editing = true
+			m.resizeViewport()
+			return m, nil
+		case "n", "N":
+			if m.search.active() {
+				delta := 1
+				if rawKey == "N" {
+					delta = -1
+				}
+				m.scrollToSearchMatch(delta)
+				return m, nil
+			}
+		}
 		switch rawKey {
 		case "ctrl+c", "q", "Q", "ctrl+q":
 			if m.streamDone {
@@ -354,6 +440,12 @@ func (m fullscreenModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewport.SetContent(m.renderBody())
 			return m, nil
 		case "esc", "escape":
+			if m.search.active() {
+				m.search.clear()
+				m.resizeViewport()
+				m.viewport.SetContent(m.renderBody())
+				return m, nil
+			}
 			return m, tea.Quit
 		case "pgup", "pageup":
 			m.viewport.HalfViewUp()
@@ -406,6 +498,9 @@ func (m *fullscreenModel) resizeViewport() {
 	if m.streamDone {
 		footer++
 	}
+	if m.search.editing || m.search.active() {
+		footer++
+	}
 	vh := m.height - footer
 	if vh < 1 {
 		vh = 1
@@ -422,48 +517,102 @@ func (m *fullscreenModel) renderBody() string {
 		width = 80
 	}
 
-	top := renderTop(width, state)
-	panes := []string{renderPane(width, "🌲 Panels", stylePanelLines(state.PanelLines, width-4), lipgloss.Color("17"))}
+	top := renderTop(width, state, m.theme)
+	panelLines := m.search.filterDisplayLines(stylePanelLines(state.PanelLines, width-4))
+	panes := []tuiPane{{title: "🌲 Panels", lines: orNoSearchMatches(panelLines), bg: m.theme.panelsBg}}
 
 	if m.detailsCollapsed {
-		panes = append(panes, renderCollapsedPane(width, "📦 Details", "press d to expand", lipgloss.Color("18")))
+		panes = append(panes, collapsedPane("📦 Details", "press d to expand", m.theme.detailsBg))
 	} else {
 		details := []string{"phase=" + state.Phase, "last_output=" + state.LastOutputAge}
 		details = append(details, state.Performance...)
 		details = append(details, state.RunParams...)
 		details = append(details, "", "task_details:")
 		details = append(details, state.TaskDetails...)
-		panes = append(panes, renderPane(width, "📦 Details", stylePlainLines(details, width-4), lipgloss.Color("18")))
+		panes = append(panes, tuiPane{title: "📦 Details", lines: stylePlainLines(details, width-4), bg: m.theme.detailsBg})
 	}
 
 	queueTitle := fmt.Sprintf("🗂 Queue (priority, %s)", state.QueueFilter)
-	panes = append(panes, renderPane(width, queueTitle, stylePlainLines(state.Queue, width-4), lipgloss.Color("20")))
-	panes = append(panes, renderPane(width, "🌳 Task Graph", stylePlainLines(state.TaskGraph, width-4), lipgloss.Color("21")))
-	panes = append(panes, renderPane(width, "🧰 Executor Dashboard", stylePlainLines(state.ExecutorDashboard, width-4), lipgloss.Color("22")))
-	workerPane := renderPane(width, "👷 Workers", styleWorkerLines(state.WorkerSummaries, width-4), lipgloss.Color("19"))
-	panes = append(panes, workerPane)
+	panes = append(panes, tuiPane{title: queueTitle, lines: stylePlainLines(state.Queue, width-4), bg: m.theme.queueBg})
+	panes = append(panes, tuiPane{title: "🌳 Task Graph", lines: stylePlainLines(state.TaskGraph, width-4), bg: m.theme.graphBg})
+	panes = append(panes, tuiPane{title: "🧰 Executor Dashboard", lines: stylePlainLines(state.ExecutorDashboard, width-4), bg: m.theme.dashboardBg})
+	workers := m.search.filterWorkers(state.WorkerSummaries)
+	workerLines := styleWorkerLines(workers, width-4)
+	if m.search.active() && len(workers) == 0 {
+		workerLines = orNoSearchMatches(nil)
+	}
+	panes = append(panes, tuiPane{title: "👷 Workers", lines: workerLines, bg: m.theme.workersBg})
 
 	if m.activityCollapsed {
-		panes = append(panes, renderCollapsedPane(width, "🧪 Activity", "press a to expand", lipgloss.Color("20")))
+		panes = append(panes, collapsedPane("🧪 Activity", "press a to expand", m.theme.activityBg))
 	} else {
 		focused := focusedWorkerSummary(state)
 		activity := styleActivityLines(focused, width-4)
-		panes = append(panes, renderPane(width, "🧪 Activity", activity, lipgloss.Color("20")))
+		panes = append(panes, tuiPane{title: "🧪 Activity", lines: activity, bg: m.theme.activityBg})
 	}
 
 	showHistory := !m.historyCollapsed && m.height >= 24
 	if showHistory {
-		panes = append(panes, renderPane(width, "🕘 History", stylePlainLines(tailLines(state.History, 16), width-4), lipgloss.Color("235")))
+		// Filter before the tail so a search reaches past the last 16 entries.
+		history := tailLines(m.search.filterStrings(state.History), 16)
+		historyLines := stylePlainLines(history, width-4)
+		if m.search.active() && len(history) == 0 {
+			historyLines = orNoSearchMatches(nil)
+		}
+		panes = append(panes, tuiPane{title: "🕘 History", lines: historyLines, bg: m.theme.historyBg})
 	} else {
-		panes = append(panes, renderCollapsedPane(width, "🕘 History", "press H to expand", lipgloss.Color("235")))
+		panes = append(panes, collapsedPane("🕘 History", "press H to expand", m.theme.historyBg))
 	}
 
-	return lipgloss.JoinVertical(lipgloss.Left, top, renderPaneStack(width, panes))
+	// Each pane renders a title row plus one row per line, and panes are
+	// separated by a single row, so match rows can be counted up front.
+	row := lipgloss.Height(top)
+	matchRows := []int{}
+	rendered := make([]string, 0, len(panes))
+	for i, pane := range panes {
+		if i > 0 {
+			row++
+		}
+		row++
+		for j := range pane.lines {
+			if m.search.matches(pane.lines[j].text) {
+				pane.lines[j].match = true
+				matchRows = append(matchRows, row+j)
+			}
+		}
+		row += len(pane.lines)
+		rendered = append(rendered, renderPane(width, pane.title, pane.lines, pane.bg, m.theme))
+	}
+	m.search.setMatchRows(matchRows)
+
+	return lipgloss.JoinVertical(lipgloss.Left, top, renderPaneStack(width, rendered, m.theme))
 }
 
-func renderTop(width int, state monitor.UIState) string {
+func collapsedPane(title string, hint string, bg lipgloss.TerminalColor) tuiPane {
+	return tuiPane{title: title, lines: []displayLine{{text: hint, tone: "muted"}}, bg: bg}
+}
+
+func orNoSearchMatches(lines []displayLine) []displayLine {
+	if len(lines) == 0 {
+		return []displayLine{{text: "no matches", tone: "muted"}}
+	}
+	return lines
+}
+
+// scrollToSearchMatch moves delta matches from the current one (0 re-shows
+// the current match) and scrolls it to the top of the viewport.
+func (m *fullscreenModel) scrollToSearchMatch(delta int) {
+	if row, ok := m.search.step(delta); ok {
+		m.viewport.SetYOffset(row)
+	}
+}
+
+func renderTop(width int, state monitor.UIState, theme tuiTheme) string {
 	header := fmt.Sprintf("🚀 %s   🎯 %s   ⏳ %s   %d / %d tasks", state.CurrentTask, state.Phase, state.LastOutputAge, state.CompletedCount, state.TotalCount)
-	style := lipgloss.NewStyle().Width(width).Padding(0, 1).Background(lipgloss.Color("24")).Foreground(lipgloss.Color("230")).Bold(true)
+	if state.ETA != "" {
+		header += "   🏁 ETA " + state.ETA
+	}
+	style := lipgloss.NewStyle().Width(width).Padding(0, 1).Background(theme.headerBg).Foreground(theme.headerFg).Bold(true)
 	return style.Render(truncateDisplayWidth(header, width-2))
 }
 
@@ -544,6 +693,16 @@ func styleActivityLines(worker monitor.UIWorkerSummary, width int) []displayLine
 	for _, item := range worker.RecentTaskEvents {
 		lines = append(lines, displayLine{text: truncateLine(item, width), tone: toneForEvent(item)})
 	}
+	if len(worker.PlanChecklist) > 0 {
+		lines = append(lines, displayLine{text: "📋 plan", tone: "normal"})
+		for _, item := range worker.PlanChecklist {
+			tone := "muted"
+			if strings.HasPrefix(item, "[~]") {
+				tone = "normal"
+			}
+			lines = append(lines, displayLine{text: truncateLine("  "+item, width), tone: tone})
+		}
+	}
 	return lines
 }
 
@@ -604,7 +763,7 @@ func stylePlainLines(lines []string, width int) []displayLine {
 	return out
 }
 
-func renderPane(width int, title string, lines []displayLine, bg lipgloss.Color) string {
+func renderPane(width int, title string, lines []displayLine, bg lipgloss.TerminalColor, theme tuiTheme) string {
 	if width <= 0 {
 		width = 80
 	}
@@ -613,35 +772,34 @@ func renderPane(width int, title string, lines []displayLine, bg lipgloss.Color)
 		inner = 1
 	}
 	pad := lipgloss.NewStyle().Width(width).Background(bg)
-	head := lipgloss.NewStyle().Width(inner).Background(bg).Foreground(lipgloss.Color("153")).Bold(true)
+	head := lipgloss.NewStyle().Width(inner).Background(bg).Foreground(theme.paneTitle).Bold(true)
 	body := []string{pad.Render(" " + head.Render(title) + " ")}
 	for _, line := range lines {
-		style := lipgloss.NewStyle().Width(inner).Background(bg).Foreground(lipgloss.Color("252"))
+		style := lipgloss.NewStyle().Width(inner).Background(bg).Foreground(theme.text)
 		switch line.tone {
 		case "warning":
-			style = style.Foreground(lipgloss.Color("220"))
+			style = style.Foreground(theme.warning)
 		case "error":
-			style = style.Foreground(lipgloss.Color("203"))
+			style = style.Foreground(theme.err)
 		case "muted":
-			style = style.Foreground(lipgloss.Color("246"))
+			style = style.Foreground(theme.muted)
+		}
+		if line.match {
+			style = style.Background(theme.matchBg).Foreground(theme.matchFg).Underline(theme.monochrome)
 		}
 		if line.selected {
-			style = style.Background(lipgloss.Color("63")).Foreground(lipgloss.Color("230")).Bold(true)
+			style = style.Background(theme.selectedBg).Foreground(theme.selectedFg).Bold(true).Reverse(theme.monochrome)
 		}
 		body = append(body, pad.Render(" "+style.Render(line.text)+" "))
 	}
 	return strings.Join(body, "\n")
 }
 
-func renderCollapsedPane(width int, title string, hint string, bg lipgloss.Color) string {
-	return renderPane(width, title, []displayLine{{text: hint, tone: "muted"}}, bg)
-}
-
-func renderPaneStack(width int, panes []string) string {
+func renderPaneStack(width int, panes []string, theme tuiTheme) string {
 	if len(panes) == 0 {
 		return ""
 	}
-	sep := lipgloss.NewStyle().Width(width).Background(lipgloss.Color("236")).Foreground(lipgloss.Color("60")).Render(strings.Repeat("─", maxInt(1, width)))
+	sep := lipgloss.NewStyle().Width(width).Background(theme.separatorBg).Foreground(theme.separatorFg).Render(strings.Repeat("─", maxInt(1, width)))
 	parts := make([]string, 0, len(panes)*2)
 	for i, pane := range panes {
 		if i > 0 {
@@ -707,24 +865,28 @@ func (m fullscreenModel) View() string {
 	if width <= 0 {
 		width = 80
 	}
-	foot := lipgloss.NewStyle().Width(width).Foreground(lipgloss.Color("230")).Background(lipgloss.Color("58"))
+	foot := lipgloss.NewStyle().Width(width).Foreground(m.theme.footerFg).Background(m.theme.footerBg)
 	if strings.Contains(strings.ToLower(m.statusLine), "❌") {
-		foot = foot.Background(lipgloss.Color("52"))
+		foot = foot.Background(m.theme.footerAlertBg)
 	}
 	footer := []string{
 		foot.Render(truncateLine(m.statusLine, width)),
 		foot.Render(truncateLine(m.keyHint, width)),
 	}
 	if m.stopping {
-		stop := lipgloss.NewStyle().Width(width).Foreground(lipgloss.Color("230")).Background(lipgloss.Color("52")).Bold(true)
+		stop := lipgloss.NewStyle().Width(width).Foreground(m.theme.footerFg).Background(m.theme.footerAlertBg).Bold(true)
 		footer = append(footer, stop.Render("Stopping..."))
 	}
 	if m.errorLine != "" {
-		warn := lipgloss.NewStyle().Width(width).Foreground(lipgloss.Color("230")).Background(lipgloss.Color("94"))
+		warn := lipgloss.NewStyle().Width(width).Foreground(m.theme.footerFg).Background(m.theme.footerWarnBg)
 		footer = append(footer, warn.Render(truncateLine("⚠ decode: "+m.errorLine, width)))
 	}
+	if m.search.editing || m.search.active() {
+		search := lipgloss.NewStyle().Width(width).Foreground(m.theme.matchFg).Background(m.theme.matchBg)
+		footer = append(footer, search.Render(truncateLine(m.search.footer(), width)))
+	}
 	if m.streamDone {
-		done := lipgloss.NewStyle().Width(width).Foreground(lipgloss.Color("254")).Background(lipgloss.Color("24"))
+		done := lipgloss.NewStyle().Width(width).Foreground(m.theme.footerDoneFg).Background(m.theme.footerDoneBg)
 		footer = append(footer, done.Render("🧾 stream ended"))
 	}
 	return lipgloss.JoinVertical(lipgloss.Left, m.viewport.View(), strings.Join(footer, "\n"))
@@ -739,24 +901,26 @@ func isTerminalEvent(eventType contracts.EventType) bool {
 	}
 }
 
-func runFullscreenFromReader(reader io.Reader, out io.Writer, errOut io.Writer) error {
+func runFullscreenFromReader(reader io.Reader, taps streamTaps, theme tuiTheme, out io.Writer, errOut io.Writer) error {
 	stream := make(chan streamMsg, 64)
 	go decodeEvents(reader, stream)
-	return runFullscreenFromStream(stream, out, errOut)
+	return runFullscreenFromStream(taps.apply(stream, errOut), theme, out, errOut)
 }
 
-func runFullscreenFromBus(busBackend, busAddress, busPrefix, busSource string, opts distributed.BusBackendOptions, out io.Writer, errOut io.Writer) error {
+func runFullscreenFromBus(busBackend, busAddress, busPrefix, busSource string, opts distributed.BusBackendOptions, taps streamTaps, theme tuiTheme, out io.Writer, errOut io.Writer) error {
 	stream, stop, err := startMonitorEventStream(busBackend, busAddress, busPrefix, busSource, opts)
 	if err != nil {
 		return err
 	}
 	defer stop()
-	return runFullscreenFromStream(stream, out, errOut)
+	return runFullscreenFromStream(taps.apply(stream, errOut), theme, out, errOut)
 }
 
-func runFullscreenFromStream(stream <-chan streamMsg, out io.Writer, errOut io.Writer) error {
+func runFullscreenFromStream(stream <-chan streamMsg, theme tuiTheme, out io.Writer, errOut io.Writer) error {
+	model := newFullscreenModel(stream, nil, false)
+	model.theme = theme
 	program := tea.NewProgram(
-		newFullscreenModel(stream, nil, false),
+		model,
 		tea.WithOutput(out),
 		tea.WithAltScreen(),
 	)
@@ -767,19 +931,19 @@ func runFullscreenFromStream(stream <-chan streamMsg, out io.Writer, errOut io.W
 	return nil
 }
 
-func renderFromBus(busBackend, busAddress, busPrefix, busSource string, opts distributed.BusBackendOptions, out io.Writer, errOut io.Writer) error {
+func renderFromBus(busBackend, busAddress, busPrefix, busSource string, opts distributed.BusBackendOptions, taps streamTaps, out io.Writer, errOut io.Writer) error {
 	stream, stop, err := startMonitorEventStream(busBackend, busAddress, busPrefix, busSource, opts)
 	if err != nil {
 		return err
 	}
 	defer stop()
-	return renderFromStream(stream, out, errOut)
+	return renderFromStream(taps.apply(stream, errOut), out, errOut)
 }
 
-func renderFromReader(reader io.Reader, out io.Writer, errOut io.Writer) error {
+func renderFromReader(reader io.Reader, taps streamTaps, out io.Writer, errOut io.Writer) error {
 	stream := make(chan streamMsg, 64)
 	go decodeEvents(reader, stream)
-	return renderFromStream(stream, out, errOut)
+	return renderFromStream(taps.apply(stream, errOut), out, errOut)
 }
 
 func renderFromStream(stream <-chan streamMsg, out io.Writer, errOut io.Writer) error {
@@ -828,11 +992,13 @@ func renderFromStream(stream <-chan streamMsg, out io.Writer, errOut io.Writer)
 	return nil
 }
 
-func runFullscreenDemo(out io.Writer, errOut io.Writer) error {
+func runFullscreenDemo(theme tuiTheme, out io.Writer, errOut io.Writer) error {
 	stream := make(chan streamMsg)
 	close(stream)
+	model := newFullscreenModel(stream, demoEvents(time.Now().UTC()), true)
+	model.theme = theme
 	program := tea.NewProgram(
-		newFullscreenModel(stream, demoEvents(time.Now().UTC()), true),
+		model,
 		tea.WithOutput(out),
 		tea.WithAltScreen(),
 	)
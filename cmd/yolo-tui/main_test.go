@@ -2,8 +2,11 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -31,6 +34,40 @@ func TestRunMainRendersMonitorViewFromStdin(t *testing.T) {
 	}
 }
 
+func TestRunMainReplaysRotatedEventsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.events.jsonl")
+	segment := &bytes.Buffer{}
+	writer := gzip.NewWriter(segment)
+	_, _ = writer.Write([]byte("{\"type\":\"task_started\",\"task_id\":\"task-1\",\"task_title\":\"Rotated task\",\"ts\":\"2026-02-10T12:00:00Z\"}\n"))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("compress segment: %v", err)
+	}
+	if err := os.WriteFile(path+".20260210T120001.000000000Z.gz", segment.Bytes(), 0o644); err != nil {
+		t.Fatalf("write segment: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("{\"type\":\"task_started\",\"task_id\":\"task-2\",\"task_title\":\"Live task\",\"ts\":\"2026-02-10T12:00:02Z\"}\n"), 0o644); err != nil {
+		t.Fatalf("write live log: %v", err)
+	}
+
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	code := RunMain([]string{"--events-file", path}, nil, out, errOut)
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d stderr=%q", code, errOut.String())
+	}
+	if !contains(out.String(), "task-1") || !contains(out.String(), "Current Task: task-2 - Live task") {
+		t.Fatalf("expected events from the segment and the live log, got %q", out.String())
+	}
+}
+
+func TestRunMainRejectsEventsFileWithAnotherInputMode(t *testing.T) {
+	errOut := &bytes.Buffer{}
+	code := RunMain([]string{"--events-file", "agent.events.jsonl", "--events-stdin"}, strings.NewReader(""), &bytes.Buffer{}, errOut)
+	if code == 0 || !contains(errOut.String(), "set exactly one event input mode") {
+		t.Fatalf("expected input mode conflict, got code=%d stderr=%q", code, errOut.String())
+	}
+}
+
 func TestRenderBodyShowsTaskDetailsForCurrentTask(t *testing.T) {
 	model := newFullscreenModel(make(chan streamMsg), nil, true)
 	now := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
@@ -70,6 +107,27 @@ func TestRenderBodyShowsTaskDetailsForCurrentTask(t *testing.T) {
 	}
 }
 
+func TestRenderBodyShowsAgentPlanInActivityPane(t *testing.T) {
+	model := newFullscreenModel(make(chan streamMsg), nil, true)
+	now := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	model.monitor.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1", TaskTitle: "Planned task", WorkerID: "worker-1", Timestamp: now})
+	model.monitor.Apply(contracts.Event{
+		Type:      contracts.EventTypePlanUpdated,
+		TaskID:    "task-1",
+		WorkerID:  "worker-1",
+		Message:   "plan 1/2 completed",
+		Metadata:  contracts.PlanUpdatedMetadata([]contracts.PlanEntry{{Content: "inspect", Status: "completed"}, {Content: "patch", Status: "in_progress"}}),
+		Timestamp: now,
+	})
+	body := model.renderBody()
+
+	for _, want := range []string{"📋 plan", "[x] inspect", "[~] patch"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected activity pane to include %q, got %q", want, body)
+		}
+	}
+}
+
 func TestRunMainSupportsDistributedBusEventsFromEnvelope(t *testing.T) {
 	bus := distributed.NewMemoryBus()
 	originalBusFactory := newDistributedBus
@@ -173,7 +231,7 @@ func TestRenderFromReaderParsesIncrementalEvents(t *testing.T) {
 
 	done := make(chan error, 1)
 	go func() {
-		done <- renderFromReader(reader, out, errOut)
+		done <- renderFromReader(reader, streamTaps{}, out, errOut)
 	}()
 
 	_, _ = writer.Write([]byte("{\"type\":\"task_started\",\"task_id\":\"task-1\",\"task_title\":\"Readable task\",\"ts\":\"2026-02-10T12:00:00Z\"}\n"))
@@ -194,7 +252,7 @@ func TestRenderFromReaderWritesOnEachEventForLiveUpdates(t *testing.T) {
 	out := &bytes.Buffer{}
 	errOut := &bytes.Buffer{}
 
-	if err := renderFromReader(input, out, errOut); err != nil {
+	if err := renderFromReader(input, streamTaps{}, out, errOut); err != nil {
 		t.Fatalf("render failed: %v", err)
 	}
 	if strings.Count(out.String(), "Current Task:") < 2 {
@@ -209,7 +267,7 @@ func TestRenderFromReaderContinuesAfterMalformedEventWithFallbackWarning(t *test
 	out := &bytes.Buffer{}
 	errOut := &bytes.Buffer{}
 
-	if err := renderFromReader(input, out, errOut); err != nil {
+	if err := renderFromReader(input, streamTaps{}, out, errOut); err != nil {
 		t.Fatalf("render failed: %v", err)
 	}
 	if !contains(out.String(), "decode_error") {
@@ -271,7 +329,7 @@ func TestRenderFromReaderIgnoresRawACPStderrLines(t *testing.T) {
 	out := &bytes.Buffer{}
 	errOut := &bytes.Buffer{}
 
-	if err := renderFromReader(input, out, errOut); err != nil {
+	if err := renderFromReader(input, streamTaps{}, out, errOut); err != nil {
 		t.Fatalf("expected raw stderr lines to be ignored, got error: %v", err)
 	}
 	if !contains(out.String(), "runner_finished") {
@@ -291,10 +349,10 @@ func TestRenderFromReaderIsDeterministicForSameInput(t *testing.T) {
 	errA := &bytes.Buffer{}
 	errB := &bytes.Buffer{}
 
-	if err := renderFromReader(strings.NewReader(content), outA, errA); err != nil {
+	if err := renderFromReader(strings.NewReader(content), streamTaps{}, outA, errA); err != nil {
 		t.Fatalf("first render failed: %v", err)
 	}
-	if err := renderFromReader(strings.NewReader(content), outB, errB); err != nil {
+	if err := renderFromReader(strings.NewReader(content), streamTaps{}, outB, errB); err != nil {
 		t.Fatalf("second render failed: %v", err)
 	}
 	if outA.String() != outB.String() {
@@ -534,19 +592,30 @@ func TestRenderTopIncludesTaskProgressCounter(t *testing.T) {
 		CompletedCount: 3,
 		TotalCount:     7,
 	}
-	rendered := renderTop(80, state)
+	rendered := renderTop(80, state, darkTUITheme())
 	if !strings.Contains(rendered, "3 / 7 tasks") {
 		t.Fatalf("expected progress counter '3 / 7 tasks' in header, got %q", rendered)
 	}
 }
 
+func TestRenderTopShowsRunETAWhenEstimated(t *testing.T) {
+	state := monitor.UIState{CurrentTask: "task-1", Phase: "running", LastOutputAge: "5s", CompletedCount: 1, TotalCount: 4}
+	if rendered := renderTop(120, state, darkTUITheme()); strings.Contains(rendered, "ETA") {
+		t.Fatalf("expected no ETA without an estimate, got %q", rendered)
+	}
+	state.ETA = "12m30s"
+	if rendered := renderTop(120, state, darkTUITheme()); !strings.Contains(rendered, "ETA 12m30s") {
+		t.Fatalf("expected the run ETA in header, got %q", rendered)
+	}
+}
+
 func TestRenderTopKeepsHeaderSingleLineAtTerminalWidth(t *testing.T) {
 	state := monitor.UIState{
 		CurrentTask:   "yr-very-long-task-id-with-a-title-that-keeps-going",
 		Phase:         "running",
 		LastOutputAge: "12s",
 	}
-	rendered := renderTop(24, state)
+	rendered := renderTop(24, state, darkTUITheme())
 	if strings.Count(rendered, "\n") != 0 {
 		t.Fatalf("expected top bar to remain single-line, got %q", rendered)
 	}
@@ -560,3 +629,158 @@ func contains(text string, sub string) bool {
 	}
 	return false
 }
+
+func TestRunMainWritesStateSnapshotWhenStreamEnds(t *testing.T) {
+	content := "{\"type\":\"task_started\",\"task_id\":\"task-1\",\"task_title\":\"Readable task\",\"ts\":\"2026-02-10T12:00:00Z\"}\n" +
+		"{\"type\":\"task_finished\",\"task_id\":\"task-1\",\"message\":\"completed\",\"ts\":\"2026-02-10T12:00:05Z\"}\n"
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	code := RunMain([]string{"--events-stdin", "--snapshot-interval", "1h", "--snapshot-file", path}, strings.NewReader(content), out, errOut)
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d stderr=%q", code, errOut.String())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read snapshot: %v", err)
+	}
+	if !strings.Contains(string(data), `"event_count": 2`) || !strings.Contains(string(data), `"completed": 1`) {
+		t.Fatalf("expected final state in snapshot, got:\n%s", data)
+	}
+}
+
+func TestRunMainRenderFinalPrintsPlainSummary(t *testing.T) {
+	content := "{\"type\":\"task_started\",\"task_id\":\"task-1\",\"task_title\":\"Readable task\",\"ts\":\"2026-02-10T12:00:00Z\"}\n" +
+		"{not json\n" +
+		"{\"type\":\"task_finished\",\"task_id\":\"task-1\",\"message\":\"completed\",\"ts\":\"2026-02-10T12:00:05Z\"}\n"
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	code := RunMain([]string{"--events-stdin", "--render-final"}, strings.NewReader(content), out, errOut)
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d stderr=%q", code, errOut.String())
+	}
+	if strings.Contains(out.String(), "\x1b[") || strings.Contains(out.String(), "Current Task:") {
+		t.Fatalf("expected a single plain summary, got %q", out.String())
+	}
+	if !contains(out.String(), "task-1  Readable task  n/a     completed  5s") || !contains(out.String(), "skipped 1 malformed event line(s)") {
+		t.Fatalf("expected task table and skipped line note, got:\n%s", out.String())
+	}
+}
+
+func TestRunMainRejectsRenderFinalWithEventsBus(t *testing.T) {
+	errOut := &bytes.Buffer{}
+	code := RunMain([]string{"--events-bus", "--render-final"}, strings.NewReader(""), &bytes.Buffer{}, errOut)
+	if code == 0 || !contains(errOut.String(), "--render-final") {
+		t.Fatalf("expected --render-final with --events-bus to be rejected, got code=%d stderr=%q", code, errOut.String())
+	}
+}
+
+func TestResolveSnapshotOptions(t *testing.T) {
+	if _, err := resolveSnapshotOptions(".", "", -time.Second); err == nil {
+		t.Fatalf("expected negative interval to be rejected")
+	}
+	if _, err := resolveSnapshotOptions(".", "state.json", 0); err == nil {
+		t.Fatalf("expected --snapshot-file without an interval to be rejected")
+	}
+	opts, err := resolveSnapshotOptions("/repo", "", 5*time.Second)
+	if err != nil || opts.path != filepath.Join("/repo", "runner-logs", "monitor-snapshot.json") || !opts.enabled() {
+		t.Fatalf("expected default snapshot path under repo, got %#v err=%v", opts, err)
+	}
+	if opts, err := resolveSnapshotOptions(".", "", 0); err != nil || opts.enabled() {
+		t.Fatalf("expected snapshots disabled by default, got %#v err=%v", opts, err)
+	}
+}
+
+func typeSearch(t *testing.T, m fullscreenModel, query string) fullscreenModel {
+	t.Helper()
+	keys := []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune{'/'}}}
+	for _, r := range query {
+		keys = append(keys, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	keys = append(keys, tea.KeyMsg{Type: tea.KeyEnter})
+	for _, key := range keys {
+		updated, _ := m.Update(key)
+		m = updated.(fullscreenModel)
+	}
+	return m
+}
+
+func TestFullscreenModelSearchFiltersWorkersAndHistory(t *testing.T) {
+	stream := make(chan streamMsg)
+	close(stream)
+	m := newFullscreenModel(stream, demoEvents(time.Now().UTC()), true)
+	m.historyCollapsed = false
+
+	m = typeSearch(t, m, "kimi")
+	if m.search.editing || m.search.query != "kimi" {
+		t.Fatalf("expected applied search query, got %#v", m.search)
+	}
+	body := m.renderBody()
+	if contains(body, "task: yr-me4i") || contains(body, "task_started | yr-me4i") {
+		t.Fatalf("expected the codex worker and its history to be filtered out, got %q", body)
+	}
+	if !contains(body, "task: yr-ttw4") || !contains(body, "runner_started | yr-ttw4") {
+		t.Fatalf("expected the kimi worker and its history to remain, got %q", body)
+	}
+	if len(m.search.matchRows) == 0 {
+		t.Fatalf("expected highlighted matches")
+	}
+	if !contains(m.View(), "match 1/") {
+		t.Fatalf("expected search footer, got %q", m.View())
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(fullscreenModel)
+	if m.search.active() || !contains(m.renderBody(), "task_started | yr-me4i") {
+		t.Fatalf("expected esc to clear the search")
+	}
+}
+
+func TestFullscreenModelSearchNavigatesMatchesWithNAndShiftN(t *testing.T) {
+	stream := make(chan streamMsg)
+	close(stream)
+	m := newFullscreenModel(stream, demoEvents(time.Now().UTC()), true)
+	m = typeSearch(t, m, "yr-(me4i|ttw4)")
+	if len(m.search.matchRows) < 2 {
+		t.Fatalf("expected regex search to match several rows, got %v", m.search.matchRows)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m = updated.(fullscreenModel)
+	if m.search.current != 1 {
+		t.Fatalf("expected n to move to the second match, got %d", m.search.current)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'N'}})
+	m = updated.(fullscreenModel)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'N'}})
+	m = updated.(fullscreenModel)
+	if m.search.current != len(m.search.matchRows)-1 {
+		t.Fatalf("expected N to wrap to the last match, got %d of %d", m.search.current, len(m.search.matchRows))
+	}
+}
+
+func TestFullscreenModelSearchPromptSwallowsShortcuts(t *testing.T) {
+	stream := make(chan streamMsg)
+	close(stream)
+	m := newFullscreenModel(stream, nil, false)
+	updated, _ := m.Update(streamDoneMsg{})
+	m = updated.(fullscreenModel)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	m = updated.(fullscreenModel)
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	m = updated.(fullscreenModel)
+	if cmd != nil || m.search.query != "q" {
+		t.Fatalf("expected q to be typed into the search prompt, got query=%q cmd=%v", m.search.query, cmd)
+	}
+}
+
+func TestCompileSearchPatternFallsBackToSubstring(t *testing.T) {
+	pattern := compileSearchPattern("task[")
+	if pattern == nil || !pattern.MatchString("TASK[1]") {
+		t.Fatalf("expected invalid regex to match as a case-insensitive substring")
+	}
+	if compileSearchPattern("") != nil {
+		t.Fatalf("expected empty query to disable search")
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	notifyRunFinished       = "run_finished"
+	notifyTaskBlocked       = "task_blocked"
+	notifyApprovalRequested = "approval_requested"
+	notifyAll               = "all"
+	notifyNone              = "none"
+)
+
+// tuiNotifications selects which monitor events raise a desktop notification.
+type tuiNotifications struct {
+	RunFinished       bool `yaml:"run_finished"`
+	TaskBlocked       bool `yaml:"task_blocked"`
+	ApprovalRequested bool `yaml:"approval_requested"`
+}
+
+func (n tuiNotifications) enabled() bool {
+	return n.RunFinished || n.TaskBlocked || n.ApprovalRequested
+}
+
+// parseTUINotifications parses a comma-separated --notify value. "all" and
+// "none" select every or no event type.
+func parseTUINotifications(raw string) (tuiNotifications, error) {
+	selected := tuiNotifications{}
+	for _, part := range strings.Split(raw, ",") {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "", notifyNone:
+		case notifyAll:
+			selected = tuiNotifications{RunFinished: true, TaskBlocked: true, ApprovalRequested: true}
+		case notifyRunFinished:
+			selected.RunFinished = true
+		case notifyTaskBlocked:
+			selected.TaskBlocked = true
+		case notifyApprovalRequested:
+			selected.ApprovalRequested = true
+		default:
+			return tuiNotifications{}, fmt.Errorf("unsupported notification %q (supported: %s, %s, %s, %s, %s)", strings.TrimSpace(part), notifyRunFinished, notifyTaskBlocked, notifyApprovalRequested, notifyAll, notifyNone)
+		}
+	}
+	return selected, nil
+}
+
+// resolveTUINotifications lets --notify replace tui.notifications from the
+// config file. Notifications are off unless one of them selects an event type.
+func resolveTUINotifications(repoRoot string, flagNotify string) (tuiNotifications, error) {
+	if strings.TrimSpace(flagNotify) != "" {
+		selected, err := parseTUINotifications(flagNotify)
+		if err != nil {
+			return tuiNotifications{}, fmt.Errorf("--notify: %w", err)
+		}
+		return selected, nil
+	}
+	config, err := loadTUIConfig(repoRoot)
+	if err != nil {
+		return tuiNotifications{}, err
+	}
+	return config.TUI.Notifications, nil
+}
+
+type desktopNotification struct {
+	title string
+	body  string
+}
+
+// notificationFor returns the notification event should raise, if any.
+func (n tuiNotifications) notificationFor(event contracts.Event) (desktopNotification, bool) {
+	switch event.Type {
+	case contracts.EventTypeRunFinished:
+		if !n.RunFinished {
+			return desktopNotification{}, false
+		}
+		status := strings.TrimSpace(event.Metadata["status"])
+		if status == "" {
+			status = "finished"
+		}
+		body := fmt.Sprintf("%s: completed=%s blocked=%s failed=%s",
+			status,
+			metadataOrZero(event.Metadata, "completed"),
+			metadataOrZero(event.Metadata, "blocked"),
+			metadataOrZero(event.Metadata, "failed"),
+		)
+		return desktopNotification{title: "yolo-runner: run " + status, body: body}, true
+	case contracts.EventTypeTaskFinished:
+		if !n.TaskBlocked || !strings.EqualFold(strings.TrimSpace(event.Message), "blocked") {
+			return desktopNotification{}, false
+		}
+		body := taskLabel(event)
+		if reason := strings.TrimSpace(event.Metadata["triage_reason"]); reason != "" {
+			body += ": " + reason
+		}
+		if category := contracts.FailureCategoryFromMetadata(event.Metadata); category != "" {
+			body += " [" + string(category) + "]"
+		}
+		return desktopNotification{title: "yolo-runner: task blocked", body: body}, true
+	case contracts.EventTypeRunnerPermission:
+		if !n.ApprovalRequested || strings.TrimSpace(event.Metadata["decision"]) != "ask" {
+			return desktopNotification{}, false
+		}
+		return desktopNotification{title: "yolo-runner: approval requested", body: taskLabel(event) + ": " + strings.TrimSpace(event.Message)}, true
+	}
+	return desktopNotification{}, false
+}
+
+func taskLabel(event contracts.Event) string {
+	label := strings.TrimSpace(event.TaskID)
+	if title := strings.TrimSpace(event.TaskTitle); title != "" {
+		label += " - " + title
+	}
+	return label
+}
+
+func metadataOrZero(metadata map[string]string, key string) string {
+	if value := strings.TrimSpace(metadata[key]); value != "" {
+		return value
+	}
+	return "0"
+}
+
+// desktopNotifier shows an OS-level notification.
+type desktopNotifier func(title string, body string) error
+
+// newDesktopNotifier returns a notifier for goos that shells out to
+// osascript on macOS and notify-send on Linux.
+func newDesktopNotifier(goos string, run func(name string, args ...string) error) (desktopNotifier, error) {
+	switch goos {
+	case "darwin":
+		return func(title string, body string) error {
+			script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+			return run("osascript", "-e", script)
+		}, nil
+	case "linux":
+		return func(title string, body string) error {
+			return run("notify-send", "--app-name=yolo-tui", title, body)
+		}, nil
+	default:
+		return nil, fmt.Errorf("desktop notifications are not supported on %s", goos)
+	}
+}
+
+func appleScriptString(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+func runNotificationCommand(name string, args ...string) error {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return errors.New(message)
+		}
+		return err
+	}
+	return nil
+}
+
+// teeNotifications forwards stream unchanged and raises a desktop
+// notification for each selected event. Notifications are sent from their own
+// goroutine so a slow notification daemon never stalls the view; the stream
+// closes only after queued notifications are sent.
+func teeNotifications(stream <-chan streamMsg, selected tuiNotifications, notify desktopNotifier, errOut io.Writer) <-chan streamMsg {
+	if !selected.enabled() || notify == nil {
+		return stream
+	}
+	out := make(chan streamMsg, cap(stream))
+	pending := make(chan desktopNotification, 16)
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		reported := false
+		for note := range pending {
+			// Report only the first failure; a missing notify-send would
+			// otherwise repeat for every event.
+			if err := notify(note.title, note.body); err != nil && !reported && errOut != nil {
+				fmt.Fprintf(errOut, "desktop notification: %v\n", err)
+				reported = true
+			}
+		}
+	}()
+	go func() {
+		defer close(out)
+		for msg := range stream {
+			if typed, ok := msg.(eventMsg); ok {
+				if note, ok := selected.notificationFor(typed.event); ok {
+					select {
+					case pending <- note:
+					default:
+					}
+				}
+			}
+			out <- msg
+		}
+		close(pending)
+		<-sent
+	}()
+	return out
+}
+
+func defaultDesktopNotifier(errOut io.Writer) desktopNotifier {
+	notify, err := newDesktopNotifier(runtime.GOOS, runNotificationCommand)
+	if err != nil {
+		if errOut != nil {
+			fmt.Fprintln(errOut, err)
+		}
+		return nil
+	}
+	return notify
+}
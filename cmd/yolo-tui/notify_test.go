@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestResolveTUINotificationsUsesConfigAndFlagOverrides(t *testing.T) {
+	repo := writeTUIConfig(t, `tui:
+  notifications:
+    run_finished: true
+    task_blocked: true
+`)
+
+	selected, err := resolveTUINotifications(repo, "")
+	if err != nil {
+		t.Fatalf("resolve notifications: %v", err)
+	}
+	if selected != (tuiNotifications{RunFinished: true, TaskBlocked: true}) {
+		t.Fatalf("expected config notifications, got %#v", selected)
+	}
+
+	selected, err = resolveTUINotifications(repo, "approval_requested")
+	if err != nil || selected != (tuiNotifications{ApprovalRequested: true}) {
+		t.Fatalf("expected --notify to replace config, got %#v err=%v", selected, err)
+	}
+	if selected, err := resolveTUINotifications(repo, "none"); err != nil || selected.enabled() {
+		t.Fatalf("expected --notify none to disable notifications, got %#v err=%v", selected, err)
+	}
+	if _, err := resolveTUINotifications(repo, "task_started"); err == nil || !strings.Contains(err.Error(), "--notify") {
+		t.Fatalf("expected unsupported notification to be rejected, got %v", err)
+	}
+	if selected, err := resolveTUINotifications(t.TempDir(), ""); err != nil || selected.enabled() {
+		t.Fatalf("expected notifications off without config, got %#v err=%v", selected, err)
+	}
+}
+
+func TestNotificationForSelectedEvents(t *testing.T) {
+	all := tuiNotifications{RunFinished: true, TaskBlocked: true, ApprovalRequested: true}
+
+	note, ok := all.notificationFor(contracts.Event{
+		Type:     contracts.EventTypeRunFinished,
+		Metadata: map[string]string{"status": "completed", "completed": "3", "blocked": "1"},
+	})
+	if !ok || note.title != "yolo-runner: run completed" || note.body != "completed: completed=3 blocked=1 failed=0" {
+		t.Fatalf("unexpected run finished notification: %#v ok=%v", note, ok)
+	}
+
+	note, ok = all.notificationFor(contracts.Event{
+		Type:      contracts.EventTypeTaskFinished,
+		TaskID:    "task-1",
+		TaskTitle: "Fix login",
+		Message:   "blocked",
+		Metadata:  map[string]string{"triage_reason": "needs credentials", "triage_category": "auth_profile_config"},
+	})
+	if !ok || note.title != "yolo-runner: task blocked" || note.body != "task-1 - Fix login: needs credentials [auth_profile_config]" {
+		t.Fatalf("unexpected task blocked notification: %#v ok=%v", note, ok)
+	}
+	if _, ok := all.notificationFor(contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: "task-1", Message: "completed"}); ok {
+		t.Fatalf("did not expect a notification for a completed task")
+	}
+
+	note, ok = all.notificationFor(contracts.Event{
+		Type:     contracts.EventTypeRunnerPermission,
+		TaskID:   "task-1",
+		Message:  "ask execute: rm -rf build",
+		Metadata: map[string]string{"decision": "ask"},
+	})
+	if !ok || note.title != "yolo-runner: approval requested" || note.body != "task-1: ask execute: rm -rf build" {
+		t.Fatalf("unexpected approval notification: %#v ok=%v", note, ok)
+	}
+	if _, ok := all.notificationFor(contracts.Event{Type: contracts.EventTypeRunnerPermission, Metadata: map[string]string{"decision": "allow"}}); ok {
+		t.Fatalf("did not expect a notification for an allowed permission")
+	}
+
+	if _, ok := (tuiNotifications{TaskBlocked: true}).notificationFor(contracts.Event{Type: contracts.EventTypeRunFinished}); ok {
+		t.Fatalf("did not expect a notification for an unselected event type")
+	}
+}
+
+func TestNewDesktopNotifierCommands(t *testing.T) {
+	var calls [][]string
+	run := func(name string, args ...string) error {
+		calls = append(calls, append([]string{name}, args...))
+		return nil
+	}
+
+	notify, err := newDesktopNotifier("darwin", run)
+	if err != nil {
+		t.Fatalf("darwin notifier: %v", err)
+	}
+	_ = notify("yolo-runner", `say "hi"`)
+	notify, err = newDesktopNotifier("linux", run)
+	if err != nil {
+		t.Fatalf("linux notifier: %v", err)
+	}
+	_ = notify("yolo-runner", "done")
+
+	if len(calls) != 2 {
+		t.Fatalf("expected two commands, got %#v", calls)
+	}
+	if calls[0][0] != "osascript" || calls[0][2] != `display notification "say \"hi\"" with title "yolo-runner"` {
+		t.Fatalf("unexpected osascript call: %#v", calls[0])
+	}
+	if strings.Join(calls[1], " ") != "notify-send --app-name=yolo-tui yolo-runner done" {
+		t.Fatalf("unexpected notify-send call: %#v", calls[1])
+	}
+	if _, err := newDesktopNotifier("windows", run); err == nil {
+		t.Fatalf("expected unsupported platform to be rejected")
+	}
+}
+
+func TestTeeNotificationsSendsBeforeStreamCloses(t *testing.T) {
+	stream := make(chan streamMsg, 4)
+	stream <- eventMsg{event: contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1"}}
+	stream <- eventMsg{event: contracts.Event{Type: contracts.EventTypeRunFinished, Metadata: map[string]string{"status": "failed"}}}
+	stream <- eventMsg{event: contracts.Event{Type: contracts.EventTypeRunFinished, Metadata: map[string]string{"status": "failed"}}}
+	close(stream)
+
+	titles := []string{}
+	notify := func(title string, body string) error {
+		titles = append(titles, title)
+		return errors.New("notify-send: not found")
+	}
+	errOut := &bytes.Buffer{}
+	forwarded := 0
+	for range teeNotifications(stream, tuiNotifications{RunFinished: true}, notify, errOut) {
+		forwarded++
+	}
+	if forwarded != 3 {
+		t.Fatalf("expected every message to be forwarded, got %d", forwarded)
+	}
+	if len(titles) != 2 || titles[0] != "yolo-runner: run failed" {
+		t.Fatalf("expected run finished notifications before close, got %#v", titles)
+	}
+	if strings.Count(errOut.String(), "desktop notification:") != 1 {
+		t.Fatalf("expected notification failure to be reported once, got %q", errOut.String())
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/egv/yolo-runner/v2/internal/ui/monitor"
+)
+
+// renderFinalFromReader consumes the whole event stream and prints one static,
+// ANSI-free summary of the run, for CI job logs and PR comments.
+func renderFinalFromReader(reader io.Reader, taps streamTaps, out io.Writer, errOut io.Writer) error {
+	stream := make(chan streamMsg, 64)
+	go decodeEvents(reader, stream)
+	return renderFinalFromStream(taps.apply(stream, errOut), out, errOut)
+}
+
+func renderFinalFromStream(stream <-chan streamMsg, out io.Writer, errOut io.Writer) error {
+	m := monitor.NewModel(nil)
+	skipped := 0
+	decodeFailures := 0
+	var lastErr error
+	for msg := range stream {
+		switch typed := msg.(type) {
+		case eventMsg:
+			decodeFailures = 0
+			m.Apply(typed.event)
+		case decodeErrorMsg:
+			skipped++
+			decodeFailures++
+			lastErr = typed.err
+			if errOut != nil {
+				_, _ = io.WriteString(errOut, "event decode warning: "+typed.err.Error()+"\n")
+			}
+		}
+	}
+	if decodeFailures >= 3 {
+		return fmt.Errorf("failed to decode event stream after %d errors: %w", decodeFailures, lastErr)
+	}
+	summary := m.FinalSummary()
+	if skipped > 0 {
+		summary += fmt.Sprintf("\nskipped %d malformed event line(s)\n", skipped)
+	}
+	_, err := io.WriteString(out, summary)
+	return err
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/egv/yolo-runner/v2/internal/ui/monitor"
+)
+
+// tuiSearch filters the panels, workers and history panes and highlights
+// matching lines in every pane. The query is a case-insensitive regular
+// expression; a query that does not compile is matched as a plain substring.
+type tuiSearch struct {
+	editing bool
+	query   string
+	pattern *regexp.Regexp
+	// matchRows are the body rows holding highlighted lines, top to bottom.
+	matchRows []int
+	current   int
+}
+
+func compileSearchPattern(query string) *regexp.Regexp {
+	if query == "" {
+		return nil
+	}
+	if pattern, err := regexp.Compile("(?i)" + query); err == nil {
+		return pattern
+	}
+	return regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+}
+
+func (s *tuiSearch) setQuery(query string) {
+	s.query = query
+	s.pattern = compileSearchPattern(query)
+	s.current = 0
+}
+
+func (s *tuiSearch) clear() {
+	*s = tuiSearch{}
+}
+
+func (s tuiSearch) active() bool {
+	return s.pattern != nil
+}
+
+func (s tuiSearch) matches(text string) bool {
+	return s.pattern != nil && s.pattern.MatchString(text)
+}
+
+func (s tuiSearch) filterStrings(lines []string) []string {
+	if !s.active() {
+		return lines
+	}
+	out := []string{}
+	for _, line := range lines {
+		if s.matches(line) {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func (s tuiSearch) filterWorkers(workers []monitor.UIWorkerSummary) []monitor.UIWorkerSummary {
+	if !s.active() {
+		return workers
+	}
+	out := []monitor.UIWorkerSummary{}
+	for _, worker := range workers {
+		if s.matches(worker.WorkerID) || s.matches(worker.Task) || s.matches(worker.Phase) || s.matches(worker.LastEvent) {
+			out = append(out, worker)
+		}
+	}
+	return out
+}
+
+func (s tuiSearch) filterDisplayLines(lines []displayLine) []displayLine {
+	if !s.active() {
+		return lines
+	}
+	out := []displayLine{}
+	for _, line := range lines {
+		if s.matches(line.text) {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// step moves to the next (delta 1) or previous (delta -1) match, wrapping
+// around, and returns its body row.
+func (s *tuiSearch) step(delta int) (int, bool) {
+	if len(s.matchRows) == 0 {
+		return 0, false
+	}
+	s.current = (s.current + delta + len(s.matchRows)) % len(s.matchRows)
+	return s.matchRows[s.current], true
+}
+
+func (s *tuiSearch) setMatchRows(rows []int) {
+	s.matchRows = rows
+	if s.current >= len(rows) {
+		s.current = 0
+	}
+}
+
+// handleEditKey applies a key typed into the search prompt and reports
+// whether the prompt consumed it.
+func (s *tuiSearch) handleEditKey(key tea.KeyMsg) bool {
+	switch key.Type {
+	case tea.KeyRunes:
+		s.setQuery(s.query + string(key.Runes))
+	case tea.KeySpace:
+		s.setQuery(s.query + " ")
+	case tea.KeyBackspace:
+		if runes := []rune(s.query); len(runes) > 0 {
+			s.setQuery(string(runes[:len(runes)-1]))
+		}
+	case tea.KeyEnter:
+		s.editing = false
+		if s.query == "" {
+			s.clear()
+		}
+	case tea.KeyEsc:
+		s.clear()
+	default:
+		return false
+	}
+	return true
+}
+
+func (s tuiSearch) footer() string {
+	if s.editing {
+		return "🔎 /" + s.query + "▏  enter apply  esc cancel"
+	}
+	position := "no matches"
+	if len(s.matchRows) > 0 {
+		position = fmt.Sprintf("match %d/%d", s.current+1, len(s.matchRows))
+	}
+	return fmt.Sprintf("🔎 %s  %s  n/N next/prev  / edit  esc clear", strings.TrimSpace(s.query), position)
+}
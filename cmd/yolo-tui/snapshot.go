@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/ui/monitor"
+)
+
+type snapshotOptions struct {
+	path     string
+	interval time.Duration
+}
+
+func (o snapshotOptions) enabled() bool {
+	return o.path != "" && o.interval > 0
+}
+
+// streamTaps are the side outputs fed from the event stream alongside the
+// rendered view.
+type streamTaps struct {
+	snapshots     snapshotOptions
+	notifications tuiNotifications
+	notify        desktopNotifier
+}
+
+func (t streamTaps) apply(stream <-chan streamMsg, errOut io.Writer) <-chan streamMsg {
+	return teeNotifications(teeStateSnapshots(stream, t.snapshots, errOut), t.notifications, t.notify, errOut)
+}
+
+// teeStateSnapshots forwards stream unchanged while folding its events into a
+// separate monitor model. That model's state is written to opts.path every
+// interval and once more when the stream ends, so the snapshot never depends
+// on what the interactive view has selected or collapsed.
+func teeStateSnapshots(stream <-chan streamMsg, opts snapshotOptions, errOut io.Writer) <-chan streamMsg {
+	if !opts.enabled() {
+		return stream
+	}
+	out := make(chan streamMsg, cap(stream))
+	go func() {
+		defer close(out)
+		model := monitor.NewModel(nil)
+		ticker := time.NewTicker(opts.interval)
+		defer ticker.Stop()
+		lastErr := ""
+		write := func() {
+			err := monitor.WriteStateSnapshot(opts.path, model.StateSnapshot())
+			if err == nil {
+				lastErr = ""
+				return
+			}
+			// Report each distinct failure once so a bad path does not flood stderr.
+			if err.Error() != lastErr && errOut != nil {
+				fmt.Fprintf(errOut, "write state snapshot: %v\n", err)
+			}
+			lastErr = err.Error()
+		}
+		for {
+			select {
+			case <-ticker.C:
+				write()
+			case msg, ok := <-stream:
+				if !ok {
+					write()
+					return
+				}
+				switch typed := msg.(type) {
+				case eventMsg:
+					model.Apply(typed.event)
+				case decodeErrorMsg:
+					model.Apply(contracts.Event{Type: contracts.EventTypeRunnerWarning, Message: "decode_error: " + typed.err.Error()})
+				}
+				out <- msg
+			}
+		}
+	}()
+	return out
+}
+
+func resolveSnapshotOptions(repoRoot string, file string, interval time.Duration) (snapshotOptions, error) {
+	if interval < 0 {
+		return snapshotOptions{}, errors.New("--snapshot-interval must be greater than or equal to 0")
+	}
+	path := strings.TrimSpace(file)
+	if interval == 0 {
+		if path != "" {
+			return snapshotOptions{}, errors.New("--snapshot-file requires --snapshot-interval")
+		}
+		return snapshotOptions{}, nil
+	}
+	if path == "" {
+		path = filepath.Join(repoRoot, "runner-logs", "monitor-snapshot.json")
+	}
+	return snapshotOptions{path: path, interval: interval}, nil
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	tuiThemeDark         = "dark"
+	tuiThemeLight        = "light"
+	tuiThemeHighContrast = "high-contrast"
+
+	tuiColorProfileAuto      = "auto"
+	tuiColorProfileTrueColor = "truecolor"
+	tuiColorProfileANSI256   = "256"
+	tuiColorProfileANSI      = "16"
+	tuiColorProfileNone      = "none"
+
+	tuiConfigRelPath     = ".yolo-runner/config.yaml"
+	tuiUserConfigRelPath = "yolo-runner/config.yaml"
+)
+
+// tuiTheme holds every color the fullscreen TUI draws with. Colors carry
+// truecolor, 256-color and 16-color values so lipgloss can degrade them to
+// whatever the terminal supports.
+type tuiTheme struct {
+	name string
+	// monochrome is set when no colors are rendered; selection and search
+	// matches then use reverse video and underline instead.
+	monochrome bool
+
+	headerBg      lipgloss.TerminalColor
+	headerFg      lipgloss.TerminalColor
+	paneTitle     lipgloss.TerminalColor
+	text          lipgloss.TerminalColor
+	warning       lipgloss.TerminalColor
+	err           lipgloss.TerminalColor
+	muted         lipgloss.TerminalColor
+	selectedBg    lipgloss.TerminalColor
+	selectedFg    lipgloss.TerminalColor
+	matchBg       lipgloss.TerminalColor
+	matchFg       lipgloss.TerminalColor
+	separatorBg   lipgloss.TerminalColor
+	separatorFg   lipgloss.TerminalColor
+	footerBg      lipgloss.TerminalColor
+	footerFg      lipgloss.TerminalColor
+	footerAlertBg lipgloss.TerminalColor
+	footerWarnBg  lipgloss.TerminalColor
+	footerDoneBg  lipgloss.TerminalColor
+	footerDoneFg  lipgloss.TerminalColor
+
+	panelsBg    lipgloss.TerminalColor
+	detailsBg   lipgloss.TerminalColor
+	queueBg     lipgloss.TerminalColor
+	graphBg     lipgloss.TerminalColor
+	dashboardBg lipgloss.TerminalColor
+	workersBg   lipgloss.TerminalColor
+	activityBg  lipgloss.TerminalColor
+	historyBg   lipgloss.TerminalColor
+}
+
+func tuiColor(trueColor string, ansi256 string, ansi string) lipgloss.CompleteColor {
+	return lipgloss.CompleteColor{TrueColor: trueColor, ANSI256: ansi256, ANSI: ansi}
+}
+
+func darkTUITheme() tuiTheme {
+	return tuiTheme{
+		name:          tuiThemeDark,
+		headerBg:      tuiColor("#005f87", "24", "4"),
+		headerFg:      tuiColor("#ffffd7", "230", "15"),
+		paneTitle:     tuiColor("#afd7ff", "153", "14"),
+		text:          tuiColor("#d0d0d0", "252", "7"),
+		warning:       tuiColor("#ffd700", "220", "11"),
+		err:           tuiColor("#ff5f5f", "203", "9"),
+		muted:         tuiColor("#949494", "246", "8"),
+		selectedBg:    tuiColor("#5f5fff", "63", "12"),
+		selectedFg:    tuiColor("#ffffd7", "230", "15"),
+		matchBg:       tuiColor("#af8700", "136", "3"),
+		matchFg:       tuiColor("#ffffd7", "230", "15"),
+		separatorBg:   tuiColor("#303030", "236", "0"),
+		separatorFg:   tuiColor("#5f5f87", "60", "8"),
+		footerBg:      tuiColor("#5f5f00", "58", "3"),
+		footerFg:      tuiColor("#ffffd7", "230", "15"),
+		footerAlertBg: tuiColor("#5f0000", "52", "1"),
+		footerWarnBg:  tuiColor("#875f00", "94", "3"),
+		footerDoneBg:  tuiColor("#005f87", "24", "4"),
+		footerDoneFg:  tuiColor("#e4e4e4", "254", "15"),
+		panelsBg:      tuiColor("#00005f", "17", "4"),
+		detailsBg:     tuiColor("#000087", "18", "4"),
+		queueBg:       tuiColor("#0000d7", "20", "4"),
+		graphBg:       tuiColor("#0000ff", "21", "4"),
+		dashboardBg:   tuiColor("#005f00", "22", "2"),
+		workersBg:     tuiColor("#0000af", "19", "4"),
+		activityBg:    tuiColor("#0000d7", "20", "4"),
+		historyBg:     tuiColor("#262626", "235", "0"),
+	}
+}
+
+func lightTUITheme() tuiTheme {
+	return tuiTheme{
+		name:          tuiThemeLight,
+		headerBg:      tuiColor("#0087af", "31", "6"),
+		headerFg:      tuiColor("#ffffff", "231", "15"),
+		paneTitle:     tuiColor("#005faf", "25", "4"),
+		text:          tuiColor("#262626", "235", "0"),
+		warning:       tuiColor("#af5f00", "130", "3"),
+		err:           tuiColor("#d70000", "160", "1"),
+		muted:         tuiColor("#767676", "243", "8"),
+		selectedBg:    tuiColor("#afd7ff", "153", "14"),
+		selectedFg:    tuiColor("#000000", "16", "0"),
+		matchBg:       tuiColor("#ffffaf", "229", "11"),
+		matchFg:       tuiColor("#000000", "16", "0"),
+		separatorBg:   tuiColor("#d0d0d0", "252", "7"),
+		separatorFg:   tuiColor("#9e9e9e", "247", "8"),
+		footerBg:      tuiColor("#d7d7af", "187", "7"),
+		footerFg:      tuiColor("#000000", "16", "0"),
+		footerAlertBg: tuiColor("#ffafaf", "217", "9"),
+		footerWarnBg:  tuiColor("#ffd7af", "223", "11"),
+		footerDoneBg:  tuiColor("#afd7d7", "152", "14"),
+		footerDoneFg:  tuiColor("#000000", "16", "0"),
+		panelsBg:      tuiColor("#eeeeee", "255", "15"),
+		detailsBg:     tuiColor("#e4e4e4", "254", "15"),
+		queueBg:       tuiColor("#eeeeee", "255", "15"),
+		graphBg:       tuiColor("#e4e4e4", "254", "15"),
+		dashboardBg:   tuiColor("#eeeeee", "255", "15"),
+		workersBg:     tuiColor("#e4e4e4", "254", "15"),
+		activityBg:    tuiColor("#eeeeee", "255", "15"),
+		historyBg:     tuiColor("#dadada", "253", "7"),
+	}
+}
+
+func highContrastTUITheme() tuiTheme {
+	black := tuiColor("#000000", "16", "0")
+	white := tuiColor("#ffffff", "231", "15")
+	return tuiTheme{
+		name:          tuiThemeHighContrast,
+		headerBg:      tuiColor("#ffff00", "226", "11"),
+		headerFg:      black,
+		paneTitle:     tuiColor("#00ffff", "51", "14"),
+		text:          white,
+		warning:       tuiColor("#ffff00", "226", "11"),
+		err:           tuiColor("#ff0000", "196", "9"),
+		muted:         tuiColor("#bcbcbc", "250", "7"),
+		selectedBg:    white,
+		selectedFg:    black,
+		matchBg:       tuiColor("#00ffff", "51", "14"),
+		matchFg:       black,
+		separatorBg:   black,
+		separatorFg:   white,
+		footerBg:      white,
+		footerFg:      black,
+		footerAlertBg: tuiColor("#ff0000", "196", "9"),
+		footerWarnBg:  tuiColor("#ffff00", "226", "11"),
+		footerDoneBg:  tuiColor("#0000ff", "21", "12"),
+		footerDoneFg:  white,
+		panelsBg:      black,
+		detailsBg:     black,
+		queueBg:       black,
+		graphBg:       black,
+		dashboardBg:   black,
+		workersBg:     black,
+		activityBg:    black,
+		historyBg:     black,
+	}
+}
+
+// monochromeTUITheme strips every color from theme, keeping only text
+// attributes.
+func monochromeTUITheme(theme tuiTheme) tuiTheme {
+	none := lipgloss.NoColor{}
+	return tuiTheme{
+		name:          theme.name,
+		monochrome:    true,
+		headerBg:      none,
+		headerFg:      none,
+		paneTitle:     none,
+		text:          none,
+		warning:       none,
+		err:           none,
+		muted:         none,
+		selectedBg:    none,
+		selectedFg:    none,
+		matchBg:       none,
+		matchFg:       none,
+		separatorBg:   none,
+		separatorFg:   none,
+		footerBg:      none,
+		footerFg:      none,
+		footerAlertBg: none,
+		footerWarnBg:  none,
+		footerDoneBg:  none,
+		footerDoneFg:  none,
+		panelsBg:      none,
+		detailsBg:     none,
+		queueBg:       none,
+		graphBg:       none,
+		dashboardBg:   none,
+		workersBg:     none,
+		activityBg:    none,
+		historyBg:     none,
+	}
+}
+
+func tuiThemeByName(name string) (tuiTheme, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", tuiThemeDark:
+		return darkTUITheme(), nil
+	case tuiThemeLight:
+		return lightTUITheme(), nil
+	case tuiThemeHighContrast:
+		return highContrastTUITheme(), nil
+	default:
+		return tuiTheme{}, fmt.Errorf("unsupported theme %q (supported: %s, %s, %s)", name, tuiThemeDark, tuiThemeLight, tuiThemeHighContrast)
+	}
+}
+
+// parseTUIColorProfile maps a profile name to a termenv profile. Auto returns
+// ok=false so lipgloss keeps detecting the terminal itself.
+func parseTUIColorProfile(name string) (termenv.Profile, bool, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", tuiColorProfileAuto:
+		return termenv.TrueColor, false, nil
+	case tuiColorProfileTrueColor:
+		return termenv.TrueColor, true, nil
+	case tuiColorProfileANSI256:
+		return termenv.ANSI256, true, nil
+	case tuiColorProfileANSI:
+		return termenv.ANSI, true, nil
+	case tuiColorProfileNone:
+		return termenv.Ascii, true, nil
+	default:
+		return termenv.Ascii, false, fmt.Errorf("unsupported color profile %q (supported: %s, %s, %s, %s, %s)", name, tuiColorProfileAuto, tuiColorProfileTrueColor, tuiColorProfileANSI256, tuiColorProfileANSI, tuiColorProfileNone)
+	}
+}
+
+type tuiConfigModel struct {
+	TUI struct {
+		Theme         string           `yaml:"theme"`
+		ColorProfile  string           `yaml:"color_profile"`
+		Notifications tuiNotifications `yaml:"notifications"`
+	} `yaml:"tui"`
+}
+
+// loadTUIConfig reads the tui section of the repo config over the user
+// config at $XDG_CONFIG_HOME/yolo-runner/config.yaml (~/.config by default),
+// so settings the repo leaves out fall back to the user's.
+func loadTUIConfig(repoRoot string) (tuiConfigModel, error) {
+	root := strings.TrimSpace(repoRoot)
+	if root == "" {
+		root = "."
+	}
+	var model tuiConfigModel
+	if path := tuiUserConfigPath(os.Getenv); path != "" {
+		if err := decodeTUIConfigFile(&model, path, path); err != nil {
+			return tuiConfigModel{}, err
+		}
+	}
+	if err := decodeTUIConfigFile(&model, filepath.Join(root, tuiConfigRelPath), tuiConfigRelPath); err != nil {
+		return tuiConfigModel{}, err
+	}
+	return model, nil
+}
+
+// decodeTUIConfigFile decodes the config file at path into model, over any
+// values already there. A missing file is not an error.
+func decodeTUIConfigFile(model *tuiConfigModel, path string, label string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("cannot read config file at %s: %w", label, err)
+	}
+	if err := yaml.Unmarshal(content, model); err != nil {
+		return fmt.Errorf("cannot parse config file at %s: %w", label, err)
+	}
+	return nil
+}
+
+func tuiUserConfigPath(getenv func(string) string) string {
+	if dir := strings.TrimSpace(getenv("XDG_CONFIG_HOME")); filepath.IsAbs(dir) {
+		return filepath.Join(dir, tuiUserConfigRelPath)
+	}
+	home := strings.TrimSpace(getenv("HOME"))
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config", tuiUserConfigRelPath)
+}
+
+type tuiDisplay struct {
+	theme tuiTheme
+	// profile is applied to the lipgloss renderer when explicit is set.
+	profile  termenv.Profile
+	explicit bool
+}
+
+// resolveTUIDisplay picks the theme and color profile. Flags win over
+// tui.theme and tui.color_profile in the config file. NO_COLOR disables color
+// unless --color-profile is given explicitly.
+func resolveTUIDisplay(repoRoot string, flagTheme string, flagProfile string, getenv func(string) string) (tuiDisplay, error) {
+	config, err := loadTUIConfig(repoRoot)
+	if err != nil {
+		return tuiDisplay{}, err
+	}
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	themeName := strings.TrimSpace(flagTheme)
+	if themeName == "" {
+		themeName = config.TUI.Theme
+	}
+	theme, err := tuiThemeByName(themeName)
+	if err != nil {
+		if strings.TrimSpace(flagTheme) == "" {
+			return tuiDisplay{}, fmt.Errorf("tui.theme in %s: %w", tuiConfigRelPath, err)
+		}
+		return tuiDisplay{}, fmt.Errorf("--theme: %w", err)
+	}
+
+	profileName := strings.TrimSpace(flagProfile)
+	fromFlag := profileName != ""
+	if !fromFlag {
+		if getenv("NO_COLOR") != "" {
+			profileName = tuiColorProfileNone
+		} else {
+			profileName = config.TUI.ColorProfile
+		}
+	}
+	profile, explicit, err := parseTUIColorProfile(profileName)
+	if err != nil {
+		if fromFlag {
+			return tuiDisplay{}, fmt.Errorf("--color-profile: %w", err)
+		}
+		return tuiDisplay{}, fmt.Errorf("tui.color_profile in %s: %w", tuiConfigRelPath, err)
+	}
+	if explicit && profile == termenv.Ascii {
+		// termenv drops every escape sequence at Ascii, including reverse
+		// video and underline, so render attributes at ANSI with no colors.
+		return tuiDisplay{theme: monochromeTUITheme(theme), profile: termenv.ANSI, explicit: true}, nil
+	}
+	return tuiDisplay{theme: theme, profile: profile, explicit: explicit}, nil
+}
+
+func (d tuiDisplay) apply() {
+	if d.explicit {
+		lipgloss.SetColorProfile(d.profile)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func writeTUIConfig(t *testing.T, content string) string {
+	t.Helper()
+	repo := t.TempDir()
+	configDir := filepath.Join(repo, ".yolo-runner")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return repo
+}
+
+func TestResolveTUIDisplayUsesConfigAndFlagOverrides(t *testing.T) {
+	repo := writeTUIConfig(t, `tui:
+  theme: light
+  color_profile: "256"
+`)
+	noEnv := func(string) string { return "" }
+
+	display, err := resolveTUIDisplay(repo, "", "", noEnv)
+	if err != nil {
+		t.Fatalf("resolve display: %v", err)
+	}
+	if display.theme.name != tuiThemeLight || !display.explicit || display.profile != termenv.ANSI256 {
+		t.Fatalf("expected config theme and profile, got %#v", display)
+	}
+
+	display, err = resolveTUIDisplay(repo, "high-contrast", "truecolor", noEnv)
+	if err != nil {
+		t.Fatalf("resolve display: %v", err)
+	}
+	if display.theme.name != tuiThemeHighContrast || display.profile != termenv.TrueColor {
+		t.Fatalf("expected flags to win over config, got %#v", display)
+	}
+}
+
+func TestLoadTUIConfigMergesUserConfigBeneathRepoConfig(t *testing.T) {
+	configHome := t.TempDir()
+	userConfig := filepath.Join(configHome, "yolo-runner", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(userConfig), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(userConfig, []byte("tui:\n  theme: high-contrast\n  color_profile: \"16\"\n"), 0o644); err != nil {
+		t.Fatalf("write user config: %v", err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	repo := writeTUIConfig(t, `tui:
+  theme: light
+`)
+
+	config, err := loadTUIConfig(repo)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if config.TUI.Theme != tuiThemeLight || config.TUI.ColorProfile != tuiColorProfileANSI {
+		t.Fatalf("expected repo theme over user color profile, got %#v", config.TUI)
+	}
+}
+
+func TestResolveTUIDisplayDefaultsToDarkWithDetectedProfile(t *testing.T) {
+	display, err := resolveTUIDisplay(t.TempDir(), "", "", func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("resolve display: %v", err)
+	}
+	if display.theme.name != tuiThemeDark || display.explicit || display.theme.monochrome {
+		t.Fatalf("expected dark theme with auto-detected profile, got %#v", display)
+	}
+}
+
+func TestResolveTUIDisplayRespectsNoColor(t *testing.T) {
+	repo := writeTUIConfig(t, "tui:\n  color_profile: truecolor\n")
+	noColor := func(key string) string {
+		if key == "NO_COLOR" {
+			return "1"
+		}
+		return ""
+	}
+
+	display, err := resolveTUIDisplay(repo, "", "", noColor)
+	if err != nil {
+		t.Fatalf("resolve display: %v", err)
+	}
+	if !display.theme.monochrome || display.theme.text != (lipgloss.NoColor{}) {
+		t.Fatalf("expected NO_COLOR to disable color over config, got %#v", display)
+	}
+
+	display, err = resolveTUIDisplay(repo, "", "16", noColor)
+	if err != nil {
+		t.Fatalf("resolve display: %v", err)
+	}
+	if display.profile != termenv.ANSI || display.theme.monochrome {
+		t.Fatalf("expected explicit --color-profile to override NO_COLOR, got %#v", display)
+	}
+}
+
+func TestResolveTUIDisplayRejectsUnknownValues(t *testing.T) {
+	noEnv := func(string) string { return "" }
+	if _, err := resolveTUIDisplay(t.TempDir(), "solarized", "", noEnv); err == nil || !strings.Contains(err.Error(), "--theme") {
+		t.Fatalf("expected unknown --theme to fail, got %v", err)
+	}
+	repo := writeTUIConfig(t, "tui:\n  color_profile: 8bit\n")
+	if _, err := resolveTUIDisplay(repo, "", "", noEnv); err == nil || !strings.Contains(err.Error(), "tui.color_profile") {
+		t.Fatalf("expected unknown tui.color_profile to fail, got %v", err)
+	}
+}
+
+func TestRenderPaneMarksSelectionAndMatchesWithoutColorInMonochrome(t *testing.T) {
+	previous := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI)
+	t.Cleanup(func() { lipgloss.SetColorProfile(previous) })
+
+	theme := monochromeTUITheme(darkTUITheme())
+	rendered := renderPane(40, "Panels", []displayLine{{text: "task-1", selected: true}, {text: "task-2", match: true}}, theme.panelsBg, theme)
+	lines := strings.Split(rendered, "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected title and two lines, got %q", rendered)
+	}
+	if !strings.Contains(lines[1], "7m") {
+		t.Fatalf("expected selected line in reverse video, got %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "4m") {
+		t.Fatalf("expected search match underlined, got %q", lines[2])
+	}
+}
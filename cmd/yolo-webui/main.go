@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -58,6 +59,8 @@ type runConfig struct {
 	taskStatusAuthToken string
 	taskStatusBackends  []string
 	shutdownTimeout     time.Duration
+	snapshotPath        string
+	snapshotInterval    time.Duration
 }
 
 type uiConfig struct {
@@ -102,11 +105,23 @@ type webuiState struct {
 	taskStatusBackends  []string
 }
 
+// stateBroadcaster fans state updates out to /ws and /events subscribers.
+// Each update gets an increasing ID, and the most recent ones are kept so an
+// SSE client can resume from its Last-Event-ID.
 type stateBroadcaster struct {
 	mu          sync.Mutex
-	subscribers map[chan statePayload]struct{}
+	subscribers map[chan stateUpdate]struct{}
+	lastID      uint64
+	history     []stateUpdate
 }
 
+type stateUpdate struct {
+	id      uint64
+	payload statePayload
+}
+
+const stateHistoryLimit = 64
+
 var errStatusAuth = errors.New("invalid status_auth_token")
 
 func main() {
@@ -131,6 +146,8 @@ func RunMain(args []string, run func(context.Context, runConfig) error) int {
 	taskStatusAuthToken := fs.String("task-status-auth-token", "", "Token required to publish task status updates through mastermind")
 	taskStatusBackends := fs.String("task-status-backends", "", "Comma-separated task-status update backends (defaults to all)")
 	shutdownTimeout := fs.Duration("shutdown-timeout", 5*time.Second, "Graceful shutdown timeout")
+	snapshotInterval := fs.Duration("snapshot-interval", 0, "Write the aggregated monitor state as JSON this often (0 disables)")
+	snapshotFile := fs.String("snapshot-file", "", "State snapshot path (default: runner-logs/monitor-snapshot.json under --repo)")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
@@ -156,6 +173,11 @@ func RunMain(args []string, run func(context.Context, runConfig) error) int {
 		fmt.Fprintln(os.Stderr, "--listen is required")
 		return 1
 	}
+	snapshotPath, err := resolveSnapshotPath(*repoRoot, *snapshotFile, *snapshotInterval)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
 
 	if run == nil {
 		run = defaultRun
@@ -173,6 +195,8 @@ func RunMain(args []string, run func(context.Context, runConfig) error) int {
 		taskStatusAuthToken: strings.TrimSpace(*taskStatusAuthToken),
 		taskStatusBackends:  parseCommaSeparatedValues(*taskStatusBackends),
 		shutdownTimeout:     *shutdownTimeout,
+		snapshotPath:        snapshotPath,
+		snapshotInterval:    *snapshotInterval,
 	}
 	if err := run(context.Background(), cfg); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -207,10 +231,14 @@ func defaultRun(ctx context.Context, cfg runConfig) error {
 	mux.HandleFunc("/api/config", runtime.handleAPIConfig)
 	mux.HandleFunc("/api/control", runtime.handleAPIControl)
 	mux.HandleFunc("/ws", runtime.handleWS)
+	mux.HandleFunc("/events", runtime.handleSSE)
 
 	server := &http.Server{Addr: cfg.listenAddr, Handler: mux}
 	shutdownCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer stop()
+	if cfg.snapshotInterval > 0 {
+		go runtime.writeStateSnapshots(shutdownCtx, cfg.snapshotPath, cfg.snapshotInterval, os.Stderr)
+	}
 
 	go func() {
 		<-shutdownCtx.Done()
@@ -248,6 +276,57 @@ func (state *webuiState) currentState() monitor.UIState {
 	return state.monitor.UIState()
 }
 
+func (state *webuiState) stateSnapshot() monitor.StateSnapshot {
+	state.monitorMu.Lock()
+	defer state.monitorMu.Unlock()
+	return state.monitor.StateSnapshot()
+}
+
+// writeStateSnapshots writes the monitor state to path every interval until
+// ctx is done, then once more so the file reflects the final state.
+func (state *webuiState) writeStateSnapshots(ctx context.Context, path string, interval time.Duration, errOut io.Writer) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastErr := ""
+	write := func() {
+		err := monitor.WriteStateSnapshot(path, state.stateSnapshot())
+		if err == nil {
+			lastErr = ""
+			return
+		}
+		if err.Error() != lastErr {
+			fmt.Fprintf(errOut, "write state snapshot: %v\n", err)
+		}
+		lastErr = err.Error()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			write()
+			return
+		case <-ticker.C:
+			write()
+		}
+	}
+}
+
+func resolveSnapshotPath(repoRoot string, file string, interval time.Duration) (string, error) {
+	if interval < 0 {
+		return "", errors.New("--snapshot-interval must be greater than or equal to 0")
+	}
+	path := strings.TrimSpace(file)
+	if interval == 0 {
+		if path != "" {
+			return "", errors.New("--snapshot-file requires --snapshot-interval")
+		}
+		return "", nil
+	}
+	if path == "" {
+		path = filepath.Join(repoRoot, "runner-logs", "monitor-snapshot.json")
+	}
+	return path, nil
+}
+
 func (state *webuiState) snapshot() statePayload {
 	return statePayload{
 		State:  state.currentState(),
@@ -393,8 +472,8 @@ func (state *webuiState) serveWS(rawConn *websocket.Conn) {
 	updates := state.hub.register()
 	defer state.hub.unregister(updates)
 	_ = websocket.JSON.Send(rawConn, state.snapshot())
-	for msg := range updates {
-		if err := websocket.JSON.Send(rawConn, msg); err != nil {
+	for update := range updates {
+		if err := websocket.JSON.Send(rawConn, update.payload); err != nil {
 			return
 		}
 	}
@@ -561,21 +640,54 @@ func sanitizeMetadata(raw map[string]string) map[string]string {
 
 func newStateBroadcaster() *stateBroadcaster {
 	return &stateBroadcaster{
-		subscribers: map[chan statePayload]struct{}{},
+		subscribers: map[chan stateUpdate]struct{}{},
 	}
 }
 
-func (b *stateBroadcaster) register() chan statePayload {
-	ch := make(chan statePayload, 32)
+func (b *stateBroadcaster) register() chan stateUpdate {
+	ch, _, _ := b.registerAfter(0)
+	return ch
+}
+
+// registerAfter subscribes to updates and returns the retained updates newer
+// than lastID. resumed is false when lastID is unknown or older than the
+// retained history, in which case the caller has to start from a full
+// snapshot.
+func (b *stateBroadcaster) registerAfter(lastID uint64) (ch chan stateUpdate, missed []stateUpdate, resumed bool) {
+	ch = make(chan stateUpdate, 32)
 	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.subscribers[ch] = struct{}{}
-	b.mu.Unlock()
-	return ch
+	if lastID == 0 || lastID > b.lastID {
+		return ch, nil, false
+	}
+	if lastID == b.lastID {
+		return ch, nil, true
+	}
+	if len(b.history) == 0 || lastID < b.history[0].id-1 {
+		return ch, nil, false
+	}
+	for _, update := range b.history {
+		if update.id > lastID {
+			missed = append(missed, update)
+		}
+	}
+	return ch, missed, true
+}
+
+func (b *stateBroadcaster) currentID() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastID
 }
 
-func (b *stateBroadcaster) unregister(ch chan statePayload) {
+func (b *stateBroadcaster) unregister(ch chan stateUpdate) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	// shutdown may already have closed ch.
+	if _, ok := b.subscribers[ch]; !ok {
+		return
+	}
 	delete(b.subscribers, ch)
 	close(ch)
 }
@@ -585,16 +697,22 @@ func (b *stateBroadcaster) shutdown() {
 	for ch := range b.subscribers {
 		close(ch)
 	}
-	b.subscribers = map[chan statePayload]struct{}{}
+	b.subscribers = map[chan stateUpdate]struct{}{}
 	b.mu.Unlock()
 }
 
 func (b *stateBroadcaster) broadcast(msg statePayload) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.lastID++
+	update := stateUpdate{id: b.lastID, payload: msg}
+	b.history = append(b.history, update)
+	if len(b.history) > stateHistoryLimit {
+		b.history = b.history[len(b.history)-stateHistoryLimit:]
+	}
 	for ch := range b.subscribers {
 		select {
-		case ch <- msg:
+		case ch <- update:
 		default:
 		}
 	}
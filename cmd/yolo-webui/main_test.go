@@ -3,8 +3,11 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -400,3 +403,45 @@ func TestWebUIControlSetTaskStatusRequiresAuthToken(t *testing.T) {
 		t.Fatalf("expected accepted with valid token, got %d", rec.Code)
 	}
 }
+
+func TestRunMainParsesSnapshotFlags(t *testing.T) {
+	var got runConfig
+	run := func(_ context.Context, cfg runConfig) error {
+		got = cfg
+		return nil
+	}
+
+	code := RunMain([]string{
+		"--distributed-bus-address", "mem://unit",
+		"--repo", "/repo",
+		"--snapshot-interval", "10s",
+	}, run)
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d", code)
+	}
+	if got.snapshotInterval != 10*time.Second || got.snapshotPath != filepath.Join("/repo", "runner-logs", "monitor-snapshot.json") {
+		t.Fatalf("expected snapshot defaults under repo, got interval=%s path=%q", got.snapshotInterval, got.snapshotPath)
+	}
+
+	if code := RunMain([]string{"--distributed-bus-address", "mem://unit", "--snapshot-file", "state.json"}, run); code != 1 {
+		t.Fatalf("expected --snapshot-file without interval to fail, got %d", code)
+	}
+}
+
+func TestWebUIWritesStateSnapshotOnShutdown(t *testing.T) {
+	state := newWebUIState("", "", nil)
+	state.monitor.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1", WorkerID: "worker-0", Timestamp: time.Now().UTC()})
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	state.writeStateSnapshots(ctx, path, time.Hour, io.Discard)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read snapshot: %v", err)
+	}
+	if !strings.Contains(string(data), `"event_count": 1`) || !strings.Contains(string(data), `"CurrentTask": "task-1"`) {
+		t.Fatalf("unexpected snapshot:\n%s", data)
+	}
+}
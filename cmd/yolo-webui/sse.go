@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const sseKeepAliveInterval = 15 * time.Second
+
+// handleSSE serves the /ws state stream as Server-Sent Events for clients
+// behind proxies that block WebSockets. Every message carries the update ID,
+// so a reconnecting client that sends Last-Event-ID receives the updates it
+// missed, or a fresh snapshot when they are no longer retained.
+func (state *webuiState) handleSSE(w http.ResponseWriter, r *http.Request) {
+	if !state.requireAuth(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	lastID, _ := strconv.ParseUint(strings.TrimSpace(r.Header.Get("Last-Event-ID")), 10, 64)
+	updates, missed, resumed := state.hub.registerAfter(lastID)
+	defer state.hub.unregister(updates)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	sent := lastID
+	if !resumed {
+		sent = state.hub.currentID()
+		missed = []stateUpdate{{id: sent, payload: state.snapshot()}}
+	}
+	for _, update := range missed {
+		if err := writeSSEUpdate(w, update); err != nil {
+			return
+		}
+		sent = update.id
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			// An update already replayed from history can also be queued
+			// on the channel.
+			if update.id <= sent {
+				continue
+			}
+			if err := writeSSEUpdate(w, update); err != nil {
+				return
+			}
+			sent = update.id
+			flusher.Flush()
+		}
+	}
+}
+
+func writeSSEUpdate(w http.ResponseWriter, update stateUpdate) error {
+	data, err := json.Marshal(update.payload)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: state\ndata: %s\n\n", update.id, data)
+	return err
+}
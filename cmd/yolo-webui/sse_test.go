@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sseMessage struct {
+	id    string
+	event string
+	data  string
+}
+
+func readSSEMessage(t *testing.T, reader *bufio.Reader) sseMessage {
+	t.Helper()
+	lines := make(chan sseMessage, 1)
+	go func() {
+		msg := sseMessage{}
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				close(lines)
+				return
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case line == "":
+				if msg.id != "" || msg.data != "" {
+					lines <- msg
+					return
+				}
+			case strings.HasPrefix(line, "id: "):
+				msg.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				msg.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				msg.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	select {
+	case msg, ok := <-lines:
+		if !ok {
+			t.Fatalf("event stream closed")
+		}
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for SSE message")
+	}
+	return sseMessage{}
+}
+
+func openSSE(t *testing.T, url string, lastEventID string) *bufio.Reader {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("open event stream: %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response: %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+func TestWebUISSEStreamsSnapshotThenUpdates(t *testing.T) {
+	state := newWebUIState("token", "", nil)
+	server := httptest.NewServer(http.HandlerFunc(state.handleSSE))
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unauthenticated request: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized without token, got %d", resp.StatusCode)
+	}
+
+	reader := openSSE(t, server.URL+"?token=token", "")
+	first := readSSEMessage(t, reader)
+	if first.id != "0" || first.event != "state" || !strings.Contains(first.data, `"state":`) {
+		t.Fatalf("expected initial snapshot, got %#v", first)
+	}
+
+	state.setConfig(uiConfig{Source: "worker-1"})
+	next := readSSEMessage(t, reader)
+	if next.id != "1" || !strings.Contains(next.data, `"source":"worker-1"`) {
+		t.Fatalf("expected broadcast update, got %#v", next)
+	}
+}
+
+func TestWebUISSEResumesFromLastEventID(t *testing.T) {
+	state := newWebUIState("", "", nil)
+	server := httptest.NewServer(http.HandlerFunc(state.handleSSE))
+	t.Cleanup(server.Close)
+	for _, source := range []string{"a", "b", "c"} {
+		state.setConfig(uiConfig{Source: source})
+	}
+
+	reader := openSSE(t, server.URL, "1")
+	for _, want := range []sseMessage{{id: "2", data: `"source":"b"`}, {id: "3", data: `"source":"c"`}} {
+		got := readSSEMessage(t, reader)
+		if got.id != want.id || !strings.Contains(got.data, want.data) {
+			t.Fatalf("expected replayed update %#v, got %#v", want, got)
+		}
+	}
+	state.setConfig(uiConfig{Source: "d"})
+	if got := readSSEMessage(t, reader); got.id != "4" || !strings.Contains(got.data, `"source":"d"`) {
+		t.Fatalf("expected live update after replay, got %#v", got)
+	}
+
+	// An ID from another server instance cannot be resumed; start over from
+	// a snapshot of the current state.
+	reader = openSSE(t, server.URL, "99")
+	if got := readSSEMessage(t, reader); got.id != "4" || !strings.Contains(got.data, `"source":"d"`) {
+		t.Fatalf("expected fresh snapshot for unknown ID, got %#v", got)
+	}
+}
+
+func TestStateBroadcasterRegisterAfterTrimsHistory(t *testing.T) {
+	hub := newStateBroadcaster()
+	for i := 0; i < stateHistoryLimit+10; i++ {
+		hub.broadcast(statePayload{})
+	}
+
+	ch, missed, resumed := hub.registerAfter(5)
+	hub.unregister(ch)
+	if resumed || len(missed) != 0 {
+		t.Fatalf("expected an expired ID to require a snapshot, got resumed=%v missed=%d", resumed, len(missed))
+	}
+	ch, missed, resumed = hub.registerAfter(uint64(stateHistoryLimit + 8))
+	hub.unregister(ch)
+	if !resumed || len(missed) != 2 || missed[0].id != uint64(stateHistoryLimit+9) {
+		t.Fatalf("expected the two newest updates, got resumed=%v missed=%#v", resumed, missed)
+	}
+	ch, missed, resumed = hub.registerAfter(uint64(stateHistoryLimit + 10))
+	hub.shutdown()
+	hub.unregister(ch)
+	if !resumed || len(missed) != 0 {
+		t.Fatalf("expected an up-to-date client to resume with nothing missed, got resumed=%v missed=%d", resumed, len(missed))
+	}
+}
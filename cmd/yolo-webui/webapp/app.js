@@ -112,10 +112,9 @@
         window.location.host +
         "/ws" +
         wsAuthSuffix;
-      const socket = new WebSocket(wsURL);
-      socket.onmessage = (event) => {
+      const applyPayload = (data, label) => {
         try {
-          const payload = JSON.parse(event.data);
+          const payload = JSON.parse(data);
           if (!payload) {
             return;
           }
@@ -124,13 +123,37 @@
             Config: payload.config || prev.Config || { Source: "" },
           }));
         } catch (err) {
-          setError("websocket decode failed");
+          setError(label + " decode failed");
         }
       };
-      socket.onopen = () => setError("");
+      let events = null;
+      let opened = false;
+      const socket = new WebSocket(wsURL);
+      socket.onmessage = (event) => applyPayload(event.data, "websocket");
+      socket.onopen = () => {
+        opened = true;
+        setError("");
+      };
       socket.onerror = () => setError("websocket error");
-      socket.onclose = () => setError("websocket disconnected");
-      return () => socket.close();
+      socket.onclose = () => {
+        if (opened || events) {
+          setError("websocket disconnected");
+          return;
+        }
+        // WebSockets look blocked; fall back to Server-Sent Events, which
+        // reconnect and resume on their own.
+        events = new EventSource("/events" + apiAuthSuffix);
+        events.addEventListener("state", (event) => applyPayload(event.data, "event stream"));
+        events.onopen = () => setError("");
+        events.onerror = () => setError("event stream reconnecting");
+      };
+      return () => {
+        socket.onclose = null;
+        socket.close();
+        if (events) {
+          events.close();
+        }
+      };
     }, []);
 
     const state = snapshot.State || emptyState;
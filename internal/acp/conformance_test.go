@@ -0,0 +1,38 @@
+package acp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/contracts/conformance"
+	acpgo "github.com/ironpark/acp-go"
+)
+
+func TestStdioRunnerAdapterConformance(t *testing.T) {
+	conformance.RunAgentRunnerSuite(t, conformance.Config{
+		Backend: "acp",
+		Model:   "acp-model",
+		NewAdapter: func(t *testing.T, scenario conformance.Scenario) contracts.AgentRunner {
+			t.Helper()
+			agent := &scriptedAgent{stopReason: acpgo.StopReasonEndTurn}
+			switch scenario {
+			case conformance.ScenarioSuccess:
+				agent.updates = []acpgo.SessionUpdate{textChunk("working line\n")}
+			case conformance.ScenarioReviewPass:
+				agent.updates = []acpgo.SessionUpdate{textChunk("REVIEW_VERDICT: pass\n")}
+			case conformance.ScenarioReviewFail:
+				agent.updates = []acpgo.SessionUpdate{textChunk("REVIEW_VERDICT: fail\n")}
+			case conformance.ScenarioTimeoutError, conformance.ScenarioContextTimeoutNoErr:
+				agent.updates = []acpgo.SessionUpdate{textChunk("still working\n")}
+				agent.delay = 50 * time.Millisecond
+			case conformance.ScenarioFailure:
+				agent.err = errors.New(conformance.FailureReason)
+			default:
+				t.Fatalf("unsupported scenario %q", scenario)
+			}
+			return newTestAdapter(agent, nil)
+		},
+	})
+}
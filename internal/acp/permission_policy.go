@@ -0,0 +1,352 @@
+package acp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	acpgo "github.com/ironpark/acp-go"
+)
+
+type PermissionDecision string
+
+const (
+	PermissionAllow PermissionDecision = "allow"
+	PermissionDeny  PermissionDecision = "deny"
+	PermissionAsk   PermissionDecision = "ask"
+)
+
+const defaultControlSocketTimeout = 2 * time.Minute
+
+// editToolKinds change files; they are only allowed when every location the
+// agent reported is inside the task clone.
+var editToolKinds = map[string]struct{}{"edit": {}, "delete": {}, "move": {}}
+
+// executeToolKind runs a command; the tool policy applies to it.
+const executeToolKind = "execute"
+
+// PermissionPolicy decides ACP permission requests by tool kind (read, edit,
+// delete, move, search, execute, think, fetch, switch_mode, other). Kinds
+// without a rule use Default.
+type PermissionPolicy struct {
+	Kinds   map[string]PermissionDecision
+	Default PermissionDecision
+}
+
+// DefaultPermissionPolicy auto-allows reads, allows edits within the clone,
+// denies network fetches and asks for everything else.
+func DefaultPermissionPolicy() PermissionPolicy {
+	return PermissionPolicy{
+		Kinds: map[string]PermissionDecision{
+			"read":   PermissionAllow,
+			"search": PermissionAllow,
+			"think":  PermissionAllow,
+			"edit":   PermissionAllow,
+			"delete": PermissionAllow,
+			"move":   PermissionAllow,
+			"fetch":  PermissionDeny,
+		},
+		Default: PermissionAsk,
+	}
+}
+
+// ParsePermissionPolicy overlays rules on the default policy. The "default"
+// key replaces the fallback decision.
+func ParsePermissionPolicy(rules map[string]string) (PermissionPolicy, error) {
+	policy := DefaultPermissionPolicy()
+	keys := make([]string, 0, len(rules))
+	for key := range rules {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		kind := strings.ToLower(strings.TrimSpace(key))
+		decision := PermissionDecision(strings.ToLower(strings.TrimSpace(rules[key])))
+		switch decision {
+		case PermissionAllow, PermissionDeny, PermissionAsk:
+		default:
+			return PermissionPolicy{}, fmt.Errorf("permission rule %q must be one of allow, deny, ask", key)
+		}
+		if kind == "default" {
+			policy.Default = decision
+			continue
+		}
+		policy.Kinds[kind] = decision
+	}
+	return policy, nil
+}
+
+// PermissionRequest describes one tool call the agent wants to run.
+type PermissionRequest struct {
+	TaskID     string
+	SessionID  string
+	ToolCallID string
+	Title      string
+	Kind       string
+	Locations  []string
+	// Command is the command line of an execute tool call, when known.
+	Command string
+}
+
+// Decide returns the policy decision and a short reason for it.
+func (p PermissionPolicy) Decide(request PermissionRequest, repoRoot string) (PermissionDecision, string) {
+	kind := strings.ToLower(strings.TrimSpace(request.Kind))
+	if kind == "" {
+		kind = "other"
+	}
+	decision, ok := p.Kinds[kind]
+	reason := "policy rule for " + kind
+	if !ok {
+		decision = p.Default
+		reason = "default policy"
+	}
+	if decision == "" {
+		decision = PermissionAsk
+	}
+	if _, isEdit := editToolKinds[kind]; isEdit && decision == PermissionAllow {
+		if len(request.Locations) == 0 {
+			return p.fallback(), "edit without reported locations"
+		}
+		for _, location := range request.Locations {
+			if !withinRoot(repoRoot, location) {
+				return PermissionDeny, "edit outside clone: " + location
+			}
+		}
+		return PermissionAllow, "edit within clone"
+	}
+	return decision, reason
+}
+
+func (p PermissionPolicy) fallback() PermissionDecision {
+	if p.Default == "" || p.Default == PermissionAllow {
+		return PermissionAsk
+	}
+	return p.Default
+}
+
+func withinRoot(root string, path string) bool {
+	root = strings.TrimSpace(root)
+	path = strings.TrimSpace(path)
+	if root == "" || path == "" {
+		return false
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// PermissionAsker resolves "ask" decisions with an operator.
+type PermissionAsker interface {
+	AskPermission(ctx context.Context, request PermissionRequest) (bool, error)
+}
+
+// ControlSocketAsker forwards permission questions to an operator process
+// listening on a Unix socket. Each question is one JSON line:
+//
+//	{"type":"permission_request","task_id":"...","tool_call_id":"...","title":"...","kind":"...","locations":[...]}
+//
+// and the operator answers with one JSON line: {"allow":true}.
+type ControlSocketAsker struct {
+	path    string
+	timeout time.Duration
+}
+
+func NewControlSocketAsker(path string, timeout time.Duration) *ControlSocketAsker {
+	if timeout <= 0 {
+		timeout = defaultControlSocketTimeout
+	}
+	return &ControlSocketAsker{path: strings.TrimSpace(path), timeout: timeout}
+}
+
+type controlSocketRequest struct {
+	Type       string   `json:"type"`
+	TaskID     string   `json:"task_id,omitempty"`
+	SessionID  string   `json:"session_id,omitempty"`
+	ToolCallID string   `json:"tool_call_id,omitempty"`
+	Title      string   `json:"title,omitempty"`
+	Kind       string   `json:"kind,omitempty"`
+	Locations  []string `json:"locations,omitempty"`
+	Command    string   `json:"command,omitempty"`
+}
+
+type controlSocketResponse struct {
+	Allow bool `json:"allow"`
+}
+
+func (a *ControlSocketAsker) AskPermission(ctx context.Context, request PermissionRequest) (bool, error) {
+	if a == nil || a.path == "" {
+		return false, errors.New("no control socket configured")
+	}
+	askCtx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(askCtx, "unix", a.path)
+	if err != nil {
+		return false, fmt.Errorf("dial control socket: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := askCtx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	payload, err := json.Marshal(controlSocketRequest{
+		Type:       "permission_request",
+		TaskID:     request.TaskID,
+		SessionID:  request.SessionID,
+		ToolCallID: request.ToolCallID,
+		Title:      request.Title,
+		Kind:       request.Kind,
+		Locations:  request.Locations,
+		Command:    request.Command,
+	})
+	if err != nil {
+		return false, err
+	}
+	if _, err := conn.Write(append(payload, '\n')); err != nil {
+		return false, fmt.Errorf("write control socket: %w", err)
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return false, fmt.Errorf("read control socket: %w", err)
+	}
+	var response controlSocketResponse
+	if err := json.Unmarshal(line, &response); err != nil {
+		return false, fmt.Errorf("decode control socket answer: %w", err)
+	}
+	return response.Allow, nil
+}
+
+// permissionGate applies the policy for one run and resolves "ask" decisions
+// through the asker, so the result is always allow or deny.
+type permissionGate struct {
+	policy   PermissionPolicy
+	asker    PermissionAsker
+	repoRoot string
+	taskID   string
+	// tools is checked before the policy for tool calls that run a command.
+	tools contracts.ToolPolicy
+	// onAsk, if set, is called before the asker is consulted so the pending
+	// question is visible while the operator decides.
+	onAsk func(request PermissionRequest, reason string)
+}
+
+func (g permissionGate) decide(ctx context.Context, request PermissionRequest) (PermissionDecision, string) {
+	if decision, reason, ok := g.decideTool(request); ok {
+		return decision, reason
+	}
+	decision, reason := g.policy.Decide(request, g.repoRoot)
+	if decision != PermissionAsk {
+		return decision, reason
+	}
+	if g.asker == nil {
+		return PermissionDeny, reason + "; no control socket configured"
+	}
+	if g.onAsk != nil {
+		g.onAsk(request, reason)
+	}
+	allowed, err := g.asker.AskPermission(ctx, request)
+	if err != nil {
+		return PermissionDeny, reason + "; ask failed: " + err.Error()
+	}
+	if allowed {
+		return PermissionAllow, reason + "; allowed by operator"
+	}
+	return PermissionDeny, reason + "; denied by operator"
+}
+
+// decideTool applies the tool policy to a tool call that runs a command. A
+// denied command is denied outright, and with an allowlist an allowed one is
+// allowed without asking. Otherwise the permission policy decides.
+func (g permissionGate) decideTool(request PermissionRequest) (PermissionDecision, string, bool) {
+	if g.tools.IsZero() {
+		return "", "", false
+	}
+	if request.Command == "" && !strings.EqualFold(strings.TrimSpace(request.Kind), executeToolKind) {
+		return "", "", false
+	}
+	allowed, reason := g.tools.CheckCommand(request.Command)
+	if !allowed {
+		return PermissionDeny, reason, true
+	}
+	if len(g.tools.Allow) > 0 {
+		return PermissionAllow, reason, true
+	}
+	return "", "", false
+}
+
+func permissionRequestFromACP(params *acpgo.RequestPermissionRequest) PermissionRequest {
+	request := PermissionRequest{
+		SessionID:  string(params.SessionId),
+		ToolCallID: string(params.ToolCall.ToolCallId),
+		Title:      strings.TrimSpace(params.ToolCall.Title),
+	}
+	if params.ToolCall.Kind != nil {
+		request.Kind = string(*params.ToolCall.Kind)
+	}
+	for _, location := range params.ToolCall.Locations {
+		if path := strings.TrimSpace(location.Path); path != "" {
+			request.Locations = append(request.Locations, path)
+		}
+	}
+	request.Command = contracts.ToolCallCommand(request.Kind, request.Title, params.ToolCall.RawInput)
+	return request
+}
+
+// permissionResponse picks a one-shot option when available so that later
+// calls of the same tool are decided by the policy again.
+func permissionResponse(options []acpgo.PermissionOption, decision PermissionDecision) *acpgo.RequestPermissionResponse {
+	preferred := []acpgo.PermissionOptionKind{acpgo.PermissionOptionKindRejectOnce, acpgo.PermissionOptionKindRejectAlways}
+	if decision == PermissionAllow {
+		preferred = []acpgo.PermissionOptionKind{acpgo.PermissionOptionKindAllowOnce, acpgo.PermissionOptionKindAllowAlways}
+	}
+	for _, kind := range preferred {
+		for _, option := range options {
+			if option.Kind == kind {
+				return &acpgo.RequestPermissionResponse{Outcome: acpgo.NewRequestPermissionOutcomeSelected(option.OptionId)}
+			}
+		}
+	}
+	return &acpgo.RequestPermissionResponse{Outcome: acpgo.NewRequestPermissionOutcomeCancelled()}
+}
+
+func permissionProgress(request PermissionRequest, decision PermissionDecision, reason string) contracts.RunnerProgress {
+	kind := request.Kind
+	if kind == "" {
+		kind = "other"
+	}
+	metadata := map[string]string{
+		"decision": string(decision),
+		"kind":     kind,
+		"reason":   reason,
+	}
+	if request.SessionID != "" {
+		metadata["session_id"] = request.SessionID
+	}
+	if request.ToolCallID != "" {
+		metadata["tool_call_id"] = request.ToolCallID
+	}
+	if len(request.Locations) > 0 {
+		metadata["locations"] = strings.Join(request.Locations, ",")
+	}
+	if request.Command != "" {
+		metadata["command"] = request.Command
+	}
+	return contracts.RunnerProgress{
+		Type:      string(contracts.EventTypeRunnerPermission),
+		Message:   string(decision) + " " + kind + ": " + request.Title,
+		Metadata:  metadata,
+		Timestamp: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,176 @@
+package acp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	acpgo "github.com/ironpark/acp-go"
+)
+
+func TestDefaultPermissionPolicyDecisions(t *testing.T) {
+	root := t.TempDir()
+	policy := DefaultPermissionPolicy()
+	cases := []struct {
+		name    string
+		request PermissionRequest
+		want    PermissionDecision
+	}{
+		{"read", PermissionRequest{Kind: "read", Locations: []string{"/etc/passwd"}}, PermissionAllow},
+		{"edit in clone", PermissionRequest{Kind: "edit", Locations: []string{filepath.Join(root, "main.go"), "docs/a.md"}}, PermissionAllow},
+		{"edit outside clone", PermissionRequest{Kind: "edit", Locations: []string{filepath.Join(root, "..", "other", "main.go")}}, PermissionDeny},
+		{"delete without locations", PermissionRequest{Kind: "delete"}, PermissionAsk},
+		{"network", PermissionRequest{Kind: "fetch"}, PermissionDeny},
+		{"execute", PermissionRequest{Kind: "execute"}, PermissionAsk},
+		{"missing kind", PermissionRequest{}, PermissionAsk},
+	}
+	for _, tc := range cases {
+		if got, reason := policy.Decide(tc.request, root); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s (%s)", tc.name, tc.want, got, reason)
+		}
+	}
+}
+
+func TestParsePermissionPolicyOverridesDefaults(t *testing.T) {
+	policy, err := ParsePermissionPolicy(map[string]string{"Execute": "allow", "default": "deny"})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if got, _ := policy.Decide(PermissionRequest{Kind: "execute"}, "/repo"); got != PermissionAllow {
+		t.Fatalf("expected execute override to allow, got %s", got)
+	}
+	if got, _ := policy.Decide(PermissionRequest{Kind: "switch_mode"}, "/repo"); got != PermissionDeny {
+		t.Fatalf("expected default override to deny, got %s", got)
+	}
+	if got, _ := policy.Decide(PermissionRequest{Kind: "edit"}, "/repo"); got != PermissionDeny {
+		t.Fatalf("expected edit without locations to use default deny, got %s", got)
+	}
+	if _, err := ParsePermissionPolicy(map[string]string{"read": "maybe"}); err == nil {
+		t.Fatalf("expected invalid decision error")
+	}
+}
+
+func TestPermissionGateResolvesAskThroughControlSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "acp-sock")
+	if err != nil {
+		t.Fatalf("create socket dir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, "control.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	questions := make(chan controlSocketRequest, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			line, _ := bufio.NewReader(conn).ReadBytes('\n')
+			var question controlSocketRequest
+			_ = json.Unmarshal(line, &question)
+			questions <- question
+			_, _ = conn.Write([]byte(`{"allow":` + map[bool]string{true: "true", false: "false"}[question.Kind == "execute"] + "}\n"))
+			_ = conn.Close()
+		}
+	}()
+
+	gate := permissionGate{policy: DefaultPermissionPolicy(), asker: NewControlSocketAsker(socketPath, time.Second), repoRoot: "/repo", taskID: "t-1"}
+	decision, reason := gate.decide(context.Background(), PermissionRequest{TaskID: "t-1", ToolCallID: "c-1", Kind: "execute", Title: "go test ./..."})
+	if decision != PermissionAllow || !strings.Contains(reason, "allowed by operator") {
+		t.Fatalf("expected operator allow, got %s (%s)", decision, reason)
+	}
+	question := <-questions
+	if question.Type != "permission_request" || question.TaskID != "t-1" || question.Title != "go test ./..." {
+		t.Fatalf("unexpected control socket question %#v", question)
+	}
+	decision, reason = gate.decide(context.Background(), PermissionRequest{Kind: "switch_mode"})
+	if decision != PermissionDeny || !strings.Contains(reason, "denied by operator") {
+		t.Fatalf("expected operator deny, got %s (%s)", decision, reason)
+	}
+
+	noSocket := permissionGate{policy: DefaultPermissionPolicy()}
+	if decision, reason := noSocket.decide(context.Background(), PermissionRequest{Kind: "execute"}); decision != PermissionDeny || !strings.Contains(reason, "no control socket") {
+		t.Fatalf("expected deny without control socket, got %s (%s)", decision, reason)
+	}
+}
+
+type permissionAskerFunc func(ctx context.Context, request PermissionRequest) (bool, error)
+
+func (f permissionAskerFunc) AskPermission(ctx context.Context, request PermissionRequest) (bool, error) {
+	return f(ctx, request)
+}
+
+func TestPermissionGateReportsPendingAskBeforeAsking(t *testing.T) {
+	steps := []string{}
+	gate := permissionGate{
+		policy: DefaultPermissionPolicy(),
+		asker: permissionAskerFunc(func(context.Context, PermissionRequest) (bool, error) {
+			steps = append(steps, "ask")
+			return true, nil
+		}),
+		onAsk: func(request PermissionRequest, reason string) {
+			steps = append(steps, "pending "+request.Kind)
+		},
+	}
+	if decision, _ := gate.decide(context.Background(), PermissionRequest{Kind: "execute"}); decision != PermissionAllow {
+		t.Fatalf("expected operator allow, got %s", decision)
+	}
+	if strings.Join(steps, ",") != "pending execute,ask" {
+		t.Fatalf("expected pending notice before the question, got %v", steps)
+	}
+}
+
+func TestPermissionGateAppliesToolPolicyToCommands(t *testing.T) {
+	asked := 0
+	gate := permissionGate{
+		policy: DefaultPermissionPolicy(),
+		tools:  contracts.ToolPolicy{Allow: []string{"go test"}, Deny: []string{"docker"}},
+		asker: permissionAskerFunc(func(context.Context, PermissionRequest) (bool, error) {
+			asked++
+			return true, nil
+		}),
+	}
+	cases := []struct {
+		request PermissionRequest
+		want    PermissionDecision
+	}{
+		{PermissionRequest{Kind: "execute", Command: "go test ./..."}, PermissionAllow},
+		{PermissionRequest{Kind: "execute", Command: "docker ps"}, PermissionDeny},
+		{PermissionRequest{Kind: "execute", Command: "go build ./..."}, PermissionDeny},
+		{PermissionRequest{Kind: "execute"}, PermissionDeny},
+		{PermissionRequest{Kind: "read", Locations: []string{"/repo/main.go"}}, PermissionAllow},
+	}
+	for _, tc := range cases {
+		if decision, reason := gate.decide(context.Background(), tc.request); decision != tc.want {
+			t.Fatalf("%+v: expected %s, got %s (%s)", tc.request, tc.want, decision, reason)
+		}
+	}
+	if asked != 0 {
+		t.Fatalf("expected the tool policy to decide without asking, asked %d times", asked)
+	}
+}
+
+func TestPermissionRequestFromACPReadsCommand(t *testing.T) {
+	execute := acpgo.ToolKindExecute
+	cases := map[string]*acpgo.RequestPermissionRequest{
+		"go test ./...": {ToolCall: acpgo.ToolCallUpdate{Kind: &execute, RawInput: json.RawMessage(`{"command":"go test ./..."}`)}},
+		"bash -lc make": {ToolCall: acpgo.ToolCallUpdate{Kind: &execute, RawInput: json.RawMessage(`{"command":["bash","-lc","make"]}`)}},
+		"git status":    {ToolCall: acpgo.ToolCallUpdate{Kind: &execute, Title: "`git status`"}},
+	}
+	for want, params := range cases {
+		if got := permissionRequestFromACP(params).Command; got != want {
+			t.Fatalf("expected command %q, got %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,577 @@
+package acp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/opencode"
+	acpgo "github.com/ironpark/acp-go"
+)
+
+const backendName = "acp"
+
+// defaultSettleDelay is how long the adapter waits for trailing session
+// updates after the prompt turn ends. The ACP connection dispatches every
+// message on its own goroutine, so updates can land after the response.
+const defaultSettleDelay = 150 * time.Millisecond
+
+var structuredReviewVerdictLinePattern = regexp.MustCompile(`(?i)^\s*REVIEW_VERDICT\s*:\s*(pass|fail)(?:\s*DONE)?\s*$`)
+var structuredReviewFailFeedbackLinePattern = regexp.MustCompile(`(?i)^\s*REVIEW_(?:FAIL_)?FEEDBACK\s*:\s*(.+?)\s*$`)
+
+type CommandSpec struct {
+	Binary string
+	Args   []string
+	Env    []string
+	Dir    string
+	Stderr io.Writer
+}
+
+// Process is a running ACP agent. Stdin and Stdout carry the JSON-RPC stream.
+type Process interface {
+	Stdin() io.WriteCloser
+	Stdout() io.ReadCloser
+	Wait() error
+	Kill() error
+}
+
+type ProcessStarter interface {
+	Start(ctx context.Context, spec CommandSpec) (Process, error)
+}
+
+type processStarterFunc func(ctx context.Context, spec CommandSpec) (Process, error)
+
+func (f processStarterFunc) Start(ctx context.Context, spec CommandSpec) (Process, error) {
+	return f(ctx, spec)
+}
+
+// StdioRunnerAdapter runs any ACP-compatible agent over stdio: it performs the
+// initialize/newSession/prompt exchange, forwards session updates as runner
+// progress and maps the prompt stop reason to the runner result.
+type StdioRunnerAdapter struct {
+	binary  string
+	args    []string
+	starter ProcessStarter
+	now     func() time.Time
+	settle  time.Duration
+	policy  PermissionPolicy
+	asker   PermissionAsker
+}
+
+func NewStdioRunnerAdapter(binary string, starter ProcessStarter, args ...string) *StdioRunnerAdapter {
+	if starter == nil {
+		starter = processStarterFunc(startProcess)
+	}
+	return &StdioRunnerAdapter{
+		binary:  strings.TrimSpace(binary),
+		args:    append([]string(nil), args...),
+		starter: starter,
+		now:     time.Now,
+		settle:  defaultSettleDelay,
+		policy:  DefaultPermissionPolicy(),
+	}
+}
+
+// WithPermissionPolicy sets how permission requests are decided. "ask"
+// decisions go to asker and are denied when it is nil or fails.
+func (a *StdioRunnerAdapter) WithPermissionPolicy(policy PermissionPolicy, asker PermissionAsker) *StdioRunnerAdapter {
+	if a == nil {
+		return nil
+	}
+	a.policy = policy
+	a.asker = asker
+	return a
+}
+
+func (a *StdioRunnerAdapter) Run(ctx context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if a == nil {
+		return contracts.RunnerResult{}, errors.New("nil acp runner adapter")
+	}
+	if a.binary == "" {
+		return contracts.RunnerResult{}, errors.New("acp agent binary is required")
+	}
+	if a.starter == nil {
+		a.starter = processStarterFunc(startProcess)
+	}
+	if a.now == nil {
+		a.now = time.Now
+	}
+
+	startedAt := a.now().UTC()
+	logPath := resolveLogPath(request)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return contracts.RunnerResult{}, err
+	}
+	logFile, err := contracts.CreateRunnerLog(logPath, request.Secrets)
+	if err != nil {
+		return contracts.RunnerResult{}, err
+	}
+	defer logFile.Close()
+	stderrFile, err := contracts.CreateRunnerLog(contracts.BackendLogSidecarPath(logPath, contracts.BackendLogStderr), request.Secrets)
+	if err != nil {
+		return contracts.RunnerResult{}, err
+	}
+	defer stderrFile.Close()
+	terminalFile, err := contracts.CreateRunnerLog(contracts.BackendLogSidecarPath(logPath, contracts.BackendLogTerminal), request.Secrets)
+	if err != nil {
+		return contracts.RunnerResult{}, err
+	}
+	defer terminalFile.Close()
+
+	runCtx, cancel := contracts.WithOptionalTimeout(ctx, request.Timeout)
+	defer cancel()
+
+	client := newStdioClient(logFile, request.OnProgress)
+	client.permissions = permissionGate{policy: a.policy, tools: request.ToolPolicy, asker: a.asker, repoRoot: request.RepoRoot, taskID: request.TaskID}
+	client.terminals = newTerminalManager(request.RepoRoot, terminalFile, client.emit)
+	client.terminals.env = contracts.RunnerEnv(request.Env)
+	client.terminals.tools = request.ToolPolicy
+	stopReason, sessionID, runErr := a.runSession(runCtx, request, client, stderrFile)
+	client.terminals.closeAll()
+	client.close()
+	runErr = contracts.FinalizeRunError(runCtx, runErr)
+
+	finishedAt := a.now().UTC()
+	result := contracts.NormalizeBackendRunnerResult(startedAt, finishedAt, request, runErr, nil)
+	if runErr == nil {
+		applyStopReason(&result, stopReason)
+	}
+	result.LogPath = logPath
+	extras := map[string]string{}
+	if stopReason != "" {
+		extras["stop_reason"] = string(stopReason)
+	}
+	if sessionID != "" {
+		extras[contracts.SessionIDArtifactKey] = string(sessionID)
+	}
+	if request.Mode == contracts.RunnerModeReview {
+		if verdict, ok := structuredReviewVerdict(logPath); ok {
+			extras["review_verdict"] = verdict
+			if verdict == "fail" {
+				if feedback, ok := structuredReviewFailFeedback(logPath); ok {
+					extras["review_fail_feedback"] = feedback
+				}
+			}
+			if result.Status == contracts.RunnerResultCompleted {
+				result.ReviewReady = verdict == "pass"
+			}
+		}
+	}
+	result.Artifacts = contracts.BuildRunnerArtifacts(backendName, request, result, extras)
+	return result, nil
+}
+
+func (a *StdioRunnerAdapter) runSession(ctx context.Context, request contracts.RunnerRequest, client *stdioClient, stderr io.Writer) (acpgo.StopReason, acpgo.SessionId, error) {
+	proc, err := a.starter.Start(ctx, CommandSpec{
+		Binary: a.binary,
+		Args:   resolveArgs(a.args, request),
+		Env:    contracts.RunnerEnv(request.Env),
+		Dir:    request.RepoRoot,
+		Stderr: stderr,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	stdin := proc.Stdin()
+	stdout := proc.Stdout()
+	connection := acpgo.NewClientSideConnection(client, stdin, stdout)
+	go func() {
+		_ = connection.Start(ctx)
+	}()
+	defer func() {
+		_ = connection.Close()
+		_ = stdin.Close()
+		_ = stdout.Close()
+		waitDone := make(chan struct{})
+		go func() {
+			_ = proc.Wait()
+			close(waitDone)
+		}()
+		select {
+		case <-waitDone:
+		case <-time.After(time.Second):
+			_ = proc.Kill()
+		}
+	}()
+
+	initialized, err := connection.Initialize(ctx, &acpgo.InitializeRequest{
+		ProtocolVersion: acpgo.ProtocolVersion(acpgo.CurrentProtocolVersion),
+		ClientCapabilities: &acpgo.ClientCapabilities{
+			Fs:       &acpgo.FileSystemCapability{ReadTextFile: true, WriteTextFile: true},
+			Terminal: client.terminals != nil,
+		},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("acp initialize: %w", err)
+	}
+	sessionID, prompt, err := a.openSession(ctx, connection, initialized, request, client)
+	if err != nil {
+		return "", "", err
+	}
+	response, err := connection.Prompt(ctx, &acpgo.PromptRequest{
+		SessionId: sessionID,
+		Prompt:    []acpgo.ContentBlock{acpgo.NewContentBlockText(prompt)},
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			// Let the agent stop its turn; the connection closes right after.
+			_ = connection.Cancel(context.Background(), &acpgo.CancelNotification{SessionId: sessionID})
+		}
+		return "", sessionID, fmt.Errorf("acp prompt: %w", err)
+	}
+	client.waitForIdle(ctx, a.settle)
+	if progress, ok := opencode.NormalizeACPPromptResponse(response); ok {
+		client.emit(progress)
+	}
+	return response.StopReason, sessionID, nil
+}
+
+// openSession starts the session the prompt runs in and returns the prompt to
+// send. A resume request loads the previous session when the agent supports
+// session/load; the history it replays is not logged or reported again.
+// Otherwise a new session gets the full task prompt.
+func (a *StdioRunnerAdapter) openSession(ctx context.Context, connection *acpgo.ClientSideConnection, initialized *acpgo.InitializeResponse, request contracts.RunnerRequest, client *stdioClient) (acpgo.SessionId, string, error) {
+	if resumeID, resumePrompt, ok := contracts.ResumeSessionFromRequest(request); ok {
+		if initialized != nil && initialized.AgentCapabilities != nil && initialized.AgentCapabilities.LoadSession {
+			client.setReplaying(true)
+			_, err := connection.LoadSession(ctx, &acpgo.LoadSessionRequest{
+				SessionId:  acpgo.SessionId(resumeID),
+				Cwd:        request.RepoRoot,
+				McpServers: []acpgo.McpServer{},
+			})
+			client.waitForIdle(ctx, a.settle)
+			client.setReplaying(false)
+			if err == nil {
+				return acpgo.SessionId(resumeID), resumePrompt, nil
+			}
+			client.emit(resumeWarning(fmt.Sprintf("acp load session %s failed, starting a new session: %v", resumeID, err)))
+		} else {
+			client.emit(resumeWarning("acp agent does not support session/load, starting a new session"))
+		}
+	}
+	session, err := connection.NewSession(ctx, &acpgo.NewSessionRequest{
+		Cwd:        request.RepoRoot,
+		McpServers: []acpgo.McpServer{},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("acp new session: %w", err)
+	}
+	return session.SessionId, request.Prompt, nil
+}
+
+func resumeWarning(message string) contracts.RunnerProgress {
+	return contracts.RunnerProgress{
+		Type:      string(contracts.EventTypeRunnerWarning),
+		Message:   message,
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// applyStopReason maps a completed prompt turn to the runner result. Only
+// end_turn means the agent finished its work.
+func applyStopReason(result *contracts.RunnerResult, stopReason acpgo.StopReason) {
+	switch stopReason {
+	case acpgo.StopReasonEndTurn, "":
+		return
+	case acpgo.StopReasonRefusal:
+		result.Status = contracts.RunnerResultFailed
+	default:
+		result.Status = contracts.RunnerResultBlocked
+	}
+	result.Reason = "acp agent stopped: " + string(stopReason)
+}
+
+func resolveLogPath(request contracts.RunnerRequest) string {
+	if request.Metadata != nil {
+		if path := strings.TrimSpace(request.Metadata["log_path"]); path != "" {
+			return path
+		}
+	}
+	if strings.TrimSpace(request.RepoRoot) != "" && strings.TrimSpace(request.TaskID) != "" {
+		return filepath.Join(request.RepoRoot, "runner-logs", backendName, request.TaskID+".jsonl")
+	}
+	if strings.TrimSpace(request.TaskID) != "" {
+		return filepath.Join("runner-logs", backendName, request.TaskID+".jsonl")
+	}
+	return filepath.Join("runner-logs", backendName, "acp-run.jsonl")
+}
+
+func resolveArgs(raw []string, request contracts.RunnerRequest) []string {
+	template := map[string]string{
+		"{{model}}":     strings.TrimSpace(request.Model),
+		"{{task_id}}":   strings.TrimSpace(request.TaskID),
+		"{{repo_root}}": strings.TrimSpace(request.RepoRoot),
+		"{{mode}}":      strings.TrimSpace(string(request.Mode)),
+	}
+	out := make([]string, 0, len(raw))
+	for _, value := range raw {
+		text := strings.TrimSpace(value)
+		for placeholder, replacement := range template {
+			text = strings.ReplaceAll(text, placeholder, replacement)
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		out = append(out, text)
+	}
+	return out
+}
+
+type execProcess struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+}
+
+func (p *execProcess) Stdin() io.WriteCloser { return p.stdin }
+func (p *execProcess) Stdout() io.ReadCloser { return p.stdout }
+func (p *execProcess) Wait() error           { return p.cmd.Wait() }
+
+func (p *execProcess) Kill() error {
+	if p.cmd.Process == nil {
+		return nil
+	}
+	return p.cmd.Process.Kill()
+}
+
+func startProcess(ctx context.Context, spec CommandSpec) (Process, error) {
+	if strings.TrimSpace(spec.Binary) == "" {
+		return nil, errors.New("acp agent binary is required")
+	}
+	cmd := exec.CommandContext(ctx, spec.Binary, spec.Args...)
+	if strings.TrimSpace(spec.Dir) != "" {
+		cmd.Dir = spec.Dir
+	}
+	if len(spec.Env) > 0 {
+		cmd.Env = append(os.Environ(), spec.Env...)
+	}
+	cmd.Stderr = spec.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &execProcess{cmd: cmd, stdin: stdin, stdout: stdout}, nil
+}
+
+func structuredReviewVerdict(logPath string) (string, bool) {
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		return "", false
+	}
+	verdict := ""
+	found := false
+	for _, line := range strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n") {
+		if matches := structuredReviewVerdictLinePattern.FindStringSubmatch(line); len(matches) == 2 {
+			verdict = strings.ToLower(matches[1])
+			found = true
+		}
+	}
+	return verdict, found
+}
+
+func structuredReviewFailFeedback(logPath string) (string, bool) {
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		return "", false
+	}
+	feedback := ""
+	for _, line := range strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n") {
+		if matches := structuredReviewFailFeedbackLinePattern.FindStringSubmatch(line); len(matches) == 2 {
+			if candidate := strings.Join(strings.Fields(matches[1]), " "); candidate != "" {
+				feedback = candidate
+			}
+		}
+	}
+	return feedback, feedback != ""
+}
+
+// stdioClient is the client side of the ACP connection. Agent message text is
+// written to the runner log so structured markers (REVIEW_VERDICT, FOLLOW_UP)
+// can be read back the same way as for CLI backends.
+type stdioClient struct {
+	mu         sync.Mutex
+	log        io.Writer
+	onProgress func(contracts.RunnerProgress)
+	lastUpdate time.Time
+	closed     bool
+	replaying  bool
+
+	permissions permissionGate
+	terminals   *terminalManager
+}
+
+func newStdioClient(log io.Writer, onProgress func(contracts.RunnerProgress)) *stdioClient {
+	return &stdioClient{log: log, onProgress: onProgress}
+}
+
+// close drops updates that arrive after the run returned, e.g. after a
+// timeout interrupted the prompt turn.
+func (c *stdioClient) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+}
+
+// setReplaying marks updates as session history replayed by session/load.
+func (c *stdioClient) setReplaying(replaying bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.replaying = replaying
+}
+
+func (c *stdioClient) emit(progress contracts.RunnerProgress) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed && c.onProgress != nil {
+		c.onProgress(progress)
+	}
+}
+
+func (c *stdioClient) waitForIdle(ctx context.Context, settle time.Duration) {
+	if settle <= 0 {
+		return
+	}
+	ticker := time.NewTicker(settle / 4)
+	defer ticker.Stop()
+	started := time.Now()
+	for {
+		c.mu.Lock()
+		last := c.lastUpdate
+		c.mu.Unlock()
+		if last.Before(started) {
+			last = started
+		}
+		if time.Since(last) >= settle {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *stdioClient) SessionUpdate(_ context.Context, params *acpgo.SessionNotification) error {
+	if params == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.lastUpdate = time.Now()
+	if c.replaying {
+		return nil
+	}
+	if chunk := params.Update.GetAgentmessagechunk(); chunk != nil && chunk.Content.IsText() && c.log != nil {
+		_, _ = io.WriteString(c.log, chunk.Content.GetText().Text)
+	}
+	if progress, ok := opencode.NormalizeACPProgressNotification(params); ok && c.onProgress != nil {
+		c.onProgress(progress)
+	}
+	return nil
+}
+
+// RequestPermission decides the tool call with the permission policy and
+// reports the decision as a runner_permission progress event.
+func (c *stdioClient) RequestPermission(ctx context.Context, params *acpgo.RequestPermissionRequest) (*acpgo.RequestPermissionResponse, error) {
+	request := permissionRequestFromACP(params)
+	request.TaskID = c.permissions.taskID
+	gate := c.permissions
+	gate.onAsk = func(request PermissionRequest, reason string) {
+		c.emit(permissionProgress(request, PermissionAsk, reason+"; waiting for operator"))
+	}
+	decision, reason := gate.decide(ctx, request)
+	c.emit(permissionProgress(request, decision, reason))
+	return permissionResponse(params.Options, decision), nil
+}
+
+func (c *stdioClient) ReadTextFile(_ context.Context, params *acpgo.ReadTextFileRequest) (*acpgo.ReadTextFileResponse, error) {
+	content, err := os.ReadFile(params.Path)
+	if err != nil {
+		return nil, err
+	}
+	text := string(content)
+	if params.Line != nil || params.Limit != nil {
+		lines := strings.Split(text, "\n")
+		start := 0
+		if params.Line != nil && *params.Line > 0 {
+			start = int(*params.Line) - 1
+		}
+		if start >= len(lines) {
+			return &acpgo.ReadTextFileResponse{}, nil
+		}
+		end := len(lines)
+		if params.Limit != nil && *params.Limit >= 0 && start+int(*params.Limit) < end {
+			end = start + int(*params.Limit)
+		}
+		text = strings.Join(lines[start:end], "\n")
+	}
+	return &acpgo.ReadTextFileResponse{Content: text}, nil
+}
+
+func (c *stdioClient) WriteTextFile(_ context.Context, params *acpgo.WriteTextFileRequest) error {
+	if err := os.MkdirAll(filepath.Dir(params.Path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(params.Path, []byte(params.Content), 0o644)
+}
+
+func (c *stdioClient) CreateTerminal(_ context.Context, params *acpgo.CreateTerminalRequest) (*acpgo.CreateTerminalResponse, error) {
+	if c.terminals == nil {
+		return nil, errors.New("terminal support disabled")
+	}
+	return c.terminals.create(params)
+}
+
+func (c *stdioClient) TerminalOutput(_ context.Context, params *acpgo.TerminalOutputRequest) (*acpgo.TerminalOutputResponse, error) {
+	if c.terminals == nil {
+		return nil, errors.New("terminal support disabled")
+	}
+	return c.terminals.output(params.TerminalId)
+}
+
+func (c *stdioClient) ReleaseTerminal(_ context.Context, params *acpgo.ReleaseTerminalRequest) error {
+	if c.terminals == nil {
+		return errors.New("terminal support disabled")
+	}
+	return c.terminals.release(params.TerminalId)
+}
+
+func (c *stdioClient) WaitForTerminalExit(ctx context.Context, params *acpgo.WaitForTerminalExitRequest) (*acpgo.WaitForTerminalExitResponse, error) {
+	if c.terminals == nil {
+		return nil, errors.New("terminal support disabled")
+	}
+	return c.terminals.waitForExit(ctx, params.TerminalId)
+}
+
+func (c *stdioClient) KillTerminalCommand(_ context.Context, params *acpgo.KillTerminalCommandRequest) error {
+	if c.terminals == nil {
+		return errors.New("terminal support disabled")
+	}
+	return c.terminals.kill(params.TerminalId)
+}
+
+var _ contracts.AgentRunner = (*StdioRunnerAdapter)(nil)
+var _ acpgo.Client = (*stdioClient)(nil)
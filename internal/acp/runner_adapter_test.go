@@ -0,0 +1,438 @@
+package acp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	acpgo "github.com/ironpark/acp-go"
+)
+
+// scriptedAgent is an in-process ACP agent. Each prompt streams the scripted
+// updates, optionally waits, and then ends with stopReason or err.
+type scriptedAgent struct {
+	conn       *acpgo.AgentSideConnection
+	updates    []acpgo.SessionUpdate
+	delay      time.Duration
+	stopReason acpgo.StopReason
+	err        error
+	permission *acpgo.RequestPermissionRequest
+	terminal   *acpgo.CreateTerminalRequest
+	// loadSession advertises session/load; history is replayed on load.
+	loadSession bool
+	history     []acpgo.SessionUpdate
+
+	mu       sync.Mutex
+	cwd      string
+	loaded   []acpgo.SessionId
+	sessions int
+	prompts  []string
+	outcomes []acpgo.RequestPermissionOutcome
+	exits    []*acpgo.WaitForTerminalExitResponse
+	outputs  []string
+}
+
+func (a *scriptedAgent) Initialize(context.Context, *acpgo.InitializeRequest) (*acpgo.InitializeResponse, error) {
+	return &acpgo.InitializeResponse{
+		ProtocolVersion:   acpgo.ProtocolVersion(acpgo.CurrentProtocolVersion),
+		AgentCapabilities: &acpgo.AgentCapabilities{LoadSession: a.loadSession},
+	}, nil
+}
+
+func (a *scriptedAgent) Authenticate(context.Context, *acpgo.AuthenticateRequest) error {
+	return nil
+}
+
+func (a *scriptedAgent) NewSession(_ context.Context, params *acpgo.NewSessionRequest) (*acpgo.NewSessionResponse, error) {
+	a.mu.Lock()
+	a.cwd = params.Cwd
+	a.sessions++
+	a.mu.Unlock()
+	return &acpgo.NewSessionResponse{SessionId: "sess-1"}, nil
+}
+
+func (a *scriptedAgent) LoadSession(ctx context.Context, params *acpgo.LoadSessionRequest) (*acpgo.LoadSessionResponse, error) {
+	if !a.loadSession {
+		return nil, errors.New("not supported")
+	}
+	a.mu.Lock()
+	a.cwd = params.Cwd
+	a.loaded = append(a.loaded, params.SessionId)
+	a.mu.Unlock()
+	for _, update := range a.history {
+		_ = a.conn.Client().SessionUpdate(ctx, &acpgo.SessionNotification{SessionId: params.SessionId, Update: update})
+	}
+	return &acpgo.LoadSessionResponse{}, nil
+}
+
+func (a *scriptedAgent) SetSessionMode(context.Context, *acpgo.SetSessionModeRequest) error {
+	return nil
+}
+
+func (a *scriptedAgent) Prompt(ctx context.Context, params *acpgo.PromptRequest) (*acpgo.PromptResponse, error) {
+	a.mu.Lock()
+	for _, block := range params.Prompt {
+		if block.IsText() {
+			a.prompts = append(a.prompts, block.GetText().Text)
+		}
+	}
+	a.mu.Unlock()
+	for _, update := range a.updates {
+		_ = a.conn.Client().SessionUpdate(ctx, &acpgo.SessionNotification{SessionId: params.SessionId, Update: update})
+	}
+	if a.permission != nil {
+		request := *a.permission
+		request.SessionId = params.SessionId
+		response, err := a.conn.Client().RequestPermission(ctx, &request)
+		if err != nil {
+			return nil, err
+		}
+		a.mu.Lock()
+		a.outcomes = append(a.outcomes, response.Outcome)
+		a.mu.Unlock()
+	}
+	if a.terminal != nil {
+		if err := a.runTerminal(ctx, params.SessionId); err != nil {
+			return nil, err
+		}
+	}
+	if a.delay > 0 {
+		time.Sleep(a.delay)
+	}
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &acpgo.PromptResponse{StopReason: a.stopReason}, nil
+}
+
+func (a *scriptedAgent) runTerminal(ctx context.Context, sessionID acpgo.SessionId) error {
+	request := *a.terminal
+	request.SessionId = sessionID
+	created, err := a.conn.Client().CreateTerminal(ctx, &request)
+	if err != nil {
+		return err
+	}
+	exit, err := a.conn.Client().WaitForTerminalExit(ctx, &acpgo.WaitForTerminalExitRequest{SessionId: sessionID, TerminalId: created.TerminalId})
+	if err != nil {
+		return err
+	}
+	output, err := a.conn.Client().TerminalOutput(ctx, &acpgo.TerminalOutputRequest{SessionId: sessionID, TerminalId: created.TerminalId})
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.exits = append(a.exits, exit)
+	a.outputs = append(a.outputs, output.Output)
+	a.mu.Unlock()
+	return a.conn.Client().ReleaseTerminal(ctx, &acpgo.ReleaseTerminalRequest{SessionId: sessionID, TerminalId: created.TerminalId})
+}
+
+func (a *scriptedAgent) Cancel(context.Context, *acpgo.CancelNotification) error {
+	return nil
+}
+
+type pipeProcess struct {
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (p *pipeProcess) Stdin() io.WriteCloser { return p.stdin }
+func (p *pipeProcess) Stdout() io.ReadCloser { return p.stdout }
+
+func (p *pipeProcess) Wait() error {
+	<-p.done
+	return nil
+}
+
+func (p *pipeProcess) Kill() error {
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
+
+func startScriptedAgent(agent *scriptedAgent, specs *[]CommandSpec) ProcessStarter {
+	return processStarterFunc(func(_ context.Context, spec CommandSpec) (Process, error) {
+		if specs != nil {
+			*specs = append(*specs, spec)
+		}
+		clientToAgentR, clientToAgentW := io.Pipe()
+		agentToClientR, agentToClientW := io.Pipe()
+		agent.conn = acpgo.NewAgentSideConnection(agent, clientToAgentR, agentToClientW)
+		proc := &pipeProcess{stdin: clientToAgentW, stdout: agentToClientR, done: make(chan struct{})}
+		go func() {
+			_ = agent.conn.Start(context.Background())
+			_ = agentToClientW.Close()
+			_ = proc.Kill()
+		}()
+		return proc, nil
+	})
+}
+
+func textChunk(text string) acpgo.SessionUpdate {
+	return acpgo.NewSessionUpdateAgentMessageChunk(acpgo.NewContentBlockText(text))
+}
+
+func newTestAdapter(agent *scriptedAgent, specs *[]CommandSpec, args ...string) *StdioRunnerAdapter {
+	adapter := NewStdioRunnerAdapter("acp-agent", startScriptedAgent(agent, specs), args...)
+	adapter.settle = 20 * time.Millisecond
+	return adapter
+}
+
+func TestStdioRunnerAdapterRunsPromptAndForwardsSessionUpdates(t *testing.T) {
+	repoRoot := t.TempDir()
+	status := acpgo.ToolCallStatusInProgress
+	agent := &scriptedAgent{
+		updates: []acpgo.SessionUpdate{
+			acpgo.NewSessionUpdateToolCall("call-1", "Run tests", nil, &status, nil, nil),
+			textChunk("all done\nFOLLOW_UP: Add docs - describe the flag\n"),
+		},
+		stopReason: acpgo.StopReasonEndTurn,
+	}
+	specs := []CommandSpec{}
+	var mu sync.Mutex
+	updates := []contracts.RunnerProgress{}
+
+	result, err := newTestAdapter(agent, &specs, "--model", "{{model}}").Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "t-1",
+		RepoRoot: repoRoot,
+		Prompt:   "implement feature",
+		Mode:     contracts.RunnerModeImplement,
+		Model:    "m-1",
+		OnProgress: func(progress contracts.RunnerProgress) {
+			mu.Lock()
+			updates = append(updates, progress)
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if result.Status != contracts.RunnerResultCompleted {
+		t.Fatalf("expected completed, got %#v", result)
+	}
+	if len(specs) != 1 || specs[0].Binary != "acp-agent" || strings.Join(specs[0].Args, " ") != "--model m-1" || specs[0].Dir != repoRoot {
+		t.Fatalf("unexpected command spec %#v", specs)
+	}
+	if agent.cwd != repoRoot || len(agent.prompts) != 1 || agent.prompts[0] != "implement feature" {
+		t.Fatalf("unexpected session cwd=%q prompts=%#v", agent.cwd, agent.prompts)
+	}
+	if result.Artifacts["stop_reason"] != "end_turn" || result.Artifacts["backend"] != "acp" {
+		t.Fatalf("unexpected artifacts %#v", result.Artifacts)
+	}
+	if !strings.Contains(result.Artifacts[contracts.FollowUpsArtifactKey], "Add docs") {
+		t.Fatalf("expected follow-ups parsed from agent text, got %#v", result.Artifacts)
+	}
+	if result.LogPath != filepath.Join(repoRoot, "runner-logs", "acp", "t-1.jsonl") {
+		t.Fatalf("unexpected log path %q", result.LogPath)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	types := []string{}
+	for _, update := range updates {
+		types = append(types, update.Type)
+	}
+	joined := strings.Join(types, ",")
+	if !strings.Contains(joined, string(contracts.EventTypeRunnerCommandStarted)) || !strings.Contains(joined, string(contracts.EventTypeRunnerOutput)) {
+		t.Fatalf("expected tool call and output progress, got %v", types)
+	}
+	if types[len(types)-1] != string(contracts.EventTypeRunnerCommandFinished) {
+		t.Fatalf("expected stop reason progress last, got %v", types)
+	}
+}
+
+func TestStdioRunnerAdapterMapsStopReasons(t *testing.T) {
+	cases := []struct {
+		stopReason acpgo.StopReason
+		want       contracts.RunnerResultStatus
+	}{
+		{acpgo.StopReasonMaxTokens, contracts.RunnerResultBlocked},
+		{acpgo.StopReasonMaxTurnRequests, contracts.RunnerResultBlocked},
+		{acpgo.StopReasonCancelled, contracts.RunnerResultBlocked},
+		{acpgo.StopReasonRefusal, contracts.RunnerResultFailed},
+	}
+	for _, tc := range cases {
+		agent := &scriptedAgent{stopReason: tc.stopReason}
+		result, err := newTestAdapter(agent, nil).Run(context.Background(), contracts.RunnerRequest{TaskID: "t-1", RepoRoot: t.TempDir(), Prompt: "go", Mode: contracts.RunnerModeImplement})
+		if err != nil {
+			t.Fatalf("%s: run failed: %v", tc.stopReason, err)
+		}
+		if result.Status != tc.want || !strings.Contains(result.Reason, string(tc.stopReason)) {
+			t.Fatalf("%s: expected %s with stop reason, got %#v", tc.stopReason, tc.want, result)
+		}
+	}
+}
+
+func TestStdioRunnerAdapterReviewVerdictAndFeedback(t *testing.T) {
+	agent := &scriptedAgent{
+		updates:    []acpgo.SessionUpdate{textChunk("REVIEW_VERDICT: fail\nREVIEW_FAIL_FEEDBACK: missing  tests\n")},
+		stopReason: acpgo.StopReasonEndTurn,
+	}
+	result, err := newTestAdapter(agent, nil).Run(context.Background(), contracts.RunnerRequest{TaskID: "t-1", RepoRoot: t.TempDir(), Prompt: "review", Mode: contracts.RunnerModeReview})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if result.ReviewReady || result.Artifacts["review_verdict"] != "fail" || result.Artifacts["review_fail_feedback"] != "missing tests" {
+		t.Fatalf("unexpected review result %#v", result)
+	}
+}
+
+func TestStdioRunnerAdapterAppliesPermissionPolicyAndReportsDecision(t *testing.T) {
+	repoRoot := t.TempDir()
+	kind := acpgo.ToolKindFetch
+	agent := &scriptedAgent{
+		stopReason: acpgo.StopReasonEndTurn,
+		permission: &acpgo.RequestPermissionRequest{
+			ToolCall: acpgo.ToolCallUpdate{ToolCallId: "call-9", Title: "curl example.com", Kind: &kind},
+			Options: []acpgo.PermissionOption{
+				{OptionId: "allow", Kind: acpgo.PermissionOptionKindAllowOnce, Name: "Allow"},
+				{OptionId: "reject", Kind: acpgo.PermissionOptionKindRejectOnce, Name: "Reject"},
+			},
+		},
+	}
+	var mu sync.Mutex
+	permissions := []contracts.RunnerProgress{}
+	result, err := newTestAdapter(agent, nil).Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "t-1",
+		RepoRoot: repoRoot,
+		Prompt:   "go",
+		Mode:     contracts.RunnerModeImplement,
+		OnProgress: func(progress contracts.RunnerProgress) {
+			if progress.Type != string(contracts.EventTypeRunnerPermission) {
+				return
+			}
+			mu.Lock()
+			permissions = append(permissions, progress)
+			mu.Unlock()
+		},
+	})
+	if err != nil || result.Status != contracts.RunnerResultCompleted {
+		t.Fatalf("expected completed run, got %#v err=%v", result, err)
+	}
+	if len(agent.outcomes) != 1 || agent.outcomes[0].GetSelected() == nil || agent.outcomes[0].GetSelected().OptionId != "reject" {
+		t.Fatalf("expected fetch to be rejected, got %#v", agent.outcomes)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(permissions) != 1 {
+		t.Fatalf("expected one permission event, got %#v", permissions)
+	}
+	metadata := permissions[0].Metadata
+	if metadata["decision"] != "deny" || metadata["kind"] != "fetch" || metadata["tool_call_id"] != "call-9" || permissions[0].Message != "deny fetch: curl example.com" {
+		t.Fatalf("unexpected permission event %#v", permissions[0])
+	}
+}
+
+func TestStdioRunnerAdapterResumesSessionWithLoadSession(t *testing.T) {
+	repoRoot := t.TempDir()
+	agent := &scriptedAgent{
+		loadSession: true,
+		history:     []acpgo.SessionUpdate{textChunk("REPLAYED HISTORY\n")},
+		updates:     []acpgo.SessionUpdate{textChunk("picked up where I stopped\n")},
+		stopReason:  acpgo.StopReasonEndTurn,
+	}
+	var mu sync.Mutex
+	messages := []string{}
+	result, err := newTestAdapter(agent, nil).Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "t-1",
+		RepoRoot: repoRoot,
+		Prompt:   "implement feature",
+		Mode:     contracts.RunnerModeImplement,
+		Metadata: map[string]string{
+			contracts.ResumeSessionIDMetadataKey: "sess-7",
+			contracts.ResumePromptMetadataKey:    "continue please",
+		},
+		OnProgress: func(progress contracts.RunnerProgress) {
+			mu.Lock()
+			messages = append(messages, progress.Message)
+			mu.Unlock()
+		},
+	})
+	if err != nil || result.Status != contracts.RunnerResultCompleted {
+		t.Fatalf("expected completed run, got %#v err=%v", result, err)
+	}
+	if len(agent.loaded) != 1 || agent.loaded[0] != "sess-7" || agent.sessions != 0 || agent.cwd != repoRoot {
+		t.Fatalf("expected session/load of sess-7, got loaded=%v new=%d cwd=%q", agent.loaded, agent.sessions, agent.cwd)
+	}
+	if len(agent.prompts) != 1 || agent.prompts[0] != "continue please" {
+		t.Fatalf("expected resume prompt, got %#v", agent.prompts)
+	}
+	if result.Artifacts[contracts.SessionIDArtifactKey] != "sess-7" {
+		t.Fatalf("expected resumed session id artifact, got %#v", result.Artifacts)
+	}
+	data, err := os.ReadFile(result.LogPath)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	if strings.Contains(string(data), "REPLAYED HISTORY") || !strings.Contains(string(data), "picked up where I stopped") {
+		t.Fatalf("expected only new turn in log, got %q", data)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, message := range messages {
+		if strings.Contains(message, "REPLAYED HISTORY") {
+			t.Fatalf("did not expect replayed history in progress, got %v", messages)
+		}
+	}
+}
+
+func TestStdioRunnerAdapterStartsNewSessionWhenLoadUnsupported(t *testing.T) {
+	agent := &scriptedAgent{stopReason: acpgo.StopReasonEndTurn}
+	var mu sync.Mutex
+	warnings := []string{}
+	result, err := newTestAdapter(agent, nil).Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "t-1",
+		RepoRoot: t.TempDir(),
+		Prompt:   "implement feature",
+		Mode:     contracts.RunnerModeImplement,
+		Metadata: map[string]string{contracts.ResumeSessionIDMetadataKey: "sess-7"},
+		OnProgress: func(progress contracts.RunnerProgress) {
+			if progress.Type == string(contracts.EventTypeRunnerWarning) {
+				mu.Lock()
+				warnings = append(warnings, progress.Message)
+				mu.Unlock()
+			}
+		},
+	})
+	if err != nil || result.Status != contracts.RunnerResultCompleted {
+		t.Fatalf("expected completed run, got %#v err=%v", result, err)
+	}
+	if len(agent.loaded) != 0 || agent.sessions != 1 || len(agent.prompts) != 1 || agent.prompts[0] != "implement feature" {
+		t.Fatalf("expected fresh session with full prompt, got loaded=%v new=%d prompts=%#v", agent.loaded, agent.sessions, agent.prompts)
+	}
+	if result.Artifacts[contracts.SessionIDArtifactKey] != "sess-1" {
+		t.Fatalf("expected new session id artifact, got %#v", result.Artifacts)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "does not support session/load") {
+		t.Fatalf("expected fallback warning, got %v", warnings)
+	}
+}
+
+func TestStdioClientReadsAndWritesTextFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "file.txt")
+	client := newStdioClient(nil, nil)
+	if err := client.WriteTextFile(context.Background(), &acpgo.WriteTextFileRequest{Path: path, Content: "a\nb\nc\nd"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	line, limit := int64(2), int64(2)
+	response, err := client.ReadTextFile(context.Background(), &acpgo.ReadTextFileRequest{Path: path, Line: &line, Limit: &limit})
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if response.Content != "b\nc" {
+		t.Fatalf("expected lines 2-3, got %q", response.Content)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected written file: %v", err)
+	}
+}
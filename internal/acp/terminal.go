@@ -0,0 +1,360 @@
+package acp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	acpgo "github.com/ironpark/acp-go"
+)
+
+// defaultTerminalOutputLimit bounds the output retained per terminal when the
+// agent does not set outputByteLimit.
+const defaultTerminalOutputLimit = 1 << 20
+
+// terminalManager runs the commands an agent starts through the ACP terminal
+// methods. Commands run inside the task clone; their output is retained for
+// terminal/output, appended to the terminal log and reported as runner output.
+type terminalManager struct {
+	repoRoot string
+	log      io.Writer
+	emit     func(contracts.RunnerProgress)
+	// env is appended to the environment of every command, after the
+	// agent's own variables.
+	env []string
+	// tools rejects commands the tool policy does not allow.
+	tools contracts.ToolPolicy
+
+	mu        sync.Mutex
+	logMu     sync.Mutex
+	nextID    int
+	terminals map[string]*terminal
+}
+
+func newTerminalManager(repoRoot string, log io.Writer, emit func(contracts.RunnerProgress)) *terminalManager {
+	return &terminalManager{
+		repoRoot:  strings.TrimSpace(repoRoot),
+		log:       log,
+		emit:      emit,
+		terminals: map[string]*terminal{},
+	}
+}
+
+type terminal struct {
+	id      string
+	command string
+	cmd     *exec.Cmd
+	limit   int
+	done    chan struct{}
+
+	mu        sync.Mutex
+	output    []byte
+	truncated bool
+	pending   strings.Builder
+	exit      *acpgo.TerminalExitStatus
+}
+
+func (m *terminalManager) create(params *acpgo.CreateTerminalRequest) (*acpgo.CreateTerminalResponse, error) {
+	command := strings.TrimSpace(params.Command)
+	if command == "" {
+		return nil, errors.New("terminal command is required")
+	}
+	if m.repoRoot == "" {
+		return nil, errors.New("terminal requires a task clone")
+	}
+	cwd := strings.TrimSpace(params.Cwd)
+	if cwd == "" {
+		cwd = m.repoRoot
+	} else if !filepath.IsAbs(cwd) {
+		cwd = filepath.Join(m.repoRoot, cwd)
+	}
+	if !withinRoot(m.repoRoot, cwd) {
+		return nil, fmt.Errorf("terminal cwd %s is outside the task clone", cwd)
+	}
+	commandLine := strings.TrimSpace(strings.Join(append([]string{command}, params.Args...), " "))
+	if allowed, reason := m.tools.CheckCommand(commandLine); !allowed {
+		return nil, fmt.Errorf("terminal command %q rejected: %s", commandLine, reason)
+	}
+
+	cmd := exec.Command(command, params.Args...)
+	cmd.Dir = cwd
+	cmd.Env = os.Environ()
+	for _, variable := range params.Env {
+		cmd.Env = append(cmd.Env, variable.Name+"="+variable.Value)
+	}
+	cmd.Env = append(cmd.Env, m.env...)
+	configureTerminalCommand(cmd)
+
+	limit := defaultTerminalOutputLimit
+	if params.OutputByteLimit != nil && *params.OutputByteLimit >= 0 {
+		limit = int(*params.OutputByteLimit)
+	}
+
+	m.mu.Lock()
+	m.nextID++
+	id := "term-" + strconv.Itoa(m.nextID)
+	m.mu.Unlock()
+
+	term := &terminal{
+		id:      id,
+		command: commandLine,
+		cmd:     cmd,
+		limit:   limit,
+		done:    make(chan struct{}),
+	}
+	writer := &terminalWriter{manager: m, terminal: term}
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start terminal command %q: %w", term.command, err)
+	}
+
+	m.mu.Lock()
+	m.terminals[id] = term
+	m.mu.Unlock()
+
+	m.writeLog(fmt.Sprintf("[%s] $ %s\n", id, term.command))
+	m.report(contracts.EventTypeRunnerCommandStarted, term, term.command, nil)
+	go m.wait(term, writer)
+	return &acpgo.CreateTerminalResponse{TerminalId: id}, nil
+}
+
+func (m *terminalManager) wait(term *terminal, writer *terminalWriter) {
+	err := term.cmd.Wait()
+	writer.flush()
+	status := &acpgo.TerminalExitStatus{}
+	if state := term.cmd.ProcessState; state != nil {
+		if signal := terminalExitSignal(state); signal != "" {
+			status.Signal = signal
+		} else {
+			code := int64(state.ExitCode())
+			status.ExitCode = &code
+		}
+	} else if err != nil {
+		code := int64(-1)
+		status.ExitCode = &code
+	}
+	term.mu.Lock()
+	term.exit = status
+	term.mu.Unlock()
+	// done closes last so waiters observe the finished event and log line.
+	defer close(term.done)
+
+	summary := exitSummary(status)
+	m.writeLog(fmt.Sprintf("[%s] %s\n", term.id, summary))
+	metadata := map[string]string{}
+	if status.ExitCode != nil {
+		metadata["exit_code"] = strconv.FormatInt(*status.ExitCode, 10)
+	}
+	if status.Signal != "" {
+		metadata["signal"] = status.Signal
+	}
+	m.report(contracts.EventTypeRunnerCommandFinished, term, term.command+" ("+summary+")", metadata)
+}
+
+func (m *terminalManager) lookup(id string) (*terminal, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	term, ok := m.terminals[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown terminal %q", id)
+	}
+	return term, nil
+}
+
+func (m *terminalManager) output(id string) (*acpgo.TerminalOutputResponse, error) {
+	term, err := m.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	term.mu.Lock()
+	defer term.mu.Unlock()
+	return &acpgo.TerminalOutputResponse{
+		Output:     string(term.output),
+		Truncated:  term.truncated,
+		ExitStatus: term.exit,
+	}, nil
+}
+
+func (m *terminalManager) waitForExit(ctx context.Context, id string) (*acpgo.WaitForTerminalExitResponse, error) {
+	term, err := m.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-term.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	term.mu.Lock()
+	defer term.mu.Unlock()
+	return &acpgo.WaitForTerminalExitResponse{ExitCode: term.exit.ExitCode, Signal: term.exit.Signal}, nil
+}
+
+func (m *terminalManager) kill(id string) error {
+	term, err := m.lookup(id)
+	if err != nil {
+		return err
+	}
+	return term.kill()
+}
+
+func (m *terminalManager) release(id string) error {
+	term, err := m.lookup(id)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	delete(m.terminals, id)
+	m.mu.Unlock()
+	return term.kill()
+}
+
+// closeAll kills terminals the agent left running when the run ends.
+func (m *terminalManager) closeAll() {
+	m.mu.Lock()
+	terminals := make([]*terminal, 0, len(m.terminals))
+	for id, term := range m.terminals {
+		terminals = append(terminals, term)
+		delete(m.terminals, id)
+	}
+	m.mu.Unlock()
+	for _, term := range terminals {
+		_ = term.kill()
+		select {
+		case <-term.done:
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (t *terminal) kill() error {
+	select {
+	case <-t.done:
+		return nil
+	default:
+	}
+	return killTerminalCommand(t.cmd)
+}
+
+func (m *terminalManager) writeLog(text string) {
+	if m.log == nil {
+		return
+	}
+	m.logMu.Lock()
+	defer m.logMu.Unlock()
+	_, _ = io.WriteString(m.log, text)
+}
+
+func (m *terminalManager) report(eventType contracts.EventType, term *terminal, message string, metadata map[string]string) {
+	if m.emit == nil {
+		return
+	}
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	metadata["source"] = "terminal"
+	metadata["terminal_id"] = term.id
+	m.emit(contracts.RunnerProgress{
+		Type:      string(eventType),
+		Message:   message,
+		Metadata:  metadata,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+func (m *terminalManager) reportLine(term *terminal, line string) {
+	if m.emit == nil {
+		return
+	}
+	progress, ok := contracts.NewRunnerOutputProgress("terminal", line, time.Now())
+	if !ok {
+		return
+	}
+	progress.Metadata["terminal_id"] = term.id
+	m.emit(progress)
+}
+
+func exitSummary(status *acpgo.TerminalExitStatus) string {
+	if status.Signal != "" {
+		return "killed by " + status.Signal
+	}
+	if status.ExitCode != nil {
+		return "exit " + strconv.FormatInt(*status.ExitCode, 10)
+	}
+	return "exited"
+}
+
+// terminalWriter receives the combined stdout/stderr of one terminal.
+type terminalWriter struct {
+	manager  *terminalManager
+	terminal *terminal
+}
+
+func (w *terminalWriter) Write(p []byte) (int, error) {
+	term := w.terminal
+	lines := []string{}
+	term.mu.Lock()
+	term.output = append(term.output, p...)
+	if len(term.output) > term.limit {
+		cut := len(term.output) - term.limit
+		for cut < len(term.output) && !utf8.RuneStart(term.output[cut]) {
+			cut++
+		}
+		term.output = append([]byte(nil), term.output[cut:]...)
+		term.truncated = true
+	}
+	for _, r := range string(p) {
+		if r == '\n' {
+			lines = append(lines, term.pending.String())
+			term.pending.Reset()
+			continue
+		}
+		term.pending.WriteRune(r)
+	}
+	term.mu.Unlock()
+
+	w.manager.writeLog(prefixTerminalLines(term.id, p))
+	for _, line := range lines {
+		w.manager.reportLine(term, line)
+	}
+	return len(p), nil
+}
+
+func (w *terminalWriter) flush() {
+	term := w.terminal
+	term.mu.Lock()
+	line := term.pending.String()
+	term.pending.Reset()
+	term.mu.Unlock()
+	if line != "" {
+		w.manager.reportLine(term, line)
+	}
+}
+
+func prefixTerminalLines(id string, p []byte) string {
+	text := string(p)
+	if text == "" {
+		return ""
+	}
+	trailingNewline := strings.HasSuffix(text, "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "[" + id + "] " + line
+	}
+	out := strings.Join(lines, "\n")
+	if trailingNewline {
+		out += "\n"
+	}
+	return out
+}
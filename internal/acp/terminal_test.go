@@ -0,0 +1,144 @@
+package acp
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	acpgo "github.com/ironpark/acp-go"
+)
+
+func TestStdioRunnerAdapterRunsAgentTerminalsInsideClone(t *testing.T) {
+	repoRoot := t.TempDir()
+	agent := &scriptedAgent{
+		stopReason: acpgo.StopReasonEndTurn,
+		terminal: &acpgo.CreateTerminalRequest{
+			Command: "sh",
+			Args:    []string{"-c", "pwd; echo built; exit 3"},
+		},
+	}
+	var mu sync.Mutex
+	events := []contracts.RunnerProgress{}
+	logPath := filepath.Join(repoRoot, "runner-logs", "acp", "t-1.jsonl")
+	result, err := newTestAdapter(agent, nil).Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "t-1",
+		RepoRoot: repoRoot,
+		Prompt:   "go",
+		Mode:     contracts.RunnerModeImplement,
+		Metadata: map[string]string{"log_path": logPath},
+		OnProgress: func(progress contracts.RunnerProgress) {
+			if progress.Metadata["terminal_id"] == "" {
+				return
+			}
+			mu.Lock()
+			events = append(events, progress)
+			mu.Unlock()
+		},
+	})
+	if err != nil || result.Status != contracts.RunnerResultCompleted {
+		t.Fatalf("expected completed run, got %#v err=%v", result, err)
+	}
+	if len(agent.exits) != 1 || agent.exits[0].ExitCode == nil || *agent.exits[0].ExitCode != 3 {
+		t.Fatalf("expected exit code 3, got %#v", agent.exits)
+	}
+	resolvedRoot, _ := filepath.EvalSymlinks(repoRoot)
+	if len(agent.outputs) != 1 || !strings.Contains(agent.outputs[0], "built") || !strings.Contains(agent.outputs[0], resolvedRoot) {
+		t.Fatalf("expected output from clone, got %#v", agent.outputs)
+	}
+
+	mu.Lock()
+	types := []string{}
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	mu.Unlock()
+	joined := strings.Join(types, ",")
+	if !strings.HasPrefix(joined, string(contracts.EventTypeRunnerCommandStarted)) || !strings.HasSuffix(joined, string(contracts.EventTypeRunnerCommandFinished)) || !strings.Contains(joined, string(contracts.EventTypeRunnerOutput)) {
+		t.Fatalf("unexpected terminal events %v", types)
+	}
+
+	sidecar, err := os.ReadFile(contracts.BackendLogSidecarPath(logPath, contracts.BackendLogTerminal))
+	if err != nil {
+		t.Fatalf("read terminal log: %v", err)
+	}
+	if !strings.Contains(string(sidecar), "[term-1] $ sh -c") || !strings.Contains(string(sidecar), "[term-1] built") || !strings.Contains(string(sidecar), "[term-1] exit 3") {
+		t.Fatalf("unexpected terminal log %q", sidecar)
+	}
+	agentLog, _ := os.ReadFile(logPath)
+	if strings.Contains(string(agentLog), "built") {
+		t.Fatalf("terminal output must not reach the agent log, got %q", agentLog)
+	}
+}
+
+func TestTerminalManagerRejectsCwdOutsideClone(t *testing.T) {
+	manager := newTerminalManager(t.TempDir(), nil, nil)
+	_, err := manager.create(&acpgo.CreateTerminalRequest{Command: "true", Cwd: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "outside the task clone") {
+		t.Fatalf("expected cwd rejection, got %v", err)
+	}
+}
+
+func TestTerminalManagerKillsCommand(t *testing.T) {
+	manager := newTerminalManager(t.TempDir(), nil, nil)
+	created, err := manager.create(&acpgo.CreateTerminalRequest{Command: "sh", Args: []string{"-c", "sleep 30"}})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if err := manager.kill(created.TerminalId); err != nil {
+		t.Fatalf("kill failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	exit, err := manager.waitForExit(ctx, created.TerminalId)
+	if err != nil {
+		t.Fatalf("wait failed: %v", err)
+	}
+	if exit.Signal != "SIGKILL" {
+		t.Fatalf("expected SIGKILL, got %#v", exit)
+	}
+	if err := manager.release(created.TerminalId); err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+	if _, err := manager.output(created.TerminalId); err == nil {
+		t.Fatalf("expected released terminal to be gone")
+	}
+}
+
+func TestTerminalManagerTruncatesOutputToLimit(t *testing.T) {
+	var log bytes.Buffer
+	manager := newTerminalManager(t.TempDir(), &log, nil)
+	limit := int64(4)
+	created, err := manager.create(&acpgo.CreateTerminalRequest{Command: "sh", Args: []string{"-c", "printf 'abcdefgh'"}, OutputByteLimit: &limit})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if _, err := manager.waitForExit(context.Background(), created.TerminalId); err != nil {
+		t.Fatalf("wait failed: %v", err)
+	}
+	output, err := manager.output(created.TerminalId)
+	if err != nil {
+		t.Fatalf("output failed: %v", err)
+	}
+	if output.Output != "efgh" || !output.Truncated || output.ExitStatus == nil {
+		t.Fatalf("unexpected output %#v", output)
+	}
+	manager.closeAll()
+	if !strings.Contains(log.String(), "abcdefgh") {
+		t.Fatalf("expected full output in terminal log, got %q", log.String())
+	}
+}
+
+func TestTerminalManagerRejectsCommandsOutsideToolPolicy(t *testing.T) {
+	manager := newTerminalManager(t.TempDir(), nil, nil)
+	manager.tools = contracts.ToolPolicy{Deny: []string{"curl"}}
+	_, err := manager.create(&acpgo.CreateTerminalRequest{Command: "sh", Args: []string{"-c", "true && curl https://example.com"}})
+	if err == nil || !strings.Contains(err.Error(), "denied by tool policy: curl") {
+		t.Fatalf("expected tool policy rejection, got %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package acp
+
+import (
+	"os"
+	"os/exec"
+)
+
+func configureTerminalCommand(_ *exec.Cmd) {}
+
+func killTerminalCommand(cmd *exec.Cmd) error {
+	if cmd == nil || cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
+
+func terminalExitSignal(_ *os.ProcessState) string {
+	return ""
+}
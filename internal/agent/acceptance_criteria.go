@@ -0,0 +1,190 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+type acceptanceCriterion struct {
+	ID      string
+	Text    string
+	Checked bool
+}
+
+var (
+	criterionListItemPattern = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(.*)$`)
+	criterionCheckboxPattern = regexp.MustCompile(`^\[([ xX])\]\s*(.*)$`)
+)
+
+// parseAcceptanceCriteria extracts checklist items from the acceptance criteria
+// section of a GitHub/Linear/tk markdown description. Without such a section it
+// falls back to task-list checkboxes anywhere in the description.
+func parseAcceptanceCriteria(description string) []acceptanceCriterion {
+	body := stripTaskFrontmatter(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(description))
+	if body == "" {
+		return nil
+	}
+	lines := strings.Split(body, "\n")
+
+	inSection := false
+	sectionFound := false
+	sectionLines := []string{}
+	for _, line := range lines {
+		if section, ok := parseQualitySectionHeader(strings.Trim(strings.TrimSpace(line), "*_")); ok {
+			inSection = section == "acceptance_criteria"
+			sectionFound = sectionFound || inSection
+			continue
+		}
+		if inSection {
+			sectionLines = append(sectionLines, line)
+		}
+	}
+
+	texts := []string{}
+	checked := []bool{}
+	if sectionFound {
+		paragraph := []string{}
+		for _, line := range sectionLines {
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" {
+				continue
+			}
+			if match := criterionListItemPattern.FindStringSubmatch(line); match != nil {
+				text, isChecked := splitCriterionCheckbox(match[1])
+				texts = append(texts, text)
+				checked = append(checked, isChecked)
+				continue
+			}
+			if len(texts) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+				texts[len(texts)-1] = texts[len(texts)-1] + " " + trimmed
+				continue
+			}
+			paragraph = append(paragraph, trimmed)
+		}
+		if len(texts) == 0 && len(paragraph) > 0 {
+			texts = append(texts, strings.Join(paragraph, " "))
+			checked = append(checked, false)
+		}
+	} else {
+		for _, line := range lines {
+			match := criterionListItemPattern.FindStringSubmatch(line)
+			if match == nil || !criterionCheckboxPattern.MatchString(strings.TrimSpace(match[1])) {
+				continue
+			}
+			text, isChecked := splitCriterionCheckbox(match[1])
+			texts = append(texts, text)
+			checked = append(checked, isChecked)
+		}
+	}
+
+	criteria := []acceptanceCriterion{}
+	for i, text := range texts {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		criteria = append(criteria, acceptanceCriterion{
+			ID:      "AC" + strconv.Itoa(len(criteria)+1),
+			Text:    text,
+			Checked: checked[i],
+		})
+	}
+	return criteria
+}
+
+func splitCriterionCheckbox(item string) (string, bool) {
+	item = strings.TrimSpace(item)
+	if match := criterionCheckboxPattern.FindStringSubmatch(item); match != nil {
+		return strings.TrimSpace(match[2]), strings.EqualFold(match[1], "x")
+	}
+	return item, false
+}
+
+func buildAcceptanceCriteriaSection(criteria []acceptanceCriterion, mode contracts.RunnerMode) string {
+	if len(criteria) == 0 {
+		return ""
+	}
+	lines := []string{"Acceptance Criteria:"}
+	for _, criterion := range criteria {
+		lines = append(lines, fmt.Sprintf("- [%s] %s", criterion.ID, criterion.Text))
+	}
+	if mode == contracts.RunnerModeReview {
+		lines = append(lines,
+			"- Report every criterion on its own line: REVIEW_CRITERION: <id> pass|fail - <evidence>",
+			"- Any failing criterion requires REVIEW_VERDICT: fail.",
+		)
+	} else {
+		lines = append(lines, "- Satisfy every criterion above; review checks each one individually.")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func reviewCriteriaFromArtifacts(result contracts.RunnerResult) []contracts.ReviewCriterionResult {
+	if len(result.Artifacts) == 0 {
+		return nil
+	}
+	return contracts.DecodeReviewCriteria(result.Artifacts[contracts.ReviewCriteriaArtifactKey])
+}
+
+// unmetAcceptanceCriteria returns the criteria the review explicitly failed.
+func unmetAcceptanceCriteria(criteria []acceptanceCriterion, results []contracts.ReviewCriterionResult) []string {
+	textByID := map[string]string{}
+	for _, criterion := range criteria {
+		textByID[criterion.ID] = criterion.Text
+	}
+	unmet := []string{}
+	for _, result := range results {
+		if result.Status != contracts.ReviewCriterionFail {
+			continue
+		}
+		entry := result.ID
+		if text := textByID[result.ID]; text != "" {
+			entry += ": " + text
+		}
+		if result.Note != "" {
+			entry += " (" + result.Note + ")"
+		}
+		unmet = append(unmet, entry)
+	}
+	return unmet
+}
+
+// appendAcceptanceCriteriaMetadata summarizes review coverage of the task's
+// acceptance criteria. Criteria without a review result are listed as
+// unverified.
+func appendAcceptanceCriteriaMetadata(metadata map[string]string, criteria []acceptanceCriterion, results []contracts.ReviewCriterionResult) map[string]string {
+	if len(criteria) == 0 {
+		return metadata
+	}
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	statusByID := map[string]string{}
+	for _, result := range results {
+		statusByID[result.ID] = result.Status
+	}
+	passed := 0
+	unverified := []string{}
+	for _, criterion := range criteria {
+		switch statusByID[criterion.ID] {
+		case contracts.ReviewCriterionPass:
+			passed++
+		case contracts.ReviewCriterionFail:
+		default:
+			unverified = append(unverified, criterion.ID+": "+criterion.Text)
+		}
+	}
+	metadata["acceptance_criteria_total"] = strconv.Itoa(len(criteria))
+	metadata["acceptance_criteria_passed"] = strconv.Itoa(passed)
+	if unmet := unmetAcceptanceCriteria(criteria, results); len(unmet) > 0 {
+		metadata["acceptance_criteria_unmet"] = strings.Join(unmet, "; ")
+	}
+	if len(unverified) > 0 {
+		metadata["acceptance_criteria_unverified"] = strings.Join(unverified, "; ")
+	}
+	return metadata
+}
@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestParseAcceptanceCriteriaFromSection(t *testing.T) {
+	description := strings.Join([]string{
+		"Intro text.",
+		"- [ ] not a criterion outside the section",
+		"",
+		"**Acceptance Criteria:**",
+		"- [ ] Parser handles GitHub checklists",
+		"- [x] Linear bullets are supported",
+		"  including nested wording",
+		"1. Numbered items work",
+		"",
+		"## Testing Plan",
+		"- run go test",
+	}, "\n")
+
+	criteria := parseAcceptanceCriteria(description)
+	want := []acceptanceCriterion{
+		{ID: "AC1", Text: "Parser handles GitHub checklists"},
+		{ID: "AC2", Text: "Linear bullets are supported including nested wording", Checked: true},
+		{ID: "AC3", Text: "Numbered items work"},
+	}
+	if len(criteria) != len(want) {
+		t.Fatalf("expected %d criteria, got %#v", len(want), criteria)
+	}
+	for i := range want {
+		if criteria[i] != want[i] {
+			t.Fatalf("criterion %d: expected %#v, got %#v", i, want[i], criteria[i])
+		}
+	}
+}
+
+func TestParseAcceptanceCriteriaFallsBackToChecklists(t *testing.T) {
+	criteria := parseAcceptanceCriteria("Do the thing.\n\n- [ ] first\n- plain bullet\n* [X] second")
+	if len(criteria) != 2 || criteria[0].Text != "first" || criteria[1].Text != "second" || !criteria[1].Checked {
+		t.Fatalf("expected checklist criteria, got %#v", criteria)
+	}
+	if got := parseAcceptanceCriteria("No checklist here."); len(got) != 0 {
+		t.Fatalf("expected no criteria, got %#v", got)
+	}
+}
+
+func TestBuildPromptListsAcceptanceCriteriaForReview(t *testing.T) {
+	task := contracts.Task{ID: "t-1", Title: "Task 1", Description: "Acceptance Criteria:\n- [ ] returns 200"}
+
+	review := buildPrompt(task, contracts.RunnerModeReview, false)
+	for _, needle := range []string{"Acceptance Criteria:\n- [AC1] returns 200", "REVIEW_CRITERION: <id> pass|fail"} {
+		if !strings.Contains(review, needle) {
+			t.Fatalf("expected review prompt to include %q, got %q", needle, review)
+		}
+	}
+	if implement := buildPrompt(task, contracts.RunnerModeImplement, false); !strings.Contains(implement, "- [AC1] returns 200") || strings.Contains(implement, "REVIEW_CRITERION") {
+		t.Fatalf("expected implement prompt to list criteria only, got %q", implement)
+	}
+}
+
+func TestLoopFailsReviewWithUnmetCriterionAndReportsCoverage(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{
+		ID:          "t-1",
+		Title:       "Task 1",
+		Status:      contracts.TaskStatusOpen,
+		Description: "## Acceptance Criteria\n- [ ] returns 200\n- [ ] logs request",
+	})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true, Artifacts: map[string]string{
+			"review_verdict":                    "pass",
+			contracts.ReviewCriteriaArtifactKey: `[{"id":"AC1","status":"pass"},{"id":"AC2","status":"fail","note":"no log line"}]`,
+		}},
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true, Artifacts: map[string]string{
+			"review_verdict":                    "pass",
+			contracts.ReviewCriteriaArtifactKey: `[{"id":"AC1","status":"pass"}]`,
+		}},
+	}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", MaxRetries: 1, RequireReview: true})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 || len(run.requests) != 4 {
+		t.Fatalf("expected completion after one review retry, got %#v with %d requests", summary, len(run.requests))
+	}
+	if !strings.Contains(run.requests[2].Prompt, "unmet acceptance criteria: AC2: logs request (no log line)") {
+		t.Fatalf("expected unmet criterion in retry prompt, got %q", run.requests[2].Prompt)
+	}
+
+	finished := eventsByType(sink.events, contracts.EventTypeTaskFinished)
+	if len(finished) != 1 {
+		t.Fatalf("expected one task_finished event, got %d", len(finished))
+	}
+	metadata := finished[0].Metadata
+	if metadata["acceptance_criteria_total"] != "2" || metadata["acceptance_criteria_passed"] != "1" {
+		t.Fatalf("unexpected criteria coverage %#v", metadata)
+	}
+	if metadata["acceptance_criteria_unverified"] != "AC2: logs request" {
+		t.Fatalf("expected unverified AC2, got %#v", metadata)
+	}
+	if _, ok := metadata["acceptance_criteria_unmet"]; ok {
+		t.Fatalf("did not expect unmet criteria after passing retry, got %#v", metadata)
+	}
+}
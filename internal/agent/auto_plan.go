@@ -0,0 +1,297 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// DefaultAutoPlanDir is where the planning run writes its proposal, relative to
+// the repository root.
+const DefaultAutoPlanDir = ".yolo-runner/plans"
+
+// AutoPlanOptions configures automatic decomposition of an epic with no child
+// tasks.
+type AutoPlanOptions struct {
+	RepoRoot string
+	Model    string
+	Timeout  time.Duration
+}
+
+// AutoPlanTask is one subtask proposed by the planning run. Key is local to the
+// proposal and is used by DependsOn until tracker IDs exist.
+type AutoPlanTask struct {
+	Key         string   `json:"key"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	DependsOn   []string `json:"depends_on"`
+}
+
+// AutoPlanProposal lists proposed subtasks in creation order: every task comes
+// after the tasks it depends on.
+type AutoPlanProposal struct {
+	RootID    string
+	RootTitle string
+	Tasks     []AutoPlanTask
+}
+
+// AutoPlanner asks the agent backend to break an epic into subtasks and creates
+// them in the tracker.
+type AutoPlanner struct {
+	storage contracts.StorageBackend
+	runner  contracts.AgentRunner
+	options AutoPlanOptions
+}
+
+func NewAutoPlanner(storage contracts.StorageBackend, runner contracts.AgentRunner, options AutoPlanOptions) *AutoPlanner {
+	return &AutoPlanner{storage: storage, runner: runner, options: options}
+}
+
+// NeedsPlan reports whether rootID is an open task without child tasks.
+func (p *AutoPlanner) NeedsPlan(ctx context.Context, rootID string) (bool, error) {
+	tree, err := p.storage.GetTaskTree(ctx, rootID)
+	if err != nil {
+		return false, err
+	}
+	if tree == nil {
+		return false, fmt.Errorf("root task %q not found", rootID)
+	}
+	if tree.Root.Status == contracts.TaskStatusClosed {
+		return false, nil
+	}
+	for taskID := range tree.Tasks {
+		if taskID != tree.Root.ID {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Propose runs the backend in plan mode and reads the subtasks it wrote to the
+// plan file.
+func (p *AutoPlanner) Propose(ctx context.Context, rootID string) (AutoPlanProposal, error) {
+	proposal := AutoPlanProposal{RootID: rootID}
+	root, err := p.storage.GetTask(ctx, rootID)
+	if err != nil {
+		return proposal, err
+	}
+	if root == nil {
+		return proposal, fmt.Errorf("root task %q not found", rootID)
+	}
+	proposal.RootTitle = root.Title
+
+	relPath := filepath.Join(DefaultAutoPlanDir, sanitizeAutoPlanFileName(rootID)+".json")
+	planPath := filepath.Join(p.options.RepoRoot, relPath)
+	if err := os.MkdirAll(filepath.Dir(planPath), 0o755); err != nil {
+		return proposal, fmt.Errorf("prepare auto-plan directory: %w", err)
+	}
+	if err := os.Remove(planPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return proposal, fmt.Errorf("clear previous auto-plan %s: %w", planPath, err)
+	}
+
+	result, err := p.runner.Run(ctx, contracts.RunnerRequest{
+		TaskID:   rootID,
+		ParentID: rootID,
+		Prompt:   buildAutoPlanPrompt(*root, relPath),
+		Mode:     contracts.RunnerModePlan,
+		Model:    p.options.Model,
+		RepoRoot: p.options.RepoRoot,
+		Timeout:  p.options.Timeout,
+	})
+	if err != nil {
+		return proposal, fmt.Errorf("auto-plan run for %s: %w", rootID, err)
+	}
+	if result.Status != contracts.RunnerResultCompleted {
+		reason := strings.TrimSpace(result.Reason)
+		if reason == "" {
+			reason = string(result.Status)
+		}
+		return proposal, fmt.Errorf("auto-plan run for %s did not complete: %s", rootID, reason)
+	}
+
+	content, err := os.ReadFile(planPath)
+	if err != nil {
+		return proposal, fmt.Errorf("read auto-plan %s: %w", planPath, err)
+	}
+	tasks, err := parseAutoPlan(content)
+	if err != nil {
+		return proposal, fmt.Errorf("invalid auto-plan %s: %w", planPath, err)
+	}
+	proposal.Tasks = tasks
+	return proposal, nil
+}
+
+// Apply creates the proposed tasks under the root and returns the tracker IDs
+// keyed by proposal key.
+func (p *AutoPlanner) Apply(ctx context.Context, proposal AutoPlanProposal) (map[string]string, error) {
+	creator, ok := p.storage.(contracts.TaskCreator)
+	if !ok {
+		return nil, fmt.Errorf("tracker does not support creating tasks")
+	}
+	created := make(map[string]string, len(proposal.Tasks))
+	for _, task := range proposal.Tasks {
+		dependsOn := make([]string, 0, len(task.DependsOn))
+		for _, key := range task.DependsOn {
+			dependsOn = append(dependsOn, created[key])
+		}
+		taskID, err := creator.CreateTask(ctx, contracts.TaskCreateRequest{
+			ParentID:    proposal.RootID,
+			Title:       task.Title,
+			Description: task.Description,
+			DependsOn:   dependsOn,
+		})
+		if err != nil {
+			return created, fmt.Errorf("create planned task %q: %w", task.Key, err)
+		}
+		created[task.Key] = taskID
+	}
+	return created, nil
+}
+
+func buildAutoPlanPrompt(root contracts.Task, planPath string) string {
+	sections := []string{
+		"You are planning work for an epic that has no subtasks yet.",
+		"Epic ID: " + root.ID,
+		"Title: " + root.Title,
+	}
+	if description := strings.TrimSpace(root.Description); description != "" {
+		sections = append(sections, "Description:\n"+description)
+	}
+	sections = append(sections, strings.Join([]string{
+		"Planning Rules:",
+		"- Inspect the repository, then split the epic into small, independently reviewable subtasks.",
+		"- Give each subtask a short unique key, a title, and a description that includes acceptance criteria.",
+		"- List in depends_on the keys of subtasks that must land first; do not create cycles.",
+		"- Do not modify any repository files other than the plan file.",
+		"- Write the plan as JSON to " + planPath + " using this shape:",
+		`  {"tasks":[{"key":"t1","title":"...","description":"...","depends_on":[]}]}`,
+	}, "\n"))
+	return strings.Join(sections, "\n\n")
+}
+
+// parseAutoPlan validates the proposal and orders tasks so dependencies come
+// first, keeping the proposed order otherwise.
+func parseAutoPlan(content []byte) ([]AutoPlanTask, error) {
+	var payload struct {
+		Tasks []AutoPlanTask `json:"tasks"`
+	}
+	if err := json.Unmarshal(content, &payload); err != nil {
+		return nil, err
+	}
+	if len(payload.Tasks) == 0 {
+		return nil, fmt.Errorf("plan has no tasks")
+	}
+
+	byKey := make(map[string]AutoPlanTask, len(payload.Tasks))
+	keys := make([]string, 0, len(payload.Tasks))
+	for i, task := range payload.Tasks {
+		task.Key = strings.TrimSpace(task.Key)
+		task.Title = strings.TrimSpace(task.Title)
+		task.Description = strings.TrimSpace(task.Description)
+		if task.Key == "" {
+			return nil, fmt.Errorf("task %d has no key", i+1)
+		}
+		if task.Title == "" {
+			return nil, fmt.Errorf("task %q has no title", task.Key)
+		}
+		if _, exists := byKey[task.Key]; exists {
+			return nil, fmt.Errorf("duplicate task key %q", task.Key)
+		}
+		byKey[task.Key] = task
+		keys = append(keys, task.Key)
+	}
+	for _, key := range keys {
+		task := byKey[key]
+		deps := make([]string, 0, len(task.DependsOn))
+		seen := map[string]struct{}{}
+		for _, dep := range task.DependsOn {
+			dep = strings.TrimSpace(dep)
+			if dep == "" {
+				continue
+			}
+			if dep == key {
+				return nil, fmt.Errorf("task %q depends on itself", key)
+			}
+			if _, ok := byKey[dep]; !ok {
+				return nil, fmt.Errorf("task %q depends on unknown task %q", key, dep)
+			}
+			if _, dup := seen[dep]; dup {
+				continue
+			}
+			seen[dep] = struct{}{}
+			deps = append(deps, dep)
+		}
+		task.DependsOn = deps
+		byKey[key] = task
+	}
+
+	ordered := make([]AutoPlanTask, 0, len(keys))
+	placed := make(map[string]struct{}, len(keys))
+	for len(ordered) < len(keys) {
+		progressed := false
+		for _, key := range keys {
+			if _, done := placed[key]; done {
+				continue
+			}
+			ready := true
+			for _, dep := range byKey[key].DependsOn {
+				if _, done := placed[dep]; !done {
+					ready = false
+					break
+				}
+			}
+			if !ready {
+				continue
+			}
+			ordered = append(ordered, byKey[key])
+			placed[key] = struct{}{}
+			progressed = true
+		}
+		if !progressed {
+			return nil, fmt.Errorf("plan dependencies contain a cycle")
+		}
+	}
+	return ordered, nil
+}
+
+func sanitizeAutoPlanFileName(rootID string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '-'
+		}
+	}, strings.TrimSpace(rootID))
+	if strings.Trim(name, ".-") == "" {
+		return "root"
+	}
+	return name
+}
+
+// WriteAutoPlanProposal prints the proposed subtasks for confirmation.
+func WriteAutoPlanProposal(w io.Writer, proposal AutoPlanProposal) error {
+	if w == nil {
+		return nil
+	}
+	lines := []string{
+		fmt.Sprintf("Auto-plan for %s (%s): %d task(s)", proposal.RootID, proposal.RootTitle, len(proposal.Tasks)),
+	}
+	for i, task := range proposal.Tasks {
+		line := fmt.Sprintf("  %d. [%s] %s", i+1, task.Key, task.Title)
+		if len(task.DependsOn) > 0 {
+			line += " (depends on " + strings.Join(task.DependsOn, ", ") + ")"
+		}
+		lines = append(lines, line)
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+type planWritingRunner struct {
+	plan     string
+	result   contracts.RunnerResult
+	requests []contracts.RunnerRequest
+}
+
+func (r *planWritingRunner) Run(_ context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	r.requests = append(r.requests, request)
+	if r.plan != "" {
+		path := filepath.Join(request.RepoRoot, DefaultAutoPlanDir, request.TaskID+".json")
+		if err := os.WriteFile(path, []byte(r.plan), 0o644); err != nil {
+			return contracts.RunnerResult{}, err
+		}
+	}
+	return r.result, nil
+}
+
+type creatingStorageBackend struct {
+	*spyStorageBackend
+	created []contracts.TaskCreateRequest
+}
+
+func (s *creatingStorageBackend) CreateTask(_ context.Context, request contracts.TaskCreateRequest) (string, error) {
+	s.created = append(s.created, request)
+	return "new-" + strconv.Itoa(len(s.created)), nil
+}
+
+func TestAutoPlannerNeedsPlanOnlyForEpicWithoutChildren(t *testing.T) {
+	empty := newSpyStorageBackend([]contracts.Task{{ID: "root", Title: "Epic", Status: contracts.TaskStatusOpen}}, nil)
+	needs, err := NewAutoPlanner(empty, nil, AutoPlanOptions{}).NeedsPlan(context.Background(), "root")
+	if err != nil || !needs {
+		t.Fatalf("expected empty epic to need a plan, got needs=%v err=%v", needs, err)
+	}
+
+	withChild := newSpyStorageBackend([]contracts.Task{
+		{ID: "root", Title: "Epic", Status: contracts.TaskStatusOpen},
+		{ID: "root.1", Title: "Child", Status: contracts.TaskStatusOpen, ParentID: "root"},
+	}, nil)
+	needs, err = NewAutoPlanner(withChild, nil, AutoPlanOptions{}).NeedsPlan(context.Background(), "root")
+	if err != nil || needs {
+		t.Fatalf("expected epic with children to skip planning, got needs=%v err=%v", needs, err)
+	}
+}
+
+func TestAutoPlannerProposeRunsPlanModeAndOrdersDependencies(t *testing.T) {
+	repoRoot := t.TempDir()
+	storage := newSpyStorageBackend([]contracts.Task{{ID: "root", Title: "Epic", Description: "Build the API", Status: contracts.TaskStatusOpen}}, nil)
+	runner := &planWritingRunner{
+		plan: `{"tasks":[
+			{"key":"api","title":"Add endpoint","description":"Expose it","depends_on":["schema"]},
+			{"key":"schema","title":"Add schema","description":"Define it"}
+		]}`,
+		result: contracts.RunnerResult{Status: contracts.RunnerResultCompleted},
+	}
+
+	proposal, err := NewAutoPlanner(storage, runner, AutoPlanOptions{RepoRoot: repoRoot, Model: "m"}).Propose(context.Background(), "root")
+	if err != nil {
+		t.Fatalf("propose failed: %v", err)
+	}
+	if len(runner.requests) != 1 || runner.requests[0].Mode != contracts.RunnerModePlan {
+		t.Fatalf("expected one plan-mode run, got %#v", runner.requests)
+	}
+	if !strings.Contains(runner.requests[0].Prompt, "Build the API") || !strings.Contains(runner.requests[0].Prompt, filepath.Join(DefaultAutoPlanDir, "root.json")) {
+		t.Fatalf("expected planning prompt to include epic and plan path, got %q", runner.requests[0].Prompt)
+	}
+	keys := []string{}
+	for _, task := range proposal.Tasks {
+		keys = append(keys, task.Key)
+	}
+	if !reflect.DeepEqual(keys, []string{"schema", "api"}) {
+		t.Fatalf("expected dependency order [schema api], got %v", keys)
+	}
+
+	var out bytes.Buffer
+	if err := WriteAutoPlanProposal(&out, proposal); err != nil {
+		t.Fatalf("write proposal: %v", err)
+	}
+	if !strings.Contains(out.String(), "2. [api] Add endpoint (depends on schema)") {
+		t.Fatalf("unexpected proposal output %q", out.String())
+	}
+}
+
+func TestAutoPlannerProposeFailsWhenRunDoesNotComplete(t *testing.T) {
+	storage := newSpyStorageBackend([]contracts.Task{{ID: "root", Title: "Epic", Status: contracts.TaskStatusOpen}}, nil)
+	runner := &planWritingRunner{result: contracts.RunnerResult{Status: contracts.RunnerResultFailed, Reason: "boom"}}
+
+	_, err := NewAutoPlanner(storage, runner, AutoPlanOptions{RepoRoot: t.TempDir()}).Propose(context.Background(), "root")
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected failed planning run error, got %v", err)
+	}
+}
+
+func TestParseAutoPlanRejectsInvalidPlans(t *testing.T) {
+	cases := map[string]string{
+		"empty":     `{"tasks":[]}`,
+		"no title":  `{"tasks":[{"key":"a"}]}`,
+		"duplicate": `{"tasks":[{"key":"a","title":"A"},{"key":"a","title":"B"}]}`,
+		"unknown":   `{"tasks":[{"key":"a","title":"A","depends_on":["b"]}]}`,
+		"cycle":     `{"tasks":[{"key":"a","title":"A","depends_on":["b"]},{"key":"b","title":"B","depends_on":["a"]}]}`,
+	}
+	for name, plan := range cases {
+		if _, err := parseAutoPlan([]byte(plan)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestAutoPlannerApplyCreatesTasksWithResolvedDependencies(t *testing.T) {
+	storage := &creatingStorageBackend{spyStorageBackend: newSpyStorageBackend(nil, nil)}
+	proposal := AutoPlanProposal{RootID: "root", Tasks: []AutoPlanTask{
+		{Key: "schema", Title: "Add schema"},
+		{Key: "api", Title: "Add endpoint", DependsOn: []string{"schema"}},
+	}}
+
+	created, err := NewAutoPlanner(storage, nil, AutoPlanOptions{}).Apply(context.Background(), proposal)
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if !reflect.DeepEqual(created, map[string]string{"schema": "new-1", "api": "new-2"}) {
+		t.Fatalf("unexpected created IDs %#v", created)
+	}
+	if len(storage.created) != 2 || storage.created[1].ParentID != "root" || !reflect.DeepEqual(storage.created[1].DependsOn, []string{"new-1"}) {
+		t.Fatalf("unexpected create requests %#v", storage.created)
+	}
+}
+
+func TestAutoPlannerApplyRequiresTaskCreator(t *testing.T) {
+	storage := newSpyStorageBackend(nil, nil)
+	_, err := NewAutoPlanner(storage, nil, AutoPlanOptions{}).Apply(context.Background(), AutoPlanProposal{RootID: "root", Tasks: []AutoPlanTask{{Key: "a", Title: "A"}}})
+	if err == nil || !strings.Contains(err.Error(), "does not support creating tasks") {
+		t.Fatalf("expected unsupported tracker error, got %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// defaultBlockedRetryBackoff is the first wait of a policy without a backoff.
+const defaultBlockedRetryBackoff = time.Minute
+
+// BlockedRetryPolicy re-queues a task blocked for a transient reason, such as
+// a rate limit, a held lock or a merge conflict on a busy main. The wait
+// doubles with each attempt.
+type BlockedRetryPolicy struct {
+	// MaxAttempts bounds how often one task is re-queued in a run.
+	MaxAttempts int
+	// Backoff is the wait before the first re-queue.
+	Backoff time.Duration
+	// MaxBackoff caps the doubled wait. Zero leaves it uncapped.
+	MaxBackoff time.Duration
+}
+
+// wait returns the backoff before re-queue number attempt, counting from 1.
+func (p BlockedRetryPolicy) wait(attempt int) time.Duration {
+	wait := p.Backoff
+	if wait <= 0 {
+		wait = defaultBlockedRetryBackoff
+	}
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || wait < p.MaxBackoff); i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	return wait
+}
+
+// BlockedRetryCategories lists the failure categories a blocked retry policy
+// can name. Operator cancels are never retried.
+func BlockedRetryCategories() []string {
+	categories := make([]string, 0, len(contracts.FailureCategories))
+	for _, category := range contracts.FailureCategories {
+		if category != contracts.FailureCategoryOperatorCancel {
+			categories = append(categories, string(category))
+		}
+	}
+	return categories
+}
+
+// blockedRetryCategory returns the failure category a blocked task is retried
+// under, or "" when it must not be retried.
+func blockedRetryCategory(data map[string]string) string {
+	category := failureCategoryOf(data)
+	if category == contracts.FailureCategoryOperatorCancel {
+		return ""
+	}
+	return string(category)
+}
+
+// blockedRetryQueue holds the blocked tasks of a run waiting to be re-queued.
+type blockedRetryQueue struct {
+	mu sync.Mutex
+	// blocked holds the category of tasks blocked since their result was
+	// last handled.
+	blocked  map[string]string
+	attempts map[string]int
+	due      map[string]time.Time
+}
+
+func newBlockedRetryQueue() *blockedRetryQueue {
+	return &blockedRetryQueue{blocked: map[string]string{}, attempts: map[string]int{}, due: map[string]time.Time{}}
+}
+
+// noteBlocked records why a task was blocked for scheduleBlockedRetry.
+func (q *blockedRetryQueue) noteBlocked(taskID string, data map[string]string) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.blocked[taskID] = blockedRetryCategory(data)
+}
+
+// next returns when the earliest re-queue is due.
+func (q *blockedRetryQueue) next() (time.Time, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	earliest := time.Time{}
+	for _, at := range q.due {
+		if earliest.IsZero() || at.Before(earliest) {
+			earliest = at
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
+// scheduleBlockedRetry schedules a re-queue of a task that just ended
+// blocked, when its category has a policy with attempts left, and records
+// the plan on the task.
+func (l *Loop) scheduleBlockedRetry(ctx context.Context, taskID string) error {
+	q := l.blockedRetries
+	q.mu.Lock()
+	category, ok := q.blocked[taskID]
+	delete(q.blocked, taskID)
+	policy, hasPolicy := l.options.BlockedRetryPolicies[category]
+	if !ok || !hasPolicy || q.attempts[taskID] >= policy.MaxAttempts {
+		q.mu.Unlock()
+		return nil
+	}
+	q.attempts[taskID]++
+	attempt := q.attempts[taskID]
+	at := time.Now().Add(policy.wait(attempt))
+	q.due[taskID] = at
+	q.mu.Unlock()
+
+	reason := fmt.Sprintf("blocked on %s; re-queue %d of %d", category, attempt, policy.MaxAttempts)
+	data := appendDecisionMetadata(map[string]string{
+		"blocked_retry_category": category,
+		"blocked_retry_count":    fmt.Sprintf("%d", attempt),
+		"blocked_retry_at":       at.UTC().Format(time.RFC3339),
+	}, "blocked_retry", reason)
+	if err := l.setTaskData(ctx, taskID, data); err != nil {
+		return err
+	}
+	_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: taskID, Metadata: data, Timestamp: time.Now().UTC()})
+	return nil
+}
+
+// requeueDueBlockedTasks reopens the blocked tasks whose backoff has passed
+// and returns how many it reopened. A task whose status changed meanwhile is
+// left alone.
+func (l *Loop) requeueDueBlockedTasks(ctx context.Context) (int, error) {
+	q := l.blockedRetries
+	now := time.Now()
+	q.mu.Lock()
+	due := []string{}
+	for taskID, at := range q.due {
+		if !now.Before(at) {
+			due = append(due, taskID)
+			delete(q.due, taskID)
+		}
+	}
+	q.mu.Unlock()
+
+	requeued := 0
+	for _, taskID := range due {
+		task, err := l.tasks.GetTask(ctx, taskID)
+		if err != nil {
+			return requeued, err
+		}
+		if task.Status != contracts.TaskStatusBlocked {
+			continue
+		}
+		if err := l.clearTaskTerminalState(taskID); err != nil {
+			return requeued, err
+		}
+		if err := l.tasks.SetTaskStatus(ctx, taskID, contracts.TaskStatusOpen); err != nil {
+			return requeued, err
+		}
+		q.mu.Lock()
+		attempt := q.attempts[taskID]
+		q.mu.Unlock()
+		_ = l.emit(ctx, contracts.Event{
+			Type:      contracts.EventTypeTaskStatusSet,
+			TaskID:    taskID,
+			TaskTitle: task.Title,
+			Message:   string(contracts.TaskStatusOpen),
+			Metadata:  appendDecisionMetadata(map[string]string{"status": string(contracts.TaskStatusOpen), "blocked_retry_count": fmt.Sprintf("%d", attempt)}, "blocked_retry", "re-queued after backoff"),
+			Timestamp: time.Now().UTC(),
+		})
+		requeued++
+	}
+	return requeued, nil
+}
+
+// waitForBlockedRetry sleeps until at, returning early on a stop request.
+func (l *Loop) waitForBlockedRetry(ctx context.Context, at time.Time) error {
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-l.options.Stop:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
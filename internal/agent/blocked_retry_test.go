@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestLoopRequeuesTaskBlockedOnTransientCategoryAfterBackoff(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultBlocked, Reason: "task lock held by another worker"},
+		{Status: contracts.RunnerResultCompleted},
+	}}
+	sink := &lockedRecordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{
+		ParentID:             "root",
+		BlockedRetryPolicies: map[string]BlockedRetryPolicy{"lock_contention": {MaxAttempts: 2, Backoff: 5 * time.Millisecond}},
+	})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 || summary.Blocked != 0 {
+		t.Fatalf("expected the re-queued task completed, got %#v", summary)
+	}
+	if len(run.requests) != 2 || mgr.statusByID["t-1"] != contracts.TaskStatusClosed {
+		t.Fatalf("expected a second run closing the task, got %d requests status=%s", len(run.requests), mgr.statusByID["t-1"])
+	}
+	if !sink.has(contracts.EventTypeTaskStatusSet, "t-1", string(contracts.TaskStatusOpen)) {
+		t.Fatalf("expected a task_status_set event for the re-queue")
+	}
+}
+
+func TestLoopStopsRequeuingBlockedTaskAfterMaxAttempts(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	blocked := contracts.RunnerResult{Status: contracts.RunnerResultBlocked, Reason: "merge conflict on main"}
+	run := &fakeRunner{results: []contracts.RunnerResult{blocked, blocked, blocked, blocked}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{
+		ParentID:             "root",
+		BlockedRetryPolicies: map[string]BlockedRetryPolicy{"merge_queue_conflict": {MaxAttempts: 2, Backoff: time.Millisecond}},
+	})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || len(run.requests) != 3 {
+		t.Fatalf("expected two re-queues then a blocked task, got %#v after %d requests", summary, len(run.requests))
+	}
+	if data := mgr.dataByID["t-1"]; data["blocked_retry_count"] != "2" || data["blocked_retry_category"] != "merge_queue_conflict" {
+		t.Fatalf("expected the re-queues recorded in task data, got %#v", data)
+	}
+}
+
+func TestLoopLeavesBlockedTaskWithoutPolicyBlocked(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultBlocked, Reason: "needs product decision"}}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{
+		ParentID:             "root",
+		BlockedRetryPolicies: map[string]BlockedRetryPolicy{"lock_contention": {MaxAttempts: 3}},
+	})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || len(run.requests) != 1 || mgr.statusByID["t-1"] != contracts.TaskStatusBlocked {
+		t.Fatalf("expected the task left blocked, got %#v status=%s", summary, mgr.statusByID["t-1"])
+	}
+}
+
+func TestBlockedRetryPolicyWaitDoublesUpToCap(t *testing.T) {
+	policy := BlockedRetryPolicy{Backoff: time.Minute, MaxBackoff: 5 * time.Minute}
+	for attempt, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 3: 4 * time.Minute, 4: 5 * time.Minute} {
+		if got := policy.wait(attempt); got != want {
+			t.Fatalf("attempt %d: expected %s, got %s", attempt, want, got)
+		}
+	}
+	if got := (BlockedRetryPolicy{}).wait(1); got != defaultBlockedRetryBackoff {
+		t.Fatalf("expected default backoff, got %s", got)
+	}
+}
+
+func TestBlockedRetryCategoryPrefersStallCategoryAndSkipsOperatorCancels(t *testing.T) {
+	if got := blockedRetryCategory(map[string]string{"stall_category": "rate_limit", "triage_reason": "merge conflict"}); got != "rate_limit" {
+		t.Fatalf("expected the stall category, got %q", got)
+	}
+	if got := blockedRetryCategory(map[string]string{"triage_reason": "CONFLICT: merge conflict in a.go"}); got != "merge_queue_conflict" {
+		t.Fatalf("expected the triage category, got %q", got)
+	}
+	if got := blockedRetryCategory(map[string]string{"triage_category": "secret_scan", "triage_reason": "merge conflict"}); got != "secret_scan" {
+		t.Fatalf("expected the recorded triage category, got %q", got)
+	}
+	if got := blockedRetryCategory(map[string]string{"triage_reason": operatorCancelReason}); got != "" {
+		t.Fatalf("expected operator cancels not retried, got %q", got)
+	}
+}
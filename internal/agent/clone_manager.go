@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -12,6 +13,7 @@ import (
 
 type GitCloneManager struct {
 	baseDir string
+	runID   string
 
 	mu     sync.Mutex
 	clones map[string]string
@@ -27,6 +29,26 @@ func NewGitCloneManager(baseDir string) *GitCloneManager {
 	}
 }
 
+// WithRunID names clones <task>@<runID>, so runs overlapping on one repo
+// keep their clones apart.
+func (m *GitCloneManager) WithRunID(runID string) *GitCloneManager {
+	m.runID = strings.TrimSpace(runID)
+	return m
+}
+
+// clonePath is where the task's clone goes in this run.
+func (m *GitCloneManager) clonePath(taskID string) string {
+	return filepath.Join(m.baseDir, runScopedName(taskID, m.runID))
+}
+
+// runScopedName suffixes name with the run ID, when there is one.
+func runScopedName(name string, runID string) string {
+	if runID == "" {
+		return name
+	}
+	return name + "@" + runID
+}
+
 func (m *GitCloneManager) CloneForTask(ctx context.Context, taskID string, repoRoot string) (string, error) {
 	if strings.TrimSpace(repoRoot) == "" {
 		return "", fmt.Errorf("repo root is required")
@@ -34,15 +56,11 @@ func (m *GitCloneManager) CloneForTask(ctx context.Context, taskID string, repoR
 	if err := os.MkdirAll(m.baseDir, 0o755); err != nil {
 		return "", err
 	}
-	clonePath := filepath.Join(m.baseDir, taskID)
+	clonePath := m.clonePath(taskID)
 	if err := os.RemoveAll(clonePath); err != nil {
 		return "", err
 	}
-	cmd := exec.CommandContext(ctx, "git", "clone", "--no-hardlinks", repoRoot, clonePath)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("git clone failed: %s: %w", strings.TrimSpace(string(output)), err)
-	}
-	if err := setCloneOriginToSourceOrigin(ctx, repoRoot, clonePath); err != nil {
+	if err := cloneSourceRepository(ctx, repoRoot, clonePath); err != nil {
 		return "", err
 	}
 
@@ -53,39 +71,97 @@ func (m *GitCloneManager) CloneForTask(ctx context.Context, taskID string, repoR
 	return clonePath, nil
 }
 
-func setCloneOriginToSourceOrigin(ctx context.Context, repoRoot string, clonePath string) error {
-	originURL, err := sourceOriginURL(ctx, repoRoot)
-	if err != nil {
-		return err
+// CloneWorkspaceForTask clones the task's workspace repository, checked out
+// at workspace.Ref when set. The clone's origin is the workspace repository.
+func (m *GitCloneManager) CloneWorkspaceForTask(ctx context.Context, taskID string, workspace Workspace) (string, error) {
+	if strings.TrimSpace(workspace.Repo) == "" {
+		return "", fmt.Errorf("workspace repo is required")
 	}
-	if originURL == "" {
-		return nil
+	if err := os.MkdirAll(m.baseDir, 0o755); err != nil {
+		return "", err
+	}
+	clonePath := m.clonePath(taskID)
+	if err := os.RemoveAll(clonePath); err != nil {
+		return "", err
+	}
+	args := []string{"clone", "--no-hardlinks"}
+	if ref := strings.TrimSpace(workspace.Ref); ref != "" {
+		args = append(args, "--branch", ref)
 	}
-	cmd := exec.CommandContext(ctx, "git", "-C", clonePath, "remote", "set-url", "origin", originURL)
+	args = append(args, workspace.Repo, clonePath)
+	cmd := exec.CommandContext(ctx, "git", args...)
 	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("git remote set-url origin failed: %s: %w", strings.TrimSpace(string(output)), err)
+		return "", fmt.Errorf("git clone failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+
+	m.mu.Lock()
+	m.clones[taskID] = clonePath
+	m.mu.Unlock()
+
+	return clonePath, nil
+}
+
+// copySourceRemotesToClone points the clone's origin at the source's origin
+// and adds the source's other remotes, so a sync remote other than origin is
+// also reachable from the clone.
+func copySourceRemotesToClone(ctx context.Context, repoRoot string, clonePath string) error {
+	for _, remote := range sourceRemotes(ctx, repoRoot) {
+		url := sourceRemoteURL(ctx, repoRoot, remote)
+		if url == "" {
+			continue
+		}
+		args := []string{"-C", clonePath, "remote", "add", remote, url}
+		if remote == "origin" {
+			args = []string{"-C", clonePath, "remote", "set-url", "origin", url}
+		}
+		cmd := exec.CommandContext(ctx, "git", args...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s failed: %s: %w", strings.Join(args[2:], " "), strings.TrimSpace(string(output)), err)
+		}
 	}
 	return nil
 }
 
-func sourceOriginURL(ctx context.Context, repoRoot string) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", "-C", repoRoot, "remote", "get-url", "origin")
+// sourceRemotes lists the source's remotes. Repositories used in tests or
+// local bootstrap may have none.
+func sourceRemotes(ctx context.Context, repoRoot string) []string {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoRoot, "remote")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		// Repositories used in tests or local bootstrap may not have origin configured.
-		return "", nil
+		return nil
 	}
-	return strings.TrimSpace(string(output)), nil
+	return strings.Fields(string(output))
 }
 
+func sourceRemoteURL(ctx context.Context, repoRoot string, remote string) string {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoRoot, "remote", "get-url", remote)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// Cleanup removes the task's clone. For a task this manager did not clone,
+// it removes the clones any run left for it, e.g. one that crashed.
 func (m *GitCloneManager) Cleanup(taskID string) error {
 	m.mu.Lock()
 	clonePath := m.clones[taskID]
 	delete(m.clones, taskID)
 	m.mu.Unlock()
 
-	if clonePath == "" {
-		clonePath = filepath.Join(m.baseDir, taskID)
+	if clonePath != "" {
+		return os.RemoveAll(clonePath)
+	}
+	errs := []error{os.RemoveAll(filepath.Join(m.baseDir, taskID))}
+	entries, err := os.ReadDir(m.baseDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		errs = append(errs, err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), taskID+"@") {
+			errs = append(errs, os.RemoveAll(filepath.Join(m.baseDir, entry.Name())))
+		}
 	}
-	return os.RemoveAll(clonePath)
+	return errors.Join(errs...)
 }
@@ -48,6 +48,40 @@ func TestGitCloneManagerClonesRepoPerTaskAndCleansUp(t *testing.T) {
 	}
 }
 
+func TestGitCloneManagerNamesClonesByRunAndCleansUpStaleOnes(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required")
+	}
+
+	repoRoot := t.TempDir()
+	runGit(t, repoRoot, "init")
+	runGit(t, repoRoot, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "init")
+
+	baseDir := t.TempDir()
+	crashed := NewGitCloneManager(baseDir).WithRunID("run-a")
+	stalePath, err := crashed.CloneForTask(context.Background(), "t-1", repoRoot)
+	if err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	if stalePath != filepath.Join(baseDir, "t-1@run-a") {
+		t.Fatalf("expected run-scoped clone path, got %q", stalePath)
+	}
+	other, err := crashed.CloneForTask(context.Background(), "t-10", repoRoot)
+	if err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+
+	if err := NewGitCloneManager(baseDir).WithRunID("run-b").Cleanup("t-1"); err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+	if _, err := os.Stat(stalePath); !os.IsNotExist(err) {
+		t.Fatalf("expected the stale clone removed, got err=%v", err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Fatalf("expected the clone of another task kept: %v", err)
+	}
+}
+
 func TestGitCloneManagerSetsCloneOriginToSourceUpstream(t *testing.T) {
 	if _, err := exec.LookPath("git"); err != nil {
 		t.Skip("git is required")
@@ -80,6 +114,79 @@ func TestGitCloneManagerSetsCloneOriginToSourceUpstream(t *testing.T) {
 	}
 }
 
+func TestGitCloneManagerCopiesNonOriginRemotesToClone(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required")
+	}
+
+	remoteRoot := t.TempDir()
+	originPath := filepath.Join(remoteRoot, "fork.git")
+	upstreamPath := filepath.Join(remoteRoot, "upstream.git")
+	runGit(t, remoteRoot, "init", "--bare", originPath)
+	runGit(t, remoteRoot, "init", "--bare", upstreamPath)
+
+	repoRoot := t.TempDir()
+	runGit(t, repoRoot, "init")
+	if err := os.WriteFile(filepath.Join(repoRoot, "README.md"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	runGit(t, repoRoot, "add", "README.md")
+	runGit(t, repoRoot, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-m", "init")
+	runGit(t, repoRoot, "remote", "add", "origin", originPath)
+	runGit(t, repoRoot, "remote", "add", "upstream", upstreamPath)
+
+	manager := NewGitCloneManager(t.TempDir())
+	clonePath, err := manager.CloneForTask(context.Background(), "t-upstream", repoRoot)
+	if err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	defer func() { _ = manager.Cleanup("t-upstream") }()
+
+	if got := strings.TrimSpace(runGitOutput(t, clonePath, "remote", "get-url", "origin")); got != originPath {
+		t.Fatalf("expected clone origin=%q, got %q", originPath, got)
+	}
+	if got := strings.TrimSpace(runGitOutput(t, clonePath, "remote", "get-url", "upstream")); got != upstreamPath {
+		t.Fatalf("expected clone upstream=%q, got %q", upstreamPath, got)
+	}
+}
+
+func TestGitCloneManagerClonesTaskWorkspaceAtRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required")
+	}
+
+	workspaceRoot := t.TempDir()
+	runGit(t, workspaceRoot, "init", "-b", "main")
+	if err := os.WriteFile(filepath.Join(workspaceRoot, "docs.md"), []byte("v1\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	runGit(t, workspaceRoot, "add", "docs.md")
+	runGit(t, workspaceRoot, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-m", "init")
+	runGit(t, workspaceRoot, "checkout", "-b", "release")
+	if err := os.WriteFile(filepath.Join(workspaceRoot, "docs.md"), []byte("v2\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	runGit(t, workspaceRoot, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-am", "release")
+	runGit(t, workspaceRoot, "checkout", "main")
+
+	manager := NewGitCloneManager(t.TempDir())
+	clonePath, err := manager.CloneWorkspaceForTask(context.Background(), "t-docs", Workspace{Repo: workspaceRoot, Ref: "release"})
+	if err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	defer func() { _ = manager.Cleanup("t-docs") }()
+
+	if got := strings.TrimSpace(runGitOutput(t, clonePath, "rev-parse", "--abbrev-ref", "HEAD")); got != "release" {
+		t.Fatalf("expected clone checked out at release, got %q", got)
+	}
+	if got := strings.TrimSpace(runGitOutput(t, clonePath, "remote", "get-url", "origin")); got != workspaceRoot {
+		t.Fatalf("expected clone origin=%q, got %q", workspaceRoot, got)
+	}
+	if _, err := manager.CloneWorkspaceForTask(context.Background(), "t-missing", Workspace{Repo: filepath.Join(workspaceRoot, "missing")}); err == nil {
+		t.Fatalf("expected a missing workspace repo to fail")
+	}
+}
+
 func TestGitCloneManagerCreatesIsolatedParallelClones(t *testing.T) {
 	if _, err := exec.LookPath("git"); err != nil {
 		t.Skip("git is required")
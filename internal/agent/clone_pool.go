@@ -0,0 +1,370 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ClonePoolRefresh is how a clone that finished a task is readied for the
+// next one.
+type ClonePoolRefresh string
+
+const (
+	// ClonePoolRefreshReset fetches the source's HEAD into the clone, resets
+	// it there and removes untracked files and task branches.
+	ClonePoolRefreshReset ClonePoolRefresh = "reset"
+	// ClonePoolRefreshRecreate discards the clone and clones again.
+	ClonePoolRefreshRecreate ClonePoolRefresh = "recreate"
+)
+
+// ParseClonePoolRefresh parses a refresh policy; empty means reset.
+func ParseClonePoolRefresh(raw string) (ClonePoolRefresh, error) {
+	switch ClonePoolRefresh(strings.ToLower(strings.TrimSpace(raw))) {
+	case "", ClonePoolRefreshReset:
+		return ClonePoolRefreshReset, nil
+	case ClonePoolRefreshRecreate:
+		return ClonePoolRefreshRecreate, nil
+	default:
+		return "", fmt.Errorf("unsupported clone pool refresh %q (expected reset or recreate)", raw)
+	}
+}
+
+// ClonePoolOptions configures a ClonePool. Size is the number of clones kept
+// ready. MaxUses recreates a clone after that many tasks even with the reset
+// policy; 0 means no limit.
+type ClonePoolOptions struct {
+	Size    int
+	Refresh ClonePoolRefresh
+	MaxUses int
+}
+
+// ClonePoolStats counts how often a task found a ready clone.
+type ClonePoolStats struct {
+	Size   int
+	Ready  int
+	Hits   int
+	Misses int
+}
+
+// HitRate is the share of clones handed out from the pool, between 0 and 1.
+func (s ClonePoolStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+type pooledClone struct {
+	path string
+	uses int
+}
+
+// ClonePool is a CloneManager that keeps clones of the repository ready, so
+// a task starts in a workspace without waiting for git clone. A ready clone
+// is moved to the task's clone path; after the task it is refreshed and
+// returned to the pool. When the pool is empty the task clones as
+// GitCloneManager does. Workspace clones and clones of other
+// repositories are not pooled.
+type ClonePool struct {
+	clones *GitCloneManager
+
+	repoRoot string
+	poolDir  string
+	options  ClonePoolOptions
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+
+	mu      sync.Mutex
+	ready   []*pooledClone
+	leased  map[string]*pooledClone
+	pending int
+	next    int
+	hits    int
+	misses  int
+	closed  bool
+}
+
+func NewClonePool(baseDir string, repoRoot string, options ClonePoolOptions) *ClonePool {
+	if options.Refresh == "" {
+		options.Refresh = ClonePoolRefreshReset
+	}
+	manager := NewGitCloneManager(baseDir)
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ClonePool{
+		clones:   manager,
+		repoRoot: filepath.Clean(repoRoot),
+		poolDir:  filepath.Join(manager.baseDir, ".pool"),
+		options:  options,
+		ctx:      ctx,
+		cancel:   cancel,
+		leased:   map[string]*pooledClone{},
+	}
+}
+
+// WithRunID names the clones handed to tasks <task>@<runID>; see
+// GitCloneManager.WithRunID.
+func (p *ClonePool) WithRunID(runID string) *ClonePool {
+	p.clones.WithRunID(runID)
+	return p
+}
+
+// Warm starts filling the pool in the background.
+func (p *ClonePool) Warm() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fillLocked()
+}
+
+func (p *ClonePool) CloneForTask(ctx context.Context, taskID string, repoRoot string) (string, error) {
+	if strings.TrimSpace(repoRoot) == "" || filepath.Clean(repoRoot) != p.repoRoot {
+		return p.clones.CloneForTask(ctx, taskID, repoRoot)
+	}
+	clonePath := p.clones.clonePath(taskID)
+	if err := os.RemoveAll(clonePath); err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	var clone *pooledClone
+	if n := len(p.ready); n > 0 {
+		clone = p.ready[n-1]
+		p.ready = p.ready[:n-1]
+	}
+	p.mu.Unlock()
+
+	if clone != nil {
+		if err := moveClone(clone.path, clonePath); err == nil {
+			clone.path = clonePath
+			p.lease(taskID, clone, true)
+			return clonePath, nil
+		}
+		_ = os.RemoveAll(clone.path)
+	}
+	if err := cloneSourceRepository(ctx, p.repoRoot, clonePath); err != nil {
+		p.mu.Lock()
+		p.misses++
+		p.fillLocked()
+		p.mu.Unlock()
+		return "", err
+	}
+	p.lease(taskID, &pooledClone{path: clonePath}, false)
+	return clonePath, nil
+}
+
+func (p *ClonePool) lease(taskID string, clone *pooledClone, hit bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if hit {
+		p.hits++
+	} else {
+		p.misses++
+	}
+	p.leased[taskID] = clone
+	p.fillLocked()
+}
+
+// Cleanup refreshes the task's clone and returns it to the pool when the
+// pool is short of clones, and removes it otherwise. A reset is quick and
+// runs before Cleanup returns, so the worker's next task finds the clone
+// ready; a clone that must be recreated is replaced in the background.
+func (p *ClonePool) Cleanup(taskID string) error {
+	p.mu.Lock()
+	clone, ok := p.leased[taskID]
+	delete(p.leased, taskID)
+	keep := ok && !p.closed && len(p.ready)+p.pending < p.options.Size
+	p.mu.Unlock()
+
+	if !ok {
+		return p.clones.Cleanup(taskID)
+	}
+	if !keep {
+		return os.RemoveAll(clone.path)
+	}
+	clone.uses++
+	if p.needsRecreate(clone) || resetPooledClone(p.ctx, p.repoRoot, clone.path) != nil {
+		err := os.RemoveAll(clone.path)
+		p.mu.Lock()
+		p.fillLocked()
+		p.mu.Unlock()
+		return err
+	}
+
+	p.mu.Lock()
+	keep = !p.closed && len(p.ready)+p.pending < p.options.Size
+	if keep {
+		poolPath := p.nextPathLocked()
+		if err := moveClone(clone.path, poolPath); err == nil {
+			clone.path = poolPath
+			p.ready = append(p.ready, clone)
+		} else {
+			keep = false
+		}
+	}
+	p.mu.Unlock()
+	if !keep {
+		return os.RemoveAll(clone.path)
+	}
+	return nil
+}
+
+// CloneWorkspaceForTask clones the task's workspace repository without the
+// pool.
+func (p *ClonePool) CloneWorkspaceForTask(ctx context.Context, taskID string, workspace Workspace) (string, error) {
+	return p.clones.CloneWorkspaceForTask(ctx, taskID, workspace)
+}
+
+// Stats reports the pool's hits and misses so far.
+func (p *ClonePool) Stats() ClonePoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return ClonePoolStats{Size: p.options.Size, Ready: len(p.ready), Hits: p.hits, Misses: p.misses}
+}
+
+// Close stops filling the pool, waits for clones being created or refreshed
+// and removes the ready ones. Clones still leased to tasks are left to
+// Cleanup.
+func (p *ClonePool) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.cancel()
+	p.wg.Wait()
+
+	p.mu.Lock()
+	ready := p.ready
+	p.ready = nil
+	p.mu.Unlock()
+	for _, clone := range ready {
+		_ = os.RemoveAll(clone.path)
+	}
+	return os.RemoveAll(p.poolDir)
+}
+
+// fillLocked starts a clone for every slot that neither a ready clone nor a
+// leased one, which comes back after its task, will fill. A failed clone is
+// not retried until the next task takes or returns a clone.
+func (p *ClonePool) fillLocked() {
+	for !p.closed && len(p.ready)+p.pending+len(p.leased) < p.options.Size {
+		path := p.nextPathLocked()
+		p.pending++
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			clone := &pooledClone{path: path}
+			p.add(clone, cloneSourceRepository(p.ctx, p.repoRoot, path))
+		}()
+	}
+}
+
+func moveClone(from string, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(from, to)
+}
+
+func (p *ClonePool) nextPathLocked() string {
+	p.next++
+	return filepath.Join(p.poolDir, strconv.Itoa(p.next))
+}
+
+// add ends a pending slot, putting clone in the pool unless err is set or
+// the pool was closed meanwhile.
+func (p *ClonePool) add(clone *pooledClone, err error) {
+	p.mu.Lock()
+	p.pending--
+	keep := clone != nil && err == nil && !p.closed
+	if keep {
+		p.ready = append(p.ready, clone)
+	}
+	p.mu.Unlock()
+	if clone != nil && !keep {
+		_ = os.RemoveAll(clone.path)
+	}
+}
+
+// needsRecreate reports whether a used clone is cloned again instead of
+// reset.
+func (p *ClonePool) needsRecreate(clone *pooledClone) bool {
+	return p.options.Refresh == ClonePoolRefreshRecreate ||
+		(p.options.MaxUses > 0 && clone.uses >= p.options.MaxUses) ||
+		cloneHasOperationInProgress(clone.path)
+}
+
+// cloneSourceRepository clones repoRoot to clonePath with the source's
+// remotes.
+func cloneSourceRepository(ctx context.Context, repoRoot string, clonePath string) error {
+	if err := os.MkdirAll(filepath.Dir(clonePath), 0o755); err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "git", "clone", "--no-hardlinks", repoRoot, clonePath)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git clone failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return copySourceRemotesToClone(ctx, repoRoot, clonePath)
+}
+
+// resetPooledClone brings a used clone back to the state of a fresh one:
+// the source's current HEAD checked out on the source's branch, no untracked
+// files and no other local branches, so a task branch of an earlier task
+// cannot be reused.
+func resetPooledClone(ctx context.Context, repoRoot string, clonePath string) error {
+	branch := ""
+	if output, err := exec.CommandContext(ctx, "git", "-C", repoRoot, "symbolic-ref", "--short", "-q", "HEAD").Output(); err == nil {
+		branch = strings.TrimSpace(string(output))
+	}
+	checkout := []string{"checkout", "--quiet", "--force", "--detach", "FETCH_HEAD"}
+	if branch != "" {
+		checkout = []string{"checkout", "--quiet", "--force", "-B", branch, "FETCH_HEAD"}
+	}
+	steps := [][]string{
+		{"fetch", "--quiet", "--no-tags", repoRoot, "HEAD"},
+		checkout,
+		{"clean", "-ffdxq"},
+	}
+	for _, args := range steps {
+		if err := runCloneGit(ctx, clonePath, args...); err != nil {
+			return err
+		}
+	}
+	output, err := exec.CommandContext(ctx, "git", "-C", clonePath, "for-each-ref", "--format=%(refname:short)", "refs/heads").Output()
+	if err != nil {
+		return fmt.Errorf("git for-each-ref failed: %w", err)
+	}
+	for _, name := range strings.Fields(string(output)) {
+		if name == branch {
+			continue
+		}
+		if err := runCloneGit(ctx, clonePath, "branch", "-D", name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runCloneGit(ctx context.Context, clonePath string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", clonePath}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %s: %w", strings.Join(args, " "), strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// cloneHasOperationInProgress reports a merge, rebase or cherry-pick left
+// behind by a task, which a reset does not reliably clear.
+func cloneHasOperationInProgress(clonePath string) bool {
+	for _, name := range []string{"MERGE_HEAD", "CHERRY_PICK_HEAD", "REVERT_HEAD", "rebase-merge", "rebase-apply"} {
+		if _, err := os.Stat(filepath.Join(clonePath, ".git", name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
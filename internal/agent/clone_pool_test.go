@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClonePoolHandsOutWarmCloneAtTaskPath(t *testing.T) {
+	repoRoot := initClonePoolSourceRepo(t)
+	baseDir := t.TempDir()
+	pool := NewClonePool(baseDir, repoRoot, ClonePoolOptions{Size: 1})
+	defer pool.Close()
+	pool.Warm()
+	waitForReadyClones(t, pool, 1)
+
+	clonePath, err := pool.CloneForTask(context.Background(), "t-1", repoRoot)
+	if err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	if clonePath != filepath.Join(baseDir, "t-1") {
+		t.Fatalf("expected clone at the task path, got %q", clonePath)
+	}
+	if _, err := os.Stat(filepath.Join(clonePath, "README.md")); err != nil {
+		t.Fatalf("expected tracked file in clone: %v", err)
+	}
+	stats := pool.Stats()
+	if stats.Hits != 1 || stats.Misses != 0 || stats.HitRate() != 1 {
+		t.Fatalf("expected one hit, got %#v", stats)
+	}
+}
+
+func TestClonePoolClonesOnMissWhenPoolIsEmpty(t *testing.T) {
+	repoRoot := initClonePoolSourceRepo(t)
+	pool := NewClonePool(t.TempDir(), repoRoot, ClonePoolOptions{Size: 1})
+	defer pool.Close()
+
+	clonePath, err := pool.CloneForTask(context.Background(), "t-1", repoRoot)
+	if err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(clonePath, "README.md")); err != nil {
+		t.Fatalf("expected tracked file in clone: %v", err)
+	}
+	stats := pool.Stats()
+	if stats.Hits != 0 || stats.Misses != 1 || stats.HitRate() != 0 {
+		t.Fatalf("expected one miss, got %#v", stats)
+	}
+}
+
+func TestClonePoolResetsReturnedCloneForNextTask(t *testing.T) {
+	repoRoot := initClonePoolSourceRepo(t)
+	pool := NewClonePool(t.TempDir(), repoRoot, ClonePoolOptions{Size: 1})
+	defer pool.Close()
+
+	clonePath, err := pool.CloneForTask(context.Background(), "t-1", repoRoot)
+	if err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	runGit(t, clonePath, "checkout", "-b", "task/t-1")
+	if err := os.WriteFile(filepath.Join(clonePath, "work.txt"), []byte("work\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	runGit(t, clonePath, "add", "work.txt")
+	runGit(t, clonePath, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-m", "work")
+	if err := os.WriteFile(filepath.Join(clonePath, "scratch.txt"), []byte("scratch\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	commitClonePoolSourceFile(t, repoRoot, "NEXT.md")
+
+	if err := pool.Cleanup("t-1"); err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+	if _, err := os.Stat(clonePath); !os.IsNotExist(err) {
+		t.Fatalf("expected task path to be released, got err=%v", err)
+	}
+	if ready := pool.Stats().Ready; ready != 1 {
+		t.Fatalf("expected the returned clone to be ready, got %d", ready)
+	}
+
+	nextPath, err := pool.CloneForTask(context.Background(), "t-2", repoRoot)
+	if err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	for _, name := range []string{"work.txt", "scratch.txt"} {
+		if _, err := os.Stat(filepath.Join(nextPath, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s from the previous task to be gone, got err=%v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(nextPath, "NEXT.md")); err != nil {
+		t.Fatalf("expected the source's new commit in the refreshed clone: %v", err)
+	}
+	if branches := strings.TrimSpace(runGitOutput(t, nextPath, "branch", "--format=%(refname:short)")); strings.Contains(branches, "task/t-1") {
+		t.Fatalf("expected the previous task branch to be deleted, got %q", branches)
+	}
+	if stats := pool.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("expected one hit and one miss, got %#v", stats)
+	}
+}
+
+func TestClonePoolRecreatesCloneAfterMaxUses(t *testing.T) {
+	repoRoot := initClonePoolSourceRepo(t)
+	pool := NewClonePool(t.TempDir(), repoRoot, ClonePoolOptions{Size: 1, MaxUses: 1})
+	defer pool.Close()
+
+	clonePath, err := pool.CloneForTask(context.Background(), "t-1", repoRoot)
+	if err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(clonePath, ".git", "marker"), []byte("used\n"), 0o644); err != nil {
+		t.Fatalf("write marker: %v", err)
+	}
+	if err := pool.Cleanup("t-1"); err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+	waitForReadyClones(t, pool, 1)
+
+	nextPath, err := pool.CloneForTask(context.Background(), "t-2", repoRoot)
+	if err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(nextPath, ".git", "marker")); !os.IsNotExist(err) {
+		t.Fatalf("expected a fresh clone after max_uses, got err=%v", err)
+	}
+}
+
+func TestClonePoolDoesNotPoolWorkspaceClones(t *testing.T) {
+	repoRoot := initClonePoolSourceRepo(t)
+	workspaceRepo := initClonePoolSourceRepo(t)
+	baseDir := t.TempDir()
+	pool := NewClonePool(baseDir, repoRoot, ClonePoolOptions{Size: 1})
+	defer pool.Close()
+
+	clonePath, err := pool.CloneWorkspaceForTask(context.Background(), "t-ws", Workspace{Repo: workspaceRepo})
+	if err != nil {
+		t.Fatalf("workspace clone failed: %v", err)
+	}
+	if err := pool.Cleanup("t-ws"); err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+	if _, err := os.Stat(clonePath); !os.IsNotExist(err) {
+		t.Fatalf("expected workspace clone removed, got err=%v", err)
+	}
+	if stats := pool.Stats(); stats.Ready != 0 || stats.Hits+stats.Misses != 0 {
+		t.Fatalf("expected workspace clones to bypass the pool, got %#v", stats)
+	}
+}
+
+func TestClonePoolCloseRemovesReadyClones(t *testing.T) {
+	repoRoot := initClonePoolSourceRepo(t)
+	baseDir := t.TempDir()
+	pool := NewClonePool(baseDir, repoRoot, ClonePoolOptions{Size: 2})
+	pool.Warm()
+	waitForReadyClones(t, pool, 2)
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, ".pool")); !os.IsNotExist(err) {
+		t.Fatalf("expected pool directory removed, got err=%v", err)
+	}
+}
+
+func TestParseClonePoolRefresh(t *testing.T) {
+	for raw, expected := range map[string]ClonePoolRefresh{"": ClonePoolRefreshReset, "reset": ClonePoolRefreshReset, " Recreate ": ClonePoolRefreshRecreate} {
+		got, err := ParseClonePoolRefresh(raw)
+		if err != nil || got != expected {
+			t.Fatalf("ParseClonePoolRefresh(%q) = %q, %v; expected %q", raw, got, err, expected)
+		}
+	}
+	if _, err := ParseClonePoolRefresh("rsync"); err == nil {
+		t.Fatalf("expected an error for an unknown refresh policy")
+	}
+}
+
+func initClonePoolSourceRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required")
+	}
+	repoRoot := t.TempDir()
+	runGit(t, repoRoot, "init", "-b", "main")
+	commitClonePoolSourceFile(t, repoRoot, "README.md")
+	return repoRoot
+}
+
+func commitClonePoolSourceFile(t *testing.T, repoRoot string, name string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(repoRoot, name), []byte(name+"\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	runGit(t, repoRoot, "add", name)
+	runGit(t, repoRoot, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-m", "add "+name)
+}
+
+func waitForReadyClones(t *testing.T, pool *ClonePool, ready int) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for pool.Stats().Ready < ready {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d ready clones, got %#v", ready, pool.Stats())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,194 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// CommitMessageConfig holds the landing commit message templates (Go
+// text/template). Empty fields keep the built-in behaviour.
+type CommitMessageConfig struct {
+	// AutoCommit renders the commit that captures the agent's changes
+	// before landing.
+	AutoCommit string
+	// Merge renders the --no-ff merge commit onto main.
+	Merge string
+	// Squash renders the single commit of the squash landing strategy.
+	Squash string
+	// TrackerURL renders the task's tracker link, exposed as .TrackerURL.
+	TrackerURL string
+	// CoAuthoredBy is the "Name <email>" of the agent identity, exposed as
+	// a Co-authored-by trailer in .CoAuthoredBy.
+	CoAuthoredBy string
+}
+
+// CommitMessageData is the value passed to commit message templates.
+type CommitMessageData struct {
+	Task          contracts.Task
+	Branch        string
+	TrackerURL    string
+	ReviewVerdict string
+	Backend       string
+	Model         string
+	CoAuthoredBy  string
+	// Default is the built-in message for the same commit.
+	Default string
+}
+
+// CommitMessages renders landing commit messages from CommitMessageConfig.
+type CommitMessages struct {
+	autoCommit   *template.Template
+	merge        *template.Template
+	squash       *template.Template
+	trackerURL   *template.Template
+	coAuthoredBy string
+}
+
+// ParseCommitMessages parses cfg. It returns nil when nothing is configured.
+// Templates are executed once against sample data so unknown fields fail at
+// startup rather than at landing; missing .Task.Metadata keys render empty.
+func ParseCommitMessages(cfg CommitMessageConfig) (*CommitMessages, error) {
+	if strings.TrimSpace(cfg.AutoCommit) == "" && strings.TrimSpace(cfg.Merge) == "" && strings.TrimSpace(cfg.Squash) == "" &&
+		strings.TrimSpace(cfg.TrackerURL) == "" && strings.TrimSpace(cfg.CoAuthoredBy) == "" {
+		return nil, nil
+	}
+	messages := &CommitMessages{coAuthoredBy: strings.TrimSpace(cfg.CoAuthoredBy)}
+	for _, field := range []struct {
+		name   string
+		source string
+		target **template.Template
+	}{
+		{"auto_commit", cfg.AutoCommit, &messages.autoCommit},
+		{"merge", cfg.Merge, &messages.merge},
+		{"squash", cfg.Squash, &messages.squash},
+		{"tracker_url", cfg.TrackerURL, &messages.trackerURL},
+	} {
+		if strings.TrimSpace(field.source) == "" {
+			continue
+		}
+		parsed, err := template.New(field.name).Option("missingkey=zero").Parse(field.source)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s commit message template: %w", field.name, err)
+		}
+		*field.target = parsed
+	}
+	sample := contracts.Task{ID: "task-1", Title: "Sample task", Metadata: map[string]string{}}
+	if _, err := messages.AutoCommit(sample, "task/task-1", "pass", "codex", "gpt-5"); err != nil {
+		return nil, err
+	}
+	if _, _, err := messages.Merge(sample, "task/task-1", "pass", "codex", "gpt-5"); err != nil {
+		return nil, err
+	}
+	if _, err := messages.Squash(sample, "task/task-1", "pass", "codex", "gpt-5"); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// AutoCommit renders the pre-landing commit message. Without a template it
+// is the built-in message plus the Co-authored-by trailer when configured.
+func (m *CommitMessages) AutoCommit(task contracts.Task, branch string, reviewVerdict string, backend string, model string) (string, error) {
+	defaultMessage := autoLandingCommitMessage(task)
+	if m == nil {
+		return defaultMessage, nil
+	}
+	data, err := m.data(task, branch, reviewVerdict, backend, model, defaultMessage)
+	if err != nil {
+		return "", err
+	}
+	if m.autoCommit == nil {
+		return appendCommitTrailer(defaultMessage, data.CoAuthoredBy), nil
+	}
+	return renderCommitMessage(m.autoCommit, data)
+}
+
+// Merge renders the merge commit message. The boolean result is false when
+// no merge template is configured, in which case git's default is kept.
+func (m *CommitMessages) Merge(task contracts.Task, branch string, reviewVerdict string, backend string, model string) (string, bool, error) {
+	if m == nil || m.merge == nil {
+		return "", false, nil
+	}
+	data, err := m.data(task, branch, reviewVerdict, backend, model, fmt.Sprintf("Merge branch '%s'", branch))
+	if err != nil {
+		return "", true, err
+	}
+	message, err := renderCommitMessage(m.merge, data)
+	return message, true, err
+}
+
+// Squash renders the squash landing commit message. Without a template it
+// is "<task id>: <title>" plus the Co-authored-by trailer when configured.
+func (m *CommitMessages) Squash(task contracts.Task, branch string, reviewVerdict string, backend string, model string) (string, error) {
+	defaultMessage := squashLandingCommitMessage(task, branch)
+	if m == nil {
+		return defaultMessage, nil
+	}
+	data, err := m.data(task, branch, reviewVerdict, backend, model, defaultMessage)
+	if err != nil {
+		return "", err
+	}
+	if m.squash == nil {
+		return appendCommitTrailer(defaultMessage, data.CoAuthoredBy), nil
+	}
+	return renderCommitMessage(m.squash, data)
+}
+
+func squashLandingCommitMessage(task contracts.Task, branch string) string {
+	taskID := strings.TrimSpace(task.ID)
+	title := strings.TrimSpace(task.Title)
+	switch {
+	case taskID != "" && title != "":
+		return taskID + ": " + title
+	case title != "":
+		return title
+	case taskID != "":
+		return "Land task " + taskID
+	default:
+		return "Squash " + branch
+	}
+}
+
+func (m *CommitMessages) data(task contracts.Task, branch string, reviewVerdict string, backend string, model string, defaultMessage string) (CommitMessageData, error) {
+	data := CommitMessageData{
+		Task:          task,
+		Branch:        branch,
+		ReviewVerdict: reviewVerdict,
+		Backend:       backend,
+		Model:         model,
+		Default:       defaultMessage,
+	}
+	if m.coAuthoredBy != "" {
+		data.CoAuthoredBy = "Co-authored-by: " + m.coAuthoredBy
+	}
+	if m.trackerURL != nil {
+		var out bytes.Buffer
+		if err := m.trackerURL.Execute(&out, data); err != nil {
+			return CommitMessageData{}, fmt.Errorf("render tracker_url commit message template: %w", err)
+		}
+		data.TrackerURL = strings.TrimSpace(out.String())
+	}
+	return data, nil
+}
+
+func renderCommitMessage(tmpl *template.Template, data CommitMessageData) (string, error) {
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("render %s commit message template: %w", tmpl.Name(), err)
+	}
+	message := strings.TrimSpace(out.String())
+	if message == "" {
+		return "", fmt.Errorf("%s commit message template rendered an empty message", tmpl.Name())
+	}
+	return message, nil
+}
+
+func appendCommitTrailer(message string, trailer string) string {
+	if trailer == "" {
+		return message
+	}
+	return message + "\n\n" + trailer
+}
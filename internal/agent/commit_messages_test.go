@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestParseCommitMessagesReturnsNilWhenUnset(t *testing.T) {
+	messages, err := ParseCommitMessages(CommitMessageConfig{})
+	if err != nil || messages != nil {
+		t.Fatalf("expected nil messages without config, got %#v err=%v", messages, err)
+	}
+	message, err := messages.AutoCommit(contracts.Task{ID: "t-1"}, "task/t-1", "", "", "")
+	if err != nil || message != "chore(task): auto-commit before landing t-1" {
+		t.Fatalf("expected built-in auto-commit message, got %q err=%v", message, err)
+	}
+	if _, templated, err := messages.Merge(contracts.Task{ID: "t-1"}, "task/t-1", "", "", ""); templated || err != nil {
+		t.Fatalf("expected no merge template, templated=%v err=%v", templated, err)
+	}
+}
+
+func TestCommitMessagesRenderTaskLinkage(t *testing.T) {
+	messages, err := ParseCommitMessages(CommitMessageConfig{
+		AutoCommit:   "{{.Task.ID}}: {{.Task.Title}} [{{.Backend}}/{{.Model}}] {{index .Task.Metadata \"labels\"}}\n\n{{.TrackerURL}}",
+		Merge:        "{{.Default}}\n\nReview: {{.ReviewVerdict}}\n{{.CoAuthoredBy}}",
+		TrackerURL:   "https://tracker.test/{{.Task.ID}}",
+		CoAuthoredBy: "codex <codex@example.test>",
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	task := contracts.Task{ID: "t-7", Title: "Fix login", Metadata: map[string]string{"labels": "auth"}}
+
+	message, err := messages.AutoCommit(task, "task/t-7", "pass", "codex", "gpt-5")
+	if err != nil || message != "t-7: Fix login [codex/gpt-5] auth\n\nhttps://tracker.test/t-7" {
+		t.Fatalf("unexpected auto-commit message %q err=%v", message, err)
+	}
+	merge, templated, err := messages.Merge(task, "task/t-7", "pass", "codex", "gpt-5")
+	if err != nil || !templated {
+		t.Fatalf("expected templated merge, err=%v", err)
+	}
+	if merge != "Merge branch 'task/t-7'\n\nReview: pass\nCo-authored-by: codex <codex@example.test>" {
+		t.Fatalf("unexpected merge message %q", merge)
+	}
+}
+
+func TestCommitMessagesAppendCoAuthorToBuiltInMessage(t *testing.T) {
+	messages, err := ParseCommitMessages(CommitMessageConfig{CoAuthoredBy: "yolo <yolo@example.test>"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	message, err := messages.AutoCommit(contracts.Task{ID: "t-1"}, "task/t-1", "", "", "")
+	if err != nil || message != "chore(task): auto-commit before landing t-1\n\nCo-authored-by: yolo <yolo@example.test>" {
+		t.Fatalf("unexpected message %q err=%v", message, err)
+	}
+}
+
+func TestCommitMessagesSquash(t *testing.T) {
+	var unset *CommitMessages
+	message, err := unset.Squash(contracts.Task{ID: "t-1", Title: " Fix login "}, "task/t-1", "", "", "")
+	if err != nil || message != "t-1: Fix login" {
+		t.Fatalf("expected built-in squash message, got %q err=%v", message, err)
+	}
+
+	messages, err := ParseCommitMessages(CommitMessageConfig{
+		Squash:     "{{.Task.Title}} ({{.Task.ID}})\n\n{{.TrackerURL}}",
+		TrackerURL: "https://tracker.test/{{.Task.ID}}",
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	message, err = messages.Squash(contracts.Task{ID: "t-7", Title: "Fix login"}, "task/t-7", "pass", "codex", "gpt-5")
+	if err != nil || message != "Fix login (t-7)\n\nhttps://tracker.test/t-7" {
+		t.Fatalf("unexpected squash message %q err=%v", message, err)
+	}
+}
+
+func TestParseCommitMessagesRejectsBadTemplates(t *testing.T) {
+	for name, cfg := range map[string]CommitMessageConfig{
+		"syntax":        {AutoCommit: "{{.Task.ID"},
+		"unknown field": {Merge: "{{.Task.Owner}}"},
+		"squash field":  {Squash: "{{.Reviewer}}"},
+		"empty":         {AutoCommit: "{{if false}}x{{end}}"},
+	} {
+		if _, err := ParseCommitMessages(cfg); err == nil {
+			t.Fatalf("%s: expected parse error", name)
+		} else if !strings.Contains(err.Error(), "commit message template") {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// DependencyPolicyConfig validates dependencies a task adds or changes
+// before it lands. A zero config disables the stage.
+type DependencyPolicyConfig struct {
+	// Allow lists dependency name globs that may be added; when set, any
+	// other dependency is rejected. "**" matches any number of segments,
+	// so "github.com/acme/**" allows every module under that owner.
+	Allow []string
+	// Deny lists dependency name globs that may never be added.
+	Deny []string
+	// AllowLicenses, when set, is the only licenses changed dependencies
+	// may have.
+	AllowLicenses []string
+	// DenyLicenses are licenses changed dependencies may not have.
+	DenyLicenses []string
+	// LicenseCommand prints one "<dependency> <license>" line per
+	// dependency, run with sh -c in the clone. Commas also separate
+	// fields, so "go-licenses report ./..." output works as is.
+	LicenseCommand string
+	// Timeout bounds LicenseCommand; zero means the landing hook default.
+	Timeout time.Duration
+}
+
+func (c DependencyPolicyConfig) enabled() bool {
+	return len(c.Allow) > 0 || len(c.Deny) > 0 || c.licensePolicy()
+}
+
+func (c DependencyPolicyConfig) licensePolicy() bool {
+	return len(c.AllowLicenses) > 0 || len(c.DenyLicenses) > 0
+}
+
+// dependencyChange is a dependency a task added or moved to another
+// version in one manifest.
+type dependencyChange struct {
+	Manifest string
+	Name     string
+	Version  string
+}
+
+func (c dependencyChange) String() string {
+	if c.Version == "" {
+		return c.Name
+	}
+	return c.Name + " " + c.Version
+}
+
+// dependencyManifestParsers map a manifest file name to a parser returning
+// dependency name to version.
+var dependencyManifestParsers = map[string]func(content string) (map[string]string, error){
+	"go.mod":       parseGoModDependencies,
+	"package.json": parsePackageJSONDependencies,
+	"Cargo.toml":   parseCargoDependencies,
+}
+
+func dependencyManifestParser(file string) func(string) (map[string]string, error) {
+	base := path.Base(file)
+	if parser, ok := dependencyManifestParsers[base]; ok {
+		return parser
+	}
+	if strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt") {
+		return parseRequirementsDependencies
+	}
+	return nil
+}
+
+func parseGoModDependencies(content string) (map[string]string, error) {
+	deps := map[string]string{}
+	inRequire := false
+	for _, line := range strings.Split(content, "\n") {
+		if index := strings.Index(line, "//"); index >= 0 {
+			line = line[:index]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case inRequire && fields[0] == ")":
+			inRequire = false
+			continue
+		case fields[0] == "require" && len(fields) == 2 && fields[1] == "(":
+			inRequire = true
+			continue
+		case fields[0] == "require":
+			fields = fields[1:]
+		case !inRequire:
+			continue
+		}
+		if len(fields) >= 2 {
+			deps[fields[0]] = fields[1]
+		}
+	}
+	return deps, nil
+}
+
+func parsePackageJSONDependencies(content string) (map[string]string, error) {
+	deps := map[string]string{}
+	if strings.TrimSpace(content) == "" {
+		return deps, nil
+	}
+	var manifest map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &manifest); err != nil {
+		return nil, err
+	}
+	for _, section := range []string{"dependencies", "devDependencies", "peerDependencies", "optionalDependencies"} {
+		raw, ok := manifest[section]
+		if !ok {
+			continue
+		}
+		entries := map[string]string{}
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			return nil, fmt.Errorf("%s: %w", section, err)
+		}
+		for name, version := range entries {
+			deps[name] = version
+		}
+	}
+	return deps, nil
+}
+
+func parseRequirementsDependencies(content string) (map[string]string, error) {
+	deps := map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		if index := strings.Index(line, "#"); index >= 0 {
+			line = line[:index]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+		end := strings.IndexAny(line, "=<>!~[;@ ")
+		if end < 0 {
+			end = len(line)
+		}
+		name := strings.ToLower(strings.TrimSpace(line[:end]))
+		if name == "" {
+			continue
+		}
+		version := strings.TrimSpace(line[end:])
+		if strings.HasPrefix(version, "[") {
+			if close := strings.Index(version, "]"); close >= 0 {
+				version = strings.TrimSpace(version[close+1:])
+			}
+		}
+		deps[name] = version
+	}
+	return deps, nil
+}
+
+func parseCargoDependencies(content string) (map[string]string, error) {
+	deps := map[string]string{}
+	inDependencies := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section := strings.Trim(line, "[] ")
+			inDependencies = strings.HasSuffix(section, "dependencies")
+			continue
+		}
+		if !inDependencies {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		name = strings.Trim(strings.TrimSpace(name), `"`)
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "{") {
+			version := ""
+			for _, field := range strings.Split(strings.Trim(value, "{} "), ",") {
+				key, fieldValue, _ := strings.Cut(field, "=")
+				if strings.TrimSpace(key) == "version" {
+					version = strings.Trim(strings.TrimSpace(fieldValue), `"`)
+				}
+			}
+			value = version
+		}
+		deps[name] = strings.Trim(value, `"`)
+	}
+	return deps, nil
+}
+
+// changedDependencies compares each changed manifest in the clone with its
+// version at the merge base and returns dependencies that are new or moved
+// to another version.
+func changedDependencies(ctx context.Context, files []string, base contracts.BaseFileReader, repoRoot string) ([]dependencyChange, error) {
+	changes := []dependencyChange{}
+	for _, file := range files {
+		parser := dependencyManifestParser(file)
+		if parser == nil || isRunnerArtifactPath(file) {
+			continue
+		}
+		current, err := os.ReadFile(filepath.Join(repoRoot, filepath.FromSlash(file)))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		previous, err := base.ReadBaseFile(ctx, file)
+		if err != nil {
+			return nil, err
+		}
+		after, err := parser(string(current))
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %w", file, err)
+		}
+		before, err := parser(previous)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s at the merge base: %w", file, err)
+		}
+		names := make([]string, 0, len(after))
+		for name := range after {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if version, ok := before[name]; ok && version == after[name] {
+				continue
+			}
+			changes = append(changes, dependencyChange{Manifest: file, Name: name, Version: after[name]})
+		}
+	}
+	return changes, nil
+}
+
+// parseLicenseReport reads "<dependency> <license>" lines. Fields may be
+// separated by commas or whitespace; the first is the dependency and the
+// last the license.
+func parseLicenseReport(output string) map[string]string {
+	licenses := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var fields []string
+		if strings.Contains(line, ",") {
+			fields = strings.Split(line, ",")
+		} else {
+			fields = strings.Fields(line)
+		}
+		if len(fields) < 2 {
+			continue
+		}
+		name := strings.TrimSpace(fields[0])
+		license := strings.TrimSpace(fields[len(fields)-1])
+		if name != "" && license != "" {
+			licenses[name] = license
+		}
+	}
+	return licenses
+}
+
+// licenseViolation explains why license breaks the policy, or returns "".
+// SPDX "OR" expressions pass when any alternative passes.
+func (c DependencyPolicyConfig) licenseViolation(license string) string {
+	if license == "" {
+		if len(c.AllowLicenses) > 0 {
+			return "has no known license"
+		}
+		return ""
+	}
+	for _, alternative := range splitLicenseAlternatives(license) {
+		if containsFold(c.DenyLicenses, alternative) {
+			continue
+		}
+		if len(c.AllowLicenses) > 0 && !containsFold(c.AllowLicenses, alternative) {
+			continue
+		}
+		return ""
+	}
+	if len(c.AllowLicenses) > 0 {
+		return "has license " + license + " (not allowed)"
+	}
+	return "has license " + license + " (denied)"
+}
+
+func splitLicenseAlternatives(license string) []string {
+	license = strings.Trim(strings.TrimSpace(license), "()")
+	parts := strings.Split(strings.ReplaceAll(license, " or ", " OR "), " OR ")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(strings.TrimSpace(candidate), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAnyGlob(globs []string, name string) bool {
+	for _, glob := range globs {
+		if matchPathGlob(glob, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// violations checks changes against the name and license
+// policy. licenses may be nil when no license policy is configured.
+func (c DependencyPolicyConfig) violations(changes []dependencyChange, licenses map[string]string) []string {
+	violations := []string{}
+	for _, change := range changes {
+		switch {
+		case matchesAnyGlob(c.Deny, change.Name):
+			violations = append(violations, change.String()+" is denied")
+			continue
+		case len(c.Allow) > 0 && !matchesAnyGlob(c.Allow, change.Name):
+			violations = append(violations, change.String()+" is not in the allow list")
+			continue
+		}
+		if c.licensePolicy() {
+			if reason := c.licenseViolation(licenses[change.Name]); reason != "" {
+				violations = append(violations, change.String()+" "+reason)
+			}
+		}
+	}
+	return violations
+}
+
+// runDependencyPolicy validates dependency changes in the task's diff. It
+// reports true when the task was blocked.
+func (l *Loop) runDependencyPolicy(ctx context.Context, task contracts.Task, taskVCS contracts.VCS, worker string, queuePos int, taskRepoRoot string) (bool, error) {
+	config := l.options.DependencyPolicy
+	if !config.enabled() {
+		return false, nil
+	}
+	lister, ok := taskVCS.(contracts.ChangedFilesLister)
+	if !ok {
+		return false, nil
+	}
+	base, ok := taskVCS.(contracts.BaseFileReader)
+	if !ok {
+		return false, nil
+	}
+	files, err := lister.ChangedFiles(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	// Unparseable manifests and a failing license command block the task
+	// like a violation: the change cannot be shown to meet the policy.
+	var violations []string
+	changes, err := changedDependencies(ctx, files, base, taskRepoRoot)
+	switch {
+	case err != nil:
+		violations = []string{err.Error()}
+	case len(changes) == 0:
+		return false, nil
+	case config.licensePolicy():
+		licenses, err := l.loadDependencyLicenses(ctx, taskRepoRoot)
+		if err != nil {
+			violations = []string{err.Error()}
+		} else {
+			violations = config.violations(changes, licenses)
+		}
+	default:
+		violations = config.violations(changes, nil)
+	}
+	if len(violations) == 0 {
+		return false, nil
+	}
+
+	described := make([]string, 0, len(changes))
+	for _, change := range changes {
+		described = append(described, change.Manifest+": "+change.String())
+	}
+	reason := "dependency policy: " + limitedList(violations)
+	blockedData := appendDecisionMetadata(map[string]string{
+		"triage_status":       "blocked",
+		"triage_reason":       reason,
+		"dependency_changes":  strings.Join(described, ","),
+		"dependency_policy":   "blocked",
+		"dependency_findings": strconv.Itoa(len(violations)),
+	}, "blocked", reason)
+	blockedData = appendTriageCategory(blockedData, contracts.FailureCategoryDependencyPolicy)
+	return true, l.blockTask(ctx, task, worker, queuePos, taskRepoRoot, compactMetadata(blockedData))
+}
+
+func (l *Loop) loadDependencyLicenses(ctx context.Context, repoRoot string) (map[string]string, error) {
+	config := l.options.DependencyPolicy
+	command := strings.TrimSpace(config.LicenseCommand)
+	if command == "" {
+		return map[string]string{}, nil
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultLandingHookTimeout
+	}
+	licenseCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	output, err := runLandingHookCommand(licenseCtx, repoRoot, command, nil)
+	if err != nil {
+		if errors.Is(licenseCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		reason := fmt.Sprintf("license command %q failed: %v", command, err)
+		if line := lastNonEmptyLine(output); line != "" {
+			reason += ": " + line
+		}
+		return nil, errors.New(reason)
+	}
+	return parseLicenseReport(output), nil
+}
@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// manifestVCS lists changed files and serves their merge-base content.
+type manifestVCS struct {
+	fakeVCS
+	changed []string
+	base    map[string]string
+}
+
+func (f *manifestVCS) ChangedFiles(context.Context) ([]string, error) {
+	return f.changed, nil
+}
+
+func (f *manifestVCS) ReadBaseFile(_ context.Context, path string) (string, error) {
+	return f.base[path], nil
+}
+
+func writeRepoFile(t *testing.T, repoRoot string, name string, content string) {
+	t.Helper()
+	path := filepath.Join(repoRoot, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
+
+func TestDependencyManifestParsers(t *testing.T) {
+	for _, tc := range []struct {
+		file    string
+		content string
+		want    map[string]string
+	}{
+		{
+			file:    "go.mod",
+			content: "module example.com/app\n\ngo 1.22\n\nrequire github.com/a/b v1.0.0\n\nrequire (\n\tgithub.com/c/d v0.2.0 // indirect\n\tgolang.org/x/sync v0.7.0\n)\n\nreplace github.com/a/b => ../b\n",
+			want:    map[string]string{"github.com/a/b": "v1.0.0", "github.com/c/d": "v0.2.0", "golang.org/x/sync": "v0.7.0"},
+		},
+		{
+			file:    "web/package.json",
+			content: `{"name":"web","version":"1.0.0","dependencies":{"react":"^18.2.0"},"devDependencies":{"@types/node":"20.1.0"}}`,
+			want:    map[string]string{"react": "^18.2.0", "@types/node": "20.1.0"},
+		},
+		{
+			file:    "requirements-dev.txt",
+			content: "# tools\n-r requirements.txt\nRequests[socks]>=2.31  # http\npytest==8.0.0\nblack\n",
+			want:    map[string]string{"requests": ">=2.31", "pytest": "==8.0.0", "black": ""},
+		},
+		{
+			file:    "crates/cli/Cargo.toml",
+			content: "[package]\nname = \"cli\"\nversion = \"0.1.0\"\n\n[dependencies]\nserde = { version = \"1.0\", features = [\"derive\"] }\nanyhow = \"1\"\n\n[dev-dependencies]\ninsta = \"1.34\"\n",
+			want:    map[string]string{"serde": "1.0", "anyhow": "1", "insta": "1.34"},
+		},
+	} {
+		parser := dependencyManifestParser(tc.file)
+		if parser == nil {
+			t.Fatalf("expected a parser for %s", tc.file)
+		}
+		got, err := parser(tc.content)
+		if err != nil {
+			t.Fatalf("parse %s: %v", tc.file, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("unexpected dependencies for %s: %#v", tc.file, got)
+		}
+	}
+	if dependencyManifestParser("go.sum") != nil {
+		t.Fatalf("expected lockfiles to be ignored")
+	}
+}
+
+func TestDependencyPolicyLicenseViolation(t *testing.T) {
+	policy := DependencyPolicyConfig{AllowLicenses: []string{"MIT", "Apache-2.0"}, DenyLicenses: []string{"GPL-3.0"}}
+	for license, want := range map[string]string{
+		"mit":                 "",
+		"MIT OR GPL-3.0":      "",
+		"GPL-3.0":             "has license GPL-3.0 (not allowed)",
+		"BSD-3-Clause":        "has license BSD-3-Clause (not allowed)",
+		"":                    "has no known license",
+		"(Apache-2.0 OR ISC)": "",
+	} {
+		if got := policy.licenseViolation(license); got != want {
+			t.Errorf("licenseViolation(%q) = %q, want %q", license, got, want)
+		}
+	}
+	denyOnly := DependencyPolicyConfig{DenyLicenses: []string{"AGPL-3.0"}}
+	if got := denyOnly.licenseViolation("AGPL-3.0"); got != "has license AGPL-3.0 (denied)" {
+		t.Fatalf("unexpected deny-only violation %q", got)
+	}
+	if got := denyOnly.licenseViolation(""); got != "" {
+		t.Fatalf("expected unknown license to pass a deny-only policy, got %q", got)
+	}
+}
+
+func TestLoopBlocksTaskThatAddsDeniedOrUnlicensedDependency(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeRepoFile(t, repoRoot, "go.mod", "module example.com/app\n\nrequire (\n\tgithub.com/acme/log v1.1.0\n\tgithub.com/evil/miner v0.1.0\n\tgithub.com/acme/kv v1.0.0\n\tgithub.com/other/gpl v2.0.0\n)\n")
+	writeRepoFile(t, repoRoot, "licenses.txt", "github.com/acme/log,https://example.com,MIT\ngithub.com/other/gpl,https://example.com,GPL-3.0\n")
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	vcs := &manifestVCS{
+		changed: []string{"go.mod", "internal/app.go"},
+		base:    map[string]string{"go.mod": "module example.com/app\n\nrequire (\n\tgithub.com/acme/log v1.0.0\n\tgithub.com/acme/kv v1.0.0\n)\n"},
+	}
+	loop := NewLoop(mgr, run, nil, LoopOptions{
+		ParentID:       "root",
+		RepoRoot:       repoRoot,
+		VCS:            vcs,
+		MergeOnSuccess: true,
+		DependencyPolicy: DependencyPolicyConfig{
+			Deny:           []string{"github.com/evil/**"},
+			AllowLicenses:  []string{"MIT", "Apache-2.0"},
+			LicenseCommand: "cat licenses.txt",
+		},
+	})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || vcs.mergeCalls != 0 {
+		t.Fatalf("expected dependency policy to block landing, got %#v calls=%v", summary, vcs.calls)
+	}
+	data := mgr.dataByID["t-1"]
+	want := "dependency policy: github.com/evil/miner v0.1.0 is denied, github.com/other/gpl v2.0.0 has license GPL-3.0 (not allowed)"
+	if data["triage_reason"] != want {
+		t.Fatalf("unexpected triage reason %q", data["triage_reason"])
+	}
+	if data["dependency_changes"] != "go.mod: github.com/acme/log v1.1.0,go.mod: github.com/evil/miner v0.1.0,go.mod: github.com/other/gpl v2.0.0" {
+		t.Fatalf("unexpected dependency changes %q", data["dependency_changes"])
+	}
+}
+
+func TestLoopLandsTaskWhoseDependencyChangesMeetPolicy(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeRepoFile(t, repoRoot, "web/package.json", `{"dependencies":{"react":"^18.3.0","@acme/ui":"1.2.0"}}`)
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	vcs := &manifestVCS{changed: []string{"web/package.json"}, base: map[string]string{"web/package.json": `{"dependencies":{"react":"^18.2.0"}}`}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{
+		ParentID:         "root",
+		RepoRoot:         repoRoot,
+		VCS:              vcs,
+		MergeOnSuccess:   true,
+		DependencyPolicy: DependencyPolicyConfig{Allow: []string{"react", "@acme/*"}},
+	})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 || !strings.Contains(strings.Join(vcs.calls, " "), "merge_to_main") {
+		t.Fatalf("expected allowed dependency changes to land, got %#v calls=%v", summary, vcs.calls)
+	}
+}
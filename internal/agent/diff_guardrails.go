@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// DiffGuardrailAction is what happens to a task whose diff trips a
+// guardrail.
+type DiffGuardrailAction string
+
+const (
+	// DiffGuardrailBlock blocks the task without review or landing.
+	DiffGuardrailBlock DiffGuardrailAction = "block"
+	// DiffGuardrailApprove asks an operator through the DiffApprover and
+	// blocks the task only if the diff is not approved.
+	DiffGuardrailApprove DiffGuardrailAction = "approve"
+)
+
+// ParseDiffGuardrailAction parses a configured action. Empty means block.
+func ParseDiffGuardrailAction(raw string) (DiffGuardrailAction, error) {
+	switch action := DiffGuardrailAction(strings.ToLower(strings.TrimSpace(raw))); action {
+	case "":
+		return DiffGuardrailBlock, nil
+	case DiffGuardrailBlock, DiffGuardrailApprove:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unsupported diff guardrail action %q (supported: block, approve)", raw)
+	}
+}
+
+// DiffGuardrailConfig bounds the diff a task may produce. Zero values
+// disable the matching check.
+type DiffGuardrailConfig struct {
+	MaxChangedFiles int
+	// MaxChangedLines bounds added plus deleted lines across text files.
+	MaxChangedLines int
+	// ForbiddenPaths are path globs no task may change, such as
+	// ".github/workflows/**". "**" matches any number of directories.
+	ForbiddenPaths []string
+	// BlockBinaryFiles trips the guardrail on any added or changed binary
+	// file.
+	BlockBinaryFiles bool
+	Action           DiffGuardrailAction
+}
+
+func (c DiffGuardrailConfig) enabled() bool {
+	return c.MaxChangedFiles > 0 || c.MaxChangedLines > 0 || len(c.ForbiddenPaths) > 0 || c.BlockBinaryFiles
+}
+
+// DiffApprover asks an operator whether a task whose diff tripped a
+// guardrail may continue to review and landing.
+type DiffApprover func(ctx context.Context, task contracts.Task, reason string) (bool, error)
+
+// checkDiffGuardrails returns one violation per tripped check, plus the
+// changed file and line totals. Runner artifacts are not counted.
+func checkDiffGuardrails(config DiffGuardrailConfig, stats []contracts.FileDiffStat) (violations []string, files int, lines int) {
+	forbidden := []string{}
+	binary := []string{}
+	for _, stat := range stats {
+		if isRunnerArtifactPath(stat.Path) {
+			continue
+		}
+		files++
+		lines += stat.Added + stat.Deleted
+		for _, glob := range config.ForbiddenPaths {
+			if matchPathGlob(glob, stat.Path) {
+				forbidden = append(forbidden, stat.Path)
+				break
+			}
+		}
+		if stat.Binary {
+			binary = append(binary, stat.Path)
+		}
+	}
+	if config.MaxChangedFiles > 0 && files > config.MaxChangedFiles {
+		violations = append(violations, fmt.Sprintf("changed %d files (max %d)", files, config.MaxChangedFiles))
+	}
+	if config.MaxChangedLines > 0 && lines > config.MaxChangedLines {
+		violations = append(violations, fmt.Sprintf("changed %d lines (max %d)", lines, config.MaxChangedLines))
+	}
+	if len(forbidden) > 0 {
+		violations = append(violations, "changed forbidden paths: "+limitedList(forbidden))
+	}
+	if config.BlockBinaryFiles && len(binary) > 0 {
+		violations = append(violations, "changed binary files: "+limitedList(binary))
+	}
+	return violations, files, lines
+}
+
+// runDiffGuardrails checks the task's diff after implementation. It reports
+// true when the task was blocked, and for an approved task the comments
+// reviewers left on it in the tracker while it awaited approval.
+func (l *Loop) runDiffGuardrails(ctx context.Context, task contracts.Task, taskVCS contracts.VCS, worker string, queuePos int, taskRepoRoot string) (bool, string, error) {
+	config := l.options.DiffGuardrails
+	if !config.enabled() {
+		return false, "", nil
+	}
+	lister, ok := taskVCS.(contracts.DiffStatLister)
+	if !ok {
+		return false, "", nil
+	}
+	stats, err := lister.DiffStats(ctx)
+	if err != nil {
+		return false, "", err
+	}
+	violations, files, lines := checkDiffGuardrails(config, stats)
+	if len(violations) == 0 {
+		return false, "", nil
+	}
+
+	reason := "diff guardrail: " + strings.Join(violations, "; ")
+	action := config.Action
+	if action == "" {
+		action = DiffGuardrailBlock
+	}
+	emitDecision := func(decision string, message string) {
+		_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeDiffGuardrail, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: message, Metadata: map[string]string{
+			"diff_guardrail_action":   string(action),
+			"diff_guardrail_decision": decision,
+			"diff_changed_files":      strconv.Itoa(files),
+			"diff_changed_lines":      strconv.Itoa(lines),
+			"triage_reason":           reason,
+		}, Timestamp: time.Now().UTC()})
+	}
+
+	if action == DiffGuardrailApprove {
+		if l.options.DiffApprover == nil {
+			reason += "; no approver configured"
+		} else {
+			emitDecision("pending", reason)
+			requestedAt := time.Now().UTC()
+			approved, err := l.options.DiffApprover(ctx, task, reason)
+			switch {
+			case err != nil:
+				reason += "; approval failed: " + err.Error()
+			case approved:
+				emitDecision("approved", "approved by operator")
+				return false, l.reviewerCommentFeedback(ctx, task, requestedAt), nil
+			default:
+				reason += "; denied by operator"
+			}
+		}
+	}
+	emitDecision("blocked", reason)
+
+	blockedData := appendDecisionMetadata(map[string]string{
+		"triage_status":         "blocked",
+		"triage_reason":         reason,
+		"diff_guardrail_action": string(action),
+		"diff_changed_files":    strconv.Itoa(files),
+		"diff_changed_lines":    strconv.Itoa(lines),
+	}, "blocked", reason)
+	blockedData = appendTriageCategory(blockedData, contracts.FailureCategoryDiffGuardrail)
+	return true, "", l.blockTask(ctx, task, worker, queuePos, taskRepoRoot, blockedData)
+}
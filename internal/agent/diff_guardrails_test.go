@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// diffStatsVCS also implements contracts.DiffStatLister.
+type diffStatsVCS struct {
+	fakeVCS
+	stats []contracts.FileDiffStat
+}
+
+func (f *diffStatsVCS) DiffStats(context.Context) ([]contracts.FileDiffStat, error) {
+	return f.stats, nil
+}
+
+func TestCheckDiffGuardrailsReportsEachTrippedCheck(t *testing.T) {
+	stats := []contracts.FileDiffStat{
+		{Path: "internal/a.go", Added: 40, Deleted: 10},
+		{Path: ".github/workflows/ci.yml", Added: 2},
+		{Path: "assets/logo.png", Binary: true},
+		{Path: "runner-logs/t-1/codex/t-1.jsonl", Added: 500},
+	}
+	violations, files, lines := checkDiffGuardrails(DiffGuardrailConfig{
+		MaxChangedFiles:  2,
+		MaxChangedLines:  50,
+		ForbiddenPaths:   []string{".github/workflows/**"},
+		BlockBinaryFiles: true,
+	}, stats)
+	if files != 3 || lines != 52 {
+		t.Fatalf("expected runner artifacts to be ignored, got files=%d lines=%d", files, lines)
+	}
+	want := []string{
+		"changed 3 files (max 2)",
+		"changed 52 lines (max 50)",
+		"changed forbidden paths: .github/workflows/ci.yml",
+		"changed binary files: assets/logo.png",
+	}
+	if strings.Join(violations, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected violations %#v", violations)
+	}
+
+	if violations, _, _ := checkDiffGuardrails(DiffGuardrailConfig{MaxChangedLines: 100}, stats); len(violations) != 0 {
+		t.Fatalf("expected diff within limits to pass, got %#v", violations)
+	}
+}
+
+func TestParseDiffGuardrailAction(t *testing.T) {
+	if action, err := ParseDiffGuardrailAction(""); err != nil || action != DiffGuardrailBlock {
+		t.Fatalf("expected empty action to block, got %q err=%v", action, err)
+	}
+	if action, err := ParseDiffGuardrailAction(" Approve "); err != nil || action != DiffGuardrailApprove {
+		t.Fatalf("expected approve, got %q err=%v", action, err)
+	}
+	if _, err := ParseDiffGuardrailAction("warn"); err == nil {
+		t.Fatalf("expected unknown action to be rejected")
+	}
+}
+
+func TestLoopBlocksTaskWhoseDiffTripsGuardrail(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	vcs := &diffStatsVCS{stats: []contracts.FileDiffStat{{Path: ".github/workflows/release.yml", Added: 3}}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", VCS: vcs, MergeOnSuccess: true, DiffGuardrails: DiffGuardrailConfig{ForbiddenPaths: []string{".github/workflows/**"}}})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || mgr.statusByID["t-1"] != contracts.TaskStatusBlocked {
+		t.Fatalf("expected guardrail to block the task, got %#v", summary)
+	}
+	if reason := mgr.dataByID["t-1"]["triage_reason"]; reason != "diff guardrail: changed forbidden paths: .github/workflows/release.yml" {
+		t.Fatalf("unexpected triage reason %q", reason)
+	}
+	if vcs.mergeCalls != 0 {
+		t.Fatalf("expected no landing, got %v", vcs.calls)
+	}
+	events := eventsByType(sink.events, contracts.EventTypeDiffGuardrail)
+	if len(events) != 1 || events[0].Metadata["diff_guardrail_decision"] != "blocked" || events[0].Metadata["diff_changed_lines"] != "3" {
+		t.Fatalf("expected one blocked diff_guardrail event, got %#v", events)
+	}
+}
+
+func TestLoopAsksApproverWhenDiffTripsGuardrail(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		approver   DiffApprover
+		wantStatus contracts.TaskStatus
+		wantReason string
+	}{
+		{
+			name:       "approved",
+			approver:   func(context.Context, contracts.Task, string) (bool, error) { return true, nil },
+			wantStatus: contracts.TaskStatusClosed,
+		},
+		{
+			name:       "denied",
+			approver:   func(context.Context, contracts.Task, string) (bool, error) { return false, nil },
+			wantStatus: contracts.TaskStatusBlocked,
+			wantReason: "diff guardrail: changed 120 lines (max 100); denied by operator",
+		},
+		{
+			name:       "no approver",
+			wantStatus: contracts.TaskStatusBlocked,
+			wantReason: "diff guardrail: changed 120 lines (max 100); no approver configured",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+			run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+			vcs := &diffStatsVCS{stats: []contracts.FileDiffStat{{Path: "internal/a.go", Added: 100, Deleted: 20}}}
+			asked := ""
+			approver := tc.approver
+			if approver != nil {
+				approver = func(ctx context.Context, task contracts.Task, reason string) (bool, error) {
+					asked = reason
+					return tc.approver(ctx, task, reason)
+				}
+			}
+			loop := NewLoop(mgr, run, nil, LoopOptions{
+				ParentID:       "root",
+				VCS:            vcs,
+				MergeOnSuccess: true,
+				DiffGuardrails: DiffGuardrailConfig{MaxChangedLines: 100, Action: DiffGuardrailApprove},
+				DiffApprover:   approver,
+			})
+
+			if _, err := loop.Run(context.Background()); err != nil {
+				t.Fatalf("loop failed: %v", err)
+			}
+			if mgr.statusByID["t-1"] != tc.wantStatus {
+				t.Fatalf("expected status %s, got %s", tc.wantStatus, mgr.statusByID["t-1"])
+			}
+			if tc.approver != nil && asked != "diff guardrail: changed 120 lines (max 100)" {
+				t.Fatalf("unexpected approval question %q", asked)
+			}
+			if reason := mgr.dataByID["t-1"]["triage_reason"]; tc.wantReason != "" && reason != tc.wantReason {
+				t.Fatalf("unexpected triage reason %q", reason)
+			}
+		})
+	}
+}
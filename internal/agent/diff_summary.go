@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	diffSummaryFilesMetadataKey = "diff_summary_files"
+	diffSummaryPhase            = "diff_summary"
+	// diffSummaryFileLimit caps the files named in the prompt and the task
+	// data; the rest are counted.
+	diffSummaryFileLimit = 30
+)
+
+// summarizeTaskDiff asks the backend for a short summary of a completed
+// task's changes and records it on the task as diff_summary, next to the
+// changed files in diff_summary_files, so human reviewers can orient before
+// reading the diff. It runs once, after the task passed its gates and before
+// it lands. The summary is best effort: a failed run only emits a warning.
+func (l *Loop) summarizeTaskDiff(ctx context.Context, task contracts.Task, taskVCS contracts.VCS, runtime taskRuntimeConfig, backend string, model string, epicID string, worker string, repoRoot string, queuePos int) {
+	if !l.options.DiffSummary {
+		return
+	}
+	var stats []contracts.FileDiffStat
+	if lister, ok := taskVCS.(contracts.DiffStatLister); ok {
+		listed, err := lister.DiffStats(ctx)
+		if err != nil {
+			l.emitFollowUpWarning(ctx, task, worker, repoRoot, queuePos, "diff summary: list changed files: "+err.Error())
+		}
+		stats = listed
+	}
+	files := describeDiffStats(stats)
+
+	logPath := diffSummaryLogPath(defaultRunnerLogPath(repoRoot, task.ID, epicID, backend))
+	if err := ensureRunnerLogDirectory(repoRoot, logPath); err != nil {
+		l.emitFollowUpWarning(ctx, task, worker, repoRoot, queuePos, "diff summary: "+err.Error())
+		return
+	}
+	metadata := map[string]string{"log_path": logPath, "clone_path": repoRoot, "review_phase": diffSummaryPhase}
+	metadata = appendTaskRuntimeMetadata(metadata, runtime)
+	if l.options.WatchdogTimeout > 0 {
+		metadata["watchdog_timeout"] = l.options.WatchdogTimeout.String()
+	}
+	if l.options.WatchdogInterval > 0 {
+		metadata["watchdog_interval"] = l.options.WatchdogInterval.String()
+	}
+	contracts.SetWatchdogHooks(metadata, l.options.WatchdogHooks, l.options.WatchdogHookTimeout)
+	startMeta := buildRunnerStartedMetadata(contracts.RunnerModeReview, backend, model, repoRoot, logPath, time.Now().UTC())
+	appendTaskRuntimeMetadata(startMeta, runtime)
+	startMeta["review_phase"] = diffSummaryPhase
+	_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerStarted, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: repoRoot, QueuePos: queuePos, Message: string(contracts.RunnerModeReview), Metadata: startMeta, Timestamp: time.Now().UTC()})
+
+	result, err := l.runRunnerWithMonitoring(ctx, contracts.RunnerRequest{
+		TaskID:     task.ID,
+		ParentID:   l.options.ParentID,
+		Mode:       contracts.RunnerModeReview,
+		RepoRoot:   repoRoot,
+		Model:      model,
+		Timeout:    runtime.timeout,
+		Prompt:     buildDiffSummaryPrompt(task, files),
+		Metadata:   metadata,
+		Env:        l.runnerEnv(task),
+		ToolPolicy: l.options.ToolPolicy,
+	}, task.ID, task.Title, worker, repoRoot, queuePos)
+	if err != nil {
+		result = contracts.RunnerResult{Status: contracts.RunnerResultFailed, Reason: err.Error()}
+	}
+	_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: repoRoot, QueuePos: queuePos, Message: string(result.Status), Metadata: buildRunnerFinishedMetadata(result), Timestamp: time.Now().UTC()})
+
+	summary := strings.TrimSpace(result.Artifacts[contracts.DiffSummaryArtifactKey])
+	if result.Status != contracts.RunnerResultCompleted || summary == "" {
+		reason := strings.TrimSpace(result.Reason)
+		if reason == "" {
+			reason = "no DIFF_SUMMARY lines in the backend output"
+		}
+		l.emitFollowUpWarning(ctx, task, worker, repoRoot, queuePos, "diff summary: "+reason)
+		summary = ""
+	}
+	data := compactMetadata(map[string]string{
+		contracts.DiffSummaryArtifactKey: summary,
+		diffSummaryFilesMetadataKey:      strings.Join(files, ", "),
+	})
+	if len(data) == 0 {
+		return
+	}
+	if err := l.setTaskData(ctx, task.ID, data); err != nil {
+		l.emitFollowUpWarning(ctx, task, worker, repoRoot, queuePos, "diff summary: record task data: "+err.Error())
+		return
+	}
+	_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: repoRoot, QueuePos: queuePos, Metadata: data, Timestamp: time.Now().UTC()})
+	_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeDiffSummarized, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: repoRoot, QueuePos: queuePos, Message: summary, Metadata: data, Timestamp: time.Now().UTC()})
+}
+
+// describeDiffStats renders each changed file as `path (+added/-deleted)`.
+// Runner artifacts are left out.
+func describeDiffStats(stats []contracts.FileDiffStat) []string {
+	files := []string{}
+	changed := 0
+	for _, stat := range stats {
+		if isRunnerArtifactPath(stat.Path) {
+			continue
+		}
+		changed++
+		if changed > diffSummaryFileLimit {
+			continue
+		}
+		if stat.Binary {
+			files = append(files, stat.Path+" (binary)")
+			continue
+		}
+		files = append(files, fmt.Sprintf("%s (+%d/-%d)", stat.Path, stat.Added, stat.Deleted))
+	}
+	if changed > diffSummaryFileLimit {
+		files = append(files, fmt.Sprintf("%d more", changed-diffSummaryFileLimit))
+	}
+	return files
+}
+
+func diffSummaryLogPath(logPath string) string {
+	return runnerLogPathWithSuffix(logPath, "diff-summary")
+}
+
+func buildDiffSummaryPrompt(task contracts.Task, files []string) string {
+	sections := []string{
+		"Mode: Review",
+		"Task ID: " + task.ID,
+		"Title: " + task.Title,
+		"Diff summary:",
+		"- Summarize the changes made for this task for a human reviewer. Do not modify any files.",
+	}
+	if len(files) > 0 {
+		sections = append(sections, "- Changed files:")
+		for _, file := range files {
+			sections = append(sections, "  - "+file)
+		}
+	} else {
+		sections = append(sections, "- Inspect the diff against main to find the changed files.")
+	}
+	sections = append(sections,
+		"- Report 2 to 6 key changes, most important first, one per line in this exact format and with no other text:",
+		"DIFF_SUMMARY: <key change>",
+	)
+	return strings.Join(sections, "\n")
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestLoopRecordsDiffSummaryBeforeLanding(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, Artifacts: map[string]string{contracts.DiffSummaryArtifactKey: "Add retry to the fetcher\nCover timeouts in tests"}},
+	}}
+	vcs := &diffStatsVCS{stats: []contracts.FileDiffStat{
+		{Path: "internal/fetch.go", Added: 12, Deleted: 3},
+		{Path: "runner-logs/t-1/codex/t-1.jsonl", Added: 400},
+	}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", RepoRoot: t.TempDir(), VCS: vcs, DiffSummary: true})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 || len(run.requests) != 2 {
+		t.Fatalf("expected implement and summary runs, got %#v after %d requests", summary, len(run.requests))
+	}
+	request := run.requests[1]
+	if request.Mode != contracts.RunnerModeReview || request.Metadata["review_phase"] != diffSummaryPhase {
+		t.Fatalf("expected a review-mode summary run, got %#v", request)
+	}
+	if !strings.Contains(request.Prompt, "internal/fetch.go (+12/-3)") || strings.Contains(request.Prompt, "runner-logs") {
+		t.Fatalf("expected the changed files without runner logs in the prompt, got %q", request.Prompt)
+	}
+	if !strings.HasSuffix(request.Metadata["log_path"], "t-1.diff-summary.jsonl") {
+		t.Fatalf("expected a separate summary log, got %q", request.Metadata["log_path"])
+	}
+	if got := mgr.dataByID["t-1"][contracts.DiffSummaryArtifactKey]; got != "Add retry to the fetcher\nCover timeouts in tests" {
+		t.Fatalf("expected the summary in task data, got %q", got)
+	}
+	if got := mgr.dataByID["t-1"][diffSummaryFilesMetadataKey]; got != "internal/fetch.go (+12/-3)" {
+		t.Fatalf("expected the changed files in task data, got %q", got)
+	}
+	if len(eventsByType(sink.events, contracts.EventTypeDiffSummarized)) != 1 {
+		t.Fatalf("expected one diff_summarized event, got %#v", sink.events)
+	}
+}
+
+func TestLoopWarnsWhenDiffSummaryIsMissing(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultFailed, Reason: "backend crashed"},
+	}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", DiffSummary: true})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 {
+		t.Fatalf("expected a failed summary not to fail the task, got %#v", summary)
+	}
+	if _, ok := mgr.dataByID["t-1"][contracts.DiffSummaryArtifactKey]; ok {
+		t.Fatalf("expected no summary in task data, got %#v", mgr.dataByID["t-1"])
+	}
+	warned := false
+	for _, event := range eventsByType(sink.events, contracts.EventTypeRunnerWarning) {
+		if event.Message == "diff summary: backend crashed" {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Fatalf("expected a diff summary warning, got %#v", sink.events)
+	}
+}
+
+func TestDescribeDiffStatsCapsTheFileList(t *testing.T) {
+	stats := make([]contracts.FileDiffStat, diffSummaryFileLimit+2)
+	for i := range stats {
+		stats[i] = contracts.FileDiffStat{Path: "f.go", Added: 1}
+	}
+	stats[0].Binary = true
+	files := describeDiffStats(stats)
+	if len(files) != diffSummaryFileLimit+1 || files[0] != "f.go (binary)" || files[len(files)-1] != "2 more" {
+		t.Fatalf("unexpected file list %q", files)
+	}
+}
@@ -0,0 +1,234 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// DryRunPlan describes the execution order a run would follow without
+// invoking runners, changing task state, or touching VCS.
+type DryRunPlan struct {
+	ParentID    string
+	Concurrency int
+	Waves       []DryRunWave
+}
+
+type DryRunWave struct {
+	Index int
+	Tasks []DryRunPlannedTask
+}
+
+type DryRunPlannedTask struct {
+	ID       string
+	Title    string
+	Order    int
+	Wave     int
+	Priority int
+	Branch   string
+	Prompt   string
+}
+
+// taskGraphSnapshotProvider exposes a read-only view of the task graph so the
+// dry-run planner can simulate dependency ordering across waves.
+type taskGraphSnapshotProvider interface {
+	TaskGraphSnapshot(ctx context.Context) (*contracts.TaskGraph, error)
+}
+
+func (p DryRunPlan) TaskCount() int {
+	total := 0
+	for _, wave := range p.Waves {
+		total += len(wave.Tasks)
+	}
+	return total
+}
+
+// buildDryRunPlan simulates the run from the task graph when the task manager
+// exposes one, otherwise it plans only the currently ready tasks in next.
+func (l *Loop) buildDryRunPlan(ctx context.Context, next []contracts.TaskSummary) (DryRunPlan, error) {
+	plan := DryRunPlan{ParentID: strings.TrimSpace(l.options.ParentID), Concurrency: l.options.Concurrency}
+	if plan.Concurrency <= 0 {
+		plan.Concurrency = 1
+	}
+
+	var ordered [][]contracts.TaskSummary
+	if provider, ok := l.tasks.(taskGraphSnapshotProvider); ok {
+		graph, err := provider.TaskGraphSnapshot(ctx)
+		if err != nil {
+			return plan, err
+		}
+		ordered = simulateDryRunWaves(graph, plan.Concurrency)
+	} else {
+		ordered = chunkTaskSummaries(next, plan.Concurrency)
+	}
+
+	order := 0
+	for waveIdx, summaries := range ordered {
+		if l.options.MaxTasks > 0 && order >= l.options.MaxTasks {
+			break
+		}
+		wave := DryRunWave{Index: waveIdx + 1}
+		for _, summary := range summaries {
+			if l.options.MaxTasks > 0 && order >= l.options.MaxTasks {
+				break
+			}
+			task, err := l.tasks.GetTask(ctx, summary.ID)
+			if err != nil {
+				return plan, err
+			}
+			repo := l.promptRepoContext("", "", "")
+			repo.Context = l.buildPromptContext(ctx, task)
+			implementPrompt, err := l.renderImplementPrompt(task, repo, "", 0, "", 0)
+			if err != nil {
+				return plan, err
+			}
+			order++
+			planned := DryRunPlannedTask{
+				ID:     task.ID,
+				Title:  task.Title,
+				Order:  order,
+				Wave:   wave.Index,
+				Prompt: implementPrompt,
+			}
+			if summary.Priority != nil {
+				planned.Priority = *summary.Priority
+			}
+			if l.options.VCS != nil || l.options.VCSFactory != nil {
+				planned.Branch = taskBranchName(task.ID, l.options.RunID)
+			}
+			wave.Tasks = append(wave.Tasks, planned)
+		}
+		if len(wave.Tasks) > 0 {
+			plan.Waves = append(plan.Waves, wave)
+		}
+	}
+	return plan, nil
+}
+
+// simulateDryRunWaves walks the graph in topological order, assuming every
+// scheduled task closes successfully, and groups ready tasks into waves no
+// wider than the configured concurrency.
+func simulateDryRunWaves(graph *contracts.TaskGraph, concurrency int) [][]contracts.TaskSummary {
+	if graph == nil || len(graph.Nodes) == 0 {
+		return nil
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	status := make(map[string]contracts.TaskStatus, len(graph.Nodes))
+	for id, node := range graph.Nodes {
+		if node != nil {
+			status[id] = node.Status
+		}
+	}
+
+	waves := [][]contracts.TaskSummary{}
+	for {
+		ready := []contracts.TaskSummary{}
+		for id, node := range graph.Nodes {
+			if node == nil || status[id] != contracts.TaskStatusOpen || len(node.Children) > 0 {
+				continue
+			}
+			satisfied := true
+			for _, dependency := range node.Dependencies {
+				if dependency == nil || status[dependency.ID] != contracts.TaskStatusClosed {
+					satisfied = false
+					break
+				}
+			}
+			if satisfied {
+				priority := node.Priority
+				ready = append(ready, contracts.TaskSummary{ID: node.ID, Title: node.Task.Title, Priority: &priority})
+			}
+		}
+		if len(ready) == 0 {
+			return waves
+		}
+		// The run takes ready tasks in this order too.
+		contracts.SortTaskSummaries(ready)
+		if len(ready) > concurrency {
+			ready = ready[:concurrency]
+		}
+		for _, task := range ready {
+			status[task.ID] = contracts.TaskStatusClosed
+		}
+		waves = append(waves, ready)
+	}
+}
+
+func chunkTaskSummaries(tasks []contracts.TaskSummary, size int) [][]contracts.TaskSummary {
+	if size <= 0 {
+		size = 1
+	}
+	chunks := [][]contracts.TaskSummary{}
+	for start := 0; start < len(tasks); start += size {
+		end := start + size
+		if end > len(tasks) {
+			end = len(tasks)
+		}
+		chunks = append(chunks, tasks[start:end])
+	}
+	return chunks
+}
+
+func taskBranchName(taskID string, runID string) string {
+	return "task/" + runScopedName(strings.TrimSpace(taskID), strings.TrimSpace(runID))
+}
+
+func (l *Loop) emitDryRunPlan(ctx context.Context, plan DryRunPlan) {
+	for _, wave := range plan.Waves {
+		for _, task := range wave.Tasks {
+			_ = l.emit(ctx, contracts.Event{
+				Type:      contracts.EventTypeDryRunPlanned,
+				TaskID:    task.ID,
+				TaskTitle: task.Title,
+				QueuePos:  task.Order,
+				Priority:  task.Priority,
+				Message:   fmt.Sprintf("wave %d", task.Wave),
+				Metadata: compactMetadata(map[string]string{
+					"parent_id":   plan.ParentID,
+					"order":       strconv.Itoa(task.Order),
+					"wave":        strconv.Itoa(task.Wave),
+					"concurrency": strconv.Itoa(plan.Concurrency),
+					"branch":      task.Branch,
+					"prompt":      task.Prompt,
+				}),
+				Timestamp: time.Now().UTC(),
+			})
+		}
+	}
+}
+
+// WriteDryRunPlan renders a human-readable execution plan.
+func WriteDryRunPlan(w io.Writer, plan DryRunPlan) error {
+	if w == nil {
+		return nil
+	}
+	lines := []string{
+		fmt.Sprintf("Dry-run plan for %s: %d task(s) in %d wave(s) at concurrency %d", plan.ParentID, plan.TaskCount(), len(plan.Waves), plan.Concurrency),
+	}
+	for _, wave := range plan.Waves {
+		lines = append(lines, fmt.Sprintf("Wave %d:", wave.Index))
+		for _, task := range wave.Tasks {
+			line := fmt.Sprintf("  %d. %s - %s", task.Order, task.ID, task.Title)
+			if task.Branch != "" {
+				line += " [branch " + task.Branch + "]"
+			}
+			lines = append(lines, line)
+			if task.Prompt == "" {
+				continue
+			}
+			lines = append(lines, "     prompt:")
+			for _, promptLine := range strings.Split(task.Prompt, "\n") {
+				lines = append(lines, "       | "+promptLine)
+			}
+		}
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
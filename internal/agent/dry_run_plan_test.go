@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	enginepkg "github.com/egv/yolo-runner/v2/internal/engine"
+)
+
+func TestLoopDryRunPlanGroupsTasksIntoDependencyWaves(t *testing.T) {
+	storage := newSpyStorageBackend(
+		[]contracts.Task{
+			{ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+			{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, ParentID: "root"},
+			{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen, ParentID: "root"},
+			{ID: "t-3", Title: "Task 3", Status: contracts.TaskStatusOpen, ParentID: "root"},
+			{ID: "t-4", Title: "Task 4", Status: contracts.TaskStatusOpen, ParentID: "root"},
+		},
+		[]contracts.TaskRelation{
+			{FromID: "t-3", ToID: "t-1", Type: contracts.RelationDependsOn},
+			{FromID: "t-4", ToID: "t-3", Type: contracts.RelationDependsOn},
+		},
+	)
+	run := &fakeRunner{}
+	sink := &recordingSink{}
+	output := &bytes.Buffer{}
+	manager := newStorageEngineTaskManager(storage, enginepkg.NewTaskEngine(), "root")
+	loop := NewLoop(manager, run, sink, LoopOptions{
+		ParentID:         "root",
+		Concurrency:      2,
+		DryRun:           true,
+		DryRunPlanOutput: output,
+		DryRunEmitPlan:   true,
+		VCS:              &fakeVCS{},
+	})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if len(run.requests) != 0 {
+		t.Fatalf("runner should not be called in dry run")
+	}
+	if got := storage.statusSetCount("t-1", contracts.TaskStatusInProgress); got != 0 {
+		t.Fatalf("dry run must not change task status, got %d updates", got)
+	}
+
+	planned := eventsByType(sink.events, contracts.EventTypeDryRunPlanned)
+	if len(planned) != 4 {
+		t.Fatalf("expected 4 dry_run_planned events, got %d", len(planned))
+	}
+	want := []struct {
+		id   string
+		wave string
+	}{{"t-1", "1"}, {"t-2", "1"}, {"t-3", "2"}, {"t-4", "3"}}
+	for i, expected := range want {
+		if planned[i].TaskID != expected.id || planned[i].Metadata["wave"] != expected.wave {
+			t.Fatalf("unexpected plan entry %d: %#v", i, planned[i])
+		}
+		if planned[i].Metadata["branch"] != "task/"+expected.id {
+			t.Fatalf("expected branch for %s, got %q", expected.id, planned[i].Metadata["branch"])
+		}
+		if !strings.Contains(planned[i].Metadata["prompt"], "Task ID: "+expected.id) {
+			t.Fatalf("expected implement prompt for %s, got %q", expected.id, planned[i].Metadata["prompt"])
+		}
+	}
+
+	text := output.String()
+	for _, needle := range []string{"4 task(s) in 3 wave(s) at concurrency 2", "Wave 1:", "1. t-1 - Task 1 [branch task/t-1]", "Wave 3:", "| Mode: Implementation"} {
+		if !strings.Contains(text, needle) {
+			t.Fatalf("expected plan output to include %q, got %q", needle, text)
+		}
+	}
+}
+
+func TestSimulateDryRunWavesOrdersReadyTasksLikeNextTasks(t *testing.T) {
+	storage := newSpyStorageBackend(
+		[]contracts.Task{
+			{ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+			{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, ParentID: "root", Metadata: map[string]string{"priority": "3"}},
+			{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen, ParentID: "root", Metadata: map[string]string{"priority": "1"}},
+			{ID: "t-3", Title: "Task 3", Status: contracts.TaskStatusOpen, ParentID: "root", Metadata: map[string]string{"priority": "1"}},
+		},
+		nil,
+	)
+	manager := newStorageEngineTaskManager(storage, enginepkg.NewTaskEngine(), "root")
+	next, err := manager.NextTasks(context.Background(), "root")
+	if err != nil {
+		t.Fatalf("next tasks: %v", err)
+	}
+	graph, err := manager.TaskGraphSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	waves := simulateDryRunWaves(graph, 3)
+	if len(waves) != 1 || len(waves[0]) != len(next) {
+		t.Fatalf("expected one wave of %d tasks, got %#v", len(next), waves)
+	}
+	for i := range next {
+		if waves[0][i].ID != next[i].ID {
+			t.Fatalf("expected the wave in NextTasks order %v, got %v", next, waves[0])
+		}
+	}
+	if waves[0][0].ID != "t-2" || waves[0][2].ID != "t-1" {
+		t.Fatalf("expected priority before ID, got %v", waves[0])
+	}
+}
+
+func TestTaskGraphSnapshotIsACopy(t *testing.T) {
+	storage := newSpyStorageBackend(
+		[]contracts.Task{
+			{ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+			{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, ParentID: "root"},
+			{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen, ParentID: "root"},
+		},
+		[]contracts.TaskRelation{{FromID: "t-2", ToID: "t-1", Type: contracts.RelationDependsOn}},
+	)
+	manager := newStorageEngineTaskManager(storage, enginepkg.NewTaskEngine(), "root")
+	graph, err := manager.TaskGraphSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	graph.Nodes["t-1"].Status = contracts.TaskStatusClosed
+	if graph.Nodes["t-2"].Dependencies[0] != graph.Nodes["t-1"] {
+		t.Fatalf("expected the copy's relations to point into the copy")
+	}
+	next, err := manager.NextTasks(context.Background(), "root")
+	if err != nil {
+		t.Fatalf("next tasks: %v", err)
+	}
+	if len(next) != 1 || next[0].ID != "t-1" {
+		t.Fatalf("expected changes to the snapshot to leave the manager's graph alone, got %v", next)
+	}
+}
+
+func TestLoopDryRunPlanFallsBackToNextTasksWithoutGraph(t *testing.T) {
+	mgr := newFakeTaskManager(
+		contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen},
+		contracts.Task{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen},
+		contracts.Task{ID: "t-3", Title: "Task 3", Status: contracts.TaskStatusOpen},
+	)
+	output := &bytes.Buffer{}
+	loop := NewLoop(mgr, &fakeRunner{}, nil, LoopOptions{ParentID: "root", Concurrency: 2, DryRun: true, DryRunPlanOutput: output, MaxTasks: 2})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Skipped != 1 {
+		t.Fatalf("expected skipped summary for dry run, got %#v", summary)
+	}
+	text := output.String()
+	if !strings.Contains(text, "2 task(s) in 1 wave(s)") || strings.Contains(text, "t-3") {
+		t.Fatalf("expected plan capped by max tasks, got %q", text)
+	}
+	if strings.Contains(text, "[branch") {
+		t.Fatalf("did not expect branches without VCS, got %q", text)
+	}
+}
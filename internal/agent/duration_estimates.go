@@ -0,0 +1,289 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	// TaskTypeLabelPrefix marks a task label naming the task's type for
+	// duration estimates, e.g. yolo:type=bug.
+	TaskTypeLabelPrefix = "yolo:type="
+	// DefaultTaskType is the type of tasks without a type label or field.
+	DefaultTaskType = "task"
+
+	// durationHistoryWindow caps how many past tasks weigh on a type's
+	// mean, so estimates follow a project whose tasks get faster or slower.
+	durationHistoryWindow = 20
+)
+
+// taskTypeMetadataKeys are the task fields trackers use for an issue or
+// work item type, checked in order when no type label is set.
+var taskTypeMetadataKeys = []string{"type", "issue_type", "work_item_type"}
+
+// taskDurationType names the group of tasks whose past durations estimate
+// task: its yolo:type= label, else its tracker type field, else "task".
+func taskDurationType(task contracts.Task) string {
+	for label := range taskLabelSet(task) {
+		if value, ok := strings.CutPrefix(label, TaskTypeLabelPrefix); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	for _, key := range taskTypeMetadataKeys {
+		if value := strings.ToLower(strings.TrimSpace(task.Metadata[key])); value != "" {
+			return value
+		}
+	}
+	return DefaultTaskType
+}
+
+type durationHistoryFile struct {
+	Types map[string]durationStat `json:"types"`
+}
+
+// durationStat is the running mean of completed task durations of one type.
+type durationStat struct {
+	Count       int     `json:"count"`
+	MeanSeconds float64 `json:"mean_seconds"`
+}
+
+type runningTaskEstimate struct {
+	taskType  string
+	startedAt time.Time
+	estimate  time.Duration
+	known     bool
+}
+
+// durationEstimator learns how long tasks of each type take and projects
+// when running tasks and the run will finish. Durations of completed tasks
+// are kept in a JSON file across runs; a missing or unreadable file starts
+// an empty history. A nil *durationEstimator estimates nothing.
+type durationEstimator struct {
+	mu      sync.Mutex
+	path    string
+	types   map[string]durationStat
+	running map[string]runningTaskEstimate
+}
+
+func newDurationEstimator(path string) *durationEstimator {
+	estimator := &durationEstimator{path: strings.TrimSpace(path), types: map[string]durationStat{}, running: map[string]runningTaskEstimate{}}
+	if estimator.path == "" {
+		return estimator
+	}
+	raw, err := os.ReadFile(estimator.path)
+	if err != nil {
+		return estimator
+	}
+	history := durationHistoryFile{}
+	if err := json.Unmarshal(raw, &history); err != nil {
+		return estimator
+	}
+	for taskType, stat := range history.Types {
+		if stat.Count > 0 && stat.MeanSeconds > 0 {
+			estimator.types[taskType] = stat
+		}
+	}
+	return estimator
+}
+
+// estimate returns the expected duration of a task of taskType, falling back
+// to the mean over all types for a type without history.
+func (e *durationEstimator) estimate(taskType string) (time.Duration, bool) {
+	if e == nil {
+		return 0, false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if stat, ok := e.types[taskType]; ok {
+		return secondsDuration(stat.MeanSeconds), true
+	}
+	return e.overallLocked()
+}
+
+func (e *durationEstimator) overallLocked() (time.Duration, bool) {
+	count, total := 0, 0.0
+	for _, stat := range e.types {
+		count += stat.Count
+		total += float64(stat.Count) * stat.MeanSeconds
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return secondsDuration(total / float64(count)), true
+}
+
+// start records that a task of taskType started at startedAt and returns its
+// estimated duration.
+func (e *durationEstimator) start(taskID string, taskType string, startedAt time.Time) (time.Duration, bool) {
+	if e == nil {
+		return 0, false
+	}
+	estimate, known := e.estimate(taskType)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.running[taskID] = runningTaskEstimate{taskType: taskType, startedAt: startedAt, estimate: estimate, known: known}
+	return estimate, known
+}
+
+// finish stops tracking a task. A completed task's duration is added to the
+// history of its type and the history is saved; tasks that were blocked or
+// failed stopped early and would skew the estimates.
+func (e *durationEstimator) finish(taskID string, completed bool, finishedAt time.Time) error {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	running, ok := e.running[taskID]
+	delete(e.running, taskID)
+	if !ok || !completed {
+		return nil
+	}
+	elapsed := finishedAt.Sub(running.startedAt)
+	if elapsed <= 0 {
+		return nil
+	}
+	stat := e.types[running.taskType]
+	stat.Count++
+	weight := stat.Count
+	if weight > durationHistoryWindow {
+		weight = durationHistoryWindow
+	}
+	stat.MeanSeconds += (elapsed.Seconds() - stat.MeanSeconds) / float64(weight)
+	e.types[running.taskType] = stat
+	return e.saveLocked()
+}
+
+func (e *durationEstimator) saveLocked() error {
+	if e.path == "" {
+		return nil
+	}
+	raw, err := json.MarshalIndent(durationHistoryFile{Types: e.types}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(e.path), 0o755); err != nil {
+		return err
+	}
+	tmp := e.path + ".tmp"
+	if err := os.WriteFile(tmp, append(raw, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, e.path)
+}
+
+// remaining projects the time until the running tasks and queued further
+// tasks are done: the summed estimates spread across the workers, but never
+// less than the longest running task still needs. Running tasks past their
+// estimate count as about to finish.
+func (e *durationEstimator) remaining(now time.Time, running []string, queued int, workers int) (time.Duration, bool) {
+	if e == nil {
+		return 0, false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	overall, overallKnown := e.overallLocked()
+	total, longest := time.Duration(0), time.Duration(0)
+	for _, taskID := range running {
+		left := overall
+		if task, ok := e.running[taskID]; ok && task.known {
+			left = task.estimate - now.Sub(task.startedAt)
+			if left < 0 {
+				left = 0
+			}
+		} else if !overallKnown {
+			return 0, false
+		}
+		total += left
+		if left > longest {
+			longest = left
+		}
+	}
+	if queued > 0 {
+		if !overallKnown {
+			return 0, false
+		}
+		total += overall * time.Duration(queued)
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+	eta := total / time.Duration(workers)
+	if eta < longest {
+		eta = longest
+	}
+	return eta.Round(time.Second), true
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second)
+}
+
+// appendTaskEstimateMetadata records a task's type and, when history allows,
+// its estimated duration and completion time on its task_started event.
+func appendTaskEstimateMetadata(metadata map[string]string, taskType string, startedAt time.Time, estimate time.Duration, known bool) map[string]string {
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	metadata["task_type"] = taskType
+	if known {
+		metadata["estimated_duration"] = estimate.String()
+		metadata["estimated_completion"] = startedAt.Add(estimate).UTC().Format(time.RFC3339)
+	}
+	return metadata
+}
+
+// reportRunEstimate emits a run_estimate event projecting when the running
+// and queued tasks will be done. Queued tasks are counted when the task
+// manager can summarize the task graph: every remaining task that is not
+// running here. Nothing is emitted while there is no
+// history to estimate from or no work left.
+func (l *Loop) reportRunEstimate(ctx context.Context, running []string, workers int) {
+	if ctx.Err() != nil {
+		return
+	}
+	queued := 0
+	if provider, ok := l.tasks.(epicProgressProvider); ok {
+		if progress, err := provider.EpicProgress(ctx); err == nil && progress.Remaining() > len(running) {
+			queued = progress.Remaining() - len(running)
+		}
+	}
+	if len(running) == 0 && queued == 0 {
+		return
+	}
+	now := time.Now().UTC()
+	eta, known := l.durations.remaining(now, running, queued, workers)
+	if !known {
+		return
+	}
+	_ = l.emit(ctx, contracts.Event{
+		Type:    contracts.EventTypeRunEstimate,
+		Message: fmt.Sprintf("run estimated to finish in %s", eta),
+		Metadata: map[string]string{
+			"root_id":              l.options.ParentID,
+			"running":              strconv.Itoa(len(running)),
+			"queued":               strconv.Itoa(queued),
+			"estimated_remaining":  eta.String(),
+			"estimated_completion": now.Add(eta).Format(time.RFC3339),
+		},
+		Timestamp: now,
+	})
+}
+
+// runningTaskIDs lists the tasks of the dispatcher's in-flight map.
+func runningTaskIDs(inFlight map[string]string) []string {
+	ids := make([]string, 0, len(inFlight))
+	for taskID := range inFlight {
+		ids = append(ids, taskID)
+	}
+	return ids
+}
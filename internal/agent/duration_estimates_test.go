@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	enginepkg "github.com/egv/yolo-runner/v2/internal/engine"
+)
+
+func TestTaskDurationTypePrefersLabelThenTrackerField(t *testing.T) {
+	cases := []struct {
+		name string
+		task contracts.Task
+		want string
+	}{
+		{name: "label", task: contracts.Task{Metadata: map[string]string{"labels": "backend,yolo:type=Bug", "type": "feature"}}, want: "bug"},
+		{name: "tracker field", task: contracts.Task{Metadata: map[string]string{"work_item_type": "User Story"}}, want: "user story"},
+		{name: "default", task: contracts.Task{}, want: DefaultTaskType},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := taskDurationType(tc.task); got != tc.want {
+				t.Fatalf("expected type %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDurationEstimatorLearnsPerTypeMeansAcrossRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".yolo-runner", "durations.json")
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	estimator := newDurationEstimator(path)
+	if _, known := estimator.start("t-1", "bug", start); known {
+		t.Fatalf("expected no estimate without history")
+	}
+	estimator.start("t-2", "feature", start)
+	estimator.start("t-3", "bug", start)
+	if err := estimator.finish("t-1", true, start.Add(10*time.Minute)); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	if err := estimator.finish("t-2", true, start.Add(20*time.Minute)); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	if err := estimator.finish("t-3", false, start.Add(time.Minute)); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+
+	next := newDurationEstimator(path)
+	if estimate, known := next.estimate("bug"); !known || estimate != 10*time.Minute {
+		t.Fatalf("expected bugs to take 10m, got %s known=%v", estimate, known)
+	}
+	if estimate, known := next.estimate("chore"); !known || estimate != 15*time.Minute {
+		t.Fatalf("expected an unseen type to use the overall mean of 15m, got %s known=%v", estimate, known)
+	}
+}
+
+func TestDurationEstimatorProjectsRemainingAcrossWorkers(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	estimator := newDurationEstimator("")
+	estimator.types["bug"] = durationStat{Count: 2, MeanSeconds: 600}
+	estimator.start("t-1", "bug", now.Add(-4*time.Minute))
+
+	if eta, known := estimator.remaining(now, []string{"t-1"}, 3, 2); !known || eta != 18*time.Minute {
+		t.Fatalf("expected (6m + 3x10m) over 2 workers = 18m, got %s known=%v", eta, known)
+	}
+	if eta, known := estimator.remaining(now, []string{"t-1"}, 0, 2); !known || eta != 6*time.Minute {
+		t.Fatalf("expected the running task's 6m, got %s known=%v", eta, known)
+	}
+	if _, known := newDurationEstimator("").remaining(now, []string{"t-1"}, 1, 1); known {
+		t.Fatalf("expected no projection without history")
+	}
+}
+
+func TestLoopEmitsTaskAndRunEstimatesFromHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "durations.json")
+	if err := os.WriteFile(path, []byte(`{"types":{"task":{"count":1,"mean_seconds":600}}}`), 0o644); err != nil {
+		t.Fatalf("write history: %v", err)
+	}
+	storage := newSpyStorageBackend([]contracts.Task{
+		{ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+		{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, ParentID: "root"},
+		{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen, ParentID: "root"},
+	}, []contracts.TaskRelation{
+		{FromID: "root", ToID: "t-1", Type: contracts.RelationParent},
+		{FromID: "root", ToID: "t-2", Type: contracts.RelationParent},
+	})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}, {Status: contracts.RunnerResultCompleted}}}
+	sink := &recordingSink{}
+	loop := NewLoopWithTaskEngine(storage, enginepkg.NewTaskEngine(), run, sink, LoopOptions{ParentID: "root", Concurrency: 1, DurationHistoryPath: path})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+
+	started := eventsByType(sink.events, contracts.EventTypeTaskStarted)
+	if len(started) != 2 || started[0].Metadata["task_type"] != DefaultTaskType || started[0].Metadata["estimated_duration"] != "10m0s" || started[0].Metadata["estimated_completion"] == "" {
+		t.Fatalf("expected a 10m estimate on task_started, got %#v", started)
+	}
+	// Once t-1 finishes its near-zero duration halves the mean, and t-2 is
+	// queued until the next dispatch.
+	between := map[string]string{}
+	for _, event := range eventsByType(sink.events, contracts.EventTypeRunEstimate) {
+		if event.Metadata["running"] == "0" {
+			between = event.Metadata
+			break
+		}
+	}
+	if between["queued"] != "1" || between["estimated_remaining"] != "5m0s" || between["root_id"] != "root" {
+		t.Fatalf("expected a 5m estimate for the queued t-2 between tasks, got %#v", between)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read history: %v", err)
+	}
+	history := durationHistoryFile{}
+	if err := json.Unmarshal(raw, &history); err != nil {
+		t.Fatalf("decode history: %v", err)
+	}
+	if history.Types[DefaultTaskType].Count != 3 {
+		t.Fatalf("expected both completions added to the history, got %#v", history)
+	}
+}
@@ -0,0 +1,197 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// EpicProgress is a rollup of the work under the loop's root task. Only leaf
+// tasks are counted; container tasks take their status from their children.
+type EpicProgress struct {
+	RootID     string
+	RootTitle  string
+	Total      int
+	Completed  int
+	InProgress int
+	Open       int
+	Blocked    int
+	Failed     int
+	// Blockers lists blocked and failed task IDs in sorted order.
+	Blockers []string
+}
+
+// Remaining is the number of tasks still waiting for or under a worker.
+func (p EpicProgress) Remaining() int {
+	return p.Open + p.InProgress
+}
+
+func (p EpicProgress) Percent() int {
+	if p.Total == 0 {
+		return 0
+	}
+	return p.Completed * 100 / p.Total
+}
+
+// epicProgressProvider is implemented by task managers that can summarize the
+// task graph under the root without exposing it to other goroutines.
+type epicProgressProvider interface {
+	EpicProgress(ctx context.Context) (EpicProgress, error)
+}
+
+func summarizeEpicProgress(graph *contracts.TaskGraph) EpicProgress {
+	progress := EpicProgress{}
+	if graph == nil {
+		return progress
+	}
+	progress.RootID = graph.RootID
+	if root := graph.Nodes[graph.RootID]; root != nil {
+		progress.RootTitle = root.Task.Title
+	}
+	for id, node := range graph.Nodes {
+		if node == nil || id == graph.RootID || len(node.Children) > 0 {
+			continue
+		}
+		progress.Total++
+		switch node.Status {
+		case contracts.TaskStatusClosed:
+			progress.Completed++
+		case contracts.TaskStatusInProgress:
+			progress.InProgress++
+		case contracts.TaskStatusBlocked:
+			progress.Blocked++
+			progress.Blockers = append(progress.Blockers, id)
+		case contracts.TaskStatusFailed:
+			progress.Failed++
+			progress.Blockers = append(progress.Blockers, id)
+		default:
+			progress.Open++
+		}
+	}
+	sort.Strings(progress.Blockers)
+	return progress
+}
+
+// epicProgressTracker keeps the per-run state behind epic_progress events: how
+// long completed tasks took and what was last written to the root task.
+type epicProgressTracker struct {
+	mu          sync.Mutex
+	completed   int
+	elapsed     time.Duration
+	lastWritten string
+}
+
+func (t *epicProgressTracker) recordCompletion(elapsed time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.completed++
+	t.elapsed += elapsed
+}
+
+// estimateRemaining projects the time left from the average duration of tasks
+// completed in this run, spread across the workers. It reports false until a
+// task has completed.
+func (t *epicProgressTracker) estimateRemaining(progress EpicProgress, concurrency int) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.completed == 0 {
+		return 0, false
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	average := t.elapsed / time.Duration(t.completed)
+	waves := (progress.Remaining() + concurrency - 1) / concurrency
+	return (average * time.Duration(waves)).Round(time.Second), true
+}
+
+// markWritten records the summary last written to the root task and reports
+// whether it changed.
+func (t *epicProgressTracker) markWritten(summary string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if summary == t.lastWritten {
+		return false
+	}
+	t.lastWritten = summary
+	return true
+}
+
+func (l *Loop) epicProgressEnabled() bool {
+	if l.options.EpicProgressInterval <= 0 || l.epicProgress == nil {
+		return false
+	}
+	_, ok := l.tasks.(epicProgressProvider)
+	return ok
+}
+
+// reportEpicProgress emits an epic_progress event and mirrors the rollup onto
+// the root task. Unforced reports are skipped when the counts have not moved
+// since the last write, so finishing a task does not repeat the periodic
+// report. Progress reporting is best effort and never fails the run.
+func (l *Loop) reportEpicProgress(ctx context.Context, force bool) {
+	if !l.epicProgressEnabled() || ctx.Err() != nil {
+		return
+	}
+	progress, err := l.tasks.(epicProgressProvider).EpicProgress(ctx)
+	if err != nil || strings.TrimSpace(progress.RootID) == "" {
+		return
+	}
+	eta, etaKnown := l.epicProgress.estimateRemaining(progress, l.options.Concurrency)
+	data := epicProgressTaskData(progress, eta, etaKnown)
+	changed := l.epicProgress.markWritten(data["epic_progress"] + "|" + data["epic_blockers"])
+	if !changed && !force {
+		return
+	}
+
+	metadata := map[string]string{
+		"total":       strconv.Itoa(progress.Total),
+		"completed":   strconv.Itoa(progress.Completed),
+		"in_progress": strconv.Itoa(progress.InProgress),
+		"open":        strconv.Itoa(progress.Open),
+		"blocked":     strconv.Itoa(progress.Blocked),
+		"failed":      strconv.Itoa(progress.Failed),
+		"remaining":   strconv.Itoa(progress.Remaining()),
+		"percent":     strconv.Itoa(progress.Percent()),
+	}
+	if len(progress.Blockers) > 0 {
+		metadata["blockers"] = strings.Join(progress.Blockers, ",")
+	}
+	if etaKnown {
+		metadata["eta"] = eta.String()
+	}
+	_ = l.emit(ctx, contracts.Event{
+		Type:      contracts.EventTypeEpicProgress,
+		TaskID:    progress.RootID,
+		TaskTitle: progress.RootTitle,
+		Message:   fmt.Sprintf("%d/%d tasks completed (%d%%)", progress.Completed, progress.Total, progress.Percent()),
+		Metadata:  metadata,
+		Timestamp: time.Now().UTC(),
+	})
+	if changed {
+		_ = l.setTaskData(ctx, progress.RootID, data)
+	}
+}
+
+func epicProgressTaskData(progress EpicProgress, eta time.Duration, etaKnown bool) map[string]string {
+	blockers := "none"
+	if len(progress.Blockers) > 0 {
+		blockers = strings.Join(progress.Blockers, ",")
+	}
+	etaValue := "unknown"
+	if etaKnown {
+		etaValue = eta.String()
+	}
+	return map[string]string{
+		"epic_progress":  fmt.Sprintf("%d/%d (%d%%)", progress.Completed, progress.Total, progress.Percent()),
+		"epic_remaining": strconv.Itoa(progress.Remaining()),
+		"epic_blockers":  blockers,
+		"epic_eta":       etaValue,
+	}
+}
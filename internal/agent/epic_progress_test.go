@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	enginepkg "github.com/egv/yolo-runner/v2/internal/engine"
+)
+
+func TestSummarizeEpicProgressCountsLeafTasksAndBlockers(t *testing.T) {
+	root := &contracts.TaskNode{ID: "root", Task: contracts.Task{ID: "root", Title: "Epic"}, Status: contracts.TaskStatusOpen}
+	group := &contracts.TaskNode{ID: "group", Status: contracts.TaskStatusInProgress, Parent: root}
+	root.Children = []*contracts.TaskNode{group}
+	nodes := map[string]*contracts.TaskNode{"root": root, "group": group}
+	for id, status := range map[string]contracts.TaskStatus{
+		"a": contracts.TaskStatusClosed,
+		"b": contracts.TaskStatusClosed,
+		"c": contracts.TaskStatusInProgress,
+		"d": contracts.TaskStatusOpen,
+		"e": contracts.TaskStatusFailed,
+		"f": contracts.TaskStatusBlocked,
+	} {
+		node := &contracts.TaskNode{ID: id, Status: status, Parent: group}
+		group.Children = append(group.Children, node)
+		nodes[id] = node
+	}
+
+	progress := summarizeEpicProgress(&contracts.TaskGraph{RootID: "root", Nodes: nodes})
+
+	if progress.RootTitle != "Epic" || progress.Total != 6 {
+		t.Fatalf("expected six leaf tasks under the epic, got %#v", progress)
+	}
+	if progress.Completed != 2 || progress.InProgress != 1 || progress.Open != 1 || progress.Failed != 1 || progress.Blocked != 1 {
+		t.Fatalf("unexpected counts %#v", progress)
+	}
+	if got := strings.Join(progress.Blockers, ","); got != "e,f" {
+		t.Fatalf("expected sorted blockers e,f, got %q", got)
+	}
+	if progress.Remaining() != 2 || progress.Percent() != 33 {
+		t.Fatalf("expected 2 remaining at 33%%, got %d at %d%%", progress.Remaining(), progress.Percent())
+	}
+}
+
+func TestEpicProgressTrackerEstimatesRemainingAcrossWorkers(t *testing.T) {
+	tracker := &epicProgressTracker{}
+	progress := EpicProgress{Open: 4, InProgress: 1}
+	if _, ok := tracker.estimateRemaining(progress, 2); ok {
+		t.Fatalf("expected no estimate before any task completed")
+	}
+
+	tracker.recordCompletion(2 * time.Minute)
+	tracker.recordCompletion(4 * time.Minute)
+	eta, ok := tracker.estimateRemaining(progress, 2)
+	if !ok || eta != 9*time.Minute {
+		t.Fatalf("expected 3 waves of 3m, got %s ok=%v", eta, ok)
+	}
+}
+
+func TestLoopEmitsEpicProgressAndWritesRollupToRootTask(t *testing.T) {
+	storage := newSpyStorageBackend([]contracts.Task{
+		{ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+		{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, ParentID: "root"},
+		{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen, ParentID: "root"},
+	}, []contracts.TaskRelation{
+		{FromID: "root", ToID: "t-1", Type: contracts.RelationParent},
+		{FromID: "root", ToID: "t-2", Type: contracts.RelationParent},
+	})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}, {Status: contracts.RunnerResultCompleted}}}
+	sink := &recordingSink{}
+	loop := NewLoopWithTaskEngine(storage, enginepkg.NewTaskEngine(), run, sink, LoopOptions{ParentID: "root", Concurrency: 1, EpicProgressInterval: time.Hour})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+
+	messages := []string{}
+	for _, event := range sink.events {
+		if event.Type != contracts.EventTypeEpicProgress {
+			continue
+		}
+		if event.TaskID != "root" || event.TaskTitle != "Root" {
+			t.Fatalf("expected epic progress on root task, got %#v", event)
+		}
+		messages = append(messages, event.Message)
+	}
+	want := "0/2 tasks completed (0%)|1/2 tasks completed (50%)|2/2 tasks completed (100%)|2/2 tasks completed (100%)"
+	if got := strings.Join(messages, "|"); got != want {
+		t.Fatalf("unexpected epic progress events:\n got %s\nwant %s", got, want)
+	}
+
+	root, err := storage.GetTask(context.Background(), "root")
+	if err != nil {
+		t.Fatalf("get root: %v", err)
+	}
+	if root.Metadata["epic_progress"] != "2/2 (100%)" || root.Metadata["epic_remaining"] != "0" || root.Metadata["epic_blockers"] != "none" {
+		t.Fatalf("expected rollup written to root task, got %#v", root.Metadata)
+	}
+	if root.Metadata["epic_eta"] == "unknown" {
+		t.Fatalf("expected an estimate once tasks completed, got %#v", root.Metadata)
+	}
+}
+
+func TestLoopSkipsEpicProgressWhenIntervalIsZero(t *testing.T) {
+	storage := newSpyStorageBackend([]contracts.Task{
+		{ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+		{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, ParentID: "root"},
+	}, []contracts.TaskRelation{
+		{FromID: "root", ToID: "t-1", Type: contracts.RelationParent},
+	})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	sink := &recordingSink{}
+	loop := NewLoopWithTaskEngine(storage, enginepkg.NewTaskEngine(), run, sink, LoopOptions{ParentID: "root", Concurrency: 1})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	for _, event := range sink.events {
+		if event.Type == contracts.EventTypeEpicProgress {
+			t.Fatalf("expected no epic progress events, got %#v", event)
+		}
+	}
+}
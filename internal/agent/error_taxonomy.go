@@ -1,9 +1,13 @@
 package agent
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
 
 type errorClass struct {
-	category    string
+	category    contracts.FailureCategory
 	remediation string
 }
 
@@ -11,15 +15,16 @@ var errorTaxonomy = []struct {
 	match func(string) bool
 	class errorClass
 }{
-	{match: containsAny("merge conflict", "non-fast-forward", "merge queue"), class: errorClass{category: "merge_queue_conflict", remediation: "Sync main, rebase the task branch, resolve conflicts, then retry landing."}},
-	{match: containsAny("review rejected", "verification not confirmed", "failing acceptance criteria"), class: errorClass{category: "review_gating", remediation: "Address review feedback, rerun implementation, and re-run review mode."}},
-	{match: containsAny("opencode stall", "runner timeout", "deadline exceeded", "timed out"), class: errorClass{category: "runner_timeout_stall", remediation: "Inspect runner and opencode logs, increase --runner-timeout if needed, then rerun."}},
-	{match: containsAny("serena initialization failed", "yolo agent missing", "permission: allow", ".opencode/agent/yolo.md"), class: errorClass{category: "runner_init", remediation: "Install the repo-local OpenCode assets under .opencode/agent, .opencode/skills, and .opencode/commands, then retry."}},
-	{match: containsAny("auth", "token", "credential", "profile", "permission denied", "config"), class: errorClass{category: "auth_profile_config", remediation: "Verify auth/profile/config values, refresh credentials, and retry with the correct profile."}},
-	{match: containsAny("chdir", "no such file", "repository does not exist", "clone"), class: errorClass{category: "filesystem_clone", remediation: "Confirm repository path exists, clone/fetch repository data, and retry from repo root."}},
-	{match: containsAny("task lock", "already locked", "resource busy", "lock held"), class: errorClass{category: "lock_contention", remediation: "Wait for other workers to finish or release stale lock, then retry."}},
-	{match: containsAny("tk ", "ticket", "task tracker", ".tickets"), class: errorClass{category: "tracker", remediation: "Verify tk CLI availability and task metadata, then rerun task selection."}},
-	{match: containsAny("git", "checkout", "branch", "rebase", "not a git repository", "worktree", "dirty", "local changes", "would be overwritten by checkout"), class: errorClass{category: "git/vcs", remediation: "Fix repository state (clean worktree, valid branch, fetch updates) and rerun."}},
+	{match: containsAny("preflight failed"), class: errorClass{category: contracts.FailureCategoryPreflight, remediation: "Fix each failed check, then rerun; --skip-preflight starts without the checks."}},
+	{match: containsAny("merge conflict", "non-fast-forward", "merge queue"), class: errorClass{category: contracts.FailureCategoryMergeQueueConflict, remediation: "Sync main, rebase the task branch, resolve conflicts, then retry landing."}},
+	{match: containsAny("review rejected", "verification not confirmed", "failing acceptance criteria"), class: errorClass{category: contracts.FailureCategoryReviewGating, remediation: "Address review feedback, rerun implementation, and re-run review mode."}},
+	{match: containsAny("opencode stall", "runner timeout", "deadline exceeded", "timed out"), class: errorClass{category: contracts.FailureCategoryRunnerTimeoutStall, remediation: "Inspect runner and opencode logs, increase --runner-timeout if needed, then rerun."}},
+	{match: containsAny("serena initialization failed", "yolo agent missing", "permission: allow", ".opencode/agent/yolo.md"), class: errorClass{category: contracts.FailureCategoryRunnerInit, remediation: "Install the repo-local OpenCode assets under .opencode/agent, .opencode/skills, and .opencode/commands, then retry."}},
+	{match: containsAny("auth", "token", "credential", "profile", "permission denied", "config"), class: errorClass{category: contracts.FailureCategoryAuthProfileConfig, remediation: "Verify auth/profile/config values, refresh credentials, and retry with the correct profile."}},
+	{match: containsAny("chdir", "no such file", "repository does not exist", "clone"), class: errorClass{category: contracts.FailureCategoryFilesystemClone, remediation: "Confirm repository path exists, clone/fetch repository data, and retry from repo root."}},
+	{match: containsAny("task lock", "already locked", "resource busy", "lock held"), class: errorClass{category: contracts.FailureCategoryLockContention, remediation: "Wait for other workers to finish or release stale lock, then retry."}},
+	{match: containsAny("tk ", "ticket", "task tracker", ".tickets"), class: errorClass{category: contracts.FailureCategoryTracker, remediation: "Verify tk CLI availability and task metadata, then rerun task selection."}},
+	{match: containsAny("git", "checkout", "branch", "rebase", "not a git repository", "worktree", "dirty", "local changes", "would be overwritten by checkout"), class: errorClass{category: contracts.FailureCategoryGitVCS, remediation: "Fix repository state (clean worktree, valid branch, fetch updates) and rerun."}},
 }
 
 func FormatActionableError(err error) string {
@@ -28,7 +33,7 @@ func FormatActionableError(err error) string {
 	}
 	cause := normalizeCause(trimGenericExitStatus(err.Error()))
 	class := classifyError(cause)
-	return "Category: " + class.category + "\nCause: " + cause + "\nNext step: " + class.remediation
+	return "Category: " + string(class.category) + "\nCause: " + cause + "\nNext step: " + class.remediation
 }
 
 func normalizeCause(cause string) string {
@@ -96,11 +101,48 @@ func classifyError(cause string) errorClass {
 		}
 	}
 	return errorClass{
-		category:    "unknown",
+		category:    contracts.FailureCategoryUnknown,
 		remediation: "Check runner logs for details and retry; escalate with full error text if it persists.",
 	}
 }
 
+// failureCategoryOf returns the failure category of blocked or failed task
+// data: the recorded triage category, else the stall category, else the
+// category its triage reason classifies under. It returns "" when the data
+// says nothing about why the task stopped.
+func failureCategoryOf(metadata map[string]string) contracts.FailureCategory {
+	if category := contracts.FailureCategoryFromMetadata(metadata); category != "" {
+		return category
+	}
+	if stall := contracts.NormalizeStallCategory(metadata[contracts.StallCategoryArtifactKey]); stall != "" {
+		return contracts.FailureCategory(stall)
+	}
+	reason := strings.TrimSpace(metadata["triage_reason"])
+	switch reason {
+	case "":
+		return ""
+	case operatorCancelReason:
+		return contracts.FailureCategoryOperatorCancel
+	}
+	return classifyError(reason).category
+}
+
+// appendTriageCategory records category as the triage category of blocked or
+// failed task data. An empty category is derived with failureCategoryOf.
+func appendTriageCategory(metadata map[string]string, category contracts.FailureCategory) map[string]string {
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	if category == "" {
+		category = failureCategoryOf(metadata)
+	}
+	if category == "" {
+		category = contracts.FailureCategoryUnknown
+	}
+	metadata[contracts.TriageCategoryMetadataKey] = string(category)
+	return metadata
+}
+
 func containsAny(parts ...string) func(string) bool {
 	return func(text string) bool {
 		for _, part := range parts {
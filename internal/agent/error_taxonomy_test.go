@@ -4,6 +4,8 @@ import (
 	"errors"
 	"strings"
 	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
 )
 
 func TestFormatActionableErrorIncludesCategoryCauseAndNextStep(t *testing.T) {
@@ -12,6 +14,7 @@ func TestFormatActionableErrorIncludesCategoryCauseAndNextStep(t *testing.T) {
 		err      error
 		category string
 	}{
+		{name: "preflight", err: errors.New("preflight failed for 1 of 2 checks: backend codex: binary \"codex\" not found in PATH"), category: "preflight"},
 		{name: "git vcs", err: errors.New("git checkout feature/task failed"), category: "git/vcs"},
 		{name: "tracker", err: errors.New("tk show task-1: file not found"), category: "tracker"},
 		{name: "runner init", err: errors.New("serena initialization failed: missing config"), category: "runner_init"},
@@ -81,3 +84,18 @@ func TestFormatActionableErrorNormalizesJoinedErrorsAndDropsGenericExitLine(t *t
 		t.Fatalf("expected detailed cause to be preserved, got %q", message)
 	}
 }
+
+func TestAppendTriageCategoryKeepsExplicitCategoryAndClassifiesTheRest(t *testing.T) {
+	data := appendTriageCategory(map[string]string{"triage_reason": "merge conflict"}, contracts.FailureCategoryQualityGate)
+	if data[contracts.TriageCategoryMetadataKey] != "quality_gate" {
+		t.Fatalf("expected explicit category kept, got %#v", data)
+	}
+	data = appendTriageCategory(map[string]string{"triage_reason": "git checkout failed: dirty worktree"}, "")
+	if data[contracts.TriageCategoryMetadataKey] != "git/vcs" {
+		t.Fatalf("expected reason classified, got %#v", data)
+	}
+	data = appendTriageCategory(map[string]string{}, "")
+	if data[contracts.TriageCategoryMetadataKey] != "unknown" {
+		t.Fatalf("expected unknown without a reason, got %#v", data)
+	}
+}
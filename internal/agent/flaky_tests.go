@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"regexp"
+	"sort"
+)
+
+// qcFlakyTestsMetadataKey lists, comma-separated, the tests of a task's
+// quality control gate that failed on one attempt and passed on another.
+const qcFlakyTestsMetadataKey = "qc_flaky_tests"
+
+var goTestFailurePattern = regexp.MustCompile(`(?m)^\s*--- FAIL: (\S+)`)
+
+// failedGoTests returns the sorted names of the tests go test output reports
+// as failed.
+func failedGoTests(output string) []string {
+	seen := map[string]struct{}{}
+	for _, match := range goTestFailurePattern.FindAllStringSubmatch(output, -1) {
+		seen[match[1]] = struct{}{}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// classifyFlakyTests splits the tests that failed on any attempt into those
+// that failed on every attempt and those that failed only on some. attempts
+// holds the failed tests of each run, empty for a run that passed.
+func classifyFlakyTests(attempts [][]string) (consistent []string, flaky []string) {
+	failures := map[string]int{}
+	for _, failed := range attempts {
+		for _, name := range failed {
+			failures[name]++
+		}
+	}
+	for name, count := range failures {
+		if count == len(attempts) {
+			consistent = append(consistent, name)
+		} else {
+			flaky = append(flaky, name)
+		}
+	}
+	sort.Strings(consistent)
+	sort.Strings(flaky)
+	return consistent, flaky
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestFailedGoTestsParsesFailLines(t *testing.T) {
+	output := "--- FAIL: TestB (0.00s)\n    --- FAIL: TestB/sub (0.00s)\n--- FAIL: TestA (0.01s)\n--- PASS: TestC (0.00s)\nFAIL\n--- FAIL: TestA (0.01s)\n"
+	if got, want := failedGoTests(output), []string{"TestA", "TestB", "TestB/sub"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestClassifyFlakyTestsSplitsConsistentFromIntermittentFailures(t *testing.T) {
+	consistent, flaky := classifyFlakyTests([][]string{{"TestA", "TestB"}, {"TestA"}, {"TestA", "TestC"}})
+	if !reflect.DeepEqual(consistent, []string{"TestA"}) || !reflect.DeepEqual(flaky, []string{"TestB", "TestC"}) {
+		t.Fatalf("unexpected classification consistent=%v flaky=%v", consistent, flaky)
+	}
+	consistent, flaky = classifyFlakyTests([][]string{{"TestA"}, nil})
+	if len(consistent) != 0 || !reflect.DeepEqual(flaky, []string{"TestA"}) {
+		t.Fatalf("expected a test passing on rerun to be flaky, got consistent=%v flaky=%v", consistent, flaky)
+	}
+}
+
+func TestRunQCTestSuiteValidationPassesFlakyTestsOnRerun(t *testing.T) {
+	repoRoot := createQCGateTestRepo(t, map[string]string{
+		"go.mod": `module qc-gate-test
+
+go 1.22
+`,
+		"main_test.go": `package main
+
+import (
+\t"os"
+\t"testing"
+)
+
+func TestStable(t *testing.T) {}
+
+func TestFlaky(t *testing.T) {
+\tif _, err := os.Stat("ran"); err != nil {
+\t\t_ = os.WriteFile("ran", nil, 0o644)
+\t\tt.Fatalf("first run fails")
+\t}
+}
+`,
+	})
+	loop := NewLoop(newFakeTaskManager(contracts.Task{ID: "t-1", Status: contracts.TaskStatusOpen}), &fakeRunner{}, nil, LoopOptions{QCGateTestReruns: 2})
+
+	result := loop.runQCTestSuiteValidation(context.Background(), repoRoot)
+	if !result.Passed || result.Status != "flaky" || result.Attempts != 2 {
+		t.Fatalf("expected the rerun to pass as flaky, got %#v", result)
+	}
+	if !reflect.DeepEqual(result.FlakyTests, []string{"TestFlaky"}) {
+		t.Fatalf("expected TestFlaky reported flaky, got %v", result.FlakyTests)
+	}
+}
+
+func TestRunQCTestSuiteValidationFailsConsistentFailuresAfterReruns(t *testing.T) {
+	repoRoot := createQCGateTestRepo(t, map[string]string{
+		"go.mod": `module qc-gate-test
+
+go 1.22
+`,
+		"main_test.go": `package main
+
+import "testing"
+
+func TestBroken(t *testing.T) {
+\tt.Fatalf("always fails")
+}
+`,
+	})
+	loop := NewLoop(newFakeTaskManager(contracts.Task{ID: "t-1", Status: contracts.TaskStatusOpen}), &fakeRunner{}, nil, LoopOptions{QCGateTestReruns: 1})
+
+	result := loop.runQCTestSuiteValidation(context.Background(), repoRoot)
+	if result.Passed || result.Status != "failed" || result.Attempts != 2 || len(result.FlakyTests) != 0 {
+		t.Fatalf("expected a consistent failure, got %#v", result)
+	}
+	if !strings.Contains(result.Reason, "tests failed on all 2 attempts: TestBroken") {
+		t.Fatalf("unexpected reason %q", result.Reason)
+	}
+}
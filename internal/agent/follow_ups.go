@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// followUpDescriptionPrefix starts the description of every filed follow-up.
+// Tasks carrying it never file follow-ups of their own, so deferred work cannot
+// cascade across runs.
+const followUpDescriptionPrefix = "Follow-up to task "
+
+const followUpPromptSection = "Deferred Work:\n" +
+	"- If you leave in-scope work undone or add TODOs, report each item on its own line: FOLLOW_UP: <title> - <details>"
+
+func followUpsFromArtifacts(result contracts.RunnerResult) []contracts.FollowUpItem {
+	if len(result.Artifacts) == 0 {
+		return nil
+	}
+	return contracts.DecodeFollowUps(result.Artifacts[contracts.FollowUpsArtifactKey])
+}
+
+func mergeFollowUps(existing []contracts.FollowUpItem, items []contracts.FollowUpItem) []contracts.FollowUpItem {
+	seen := make(map[string]struct{}, len(existing))
+	for _, item := range existing {
+		seen[strings.ToLower(item.Title)] = struct{}{}
+	}
+	for _, item := range items {
+		key := strings.ToLower(strings.TrimSpace(item.Title))
+		if key == "" {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		existing = append(existing, item)
+	}
+	return existing
+}
+
+// reviewFollowUp captures review feedback the remediation loop could not
+// resolve.
+func reviewFollowUp(task contracts.Task, feedback string) contracts.FollowUpItem {
+	feedback = strings.TrimSpace(feedback)
+	if feedback == "" {
+		feedback = "review failed after the retry budget was exhausted"
+	}
+	return contracts.FollowUpItem{Title: "Resolve review feedback: " + task.Title, Details: feedback}
+}
+
+func isFollowUpTask(task contracts.Task) bool {
+	return strings.HasPrefix(strings.TrimSpace(task.Description), followUpDescriptionPrefix)
+}
+
+// fileFollowUps creates a tracker task per deferred item next to the original
+// task and records the new IDs on it as follow_up_task_ids. Filing problems
+// are reported as warnings and never change the task outcome.
+func (l *Loop) fileFollowUps(ctx context.Context, task contracts.Task, items []contracts.FollowUpItem, worker string, clonePath string, queuePos int) {
+	if !l.options.FollowUpIssues || len(items) == 0 || isFollowUpTask(task) {
+		return
+	}
+	creator, ok := l.tasks.(contracts.TaskCreator)
+	if !ok {
+		l.emitFollowUpWarning(ctx, task, worker, clonePath, queuePos, "tracker does not support creating follow-up tasks")
+		return
+	}
+	parentID := strings.TrimSpace(task.ParentID)
+	if parentID == "" {
+		parentID = strings.TrimSpace(l.options.ParentID)
+	}
+	created := []string{}
+	for _, item := range items {
+		description := followUpDescriptionPrefix + task.ID + ": " + task.Title
+		if details := strings.TrimSpace(item.Details); details != "" {
+			description += "\n\n" + details
+		}
+		followUpID, err := creator.CreateTask(ctx, contracts.TaskCreateRequest{
+			ParentID:    parentID,
+			Title:       item.Title,
+			Description: description,
+		})
+		if err != nil {
+			l.emitFollowUpWarning(ctx, task, worker, clonePath, queuePos, "create follow-up task "+item.Title+": "+err.Error())
+			continue
+		}
+		created = append(created, followUpID)
+	}
+	if len(created) == 0 {
+		return
+	}
+	data := map[string]string{"follow_up_task_ids": strings.Join(created, ",")}
+	if err := l.setTaskData(ctx, task.ID, data); err != nil {
+		l.emitFollowUpWarning(ctx, task, worker, clonePath, queuePos, "record follow-up tasks: "+err.Error())
+		return
+	}
+	_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: clonePath, QueuePos: queuePos, Metadata: data, Timestamp: time.Now().UTC()})
+}
+
+func (l *Loop) emitFollowUpWarning(ctx context.Context, task contracts.Task, worker string, clonePath string, queuePos int, message string) {
+	_ = l.emit(ctx, contracts.Event{
+		Type:      contracts.EventTypeRunnerWarning,
+		TaskID:    task.ID,
+		TaskTitle: task.Title,
+		WorkerID:  worker,
+		ClonePath: clonePath,
+		QueuePos:  queuePos,
+		Message:   message,
+		Timestamp: time.Now().UTC(),
+	})
+}
@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+type followUpTaskManager struct {
+	*fakeTaskManager
+	created []contracts.TaskCreateRequest
+}
+
+func (m *followUpTaskManager) CreateTask(_ context.Context, request contracts.TaskCreateRequest) (string, error) {
+	m.created = append(m.created, request)
+	return "fu-" + strconv.Itoa(len(m.created)), nil
+}
+
+func TestLoopFilesFollowUpsForReportedTODOs(t *testing.T) {
+	mgr := &followUpTaskManager{fakeTaskManager: newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", ParentID: "root", Status: contracts.TaskStatusOpen})}
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted, Artifacts: map[string]string{
+			contracts.FollowUpsArtifactKey: `[{"title":"Add metrics","details":"counters missing"}]`,
+		}},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+	}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", RequireReview: true, FollowUpIssues: true})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 {
+		t.Fatalf("expected completed task, got %#v", summary)
+	}
+	if !strings.Contains(run.requests[0].Prompt, "FOLLOW_UP: <title> - <details>") {
+		t.Fatalf("expected follow-up instructions in implement prompt, got %q", run.requests[0].Prompt)
+	}
+	if len(mgr.created) != 1 {
+		t.Fatalf("expected one follow-up, got %#v", mgr.created)
+	}
+	created := mgr.created[0]
+	if created.ParentID != "root" || created.Title != "Add metrics" || created.Description != "Follow-up to task t-1: Task 1\n\ncounters missing" {
+		t.Fatalf("unexpected follow-up request %#v", created)
+	}
+	if got := mgr.dataByID["t-1"]["follow_up_task_ids"]; got != "fu-1" {
+		t.Fatalf("expected follow-up link on original task, got %q", got)
+	}
+}
+
+func TestLoopFilesFollowUpForUnresolvedReviewFeedback(t *testing.T) {
+	mgr := &followUpTaskManager{fakeTaskManager: newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})}
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, Artifacts: map[string]string{"review_verdict": "fail", "review_fail_feedback": "missing error handling"}},
+	}}
+	loop := NewLoop(mgr, run, &recordingSink{}, LoopOptions{ParentID: "root", MaxRetries: 0, RequireReview: true, FollowUpIssues: true})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Failed != 1 {
+		t.Fatalf("expected failed task, got %#v", summary)
+	}
+	if len(mgr.created) != 1 {
+		t.Fatalf("expected one review follow-up, got %#v", mgr.created)
+	}
+	if mgr.created[0].Title != "Resolve review feedback: Task 1" || !strings.Contains(mgr.created[0].Description, "missing error handling") {
+		t.Fatalf("unexpected review follow-up %#v", mgr.created[0])
+	}
+	if mgr.created[0].ParentID != "root" {
+		t.Fatalf("expected follow-up under run parent, got %q", mgr.created[0].ParentID)
+	}
+}
+
+func TestLoopSkipsFollowUpsWhenDisabledOrForFollowUpTasks(t *testing.T) {
+	results := func() []contracts.RunnerResult {
+		return []contracts.RunnerResult{
+			{Status: contracts.RunnerResultCompleted, Artifacts: map[string]string{contracts.FollowUpsArtifactKey: `[{"title":"More work"}]`}},
+			{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+		}
+	}
+
+	disabled := &followUpTaskManager{fakeTaskManager: newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})}
+	if _, err := NewLoop(disabled, &fakeRunner{results: results()}, nil, LoopOptions{ParentID: "root", RequireReview: true}).Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if len(disabled.created) != 0 {
+		t.Fatalf("expected no follow-ups when disabled, got %#v", disabled.created)
+	}
+
+	followUp := &followUpTaskManager{fakeTaskManager: newFakeTaskManager(contracts.Task{ID: "fu-1", Title: "More work", Description: "Follow-up to task t-1: Task 1", Status: contracts.TaskStatusOpen})}
+	if _, err := NewLoop(followUp, &fakeRunner{results: results()}, nil, LoopOptions{ParentID: "root", RequireReview: true, FollowUpIssues: true}).Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if len(followUp.created) != 0 {
+		t.Fatalf("expected follow-up tasks not to file follow-ups, got %#v", followUp.created)
+	}
+}
@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	defaultLandingHookTimeout = 10 * time.Minute
+	landingHookOutputLimit    = 2000
+)
+
+// LandingHookError reports a pre-landing hook that exited non-zero, timed
+// out or could not be started.
+type LandingHookError struct {
+	Command string
+	Output  string
+	Err     error
+}
+
+func (e *LandingHookError) Error() string {
+	reason := fmt.Sprintf("pre-landing hook %q failed: %v", e.Command, e.Err)
+	if line := strings.TrimSpace(lastNonEmptyLine(e.Output)); line != "" {
+		reason += ": " + line
+	}
+	return reason
+}
+
+func (e *LandingHookError) Unwrap() error {
+	return e.Err
+}
+
+// Metadata returns the triage fields recorded when the hook blocks landing.
+func (e *LandingHookError) Metadata() map[string]string {
+	return compactMetadata(map[string]string{
+		"landing_hook":        e.Command,
+		"landing_hook_output": tailOutput(e.Output, landingHookOutputLimit),
+	})
+}
+
+// runPreLandingHooks runs each configured hook with sh -c in repoRoot and
+// stops at the first failure. Hooks run on the task branch after the
+// auto-commit, so e.g. `make generate && git diff --exit-code` catches
+// stale generated files before they reach main. Files a hook names in
+// `ARTIFACT: <path>` output lines are collected for the task, whether or
+// not it passes. Hooks get the task's TaskEnv; secrets in their output are
+// masked.
+func (l *Loop) runPreLandingHooks(ctx context.Context, task contracts.Task, repoRoot string) error {
+	timeout := l.options.LandingHookTimeout
+	if timeout <= 0 {
+		timeout = defaultLandingHookTimeout
+	}
+	for _, command := range l.options.PreLandingHooks {
+		command = strings.TrimSpace(command)
+		if command == "" {
+			continue
+		}
+		hookCtx, cancel := context.WithTimeout(ctx, timeout)
+		output, err := runLandingHookCommand(hookCtx, repoRoot, command, l.taskEnv(task))
+		output = l.maskSecrets(output)
+		if err != nil && errors.Is(hookCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		cancel()
+		l.collectTaskArtifacts(task.ID, repoRoot, contracts.ParseTaskArtifacts(output))
+		if err != nil {
+			return &LandingHookError{Command: command, Output: output, Err: err}
+		}
+	}
+	return nil
+}
+
+// runLandingHookCommand is runQCGateCommand with a WaitDelay, so a hook that
+// leaves children holding its output open still returns on timeout.
+func runLandingHookCommand(ctx context.Context, repoRoot string, command string, env map[string]string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	if strings.TrimSpace(repoRoot) != "" {
+		cmd.Dir = strings.TrimSpace(repoRoot)
+	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), contracts.RunnerEnv(env)...)
+	}
+	cmd.WaitDelay = time.Second
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+func lastNonEmptyLine(text string) string {
+	lines := strings.Split(text, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+func tailOutput(output string, limit int) string {
+	output = strings.TrimSpace(output)
+	if len(output) <= limit {
+		return output
+	}
+	return "..." + output[len(output)-limit:]
+}
@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestRunPreLandingHooksRunsInRepoRootAndStopsAtFirstFailure(t *testing.T) {
+	repoRoot := t.TempDir()
+	loop := NewLoop(newFakeTaskManager(), &fakeRunner{}, nil, LoopOptions{PreLandingHooks: []string{
+		"touch first-ran",
+		"echo regenerated files differ >&2; exit 3",
+		"touch third-ran",
+	}})
+
+	err := loop.runPreLandingHooks(context.Background(), contracts.Task{ID: "t-1"}, repoRoot)
+	var hookErr *LandingHookError
+	if !errors.As(err, &hookErr) {
+		t.Fatalf("expected LandingHookError, got %v", err)
+	}
+	if hookErr.Command != "echo regenerated files differ >&2; exit 3" {
+		t.Fatalf("expected failing hook command, got %q", hookErr.Command)
+	}
+	if !strings.Contains(err.Error(), "regenerated files differ") || !strings.Contains(err.Error(), "exit status 3") {
+		t.Fatalf("expected exit status and output in reason, got %q", err.Error())
+	}
+	if _, statErr := os.Stat(filepath.Join(repoRoot, "first-ran")); statErr != nil {
+		t.Fatalf("expected first hook to run in repo root: %v", statErr)
+	}
+	if _, statErr := os.Stat(filepath.Join(repoRoot, "third-ran")); !os.IsNotExist(statErr) {
+		t.Fatalf("expected hooks after the failure to be skipped")
+	}
+}
+
+func TestRunPreLandingHooksTimesOut(t *testing.T) {
+	loop := NewLoop(newFakeTaskManager(), &fakeRunner{}, nil, LoopOptions{PreLandingHooks: []string{"sleep 5"}, LandingHookTimeout: 50 * time.Millisecond})
+
+	err := loop.runPreLandingHooks(context.Background(), contracts.Task{ID: "t-1"}, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Fatalf("expected hook timeout, got %v", err)
+	}
+}
+
+func TestLandingHookErrorMetadataKeepsOutputTail(t *testing.T) {
+	err := &LandingHookError{Command: "make generate", Output: strings.Repeat("x", landingHookOutputLimit) + "tail", Err: errors.New("exit status 2")}
+	metadata := err.Metadata()
+	if metadata["landing_hook"] != "make generate" {
+		t.Fatalf("unexpected hook metadata %#v", metadata)
+	}
+	if output := metadata["landing_hook_output"]; !strings.HasPrefix(output, "...") || !strings.HasSuffix(output, "tail") || len(output) != landingHookOutputLimit+3 {
+		t.Fatalf("expected truncated output tail, got %d bytes", len(output))
+	}
+}
+
+func TestLoopBlocksLandingWhenPreLandingHookFails(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+	}}
+	vcs := &fakeVCS{}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{
+		ParentID:        "root",
+		RequireReview:   true,
+		MergeOnSuccess:  true,
+		VCS:             vcs,
+		PreLandingHooks: []string{"true", "echo 'generated code is stale'; exit 1"},
+	})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || mgr.statusByID["t-1"] != contracts.TaskStatusBlocked {
+		t.Fatalf("expected blocked task, summary=%#v status=%s", summary, mgr.statusByID["t-1"])
+	}
+	if !containsCallPrefix(vcs.calls, "commit_all:") || containsCall(vcs.calls, "merge_to_main:task/t-1") || containsCall(vcs.calls, "push_main") {
+		t.Fatalf("expected auto-commit but no merge or push, got %v", vcs.calls)
+	}
+	data := mgr.dataByID["t-1"]
+	if !strings.Contains(data["triage_reason"], "pre-landing hook") || data["landing_hook"] != "echo 'generated code is stale'; exit 1" || data["landing_hook_output"] != "generated code is stale" {
+		t.Fatalf("expected structured hook triage, got %#v", data)
+	}
+	if !hasEventType(sink.events, contracts.EventTypeMergeBlocked) || hasEventType(sink.events, contracts.EventTypeMergeRetry) {
+		t.Fatalf("expected landing to block without retry")
+	}
+}
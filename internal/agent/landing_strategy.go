@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// LandingStrategy selects how a finished task branch reaches main.
+type LandingStrategy string
+
+const (
+	// LandingStrategyMerge creates a --no-ff merge commit (the default).
+	LandingStrategyMerge LandingStrategy = "merge"
+	// LandingStrategyRebaseFF rebases the task branch onto main and
+	// fast-forwards main, so history stays linear.
+	LandingStrategyRebaseFF LandingStrategy = "rebase_ff"
+	// LandingStrategySquash lands the task branch as one commit on main.
+	LandingStrategySquash LandingStrategy = "squash"
+)
+
+// ParseLandingStrategy accepts merge, rebase_ff and squash; empty means
+// merge.
+func ParseLandingStrategy(raw string) (LandingStrategy, error) {
+	switch strategy := LandingStrategy(strings.ToLower(strings.TrimSpace(raw))); strategy {
+	case "", LandingStrategyMerge:
+		return LandingStrategyMerge, nil
+	case LandingStrategyRebaseFF, LandingStrategySquash:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unsupported landing strategy %q (supported: %s, %s, %s)", raw, LandingStrategyMerge, LandingStrategyRebaseFF, LandingStrategySquash)
+	}
+}
+
+func (l *Loop) landingStrategy() LandingStrategy {
+	if l.options.LandingStrategy == "" {
+		return LandingStrategyMerge
+	}
+	return l.options.LandingStrategy
+}
+
+// landTaskBranch brings taskBranch onto main with the configured strategy.
+func (l *Loop) landTaskBranch(ctx context.Context, vcs contracts.VCS, task contracts.Task, taskBranch string, reviewVerdict string, backend string, model string) error {
+	switch strategy := l.landingStrategy(); strategy {
+	case LandingStrategyRebaseFF:
+		lander, ok := vcs.(contracts.RebaseLander)
+		if !ok {
+			return fmt.Errorf("landing strategy %s is not supported by this VCS", strategy)
+		}
+		return lander.RebaseAndFastForwardMain(ctx, taskBranch)
+	case LandingStrategySquash:
+		lander, ok := vcs.(contracts.SquashLander)
+		if !ok {
+			return fmt.Errorf("landing strategy %s is not supported by this VCS", strategy)
+		}
+		message, err := l.options.CommitMessages.Squash(task, taskBranch, reviewVerdict, backend, model)
+		if err != nil {
+			return err
+		}
+		return lander.SquashToMain(ctx, taskBranch, message)
+	default:
+		return l.mergeTaskBranch(ctx, vcs, task, taskBranch, reviewVerdict, backend, model)
+	}
+}
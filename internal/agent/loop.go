@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/prompt"
 	"github.com/egv/yolo-runner/v2/internal/scheduler"
 	taskquality "github.com/egv/yolo-runner/v2/internal/task_quality"
 	"github.com/egv/yolo-runner/v2/internal/tk"
@@ -36,6 +38,12 @@ type taskRuntimeConfig struct {
 	mode      string
 	timeout   time.Duration
 	useConfig bool
+	// executor is the WorkerAffinity executor the task is pinned to.
+	executor string
+	// retryBudget bounds the task's review and completion retries;
+	// retryBudgetSource names the override that set it, if any.
+	retryBudget       int
+	retryBudgetSource string
 }
 
 type taskLock interface {
@@ -55,21 +63,47 @@ type CloneManager interface {
 
 type VCSFactory func(repoRoot string) contracts.VCS
 
+// PromptContextBuilder gathers repository context for implement prompts.
+type PromptContextBuilder interface {
+	BuildPromptContext(ctx context.Context, task contracts.Task) string
+}
+
 type LoopOptions struct {
 	ParentID             string
 	MaxRetries           int
 	MaxTasks             int
 	Concurrency          int
 	SchedulerStatePath   string
+	DurationHistoryPath  string
 	DryRun               bool
+	DryRunPlanOutput     io.Writer
+	DryRunEmitPlan       bool
+	PromptTemplates      *prompt.Templates
+	CommitMessages       *CommitMessages
+	LandingStrategy      LandingStrategy
+	PreLandingHooks      []string
+	LandingHookTimeout   time.Duration
+	MergeQueueBatchSize  int
+	PushRetries          int
+	PromptContext        PromptContextBuilder
+	FollowUpIssues       bool
+	DiffSummary          bool
+	ResumeSessions       bool
+	StallNudgePrompt     string
+	StallPolicies        map[contracts.StallCategory]contracts.StallPolicy
+	RateLimitBackoff     time.Duration
+	EpicProgressInterval time.Duration
 	Stop                 <-chan struct{}
 	RepoRoot             string
 	Backend              string
 	Model                string
 	FallbackModel        string
+	FallbackChain        []ModelTarget
 	RunnerTimeout        time.Duration
 	WatchdogTimeout      time.Duration
 	WatchdogInterval     time.Duration
+	WatchdogHooks        []string
+	WatchdogHookTimeout  time.Duration
 	HeartbeatInterval    time.Duration
 	NoOutputWarningAfter time.Duration
 	TDDMode              bool
@@ -82,6 +116,61 @@ type LoopOptions struct {
 	MergeOnSuccess       bool
 	CloneManager         CloneManager
 	VCSFactory           VCSFactory
+	WorkspaceVCSFactory  WorkspaceVCSFactory
+	PathScope            PathScopeConfig
+	DiffGuardrails       DiffGuardrailConfig
+	DiffApprover         DiffApprover
+	SecretScan           SecretScanConfig
+	DependencyPolicy     DependencyPolicyConfig
+
+	// TaskDiscoveryInterval is how often the tracker is polled for tasks
+	// added under the root while the run is active; 0 disables polling.
+	TaskDiscoveryInterval time.Duration
+	// StatusReconcileInterval is how often in-flight tasks are checked for a
+	// status someone else set in the tracker, canceling their runners; 0
+	// leaves the check to when a runner finishes.
+	StatusReconcileInterval time.Duration
+	// BlockedRetryPolicies re-queues blocked tasks after a backoff, keyed
+	// by the categories BlockedRetryCategories lists.
+	BlockedRetryPolicies map[string]BlockedRetryPolicy
+	// QCGateTestReruns reruns a failing quality control test suite up to
+	// this many times. Tests that pass on a rerun are reported as flaky
+	// instead of blocking the task.
+	QCGateTestReruns int
+	// TaskEnv is injected into the runner and pre-landing hook processes
+	// of each task it applies to. Secret values are masked in events and
+	// runner logs.
+	TaskEnv []TaskEnvVar
+	// ToolPolicy restricts the commands runners may run. It is passed to
+	// backends with each request and enforced for every backend with PATH
+	// shims for the commands it names.
+	ToolPolicy contracts.ToolPolicy
+	// ShutdownGrace is how long in-flight tasks may keep running after Stop
+	// closes. When it runs out their runners are interrupted and the tasks
+	// reopened, as with Interrupt. 0 waits for them to finish.
+	ShutdownGrace time.Duration
+	// WorkerAffinity pins tasks to named executors by label. The executor
+	// is passed to runners in the ExecutorMetadataKey request metadata.
+	WorkerAffinity WorkerAffinityConfig
+	// OrphanedTasks selects what happens at startup to tasks a crashed run
+	// left in_progress; empty means OrphanedTaskReopen.
+	OrphanedTasks OrphanedTaskPolicy
+	// ReviewMode selects one review prompt or a spec compliance phase
+	// followed by a code quality phase; empty means ReviewModeSingle.
+	ReviewMode ReviewMode
+	// Pipeline lists the stages each task goes through; the zero value is
+	// the built-in implement, review, land flow.
+	Pipeline Pipeline
+	// StageApprover answers the approval stages of Pipeline. Without it
+	// approval stages block the task.
+	StageApprover StageApprover
+	// ReviewConcurrency is how many tasks may be in review, gates and
+	// landing while their worker implements the next task. 0 keeps each
+	// task on its worker until it finishes.
+	ReviewConcurrency int
+	// RunID names this run. It is stamped on every event and, under
+	// contracts.RunIDTaskDataKey, on the data of every task the run starts.
+	RunID string
 }
 
 type Loop struct {
@@ -91,9 +180,23 @@ type Loop struct {
 	options         LoopOptions
 	taskLock        taskLock
 	landingLock     landingLock
+	mergeQueue      mergeQueue
+	artifacts       taskArtifacts
 	cloneManager    CloneManager
 	schedulerState  *schedulerStateStore
+	rateLimit       *rateLimitBackoff
+	epicProgress    *epicProgressTracker
+	durations       *durationEstimator
+	control         *runControl
+	blockedRetries  *blockedRetryQueue
 	workerStartHook func(workerID int)
+	// knownTasks holds the tasks under the root seen so far, so polling
+	// reports only tasks added during the run.
+	knownTasks map[string]struct{}
+	// toolShimDir holds the tool policy shims while Run is running.
+	toolShimDir string
+	// reviewSlots is set while Run is running with ReviewConcurrency.
+	reviewSlots *reviewSlots
 }
 
 type taskConcurrencyCalculator interface {
@@ -114,6 +217,11 @@ func NewLoop(tasks contracts.TaskManager, runner contracts.AgentRunner, events c
 		landingLock:    scheduler.NewLandingLock(),
 		cloneManager:   options.CloneManager,
 		schedulerState: newSchedulerStateStore(options.SchedulerStatePath, options.ParentID),
+		rateLimit:      &rateLimitBackoff{},
+		epicProgress:   &epicProgressTracker{},
+		durations:      newDurationEstimator(options.DurationHistoryPath),
+		control:        newRunControl(),
+		blockedRetries: newBlockedRetryQueue(),
 	}
 }
 
@@ -143,6 +251,11 @@ func (l *Loop) Run(ctx context.Context) (contracts.LoopSummary, error) {
 	} else {
 		l.options.Concurrency = requestedConcurrency
 	}
+	if l.taskDiscoveryEnabled() && requestedConcurrency > l.options.Concurrency {
+		// Keep the requested workers so tasks added during the run can
+		// use the ones the starting graph is too narrow for.
+		l.options.Concurrency = requestedConcurrency
+	}
 	if l.options.Concurrency <= 0 {
 		l.options.Concurrency = 1
 	}
@@ -152,6 +265,18 @@ func (l *Loop) Run(ctx context.Context) (contracts.LoopSummary, error) {
 		if err != nil {
 			return summary, err
 		}
+		if l.options.DryRunPlanOutput != nil || l.options.DryRunEmitPlan {
+			plan, err := l.buildDryRunPlan(ctx, next)
+			if err != nil {
+				return summary, err
+			}
+			if err := WriteDryRunPlan(l.options.DryRunPlanOutput, plan); err != nil {
+				return summary, err
+			}
+			if l.options.DryRunEmitPlan {
+				l.emitDryRunPlan(ctx, plan)
+			}
+		}
 		if len(next) == 0 {
 			return summary, nil
 		}
@@ -167,14 +292,44 @@ func (l *Loop) Run(ctx context.Context) (contracts.LoopSummary, error) {
 	if err := l.recoverSchedulerState(ctx); err != nil {
 		return summary, err
 	}
+	cleanupToolSandbox, err := l.prepareToolSandbox()
+	if err != nil {
+		return summary, err
+	}
+	defer cleanupToolSandbox()
+
+	var progressTick <-chan time.Time
+	if l.epicProgressEnabled() {
+		l.reportEpicProgress(ctx, true)
+		defer l.reportEpicProgress(ctx, true)
+		ticker := time.NewTicker(l.options.EpicProgressInterval)
+		defer ticker.Stop()
+		progressTick = ticker.C
+	}
+	var discoveryTick <-chan time.Time
+	if l.taskDiscoveryEnabled() {
+		l.discoverTasks(ctx)
+		ticker := time.NewTicker(l.options.TaskDiscoveryInterval)
+		defer ticker.Stop()
+		discoveryTick = ticker.C
+	}
+	var reconcileTick <-chan time.Time
+	if l.options.StatusReconcileInterval > 0 {
+		ticker := time.NewTicker(l.options.StatusReconcileInterval)
+		defer ticker.Stop()
+		reconcileTick = ticker.C
+	}
 
 	type taskResult struct {
 		taskID   string
 		workerID int
 		queuePos int
 		priority int
+		elapsed  time.Duration
 		summary  contracts.LoopSummary
 		err      error
+		// interrupted is set when Interrupt reopened the task.
+		interrupted bool
 	}
 	type taskJob struct {
 		taskID   string
@@ -182,12 +337,20 @@ func (l *Loop) Run(ctx context.Context) (contracts.LoopSummary, error) {
 		priority int
 	}
 
-	results := make(chan taskResult, l.options.Concurrency)
+	l.reviewSlots = newReviewSlots(l.options.Concurrency, l.options.ReviewConcurrency)
+	workers := l.reviewSlots.workers(l.options.Concurrency)
+	results := make(chan taskResult, workers)
 	tasksCh := make(chan taskJob)
-	inFlight := map[string]struct{}{}
+	// inFlight maps each running task to the executor it is pinned to.
+	inFlight := map[string]string{}
 	queueCounter := 0
+	// stopping is set once Stop closes; shutdownDeadline then fires when
+	// ShutdownGrace runs out.
+	stopping := false
+	var shutdownDeadline <-chan time.Time
+	interruptedTasks := []string{}
 
-	for workerID := 0; workerID < l.options.Concurrency; workerID++ {
+	for workerID := 0; workerID < workers; workerID++ {
 		id := workerID
 		go func() {
 			if l.workerStartHook != nil {
@@ -200,8 +363,36 @@ func (l *Loop) Run(ctx context.Context) (contracts.LoopSummary, error) {
 							l.taskLock.Unlock(taskID)
 						}
 					}()
-					resultSummary, taskErr := l.runTask(ctx, taskID, id, queuePos, priority)
-					results <- taskResult{taskID: taskID, workerID: id, queuePos: queuePos, priority: priority, summary: resultSummary, err: taskErr}
+					startedAt := time.Now()
+					taskCtx := l.control.startTask(ctx, taskID)
+					resultSummary, taskErr := l.runTask(taskCtx, taskID, id, queuePos, priority)
+					l.reviewSlots.finish(taskID)
+					canceled, external := l.control.finishTask(taskID)
+					interrupted := l.control.takeInterrupted(taskID) && (taskErr != nil || resultSummary.Completed == 0)
+					if interrupted {
+						// Shutdown interrupted the task; whatever
+						// outcome its canceled runner reached, it is
+						// reopened for the next run.
+						resultSummary = contracts.LoopSummary{}
+						taskErr = l.reopenInterruptedTask(ctx, taskID, fmt.Sprintf("worker-%d", id), queuePos)
+					} else if external != "" && ctx.Err() == nil && taskErr != nil {
+						// Someone changed the task's status in the
+						// tracker and its runner was canceled; their
+						// status stands.
+						task, err := l.tasks.GetTask(ctx, taskID)
+						if err != nil {
+							task = contracts.Task{ID: taskID}
+						}
+						resultSummary = contracts.LoopSummary{Skipped: 1}
+						taskErr = l.yieldToExternalStatus(ctx, task, external, fmt.Sprintf("worker-%d", id), "", queuePos)
+					} else if canceled && ctx.Err() == nil && (taskErr != nil || resultSummary.Completed == 0) {
+						// The operator canceled the task before it
+						// completed; however the interrupted run ended,
+						// the task is blocked.
+						resultSummary = contracts.LoopSummary{Blocked: 1}
+						taskErr = l.blockCanceledTask(ctx, taskID, fmt.Sprintf("worker-%d", id), queuePos)
+					}
+					results <- taskResult{taskID: taskID, workerID: id, queuePos: queuePos, priority: priority, elapsed: time.Since(startedAt), summary: resultSummary, err: taskErr, interrupted: interrupted}
 				}(job.taskID, job.queuePos, job.priority)
 			}
 		}()
@@ -210,13 +401,31 @@ func (l *Loop) Run(ctx context.Context) (contracts.LoopSummary, error) {
 
 	for {
 		if l.stopRequested() && len(inFlight) == 0 {
+			l.emitRunInterrupted(ctx, interruptedTasks)
+			if len(interruptedTasks) > 0 {
+				return summary, ErrRunInterrupted
+			}
 			return summary, nil
 		}
+		if l.stopRequested() && !stopping {
+			stopping = true
+			if l.options.ShutdownGrace > 0 {
+				shutdownDeadline = time.After(l.options.ShutdownGrace)
+			}
+		}
 		if l.options.MaxTasks > 0 && summary.TotalProcessed() >= l.options.MaxTasks && len(inFlight) == 0 {
 			return summary, nil
 		}
+		requeued, err := l.requeueDueBlockedTasks(ctx)
+		if err != nil {
+			return summary, err
+		}
+		summary.Blocked -= requeued
 
-		for len(inFlight) < l.options.Concurrency {
+		dispatchPause := l.rateLimitPause()
+		paused, resumed := l.control.pauseState()
+		dispatched := 0
+		for dispatchPause == 0 && !paused && !stopping && len(inFlight) < workers {
 			if l.options.MaxTasks > 0 && summary.TotalProcessed()+len(inFlight) >= l.options.MaxTasks {
 				break
 			}
@@ -230,13 +439,22 @@ func (l *Loop) Run(ctx context.Context) (contracts.LoopSummary, error) {
 			}
 
 			taskID := ""
+			taskExecutor := ""
 			taskPriority := 0
 			for _, candidate := range next {
 				if _, running := inFlight[candidate.ID]; !running {
+					executor, free, err := l.dispatchExecutor(ctx, candidate.ID, inFlight)
+					if err != nil {
+						return summary, err
+					}
+					if !free {
+						continue
+					}
 					if l.taskLock != nil && !l.taskLock.TryLock(candidate.ID) {
 						continue
 					}
 					taskID = candidate.ID
+					taskExecutor = executor
 					if candidate.Priority != nil {
 						taskPriority = *candidate.Priority
 					}
@@ -246,16 +464,52 @@ func (l *Loop) Run(ctx context.Context) (contracts.LoopSummary, error) {
 			if taskID == "" {
 				break
 			}
+			if l.knownTasks != nil {
+				if _, known := l.knownTasks[taskID]; !known {
+					l.discoverTasks(ctx)
+				}
+			}
 
+			if !l.reviewSlots.reserve() {
+				if l.taskLock != nil {
+					l.taskLock.Unlock(taskID)
+				}
+				break
+			}
 			if err := l.markTaskInFlight(taskID); err != nil {
 				return summary, err
 			}
 
 			queueCounter++
-			inFlight[taskID] = struct{}{}
+			dispatched++
+			inFlight[taskID] = taskExecutor
 			tasksCh <- taskJob{taskID: taskID, queuePos: queueCounter, priority: taskPriority}
 		}
+		if dispatched > 0 {
+			l.reportRunEstimate(ctx, runningTaskIDs(inFlight), l.options.Concurrency)
+		}
 
+		if len(inFlight) == 0 && dispatchPause > 0 {
+			if err := sleepContext(ctx, dispatchPause); err != nil {
+				return summary, err
+			}
+			continue
+		}
+		if len(inFlight) == 0 && paused {
+			select {
+			case <-resumed:
+			case <-l.options.Stop:
+			case <-ctx.Done():
+				return summary, ctx.Err()
+			}
+			continue
+		}
+		if retryAt, ok := l.blockedRetries.next(); ok && len(inFlight) == 0 {
+			if err := l.waitForBlockedRetry(ctx, retryAt); err != nil {
+				return summary, err
+			}
+			continue
+		}
 		if len(inFlight) == 0 {
 			if completionChecker, ok := l.tasks.(taskCompletionChecker); ok {
 				complete, err := completionChecker.IsComplete(ctx)
@@ -269,8 +523,52 @@ func (l *Loop) Run(ctx context.Context) (contracts.LoopSummary, error) {
 			return summary, nil
 		}
 
-		result := <-results
+		var dispatchResumed <-chan time.Time
+		if dispatchPause > 0 {
+			dispatchResumed = time.After(dispatchPause)
+		}
+		var blockedRetryDue <-chan time.Time
+		if retryAt, ok := l.blockedRetries.next(); ok {
+			blockedRetryDue = time.After(time.Until(retryAt))
+		}
+		var stopSignal <-chan struct{}
+		if !stopping {
+			stopSignal = l.options.Stop
+		}
+		var result taskResult
+		select {
+		case result = <-results:
+		case <-stopSignal:
+			continue
+		case <-shutdownDeadline:
+			l.Interrupt()
+			shutdownDeadline = nil
+			continue
+		case <-dispatchResumed:
+			continue
+		case <-blockedRetryDue:
+			continue
+		case <-resumed:
+			continue
+		case <-l.reviewSlots.freedSignal():
+			// A task moved on to review; its implement slot is free.
+			continue
+		case <-progressTick:
+			l.reportEpicProgress(ctx, true)
+			continue
+		case <-discoveryTick:
+			// Tasks found here are dispatched on the next pass, without
+			// waiting for a running task to finish.
+			l.discoverTasks(ctx)
+			continue
+		case <-reconcileTick:
+			l.reconcileRunningTasks(ctx)
+			continue
+		}
 		delete(inFlight, result.taskID)
+		if result.interrupted {
+			interruptedTasks = append(interruptedTasks, result.taskID)
+		}
 		if result.err != nil {
 			return summary, result.err
 		}
@@ -278,6 +576,21 @@ func (l *Loop) Run(ctx context.Context) (contracts.LoopSummary, error) {
 		summary.Blocked += result.summary.Blocked
 		summary.Failed += result.summary.Failed
 		summary.Skipped += result.summary.Skipped
+		if result.summary.Blocked > 0 {
+			if err := l.scheduleBlockedRetry(ctx, result.taskID); err != nil {
+				return summary, err
+			}
+		}
+		if l.epicProgressEnabled() {
+			if result.summary.Completed > 0 {
+				l.epicProgress.recordCompletion(result.elapsed)
+			}
+			l.reportEpicProgress(ctx, false)
+		}
+		// Estimates are best effort; a history that cannot be saved only
+		// loses this task's duration.
+		_ = l.durations.finish(result.taskID, result.summary.Completed > 0, time.Now())
+		l.reportRunEstimate(ctx, runningTaskIDs(inFlight), l.options.Concurrency)
 	}
 }
 
@@ -289,7 +602,10 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 	if err != nil {
 		return summary, err
 	}
-	metadata := taskMonitoringMetadata(task)
+	startedAt := time.Now().UTC()
+	taskType := taskDurationType(task)
+	estimate, estimateKnown := l.durations.start(task.ID, taskType, startedAt)
+	metadata := appendTaskEstimateMetadata(taskMonitoringMetadata(task), taskType, startedAt, estimate, estimateKnown)
 	_ = l.emit(ctx, contracts.Event{
 		Type:      contracts.EventTypeTaskStarted,
 		TaskID:    task.ID,
@@ -299,13 +615,21 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 		Priority:  taskPriority,
 		Message:   task.Title,
 		Metadata:  metadata,
-		Timestamp: time.Now().UTC(),
+		Timestamp: startedAt,
 	})
 
 	taskRuntime, err := resolveTaskRuntimeConfig(task, l.options)
 	if err != nil {
 		return summary, err
 	}
+	taskRuntime.retryBudget, taskRuntime.retryBudgetSource, err = resolveTaskRetryBudget(task, l.options.MaxRetries)
+	if err != nil {
+		if err := l.blockInvalidRetryBudget(ctx, task, worker, queuePos, err); err != nil {
+			return summary, err
+		}
+		summary.Blocked++
+		return summary, nil
+	}
 
 	epicID := strings.TrimSpace(task.ParentID)
 	if epicID == "" {
@@ -338,6 +662,7 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 				"tests_failing": strconv.FormatBool(testsFailing),
 			}
 			blockedData = appendDecisionMetadata(blockedData, "blocked", reason)
+			blockedData = appendTriageCategory(blockedData, contracts.FailureCategoryTDDGate)
 			if err := l.markTaskBlockedWithData(task.ID, blockedData); err != nil {
 				return summary, err
 			}
@@ -352,6 +677,7 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 				"tests_failing": strconv.FormatBool(testsFailing),
 			}
 			finishedMetadata = appendDecisionMetadata(finishedMetadata, "blocked", reason)
+			finishedMetadata = appendTriageCategory(finishedMetadata, contracts.FailureCategoryTDDGate)
 			_ = l.emit(ctx, contracts.Event{
 				Type:      contracts.EventTypeTaskFinished,
 				TaskID:    task.ID,
@@ -363,7 +689,7 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 				Metadata:  finishedMetadata,
 				Timestamp: time.Now().UTC(),
 			})
-			if err := l.tasks.SetTaskData(ctx, task.ID, blockedData); err != nil {
+			if err := l.setTaskData(ctx, task.ID, blockedData); err != nil {
 				return summary, err
 			}
 			_ = l.emit(ctx, contracts.Event{
@@ -384,7 +710,23 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 		}
 	}
 
-	if l.cloneManager != nil {
+	workspace, hasWorkspace := WorkspaceFromTask(task)
+	if hasWorkspace {
+		clonePath, blockReason := l.cloneWorkspace(ctx, task.ID, workspace)
+		if blockReason != "" {
+			if err := l.blockTaskWorkspace(ctx, task, worker, queuePos, workspace, blockReason); err != nil {
+				return summary, err
+			}
+			summary.Blocked++
+			return summary, nil
+		}
+		taskRepoRoot = clonePath
+		defer func() {
+			if cleanupErr := l.cloneManager.Cleanup(task.ID); cleanupErr != nil && err == nil {
+				err = cleanupErr
+			}
+		}()
+	} else if l.cloneManager != nil {
 		clonePath, cloneErr := l.cloneManager.CloneForTask(ctx, task.ID, l.options.RepoRoot)
 		if cloneErr != nil {
 			return summary, cloneErr
@@ -399,6 +741,9 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 
 	taskBranch := ""
 	taskVCS := l.vcsForRepo(taskRepoRoot)
+	if hasWorkspace {
+		taskVCS = l.vcsForWorkspace(taskRepoRoot, workspace)
+	}
 	if taskVCS != nil {
 		if err := taskVCS.EnsureMain(ctx); err != nil {
 			return summary, err
@@ -417,6 +762,7 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 	if count, err := metadataRetryCount(task.Metadata, "review_retry_count"); err == nil {
 		reviewRetries = count
 	}
+	reviewPhaseRetries := reviewPhaseRetriesFromMetadata(task.Metadata)
 	reviewRetryFeedback := ""
 	if feedback := reviewRetryBlockersFromMetadata(task.Metadata); feedback != "" {
 		reviewRetryFeedback = feedback
@@ -426,23 +772,57 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 		completionRetries = count
 	}
 	completionAddendum := strings.TrimSpace(task.Metadata["completion_addendum"])
+	resumeSessionID := ""
+	resumeReason := ""
+	if sessionID := strings.TrimSpace(task.Metadata[interruptedSessionMetadataKey]); sessionID != "" && l.options.ResumeSessions {
+		// Continue the session a shutdown interrupted; it is resumed
+		// once.
+		if err := l.setTaskData(ctx, task.ID, map[string]string{interruptedSessionMetadataKey: ""}); err != nil {
+			return summary, err
+		}
+		resumeSessionID = sessionID
+		resumeReason = shutdownInterruptReason
+	}
+	stallNudged := strings.TrimSpace(task.Metadata[stallNudgedMetadataKey]) == "true"
+	pendingNudge := ""
+	watchdogExtensions := 0
+	if count, err := metadataRetryCount(task.Metadata, watchdogExtensionsMetadataKey); err == nil {
+		watchdogExtensions = count
+	}
 	implementModel := taskRuntime.model
 	if implementModel == "" {
 		implementModel = strings.TrimSpace(l.options.Model)
 	}
-	fallbackModel := strings.TrimSpace(l.options.FallbackModel)
 	usedModelFallback := false
 	modelBeforeFallback := ""
+	backendBeforeFallback := ""
 	modelFallbackReason := ""
 	taskBackend := taskRuntime.backend
 	if taskBackend == "" {
 		taskBackend = strings.TrimSpace(l.options.Backend)
 	}
+	modelFallbacks := l.modelFallbacks(ModelTarget{Backend: taskBackend, Model: implementModel})
+	modelFallbackAttempts := 0
+	repoContext := l.buildPromptContext(ctx, task)
+	acceptanceCriteria := parseAcceptanceCriteria(task.Description)
+	var criteriaResults []contracts.ReviewCriterionResult
+	var followUps []contracts.FollowUpItem
+	reviewVerdict := ""
+	if l.options.RunID != "" {
+		if err := l.setTaskData(ctx, task.ID, map[string]string{contracts.RunIDTaskDataKey: l.options.RunID}); err != nil {
+			return summary, err
+		}
+	}
 	for {
+		if err := l.reviewSlots.leaveReview(ctx, task.ID); err != nil {
+			return summary, err
+		}
 		reviewFailed := false
+		failedReviewPhase := ""
 		if err := l.tasks.SetTaskStatus(ctx, task.ID, contracts.TaskStatusInProgress); err != nil {
 			return summary, err
 		}
+		l.control.watch(task.ID)
 		implementLogPath := defaultRunnerLogPath(taskRepoRoot, task.ID, epicID, taskBackend)
 		if err := ensureRunnerLogDirectory(taskRepoRoot, implementLogPath); err != nil {
 			return summary, err
@@ -452,148 +832,330 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 		if usedModelFallback {
 			implementStartMeta = appendDecisionMetadata(implementStartMeta, "model_fallback", modelFallbackReason)
 			implementStartMeta["model_previous"] = modelBeforeFallback
-			if fallbackModel != "" {
-				implementStartMeta["model_fallback"] = fallbackModel
+			implementStartMeta["model_fallback"] = implementModel
+			implementStartMeta["model_fallback_attempt"] = strconv.Itoa(modelFallbackAttempts)
+			if !strings.EqualFold(backendBeforeFallback, taskBackend) {
+				implementStartMeta["backend_previous"] = backendBeforeFallback
 			}
 		}
+		if pendingNudge != "" {
+			implementStartMeta = appendDecisionMetadata(implementStartMeta, "stall_nudge", resumeReason)
+			implementStartMeta[stallNudgedMetadataKey] = "true"
+		} else if resumeSessionID != "" {
+			implementStartMeta = appendDecisionMetadata(implementStartMeta, "resume", resumeReason)
+		}
+		if resumeSessionID != "" {
+			implementStartMeta[contracts.ResumeSessionIDMetadataKey] = resumeSessionID
+		}
 		_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerStarted, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(contracts.RunnerModeImplement), Metadata: implementStartMeta, Timestamp: time.Now().UTC()})
 		requestMetadata := map[string]string{"log_path": implementLogPath, "clone_path": taskRepoRoot}
 		appendTaskRuntimeMetadata(requestMetadata, taskRuntime)
-		if l.options.WatchdogTimeout > 0 {
-			requestMetadata["watchdog_timeout"] = l.options.WatchdogTimeout.String()
+		if taskBackend != "" && !strings.EqualFold(taskBackend, taskRuntime.backend) {
+			requestMetadata["backend"] = taskBackend
+		}
+		if l.options.ResumeSessions {
+			requestMetadata[contracts.ResumableSessionMetadataKey] = "true"
+		}
+		if resumeSessionID != "" {
+			requestMetadata[contracts.ResumeSessionIDMetadataKey] = resumeSessionID
+			requestMetadata[contracts.ResumePromptMetadataKey] = contracts.BuildResumePrompt(resumeReason)
+			if pendingNudge != "" {
+				requestMetadata[contracts.ResumePromptMetadataKey] = pendingNudge
+			}
+		}
+		if watchdogTimeout := l.watchdogTimeoutFor(watchdogExtensions); watchdogTimeout > 0 {
+			requestMetadata["watchdog_timeout"] = watchdogTimeout.String()
 		}
 		if l.options.WatchdogInterval > 0 {
 			requestMetadata["watchdog_interval"] = l.options.WatchdogInterval.String()
 		}
+		contracts.SetWatchdogHooks(requestMetadata, l.options.WatchdogHooks, l.options.WatchdogHookTimeout)
+
+		implementRepo := l.promptRepoContext(taskRepoRoot, taskBackend, implementModel)
+		implementRepo.Context = repoContext
+		implementPrompt, err := l.renderImplementPrompt(
+			task,
+			implementRepo,
+			reviewRetryFeedback,
+			reviewRetries,
+			completionAddendum,
+			completionRetries,
+		)
+		if err != nil {
+			return summary, err
+		}
+		if pendingNudge != "" {
+			implementPrompt = implementPrompt + "\n\n" + pendingNudge
+		}
+		resumeSessionID = ""
+		resumeReason = ""
+		pendingNudge = ""
 
 		result, err := l.runRunnerWithMonitoring(ctx, contracts.RunnerRequest{
-			TaskID:   task.ID,
-			ParentID: l.options.ParentID,
-			Mode:     contracts.RunnerModeImplement,
-			RepoRoot: taskRepoRoot,
-			Model:    implementModel,
-			Timeout:  taskRuntime.timeout,
-			Prompt: buildImplementPrompt(
-				task,
-				reviewRetryFeedback,
-				reviewRetries,
-				completionAddendum,
-				completionRetries,
-				l.options.TDDMode,
-			),
-			Metadata: requestMetadata,
+			TaskID:     task.ID,
+			ParentID:   l.options.ParentID,
+			Mode:       contracts.RunnerModeImplement,
+			RepoRoot:   taskRepoRoot,
+			Model:      implementModel,
+			Timeout:    taskRuntime.timeout,
+			Prompt:     implementPrompt,
+			Metadata:   requestMetadata,
+			Env:        l.runnerEnv(task),
+			Secrets:    l.secretValues(),
+			ToolPolicy: l.options.ToolPolicy,
 		}, task.ID, task.Title, worker, taskRepoRoot, queuePos)
+		if l.control.isInterrupted(task.ID) {
+			// The worker reopens the task; keep its session for the
+			// next run.
+			return summary, l.saveInterruptedSession(ctx, task, result)
+		}
 		if err != nil {
 			return summary, err
 		}
+		l.collectRunnerArtifacts(task.ID, taskRepoRoot, result)
 		_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(result.Status), Metadata: buildRunnerFinishedMetadata(result), Timestamp: time.Now().UTC()})
+		if yielded, err := l.yieldIfStatusChanged(ctx, task, worker, taskRepoRoot, queuePos); err != nil {
+			return summary, err
+		} else if yielded {
+			summary.Skipped++
+			return summary, nil
+		}
+		followUps = mergeFollowUps(followUps, followUpsFromArtifacts(result))
+		if result.Status == contracts.RunnerResultCompleted {
+			if blocked, err := l.runPathScopeGate(ctx, task, taskVCS, worker, queuePos, taskRepoRoot); err != nil {
+				return summary, err
+			} else if blocked {
+				summary.Blocked++
+				return summary, nil
+			}
+			if blocked, comments, err := l.runDiffGuardrails(ctx, task, taskVCS, worker, queuePos, taskRepoRoot); err != nil {
+				return summary, err
+			} else if blocked {
+				summary.Blocked++
+				return summary, nil
+			} else if comments != "" && reviewRetries < taskRuntime.retryBudget && ctx.Err() == nil {
+				reviewRetries++
+				reviewRetryFeedback = comments
+				retryData := appendDecisionMetadata(map[string]string{
+					"review_retry_count": fmt.Sprintf("%d", reviewRetries),
+					"review_feedback":    reviewRetryFeedback,
+				}, "retry", reviewerCommentsReason)
+				if err := l.scheduleTaskRetry(ctx, &task, retryData, worker, taskRepoRoot, queuePos); err != nil {
+					return summary, err
+				}
+				continue
+			}
+			if blocked, err := l.runSecretScan(ctx, task, taskVCS, worker, queuePos, taskRepoRoot); err != nil {
+				return summary, err
+			} else if blocked {
+				summary.Blocked++
+				return summary, nil
+			}
+			if blocked, err := l.runDependencyPolicy(ctx, task, taskVCS, worker, queuePos, taskRepoRoot); err != nil {
+				return summary, err
+			} else if blocked {
+				summary.Blocked++
+				return summary, nil
+			}
+		}
 
-		if result.Status == contracts.RunnerResultCompleted && l.options.RequireReview {
-			reviewAttempt := reviewRetries + 1
-			reviewTelemetry := map[string]string{
-				"review_attempt":     fmt.Sprintf("%d", reviewAttempt),
-				"review_retry_count": fmt.Sprintf("%d", reviewRetries),
+		if result.Status == contracts.RunnerResultCompleted {
+			if outcome, err := l.runPipelineStages(ctx, true, task, taskRuntime, taskBackend, implementModel, epicID, worker, taskRepoRoot, queuePos); err != nil {
+				return summary, err
+			} else if outcome.blocked {
+				summary.Blocked++
+				return summary, nil
+			} else if outcome.gateFailure != "" {
+				result.Status = contracts.RunnerResultFailed
+				result.Reason = outcome.gateFailure
 			}
-			_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeReviewStarted, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: reviewTelemetry, Timestamp: time.Now().UTC()})
-			reviewLogPath := defaultRunnerLogPath(taskRepoRoot, task.ID, epicID, taskBackend)
-			if err := ensureRunnerLogDirectory(taskRepoRoot, reviewLogPath); err != nil {
+		}
+
+		if result.Status == contracts.RunnerResultCompleted && l.options.RequireReview && l.options.Pipeline.reviews() {
+			if err := l.reviewSlots.enterReview(ctx, task.ID); err != nil {
 				return summary, err
 			}
-			reviewStartMeta := buildRunnerStartedMetadata(contracts.RunnerModeReview, taskBackend, implementModel, taskRepoRoot, reviewLogPath, time.Now().UTC())
-			appendTaskRuntimeMetadata(reviewStartMeta, taskRuntime)
-			_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerStarted, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(contracts.RunnerModeReview), Metadata: reviewStartMeta, Timestamp: time.Now().UTC()})
-			reviewMetadata := map[string]string{"log_path": reviewLogPath, "clone_path": taskRepoRoot}
-			appendTaskRuntimeMetadata(reviewMetadata, taskRuntime)
-			if l.options.WatchdogTimeout > 0 {
-				reviewMetadata["watchdog_timeout"] = l.options.WatchdogTimeout.String()
-			}
-			if l.options.WatchdogInterval > 0 {
-				reviewMetadata["watchdog_interval"] = l.options.WatchdogInterval.String()
-			}
-
-			reviewResult, reviewErr := l.runRunnerWithMonitoring(ctx, contracts.RunnerRequest{
-				TaskID:   task.ID,
-				ParentID: l.options.ParentID,
-				Mode:     contracts.RunnerModeReview,
-				RepoRoot: taskRepoRoot,
-				Model:    implementModel,
-				Timeout:  taskRuntime.timeout,
-				Prompt:   buildPrompt(task, contracts.RunnerModeReview, false),
-				Metadata: reviewMetadata,
-			}, task.ID, task.Title, worker, taskRepoRoot, queuePos)
-			if reviewErr != nil {
-				return summary, reviewErr
-			}
-			_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(reviewResult.Status), Metadata: buildRunnerFinishedMetadata(reviewResult), Timestamp: time.Now().UTC()})
-
-			finalReviewResult := reviewResult
-			if reviewResult.Status == contracts.RunnerResultCompleted && !reviewResult.ReviewReady && reviewVerdictFromArtifacts(reviewResult) == "" {
-				verdictMetadata := map[string]string{
-					"log_path":     reviewLogPath,
-					"clone_path":   taskRepoRoot,
-					"review_phase": "verdict_retry",
+			l.control.watch(task.ID)
+			for _, reviewPhase := range l.reviewPhases() {
+				reviewAttempt := reviewRetries + 1
+				reviewTelemetry := map[string]string{
+					"review_attempt":     fmt.Sprintf("%d", reviewAttempt),
+					"review_retry_count": fmt.Sprintf("%d", reviewRetries),
 				}
+				reviewTelemetry = appendRetryBudgetMetadata(reviewTelemetry, taskRuntime)
+				reviewTelemetry = appendReviewPhaseMetadata(reviewTelemetry, reviewPhase, reviewPhaseRetries[reviewPhase])
+				_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeReviewStarted, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: reviewTelemetry, Timestamp: time.Now().UTC()})
+				reviewLogPath := defaultRunnerLogPath(taskRepoRoot, task.ID, epicID, taskBackend)
+				if err := ensureRunnerLogDirectory(taskRepoRoot, reviewLogPath); err != nil {
+					return summary, err
+				}
+				reviewStartMeta := buildRunnerStartedMetadata(contracts.RunnerModeReview, taskBackend, implementModel, taskRepoRoot, reviewLogPath, time.Now().UTC())
+				appendTaskRuntimeMetadata(reviewStartMeta, taskRuntime)
+				if reviewPhase != "" {
+					reviewStartMeta["review_phase"] = reviewPhase
+				}
+				_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerStarted, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(contracts.RunnerModeReview), Metadata: reviewStartMeta, Timestamp: time.Now().UTC()})
+				reviewMetadata := map[string]string{"log_path": reviewLogPath, "clone_path": taskRepoRoot}
+				if reviewPhase != "" {
+					reviewMetadata["review_phase"] = reviewPhase
+				}
+				appendTaskRuntimeMetadata(reviewMetadata, taskRuntime)
 				if l.options.WatchdogTimeout > 0 {
-					verdictMetadata["watchdog_timeout"] = l.options.WatchdogTimeout.String()
+					reviewMetadata["watchdog_timeout"] = l.options.WatchdogTimeout.String()
 				}
 				if l.options.WatchdogInterval > 0 {
-					verdictMetadata["watchdog_interval"] = l.options.WatchdogInterval.String()
+					reviewMetadata["watchdog_interval"] = l.options.WatchdogInterval.String()
 				}
-				verdictStartMeta := buildRunnerStartedMetadata(contracts.RunnerModeReview, taskBackend, implementModel, taskRepoRoot, reviewLogPath, time.Now().UTC())
-				appendTaskRuntimeMetadata(verdictStartMeta, taskRuntime)
-				verdictStartMeta["review_phase"] = "verdict_retry"
-				_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerStarted, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(contracts.RunnerModeReview), Metadata: verdictStartMeta, Timestamp: time.Now().UTC()})
-
-				verdictResult, verdictErr := l.runRunnerWithMonitoring(ctx, contracts.RunnerRequest{
-					TaskID:   task.ID,
-					ParentID: l.options.ParentID,
-					Mode:     contracts.RunnerModeReview,
-					RepoRoot: taskRepoRoot,
-					Model:    implementModel,
-					Timeout:  taskRuntime.timeout,
-					Prompt:   buildReviewVerdictPrompt(task),
-					Metadata: verdictMetadata,
+				contracts.SetWatchdogHooks(reviewMetadata, l.options.WatchdogHooks, l.options.WatchdogHookTimeout)
+
+				reviewPrompt, err := l.renderReviewPhasePrompt(task, l.promptRepoContext(taskRepoRoot, taskBackend, implementModel), reviewPhase)
+				if err != nil {
+					return summary, err
+				}
+
+				reviewResult, reviewErr := l.runRunnerWithMonitoring(ctx, contracts.RunnerRequest{
+					TaskID:     task.ID,
+					ParentID:   l.options.ParentID,
+					Mode:       contracts.RunnerModeReview,
+					RepoRoot:   taskRepoRoot,
+					Model:      implementModel,
+					Timeout:    taskRuntime.timeout,
+					Prompt:     reviewPrompt,
+					Metadata:   reviewMetadata,
+					Env:        l.runnerEnv(task),
+					Secrets:    l.secretValues(),
+					ToolPolicy: l.options.ToolPolicy,
 				}, task.ID, task.Title, worker, taskRepoRoot, queuePos)
-				if verdictErr != nil {
-					return summary, verdictErr
+				if reviewErr != nil {
+					return summary, reviewErr
+				}
+				l.collectRunnerArtifacts(task.ID, taskRepoRoot, reviewResult)
+				_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(reviewResult.Status), Metadata: buildRunnerFinishedMetadata(reviewResult), Timestamp: time.Now().UTC()})
+
+				finalReviewResult := reviewResult
+				if reviewResult.Status == contracts.RunnerResultCompleted && !reviewResult.ReviewReady && reviewVerdictFromArtifacts(reviewResult) == "" {
+					verdictMetadata := map[string]string{
+						"log_path":     reviewLogPath,
+						"clone_path":   taskRepoRoot,
+						"review_phase": "verdict_retry",
+					}
+					if taskRuntime.executor != "" {
+						verdictMetadata[ExecutorMetadataKey] = taskRuntime.executor
+					}
+					if l.options.WatchdogTimeout > 0 {
+						verdictMetadata["watchdog_timeout"] = l.options.WatchdogTimeout.String()
+					}
+					if l.options.WatchdogInterval > 0 {
+						verdictMetadata["watchdog_interval"] = l.options.WatchdogInterval.String()
+					}
+					contracts.SetWatchdogHooks(verdictMetadata, l.options.WatchdogHooks, l.options.WatchdogHookTimeout)
+					verdictStartMeta := buildRunnerStartedMetadata(contracts.RunnerModeReview, taskBackend, implementModel, taskRepoRoot, reviewLogPath, time.Now().UTC())
+					appendTaskRuntimeMetadata(verdictStartMeta, taskRuntime)
+					verdictStartMeta["review_phase"] = "verdict_retry"
+					_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerStarted, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(contracts.RunnerModeReview), Metadata: verdictStartMeta, Timestamp: time.Now().UTC()})
+
+					verdictResult, verdictErr := l.runRunnerWithMonitoring(ctx, contracts.RunnerRequest{
+						TaskID:     task.ID,
+						ParentID:   l.options.ParentID,
+						Mode:       contracts.RunnerModeReview,
+						RepoRoot:   taskRepoRoot,
+						Model:      implementModel,
+						Timeout:    taskRuntime.timeout,
+						Prompt:     buildReviewVerdictPrompt(task),
+						Metadata:   verdictMetadata,
+						Env:        l.runnerEnv(task),
+						Secrets:    l.secretValues(),
+						ToolPolicy: l.options.ToolPolicy,
+					}, task.ID, task.Title, worker, taskRepoRoot, queuePos)
+					if verdictErr != nil {
+						return summary, verdictErr
+					}
+					l.collectRunnerArtifacts(task.ID, taskRepoRoot, verdictResult)
+					_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(verdictResult.Status), Metadata: buildRunnerFinishedMetadata(verdictResult), Timestamp: time.Now().UTC()})
+					finalReviewResult = verdictResult
 				}
-				_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(verdictResult.Status), Metadata: buildRunnerFinishedMetadata(verdictResult), Timestamp: time.Now().UTC()})
-				finalReviewResult = verdictResult
-			}
 
-			if finalReviewResult.Status == contracts.RunnerResultCompleted && !finalReviewResult.ReviewReady {
-				finalReviewResult.Status = contracts.RunnerResultFailed
-				if verdict := reviewVerdictFromArtifacts(finalReviewResult); verdict == "fail" {
-					finalReviewResult.Reason = buildReviewFailReason(finalReviewResult)
-				} else {
-					finalReviewResult.Reason = "review verdict missing explicit pass"
+				// The quality phase does not report criteria; the spec phase
+				// results stand.
+				if reviewPhase != reviewPhaseQuality {
+					criteriaResults = reviewCriteriaFromArtifacts(finalReviewResult)
+				}
+				if finalReviewResult.Status == contracts.RunnerResultCompleted && finalReviewResult.ReviewReady && reviewPhase != reviewPhaseQuality {
+					if unmet := unmetAcceptanceCriteria(acceptanceCriteria, criteriaResults); len(unmet) > 0 {
+						feedback := "unmet acceptance criteria: " + strings.Join(unmet, "; ")
+						artifacts := map[string]string{}
+						for key, value := range finalReviewResult.Artifacts {
+							artifacts[key] = value
+						}
+						artifacts["review_verdict"] = "fail"
+						artifacts["review_fail_feedback"] = feedback
+						finalReviewResult.Artifacts = artifacts
+						finalReviewResult.ReviewReady = false
+					}
+				}
+				if finalReviewResult.Status == contracts.RunnerResultCompleted && !finalReviewResult.ReviewReady {
+					finalReviewResult.Status = contracts.RunnerResultFailed
+					if verdict := reviewVerdictFromArtifacts(finalReviewResult); verdict == "fail" {
+						finalReviewResult.Reason = buildReviewFailReason(finalReviewResult)
+					} else {
+						finalReviewResult.Reason = "review verdict missing explicit pass"
+					}
 				}
-			}
-			if finalReviewResult.Status == contracts.RunnerResultFailed {
-				finalReviewResult.Reason = resolveReviewFailureReason(finalReviewResult.Reason, task.Metadata)
-			}
-			reviewFinishedMetadata := map[string]string{
-				"review_attempt":     fmt.Sprintf("%d", reviewAttempt),
-				"review_retry_count": fmt.Sprintf("%d", reviewRetries),
-			}
-			if strings.TrimSpace(finalReviewResult.Reason) != "" {
-				reviewFinishedMetadata["reason"] = strings.TrimSpace(finalReviewResult.Reason)
-			}
-			if verdict := reviewVerdictFromArtifacts(finalReviewResult); verdict != "" {
-				reviewFinishedMetadata["review_verdict"] = verdict
-			}
-			if feedback := reviewFailFeedbackFromArtifacts(finalReviewResult); feedback != "" {
-				reviewFinishedMetadata["review_fail_feedback"] = feedback
-			}
-			_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeReviewFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(finalReviewResult.Status), Metadata: reviewFinishedMetadata, Timestamp: time.Now().UTC()})
-			if finalReviewResult.Status != contracts.RunnerResultCompleted {
-				result = finalReviewResult
 				if finalReviewResult.Status == contracts.RunnerResultFailed {
-					reviewFailed = true
+					finalReviewResult.Reason = resolveReviewFailureReason(finalReviewResult.Reason, task.Metadata)
+					if reviewPhase != "" && strings.HasPrefix(finalReviewResult.Reason, "review ") {
+						finalReviewResult.Reason = reviewPhaseLabel(reviewPhase) + " " + finalReviewResult.Reason
+					}
+				}
+				reviewFinishedMetadata := map[string]string{
+					"review_attempt":     fmt.Sprintf("%d", reviewAttempt),
+					"review_retry_count": fmt.Sprintf("%d", reviewRetries),
+				}
+				reviewFinishedMetadata = appendRetryBudgetMetadata(reviewFinishedMetadata, taskRuntime)
+				reviewFinishedMetadata = appendReviewPhaseMetadata(reviewFinishedMetadata, reviewPhase, reviewPhaseRetries[reviewPhase])
+				reviewFinishedMetadata = appendReviewPhaseOutcomeMetadata(reviewFinishedMetadata, reviewPhase, finalReviewResult)
+				if strings.TrimSpace(finalReviewResult.Reason) != "" {
+					reviewFinishedMetadata["reason"] = strings.TrimSpace(finalReviewResult.Reason)
+				}
+				reviewVerdict = reviewVerdictFromArtifacts(finalReviewResult)
+				if reviewVerdict != "" {
+					reviewFinishedMetadata["review_verdict"] = reviewVerdict
+				}
+				if feedback := reviewFailFeedbackFromArtifacts(finalReviewResult); feedback != "" {
+					reviewFinishedMetadata["review_fail_feedback"] = feedback
+				}
+				reviewFinishedMetadata = appendAcceptanceCriteriaMetadata(reviewFinishedMetadata, acceptanceCriteria, criteriaResults)
+				_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeReviewFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(finalReviewResult.Status), Metadata: reviewFinishedMetadata, Timestamp: time.Now().UTC()})
+				if finalReviewResult.Status != contracts.RunnerResultCompleted {
+					result = finalReviewResult
+					if finalReviewResult.Status == contracts.RunnerResultFailed {
+						reviewFailed = true
+						failedReviewPhase = reviewPhase
+					}
+					break
 				}
 			}
 		}
 
+		if result.Status == contracts.RunnerResultCompleted {
+			if outcome, err := l.runPipelineStages(ctx, false, task, taskRuntime, taskBackend, implementModel, epicID, worker, taskRepoRoot, queuePos); err != nil {
+				return summary, err
+			} else if outcome.blocked {
+				summary.Blocked++
+				return summary, nil
+			} else if outcome.gateFailure != "" {
+				result.Status = contracts.RunnerResultFailed
+				result.Reason = outcome.gateFailure
+			}
+		}
+
+		if yielded, err := l.yieldIfStatusChanged(ctx, task, worker, taskRepoRoot, queuePos); err != nil {
+			return summary, err
+		} else if yielded {
+			summary.Skipped++
+			return summary, nil
+		}
+
 		switch result.Status {
 		case contracts.RunnerResultCompleted:
 			if blocked, err := l.runQCGate(ctx, task, result, worker, queuePos, taskRepoRoot); err != nil {
@@ -602,155 +1164,37 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 				summary.Blocked++
 				return summary, nil
 			}
+			l.summarizeTaskDiff(ctx, task, taskVCS, taskRuntime, taskBackend, implementModel, epicID, worker, taskRepoRoot, queuePos)
 
 			if err := l.markTaskCompleted(task.ID); err != nil {
 				return summary, err
 			}
-			if l.options.MergeOnSuccess && taskVCS != nil && taskBranch != "" {
-				landingState := scheduler.NewLandingQueueStateMachine(2)
-				autoCommitSHA := ""
-				buildLandingMetadata := func(status string, attempt int, reason string) map[string]string {
-					metadata := map[string]string{"landing_status": status}
-					metadata = appendDecisionMetadata(metadata, status, reason)
-					if attempt > 0 {
-						metadata["landing_attempt"] = fmt.Sprintf("%d", attempt)
-					}
-					if strings.TrimSpace(reason) != "" {
-						metadata["triage_reason"] = reason
-					}
-					if autoCommitSHA != "" {
-						metadata["auto_commit_sha"] = autoCommitSHA
-					}
-					return metadata
-				}
-				emitMergeQueueEvent := func(eventType contracts.EventType, metadata map[string]string) {
-					merged := map[string]string{}
-					for key, value := range metadata {
-						merged[key] = value
-					}
-					if autoCommitSHA != "" {
-						merged["auto_commit_sha"] = autoCommitSHA
-					}
-					_ = l.emit(ctx, contracts.Event{
-						Type:      eventType,
-						TaskID:    task.ID,
-						TaskTitle: task.Title,
-						WorkerID:  worker,
-						ClonePath: taskRepoRoot,
-						QueuePos:  queuePos,
-						Metadata:  compactMetadata(merged),
-						Timestamp: time.Now().UTC(),
-					})
-				}
-				emitMergeQueueEvent(contracts.EventTypeMergeQueued, appendDecisionMetadata(map[string]string{"landing_status": string(landingState.State())}, string(landingState.State()), ""))
-				_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: buildLandingMetadata(string(landingState.State()), 0, ""), Timestamp: time.Now().UTC()})
-				if l.landingLock != nil {
-					l.landingLock.Lock()
-					defer l.landingLock.Unlock()
-				}
-				landingBlocked := false
-				landingReason := ""
-				autoCommitDone := false
-				for attempt := 1; attempt <= 2; attempt++ {
-					_ = landingState.Apply(scheduler.LandingEventBegin)
-					_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: buildLandingMetadata(string(landingState.State()), attempt, ""), Timestamp: time.Now().UTC()})
-
-					if !autoCommitDone {
-						sha, err := taskVCS.CommitAll(ctx, autoLandingCommitMessage(task))
-						if err != nil {
-							landingReason = err.Error()
-							_ = landingState.Apply(scheduler.LandingEventFailedPermanent)
-							_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: buildLandingMetadata(string(landingState.State()), attempt, landingReason), Timestamp: time.Now().UTC()})
-							landingBlocked = true
-							break
-						}
-						autoCommitDone = true
-						autoCommitSHA = strings.TrimSpace(sha)
-						if autoCommitSHA != "" {
-							_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: buildLandingMetadata(string(landingState.State()), attempt, ""), Timestamp: time.Now().UTC()})
-						}
-					}
-
-					if err := taskVCS.MergeToMain(ctx, taskBranch); err != nil {
-						landingReason = err.Error()
-						_ = landingState.Apply(scheduler.LandingEventFailedRetryable)
-						_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: buildLandingMetadata(string(landingState.State()), attempt, landingReason), Timestamp: time.Now().UTC()})
-						if attempt < 2 {
-							emitMergeQueueEvent(contracts.EventTypeMergeRetry, appendDecisionMetadata(map[string]string{
-								"landing_status":  string(landingState.State()),
-								"landing_attempt": fmt.Sprintf("%d", attempt),
-								"triage_reason":   landingReason,
-							}, "retry", landingReason))
-							if isMergeConflictError(landingReason) {
-								remediationResult := l.runLandingMergeConflictRemediation(ctx, task, taskVCS, taskBranch, worker, taskRepoRoot, queuePos, landingReason, taskRuntime)
-								if remediationResult.Status != contracts.RunnerResultCompleted {
-									remediationReason := strings.TrimSpace(remediationResult.Reason)
-									if remediationReason == "" {
-										remediationReason = "runner did not complete successfully"
-									}
-									landingReason = "merge conflict remediation failed: " + remediationReason
-									landingBlocked = true
-									break
-								}
-								autoCommitDone = false
-								autoCommitSHA = ""
-							}
-							_ = landingState.Apply(scheduler.LandingEventRequeued)
-							_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: buildLandingMetadata(string(landingState.State()), 0, ""), Timestamp: time.Now().UTC()})
-							emitMergeQueueEvent(contracts.EventTypeMergeQueued, appendDecisionMetadata(map[string]string{
-								"landing_status":  string(landingState.State()),
-								"landing_attempt": fmt.Sprintf("%d", attempt+1),
-							}, string(landingState.State()), ""))
-							continue
-						}
-						landingBlocked = true
-						break
-					}
-
-					mergeMetadata := map[string]string{}
-					if autoCommitSHA != "" {
-						mergeMetadata["auto_commit_sha"] = autoCommitSHA
-					}
-					if len(mergeMetadata) == 0 {
-						mergeMetadata = nil
-					}
-					_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeMergeCompleted, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: taskBranch, Metadata: mergeMetadata, Timestamp: time.Now().UTC()})
-					if err := taskVCS.PushMain(ctx); err != nil {
-						landingReason = err.Error()
-						_ = landingState.Apply(scheduler.LandingEventFailedPermanent)
-						_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: buildLandingMetadata(string(landingState.State()), attempt, landingReason), Timestamp: time.Now().UTC()})
-						landingBlocked = true
-						break
+			if l.options.MergeOnSuccess && l.options.Pipeline.lands() && taskVCS != nil && taskBranch != "" {
+				ticket := newLandingTicket(ctx, task, taskVCS, taskBranch, worker, taskRepoRoot, queuePos)
+				ticket.reviewVerdict = reviewVerdict
+				ticket.backend = taskBackend
+				ticket.model = implementModel
+				ticket.runtime = taskRuntime
+				ticket.workspace = workspace
+				l.emitLandingEvent(ticket, contracts.EventTypeMergeQueued, appendDecisionMetadata(map[string]string{"landing_status": ticket.status()}, ticket.status(), ""))
+				l.emitLandingData(ticket, 0, "")
+				l.enqueueLanding(ticket)
+				if ticket.blocked {
+					l.emitLandingEvent(ticket, contracts.EventTypeMergeBlocked, appendDecisionMetadata(map[string]string{
+						"landing_status": ticket.status(),
+						"triage_reason":  ticket.reason,
+					}, "blocked", ticket.reason))
+					blockedData := map[string]string{"triage_status": "blocked", "landing_status": ticket.status()}
+					if ticket.reason != "" {
+						blockedData["triage_reason"] = ticket.reason
 					}
-					pushMetadata := map[string]string{}
-					if autoCommitSHA != "" {
-						pushMetadata["auto_commit_sha"] = autoCommitSHA
+					blockedData = appendDecisionMetadata(blockedData, "blocked", ticket.reason)
+					blockedData = appendTriageCategory(blockedData, contracts.FailureCategoryMergeQueueConflict)
+					if ticket.autoCommitSHA != "" {
+						blockedData["auto_commit_sha"] = ticket.autoCommitSHA
 					}
-					if len(pushMetadata) == 0 {
-						pushMetadata = nil
-					}
-					_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypePushCompleted, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: pushMetadata, Timestamp: time.Now().UTC()})
-					_ = landingState.Apply(scheduler.LandingEventSucceeded)
-					_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: buildLandingMetadata(string(landingState.State()), 0, ""), Timestamp: time.Now().UTC()})
-					emitMergeQueueEvent(contracts.EventTypeMergeLanded, appendDecisionMetadata(map[string]string{
-						"landing_status":  string(landingState.State()),
-						"landing_attempt": fmt.Sprintf("%d", attempt),
-					}, "landed", landingReason))
-					break
-				}
-
-				if landingBlocked {
-					emitMergeQueueEvent(contracts.EventTypeMergeBlocked, appendDecisionMetadata(map[string]string{
-						"landing_status": string(landingState.State()),
-						"triage_reason":  landingReason,
-					}, "blocked", landingReason))
-					blockedData := map[string]string{"triage_status": "blocked", "landing_status": string(landingState.State())}
-					if landingReason != "" {
-						blockedData["triage_reason"] = landingReason
-					}
-					blockedData = appendDecisionMetadata(blockedData, "blocked", landingReason)
-					if autoCommitSHA != "" {
-						blockedData["auto_commit_sha"] = autoCommitSHA
+					for key, value := range ticket.triage {
+						blockedData[key] = value
 					}
 					if err := l.markTaskBlockedWithData(task.ID, blockedData); err != nil {
 						return summary, err
@@ -759,12 +1203,18 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 						return summary, err
 					}
 					finishedMetadata := map[string]string{"triage_status": "blocked"}
-					if landingReason != "" {
-						finishedMetadata["triage_reason"] = landingReason
+					if ticket.reason != "" {
+						finishedMetadata["triage_reason"] = ticket.reason
 					}
-					finishedMetadata = appendDecisionMetadata(finishedMetadata, "blocked", landingReason)
+					finishedMetadata = appendDecisionMetadata(finishedMetadata, "blocked", ticket.reason)
+					finishedMetadata = appendTriageCategory(finishedMetadata, contracts.FailureCategoryMergeQueueConflict)
+					finishedMetadata = appendAcceptanceCriteriaMetadata(finishedMetadata, acceptanceCriteria, criteriaResults)
+					for key, value := range ticket.triage {
+						finishedMetadata[key] = value
+					}
+					l.fileFollowUps(ctx, task, followUps, worker, taskRepoRoot, queuePos)
 					_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(contracts.TaskStatusBlocked), Metadata: finishedMetadata, Timestamp: time.Now().UTC()})
-					if err := l.tasks.SetTaskData(ctx, task.ID, blockedData); err != nil {
+					if err := l.setTaskData(ctx, task.ID, blockedData); err != nil {
 						return summary, err
 					}
 					_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: blockedData, Timestamp: time.Now().UTC()})
@@ -775,21 +1225,111 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 					return summary, nil
 				}
 			}
+			completedBy := buildCompletedByMetadata(taskBackend, implementModel, modelFallbackAttempts)
+			if usedModelFallback {
+				if err := l.setTaskData(ctx, task.ID, completedBy); err != nil {
+					return summary, err
+				}
+				_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: completedBy, Timestamp: time.Now().UTC()})
+			}
 			if err := l.tasks.SetTaskStatus(ctx, task.ID, contracts.TaskStatusClosed); err != nil {
 				return summary, err
 			}
 			if err := l.clearTaskTerminalState(task.ID); err != nil {
 				return summary, err
 			}
-			_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(contracts.TaskStatusClosed), Timestamp: time.Now().UTC()})
+			l.fileFollowUps(ctx, task, followUps, worker, taskRepoRoot, queuePos)
+			finishedMetadata := appendAcceptanceCriteriaMetadata(nil, acceptanceCriteria, criteriaResults)
+			if finishedMetadata == nil {
+				finishedMetadata = map[string]string{}
+			}
+			for key, value := range completedBy {
+				finishedMetadata[key] = value
+			}
+			_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(contracts.TaskStatusClosed), Metadata: finishedMetadata, Timestamp: time.Now().UTC()})
 			summary.Completed++
 			return summary, nil
 		case contracts.RunnerResultBlocked:
+			stallCategory := contracts.StallCategoryFromArtifacts(result.Artifacts)
+			stallPolicy := l.stallPolicy(result)
+			stallReason := strings.TrimSpace(result.Reason)
+			if stallReason == "" {
+				stallReason = "agent stalled (" + string(stallCategory) + ")"
+			}
+			if nudge := l.stallNudge(result); nudge != "" && !stallNudged && ctx.Err() == nil {
+				retryData := map[string]string{
+					stallNudgedMetadataKey: "true",
+					"triage_reason":        stallReason,
+					"stall_category":       string(stallCategory),
+					"stall_policy":         string(stallPolicy),
+				}
+				retryData = appendDecisionMetadata(retryData, "stall_nudge", stallReason)
+				if err := l.scheduleTaskRetry(ctx, &task, retryData, worker, taskRepoRoot, queuePos); err != nil {
+					return summary, err
+				}
+				stallNudged = true
+				pendingNudge = stallNudgeMessage(stallCategory, nudge)
+				resumeReason = stallReason
+				resumeSessionID = l.resumableSession(ctx, result)
+				continue
+			}
+			if (stallPolicy == contracts.StallPolicyRetry || stallPolicy == contracts.StallPolicyExtendTimeout) && completionRetries < taskRuntime.retryBudget && ctx.Err() == nil {
+				completionRetries++
+				completionAddendum = appendCompletionAddendum(completionAddendum, completionRetries, stallReason)
+				retryData := map[string]string{
+					"completion_retry_count": fmt.Sprintf("%d", completionRetries),
+					"completion_addendum":    completionAddendum,
+					"triage_reason":          stallReason,
+					"stall_category":         string(stallCategory),
+					"stall_policy":           string(stallPolicy),
+				}
+				decision := "retry"
+				sessionID := l.resumableSession(ctx, result)
+				if sessionID != "" {
+					decision = "resume"
+					retryData[contracts.ResumeSessionIDMetadataKey] = sessionID
+				}
+				if stallPolicy == contracts.StallPolicyExtendTimeout {
+					decision = "extend_timeout"
+					watchdogExtensions++
+					retryData[watchdogExtensionsMetadataKey] = fmt.Sprintf("%d", watchdogExtensions)
+					retryData["watchdog_timeout"] = l.watchdogTimeoutFor(watchdogExtensions).String()
+				}
+				retryData = appendDecisionMetadata(retryData, decision, stallReason)
+				if err := l.scheduleTaskRetry(ctx, &task, retryData, worker, taskRepoRoot, queuePos); err != nil {
+					return summary, err
+				}
+				resumeSessionID = sessionID
+				resumeReason = stallReason
+				continue
+			}
+			if sessionID := l.resumableSession(ctx, result); sessionID != "" && stallPolicy != contracts.StallPolicyBlock && completionRetries < taskRuntime.retryBudget {
+				interruptReason := strings.TrimSpace(result.Reason)
+				if interruptReason == "" {
+					interruptReason = "runner interrupted"
+				}
+				completionRetries++
+				completionAddendum = appendCompletionAddendum(completionAddendum, completionRetries, interruptReason)
+				retryData := map[string]string{
+					"completion_retry_count":             fmt.Sprintf("%d", completionRetries),
+					"completion_addendum":                completionAddendum,
+					"triage_reason":                      interruptReason,
+					contracts.ResumeSessionIDMetadataKey: sessionID,
+				}
+				retryData = appendDecisionMetadata(retryData, "resume", interruptReason)
+				if err := l.scheduleTaskRetry(ctx, &task, retryData, worker, taskRepoRoot, queuePos); err != nil {
+					return summary, err
+				}
+				resumeSessionID = sessionID
+				resumeReason = interruptReason
+				continue
+			}
 			blockedData := map[string]string{"triage_status": "blocked"}
 			if result.Reason != "" {
 				blockedData["triage_reason"] = result.Reason
 			}
 			blockedData = appendDecisionMetadata(blockedData, "blocked", result.Reason)
+			blockedData = appendTriageCategory(blockedData, contracts.FailureCategory(stallCategory))
 			blockedData = appendReviewOutcomeMetadata(blockedData, result)
 			if err := l.markTaskBlockedWithData(task.ID, blockedData); err != nil {
 				return summary, err
@@ -802,9 +1342,12 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 				finishedMetadata["triage_reason"] = result.Reason
 			}
 			finishedMetadata = appendDecisionMetadata(finishedMetadata, "blocked", result.Reason)
+			finishedMetadata = appendTriageCategory(finishedMetadata, contracts.FailureCategory(stallCategory))
 			finishedMetadata = appendReviewOutcomeMetadata(finishedMetadata, result)
+			finishedMetadata = appendAcceptanceCriteriaMetadata(finishedMetadata, acceptanceCriteria, criteriaResults)
+			l.fileFollowUps(ctx, task, followUps, worker, taskRepoRoot, queuePos)
 			_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(contracts.TaskStatusBlocked), Metadata: finishedMetadata, Timestamp: time.Now().UTC()})
-			if err := l.tasks.SetTaskData(ctx, task.ID, blockedData); err != nil {
+			if err := l.setTaskData(ctx, task.ID, blockedData); err != nil {
 				return summary, err
 			}
 			_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: blockedData, Timestamp: time.Now().UTC()})
@@ -814,24 +1357,41 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 			summary.Blocked++
 			return summary, nil
 		case contracts.RunnerResultFailed:
-			if !reviewFailed && !usedModelFallback && shouldUseModelFallbackForFailure(result, implementModel, fallbackModel) {
+			if !reviewFailed && modelFallbackAttempts < len(modelFallbacks) && isRecoverableModelFailureReason(result.Reason) {
+				next := modelFallbacks[modelFallbackAttempts]
+				modelFallbackAttempts++
 				usedModelFallback = true
 				modelFallbackReason = strings.TrimSpace(result.Reason)
 				modelBeforeFallback = implementModel
-				implementModel = fallbackModel
+				backendBeforeFallback = taskBackend
+				implementModel = next.Model
+				taskBackend = next.Backend
 				continue
 			}
 
 			reviewFail := reviewFailed || isReviewFailResult(result)
 			if reviewFail {
 				feedback := strings.TrimSpace(reviewFailFeedbackFromArtifacts(result))
+				if failedReviewPhase != "" && feedback != "" {
+					feedback = reviewPhaseLabel(failedReviewPhase) + ": " + feedback
+				}
 				if feedback == "" {
 					feedback = strings.TrimSpace(result.Reason)
 				}
 				reviewRetryFeedback = feedback
-				if reviewRetries < l.options.MaxRetries {
+				// Each review phase has its own retry budget.
+				retriesUsed := reviewRetries
+				if failedReviewPhase != "" {
+					retriesUsed = reviewPhaseRetries[failedReviewPhase]
+				}
+				if retriesUsed < taskRuntime.retryBudget {
 					reviewRetries++
 					retryData := map[string]string{"review_retry_count": fmt.Sprintf("%d", reviewRetries)}
+					if failedReviewPhase != "" {
+						reviewPhaseRetries[failedReviewPhase]++
+						retryData = appendReviewPhaseMetadata(retryData, failedReviewPhase, reviewPhaseRetries[failedReviewPhase])
+						retryData = appendReviewPhaseOutcomeMetadata(retryData, failedReviewPhase, result)
+					}
 					if reviewRetryFeedback != "" {
 						retryData["review_feedback"] = reviewRetryFeedback
 					}
@@ -840,7 +1400,7 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 						retryData["triage_reason"] = strings.TrimSpace(result.Reason)
 					}
 					retryData = appendDecisionMetadata(retryData, "retry", result.Reason)
-					if err := l.tasks.SetTaskData(ctx, task.ID, retryData); err != nil {
+					if err := l.setTaskData(ctx, task.ID, retryData); err != nil {
 						return summary, err
 					}
 					if task.Metadata == nil {
@@ -862,7 +1422,7 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 				if completionReason == "" {
 					completionReason = "implementation completion failed"
 				}
-				if completionRetries < l.options.MaxRetries {
+				if completionRetries < taskRuntime.retryBudget {
 					completionRetries++
 					completionAddendum = appendCompletionAddendum(completionAddendum, completionRetries, completionReason)
 					retryData := map[string]string{"completion_retry_count": fmt.Sprintf("%d", completionRetries)}
@@ -870,19 +1430,16 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 					retryData = appendDecisionMetadata(retryData, "retry", completionReason)
 					retryData = appendReviewOutcomeMetadata(retryData, result)
 					retryData["triage_reason"] = completionReason
-					if err := l.tasks.SetTaskData(ctx, task.ID, retryData); err != nil {
-						return summary, err
-					}
-					if task.Metadata == nil {
-						task.Metadata = map[string]string{}
+					sessionID := l.resumableSession(ctx, result)
+					if sessionID != "" {
+						retryData = appendDecisionMetadata(retryData, "resume", completionReason)
+						retryData[contracts.ResumeSessionIDMetadataKey] = sessionID
 					}
-					for key, value := range retryData {
-						task.Metadata[key] = value
-					}
-					_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: retryData, Timestamp: time.Now().UTC()})
-					if err := l.tasks.SetTaskStatus(ctx, task.ID, contracts.TaskStatusOpen); err != nil {
+					if err := l.scheduleTaskRetry(ctx, &task, retryData, worker, taskRepoRoot, queuePos); err != nil {
 						return summary, err
 					}
+					resumeSessionID = sessionID
+					resumeReason = completionReason
 					continue
 				}
 
@@ -894,6 +1451,7 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 					"triage_reason":          completionReason,
 				}
 				blockedData = appendDecisionMetadata(blockedData, "blocked", completionReason)
+				blockedData = appendTriageCategory(blockedData, "")
 				blockedData = appendReviewOutcomeMetadata(blockedData, result)
 				if err := l.markTaskBlockedWithData(task.ID, blockedData); err != nil {
 					return summary, err
@@ -908,8 +1466,11 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 					"completion_addendum":    completionAddendum,
 				}
 				finishedMetadata = appendDecisionMetadata(finishedMetadata, "blocked", completionReason)
+				finishedMetadata = appendTriageCategory(finishedMetadata, "")
+				finishedMetadata = appendAcceptanceCriteriaMetadata(finishedMetadata, acceptanceCriteria, criteriaResults)
+				l.fileFollowUps(ctx, task, followUps, worker, taskRepoRoot, queuePos)
 				_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(contracts.TaskStatusBlocked), Metadata: finishedMetadata, Timestamp: time.Now().UTC()})
-				if err := l.tasks.SetTaskData(ctx, task.ID, blockedData); err != nil {
+				if err := l.setTaskData(ctx, task.ID, blockedData); err != nil {
 					return summary, err
 				}
 				_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: blockedData, Timestamp: time.Now().UTC()})
@@ -925,11 +1486,16 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 				failedData["triage_reason"] = result.Reason
 			}
 			failedData = appendDecisionMetadata(failedData, "failed", result.Reason)
+			failedData = appendTriageCategory(failedData, contracts.FailureCategoryReviewGating)
 			if reviewFail || reviewRetries > 0 {
 				failedData["review_retry_count"] = fmt.Sprintf("%d", reviewRetries)
 			}
 			failedData = appendReviewOutcomeMetadata(failedData, result)
-			if err := l.tasks.SetTaskData(ctx, task.ID, failedData); err != nil {
+			if failedReviewPhase != "" {
+				failedData = appendReviewPhaseMetadata(failedData, failedReviewPhase, reviewPhaseRetries[failedReviewPhase])
+				failedData = appendReviewPhaseOutcomeMetadata(failedData, failedReviewPhase, result)
+			}
+			if err := l.setTaskData(ctx, task.ID, failedData); err != nil {
 				return summary, err
 			}
 			_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: failedData, Timestamp: time.Now().UTC()})
@@ -944,7 +1510,14 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 				finishedMetadata["triage_reason"] = result.Reason
 			}
 			finishedMetadata = appendDecisionMetadata(finishedMetadata, "failed", result.Reason)
+			finishedMetadata = appendTriageCategory(finishedMetadata, contracts.FailureCategoryReviewGating)
 			finishedMetadata = appendReviewOutcomeMetadata(finishedMetadata, result)
+			finishedMetadata = appendAcceptanceCriteriaMetadata(finishedMetadata, acceptanceCriteria, criteriaResults)
+			l.fileFollowUps(ctx, task, followUps, worker, taskRepoRoot, queuePos)
+			if reviewFail {
+				followUps = mergeFollowUps(followUps, []contracts.FollowUpItem{reviewFollowUp(task, reviewRetryFeedback)})
+			}
+			l.fileFollowUps(ctx, task, followUps, worker, taskRepoRoot, queuePos)
 			_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(contracts.TaskStatusFailed), Metadata: finishedMetadata, Timestamp: time.Now().UTC()})
 			summary.Failed++
 			return summary, nil
@@ -954,8 +1527,9 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 				failedData["triage_reason"] = result.Reason
 			}
 			failedData = appendDecisionMetadata(failedData, "failed", result.Reason)
+			failedData = appendTriageCategory(failedData, "")
 			failedData = appendReviewOutcomeMetadata(failedData, result)
-			if err := l.tasks.SetTaskData(ctx, task.ID, failedData); err != nil {
+			if err := l.setTaskData(ctx, task.ID, failedData); err != nil {
 				return summary, err
 			}
 			_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: failedData, Timestamp: time.Now().UTC()})
@@ -970,7 +1544,9 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 				finishedMetadata["triage_reason"] = result.Reason
 			}
 			finishedMetadata = appendDecisionMetadata(finishedMetadata, "failed", result.Reason)
+			finishedMetadata = appendTriageCategory(finishedMetadata, "")
 			finishedMetadata = appendReviewOutcomeMetadata(finishedMetadata, result)
+			finishedMetadata = appendAcceptanceCriteriaMetadata(finishedMetadata, acceptanceCriteria, criteriaResults)
 			_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(contracts.TaskStatusFailed), Metadata: finishedMetadata, Timestamp: time.Now().UTC()})
 			summary.Failed++
 			return summary, nil
@@ -978,6 +1554,24 @@ func (l *Loop) runTask(ctx context.Context, taskID string, workerID int, queuePo
 	}
 }
 
+// blockTask records blockedData on the task, marks it blocked and emits the
+// task_finished and task_data_updated events for a task that ends before
+// landing.
+func (l *Loop) blockTask(ctx context.Context, task contracts.Task, worker string, queuePos int, clonePath string, blockedData map[string]string) error {
+	if err := l.markTaskBlockedWithData(task.ID, blockedData); err != nil {
+		return err
+	}
+	if err := l.tasks.SetTaskStatus(ctx, task.ID, contracts.TaskStatusBlocked); err != nil {
+		return err
+	}
+	_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: clonePath, QueuePos: queuePos, Message: string(contracts.TaskStatusBlocked), Metadata: blockedData, Timestamp: time.Now().UTC()})
+	if err := l.setTaskData(ctx, task.ID, blockedData); err != nil {
+		return err
+	}
+	_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: clonePath, QueuePos: queuePos, Metadata: blockedData, Timestamp: time.Now().UTC()})
+	return l.clearTaskTerminalState(task.ID)
+}
+
 func (l *Loop) vcsForRepo(repoRoot string) contracts.VCS {
 	if l == nil {
 		return nil
@@ -1000,6 +1594,10 @@ func eventTypeForRunnerProgress(progressType string) contracts.EventType {
 		return contracts.EventTypeRunnerOutput
 	case "runner_warning":
 		return contracts.EventTypeRunnerWarning
+	case "runner_permission":
+		return contracts.EventTypeRunnerPermission
+	case "plan_updated":
+		return contracts.EventTypePlanUpdated
 	default:
 		return contracts.EventTypeRunnerProgress
 	}
@@ -1032,13 +1630,69 @@ func cloneStringMap(input map[string]string) map[string]string {
 }
 
 func (l *Loop) emit(ctx context.Context, event contracts.Event) error {
+	event = l.maskEventSecrets(l.withTaskArtifacts(event))
+	if event.RunID == "" {
+		event.RunID = l.options.RunID
+	}
 	if l.events == nil {
 		return nil
 	}
 	return l.events.Emit(ctx, event)
 }
 
+// resumableSession returns the backend session an interrupted implement run
+// left behind, or "" when resume_sessions is off or there is nothing to resume.
+func (l *Loop) resumableSession(ctx context.Context, result contracts.RunnerResult) string {
+	if !l.options.ResumeSessions || ctx.Err() != nil || result.Artifacts == nil {
+		return ""
+	}
+	if mode := strings.TrimSpace(result.Artifacts["mode"]); mode != "" && mode != string(contracts.RunnerModeImplement) {
+		return ""
+	}
+	return strings.TrimSpace(result.Artifacts[contracts.SessionIDArtifactKey])
+}
+
+// scheduleTaskRetry stores retry data on the task and reopens it for the next
+// implement pass.
+func (l *Loop) scheduleTaskRetry(ctx context.Context, task *contracts.Task, retryData map[string]string, worker string, taskRepoRoot string, queuePos int) error {
+	if err := l.setTaskData(ctx, task.ID, retryData); err != nil {
+		return err
+	}
+	if task.Metadata == nil {
+		task.Metadata = map[string]string{}
+	}
+	for key, value := range retryData {
+		task.Metadata[key] = value
+	}
+	_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Metadata: retryData, Timestamp: time.Now().UTC()})
+	return l.tasks.SetTaskStatus(ctx, task.ID, contracts.TaskStatusOpen)
+}
+
+// runRunnerWithMonitoring runs the request and, when rate-limit backoff is
+// enabled, waits out the shared backoff and reruns it while the provider keeps
+// throttling.
 func (l *Loop) runRunnerWithMonitoring(ctx context.Context, request contracts.RunnerRequest, taskID string, taskTitle string, worker string, clonePath string, queuePos int) (contracts.RunnerResult, error) {
+	for attempt := 1; ; attempt++ {
+		if err := sleepContext(ctx, l.rateLimitPause()); err != nil {
+			return contracts.RunnerResult{}, err
+		}
+		result, err := l.runMonitoredRunner(ctx, request, taskID, taskTitle, worker, clonePath, queuePos)
+		result = l.maskRunnerResultSecrets(result)
+		if err != nil || l.options.RateLimitBackoff <= 0 {
+			return result, err
+		}
+		if !isRateLimitedResult(result) {
+			l.rateLimit.reset()
+			return result, nil
+		}
+		if attempt > maxRateLimitRetries || ctx.Err() != nil {
+			return result, nil
+		}
+		l.tripRateLimit(ctx, request, result, attempt, taskTitle, worker, clonePath, queuePos)
+	}
+}
+
+func (l *Loop) runMonitoredRunner(ctx context.Context, request contracts.RunnerRequest, taskID string, taskTitle string, worker string, clonePath string, queuePos int) (contracts.RunnerResult, error) {
 	heartbeatInterval := l.options.HeartbeatInterval
 	if heartbeatInterval <= 0 {
 		heartbeatInterval = 5 * time.Second
@@ -1164,21 +1818,31 @@ func (l *Loop) runLandingMergeConflictRemediation(ctx context.Context, task cont
 	if l.options.WatchdogInterval > 0 {
 		remediationMetadata["watchdog_interval"] = l.options.WatchdogInterval.String()
 	}
+	contracts.SetWatchdogHooks(remediationMetadata, l.options.WatchdogHooks, l.options.WatchdogHookTimeout)
+
+	remediationPrompt, err := l.renderMergeConflictRemediationPrompt(task, l.promptRepoContext(taskRepoRoot, runtimeBackend, runtimeModel), taskBranch, mergeFailureReason)
+	if err != nil {
+		return contracts.RunnerResult{Status: contracts.RunnerResultFailed, Reason: err.Error()}
+	}
 
 	result, err := l.runRunnerWithMonitoring(ctx, contracts.RunnerRequest{
-		TaskID:   task.ID,
-		ParentID: l.options.ParentID,
-		Mode:     contracts.RunnerModeImplement,
-		RepoRoot: taskRepoRoot,
-		Model:    runtimeModel,
-		Timeout:  runtime.timeout,
-		Prompt:   buildMergeConflictRemediationPrompt(task, taskBranch, mergeFailureReason),
-		Metadata: remediationMetadata,
+		TaskID:     task.ID,
+		ParentID:   l.options.ParentID,
+		Mode:       contracts.RunnerModeImplement,
+		RepoRoot:   taskRepoRoot,
+		Model:      runtimeModel,
+		Timeout:    runtime.timeout,
+		Prompt:     remediationPrompt,
+		Metadata:   remediationMetadata,
+		Env:        l.runnerEnv(task),
+		Secrets:    l.secretValues(),
+		ToolPolicy: l.options.ToolPolicy,
 	}, task.ID, task.Title, worker, taskRepoRoot, queuePos)
 	if err != nil {
 		result = contracts.RunnerResult{Status: contracts.RunnerResultFailed, Reason: err.Error()}
 	}
 
+	l.collectRunnerArtifacts(task.ID, taskRepoRoot, result)
 	_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: string(result.Status), Metadata: buildRunnerFinishedMetadata(result), Timestamp: time.Now().UTC()})
 	return result
 }
@@ -1189,9 +1853,10 @@ func resolveTaskRuntimeConfig(task contracts.Task, options LoopOptions) (taskRun
 	timeout := options.RunnerTimeout
 
 	taskRuntime := taskRuntimeConfig{
-		backend: backend,
-		model:   model,
-		timeout: timeout,
+		backend:  backend,
+		model:    model,
+		timeout:  timeout,
+		executor: options.WorkerAffinity.executorFor(task),
 	}
 
 	overrides, hasOverrides, err := tk.ParseTicketFrontmatterFromDescription(task.Description)
@@ -1260,6 +1925,13 @@ func appendTaskRuntimeMetadata(metadata map[string]string, runtime taskRuntimeCo
 		metadata["task_mode"] = strings.TrimSpace(strings.ToLower(runtime.mode))
 		metadata["runtime_mode"] = strings.TrimSpace(strings.ToLower(runtime.mode))
 	}
+	if runtime.executor != "" {
+		metadata[ExecutorMetadataKey] = runtime.executor
+	}
+	if runtime.retryBudgetSource != "" {
+		metadata["runtime_retry_budget"] = strconv.Itoa(runtime.retryBudget)
+		metadata["retry_budget_source"] = runtime.retryBudgetSource
+	}
 	return compactMetadata(metadata)
 }
 
@@ -1325,6 +1997,9 @@ func buildPrompt(task contracts.Task, mode contracts.RunnerMode, tddMode bool) s
 			sections = append(sections, strings.Join(retrySection, "\n"))
 		}
 	}
+	if criteriaSection := buildAcceptanceCriteriaSection(parseAcceptanceCriteria(task.Description), mode); criteriaSection != "" {
+		sections = append(sections, criteriaSection)
+	}
 	if strings.TrimSpace(task.Description) != "" {
 		sections = append(sections, "Description:\n"+task.Description)
 	}
@@ -1505,6 +2180,7 @@ func (l *Loop) runQualityGate(ctx context.Context, task contracts.Task, worker s
 			blockedData[key] = value
 		}
 		blockedData = appendDecisionMetadata(blockedData, "blocked", qualityGateReason)
+		blockedData = appendTriageCategory(blockedData, contracts.FailureCategoryQualityGate)
 		if err := l.markTaskBlockedWithData(task.ID, blockedData); err != nil {
 			return false, err
 		}
@@ -1520,6 +2196,7 @@ func (l *Loop) runQualityGate(ctx context.Context, task contracts.Task, worker s
 			"quality_gate":      "true",
 		}
 		finishedMetadata = appendDecisionMetadata(finishedMetadata, "blocked", qualityGateReason)
+		finishedMetadata = appendTriageCategory(finishedMetadata, contracts.FailureCategoryQualityGate)
 		_ = l.emit(ctx, contracts.Event{
 			Type:      contracts.EventTypeTaskFinished,
 			TaskID:    task.ID,
@@ -1531,7 +2208,7 @@ func (l *Loop) runQualityGate(ctx context.Context, task contracts.Task, worker s
 			Metadata:  finishedMetadata,
 			Timestamp: time.Now().UTC(),
 		})
-		if err := l.tasks.SetTaskData(ctx, task.ID, blockedData); err != nil {
+		if err := l.setTaskData(ctx, task.ID, blockedData); err != nil {
 			return false, err
 		}
 		_ = l.emit(ctx, contracts.Event{
@@ -1600,6 +2277,7 @@ func (l *Loop) runQualityGate(ctx context.Context, task contracts.Task, worker s
 		"quality_gate_comment": qualityComment,
 	}
 	blockedData = appendDecisionMetadata(blockedData, "blocked", qualityGateReason)
+	blockedData = appendTriageCategory(blockedData, contracts.FailureCategoryQualityGate)
 	if err := l.markTaskBlockedWithData(task.ID, blockedData); err != nil {
 		return false, err
 	}
@@ -1614,6 +2292,7 @@ func (l *Loop) runQualityGate(ctx context.Context, task contracts.Task, worker s
 		"quality_gate":      "true",
 	}
 	finishedMetadata = appendDecisionMetadata(finishedMetadata, "blocked", qualityGateReason)
+	finishedMetadata = appendTriageCategory(finishedMetadata, contracts.FailureCategoryQualityGate)
 	_ = l.emit(ctx, contracts.Event{
 		Type:      contracts.EventTypeTaskFinished,
 		TaskID:    task.ID,
@@ -1625,7 +2304,7 @@ func (l *Loop) runQualityGate(ctx context.Context, task contracts.Task, worker s
 		Metadata:  finishedMetadata,
 		Timestamp: time.Now().UTC(),
 	})
-	if err := l.tasks.SetTaskData(ctx, task.ID, blockedData); err != nil {
+	if err := l.setTaskData(ctx, task.ID, blockedData); err != nil {
 		return false, err
 	}
 	_ = l.emit(ctx, contracts.Event{
@@ -1653,6 +2332,9 @@ type qcGateToolResult struct {
 	Threshold int    `json:"threshold,omitempty"`
 	Command   string `json:"command,omitempty"`
 	Critical  bool   `json:"critical,omitempty"`
+	// Attempts counts the runs of a tool rerun after failing.
+	Attempts   int      `json:"attempts,omitempty"`
+	FlakyTests []string `json:"flaky_tests,omitempty"`
 }
 
 type qcGateReport struct {
@@ -1746,10 +2428,13 @@ func (l *Loop) runQCGate(ctx context.Context, task contracts.Task, result contra
 		if strings.TrimSpace(outcome.Reason) != "" {
 			qcMetadata[keyPrefix+"_reason"] = outcome.Reason
 		}
+		if len(outcome.FlakyTests) > 0 {
+			qcMetadata[qcFlakyTestsMetadataKey] = strings.Join(outcome.FlakyTests, ",")
+		}
 	}
 
 	if len(failed) == 0 {
-		if err := l.tasks.SetTaskData(ctx, task.ID, qcMetadata); err != nil {
+		if err := l.setTaskData(ctx, task.ID, qcMetadata); err != nil {
 			return false, err
 		}
 		_ = l.emit(ctx, contracts.Event{
@@ -1776,6 +2461,7 @@ func (l *Loop) runQCGate(ctx context.Context, task contracts.Task, result contra
 		"qc_gate_report":    string(reportJSON),
 	}
 	blockedData = appendDecisionMetadata(blockedData, "blocked", blockedReason)
+	blockedData = appendTriageCategory(blockedData, contracts.FailureCategoryQualityGate)
 	for key, value := range qcMetadata {
 		blockedData[key] = value
 	}
@@ -1794,6 +2480,7 @@ func (l *Loop) runQCGate(ctx context.Context, task contracts.Task, result contra
 		"qc_gate_tools":     strings.Join(tools, ","),
 	}
 	finishedMetadata = appendDecisionMetadata(finishedMetadata, "blocked", blockedReason)
+	finishedMetadata = appendTriageCategory(finishedMetadata, contracts.FailureCategoryQualityGate)
 	for key, value := range qcMetadata {
 		finishedMetadata[key] = value
 	}
@@ -1808,7 +2495,7 @@ func (l *Loop) runQCGate(ctx context.Context, task contracts.Task, result contra
 		Metadata:  finishedMetadata,
 		Timestamp: time.Now().UTC(),
 	})
-	if err := l.tasks.SetTaskData(ctx, task.ID, blockedData); err != nil {
+	if err := l.setTaskData(ctx, task.ID, blockedData); err != nil {
 		return false, err
 	}
 	_ = l.emit(ctx, contracts.Event{
@@ -1846,6 +2533,27 @@ func (l *Loop) runQCTestSuiteValidation(ctx context.Context, repoRoot string) qc
 		if result.Reason == "" {
 			result.Reason = "test suite returned non-zero status"
 		}
+		attempts := [][]string{failedGoTests(output)}
+		for rerun := 0; rerun < l.options.QCGateTestReruns && err != nil; rerun++ {
+			output, err = runQCGateCommand(ctx, repoRoot, "go", "test", "./...")
+			attempts = append(attempts, failedGoTests(output))
+		}
+		if len(attempts) == 1 {
+			return result
+		}
+		result.Attempts = len(attempts)
+		consistent, flaky := classifyFlakyTests(attempts)
+		result.FlakyTests = flaky
+		if err == nil {
+			result.Passed = true
+			result.Status = "flaky"
+			result.Value = "flaky"
+			result.Reason = ""
+			return result
+		}
+		if len(consistent) > 0 {
+			result.Reason = fmt.Sprintf("tests failed on all %d attempts: %s", len(attempts), limitedList(consistent))
+		}
 		return result
 	}
 	result.Critical = true
@@ -2401,18 +3109,29 @@ func buildImplementPrompt(task contracts.Task, reviewFeedback string, reviewRetr
 	return prompt
 }
 
-func buildMergeConflictRemediationPrompt(task contracts.Task, taskBranch string, mergeFailureReason string) string {
+func buildMergeConflictRemediationPrompt(task contracts.Task, taskBranch string, mergeFailureReason string, strategy LandingStrategy) string {
 	base := buildImplementPrompt(task, "", 0, "", 0, false)
+	instructions := []string{
+		"Landing Merge Remediation:",
+		"- Auto-landing failed while merging the task branch into main.",
+		"- Resolve merge conflicts on the task branch so merge-to-main can succeed.",
+		"- Keep accepted behavior intact; do not discard required changes.",
+		"- Run relevant tests after conflict resolution.",
+		"- Commit conflict-resolution changes on the task branch.",
+	}
+	if strategy == LandingStrategyRebaseFF {
+		instructions = []string{
+			"Landing Rebase Remediation:",
+			"- Auto-landing failed while rebasing the task branch onto main; main is fast-forwarded, so history must stay linear.",
+			"- Run `git rebase main` on the task branch, resolve each conflict, `git add` the files and `git rebase --continue` until the rebase completes.",
+			"- Do not merge main into the task branch and do not create merge commits.",
+			"- Keep accepted behavior intact; do not discard required changes.",
+			"- Run relevant tests after the rebase.",
+		}
+	}
 	sections := []string{
 		base,
-		strings.Join([]string{
-			"Landing Merge Remediation:",
-			"- Auto-landing failed while merging the task branch into main.",
-			"- Resolve merge conflicts on the task branch so merge-to-main can succeed.",
-			"- Keep accepted behavior intact; do not discard required changes.",
-			"- Run relevant tests after conflict resolution.",
-			"- Commit conflict-resolution changes on the task branch.",
-		}, "\n"),
+		strings.Join(instructions, "\n"),
 	}
 	if strings.TrimSpace(taskBranch) != "" {
 		sections = append(sections, "Target Branch: "+strings.TrimSpace(taskBranch))
@@ -2428,7 +3147,7 @@ func isMergeConflictError(reason string) bool {
 	if lower == "" {
 		return false
 	}
-	for _, needle := range []string{"automatic merge failed", "merge conflict", "conflict (", "needs merge"} {
+	for _, needle := range []string{"automatic merge failed", "merge conflict", "conflict (", "needs merge", "could not apply"} {
 		if strings.Contains(lower, needle) {
 			return true
 		}
@@ -2451,10 +3170,6 @@ func isReviewFailResult(result contracts.RunnerResult) bool {
 		strings.Contains(lower, "failing acceptance criteria")
 }
 
-func shouldUseModelFallbackForFailure(result contracts.RunnerResult, currentModel string, fallbackModel string) bool {
-	return isRecoverableModelFailureResult(result, currentModel, fallbackModel)
-}
-
 func isRecoverableModelFailureReason(reason string) bool {
 	text := strings.ToLower(strings.TrimSpace(reason))
 	if text == "" {
@@ -2510,8 +3225,17 @@ func isRecoverableModelFailureReason(reason string) bool {
 	return false
 }
 
-func isRecoverableModelFailureResult(result contracts.RunnerResult, currentModel string, fallbackModel string) bool {
-	return isRecoverableModelFailureReason(result.Reason) && strings.TrimSpace(currentModel) != "" && strings.TrimSpace(fallbackModel) != "" && !strings.EqualFold(strings.TrimSpace(currentModel), strings.TrimSpace(fallbackModel))
+// mergeTaskBranch merges taskBranch onto main, with the merge commit message
+// from CommitMessages when one is configured and the VCS supports it.
+func (l *Loop) mergeTaskBranch(ctx context.Context, vcs contracts.VCS, task contracts.Task, taskBranch string, reviewVerdict string, backend string, model string) error {
+	message, templated, err := l.options.CommitMessages.Merge(task, taskBranch, reviewVerdict, backend, model)
+	if err != nil {
+		return err
+	}
+	if merger, ok := vcs.(contracts.MessageMerger); ok && templated {
+		return merger.MergeToMainWithMessage(ctx, taskBranch, message)
+	}
+	return vcs.MergeToMain(ctx, taskBranch)
 }
 
 func autoLandingCommitMessage(task contracts.Task) string {
@@ -2573,6 +3297,10 @@ func runnerLogBackendDir(backend string) string {
 		return "claude"
 	case "kimi":
 		return "kimi"
+	case "qwen":
+		return "qwen"
+	case "opencode-server", "ollama", "amazonq":
+		return strings.TrimSpace(strings.ToLower(backend))
 	default:
 		return "opencode"
 	}
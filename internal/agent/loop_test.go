@@ -2230,6 +2230,250 @@ func (f *fakeTaskManager) SetTaskData(_ context.Context, taskID string, data map
 	return nil
 }
 
+func TestLoopResumesInterruptedSessionWhenEnabled(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultBlocked, Reason: "runner timed out after 10m0s", Artifacts: map[string]string{"mode": "implement", contracts.SessionIDArtifactKey: "sess-1"}},
+		{Status: contracts.RunnerResultCompleted},
+	}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", MaxRetries: 1, ResumeSessions: true})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 {
+		t.Fatalf("expected resumed run to complete, got %#v", summary)
+	}
+	if len(run.requests) != 2 {
+		t.Fatalf("expected interrupted and resumed requests, got %d", len(run.requests))
+	}
+	if _, _, ok := contracts.ResumeSessionFromRequest(run.requests[0]); ok {
+		t.Fatalf("did not expect first request to resume, got %#v", run.requests[0].Metadata)
+	}
+	sessionID, prompt, ok := contracts.ResumeSessionFromRequest(run.requests[1])
+	if !ok || sessionID != "sess-1" {
+		t.Fatalf("expected resumed request for sess-1, got %#v", run.requests[1].Metadata)
+	}
+	if !strings.Contains(prompt, "runner timed out after 10m0s") {
+		t.Fatalf("expected resume prompt to name the interruption, got %q", prompt)
+	}
+	if got := mgr.dataByID["t-1"][contracts.ResumeSessionIDMetadataKey]; got != "sess-1" {
+		t.Fatalf("expected resume_session_id task data, got %q", got)
+	}
+	resumedStart := false
+	for _, event := range sink.events {
+		if event.Type == contracts.EventTypeRunnerStarted && event.Metadata["decision"] == "resume" && event.Metadata[contracts.ResumeSessionIDMetadataKey] == "sess-1" {
+			resumedStart = true
+		}
+	}
+	if !resumedStart {
+		t.Fatalf("expected runner_started with resume decision, got %#v", sink.events)
+	}
+}
+
+func TestLoopDoesNotResumeSessionsWhenDisabled(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultFailed, Reason: "codex exited: signal: killed", Artifacts: map[string]string{"mode": "implement", contracts.SessionIDArtifactKey: "thread-1"}},
+		{Status: contracts.RunnerResultBlocked, Reason: "runner timed out", Artifacts: map[string]string{"mode": "implement", contracts.SessionIDArtifactKey: "thread-2"}},
+	}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", MaxRetries: 1})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || len(run.requests) != 2 {
+		t.Fatalf("expected one completion retry then blocked, got %#v after %d requests", summary, len(run.requests))
+	}
+	if _, _, ok := contracts.ResumeSessionFromRequest(run.requests[1]); ok {
+		t.Fatalf("did not expect resume metadata, got %#v", run.requests[1].Metadata)
+	}
+	if !strings.Contains(run.requests[1].Prompt, "Completion Remediation Loop: Attempt 1") {
+		t.Fatalf("expected full implement prompt on retry, got %q", run.requests[1].Prompt)
+	}
+}
+
+func TestLoopNudgesQuestionStallOnceBeforeBlocking(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	stalled := contracts.RunnerResult{Status: contracts.RunnerResultBlocked, Reason: "opencode stall category=question", Artifacts: map[string]string{"mode": "implement", "stall_category": "question"}}
+	run := &fakeRunner{results: []contracts.RunnerResult{stalled, stalled}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", MaxRetries: 2, StallNudgePrompt: DefaultStallNudgePrompt})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || len(run.requests) != 2 {
+		t.Fatalf("expected one nudge then blocked, got %#v after %d requests", summary, len(run.requests))
+	}
+	if strings.Contains(run.requests[0].Prompt, "Stall Auto-Response") {
+		t.Fatalf("did not expect nudge in first prompt")
+	}
+	if !strings.Contains(run.requests[1].Prompt, "Stall Auto-Response") || !strings.Contains(run.requests[1].Prompt, DefaultStallNudgePrompt) {
+		t.Fatalf("expected nudge in retry prompt, got %q", run.requests[1].Prompt)
+	}
+	if got := mgr.dataByID["t-1"]["completion_retry_count"]; got != "" {
+		t.Fatalf("expected nudge not to spend the completion retry budget, got %q", got)
+	}
+	if got := mgr.dataByID["t-1"][stallNudgedMetadataKey]; got != "true" {
+		t.Fatalf("expected stall_nudged task data, got %q", got)
+	}
+	nudged := false
+	for _, event := range sink.events {
+		if event.Type == contracts.EventTypeRunnerStarted && event.Metadata["decision"] == "stall_nudge" {
+			nudged = true
+		}
+	}
+	if !nudged {
+		t.Fatalf("expected runner_started with stall_nudge decision")
+	}
+}
+
+func TestLoopSendsStallNudgeToResumedSession(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultBlocked, Reason: "stalled", Artifacts: map[string]string{"stall_category": "question", contracts.SessionIDArtifactKey: "ses_1"}},
+		{Status: contracts.RunnerResultCompleted},
+	}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", ResumeSessions: true, StallNudgePrompt: "pick option A"})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 {
+		t.Fatalf("expected nudged run to complete, got %#v", summary)
+	}
+	sessionID, prompt, ok := contracts.ResumeSessionFromRequest(run.requests[1])
+	if !ok || sessionID != "ses_1" || !strings.Contains(prompt, "pick option A") {
+		t.Fatalf("expected nudge sent to resumed session, got %q %q %v", sessionID, prompt, ok)
+	}
+}
+
+func TestLoopDoesNotNudgeQuestionStallWhenDisabled(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultBlocked, Reason: "stalled", Artifacts: map[string]string{"stall_category": "question"}},
+	}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", MaxRetries: 2})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || len(run.requests) != 1 {
+		t.Fatalf("expected immediate block without nudge, got %#v after %d requests", summary, len(run.requests))
+	}
+}
+
+func TestLoopRetriesRateLimitStallWithRetryPolicy(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultBlocked, Reason: "opencode stall category=rate_limit", Artifacts: map[string]string{"stall_category": "rate_limit"}},
+		{Status: contracts.RunnerResultCompleted},
+	}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", MaxRetries: 2, StallPolicies: map[contracts.StallCategory]contracts.StallPolicy{
+		contracts.StallCategoryRateLimit: contracts.StallPolicyRetry,
+	}})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 || len(run.requests) != 2 {
+		t.Fatalf("expected retried run to complete, got %#v after %d requests", summary, len(run.requests))
+	}
+	data := mgr.dataByID["t-1"]
+	if data["completion_retry_count"] != "1" || data["stall_policy"] != "retry" || data["decision"] != "retry" {
+		t.Fatalf("expected retry policy recorded in task data, got %#v", data)
+	}
+}
+
+func TestLoopExtendsWatchdogTimeoutForSilenceStall(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	silent := contracts.RunnerResult{Status: contracts.RunnerResultBlocked, Reason: "opencode stall category=no_output", Artifacts: map[string]string{"stall_category": "silence", "stall_detail": "no_output"}}
+	run := &fakeRunner{results: []contracts.RunnerResult{silent, silent, {Status: contracts.RunnerResultCompleted}}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", MaxRetries: 2, WatchdogTimeout: 5 * time.Minute, StallPolicies: map[contracts.StallCategory]contracts.StallPolicy{
+		contracts.StallCategorySilence: contracts.StallPolicyExtendTimeout,
+	}})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 || len(run.requests) != 3 {
+		t.Fatalf("expected two extensions then completion, got %#v after %d requests", summary, len(run.requests))
+	}
+	for i, want := range []string{"5m0s", "10m0s", "20m0s"} {
+		if got := run.requests[i].Metadata["watchdog_timeout"]; got != want {
+			t.Fatalf("request %d: expected watchdog_timeout %q, got %q", i, want, got)
+		}
+	}
+	if got := mgr.dataByID["t-1"][watchdogExtensionsMetadataKey]; got != "2" {
+		t.Fatalf("expected watchdog extensions in task data, got %q", got)
+	}
+}
+
+func TestLoopPassesWatchdogHooksToRunner(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	hooks := []string{"kill -QUIT $YOLO_WATCHDOG_PID"}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", WatchdogHooks: hooks, WatchdogHookTimeout: 30 * time.Second})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if len(run.requests) != 1 {
+		t.Fatalf("expected one runner request, got %d", len(run.requests))
+	}
+	got, timeout := contracts.WatchdogHooksFromMetadata(run.requests[0].Metadata)
+	if len(got) != 1 || got[0] != hooks[0] || timeout != 30*time.Second {
+		t.Fatalf("expected watchdog hooks in request metadata, got %q with %s", got, timeout)
+	}
+}
+
+func TestLoopBlockPolicySkipsSessionResume(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultBlocked, Reason: "stalled", Artifacts: map[string]string{"mode": "implement", "stall_category": "waiting_on_tool", contracts.SessionIDArtifactKey: "ses_1"}},
+	}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", MaxRetries: 2, ResumeSessions: true, StallPolicies: map[contracts.StallCategory]contracts.StallPolicy{
+		contracts.StallCategoryWaitingOnTool: contracts.StallPolicyBlock,
+	}})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || len(run.requests) != 1 {
+		t.Fatalf("expected block policy to block immediately, got %#v after %d requests", summary, len(run.requests))
+	}
+	if got := mgr.dataByID["t-1"][contracts.TriageCategoryMetadataKey]; got != string(contracts.FailureCategoryWaitingOnTool) {
+		t.Fatalf("expected the stall category as triage category, got %q", got)
+	}
+}
+
+func TestLoopRetryPolicyBlocksWhenRetryBudgetIsSpent(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	stalled := contracts.RunnerResult{Status: contracts.RunnerResultBlocked, Reason: "stalled", Artifacts: map[string]string{"stall_category": "rate_limit"}}
+	run := &fakeRunner{results: []contracts.RunnerResult{stalled, stalled}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", MaxRetries: 1, StallPolicies: map[contracts.StallCategory]contracts.StallPolicy{
+		contracts.StallCategoryRateLimit: contracts.StallPolicyRetry,
+	}})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || len(run.requests) != 2 {
+		t.Fatalf("expected one retry then blocked, got %#v after %d requests", summary, len(run.requests))
+	}
+}
+
 type fakeRunner struct {
 	results          []contracts.RunnerResult
 	idx              int
@@ -2375,6 +2619,7 @@ type fakeVCS struct {
 	mergeErrs  []error
 	mergeCalls int
 	pushErr    error
+	pushErrs   []error
 }
 
 func (f *fakeVCS) EnsureMain(context.Context) error {
@@ -2414,6 +2659,48 @@ func (f *fakeVCS) MergeToMain(_ context.Context, branch string) error {
 	return f.mergeErr
 }
 
+// messageMergingVCS also implements contracts.MessageMerger.
+type messageMergingVCS struct {
+	fakeVCS
+}
+
+func (f *messageMergingVCS) MergeToMainWithMessage(ctx context.Context, branch string, message string) error {
+	f.calls = append(f.calls, "merge_message:"+message)
+	return f.MergeToMain(ctx, branch)
+}
+
+// rebaseLandingVCS also implements contracts.RebaseLander.
+type rebaseLandingVCS struct {
+	fakeVCS
+}
+
+func (f *rebaseLandingVCS) RebaseAndFastForwardMain(ctx context.Context, branch string) error {
+	f.calls = append(f.calls, "rebase_ff:"+branch)
+	f.mergeCalls++
+	if len(f.mergeErrs) > 0 {
+		err := f.mergeErrs[0]
+		f.mergeErrs = f.mergeErrs[1:]
+		return err
+	}
+	return f.mergeErr
+}
+
+// squashLandingVCS also implements contracts.SquashLander.
+type squashLandingVCS struct {
+	fakeVCS
+}
+
+func (f *squashLandingVCS) SquashToMain(_ context.Context, branch string, message string) error {
+	f.calls = append(f.calls, "squash:"+branch+":"+message)
+	f.mergeCalls++
+	if len(f.mergeErrs) > 0 {
+		err := f.mergeErrs[0]
+		f.mergeErrs = f.mergeErrs[1:]
+		return err
+	}
+	return f.mergeErr
+}
+
 func (f *fakeVCS) PushBranch(_ context.Context, branch string) error {
 	f.calls = append(f.calls, "push_branch:"+branch)
 	return nil
@@ -2421,6 +2708,11 @@ func (f *fakeVCS) PushBranch(_ context.Context, branch string) error {
 
 func (f *fakeVCS) PushMain(context.Context) error {
 	f.calls = append(f.calls, "push_main")
+	if len(f.pushErrs) > 0 {
+		err := f.pushErrs[0]
+		f.pushErrs = f.pushErrs[1:]
+		return err
+	}
 	return f.pushErr
 }
 
@@ -2465,6 +2757,9 @@ func TestLoopFailsTaskWhenReviewFails(t *testing.T) {
 	if mgr.statusByID["t-1"] != contracts.TaskStatusFailed {
 		t.Fatalf("expected failed task status, got %s", mgr.statusByID["t-1"])
 	}
+	if got := mgr.dataByID["t-1"][contracts.TriageCategoryMetadataKey]; got != string(contracts.FailureCategoryReviewGating) {
+		t.Fatalf("expected review_gating triage category, got %q", got)
+	}
 }
 
 func TestLoopFailsTaskWhenReviewVerdictIsMissing(t *testing.T) {
@@ -2700,6 +2995,52 @@ func TestLoopMergesAndPushesAfterSuccessfulReview(t *testing.T) {
 	}
 }
 
+func TestLoopLandsWithTemplatedCommitMessages(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true, Artifacts: map[string]string{"review_verdict": "pass"}},
+	}}
+	messages, err := ParseCommitMessages(CommitMessageConfig{
+		AutoCommit:   "feat({{.Task.ID}}): {{.Task.Title}}\n\nRefs: {{.TrackerURL}}\n{{.CoAuthoredBy}}",
+		Merge:        "Merge {{.Branch}} (review: {{.ReviewVerdict}})",
+		TrackerURL:   "https://example.test/issues/{{.Task.ID}}",
+		CoAuthoredBy: "yolo-agent <agent@example.test>",
+	})
+	if err != nil {
+		t.Fatalf("parse commit messages: %v", err)
+	}
+	vcs := &messageMergingVCS{}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", RequireReview: true, MergeOnSuccess: true, VCS: vcs, CommitMessages: messages})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 {
+		t.Fatalf("expected completed summary, got %#v", summary)
+	}
+	wantCommit := "commit_all:feat(t-1): Task 1\n\nRefs: https://example.test/issues/t-1\nCo-authored-by: yolo-agent <agent@example.test>"
+	if !containsCall(vcs.calls, wantCommit) {
+		t.Fatalf("expected templated auto-commit %q, got %v", wantCommit, vcs.calls)
+	}
+	if !containsCall(vcs.calls, "merge_message:Merge task/t-1 (review: pass)") {
+		t.Fatalf("expected templated merge message, got %v", vcs.calls)
+	}
+
+	// A VCS without MessageMerger keeps git's default merge message.
+	mgr = newFakeTaskManager(contracts.Task{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen})
+	run = &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	plain := &fakeVCS{}
+	loop = NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", MergeOnSuccess: true, VCS: plain, CommitMessages: messages})
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if !containsCall(plain.calls, "merge_to_main:task/t-2") {
+		t.Fatalf("expected plain merge, got %v", plain.calls)
+	}
+}
+
 func TestLoopBlocksTaskWhenAutoCommitBeforeLandingFails(t *testing.T) {
 	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
 	run := &fakeRunner{results: []contracts.RunnerResult{
@@ -2874,6 +3215,25 @@ func TestLoopEmitsLifecycleEvents(t *testing.T) {
 	}
 }
 
+func TestLoopStampsRunIDOnEventsAndTaskData(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", RunID: "run-1"})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	for _, event := range sink.events {
+		if event.RunID != "run-1" {
+			t.Fatalf("expected every event stamped with run-1, got %#v", event)
+		}
+	}
+	if got := mgr.dataByID["t-1"][contracts.RunIDTaskDataKey]; got != "run-1" {
+		t.Fatalf("expected run-1 in task data, got %q", got)
+	}
+}
+
 func TestLoopEmitsParallelContextInRunnerStartedEvent(t *testing.T) {
 	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
 	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
@@ -3121,7 +3481,7 @@ func TestLoopEmitsRunnerFinishedMetadataWithStallDiagnostics(t *testing.T) {
 
 func TestLoopEmitsRunnerProgressEventsFromRunnerCallback(t *testing.T) {
 	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
-	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}, progressEvents: []contracts.RunnerProgress{{Type: "runner_cmd_started", Message: "cmd start"}, {Type: "runner_output", Message: "line output"}, {Type: "runner_cmd_finished", Message: "cmd finish"}, {Type: "runner_warning", Message: "stall warning"}}}
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}, progressEvents: []contracts.RunnerProgress{{Type: "runner_cmd_started", Message: "cmd start"}, {Type: "runner_output", Message: "line output"}, {Type: "runner_cmd_finished", Message: "cmd finish"}, {Type: "runner_warning", Message: "stall warning"}, {Type: "runner_permission", Message: "deny fetch: curl"}, {Type: "plan_updated", Message: "plan 0/1 completed"}}}
 	sink := &recordingSink{}
 	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root"})
 
@@ -3129,6 +3489,12 @@ func TestLoopEmitsRunnerProgressEventsFromRunnerCallback(t *testing.T) {
 	if err != nil {
 		t.Fatalf("loop failed: %v", err)
 	}
+	if permissionEvents := eventsByType(sink.events, contracts.EventTypeRunnerPermission); len(permissionEvents) != 1 || permissionEvents[0].Message != "deny fetch: curl" {
+		t.Fatalf("expected one runner_permission event, got %#v", permissionEvents)
+	}
+	if planEvents := eventsByType(sink.events, contracts.EventTypePlanUpdated); len(planEvents) != 1 || planEvents[0].Message != "plan 0/1 completed" {
+		t.Fatalf("expected one plan_updated event, got %#v", planEvents)
+	}
 	startedEvents := eventsByType(sink.events, contracts.EventTypeRunnerCommandStarted)
 	outputEvents := eventsByType(sink.events, contracts.EventTypeRunnerOutput)
 	finishedEvents := eventsByType(sink.events, contracts.EventTypeRunnerCommandFinished)
@@ -3840,6 +4206,106 @@ func TestLoopRunsMergeConflictRemediationBeforeLandingRetry(t *testing.T) {
 	}
 }
 
+func TestLoopRebaseLandingRemediatesRebaseConflicts(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+		{Status: contracts.RunnerResultCompleted},
+	}}
+	vcs := &rebaseLandingVCS{fakeVCS{mergeErrs: []error{errors.New("git rebase main failed: error: could not apply 1a2b3c4... Task 1"), nil}}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", VCS: vcs, MergeOnSuccess: true, RequireReview: true, LandingStrategy: LandingStrategyRebaseFF})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 {
+		t.Fatalf("expected task to complete after rebase remediation, got %#v", summary)
+	}
+	for _, call := range vcs.calls {
+		if strings.HasPrefix(call, "merge_to_main:") {
+			t.Fatalf("expected no merge commits under rebase_ff, got calls %#v", vcs.calls)
+		}
+	}
+	if vcs.mergeCalls != 2 {
+		t.Fatalf("expected two rebase attempts, got %d", vcs.mergeCalls)
+	}
+	if len(run.requests) != 3 || !strings.Contains(run.requests[2].Prompt, "Landing Rebase Remediation:") || !strings.Contains(run.requests[2].Prompt, "git rebase --continue") {
+		t.Fatalf("expected rebase remediation prompt, got %#v", run.requests)
+	}
+	completed := false
+	for _, event := range sink.events {
+		if event.Type == contracts.EventTypeMergeCompleted {
+			completed = event.Metadata["landing_strategy"] == string(LandingStrategyRebaseFF)
+		}
+	}
+	if !completed {
+		t.Fatalf("expected merge_completed to record the rebase_ff strategy")
+	}
+}
+
+func TestLoopRebaseLandingBlocksWhenVCSCannotRebase(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+	}}
+	vcs := &fakeVCS{}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", VCS: vcs, MergeOnSuccess: true, RequireReview: true, LandingStrategy: LandingStrategyRebaseFF})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 0 || vcs.mergeCalls != 0 {
+		t.Fatalf("expected landing to fail without a rebase-capable VCS, summary=%#v merges=%d", summary, vcs.mergeCalls)
+	}
+}
+
+func TestLoopSquashLandingUsesTemplatedMessage(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true, Artifacts: map[string]string{"review_verdict": "pass"}},
+	}}
+	messages, err := ParseCommitMessages(CommitMessageConfig{Squash: "{{.Task.Title}} ({{.Task.ID}}, review: {{.ReviewVerdict}})"})
+	if err != nil {
+		t.Fatalf("parse commit messages: %v", err)
+	}
+	vcs := &squashLandingVCS{}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", VCS: vcs, MergeOnSuccess: true, RequireReview: true, CommitMessages: messages, LandingStrategy: LandingStrategySquash})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 {
+		t.Fatalf("expected completed summary, got %#v", summary)
+	}
+	if !containsCall(vcs.calls, "squash:task/t-1:Task 1 (t-1, review: pass)") {
+		t.Fatalf("expected templated squash landing, got %v", vcs.calls)
+	}
+	for _, call := range vcs.calls {
+		if strings.HasPrefix(call, "merge_to_main:") {
+			t.Fatalf("expected no merge commit under squash, got calls %#v", vcs.calls)
+		}
+	}
+}
+
+func TestParseLandingStrategy(t *testing.T) {
+	for raw, want := range map[string]LandingStrategy{"": LandingStrategyMerge, "merge": LandingStrategyMerge, " Rebase_FF ": LandingStrategyRebaseFF, "squash": LandingStrategySquash} {
+		got, err := ParseLandingStrategy(raw)
+		if err != nil || got != want {
+			t.Fatalf("ParseLandingStrategy(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, err := ParseLandingStrategy("octopus"); err == nil {
+		t.Fatalf("expected unknown strategy to be rejected")
+	}
+}
+
 func TestLoopBlocksTaskWhenMergeConflictRemediationFails(t *testing.T) {
 	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
 	run := &fakeRunner{results: []contracts.RunnerResult{
@@ -4560,6 +5026,20 @@ func TestRunnerLogBackendDirSupportsClaude(t *testing.T) {
 	}
 }
 
+func TestRunnerLogBackendDirSupportsQwen(t *testing.T) {
+	if got := runnerLogBackendDir("qwen"); got != "qwen" {
+		t.Fatalf("expected qwen backend dir, got %q", got)
+	}
+}
+
+func TestRunnerLogBackendDirKeepsOwnDirectoryForAdapterBackends(t *testing.T) {
+	for _, backend := range []string{"opencode-server", "ollama", "amazonq"} {
+		if got := runnerLogBackendDir(backend); got != backend {
+			t.Fatalf("expected %s backend dir, got %q", backend, got)
+		}
+	}
+}
+
 type statusTransition struct {
 	taskID string
 	status contracts.TaskStatus
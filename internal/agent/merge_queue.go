@@ -0,0 +1,511 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/scheduler"
+)
+
+const landingMaxAttempts = 2
+
+// DefaultPushRetries is how many times a rejected push of main is retried
+// on top of the updated remote main before the task blocks.
+const DefaultPushRetries = 3
+
+// landingTicket carries one reviewed task through the merge queue. The
+// worker that finished the task waits on done and then reads the outcome.
+type landingTicket struct {
+	ctx           context.Context
+	task          contracts.Task
+	vcs           contracts.VCS
+	branch        string
+	worker        string
+	repoRoot      string
+	queuePos      int
+	reviewVerdict string
+	backend       string
+	model         string
+	runtime       taskRuntimeConfig
+	workspace     Workspace
+	state         *scheduler.LandingQueueStateMachine
+
+	autoCommitSHA string
+	landedSHA     string
+	prepared      bool
+	blocked       bool
+	reason        string
+	triage        map[string]string
+	done          chan struct{}
+}
+
+func newLandingTicket(ctx context.Context, task contracts.Task, vcs contracts.VCS, branch string, worker string, repoRoot string, queuePos int) *landingTicket {
+	return &landingTicket{
+		ctx:      ctx,
+		task:     task,
+		vcs:      vcs,
+		branch:   branch,
+		worker:   worker,
+		repoRoot: repoRoot,
+		queuePos: queuePos,
+		state:    scheduler.NewLandingQueueStateMachine(landingMaxAttempts),
+		done:     make(chan struct{}),
+	}
+}
+
+func (t *landingTicket) status() string {
+	return string(t.state.State())
+}
+
+// metadata is the landing state recorded on task_data_updated events.
+func (t *landingTicket) metadata(attempt int, reason string) map[string]string {
+	metadata := map[string]string{"landing_status": t.status()}
+	metadata = appendDecisionMetadata(metadata, t.status(), reason)
+	if attempt > 0 {
+		metadata["landing_attempt"] = fmt.Sprintf("%d", attempt)
+	}
+	if strings.TrimSpace(reason) != "" {
+		metadata["triage_reason"] = reason
+	}
+	if t.autoCommitSHA != "" {
+		metadata["auto_commit_sha"] = t.autoCommitSHA
+	}
+	return metadata
+}
+
+// mergeQueue orders landings. A single lander goroutine drains it while
+// tickets are pending, so workers never contend on main themselves.
+type mergeQueue struct {
+	mu      sync.Mutex
+	pending []*landingTicket
+	landing bool
+}
+
+// enqueueLanding adds t to the merge queue and blocks until it has landed or
+// been blocked.
+func (l *Loop) enqueueLanding(t *landingTicket) {
+	l.mergeQueue.mu.Lock()
+	l.mergeQueue.pending = append(l.mergeQueue.pending, t)
+	position, depth := len(l.mergeQueue.pending), len(l.mergeQueue.pending)
+	start := !l.mergeQueue.landing
+	l.mergeQueue.landing = true
+	l.mergeQueue.mu.Unlock()
+
+	l.emitMergeQueuePosition(t, position, depth)
+	if start {
+		go l.runMergeQueue()
+	}
+	<-t.done
+}
+
+// nextLandingBatch pops the head ticket plus up to batchSize-1 tickets that
+// can be integrated in the head's repository. It returns nil and stops the
+// lander when the queue is empty.
+func (q *mergeQueue) nextLandingBatch(batchSize int) ([]*landingTicket, []*landingTicket) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		q.landing = false
+		return nil, nil
+	}
+	head := q.pending[0]
+	batch := []*landingTicket{head}
+	rest := []*landingTicket{}
+	for _, candidate := range q.pending[1:] {
+		if len(batch) < batchSize && canBatchLanding(head, candidate) {
+			batch = append(batch, candidate)
+			continue
+		}
+		rest = append(rest, candidate)
+	}
+	q.pending = rest
+	return batch, append([]*landingTicket(nil), rest...)
+}
+
+// canBatchLanding reports whether candidate's branch can be merged in head's
+// repository: head's VCS can land a batch, both land on the same workspace,
+// and either share a repository or head can fetch the branch from
+// candidate's clone.
+func canBatchLanding(head *landingTicket, candidate *landingTicket) bool {
+	if _, ok := head.vcs.(contracts.BatchLander); !ok {
+		return false
+	}
+	if candidate.workspace != head.workspace {
+		return false
+	}
+	if candidate.repoRoot == head.repoRoot {
+		return true
+	}
+	_, ok := head.vcs.(contracts.BranchFetcher)
+	return ok && strings.TrimSpace(candidate.repoRoot) != ""
+}
+
+func (l *Loop) runMergeQueue() {
+	batchSize := l.options.MergeQueueBatchSize
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	for {
+		batch, waiting := l.mergeQueue.nextLandingBatch(batchSize)
+		if len(batch) == 0 {
+			return
+		}
+		for i, t := range waiting {
+			l.emitMergeQueuePosition(t, i+1, len(waiting))
+		}
+		if l.landingLock != nil {
+			l.landingLock.Lock()
+		}
+		if len(batch) == 1 {
+			l.landTicket(batch[0])
+		} else {
+			l.landBatch(batch)
+		}
+		if l.landingLock != nil {
+			l.landingLock.Unlock()
+		}
+		for _, t := range batch {
+			close(t.done)
+		}
+	}
+}
+
+// landTicket lands one task on its own: auto-commit, pre-landing hooks,
+// merge with the configured strategy and push, retrying once after merge
+// conflict remediation.
+func (l *Loop) landTicket(t *landingTicket) {
+	for attempt := t.state.Attempts() + 1; attempt <= landingMaxAttempts; attempt++ {
+		_ = t.state.Apply(scheduler.LandingEventBegin)
+		l.emitLandingData(t, attempt, "")
+		if !t.prepared && !l.prepareLanding(t, attempt) {
+			return
+		}
+		if err := l.landTaskBranch(t.ctx, t.vcs, t.task, t.branch, t.reviewVerdict, t.backend, t.model); err != nil {
+			if !l.requeueFailedLanding(t, attempt, err) {
+				return
+			}
+			continue
+		}
+		l.emitMergeCompleted(t, nil)
+		landErr, pushErr := l.pushMainWithRetry(t.ctx, t.vcs, []*landingTicket{t}, func() error {
+			return l.landTaskBranch(t.ctx, t.vcs, t.task, t.branch, t.reviewVerdict, t.backend, t.model)
+		})
+		if landErr != nil {
+			if !l.requeueFailedLanding(t, attempt, landErr) {
+				return
+			}
+			continue
+		}
+		if pushErr != nil {
+			l.failLanding(t, attempt, pushErr.Error())
+			return
+		}
+		if lander, ok := t.vcs.(contracts.BatchLander); ok {
+			if sha, err := lander.MainHead(t.ctx); err == nil {
+				t.landedSHA = strings.TrimSpace(sha)
+			}
+		}
+		l.completeLanding(t, attempt, nil)
+		return
+	}
+}
+
+// landBatch syncs main once in the head ticket's repository, lands every
+// ticket of batch on it, runs the pre-landing hooks on the combined result
+// and pushes main once. Tickets that fail to merge, all merged tickets when
+// the hooks or the push fail, and tickets whose landed commit is missing from
+// the pushed main fall back to landTicket for their remaining attempt.
+func (l *Loop) landBatch(batch []*landingTicket) {
+	head := batch[0]
+	batcher := head.vcs.(contracts.BatchLander)
+	localMain := batcher.OnLocalMain()
+	type failedLanding struct {
+		ticket *landingTicket
+		err    error
+	}
+	merged := []*landingTicket{}
+	failed := []failedLanding{}
+	// Landing through localMain never syncs main again, so a remote that
+	// moves mid-batch cannot reset away the tickets already merged.
+	syncErr := head.vcs.EnsureMain(head.ctx)
+	for _, t := range batch {
+		_ = t.state.Apply(scheduler.LandingEventBegin)
+		l.emitLandingData(t, 1, "")
+		if !l.prepareLanding(t, 1) {
+			continue
+		}
+		if syncErr != nil {
+			failed = append(failed, failedLanding{ticket: t, err: syncErr})
+			continue
+		}
+		if t.repoRoot != head.repoRoot {
+			if err := head.vcs.(contracts.BranchFetcher).FetchBranch(t.ctx, t.repoRoot, t.branch); err != nil {
+				failed = append(failed, failedLanding{ticket: t, err: err})
+				continue
+			}
+		}
+		if err := l.landBatchTicket(localMain, batcher, t); err != nil {
+			failed = append(failed, failedLanding{ticket: t, err: err})
+			continue
+		}
+		merged = append(merged, t)
+	}
+
+	if len(merged) > 0 {
+		ids := make([]string, 0, len(merged))
+		for _, t := range merged {
+			ids = append(ids, t.task.ID)
+		}
+		batchMetadata := map[string]string{
+			"merge_queue_batch":      strings.Join(ids, ","),
+			"merge_queue_batch_size": fmt.Sprintf("%d", len(merged)),
+		}
+		for _, t := range merged {
+			l.emitMergeCompleted(t, batchMetadata)
+		}
+		landErr := l.runPreLandingHooks(head.ctx, head.task, head.repoRoot)
+		var pushErr error
+		if landErr == nil {
+			landErr, pushErr = l.pushMainWithRetry(head.ctx, head.vcs, merged, func() error {
+				if err := head.vcs.EnsureMain(head.ctx); err != nil {
+					return err
+				}
+				for _, t := range merged {
+					if err := l.landBatchTicket(localMain, batcher, t); err != nil {
+						return err
+					}
+				}
+				return l.runPreLandingHooks(head.ctx, head.task, head.repoRoot)
+			})
+		}
+		if err := errors.Join(landErr, pushErr); err != nil {
+			// Drop the unpushed batch so the fallback landings start from
+			// the remote main.
+			if resetErr := batcher.ResetMain(head.ctx); resetErr != nil {
+				err = errors.Join(err, resetErr)
+			}
+			for _, t := range merged {
+				failed = append(failed, failedLanding{ticket: t, err: fmt.Errorf("batched landing failed: %w", err)})
+			}
+		} else {
+			for _, t := range merged {
+				onMain, err := batcher.IsOnMain(head.ctx, t.landedSHA)
+				if err == nil && !onMain {
+					err = fmt.Errorf("landed commit %s is missing from the pushed main", t.landedSHA)
+				}
+				if err != nil {
+					failed = append(failed, failedLanding{ticket: t, err: err})
+					continue
+				}
+				l.completeLanding(t, 1, batchMetadata)
+			}
+		}
+	}
+
+	for _, failure := range failed {
+		if l.requeueFailedLanding(failure.ticket, 1, failure.err) {
+			l.landTicket(failure.ticket)
+		}
+	}
+}
+
+// landBatchTicket lands t's branch on the batch's main and records the
+// commit main points at afterwards.
+func (l *Loop) landBatchTicket(vcs contracts.VCS, batcher contracts.BatchLander, t *landingTicket) error {
+	if err := l.landTaskBranch(t.ctx, vcs, t.task, t.branch, t.reviewVerdict, t.backend, t.model); err != nil {
+		return err
+	}
+	sha, err := batcher.MainHead(t.ctx)
+	if err != nil {
+		return err
+	}
+	t.landedSHA = strings.TrimSpace(sha)
+	return nil
+}
+
+// pushMainWithRetry pushes main. When the remote rejects the push because
+// main moved, it re-lands on the updated main with reland and pushes again,
+// up to PushRetries times. landErr reports a failed re-land, which is
+// handled like any other merge failure; pushErr is the final push error.
+func (l *Loop) pushMainWithRetry(ctx context.Context, vcs contracts.VCS, tickets []*landingTicket, reland func() error) (landErr error, pushErr error) {
+	for retry := 1; ; retry++ {
+		err := vcs.PushMain(ctx)
+		if err == nil || !isPushRejectedError(err.Error()) || retry > l.options.PushRetries {
+			return nil, err
+		}
+		for _, t := range tickets {
+			l.emitLandingEvent(t, contracts.EventTypePushRetry, appendDecisionMetadata(map[string]string{
+				"landing_status": t.status(),
+				"push_attempt":   fmt.Sprintf("%d", retry),
+				"push_retries":   fmt.Sprintf("%d", l.options.PushRetries),
+				"triage_reason":  err.Error(),
+			}, "retry", err.Error()))
+		}
+		if err := reland(); err != nil {
+			return err, nil
+		}
+	}
+}
+
+// isPushRejectedError reports whether a push failed only because the remote
+// branch has commits the local one lacks.
+func isPushRejectedError(reason string) bool {
+	lower := strings.ToLower(reason)
+	for _, needle := range []string{"non-fast-forward", "fetch first", "updates were rejected"} {
+		if strings.Contains(lower, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareLanding auto-commits the task branch and runs the pre-landing
+// hooks. A failure blocks the ticket permanently.
+func (l *Loop) prepareLanding(t *landingTicket, attempt int) bool {
+	message, err := l.options.CommitMessages.AutoCommit(t.task, t.branch, t.reviewVerdict, t.backend, t.model)
+	sha := ""
+	if err == nil {
+		sha, err = t.vcs.CommitAll(t.ctx, message)
+	}
+	if err != nil {
+		l.failLanding(t, attempt, err.Error())
+		return false
+	}
+	t.autoCommitSHA = strings.TrimSpace(sha)
+	if t.autoCommitSHA != "" {
+		l.emitLandingData(t, attempt, "")
+	}
+	if err := l.runPreLandingHooks(t.ctx, t.task, t.repoRoot); err != nil {
+		var hookErr *LandingHookError
+		if errors.As(err, &hookErr) {
+			t.triage = hookErr.Metadata()
+		}
+		l.failLanding(t, attempt, err.Error())
+		return false
+	}
+	t.prepared = true
+	return true
+}
+
+// requeueFailedLanding records a failed merge attempt. Before the last
+// attempt it runs merge conflict remediation when needed and requeues the
+// ticket; it returns false once the ticket is blocked.
+func (l *Loop) requeueFailedLanding(t *landingTicket, attempt int, err error) bool {
+	t.reason = err.Error()
+	_ = t.state.Apply(scheduler.LandingEventFailedRetryable)
+	l.emitLandingData(t, attempt, t.reason)
+	if attempt >= landingMaxAttempts {
+		t.blocked = true
+		return false
+	}
+	l.emitLandingEvent(t, contracts.EventTypeMergeRetry, appendDecisionMetadata(map[string]string{
+		"landing_status":  t.status(),
+		"landing_attempt": fmt.Sprintf("%d", attempt),
+		"triage_reason":   t.reason,
+	}, "retry", t.reason))
+	if isMergeConflictError(t.reason) {
+		remediationResult := l.runLandingMergeConflictRemediation(t.ctx, t.task, t.vcs, t.branch, t.worker, t.repoRoot, t.queuePos, t.reason, t.runtime)
+		if remediationResult.Status != contracts.RunnerResultCompleted {
+			remediationReason := strings.TrimSpace(remediationResult.Reason)
+			if remediationReason == "" {
+				remediationReason = "runner did not complete successfully"
+			}
+			t.reason = "merge conflict remediation failed: " + remediationReason
+			t.blocked = true
+			return false
+		}
+		t.prepared = false
+		t.autoCommitSHA = ""
+	}
+	_ = t.state.Apply(scheduler.LandingEventRequeued)
+	l.emitLandingData(t, 0, "")
+	l.emitLandingEvent(t, contracts.EventTypeMergeQueued, appendDecisionMetadata(map[string]string{
+		"landing_status":  t.status(),
+		"landing_attempt": fmt.Sprintf("%d", attempt+1),
+	}, t.status(), ""))
+	return true
+}
+
+func (l *Loop) failLanding(t *landingTicket, attempt int, reason string) {
+	t.reason = reason
+	_ = t.state.Apply(scheduler.LandingEventFailedPermanent)
+	l.emitLandingData(t, attempt, reason)
+	t.blocked = true
+}
+
+func (l *Loop) emitMergeCompleted(t *landingTicket, extra map[string]string) {
+	metadata := map[string]string{}
+	if t.autoCommitSHA != "" {
+		metadata["auto_commit_sha"] = t.autoCommitSHA
+	}
+	if strategy := l.landingStrategy(); strategy != LandingStrategyMerge {
+		metadata["landing_strategy"] = string(strategy)
+	}
+	for key, value := range extra {
+		metadata[key] = value
+	}
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+	_ = l.emit(t.ctx, contracts.Event{Type: contracts.EventTypeMergeCompleted, TaskID: t.task.ID, TaskTitle: t.task.Title, WorkerID: t.worker, ClonePath: t.repoRoot, QueuePos: t.queuePos, Message: t.branch, Metadata: metadata, Timestamp: time.Now().UTC()})
+}
+
+func (l *Loop) completeLanding(t *landingTicket, attempt int, extra map[string]string) {
+	pushMetadata := map[string]string{}
+	if t.autoCommitSHA != "" {
+		pushMetadata["auto_commit_sha"] = t.autoCommitSHA
+	}
+	if t.landedSHA != "" {
+		pushMetadata["landed_sha"] = t.landedSHA
+	}
+	for key, value := range extra {
+		pushMetadata[key] = value
+	}
+	if len(pushMetadata) == 0 {
+		pushMetadata = nil
+	}
+	_ = l.emit(t.ctx, contracts.Event{Type: contracts.EventTypePushCompleted, TaskID: t.task.ID, TaskTitle: t.task.Title, WorkerID: t.worker, ClonePath: t.repoRoot, QueuePos: t.queuePos, Metadata: pushMetadata, Timestamp: time.Now().UTC()})
+	_ = t.state.Apply(scheduler.LandingEventSucceeded)
+	l.emitLandingData(t, 0, "")
+	l.emitLandingEvent(t, contracts.EventTypeMergeLanded, appendDecisionMetadata(map[string]string{
+		"landing_status":  t.status(),
+		"landing_attempt": fmt.Sprintf("%d", attempt),
+		"landed_sha":      t.landedSHA,
+	}, "landed", t.reason))
+}
+
+func (l *Loop) emitLandingData(t *landingTicket, attempt int, reason string) {
+	_ = l.emit(t.ctx, contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: t.task.ID, TaskTitle: t.task.Title, WorkerID: t.worker, ClonePath: t.repoRoot, QueuePos: t.queuePos, Metadata: t.metadata(attempt, reason), Timestamp: time.Now().UTC()})
+}
+
+func (l *Loop) emitLandingEvent(t *landingTicket, eventType contracts.EventType, metadata map[string]string) {
+	merged := map[string]string{}
+	for key, value := range metadata {
+		merged[key] = value
+	}
+	if t.autoCommitSHA != "" {
+		merged["auto_commit_sha"] = t.autoCommitSHA
+	}
+	_ = l.emit(t.ctx, contracts.Event{
+		Type:      eventType,
+		TaskID:    t.task.ID,
+		TaskTitle: t.task.Title,
+		WorkerID:  t.worker,
+		ClonePath: t.repoRoot,
+		QueuePos:  t.queuePos,
+		Metadata:  compactMetadata(merged),
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+func (l *Loop) emitMergeQueuePosition(t *landingTicket, position int, depth int) {
+	l.emitLandingEvent(t, contracts.EventTypeMergeQueuePosition, map[string]string{
+		"merge_queue_position": fmt.Sprintf("%d", position),
+		"merge_queue_depth":    fmt.Sprintf("%d", depth),
+	})
+}
@@ -0,0 +1,439 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	gitvcs "github.com/egv/yolo-runner/v2/internal/vcs/git"
+)
+
+// gatedLandingLock holds the first landing until release is closed.
+type gatedLandingLock struct {
+	held    chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (g *gatedLandingLock) Lock() {
+	g.once.Do(func() {
+		close(g.held)
+		<-g.release
+	})
+}
+
+func (g *gatedLandingLock) Unlock() {}
+
+// fetchingVCS also implements contracts.BranchFetcher and
+// contracts.BatchLander. The first offMain IsOnMain checks report the
+// landing missing from main.
+type fetchingVCS struct {
+	fakeVCS
+	offMain int
+}
+
+func (f *fetchingVCS) FetchBranch(_ context.Context, repoPath string, branch string) error {
+	f.calls = append(f.calls, "fetch:"+repoPath+":"+branch)
+	return nil
+}
+
+func (f *fetchingVCS) OnLocalMain() contracts.VCS {
+	return f
+}
+
+func (f *fetchingVCS) MainHead(context.Context) (string, error) {
+	return fmt.Sprintf("main-%d", len(f.calls)), nil
+}
+
+func (f *fetchingVCS) IsOnMain(context.Context, string) (bool, error) {
+	if f.offMain > 0 {
+		f.offMain--
+		return false, nil
+	}
+	return true, nil
+}
+
+func (f *fetchingVCS) ResetMain(context.Context) error {
+	f.calls = append(f.calls, "reset_main")
+	return nil
+}
+
+func queuePositions(sink *lockedRecordingSink) []string {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	positions := []string{}
+	for _, event := range sink.events {
+		if event.Type == contracts.EventTypeMergeQueuePosition {
+			positions = append(positions, fmt.Sprintf("%s:%s/%s", event.TaskID, event.Metadata["merge_queue_position"], event.Metadata["merge_queue_depth"]))
+		}
+	}
+	return positions
+}
+
+func waitForQueuePositions(t *testing.T, sink *lockedRecordingSink, count int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(queuePositions(sink)) < count {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d merge queue position events, got %v", count, queuePositions(sink))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMergeQueueLandsTasksInOrderAndReportsPositions(t *testing.T) {
+	vcs := &fakeVCS{}
+	sink := &lockedRecordingSink{}
+	loop := NewLoop(newFakeTaskManager(), &fakeRunner{}, sink, LoopOptions{ParentID: "root", VCS: vcs, MergeOnSuccess: true})
+	gate := &gatedLandingLock{held: make(chan struct{}), release: make(chan struct{})}
+	loop.landingLock = gate
+
+	var wg sync.WaitGroup
+	enqueue := func(id string) *landingTicket {
+		ticket := newLandingTicket(context.Background(), contracts.Task{ID: id, Title: id}, vcs, "task/"+id, "worker-0", "", 0)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loop.enqueueLanding(ticket)
+		}()
+		return ticket
+	}
+	tickets := []*landingTicket{enqueue("a")}
+	<-gate.held
+	tickets = append(tickets, enqueue("b"))
+	waitForQueuePositions(t, sink, 2)
+	tickets = append(tickets, enqueue("c"))
+	waitForQueuePositions(t, sink, 3)
+	close(gate.release)
+	wg.Wait()
+
+	merges := []string{}
+	for _, call := range vcs.calls {
+		if strings.HasPrefix(call, "merge_to_main:") {
+			merges = append(merges, strings.TrimPrefix(call, "merge_to_main:"))
+		}
+	}
+	if !reflect.DeepEqual(merges, []string{"task/a", "task/b", "task/c"}) {
+		t.Fatalf("expected tasks to land in queue order, got %v", merges)
+	}
+	if got := queuePositions(sink); !reflect.DeepEqual(got, []string{"a:1/1", "b:1/1", "c:2/2", "c:1/1"}) {
+		t.Fatalf("unexpected merge queue positions %v", got)
+	}
+	for _, ticket := range tickets {
+		if ticket.blocked || ticket.status() != "landed" {
+			t.Fatalf("expected %s to land, got status=%s reason=%q", ticket.task.ID, ticket.status(), ticket.reason)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		loop.mergeQueue.mu.Lock()
+		landing := loop.mergeQueue.landing
+		loop.mergeQueue.mu.Unlock()
+		if !landing {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the lander to stop once the queue drained")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMergeQueueBatchesCompatibleTasksIntoOnePush(t *testing.T) {
+	head := &fetchingVCS{fakeVCS: fakeVCS{mergeErrs: []error{nil, nil, errors.New("git merge --no-ff task/c failed")}}}
+	vcsB := &fetchingVCS{}
+	vcsC := &fetchingVCS{}
+	sink := &lockedRecordingSink{}
+	loop := NewLoop(newFakeTaskManager(), &fakeRunner{}, sink, LoopOptions{ParentID: "root", MergeOnSuccess: true, MergeQueueBatchSize: 3})
+
+	ctx := context.Background()
+	a := newLandingTicket(ctx, contracts.Task{ID: "a"}, head, "task/a", "worker-0", "/clones/a", 0)
+	b := newLandingTicket(ctx, contracts.Task{ID: "b"}, vcsB, "task/b", "worker-1", "/clones/b", 0)
+	c := newLandingTicket(ctx, contracts.Task{ID: "c"}, vcsC, "task/c", "worker-2", "/clones/c", 0)
+	loop.mergeQueue.pending = []*landingTicket{a, b, c}
+	loop.mergeQueue.landing = true
+	loop.runMergeQueue()
+
+	if !containsCall(head.calls, "fetch:/clones/b:task/b") || !containsCall(head.calls, "merge_to_main:task/b") {
+		t.Fatalf("expected task/b to be fetched and merged in the head clone, got %v", head.calls)
+	}
+	pushes := 0
+	for _, call := range head.calls {
+		if call == "push_main" {
+			pushes++
+		}
+	}
+	if pushes != 1 {
+		t.Fatalf("expected one batched push, got %d in %v", pushes, head.calls)
+	}
+	if containsCall(vcsB.calls, "push_main") {
+		t.Fatalf("expected batched task to land without its own push, got %v", vcsB.calls)
+	}
+	if !containsCall(vcsC.calls, "merge_to_main:task/c") || !containsCall(vcsC.calls, "push_main") {
+		t.Fatalf("expected task/c to fall back to landing from its own clone, got %v", vcsC.calls)
+	}
+	for _, ticket := range []*landingTicket{a, b, c} {
+		if ticket.blocked || ticket.status() != "landed" {
+			t.Fatalf("expected %s to land, got status=%s reason=%q", ticket.task.ID, ticket.status(), ticket.reason)
+		}
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	batches := map[string]string{}
+	landed := map[string]string{}
+	for _, event := range sink.events {
+		if event.Type == contracts.EventTypePushCompleted {
+			batches[event.TaskID] = event.Metadata["merge_queue_batch"]
+		}
+		if event.Type == contracts.EventTypeMergeLanded {
+			landed[event.TaskID] = event.Metadata["landed_sha"]
+		}
+	}
+	if !reflect.DeepEqual(batches, map[string]string{"a": "a,b", "b": "a,b", "c": ""}) {
+		t.Fatalf("unexpected batch metadata on push_completed events %v", batches)
+	}
+	if landed["a"] != a.landedSHA || landed["b"] != b.landedSHA || landed["c"] != c.landedSHA || landed["a"] == landed["b"] || landed["c"] == "" {
+		t.Fatalf("expected each merge_landed event to carry its commit on main, got %v", landed)
+	}
+}
+
+func TestMergeQueueBatchFallsBackToIndividualLandingWhenPushFails(t *testing.T) {
+	head := &fetchingVCS{fakeVCS: fakeVCS{pushErr: errors.New("rejected")}}
+	other := &fetchingVCS{}
+	loop := NewLoop(newFakeTaskManager(), &fakeRunner{}, nil, LoopOptions{ParentID: "root", MergeOnSuccess: true, MergeQueueBatchSize: 2})
+
+	ctx := context.Background()
+	a := newLandingTicket(ctx, contracts.Task{ID: "a"}, head, "task/a", "worker-0", "/clones/a", 0)
+	b := newLandingTicket(ctx, contracts.Task{ID: "b"}, other, "task/b", "worker-1", "/clones/b", 0)
+	loop.mergeQueue.pending = []*landingTicket{a, b}
+	loop.mergeQueue.landing = true
+	loop.runMergeQueue()
+
+	if !a.blocked || !strings.Contains(a.reason, "rejected") {
+		t.Fatalf("expected head task to block once its own push fails, got blocked=%v reason=%q", a.blocked, a.reason)
+	}
+	if b.blocked || b.status() != "landed" || !containsCall(other.calls, "push_main") {
+		t.Fatalf("expected task/b to land from its own clone, got status=%s calls=%v", b.status(), other.calls)
+	}
+}
+
+func TestMergeQueueBatchRunsPreLandingHooksOnCombinedResult(t *testing.T) {
+	head := &fetchingVCS{}
+	other := &fetchingVCS{}
+	headRoot, otherRoot := t.TempDir(), t.TempDir()
+	// The hook passes in each task's clone and fails on its second run in
+	// the head clone, which is the check of the combined batch.
+	loop := NewLoop(newFakeTaskManager(), &fakeRunner{}, nil, LoopOptions{ParentID: "root", MergeOnSuccess: true, MergeQueueBatchSize: 2, PreLandingHooks: []string{
+		"if [ -f checked ]; then exit 1; fi; touch checked",
+	}})
+
+	ctx := context.Background()
+	a := newLandingTicket(ctx, contracts.Task{ID: "a"}, head, "task/a", "worker-0", headRoot, 0)
+	b := newLandingTicket(ctx, contracts.Task{ID: "b"}, other, "task/b", "worker-1", otherRoot, 0)
+	loop.mergeQueue.pending = []*landingTicket{a, b}
+	loop.mergeQueue.landing = true
+	loop.runMergeQueue()
+
+	if !containsCall(head.calls, "reset_main") {
+		t.Fatalf("expected the failed batch to be dropped from main, got %v", head.calls)
+	}
+	if countCalls(head.calls, "push_main") != 1 || countCalls(other.calls, "push_main") != 1 {
+		t.Fatalf("expected both tasks to land on their own after the combined check failed, got head=%v other=%v", head.calls, other.calls)
+	}
+	for _, ticket := range []*landingTicket{a, b} {
+		if ticket.blocked || ticket.status() != "landed" {
+			t.Fatalf("expected %s to land, got status=%s reason=%q", ticket.task.ID, ticket.status(), ticket.reason)
+		}
+	}
+}
+
+func TestMergeQueueBatchRequeuesTasksMissingFromPushedMain(t *testing.T) {
+	head := &fetchingVCS{offMain: 1}
+	loop := NewLoop(newFakeTaskManager(), &fakeRunner{}, nil, LoopOptions{ParentID: "root", MergeOnSuccess: true, MergeQueueBatchSize: 2})
+
+	ctx := context.Background()
+	a := newLandingTicket(ctx, contracts.Task{ID: "a"}, head, "task/a", "worker-0", "/clones/a", 0)
+	b := newLandingTicket(ctx, contracts.Task{ID: "b"}, head, "task/b", "worker-1", "/clones/a", 0)
+	loop.mergeQueue.pending = []*landingTicket{a, b}
+	loop.mergeQueue.landing = true
+	loop.runMergeQueue()
+
+	if !strings.Contains(a.reason, "missing from the pushed main") || a.status() != "landed" {
+		t.Fatalf("expected task/a to be requeued and land on its own, got status=%s reason=%q", a.status(), a.reason)
+	}
+	if b.reason != "" || b.status() != "landed" {
+		t.Fatalf("expected task/b to land with the batch, got status=%s reason=%q", b.status(), b.reason)
+	}
+	if got := countCalls(head.calls, "merge_to_main:task/a"); got != 2 {
+		t.Fatalf("expected task/a to be merged again, got %d merges in %v", got, head.calls)
+	}
+}
+
+// advancingRunner runs git in dir and calls advance once, right after the
+// first merge onto main.
+type advancingRunner struct {
+	dir     string
+	advance func()
+	once    sync.Once
+}
+
+func (r *advancingRunner) Run(name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = r.dir
+	out, err := cmd.CombinedOutput()
+	if err == nil && len(args) > 0 && args[0] == "merge" {
+		r.once.Do(r.advance)
+	}
+	return string(out), err
+}
+
+func TestMergeQueueBatchKeepsEarlierMergesWhenRemoteAdvancesMidBatch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "yolo")
+	t.Setenv("GIT_AUTHOR_EMAIL", "yolo@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "yolo")
+	t.Setenv("GIT_COMMITTER_EMAIL", "yolo@example.com")
+
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	repo := t.TempDir()
+	upstream := filepath.Join(t.TempDir(), "upstream")
+	runGit(t, "", "init", "--bare", "--initial-branch=main", origin)
+	runGit(t, "", "clone", origin, repo)
+	runGit(t, repo, "checkout", "-b", "main")
+	writeRepoFile(t, repo, "README.md", "seed\n")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-m", "seed")
+	runGit(t, repo, "push", "-u", "origin", "main")
+	for _, id := range []string{"a", "b"} {
+		runGit(t, repo, "checkout", "-b", "task/"+id, "main")
+		writeRepoFile(t, repo, id+".txt", id+"\n")
+		runGit(t, repo, "add", ".")
+		runGit(t, repo, "commit", "-m", "task "+id)
+	}
+	runGit(t, repo, "checkout", "main")
+	runGit(t, "", "clone", origin, upstream)
+
+	runner := &advancingRunner{dir: repo, advance: func() {
+		writeRepoFile(t, upstream, "upstream.txt", "upstream\n")
+		runGit(t, upstream, "add", ".")
+		runGit(t, upstream, "commit", "-m", "upstream")
+		runGit(t, upstream, "push", "origin", "main")
+	}}
+	vcs := gitvcs.NewVCSAdapter(runner)
+	loop := NewLoop(newFakeTaskManager(), &fakeRunner{}, nil, LoopOptions{ParentID: "root", MergeOnSuccess: true, MergeQueueBatchSize: 2, PushRetries: DefaultPushRetries})
+
+	ctx := context.Background()
+	a := newLandingTicket(ctx, contracts.Task{ID: "a"}, vcs, "task/a", "worker-0", repo, 0)
+	b := newLandingTicket(ctx, contracts.Task{ID: "b"}, vcs, "task/b", "worker-1", repo, 0)
+	loop.mergeQueue.pending = []*landingTicket{a, b}
+	loop.mergeQueue.landing = true
+	loop.runMergeQueue()
+
+	for _, ticket := range []*landingTicket{a, b} {
+		if ticket.blocked || ticket.status() != "landed" || ticket.reason != "" {
+			t.Fatalf("expected %s to land with the batch, got status=%s reason=%q", ticket.task.ID, ticket.status(), ticket.reason)
+		}
+	}
+	files := runGitOutput(t, origin, "ls-tree", "--name-only", "main")
+	for _, file := range []string{"a.txt", "b.txt", "upstream.txt"} {
+		if !strings.Contains(files, file) {
+			t.Fatalf("expected %s on the pushed main, got files %q", file, files)
+		}
+	}
+}
+
+func countCalls(calls []string, want string) int {
+	count := 0
+	for _, call := range calls {
+		if call == want {
+			count++
+		}
+	}
+	return count
+}
+
+func TestLoopRetriesRejectedPushOnUpdatedMain(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	vcs := &fakeVCS{pushErrs: []error{errors.New("git push origin main failed: ! [rejected] main -> main (fetch first)")}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", VCS: vcs, MergeOnSuccess: true, PushRetries: DefaultPushRetries})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 {
+		t.Fatalf("expected task to land after push retry, got %#v", summary)
+	}
+	if countCalls(vcs.calls, "merge_to_main:task/t-1") != 2 || countCalls(vcs.calls, "push_main") != 2 {
+		t.Fatalf("expected one re-merge and one re-push, got %v", vcs.calls)
+	}
+	retries := eventsByType(sink.events, contracts.EventTypePushRetry)
+	if len(retries) != 1 || retries[0].Metadata["push_attempt"] != "1" || retries[0].Metadata["push_retries"] != "3" {
+		t.Fatalf("expected one push_retry event, got %#v", retries)
+	}
+}
+
+func TestLoopBlocksWhenPushRetriesAreExhausted(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	vcs := &fakeVCS{pushErr: errors.New("Updates were rejected because the tip of your current branch is behind")}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", VCS: vcs, MergeOnSuccess: true, PushRetries: 2})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || mgr.statusByID["t-1"] != contracts.TaskStatusBlocked {
+		t.Fatalf("expected task to block once push retries run out, got %#v status=%s", summary, mgr.statusByID["t-1"])
+	}
+	if countCalls(vcs.calls, "push_main") != 3 {
+		t.Fatalf("expected the first push plus two retries, got %v", vcs.calls)
+	}
+	if retries := eventsByType(sink.events, contracts.EventTypePushRetry); len(retries) != 2 {
+		t.Fatalf("expected two push_retry events, got %#v", retries)
+	}
+}
+
+func TestLoopDoesNotRetryPushForOtherFailures(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	vcs := &fakeVCS{pushErr: errors.New("remote: Permission to acme/app.git denied")}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", VCS: vcs, MergeOnSuccess: true, PushRetries: DefaultPushRetries})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || countCalls(vcs.calls, "push_main") != 1 {
+		t.Fatalf("expected a non-rejection push failure to block at once, got %#v calls=%v", summary, vcs.calls)
+	}
+}
+
+func TestCanBatchLandingRequiresSameWorkspace(t *testing.T) {
+	ctx := context.Background()
+	head := newLandingTicket(ctx, contracts.Task{ID: "a"}, &fetchingVCS{}, "task/a", "worker-0", "/clones/a", 0)
+	sameRepo := newLandingTicket(ctx, contracts.Task{ID: "b"}, &fetchingVCS{}, "task/b", "worker-1", "/clones/b", 0)
+	otherRepo := newLandingTicket(ctx, contracts.Task{ID: "c"}, &fetchingVCS{}, "task/c", "worker-2", "/clones/c", 0)
+	otherRepo.workspace = Workspace{Repo: "git@example.com:acme/docs.git"}
+
+	if !canBatchLanding(head, sameRepo) {
+		t.Fatalf("expected clones of the run's repository to batch")
+	}
+	if canBatchLanding(head, otherRepo) {
+		t.Fatalf("expected a task in another workspace not to batch")
+	}
+}
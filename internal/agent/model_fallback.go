@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ModelTarget is one entry of the model fallback chain. An empty Backend
+// keeps the backend of the task.
+type ModelTarget struct {
+	Backend string
+	Model   string
+}
+
+func (t ModelTarget) sameAs(other ModelTarget) bool {
+	return strings.EqualFold(strings.TrimSpace(t.Backend), strings.TrimSpace(other.Backend)) &&
+		strings.EqualFold(strings.TrimSpace(t.Model), strings.TrimSpace(other.Model))
+}
+
+// modelFallbacks returns the targets tried, in order, after the task's own
+// backend and model fail with a provider error: FallbackChain followed by
+// FallbackModel. Entries that repeat an earlier target are skipped.
+func (l *Loop) modelFallbacks(primary ModelTarget) []ModelTarget {
+	candidates := append([]ModelTarget{}, l.options.FallbackChain...)
+	if model := strings.TrimSpace(l.options.FallbackModel); model != "" {
+		candidates = append(candidates, ModelTarget{Model: model})
+	}
+	if strings.TrimSpace(primary.Model) == "" || len(candidates) == 0 {
+		return nil
+	}
+	seen := []ModelTarget{primary}
+	fallbacks := make([]ModelTarget, 0, len(candidates))
+	for _, candidate := range candidates {
+		target := ModelTarget{Backend: strings.TrimSpace(candidate.Backend), Model: strings.TrimSpace(candidate.Model)}
+		if target.Backend == "" {
+			target.Backend = primary.Backend
+		}
+		if target.Model == "" {
+			continue
+		}
+		duplicate := false
+		for _, previous := range seen {
+			if previous.sameAs(target) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		seen = append(seen, target)
+		fallbacks = append(fallbacks, target)
+	}
+	return fallbacks
+}
+
+// buildCompletedByMetadata records the backend and model that completed a
+// task and how many fallbacks it took to get there.
+func buildCompletedByMetadata(backend string, model string, fallbackAttempts int) map[string]string {
+	metadata := map[string]string{}
+	if backend = strings.TrimSpace(backend); backend != "" {
+		metadata["completed_backend"] = backend
+	}
+	if model = strings.TrimSpace(model); model != "" {
+		metadata["completed_model"] = model
+	}
+	if fallbackAttempts > 0 {
+		metadata["model_fallback_attempts"] = strconv.Itoa(fallbackAttempts)
+	}
+	return metadata
+}
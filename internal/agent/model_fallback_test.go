@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestModelFallbacksInheritBackendAndSkipRepeats(t *testing.T) {
+	loop := NewLoop(newFakeTaskManager(), &fakeRunner{}, nil, LoopOptions{
+		FallbackChain: []ModelTarget{
+			{Model: "openai/gpt-5.3-codex"},
+			{Backend: "claude", Model: "claude-sonnet"},
+			{Backend: "codex", Model: "gpt-5.3-mini"},
+			{Backend: "Claude", Model: "claude-sonnet"},
+		},
+		FallbackModel: "gpt-5.3-mini",
+	})
+
+	got := loop.modelFallbacks(ModelTarget{Backend: "codex", Model: "openai/gpt-5.3-codex"})
+	want := []ModelTarget{
+		{Backend: "claude", Model: "claude-sonnet"},
+		{Backend: "codex", Model: "gpt-5.3-mini"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected fallbacks %#v", got)
+	}
+}
+
+func TestLoopWalksModelFallbackChainAndRecordsCompletingModel(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultFailed, Reason: "provider error: upstream unavailable"},
+		{Status: contracts.RunnerResultFailed, Reason: "invalid json response from model"},
+		{Status: contracts.RunnerResultCompleted},
+	}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{
+		ParentID: "root",
+		Backend:  "codex",
+		Model:    "gpt-5.3-codex",
+		FallbackChain: []ModelTarget{
+			{Backend: "claude", Model: "claude-sonnet"},
+			{Backend: "kimi", Model: "kimi-k2"},
+		},
+	})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 || len(run.requests) != 3 {
+		t.Fatalf("expected completion on the last fallback, got %#v after %d requests", summary, len(run.requests))
+	}
+	for i, want := range []ModelTarget{{"codex", "gpt-5.3-codex"}, {"claude", "claude-sonnet"}, {"kimi", "kimi-k2"}} {
+		got := ModelTarget{Backend: run.requests[i].Metadata["backend"], Model: run.requests[i].Model}
+		if got != want {
+			t.Fatalf("request %d: expected %#v, got %#v", i, want, got)
+		}
+	}
+
+	data := mgr.dataByID["t-1"]
+	if data["completed_backend"] != "kimi" || data["completed_model"] != "kimi-k2" || data["model_fallback_attempts"] != "2" {
+		t.Fatalf("expected completing model in task data, got %#v", data)
+	}
+	finished := eventsByType(sink.events, contracts.EventTypeTaskFinished)
+	if len(finished) != 1 || finished[0].Metadata["completed_model"] != "kimi-k2" {
+		t.Fatalf("expected completing model on task_finished, got %#v", finished)
+	}
+	started := eventsByType(sink.events, contracts.EventTypeRunnerStarted)
+	if started[2].Metadata["backend_previous"] != "claude" || started[2].Metadata["model_fallback_attempt"] != "2" {
+		t.Fatalf("expected fallback attempt metadata, got %#v", started[2].Metadata)
+	}
+}
+
+func TestLoopStopsAtEndOfModelFallbackChain(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultFailed, Reason: "provider error"},
+		{Status: contracts.RunnerResultFailed, Reason: "provider error"},
+	}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{
+		ParentID:      "root",
+		Model:         "primary-model",
+		FallbackChain: []ModelTarget{{Model: "fallback-model"}},
+	})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 0 || len(run.requests) != 2 {
+		t.Fatalf("expected one fallback attempt, got %#v after %d requests", summary, len(run.requests))
+	}
+	if got := mgr.dataByID["t-1"]["completed_model"]; got != "" {
+		t.Fatalf("did not expect completing model on failed task, got %q", got)
+	}
+}
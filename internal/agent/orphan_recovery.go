@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// OrphanedTaskPolicy selects what startup recovery does with a task a crashed
+// run left in_progress.
+type OrphanedTaskPolicy string
+
+const (
+	// OrphanedTaskReopen reopens the task so this run picks it up again
+	// (the default).
+	OrphanedTaskReopen OrphanedTaskPolicy = "reopen"
+	// OrphanedTaskBlock blocks the task with an orphaned triage so an
+	// operator looks at it before it runs again.
+	OrphanedTaskBlock OrphanedTaskPolicy = "block"
+)
+
+const orphanedTaskReason = "left in_progress by a run that did not finish"
+
+// ParseOrphanedTaskPolicy accepts reopen and block; empty means reopen.
+func ParseOrphanedTaskPolicy(raw string) (OrphanedTaskPolicy, error) {
+	switch policy := OrphanedTaskPolicy(strings.ToLower(strings.TrimSpace(raw))); policy {
+	case "", OrphanedTaskReopen:
+		return OrphanedTaskReopen, nil
+	case OrphanedTaskBlock:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unsupported orphaned task policy %q (supported: %s, %s)", raw, OrphanedTaskReopen, OrphanedTaskBlock)
+	}
+}
+
+// recoverOrphanedTask handles a task the scheduler state still lists in
+// flight when the run starts, so its previous run ended without recording an
+// outcome. Its stale clone and task lock are released. A task the tracker
+// still shows in_progress is reopened or blocked per OrphanedTasks; any other
+// status is an outcome the tracker did record, and is kept.
+func (l *Loop) recoverOrphanedTask(ctx context.Context, taskID string) error {
+	if l.taskLock != nil {
+		l.taskLock.Unlock(taskID)
+	}
+	if l.cloneManager != nil {
+		if err := l.cloneManager.Cleanup(taskID); err != nil {
+			return fmt.Errorf("clean up stale clone of %s: %w", taskID, err)
+		}
+	}
+	task, err := l.tasks.GetTask(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if task.Status != contracts.TaskStatusInProgress {
+		return nil
+	}
+
+	// The crashed run stamped its ID on the task when it started it.
+	orphanedBy := strings.TrimSpace(task.Metadata[contracts.RunIDTaskDataKey])
+	if l.options.OrphanedTasks != OrphanedTaskBlock {
+		if err := l.tasks.SetTaskStatus(ctx, taskID, contracts.TaskStatusOpen); err != nil {
+			return err
+		}
+		metadata := appendDecisionMetadata(map[string]string{"status": string(contracts.TaskStatusOpen), "orphaned_run_id": orphanedBy}, "recovered", orphanedTaskReason)
+		_ = l.emit(ctx, contracts.Event{
+			Type:      contracts.EventTypeTaskFinished,
+			TaskID:    task.ID,
+			TaskTitle: task.Title,
+			Message:   string(contracts.TaskStatusOpen),
+			Metadata:  compactMetadata(metadata),
+			Timestamp: time.Now().UTC(),
+		})
+		return nil
+	}
+
+	blockedData := map[string]string{"triage_status": "blocked", "triage_reason": orphanedTaskReason}
+	if orphanedBy != "" {
+		blockedData["orphaned_run_id"] = orphanedBy
+	}
+	blockedData = appendDecisionMetadata(blockedData, "blocked", orphanedTaskReason)
+	blockedData = appendTriageCategory(blockedData, contracts.FailureCategoryOrphaned)
+	if err := l.tasks.SetTaskStatus(ctx, taskID, contracts.TaskStatusBlocked); err != nil {
+		return err
+	}
+	if err := l.setTaskData(ctx, taskID, blockedData); err != nil {
+		return err
+	}
+	_ = l.emit(ctx, contracts.Event{
+		Type:      contracts.EventTypeTaskFinished,
+		TaskID:    task.ID,
+		TaskTitle: task.Title,
+		Message:   string(contracts.TaskStatusBlocked),
+		Metadata:  blockedData,
+		Timestamp: time.Now().UTC(),
+	})
+	return nil
+}
@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func writeInFlightSchedulerState(t *testing.T, taskIDs ...string) string {
+	t.Helper()
+	statePath := filepath.Join(t.TempDir(), "scheduler-state.json")
+	store := newSchedulerStateStore(statePath, "root")
+	snapshot, err := store.Load()
+	if err != nil {
+		t.Fatalf("load scheduler state: %v", err)
+	}
+	for _, taskID := range taskIDs {
+		snapshot.InFlight[taskID] = struct{}{}
+	}
+	if err := store.Save(snapshot); err != nil {
+		t.Fatalf("save scheduler state: %v", err)
+	}
+	return statePath
+}
+
+func TestLoopReopensTaskOrphanedByCrashedRun(t *testing.T) {
+	statePath := writeInFlightSchedulerState(t, "t-1")
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusInProgress})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", SchedulerStatePath: statePath})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 || len(run.requests) != 1 {
+		t.Fatalf("expected the orphaned task to run again, got %#v", summary)
+	}
+	recovered := false
+	for _, event := range sink.events {
+		if event.Type == contracts.EventTypeTaskFinished && event.Metadata["decision"] == "recovered" {
+			recovered = true
+		}
+	}
+	if !recovered {
+		t.Fatalf("expected a recovered task_finished event, got %#v", sink.events)
+	}
+}
+
+func TestLoopBlocksOrphanedTaskAndCleansItsClone(t *testing.T) {
+	statePath := writeInFlightSchedulerState(t, "t-1")
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusInProgress})
+	run := &fakeRunner{}
+	clones := newFakeCloneManager()
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", SchedulerStatePath: statePath, CloneManager: clones, OrphanedTasks: OrphanedTaskBlock})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if len(run.requests) != 0 {
+		t.Fatalf("expected the blocked task not to run, got %#v", run.requests)
+	}
+	if mgr.statusByID["t-1"] != contracts.TaskStatusBlocked {
+		t.Fatalf("expected t-1 blocked, got %s", mgr.statusByID["t-1"])
+	}
+	if got := mgr.dataByID["t-1"][contracts.TriageCategoryMetadataKey]; got != string(contracts.FailureCategoryOrphaned) {
+		t.Fatalf("expected orphaned triage category, got %q", got)
+	}
+	if clones.cleanupByID["t-1"] != 1 {
+		t.Fatalf("expected the stale clone to be cleaned up once, got %d", clones.cleanupByID["t-1"])
+	}
+	snapshot, err := newSchedulerStateStore(statePath, "root").Load()
+	if err != nil {
+		t.Fatalf("load scheduler state: %v", err)
+	}
+	if len(snapshot.InFlight) != 0 {
+		t.Fatalf("expected the in-flight set cleared, got %#v", snapshot.InFlight)
+	}
+}
+
+func TestLoopKeepsOutcomeTrackerRecordedForInFlightTask(t *testing.T) {
+	statePath := writeInFlightSchedulerState(t, "t-1")
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusClosed})
+	run := &fakeRunner{}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", SchedulerStatePath: statePath, OrphanedTasks: OrphanedTaskBlock})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if mgr.statusByID["t-1"] != contracts.TaskStatusClosed || len(run.requests) != 0 {
+		t.Fatalf("expected closed t-1 to stay closed, got %s after %d runs", mgr.statusByID["t-1"], len(run.requests))
+	}
+}
+
+func TestParseOrphanedTaskPolicy(t *testing.T) {
+	for raw, want := range map[string]OrphanedTaskPolicy{"": OrphanedTaskReopen, "reopen": OrphanedTaskReopen, " Block ": OrphanedTaskBlock} {
+		got, err := ParseOrphanedTaskPolicy(raw)
+		if err != nil || got != want {
+			t.Fatalf("ParseOrphanedTaskPolicy(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, err := ParseOrphanedTaskPolicy("fail"); err == nil {
+		t.Fatalf("expected an unknown policy to be rejected")
+	}
+}
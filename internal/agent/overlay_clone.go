@@ -0,0 +1,327 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// CloneStrategy is how a task gets its own copy of the repository.
+type CloneStrategy string
+
+const (
+	// CloneStrategyClone gives every task a full git clone.
+	CloneStrategyClone CloneStrategy = "clone"
+	// CloneStrategyOverlay layers each task's writes on one shared
+	// read-only checkout, falling back to clones where no overlay or
+	// copy-on-write support is available.
+	CloneStrategyOverlay CloneStrategy = "overlay"
+)
+
+// ParseCloneStrategy parses a clone strategy; empty means clone.
+func ParseCloneStrategy(raw string) (CloneStrategy, error) {
+	switch CloneStrategy(strings.ToLower(strings.TrimSpace(raw))) {
+	case "", CloneStrategyClone:
+		return CloneStrategyClone, nil
+	case CloneStrategyOverlay:
+		return CloneStrategyOverlay, nil
+	default:
+		return "", fmt.Errorf("unsupported clone strategy %q (expected clone or overlay)", raw)
+	}
+}
+
+// overlayMethod is one way to give a task a writable view of the base
+// checkout. remove is nil for methods that leave a plain directory.
+type overlayMethod struct {
+	name   string
+	create func(ctx context.Context, base string, upper string, work string, merged string) error
+	remove func(merged string) error
+}
+
+// defaultOverlayMethods lists the methods in order of preference: a kernel
+// overlay mount, which needs privileges, an unprivileged FUSE overlay and a
+// reflink copy on filesystems with copy-on-write (btrfs, XFS, APFS).
+func defaultOverlayMethods() []overlayMethod {
+	methods := []overlayMethod{}
+	if runtime.GOOS == "linux" {
+		methods = append(methods, overlayMethod{name: "overlayfs", create: mountKernelOverlay, remove: unmountKernelOverlay})
+		if _, err := exec.LookPath("fuse-overlayfs"); err == nil {
+			methods = append(methods, overlayMethod{name: "fuse-overlayfs", create: mountFuseOverlay, remove: unmountFuseOverlay})
+		}
+	}
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		methods = append(methods, overlayMethod{name: "reflink", create: reflinkCopy})
+	}
+	return methods
+}
+
+type overlayLayer struct {
+	merged string
+	dir    string
+	method overlayMethod
+}
+
+// OverlayCloneManager is a CloneManager for very large repositories. It keeps
+// one shared checkout of the repository and gives each task an overlay of
+// it, so disk usage grows with what tasks write rather than with the number
+// of tasks. The first method that works is used for the rest of the run; if
+// none does, or the chosen one fails for a task, the task gets a clone as
+// with GitCloneManager. The shared checkout is refreshed from the source
+// whenever no task is using it.
+type OverlayCloneManager struct {
+	clones   *GitCloneManager
+	repoRoot string
+	basePath string
+	layerDir string
+
+	mu        sync.Mutex
+	methods   []overlayMethod
+	chosen    *overlayMethod
+	exhausted bool
+	active    int
+	layers    map[string]*overlayLayer
+}
+
+func NewOverlayCloneManager(baseDir string, repoRoot string) *OverlayCloneManager {
+	manager := NewGitCloneManager(baseDir)
+	return &OverlayCloneManager{
+		clones:   manager,
+		repoRoot: filepath.Clean(repoRoot),
+		basePath: filepath.Join(manager.baseDir, ".base"),
+		layerDir: filepath.Join(manager.baseDir, ".overlay"),
+		methods:  defaultOverlayMethods(),
+		layers:   map[string]*overlayLayer{},
+	}
+}
+
+func (m *OverlayCloneManager) CloneForTask(ctx context.Context, taskID string, repoRoot string) (string, error) {
+	if strings.TrimSpace(repoRoot) == "" || filepath.Clean(repoRoot) != m.repoRoot {
+		return m.clones.CloneForTask(ctx, taskID, repoRoot)
+	}
+	if err := m.acquireBase(ctx); err != nil {
+		return m.clones.CloneForTask(ctx, taskID, repoRoot)
+	}
+	if merged, ok := m.layer(ctx, taskID); ok {
+		return merged, nil
+	}
+	m.releaseBase()
+	return m.clones.CloneForTask(ctx, taskID, repoRoot)
+}
+
+// WithRunID names task overlays <task>@<runID>; see
+// GitCloneManager.WithRunID.
+func (m *OverlayCloneManager) WithRunID(runID string) *OverlayCloneManager {
+	m.clones.WithRunID(runID)
+	return m
+}
+
+// CloneWorkspaceForTask clones the task's workspace repository without an
+// overlay.
+func (m *OverlayCloneManager) CloneWorkspaceForTask(ctx context.Context, taskID string, workspace Workspace) (string, error) {
+	return m.clones.CloneWorkspaceForTask(ctx, taskID, workspace)
+}
+
+// Cleanup removes the task's overlay, or its clone when it got one. An
+// overlay that cannot be unmounted is left in place, since removing files
+// through it is not safe.
+func (m *OverlayCloneManager) Cleanup(taskID string) error {
+	m.mu.Lock()
+	layer, ok := m.layers[taskID]
+	delete(m.layers, taskID)
+	m.mu.Unlock()
+	if !ok {
+		return m.clones.Cleanup(taskID)
+	}
+	if layer.method.remove != nil {
+		if err := layer.method.remove(layer.merged); err != nil {
+			// The shared checkout stays marked as used, so it is not
+			// refreshed under the stale mount.
+			return fmt.Errorf("remove %s overlay %s: %w", layer.method.name, layer.merged, err)
+		}
+	}
+	m.releaseBase()
+	return errors.Join(os.RemoveAll(layer.merged), os.RemoveAll(layer.dir))
+}
+
+// Method names the overlay method in use: empty before the first task and
+// "clone" when no method works here.
+func (m *OverlayCloneManager) Method() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case m.chosen != nil:
+		return m.chosen.name
+	case m.exhausted:
+		return string(CloneStrategyClone)
+	default:
+		return ""
+	}
+}
+
+// Close removes the shared checkout once no task uses it.
+func (m *OverlayCloneManager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.active > 0 {
+		return nil
+	}
+	return errors.Join(os.RemoveAll(m.basePath), os.RemoveAll(m.layerDir))
+}
+
+// acquireBase marks the shared checkout as used, first bringing it up to
+// date with the source when no task is using it.
+func (m *OverlayCloneManager) acquireBase(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.active == 0 {
+		if err := m.refreshBaseLocked(ctx); err != nil {
+			return err
+		}
+	}
+	m.active++
+	return nil
+}
+
+func (m *OverlayCloneManager) releaseBase() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active--
+}
+
+func (m *OverlayCloneManager) refreshBaseLocked(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(m.basePath, ".git")); err == nil {
+		if err := resetPooledClone(ctx, m.repoRoot, m.basePath); err == nil {
+			return nil
+		}
+	}
+	if err := os.RemoveAll(m.basePath); err != nil {
+		return err
+	}
+	return cloneSourceRepository(ctx, m.repoRoot, m.basePath)
+}
+
+// layer creates the task's overlay with the chosen method, or tries every
+// method until one works when none is chosen yet.
+func (m *OverlayCloneManager) layer(ctx context.Context, taskID string) (string, bool) {
+	m.mu.Lock()
+	candidates := m.methods
+	if m.chosen != nil {
+		candidates = []overlayMethod{*m.chosen}
+	} else if m.exhausted {
+		candidates = nil
+	}
+	m.mu.Unlock()
+
+	merged := m.clones.clonePath(taskID)
+	dir := filepath.Join(m.layerDir, runScopedName(taskID, m.clones.runID))
+	for _, method := range candidates {
+		if err := m.createLayer(ctx, method, dir, merged); err != nil {
+			continue
+		}
+		m.mu.Lock()
+		if m.chosen == nil {
+			chosen := method
+			m.chosen = &chosen
+		}
+		m.layers[taskID] = &overlayLayer{merged: merged, dir: dir, method: method}
+		m.mu.Unlock()
+		return merged, true
+	}
+
+	m.mu.Lock()
+	if m.chosen == nil {
+		m.exhausted = true
+	}
+	m.mu.Unlock()
+	return "", false
+}
+
+func (m *OverlayCloneManager) createLayer(ctx context.Context, method overlayMethod, dir string, merged string) error {
+	if err := os.RemoveAll(merged); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	upper := filepath.Join(dir, "upper")
+	work := filepath.Join(dir, "work")
+	for _, path := range []string{upper, work} {
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			return err
+		}
+	}
+	if err := method.create(ctx, m.basePath, upper, work, merged); err != nil {
+		_ = os.RemoveAll(merged)
+		_ = os.RemoveAll(dir)
+		return err
+	}
+	return nil
+}
+
+func overlayMountOptions(base string, upper string, work string) (string, error) {
+	for _, path := range []string{base, upper, work} {
+		if strings.ContainsAny(path, ",:") {
+			return "", fmt.Errorf("overlay path %q contains , or :", path)
+		}
+	}
+	return "lowerdir=" + base + ",upperdir=" + upper + ",workdir=" + work, nil
+}
+
+func mountKernelOverlay(ctx context.Context, base string, upper string, work string, merged string) error {
+	options, err := overlayMountOptions(base, upper, work)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(merged, 0o755); err != nil {
+		return err
+	}
+	return runOverlayCommand(ctx, "mount", "-t", "overlay", "overlay", "-o", options, merged)
+}
+
+func unmountKernelOverlay(merged string) error {
+	return runOverlayCommand(context.Background(), "umount", merged)
+}
+
+func mountFuseOverlay(ctx context.Context, base string, upper string, work string, merged string) error {
+	options, err := overlayMountOptions(base, upper, work)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(merged, 0o755); err != nil {
+		return err
+	}
+	return runOverlayCommand(ctx, "fuse-overlayfs", "-o", options, merged)
+}
+
+func unmountFuseOverlay(merged string) error {
+	for _, binary := range []string{"fusermount3", "fusermount"} {
+		if _, err := exec.LookPath(binary); err == nil {
+			return runOverlayCommand(context.Background(), binary, "-u", merged)
+		}
+	}
+	return runOverlayCommand(context.Background(), "umount", merged)
+}
+
+// reflinkCopy copies the base checkout sharing its blocks. On Linux it fails
+// rather than falling back to a full copy on filesystems without
+// copy-on-write.
+func reflinkCopy(ctx context.Context, base string, _ string, _ string, merged string) error {
+	if runtime.GOOS == "darwin" {
+		return runOverlayCommand(ctx, "cp", "-c", "-pR", base, merged)
+	}
+	return runOverlayCommand(ctx, "cp", "-a", "--reflink=always", base, merged)
+}
+
+func runOverlayCommand(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %s: %w", name, strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestOverlayCloneManagerLayersTaskOnSharedBase(t *testing.T) {
+	repoRoot := initClonePoolSourceRepo(t)
+	baseDir := t.TempDir()
+	manager := NewOverlayCloneManager(baseDir, repoRoot)
+	manager.methods = []overlayMethod{copyOverlayMethod("copy")}
+	defer manager.Close()
+
+	clonePath, err := manager.CloneForTask(context.Background(), "t-1", repoRoot)
+	if err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	if clonePath != filepath.Join(baseDir, "t-1") {
+		t.Fatalf("expected overlay at the task path, got %q", clonePath)
+	}
+	if _, err := os.Stat(filepath.Join(clonePath, "README.md")); err != nil {
+		t.Fatalf("expected tracked file in overlay: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, ".base", ".git")); err != nil {
+		t.Fatalf("expected shared base checkout: %v", err)
+	}
+	if method := manager.Method(); method != "copy" {
+		t.Fatalf("expected copy method, got %q", method)
+	}
+
+	if err := manager.Cleanup("t-1"); err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+	for _, path := range []string{clonePath, filepath.Join(baseDir, ".overlay", "t-1")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("expected %s removed, got err=%v", path, err)
+		}
+	}
+}
+
+func TestOverlayCloneManagerFallsBackToCloneWithoutOverlaySupport(t *testing.T) {
+	repoRoot := initClonePoolSourceRepo(t)
+	manager := NewOverlayCloneManager(t.TempDir(), repoRoot)
+	attempts := 0
+	manager.methods = []overlayMethod{{
+		name: "broken",
+		create: func(context.Context, string, string, string, string) error {
+			attempts++
+			return errors.New("not supported")
+		},
+	}}
+	defer manager.Close()
+
+	for _, taskID := range []string{"t-1", "t-2"} {
+		clonePath, err := manager.CloneForTask(context.Background(), taskID, repoRoot)
+		if err != nil {
+			t.Fatalf("clone failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(clonePath, "README.md")); err != nil {
+			t.Fatalf("expected tracked file in fallback clone: %v", err)
+		}
+		if err := manager.Cleanup(taskID); err != nil {
+			t.Fatalf("cleanup failed: %v", err)
+		}
+	}
+	if method := manager.Method(); method != "clone" {
+		t.Fatalf("expected clone fallback, got %q", method)
+	}
+	if attempts != 1 {
+		t.Fatalf("expected the failed method to be tried once per run, got %d attempts", attempts)
+	}
+}
+
+func TestOverlayCloneManagerRefreshesBaseWhenUnused(t *testing.T) {
+	repoRoot := initClonePoolSourceRepo(t)
+	manager := NewOverlayCloneManager(t.TempDir(), repoRoot)
+	manager.methods = []overlayMethod{copyOverlayMethod("copy")}
+	defer manager.Close()
+
+	if _, err := manager.CloneForTask(context.Background(), "t-1", repoRoot); err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	if err := manager.Cleanup("t-1"); err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+	commitClonePoolSourceFile(t, repoRoot, "NEXT.md")
+
+	clonePath, err := manager.CloneForTask(context.Background(), "t-2", repoRoot)
+	if err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(clonePath, "NEXT.md")); err != nil {
+		t.Fatalf("expected the source's new commit in the refreshed base: %v", err)
+	}
+}
+
+func TestOverlayCloneManagerKeepsTaskWritesOutOfBase(t *testing.T) {
+	repoRoot := initClonePoolSourceRepo(t)
+	baseDir := t.TempDir()
+	manager := NewOverlayCloneManager(baseDir, repoRoot)
+	defer manager.Close()
+
+	clonePath, err := manager.CloneForTask(context.Background(), "t-1", repoRoot)
+	if err != nil {
+		t.Fatalf("clone failed: %v", err)
+	}
+	defer func() { _ = manager.Cleanup("t-1") }()
+	if err := os.WriteFile(filepath.Join(clonePath, "work.txt"), []byte("work\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	runGit(t, clonePath, "checkout", "-b", "task/t-1")
+	if _, err := os.Stat(filepath.Join(baseDir, ".base", "work.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected the task's write to stay out of the base (method %q), got err=%v", manager.Method(), err)
+	}
+}
+
+func TestParseCloneStrategy(t *testing.T) {
+	for raw, expected := range map[string]CloneStrategy{"": CloneStrategyClone, "clone": CloneStrategyClone, " Overlay ": CloneStrategyOverlay} {
+		got, err := ParseCloneStrategy(raw)
+		if err != nil || got != expected {
+			t.Fatalf("ParseCloneStrategy(%q) = %q, %v; expected %q", raw, got, err, expected)
+		}
+	}
+	if _, err := ParseCloneStrategy("worktree"); err == nil {
+		t.Fatalf("expected an error for an unknown clone strategy")
+	}
+}
+
+// copyOverlayMethod stands in for an overlay with a plain copy of the base.
+func copyOverlayMethod(name string) overlayMethod {
+	return overlayMethod{
+		name: name,
+		create: func(ctx context.Context, base string, _ string, _ string, merged string) error {
+			return exec.CommandContext(ctx, "cp", "-a", base, merged).Run()
+		},
+	}
+}
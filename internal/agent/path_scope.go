@@ -0,0 +1,281 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// pathScopeViolationLimit caps how many out-of-scope files the triage reason
+// lists.
+const pathScopeViolationLimit = 10
+
+// codeOwnersLocations are where GitHub looks for CODEOWNERS, in order.
+var codeOwnersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// PathScopeConfig restricts the files a task may change based on its
+// labels. A task with no matching label is not restricted.
+type PathScopeConfig struct {
+	// Labels maps a task label to the path globs tasks with that label may
+	// change. "**" matches any number of directories.
+	Labels map[string][]string
+	// CodeOwners treats task labels that name a CODEOWNERS owner (with or
+	// without the leading @) as a scope: such a task may change the files
+	// that owner owns.
+	CodeOwners bool
+}
+
+type codeOwnersRule struct {
+	pattern string
+	owners  []string
+}
+
+// taskPathScope is the resolved scope of one task.
+type taskPathScope struct {
+	globs      []string
+	owners     map[string]struct{}
+	codeOwners []codeOwnersRule
+}
+
+func (s taskPathScope) restricted() bool {
+	return len(s.globs) > 0 || len(s.owners) > 0
+}
+
+func (s taskPathScope) allows(file string) bool {
+	for _, glob := range s.globs {
+		if matchPathGlob(glob, file) {
+			return true
+		}
+	}
+	if len(s.owners) == 0 {
+		return false
+	}
+	// As on GitHub, the last matching CODEOWNERS rule decides ownership.
+	for i := len(s.codeOwners) - 1; i >= 0; i-- {
+		rule := s.codeOwners[i]
+		if !matchCodeOwnersPattern(rule.pattern, file) {
+			continue
+		}
+		for _, owner := range rule.owners {
+			if _, ok := s.owners[owner]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func (s taskPathScope) describe() string {
+	parts := append([]string{}, s.globs...)
+	owners := make([]string, 0, len(s.owners))
+	for owner := range s.owners {
+		owners = append(owners, "CODEOWNERS @"+owner)
+	}
+	sort.Strings(owners)
+	return strings.Join(append(parts, owners...), ", ")
+}
+
+// resolveTaskPathScope collects the globs of the task's labels and, when
+// enabled, the CODEOWNERS owners among them.
+func resolveTaskPathScope(task contracts.Task, config PathScopeConfig, repoRoot string) (taskPathScope, error) {
+	scope := taskPathScope{owners: map[string]struct{}{}}
+	labels := taskLabelSet(task)
+	if len(labels) == 0 {
+		return scope, nil
+	}
+	for label, globs := range config.Labels {
+		if _, ok := labels[normalizeScopeLabel(label)]; ok {
+			scope.globs = append(scope.globs, globs...)
+		}
+	}
+	sort.Strings(scope.globs)
+	if !config.CodeOwners {
+		return scope, nil
+	}
+	rules, err := readCodeOwners(repoRoot)
+	if err != nil {
+		return scope, err
+	}
+	scope.codeOwners = rules
+	for _, rule := range rules {
+		for _, owner := range rule.owners {
+			if _, ok := labels[owner]; ok {
+				scope.owners[owner] = struct{}{}
+			}
+		}
+	}
+	return scope, nil
+}
+
+// taskLabelSet reads the comma-separated labels trackers store in the
+// "labels" (or "label") task metadata.
+func taskLabelSet(task contracts.Task) map[string]struct{} {
+	labels := map[string]struct{}{}
+	for _, key := range []string{"labels", "label"} {
+		for _, part := range strings.Split(task.Metadata[key], ",") {
+			if label := normalizeScopeLabel(part); label != "" {
+				labels[label] = struct{}{}
+			}
+		}
+	}
+	return labels
+}
+
+func normalizeScopeLabel(label string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(label)), "@")
+}
+
+func readCodeOwners(repoRoot string) ([]codeOwnersRule, error) {
+	for _, location := range codeOwnersLocations {
+		file, err := os.Open(filepath.Join(repoRoot, filepath.FromSlash(location)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		rules := []codeOwnersRule{}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Fields(line)
+			rule := codeOwnersRule{pattern: fields[0]}
+			for _, owner := range fields[1:] {
+				if strings.HasPrefix(owner, "#") {
+					break
+				}
+				rule.owners = append(rule.owners, normalizeScopeLabel(owner))
+			}
+			rules = append(rules, rule)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("read %s: %w", location, err)
+		}
+		return rules, nil
+	}
+	return nil, nil
+}
+
+// matchCodeOwnersPattern applies gitignore-style CODEOWNERS semantics: a
+// pattern without an inner slash matches at any depth, and a pattern that
+// names a directory also matches everything below it.
+func matchCodeOwnersPattern(pattern string, file string) bool {
+	glob := strings.TrimPrefix(pattern, "/")
+	if !strings.Contains(strings.TrimSuffix(glob, "/"), "/") && !strings.HasPrefix(pattern, "/") {
+		glob = "**/" + glob
+	}
+	if strings.HasSuffix(glob, "/") {
+		return matchPathGlob(glob+"**", file)
+	}
+	return matchPathGlob(glob, file) || matchPathGlob(glob+"/**", file)
+}
+
+// matchPathGlob matches a slash-separated path against a glob whose "**"
+// segments match any number of directories. A trailing "/" means the whole
+// directory.
+func matchPathGlob(pattern string, name string) bool {
+	pattern = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(pattern), "./"), "/")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return matchGlobSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchGlobSegments(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchGlobSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// isRunnerArtifactPath reports files the runner itself writes into the
+// clone, which never count against a task's scope.
+func isRunnerArtifactPath(file string) bool {
+	return strings.HasPrefix(file, "runner-logs/") || strings.HasPrefix(file, ".yolo-runner/")
+}
+
+// runPathScopeGate blocks the task when the implementation changed files
+// outside the task's path scope. Tasks without a scope, and VCS adapters
+// that cannot list changed files, are not checked.
+func (l *Loop) runPathScopeGate(ctx context.Context, task contracts.Task, taskVCS contracts.VCS, worker string, queuePos int, taskRepoRoot string) (bool, error) {
+	if len(l.options.PathScope.Labels) == 0 && !l.options.PathScope.CodeOwners {
+		return false, nil
+	}
+	lister, ok := taskVCS.(contracts.ChangedFilesLister)
+	if !ok {
+		return false, nil
+	}
+	scope, err := resolveTaskPathScope(task, l.options.PathScope, taskRepoRoot)
+	if err != nil {
+		return false, err
+	}
+	if !scope.restricted() {
+		return false, nil
+	}
+	changed, err := lister.ChangedFiles(ctx)
+	if err != nil {
+		return false, err
+	}
+	outside := []string{}
+	for _, file := range changed {
+		if !isRunnerArtifactPath(file) && !scope.allows(file) {
+			outside = append(outside, file)
+		}
+	}
+	if len(outside) == 0 {
+		return false, nil
+	}
+
+	reason := fmt.Sprintf("changed %d file(s) outside the task's path scope (%s): %s", len(outside), scope.describe(), limitedList(outside))
+	blockedData := appendDecisionMetadata(map[string]string{
+		"triage_status":         "blocked",
+		"triage_reason":         reason,
+		"path_scope":            scope.describe(),
+		"path_scope_violations": strings.Join(outside, ","),
+	}, "blocked", reason)
+	blockedData = appendTriageCategory(blockedData, contracts.FailureCategoryPathScope)
+	return true, l.blockTask(ctx, task, worker, queuePos, taskRepoRoot, blockedData)
+}
+
+// limitedList joins items for a triage reason, listing at most
+// pathScopeViolationLimit of them.
+func limitedList(items []string) string {
+	listed := items
+	if len(listed) > pathScopeViolationLimit {
+		listed = listed[:pathScopeViolationLimit]
+	}
+	text := strings.Join(listed, ", ")
+	if len(items) > len(listed) {
+		text += fmt.Sprintf(" and %d more", len(items)-len(listed))
+	}
+	return text
+}
@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// changedFilesVCS also implements contracts.ChangedFilesLister.
+type changedFilesVCS struct {
+	fakeVCS
+	changed []string
+}
+
+func (f *changedFilesVCS) ChangedFiles(context.Context) ([]string, error) {
+	return f.changed, nil
+}
+
+func TestMatchPathGlob(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"web/**", "web/src/app.ts", true},
+		{"web/", "web/index.html", true},
+		{"web/**", "webapp/index.html", false},
+		{"**/*.md", "README.md", true},
+		{"**/*.md", "docs/guide/intro.md", true},
+		{"internal/*/store.go", "internal/db/store.go", true},
+		{"internal/*/store.go", "internal/db/sql/store.go", false},
+		{"./cmd/**/main.go", "cmd/yolo-agent/main.go", true},
+	} {
+		if got := matchPathGlob(tc.pattern, tc.file); got != tc.want {
+			t.Errorf("matchPathGlob(%q, %q) = %v, want %v", tc.pattern, tc.file, got, tc.want)
+		}
+	}
+}
+
+func TestMatchCodeOwnersPattern(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"*.js", "web/app.js", true},
+		{"/docs/", "docs/guide.md", true},
+		{"/docs/", "web/docs/guide.md", false},
+		{"docs", "web/docs/guide.md", true},
+		{"apps/web", "apps/web/src/app.ts", true},
+		{"apps/web", "lib/apps/web/app.ts", false},
+	} {
+		if got := matchCodeOwnersPattern(tc.pattern, tc.file); got != tc.want {
+			t.Errorf("matchCodeOwnersPattern(%q, %q) = %v, want %v", tc.pattern, tc.file, got, tc.want)
+		}
+	}
+}
+
+func TestResolveTaskPathScopeUsesLabelsAndCodeOwners(t *testing.T) {
+	repoRoot := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoRoot, ".github"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	codeOwners := "# owners\n* @acme/core\n/web/ @acme/web\n/web/legacy/ @acme/core\n"
+	if err := os.WriteFile(filepath.Join(repoRoot, ".github", "CODEOWNERS"), []byte(codeOwners), 0o644); err != nil {
+		t.Fatalf("write CODEOWNERS: %v", err)
+	}
+	task := contracts.Task{ID: "t-1", Metadata: map[string]string{"labels": "bug, @acme/web, Docs"}}
+	scope, err := resolveTaskPathScope(task, PathScopeConfig{Labels: map[string][]string{"docs": {"docs/**"}, "api": {"internal/api/**"}}, CodeOwners: true}, repoRoot)
+	if err != nil {
+		t.Fatalf("resolve scope: %v", err)
+	}
+	if !scope.restricted() || scope.describe() != "docs/**, CODEOWNERS @acme/web" {
+		t.Fatalf("unexpected scope %q", scope.describe())
+	}
+	for file, want := range map[string]bool{
+		"docs/intro.md":       true,
+		"web/src/app.ts":      true,
+		"web/legacy/old.js":   false,
+		"internal/api/api.go": false,
+	} {
+		if got := scope.allows(file); got != want {
+			t.Errorf("allows(%q) = %v, want %v", file, got, want)
+		}
+	}
+
+	unlabeled, err := resolveTaskPathScope(contracts.Task{ID: "t-2"}, PathScopeConfig{Labels: map[string][]string{"docs": {"docs/**"}}, CodeOwners: true}, repoRoot)
+	if err != nil || unlabeled.restricted() {
+		t.Fatalf("expected a task without scoped labels to be unrestricted, got %q err=%v", unlabeled.describe(), err)
+	}
+}
+
+func TestLoopBlocksTaskThatChangesFilesOutsidePathScope(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, Metadata: map[string]string{"labels": "web"}})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	vcs := &changedFilesVCS{changed: []string{"web/app.ts", "internal/db/store.go", "runner-logs/t-1/codex/t-1.jsonl"}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", VCS: vcs, MergeOnSuccess: true, PathScope: PathScopeConfig{Labels: map[string][]string{"web": {"web/**"}}}})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || mgr.statusByID["t-1"] != contracts.TaskStatusBlocked {
+		t.Fatalf("expected out-of-scope change to block the task, got %#v", summary)
+	}
+	data := mgr.dataByID["t-1"]
+	if data["triage_reason"] != "changed 1 file(s) outside the task's path scope (web/**): internal/db/store.go" {
+		t.Fatalf("unexpected triage reason %q", data["triage_reason"])
+	}
+	if data["path_scope_violations"] != "internal/db/store.go" {
+		t.Fatalf("unexpected violations %q", data["path_scope_violations"])
+	}
+	if data[contracts.TriageCategoryMetadataKey] != string(contracts.FailureCategoryPathScope) {
+		t.Fatalf("unexpected triage category %q", data[contracts.TriageCategoryMetadataKey])
+	}
+	if vcs.mergeCalls != 0 {
+		t.Fatalf("expected no landing for an out-of-scope task, got %v", vcs.calls)
+	}
+}
+
+func TestLoopLandsTaskThatStaysInPathScope(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, Metadata: map[string]string{"labels": "web"}})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	vcs := &changedFilesVCS{changed: []string{"web/app.ts"}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", VCS: vcs, MergeOnSuccess: true, PathScope: PathScopeConfig{Labels: map[string][]string{"web": {"web/**"}}}})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 || !strings.Contains(strings.Join(vcs.calls, " "), "merge_to_main:task/t-1") {
+		t.Fatalf("expected in-scope task to land, got %#v calls=%v", summary, vcs.calls)
+	}
+}
@@ -0,0 +1,346 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// PipelineStageType selects what a pipeline stage does.
+type PipelineStageType string
+
+const (
+	// PipelineStageAgent runs a prompt with the task's backend in the
+	// task clone. The stages named implement and review without a prompt
+	// are the built-in implementation and review runs.
+	PipelineStageAgent PipelineStageType = "agent"
+	// PipelineStageCommand runs a shell command in the task clone, e.g. to
+	// regenerate a changelog. Its changes land with the task.
+	PipelineStageCommand PipelineStageType = "command"
+	// PipelineStageGate runs a shell check in the task clone. A failing
+	// gate sends the task back to implementation with the check's output,
+	// like a failed completion.
+	PipelineStageGate PipelineStageType = "gate"
+	// PipelineStageApproval asks an operator through the StageApprover
+	// whether the task may continue.
+	PipelineStageApproval PipelineStageType = "approval"
+	// PipelineStageLand lands the task branch on main. It is the last
+	// stage when present; a pipeline without it does not land tasks.
+	PipelineStageLand PipelineStageType = "land"
+)
+
+const (
+	pipelineStageImplement      = "implement"
+	pipelineStageReview         = "review"
+	defaultPipelineStageTimeout = 10 * time.Minute
+	pipelineStageOutputLimit    = 2000
+)
+
+// pipelineStageNamePattern keeps stage names usable in runner log file names.
+var pipelineStageNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// PipelineStage is one step of a task's pipeline.
+type PipelineStage struct {
+	Name string
+	Type PipelineStageType
+	// Prompt is the instruction of an agent stage.
+	Prompt string
+	// Command is the sh -c command of a command or gate stage.
+	Command string
+	// Timeout bounds a command, gate or agent stage; 0 means 10 minutes
+	// for commands and gates and the task's runner timeout for agents.
+	Timeout time.Duration
+}
+
+// Pipeline is the ordered list of stages each task goes through. The zero
+// value is the built-in implement, review, land flow.
+type Pipeline struct {
+	Stages []PipelineStage
+}
+
+// StageApprover asks an operator whether a task may pass an approval stage.
+type StageApprover func(ctx context.Context, task contracts.Task, stage string) (bool, error)
+
+// ParsePipelineStageType accepts agent, command, gate, approval and land.
+func ParsePipelineStageType(raw string) (PipelineStageType, error) {
+	switch stageType := PipelineStageType(strings.ToLower(strings.TrimSpace(raw))); stageType {
+	case PipelineStageAgent, PipelineStageCommand, PipelineStageGate, PipelineStageApproval, PipelineStageLand:
+		return stageType, nil
+	default:
+		return "", fmt.Errorf("unsupported pipeline stage type %q (supported: %s, %s, %s, %s, %s)", raw, PipelineStageAgent, PipelineStageCommand, PipelineStageGate, PipelineStageApproval, PipelineStageLand)
+	}
+}
+
+// NewPipeline validates stages. The pipeline starts with the built-in
+// implement stage; review, when present, is the built-in review stage; land,
+// when present, is last. Other stages run between them in order. No stages
+// means the built-in flow.
+func NewPipeline(stages []PipelineStage) (Pipeline, error) {
+	if len(stages) == 0 {
+		return Pipeline{}, nil
+	}
+	validated := make([]PipelineStage, len(stages))
+	seen := map[string]bool{}
+	for i, stage := range stages {
+		name := strings.TrimSpace(stage.Name)
+		if !pipelineStageNamePattern.MatchString(name) {
+			return Pipeline{}, fmt.Errorf("stage %d name %q must be letters, digits, dots, dashes or underscores", i, name)
+		}
+		if _, err := ParsePipelineStageType(string(stage.Type)); err != nil {
+			return Pipeline{}, fmt.Errorf("stage %q: %w", name, err)
+		}
+		if seen[name] {
+			return Pipeline{}, fmt.Errorf("stage %q is listed twice", name)
+		}
+		seen[name] = true
+		builtin := name == pipelineStageImplement || name == pipelineStageReview
+		switch {
+		case i == 0 && name != pipelineStageImplement:
+			return Pipeline{}, fmt.Errorf("the first stage must be %s, got %q", pipelineStageImplement, name)
+		case builtin && (stage.Type != PipelineStageAgent || strings.TrimSpace(stage.Prompt) != ""):
+			return Pipeline{}, fmt.Errorf("stage %q is built in and must be type %s without a prompt", name, PipelineStageAgent)
+		case stage.Type == PipelineStageLand && i != len(stages)-1:
+			return Pipeline{}, fmt.Errorf("land stage %q must be the last stage", name)
+		case stage.Type == PipelineStageAgent && !builtin && strings.TrimSpace(stage.Prompt) == "":
+			return Pipeline{}, fmt.Errorf("agent stage %q needs a prompt", name)
+		case (stage.Type == PipelineStageCommand || stage.Type == PipelineStageGate) && strings.TrimSpace(stage.Command) == "":
+			return Pipeline{}, fmt.Errorf("%s stage %q needs a command", stage.Type, name)
+		case stage.Timeout < 0:
+			return Pipeline{}, fmt.Errorf("stage %q timeout must not be negative", name)
+		}
+		stage.Name = name
+		validated[i] = stage
+	}
+	return Pipeline{Stages: validated}, nil
+}
+
+func (p Pipeline) configured() bool {
+	return len(p.Stages) > 0
+}
+
+// reviews reports whether completed tasks go through the built-in review.
+func (p Pipeline) reviews() bool {
+	return !p.configured() || p.index(pipelineStageReview) >= 0
+}
+
+// lands reports whether completed tasks are landed.
+func (p Pipeline) lands() bool {
+	return !p.configured() || p.Stages[len(p.Stages)-1].Type == PipelineStageLand
+}
+
+func (p Pipeline) index(name string) int {
+	for i, stage := range p.Stages {
+		if stage.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// customStages returns the stages that run after implementation and before
+// review (beforeReview) or after review and before landing. Without a
+// review stage they all run after implementation.
+func (p Pipeline) customStages(beforeReview bool) []PipelineStage {
+	if !p.configured() {
+		return nil
+	}
+	review := p.index(pipelineStageReview)
+	if review < 0 && beforeReview {
+		return nil
+	}
+	stages := []PipelineStage{}
+	for i, stage := range p.Stages {
+		if stage.Name == pipelineStageImplement || stage.Name == pipelineStageReview || stage.Type == PipelineStageLand {
+			continue
+		}
+		if review >= 0 && (i < review) != beforeReview {
+			continue
+		}
+		stages = append(stages, stage)
+	}
+	return stages
+}
+
+// pipelineStageOutcome is the result of the custom stages at one point of
+// the pipeline. A failed gate asks for another implementation attempt; any
+// other failed stage blocks the task.
+type pipelineStageOutcome struct {
+	gateFailure string
+	blocked     bool
+}
+
+// runPipelineStages runs the custom stages at one point of the pipeline in
+// order and stops at the first failure.
+func (l *Loop) runPipelineStages(ctx context.Context, beforeReview bool, task contracts.Task, runtime taskRuntimeConfig, backend string, model string, epicID string, worker string, repoRoot string, queuePos int) (pipelineStageOutcome, error) {
+	for _, stage := range l.options.Pipeline.customStages(beforeReview) {
+		startedMeta := map[string]string{"pipeline_stage": stage.Name, "pipeline_stage_type": string(stage.Type)}
+		_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypePipelineStageStarted, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: repoRoot, QueuePos: queuePos, Message: stage.Name, Metadata: startedMeta, Timestamp: time.Now().UTC()})
+
+		var failure string
+		var output string
+		switch stage.Type {
+		case PipelineStageAgent:
+			failure = l.runPipelineAgentStage(ctx, stage, task, runtime, backend, model, epicID, worker, repoRoot, queuePos)
+		case PipelineStageCommand, PipelineStageGate:
+			output, failure = l.runPipelineCommandStage(ctx, stage, task, repoRoot)
+		case PipelineStageApproval:
+			failure = l.runPipelineApprovalStage(ctx, stage, task)
+		}
+
+		finishedMeta := compactMetadata(map[string]string{
+			"pipeline_stage":        stage.Name,
+			"pipeline_stage_type":   string(stage.Type),
+			"pipeline_stage_output": tailOutput(output, pipelineStageOutputLimit),
+			"reason":                failure,
+		})
+		status := "passed"
+		if failure != "" {
+			status = "failed"
+		}
+		_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypePipelineStageFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: repoRoot, QueuePos: queuePos, Message: status, Metadata: finishedMeta, Timestamp: time.Now().UTC()})
+		if failure == "" {
+			continue
+		}
+		if stage.Type == PipelineStageGate {
+			return pipelineStageOutcome{gateFailure: failure}, nil
+		}
+		blockedData := appendDecisionMetadata(map[string]string{
+			"triage_status":         "blocked",
+			"triage_reason":         failure,
+			"pipeline_stage":        stage.Name,
+			"pipeline_stage_type":   string(stage.Type),
+			"pipeline_stage_output": tailOutput(output, pipelineStageOutputLimit),
+		}, "blocked", failure)
+		blockedData = appendTriageCategory(compactMetadata(blockedData), contracts.FailureCategoryPipelineStage)
+		return pipelineStageOutcome{blocked: true}, l.blockTask(ctx, task, worker, queuePos, repoRoot, blockedData)
+	}
+	return pipelineStageOutcome{}, nil
+}
+
+func (l *Loop) runPipelineAgentStage(ctx context.Context, stage PipelineStage, task contracts.Task, runtime taskRuntimeConfig, backend string, model string, epicID string, worker string, repoRoot string, queuePos int) string {
+	logPath := runnerLogPathWithSuffix(defaultRunnerLogPath(repoRoot, task.ID, epicID, backend), stage.Name)
+	if err := ensureRunnerLogDirectory(repoRoot, logPath); err != nil {
+		return fmt.Sprintf("pipeline stage %q: %v", stage.Name, err)
+	}
+	timeout := runtime.timeout
+	if stage.Timeout > 0 {
+		timeout = stage.Timeout
+	}
+	metadata := map[string]string{"log_path": logPath, "clone_path": repoRoot, "pipeline_stage": stage.Name}
+	metadata = appendTaskRuntimeMetadata(metadata, runtime)
+	if l.options.WatchdogTimeout > 0 {
+		metadata["watchdog_timeout"] = l.options.WatchdogTimeout.String()
+	}
+	if l.options.WatchdogInterval > 0 {
+		metadata["watchdog_interval"] = l.options.WatchdogInterval.String()
+	}
+	contracts.SetWatchdogHooks(metadata, l.options.WatchdogHooks, l.options.WatchdogHookTimeout)
+	startMeta := buildRunnerStartedMetadata(contracts.RunnerModeImplement, backend, model, repoRoot, logPath, time.Now().UTC())
+	appendTaskRuntimeMetadata(startMeta, runtime)
+	startMeta["pipeline_stage"] = stage.Name
+	_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerStarted, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: repoRoot, QueuePos: queuePos, Message: string(contracts.RunnerModeImplement), Metadata: startMeta, Timestamp: time.Now().UTC()})
+
+	result, err := l.runRunnerWithMonitoring(ctx, contracts.RunnerRequest{
+		TaskID:     task.ID,
+		ParentID:   l.options.ParentID,
+		Mode:       contracts.RunnerModeImplement,
+		RepoRoot:   repoRoot,
+		Model:      model,
+		Timeout:    timeout,
+		Prompt:     buildPipelineAgentPrompt(task, stage),
+		Metadata:   metadata,
+		Env:        l.runnerEnv(task),
+		ToolPolicy: l.options.ToolPolicy,
+	}, task.ID, task.Title, worker, repoRoot, queuePos)
+	if err != nil {
+		result = contracts.RunnerResult{Status: contracts.RunnerResultFailed, Reason: err.Error()}
+	}
+	l.collectRunnerArtifacts(task.ID, repoRoot, result)
+	_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeRunnerFinished, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: repoRoot, QueuePos: queuePos, Message: string(result.Status), Metadata: buildRunnerFinishedMetadata(result), Timestamp: time.Now().UTC()})
+	if result.Status == contracts.RunnerResultCompleted {
+		return ""
+	}
+	reason := fmt.Sprintf("pipeline stage %q %s", stage.Name, result.Status)
+	if detail := strings.TrimSpace(result.Reason); detail != "" {
+		reason += ": " + detail
+	}
+	return reason
+}
+
+// runPipelineCommandStage runs a command or gate stage like a pre-landing
+// hook: sh -c in the clone with the task's TaskEnv, secrets masked and
+// ARTIFACT lines collected.
+func (l *Loop) runPipelineCommandStage(ctx context.Context, stage PipelineStage, task contracts.Task, repoRoot string) (string, string) {
+	timeout := stage.Timeout
+	if timeout <= 0 {
+		timeout = defaultPipelineStageTimeout
+	}
+	stageCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	output, err := runLandingHookCommand(stageCtx, repoRoot, stage.Command, l.taskEnv(task))
+	output = l.maskSecrets(output)
+	if err != nil && errors.Is(stageCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	l.collectTaskArtifacts(task.ID, repoRoot, contracts.ParseTaskArtifacts(output))
+	if err == nil {
+		return output, ""
+	}
+	reason := fmt.Sprintf("pipeline %s %q failed: %v", stage.Type, stage.Name, err)
+	if line := strings.TrimSpace(lastNonEmptyLine(output)); line != "" {
+		reason += ": " + line
+	}
+	return output, reason
+}
+
+func (l *Loop) runPipelineApprovalStage(ctx context.Context, stage PipelineStage, task contracts.Task) string {
+	reason := fmt.Sprintf("pipeline approval %q", stage.Name)
+	if l.options.StageApprover == nil {
+		return reason + ": no approver configured"
+	}
+	approved, err := l.options.StageApprover(ctx, task, stage.Name)
+	switch {
+	case err != nil:
+		return reason + ": approval failed: " + err.Error()
+	case !approved:
+		return reason + ": denied by operator"
+	default:
+		return ""
+	}
+}
+
+func buildPipelineAgentPrompt(task contracts.Task, stage PipelineStage) string {
+	sections := []string{
+		"Mode: Implementation",
+		"Task ID: " + task.ID,
+		"Title: " + task.Title,
+		"Pipeline Stage: " + stage.Name,
+		strings.Join([]string{
+			"Command Contract:",
+			"- The task is implemented; this stage runs one extra step on the task branch.",
+			"- Do not call task-selection/status tools (the runner owns task state).",
+			"- Keep edits scoped to what this stage asks for.",
+		}, "\n"),
+		"Stage Instructions:\n" + strings.TrimSpace(stage.Prompt),
+	}
+	if strings.TrimSpace(task.Description) != "" {
+		sections = append(sections, "Task Description:\n"+task.Description)
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// runnerLogPathWithSuffix keeps an extra run out of the implement log, whose
+// transcript it would otherwise replace.
+func runnerLogPathWithSuffix(logPath string, suffix string) string {
+	if logPath == "" {
+		return ""
+	}
+	ext := filepath.Ext(logPath)
+	return strings.TrimSuffix(logPath, ext) + "." + suffix + ext
+}
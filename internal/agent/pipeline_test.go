@@ -0,0 +1,178 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestNewPipelineValidatesStages(t *testing.T) {
+	implement := PipelineStage{Name: "implement", Type: PipelineStageAgent}
+	review := PipelineStage{Name: "review", Type: PipelineStageAgent}
+	land := PipelineStage{Name: "land", Type: PipelineStageLand}
+	cases := []struct {
+		name    string
+		stages  []PipelineStage
+		wantErr string
+	}{
+		{name: "built-in flow", stages: []PipelineStage{implement, review, land}},
+		{name: "extra stages", stages: []PipelineStage{implement, {Name: "docs", Type: PipelineStageAgent, Prompt: "Update the docs."}, review, {Name: "scan", Type: PipelineStageGate, Command: "make scan"}, {Name: "sign-off", Type: PipelineStageApproval}, land}},
+		{name: "no land", stages: []PipelineStage{implement, review}},
+		{name: "implement not first", stages: []PipelineStage{review, implement}, wantErr: "first stage must be implement"},
+		{name: "land not last", stages: []PipelineStage{implement, land, review}, wantErr: "must be the last stage"},
+		{name: "duplicate", stages: []PipelineStage{implement, review, review}, wantErr: "listed twice"},
+		{name: "unknown type", stages: []PipelineStage{implement, {Name: "x", Type: "deploy"}}, wantErr: "unsupported pipeline stage type"},
+		{name: "agent without prompt", stages: []PipelineStage{implement, {Name: "docs", Type: PipelineStageAgent}}, wantErr: "needs a prompt"},
+		{name: "gate without command", stages: []PipelineStage{implement, {Name: "scan", Type: PipelineStageGate}}, wantErr: "needs a command"},
+		{name: "built-in with prompt", stages: []PipelineStage{implement, {Name: "review", Type: PipelineStageAgent, Prompt: "Be strict."}}, wantErr: "is built in"},
+		{name: "bad name", stages: []PipelineStage{implement, {Name: "a/b", Type: PipelineStageApproval}}, wantErr: "must be letters"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewPipeline(tc.stages)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPipelineCustomStagesSplitAtReview(t *testing.T) {
+	pipeline, err := NewPipeline([]PipelineStage{
+		{Name: "implement", Type: PipelineStageAgent},
+		{Name: "docs", Type: PipelineStageAgent, Prompt: "Update the docs."},
+		{Name: "review", Type: PipelineStageAgent},
+		{Name: "scan", Type: PipelineStageGate, Command: "make scan"},
+		{Name: "land", Type: PipelineStageLand},
+	})
+	if err != nil {
+		t.Fatalf("pipeline: %v", err)
+	}
+	if before := pipeline.customStages(true); len(before) != 1 || before[0].Name != "docs" {
+		t.Fatalf("expected docs before review, got %#v", before)
+	}
+	if after := pipeline.customStages(false); len(after) != 1 || after[0].Name != "scan" {
+		t.Fatalf("expected scan after review, got %#v", after)
+	}
+	if !pipeline.reviews() || !pipeline.lands() {
+		t.Fatalf("expected the pipeline to review and land")
+	}
+
+	noReview, err := NewPipeline([]PipelineStage{{Name: "implement", Type: PipelineStageAgent}, {Name: "scan", Type: PipelineStageGate, Command: "make scan"}})
+	if err != nil {
+		t.Fatalf("pipeline: %v", err)
+	}
+	if noReview.reviews() || noReview.lands() || len(noReview.customStages(true)) != 0 || len(noReview.customStages(false)) != 1 {
+		t.Fatalf("expected a pipeline without review or land to run scan after implementation, got %#v", noReview)
+	}
+}
+
+func TestLoopRunsPipelineStagesAroundReview(t *testing.T) {
+	pipeline, err := NewPipeline([]PipelineStage{
+		{Name: "implement", Type: PipelineStageAgent},
+		{Name: "changelog", Type: PipelineStageAgent, Prompt: "Add a CHANGELOG.md entry."},
+		{Name: "review", Type: PipelineStageAgent},
+		{Name: "scan", Type: PipelineStageGate, Command: "echo clean"},
+	})
+	if err != nil {
+		t.Fatalf("pipeline: %v", err)
+	}
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+	}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", RequireReview: true, Pipeline: pipeline})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 || len(run.requests) != 3 {
+		t.Fatalf("expected implement, changelog and review runs, got %#v after %d requests", summary, len(run.requests))
+	}
+	changelog := run.requests[1]
+	if changelog.Mode != contracts.RunnerModeImplement || changelog.Metadata["pipeline_stage"] != "changelog" || !strings.Contains(changelog.Prompt, "Add a CHANGELOG.md entry.") {
+		t.Fatalf("expected the changelog stage to run before review, got %#v", changelog)
+	}
+	if run.requests[2].Mode != contracts.RunnerModeReview {
+		t.Fatalf("expected review after the changelog stage, got %#v", run.requests[2])
+	}
+	finished := eventsByType(sink.events, contracts.EventTypePipelineStageFinished)
+	if len(finished) != 2 || finished[0].Metadata["pipeline_stage"] != "changelog" || finished[1].Metadata["pipeline_stage"] != "scan" || finished[1].Message != "passed" {
+		t.Fatalf("expected changelog and scan to pass, got %#v", finished)
+	}
+	if finished[1].Metadata["pipeline_stage_output"] != "clean" {
+		t.Fatalf("expected the gate output on its event, got %#v", finished[1].Metadata)
+	}
+}
+
+func TestLoopRetriesImplementationWhenPipelineGateFails(t *testing.T) {
+	pipeline, err := NewPipeline([]PipelineStage{
+		{Name: "implement", Type: PipelineStageAgent},
+		{Name: "lint", Type: PipelineStageGate, Command: "echo 'main.go:3: unused import'; exit 1"},
+	})
+	if err != nil {
+		t.Fatalf("pipeline: %v", err)
+	}
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted},
+	}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", MaxRetries: 1, RequireReview: true, Pipeline: pipeline})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || len(run.requests) != 2 {
+		t.Fatalf("expected one implementation retry before blocking, got %#v after %d requests", summary, len(run.requests))
+	}
+	if !strings.Contains(run.requests[1].Prompt, "unused import") {
+		t.Fatalf("expected the gate output in the retry prompt, got %q", run.requests[1].Prompt)
+	}
+	if reason := mgr.dataByID["t-1"]["triage_reason"]; !strings.HasPrefix(reason, `pipeline gate "lint" failed`) {
+		t.Fatalf("expected the gate failure as triage reason, got %q", reason)
+	}
+}
+
+func TestLoopBlocksTaskWhenPipelineApprovalIsDenied(t *testing.T) {
+	pipeline, err := NewPipeline([]PipelineStage{
+		{Name: "implement", Type: PipelineStageAgent},
+		{Name: "sign-off", Type: PipelineStageApproval},
+		{Name: "land", Type: PipelineStageLand},
+	})
+	if err != nil {
+		t.Fatalf("pipeline: %v", err)
+	}
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	asked := ""
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", RequireReview: true, Pipeline: pipeline, StageApprover: func(_ context.Context, _ contracts.Task, stage string) (bool, error) {
+		asked = stage
+		return false, nil
+	}})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || len(run.requests) != 1 || asked != "sign-off" {
+		t.Fatalf("expected the denied approval to block without review, got %#v after %d requests (asked %q)", summary, len(run.requests), asked)
+	}
+	data := mgr.dataByID["t-1"]
+	if data[contracts.TriageCategoryMetadataKey] != string(contracts.FailureCategoryPipelineStage) || data["pipeline_stage"] != "sign-off" {
+		t.Fatalf("expected a pipeline_stage triage for sign-off, got %#v", data)
+	}
+}
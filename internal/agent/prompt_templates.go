@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/prompt"
+)
+
+// promptRepoContext describes where and with which backend a prompt will run.
+func (l *Loop) promptRepoContext(repoRoot string, backend string, model string) prompt.RepoContext {
+	if strings.TrimSpace(repoRoot) == "" {
+		repoRoot = l.options.RepoRoot
+	}
+	if strings.TrimSpace(backend) == "" {
+		backend = l.options.Backend
+	}
+	if strings.TrimSpace(model) == "" {
+		model = l.options.Model
+	}
+	return prompt.RepoContext{
+		Root:     repoRoot,
+		ParentID: l.options.ParentID,
+		Backend:  backend,
+		Model:    model,
+		TDDMode:  l.options.TDDMode,
+	}
+}
+
+// renderImplementPrompt uses the remediation template for review or completion
+// retries when one is configured, then the implement template, then the
+// built-in prompt.
+func (l *Loop) renderImplementPrompt(task contracts.Task, repo prompt.RepoContext, reviewFeedback string, reviewRetryCount int, completionFeedback string, completionRetryCount int) (string, error) {
+	builtIn := buildImplementPrompt(task, reviewFeedback, reviewRetryCount, completionFeedback, completionRetryCount, l.options.TDDMode)
+	if repoContext := strings.TrimSpace(repo.Context); repoContext != "" {
+		builtIn = builtIn + "\n\nRepository Context:\n" + repoContext
+	}
+	if l.options.FollowUpIssues && !isFollowUpTask(task) {
+		builtIn = builtIn + "\n\n" + followUpPromptSection
+	}
+	data := prompt.TemplateData{
+		Task:    task,
+		Mode:    string(contracts.RunnerModeImplement),
+		Default: builtIn,
+		Retry: prompt.RetryContext{
+			ReviewAttempt:      reviewRetryCount,
+			ReviewFeedback:     strings.TrimSpace(reviewFeedback),
+			CompletionAttempt:  completionRetryCount,
+			CompletionFeedback: strings.TrimSpace(completionFeedback),
+		},
+		Repo: repo,
+	}
+	kind := prompt.TemplateImplement
+	if (reviewRetryCount > 0 || completionRetryCount > 0) && l.options.PromptTemplates.Has(prompt.TemplateRemediation) {
+		kind = prompt.TemplateRemediation
+	}
+	return renderPromptTemplate(l.options.PromptTemplates, kind, data)
+}
+
+func (l *Loop) buildPromptContext(ctx context.Context, task contracts.Task) string {
+	if l.options.PromptContext == nil {
+		return ""
+	}
+	return strings.TrimSpace(l.options.PromptContext.BuildPromptContext(ctx, task))
+}
+
+func (l *Loop) renderReviewPrompt(task contracts.Task, repo prompt.RepoContext) (string, error) {
+	return renderPromptTemplate(l.options.PromptTemplates, prompt.TemplateReview, prompt.TemplateData{
+		Task:    task,
+		Mode:    string(contracts.RunnerModeReview),
+		Default: buildPrompt(task, contracts.RunnerModeReview, false),
+		Repo:    repo,
+	})
+}
+
+func (l *Loop) renderMergeConflictRemediationPrompt(task contracts.Task, repo prompt.RepoContext, taskBranch string, mergeFailureReason string) (string, error) {
+	return renderPromptTemplate(l.options.PromptTemplates, prompt.TemplateRemediation, prompt.TemplateData{
+		Task:    task,
+		Mode:    string(contracts.RunnerModeImplement),
+		Default: buildMergeConflictRemediationPrompt(task, taskBranch, mergeFailureReason, l.landingStrategy()),
+		Retry: prompt.RetryContext{
+			MergeBranch:     strings.TrimSpace(taskBranch),
+			MergeFailure:    strings.TrimSpace(mergeFailureReason),
+			LandingStrategy: string(l.landingStrategy()),
+		},
+		Repo: repo,
+	})
+}
+
+func renderPromptTemplate(templates *prompt.Templates, kind prompt.TemplateKind, data prompt.TemplateData) (string, error) {
+	rendered, ok, err := templates.Render(kind, data)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return data.Default, nil
+	}
+	return rendered, nil
+}
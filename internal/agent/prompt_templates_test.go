@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/prompt"
+)
+
+func loadTestPromptTemplates(t *testing.T, files map[string]string) *prompt.Templates {
+	t.Helper()
+	repoRoot := t.TempDir()
+	dir := filepath.Join(repoRoot, prompt.DefaultTemplateDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir prompts dir: %v", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write template %s: %v", name, err)
+		}
+	}
+	templates, err := prompt.LoadTemplates(repoRoot, nil)
+	if err != nil {
+		t.Fatalf("load templates: %v", err)
+	}
+	return templates
+}
+
+func TestLoopRendersConfiguredPromptTemplates(t *testing.T) {
+	templates := loadTestPromptTemplates(t, map[string]string{
+		"implement.tmpl":   "HOUSE RULES {{.Task.ID}} backend={{.Repo.Backend}} model={{.Repo.Model}}\n{{.Default}}",
+		"review.tmpl":      "REVIEW {{.Task.ID}}\n{{.Default}}",
+		"remediation.tmpl": "FIX {{.Task.ID}} attempt={{.Retry.ReviewAttempt}} feedback={{.Retry.ReviewFeedback}}",
+	})
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, Artifacts: map[string]string{"review_verdict": "fail", "review_fail_feedback": "cover edge case"}},
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+	}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{
+		ParentID:        "root",
+		MaxRetries:      1,
+		RequireReview:   true,
+		Backend:         "codex",
+		Model:           "gpt-5",
+		PromptTemplates: templates,
+	})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if len(run.requests) != 4 {
+		t.Fatalf("expected 4 runner requests, got %d", len(run.requests))
+	}
+	implement := run.requests[0].Prompt
+	if !strings.HasPrefix(implement, "HOUSE RULES t-1 backend=codex model=gpt-5") || !strings.Contains(implement, "Mode: Implementation") {
+		t.Fatalf("expected implement template with built-in prompt, got %q", implement)
+	}
+	if review := run.requests[1].Prompt; !strings.HasPrefix(review, "REVIEW t-1") || !strings.Contains(review, "REVIEW_VERDICT") {
+		t.Fatalf("expected review template, got %q", review)
+	}
+	if retry := run.requests[2].Prompt; retry != "FIX t-1 attempt=1 feedback=cover edge case" {
+		t.Fatalf("expected remediation template on retry, got %q", retry)
+	}
+}
+
+func TestLoopKeepsBuiltInPromptsWithoutTemplates(t *testing.T) {
+	task := contracts.Task{ID: "t-1", Title: "Task 1"}
+	loop := NewLoop(newFakeTaskManager(), &fakeRunner{}, nil, LoopOptions{TDDMode: true})
+
+	got, err := loop.renderImplementPrompt(task, loop.promptRepoContext("", "", ""), "", 0, "", 0)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	if want := buildImplementPrompt(task, "", 0, "", 0, true); got != want {
+		t.Fatalf("expected built-in prompt, got %q", got)
+	}
+}
+
+func TestLoopFailsTaskRunWhenPromptTemplateCannotRender(t *testing.T) {
+	templates := loadTestPromptTemplates(t, map[string]string{"implement.tmpl": "{{.Task.Unknown}}"})
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", PromptTemplates: templates})
+
+	if _, err := loop.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "render implement prompt template") {
+		t.Fatalf("expected render error, got %v", err)
+	}
+	if len(run.requests) != 0 {
+		t.Fatalf("runner should not be called when the prompt cannot render")
+	}
+}
+
+type staticPromptContext struct {
+	context string
+	calls   []string
+}
+
+func (s *staticPromptContext) BuildPromptContext(_ context.Context, task contracts.Task) string {
+	s.calls = append(s.calls, task.ID)
+	return s.context
+}
+
+func TestLoopInjectsRepoContextIntoImplementPromptOnly(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+	}}
+	builder := &staticPromptContext{context: "### README.md\nUse make test."}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", RequireReview: true, PromptContext: builder})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if len(run.requests) != 2 {
+		t.Fatalf("expected implement and review requests, got %d", len(run.requests))
+	}
+	if !strings.HasSuffix(run.requests[0].Prompt, "Repository Context:\n### README.md\nUse make test.") {
+		t.Fatalf("expected repo context in implement prompt, got %q", run.requests[0].Prompt)
+	}
+	if strings.Contains(run.requests[1].Prompt, "Repository Context:") {
+		t.Fatalf("did not expect repo context in review prompt, got %q", run.requests[1].Prompt)
+	}
+	if len(builder.calls) != 1 || builder.calls[0] != "t-1" {
+		t.Fatalf("expected one context build for t-1, got %#v", builder.calls)
+	}
+}
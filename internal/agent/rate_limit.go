@@ -0,0 +1,134 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// maxRateLimitBackoff caps the shared backoff.
+const maxRateLimitBackoff = 10 * time.Minute
+
+// maxRateLimitRetries bounds how often one runner call is retried after being
+// throttled before its result is handled like any other failure.
+const maxRateLimitRetries = 5
+
+var rateLimitReasonMarkers = []string{"rate limit", "rate_limit", "ratelimit", "too many requests", "429", "quota exceeded"}
+
+// rateLimitBackoff is shared by all workers of a loop. One throttled runner
+// pauses dispatch and new runner calls for every worker until the backoff
+// expires; each new throttling incident doubles the pause.
+type rateLimitBackoff struct {
+	mu      sync.Mutex
+	until   time.Time
+	strikes int
+}
+
+// trip records a throttled run and returns when work may resume. Workers
+// throttled while a pause is already active share that pause instead of
+// extending it.
+func (b *rateLimitBackoff) trip(now time.Time, base time.Duration, maxBackoff time.Duration) time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.Before(b.until) {
+		return b.until
+	}
+	wait := base
+	for i := 0; i < b.strikes && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	b.strikes++
+	b.until = now.Add(wait)
+	return b.until
+}
+
+// remaining returns how long dispatch stays paused.
+func (b *rateLimitBackoff) remaining(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !now.Before(b.until) {
+		return 0
+	}
+	return b.until.Sub(now)
+}
+
+// reset clears the backoff growth after a run that was not throttled.
+func (b *rateLimitBackoff) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.strikes = 0
+}
+
+// rateLimitPause returns how long dispatch stays paused, or 0 when
+// rate-limit backoff is off.
+func (l *Loop) rateLimitPause() time.Duration {
+	if l.options.RateLimitBackoff <= 0 || l.rateLimit == nil {
+		return 0
+	}
+	return l.rateLimit.remaining(time.Now())
+}
+
+// tripRateLimit starts or joins the shared backoff and reports it with a
+// rate_limited event.
+func (l *Loop) tripRateLimit(ctx context.Context, request contracts.RunnerRequest, result contracts.RunnerResult, attempt int, taskTitle string, worker string, clonePath string, queuePos int) {
+	now := time.Now()
+	until := l.rateLimit.trip(now, l.options.RateLimitBackoff, maxRateLimitBackoff)
+	wait := until.Sub(now).Round(time.Millisecond)
+	_ = l.emit(ctx, contracts.Event{
+		Type:      contracts.EventTypeRateLimited,
+		TaskID:    request.TaskID,
+		TaskTitle: taskTitle,
+		WorkerID:  worker,
+		ClonePath: clonePath,
+		QueuePos:  queuePos,
+		Message:   fmt.Sprintf("provider rate limit; pausing dispatch for %s", wait),
+		Metadata: map[string]string{
+			"mode":      string(request.Mode),
+			"attempt":   fmt.Sprintf("%d", attempt),
+			"backoff":   wait.String(),
+			"resume_at": until.UTC().Format(time.RFC3339),
+			"reason":    strings.TrimSpace(result.Reason),
+		},
+		Timestamp: now.UTC(),
+	})
+}
+
+// isRateLimitedResult reports runs that failed or stalled because the model
+// provider throttled them.
+func isRateLimitedResult(result contracts.RunnerResult) bool {
+	if result.Status != contracts.RunnerResultFailed && result.Status != contracts.RunnerResultBlocked {
+		return false
+	}
+	if contracts.StallCategoryFromArtifacts(result.Artifacts) == contracts.StallCategoryRateLimit {
+		return true
+	}
+	reason := strings.ToLower(result.Reason)
+	for _, marker := range rateLimitReasonMarkers {
+		if strings.Contains(reason, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestRateLimitBackoffDoublesPerIncidentAndCaps(t *testing.T) {
+	backoff := &rateLimitBackoff{}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if until := backoff.trip(now, 10*time.Second, time.Minute); until.Sub(now) != 10*time.Second {
+		t.Fatalf("expected base backoff, got %s", until.Sub(now))
+	}
+	if until := backoff.trip(now.Add(5*time.Second), 10*time.Second, time.Minute); !until.Equal(now.Add(10 * time.Second)) {
+		t.Fatalf("expected second worker to join the active pause, got %s", until)
+	}
+	now = now.Add(10 * time.Second)
+	if until := backoff.trip(now, 10*time.Second, time.Minute); until.Sub(now) != 20*time.Second {
+		t.Fatalf("expected doubled backoff, got %s", until.Sub(now))
+	}
+	for i := 0; i < 5; i++ {
+		now = backoff.trip(now, 10*time.Second, time.Minute)
+	}
+	if got := backoff.remaining(now.Add(-time.Minute)); got != time.Minute {
+		t.Fatalf("expected backoff capped at 1m, got %s", got)
+	}
+
+	backoff.reset()
+	if until := backoff.trip(now, 10*time.Second, time.Minute); until.Sub(now) != 10*time.Second {
+		t.Fatalf("expected reset to restart at base backoff, got %s", until.Sub(now))
+	}
+}
+
+func TestIsRateLimitedResult(t *testing.T) {
+	cases := []struct {
+		result contracts.RunnerResult
+		want   bool
+	}{
+		{contracts.RunnerResult{Status: contracts.RunnerResultFailed, Reason: "codex exited: 429 Too Many Requests"}, true},
+		{contracts.RunnerResult{Status: contracts.RunnerResultBlocked, Reason: "opencode stall", Artifacts: map[string]string{"stall_category": "rate_limit"}}, true},
+		{contracts.RunnerResult{Status: contracts.RunnerResultFailed, Reason: "tests failed"}, false},
+		{contracts.RunnerResult{Status: contracts.RunnerResultCompleted, Reason: "hit a rate limit but recovered"}, false},
+	}
+	for _, tc := range cases {
+		if got := isRateLimitedResult(tc.result); got != tc.want {
+			t.Fatalf("isRateLimitedResult(%#v) = %v, want %v", tc.result, got, tc.want)
+		}
+	}
+}
+
+func TestLoopBacksOffAndRetriesRateLimitedRun(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultFailed, Reason: "provider error: 429 Too Many Requests"},
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, Artifacts: map[string]string{"review_verdict": "pass"}},
+	}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", MaxRetries: 0, RateLimitBackoff: 20 * time.Millisecond})
+
+	started := time.Now()
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 {
+		t.Fatalf("expected throttled task to complete after backoff, got %#v", summary)
+	}
+	if elapsed := time.Since(started); elapsed < 20*time.Millisecond {
+		t.Fatalf("expected loop to wait out the backoff, finished after %s", elapsed)
+	}
+	if run.modes[0] != contracts.RunnerModeImplement || run.modes[1] != contracts.RunnerModeImplement {
+		t.Fatalf("expected implement run to be retried, got %#v", run.modes)
+	}
+	if got := mgr.dataByID["t-1"]["completion_retry_count"]; got != "" {
+		t.Fatalf("expected rate-limit retry not to spend the retry budget, got %q", got)
+	}
+	var limited *contracts.Event
+	for i := range sink.events {
+		if sink.events[i].Type == contracts.EventTypeRateLimited {
+			limited = &sink.events[i]
+		}
+	}
+	if limited == nil {
+		t.Fatalf("expected rate_limited event")
+	}
+	if limited.TaskID != "t-1" || limited.Metadata["attempt"] != "1" || limited.Metadata["backoff"] != "20ms" {
+		t.Fatalf("unexpected rate_limited event %#v", limited)
+	}
+}
+
+func TestLoopPausesDispatchDuringRateLimitBackoff(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, Artifacts: map[string]string{"review_verdict": "pass"}},
+	}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", RateLimitBackoff: time.Second})
+	pausedUntil := time.Now().Add(30 * time.Millisecond)
+	loop.rateLimit.until = pausedUntil
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if len(run.requests) == 0 {
+		t.Fatalf("expected task to run after the pause")
+	}
+	if time.Now().Before(pausedUntil) {
+		t.Fatalf("expected dispatch to wait for the shared backoff")
+	}
+}
+
+func TestLoopDoesNotRetryRateLimitedRunWhenBackoffDisabled(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultFailed, Reason: "429 Too Many Requests"},
+	}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root"})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 0 || len(run.requests) != 1 {
+		t.Fatalf("expected no rate-limit retry without backoff, got %#v after %d requests", summary, len(run.requests))
+	}
+}
@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	// RetryBudgetLabelPrefix marks a task label that overrides the run's
+	// retry budget for that task, e.g. yolo:retry-budget=3.
+	RetryBudgetLabelPrefix = "yolo:retry-budget="
+	// RetryBudgetMetadataKey is the task metadata field that overrides the
+	// retry budget, for trackers with custom fields instead of labels.
+	RetryBudgetMetadataKey = "retry_budget"
+)
+
+// resolveTaskRetryBudget returns the retry budget for task and where it came
+// from: "label", "metadata" or "" for the run-level budget. A label and a
+// metadata field that disagree, or a value that is not a non-negative
+// integer, are errors.
+func resolveTaskRetryBudget(task contracts.Task, runBudget int) (int, string, error) {
+	budget, source := runBudget, ""
+	for label := range taskLabelSet(task) {
+		raw, ok := strings.CutPrefix(label, RetryBudgetLabelPrefix)
+		if !ok {
+			continue
+		}
+		value, err := parseTaskRetryBudget(raw)
+		if err != nil {
+			return runBudget, "", fmt.Errorf("label %s%s: %w", RetryBudgetLabelPrefix, raw, err)
+		}
+		if source != "" && value != budget {
+			return runBudget, "", fmt.Errorf("conflicting %s labels (%d and %d)", strings.TrimSuffix(RetryBudgetLabelPrefix, "="), budget, value)
+		}
+		budget, source = value, "label"
+	}
+	if raw := strings.TrimSpace(task.Metadata[RetryBudgetMetadataKey]); raw != "" {
+		value, err := parseTaskRetryBudget(raw)
+		if err != nil {
+			return runBudget, "", fmt.Errorf("%s metadata %q: %w", RetryBudgetMetadataKey, raw, err)
+		}
+		if source != "" && value != budget {
+			return runBudget, "", fmt.Errorf("%s metadata %d conflicts with label %d", RetryBudgetMetadataKey, value, budget)
+		}
+		if source == "" {
+			budget, source = value, "metadata"
+		}
+	}
+	return budget, source, nil
+}
+
+// appendRetryBudgetMetadata records the task's retry budget on review
+// telemetry, naming the override when one set it.
+func appendRetryBudgetMetadata(metadata map[string]string, runtime taskRuntimeConfig) map[string]string {
+	metadata["retry_budget"] = strconv.Itoa(runtime.retryBudget)
+	if runtime.retryBudgetSource != "" {
+		metadata["retry_budget_source"] = runtime.retryBudgetSource
+	}
+	return metadata
+}
+
+func parseTaskRetryBudget(raw string) (int, error) {
+	value, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("retry budget must be a non-negative integer")
+	}
+	return value, nil
+}
+
+// blockInvalidRetryBudget blocks a task whose retry budget override cannot be
+// used, so a typo in a label does not silently fall back to the run budget.
+func (l *Loop) blockInvalidRetryBudget(ctx context.Context, task contracts.Task, worker string, queuePos int, cause error) error {
+	reason := "invalid retry budget override: " + cause.Error()
+	blockedData := appendDecisionMetadata(map[string]string{
+		"triage_status": "blocked",
+		"triage_reason": reason,
+	}, "blocked", reason)
+	blockedData = appendTriageCategory(blockedData, contracts.FailureCategoryAuthProfileConfig)
+	return l.blockTask(ctx, task, worker, queuePos, "", blockedData)
+}
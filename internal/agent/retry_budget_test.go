@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestResolveTaskRetryBudget(t *testing.T) {
+	cases := []struct {
+		name       string
+		metadata   map[string]string
+		wantBudget int
+		wantSource string
+		wantErr    bool
+	}{
+		{name: "run budget", metadata: map[string]string{"labels": "backend"}, wantBudget: 5},
+		{name: "label", metadata: map[string]string{"labels": "backend, yolo:retry-budget=3"}, wantBudget: 3, wantSource: "label"},
+		{name: "metadata", metadata: map[string]string{RetryBudgetMetadataKey: "0"}, wantBudget: 0, wantSource: "metadata"},
+		{name: "agreeing label and metadata", metadata: map[string]string{"labels": "yolo:retry-budget=8", RetryBudgetMetadataKey: "8"}, wantBudget: 8, wantSource: "label"},
+		{name: "negative", metadata: map[string]string{"labels": "yolo:retry-budget=-1"}, wantErr: true},
+		{name: "not a number", metadata: map[string]string{RetryBudgetMetadataKey: "lots"}, wantErr: true},
+		{name: "conflicting labels", metadata: map[string]string{"labels": "yolo:retry-budget=2,yolo:retry-budget=4"}, wantErr: true},
+		{name: "label and metadata disagree", metadata: map[string]string{"labels": "yolo:retry-budget=2", RetryBudgetMetadataKey: "4"}, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			budget, source, err := resolveTaskRetryBudget(contracts.Task{ID: "t-1", Metadata: tc.metadata}, 5)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got budget %d from %q", budget, source)
+				}
+				return
+			}
+			if err != nil || budget != tc.wantBudget || source != tc.wantSource {
+				t.Fatalf("got %d from %q (%v), want %d from %q", budget, source, err, tc.wantBudget, tc.wantSource)
+			}
+		})
+	}
+}
+
+func TestLoopUsesTaskRetryBudgetOverride(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, Metadata: map[string]string{"labels": "yolo:retry-budget=2"}})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultFailed, Reason: "first"},
+		{Status: contracts.RunnerResultFailed, Reason: "second"},
+		{Status: contracts.RunnerResultCompleted},
+	}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", MaxRetries: 0})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 || len(run.requests) != 3 {
+		t.Fatalf("expected two retries from the label budget, got %#v after %d requests", summary, len(run.requests))
+	}
+}
+
+func TestLoopBlocksTaskWithInvalidRetryBudget(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, Metadata: map[string]string{"labels": "yolo:retry-budget=many"}})
+	run := &fakeRunner{}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", MaxRetries: 5})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || len(run.requests) != 0 {
+		t.Fatalf("expected the task blocked before running, got %#v after %d requests", summary, len(run.requests))
+	}
+	if got := mgr.dataByID["t-1"][contracts.TriageCategoryMetadataKey]; got != string(contracts.FailureCategoryAuthProfileConfig) {
+		t.Fatalf("expected auth_profile_config triage category, got %q", got)
+	}
+}
+
+func TestLoopReportsRetryBudgetOverrideInReviewTelemetry(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, Metadata: map[string]string{"labels": "yolo:retry-budget=1"}})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, Artifacts: map[string]string{"review_verdict": "fail", "review_fail_feedback": "missing test"}},
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+	}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", MaxRetries: 0, RequireReview: true})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 {
+		t.Fatalf("expected the label budget to allow one review retry, got %#v", summary)
+	}
+	for _, eventType := range []contracts.EventType{contracts.EventTypeReviewStarted, contracts.EventTypeReviewFinished} {
+		events := eventsByType(sink.events, eventType)
+		if len(events) != 2 {
+			t.Fatalf("expected two %s events, got %d", eventType, len(events))
+		}
+		if events[0].Metadata["retry_budget"] != "1" || events[0].Metadata["retry_budget_source"] != "label" {
+			t.Fatalf("expected %s to report the label budget, got %#v", eventType, events[0].Metadata)
+		}
+	}
+}
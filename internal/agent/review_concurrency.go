@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"context"
+	"sync"
+)
+
+// reviewSlots lets a task that reached review hand its implement slot to the
+// next task, so slow reviews do not hold back implementation. Tasks hold an
+// implement slot while they implement and a review slot from review until
+// they finish, including their gates and landing; ReviewConcurrency bounds
+// the review slots. A nil *reviewSlots, used when ReviewConcurrency is 0,
+// keeps each task on its worker slot from start to finish.
+type reviewSlots struct {
+	implement chan struct{}
+	review    chan struct{}
+	// freed wakes the dispatcher when a task hands over its implement slot.
+	freed chan struct{}
+
+	mu   sync.Mutex
+	held map[string]heldSlot
+}
+
+// heldSlot is the slot a task in flight holds. Tasks start on the implement
+// slot the dispatcher reserved for them.
+type heldSlot int
+
+const (
+	heldImplementSlot heldSlot = iota
+	heldReviewSlot
+	heldNoSlot
+)
+
+func newReviewSlots(concurrency int, reviewConcurrency int) *reviewSlots {
+	if reviewConcurrency <= 0 {
+		return nil
+	}
+	return &reviewSlots{
+		implement: make(chan struct{}, concurrency),
+		review:    make(chan struct{}, reviewConcurrency),
+		freed:     make(chan struct{}, 1),
+		held:      map[string]heldSlot{},
+	}
+}
+
+// workers is the number of tasks that may be in flight at once.
+func (s *reviewSlots) workers(concurrency int) int {
+	if s == nil {
+		return concurrency
+	}
+	return concurrency + cap(s.review)
+}
+
+// reserve takes an implement slot for a task about to be dispatched; false
+// means every implement slot is taken.
+func (s *reviewSlots) reserve() bool {
+	if s == nil {
+		return true
+	}
+	select {
+	case s.implement <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// freedSignal fires when an implement slot was handed over; it is nil
+// without review slots.
+func (s *reviewSlots) freedSignal() <-chan struct{} {
+	if s == nil {
+		return nil
+	}
+	return s.freed
+}
+
+// enterReview waits for a review slot and then gives up the task's implement
+// slot.
+func (s *reviewSlots) enterReview(ctx context.Context, taskID string) error {
+	if s == nil || s.heldBy(taskID) == heldReviewSlot {
+		return nil
+	}
+	select {
+	case s.review <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	s.setHeld(taskID, heldReviewSlot)
+	<-s.implement
+	select {
+	case s.freed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// leaveReview returns a task sent back to implementation to an implement
+// slot. The review slot is released first, so tasks waiting for one can
+// free the implement slot this task waits for.
+func (s *reviewSlots) leaveReview(ctx context.Context, taskID string) error {
+	if s == nil || s.heldBy(taskID) != heldReviewSlot {
+		return nil
+	}
+	s.setHeld(taskID, heldNoSlot)
+	<-s.review
+	select {
+	case s.implement <- struct{}{}:
+		s.setHeld(taskID, heldImplementSlot)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// finish releases the slot a task held when it ended.
+func (s *reviewSlots) finish(taskID string) {
+	if s == nil {
+		return
+	}
+	held := s.heldBy(taskID)
+	s.mu.Lock()
+	delete(s.held, taskID)
+	s.mu.Unlock()
+	switch held {
+	case heldImplementSlot:
+		<-s.implement
+	case heldReviewSlot:
+		<-s.review
+	}
+}
+
+func (s *reviewSlots) heldBy(taskID string) heldSlot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.held[taskID]
+}
+
+func (s *reviewSlots) setHeld(taskID string, slot heldSlot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.held[taskID] = slot
+}
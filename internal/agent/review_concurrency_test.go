@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestReviewSlotsHandImplementSlotToNextTask(t *testing.T) {
+	slots := newReviewSlots(1, 1)
+	if slots.workers(1) != 2 {
+		t.Fatalf("expected one worker per implement and review slot, got %d", slots.workers(1))
+	}
+	if !slots.reserve() || slots.reserve() {
+		t.Fatalf("expected exactly one implement slot")
+	}
+	if err := slots.enterReview(context.Background(), "t-1"); err != nil {
+		t.Fatalf("enter review: %v", err)
+	}
+	select {
+	case <-slots.freedSignal():
+	default:
+		t.Fatalf("expected the dispatcher to be woken")
+	}
+	if !slots.reserve() {
+		t.Fatalf("expected the implement slot to be free while t-1 reviews")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := slots.leaveReview(ctx, "t-1"); err == nil {
+		t.Fatalf("expected t-1 to wait for t-2's implement slot")
+	}
+	slots.finish("t-1")
+	slots.finish("t-2")
+	if !slots.reserve() {
+		t.Fatalf("expected finished tasks to release their slots")
+	}
+}
+
+func TestNilReviewSlotsKeepTasksOnTheirWorker(t *testing.T) {
+	var slots *reviewSlots
+	if slots.workers(3) != 3 || !slots.reserve() || slots.freedSignal() != nil {
+		t.Fatalf("expected nil review slots to leave dispatching alone")
+	}
+	if err := slots.enterReview(context.Background(), "t-1"); err != nil {
+		t.Fatalf("enter review: %v", err)
+	}
+	slots.finish("t-1")
+}
+
+// reviewOverlapRunner holds the review of one task until the other task's
+// implementation starts, or gives up after a while.
+type reviewOverlapRunner struct {
+	mu         sync.Mutex
+	implements map[string]chan struct{}
+	overlapped bool
+}
+
+func (r *reviewOverlapRunner) implementStarted(taskID string) chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.implements == nil {
+		r.implements = map[string]chan struct{}{}
+	}
+	if r.implements[taskID] == nil {
+		r.implements[taskID] = make(chan struct{})
+	}
+	return r.implements[taskID]
+}
+
+func (r *reviewOverlapRunner) Run(_ context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	if request.Mode == contracts.RunnerModeImplement {
+		close(r.implementStarted(request.TaskID))
+		return contracts.RunnerResult{Status: contracts.RunnerResultCompleted}, nil
+	}
+	if request.TaskID == "t-1" {
+		select {
+		case <-r.implementStarted("t-2"):
+			r.mu.Lock()
+			r.overlapped = true
+			r.mu.Unlock()
+		case <-time.After(2 * time.Second):
+		}
+	}
+	return contracts.RunnerResult{Status: contracts.RunnerResultCompleted, ReviewReady: true}, nil
+}
+
+func TestLoopImplementsNextTaskWhileReviewRuns(t *testing.T) {
+	mgr := newFakeTaskManager(
+		contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen},
+		contracts.Task{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen},
+	)
+	run := &reviewOverlapRunner{}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", Concurrency: 1, ReviewConcurrency: 1, RequireReview: true})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 2 {
+		t.Fatalf("expected both tasks completed, got %#v", summary)
+	}
+	if !run.overlapped {
+		t.Fatalf("expected t-2 to start implementing while t-1 was in review")
+	}
+}
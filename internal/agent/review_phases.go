@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/prompt"
+)
+
+// ReviewMode selects how a completed task is reviewed.
+type ReviewMode string
+
+const (
+	// ReviewModeSingle runs one review prompt covering acceptance criteria
+	// and tests (the default).
+	ReviewModeSingle ReviewMode = "single"
+	// ReviewModeTwoPhase runs a spec compliance review and, once it passes,
+	// a code quality review. Each phase has its own verdict, feedback and
+	// retry count.
+	ReviewModeTwoPhase ReviewMode = "two_phase"
+)
+
+const (
+	// reviewPhaseSpec checks the implementation against the task's
+	// acceptance criteria and description.
+	reviewPhaseSpec = "spec"
+	// reviewPhaseQuality checks code quality and TDD evidence.
+	reviewPhaseQuality = "quality"
+)
+
+// ParseReviewMode accepts single and two_phase; empty means single.
+func ParseReviewMode(raw string) (ReviewMode, error) {
+	switch mode := ReviewMode(strings.ToLower(strings.TrimSpace(raw))); mode {
+	case "", ReviewModeSingle:
+		return ReviewModeSingle, nil
+	case ReviewModeTwoPhase:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported review mode %q (supported: %s, %s)", raw, ReviewModeSingle, ReviewModeTwoPhase)
+	}
+}
+
+// reviewPhases lists the review prompts each review attempt runs in order.
+// The single review has no phase name.
+func (l *Loop) reviewPhases() []string {
+	if l.options.ReviewMode == ReviewModeTwoPhase {
+		return []string{reviewPhaseSpec, reviewPhaseQuality}
+	}
+	return []string{""}
+}
+
+func reviewPhaseRetryCountKey(phase string) string {
+	return "review_" + phase + "_retry_count"
+}
+
+func reviewPhaseFeedbackKey(phase string) string {
+	return "review_" + phase + "_feedback"
+}
+
+func reviewPhaseVerdictKey(phase string) string {
+	return "review_" + phase + "_verdict"
+}
+
+// reviewPhaseRetriesFromMetadata restores the per-phase retry counts a
+// previous run recorded on the task.
+func reviewPhaseRetriesFromMetadata(metadata map[string]string) map[string]int {
+	retries := map[string]int{}
+	for _, phase := range []string{reviewPhaseSpec, reviewPhaseQuality} {
+		if count, err := metadataRetryCount(metadata, reviewPhaseRetryCountKey(phase)); err == nil {
+			retries[phase] = count
+		}
+	}
+	return retries
+}
+
+// appendReviewPhaseMetadata names the review phase and its retry count; it
+// leaves the single review's metadata unchanged.
+func appendReviewPhaseMetadata(metadata map[string]string, phase string, retries int) map[string]string {
+	if phase == "" {
+		return metadata
+	}
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	metadata["review_phase"] = phase
+	metadata[reviewPhaseRetryCountKey(phase)] = strconv.Itoa(retries)
+	return metadata
+}
+
+// appendReviewPhaseOutcomeMetadata records a phase's verdict and feedback
+// under phase-specific keys, so the spec and quality outcomes stay apart in
+// the task data.
+func appendReviewPhaseOutcomeMetadata(metadata map[string]string, phase string, result contracts.RunnerResult) map[string]string {
+	if phase == "" {
+		return metadata
+	}
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	if verdict := reviewVerdictFromArtifacts(result); verdict != "" {
+		metadata[reviewPhaseVerdictKey(phase)] = verdict
+	}
+	if feedback := reviewFailFeedbackFromArtifacts(result); feedback != "" {
+		metadata[reviewPhaseFeedbackKey(phase)] = feedback
+	}
+	return metadata
+}
+
+func reviewPhaseLabel(phase string) string {
+	switch phase {
+	case reviewPhaseSpec:
+		return "spec compliance"
+	case reviewPhaseQuality:
+		return "code quality"
+	default:
+		return phase
+	}
+}
+
+func (l *Loop) renderReviewPhasePrompt(task contracts.Task, repo prompt.RepoContext, phase string) (string, error) {
+	if phase == "" {
+		return l.renderReviewPrompt(task, repo)
+	}
+	return renderPromptTemplate(l.options.PromptTemplates, prompt.TemplateReview, prompt.TemplateData{
+		Task:        task,
+		Mode:        string(contracts.RunnerModeReview),
+		ReviewPhase: phase,
+		Default:     buildReviewPhasePrompt(task, phase),
+		Repo:        repo,
+	})
+}
+
+func buildReviewPhasePrompt(task contracts.Task, phase string) string {
+	sections := []string{
+		"Mode: Review",
+		"Task ID: " + task.ID,
+		"Title: " + task.Title,
+		"Review Phase: " + reviewPhaseLabel(phase),
+	}
+	instructions := []string{"Review Instructions:"}
+	switch phase {
+	case reviewPhaseSpec:
+		instructions = append(instructions,
+			"- Check only whether the implementation does what the task asks: every acceptance criterion and the description.",
+			"- Code quality is reviewed in a separate phase; do not fail this phase for style or structure.",
+			"- Use pass only when every requirement is met.",
+		)
+	case reviewPhaseQuality:
+		instructions = append(instructions,
+			"- Spec compliance was reviewed and passed; check code quality only.",
+			"- Check readability, naming, error handling, duplication and consistency with the surrounding code.",
+			"- Check TDD evidence: tests cover the changed behavior, exercise failure paths, and pass.",
+			"- Use pass only when the code is ready to merge as written.",
+		)
+	}
+	instructions = append(instructions,
+		"- Include exactly one verdict line in this format: REVIEW_VERDICT: pass OR REVIEW_VERDICT: fail",
+		"- If fail, include exactly one structured line: REVIEW_FAIL_FEEDBACK: <blocking gaps and required fixes>.",
+	)
+	sections = append(sections, strings.Join(instructions, "\n"))
+	if phase == reviewPhaseSpec {
+		if criteriaSection := buildAcceptanceCriteriaSection(parseAcceptanceCriteria(task.Description), contracts.RunnerModeReview); criteriaSection != "" {
+			sections = append(sections, criteriaSection)
+		}
+	}
+	if strings.TrimSpace(task.Description) != "" {
+		sections = append(sections, "Description:\n"+task.Description)
+	}
+	return strings.Join(sections, "\n\n")
+}
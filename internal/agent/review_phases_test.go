@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestParseReviewMode(t *testing.T) {
+	for raw, want := range map[string]ReviewMode{"": ReviewModeSingle, "single": ReviewModeSingle, " Two_Phase ": ReviewModeTwoPhase} {
+		got, err := ParseReviewMode(raw)
+		if err != nil || got != want {
+			t.Fatalf("ParseReviewMode(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, err := ParseReviewMode("three_phase"); err == nil {
+		t.Fatalf("expected an error for an unknown review mode")
+	}
+}
+
+func TestBuildReviewPhasePromptFocusesEachPhase(t *testing.T) {
+	task := contracts.Task{ID: "t-1", Title: "Task 1", Description: "Add a flag.\n\nAcceptance Criteria:\n- flag parses"}
+
+	spec := buildReviewPhasePrompt(task, reviewPhaseSpec)
+	for _, want := range []string{"Review Phase: spec compliance", "REVIEW_CRITERION:", "REVIEW_VERDICT: pass"} {
+		if !strings.Contains(spec, want) {
+			t.Fatalf("expected spec prompt to contain %q, got %q", want, spec)
+		}
+	}
+	quality := buildReviewPhasePrompt(task, reviewPhaseQuality)
+	for _, want := range []string{"Review Phase: code quality", "TDD evidence", "REVIEW_FAIL_FEEDBACK:"} {
+		if !strings.Contains(quality, want) {
+			t.Fatalf("expected quality prompt to contain %q, got %q", want, quality)
+		}
+	}
+	if strings.Contains(quality, "REVIEW_CRITERION:") {
+		t.Fatalf("expected quality prompt not to ask for criteria results, got %q", quality)
+	}
+}
+
+func TestLoopTwoPhaseReviewRunsSpecThenQuality(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+	}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", RequireReview: true, ReviewMode: ReviewModeTwoPhase})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 || len(run.requests) != 3 {
+		t.Fatalf("expected implement, spec and quality runs, got %#v after %d requests", summary, len(run.requests))
+	}
+	for i, phase := range []string{reviewPhaseSpec, reviewPhaseQuality} {
+		request := run.requests[i+1]
+		if request.Mode != contracts.RunnerModeReview || request.Metadata["review_phase"] != phase {
+			t.Fatalf("expected request %d to review the %s phase, got %#v", i+1, phase, request)
+		}
+		if !strings.Contains(request.Prompt, "Review Phase: "+reviewPhaseLabel(phase)) {
+			t.Fatalf("expected the %s prompt, got %q", phase, request.Prompt)
+		}
+	}
+	finished := eventsByType(sink.events, contracts.EventTypeReviewFinished)
+	if len(finished) != 2 || finished[0].Metadata["review_phase"] != reviewPhaseSpec || finished[1].Metadata["review_phase"] != reviewPhaseQuality {
+		t.Fatalf("expected one review_finished per phase, got %#v", finished)
+	}
+}
+
+func TestLoopTwoPhaseReviewRetriesFailedPhaseWithItsOwnBudget(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	qualityFail := contracts.RunnerResult{Status: contracts.RunnerResultCompleted, Artifacts: map[string]string{"review_verdict": "fail", "review_fail_feedback": "no test for the error path"}}
+	run := &fakeRunner{results: []contracts.RunnerResult{
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, Artifacts: map[string]string{"review_verdict": "fail", "review_fail_feedback": "flag is ignored"}},
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+		qualityFail,
+		{Status: contracts.RunnerResultCompleted},
+		{Status: contracts.RunnerResultCompleted, ReviewReady: true},
+		qualityFail,
+	}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", MaxRetries: 1, RequireReview: true, ReviewMode: ReviewModeTwoPhase})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Failed != 1 || len(run.requests) != 8 {
+		t.Fatalf("expected one retry per phase before failing, got %#v after %d requests", summary, len(run.requests))
+	}
+	if !strings.Contains(run.requests[2].Prompt, "spec compliance: flag is ignored") {
+		t.Fatalf("expected the spec feedback in the retry prompt, got %q", run.requests[2].Prompt)
+	}
+	if !strings.Contains(run.requests[5].Prompt, "code quality: no test for the error path") {
+		t.Fatalf("expected the quality feedback in the retry prompt, got %q", run.requests[5].Prompt)
+	}
+	data := mgr.dataByID["t-1"]
+	if data["review_retry_count"] != "2" || data["review_spec_retry_count"] != "1" || data["review_quality_retry_count"] != "1" {
+		t.Fatalf("expected per-phase retry counts, got %#v", data)
+	}
+	if data["review_spec_feedback"] != "flag is ignored" || data["review_quality_verdict"] != "fail" || data["review_phase"] != reviewPhaseQuality {
+		t.Fatalf("expected per-phase outcomes, got %#v", data)
+	}
+	if !strings.HasPrefix(data["triage_reason"], "code quality review rejected") {
+		t.Fatalf("expected the failing phase in the triage reason, got %q", data["triage_reason"])
+	}
+}
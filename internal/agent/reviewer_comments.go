@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const reviewerCommentsReason = "reviewer comments left while awaiting approval"
+
+// reviewerCommentFeedback renders the comments people left on the task in
+// the tracker since as remediation feedback. It is empty when there are none
+// or the tracker cannot list comments; the runner's own lifecycle comments
+// do not count.
+func (l *Loop) reviewerCommentFeedback(ctx context.Context, task contracts.Task, since time.Time) string {
+	reader, ok := l.tasks.(contracts.TaskCommentReader)
+	if !ok {
+		return ""
+	}
+	comments, err := reader.TaskComments(ctx, task.ID, since)
+	if err != nil {
+		return ""
+	}
+	return formatReviewerComments(comments)
+}
+
+func formatReviewerComments(comments []contracts.TaskComment) string {
+	lines := []string{}
+	for _, comment := range comments {
+		body := strings.TrimSpace(comment.Body)
+		if body == "" || contracts.IsLifecycleComment(body) {
+			continue
+		}
+		author := strings.TrimSpace(comment.Author)
+		if author == "" {
+			author = "reviewer"
+		}
+		lines = append(lines, "- "+author+": "+strings.ReplaceAll(body, "\n", "\n  "))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "Reviewer comments left on the task while it awaited approval:\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// commentingTaskManager serves comments a reviewer posts on the task.
+type commentingTaskManager struct {
+	*fakeTaskManager
+	comments []contracts.TaskComment
+}
+
+func (m *commentingTaskManager) TaskComments(_ context.Context, _ string, since time.Time) ([]contracts.TaskComment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	comments := []contracts.TaskComment{}
+	for _, comment := range m.comments {
+		if !comment.CreatedAt.Before(since) {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+func (m *commentingTaskManager) comment(author string, body string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.comments = append(m.comments, contracts.TaskComment{Author: author, Body: body, CreatedAt: time.Now().UTC()})
+}
+
+func TestLoopRemediatesReviewerCommentsLeftWhileAwaitingApproval(t *testing.T) {
+	mgr := &commentingTaskManager{fakeTaskManager: newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})}
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}, {Status: contracts.RunnerResultCompleted}}}
+	vcs := &diffStatsVCS{stats: []contracts.FileDiffStat{{Path: "internal/a.go", Added: 100, Deleted: 20}}}
+	approvals := 0
+	loop := NewLoop(mgr, run, nil, LoopOptions{
+		ParentID:       "root",
+		MaxRetries:     1,
+		VCS:            vcs,
+		MergeOnSuccess: true,
+		DiffGuardrails: DiffGuardrailConfig{MaxChangedLines: 100, Action: DiffGuardrailApprove},
+		DiffApprover: func(context.Context, contracts.Task, string) (bool, error) {
+			approvals++
+			if approvals == 1 {
+				mgr.comment("", "yolo-runner: review passed")
+				mgr.comment("alice", "Please keep the old flag working")
+			}
+			return true, nil
+		},
+	})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 1 || mgr.statusByID["t-1"] != contracts.TaskStatusClosed {
+		t.Fatalf("expected the task completed after remediation, got %#v status=%s", summary, mgr.statusByID["t-1"])
+	}
+	if len(run.requests) != 2 || approvals != 2 {
+		t.Fatalf("expected a second implement pass and approval, got %d requests and %d approvals", len(run.requests), approvals)
+	}
+	if prompt := run.requests[1].Prompt; !strings.Contains(prompt, "- alice: Please keep the old flag working") || strings.Contains(prompt, "review passed") {
+		t.Fatalf("expected the reviewer comment in the remediation prompt, got %q", prompt)
+	}
+	if data := mgr.dataByID["t-1"]; data["review_retry_count"] != "1" || data["reason"] != reviewerCommentsReason {
+		t.Fatalf("expected remediation recorded in task data, got %#v", data)
+	}
+}
+
+func TestFormatReviewerCommentsSkipsLifecycleAndEmptyComments(t *testing.T) {
+	got := formatReviewerComments([]contracts.TaskComment{
+		{Author: "bot", Body: "yolo-runner: started\nworker: worker-0"},
+		{Author: "alice", Body: "  "},
+		{Body: "Rename the flag\nand update the docs"},
+	})
+	want := "Reviewer comments left on the task while it awaited approval:\n- reviewer: Rename the flag\n  and update the docs"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got := formatReviewerComments([]contracts.TaskComment{{Body: "yolo-runner: landed"}}); got != "" {
+		t.Fatalf("expected no feedback from lifecycle comments only, got %q", got)
+	}
+}
@@ -0,0 +1,242 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const operatorCancelReason = "canceled by operator"
+
+// ErrTaskRunning is returned by RetryTask for a task that is still in flight.
+var ErrTaskRunning = errors.New("task is running")
+
+// runControl holds operator requests that arrive while the loop runs: pausing
+// dispatch and cancelling in-flight tasks. It also tracks in-flight tasks
+// whose status someone changed in the tracker.
+type runControl struct {
+	mu       sync.Mutex
+	paused   bool
+	resumed  chan struct{}
+	running  map[string]context.CancelFunc
+	canceled map[string]bool
+	// watched holds the tasks whose runner is working, while the loop
+	// itself writes no status but in_progress.
+	watched  map[string]bool
+	external map[string]contracts.TaskStatus
+	// interrupted holds the in-flight tasks Interrupt canceled.
+	interrupted map[string]bool
+}
+
+func newRunControl() *runControl {
+	return &runControl{
+		running:     map[string]context.CancelFunc{},
+		canceled:    map[string]bool{},
+		watched:     map[string]bool{},
+		external:    map[string]contracts.TaskStatus{},
+		interrupted: map[string]bool{},
+	}
+}
+
+// pauseState reports whether dispatch is paused and, if so, a channel that is
+// closed on resume.
+func (c *runControl) pauseState() (bool, <-chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused, c.resumed
+}
+
+func (c *runControl) startTask(ctx context.Context, taskID string) context.Context {
+	taskCtx, cancel := context.WithCancel(ctx)
+	c.mu.Lock()
+	c.running[taskID] = cancel
+	delete(c.canceled, taskID)
+	delete(c.external, taskID)
+	delete(c.interrupted, taskID)
+	c.mu.Unlock()
+	return taskCtx
+}
+
+// finishTask releases the task's context and reports whether an operator
+// canceled it and the tracker status that canceled it, if any.
+func (c *runControl) finishTask(taskID string) (bool, contracts.TaskStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cancel, ok := c.running[taskID]; ok {
+		cancel()
+	}
+	delete(c.running, taskID)
+	delete(c.watched, taskID)
+	canceled := c.canceled[taskID]
+	delete(c.canceled, taskID)
+	external := c.external[taskID]
+	delete(c.external, taskID)
+	return canceled, external
+}
+
+func (c *runControl) watch(taskID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.watched[taskID] = true
+}
+
+// unwatch stops watching the task and reports whether it was watched.
+func (c *runControl) unwatch(taskID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	watched := c.watched[taskID]
+	delete(c.watched, taskID)
+	return watched
+}
+
+// watchedTasks returns the IDs of tasks whose runner is working.
+func (c *runControl) watchedTasks() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := make([]string, 0, len(c.watched))
+	for id := range c.watched {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// cancelForExternalStatus cancels a task that is still watched, recording
+// the status it was changed to. It reports whether the task was canceled.
+func (c *runControl) cancelForExternalStatus(taskID string, status contracts.TaskStatus) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cancel, running := c.running[taskID]
+	if !running || !c.watched[taskID] {
+		return false
+	}
+	c.external[taskID] = status
+	cancel()
+	return true
+}
+
+// Pause stops dispatching new tasks. Tasks already in flight keep running.
+func (l *Loop) Pause() {
+	l.control.mu.Lock()
+	defer l.control.mu.Unlock()
+	if !l.control.paused {
+		l.control.paused = true
+		l.control.resumed = make(chan struct{})
+	}
+}
+
+// Resume restarts dispatch after Pause.
+func (l *Loop) Resume() {
+	l.control.mu.Lock()
+	defer l.control.mu.Unlock()
+	if l.control.paused {
+		l.control.paused = false
+		close(l.control.resumed)
+		l.control.resumed = nil
+	}
+}
+
+func (l *Loop) Paused() bool {
+	paused, _ := l.control.pauseState()
+	return paused
+}
+
+// RunningTasks returns the IDs of tasks currently in flight.
+func (l *Loop) RunningTasks() []string {
+	l.control.mu.Lock()
+	defer l.control.mu.Unlock()
+	ids := make([]string, 0, len(l.control.running))
+	for id := range l.control.running {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// CancelTask stops a task. An in-flight task has its runner canceled and
+// finishes as blocked; a task that is not running is blocked directly so the
+// loop does not pick it up. It reports whether the task was in flight.
+func (l *Loop) CancelTask(ctx context.Context, taskID string) (bool, error) {
+	taskID = strings.TrimSpace(taskID)
+	l.control.mu.Lock()
+	cancel, running := l.control.running[taskID]
+	if running {
+		l.control.canceled[taskID] = true
+	}
+	l.control.mu.Unlock()
+	if running {
+		cancel()
+		return true, nil
+	}
+	return false, l.blockCanceledTask(ctx, taskID, "", 0)
+}
+
+// RetryTask reopens a blocked or failed task so the loop dispatches it again.
+func (l *Loop) RetryTask(ctx context.Context, taskID string) error {
+	taskID = strings.TrimSpace(taskID)
+	l.control.mu.Lock()
+	_, running := l.control.running[taskID]
+	l.control.mu.Unlock()
+	if running {
+		return ErrTaskRunning
+	}
+	if _, err := l.tasks.GetTask(ctx, taskID); err != nil {
+		return err
+	}
+	if err := l.clearTaskTerminalState(taskID); err != nil {
+		return err
+	}
+	if err := l.tasks.SetTaskStatus(ctx, taskID, contracts.TaskStatusOpen); err != nil {
+		return err
+	}
+	_ = l.emit(ctx, contracts.Event{
+		Type:      contracts.EventTypeTaskStatusSet,
+		TaskID:    taskID,
+		Message:   string(contracts.TaskStatusOpen),
+		Metadata:  appendDecisionMetadata(map[string]string{"status": string(contracts.TaskStatusOpen)}, "retry", "retried by operator"),
+		Timestamp: time.Now().UTC(),
+	})
+	return nil
+}
+
+// blockCanceledTask records an operator cancel as a blocked outcome. worker is
+// empty for a task canceled before it was dispatched.
+func (l *Loop) blockCanceledTask(ctx context.Context, taskID string, worker string, queuePos int) error {
+	task, err := l.tasks.GetTask(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	blockedData := appendDecisionMetadata(map[string]string{
+		"triage_status": "blocked",
+		"triage_reason": operatorCancelReason,
+	}, "blocked", operatorCancelReason)
+	blockedData = appendTriageCategory(blockedData, contracts.FailureCategoryOperatorCancel)
+	if err := l.markTaskBlockedWithData(task.ID, blockedData); err != nil {
+		return err
+	}
+	if err := l.tasks.SetTaskStatus(ctx, task.ID, contracts.TaskStatusBlocked); err != nil {
+		return err
+	}
+	if err := l.setTaskData(ctx, task.ID, blockedData); err != nil {
+		return err
+	}
+	eventType := contracts.EventTypeTaskStatusSet
+	if worker != "" {
+		eventType = contracts.EventTypeTaskFinished
+	}
+	_ = l.emit(ctx, contracts.Event{
+		Type:      eventType,
+		TaskID:    task.ID,
+		TaskTitle: task.Title,
+		WorkerID:  worker,
+		QueuePos:  queuePos,
+		Message:   string(contracts.TaskStatusBlocked),
+		Metadata:  blockedData,
+		Timestamp: time.Now().UTC(),
+	})
+	return nil
+}
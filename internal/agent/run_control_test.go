@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// cancelAwareRunner blocks each run until its context is canceled.
+type cancelAwareRunner struct {
+	started chan string
+}
+
+func (r *cancelAwareRunner) Run(ctx context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	r.started <- request.TaskID
+	<-ctx.Done()
+	return contracts.RunnerResult{}, ctx.Err()
+}
+
+type lockedRecordingSink struct {
+	mu     sync.Mutex
+	events []contracts.Event
+}
+
+func (s *lockedRecordingSink) Emit(_ context.Context, event contracts.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *lockedRecordingSink) has(eventType contracts.EventType, taskID string, message string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, event := range s.events {
+		if event.Type == eventType && event.TaskID == taskID && event.Message == message {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLoopPauseHoldsDispatchUntilResume(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root"})
+	loop.Pause()
+	if !loop.Paused() {
+		t.Fatalf("expected loop to report paused")
+	}
+
+	done := make(chan contracts.LoopSummary, 1)
+	go func() {
+		summary, err := loop.Run(context.Background())
+		if err != nil {
+			t.Errorf("loop failed: %v", err)
+		}
+		done <- summary
+	}()
+
+	select {
+	case <-done:
+		t.Fatalf("expected paused loop to wait instead of finishing")
+	case <-time.After(50 * time.Millisecond):
+	}
+	loop.Resume()
+	select {
+	case summary := <-done:
+		if summary.Completed != 1 {
+			t.Fatalf("expected task to run after resume, got %#v", summary)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected loop to finish after resume")
+	}
+}
+
+func TestLoopCancelTaskBlocksInFlightTask(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &cancelAwareRunner{started: make(chan string, 1)}
+	sink := &lockedRecordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root"})
+
+	type outcome struct {
+		summary contracts.LoopSummary
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		summary, err := loop.Run(context.Background())
+		done <- outcome{summary, err}
+	}()
+
+	<-run.started
+	if err := loop.RetryTask(context.Background(), "t-1"); !errors.Is(err, ErrTaskRunning) {
+		t.Fatalf("expected retry of a running task to be rejected, got %v", err)
+	}
+	running, err := loop.CancelTask(context.Background(), "t-1")
+	if err != nil || !running {
+		t.Fatalf("expected in-flight cancel, got running=%v err=%v", running, err)
+	}
+
+	result := <-done
+	if result.err != nil {
+		t.Fatalf("expected cancel not to fail the run, got %v", result.err)
+	}
+	if result.summary.Blocked != 1 {
+		t.Fatalf("expected canceled task to count as blocked, got %#v", result.summary)
+	}
+	if mgr.statusByID["t-1"] != contracts.TaskStatusBlocked || mgr.dataByID["t-1"]["triage_reason"] != operatorCancelReason {
+		t.Fatalf("expected task blocked with cancel reason, got status=%q data=%#v", mgr.statusByID["t-1"], mgr.dataByID["t-1"])
+	}
+	if !sink.has(contracts.EventTypeTaskFinished, "t-1", string(contracts.TaskStatusBlocked)) {
+		t.Fatalf("expected blocked task_finished event")
+	}
+	if len(loop.RunningTasks()) != 0 {
+		t.Fatalf("expected no running tasks after cancel, got %v", loop.RunningTasks())
+	}
+}
+
+func TestLoopCancelAndRetryQueuedTask(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	sink := &lockedRecordingSink{}
+	loop := NewLoop(mgr, &fakeRunner{}, sink, LoopOptions{ParentID: "root"})
+
+	running, err := loop.CancelTask(context.Background(), "t-1")
+	if err != nil || running {
+		t.Fatalf("expected queued cancel, got running=%v err=%v", running, err)
+	}
+	if mgr.statusByID["t-1"] != contracts.TaskStatusBlocked {
+		t.Fatalf("expected queued task to be blocked, got %q", mgr.statusByID["t-1"])
+	}
+	if !sink.has(contracts.EventTypeTaskStatusSet, "t-1", string(contracts.TaskStatusBlocked)) {
+		t.Fatalf("expected task_status_set event for queued cancel")
+	}
+
+	if err := loop.RetryTask(context.Background(), "t-1"); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if mgr.statusByID["t-1"] != contracts.TaskStatusOpen {
+		t.Fatalf("expected retried task to be reopened, got %q", mgr.statusByID["t-1"])
+	}
+	if err := loop.RetryTask(context.Background(), "missing"); err == nil {
+		t.Fatalf("expected retry of an unknown task to fail")
+	}
+}
@@ -74,7 +74,7 @@ func (l *Loop) recoverSchedulerState(ctx context.Context) error {
 		if storedData, exists := snapshot.TaskData[taskID]; exists {
 			taskData = storedData
 		}
-		if err := l.tasks.SetTaskData(ctx, taskID, taskData); err != nil {
+		if err := l.setTaskData(ctx, taskID, taskData); err != nil {
 			return err
 		}
 		delete(snapshot.Blocked, taskID)
@@ -87,7 +87,7 @@ func (l *Loop) recoverSchedulerState(ctx context.Context) error {
 		if _, blocked := snapshot.Blocked[taskID]; blocked {
 			continue
 		}
-		if err := l.tasks.SetTaskStatus(ctx, taskID, contracts.TaskStatusOpen); err != nil {
+		if err := l.recoverOrphanedTask(ctx, taskID); err != nil {
 			return err
 		}
 	}
@@ -127,6 +127,7 @@ func (l *Loop) markTaskBlocked(taskID string) error {
 }
 
 func (l *Loop) markTaskBlockedWithData(taskID string, taskData map[string]string) error {
+	l.blockedRetries.noteBlocked(taskID, taskData)
 	if l.schedulerState == nil {
 		return nil
 	}
@@ -0,0 +1,196 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// SecretScanConfig enables scanning of each task's diff for secrets before
+// it is reviewed and landed.
+type SecretScanConfig struct {
+	// Enabled runs the built-in rules over the lines the task added.
+	Enabled bool
+	// Command is an optional external scanner run with sh -c in the clone,
+	// for example "gitleaks protect --no-banner". A non-zero exit is a
+	// finding.
+	Command string
+	// Timeout bounds Command; zero means the landing hook default.
+	Timeout time.Duration
+}
+
+func (c SecretScanConfig) enabled() bool {
+	return c.Enabled || strings.TrimSpace(c.Command) != ""
+}
+
+type secretRule struct {
+	id      string
+	pattern *regexp.Regexp
+}
+
+// builtinSecretRules are gitleaks-style rules for common credential
+// formats. They favour precision: a rule should rarely fire on code.
+var builtinSecretRules = []secretRule{
+	{"private-key", regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+	{"aws-access-key-id", regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`)},
+	{"github-token", regexp.MustCompile(`\bgh[pousr]_[0-9A-Za-z]{36,255}\b`)},
+	{"github-fine-grained-token", regexp.MustCompile(`\bgithub_pat_[0-9A-Za-z_]{60,255}\b`)},
+	{"gitlab-token", regexp.MustCompile(`\bglpat-[0-9A-Za-z_\-]{20,}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abposr]-[0-9A-Za-z\-]{10,}`)},
+	{"stripe-secret-key", regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}\b`)},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{"anthropic-api-key", regexp.MustCompile(`\bsk-ant-[0-9A-Za-z_\-]{32,}`)},
+	{"openai-api-key", regexp.MustCompile(`\bsk-(?:proj-)?[0-9A-Za-z_\-]{20,}T3BlbkFJ[0-9A-Za-z_\-]{20,}`)},
+	{"generic-secret", regexp.MustCompile(`(?i)\b(?:api[_-]?key|secret[_-]?key|client[_-]?secret|access[_-]?token|auth[_-]?token|password|passwd)["']?\s*(?::=|[:=])\s*["'][^"'\s]{12,}["']`)},
+}
+
+// secretFinding is one rule match on an added line. Evidence is redacted.
+type secretFinding struct {
+	Path     string
+	Line     int
+	Rule     string
+	Evidence string
+}
+
+func (f secretFinding) String() string {
+	location := f.Path
+	if f.Line > 0 {
+		location += ":" + strconv.Itoa(f.Line)
+	}
+	if location == "" {
+		return fmt.Sprintf("%s (%s)", f.Rule, f.Evidence)
+	}
+	return fmt.Sprintf("%s %s (%s)", location, f.Rule, f.Evidence)
+}
+
+var diffHunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// scanDiffForSecrets runs the built-in rules over the added lines of a
+// unified diff. Runner artifacts are skipped.
+func scanDiffForSecrets(diff string) []secretFinding {
+	findings := []secretFinding{}
+	path := ""
+	line := 0
+	previous := ""
+	for _, text := range strings.Split(diff, "\n") {
+		header := strings.HasPrefix(previous, "--- ")
+		previous = text
+		switch {
+		case header && strings.HasPrefix(text, "+++ "):
+			path = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+			if path == "/dev/null" {
+				path = ""
+			}
+		case strings.HasPrefix(text, "@@"):
+			if match := diffHunkHeader.FindStringSubmatch(text); match != nil {
+				line, _ = strconv.Atoi(match[1])
+			}
+		case strings.HasPrefix(text, "+"):
+			if path != "" && !isRunnerArtifactPath(path) {
+				for _, rule := range builtinSecretRules {
+					if match := rule.pattern.FindString(text[1:]); match != "" {
+						findings = append(findings, secretFinding{Path: path, Line: line, Rule: rule.id, Evidence: redactSecret(match)})
+					}
+				}
+			}
+			line++
+		case strings.HasPrefix(text, " "):
+			line++
+		}
+	}
+	return findings
+}
+
+// redactSecret keeps enough of a match to recognise the credential type
+// without revealing it.
+func redactSecret(secret string) string {
+	const keep = 4
+	if len(secret) <= keep {
+		return "****"
+	}
+	return secret[:keep] + "****"
+}
+
+// redactSecrets masks every built-in rule match in text, for scanner output
+// that is recorded in the task data.
+func redactSecrets(text string) string {
+	for _, rule := range builtinSecretRules {
+		text = rule.pattern.ReplaceAllStringFunc(text, redactSecret)
+	}
+	return text
+}
+
+// runSecretScan scans the task's diff after implementation and blocks the
+// task, with redacted evidence and a security_alert event, when a secret is
+// found. It reports true when the task was blocked.
+func (l *Loop) runSecretScan(ctx context.Context, task contracts.Task, taskVCS contracts.VCS, worker string, queuePos int, taskRepoRoot string) (bool, error) {
+	config := l.options.SecretScan
+	if !config.enabled() {
+		return false, nil
+	}
+	findings := []secretFinding{}
+	if config.Enabled {
+		if reader, ok := taskVCS.(contracts.DiffReader); ok {
+			diff, err := reader.Diff(ctx)
+			if err != nil {
+				return false, err
+			}
+			findings = append(findings, scanDiffForSecrets(diff)...)
+		}
+	}
+	if command := strings.TrimSpace(config.Command); command != "" {
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = defaultLandingHookTimeout
+		}
+		scanCtx, cancel := context.WithTimeout(ctx, timeout)
+		output, err := runLandingHookCommand(scanCtx, taskRepoRoot, command, nil)
+		if err != nil && errors.Is(scanCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		cancel()
+		if err != nil {
+			evidence := err.Error()
+			if line := lastNonEmptyLine(output); line != "" {
+				evidence = line
+			}
+			findings = append(findings, secretFinding{Rule: "command", Evidence: redactSecrets(evidence)})
+		}
+	}
+	if len(findings) == 0 {
+		return false, nil
+	}
+
+	described := make([]string, 0, len(findings))
+	rules := []string{}
+	seenRules := map[string]struct{}{}
+	for _, finding := range findings {
+		described = append(described, finding.String())
+		if _, ok := seenRules[finding.Rule]; !ok {
+			seenRules[finding.Rule] = struct{}{}
+			rules = append(rules, finding.Rule)
+		}
+	}
+	reason := fmt.Sprintf("secret scan found %d potential secret(s): %s", len(findings), limitedList(described))
+	metadata := map[string]string{
+		"secret_findings":   strconv.Itoa(len(findings)),
+		"secret_scan_rules": strings.Join(rules, ","),
+		"triage_reason":     reason,
+	}
+	_ = l.emit(ctx, contracts.Event{Type: contracts.EventTypeSecurityAlert, TaskID: task.ID, TaskTitle: task.Title, WorkerID: worker, ClonePath: taskRepoRoot, QueuePos: queuePos, Message: reason, Metadata: metadata, Timestamp: time.Now().UTC()})
+
+	blockedData := appendDecisionMetadata(map[string]string{
+		"triage_status":     "blocked",
+		"triage_reason":     reason,
+		"secret_findings":   metadata["secret_findings"],
+		"secret_scan_rules": metadata["secret_scan_rules"],
+	}, "blocked", reason)
+	blockedData = appendTriageCategory(blockedData, contracts.FailureCategorySecretScan)
+	return true, l.blockTask(ctx, task, worker, queuePos, taskRepoRoot, blockedData)
+}
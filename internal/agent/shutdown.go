@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// ErrRunInterrupted is returned by Run when shutdown interrupted in-flight
+// tasks. They were reopened, so a later run picks them up again.
+var ErrRunInterrupted = errors.New("run interrupted")
+
+const shutdownInterruptReason = "interrupted by shutdown"
+
+// interruptedSessionMetadataKey holds the backend session of an implement run
+// that shutdown interrupted. With resume_sessions, the next run of the task
+// continues it.
+const interruptedSessionMetadataKey = "interrupted_session_id"
+
+// Interrupt cancels the runners of every in-flight task. The tasks are
+// reopened for the next run instead of finishing as blocked. Close
+// LoopOptions.Stop as well to stop dispatching.
+func (l *Loop) Interrupt() {
+	l.control.interruptAll()
+}
+
+func (c *runControl) interruptAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for taskID, cancel := range c.running {
+		c.interrupted[taskID] = true
+		cancel()
+	}
+}
+
+func (c *runControl) isInterrupted(taskID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.interrupted[taskID]
+}
+
+// takeInterrupted reports whether shutdown interrupted the task and forgets
+// it.
+func (c *runControl) takeInterrupted(taskID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	interrupted := c.interrupted[taskID]
+	delete(c.interrupted, taskID)
+	return interrupted
+}
+
+// saveInterruptedSession records the backend session of an interrupted
+// implement run so the next run can resume it.
+func (l *Loop) saveInterruptedSession(ctx context.Context, task contracts.Task, result contracts.RunnerResult) error {
+	if !l.options.ResumeSessions || result.Artifacts == nil {
+		return nil
+	}
+	sessionID := strings.TrimSpace(result.Artifacts[contracts.SessionIDArtifactKey])
+	if sessionID == "" {
+		return nil
+	}
+	return l.setTaskData(context.WithoutCancel(ctx), task.ID, map[string]string{interruptedSessionMetadataKey: sessionID})
+}
+
+// reopenInterruptedTask undoes whatever outcome an interrupted task reached,
+// so the next run dispatches it again.
+func (l *Loop) reopenInterruptedTask(ctx context.Context, taskID string, worker string, queuePos int) error {
+	ctx = context.WithoutCancel(ctx)
+	if err := l.clearTaskTerminalState(taskID); err != nil {
+		return err
+	}
+	if err := l.tasks.SetTaskStatus(ctx, taskID, contracts.TaskStatusOpen); err != nil {
+		return err
+	}
+	task, err := l.tasks.GetTask(ctx, taskID)
+	if err != nil {
+		task = contracts.Task{ID: taskID}
+	}
+	_ = l.emit(ctx, contracts.Event{
+		Type:      contracts.EventTypeTaskFinished,
+		TaskID:    task.ID,
+		TaskTitle: task.Title,
+		WorkerID:  worker,
+		QueuePos:  queuePos,
+		Message:   string(contracts.TaskStatusOpen),
+		Metadata:  appendDecisionMetadata(map[string]string{"status": string(contracts.TaskStatusOpen)}, "interrupted", shutdownInterruptReason),
+		Timestamp: time.Now().UTC(),
+	})
+	return nil
+}
+
+// emitRunInterrupted reports a run stopped by LoopOptions.Stop, naming the
+// tasks whose runners it interrupted.
+func (l *Loop) emitRunInterrupted(ctx context.Context, interrupted []string) {
+	sort.Strings(interrupted)
+	metadata := map[string]string{
+		"interrupted_count": strconv.Itoa(len(interrupted)),
+		"interrupted_tasks": strings.Join(interrupted, ","),
+	}
+	if l.options.ShutdownGrace > 0 {
+		metadata["shutdown_grace"] = l.options.ShutdownGrace.String()
+	}
+	_ = l.emit(context.WithoutCancel(ctx), contracts.Event{
+		Type:      contracts.EventTypeRunInterrupted,
+		TaskID:    l.options.ParentID,
+		Message:   shutdownInterruptReason,
+		Metadata:  compactMetadata(metadata),
+		Timestamp: time.Now().UTC(),
+	})
+}
@@ -0,0 +1,163 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// sessionRunner blocks each run until its context is canceled and reports the
+// backend session it was working in.
+type sessionRunner struct {
+	started chan string
+}
+
+func (r *sessionRunner) Run(ctx context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	r.started <- request.TaskID
+	<-ctx.Done()
+	return contracts.RunnerResult{
+		Status:    contracts.RunnerResultFailed,
+		Reason:    "canceled",
+		Artifacts: map[string]string{"mode": "implement", contracts.SessionIDArtifactKey: "sess-" + request.TaskID},
+	}, ctx.Err()
+}
+
+func TestLoopStopLetsInFlightTasksFinishWithinGrace(t *testing.T) {
+	mgr := newFakeTaskManager(
+		contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen},
+		contracts.Task{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen},
+	)
+	run := &dependencyTrackingRunner{release: make(chan struct{}), started: make(chan string, 2)}
+	sink := &lockedRecordingSink{}
+	stop := make(chan struct{})
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", Stop: stop, ShutdownGrace: time.Minute})
+
+	type outcome struct {
+		summary contracts.LoopSummary
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		summary, err := loop.Run(context.Background())
+		done <- outcome{summary, err}
+	}()
+	<-run.started
+	close(stop)
+	time.Sleep(50 * time.Millisecond)
+	close(run.release)
+
+	result := <-done
+	if result.err != nil {
+		t.Fatalf("loop failed: %v", result.err)
+	}
+	if result.summary.Completed != 1 {
+		t.Fatalf("expected the in-flight task to finish and nothing else to start, got %#v", result.summary)
+	}
+	if mgr.statusByID["t-2"] != contracts.TaskStatusOpen {
+		t.Fatalf("expected t-2 to stay open, got %s", mgr.statusByID["t-2"])
+	}
+	if !sink.has(contracts.EventTypeRunInterrupted, "root", shutdownInterruptReason) {
+		t.Fatalf("expected run_interrupted, got %#v", sink.events)
+	}
+}
+
+func TestLoopInterruptsAndReopensTasksWhenGraceRunsOut(t *testing.T) {
+	mgr := newFakeTaskManager(
+		contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen},
+		contracts.Task{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen},
+	)
+	run := &sessionRunner{started: make(chan string, 2)}
+	sink := &lockedRecordingSink{}
+	stop := make(chan struct{})
+	statePath := t.TempDir() + "/scheduler-state.json"
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", Concurrency: 2, Stop: stop, ShutdownGrace: 20 * time.Millisecond, ResumeSessions: true, SchedulerStatePath: statePath})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := loop.Run(context.Background())
+		done <- err
+	}()
+	<-run.started
+	<-run.started
+	close(stop)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrRunInterrupted) {
+			t.Fatalf("expected ErrRunInterrupted, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the loop to interrupt its tasks after the grace period")
+	}
+	for _, taskID := range []string{"t-1", "t-2"} {
+		if mgr.statusByID[taskID] != contracts.TaskStatusOpen {
+			t.Fatalf("expected %s reopened, got %s", taskID, mgr.statusByID[taskID])
+		}
+		if got := mgr.dataByID[taskID][interruptedSessionMetadataKey]; got != "sess-"+taskID {
+			t.Fatalf("expected %s session saved, got %q", taskID, got)
+		}
+	}
+	var interrupted contracts.Event
+	for _, event := range sink.events {
+		if event.Type == contracts.EventTypeRunInterrupted {
+			interrupted = event
+		}
+	}
+	if interrupted.Metadata["interrupted_tasks"] != "t-1,t-2" || interrupted.Metadata["interrupted_count"] != "2" {
+		t.Fatalf("expected run_interrupted to name both tasks, got %#v", interrupted)
+	}
+	snapshot, err := newSchedulerStateStore(statePath, "root").Load()
+	if err != nil {
+		t.Fatalf("load scheduler state: %v", err)
+	}
+	if len(snapshot.InFlight) != 0 || len(snapshot.Blocked) != 0 || len(snapshot.Completed) != 0 {
+		t.Fatalf("expected no task left in the scheduler state, got %#v", snapshot)
+	}
+}
+
+func TestLoopInterruptCancelsInFlightTasksAtOnce(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &cancelAwareRunner{started: make(chan string, 1)}
+	stop := make(chan struct{})
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", Stop: stop})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := loop.Run(context.Background())
+		done <- err
+	}()
+	<-run.started
+	close(stop)
+	loop.Interrupt()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrRunInterrupted) {
+			t.Fatalf("expected ErrRunInterrupted, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected Interrupt to cancel the task")
+	}
+	if mgr.statusByID["t-1"] != contracts.TaskStatusOpen {
+		t.Fatalf("expected t-1 reopened, got %s", mgr.statusByID["t-1"])
+	}
+}
+
+func TestLoopResumesSessionInterruptedByShutdown(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, Metadata: map[string]string{interruptedSessionMetadataKey: "sess-1"}})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", ResumeSessions: true})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if len(run.requests) != 1 || run.requests[0].Metadata[contracts.ResumeSessionIDMetadataKey] != "sess-1" {
+		t.Fatalf("expected the implement run to resume sess-1, got %#v", run.requests)
+	}
+	if got := mgr.dataByID["t-1"][interruptedSessionMetadataKey]; got != "" {
+		t.Fatalf("expected the interrupted session to be resumed once, got %q", got)
+	}
+}
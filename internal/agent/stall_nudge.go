@@ -0,0 +1,36 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// DefaultStallNudgePrompt answers an agent that stalled waiting for a reply.
+const DefaultStallNudgePrompt = "Proceed with the most reasonable assumption and document it."
+
+// stallNudgedMetadataKey marks tasks that already received the nudge, so a
+// second question stall blocks the task.
+const stallNudgedMetadataKey = "stall_nudged"
+
+// stallNudge returns the nudge for a stalled run whose stall policy is nudge,
+// or "" when the run should not be nudged.
+func (l *Loop) stallNudge(result contracts.RunnerResult) string {
+	if l.stallPolicy(result) != contracts.StallPolicyNudge {
+		return ""
+	}
+	if nudge := strings.TrimSpace(l.options.StallNudgePrompt); nudge != "" {
+		return nudge
+	}
+	return DefaultStallNudgePrompt
+}
+
+// stallNudgeMessage is sent to a resumed session and appended to a fresh
+// implement prompt.
+func stallNudgeMessage(category contracts.StallCategory, nudge string) string {
+	stalled := "- The previous run stopped while waiting for an answer to a question. Nobody will answer it.\n"
+	if category != contracts.StallCategoryQuestion {
+		stalled = "- The previous run stalled (" + string(category) + "). Nobody is watching it.\n"
+	}
+	return "Stall Auto-Response:\n" + stalled + "- " + strings.TrimSpace(nudge)
+}
@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// defaultStallWatchdogTimeout matches the opencode watchdog default and is
+// the base for extend_timeout when no watchdog timeout is configured.
+const defaultStallWatchdogTimeout = 10 * time.Minute
+
+// watchdogExtensionsMetadataKey counts extend_timeout retries so a requeued
+// task keeps its longer watchdog timeout.
+const watchdogExtensionsMetadataKey = "watchdog_extensions"
+
+// stallPolicy returns the configured policy for a stalled run. It returns ""
+// when the run did not stall or its category has no policy, leaving the
+// default blocked handling in place. Question stalls fall back to nudge while
+// a nudge prompt is configured.
+func (l *Loop) stallPolicy(result contracts.RunnerResult) contracts.StallPolicy {
+	category := contracts.StallCategoryFromArtifacts(result.Artifacts)
+	if category == "" {
+		return ""
+	}
+	if policy, ok := l.options.StallPolicies[category]; ok {
+		return policy
+	}
+	if category == contracts.StallCategoryQuestion && strings.TrimSpace(l.options.StallNudgePrompt) != "" {
+		return contracts.StallPolicyNudge
+	}
+	return ""
+}
+
+// watchdogTimeoutFor doubles the watchdog timeout once per extend_timeout
+// retry.
+func (l *Loop) watchdogTimeoutFor(extensions int) time.Duration {
+	timeout := l.options.WatchdogTimeout
+	if timeout <= 0 {
+		if extensions == 0 {
+			return 0
+		}
+		timeout = defaultStallWatchdogTimeout
+	}
+	for i := 0; i < extensions; i++ {
+		timeout *= 2
+	}
+	return timeout
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const externalStatusReason = "status changed in tracker"
+
+// externalTaskStatus reads the task's tracker status and reports it when it
+// is closed, blocked or failed. It is only asked about tasks the loop has
+// marked in_progress, so such a status was set by someone else. It reads
+// through a canceled task context, and a failed read reports no change.
+func (l *Loop) externalTaskStatus(ctx context.Context, taskID string) (contracts.TaskStatus, bool) {
+	task, err := l.tasks.GetTask(context.WithoutCancel(ctx), taskID)
+	if err != nil {
+		return "", false
+	}
+	switch task.Status {
+	case contracts.TaskStatusClosed, contracts.TaskStatusBlocked, contracts.TaskStatusFailed:
+		return task.Status, true
+	default:
+		return "", false
+	}
+}
+
+// reconcileRunningTasks cancels the runner of every in-flight task whose
+// tracker status someone changed while it worked. The task finishes as
+// skipped and keeps that status.
+func (l *Loop) reconcileRunningTasks(ctx context.Context) {
+	for _, taskID := range l.control.watchedTasks() {
+		if ctx.Err() != nil {
+			return
+		}
+		if status, changed := l.externalTaskStatus(ctx, taskID); changed {
+			l.control.cancelForExternalStatus(taskID, status)
+		}
+	}
+}
+
+// yieldToExternalStatus finishes a task whose tracker status someone else
+// changed, without writing a status or data over theirs.
+func (l *Loop) yieldToExternalStatus(ctx context.Context, task contracts.Task, status contracts.TaskStatus, worker string, clonePath string, queuePos int) error {
+	if err := l.clearTaskTerminalState(task.ID); err != nil {
+		return err
+	}
+	_ = l.emit(context.WithoutCancel(ctx), contracts.Event{
+		Type:      contracts.EventTypeTaskFinished,
+		TaskID:    task.ID,
+		TaskTitle: task.Title,
+		WorkerID:  worker,
+		ClonePath: clonePath,
+		QueuePos:  queuePos,
+		Message:   string(status),
+		Metadata: appendDecisionMetadata(map[string]string{
+			"external_status": string(status),
+		}, "skipped", externalStatusReason+" to "+string(status)),
+		Timestamp: time.Now().UTC(),
+	})
+	return nil
+}
+
+// yieldIfStatusChanged stops watching the task, which the loop is about to
+// write an outcome for, and yields to a tracker status someone set while its
+// runner worked. It reports whether the task was yielded.
+func (l *Loop) yieldIfStatusChanged(ctx context.Context, task contracts.Task, worker string, clonePath string, queuePos int) (bool, error) {
+	if !l.control.unwatch(task.ID) {
+		return false, nil
+	}
+	status, changed := l.externalTaskStatus(ctx, task.ID)
+	if !changed {
+		return false, nil
+	}
+	return true, l.yieldToExternalStatus(ctx, task, status, worker, clonePath, queuePos)
+}
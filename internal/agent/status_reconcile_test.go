@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// statusChangingRunner sets the task's status the way a human editing the
+// tracker would, then completes.
+type statusChangingRunner struct {
+	mgr    *fakeTaskManager
+	status contracts.TaskStatus
+}
+
+func (r *statusChangingRunner) Run(ctx context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	if err := r.mgr.SetTaskStatus(ctx, request.TaskID, r.status); err != nil {
+		return contracts.RunnerResult{}, err
+	}
+	return contracts.RunnerResult{Status: contracts.RunnerResultCompleted}, nil
+}
+
+func TestLoopKeepsStatusSetInTrackerWhileRunnerWorked(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	sink := &lockedRecordingSink{}
+	loop := NewLoop(mgr, &statusChangingRunner{mgr: mgr, status: contracts.TaskStatusBlocked}, sink, LoopOptions{ParentID: "root"})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Skipped != 1 || summary.Completed != 0 {
+		t.Fatalf("expected the task skipped, got %#v", summary)
+	}
+	if mgr.statusByID["t-1"] != contracts.TaskStatusBlocked {
+		t.Fatalf("expected the tracker's blocked status kept, got %q", mgr.statusByID["t-1"])
+	}
+	if len(mgr.dataByID["t-1"]) != 0 {
+		t.Fatalf("expected no task data written, got %#v", mgr.dataByID["t-1"])
+	}
+	finished, ok := findEventByType(sink.events, contracts.EventTypeTaskFinished)
+	if !ok || finished.Message != string(contracts.TaskStatusBlocked) || finished.Metadata["external_status"] != "blocked" || finished.Metadata["decision"] != "skipped" {
+		t.Fatalf("expected a skipped task_finished event, got %#v", finished)
+	}
+}
+
+func TestLoopCancelsRunnerOfTaskClosedInTracker(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &cancelAwareRunner{started: make(chan string, 1)}
+	sink := &lockedRecordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", StatusReconcileInterval: 5 * time.Millisecond})
+
+	type outcome struct {
+		summary contracts.LoopSummary
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		summary, err := loop.Run(context.Background())
+		done <- outcome{summary, err}
+	}()
+
+	<-run.started
+	if err := mgr.SetTaskStatus(context.Background(), "t-1", contracts.TaskStatusClosed); err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	var result outcome
+	select {
+	case result = <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected the closed task's runner to be canceled")
+	}
+	if result.err != nil {
+		t.Fatalf("expected the cancel not to fail the run, got %v", result.err)
+	}
+	if result.summary.Skipped != 1 {
+		t.Fatalf("expected the task skipped, got %#v", result.summary)
+	}
+	if mgr.statusByID["t-1"] != contracts.TaskStatusClosed {
+		t.Fatalf("expected the tracker's closed status kept, got %q", mgr.statusByID["t-1"])
+	}
+	if !sink.has(contracts.EventTypeTaskFinished, "t-1", string(contracts.TaskStatusClosed)) {
+		t.Fatalf("expected a task_finished event with the tracker's status")
+	}
+}
+
+func TestRunControlOnlyCancelsWatchedTasksForExternalStatus(t *testing.T) {
+	control := newRunControl()
+	taskCtx := control.startTask(context.Background(), "t-1")
+	if control.cancelForExternalStatus("t-1", contracts.TaskStatusClosed) {
+		t.Fatalf("expected a task that is not watched to be left running")
+	}
+	control.watch("t-1")
+	if !control.cancelForExternalStatus("t-1", contracts.TaskStatusClosed) || taskCtx.Err() == nil {
+		t.Fatalf("expected the watched task canceled")
+	}
+	if canceled, external := control.finishTask("t-1"); canceled || external != contracts.TaskStatusClosed {
+		t.Fatalf("expected the external status reported, got canceled=%v external=%q", canceled, external)
+	}
+}
@@ -5,16 +5,34 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/egv/yolo-runner/v2/internal/contracts"
 )
 
+// taskGraphFullRefreshInterval bounds how long the graph is kept up to date
+// from change lists alone, which do not report removed tasks.
+const taskGraphFullRefreshInterval = 5 * time.Minute
+
 type storageEngineTaskManager struct {
 	mu      sync.Mutex
 	storage contracts.StorageBackend
 	engine  contracts.TaskEngine
 	rootID  string
 	graph   *contracts.TaskGraph
+	now     func() time.Time
+
+	// tree is the tree the graph reflects: the last full read with the
+	// changes read since merged in.
+	tree          *contracts.TaskTree
+	changeCursor  string
+	fullRefreshAt time.Time
+}
+
+// taskGraphUpdater is implemented by task engines that can bring a graph up
+// to date with a tree in place when only task fields and statuses changed.
+type taskGraphUpdater interface {
+	UpdateGraph(graph *contracts.TaskGraph, tree *contracts.TaskTree) (bool, error)
 }
 
 type taskStatePersistenceBackend interface {
@@ -25,12 +43,18 @@ type taskStatePersistenceBackend interface {
 var _ contracts.TaskManager = (*storageEngineTaskManager)(nil)
 var _ taskConcurrencyCalculator = (*storageEngineTaskManager)(nil)
 var _ taskCompletionChecker = (*storageEngineTaskManager)(nil)
+var _ taskGraphSnapshotProvider = (*storageEngineTaskManager)(nil)
+var _ epicProgressProvider = (*storageEngineTaskManager)(nil)
+var _ taskLister = (*storageEngineTaskManager)(nil)
+var _ contracts.TaskCreator = (*storageEngineTaskManager)(nil)
+var _ contracts.TaskCommentReader = (*storageEngineTaskManager)(nil)
 
 func newStorageEngineTaskManager(storage contracts.StorageBackend, taskEngine contracts.TaskEngine, rootID string) *storageEngineTaskManager {
 	return &storageEngineTaskManager{
 		storage: storage,
 		engine:  taskEngine,
 		rootID:  strings.TrimSpace(rootID),
+		now:     time.Now,
 	}
 }
 
@@ -91,6 +115,43 @@ func (m *storageEngineTaskManager) SetTaskData(ctx context.Context, taskID strin
 	return nil
 }
 
+// CreateTask forwards to the storage backend when it can create tasks. New
+// tasks enter the graph on the next NextTasks refresh.
+func (m *storageEngineTaskManager) CreateTask(ctx context.Context, request contracts.TaskCreateRequest) (string, error) {
+	creator, ok := m.storage.(contracts.TaskCreator)
+	if !ok {
+		return "", fmt.Errorf("tracker does not support creating tasks")
+	}
+	return creator.CreateTask(ctx, request)
+}
+
+// TaskComments forwards to the storage backend when it can list comments.
+func (m *storageEngineTaskManager) TaskComments(ctx context.Context, taskID string, since time.Time) ([]contracts.TaskComment, error) {
+	reader, ok := m.storage.(contracts.TaskCommentReader)
+	if !ok {
+		return nil, fmt.Errorf("tracker does not support reading comments")
+	}
+	return reader.TaskComments(ctx, taskID, since)
+}
+
+// AssignTask forwards to the storage backend when it can assign tasks.
+func (m *storageEngineTaskManager) AssignTask(ctx context.Context, taskID string, assignee string) error {
+	assigner, ok := m.storage.(contracts.TaskAssigner)
+	if !ok {
+		return fmt.Errorf("tracker does not support assigning tasks")
+	}
+	return assigner.AssignTask(ctx, taskID, assignee)
+}
+
+// AddTaskLabels forwards to the storage backend when it can label tasks.
+func (m *storageEngineTaskManager) AddTaskLabels(ctx context.Context, taskID string, labels []string) error {
+	labeler, ok := m.storage.(contracts.TaskLabeler)
+	if !ok {
+		return fmt.Errorf("tracker does not support labeling tasks")
+	}
+	return labeler.AddTaskLabels(ctx, taskID, labels)
+}
+
 func (m *storageEngineTaskManager) CalculateConcurrency(ctx context.Context, maxWorkers int) (int, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -124,9 +185,127 @@ func (m *storageEngineTaskManager) IsComplete(ctx context.Context) (bool, error)
 	if err := m.refreshGraphLocked(ctx, rootID); err != nil {
 		return false, err
 	}
+	if !m.engine.IsComplete(m.graph) {
+		return false, nil
+	}
+	// Confirm against the whole tree, since change lists miss removed tasks
+	// and tasks moved out of the tree.
+	if m.changeCursor != "" {
+		if err := m.fullRefreshLocked(ctx, rootID); err != nil {
+			return false, err
+		}
+	}
 	return m.engine.IsComplete(m.graph), nil
 }
 
+// TaskGraphSnapshot refreshes the graph and returns a deep copy of it, so
+// callers can read it without the lock while tasks keep changing.
+func (m *storageEngineTaskManager) TaskGraphSnapshot(ctx context.Context) (*contracts.TaskGraph, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rootID, err := m.resolveRootID("")
+	if err != nil {
+		return nil, err
+	}
+	if err := m.refreshGraphLocked(ctx, rootID); err != nil {
+		return nil, err
+	}
+	return cloneTaskGraph(m.graph), nil
+}
+
+// cloneTaskGraph copies graph with its nodes, pointing the copies' relations
+// at each other.
+func cloneTaskGraph(graph *contracts.TaskGraph) *contracts.TaskGraph {
+	if graph == nil {
+		return nil
+	}
+	clones := make(map[*contracts.TaskNode]*contracts.TaskNode, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		if node == nil {
+			continue
+		}
+		clone := *node
+		clone.Task.Metadata = cloneStringMap(node.Task.Metadata)
+		clones[node] = &clone
+	}
+	relink := func(node *contracts.TaskNode) *contracts.TaskNode {
+		if clone, ok := clones[node]; ok {
+			return clone
+		}
+		return node
+	}
+	relinkAll := func(nodes []*contracts.TaskNode) []*contracts.TaskNode {
+		if nodes == nil {
+			return nil
+		}
+		out := make([]*contracts.TaskNode, len(nodes))
+		for i, node := range nodes {
+			out[i] = relink(node)
+		}
+		return out
+	}
+	snapshot := &contracts.TaskGraph{
+		RootID: graph.RootID,
+		Nodes:  make(map[string]*contracts.TaskNode, len(graph.Nodes)),
+		Edges:  append([]contracts.TaskEdge(nil), graph.Edges...),
+	}
+	for id, node := range graph.Nodes {
+		if node == nil {
+			snapshot.Nodes[id] = nil
+			continue
+		}
+		clone := clones[node]
+		clone.Parent = relink(node.Parent)
+		clone.Children = relinkAll(node.Children)
+		clone.Dependencies = relinkAll(node.Dependencies)
+		clone.Dependents = relinkAll(node.Dependents)
+		snapshot.Nodes[id] = clone
+	}
+	return snapshot
+}
+
+// EpicProgress refreshes the graph and summarizes it while holding the lock,
+// since workers update node statuses in place.
+func (m *storageEngineTaskManager) EpicProgress(ctx context.Context) (EpicProgress, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rootID, err := m.resolveRootID("")
+	if err != nil {
+		return EpicProgress{}, err
+	}
+	if err := m.refreshGraphLocked(ctx, rootID); err != nil {
+		return EpicProgress{}, err
+	}
+	return summarizeEpicProgress(m.graph), nil
+}
+
+// ListTasks refreshes the graph and copies out every task in it while holding
+// the lock, since workers update node statuses in place.
+func (m *storageEngineTaskManager) ListTasks(ctx context.Context) ([]contracts.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rootID, err := m.resolveRootID("")
+	if err != nil {
+		return nil, err
+	}
+	if err := m.refreshGraphLocked(ctx, rootID); err != nil {
+		return nil, err
+	}
+	tasks := make([]contracts.Task, 0, len(m.graph.Nodes))
+	for _, node := range m.graph.Nodes {
+		if node == nil {
+			continue
+		}
+		task := node.Task
+		task.Status = node.Status
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
 func (m *storageEngineTaskManager) resolveRootID(parentID string) (string, error) {
 	if rootID := strings.TrimSpace(parentID); rootID != "" {
 		m.rootID = rootID
@@ -138,6 +317,11 @@ func (m *storageEngineTaskManager) resolveRootID(parentID string) (string, error
 	return "", fmt.Errorf("parent task ID is required")
 }
 
+// refreshGraphLocked brings the graph up to date with the tracker. Backends
+// that list changed tasks are asked only for the changes since the last read,
+// with the whole tree read again every taskGraphFullRefreshInterval; other
+// backends are read in full. Either way the graph is updated in place unless
+// tasks were added, moved or linked differently.
 func (m *storageEngineTaskManager) refreshGraphLocked(ctx context.Context, rootID string) error {
 	if m.storage == nil {
 		return fmt.Errorf("storage backend is required")
@@ -146,14 +330,98 @@ func (m *storageEngineTaskManager) refreshGraphLocked(ctx context.Context, rootI
 		return fmt.Errorf("task engine is required")
 	}
 
+	reader, ok := m.storage.(contracts.TaskChangeReader)
+	if ok && m.changeCursor != "" && m.graph != nil && m.graph.RootID == rootID && m.now().Sub(m.fullRefreshAt) < taskGraphFullRefreshInterval {
+		changes, err := reader.TaskChangesSince(ctx, rootID, m.changeCursor)
+		if err != nil {
+			return err
+		}
+		if changes == nil || len(changes.Tasks) == 0 {
+			if changes != nil && changes.Cursor != "" {
+				m.changeCursor = changes.Cursor
+			}
+			return nil
+		}
+		// A change list the graph cannot take, such as a task whose new
+		// parent is outside the known tree, falls back to a full read.
+		if err := m.applyTreeLocked(mergeTaskChanges(m.tree, changes)); err == nil {
+			m.changeCursor = changes.Cursor
+			return nil
+		}
+	}
+	return m.fullRefreshLocked(ctx, rootID)
+}
+
+func (m *storageEngineTaskManager) fullRefreshLocked(ctx context.Context, rootID string) error {
+	// The cursor is taken before the read so changes made during it are
+	// listed again rather than missed.
+	cursor := ""
+	if reader, ok := m.storage.(contracts.TaskChangeReader); ok {
+		changes, err := reader.TaskChangesSince(ctx, rootID, "")
+		if err != nil {
+			return err
+		}
+		if changes != nil {
+			cursor = changes.Cursor
+		}
+	}
 	tree, err := m.storage.GetTaskTree(ctx, rootID)
 	if err != nil {
 		return err
 	}
+	if err := m.applyTreeLocked(tree); err != nil {
+		return err
+	}
+	m.changeCursor = cursor
+	m.fullRefreshAt = m.now()
+	return nil
+}
+
+func (m *storageEngineTaskManager) applyTreeLocked(tree *contracts.TaskTree) error {
+	if updater, ok := m.engine.(taskGraphUpdater); ok && m.graph != nil {
+		updated, err := updater.UpdateGraph(m.graph, tree)
+		if err != nil {
+			return err
+		}
+		if updated {
+			m.tree = tree
+			return nil
+		}
+	}
 	graph, err := m.engine.BuildGraph(tree)
 	if err != nil {
 		return err
 	}
 	m.graph = graph
+	m.tree = tree
 	return nil
 }
+
+// mergeTaskChanges returns tree with the changed tasks and their relations
+// replacing the ones it had.
+func mergeTaskChanges(tree *contracts.TaskTree, changes *contracts.TaskChanges) *contracts.TaskTree {
+	merged := *tree
+	merged.Tasks = make(map[string]contracts.Task, len(tree.Tasks)+len(changes.Tasks))
+	for id, task := range tree.Tasks {
+		merged.Tasks[id] = task
+	}
+	for id, task := range changes.Tasks {
+		merged.Tasks[id] = task
+		if id == merged.Root.ID {
+			merged.Root = task
+		}
+	}
+	merged.Relations = make([]contracts.TaskRelation, 0, len(tree.Relations)+len(changes.Relations))
+	for _, relation := range tree.Relations {
+		ownerID := relation.FromID
+		if relation.Type == contracts.RelationParent {
+			ownerID = relation.ToID
+		}
+		if _, changed := changes.Tasks[ownerID]; changed {
+			continue
+		}
+		merged.Relations = append(merged.Relations, relation)
+	}
+	merged.Relations = append(merged.Relations, changes.Relations...)
+	return &merged
+}
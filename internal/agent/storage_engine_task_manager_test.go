@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	enginepkg "github.com/egv/yolo-runner/v2/internal/engine"
+)
+
+func TestStorageEngineTaskManagerUpdatesGraphInPlaceWhenShapeIsUnchanged(t *testing.T) {
+	storage := newSpyStorageBackend([]contracts.Task{
+		{ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+		{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, ParentID: "root"},
+		{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen, ParentID: "root"},
+	}, nil)
+	engine := &countingTaskEngine{TaskEngine: enginepkg.NewTaskEngine()}
+	manager := newStorageEngineTaskManager(storage, engine, "root")
+
+	assertNextTaskIDs(t, manager, "t-1", "t-2")
+	storage.putTask(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusInProgress, ParentID: "root"})
+	assertNextTaskIDs(t, manager, "t-2")
+	if engine.builds != 1 {
+		t.Fatalf("expected a status change to update the graph in place, got %d builds", engine.builds)
+	}
+
+	storage.putTask(contracts.Task{ID: "t-3", Title: "Task 3", Status: contracts.TaskStatusOpen, ParentID: "root"})
+	assertNextTaskIDs(t, manager, "t-2", "t-3")
+	if engine.builds != 2 {
+		t.Fatalf("expected a new task to rebuild the graph, got %d builds", engine.builds)
+	}
+}
+
+func TestStorageEngineTaskManagerRefreshesFromChangeLists(t *testing.T) {
+	storage := newChangeListingStorageBackend([]contracts.Task{
+		{ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+		{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, ParentID: "root"},
+	})
+	engine := &countingTaskEngine{TaskEngine: enginepkg.NewTaskEngine()}
+	manager := newStorageEngineTaskManager(storage, engine, "root")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	manager.now = func() time.Time { return now }
+
+	assertNextTaskIDs(t, manager, "t-1")
+	assertNextTaskIDs(t, manager, "t-1")
+	storage.change(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusClosed, ParentID: "root"})
+	storage.change(contracts.Task{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen, ParentID: "root"})
+	assertNextTaskIDs(t, manager, "t-2")
+	if storage.getTaskTreeCalls != 1 {
+		t.Fatalf("expected one full read, got %d", storage.getTaskTreeCalls)
+	}
+
+	now = now.Add(taskGraphFullRefreshInterval)
+	assertNextTaskIDs(t, manager, "t-2")
+	if storage.getTaskTreeCalls != 2 {
+		t.Fatalf("expected a full read after the refresh interval, got %d", storage.getTaskTreeCalls)
+	}
+}
+
+func TestStorageEngineTaskManagerConfirmsCompletionWithFullRead(t *testing.T) {
+	storage := newChangeListingStorageBackend([]contracts.Task{
+		{ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+		{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, ParentID: "root"},
+	})
+	manager := newStorageEngineTaskManager(storage, enginepkg.NewTaskEngine(), "root")
+
+	assertNextTaskIDs(t, manager, "t-1")
+	storage.change(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusClosed, ParentID: "root"})
+	// Added without a change entry, as with a task the change list missed.
+	storage.putTask(contracts.Task{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen, ParentID: "root"})
+
+	complete, err := manager.IsComplete(context.Background())
+	if err != nil {
+		t.Fatalf("IsComplete failed: %v", err)
+	}
+	if complete {
+		t.Fatalf("expected the full read to find the open task")
+	}
+	if storage.getTaskTreeCalls != 2 {
+		t.Fatalf("expected completion to be confirmed with a full read, got %d reads", storage.getTaskTreeCalls)
+	}
+}
+
+func assertNextTaskIDs(t *testing.T, manager *storageEngineTaskManager, expected ...string) {
+	t.Helper()
+	next, err := manager.NextTasks(context.Background(), "root")
+	if err != nil {
+		t.Fatalf("NextTasks failed: %v", err)
+	}
+	ids := make([]string, 0, len(next))
+	for _, task := range next {
+		ids = append(ids, task.ID)
+	}
+	if len(ids) != len(expected) {
+		t.Fatalf("expected next tasks %v, got %v", expected, ids)
+	}
+	for i := range ids {
+		if ids[i] != expected[i] {
+			t.Fatalf("expected next tasks %v, got %v", expected, ids)
+		}
+	}
+}
+
+type countingTaskEngine struct {
+	*enginepkg.TaskEngine
+	builds int
+}
+
+func (e *countingTaskEngine) BuildGraph(tree *contracts.TaskTree) (*contracts.TaskGraph, error) {
+	e.builds++
+	return e.TaskEngine.BuildGraph(tree)
+}
+
+func (s *spyStorageBackend) putTask(task contracts.Task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[task.ID] = task
+}
+
+// changeListingStorageBackend lists the tasks changed through change since
+// the cursor it last returned.
+type changeListingStorageBackend struct {
+	*spyStorageBackend
+	cursor  int
+	changed map[int][]contracts.Task
+}
+
+func newChangeListingStorageBackend(tasks []contracts.Task) *changeListingStorageBackend {
+	return &changeListingStorageBackend{spyStorageBackend: newSpyStorageBackend(tasks, nil), changed: map[int][]contracts.Task{}}
+}
+
+func (s *changeListingStorageBackend) change(task contracts.Task) {
+	s.putTask(task)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.changed[s.cursor] = append(s.changed[s.cursor], task)
+}
+
+func (s *changeListingStorageBackend) TaskChangesSince(_ context.Context, _ string, cursor string) (*contracts.TaskChanges, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changes := &contracts.TaskChanges{Tasks: map[string]contracts.Task{}}
+	if cursor != "" {
+		since, err := strconv.Atoi(cursor)
+		if err != nil {
+			return nil, err
+		}
+		for at := since; at <= s.cursor; at++ {
+			for _, task := range s.changed[at] {
+				changes.Tasks[task.ID] = task
+				changes.Relations = append(changes.Relations, contracts.TaskRelation{FromID: task.ParentID, ToID: task.ID, Type: contracts.RelationParent})
+			}
+		}
+	}
+	s.cursor++
+	changes.Cursor = strconv.Itoa(s.cursor)
+	return changes, nil
+}
@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// taskArtifactsDir is where collected artifacts are kept, under the run's
+// RepoRoot, so they outlive per-task clones.
+var taskArtifactsDir = filepath.Join("runner-logs", "artifacts")
+
+// taskArtifacts tracks the artifacts collected for each task until its
+// task_finished event reports them.
+type taskArtifacts struct {
+	mu     sync.Mutex
+	byTask map[string][]string
+}
+
+func (a *taskArtifacts) add(taskID string, paths ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.byTask == nil {
+		a.byTask = map[string][]string{}
+	}
+	for _, path := range paths {
+		known := false
+		for _, existing := range a.byTask[taskID] {
+			if existing == path {
+				known = true
+				break
+			}
+		}
+		if !known {
+			a.byTask[taskID] = append(a.byTask[taskID], path)
+		}
+	}
+}
+
+func (a *taskArtifacts) take(taskID string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	paths := a.byTask[taskID]
+	delete(a.byTask, taskID)
+	return paths
+}
+
+// collectRunnerArtifacts collects the files a runner registered through
+// contracts.TaskArtifactsArtifactKey.
+func (l *Loop) collectRunnerArtifacts(taskID string, cloneRoot string, result contracts.RunnerResult) {
+	l.collectTaskArtifacts(taskID, cloneRoot, contracts.DecodeTaskArtifacts(result.Artifacts[contracts.TaskArtifactsArtifactKey]))
+}
+
+// collectTaskArtifacts copies registered files or directories from the
+// clone into runner-logs/artifacts/<task>/ under RepoRoot, keeping their
+// clone-relative paths. Paths outside the clone and missing files are
+// skipped; collection never fails the task.
+func (l *Loop) collectTaskArtifacts(taskID string, cloneRoot string, paths []string) {
+	repoRoot := strings.TrimSpace(l.options.RepoRoot)
+	cloneRoot = strings.TrimSpace(cloneRoot)
+	if cloneRoot == "" {
+		cloneRoot = repoRoot
+	}
+	if repoRoot == "" || cloneRoot == "" || strings.TrimSpace(taskID) == "" || len(paths) == 0 {
+		return
+	}
+	destRoot := filepath.Join(repoRoot, taskArtifactsDir, taskID)
+	for _, path := range paths {
+		source := filepath.FromSlash(strings.TrimSpace(path))
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(cloneRoot, source)
+		}
+		rel, err := filepath.Rel(filepath.Clean(cloneRoot), filepath.Clean(source))
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if strings.HasPrefix(filepath.ToSlash(rel)+"/", filepath.ToSlash(taskArtifactsDir)+"/") {
+			continue
+		}
+		copied, err := copyTaskArtifact(source, filepath.Join(destRoot, rel))
+		if err != nil || !copied {
+			continue
+		}
+		l.artifacts.add(taskID, filepath.ToSlash(filepath.Join(taskArtifactsDir, taskID, rel)))
+	}
+}
+
+// copyTaskArtifact copies a regular file, or a directory tree without its
+// symlinks, and reports whether there was anything to copy.
+func copyTaskArtifact(source string, dest string) (bool, error) {
+	info, err := os.Lstat(source)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	switch {
+	case info.Mode().IsRegular():
+		return true, copyTaskArtifactFile(source, dest, info.Mode().Perm())
+	case info.IsDir():
+		err := filepath.WalkDir(source, func(path string, entry fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(source, path)
+			if err != nil {
+				return err
+			}
+			entryInfo, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			return copyTaskArtifactFile(path, filepath.Join(dest, rel), entryInfo.Mode().Perm())
+		})
+		return err == nil, err
+	default:
+		return false, nil
+	}
+}
+
+func copyTaskArtifactFile(source string, dest string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// withTaskArtifacts adds the task's collected artifacts to task_finished
+// metadata: "artifacts" lists them comma-separated, relative to RepoRoot.
+func (l *Loop) withTaskArtifacts(event contracts.Event) contracts.Event {
+	if event.Type != contracts.EventTypeTaskFinished {
+		return event
+	}
+	paths := l.artifacts.take(event.TaskID)
+	if len(paths) == 0 {
+		return event
+	}
+	sort.Strings(paths)
+	metadata := make(map[string]string, len(event.Metadata)+2)
+	for key, value := range event.Metadata {
+		metadata[key] = value
+	}
+	metadata["artifacts"] = strings.Join(paths, ",")
+	metadata["artifacts_dir"] = filepath.ToSlash(filepath.Join(taskArtifactsDir, event.TaskID))
+	event.Metadata = metadata
+	return event
+}
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestLoopCollectsRunnerArtifactsIntoRunnerLogs(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeRepoFile(t, repoRoot, "reports/junit.xml", "<testsuites/>")
+	writeRepoFile(t, repoRoot, "build/app", "binary")
+	writeRepoFile(t, repoRoot, "build/lib/util.a", "archive")
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{{
+		Status:    contracts.RunnerResultCompleted,
+		Artifacts: map[string]string{contracts.TaskArtifactsArtifactKey: contracts.EncodeTaskArtifacts([]string{"reports/junit.xml", "build", "missing.out", "../outside.txt", "/etc/hosts"})},
+	}}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", RepoRoot: repoRoot})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	finished := eventsByType(sink.events, contracts.EventTypeTaskFinished)
+	if len(finished) != 1 {
+		t.Fatalf("expected one task_finished event, got %d", len(finished))
+	}
+	if got := finished[0].Metadata["artifacts"]; got != "runner-logs/artifacts/t-1/build,runner-logs/artifacts/t-1/reports/junit.xml" {
+		t.Fatalf("unexpected artifacts metadata %q", got)
+	}
+	if got := finished[0].Metadata["artifacts_dir"]; got != "runner-logs/artifacts/t-1" {
+		t.Fatalf("unexpected artifacts dir %q", got)
+	}
+	for name, want := range map[string]string{
+		"reports/junit.xml": "<testsuites/>",
+		"build/app":         "binary",
+		"build/lib/util.a":  "archive",
+	} {
+		content, err := os.ReadFile(filepath.Join(repoRoot, "runner-logs", "artifacts", "t-1", name))
+		if err != nil || string(content) != want {
+			t.Fatalf("expected %s to be collected, got %q err=%v", name, content, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(repoRoot, "runner-logs", "artifacts", "t-1", "outside.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected paths outside the clone to be skipped, got %v", err)
+	}
+}
+
+func TestLoopCollectsArtifactsNamedByPreLandingHooks(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeRepoFile(t, repoRoot, "coverage.out", "mode: set\n")
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{
+		ParentID:        "root",
+		RepoRoot:        repoRoot,
+		VCS:             &fakeVCS{},
+		MergeOnSuccess:  true,
+		PreLandingHooks: []string{"echo 'ARTIFACT: coverage.out'; exit 1"},
+	})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 {
+		t.Fatalf("expected failing hook to block the task, got %#v", summary)
+	}
+	finished := eventsByType(sink.events, contracts.EventTypeTaskFinished)
+	if len(finished) != 1 || finished[0].Metadata["artifacts"] != "runner-logs/artifacts/t-1/coverage.out" {
+		t.Fatalf("expected hook artifact on task_finished, got %#v", finished)
+	}
+	if content, err := os.ReadFile(filepath.Join(repoRoot, "runner-logs", "artifacts", "t-1", "coverage.out")); err != nil || string(content) != "mode: set\n" {
+		t.Fatalf("expected coverage.out to be collected, got %q err=%v", content, err)
+	}
+}
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// taskLister is implemented by task managers that can list every task under
+// the root, not only the runnable ones, without exposing the task graph to
+// other goroutines.
+type taskLister interface {
+	ListTasks(ctx context.Context) ([]contracts.Task, error)
+}
+
+func (l *Loop) taskDiscoveryEnabled() bool {
+	if l.options.TaskDiscoveryInterval <= 0 {
+		return false
+	}
+	_, ok := l.tasks.(taskLister)
+	return ok
+}
+
+// discoverTasks lists the tasks under the root and emits task_discovered for
+// the ones not seen earlier in the run, reporting how many there were. The
+// first call only records the tasks the run started with. Discovery is best
+// effort and never fails the run.
+func (l *Loop) discoverTasks(ctx context.Context) int {
+	if !l.taskDiscoveryEnabled() || ctx.Err() != nil {
+		return 0
+	}
+	tasks, err := l.tasks.(taskLister).ListTasks(ctx)
+	if err != nil {
+		return 0
+	}
+	seeding := l.knownTasks == nil
+	if seeding {
+		l.knownTasks = make(map[string]struct{}, len(tasks))
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	discovered := 0
+	for _, task := range tasks {
+		if _, known := l.knownTasks[task.ID]; known {
+			continue
+		}
+		l.knownTasks[task.ID] = struct{}{}
+		if seeding {
+			continue
+		}
+		discovered++
+		metadata := map[string]string{"status": string(task.Status)}
+		if parentID := strings.TrimSpace(task.ParentID); parentID != "" {
+			metadata["parent_id"] = parentID
+		}
+		_ = l.emit(ctx, contracts.Event{
+			Type:      contracts.EventTypeTaskDiscovered,
+			TaskID:    task.ID,
+			TaskTitle: task.Title,
+			Message:   task.Title,
+			Metadata:  metadata,
+			Timestamp: time.Now().UTC(),
+		})
+	}
+	return discovered
+}
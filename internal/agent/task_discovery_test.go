@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	enginepkg "github.com/egv/yolo-runner/v2/internal/engine"
+)
+
+func TestLoopDiscoversTasksAddedDuringRunAndDispatchesThem(t *testing.T) {
+	storage := newSpyStorageBackend([]contracts.Task{
+		{ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+		{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, ParentID: "root"},
+	}, nil)
+	run := &appendingRunner{storage: storage, added: contracts.Task{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen, ParentID: "root"}, started: make(chan struct{})}
+	sink := &lockedRecordingSink{}
+	loop := NewLoopWithTaskEngine(storage, enginepkg.NewTaskEngine(), run, sink, LoopOptions{ParentID: "root", Concurrency: 2, TaskDiscoveryInterval: 5 * time.Millisecond})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 2 {
+		t.Fatalf("expected both tasks completed, got %#v", summary)
+	}
+	if run.waited {
+		t.Fatalf("expected the added task to start while the first one was running")
+	}
+	discovered := []contracts.Event{}
+	for _, event := range sink.events {
+		if event.Type == contracts.EventTypeTaskDiscovered {
+			discovered = append(discovered, event)
+		}
+	}
+	if len(discovered) != 1 || discovered[0].TaskID != "t-2" || discovered[0].TaskTitle != "Task 2" || discovered[0].Metadata["parent_id"] != "root" || discovered[0].Metadata["status"] != "open" {
+		t.Fatalf("expected one task_discovered event for t-2, got %#v", discovered)
+	}
+}
+
+func TestLoopSkipsTaskDiscoveryWhenIntervalIsZero(t *testing.T) {
+	storage := newSpyStorageBackend([]contracts.Task{
+		{ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+		{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, ParentID: "root"},
+	}, nil)
+	run := &appendingRunner{storage: storage, added: contracts.Task{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen, ParentID: "root"}, started: make(chan struct{}), wait: time.Millisecond}
+	sink := &lockedRecordingSink{}
+	loop := NewLoopWithTaskEngine(storage, enginepkg.NewTaskEngine(), run, sink, LoopOptions{ParentID: "root", Concurrency: 2})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	for _, event := range sink.events {
+		if event.Type == contracts.EventTypeTaskDiscovered {
+			t.Fatalf("expected no task_discovered events, got %#v", event)
+		}
+	}
+}
+
+// appendingRunner adds a task under the root while running t-1 and holds t-1
+// until the added task starts, or until wait passes.
+type appendingRunner struct {
+	storage *spyStorageBackend
+	added   contracts.Task
+	started chan struct{}
+	wait    time.Duration
+	waited  bool
+}
+
+func (r *appendingRunner) Run(_ context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	if request.TaskID != "t-1" {
+		close(r.started)
+		return contracts.RunnerResult{Status: contracts.RunnerResultCompleted}, nil
+	}
+	r.storage.putTask(r.added)
+	wait := r.wait
+	if wait <= 0 {
+		wait = 5 * time.Second
+	}
+	select {
+	case <-r.started:
+	case <-time.After(wait):
+		r.waited = true
+	}
+	return contracts.RunnerResult{Status: contracts.RunnerResultCompleted}, nil
+}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// TaskEnvVar is an environment variable injected into the runner and
+// pre-landing hook processes of a task, e.g. the test database credentials
+// of a profile.
+type TaskEnvVar struct {
+	Name  string
+	Value string
+	// Secret masks Value in events, runner logs, hook output and the task
+	// data the loop writes back to the tracker. Comments are built from
+	// events and are masked with them. The tracker's own copy of the task
+	// title and description is left as the author wrote it.
+	Secret bool
+	// Labels limits the variable to tasks with one of these labels. Empty
+	// injects it into every task.
+	Labels []string
+}
+
+// taskEnv returns the variables of LoopOptions.TaskEnv that apply to task.
+// A later variable overrides an earlier one with the same name.
+func (l *Loop) taskEnv(task contracts.Task) map[string]string {
+	if len(l.options.TaskEnv) == 0 {
+		return nil
+	}
+	labels := taskLabelSet(task)
+	env := map[string]string{}
+	for _, variable := range l.options.TaskEnv {
+		if taskEnvVarApplies(variable, labels) {
+			env[variable.Name] = variable.Value
+		}
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
+
+func taskEnvVarApplies(variable TaskEnvVar, labels map[string]struct{}) bool {
+	if len(variable.Labels) == 0 {
+		return true
+	}
+	for _, label := range variable.Labels {
+		if _, ok := labels[normalizeScopeLabel(label)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskEnvSecrets returns the values of the secret variables in env, whichever
+// tasks they apply to.
+func TaskEnvSecrets(env []TaskEnvVar) []string {
+	secrets := []string{}
+	for _, variable := range env {
+		if variable.Secret && variable.Value != "" {
+			secrets = append(secrets, variable.Value)
+		}
+	}
+	return secrets
+}
+
+func (l *Loop) secretValues() []string {
+	return TaskEnvSecrets(l.options.TaskEnv)
+}
+
+func (l *Loop) maskSecrets(text string) string {
+	return contracts.MaskSecrets(text, l.secretValues())
+}
+
+func (l *Loop) maskEventSecrets(event contracts.Event) contracts.Event {
+	return contracts.MaskEventSecrets(event, l.secretValues())
+}
+
+// setTaskData writes data to the tracker with secrets masked in its values.
+func (l *Loop) setTaskData(ctx context.Context, taskID string, data map[string]string) error {
+	secrets := l.secretValues()
+	if len(secrets) == 0 {
+		return l.tasks.SetTaskData(ctx, taskID, data)
+	}
+	masked := make(map[string]string, len(data))
+	for key, value := range data {
+		masked[key] = contracts.MaskSecrets(value, secrets)
+	}
+	return l.tasks.SetTaskData(ctx, taskID, masked)
+}
+
+// maskRunnerResultSecrets masks secrets in the reason of a runner result and
+// rewrites its log and stderr log with the secrets masked. Built-in backends
+// mask their logs as they write them; this catches backends that do not.
+func (l *Loop) maskRunnerResultSecrets(result contracts.RunnerResult) contracts.RunnerResult {
+	secrets := l.secretValues()
+	if len(secrets) == 0 {
+		return result
+	}
+	result.Reason = contracts.MaskSecrets(result.Reason, secrets)
+	for _, path := range []string{result.LogPath, contracts.BackendLogSidecarPath(result.LogPath, contracts.BackendLogStderr)} {
+		maskFileSecrets(path, secrets)
+	}
+	return result
+}
+
+func maskFileSecrets(path string, secrets []string) {
+	if strings.TrimSpace(path) == "" {
+		return
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	masked := contracts.MaskSecrets(string(content), secrets)
+	if masked == string(content) {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(path, []byte(masked), info.Mode().Perm())
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestLoopInjectsTaskEnvIntoRunnerAndMasksSecrets(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "t-1.jsonl")
+	if err := os.WriteFile(logPath, []byte(`{"dsn":"postgres://app:hunter2@db"}`+"\n"), 0o644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Rotate hunter2", Status: contracts.TaskStatusOpen, Metadata: map[string]string{"labels": "DB"}})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultBlocked, Reason: "cannot connect with hunter2", LogPath: logPath}}}
+	sink := &recordingSink{}
+	loop := NewLoop(mgr, run, sink, LoopOptions{ParentID: "root", TaskEnv: []TaskEnvVar{
+		{Name: "APP_ENV", Value: "test"},
+		{Name: "DB_PASSWORD", Value: "hunter2", Secret: true, Labels: []string{"db"}},
+		{Name: "UI_TOKEN", Value: "ui-secret", Secret: true, Labels: []string{"ui"}},
+	}})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if len(run.requests) == 0 {
+		t.Fatalf("expected a runner request")
+	}
+	if want := map[string]string{"APP_ENV": "test", "DB_PASSWORD": "hunter2"}; !reflect.DeepEqual(run.requests[0].Env, want) {
+		t.Fatalf("expected env %v, got %v", want, run.requests[0].Env)
+	}
+	for _, event := range sink.events {
+		if strings.Contains(event.TaskTitle, "hunter2") {
+			t.Fatalf("expected secret masked in %s task title, got %q", event.Type, event.TaskTitle)
+		}
+		if strings.Contains(event.Message, "hunter2") {
+			t.Fatalf("expected secret masked in %s message, got %q", event.Type, event.Message)
+		}
+		for key, value := range event.Metadata {
+			if strings.Contains(value, "hunter2") {
+				t.Fatalf("expected secret masked in %s metadata %s, got %q", event.Type, key, value)
+			}
+		}
+	}
+	if reason := mgr.dataByID["t-1"]["triage_reason"]; reason != "cannot connect with ***" {
+		t.Fatalf("expected masked triage reason, got %q", reason)
+	}
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	if strings.Contains(string(content), "hunter2") || !strings.Contains(string(content), "postgres://app:***@db") {
+		t.Fatalf("expected secret masked in runner log, got %q", content)
+	}
+}
+
+func TestLoopMasksSecretsInTaskDataWrittenToTracker(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	loop := NewLoop(mgr, &fakeRunner{}, nil, LoopOptions{TaskEnv: []TaskEnvVar{{Name: "DB_PASSWORD", Value: "hunter2", Secret: true}}})
+	data := map[string]string{"review_feedback": "login with hunter2 failed"}
+
+	if err := loop.setTaskData(context.Background(), "t-1", data); err != nil {
+		t.Fatalf("set task data: %v", err)
+	}
+	if got := mgr.dataByID["t-1"]["review_feedback"]; got != "login with *** failed" {
+		t.Fatalf("expected masked task data, got %q", got)
+	}
+	if data["review_feedback"] != "login with hunter2 failed" {
+		t.Fatalf("expected caller's data untouched, got %v", data)
+	}
+}
+
+func TestRunPreLandingHooksGetTaskEnvAndMaskSecrets(t *testing.T) {
+	loop := NewLoop(newFakeTaskManager(), &fakeRunner{}, nil, LoopOptions{
+		PreLandingHooks: []string{`echo "db=$DB_PASSWORD"; exit 1`},
+		TaskEnv:         []TaskEnvVar{{Name: "DB_PASSWORD", Value: "hunter2", Secret: true}},
+	})
+
+	err := loop.runPreLandingHooks(context.Background(), contracts.Task{ID: "t-1"}, t.TempDir())
+	var hookErr *LandingHookError
+	if !errors.As(err, &hookErr) {
+		t.Fatalf("expected LandingHookError, got %v", err)
+	}
+	if strings.TrimSpace(hookErr.Output) != "db=***" {
+		t.Fatalf("expected the hook to get the secret and its output masked, got %q", hookErr.Output)
+	}
+}
@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// prepareToolSandbox writes PATH shims for the commands named by
+// LoopOptions.ToolPolicy, so runners of every backend go through the policy
+// when they call those commands by name. It returns a cleanup function that
+// removes the shims. Windows runners are left to the ACP permission checks.
+func (l *Loop) prepareToolSandbox() (func(), error) {
+	if l.options.ToolPolicy.IsZero() || runtime.GOOS == "windows" {
+		return func() {}, nil
+	}
+	dir, err := os.MkdirTemp("", "yolo-tool-shims-")
+	if err != nil {
+		return nil, fmt.Errorf("create tool policy shims: %w", err)
+	}
+	if err := writeToolShims(dir, l.options.ToolPolicy, exec.LookPath); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	l.toolShimDir = dir
+	return func() {
+		l.toolShimDir = ""
+		_ = os.RemoveAll(dir)
+	}, nil
+}
+
+// runnerEnv is the task environment of a runner: taskEnv with the tool
+// policy shims first on PATH.
+func (l *Loop) runnerEnv(task contracts.Task) map[string]string {
+	env := l.taskEnv(task)
+	if l.toolShimDir == "" {
+		return env
+	}
+	if env == nil {
+		env = map[string]string{}
+	}
+	path := l.toolShimDir
+	if current := os.Getenv("PATH"); current != "" {
+		path += string(os.PathListSeparator) + current
+	}
+	env["PATH"] = path
+	return env
+}
+
+// toolShimNamePattern matches the command names a shim can be written for.
+var toolShimNamePattern = regexp.MustCompile(`^[A-Za-z0-9._+-]+$`)
+
+// writeToolShims writes one sh script per command name in the policy. A
+// shim refuses denied calls with exit status 126 and, when the allowlist
+// names the command, every call the allowlist does not match. Other calls
+// run the real command found by lookPath.
+func writeToolShims(dir string, policy contracts.ToolPolicy, lookPath func(string) (string, error)) error {
+	deny := toolPatternsByName(policy.Deny)
+	allow := toolPatternsByName(policy.Allow)
+	names := map[string]struct{}{}
+	for name := range deny {
+		names[name] = struct{}{}
+	}
+	for name := range allow {
+		names[name] = struct{}{}
+	}
+	for name := range names {
+		if !toolShimNamePattern.MatchString(name) {
+			continue
+		}
+		var script strings.Builder
+		script.WriteString("#!/bin/sh\n# yolo-agent tool policy shim for " + name + "\n")
+		script.WriteString("refuse() { echo \"yolo-agent: $1\" >&2; exit 126; }\n")
+		for _, args := range deny[name] {
+			pattern := strings.Join(append([]string{name}, args...), " ")
+			fmt.Fprintf(&script, "if %s; then refuse %s; fi\n", shimArgsCondition(args), shellQuote(pattern+" is denied by the tool policy"))
+		}
+		target := "echo " + shellQuote("yolo-agent: "+name+": command not found") + " >&2; exit 127"
+		if path, err := lookPath(name); err == nil {
+			target = "exec " + shellQuote(path) + ` "$@"`
+		}
+		if len(allow[name]) == 0 {
+			script.WriteString(target + "\n")
+		} else {
+			for _, args := range allow[name] {
+				fmt.Fprintf(&script, "if %s; then %s; fi\n", shimArgsCondition(args), target)
+			}
+			fmt.Fprintf(&script, "refuse \"%s $* is not in the tool allowlist\"\n", name)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script.String()), 0o755); err != nil {
+			return fmt.Errorf("write tool policy shim for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// toolPatternsByName groups the leading arguments of patterns by command
+// name.
+func toolPatternsByName(patterns []string) map[string][][]string {
+	byName := map[string][][]string{}
+	for _, pattern := range patterns {
+		words := contracts.ToolPatternWords(pattern)
+		if len(words) == 0 {
+			continue
+		}
+		byName[words[0]] = append(byName[words[0]], words[1:])
+	}
+	return byName
+}
+
+// shimArgsCondition is a sh condition that holds when the shim's arguments
+// start with args.
+func shimArgsCondition(args []string) string {
+	if len(args) == 0 {
+		return "true"
+	}
+	conditions := []string{`[ "$#" -ge ` + strconv.Itoa(len(args)) + " ]"}
+	for i, arg := range args {
+		conditions = append(conditions, `[ "${`+strconv.Itoa(i+1)+`}" = `+shellQuote(arg)+" ]")
+	}
+	return strings.Join(conditions, " && ")
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestWriteToolShimsEnforcesToolPolicy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("tool policy shims are sh scripts")
+	}
+	echo, err := exec.LookPath("echo")
+	if err != nil {
+		t.Skipf("echo not found: %v", err)
+	}
+	dir := t.TempDir()
+	policy := contracts.ToolPolicy{Allow: []string{"go test"}, Deny: []string{"git push", "docker"}}
+	lookPath := func(name string) (string, error) {
+		if name == "docker" {
+			return "", exec.ErrNotFound
+		}
+		return echo, nil
+	}
+	if err := writeToolShims(dir, policy, lookPath); err != nil {
+		t.Fatalf("write shims: %v", err)
+	}
+
+	cases := []struct {
+		command []string
+		status  int
+		output  string
+	}{
+		{[]string{"git", "status"}, 0, "status"},
+		{[]string{"git", "push", "origin"}, 126, "yolo-agent: git push is denied by the tool policy"},
+		{[]string{"go", "test", "./..."}, 0, "test ./..."},
+		{[]string{"go", "build", "./..."}, 126, "yolo-agent: go build ./... is not in the tool allowlist"},
+		{[]string{"docker", "ps"}, 126, "yolo-agent: docker is denied by the tool policy"},
+	}
+	for _, tc := range cases {
+		cmd := exec.Command(filepath.Join(dir, tc.command[0]), tc.command[1:]...)
+		output, _ := cmd.CombinedOutput()
+		if status := cmd.ProcessState.ExitCode(); status != tc.status || strings.TrimSpace(string(output)) != tc.output {
+			t.Fatalf("%v: expected status %d and %q, got %d and %q", tc.command, tc.status, tc.output, status, output)
+		}
+	}
+}
+
+func TestLoopPassesToolPolicyAndShimPathToRunner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("tool policy shims are sh scripts")
+	}
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen})
+	run := &fakeRunner{results: []contracts.RunnerResult{{Status: contracts.RunnerResultCompleted}}}
+	policy := contracts.ToolPolicy{Deny: []string{"curl"}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", ToolPolicy: policy})
+
+	if _, err := loop.Run(context.Background()); err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if len(run.requests) == 0 {
+		t.Fatalf("expected a runner request")
+	}
+	request := run.requests[0]
+	if !reflect.DeepEqual(request.ToolPolicy, policy) {
+		t.Fatalf("expected tool policy %v, got %v", policy, request.ToolPolicy)
+	}
+	shimDir, _, _ := strings.Cut(request.Env["PATH"], string(os.PathListSeparator))
+	if !strings.Contains(filepath.Base(shimDir), "yolo-tool-shims-") {
+		t.Fatalf("expected the shim dir first on PATH, got %q", request.Env["PATH"])
+	}
+	if _, err := os.Stat(shimDir); !os.IsNotExist(err) {
+		t.Fatalf("expected shims removed after the run, got %v", err)
+	}
+}
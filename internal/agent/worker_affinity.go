@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"context"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// ExecutorMetadataKey names, in runner request metadata, the executor a task
+// is pinned to. Requests without it run on the default executor.
+const ExecutorMetadataKey = "executor"
+
+// WorkerAffinityRule pins tasks with one of Labels to the executor named
+// Executor, e.g. GPU tasks to the GPU host.
+type WorkerAffinityRule struct {
+	Labels   []string
+	Executor string
+}
+
+// WorkerAffinityConfig routes tasks to named executors by label. The first
+// rule matching a task's labels wins; tasks no rule matches run on the
+// default executor.
+type WorkerAffinityConfig struct {
+	Rules []WorkerAffinityRule
+	// Slots caps how many tasks run on an executor at once. Tasks pinned
+	// to a full executor wait while other tasks are dispatched. Executors
+	// without an entry are only limited by the loop's concurrency.
+	Slots map[string]int
+}
+
+// executorFor returns the executor task is pinned to, or "" for the
+// default executor.
+func (c WorkerAffinityConfig) executorFor(task contracts.Task) string {
+	if len(c.Rules) == 0 {
+		return ""
+	}
+	labels := taskLabelSet(task)
+	for _, rule := range c.Rules {
+		for _, label := range rule.Labels {
+			if _, ok := labels[normalizeScopeLabel(label)]; ok {
+				return strings.TrimSpace(rule.Executor)
+			}
+		}
+	}
+	return ""
+}
+
+// hasFreeSlot reports whether one more task may start on executor while the
+// tasks in inFlight, keyed by task ID, run on theirs.
+func (c WorkerAffinityConfig) hasFreeSlot(executor string, inFlight map[string]string) bool {
+	limit, ok := c.Slots[executor]
+	if !ok || limit <= 0 {
+		return true
+	}
+	running := 0
+	for _, other := range inFlight {
+		if other == executor {
+			running++
+		}
+	}
+	return running < limit
+}
+
+// dispatchExecutor returns the executor a dispatch candidate is pinned to and
+// whether it has a free slot. Without affinity rules every task runs on the
+// default executor and the tracker is not read.
+func (l *Loop) dispatchExecutor(ctx context.Context, taskID string, inFlight map[string]string) (string, bool, error) {
+	if len(l.options.WorkerAffinity.Rules) == 0 {
+		return "", true, nil
+	}
+	task, err := l.tasks.GetTask(ctx, taskID)
+	if err != nil {
+		return "", false, err
+	}
+	executor := l.options.WorkerAffinity.executorFor(task)
+	return executor, l.options.WorkerAffinity.hasFreeSlot(executor, inFlight), nil
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+type executorRecordingRunner struct {
+	mu          sync.Mutex
+	executors   map[string]string
+	active      map[string]int
+	maxActive   map[string]int
+	totalActive int
+	maxTotal    int
+}
+
+func (r *executorRecordingRunner) Run(_ context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	executor := request.Metadata[ExecutorMetadataKey]
+	r.mu.Lock()
+	r.executors[request.TaskID] = executor
+	r.active[executor]++
+	r.totalActive++
+	if r.active[executor] > r.maxActive[executor] {
+		r.maxActive[executor] = r.active[executor]
+	}
+	if r.totalActive > r.maxTotal {
+		r.maxTotal = r.totalActive
+	}
+	r.mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	r.mu.Lock()
+	r.active[executor]--
+	r.totalActive--
+	r.mu.Unlock()
+	return contracts.RunnerResult{Status: contracts.RunnerResultCompleted}, nil
+}
+
+func TestLoopPinsLabeledTasksToExecutorsAndHonorsSlots(t *testing.T) {
+	mgr := newFakeTaskManager(
+		contracts.Task{ID: "g-1", Title: "Train", Status: contracts.TaskStatusOpen, Metadata: map[string]string{"labels": "GPU"}},
+		contracts.Task{ID: "g-2", Title: "Benchmark", Status: contracts.TaskStatusOpen, Metadata: map[string]string{"labels": "gpu,perf"}},
+		contracts.Task{ID: "i-1", Title: "Fix the iOS build", Status: contracts.TaskStatusOpen, Metadata: map[string]string{"labels": "ios"}},
+		contracts.Task{ID: "t-1", Title: "Docs", Status: contracts.TaskStatusOpen},
+	)
+	run := &executorRecordingRunner{executors: map[string]string{}, active: map[string]int{}, maxActive: map[string]int{}}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", Concurrency: 4, WorkerAffinity: WorkerAffinityConfig{
+		Rules: []WorkerAffinityRule{
+			{Labels: []string{"gpu"}, Executor: "gpu-host"},
+			{Labels: []string{"ios", "macos"}, Executor: "mac"},
+		},
+		Slots: map[string]int{"gpu-host": 1},
+	}})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 4 {
+		t.Fatalf("expected 4 completed tasks, got %#v", summary)
+	}
+	want := map[string]string{"g-1": "gpu-host", "g-2": "gpu-host", "i-1": "mac", "t-1": ""}
+	for taskID, executor := range want {
+		if got := run.executors[taskID]; got != executor {
+			t.Fatalf("expected %s on executor %q, got %q", taskID, executor, got)
+		}
+	}
+	if run.maxActive["gpu-host"] != 1 {
+		t.Fatalf("expected one task at a time on gpu-host, got %d", run.maxActive["gpu-host"])
+	}
+	if run.maxTotal < 3 {
+		t.Fatalf("expected other tasks to run while gpu tasks wait, got at most %d at once", run.maxTotal)
+	}
+}
+
+func TestWorkerAffinityFirstMatchingRuleWins(t *testing.T) {
+	config := WorkerAffinityConfig{Rules: []WorkerAffinityRule{
+		{Labels: []string{"gpu"}, Executor: "gpu-host"},
+		{Labels: []string{"perf"}, Executor: "bench"},
+	}}
+	task := contracts.Task{ID: "t-1", Metadata: map[string]string{"labels": "perf, GPU"}}
+	if got := config.executorFor(task); got != "gpu-host" {
+		t.Fatalf("expected gpu-host, got %q", got)
+	}
+	if got := config.executorFor(contracts.Task{ID: "t-2"}); got != "" {
+		t.Fatalf("expected the default executor for an unlabeled task, got %q", got)
+	}
+}
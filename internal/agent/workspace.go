@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"context"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// Task metadata keys that point a task at a repository other than the
+// run's own.
+const (
+	workspaceRepoMetadataKey = "workspace_repo"
+	workspaceRefMetadataKey  = "workspace_ref"
+)
+
+// Workspace is the repository a task runs in when its tracker metadata sets
+// workspace_repo. Ref is the branch the task starts from and lands on; empty
+// means the sync branch.
+type Workspace struct {
+	Repo string
+	Ref  string
+}
+
+// WorkspaceCloneManager is implemented by clone managers that can clone a
+// task's own workspace repository instead of the run's repository.
+type WorkspaceCloneManager interface {
+	CloneWorkspaceForTask(ctx context.Context, taskID string, workspace Workspace) (string, error)
+}
+
+// WorkspaceVCSFactory returns the VCS for a clone of workspace at repoRoot.
+type WorkspaceVCSFactory func(repoRoot string, workspace Workspace) contracts.VCS
+
+// WorkspaceFromTask reads the task's workspace spec. ok is false when the
+// task runs in the run's repository.
+func WorkspaceFromTask(task contracts.Task) (Workspace, bool) {
+	workspace := Workspace{
+		Repo: strings.TrimSpace(task.Metadata[workspaceRepoMetadataKey]),
+		Ref:  strings.TrimSpace(task.Metadata[workspaceRefMetadataKey]),
+	}
+	return workspace, workspace.Repo != ""
+}
+
+// cloneWorkspace clones the task's workspace. A missing capability or a
+// failed clone is reported as a reason to block the task rather than as a
+// run error, since it comes from the task's own metadata.
+func (l *Loop) cloneWorkspace(ctx context.Context, taskID string, workspace Workspace) (string, string) {
+	cloner, ok := l.cloneManager.(WorkspaceCloneManager)
+	if !ok {
+		return "", "task workspace " + workspace.Repo + " requires per-task clones"
+	}
+	clonePath, err := cloner.CloneWorkspaceForTask(ctx, taskID, workspace)
+	if err != nil {
+		return "", "cannot clone task workspace " + workspace.Repo + ": " + err.Error()
+	}
+	return clonePath, ""
+}
+
+// vcsForWorkspace scopes the VCS to a workspace clone, falling back to the
+// run's VCS factory when no workspace factory is configured.
+func (l *Loop) vcsForWorkspace(repoRoot string, workspace Workspace) contracts.VCS {
+	if l.options.WorkspaceVCSFactory != nil {
+		if scoped := l.options.WorkspaceVCSFactory(repoRoot, workspace); scoped != nil {
+			return scoped
+		}
+	}
+	return l.vcsForRepo(repoRoot)
+}
+
+func (l *Loop) blockTaskWorkspace(ctx context.Context, task contracts.Task, worker string, queuePos int, workspace Workspace, reason string) error {
+	blockedData := appendDecisionMetadata(map[string]string{
+		"triage_status":          "blocked",
+		"triage_reason":          reason,
+		workspaceRepoMetadataKey: workspace.Repo,
+	}, "blocked", reason)
+	blockedData = appendTriageCategory(blockedData, "")
+	return l.blockTask(ctx, task, worker, queuePos, "", blockedData)
+}
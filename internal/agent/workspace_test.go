@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// workspaceCloneManager also implements WorkspaceCloneManager.
+type workspaceCloneManager struct {
+	*fakeCloneManager
+	mu         sync.Mutex
+	workspaces map[string]Workspace
+}
+
+func (f *workspaceCloneManager) CloneWorkspaceForTask(_ context.Context, taskID string, workspace Workspace) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.workspaces[taskID] = workspace
+	return "/tmp/workspace/" + taskID, nil
+}
+
+func TestWorkspaceFromTask(t *testing.T) {
+	workspace, ok := WorkspaceFromTask(contracts.Task{Metadata: map[string]string{"workspace_repo": " git@example.com:acme/docs.git ", "workspace_ref": "release"}})
+	if !ok || workspace != (Workspace{Repo: "git@example.com:acme/docs.git", Ref: "release"}) {
+		t.Fatalf("unexpected workspace %#v ok=%v", workspace, ok)
+	}
+	if _, ok := WorkspaceFromTask(contracts.Task{Metadata: map[string]string{"workspace_ref": "release"}}); ok {
+		t.Fatalf("expected a ref without repo to keep the run's repository")
+	}
+}
+
+func TestLoopClonesTaskWorkspaceAndScopesVCS(t *testing.T) {
+	mgr := newFakeTaskManager(
+		contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen},
+		contracts.Task{ID: "t-2", Title: "Task 2", Status: contracts.TaskStatusOpen, Metadata: map[string]string{"workspace_repo": "git@example.com:acme/docs.git", "workspace_ref": "release"}},
+	)
+	run := &repoRecordingRunner{}
+	clones := &workspaceCloneManager{fakeCloneManager: newFakeCloneManager(), workspaces: map[string]Workspace{}}
+	runVCS := &fakeVCS{}
+	workspaceVCS := &fakeVCS{}
+	var scopedWorkspace Workspace
+	loop := NewLoop(mgr, run, nil, LoopOptions{
+		ParentID:       "root",
+		RepoRoot:       "/repo",
+		MergeOnSuccess: true,
+		CloneManager:   clones,
+		VCSFactory:     func(string) contracts.VCS { return runVCS },
+		WorkspaceVCSFactory: func(repoRoot string, workspace Workspace) contracts.VCS {
+			if repoRoot != "/tmp/workspace/t-2" {
+				t.Errorf("unexpected workspace clone path %q", repoRoot)
+			}
+			scopedWorkspace = workspace
+			return workspaceVCS
+		},
+	})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Completed != 2 {
+		t.Fatalf("expected both tasks to complete, got %#v", summary)
+	}
+	if run.byTaskID["t-1"] != "/tmp/clone/t-1" || run.byTaskID["t-2"] != "/tmp/workspace/t-2" {
+		t.Fatalf("expected each task in its own repository clone, got %#v", run.byTaskID)
+	}
+	if clones.workspaces["t-2"] != scopedWorkspace || scopedWorkspace.Ref != "release" {
+		t.Fatalf("expected workspace clone and VCS for t-2, got clones=%#v vcs=%#v", clones.workspaces, scopedWorkspace)
+	}
+	if !containsCall(workspaceVCS.calls, "merge_to_main:task/t-2") || containsCall(runVCS.calls, "merge_to_main:task/t-2") {
+		t.Fatalf("expected t-2 to land in its workspace, got workspace=%v run=%v", workspaceVCS.calls, runVCS.calls)
+	}
+	if clones.cleanupByID["t-2"] != 1 {
+		t.Fatalf("expected workspace clone cleanup, got %#v", clones.cleanupByID)
+	}
+}
+
+func TestLoopBlocksWorkspaceTaskWithoutWorkspaceClones(t *testing.T) {
+	mgr := newFakeTaskManager(contracts.Task{ID: "t-1", Title: "Task 1", Status: contracts.TaskStatusOpen, Metadata: map[string]string{"workspace_repo": "git@example.com:acme/docs.git"}})
+	run := &repoRecordingRunner{}
+	loop := NewLoop(mgr, run, nil, LoopOptions{ParentID: "root", CloneManager: newFakeCloneManager()})
+
+	summary, err := loop.Run(context.Background())
+	if err != nil {
+		t.Fatalf("loop failed: %v", err)
+	}
+	if summary.Blocked != 1 || mgr.statusByID["t-1"] != contracts.TaskStatusBlocked {
+		t.Fatalf("expected workspace task to block, got %#v", summary)
+	}
+	if got := mgr.dataByID["t-1"]["triage_reason"]; !strings.Contains(got, "requires per-task clones") {
+		t.Fatalf("unexpected triage reason %q", got)
+	}
+	if len(run.byTaskID) != 0 {
+		t.Fatalf("expected no runner call, got %#v", run.byTaskID)
+	}
+}
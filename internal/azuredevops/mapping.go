@@ -0,0 +1,211 @@
+package azuredevops
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	relationChild       = "System.LinkTypes.Hierarchy-Forward"
+	relationParent      = "System.LinkTypes.Hierarchy-Reverse"
+	relationPredecessor = "System.LinkTypes.Dependency-Reverse"
+
+	defaultPriority = 1
+
+	blockedTag = "yolo-blocked"
+	failedTag  = "yolo-failed"
+)
+
+// StateMapping names the work item state written for each task status. Empty
+// entries use the defaults of the Agile process; Blocked and Failed default to
+// the Open state plus a yolo-blocked or yolo-failed tag, because the built-in
+// processes have no blocked state.
+type StateMapping struct {
+	Open       string
+	InProgress string
+	Blocked    string
+	Failed     string
+	Closed     string
+}
+
+var (
+	closedStates     = []string{"closed", "done", "resolved", "removed", "completed"}
+	inProgressStates = []string{"active", "doing", "committed", "in progress"}
+)
+
+func (m StateMapping) withDefaults() StateMapping {
+	m.Open = strings.TrimSpace(m.Open)
+	m.InProgress = strings.TrimSpace(m.InProgress)
+	m.Blocked = strings.TrimSpace(m.Blocked)
+	m.Failed = strings.TrimSpace(m.Failed)
+	m.Closed = strings.TrimSpace(m.Closed)
+	if m.Open == "" {
+		m.Open = "New"
+	}
+	if m.InProgress == "" {
+		m.InProgress = "Active"
+	}
+	if m.Closed == "" {
+		m.Closed = "Closed"
+	}
+	return m
+}
+
+// stateForStatus returns the work item state for status and the yolo tag that
+// marks it, if any.
+func (m StateMapping) stateForStatus(status contracts.TaskStatus) (string, string, bool) {
+	switch status {
+	case contracts.TaskStatusOpen:
+		return m.Open, "", true
+	case contracts.TaskStatusInProgress:
+		return m.InProgress, "", true
+	case contracts.TaskStatusClosed:
+		return m.Closed, "", true
+	case contracts.TaskStatusBlocked:
+		if m.Blocked != "" {
+			return m.Blocked, "", true
+		}
+		return m.Open, blockedTag, true
+	case contracts.TaskStatusFailed:
+		if m.Failed != "" {
+			return m.Failed, "", true
+		}
+		return m.Open, failedTag, true
+	default:
+		return "", "", false
+	}
+}
+
+// taskStatus maps a work item state and its tags to a task status. Configured
+// states win over the built-in state names of the Agile, Scrum and Basic
+// processes; unknown states are treated as open.
+func (m StateMapping) taskStatus(state string, tags []string) contracts.TaskStatus {
+	state = strings.TrimSpace(state)
+	switch {
+	case m.Closed != "" && strings.EqualFold(state, m.Closed):
+		return contracts.TaskStatusClosed
+	case m.Blocked != "" && strings.EqualFold(state, m.Blocked):
+		return contracts.TaskStatusBlocked
+	case m.Failed != "" && strings.EqualFold(state, m.Failed):
+		return contracts.TaskStatusFailed
+	case m.InProgress != "" && strings.EqualFold(state, m.InProgress):
+		return contracts.TaskStatusInProgress
+	case containsFold(closedStates, state):
+		return contracts.TaskStatusClosed
+	case containsFold(inProgressStates, state):
+		return contracts.TaskStatusInProgress
+	case containsFold(tags, blockedTag):
+		return contracts.TaskStatusBlocked
+	case containsFold(tags, failedTag):
+		return contracts.TaskStatusFailed
+	default:
+		return contracts.TaskStatusOpen
+	}
+}
+
+// Scope limits the work items yolo-agent picks up below the root to an area
+// and iteration path. Child paths are in scope; empty paths match everything.
+type Scope struct {
+	AreaPath      string
+	IterationPath string
+}
+
+func (s Scope) contains(item workItemPayload) bool {
+	return pathWithin(item.Fields.AreaPath, s.AreaPath) && pathWithin(item.Fields.IterationPath, s.IterationPath)
+}
+
+func pathWithin(path string, scope string) bool {
+	scope = strings.Trim(strings.TrimSpace(scope), `\`)
+	if scope == "" {
+		return true
+	}
+	path = strings.Trim(strings.TrimSpace(path), `\`)
+	if strings.EqualFold(path, scope) {
+		return true
+	}
+	return len(path) > len(scope) && strings.EqualFold(path[:len(scope)+1], scope+`\`)
+}
+
+func (item workItemPayload) relatedIDs(rel string) []int {
+	ids := []int{}
+	for _, relation := range item.Relations {
+		if !strings.EqualFold(relation.Rel, rel) {
+			continue
+		}
+		if id := workItemIDFromURL(relation.URL); id > 0 && id != item.ID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func (item workItemPayload) parentID() int {
+	if ids := item.relatedIDs(relationParent); len(ids) > 0 {
+		return ids[0]
+	}
+	return 0
+}
+
+func (item workItemPayload) tags() []string {
+	tags := []string{}
+	for _, tag := range strings.Split(item.Fields.Tags, ";") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// priority converts Microsoft.VSTS.Common.Priority (1 is most urgent) to
+// scheduler ordering where lower is more urgent.
+func (item workItemPayload) priority() int {
+	if item.Fields.Priority == nil || *item.Fields.Priority < 1 {
+		return defaultPriority
+	}
+	return int(*item.Fields.Priority) - 1
+}
+
+// withTag returns tags without the yolo status tags, plus tag when set.
+func withTag(tags []string, tag string) string {
+	kept := make([]string, 0, len(tags)+1)
+	for _, existing := range tags {
+		if strings.EqualFold(existing, blockedTag) || strings.EqualFold(existing, failedTag) {
+			continue
+		}
+		kept = append(kept, existing)
+	}
+	if tag != "" {
+		kept = append(kept, tag)
+	}
+	return strings.Join(kept, "; ")
+}
+
+func workItemIDFromURL(rawURL string) int {
+	trimmed := strings.TrimSpace(rawURL)
+	if idx := strings.IndexAny(trimmed, "?#"); idx >= 0 {
+		trimmed = trimmed[:idx]
+	}
+	trimmed = strings.TrimRight(trimmed, "/")
+	lastSlash := strings.LastIndexByte(trimmed, '/')
+	if lastSlash < 0 {
+		return 0
+	}
+	id, err := strconv.Atoi(trimmed[lastSlash+1:])
+	if err != nil || id <= 0 {
+		return 0
+	}
+	return id
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(strings.TrimSpace(candidate), value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package azuredevops
+
+import (
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestStateMappingTaskStatusCoversBuiltInProcesses(t *testing.T) {
+	states := StateMapping{}.withDefaults()
+	for state, want := range map[string]contracts.TaskStatus{
+		"New":         contracts.TaskStatusOpen,
+		"To Do":       contracts.TaskStatusOpen,
+		"Approved":    contracts.TaskStatusOpen,
+		"Active":      contracts.TaskStatusInProgress,
+		"Doing":       contracts.TaskStatusInProgress,
+		"Committed":   contracts.TaskStatusInProgress,
+		"Resolved":    contracts.TaskStatusClosed,
+		"Done":        contracts.TaskStatusClosed,
+		"Closed":      contracts.TaskStatusClosed,
+		"Removed":     contracts.TaskStatusClosed,
+		"Custom Wait": contracts.TaskStatusOpen,
+	} {
+		if got := states.taskStatus(state, nil); got != want {
+			t.Fatalf("state %q: expected %q, got %q", state, want, got)
+		}
+	}
+}
+
+func TestStateMappingHonorsConfiguredStates(t *testing.T) {
+	states := StateMapping{Blocked: "On Hold", InProgress: "Doing"}.withDefaults()
+	if got := states.taskStatus("on hold", nil); got != contracts.TaskStatusBlocked {
+		t.Fatalf("expected configured blocked state, got %q", got)
+	}
+	state, tag, ok := states.stateForStatus(contracts.TaskStatusBlocked)
+	if !ok || state != "On Hold" || tag != "" {
+		t.Fatalf("expected configured blocked state without tag, got %q %q", state, tag)
+	}
+	state, tag, _ = states.stateForStatus(contracts.TaskStatusFailed)
+	if state != "New" || tag != failedTag {
+		t.Fatalf("expected failed to fall back to open state with tag, got %q %q", state, tag)
+	}
+	if got := states.taskStatus("Closed", []string{blockedTag}); got != contracts.TaskStatusClosed {
+		t.Fatalf("expected closed state to win over stale blocked tag, got %q", got)
+	}
+}
+
+func TestScopeMatchesChildPaths(t *testing.T) {
+	scope := Scope{AreaPath: `Contoso\Web`, IterationPath: `Contoso\Sprint 4`}
+	cases := []struct {
+		area, iteration string
+		want            bool
+	}{
+		{`Contoso\Web`, `Contoso\Sprint 4`, true},
+		{`contoso\web\frontend`, `Contoso\Sprint 4`, true},
+		{`Contoso\Website`, `Contoso\Sprint 4`, false},
+		{`Contoso\Web`, `Contoso\Sprint 5`, false},
+	}
+	for _, tc := range cases {
+		item := workItemPayload{Fields: workItemFieldsPayload{AreaPath: tc.area, IterationPath: tc.iteration}}
+		if got := scope.contains(item); got != tc.want {
+			t.Fatalf("scope.contains(%q, %q) = %v, want %v", tc.area, tc.iteration, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,673 @@
+package azuredevops
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	defaultAPIEndpoint   = "https://dev.azure.com"
+	defaultWorkItemType  = "Task"
+	apiVersion           = "7.1"
+	commentsAPIVersion   = "7.1-preview.4"
+	maxReadResponseSize  = 8 << 20
+	workItemsBatchSize   = 200
+	maxRateLimitBackoff  = 30 * time.Second
+	maxRateLimitAttempts = 3
+	taskDataCommentTag   = "<!-- yolo-runner-task-data -->"
+)
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Config selects an Azure DevOps project. Token is a personal access token
+// with Work Items read and write scope. APIEndpoint defaults to
+// https://dev.azure.com and can point at an Azure DevOps Server collection
+// URL without the organization segment.
+type Config struct {
+	Organization string
+	Project      string
+	Token        string
+	Scope        Scope
+	States       StateMapping
+	WorkItemType string
+	APIEndpoint  string
+	HTTPClient   HTTPClient
+}
+
+// TaskManager runs yolo-agent against Azure Boards work items. The root task
+// is a work item; its children are linked with parent/child links and
+// dependencies with predecessor/successor links.
+type TaskManager struct {
+	projectURL   string
+	token        string
+	scope        Scope
+	states       StateMapping
+	workItemType string
+	client       HTTPClient
+	sleep        func(time.Duration)
+}
+
+type workItemPayload struct {
+	ID        int                       `json:"id"`
+	Fields    workItemFieldsPayload     `json:"fields"`
+	Relations []workItemRelationPayload `json:"relations"`
+}
+
+type workItemFieldsPayload struct {
+	Title         string   `json:"System.Title"`
+	Description   string   `json:"System.Description"`
+	State         string   `json:"System.State"`
+	WorkItemType  string   `json:"System.WorkItemType"`
+	AreaPath      string   `json:"System.AreaPath"`
+	IterationPath string   `json:"System.IterationPath"`
+	Tags          string   `json:"System.Tags"`
+	Priority      *float64 `json:"Microsoft.VSTS.Common.Priority"`
+}
+
+type workItemRelationPayload struct {
+	Rel string `json:"rel"`
+	URL string `json:"url"`
+}
+
+type jsonPatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+func NewTaskManager(cfg Config) (*TaskManager, error) {
+	organization := strings.TrimSpace(cfg.Organization)
+	if organization == "" {
+		return nil, errors.New("azure devops organization is required")
+	}
+	project := strings.TrimSpace(cfg.Project)
+	if project == "" {
+		return nil, errors.New("azure devops project is required")
+	}
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		return nil, errors.New("azure devops personal access token is required")
+	}
+
+	endpoint := strings.TrimRight(strings.TrimSpace(cfg.APIEndpoint), "/")
+	if endpoint == "" {
+		endpoint = defaultAPIEndpoint
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	workItemType := strings.TrimSpace(cfg.WorkItemType)
+	if workItemType == "" {
+		workItemType = defaultWorkItemType
+	}
+
+	manager := &TaskManager{
+		projectURL:   endpoint + "/" + url.PathEscape(organization) + "/" + url.PathEscape(project),
+		token:        token,
+		scope:        cfg.Scope,
+		states:       cfg.States.withDefaults(),
+		workItemType: workItemType,
+		client:       client,
+		sleep:        time.Sleep,
+	}
+	probeURL := endpoint + "/" + url.PathEscape(organization) + "/_apis/projects/" + url.PathEscape(project) + "?api-version=" + apiVersion
+	if err := manager.probeProject(context.Background(), probeURL, project); err != nil {
+		return nil, fmt.Errorf("azure devops auth validation failed: %w", err)
+	}
+	return manager, nil
+}
+
+func (m *TaskManager) NextTasks(ctx context.Context, parentID string) ([]contracts.TaskSummary, error) {
+	rootID, err := parseWorkItemID(parentID, "parent task ID")
+	if err != nil {
+		return nil, err
+	}
+	root, err := m.fetchWorkItem(ctx, rootID)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, nil
+	}
+	children, err := m.fetchWorkItems(ctx, root.relatedIDs(relationChild))
+	if err != nil {
+		return nil, err
+	}
+	children = m.inScope(children)
+
+	statusByID := map[int]contracts.TaskStatus{root.ID: m.statusOf(*root)}
+	for _, child := range children {
+		statusByID[child.ID] = m.statusOf(child)
+	}
+	sort.Slice(children, func(i int, j int) bool {
+		if children[i].priority() != children[j].priority() {
+			return children[i].priority() < children[j].priority()
+		}
+		return children[i].ID < children[j].ID
+	})
+
+	tasks := make([]contracts.TaskSummary, 0, len(children))
+	for _, child := range children {
+		if statusByID[child.ID] != contracts.TaskStatusOpen {
+			continue
+		}
+		if !dependenciesClosed(child.relatedIDs(relationPredecessor), statusByID) {
+			continue
+		}
+		tasks = append(tasks, taskSummaryFromWorkItem(child))
+	}
+	if len(tasks) > 0 {
+		return tasks, nil
+	}
+	if len(root.relatedIDs(relationChild)) > 0 || statusByID[root.ID] != contracts.TaskStatusOpen {
+		return nil, nil
+	}
+	predecessors, err := m.fetchWorkItems(ctx, root.relatedIDs(relationPredecessor))
+	if err != nil {
+		return nil, err
+	}
+	for _, predecessor := range predecessors {
+		statusByID[predecessor.ID] = m.statusOf(predecessor)
+	}
+	if !dependenciesClosed(root.relatedIDs(relationPredecessor), statusByID) {
+		return nil, nil
+	}
+	return []contracts.TaskSummary{taskSummaryFromWorkItem(*root)}, nil
+}
+
+func (m *TaskManager) GetTask(ctx context.Context, taskID string) (contracts.Task, error) {
+	id, err := parseWorkItemID(taskID, "task ID")
+	if err != nil {
+		return contracts.Task{}, err
+	}
+	item, err := m.fetchWorkItem(ctx, id)
+	if err != nil {
+		return contracts.Task{}, err
+	}
+	if item == nil {
+		return contracts.Task{}, nil
+	}
+	return m.taskFromWorkItem(*item), nil
+}
+
+// GetTaskTree returns the root work item and every in-scope descendant
+// reachable through parent/child links.
+func (m *TaskManager) GetTaskTree(ctx context.Context, rootID string) (*contracts.TaskTree, error) {
+	id, err := parseWorkItemID(rootID, "root task ID")
+	if err != nil {
+		return nil, err
+	}
+	root, err := m.fetchWorkItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, fmt.Errorf("root task %q not found", rootID)
+	}
+
+	items := map[int]workItemPayload{root.ID: *root}
+	frontier := root.relatedIDs(relationChild)
+	for len(frontier) > 0 {
+		fetched, err := m.fetchWorkItems(ctx, frontier)
+		if err != nil {
+			return nil, err
+		}
+		frontier = nil
+		for _, item := range m.inScope(fetched) {
+			if _, seen := items[item.ID]; seen {
+				continue
+			}
+			items[item.ID] = item
+			frontier = append(frontier, item.relatedIDs(relationChild)...)
+		}
+	}
+
+	tasks := make(map[string]contracts.Task, len(items))
+	relations := []contracts.TaskRelation{}
+	for _, item := range items {
+		task := m.taskFromWorkItem(item)
+		if item.ID == root.ID {
+			task.ParentID = ""
+		} else if _, ok := items[item.parentID()]; ok {
+			relations = append(relations, contracts.TaskRelation{FromID: task.ParentID, ToID: task.ID, Type: contracts.RelationParent})
+		} else {
+			task.ParentID = ""
+		}
+		for _, depID := range item.relatedIDs(relationPredecessor) {
+			if _, ok := items[depID]; !ok {
+				continue
+			}
+			dep := strconv.Itoa(depID)
+			relations = append(relations,
+				contracts.TaskRelation{FromID: task.ID, ToID: dep, Type: contracts.RelationDependsOn},
+				contracts.TaskRelation{FromID: dep, ToID: task.ID, Type: contracts.RelationBlocks},
+			)
+		}
+		tasks[task.ID] = task
+	}
+	sort.Slice(relations, func(i int, j int) bool {
+		if relations[i].Type != relations[j].Type {
+			return relations[i].Type < relations[j].Type
+		}
+		if relations[i].FromID != relations[j].FromID {
+			return relations[i].FromID < relations[j].FromID
+		}
+		return relations[i].ToID < relations[j].ToID
+	})
+
+	return &contracts.TaskTree{
+		Root:      tasks[strconv.Itoa(root.ID)],
+		Tasks:     tasks,
+		Relations: relations,
+	}, nil
+}
+
+// SetTaskStatus moves the work item to the state mapped for status. Without a
+// dedicated blocked or failed state, the work item goes back to the open state
+// and gets a yolo-blocked or yolo-failed tag.
+func (m *TaskManager) SetTaskStatus(ctx context.Context, taskID string, status contracts.TaskStatus) error {
+	id, err := parseWorkItemID(taskID, "task ID")
+	if err != nil {
+		return err
+	}
+	state, tag, ok := m.states.stateForStatus(status)
+	if !ok {
+		return fmt.Errorf("unsupported task status %q", status)
+	}
+
+	item, err := m.fetchWorkItem(ctx, id)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return fmt.Errorf("update Azure DevOps work item %d status %q: work item not found", id, status)
+	}
+	operations := []jsonPatchOperation{{Op: "add", Path: "/fields/System.State", Value: state}}
+	tags := item.tags()
+	if updated := withTag(tags, tag); updated != strings.Join(tags, "; ") {
+		operations = append(operations, jsonPatchOperation{Op: "add", Path: "/fields/System.Tags", Value: updated})
+	}
+
+	statusCode, body, err := m.doJSON(ctx, http.MethodPatch, m.workItemURL(id), "application/json-patch+json", operations)
+	if err != nil {
+		return fmt.Errorf("update Azure DevOps work item %d status %q: %w", id, status, err)
+	}
+	if statusCode >= http.StatusBadRequest {
+		return fmt.Errorf("update Azure DevOps work item %d status %q: request failed with status %d: %s", id, status, statusCode, firstAPIError(body))
+	}
+	return nil
+}
+
+// SetTaskData records data as a work item comment.
+func (m *TaskManager) SetTaskData(ctx context.Context, taskID string, data map[string]string) error {
+	id, err := parseWorkItemID(taskID, "task ID")
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(data))
+	entries := map[string]string{}
+	for key, value := range data {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if _, ok := entries[key]; !ok {
+			keys = append(keys, key)
+		}
+		entries[key] = value
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, html.EscapeString(key+"="+entries[key]))
+	}
+	if err := m.postComment(ctx, id, taskDataCommentTag+strings.Join(lines, "<br>")); err != nil {
+		return fmt.Errorf("write Azure DevOps work item %d task data %q: %w", id, keys[0], err)
+	}
+	return nil
+}
+
+// AddTaskComment posts body as a work item comment.
+func (m *TaskManager) AddTaskComment(ctx context.Context, taskID string, body string) error {
+	id, err := parseWorkItemID(taskID, "task ID")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil
+	}
+	if err := m.postComment(ctx, id, commentHTML(body)); err != nil {
+		return fmt.Errorf("comment on Azure DevOps work item %d: %w", id, err)
+	}
+	return nil
+}
+
+// CreateTask creates a work item of the configured type in the configured
+// area and iteration, linked to its parent and to its predecessors.
+func (m *TaskManager) CreateTask(ctx context.Context, request contracts.TaskCreateRequest) (string, error) {
+	title := strings.TrimSpace(request.Title)
+	if title == "" {
+		return "", errors.New("task title is required")
+	}
+	operations := []jsonPatchOperation{{Op: "add", Path: "/fields/System.Title", Value: title}}
+	if description := strings.TrimSpace(request.Description); description != "" {
+		operations = append(operations, jsonPatchOperation{Op: "add", Path: "/fields/System.Description", Value: commentHTML(description)})
+	}
+	if area := strings.TrimSpace(m.scope.AreaPath); area != "" {
+		operations = append(operations, jsonPatchOperation{Op: "add", Path: "/fields/System.AreaPath", Value: area})
+	}
+	if iteration := strings.TrimSpace(m.scope.IterationPath); iteration != "" {
+		operations = append(operations, jsonPatchOperation{Op: "add", Path: "/fields/System.IterationPath", Value: iteration})
+	}
+	if parentID := strings.TrimSpace(request.ParentID); parentID != "" {
+		id, err := parseWorkItemID(parentID, "parent task ID")
+		if err != nil {
+			return "", err
+		}
+		operations = append(operations, m.relationOperation(relationParent, id))
+	}
+	for _, depID := range request.DependsOn {
+		if strings.TrimSpace(depID) == "" {
+			continue
+		}
+		id, err := parseWorkItemID(depID, "dependency task ID")
+		if err != nil {
+			return "", err
+		}
+		operations = append(operations, m.relationOperation(relationPredecessor, id))
+	}
+
+	requestURL := m.projectURL + "/_apis/wit/workitems/$" + url.PathEscape(m.workItemType) + "?api-version=" + apiVersion
+	statusCode, body, err := m.doJSON(ctx, http.MethodPost, requestURL, "application/json-patch+json", operations)
+	if err != nil {
+		return "", fmt.Errorf("create Azure DevOps work item %q: %w", title, err)
+	}
+	if statusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("create Azure DevOps work item %q: request failed with status %d: %s", title, statusCode, firstAPIError(body))
+	}
+	var created workItemPayload
+	if err := json.Unmarshal(body, &created); err != nil {
+		return "", fmt.Errorf("create Azure DevOps work item %q: cannot parse response: %w", title, err)
+	}
+	if created.ID <= 0 {
+		return "", fmt.Errorf("create Azure DevOps work item %q: response has no work item id", title)
+	}
+	return strconv.Itoa(created.ID), nil
+}
+
+func (m *TaskManager) taskFromWorkItem(item workItemPayload) contracts.Task {
+	id := strconv.Itoa(item.ID)
+	metadata := map[string]string{}
+	if deps := item.relatedIDs(relationPredecessor); len(deps) > 0 {
+		depIDs := make([]string, 0, len(deps))
+		for _, dep := range deps {
+			depIDs = append(depIDs, strconv.Itoa(dep))
+		}
+		metadata["dependencies"] = strings.Join(depIDs, ",")
+	}
+	if itemType := strings.TrimSpace(item.Fields.WorkItemType); itemType != "" {
+		metadata["work_item_type"] = itemType
+	}
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+	parentID := ""
+	if parent := item.parentID(); parent > 0 {
+		parentID = strconv.Itoa(parent)
+	}
+	title := strings.TrimSpace(item.Fields.Title)
+	if title == "" {
+		title = id
+	}
+	return contracts.Task{
+		ID:          id,
+		Title:       title,
+		Description: item.Fields.Description,
+		Status:      m.statusOf(item),
+		ParentID:    parentID,
+		Metadata:    metadata,
+	}
+}
+
+func (m *TaskManager) statusOf(item workItemPayload) contracts.TaskStatus {
+	return m.states.taskStatus(item.Fields.State, item.tags())
+}
+
+func (m *TaskManager) inScope(items []workItemPayload) []workItemPayload {
+	kept := make([]workItemPayload, 0, len(items))
+	for _, item := range items {
+		if m.scope.contains(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+func (m *TaskManager) relationOperation(rel string, id int) jsonPatchOperation {
+	return jsonPatchOperation{
+		Op:    "add",
+		Path:  "/relations/-",
+		Value: workItemRelationPayload{Rel: rel, URL: m.projectURL + "/_apis/wit/workItems/" + strconv.Itoa(id)},
+	}
+}
+
+func (m *TaskManager) workItemURL(id int) string {
+	return m.projectURL + "/_apis/wit/workitems/" + strconv.Itoa(id) + "?api-version=" + apiVersion
+}
+
+func (m *TaskManager) fetchWorkItem(ctx context.Context, id int) (*workItemPayload, error) {
+	requestURL := m.projectURL + "/_apis/wit/workitems/" + strconv.Itoa(id) + "?$expand=relations&api-version=" + apiVersion
+	statusCode, body, err := m.doJSON(ctx, http.MethodGet, requestURL, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("query Azure DevOps work item %d: %w", id, err)
+	}
+	if statusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if statusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("query Azure DevOps work item %d: request failed with status %d: %s", id, statusCode, firstAPIError(body))
+	}
+	var item workItemPayload
+	if err := json.Unmarshal(body, &item); err != nil {
+		return nil, fmt.Errorf("query Azure DevOps work item %d: cannot parse response: %w", id, err)
+	}
+	if item.ID <= 0 {
+		item.ID = id
+	}
+	return &item, nil
+}
+
+// fetchWorkItems loads work items with their relations through the batch API.
+// Deleted or inaccessible work items are skipped.
+func (m *TaskManager) fetchWorkItems(ctx context.Context, ids []int) ([]workItemPayload, error) {
+	items := make([]workItemPayload, 0, len(ids))
+	requestURL := m.projectURL + "/_apis/wit/workitemsbatch?api-version=" + apiVersion
+	for start := 0; start < len(ids); start += workItemsBatchSize {
+		end := start + workItemsBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		payload := map[string]any{"ids": ids[start:end], "$expand": "Relations", "errorPolicy": "Omit"}
+		statusCode, body, err := m.doJSON(ctx, http.MethodPost, requestURL, "application/json", payload)
+		if err != nil {
+			return nil, fmt.Errorf("query Azure DevOps work items: %w", err)
+		}
+		if statusCode >= http.StatusBadRequest {
+			return nil, fmt.Errorf("query Azure DevOps work items: request failed with status %d: %s", statusCode, firstAPIError(body))
+		}
+		var batch struct {
+			Value []*workItemPayload `json:"value"`
+		}
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return nil, fmt.Errorf("query Azure DevOps work items: cannot parse response: %w", err)
+		}
+		for _, item := range batch.Value {
+			if item != nil && item.ID > 0 {
+				items = append(items, *item)
+			}
+		}
+	}
+	return items, nil
+}
+
+func (m *TaskManager) postComment(ctx context.Context, id int, text string) error {
+	requestURL := m.projectURL + "/_apis/wit/workItems/" + strconv.Itoa(id) + "/comments?api-version=" + commentsAPIVersion
+	statusCode, body, err := m.doJSON(ctx, http.MethodPost, requestURL, "application/json", map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	if statusCode >= http.StatusBadRequest {
+		return fmt.Errorf("request failed with status %d: %s", statusCode, firstAPIError(body))
+	}
+	return nil
+}
+
+func (m *TaskManager) probeProject(ctx context.Context, requestURL string, project string) error {
+	statusCode, body, err := m.doJSON(ctx, http.MethodGet, requestURL, "", nil)
+	if err != nil {
+		return fmt.Errorf("probe request failed: %w", err)
+	}
+	if statusCode >= http.StatusBadRequest {
+		return fmt.Errorf("probe failed with status %d: %s", statusCode, firstAPIError(body))
+	}
+	var probe struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
+		// A PAT without access is answered with a sign-in page instead of JSON.
+		return fmt.Errorf("cannot parse probe response (check the personal access token): %w", err)
+	}
+	if !strings.EqualFold(strings.TrimSpace(probe.Name), project) && !strings.EqualFold(strings.TrimSpace(probe.ID), project) {
+		return fmt.Errorf("probe failed: expected project %q, got %q", project, strings.TrimSpace(probe.Name))
+	}
+	return nil
+}
+
+// doJSON sends an authenticated request and retries when Azure DevOps
+// throttles it with 429 and Retry-After.
+func (m *TaskManager) doJSON(ctx context.Context, method string, requestURL string, contentType string, payload any) (int, []byte, error) {
+	var requestBody []byte
+	if payload != nil {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return 0, nil, fmt.Errorf("cannot encode request body: %w", err)
+		}
+		requestBody = body
+	}
+	for attempt := 0; ; attempt++ {
+		var bodyReader io.Reader
+		if requestBody != nil {
+			bodyReader = bytes.NewReader(requestBody)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, requestURL, bodyReader)
+		if err != nil {
+			return 0, nil, fmt.Errorf("cannot build request: %w", err)
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(":"+m.token)))
+		if requestBody != nil {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		resp, err := m.client.Do(req)
+		if err != nil {
+			return 0, nil, fmt.Errorf("request failed: %w", err)
+		}
+		body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxReadResponseSize))
+		_ = resp.Body.Close()
+		if readErr != nil {
+			return 0, nil, fmt.Errorf("cannot read response: %w", readErr)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxRateLimitAttempts {
+			m.sleep(retryAfter(resp.Header, attempt))
+			continue
+		}
+		return resp.StatusCode, body, nil
+	}
+}
+
+func retryAfter(headers http.Header, attempt int) time.Duration {
+	wait := time.Duration(attempt+1) * 5 * time.Second
+	if seconds, err := strconv.Atoi(strings.TrimSpace(headers.Get("Retry-After"))); err == nil && seconds > 0 {
+		wait = time.Duration(seconds) * time.Second
+	}
+	if wait > maxRateLimitBackoff {
+		wait = maxRateLimitBackoff
+	}
+	return wait
+}
+
+func dependenciesClosed(dependencies []int, statusByID map[int]contracts.TaskStatus) bool {
+	for _, depID := range dependencies {
+		status, ok := statusByID[depID]
+		if !ok {
+			continue
+		}
+		if status != contracts.TaskStatusClosed {
+			return false
+		}
+	}
+	return true
+}
+
+func taskSummaryFromWorkItem(item workItemPayload) contracts.TaskSummary {
+	priority := item.priority()
+	title := strings.TrimSpace(item.Fields.Title)
+	if title == "" {
+		title = strconv.Itoa(item.ID)
+	}
+	return contracts.TaskSummary{ID: strconv.Itoa(item.ID), Title: title, Priority: &priority}
+}
+
+// commentHTML renders plain text for Azure DevOps, which stores comments and
+// descriptions as HTML.
+func commentHTML(text string) string {
+	return strings.ReplaceAll(html.EscapeString(strings.TrimSpace(text)), "\n", "<br>")
+}
+
+func parseWorkItemID(raw string, fieldName string) (int, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return 0, fmt.Errorf("%s is required", fieldName)
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", fieldName, value)
+	}
+	return id, nil
+}
+
+func firstAPIError(body []byte) string {
+	bodyText := strings.TrimSpace(string(body))
+	if bodyText == "" {
+		return "unknown error"
+	}
+	var payload struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && strings.TrimSpace(payload.Message) != "" {
+		return strings.TrimSpace(payload.Message)
+	}
+	return bodyText
+}
@@ -0,0 +1,405 @@
+package azuredevops
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestNewTaskManagerRequiresOrganizationProjectAndToken(t *testing.T) {
+	for _, tc := range []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{Project: "Boards", Token: "pat"}, "organization"},
+		{Config{Organization: "contoso", Token: "pat"}, "project"},
+		{Config{Organization: "contoso", Project: "Boards"}, "token"},
+	} {
+		_, err := NewTaskManager(tc.cfg)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("expected %s validation error, got %v", tc.want, err)
+		}
+	}
+}
+
+func TestNewTaskManagerProbesProjectWithPAT(t *testing.T) {
+	server := newFakeBoards(t)
+
+	if _, err := server.manager(t, Config{}); err != nil {
+		t.Fatalf("expected valid probe, got %v", err)
+	}
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte(":pat-test"))
+	if server.lastAuth != want {
+		t.Fatalf("expected PAT basic auth, got %q", server.lastAuth)
+	}
+}
+
+func TestNewTaskManagerWrapsProbeAuthErrors(t *testing.T) {
+	server := newFakeBoards(t)
+	server.probeStatus = http.StatusUnauthorized
+
+	_, err := server.manager(t, Config{})
+	if err == nil || !strings.Contains(err.Error(), "azure devops auth validation failed") {
+		t.Fatalf("expected wrapped auth failure, got %v", err)
+	}
+}
+
+func TestTaskManagerNextTasksHonorsDependenciesPriorityAndScope(t *testing.T) {
+	server := newFakeBoards(t)
+	server.add(workItem(100, "Epic", "Active", `Team\Web`, 0, withChildren(101, 102, 103, 104)))
+	server.add(workItem(101, "Blocked by 102", "New", `Team\Web`, 100, withPredecessors(102)))
+	server.add(workItem(102, "Low priority", "New", `Team\Web\Frontend`, 100, withPriority(3)))
+	server.add(workItem(103, "Urgent", "New", `Team\Web`, 100, withPriority(1)))
+	server.add(workItem(104, "Other team", "New", `Team\Mobile`, 100))
+	manager, err := server.manager(t, Config{Scope: Scope{AreaPath: `Team\Web`}})
+	if err != nil {
+		t.Fatalf("build manager: %v", err)
+	}
+
+	tasks, err := manager.NextTasks(context.Background(), "100")
+	if err != nil {
+		t.Fatalf("next tasks: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != "103" || tasks[1].ID != "102" {
+		t.Fatalf("expected urgent then low-priority in-scope task, got %#v", tasks)
+	}
+	if *tasks[0].Priority != 0 || *tasks[1].Priority != 2 {
+		t.Fatalf("expected normalized priorities, got %d and %d", *tasks[0].Priority, *tasks[1].Priority)
+	}
+}
+
+func TestTaskManagerNextTasksReturnsOpenLeafRoot(t *testing.T) {
+	server := newFakeBoards(t)
+	server.add(workItem(7, "Leaf", "To Do", "", 0))
+	manager, err := server.manager(t, Config{})
+	if err != nil {
+		t.Fatalf("build manager: %v", err)
+	}
+
+	tasks, err := manager.NextTasks(context.Background(), "7")
+	if err != nil {
+		t.Fatalf("next tasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != "7" {
+		t.Fatalf("expected leaf root to be runnable, got %#v", tasks)
+	}
+}
+
+func TestTaskManagerGetTaskTreeWalksHierarchy(t *testing.T) {
+	server := newFakeBoards(t)
+	server.add(workItem(1, "Epic", "New", "", 0, withChildren(2)))
+	server.add(workItem(2, "Feature", "Active", "", 1, withChildren(3, 4)))
+	server.add(workItem(3, "Task A", "Done", "", 2))
+	server.add(workItem(4, "Task B", "New", "", 2, withPredecessors(3), withTags("backend; yolo-blocked")))
+	manager, err := server.manager(t, Config{})
+	if err != nil {
+		t.Fatalf("build manager: %v", err)
+	}
+
+	tree, err := manager.GetTaskTree(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("task tree: %v", err)
+	}
+	if len(tree.Tasks) != 4 || tree.Root.ID != "1" {
+		t.Fatalf("expected four tasks under root 1, got %#v", tree)
+	}
+	if got := tree.Tasks["2"].Status; got != contracts.TaskStatusInProgress {
+		t.Fatalf("expected Active to map to in_progress, got %q", got)
+	}
+	if got := tree.Tasks["3"].Status; got != contracts.TaskStatusClosed {
+		t.Fatalf("expected Done to map to closed, got %q", got)
+	}
+	if got := tree.Tasks["4"].Status; got != contracts.TaskStatusBlocked {
+		t.Fatalf("expected yolo-blocked tag to map to blocked, got %q", got)
+	}
+	if tree.Tasks["4"].ParentID != "2" || tree.Tasks["4"].Metadata["dependencies"] != "3" {
+		t.Fatalf("expected parent and dependency metadata, got %#v", tree.Tasks["4"])
+	}
+	for _, want := range []contracts.TaskRelation{
+		{FromID: "1", ToID: "2", Type: contracts.RelationParent},
+		{FromID: "4", ToID: "3", Type: contracts.RelationDependsOn},
+		{FromID: "3", ToID: "4", Type: contracts.RelationBlocks},
+	} {
+		found := false
+		for _, relation := range tree.Relations {
+			if relation == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("expected relation %#v in %#v", want, tree.Relations)
+		}
+	}
+}
+
+func TestTaskManagerSetTaskStatusWritesMappedStateAndTags(t *testing.T) {
+	server := newFakeBoards(t)
+	server.add(workItem(5, "Task", "Active", "", 0, withTags("backend")))
+	manager, err := server.manager(t, Config{States: StateMapping{Closed: "Done"}})
+	if err != nil {
+		t.Fatalf("build manager: %v", err)
+	}
+
+	if err := manager.SetTaskStatus(context.Background(), "5", contracts.TaskStatusBlocked); err != nil {
+		t.Fatalf("set blocked: %v", err)
+	}
+	if item := server.items[5]; item.Fields.State != "New" || item.Fields.Tags != "backend; yolo-blocked" {
+		t.Fatalf("expected open state with blocked tag, got %#v", item.Fields)
+	}
+	if err := manager.SetTaskStatus(context.Background(), "5", contracts.TaskStatusClosed); err != nil {
+		t.Fatalf("set closed: %v", err)
+	}
+	if item := server.items[5]; item.Fields.State != "Done" || item.Fields.Tags != "backend" {
+		t.Fatalf("expected configured closed state without blocked tag, got %#v", item.Fields)
+	}
+}
+
+func TestTaskManagerSetTaskDataAndCommentsPostHTMLComments(t *testing.T) {
+	server := newFakeBoards(t)
+	server.add(workItem(5, "Task", "New", "", 0))
+	manager, err := server.manager(t, Config{})
+	if err != nil {
+		t.Fatalf("build manager: %v", err)
+	}
+
+	if err := manager.SetTaskData(context.Background(), "5", map[string]string{"b": "2", "a": "<1>"}); err != nil {
+		t.Fatalf("set task data: %v", err)
+	}
+	if err := manager.AddTaskComment(context.Background(), "5", "yolo-runner: started\nworker=1"); err != nil {
+		t.Fatalf("add comment: %v", err)
+	}
+	if len(server.comments[5]) != 2 {
+		t.Fatalf("expected two comments, got %#v", server.comments)
+	}
+	if got := server.comments[5][0]; got != taskDataCommentTag+"a=&lt;1&gt;<br>b=2" {
+		t.Fatalf("unexpected task data comment %q", got)
+	}
+	if got := server.comments[5][1]; got != "yolo-runner: started<br>worker=1" {
+		t.Fatalf("unexpected comment %q", got)
+	}
+}
+
+func TestTaskManagerCreateTaskLinksParentAndPredecessors(t *testing.T) {
+	server := newFakeBoards(t)
+	manager, err := server.manager(t, Config{Scope: Scope{AreaPath: `Team\Web`, IterationPath: `Team\Sprint 4`}, WorkItemType: "User Story"})
+	if err != nil {
+		t.Fatalf("build manager: %v", err)
+	}
+
+	id, err := manager.CreateTask(context.Background(), contracts.TaskCreateRequest{ParentID: "10", Title: "Follow-up", DependsOn: []string{"11"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	created := server.items[mustAtoi(t, id)]
+	if server.createdType != "User Story" || created.Fields.Title != "Follow-up" || created.Fields.AreaPath != `Team\Web` || created.Fields.IterationPath != `Team\Sprint 4` {
+		t.Fatalf("unexpected created work item %q %#v", server.createdType, created.Fields)
+	}
+	if created.parentID() != 10 || len(created.relatedIDs(relationPredecessor)) != 1 {
+		t.Fatalf("expected parent and predecessor links, got %#v", created.Relations)
+	}
+}
+
+func TestTaskManagerRetriesThrottledRequests(t *testing.T) {
+	server := newFakeBoards(t)
+	server.add(workItem(5, "Task", "New", "", 0))
+	manager, err := server.manager(t, Config{})
+	if err != nil {
+		t.Fatalf("build manager: %v", err)
+	}
+	var slept []time.Duration
+	manager.sleep = func(d time.Duration) { slept = append(slept, d) }
+	server.throttle = 1
+
+	task, err := manager.GetTask(context.Background(), "5")
+	if err != nil || task.ID != "5" {
+		t.Fatalf("expected throttled request to be retried, got %#v %v", task, err)
+	}
+	if len(slept) != 1 || slept[0] != 2*time.Second {
+		t.Fatalf("expected Retry-After backoff, got %v", slept)
+	}
+}
+
+type fakeBoards struct {
+	mu          sync.Mutex
+	server      *httptest.Server
+	items       map[int]workItemPayload
+	comments    map[int][]string
+	createdType string
+	probeStatus int
+	throttle    int
+	lastAuth    string
+}
+
+func newFakeBoards(t *testing.T) *fakeBoards {
+	t.Helper()
+	f := &fakeBoards{items: map[int]workItemPayload{}, comments: map[int][]string{}}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeBoards) manager(t *testing.T, cfg Config) (*TaskManager, error) {
+	t.Helper()
+	cfg.Organization = "contoso"
+	cfg.Project = "Boards"
+	cfg.Token = "pat-test"
+	cfg.APIEndpoint = f.server.URL
+	cfg.HTTPClient = f.server.Client()
+	return NewTaskManager(cfg)
+}
+
+func (f *fakeBoards) add(item workItemPayload) {
+	f.items[item.ID] = item
+}
+
+func (f *fakeBoards) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastAuth = r.Header.Get("Authorization")
+	if f.throttle > 0 {
+		f.throttle--
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+	path := r.URL.Path
+	switch {
+	case path == "/contoso/_apis/projects/Boards":
+		if f.probeStatus != 0 {
+			w.WriteHeader(f.probeStatus)
+			_, _ = w.Write([]byte(`{"message":"TF400813: not authorized"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"c0ffee","name":"Boards"}`))
+	case path == "/contoso/Boards/_apis/wit/workitemsbatch":
+		var request struct {
+			IDs []int `json:"ids"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		value := []*workItemPayload{}
+		for _, id := range request.IDs {
+			if item, ok := f.items[id]; ok {
+				value = append(value, &item)
+			} else {
+				value = append(value, nil)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"count": len(value), "value": value})
+	case strings.HasPrefix(path, "/contoso/Boards/_apis/wit/workitems/$"):
+		f.createdType = strings.TrimPrefix(path, "/contoso/Boards/_apis/wit/workitems/$")
+		item := workItemPayload{ID: 500 + len(f.items)}
+		f.applyPatch(&item, r.Body)
+		f.items[item.ID] = item
+		_ = json.NewEncoder(w).Encode(item)
+	case strings.HasSuffix(path, "/comments"):
+		id, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(path, "/contoso/Boards/_apis/wit/workItems/"), "/comments"))
+		var request struct {
+			Text string `json:"text"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		f.comments[id] = append(f.comments[id], request.Text)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	case strings.HasPrefix(path, "/contoso/Boards/_apis/wit/workitems/"):
+		id, _ := strconv.Atoi(strings.TrimPrefix(path, "/contoso/Boards/_apis/wit/workitems/"))
+		item, ok := f.items[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodPatch {
+			f.applyPatch(&item, r.Body)
+			f.items[id] = item
+		}
+		_ = json.NewEncoder(w).Encode(item)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeBoards) applyPatch(item *workItemPayload, body io.Reader) {
+	var operations []struct {
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	}
+	_ = json.NewDecoder(body).Decode(&operations)
+	for _, operation := range operations {
+		var text string
+		_ = json.Unmarshal(operation.Value, &text)
+		switch operation.Path {
+		case "/fields/System.Title":
+			item.Fields.Title = text
+		case "/fields/System.State":
+			item.Fields.State = text
+		case "/fields/System.Tags":
+			item.Fields.Tags = text
+		case "/fields/System.AreaPath":
+			item.Fields.AreaPath = text
+		case "/fields/System.IterationPath":
+			item.Fields.IterationPath = text
+		case "/relations/-":
+			var relation workItemRelationPayload
+			_ = json.Unmarshal(operation.Value, &relation)
+			item.Relations = append(item.Relations, relation)
+		}
+	}
+}
+
+type workItemOption func(*workItemPayload)
+
+func workItem(id int, title string, state string, area string, parent int, options ...workItemOption) workItemPayload {
+	item := workItemPayload{ID: id, Fields: workItemFieldsPayload{Title: title, State: state, AreaPath: area, WorkItemType: "Task"}}
+	if parent > 0 {
+		item.Relations = append(item.Relations, workItemRelation(relationParent, parent))
+	}
+	for _, option := range options {
+		option(&item)
+	}
+	return item
+}
+
+func withChildren(ids ...int) workItemOption {
+	return func(item *workItemPayload) {
+		for _, id := range ids {
+			item.Relations = append(item.Relations, workItemRelation(relationChild, id))
+		}
+	}
+}
+
+func withPredecessors(ids ...int) workItemOption {
+	return func(item *workItemPayload) {
+		for _, id := range ids {
+			item.Relations = append(item.Relations, workItemRelation(relationPredecessor, id))
+		}
+	}
+}
+
+func withPriority(priority float64) workItemOption {
+	return func(item *workItemPayload) { item.Fields.Priority = &priority }
+}
+
+func withTags(tags string) workItemOption {
+	return func(item *workItemPayload) { item.Fields.Tags = tags }
+}
+
+func workItemRelation(rel string, id int) workItemRelationPayload {
+	return workItemRelationPayload{Rel: rel, URL: "https://dev.azure.com/contoso/_apis/wit/workItems/" + strconv.Itoa(id)}
+}
+
+func mustAtoi(t *testing.T, raw string) int {
+	t.Helper()
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		t.Fatalf("parse %q: %v", raw, err)
+	}
+	return value
+}
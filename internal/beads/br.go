@@ -3,6 +3,7 @@ package beads
 import (
 	"os"
 	"path/filepath"
+	"sync"
 )
 
 // RustAdapter provides beads_rust (br) CLI integration
@@ -10,6 +11,9 @@ import (
 // See: https://github.com/Dicklesworthstone/beads_rust
 type RustAdapter struct {
 	runner Runner
+	// mu serializes br invocations; concurrent workers share one .beads
+	// database and br does not lock it across processes without the daemon.
+	mu sync.Mutex
 }
 
 type brDependency struct {
@@ -20,6 +24,8 @@ type brDependency struct {
 
 func (a *RustAdapter) run(args ...string) (string, error) {
 	command := append([]string{"br", "--no-daemon"}, args...)
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	return a.runner.Run(command...)
 }
 
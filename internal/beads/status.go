@@ -0,0 +1,44 @@
+package beads
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// taskStatusFromBeads maps a beads issue status onto the runner's task
+// statuses. Deferred issues are not runnable, so they read as blocked;
+// tombstoned issues are deleted and read as closed.
+func taskStatusFromBeads(raw string) contracts.TaskStatus {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "in_progress":
+		return contracts.TaskStatusInProgress
+	case "blocked", "deferred":
+		return contracts.TaskStatusBlocked
+	case "closed", "tombstone":
+		return contracts.TaskStatusClosed
+	case "failed":
+		return contracts.TaskStatusFailed
+	default:
+		return contracts.TaskStatusOpen
+	}
+}
+
+// beadsStatusForTask returns the br status to write for a task status. beads
+// has no failed state, so failures are stored as blocked and the failure is
+// kept in the task manager's terminal-state overlay.
+func beadsStatusForTask(status contracts.TaskStatus) (string, error) {
+	switch status {
+	case contracts.TaskStatusOpen:
+		return "open", nil
+	case contracts.TaskStatusInProgress:
+		return "in_progress", nil
+	case contracts.TaskStatusBlocked, contracts.TaskStatusFailed:
+		return "blocked", nil
+	case contracts.TaskStatusClosed:
+		return "closed", nil
+	default:
+		return "", fmt.Errorf("unsupported task status %q", status)
+	}
+}
@@ -14,6 +14,8 @@ type StorageBackend struct {
 }
 
 var _ contracts.StorageBackend = (*StorageBackend)(nil)
+var _ contracts.TaskCreator = (*StorageBackend)(nil)
+var _ contracts.TaskCommenter = (*StorageBackend)(nil)
 
 // NewStorageBackend creates a new beads storage backend
 func NewStorageBackend(runner Runner, repoRoot string) *StorageBackend {
@@ -62,3 +64,19 @@ func (b *StorageBackend) SetTaskData(ctx context.Context, taskID string, data ma
 	}
 	return b.manager.SetTaskData(ctx, taskID, data)
 }
+
+// CreateTask adds a task under request.ParentID
+func (b *StorageBackend) CreateTask(ctx context.Context, request contracts.TaskCreateRequest) (string, error) {
+	if b == nil || b.manager == nil {
+		return "", fmt.Errorf("beads storage backend is not initialized")
+	}
+	return b.manager.CreateTask(ctx, request)
+}
+
+// AddTaskComment posts a comment on a task
+func (b *StorageBackend) AddTaskComment(ctx context.Context, taskID string, body string) error {
+	if b == nil || b.manager == nil {
+		return fmt.Errorf("beads storage backend is not initialized")
+	}
+	return b.manager.AddTaskComment(ctx, taskID, body)
+}
@@ -121,12 +121,37 @@ func (m *TaskManager) GetTask(ctx context.Context, taskID string) (contracts.Tas
 		return contracts.Task{}, err
 	}
 
-	return contracts.Task{
+	task := contracts.Task{
 		ID:          bead.ID,
 		Title:       bead.Title,
 		Description: bead.Description,
-		Status:      contracts.TaskStatus(bead.Status),
-	}, nil
+		Status:      m.effectiveStatus(bead.ID, bead.Status),
+	}
+	// Parent and dependency links are only in the JSONL export; br show does
+	// not include them.
+	if issues, err := m.loadJSONLIssues(); err == nil {
+		if issue, ok := issues[bead.ID]; ok {
+			deps := []string{}
+			for _, dep := range issue.Dependencies {
+				dependsOnID := strings.TrimSpace(dep.DependsOnID)
+				if dependsOnID == "" {
+					continue
+				}
+				if dep.Type == "parent-child" {
+					if task.ParentID == "" {
+						task.ParentID = dependsOnID
+					}
+					continue
+				}
+				deps = append(deps, dependsOnID)
+			}
+			if len(deps) > 0 {
+				sort.Strings(deps)
+				task.Metadata = map[string]string{"dependencies": strings.Join(deps, ",")}
+			}
+		}
+	}
+	return task, nil
 }
 
 // GetTaskTree retrieves the full task tree starting from rootID
@@ -160,7 +185,7 @@ func (m *TaskManager) GetTaskTree(ctx context.Context, rootID string) (*contract
 		ID:          rootBead.ID,
 		Title:       rootBead.Title,
 		Description: rootBead.Description,
-		Status:      contracts.TaskStatus(rootBead.Status),
+		Status:      m.effectiveStatus(rootBead.ID, rootBead.Status),
 	}
 
 	// Process children recursively
@@ -176,7 +201,8 @@ func (m *TaskManager) GetTaskTree(ctx context.Context, rootID string) (*contract
 	}, nil
 }
 
-func (m *TaskManager) getTaskTreeFromJSONL(rootID string) (*contracts.TaskTree, error) {
+// loadJSONLIssues reads the .beads/issues.jsonl export keyed by issue ID.
+func (m *TaskManager) loadJSONLIssues() (map[string]issueRecord, error) {
 	issuesPath := filepath.Join(m.repoRoot, ".beads", "issues.jsonl")
 	file, err := os.Open(issuesPath)
 	if err != nil {
@@ -185,7 +211,6 @@ func (m *TaskManager) getTaskTreeFromJSONL(rootID string) (*contracts.TaskTree,
 	defer file.Close()
 
 	issues := make(map[string]issueRecord)
-	childrenByParent := make(map[string][]string)
 	scanner := bufio.NewScanner(file)
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 2*1024*1024)
@@ -199,15 +224,26 @@ func (m *TaskManager) getTaskTreeFromJSONL(rootID string) (*contracts.TaskTree,
 			return nil, err
 		}
 		issues[issue.ID] = issue
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+func (m *TaskManager) getTaskTreeFromJSONL(rootID string) (*contracts.TaskTree, error) {
+	issues, err := m.loadJSONLIssues()
+	if err != nil {
+		return nil, err
+	}
+	childrenByParent := make(map[string][]string)
+	for _, issue := range issues {
 		for _, dep := range issue.Dependencies {
 			if dep.Type == "parent-child" {
 				childrenByParent[dep.DependsOnID] = append(childrenByParent[dep.DependsOnID], issue.ID)
 			}
 		}
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
 
 	rootIssue, ok := issues[rootID]
 	if !ok {
@@ -256,7 +292,7 @@ func (m *TaskManager) getTaskTreeFromJSONL(rootID string) (*contracts.TaskTree,
 			ID:          issue.ID,
 			Title:       issue.Title,
 			Description: issue.Description,
-			Status:      contracts.TaskStatus(issue.Status),
+			Status:      m.effectiveStatus(issue.ID, issue.Status),
 			ParentID:    parentID,
 		}
 		if parentID != "" {
@@ -301,7 +337,7 @@ func (m *TaskManager) getTaskTreeFromJSONL(rootID string) (*contracts.TaskTree,
 	}
 
 	return &contracts.TaskTree{
-		Root:                      contracts.Task{ID: rootIssue.ID, Title: rootIssue.Title, Description: rootIssue.Description, Status: contracts.TaskStatus(rootIssue.Status)},
+		Root:                      tasks[rootIssue.ID],
 		Tasks:                     tasks,
 		Relations:                 relations,
 		MissingDependencyIDs:      missingIDs,
@@ -327,7 +363,7 @@ func (m *TaskManager) processChildren(children []Issue, parentID string, tasks m
 			ID:          bead.ID,
 			Title:       bead.Title,
 			Description: bead.Description,
-			Status:      contracts.TaskStatus(bead.Status),
+			Status:      m.effectiveStatus(bead.ID, bead.Status),
 			ParentID:    parentID,
 		}
 
@@ -398,7 +434,14 @@ func (m *TaskManager) buildDependencyRelations(tasks map[string]contracts.Task)
 
 // SetTaskStatus updates the status of a task
 func (m *TaskManager) SetTaskStatus(ctx context.Context, taskID string, status contracts.TaskStatus) error {
-	if err := m.adapter.UpdateStatus(taskID, string(status)); err != nil {
+	brStatus, err := beadsStatusForTask(status)
+	if err != nil {
+		return err
+	}
+	if err := m.adapter.UpdateStatus(taskID, brStatus); err != nil {
+		return err
+	}
+	if err := m.flushJSONL(); err != nil {
 		return err
 	}
 
@@ -429,8 +472,92 @@ func (m *TaskManager) SetTaskData(ctx context.Context, taskID string, data map[s
 	return nil
 }
 
+// CreateTask adds an issue with br create, attaches it to the parent and
+// records dependencies with br dep add.
+func (m *TaskManager) CreateTask(ctx context.Context, request contracts.TaskCreateRequest) (string, error) {
+	title := strings.TrimSpace(request.Title)
+	if title == "" {
+		return "", fmt.Errorf("task title is required")
+	}
+	args := []string{"create", title, "--type", "task"}
+	if description := strings.TrimSpace(request.Description); description != "" {
+		args = append(args, "--description", description)
+	}
+	if parentID := strings.TrimSpace(request.ParentID); parentID != "" {
+		args = append(args, "--parent", parentID)
+	}
+	args = append(args, "--json")
+	output, err := m.adapter.run(args...)
+	if err != nil {
+		return "", err
+	}
+	taskID, err := parseCreatedIssueID(output)
+	if err != nil {
+		return "", err
+	}
+	for _, dependsOnID := range request.DependsOn {
+		dependsOnID = strings.TrimSpace(dependsOnID)
+		if dependsOnID == "" {
+			continue
+		}
+		if _, err := m.adapter.run("dep", "add", taskID, dependsOnID); err != nil {
+			return taskID, fmt.Errorf("add dependency %s -> %s: %w", taskID, dependsOnID, err)
+		}
+	}
+	return taskID, m.flushJSONL()
+}
+
+// AddTaskComment posts a comment with br comments add.
+func (m *TaskManager) AddTaskComment(ctx context.Context, taskID string, body string) error {
+	taskID = strings.TrimSpace(taskID)
+	if taskID == "" {
+		return fmt.Errorf("task ID is required")
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil
+	}
+	_, err := m.adapter.run("comments", "add", taskID, body)
+	return err
+}
+
+func parseCreatedIssueID(output string) (string, error) {
+	var created Bead
+	if err := traceJSONParse("Create", []byte(output), &created); err == nil && strings.TrimSpace(created.ID) != "" {
+		return strings.TrimSpace(created.ID), nil
+	}
+	var createdList []Bead
+	if err := traceJSONParse("Create", []byte(output), &createdList); err == nil && len(createdList) > 0 && strings.TrimSpace(createdList[0].ID) != "" {
+		return strings.TrimSpace(createdList[0].ID), nil
+	}
+	return "", fmt.Errorf("br create did not return an issue ID")
+}
+
 // Helper methods
 
+// flushJSONL re-exports .beads/issues.jsonl after a write when the repo has
+// one, since GetTaskTree prefers reading it over shelling out to br.
+func (m *TaskManager) flushJSONL() error {
+	if _, err := os.Stat(filepath.Join(m.repoRoot, ".beads", "issues.jsonl")); err != nil {
+		return nil
+	}
+	return m.adapter.Sync()
+}
+
+// effectiveStatus maps a br status and applies failed/blocked states recorded
+// during this run, which br cannot tell apart.
+func (m *TaskManager) effectiveStatus(taskID string, raw string) contracts.TaskStatus {
+	status := taskStatusFromBeads(raw)
+	if status == contracts.TaskStatusClosed {
+		return status
+	}
+	m.terminalMu.RLock()
+	defer m.terminalMu.RUnlock()
+	if state, ok := m.terminalState[taskID]; ok {
+		return state
+	}
+	return status
+}
+
 func (m *TaskManager) isTerminal(taskID string) bool {
 	if taskID == "" {
 		return false
@@ -0,0 +1,114 @@
+package beads
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestTaskStatusFromBeadsMapsBrStatuses(t *testing.T) {
+	cases := map[string]contracts.TaskStatus{
+		"open":        contracts.TaskStatusOpen,
+		"in_progress": contracts.TaskStatusInProgress,
+		"blocked":     contracts.TaskStatusBlocked,
+		"deferred":    contracts.TaskStatusBlocked,
+		"closed":      contracts.TaskStatusClosed,
+		"tombstone":   contracts.TaskStatusClosed,
+		"":            contracts.TaskStatusOpen,
+	}
+	for raw, want := range cases {
+		if got := taskStatusFromBeads(raw); got != want {
+			t.Fatalf("status %q: expected %q, got %q", raw, want, got)
+		}
+	}
+}
+
+func TestTaskManagerSetTaskStatusStoresFailedAsBlockedAndOverlaysIt(t *testing.T) {
+	repoRoot := writeBeadsJSONL(t, []string{
+		`{"id":"root","title":"Root","status":"open","issue_type":"epic"}`,
+		`{"id":"root.1","title":"Task","status":"open","issue_type":"task","dependencies":[{"issue_id":"root.1","depends_on_id":"root","type":"parent-child"}]}`,
+	})
+	runner := &fakeRunner{}
+	manager := NewTaskManager(runner, repoRoot)
+
+	if err := manager.SetTaskStatus(context.Background(), "root.1", contracts.TaskStatusFailed); err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	assertCall(t, runner.calls, []string{"br", "--no-daemon", "update", "root.1", "--status", "blocked"})
+	if len(runner.calls) != 2 || joinArgs(runner.calls[1]) != "br --no-daemon sync --flush-only" {
+		t.Fatalf("expected JSONL flush after status write, got %v", runner.calls)
+	}
+
+	tree, err := manager.GetTaskTree(context.Background(), "root")
+	if err != nil {
+		t.Fatalf("get tree: %v", err)
+	}
+	if got := tree.Tasks["root.1"].Status; got != contracts.TaskStatusFailed {
+		t.Fatalf("expected failed overlay in tree, got %q", got)
+	}
+	if err := manager.SetTaskStatus(context.Background(), "root.1", contracts.TaskStatus("review")); err == nil {
+		t.Fatalf("expected unsupported status error")
+	}
+}
+
+func TestTaskManagerGetTaskIncludesParentAndDependenciesFromJSONL(t *testing.T) {
+	repoRoot := writeBeadsJSONL(t, []string{
+		`{"id":"root.2","title":"Second","status":"in_progress","issue_type":"task","dependencies":[{"issue_id":"root.2","depends_on_id":"root","type":"parent-child"},{"issue_id":"root.2","depends_on_id":"root.1","type":"blocks"}]}`,
+	})
+	runner := &fakeRunner{output: `[{"id":"root.2","title":"Second","status":"in_progress"}]`}
+	manager := NewTaskManager(runner, repoRoot)
+
+	task, err := manager.GetTask(context.Background(), "root.2")
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.ParentID != "root" || task.Metadata["dependencies"] != "root.1" || task.Status != contracts.TaskStatusInProgress {
+		t.Fatalf("unexpected task %#v", task)
+	}
+}
+
+func TestStorageBackendCreatesTasksAndComments(t *testing.T) {
+	runner := &fakeRunner{outputs: []string{`{"id":"root.3","title":"New"}`, "", ""}}
+	backend := NewStorageBackend(runner, t.TempDir())
+
+	id, err := backend.CreateTask(context.Background(), contracts.TaskCreateRequest{ParentID: "root", Title: "New", Description: "Body", DependsOn: []string{"root.1"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if id != "root.3" {
+		t.Fatalf("expected created ID root.3, got %q", id)
+	}
+	if err := backend.AddTaskComment(context.Background(), "root.3", "started"); err != nil {
+		t.Fatalf("add comment: %v", err)
+	}
+
+	want := []string{
+		"br --no-daemon create New --type task --description Body --parent root --json",
+		"br --no-daemon dep add root.3 root.1",
+		"br --no-daemon comments add root.3 started",
+	}
+	got := make([]string, 0, len(runner.calls))
+	for _, call := range runner.calls {
+		got = append(got, joinArgs(call))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected br calls:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func writeBeadsJSONL(t *testing.T, lines []string) string {
+	t.Helper()
+	repoRoot := t.TempDir()
+	beadsDir := filepath.Join(repoRoot, ".beads")
+	if err := os.MkdirAll(beadsDir, 0o755); err != nil {
+		t.Fatalf("mkdir .beads: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(beadsDir, "issues.jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatalf("write issues.jsonl: %v", err)
+	}
+	return repoRoot
+}
@@ -84,14 +84,14 @@ func (a *CLIRunnerAdapter) Run(ctx context.Context, request contracts.RunnerRequ
 		return contracts.RunnerResult{}, err
 	}
 
-	stdoutFile, err := os.Create(logPath)
+	stdoutFile, err := contracts.CreateRunnerLog(logPath, request.Secrets)
 	if err != nil {
 		return contracts.RunnerResult{}, err
 	}
 	defer func() { _ = stdoutFile.Close() }()
 
 	stderrPath := contracts.BackendLogSidecarPath(logPath, contracts.BackendLogStderr)
-	stderrFile, err := os.Create(stderrPath)
+	stderrFile, err := contracts.CreateRunnerLog(stderrPath, request.Secrets)
 	if err != nil {
 		return contracts.RunnerResult{}, err
 	}
@@ -121,6 +121,7 @@ func (a *CLIRunnerAdapter) Run(ctx context.Context, request contracts.RunnerRequ
 	runErr := a.runner.Run(runCtx, CommandSpec{
 		Binary: a.binary,
 		Args:   a.buildArgs(request),
+		Env:    contracts.RunnerEnv(request.Env),
 		Dir:    request.RepoRoot,
 		Stdout: stdoutWriter,
 		Stderr: stderrWriter,
@@ -2,6 +2,8 @@ package claude
 
 import (
 	"context"
+	"encoding/json"
+	"os"
 	"strings"
 	"time"
 
@@ -25,12 +27,16 @@ var _ contracts.AgentRunner = (*SessionRunnerAdapter)(nil)
 
 // buildClaudeArgs returns the full claude CLI argument list with the prompt as
 // the last positional argument. Passing the prompt via args (not stdin) means
-// claude processes it immediately without waiting for stdin EOF.
-func buildClaudeArgs(model, prompt string) []string {
+// claude processes it immediately without waiting for stdin EOF. A non-empty
+// resumeSessionID continues that claude session with --resume.
+func buildClaudeArgs(model, prompt, resumeSessionID string) []string {
 	args := []string{"--print", "--output-format", "stream-json", "--verbose", "--dangerously-skip-permissions"}
 	if m := strings.TrimSpace(model); m != "" {
 		args = append(args, "--model", m)
 	}
+	if id := strings.TrimSpace(resumeSessionID); id != "" {
+		args = append(args, "--resume", id)
+	}
 	return append(args, prompt)
 }
 
@@ -48,13 +54,20 @@ func (a *SessionRunnerAdapter) Run(ctx context.Context, request contracts.Runner
 		metadata[k] = v
 	}
 
+	prompt := request.Prompt
+	resumeSessionID, resumePrompt, resume := contracts.ResumeSessionFromRequest(request)
+	if resume {
+		prompt = resumePrompt
+	}
+
 	startReq := contracts.TaskSessionStartRequest{
 		TaskID:   request.TaskID,
 		RepoRoot: request.RepoRoot,
+		Env:      request.Env,
 		Metadata: metadata,
 		// Pass the prompt as a CLI argument so claude processes it immediately
 		// without waiting for stdin input.
-		Command: buildClaudeArgs(request.Model, request.Prompt),
+		Command: buildClaudeArgs(request.Model, prompt, resumeSessionID),
 	}
 
 	session, err := a.runtime.Start(runCtx, startReq)
@@ -79,7 +92,7 @@ func (a *SessionRunnerAdapter) Run(ctx context.Context, request contracts.Runner
 	}
 
 	execReq := contracts.TaskSessionExecuteRequest{
-		Prompt:    request.Prompt,
+		Prompt:    prompt,
 		Model:     request.Model,
 		Mode:      request.Mode,
 		Metadata:  request.Metadata,
@@ -107,10 +120,13 @@ func (a *SessionRunnerAdapter) Run(ctx context.Context, request contracts.Runner
 }
 
 func buildSessionExtras(request contracts.RunnerRequest, result contracts.RunnerResult, logPath string) map[string]string {
+	extras := map[string]string{}
+	if sessionID := sessionIDFromLog(logPath); sessionID != "" {
+		extras[contracts.SessionIDArtifactKey] = sessionID
+	}
 	if request.Mode != contracts.RunnerModeReview {
-		return nil
+		return extras
 	}
-	extras := map[string]string{}
 	if verdict, ok := structuredReviewVerdict(logPath); ok {
 		extras["review_verdict"] = verdict
 		if verdict == "fail" {
@@ -121,3 +137,27 @@ func buildSessionExtras(request contracts.RunnerRequest, result contracts.Runner
 	}
 	return extras
 }
+
+// sessionIDFromLog returns the claude session id reported by the stream-json
+// events in the run log, so an interrupted run can be continued with --resume.
+func sessionIDFromLog(logPath string) string {
+	if strings.TrimSpace(logPath) == "" {
+		return ""
+	}
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		var event struct {
+			SessionID string `json:"session_id"`
+		}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			continue
+		}
+		if id := strings.TrimSpace(event.SessionID); id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -482,6 +482,11 @@ func (s *StdinTaskSession) scanForResult(ctx context.Context, req contracts.Task
 					Message:   text,
 					Timestamp: time.Now().UTC(),
 				})
+				s.emitToolCalls(ctx, req.EventSink, toolCallsFromMessage(line))
+			}
+		case "user":
+			if req.EventSink != nil {
+				s.emitToolCalls(ctx, req.EventSink, toolCallsFromMessage(line))
 			}
 		case "tool_use":
 			if err := s.handleToolUse(ctx, msg.ID, msg.Name, req); err != nil {
@@ -508,6 +513,25 @@ func stdinExtractAssistantText(content []struct {
 	return strings.Join(parts, "")
 }
 
+// emitToolCalls reports tool_use and tool_result content blocks as progress
+// events carrying tool call metadata.
+func (s *StdinTaskSession) emitToolCalls(ctx context.Context, sink contracts.TaskSessionEventSink, calls []contracts.ToolCall) {
+	for _, call := range calls {
+		message := contracts.DescribeToolCall(call, "")
+		if message == "" {
+			message = "tool " + call.Status
+		}
+		_ = sink.HandleEvent(ctx, contracts.TaskSessionEvent{
+			Type:      contracts.TaskSessionEventTypeProgress,
+			SessionID: s.id,
+			Message:   message,
+			Timestamp: time.Now().UTC(),
+			Metadata:  contracts.ApplyToolCallMetadata(nil, call),
+			Progress:  &contracts.TaskSessionProgressEvent{Phase: "tool_call"},
+		})
+	}
+}
+
 // handleToolUse always approves tool_use requests by writing "y\n" to stdin.
 // ApprovalHandler is called for observation only — its decision is not honoured
 // because this runtime auto-approves all tool calls per its contract.
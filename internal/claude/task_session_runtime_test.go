@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"slices"
 	"strings"
 	"testing"
@@ -122,6 +124,47 @@ func TestStdinTaskSession_Execute_ApprovesToolUse(t *testing.T) {
 	}
 }
 
+func TestStdinTaskSession_Execute_EmitsToolCallTelemetry(t *testing.T) {
+	stdinR, stdinW := io.Pipe()
+	stdoutR, stdoutW := io.Pipe()
+	go io.Copy(io.Discard, stdinR) //nolint:errcheck
+	sess := newTestSession(stdinW, stdoutR)
+	go func() {
+		_, _ = fmt.Fprintln(stdoutW, `{"type":"system","subtype":"init"}`)
+		_, _ = fmt.Fprintln(stdoutW, `{"type":"assistant","message":{"content":[{"type":"tool_use","id":"toolu_1","name":"Edit","input":{"file_path":"internal/agent/loop.go","old_string":"a","new_string":"b"}}]}}`)
+		_, _ = fmt.Fprintln(stdoutW, `{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"toolu_1","is_error":true}]}}`)
+		_, _ = fmt.Fprintln(stdoutW, `{"type":"result","subtype":"success"}`)
+		_ = stdoutW.Close()
+	}()
+
+	var progress []contracts.RunnerProgress
+	sink := contracts.TaskSessionEventSinkFunc(func(_ context.Context, e contracts.TaskSessionEvent) error {
+		if e.Type == contracts.TaskSessionEventTypeProgress {
+			if normalized, ok := contracts.NormalizeTaskSessionEvent(e); ok {
+				progress = append(progress, normalized)
+			}
+		}
+		return nil
+	})
+	if err := sess.Execute(t.Context(), contracts.TaskSessionExecuteRequest{Prompt: "p", EventSink: sink}); err != nil {
+		t.Fatalf("Execute() = %v; want nil", err)
+	}
+	if len(progress) != 2 {
+		t.Fatalf("got %d tool call events; want 2", len(progress))
+	}
+	started, ok := contracts.ToolCallFromMetadata(progress[0].Metadata)
+	if !ok || started.ID != "toolu_1" || started.Kind != "edit" || started.Status != "in_progress" || len(started.Locations) != 1 || started.Locations[0] != "internal/agent/loop.go" {
+		t.Fatalf("unexpected started tool call %#v", started)
+	}
+	if progress[0].Message != "editing internal/agent/loop.go" {
+		t.Errorf("started message = %q", progress[0].Message)
+	}
+	finished, ok := contracts.ToolCallFromMetadata(progress[1].Metadata)
+	if !ok || finished.ID != "toolu_1" || finished.Status != "failed" {
+		t.Fatalf("unexpected finished tool call %#v", finished)
+	}
+}
+
 // T12: Cancel sends Stop; Teardown force kills.
 func TestStdinTaskSession_Cancel_StopsProcess(t *testing.T) {
 	cmd := exec.Command("cat") // blocks waiting for stdin
@@ -300,7 +343,7 @@ func TestOsStdinProcess_Stop_NoErrorWhenAlreadyDone(t *testing.T) {
 // deadlock that occurs in --print mode when reading from stdin.
 func TestBuildClaudeArgs_RequiredFlagsAndPrompt(t *testing.T) {
 	prompt := "do the thing"
-	args := buildClaudeArgs("claude-test-model", prompt)
+	args := buildClaudeArgs("claude-test-model", prompt, "")
 	for _, required := range []string{"--print", "--output-format", "stream-json", "--dangerously-skip-permissions"} {
 		if !slices.Contains(args, required) {
 			t.Errorf("buildClaudeArgs missing %q; got %v", required, args)
@@ -316,15 +359,45 @@ func TestBuildClaudeArgs_RequiredFlagsAndPrompt(t *testing.T) {
 }
 
 func TestBuildClaudeArgs_NoModelFlag(t *testing.T) {
-	args := buildClaudeArgs("", "hello")
+	args := buildClaudeArgs("", "hello", "")
 	if slices.Contains(args, "--model") {
 		t.Errorf("expected no --model flag for empty model; got %v", args)
 	}
+	if slices.Contains(args, "--resume") {
+		t.Errorf("expected no --resume flag without a session; got %v", args)
+	}
 	if args[len(args)-1] != "hello" {
 		t.Errorf("prompt not last arg; got %v", args)
 	}
 }
 
+func TestBuildClaudeArgs_ResumeSession(t *testing.T) {
+	args := buildClaudeArgs("", "continue", "session-abc")
+	idx := slices.Index(args, "--resume")
+	if idx == -1 || idx+1 >= len(args) || args[idx+1] != "session-abc" {
+		t.Errorf("expected --resume session-abc in args; got %v", args)
+	}
+	if args[len(args)-1] != "continue" {
+		t.Errorf("prompt not last arg; got %v", args)
+	}
+}
+
+func TestSessionIDFromLog(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "claude.jsonl")
+	content := "not json\n" +
+		`{"type":"system","subtype":"init","session_id":"session-abc"}` + "\n" +
+		`{"type":"result","subtype":"success","session_id":"session-abc"}` + "\n"
+	if err := os.WriteFile(logPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+	if got := sessionIDFromLog(logPath); got != "session-abc" {
+		t.Fatalf("expected session-abc, got %q", got)
+	}
+	if got := sessionIDFromLog(filepath.Join(t.TempDir(), "missing.jsonl")); got != "" {
+		t.Fatalf("expected no session for missing log, got %q", got)
+	}
+}
+
 // Execute must close stdin so claude does not block waiting for more input.
 func TestStdinTaskSession_Execute_CloseStdinAfterPrompt(t *testing.T) {
 	stdinR, stdinW := io.Pipe()
@@ -0,0 +1,105 @@
+package claude
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// claudeToolKinds maps Claude Code tool names to ACP tool kinds.
+var claudeToolKinds = map[string]string{
+	"read":         "read",
+	"edit":         "edit",
+	"multiedit":    "edit",
+	"write":        "edit",
+	"notebookedit": "edit",
+	"bash":         "execute",
+	"grep":         "search",
+	"glob":         "search",
+	"ls":           "search",
+	"webfetch":     "fetch",
+	"websearch":    "fetch",
+	"todowrite":    "think",
+	"task":         "think",
+}
+
+type streamContentBlock struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	IsError   bool            `json:"is_error"`
+}
+
+type toolUseInput struct {
+	FilePath     string `json:"file_path"`
+	NotebookPath string `json:"notebook_path"`
+	Path         string `json:"path"`
+	Command      string `json:"command"`
+	Pattern      string `json:"pattern"`
+	URL          string `json:"url"`
+	Query        string `json:"query"`
+	Description  string `json:"description"`
+}
+
+// toolCallsFromMessage extracts tool_use (started) and tool_result (finished)
+// blocks from one stream-json assistant or user message line.
+func toolCallsFromMessage(line []byte) []contracts.ToolCall {
+	var msg struct {
+		Message struct {
+			Content []streamContentBlock `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return nil
+	}
+	calls := []contracts.ToolCall{}
+	for _, block := range msg.Message.Content {
+		switch block.Type {
+		case "tool_use":
+			calls = append(calls, toolCallFromToolUse(block))
+		case "tool_result":
+			status := contracts.ToolCallStatusCompleted
+			if block.IsError {
+				status = contracts.ToolCallStatusFailed
+			}
+			if id := strings.TrimSpace(block.ToolUseID); id != "" {
+				calls = append(calls, contracts.ToolCall{ID: id, Status: status})
+			}
+		}
+	}
+	return calls
+}
+
+func toolCallFromToolUse(block streamContentBlock) contracts.ToolCall {
+	name := strings.TrimSpace(block.Name)
+	kind, ok := claudeToolKinds[strings.ToLower(name)]
+	if !ok {
+		kind = "other"
+	}
+	call := contracts.ToolCall{
+		ID:     strings.TrimSpace(block.ID),
+		Kind:   kind,
+		Title:  name,
+		Status: contracts.ToolCallStatusInProgress,
+	}
+	var input toolUseInput
+	if len(block.Input) == 0 || json.Unmarshal(block.Input, &input) != nil {
+		return call
+	}
+	for _, path := range []string{input.FilePath, input.NotebookPath, input.Path} {
+		if trimmed := strings.TrimSpace(path); trimmed != "" {
+			call.Locations = append(call.Locations, trimmed)
+			break
+		}
+	}
+	for _, detail := range []string{input.Command, input.Pattern, input.URL, input.Query, input.Description} {
+		if trimmed := strings.TrimSpace(detail); trimmed != "" {
+			call.Title = trimmed
+			break
+		}
+	}
+	return call
+}
@@ -170,6 +170,9 @@ func NormalizeAppServerNotification(message contracts.JSONRPCMessage, mode contr
 			lookupString(params, "title", "name"),
 			defaultProgressMessage(method, itemType),
 		)
+		if call, ok := toolCallFromItem(method, itemID, itemType, item); ok {
+			event.Metadata = contracts.ApplyToolCallMetadata(event.Metadata, call)
+		}
 		event.Progress = &contracts.TaskSessionProgressEvent{Phase: itemType}
 		return event, nil, true
 	}
@@ -351,6 +354,64 @@ func coalesceMessage(values ...string) string {
 	return ""
 }
 
+// codexToolKinds maps app-server item types (camelCase or snake_case) to ACP
+// tool kinds. Items that are not tool invocations are not listed.
+var codexToolKinds = map[string]string{
+	"commandexecution": "execute",
+	"filechange":       "edit",
+	"mcptoolcall":      "other",
+	"websearch":        "fetch",
+}
+
+func toolCallFromItem(method string, itemID string, itemType string, item map[string]any) (contracts.ToolCall, bool) {
+	kind, ok := codexToolKinds[strings.ToLower(strings.ReplaceAll(itemType, "_", ""))]
+	if !ok || itemID == "" {
+		return contracts.ToolCall{}, false
+	}
+	call := contracts.ToolCall{ID: itemID, Kind: kind, Status: codexToolStatus(method, lookupString(item, "status"))}
+	switch kind {
+	case "execute":
+		call.Title = lookupString(item, "command")
+		if call.Title == "" {
+			call.Title = strings.Join(stringSlice(lookupSlice(item, "command")), " ")
+		}
+	case "edit":
+		for _, raw := range lookupSlice(item, "changes") {
+			if change, ok := raw.(map[string]any); ok {
+				if path := lookupString(change, "path"); path != "" {
+					call.Locations = append(call.Locations, path)
+				}
+			}
+		}
+	case "fetch":
+		call.Title = lookupString(item, "query", "url")
+	default:
+		call.Title = strings.Trim(lookupString(item, "server")+"/"+lookupString(item, "tool"), "/")
+	}
+	if call.Title == "" {
+		call.Title = lookupString(item, "title", "name")
+	}
+	return call, true
+}
+
+func codexToolStatus(method string, status string) string {
+	switch strings.ToLower(strings.ReplaceAll(status, "_", "")) {
+	case "inprogress":
+		return contracts.ToolCallStatusInProgress
+	case "completed":
+		return contracts.ToolCallStatusCompleted
+	case "failed", "declined":
+		return contracts.ToolCallStatusFailed
+	}
+	switch {
+	case strings.HasSuffix(method, "/started"):
+		return contracts.ToolCallStatusInProgress
+	case strings.HasSuffix(method, "/completed"):
+		return contracts.ToolCallStatusCompleted
+	}
+	return ""
+}
+
 func normalizeItemType(raw string) string {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" {
@@ -41,7 +41,7 @@ func (a *AppServerRunnerAdapter) Run(ctx context.Context, request contracts.Runn
 	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
 		return contracts.RunnerResult{}, err
 	}
-	logFile, err := os.Create(logPath)
+	logFile, err := contracts.CreateRunnerLog(logPath, request.Secrets)
 	if err != nil {
 		return contracts.RunnerResult{}, err
 	}
@@ -54,6 +54,7 @@ func (a *AppServerRunnerAdapter) Run(ctx context.Context, request contracts.Runn
 		TaskID:   request.TaskID,
 		Backend:  "codex",
 		RepoRoot: request.RepoRoot,
+		Env:      request.Env,
 		Metadata: request.Metadata,
 	})
 	if err != nil {
@@ -107,21 +107,21 @@ func (a *CLIRunnerAdapter) Run(ctx context.Context, request contracts.RunnerRequ
 		return contracts.RunnerResult{}, err
 	}
 
-	stdoutFile, err := os.Create(logPath)
+	stdoutFile, err := contracts.CreateRunnerLog(logPath, request.Secrets)
 	if err != nil {
 		return contracts.RunnerResult{}, err
 	}
 	defer stdoutFile.Close()
 
 	stderrPath := contracts.BackendLogSidecarPath(logPath, contracts.BackendLogStderr)
-	stderrFile, err := os.Create(stderrPath)
+	stderrFile, err := contracts.CreateRunnerLog(stderrPath, request.Secrets)
 	if err != nil {
 		return contracts.RunnerResult{}, err
 	}
 	defer stderrFile.Close()
 
 	protocolPath := contracts.BackendLogSidecarPath(logPath, contracts.BackendLogProtocolTrace)
-	protocolFile, err := os.Create(protocolPath)
+	protocolFile, err := contracts.CreateRunnerLog(protocolPath, request.Secrets)
 	if err != nil {
 		return contracts.RunnerResult{}, err
 	}
@@ -132,10 +132,11 @@ func (a *CLIRunnerAdapter) Run(ctx context.Context, request contracts.RunnerRequ
 
 	var completion *AppServerCompletion
 	var runErr error
+	threadID := ""
 	if a.runner != nil {
 		runErr, completion = a.runLegacyLineMode(runCtx, request, stdoutFile, stderrFile, protocolFile)
 	} else {
-		runErr, completion = a.runAppServerMode(runCtx, request, stdoutFile, stderrFile, protocolFile)
+		runErr, completion, threadID = a.runAppServerMode(runCtx, request, stdoutFile, stderrFile, protocolFile)
 	}
 	runErr = contracts.FinalizeRunError(runCtx, runErr)
 
@@ -146,6 +147,9 @@ func (a *CLIRunnerAdapter) Run(ctx context.Context, request contracts.RunnerRequ
 	hasCompletion := completion != nil
 	hasCompletionVerdict := completion != nil && completion.HasReviewVerdict
 	result.Artifacts = buildRunnerArtifacts(request, result)
+	if threadID != "" {
+		result.Artifacts[contracts.SessionIDArtifactKey] = threadID
+	}
 	ApplyAppServerCompletion(&result, completion)
 	if result.Status == contracts.RunnerResultCompleted && request.Mode == contracts.RunnerModeReview && (!hasCompletion || !hasCompletionVerdict) {
 		result.ReviewReady = hasStructuredPassVerdict(logPath)
@@ -243,14 +247,14 @@ func nonNilAppServerStarter(starter AppServerStarter) AppServerStarter {
 	return appServerStarterFunc(startAppServerProcess)
 }
 
-func (a *CLIRunnerAdapter) runLegacyLineMode(ctx context.Context, request contracts.RunnerRequest, stdoutFile *os.File, stderrFile *os.File, protocolFile *os.File) (error, *AppServerCompletion) {
+func (a *CLIRunnerAdapter) runLegacyLineMode(ctx context.Context, request contracts.RunnerRequest, stdoutFile io.Writer, stderrFile io.Writer, protocolFile io.Writer) (error, *AppServerCompletion) {
 	var completionMu sync.Mutex
 	var completion *AppServerCompletion
 
 	emitProgress := func(source string, line string) {
 		if message, ok := decodeJSONRPCNotification(line); ok {
 			completionMu.Lock()
-			_, _ = protocolFile.WriteString(strings.TrimRight(line, "\r") + "\n")
+			_, _ = io.WriteString(protocolFile, strings.TrimRight(line, "\r")+"\n")
 			progress, nextCompletion, ok := RunnerProgressFromAppServerNotification(message, request.Mode)
 			if ok && request.OnProgress != nil {
 				request.OnProgress(progress)
@@ -281,6 +285,7 @@ func (a *CLIRunnerAdapter) runLegacyLineMode(ctx context.Context, request contra
 	runErr := a.runner.Run(ctx, CommandSpec{
 		Binary: a.binary,
 		Args:   a.buildArgs(request),
+		Env:    contracts.RunnerEnv(request.Env),
 		Dir:    request.RepoRoot,
 		Stdout: stdoutWriter,
 		Stderr: stderrWriter,
@@ -293,15 +298,16 @@ func (a *CLIRunnerAdapter) runLegacyLineMode(ctx context.Context, request contra
 	return runErr, completion
 }
 
-func (a *CLIRunnerAdapter) runAppServerMode(ctx context.Context, request contracts.RunnerRequest, stdoutFile *os.File, stderrFile *os.File, protocolFile *os.File) (runErr error, completion *AppServerCompletion) {
+func (a *CLIRunnerAdapter) runAppServerMode(ctx context.Context, request contracts.RunnerRequest, stdoutFile io.Writer, stderrFile io.Writer, protocolFile io.Writer) (runErr error, completion *AppServerCompletion, threadID string) {
 	spec := CommandSpec{
 		Binary: a.binary,
 		Args:   a.buildArgs(request),
+		Env:    contracts.RunnerEnv(request.Env),
 		Dir:    request.RepoRoot,
 	}
 	proc, err := nonNilAppServerStarter(a.starter).Start(ctx, spec)
 	if err != nil {
-		return err, nil
+		return err, nil, ""
 	}
 	waitDone := make(chan error, 1)
 	go func() {
@@ -333,7 +339,6 @@ func (a *CLIRunnerAdapter) runAppServerMode(ctx context.Context, request contrac
 	writer := proc.Stdin()
 
 	nextID := 1
-	threadID := ""
 	turnID := ""
 	streamCompletion := &AppServerCompletion{}
 	defer func() {
@@ -391,32 +396,18 @@ func (a *CLIRunnerAdapter) runAppServerMode(ctx context.Context, request contrac
 			"experimentalApi": true,
 		},
 	}); err != nil {
-		return err, completion
+		return err, completion, threadID
 	}
 	if err := sendJSONRPCMessage(writer, contracts.JSONRPCMessage{
 		JSONRPC: "2.0",
 		Method:  "initialized",
 	}); err != nil {
-		return err, completion
+		return err, completion, threadID
 	}
 
-	threadResp, err := call("thread/start", map[string]any{
-		"approvalPolicy": "never",
-		"cwd":            strings.TrimSpace(request.RepoRoot),
-		"ephemeral":      true,
-		"model":          strings.TrimSpace(request.Model),
-		"sandbox":        "danger-full-access",
-		"personality":    "pragmatic",
-	})
+	threadID, prompt, err := a.openAppServerThread(call, request)
 	if err != nil {
-		return err, completion
-	}
-	threadID = lookupString(lookupMap(threadResp.Result, "thread"), "id")
-	if threadID == "" {
-		threadID = lookupString(threadResp.Result, "threadId", "thread_id")
-	}
-	if threadID == "" {
-		return errors.New("codex app-server thread/start response missing thread id"), completion
+		return err, completion, threadID
 	}
 
 	turnResp, err := call("turn/start", map[string]any{
@@ -424,19 +415,19 @@ func (a *CLIRunnerAdapter) runAppServerMode(ctx context.Context, request contrac
 		"input": []map[string]any{
 			{
 				"type": "text",
-				"text": strings.TrimSpace(request.Prompt),
+				"text": prompt,
 			},
 		},
 	})
 	if err != nil {
-		return err, completion
+		return err, completion, threadID
 	}
 	turnID = lookupString(lookupMap(turnResp.Result, "turn"), "id")
 
 	for completion == nil {
 		msg, err := a.readAppServerMessage(ctx, reader, stdoutFile, protocolFile)
 		if err != nil {
-			return err, completion
+			return err, completion, threadID
 		}
 		threadID, turnID = trackAppServerLifecycle(msg, threadID, turnID)
 		mergeAppServerStreamCompletion(streamCompletion, msg, request.Mode)
@@ -446,11 +437,64 @@ func (a *CLIRunnerAdapter) runAppServerMode(ctx context.Context, request contrac
 			completion = nextCompletion
 		}
 		if handleErr != nil {
-			return handleErr, completion
+			return handleErr, completion, threadID
+		}
+	}
+
+	return nil, completion, threadID
+}
+
+// openAppServerThread starts the thread the turn runs in and returns the
+// prompt to send. A resume request continues the previous thread with
+// thread/resume and falls back to a new thread when that fails. Threads stay
+// ephemeral unless the loop may resume them later.
+func (a *CLIRunnerAdapter) openAppServerThread(call func(string, map[string]any) (contracts.JSONRPCMessage, error), request contracts.RunnerRequest) (string, string, error) {
+	if resumeID, resumePrompt, ok := contracts.ResumeSessionFromRequest(request); ok {
+		resp, err := call("thread/resume", map[string]any{
+			"threadId":       resumeID,
+			"approvalPolicy": "never",
+			"cwd":            strings.TrimSpace(request.RepoRoot),
+			"model":          strings.TrimSpace(request.Model),
+			"sandbox":        "danger-full-access",
+		})
+		if err == nil {
+			if threadID := appServerThreadID(resp); threadID != "" {
+				return threadID, resumePrompt, nil
+			}
+			return resumeID, resumePrompt, nil
+		}
+		if request.OnProgress != nil {
+			request.OnProgress(contracts.RunnerProgress{
+				Type:      string(contracts.EventTypeRunnerWarning),
+				Message:   fmt.Sprintf("codex thread/resume %s failed, starting a new thread: %v", resumeID, err),
+				Timestamp: a.now().UTC(),
+			})
 		}
 	}
 
-	return nil, completion
+	threadResp, err := call("thread/start", map[string]any{
+		"approvalPolicy": "never",
+		"cwd":            strings.TrimSpace(request.RepoRoot),
+		"ephemeral":      !contracts.ResumableSessionRequested(request),
+		"model":          strings.TrimSpace(request.Model),
+		"sandbox":        "danger-full-access",
+		"personality":    "pragmatic",
+	})
+	if err != nil {
+		return "", "", err
+	}
+	threadID := appServerThreadID(threadResp)
+	if threadID == "" {
+		return "", "", errors.New("codex app-server thread/start response missing thread id")
+	}
+	return threadID, strings.TrimSpace(request.Prompt), nil
+}
+
+func appServerThreadID(resp contracts.JSONRPCMessage) string {
+	if threadID := lookupString(lookupMap(resp.Result, "thread"), "id"); threadID != "" {
+		return threadID
+	}
+	return lookupString(resp.Result, "threadId", "thread_id")
 }
 
 func trackAppServerLifecycle(message contracts.JSONRPCMessage, threadID string, turnID string) (string, string) {
@@ -511,7 +555,7 @@ func shutdownAppServerProcess(proc appServerProcess, writer io.WriteCloser, wait
 	}
 }
 
-func (a *CLIRunnerAdapter) readAppServerMessage(ctx context.Context, reader *jsonRPCPayloadReader, stdoutFile *os.File, protocolFile *os.File) (contracts.JSONRPCMessage, error) {
+func (a *CLIRunnerAdapter) readAppServerMessage(ctx context.Context, reader *jsonRPCPayloadReader, stdoutFile io.Writer, protocolFile io.Writer) (contracts.JSONRPCMessage, error) {
 	type result struct {
 		payload []byte
 		err     error
@@ -538,8 +582,8 @@ func (a *CLIRunnerAdapter) readAppServerMessage(ctx context.Context, reader *jso
 	if text == "" {
 		return contracts.JSONRPCMessage{}, io.EOF
 	}
-	_, _ = stdoutFile.WriteString(text + "\n")
-	_, _ = protocolFile.WriteString(text + "\n")
+	_, _ = io.WriteString(stdoutFile, text+"\n")
+	_, _ = io.WriteString(protocolFile, text+"\n")
 	var message contracts.JSONRPCMessage
 	if err := json.Unmarshal([]byte(text), &message); err != nil {
 		return contracts.JSONRPCMessage{}, err
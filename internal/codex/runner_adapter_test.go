@@ -128,6 +128,57 @@ func TestNormalizeAppServerNotificationMapsLifecycleItemApprovalAndProgress(t *t
 				}
 			},
 		},
+		{
+			name: "file change item carries tool call metadata",
+			message: contracts.JSONRPCMessage{
+				Method: "item/completed",
+				Params: map[string]any{
+					"threadId": "thread-1",
+					"turnId":   "turn-2",
+					"item": map[string]any{
+						"id":      "item-4",
+						"type":    "fileChange",
+						"status":  "completed",
+						"changes": []any{map[string]any{"path": "/repo/internal/agent/loop.go", "kind": "update"}},
+					},
+				},
+			},
+			mode:         contracts.RunnerModeImplement,
+			wantType:     contracts.TaskSessionEventTypeProgress,
+			wantProgress: string(contracts.EventTypeRunnerProgress),
+			assert: func(t *testing.T, event contracts.TaskSessionEvent, progress contracts.RunnerProgress) {
+				t.Helper()
+				call, ok := contracts.ToolCallFromMetadata(progress.Metadata)
+				if !ok || call.ID != "item-4" || call.Kind != "edit" || call.Status != "completed" || len(call.Locations) != 1 || call.Locations[0] != "/repo/internal/agent/loop.go" {
+					t.Fatalf("expected file change tool call metadata, got %#v", progress.Metadata)
+				}
+			},
+		},
+		{
+			name: "command item carries tool call metadata",
+			message: contracts.JSONRPCMessage{
+				Method: "item/started",
+				Params: map[string]any{
+					"threadId": "thread-1",
+					"item": map[string]any{
+						"id":      "item-5",
+						"type":    "commandExecution",
+						"command": "go test ./...",
+						"status":  "inProgress",
+					},
+				},
+			},
+			mode:         contracts.RunnerModeImplement,
+			wantType:     contracts.TaskSessionEventTypeProgress,
+			wantProgress: string(contracts.EventTypeRunnerProgress),
+			assert: func(t *testing.T, event contracts.TaskSessionEvent, progress contracts.RunnerProgress) {
+				t.Helper()
+				call, ok := contracts.ToolCallFromMetadata(progress.Metadata)
+				if !ok || call.Kind != "execute" || call.Title != "go test ./..." || call.Status != "in_progress" {
+					t.Fatalf("expected command tool call metadata, got %#v", progress.Metadata)
+				}
+			},
+		},
 		{
 			name: "approval request",
 			message: contracts.JSONRPCMessage{
@@ -1230,6 +1281,106 @@ func TestCLIRunnerAdapterAppServerTreatsForcedKillAfterCompletionAsSuccess(t *te
 	}
 }
 
+func TestCLIRunnerAdapterAppServerResumesInterruptedThread(t *testing.T) {
+	repoRoot := t.TempDir()
+	harness := contracts.NewFakeStdioJSONRPCHarness()
+	t.Cleanup(func() {
+		_ = harness.Close()
+	})
+
+	clientWriter, clientReader := harness.ClientIO()
+	stderrReader, stderrWriter := io.Pipe()
+	waitCh := make(chan error, 1)
+	proc := &fakeAppServerProcess{
+		stdin:  clientWriter,
+		stdout: clientReader,
+		stderr: stderrReader,
+		waitCh: waitCh,
+	}
+	proc.killFn = func() error {
+		_ = stderrWriter.Close()
+		_ = harness.Close()
+		waitCh <- errors.New("signal: killed")
+		return nil
+	}
+
+	adapter := NewCLIRunnerAdapter("codex-bin", nil)
+	adapter.starter = appServerStarterFunc(func(_ context.Context, _ CommandSpec) (appServerProcess, error) {
+		return proc, nil
+	})
+
+	serverDone := make(chan error, 1)
+	go func() {
+		defer close(serverDone)
+		expect := func(method string) (contracts.JSONRPCMessage, error) {
+			msg, err := harness.ReadMessage(context.Background())
+			if err != nil {
+				return msg, err
+			}
+			if msg.Method != method {
+				return msg, fmt.Errorf("expected %s, got %q", method, msg.Method)
+			}
+			return msg, nil
+		}
+
+		msg, err := expect("initialize")
+		if err == nil {
+			err = harness.SendMessage(contracts.JSONRPCMessage{JSONRPC: "2.0", ID: msg.ID, Result: map[string]any{"protocolVersion": 2}})
+		}
+		if err == nil {
+			_, err = expect("initialized")
+		}
+		if err == nil {
+			msg, err = expect("thread/resume")
+		}
+		if err == nil && msg.Params["threadId"] != "thread-9" {
+			err = fmt.Errorf("expected thread/resume of thread-9, got %#v", msg.Params)
+		}
+		if err == nil {
+			err = harness.SendMessage(contracts.JSONRPCMessage{JSONRPC: "2.0", ID: msg.ID, Result: map[string]any{"thread": map[string]any{"id": "thread-9"}}})
+		}
+		if err == nil {
+			msg, err = expect("turn/start")
+		}
+		if err == nil && !strings.Contains(fmt.Sprint(msg.Params["input"]), "continue where you stopped") {
+			err = fmt.Errorf("expected resume prompt in turn/start, got %#v", msg.Params["input"])
+		}
+		if err == nil {
+			err = harness.SendMessage(contracts.JSONRPCMessage{JSONRPC: "2.0", ID: msg.ID, Result: map[string]any{"turn": map[string]any{"id": "turn-1"}}})
+		}
+		if err == nil {
+			err = harness.SendMessage(contracts.JSONRPCMessage{JSONRPC: "2.0", Method: "turn/completed", Params: map[string]any{"threadId": "thread-9", "turnId": "turn-1", "stopReason": "end_turn"}})
+		}
+		if err == nil {
+			_, err = expect("shutdown")
+		}
+		serverDone <- err
+	}()
+
+	result, err := adapter.Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "t-app-server-resume",
+		RepoRoot: repoRoot,
+		Prompt:   "implement",
+		Mode:     contracts.RunnerModeImplement,
+		Metadata: map[string]string{
+			contracts.ResumeSessionIDMetadataKey: "thread-9",
+			contracts.ResumePromptMetadataKey:    "continue where you stopped",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := <-serverDone; err != nil {
+		t.Fatalf("app-server interaction failed: %v", err)
+	}
+	if result.Status != contracts.RunnerResultCompleted {
+		t.Fatalf("expected completed result, got %q (%s)", result.Status, result.Reason)
+	}
+	if result.Artifacts[contracts.SessionIDArtifactKey] != "thread-9" {
+		t.Fatalf("expected resumed thread id artifact, got %#v", result.Artifacts)
+	}
+}
+
 func TestCLIRunnerAdapterAppServerUsesStreamedReviewPassVerdictWhenCompletionLacksVerdict(t *testing.T) {
 	repoRoot := t.TempDir()
 	harness := contracts.NewFakeStdioJSONRPCHarness()
@@ -0,0 +1,135 @@
+package codingagents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// AmazonQAuth is how the Amazon Q Developer CLI authenticates. Profile and
+// Region select the AWS credentials its AWS tools use; StartURL and
+// SSORegion identify the IAM Identity Center instance of a Pro
+// subscription and are only used to tell the operator how to sign in.
+type AmazonQAuth struct {
+	Profile   string
+	Region    string
+	StartURL  string
+	SSORegion string
+}
+
+// AmazonQAuthFromConfig reads the aws_profile, aws_region, sso_start_url
+// and sso_region keys of a backend's config block.
+func AmazonQAuthFromConfig(config map[string]any) AmazonQAuth {
+	value := func(key string) string {
+		raw, _ := configValueString(config, key)
+		return raw
+	}
+	return AmazonQAuth{
+		Profile:   value("aws_profile"),
+		Region:    value("aws_region"),
+		StartURL:  value("sso_start_url"),
+		SSORegion: value("sso_region"),
+	}
+}
+
+// Env returns the environment entries that select the configured AWS
+// profile and region.
+func (a AmazonQAuth) Env() []string {
+	env := []string{}
+	if a.Profile != "" {
+		env = append(env, "AWS_PROFILE="+a.Profile)
+	}
+	if a.Region != "" {
+		env = append(env, "AWS_REGION="+a.Region, "AWS_DEFAULT_REGION="+a.Region)
+	}
+	return env
+}
+
+func (a AmazonQAuth) loginCommand(binary string) string {
+	if a.StartURL == "" {
+		return binary + " login"
+	}
+	command := binary + " login --license pro --identity-provider " + a.StartURL
+	if a.SSORegion != "" {
+		command += " --region " + a.SSORegion
+	}
+	return command
+}
+
+// AmazonQRunnerAdapter runs the Amazon Q Developer CLI (`q chat`) as a
+// command backend. Until it has seen the CLI signed in, each run first
+// checks with `q whoami`, so a missing login fails the task with the
+// command to fix it instead of hanging on a browser prompt.
+type AmazonQRunnerAdapter struct {
+	*GenericCLIRunnerAdapter
+	auth   AmazonQAuth
+	whoami func(context.Context, string, ...string) ([]byte, error)
+
+	mu       sync.Mutex
+	signedIn bool
+}
+
+func NewAmazonQRunnerAdapter(backend string, binary string, args []string, auth AmazonQAuth, runner CommandRunner) *AmazonQRunnerAdapter {
+	if strings.TrimSpace(binary) == "" {
+		binary = "q"
+	}
+	return &AmazonQRunnerAdapter{
+		GenericCLIRunnerAdapter: NewGenericCLIRunnerAdapter(backend, binary, args, runner).WithEnv(auth.Env()),
+		auth:                    auth,
+		whoami:                  runAgentHealthCommand,
+	}
+}
+
+func (a *AmazonQRunnerAdapter) Run(ctx context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if a == nil || a.GenericCLIRunnerAdapter == nil {
+		return contracts.RunnerResult{}, errors.New("nil amazon q runner adapter")
+	}
+	if err := a.ensureSignedIn(ctx); err != nil {
+		now := time.Now().UTC()
+		result := contracts.NormalizeBackendRunnerResult(now, now, request, err, nil)
+		result.Artifacts = contracts.BuildRunnerArtifacts(a.backend, request, result, nil)
+		return result, nil
+	}
+	return a.GenericCLIRunnerAdapter.Run(ctx, request)
+}
+
+// ensureSignedIn checks the sign-in until it succeeds once, so a login
+// done while yolo-agent is running is picked up by the next task.
+func (a *AmazonQRunnerAdapter) ensureSignedIn(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.signedIn {
+		return nil
+	}
+	if err := a.checkSignedIn(ctx); err != nil {
+		return err
+	}
+	a.signedIn = true
+	return nil
+}
+
+func (a *AmazonQRunnerAdapter) checkSignedIn(ctx context.Context) error {
+	checkCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	whoami := a.whoami
+	if whoami == nil {
+		whoami = runAgentHealthCommand
+	}
+	output, err := whoami(checkCtx, a.binary, "whoami")
+	if err == nil {
+		return nil
+	}
+	detail := strings.TrimSpace(ansiEscapePattern.ReplaceAllString(string(output), ""))
+	if detail == "" {
+		detail = err.Error()
+	}
+	return fmt.Errorf("amazon q is not signed in (%s); run `%s`", detail, a.auth.loginCommand(a.binary))
+}
@@ -0,0 +1,77 @@
+package codingagents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestAmazonQRunnerAdapterRunsSignedInCLIWithConfiguredAWSProfile(t *testing.T) {
+	var spec CommandSpec
+	runner := commandRunnerFunc(func(_ context.Context, got CommandSpec) error {
+		spec = got
+		fmt.Fprintln(got.Stdout, "\x1b[32m> \x1b[0mLooks good.")
+		fmt.Fprintln(got.Stdout, "\x1b[32m> \x1b[0mREVIEW_VERDICT: pass")
+		return nil
+	})
+	auth := AmazonQAuthFromConfig(map[string]any{"aws_profile": "dev-sso", "aws_region": "eu-west-1"})
+	adapter := NewAmazonQRunnerAdapter("amazonq", "", []string{"chat", "--no-interactive", "--model", "{{model}}", "{{prompt}}"}, auth, runner)
+	whoamiCalls := 0
+	adapter.whoami = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		whoamiCalls++
+		if name != "q" || strings.Join(args, " ") != "whoami" {
+			t.Fatalf("unexpected sign-in check %s %v", name, args)
+		}
+		return []byte("Logged in with IAM Identity Center\n"), nil
+	}
+
+	for run := 0; run < 2; run++ {
+		result, err := adapter.Run(context.Background(), contracts.RunnerRequest{
+			TaskID:   "task-1",
+			Mode:     contracts.RunnerModeReview,
+			Model:    "claude-sonnet-4",
+			Prompt:   "Review the change",
+			RepoRoot: t.TempDir(),
+		})
+		if err != nil {
+			t.Fatalf("run %d failed: %v", run, err)
+		}
+		if result.Status != contracts.RunnerResultCompleted || !result.ReviewReady || result.Artifacts["review_verdict"] != "pass" {
+			t.Fatalf("run %d: expected passing review, got %#v", run, result)
+		}
+	}
+	if whoamiCalls != 1 {
+		t.Fatalf("expected one sign-in check, got %d", whoamiCalls)
+	}
+	if strings.Join(spec.Env, " ") != "AWS_PROFILE=dev-sso AWS_REGION=eu-west-1 AWS_DEFAULT_REGION=eu-west-1" {
+		t.Fatalf("unexpected env %v", spec.Env)
+	}
+	if strings.Join(spec.Args, " ") != "chat --no-interactive --model claude-sonnet-4 Review the change" {
+		t.Fatalf("unexpected args %v", spec.Args)
+	}
+}
+
+func TestAmazonQRunnerAdapterFailsWithLoginCommandWhenSignedOut(t *testing.T) {
+	runner := commandRunnerFunc(func(context.Context, CommandSpec) error {
+		t.Fatal("expected q chat not to run while signed out")
+		return nil
+	})
+	auth := AmazonQAuthFromConfig(map[string]any{"sso_start_url": "https://example.awsapps.com/start", "sso_region": "us-east-1"})
+	adapter := NewAmazonQRunnerAdapter("amazonq", "q", nil, auth, runner)
+	adapter.whoami = func(context.Context, string, ...string) ([]byte, error) {
+		return []byte("Not logged in\n"), errors.New("exit status 1")
+	}
+
+	result, err := adapter.Run(context.Background(), contracts.RunnerRequest{TaskID: "task-1", Mode: contracts.RunnerModeImplement, RepoRoot: t.TempDir()})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	want := "amazon q is not signed in (Not logged in); run `q login --license pro --identity-provider https://example.awsapps.com/start --region us-east-1`"
+	if result.Status != contracts.RunnerResultFailed || result.Reason != want {
+		t.Fatalf("expected sign-in failure %q, got %s %q", want, result.Status, result.Reason)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/egv/yolo-runner/v2/internal/distributed"
+	"github.com/egv/yolo-runner/v2/pkg/registry"
 	"gopkg.in/yaml.v3"
 )
 
@@ -40,6 +41,8 @@ type BackendDefinition struct {
 	DistributedCaps     []distributed.Capability `yaml:"distributed_capabilities" json:"distributed_capabilities"`
 	SupportedModels     []string                 `yaml:"supported_models" json:"supported_models"`
 	RequiredCredentials []string                 `yaml:"required_credentials" json:"required_credentials"`
+	MinVersion          string                   `yaml:"min_version" json:"min_version"`
+	VersionArgs         []string                 `yaml:"version_args" json:"version_args"`
 }
 
 type BackendCapabilityProfile struct {
@@ -79,6 +82,16 @@ func LoadCatalog(repoRoot string) (Catalog, error) {
 			return Catalog{}, err
 		}
 	}
+	// Backends registered through pkg/registry are selectable by name; a
+	// custom definition with the same name replaces the bare entry.
+	for _, name := range registry.Backends() {
+		if _, ok := catalog.Backend(name); ok {
+			continue
+		}
+		if err := catalog.add(BackendDefinition{Name: name, Adapter: name}); err != nil {
+			return Catalog{}, err
+		}
+	}
 
 	repoRoot = strings.TrimSpace(repoRoot)
 	if repoRoot == "" {
@@ -284,12 +297,14 @@ func validateBackendDefinition(definition BackendDefinition) error {
 		}
 	}
 	switch definition.Adapter {
-	case "opencode", "opencode-serve", "codex", "codex-app-server", "claude", "kimi", "command":
+	case "opencode", "opencode-serve", "opencode-server", "ollama", "amazonq", "codex", "codex-app-server", "claude", "kimi", "qwen", "acp", "command", "exec":
 	default:
-		return fmt.Errorf("unsupported adapter %q", definition.Adapter)
+		if _, ok := registry.Backend(definition.Adapter); !ok {
+			return fmt.Errorf("unsupported adapter %q", definition.Adapter)
+		}
 	}
-	if definition.Adapter == "command" && strings.TrimSpace(definition.Binary) == "" {
-		return fmt.Errorf("command adapter requires binary")
+	if (definition.Adapter == "command" || definition.Adapter == "acp" || definition.Adapter == "exec") && strings.TrimSpace(definition.Binary) == "" {
+		return fmt.Errorf("%s adapter requires binary", definition.Adapter)
 	}
 	for _, raw := range definition.SupportedModels {
 		trimmed := strings.TrimSpace(raw)
@@ -300,6 +315,9 @@ func validateBackendDefinition(definition BackendDefinition) error {
 			return fmt.Errorf("invalid supported model pattern %q", trimmed)
 		}
 	}
+	if minVersion := strings.TrimSpace(definition.MinVersion); minVersion != "" && !backendVersionPattern.MatchString(minVersion) {
+		return fmt.Errorf("invalid min_version %q (expected a version such as 1.2.0)", minVersion)
+	}
 	for _, capability := range definition.DistributedCaps {
 		normalized, ok := supportedDistributedCapability(capability)
 		if !ok {
@@ -382,6 +400,8 @@ func normalizeBackendDefinition(definition BackendDefinition) BackendDefinition
 	definition.RequiredCredentials = normalizeStringSlice(definition.RequiredCredentials)
 	definition.SupportedModels = normalizeStringSlice(definition.SupportedModels)
 	definition.DistributedCaps = normalizeDistributedCaps(definition.DistributedCaps)
+	definition.MinVersion = strings.TrimSpace(definition.MinVersion)
+	definition.VersionArgs = normalizeStringSlice(definition.VersionArgs)
 	return definition
 }
 
@@ -277,3 +277,29 @@ func TestCatalogBuiltinCodexCLIPreservesLegacyFallbackCapabilitiesOnly(t *testin
 		t.Fatalf("expected codex to advertise larger_model, got %#v", codex.DistributedCaps)
 	}
 }
+
+func TestCatalogACPAdapterRequiresBinary(t *testing.T) {
+	repoRoot := t.TempDir()
+	customDir := filepath.Join(repoRoot, ".yolo-runner", "coding-agents")
+	if err := os.MkdirAll(customDir, 0o755); err != nil {
+		t.Fatalf("create custom backend directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(customDir, "acp.yaml"), []byte("name: bare-acp\nadapter: acp\n"), 0o644); err != nil {
+		t.Fatalf("write custom backend definition: %v", err)
+	}
+	if _, err := LoadCatalog(repoRoot); err == nil || !strings.Contains(err.Error(), "acp adapter requires binary") {
+		t.Fatalf("expected missing binary error, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(customDir, "acp.yaml"), []byte("name: gemini-acp\nadapter: acp\nbinary: gemini\nargs:\n  - --experimental-acp\n"), 0o644); err != nil {
+		t.Fatalf("write custom backend definition: %v", err)
+	}
+	catalog, err := LoadCatalog(repoRoot)
+	if err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+	definition, ok := catalog.Backend("gemini-acp")
+	if !ok || definition.Adapter != "acp" || definition.Binary != "gemini" {
+		t.Fatalf("unexpected acp backend definition %#v", definition)
+	}
+}
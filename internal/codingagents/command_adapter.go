@@ -22,9 +22,13 @@ type CommandSpec struct {
 	Binary string
 	Args   []string
 	Env    []string
-	Dir    string
-	Stdout io.Writer
-	Stderr io.Writer
+	// Secrets holds the values of Env entries that are secrets, so a remote
+	// executor can keep them off command lines and manifests.
+	Secrets []string
+	Dir     string
+	Stdin   io.Reader
+	Stdout  io.Writer
+	Stderr  io.Writer
 }
 
 type CommandRunner interface {
@@ -52,6 +56,7 @@ type GenericCLIRunnerAdapter struct {
 	backend          string
 	binary           string
 	args             []string
+	env              []string
 	runner           CommandRunner
 	starter          CommandStarter
 	health           *BackendHealthConfig
@@ -62,7 +67,13 @@ type GenericCLIRunnerAdapter struct {
 	now              func() time.Time
 }
 
-var structuredReviewVerdictLinePattern = regexp.MustCompile(`(?i)^\s*REVIEW_VERDICT\s*:\s*(pass|fail)(?:\s*DONE)?\s*$`)
+// structuredReviewVerdictLinePattern tolerates a leading "> ", the reply
+// marker of chat CLIs such as Amazon Q.
+var structuredReviewVerdictLinePattern = regexp.MustCompile(`(?i)^\s*(?:>\s*)?REVIEW_VERDICT\s*:\s*(pass|fail)(?:\s*DONE)?\s*$`)
+
+// ansiEscapePattern matches terminal color and cursor sequences, which some
+// CLIs print even when stdout is not a terminal.
+var ansiEscapePattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
 
 func NewGenericCLIRunnerAdapter(backend string, binary string, args []string, runner CommandRunner) *GenericCLIRunnerAdapter {
 	if strings.TrimSpace(backend) == "" {
@@ -92,6 +103,15 @@ func (a *GenericCLIRunnerAdapter) WithStarter(starter CommandStarter) *GenericCL
 	return a
 }
 
+// WithEnv adds KEY=value entries to the environment the command runs with.
+func (a *GenericCLIRunnerAdapter) WithEnv(env []string) *GenericCLIRunnerAdapter {
+	if a == nil {
+		return nil
+	}
+	a.env = append([]string(nil), env...)
+	return a
+}
+
 func (a *GenericCLIRunnerAdapter) WithHealthConfig(cfg *BackendHealthConfig) *GenericCLIRunnerAdapter {
 	if a == nil {
 		return nil
@@ -135,14 +155,14 @@ func (a *GenericCLIRunnerAdapter) Run(ctx context.Context, request contracts.Run
 		return contracts.RunnerResult{}, err
 	}
 
-	stdoutFile, err := os.Create(logPath)
+	stdoutFile, err := contracts.CreateRunnerLog(logPath, request.Secrets)
 	if err != nil {
 		return contracts.RunnerResult{}, err
 	}
 	defer stdoutFile.Close()
 
 	stderrPath := contracts.BackendLogSidecarPath(logPath, contracts.BackendLogStderr)
-	stderrFile, err := os.Create(stderrPath)
+	stderrFile, err := contracts.CreateRunnerLog(stderrPath, request.Secrets)
 	if err != nil {
 		return contracts.RunnerResult{}, err
 	}
@@ -171,11 +191,13 @@ func (a *GenericCLIRunnerAdapter) Run(ctx context.Context, request contracts.Run
 	defer cancel()
 
 	spec := CommandSpec{
-		Binary: a.binary,
-		Args:   commandArgs,
-		Dir:    request.RepoRoot,
-		Stdout: stdoutWriter,
-		Stderr: stderrWriter,
+		Binary:  a.binary,
+		Args:    commandArgs,
+		Env:     append(append([]string(nil), a.env...), contracts.RunnerEnv(request.Env)...),
+		Secrets: request.Secrets,
+		Dir:     request.RepoRoot,
+		Stdout:  stdoutWriter,
+		Stderr:  stderrWriter,
 	}
 
 	var runErr error
@@ -217,18 +239,22 @@ func (a *GenericCLIRunnerAdapter) runManaged(ctx context.Context, spec CommandSp
 		},
 		GracePeriod: a.gracePeriod,
 	}
-	return supervisor.Run(ctx, func(ctx context.Context, proc SupervisedProcess) error {
-		if waitable, ok := proc.(interface{ WaitChan() <-chan error }); ok {
-			waitCh := waitable.WaitChan()
-			select {
-			case err := <-waitCh:
-				return err
-			case <-ctx.Done():
-				return ctx.Err()
-			}
+	return supervisor.Run(ctx, waitSupervisedProcess)
+}
+
+// waitSupervisedProcess waits for proc to exit or ctx to end, whichever
+// comes first.
+func waitSupervisedProcess(ctx context.Context, proc SupervisedProcess) error {
+	if waitable, ok := proc.(interface{ WaitChan() <-chan error }); ok {
+		waitCh := waitable.WaitChan()
+		select {
+		case err := <-waitCh:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
 		}
-		return proc.Wait()
-	})
+	}
+	return proc.Wait()
 }
 
 func (a *GenericCLIRunnerAdapter) waitUntilReady(ctx context.Context, proc SupervisedProcess) error {
@@ -301,7 +327,7 @@ func structuredReviewVerdict(logPath string) (string, bool) {
 	if err != nil {
 		return "", false
 	}
-	normalized := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(string(content))
+	normalized := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(ansiEscapePattern.ReplaceAllString(string(content), ""))
 	if normalized == "" {
 		return "", false
 	}
@@ -370,6 +396,7 @@ func runCommand(ctx context.Context, spec CommandSpec) error {
 	if len(spec.Env) > 0 {
 		cmd.Env = append(os.Environ(), spec.Env...)
 	}
+	cmd.Stdin = spec.Stdin
 	cmd.Stdout = spec.Stdout
 	cmd.Stderr = spec.Stderr
 	err = cmd.Run()
@@ -470,6 +497,7 @@ func startManagedCommand(ctx context.Context, spec CommandSpec) (SupervisedProce
 	if len(spec.Env) > 0 {
 		cmd.Env = append(os.Environ(), spec.Env...)
 	}
+	cmd.Stdin = spec.Stdin
 	cmd.Stdout = spec.Stdout
 	cmd.Stderr = spec.Stderr
 	if err := cmd.Start(); err != nil {
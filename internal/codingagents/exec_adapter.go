@@ -0,0 +1,256 @@
+package codingagents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// ExecProtocolVersion is sent with every exec request so an executable can
+// reject a protocol it does not understand.
+const ExecProtocolVersion = 1
+
+const (
+	ExecMessageProgress = "progress"
+	ExecMessageResult   = "result"
+)
+
+// ExecRequest is written to the executable's stdin as a single JSON
+// object, after which stdin is closed.
+type ExecRequest struct {
+	Protocol       int               `json:"protocol"`
+	Backend        string            `json:"backend"`
+	TaskID         string            `json:"task_id"`
+	ParentID       string            `json:"parent_id,omitempty"`
+	Mode           string            `json:"mode"`
+	Model          string            `json:"model,omitempty"`
+	Prompt         string            `json:"prompt"`
+	RepoRoot       string            `json:"repo_root"`
+	TimeoutSeconds int64             `json:"timeout_seconds,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+}
+
+// ExecMessage is one NDJSON line on the executable's stdout. Progress
+// messages become runner progress events; the last result message is the
+// run's result. Lines that are not messages are treated as runner output.
+type ExecMessage struct {
+	Type string `json:"type"`
+
+	// Event is the runner event type of a progress message, such as
+	// runner_output or runner_warning; it defaults to runner_progress.
+	Event    string            `json:"event,omitempty"`
+	Message  string            `json:"message,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	Status      string            `json:"status,omitempty"`
+	Reason      string            `json:"reason,omitempty"`
+	ReviewReady bool              `json:"review_ready,omitempty"`
+	Artifacts   map[string]string `json:"artifacts,omitempty"`
+}
+
+// ExecRunnerAdapter runs any executable that speaks the exec protocol:
+// request JSON on stdin, NDJSON progress and a final result on stdout.
+type ExecRunnerAdapter struct {
+	backend     string
+	binary      string
+	args        []string
+	runner      CommandRunner
+	starter     CommandStarter
+	gracePeriod time.Duration
+	now         func() time.Time
+}
+
+func NewExecRunnerAdapter(backend string, binary string, args []string, runner CommandRunner) *ExecRunnerAdapter {
+	if strings.TrimSpace(backend) == "" {
+		backend = "exec"
+	}
+	adapter := &ExecRunnerAdapter{
+		backend: strings.ToLower(strings.TrimSpace(backend)),
+		binary:  strings.TrimSpace(binary),
+		args:    append([]string(nil), normalizeStringSlice(args)...),
+		runner:  runner,
+		now:     time.Now,
+	}
+	if runner == nil {
+		adapter.starter = commandStarterFunc(startManagedCommand)
+	}
+	return adapter
+}
+
+func (a *ExecRunnerAdapter) Run(ctx context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if a == nil {
+		return contracts.RunnerResult{}, errors.New("nil exec runner adapter")
+	}
+	if strings.TrimSpace(a.binary) == "" {
+		return contracts.RunnerResult{}, errors.New("binary is required")
+	}
+	if a.runner == nil && a.starter == nil {
+		a.starter = commandStarterFunc(startManagedCommand)
+	}
+	if a.now == nil {
+		a.now = time.Now
+	}
+	request = requestWithBackend(request, a.backend)
+
+	payload, err := json.Marshal(newExecRequest(a.backend, request))
+	if err != nil {
+		return contracts.RunnerResult{}, err
+	}
+
+	startedAt := a.now().UTC()
+	logPath := resolveLogPath(request, a.backend)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return contracts.RunnerResult{}, err
+	}
+	stdoutFile, err := contracts.CreateRunnerLog(logPath, request.Secrets)
+	if err != nil {
+		return contracts.RunnerResult{}, err
+	}
+	defer stdoutFile.Close()
+	stderrFile, err := contracts.CreateRunnerLog(contracts.BackendLogSidecarPath(logPath, contracts.BackendLogStderr), request.Secrets)
+	if err != nil {
+		return contracts.RunnerResult{}, err
+	}
+	defer stderrFile.Close()
+
+	var (
+		mu       sync.Mutex
+		reported *ExecMessage
+	)
+	emit := func(progress contracts.RunnerProgress) {
+		if request.OnProgress != nil {
+			request.OnProgress(progress)
+		}
+	}
+	emitOutput := func(source string, line string) {
+		if progress, ok := contracts.NewRunnerOutputProgress(source, line, a.now().UTC()); ok {
+			emit(progress)
+		}
+	}
+	stdoutWriter := newLineWriter(stdoutFile, func(line string) {
+		message, ok := parseExecMessage(line)
+		if !ok {
+			emitOutput("stdout", line)
+			return
+		}
+		switch message.Type {
+		case ExecMessageResult:
+			mu.Lock()
+			reported = &message
+			mu.Unlock()
+		case ExecMessageProgress:
+			progressType := strings.TrimSpace(message.Event)
+			if progressType == "" {
+				progressType = string(contracts.EventTypeRunnerProgress)
+			}
+			emit(contracts.RunnerProgress{Type: progressType, Message: message.Message, Metadata: message.Metadata, Timestamp: a.now().UTC()})
+		default:
+			emitOutput("stdout", line)
+		}
+	})
+	stderrWriter := newLineWriter(stderrFile, func(line string) {
+		emitOutput("stderr", line)
+	})
+
+	runCtx, cancel := contracts.WithOptionalTimeout(ctx, request.Timeout)
+	defer cancel()
+	spec := CommandSpec{
+		Binary: a.binary,
+		Args:   resolveCommandArgs(a.args, request),
+		Env:    contracts.RunnerEnv(request.Env),
+		Dir:    request.RepoRoot,
+		Stdin:  bytes.NewReader(append(payload, '\n')),
+		Stdout: stdoutWriter,
+		Stderr: stderrWriter,
+	}
+	var runErr error
+	if a.starter != nil {
+		supervisor := ProcessSupervisor{
+			Start: func(ctx context.Context) (SupervisedProcess, error) {
+				return a.starter.Start(ctx, spec)
+			},
+			GracePeriod: a.gracePeriod,
+		}
+		runErr = supervisor.Run(runCtx, waitSupervisedProcess)
+	} else {
+		runErr = a.runner.Run(runCtx, spec)
+	}
+	stdoutWriter.Flush()
+	stderrWriter.Flush()
+	runErr = contracts.FinalizeRunError(runCtx, runErr)
+
+	mu.Lock()
+	final := reported
+	mu.Unlock()
+	finishedAt := a.now().UTC()
+	result := contracts.NormalizeBackendRunnerResult(startedAt, finishedAt, request, runErr, nil)
+	extras := map[string]string{}
+	// A reported result wins over the exit status, so an executable may exit
+	// non-zero after reporting why it blocked; timeouts and cancellation
+	// still win over both.
+	switch {
+	case final != nil && runCtx.Err() == nil:
+		result.Status = contracts.RunnerResultStatus(strings.ToLower(strings.TrimSpace(final.Status)))
+		result.Reason = strings.TrimSpace(final.Reason)
+		if err := result.Validate(); err != nil {
+			result.Status = contracts.RunnerResultFailed
+			result.Reason = fmt.Sprintf("exec backend reported invalid status %q", final.Status)
+		}
+		result.ReviewReady = final.ReviewReady && result.Status == contracts.RunnerResultCompleted
+		for key, value := range final.Artifacts {
+			extras[key] = value
+		}
+		if request.Mode == contracts.RunnerModeReview && result.Status == contracts.RunnerResultCompleted && extras["review_verdict"] == "" {
+			extras["review_verdict"] = "fail"
+			if result.ReviewReady {
+				extras["review_verdict"] = "pass"
+			}
+		}
+	case runErr == nil:
+		result.Status = contracts.RunnerResultFailed
+		result.Reason = "exec backend exited without a result"
+	}
+	result.LogPath = logPath
+	result.Artifacts = contracts.BuildRunnerArtifacts(a.backend, request, result, extras)
+	return result, nil
+}
+
+func newExecRequest(backend string, request contracts.RunnerRequest) ExecRequest {
+	return ExecRequest{
+		Protocol:       ExecProtocolVersion,
+		Backend:        backend,
+		TaskID:         request.TaskID,
+		ParentID:       request.ParentID,
+		Mode:           string(request.Mode),
+		Model:          request.Model,
+		Prompt:         request.Prompt,
+		RepoRoot:       request.RepoRoot,
+		TimeoutSeconds: int64(request.Timeout / time.Second),
+		Metadata:       request.Metadata,
+	}
+}
+
+func parseExecMessage(line string) (ExecMessage, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return ExecMessage{}, false
+	}
+	message := ExecMessage{}
+	if err := json.Unmarshal([]byte(line), &message); err != nil {
+		return ExecMessage{}, false
+	}
+	message.Type = strings.ToLower(strings.TrimSpace(message.Type))
+	return message, message.Type != ""
+}
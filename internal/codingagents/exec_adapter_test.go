@@ -0,0 +1,168 @@
+package codingagents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestExecRunnerAdapterSendsRequestAndUsesReportedResult(t *testing.T) {
+	repoRoot := t.TempDir()
+	var received ExecRequest
+	runner := commandRunnerFunc(func(_ context.Context, spec CommandSpec) error {
+		if err := json.NewDecoder(spec.Stdin).Decode(&received); err != nil {
+			return err
+		}
+		fmt.Fprintln(spec.Stdout, `{"type":"progress","message":"reading files","metadata":{"step":"1"}}`)
+		fmt.Fprintln(spec.Stdout, `{"type":"progress","event":"runner_warning","message":"slow tool"}`)
+		fmt.Fprintln(spec.Stdout, "plain text line")
+		fmt.Fprintln(spec.Stderr, "compiling")
+		fmt.Fprintln(spec.Stdout, `{"type":"result","status":"completed","review_ready":true,"artifacts":{"session_id":"s-1"}}`)
+		return nil
+	})
+	progress := []contracts.RunnerProgress{}
+	adapter := NewExecRunnerAdapter("in-house", "./agent", []string{"--task={{task_id}}"}, runner)
+
+	result, err := adapter.Run(context.Background(), contracts.RunnerRequest{
+		TaskID:     "task-1",
+		ParentID:   "root",
+		Prompt:     "Review the change",
+		Mode:       contracts.RunnerModeReview,
+		Model:      "m-1",
+		RepoRoot:   repoRoot,
+		Timeout:    90 * time.Second,
+		OnProgress: func(p contracts.RunnerProgress) { progress = append(progress, p) },
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if received.Protocol != ExecProtocolVersion || received.Backend != "in-house" || received.TaskID != "task-1" || received.ParentID != "root" ||
+		received.Mode != "review" || received.Model != "m-1" || received.Prompt != "Review the change" || received.RepoRoot != repoRoot || received.TimeoutSeconds != 90 {
+		t.Fatalf("unexpected request %#v", received)
+	}
+	if result.Status != contracts.RunnerResultCompleted || !result.ReviewReady {
+		t.Fatalf("expected completed review-ready result, got %#v", result)
+	}
+	if result.Artifacts["review_verdict"] != "pass" || result.Artifacts["session_id"] != "s-1" || result.Artifacts["backend"] != "in-house" {
+		t.Fatalf("unexpected artifacts %#v", result.Artifacts)
+	}
+	if result.LogPath != filepath.Join(repoRoot, "runner-logs", "in-house", "task-1.jsonl") {
+		t.Fatalf("unexpected log path %q", result.LogPath)
+	}
+
+	got := []string{}
+	for _, p := range progress {
+		got = append(got, p.Type+":"+p.Message)
+	}
+	want := "runner_progress:reading files|runner_warning:slow tool|runner_output:plain text line|runner_output:stderr: compiling"
+	if strings.Join(got, "|") != want {
+		t.Fatalf("unexpected progress %q", strings.Join(got, "|"))
+	}
+	if progress[0].Metadata["step"] != "1" {
+		t.Fatalf("expected progress metadata, got %#v", progress[0].Metadata)
+	}
+}
+
+func TestExecRunnerAdapterMasksSecretsInRunnerLogs(t *testing.T) {
+	repoRoot := t.TempDir()
+	runner := commandRunnerFunc(func(_ context.Context, spec CommandSpec) error {
+		// The secret arrives split across writes, as a backend streams it.
+		fmt.Fprint(spec.Stdout, "connecting to postgres://app:hun")
+		fmt.Fprintln(spec.Stdout, "ter2@db")
+		fmt.Fprintln(spec.Stderr, "auth failed for hunter2")
+		fmt.Fprintln(spec.Stdout, `{"type":"result","status":"completed"}`)
+		return nil
+	})
+	adapter := NewExecRunnerAdapter("in-house", "./agent", nil, runner)
+
+	result, err := adapter.Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "task-1",
+		RepoRoot: repoRoot,
+		Env:      map[string]string{"DB_PASSWORD": "hunter2"},
+		Secrets:  []string{"hunter2"},
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	for path, want := range map[string]string{
+		result.LogPath: "connecting to postgres://app:***@db\n",
+		contracts.BackendLogSidecarPath(result.LogPath, contracts.BackendLogStderr): "auth failed for ***\n",
+	} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if strings.Contains(string(content), "hunter2") || !strings.HasPrefix(string(content), want) {
+			t.Fatalf("expected %s to start with %q and hold no secret, got %q", path, want, content)
+		}
+	}
+}
+
+func TestExecRunnerAdapterResolvesStatusFromResultAndExit(t *testing.T) {
+	cases := []struct {
+		name       string
+		stdout     string
+		runErr     error
+		wantStatus contracts.RunnerResultStatus
+		wantReason string
+	}{
+		{name: "reported blocked wins over exit status", stdout: `{"type":"result","status":"blocked","reason":"needs credentials"}`, runErr: errors.New("exit status 2"), wantStatus: contracts.RunnerResultBlocked, wantReason: "needs credentials"},
+		{name: "missing result", stdout: "done", wantStatus: contracts.RunnerResultFailed, wantReason: "exec backend exited without a result"},
+		{name: "invalid status", stdout: `{"type":"result","status":"maybe"}`, wantStatus: contracts.RunnerResultFailed, wantReason: `exec backend reported invalid status "maybe"`},
+		{name: "exit error without result", runErr: errors.New("exit status 1"), wantStatus: contracts.RunnerResultFailed, wantReason: "exit status 1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner := commandRunnerFunc(func(_ context.Context, spec CommandSpec) error {
+				_, _ = io.Copy(io.Discard, spec.Stdin)
+				fmt.Fprintln(spec.Stdout, tc.stdout)
+				return tc.runErr
+			})
+			result, err := NewExecRunnerAdapter("in-house", "./agent", nil, runner).Run(context.Background(), contracts.RunnerRequest{
+				TaskID:   "task-1",
+				Mode:     contracts.RunnerModeImplement,
+				RepoRoot: t.TempDir(),
+			})
+			if err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			if result.Status != tc.wantStatus || result.Reason != tc.wantReason {
+				t.Fatalf("expected %s %q, got %s %q", tc.wantStatus, tc.wantReason, result.Status, result.Reason)
+			}
+		})
+	}
+}
+
+func TestExecRunnerAdapterRunsExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	repoRoot := t.TempDir()
+	script := filepath.Join(repoRoot, "agent.sh")
+	content := "#!/bin/sh\nread request\ncase \"$request\" in *'\"task_id\":\"task-7\"'*) ;; *) exit 3 ;; esac\necho '{\"type\":\"result\",\"status\":\"completed\"}'\n"
+	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+
+	result, err := NewExecRunnerAdapter("in-house", script, nil, nil).Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "task-7",
+		Mode:     contracts.RunnerModeImplement,
+		RepoRoot: repoRoot,
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if result.Status != contracts.RunnerResultCompleted {
+		t.Fatalf("expected completed result, got %#v", result)
+	}
+}
@@ -0,0 +1,84 @@
+package codingagents
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var backendVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// CheckBackendBinary reports whether the backend's binary can be found.
+// Backends without a binary, such as the HTTP ones, always pass.
+func CheckBackendBinary(definition BackendDefinition) error {
+	binary := strings.TrimSpace(definition.Binary)
+	if binary == "" {
+		return nil
+	}
+	if _, err := exec.LookPath(binary); err != nil {
+		return fmt.Errorf("binary %q not found in PATH", binary)
+	}
+	return nil
+}
+
+// CheckBackendVersion runs the backend's binary with version_args (default
+// --version) and compares the first version number it prints with
+// min_version. It returns the detected version; without a min_version or a
+// binary it does nothing.
+func CheckBackendVersion(ctx context.Context, definition BackendDefinition, run func(context.Context, string, ...string) ([]byte, error)) (string, error) {
+	minVersion := strings.TrimSpace(definition.MinVersion)
+	binary := strings.TrimSpace(definition.Binary)
+	if minVersion == "" || binary == "" {
+		return "", nil
+	}
+	if run == nil {
+		run = runAgentHealthCommand
+	}
+	args := definition.VersionArgs
+	if len(args) == 0 {
+		args = []string{"--version"}
+	}
+	output, err := run(ctx, binary, args...)
+	if err != nil {
+		return "", fmt.Errorf("%s %s failed: %w", binary, strings.Join(args, " "), err)
+	}
+	version := backendVersionPattern.FindString(string(output))
+	if version == "" {
+		return "", fmt.Errorf("cannot find a version in the output of %s %s", binary, strings.Join(args, " "))
+	}
+	if compareBackendVersions(version, minVersion) < 0 {
+		return version, fmt.Errorf("version %s is older than the required min_version %s", version, minVersion)
+	}
+	return version, nil
+}
+
+// compareBackendVersions compares dotted versions numerically; a missing
+// patch number counts as zero.
+func compareBackendVersions(a string, b string) int {
+	left := parseBackendVersion(a)
+	right := parseBackendVersion(b)
+	for i := range left {
+		if left[i] != right[i] {
+			if left[i] < right[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func parseBackendVersion(raw string) [3]int {
+	var parts [3]int
+	matches := backendVersionPattern.FindStringSubmatch(raw)
+	if len(matches) < 3 {
+		return parts
+	}
+	for i, value := range matches[1:] {
+		parts[i], _ = strconv.Atoi(value)
+	}
+	return parts
+}
@@ -0,0 +1,70 @@
+package codingagents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckBackendBinaryReportsMissingBinary(t *testing.T) {
+	if err := CheckBackendBinary(BackendDefinition{Name: "http"}); err != nil {
+		t.Fatalf("expected backend without binary to pass, got %v", err)
+	}
+	err := CheckBackendBinary(BackendDefinition{Name: "missing", Binary: "yolo-preflight-missing-agent"})
+	if err == nil || !strings.Contains(err.Error(), "not found in PATH") {
+		t.Fatalf("expected missing binary error, got %v", err)
+	}
+}
+
+func TestCheckBackendVersionComparesWithMinVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		minVersion string
+		wantErr    string
+	}{
+		{name: "newer", output: "codex-cli 0.46.0\n", minVersion: "0.40.0"},
+		{name: "equal without patch", output: "1.2.0 (Claude Code)", minVersion: "1.2"},
+		{name: "numeric not lexical", output: "v0.10.1", minVersion: "0.9.9"},
+		{name: "older", output: "kimi, version 0.3.9", minVersion: "0.4.0", wantErr: "version 0.3.9 is older than the required min_version 0.4.0"},
+		{name: "no version", output: "unknown", minVersion: "1.0.0", wantErr: "cannot find a version"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotArgs []string
+			_, err := CheckBackendVersion(context.Background(), BackendDefinition{Binary: "agent", MinVersion: tc.minVersion}, func(_ context.Context, name string, args ...string) ([]byte, error) {
+				gotArgs = args
+				return []byte(tc.output), nil
+			})
+			if strings.Join(gotArgs, " ") != "--version" {
+				t.Fatalf("expected --version, got %#v", gotArgs)
+			}
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckBackendVersionSkipsWithoutMinVersion(t *testing.T) {
+	version, err := CheckBackendVersion(context.Background(), BackendDefinition{Binary: "agent"}, func(context.Context, string, ...string) ([]byte, error) {
+		return nil, errors.New("should not run")
+	})
+	if err != nil || version != "" {
+		t.Fatalf("expected no check without min_version, got %q, %v", version, err)
+	}
+}
+
+func TestValidateBackendDefinitionRejectsInvalidMinVersion(t *testing.T) {
+	err := validateBackendDefinition(BackendDefinition{Name: "agent", Adapter: "command", Binary: "agent", MinVersion: "latest"})
+	if err == nil || !strings.Contains(err.Error(), "invalid min_version") {
+		t.Fatalf("expected invalid min_version error, got %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package contracts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// commentQueueSize bounds the comments waiting for the worker. A task posts
+// a handful of comments, so it only fills up when the tracker stops
+// answering.
+const commentQueueSize = 256
+
+// TrackerCommentEventSink posts a structured comment on the tracker task at
+// each lifecycle milestone: started, diff summary, review pass/fail, landed,
+// blocked and failed. Other events are ignored.
+//
+// Emit only queues the comment: a worker posts it so the loop never waits
+// on the tracker, and failures are written as warnings instead of failing
+// the emit. Close flushes the queue.
+type TrackerCommentEventSink struct {
+	commenter TaskCommenter
+	warnings  io.Writer
+
+	mu      sync.Mutex
+	queue   chan taskComment
+	closed  bool
+	dropped int
+	done    chan struct{}
+}
+
+type taskComment struct {
+	taskID string
+	body   string
+}
+
+// NewTrackerCommentEventSink posts comments with commenter and writes the
+// ones that fail to warnings.
+func NewTrackerCommentEventSink(commenter TaskCommenter, warnings io.Writer) *TrackerCommentEventSink {
+	if warnings == nil {
+		warnings = io.Discard
+	}
+	s := &TrackerCommentEventSink{
+		commenter: commenter,
+		warnings:  warnings,
+		queue:     make(chan taskComment, commentQueueSize),
+		done:      make(chan struct{}),
+	}
+	go s.work()
+	return s
+}
+
+// Emit queues the comment for a milestone event and ignores other events.
+// It never waits on the tracker and never fails.
+func (s *TrackerCommentEventSink) Emit(_ context.Context, event Event) error {
+	if s == nil || s.commenter == nil {
+		return nil
+	}
+	taskID := strings.TrimSpace(event.TaskID)
+	if taskID == "" {
+		return nil
+	}
+	body, ok := BuildLifecycleComment(event)
+	if !ok {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	select {
+	case s.queue <- taskComment{taskID: taskID, body: body}:
+	default:
+		s.dropped++
+	}
+	return nil
+}
+
+// Close waits for the queued comments to reach the tracker. Events emitted
+// after Close are ignored.
+func (s *TrackerCommentEventSink) Close() {
+	if s == nil || s.commenter == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	dropped := s.dropped
+	s.mu.Unlock()
+	<-s.done
+	if dropped > 0 {
+		fmt.Fprintf(s.warnings, "warning: comment trail skipped %d comments because the tracker fell behind\n", dropped)
+	}
+}
+
+func (s *TrackerCommentEventSink) work() {
+	defer close(s.done)
+	for comment := range s.queue {
+		if err := s.commenter.AddTaskComment(context.Background(), comment.taskID, comment.body); err != nil {
+			fmt.Fprintf(s.warnings, "warning: comment on %s: %v\n", comment.taskID, err)
+		}
+	}
+}
+
+const lifecycleCommentPrefix = "yolo-runner: "
+
+// IsLifecycleComment reports whether body is a comment BuildLifecycleComment
+// rendered.
+func IsLifecycleComment(body string) bool {
+	return strings.HasPrefix(strings.TrimSpace(body), lifecycleCommentPrefix)
+}
+
+// BuildLifecycleComment renders the comment for a milestone event. The first
+// line names the milestone; following lines are `key: value` details.
+func BuildLifecycleComment(event Event) (string, bool) {
+	milestone := ""
+	details := [][2]string{}
+	add := func(key string, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			details = append(details, [2]string{key, value})
+		}
+	}
+	switch event.Type {
+	case EventTypeTaskStarted:
+		milestone = "started"
+		add("worker", event.WorkerID)
+		add("backend", event.Metadata["backend"])
+		add("model", event.Metadata["model"])
+	case EventTypeReviewFinished:
+		if event.Message == string(RunnerResultCompleted) {
+			milestone = "review passed"
+		} else {
+			milestone = "review failed"
+			feedback := event.Metadata["review_fail_feedback"]
+			if strings.TrimSpace(feedback) == "" {
+				feedback = event.Metadata["reason"]
+			}
+			add("feedback", feedback)
+		}
+		add("phase", event.Metadata["review_phase"])
+		add("attempt", event.Metadata["review_attempt"])
+		add("acceptance criteria unmet", event.Metadata["acceptance_criteria_unmet"])
+	case EventTypeDiffSummarized:
+		milestone = "diff summary"
+		add("files", event.Metadata["diff_summary_files"])
+		add("changes", strings.ReplaceAll(event.Metadata[DiffSummaryArtifactKey], "\n", "; "))
+	case EventTypeMergeLanded:
+		milestone = "landed"
+		// landed_sha is the commit on main; without it, only the task
+		// branch's commit is known.
+		if landed := strings.TrimSpace(event.Metadata["landed_sha"]); landed != "" {
+			add("commit", landed)
+		} else {
+			add("branch commit", event.Metadata["auto_commit_sha"])
+		}
+		add("attempt", event.Metadata["landing_attempt"])
+	case EventTypeTaskFinished:
+		switch event.Message {
+		case string(TaskStatusBlocked):
+			milestone = "blocked"
+		case string(TaskStatusFailed):
+			milestone = "failed"
+		default:
+			return "", false
+		}
+		add("triage category", event.Metadata[TriageCategoryMetadataKey])
+		add("triage reason", event.Metadata["triage_reason"])
+	default:
+		return "", false
+	}
+
+	lines := []string{lifecycleCommentPrefix + milestone}
+	for _, detail := range details {
+		lines = append(lines, detail[0]+": "+detail[1])
+	}
+	return strings.Join(lines, "\n"), true
+}
@@ -0,0 +1,110 @@
+package contracts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingCommenter struct {
+	taskIDs []string
+	bodies  []string
+}
+
+func (r *recordingCommenter) AddTaskComment(_ context.Context, taskID string, body string) error {
+	r.taskIDs = append(r.taskIDs, taskID)
+	r.bodies = append(r.bodies, body)
+	return nil
+}
+
+func TestTrackerCommentEventSinkPostsLifecycleMilestones(t *testing.T) {
+	commenter := &recordingCommenter{}
+	sink := NewTrackerCommentEventSink(commenter, nil)
+	events := []Event{
+		{Type: EventTypeTaskStarted, TaskID: "t-1", WorkerID: "worker-0"},
+		{Type: EventTypeRunnerOutput, TaskID: "t-1", Message: "noise"},
+		{Type: EventTypeDiffSummarized, TaskID: "t-1", Metadata: map[string]string{"diff_summary_files": "a.go (+3/-1)", DiffSummaryArtifactKey: "Add retry\nCover timeouts"}},
+		{Type: EventTypeReviewFinished, TaskID: "t-1", Message: string(RunnerResultFailed), Metadata: map[string]string{"review_attempt": "1", "review_fail_feedback": "missing tests"}},
+		{Type: EventTypeReviewFinished, TaskID: "t-1", Message: string(RunnerResultCompleted), Metadata: map[string]string{"review_attempt": "2"}},
+		{Type: EventTypeMergeLanded, TaskID: "t-1", Metadata: map[string]string{"auto_commit_sha": "abc123", "landed_sha": "def456", "landing_attempt": "1"}},
+		{Type: EventTypeTaskFinished, TaskID: "t-1", Message: string(TaskStatusClosed)},
+		{Type: EventTypeTaskFinished, TaskID: "t-2", Message: string(TaskStatusBlocked), Metadata: map[string]string{"triage_reason": "merge conflict", "triage_category": "merge_queue_conflict"}},
+	}
+	for _, event := range events {
+		if err := sink.Emit(context.Background(), event); err != nil {
+			t.Fatalf("emit failed: %v", err)
+		}
+	}
+	sink.Close()
+
+	want := []string{
+		"yolo-runner: started\nworker: worker-0",
+		"yolo-runner: diff summary\nfiles: a.go (+3/-1)\nchanges: Add retry; Cover timeouts",
+		"yolo-runner: review failed\nfeedback: missing tests\nattempt: 1",
+		"yolo-runner: review passed\nattempt: 2",
+		"yolo-runner: landed\ncommit: def456\nattempt: 1",
+		"yolo-runner: blocked\ntriage category: merge_queue_conflict\ntriage reason: merge conflict",
+	}
+	if len(commenter.bodies) != len(want) {
+		t.Fatalf("expected %d comments, got %#v", len(want), commenter.bodies)
+	}
+	for i := range want {
+		if commenter.bodies[i] != want[i] {
+			t.Fatalf("comment %d: expected %q, got %q", i, want[i], commenter.bodies[i])
+		}
+	}
+	if commenter.taskIDs[5] != "t-2" {
+		t.Fatalf("expected blocked comment on t-2, got %q", commenter.taskIDs[5])
+	}
+}
+
+type blockingCommenter struct {
+	release chan struct{}
+	err     error
+	bodies  []string
+}
+
+func (b *blockingCommenter) AddTaskComment(_ context.Context, _ string, body string) error {
+	<-b.release
+	b.bodies = append(b.bodies, body)
+	return b.err
+}
+
+func TestTrackerCommentEventSinkDoesNotWaitOnTracker(t *testing.T) {
+	commenter := &blockingCommenter{release: make(chan struct{}), err: errors.New("tracker unavailable")}
+	var warnings bytes.Buffer
+	sink := NewTrackerCommentEventSink(commenter, &warnings)
+	emitted := make(chan error, 1)
+	go func() {
+		emitted <- sink.Emit(context.Background(), Event{Type: EventTypeMergeLanded, TaskID: "t-1", Metadata: map[string]string{"auto_commit_sha": "abc123"}})
+	}()
+	select {
+	case err := <-emitted:
+		if err != nil {
+			t.Fatalf("emit failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("emit waited on the tracker")
+	}
+	close(commenter.release)
+	sink.Close()
+
+	if len(commenter.bodies) != 1 || commenter.bodies[0] != "yolo-runner: landed\nbranch commit: abc123" {
+		t.Fatalf("expected the queued comment posted by Close, got %#v", commenter.bodies)
+	}
+	if !strings.Contains(warnings.String(), "warning: comment on t-1: tracker unavailable") {
+		t.Fatalf("expected the failure as a warning, got %q", warnings.String())
+	}
+}
+
+func TestIsLifecycleComment(t *testing.T) {
+	if !IsLifecycleComment("yolo-runner: landed\ncommit: abc123") {
+		t.Fatalf("expected a lifecycle comment to be recognised")
+	}
+	if IsLifecycleComment("Please keep the old flag working") {
+		t.Fatalf("expected a human comment not to be a lifecycle comment")
+	}
+}
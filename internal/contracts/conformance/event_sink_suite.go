@@ -0,0 +1,143 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// EventSinkFixture is a sink and a way to read back what it delivered.
+type EventSinkFixture struct {
+	Sink contracts.EventSink
+	// Events returns the events the sink delivered so far, in delivery
+	// order, flushing or closing the sink first if it buffers.
+	Events func(t *testing.T) []contracts.Event
+}
+
+type EventSinkFactory func(t *testing.T) EventSinkFixture
+
+type EventSinkConfig struct {
+	Backend      string
+	NewEventSink EventSinkFactory
+}
+
+func RunEventSinkSuite(t *testing.T, cfg EventSinkConfig) {
+	t.Helper()
+
+	backend := strings.TrimSpace(cfg.Backend)
+	if backend == "" {
+		t.Fatal("conformance backend is required")
+	}
+	if cfg.NewEventSink == nil {
+		t.Fatal("conformance event sink factory is required")
+	}
+
+	t.Run("delivers events in emit order with their fields", func(t *testing.T) {
+		fixture := eventSinkFixture(t, cfg)
+		at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		sent := []contracts.Event{
+			{Type: contracts.EventTypeRunStarted, Message: "run started", Timestamp: at},
+			{Type: contracts.EventTypeTaskStarted, TaskID: "t-1", TaskTitle: "Task 1", WorkerID: "worker-0", Metadata: map[string]string{"model": "m-1"}, Timestamp: at},
+			{Type: contracts.EventTypeTaskFinished, TaskID: "t-1", Message: "closed", Timestamp: at.Add(time.Second)},
+		}
+		for _, event := range sent {
+			if err := fixture.Sink.Emit(context.Background(), event); err != nil {
+				t.Fatalf("Emit(%s) returned error: %v", event.Type, err)
+			}
+		}
+		got := fixture.Events(t)
+		if len(got) != len(sent) {
+			t.Fatalf("expected %d delivered events, got %#v", len(sent), got)
+		}
+		for i := range sent {
+			assertDeliveredEvent(t, got[i], sent[i])
+		}
+	})
+
+	t.Run("emit leaves the caller's metadata unchanged", func(t *testing.T) {
+		fixture := eventSinkFixture(t, cfg)
+		metadata := map[string]string{"model": "m-1"}
+		if err := fixture.Sink.Emit(context.Background(), contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "t-1", Metadata: metadata, Timestamp: time.Now().UTC()}); err != nil {
+			t.Fatalf("Emit returned error: %v", err)
+		}
+		fixture.Events(t)
+		if len(metadata) != 1 || metadata["model"] != "m-1" {
+			t.Fatalf("expected the emitted metadata map to be left unchanged, got %#v", metadata)
+		}
+	})
+
+	t.Run("emit is safe for concurrent workers", func(t *testing.T) {
+		fixture := eventSinkFixture(t, cfg)
+		const workers, perWorker = 8, 10
+		var wg sync.WaitGroup
+		errs := make(chan error, workers*perWorker)
+		for worker := 0; worker < workers; worker++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+				for i := 0; i < perWorker; i++ {
+					errs <- fixture.Sink.Emit(context.Background(), contracts.Event{
+						Type:      contracts.EventTypeTaskStarted,
+						TaskID:    fmt.Sprintf("t-%d-%d", worker, i),
+						WorkerID:  fmt.Sprintf("worker-%d", worker),
+						Timestamp: time.Now().UTC(),
+					})
+				}
+			}(worker)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("concurrent Emit returned error: %v", err)
+			}
+		}
+		got := fixture.Events(t)
+		if len(got) != workers*perWorker {
+			t.Fatalf("expected %d delivered events, got %d", workers*perWorker, len(got))
+		}
+		seen := map[string]bool{}
+		for _, event := range got {
+			if seen[event.TaskID] {
+				t.Fatalf("expected each event delivered once, got %q twice", event.TaskID)
+			}
+			seen[event.TaskID] = true
+		}
+	})
+}
+
+func eventSinkFixture(t *testing.T, cfg EventSinkConfig) EventSinkFixture {
+	t.Helper()
+
+	fixture := cfg.NewEventSink(t)
+	if fixture.Sink == nil {
+		t.Fatal("conformance event sink is required")
+	}
+	if fixture.Events == nil {
+		t.Fatal("conformance event sink fixture requires Events")
+	}
+	return fixture
+}
+
+func assertDeliveredEvent(t *testing.T, got contracts.Event, want contracts.Event) {
+	t.Helper()
+	if got.Type != want.Type || got.TaskID != want.TaskID || got.TaskTitle != want.TaskTitle || got.WorkerID != want.WorkerID || got.Message != want.Message {
+		t.Fatalf("expected event %#v, got %#v", want, got)
+	}
+	if len(got.Metadata) < len(want.Metadata) {
+		t.Fatalf("expected metadata %#v on %s, got %#v", want.Metadata, want.Type, got.Metadata)
+	}
+	for key, value := range want.Metadata {
+		if got.Metadata[key] != value {
+			t.Fatalf("expected metadata %s=%q on %s, got %#v", key, value, want.Type, got.Metadata)
+		}
+	}
+	if !got.Timestamp.IsZero() && got.Timestamp.Unix() != want.Timestamp.Unix() {
+		t.Fatalf("expected timestamp %s on %s, got %s", want.Timestamp, want.Type, got.Timestamp)
+	}
+}
@@ -0,0 +1,175 @@
+package conformance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// VCSFixture is a VCS over a fresh repository whose main branch has at least
+// one commit and whose remote accepts pushes of main and task branches.
+type VCSFixture struct {
+	VCS contracts.VCS
+	// WriteFile creates or overwrites path in the working tree the VCS
+	// commits from.
+	WriteFile func(t *testing.T, path string, content string)
+	// ReadMain returns the content of path at the tip of the local main
+	// branch, and false when main does not have it.
+	ReadMain func(t *testing.T, path string) (string, bool)
+	// ReadRemoteMain returns the content of path at the tip of the remote
+	// main branch, and false when the remote main does not have it.
+	ReadRemoteMain func(t *testing.T, path string) (string, bool)
+}
+
+type VCSFactory func(t *testing.T) VCSFixture
+
+type VCSConfig struct {
+	Backend string
+	NewVCS  VCSFactory
+}
+
+func RunVCSSuite(t *testing.T, cfg VCSConfig) {
+	t.Helper()
+
+	backend := strings.TrimSpace(cfg.Backend)
+	if backend == "" {
+		t.Fatal("conformance backend is required")
+	}
+	if cfg.NewVCS == nil {
+		t.Fatal("conformance VCS factory is required")
+	}
+
+	t.Run("task branch names the task and keeps commits off main", func(t *testing.T) {
+		fixture := vcsFixture(t, cfg)
+		ctx := context.Background()
+		branch := createTaskBranch(t, fixture, "t-1")
+		if !strings.Contains(branch, "t-1") {
+			t.Fatalf("expected task branch to name task t-1, got %q", branch)
+		}
+		fixture.WriteFile(t, "t-1.txt", "one\n")
+		sha, err := fixture.VCS.CommitAll(ctx, "t-1: add file")
+		if err != nil {
+			t.Fatalf("CommitAll returned error: %v", err)
+		}
+		if strings.TrimSpace(sha) == "" {
+			t.Fatalf("expected CommitAll to return the commit SHA")
+		}
+		if _, ok := fixture.ReadMain(t, "t-1.txt"); ok {
+			t.Fatalf("expected task branch commit to stay off main")
+		}
+	})
+
+	t.Run("task branches are distinct per task", func(t *testing.T) {
+		fixture := vcsFixture(t, cfg)
+		first := createTaskBranch(t, fixture, "t-1")
+		second := createTaskBranch(t, fixture, "t-2")
+		if first == second {
+			t.Fatalf("expected distinct branches for t-1 and t-2, got %q for both", first)
+		}
+	})
+
+	t.Run("commit all without changes returns the current head", func(t *testing.T) {
+		fixture := vcsFixture(t, cfg)
+		ctx := context.Background()
+		createTaskBranch(t, fixture, "t-1")
+		fixture.WriteFile(t, "t-1.txt", "one\n")
+		first, err := fixture.VCS.CommitAll(ctx, "t-1: add file")
+		if err != nil {
+			t.Fatalf("CommitAll returned error: %v", err)
+		}
+		second, err := fixture.VCS.CommitAll(ctx, "t-1: nothing new")
+		if err != nil {
+			t.Fatalf("CommitAll without changes returned error: %v", err)
+		}
+		if second != first {
+			t.Fatalf("expected CommitAll without changes to return %q, got %q", first, second)
+		}
+	})
+
+	t.Run("checkout returns to a task branch", func(t *testing.T) {
+		fixture := vcsFixture(t, cfg)
+		ctx := context.Background()
+		branch := createTaskBranch(t, fixture, "t-1")
+		if err := fixture.VCS.EnsureMain(ctx); err != nil {
+			t.Fatalf("EnsureMain returned error: %v", err)
+		}
+		if err := fixture.VCS.Checkout(ctx, branch); err != nil {
+			t.Fatalf("Checkout(%q) returned error: %v", branch, err)
+		}
+		fixture.WriteFile(t, "t-1.txt", "one\n")
+		if _, err := fixture.VCS.CommitAll(ctx, "t-1: add file"); err != nil {
+			t.Fatalf("CommitAll returned error: %v", err)
+		}
+		if _, ok := fixture.ReadMain(t, "t-1.txt"); ok {
+			t.Fatalf("expected commit after Checkout(%q) to land on the task branch, not main", branch)
+		}
+	})
+
+	t.Run("merge to main lands the task branch and push main publishes it", func(t *testing.T) {
+		fixture := vcsFixture(t, cfg)
+		ctx := context.Background()
+		branch := createTaskBranch(t, fixture, "t-1")
+		fixture.WriteFile(t, "t-1.txt", "one\n")
+		if _, err := fixture.VCS.CommitAll(ctx, "t-1: add file"); err != nil {
+			t.Fatalf("CommitAll returned error: %v", err)
+		}
+		if err := fixture.VCS.MergeToMain(ctx, branch); err != nil {
+			t.Fatalf("MergeToMain(%q) returned error: %v", branch, err)
+		}
+		if content, ok := fixture.ReadMain(t, "t-1.txt"); !ok || content != "one\n" {
+			t.Fatalf("expected merged file on main, got %q (present=%t)", content, ok)
+		}
+		if _, ok := fixture.ReadRemoteMain(t, "t-1.txt"); ok {
+			t.Fatalf("expected remote main unchanged before PushMain")
+		}
+		if err := fixture.VCS.PushMain(ctx); err != nil {
+			t.Fatalf("PushMain returned error: %v", err)
+		}
+		if content, ok := fixture.ReadRemoteMain(t, "t-1.txt"); !ok || content != "one\n" {
+			t.Fatalf("expected pushed file on remote main, got %q (present=%t)", content, ok)
+		}
+	})
+
+	t.Run("push branch publishes the task branch", func(t *testing.T) {
+		fixture := vcsFixture(t, cfg)
+		ctx := context.Background()
+		branch := createTaskBranch(t, fixture, "t-1")
+		fixture.WriteFile(t, "t-1.txt", "one\n")
+		if _, err := fixture.VCS.CommitAll(ctx, "t-1: add file"); err != nil {
+			t.Fatalf("CommitAll returned error: %v", err)
+		}
+		if err := fixture.VCS.PushBranch(ctx, branch); err != nil {
+			t.Fatalf("PushBranch(%q) returned error: %v", branch, err)
+		}
+		if _, ok := fixture.ReadRemoteMain(t, "t-1.txt"); ok {
+			t.Fatalf("expected PushBranch to leave remote main unchanged")
+		}
+	})
+}
+
+func vcsFixture(t *testing.T, cfg VCSConfig) VCSFixture {
+	t.Helper()
+
+	fixture := cfg.NewVCS(t)
+	if fixture.VCS == nil {
+		t.Fatal("conformance VCS is required")
+	}
+	if fixture.WriteFile == nil || fixture.ReadMain == nil || fixture.ReadRemoteMain == nil {
+		t.Fatal("conformance VCS fixture requires WriteFile, ReadMain and ReadRemoteMain")
+	}
+	return fixture
+}
+
+func createTaskBranch(t *testing.T, fixture VCSFixture, taskID string) string {
+	t.Helper()
+	branch, err := fixture.VCS.CreateTaskBranch(context.Background(), taskID)
+	if err != nil {
+		t.Fatalf("CreateTaskBranch(%q) returned error: %v", taskID, err)
+	}
+	if strings.TrimSpace(branch) == "" {
+		t.Fatalf("expected CreateTaskBranch(%q) to return a branch name", taskID)
+	}
+	return branch
+}
@@ -0,0 +1,103 @@
+package contracts_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/contracts/conformance"
+)
+
+func TestFileEventSinkConformance(t *testing.T) {
+	conformance.RunEventSinkSuite(t, conformance.EventSinkConfig{
+		Backend: "file",
+		NewEventSink: func(t *testing.T) conformance.EventSinkFixture {
+			path := filepath.Join(t.TempDir(), "events.jsonl")
+			return conformance.EventSinkFixture{
+				Sink:   contracts.NewFileEventSink(path),
+				Events: func(t *testing.T) []contracts.Event { return readEventLog(t, path) },
+			}
+		},
+	})
+}
+
+func TestStreamEventSinkConformance(t *testing.T) {
+	conformance.RunEventSinkSuite(t, conformance.EventSinkConfig{
+		Backend: "stream",
+		NewEventSink: func(t *testing.T) conformance.EventSinkFixture {
+			buffer := &lockedBuffer{}
+			return conformance.EventSinkFixture{
+				Sink:   contracts.NewStreamEventSink(buffer),
+				Events: func(t *testing.T) []contracts.Event { return decodeEvents(t, buffer.reader()) },
+			}
+		},
+	})
+}
+
+func TestSequencedFanoutEventSinkConformance(t *testing.T) {
+	conformance.RunEventSinkSuite(t, conformance.EventSinkConfig{
+		Backend: "sequenced-fanout",
+		NewEventSink: func(t *testing.T) conformance.EventSinkFixture {
+			path := filepath.Join(t.TempDir(), "events.jsonl")
+			buffer := &lockedBuffer{}
+			sink := contracts.NewSequencedEventSink("run-1", contracts.NewFanoutEventSink(contracts.NewFileEventSink(path), contracts.NewStreamEventSink(buffer)))
+			return conformance.EventSinkFixture{
+				Sink: sink,
+				Events: func(t *testing.T) []contracts.Event {
+					events := readEventLog(t, path)
+					if streamed := decodeEvents(t, buffer.reader()); len(streamed) != len(events) {
+						t.Fatalf("expected fanout to deliver %d events to every sink, streamed %d", len(events), len(streamed))
+					}
+					return events
+				},
+			}
+		},
+	})
+}
+
+func readEventLog(t *testing.T, path string) []contracts.Event {
+	t.Helper()
+	reader, err := contracts.OpenEventLog(path)
+	if err != nil {
+		t.Fatalf("open event log: %v", err)
+	}
+	defer reader.Close()
+	return decodeEvents(t, reader)
+}
+
+func decodeEvents(t *testing.T, reader io.Reader) []contracts.Event {
+	t.Helper()
+	decoder := contracts.NewEventDecoder(reader)
+	events := []contracts.Event{}
+	for {
+		event, err := decoder.Next()
+		if errors.Is(err, io.EOF) {
+			return events
+		}
+		if err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		events = append(events, event)
+	}
+}
+
+type lockedBuffer struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *lockedBuffer) reader() io.Reader {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.NewReader(append([]byte(nil), b.buffer.Bytes()...))
+}
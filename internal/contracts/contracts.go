@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"sort"
 	"time"
 )
 
@@ -36,6 +37,23 @@ type TaskSummary struct {
 	Priority *int
 }
 
+// SortTaskSummaries orders tasks the way the task engine offers them: by
+// priority, lowest first, then by ID. A missing priority counts as 0.
+func SortTaskSummaries(tasks []TaskSummary) {
+	priority := func(task TaskSummary) int {
+		if task.Priority == nil {
+			return 0
+		}
+		return *task.Priority
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if left, right := priority(tasks[i]), priority(tasks[j]); left != right {
+			return left < right
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+}
+
 type Task struct {
 	ID          string
 	Title       string
@@ -54,6 +72,28 @@ type StorageBackend interface {
 	SetTaskData(ctx context.Context, taskID string, data map[string]string) error
 }
 
+// TaskChanges lists what changed under a root since an earlier read.
+type TaskChanges struct {
+	// Tasks holds the tasks created or updated since the cursor, in their
+	// current state.
+	Tasks map[string]Task
+	// Relations holds the changed tasks' relations: the parent relation
+	// of each changed task and the depends_on and blocks relations it is
+	// the source of.
+	Relations []TaskRelation
+	// Cursor marks this read for the next TaskChangesSince call.
+	Cursor string
+}
+
+// TaskChangeReader is implemented by storage backends that can list the tasks
+// under a root that changed since an earlier read, so the task graph can be
+// refreshed without reading the whole tree. An empty cursor returns only a
+// cursor. Removed tasks are not reported, so callers still read the whole
+// tree from time to time.
+type TaskChangeReader interface {
+	TaskChangesSince(ctx context.Context, rootID string, cursor string) (*TaskChanges, error)
+}
+
 type TaskTree struct {
 	Root      Task
 	Tasks     map[string]Task
@@ -133,11 +173,60 @@ type TaskManager interface {
 	SetTaskData(ctx context.Context, taskID string, data map[string]string) error
 }
 
+// TaskCreateRequest describes a new task to add to the tracker. DependsOn holds
+// IDs of existing tasks the new task must wait for.
+type TaskCreateRequest struct {
+	ParentID    string
+	Title       string
+	Description string
+	DependsOn   []string
+}
+
+// TaskCreator is implemented by storage backends that can add tasks to the
+// tracker. It returns the tracker ID of the created task.
+type TaskCreator interface {
+	CreateTask(ctx context.Context, request TaskCreateRequest) (string, error)
+}
+
+// TaskCommenter is implemented by trackers that can post free-form comments on
+// a task, e.g. GitHub issue comments, Linear comments or tk notes.
+type TaskCommenter interface {
+	AddTaskComment(ctx context.Context, taskID string, body string) error
+}
+
+// TaskComment is a comment on a task in the tracker.
+type TaskComment struct {
+	Author    string
+	Body      string
+	CreatedAt time.Time
+}
+
+// TaskCommentReader is implemented by trackers that can list the comments on
+// a task. TaskComments returns the comments posted or edited at or after
+// since, oldest first, leaving out the task data the runner records as
+// comments.
+type TaskCommentReader interface {
+	TaskComments(ctx context.Context, taskID string, since time.Time) ([]TaskComment, error)
+}
+
+// TaskAssigner is implemented by trackers that can assign a task to a person,
+// e.g. a GitHub issue assignee. assignee is the tracker's login for them.
+type TaskAssigner interface {
+	AssignTask(ctx context.Context, taskID string, assignee string) error
+}
+
+// TaskLabeler is implemented by trackers that can add labels to a task. Labels
+// the task already has are kept.
+type TaskLabeler interface {
+	AddTaskLabels(ctx context.Context, taskID string, labels []string) error
+}
+
 type RunnerMode string
 
 const (
 	RunnerModeImplement RunnerMode = "implement"
 	RunnerModeReview    RunnerMode = "review"
+	RunnerModePlan      RunnerMode = "plan"
 )
 
 type RunnerRequest struct {
@@ -150,6 +239,17 @@ type RunnerRequest struct {
 	Timeout    time.Duration
 	MaxRetries int `json:"max_retries"`
 	Metadata   map[string]string
+	// Env holds extra environment variables for the runner process, such as
+	// the task environment of a profile. It may hold secrets, so it is never
+	// serialized.
+	Env map[string]string `json:"-"`
+	// Secrets are values the runner masks in its logs as it writes them,
+	// such as the secret variables of Env. Never serialized.
+	Secrets []string `json:"-"`
+	// ToolPolicy restricts the commands the agent may run. Backends that
+	// decide tool calls themselves, such as ACP permission requests and
+	// terminals, enforce it directly.
+	ToolPolicy ToolPolicy
 	OnProgress func(RunnerProgress)
 }
 
@@ -209,6 +309,7 @@ type EventType string
 const (
 	EventTypeRunStarted            EventType = "run_started"
 	EventTypeRunFinished           EventType = "run_finished"
+	EventTypeRunInterrupted        EventType = "run_interrupted"
 	EventTypeTaskStarted           EventType = "task_started"
 	EventTypeTaskCompleted         EventType = "task_completed"
 	EventTypeTaskFailed            EventType = "task_failed"
@@ -221,17 +322,31 @@ const (
 	EventTypeRunnerCommandFinished EventType = "runner_cmd_finished"
 	EventTypeRunnerOutput          EventType = "runner_output"
 	EventTypeRunnerWarning         EventType = "runner_warning"
+	EventTypeRunnerPermission      EventType = "runner_permission"
+	EventTypePlanUpdated           EventType = "plan_updated"
+	EventTypeRateLimited           EventType = "rate_limited"
 	EventTypeReviewStarted         EventType = "review_started"
 	EventTypeReviewFinished        EventType = "review_finished"
 	EventTypeBranchCreated         EventType = "branch_created"
+	EventTypeDiffGuardrail         EventType = "diff_guardrail"
+	EventTypeDiffSummarized        EventType = "diff_summarized"
+	EventTypePipelineStageStarted  EventType = "pipeline_stage_started"
+	EventTypePipelineStageFinished EventType = "pipeline_stage_finished"
+	EventTypeSecurityAlert         EventType = "security_alert"
 	EventTypeMergeQueued           EventType = "merge_queued"
+	EventTypeMergeQueuePosition    EventType = "merge_queue_position"
 	EventTypeMergeRetry            EventType = "merge_retry"
 	EventTypeMergeBlocked          EventType = "merge_blocked"
 	EventTypeMergeLanded           EventType = "merge_landed"
 	EventTypeMergeCompleted        EventType = "merge_completed"
+	EventTypePushRetry             EventType = "push_retry"
 	EventTypePushCompleted         EventType = "push_completed"
 	EventTypeTaskStatusSet         EventType = "task_status_set"
 	EventTypeTaskDataUpdated       EventType = "task_data_updated"
+	EventTypeDryRunPlanned         EventType = "dry_run_planned"
+	EventTypeEpicProgress          EventType = "epic_progress"
+	EventTypeRunEstimate           EventType = "run_estimate"
+	EventTypeTaskDiscovered        EventType = "task_discovered"
 )
 
 type Event struct {
@@ -245,8 +360,16 @@ type Event struct {
 	Message   string
 	Metadata  map[string]string
 	Timestamp time.Time
+	// RunID and Seq are stamped by SequencedEventSink so consumers can spot
+	// lost or repeated events. Seq starts at 1; 0 means unsequenced.
+	RunID string
+	Seq   uint64
 }
 
+// RunIDTaskDataKey is the task data key naming the run that last started the
+// task.
+const RunIDTaskDataKey = "run_id"
+
 func MarshalEventJSONL(event Event) (string, error) {
 	payload := struct {
 		Type      EventType         `json:"type"`
@@ -259,6 +382,8 @@ func MarshalEventJSONL(event Event) (string, error) {
 		Message   string            `json:"message,omitempty"`
 		Metadata  map[string]string `json:"metadata,omitempty"`
 		TS        string            `json:"ts"`
+		RunID     string            `json:"run_id,omitempty"`
+		Seq       uint64            `json:"seq,omitempty"`
 	}{
 		Type:      event.Type,
 		TaskID:    event.TaskID,
@@ -270,6 +395,8 @@ func MarshalEventJSONL(event Event) (string, error) {
 		Message:   event.Message,
 		Metadata:  event.Metadata,
 		TS:        event.Timestamp.UTC().Format(time.RFC3339),
+		RunID:     event.RunID,
+		Seq:       event.Seq,
 	}
 
 	data, err := json.Marshal(payload)
@@ -296,3 +423,80 @@ type VCS interface {
 	PushBranch(ctx context.Context, branch string) error
 	PushMain(ctx context.Context) error
 }
+
+// RebaseLander is implemented by VCS adapters that can land a branch by
+// rebasing it onto main and fast-forwarding main, keeping history linear.
+type RebaseLander interface {
+	RebaseAndFastForwardMain(ctx context.Context, sourceBranch string) error
+}
+
+// SquashLander is implemented by VCS adapters that can land a branch as a
+// single commit on main.
+type SquashLander interface {
+	SquashToMain(ctx context.Context, sourceBranch string, message string) error
+}
+
+// ChangedFilesLister is implemented by VCS adapters that can list the files
+// a task branch changed relative to main, including uncommitted work.
+type ChangedFilesLister interface {
+	ChangedFiles(ctx context.Context) ([]string, error)
+}
+
+// FileDiffStat is the line count change of one file in a task's diff.
+// Binary files report no line counts.
+type FileDiffStat struct {
+	Path    string
+	Added   int
+	Deleted int
+	Binary  bool
+}
+
+// DiffStatLister is implemented by VCS adapters that can report per-file
+// line counts of a task branch's changes relative to main, including
+// uncommitted work.
+type DiffStatLister interface {
+	DiffStats(ctx context.Context) ([]FileDiffStat, error)
+}
+
+// DiffReader is implemented by VCS adapters that can return the unified
+// diff of a task branch's changes relative to main, including uncommitted
+// work.
+type DiffReader interface {
+	Diff(ctx context.Context) (string, error)
+}
+
+// BaseFileReader is implemented by VCS adapters that can read a file as it
+// was where the task branch forked from main. A file that did not exist
+// there reads as empty.
+type BaseFileReader interface {
+	ReadBaseFile(ctx context.Context, path string) (string, error)
+}
+
+// BranchFetcher is implemented by VCS adapters that can fetch a branch from
+// another local repository, which lets the merge queue land several task
+// clones from a single integration checkout.
+type BranchFetcher interface {
+	FetchBranch(ctx context.Context, repoPath string, branch string) error
+}
+
+// BatchLander is implemented by VCS adapters that can land several branches
+// on one synced main before a single push. The merge queue calls EnsureMain
+// once, lands every branch through the VCS returned by OnLocalMain, and after
+// the push checks that each landing's commit is on main.
+type BatchLander interface {
+	// OnLocalMain returns a VCS whose landing methods work on the local main
+	// as it is instead of syncing it from the remote first.
+	OnLocalMain() VCS
+	// MainHead returns the commit main points at.
+	MainHead(ctx context.Context) (string, error)
+	// IsOnMain reports whether sha is main or one of its ancestors.
+	IsOnMain(ctx context.Context, sha string) (bool, error)
+	// ResetMain drops local main commits that are not on the remote.
+	ResetMain(ctx context.Context) error
+}
+
+// MessageMerger is implemented by VCS adapters that can merge onto main with
+// a caller-supplied merge commit message.
+type MessageMerger interface {
+	MergeToMainWithMessage(ctx context.Context, sourceBranch string, message string) error
+}
@@ -0,0 +1,46 @@
+package contracts
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DiffSummaryArtifactKey holds the key changes a diff summary run reported,
+// one per line.
+const DiffSummaryArtifactKey = "diff_summary"
+
+// diffSummaryLinePattern matches `DIFF_SUMMARY: key change`. Like
+// followUpLinePattern it stops at quotes and backslashes so it also works on
+// JSON-encoded transcripts.
+var diffSummaryLinePattern = regexp.MustCompile(`\bDIFF_SUMMARY:[ \t]*([^\n\r"\\]*)`)
+
+// ParseDiffSummary extracts DIFF_SUMMARY lines from runner output. Lines are
+// de-duplicated and placeholders such as `<key change>` are ignored.
+func ParseDiffSummary(text string) []string {
+	changes := []string{}
+	seen := map[string]struct{}{}
+	for _, match := range diffSummaryLinePattern.FindAllStringSubmatch(text, -1) {
+		change := strings.Join(strings.Fields(match[1]), " ")
+		if change == "" || strings.HasPrefix(change, "<") {
+			continue
+		}
+		if _, ok := seen[change]; ok {
+			continue
+		}
+		seen[change] = struct{}{}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func diffSummaryFromLog(logPath string) string {
+	if strings.TrimSpace(logPath) == "" {
+		return ""
+	}
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		return ""
+	}
+	return strings.Join(ParseDiffSummary(string(content)), "\n")
+}
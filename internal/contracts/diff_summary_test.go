@@ -0,0 +1,31 @@
+package contracts
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseDiffSummarySkipsPlaceholdersAndDuplicates(t *testing.T) {
+	text := "DIFF_SUMMARY: <key change>\nDIFF_SUMMARY:  Add retry to   the fetcher\n" +
+		`{"text":"DIFF_SUMMARY: Cover timeouts in tests\n"}` + "\nDIFF_SUMMARY: Add retry to the fetcher\n"
+
+	got := ParseDiffSummary(text)
+	want := []string{"Add retry to the fetcher", "Cover timeouts in tests"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestBuildRunnerArtifactsReadsDiffSummaryFromReviewLog(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "t-1.diff-summary.jsonl")
+	if err := os.WriteFile(logPath, []byte("DIFF_SUMMARY: Add retry\nDIFF_SUMMARY: Cover timeouts\n"), 0o644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	artifacts := BuildRunnerArtifacts("codex", RunnerRequest{Mode: RunnerModeReview}, RunnerResult{Status: RunnerResultCompleted, LogPath: logPath}, nil)
+	if got := artifacts[DiffSummaryArtifactKey]; got != "Add retry\nCover timeouts" {
+		t.Fatalf("expected diff summary artifact, got %q", got)
+	}
+}
@@ -0,0 +1,92 @@
+package contracts
+
+import (
+	"context"
+	"sync"
+)
+
+// EventFilter selects which events reach a sink. Exclude wins over Include,
+// and an empty Include lets every type through.
+type EventFilter struct {
+	Include []EventType
+	Exclude []EventType
+	// SamplePercent keeps only this share (0-100) of events of a type. Types
+	// without an entry are kept in full.
+	SamplePercent map[EventType]int
+}
+
+func (f EventFilter) IsZero() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0 && len(f.SamplePercent) == 0
+}
+
+// FilteredEventSink applies an EventFilter in front of another sink. Sampling
+// is deterministic: with a 10% rate the 10th, 20th, ... event of that type
+// is kept, so reruns produce the same file.
+type FilteredEventSink struct {
+	sink    EventSink
+	include map[EventType]struct{}
+	exclude map[EventType]struct{}
+	sample  map[EventType]int
+	mu      sync.Mutex
+	seen    map[EventType]int
+}
+
+// NewFilteredEventSink wraps sink with filter. A zero filter returns sink
+// unchanged.
+func NewFilteredEventSink(sink EventSink, filter EventFilter) EventSink {
+	if sink == nil || filter.IsZero() {
+		return sink
+	}
+	filtered := &FilteredEventSink{
+		sink:   sink,
+		sample: map[EventType]int{},
+		seen:   map[EventType]int{},
+	}
+	if len(filter.Include) > 0 {
+		filtered.include = make(map[EventType]struct{}, len(filter.Include))
+		for _, eventType := range filter.Include {
+			filtered.include[eventType] = struct{}{}
+		}
+	}
+	filtered.exclude = make(map[EventType]struct{}, len(filter.Exclude))
+	for _, eventType := range filter.Exclude {
+		filtered.exclude[eventType] = struct{}{}
+	}
+	for eventType, percent := range filter.SamplePercent {
+		filtered.sample[eventType] = percent
+	}
+	return filtered
+}
+
+func (s *FilteredEventSink) Emit(ctx context.Context, event Event) error {
+	if s == nil || s.sink == nil {
+		return nil
+	}
+	if !s.keep(event.Type) {
+		return nil
+	}
+	return s.sink.Emit(ctx, event)
+}
+
+func (s *FilteredEventSink) keep(eventType EventType) bool {
+	if s.include != nil {
+		if _, ok := s.include[eventType]; !ok {
+			return false
+		}
+	}
+	if _, ok := s.exclude[eventType]; ok {
+		return false
+	}
+	percent, ok := s.sample[eventType]
+	if !ok || percent >= 100 {
+		return true
+	}
+	if percent <= 0 {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seen[eventType]++
+	n := s.seen[eventType]
+	return n*percent/100 > (n-1)*percent/100
+}
@@ -0,0 +1,62 @@
+package contracts
+
+import (
+	"context"
+	"testing"
+)
+
+type collectingEventSink struct {
+	events []Event
+}
+
+func (c *collectingEventSink) Emit(_ context.Context, event Event) error {
+	c.events = append(c.events, event)
+	return nil
+}
+
+func TestNewFilteredEventSinkReturnsSinkForZeroFilter(t *testing.T) {
+	sink := &collectingEventSink{}
+	if got := NewFilteredEventSink(sink, EventFilter{}); got != EventSink(sink) {
+		t.Fatalf("expected zero filter to return the sink unchanged, got %T", got)
+	}
+}
+
+func TestFilteredEventSinkAppliesIncludeAndExclude(t *testing.T) {
+	sink := &collectingEventSink{}
+	filtered := NewFilteredEventSink(sink, EventFilter{
+		Include: []EventType{EventTypeTaskStarted, EventTypeTaskFinished, EventTypeRunnerHeartbeat},
+		Exclude: []EventType{EventTypeRunnerHeartbeat},
+	})
+
+	for _, eventType := range []EventType{EventTypeTaskStarted, EventTypeRunnerOutput, EventTypeRunnerHeartbeat, EventTypeTaskFinished} {
+		if err := filtered.Emit(context.Background(), Event{Type: eventType}); err != nil {
+			t.Fatalf("emit %s: %v", eventType, err)
+		}
+	}
+
+	if len(sink.events) != 2 || sink.events[0].Type != EventTypeTaskStarted || sink.events[1].Type != EventTypeTaskFinished {
+		t.Fatalf("expected only included, non-excluded events, got %#v", sink.events)
+	}
+}
+
+func TestFilteredEventSinkSamplesPerEventType(t *testing.T) {
+	sink := &collectingEventSink{}
+	filtered := NewFilteredEventSink(sink, EventFilter{SamplePercent: map[EventType]int{
+		EventTypeRunnerOutput:    25,
+		EventTypeRunnerHeartbeat: 0,
+	}})
+
+	for i := 0; i < 8; i++ {
+		_ = filtered.Emit(context.Background(), Event{Type: EventTypeRunnerOutput, Message: string(rune('a' + i))})
+		_ = filtered.Emit(context.Background(), Event{Type: EventTypeRunnerHeartbeat})
+	}
+	_ = filtered.Emit(context.Background(), Event{Type: EventTypeTaskFinished})
+
+	got := ""
+	for _, event := range sink.events {
+		got += string(event.Type) + ":" + event.Message + " "
+	}
+	if want := "runner_output:d runner_output:h task_finished: "; got != want {
+		t.Fatalf("unexpected sampled events\n got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,120 @@
+package contracts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FailureCategory says why a task ended blocked or failed. The loop records
+// it next to the free-text triage_reason so retry policies, dashboards and
+// reports can key on it instead of matching the reason text.
+type FailureCategory string
+
+const (
+	// FailureCategoryPreflight means the preflight checks failed.
+	FailureCategoryPreflight FailureCategory = "preflight"
+	// FailureCategoryMergeQueueConflict means the task could not land on main.
+	FailureCategoryMergeQueueConflict FailureCategory = "merge_queue_conflict"
+	// FailureCategoryReviewGating means review rejected the implementation.
+	FailureCategoryReviewGating FailureCategory = "review_gating"
+	// FailureCategoryRunnerTimeoutStall means the runner timed out.
+	FailureCategoryRunnerTimeoutStall FailureCategory = "runner_timeout_stall"
+	// FailureCategoryRunnerInit means the runner could not start.
+	FailureCategoryRunnerInit FailureCategory = "runner_init"
+	// FailureCategoryAuthProfileConfig means credentials, a profile or the
+	// config were wrong.
+	FailureCategoryAuthProfileConfig FailureCategory = "auth_profile_config"
+	// FailureCategoryFilesystemClone means the repository or a clone of it was
+	// missing.
+	FailureCategoryFilesystemClone FailureCategory = "filesystem_clone"
+	// FailureCategoryLockContention means another worker held a lock.
+	FailureCategoryLockContention FailureCategory = "lock_contention"
+	// FailureCategoryTracker means the task tracker failed.
+	FailureCategoryTracker FailureCategory = "tracker"
+	// FailureCategoryGitVCS means the repository state got in the way.
+	FailureCategoryGitVCS FailureCategory = "git/vcs"
+	// FailureCategoryQuestion and the other stall categories mean the runner
+	// stalled; see StallCategory.
+	FailureCategoryQuestion      FailureCategory = FailureCategory(StallCategoryQuestion)
+	FailureCategoryWaitingOnTool FailureCategory = FailureCategory(StallCategoryWaitingOnTool)
+	FailureCategoryRateLimit     FailureCategory = FailureCategory(StallCategoryRateLimit)
+	FailureCategorySilence       FailureCategory = FailureCategory(StallCategorySilence)
+	// FailureCategoryTDDGate means TDD mode found no failing tests to start
+	// from.
+	FailureCategoryTDDGate FailureCategory = "tdd_gate"
+	// FailureCategoryQualityGate means the task description or the quality
+	// control tools scored below their threshold.
+	FailureCategoryQualityGate FailureCategory = "quality_gate"
+	// FailureCategoryDiffGuardrail means the diff was larger than allowed.
+	FailureCategoryDiffGuardrail FailureCategory = "diff_guardrail"
+	// FailureCategoryPathScope means files outside the task's path scope
+	// changed.
+	FailureCategoryPathScope FailureCategory = "path_scope"
+	// FailureCategorySecretScan means the diff looked like it held a secret.
+	FailureCategorySecretScan FailureCategory = "secret_scan"
+	// FailureCategoryDependencyPolicy means a dependency change broke policy.
+	FailureCategoryDependencyPolicy FailureCategory = "dependency_policy"
+	// FailureCategoryOperatorCancel means an operator canceled the task.
+	FailureCategoryOperatorCancel FailureCategory = "operator_cancel"
+	// FailureCategoryOrphaned means a crashed run left the task in progress.
+	FailureCategoryOrphaned FailureCategory = "orphaned"
+	// FailureCategoryPipelineStage means a configured pipeline stage failed
+	// or was not approved.
+	FailureCategoryPipelineStage FailureCategory = "pipeline_stage"
+	// FailureCategoryUnknown is every other failure.
+	FailureCategoryUnknown FailureCategory = "unknown"
+)
+
+// FailureCategories lists the categories in the order they are documented.
+var FailureCategories = []FailureCategory{
+	FailureCategoryQuestion,
+	FailureCategoryWaitingOnTool,
+	FailureCategoryRateLimit,
+	FailureCategorySilence,
+	FailureCategoryPreflight,
+	FailureCategoryMergeQueueConflict,
+	FailureCategoryReviewGating,
+	FailureCategoryRunnerTimeoutStall,
+	FailureCategoryRunnerInit,
+	FailureCategoryAuthProfileConfig,
+	FailureCategoryFilesystemClone,
+	FailureCategoryLockContention,
+	FailureCategoryTracker,
+	FailureCategoryGitVCS,
+	FailureCategoryTDDGate,
+	FailureCategoryQualityGate,
+	FailureCategoryDiffGuardrail,
+	FailureCategoryPathScope,
+	FailureCategorySecretScan,
+	FailureCategoryDependencyPolicy,
+	FailureCategoryOperatorCancel,
+	FailureCategoryOrphaned,
+	FailureCategoryPipelineStage,
+	FailureCategoryUnknown,
+}
+
+// TriageCategoryMetadataKey is the task data and event metadata key holding
+// the FailureCategory of a blocked or failed task. triage_reason keeps the
+// details.
+const TriageCategoryMetadataKey = "triage_category"
+
+// ParseFailureCategory accepts only the taxonomy names.
+func ParseFailureCategory(raw string) (FailureCategory, error) {
+	value := FailureCategory(strings.ToLower(strings.TrimSpace(raw)))
+	for _, category := range FailureCategories {
+		if value == category {
+			return category, nil
+		}
+	}
+	return "", fmt.Errorf("unknown failure category %q", strings.TrimSpace(raw))
+}
+
+// FailureCategoryFromMetadata returns the failure category recorded in task
+// data or event metadata, or "" when there is none.
+func FailureCategoryFromMetadata(metadata map[string]string) FailureCategory {
+	category, err := ParseFailureCategory(metadata[TriageCategoryMetadataKey])
+	if err != nil {
+		return ""
+	}
+	return category
+}
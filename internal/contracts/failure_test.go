@@ -0,0 +1,27 @@
+package contracts
+
+import "testing"
+
+func TestParseFailureCategoryAcceptsTaxonomyNames(t *testing.T) {
+	if category, err := ParseFailureCategory(" Quality_Gate "); err != nil || category != FailureCategoryQualityGate {
+		t.Fatalf("expected quality_gate, got %q err=%v", category, err)
+	}
+	if category, err := ParseFailureCategory("rate_limit"); err != nil || category != FailureCategory(StallCategoryRateLimit) {
+		t.Fatalf("expected stall categories accepted, got %q err=%v", category, err)
+	}
+	if _, err := ParseFailureCategory("tests keep failing"); err == nil {
+		t.Fatalf("expected free text rejected")
+	}
+}
+
+func TestFailureCategoryFromMetadataIgnoresUnknownValues(t *testing.T) {
+	if got := FailureCategoryFromMetadata(map[string]string{TriageCategoryMetadataKey: "git/vcs"}); got != FailureCategoryGitVCS {
+		t.Fatalf("expected git/vcs, got %q", got)
+	}
+	if got := FailureCategoryFromMetadata(map[string]string{TriageCategoryMetadataKey: "flaky"}); got != "" {
+		t.Fatalf("expected unknown value ignored, got %q", got)
+	}
+	if got := FailureCategoryFromMetadata(nil); got != "" {
+		t.Fatalf("expected no category without metadata, got %q", got)
+	}
+}
@@ -1,20 +1,55 @@
 package contracts
 
 import (
+	"compress/gzip"
 	"context"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
+// eventLogSegmentLayout stamps rotated segments. It has a fixed width, so
+// segment names sort in rotation order.
+const eventLogSegmentLayout = "20060102T150405.000000000Z"
+
+// FileEventSinkOptions configures rotation of the event log. The zero value
+// keeps appending to a single file.
+type FileEventSinkOptions struct {
+	// MaxBytes rotates the log before a write would grow it past this size.
+	MaxBytes int64
+	// MaxAge rotates the log once the sink has been writing to it this long.
+	MaxAge time.Duration
+	// Compress gzips rotated segments.
+	Compress bool
+	// MaxSegments keeps at most this many rotated segments. 0 keeps all.
+	MaxSegments int
+	// RetainFor removes segments rotated longer ago than this. 0 keeps all.
+	RetainFor time.Duration
+}
+
+func (o FileEventSinkOptions) rotates() bool {
+	return o.MaxBytes > 0 || o.MaxAge > 0
+}
+
 type FileEventSink struct {
-	path string
-	mu   sync.Mutex
+	path    string
+	options FileEventSinkOptions
+	now     func() time.Time
+	mu      sync.Mutex
+	// segmentStart is when the sink first wrote to the current segment.
+	segmentStart time.Time
 }
 
 func NewFileEventSink(path string) *FileEventSink {
-	return &FileEventSink{path: path}
+	return NewFileEventSinkWithOptions(path, FileEventSinkOptions{})
+}
+
+func NewFileEventSinkWithOptions(path string, options FileEventSinkOptions) *FileEventSink {
+	return &FileEventSink{path: path, options: options, now: func() time.Time { return time.Now().UTC() }}
 }
 
 func (s *FileEventSink) Emit(_ context.Context, event Event) error {
@@ -33,6 +68,9 @@ func (s *FileEventSink) Emit(_ context.Context, event Event) error {
 	if err != nil {
 		return err
 	}
+	if err := s.rotateIfNeededLocked(int64(len(line))); err != nil {
+		return err
+	}
 	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
 	if err != nil {
 		return err
@@ -41,3 +79,177 @@ func (s *FileEventSink) Emit(_ context.Context, event Event) error {
 	_, err = file.WriteString(line)
 	return err
 }
+
+func (s *FileEventSink) rotateIfNeededLocked(incoming int64) error {
+	if !s.options.rotates() {
+		return nil
+	}
+	now := s.now()
+	if s.segmentStart.IsZero() {
+		s.segmentStart = now
+	}
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		s.segmentStart = now
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+	tooBig := s.options.MaxBytes > 0 && info.Size()+incoming > s.options.MaxBytes
+	tooOld := s.options.MaxAge > 0 && now.Sub(s.segmentStart) >= s.options.MaxAge
+	if !tooBig && !tooOld {
+		return nil
+	}
+
+	segment := s.path + "." + now.Format(eventLogSegmentLayout)
+	if err := os.Rename(s.path, segment); err != nil {
+		return err
+	}
+	s.segmentStart = now
+	if s.options.Compress {
+		if err := gzipFile(segment); err != nil {
+			return err
+		}
+	}
+	return s.pruneSegmentsLocked(now)
+}
+
+func (s *FileEventSink) pruneSegmentsLocked(now time.Time) error {
+	segments, err := EventLogSegments(s.path)
+	if err != nil {
+		return err
+	}
+	keep := len(segments)
+	if s.options.MaxSegments > 0 && keep > s.options.MaxSegments {
+		keep = s.options.MaxSegments
+	}
+	for i, segment := range segments {
+		expired := i < len(segments)-keep
+		if !expired && s.options.RetainFor > 0 {
+			if rotatedAt, ok := segmentRotatedAt(s.path, segment); ok && now.Sub(rotatedAt) > s.options.RetainFor {
+				expired = true
+			}
+		}
+		if expired {
+			if err := os.Remove(segment); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EventLogSegments lists the rotated segments of the event log at path,
+// oldest first. The live log itself is not included.
+func EventLogSegments(path string) ([]string, error) {
+	matches, err := filepath.Glob(globEscape(path) + ".*")
+	if err != nil {
+		return nil, err
+	}
+	segments := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if _, ok := segmentRotatedAt(path, match); ok {
+			segments = append(segments, match)
+		}
+	}
+	sort.Strings(segments)
+	return segments, nil
+}
+
+func segmentRotatedAt(path string, segment string) (time.Time, bool) {
+	stamp := strings.TrimSuffix(strings.TrimPrefix(segment, path+"."), ".gz")
+	rotatedAt, err := time.Parse(eventLogSegmentLayout, stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return rotatedAt, true
+}
+
+func globEscape(path string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`)
+	return replacer.Replace(path)
+}
+
+func gzipFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	target, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	writer := gzip.NewWriter(target)
+	if _, err := io.Copy(writer, source); err != nil {
+		_ = writer.Close()
+		_ = target.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		_ = target.Close()
+		return err
+	}
+	if err := target.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// OpenEventLog returns a reader over the event log at path and its rotated
+// segments, oldest first, decompressing gzipped segments on the fly.
+func OpenEventLog(path string) (io.ReadCloser, error) {
+	segments, err := EventLogSegments(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil || len(segments) == 0 {
+		segments = append(segments, path)
+	}
+	log := &eventLogReader{}
+	readers := make([]io.Reader, 0, len(segments))
+	for _, segment := range segments {
+		file, err := os.Open(segment)
+		if err != nil {
+			_ = log.Close()
+			return nil, err
+		}
+		log.closers = append(log.closers, file)
+		reader := io.Reader(file)
+		if strings.HasSuffix(segment, ".gz") {
+			gz, err := gzip.NewReader(file)
+			if err != nil {
+				_ = log.Close()
+				return nil, err
+			}
+			log.closers = append(log.closers, gz)
+			reader = gz
+		}
+		readers = append(readers, reader)
+	}
+	log.reader = io.MultiReader(readers...)
+	return log, nil
+}
+
+type eventLogReader struct {
+	reader  io.Reader
+	closers []io.Closer
+}
+
+func (r *eventLogReader) Read(p []byte) (int, error) {
+	return r.reader.Read(p)
+}
+
+func (r *eventLogReader) Close() error {
+	var firstErr error
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		if err := r.closers[i].Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
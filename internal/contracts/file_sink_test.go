@@ -3,6 +3,8 @@ package contracts
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -121,3 +123,123 @@ func TestFanoutEventSinkBroadcastsToAllSinks(t *testing.T) {
 		t.Fatalf("expected both sinks to receive event, got left=%q right=%q", left.String(), right.String())
 	}
 }
+
+func TestFileEventSinkRotatesBySizeAndCompressesSegments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.events.jsonl")
+	clock := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	sink := NewFileEventSinkWithOptions(path, FileEventSinkOptions{MaxBytes: 200, Compress: true})
+	sink.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	for i := 0; i < 6; i++ {
+		if err := sink.Emit(context.Background(), Event{Type: EventTypeRunnerOutput, TaskID: "task-1", Message: fmt.Sprintf("line %d", i), Timestamp: clock}); err != nil {
+			t.Fatalf("emit %d: %v", i, err)
+		}
+	}
+
+	segments, err := EventLogSegments(path)
+	if err != nil {
+		t.Fatalf("list segments: %v", err)
+	}
+	if len(segments) < 2 {
+		t.Fatalf("expected size rotation to produce segments, got %v", segments)
+	}
+	for _, segment := range segments {
+		if !strings.HasSuffix(segment, ".gz") {
+			t.Fatalf("expected compressed segment, got %s", segment)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() > 200 {
+		t.Fatalf("expected live log under the size limit, got %v %v", info, err)
+	}
+
+	assertEventLogMessages(t, path, "line 0|line 1|line 2|line 3|line 4|line 5")
+}
+
+func TestFileEventSinkRotatesByAgeAndPrunesSegments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.events.jsonl")
+	clock := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	sink := NewFileEventSinkWithOptions(path, FileEventSinkOptions{MaxAge: time.Hour, MaxSegments: 2})
+	sink.now = func() time.Time { return clock }
+
+	for i := 0; i < 5; i++ {
+		if err := sink.Emit(context.Background(), Event{Type: EventTypeTaskStarted, Message: fmt.Sprintf("hour %d", i)}); err != nil {
+			t.Fatalf("emit %d: %v", i, err)
+		}
+		clock = clock.Add(time.Hour)
+	}
+
+	segments, err := EventLogSegments(path)
+	if err != nil {
+		t.Fatalf("list segments: %v", err)
+	}
+	if len(segments) != 2 {
+		t.Fatalf("expected retention to keep two segments, got %v", segments)
+	}
+	assertEventLogMessages(t, path, "hour 2|hour 3|hour 4")
+}
+
+func TestFileEventSinkRemovesSegmentsPastRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.events.jsonl")
+	clock := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	sink := NewFileEventSinkWithOptions(path, FileEventSinkOptions{MaxAge: time.Hour, RetainFor: 90 * time.Minute})
+	sink.now = func() time.Time { return clock }
+
+	for i := 0; i < 4; i++ {
+		if err := sink.Emit(context.Background(), Event{Type: EventTypeTaskStarted, Message: fmt.Sprintf("hour %d", i)}); err != nil {
+			t.Fatalf("emit %d: %v", i, err)
+		}
+		clock = clock.Add(time.Hour)
+	}
+
+	// The segment holding hour 0 was rotated two hours before the last write.
+	assertEventLogMessages(t, path, "hour 1|hour 2|hour 3")
+}
+
+func TestOpenEventLogReadsRotatedSegmentsWithoutLiveLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.events.jsonl")
+	clock := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	sink := NewFileEventSinkWithOptions(path, FileEventSinkOptions{MaxAge: time.Minute, Compress: true})
+	sink.now = func() time.Time { return clock }
+	for i := 0; i < 2; i++ {
+		if err := sink.Emit(context.Background(), Event{Type: EventTypeTaskStarted, Message: fmt.Sprintf("event %d", i)}); err != nil {
+			t.Fatalf("emit %d: %v", i, err)
+		}
+		clock = clock.Add(time.Minute)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("remove live log: %v", err)
+	}
+
+	assertEventLogMessages(t, path, "event 0")
+
+	if _, err := OpenEventLog(filepath.Join(t.TempDir(), "missing.jsonl")); err == nil {
+		t.Fatalf("expected error for a log with no segments")
+	}
+}
+
+func assertEventLogMessages(t *testing.T, path string, want string) {
+	t.Helper()
+	reader, err := OpenEventLog(path)
+	if err != nil {
+		t.Fatalf("open event log: %v", err)
+	}
+	defer reader.Close()
+	decoder := NewEventDecoder(reader)
+	messages := []string{}
+	for {
+		event, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("decode event log: %v", err)
+		}
+		messages = append(messages, event.Message)
+	}
+	if got := strings.Join(messages, "|"); got != want {
+		t.Fatalf("unexpected replayed events\n got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package contracts
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// FollowUpsArtifactKey holds the JSON-encoded deferred work reported by an
+// implement run.
+const FollowUpsArtifactKey = "follow_ups"
+
+// followUpLinePattern matches `FOLLOW_UP: title - details`. Like
+// reviewCriterionLinePattern it stops at quotes and backslashes so it also
+// works on JSON-encoded transcripts.
+var followUpLinePattern = regexp.MustCompile(`(?i)FOLLOW_UP:[ \t]*([^\n\r"\\]*)`)
+
+type FollowUpItem struct {
+	Title   string `json:"title"`
+	Details string `json:"details,omitempty"`
+}
+
+// ParseFollowUps extracts FOLLOW_UP lines from runner output. Items are
+// de-duplicated by title and placeholder titles such as `<title>` are ignored.
+func ParseFollowUps(text string) []FollowUpItem {
+	items := []FollowUpItem{}
+	seen := map[string]struct{}{}
+	for _, match := range followUpLinePattern.FindAllStringSubmatch(text, -1) {
+		title, details, _ := strings.Cut(strings.Join(strings.Fields(match[1]), " "), " - ")
+		title = strings.TrimSpace(title)
+		if title == "" || strings.HasPrefix(title, "<") {
+			continue
+		}
+		key := strings.ToLower(title)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		items = append(items, FollowUpItem{Title: title, Details: strings.TrimSpace(details)})
+	}
+	return items
+}
+
+func EncodeFollowUps(items []FollowUpItem) string {
+	if len(items) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func DecodeFollowUps(raw string) []FollowUpItem {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	items := []FollowUpItem{}
+	if err := json.Unmarshal([]byte(raw), &items); err != nil {
+		return nil
+	}
+	return items
+}
+
+func followUpsFromLog(logPath string) string {
+	if strings.TrimSpace(logPath) == "" {
+		return ""
+	}
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		return ""
+	}
+	return EncodeFollowUps(ParseFollowUps(string(content)))
+}
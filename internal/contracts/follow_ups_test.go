@@ -0,0 +1,43 @@
+package contracts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFollowUpsReadsPlainAndEscapedTranscripts(t *testing.T) {
+	text := "FOLLOW_UP: Add retry metrics - counters are not exported yet\n" +
+		`{"text":"FOLLOW_UP: Document config flag\nDone."}` + "\n" +
+		"FOLLOW_UP: <title> - <details>\n" +
+		"follow_up: add retry metrics - duplicate\n"
+
+	items := ParseFollowUps(text)
+	if len(items) != 2 {
+		t.Fatalf("expected 2 follow-ups, got %#v", items)
+	}
+	if items[0] != (FollowUpItem{Title: "Add retry metrics", Details: "counters are not exported yet"}) {
+		t.Fatalf("unexpected first follow-up %#v", items[0])
+	}
+	if items[1] != (FollowUpItem{Title: "Document config flag"}) {
+		t.Fatalf("unexpected second follow-up %#v", items[1])
+	}
+}
+
+func TestFollowUpsRoundTripThroughImplementArtifacts(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "implement.jsonl")
+	if err := os.WriteFile(logPath, []byte("FOLLOW_UP: Split parser - too large for this task\n"), 0o644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	artifacts := BuildRunnerArtifacts("codex", RunnerRequest{Mode: RunnerModeImplement}, RunnerResult{Status: RunnerResultCompleted, LogPath: logPath}, nil)
+	decoded := DecodeFollowUps(artifacts[FollowUpsArtifactKey])
+	if len(decoded) != 1 || decoded[0].Title != "Split parser" || decoded[0].Details != "too large for this task" {
+		t.Fatalf("expected follow-ups artifact, got %#v", artifacts)
+	}
+
+	review := BuildRunnerArtifacts("codex", RunnerRequest{Mode: RunnerModeReview}, RunnerResult{Status: RunnerResultCompleted, LogPath: logPath}, nil)
+	if _, ok := review[FollowUpsArtifactKey]; ok {
+		t.Fatalf("did not expect follow-ups for review runs, got %#v", review)
+	}
+}
@@ -0,0 +1,62 @@
+package contracts
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+const (
+	PlanEntryPending    = "pending"
+	PlanEntryInProgress = "in_progress"
+	PlanEntryCompleted  = "completed"
+)
+
+// PlanEntry is one step of the plan an agent reports while it works.
+type PlanEntry struct {
+	Content  string `json:"content"`
+	Priority string `json:"priority,omitempty"`
+	Status   string `json:"status"`
+}
+
+// PlanUpdatedMetadata encodes the full plan into event metadata. Agents resend
+// the whole plan on every update, so consumers replace rather than merge.
+func PlanUpdatedMetadata(entries []PlanEntry) map[string]string {
+	payload, err := json.Marshal(entries)
+	if err != nil || len(entries) == 0 {
+		payload = []byte("[]")
+	}
+	return map[string]string{
+		"plan_entries":   string(payload),
+		"plan_total":     strconv.Itoa(len(entries)),
+		"plan_completed": strconv.Itoa(completedPlanEntries(entries)),
+	}
+}
+
+// PlanEntriesFromMetadata decodes the plan carried by a plan_updated event.
+func PlanEntriesFromMetadata(metadata map[string]string) []PlanEntry {
+	raw := strings.TrimSpace(metadata["plan_entries"])
+	if raw == "" {
+		return nil
+	}
+	entries := []PlanEntry{}
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		return nil
+	}
+	return entries
+}
+
+// PlanUpdatedMessage summarizes a plan as "plan N/M completed".
+func PlanUpdatedMessage(entries []PlanEntry) string {
+	return "plan " + strconv.Itoa(completedPlanEntries(entries)) + "/" + strconv.Itoa(len(entries)) + " completed"
+}
+
+func completedPlanEntries(entries []PlanEntry) int {
+	completed := 0
+	for _, entry := range entries {
+		if entry.Status == PlanEntryCompleted {
+			completed++
+		}
+	}
+	return completed
+}
@@ -0,0 +1,34 @@
+package contracts
+
+import "testing"
+
+func TestPlanUpdatedMetadataRoundTrip(t *testing.T) {
+	entries := []PlanEntry{
+		{Content: "read code", Priority: "high", Status: PlanEntryCompleted},
+		{Content: "write fix", Priority: "medium", Status: PlanEntryInProgress},
+		{Content: "run tests", Status: PlanEntryPending},
+	}
+	metadata := PlanUpdatedMetadata(entries)
+	if metadata["plan_total"] != "3" || metadata["plan_completed"] != "1" {
+		t.Fatalf("unexpected plan counters %#v", metadata)
+	}
+	decoded := PlanEntriesFromMetadata(metadata)
+	if len(decoded) != 3 || decoded[1] != entries[1] || decoded[2] != entries[2] {
+		t.Fatalf("unexpected decoded plan %#v", decoded)
+	}
+	if got := PlanUpdatedMessage(entries); got != "plan 1/3 completed" {
+		t.Fatalf("unexpected plan message %q", got)
+	}
+}
+
+func TestPlanEntriesFromMetadataIgnoresMissingOrInvalidPayload(t *testing.T) {
+	if entries := PlanEntriesFromMetadata(nil); entries != nil {
+		t.Fatalf("expected nil for missing plan, got %#v", entries)
+	}
+	if entries := PlanEntriesFromMetadata(map[string]string{"plan_entries": "{"}); entries != nil {
+		t.Fatalf("expected nil for invalid plan, got %#v", entries)
+	}
+	if entries := PlanEntriesFromMetadata(PlanUpdatedMetadata(nil)); len(entries) != 0 {
+		t.Fatalf("expected empty plan, got %#v", entries)
+	}
+}
@@ -0,0 +1,146 @@
+package contracts
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Redaction presets for common sensitive values.
+const (
+	RedactionPresetAPIKeys    = "api_keys"
+	RedactionPresetEmails     = "emails"
+	RedactionPresetPrivateIPs = "private_ips"
+)
+
+var redactionPresets = map[string][]string{
+	RedactionPresetAPIKeys: {
+		`\bsk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}`,
+		`\bgh[pousr]_[A-Za-z0-9]{30,}`,
+		`\bgithub_pat_[A-Za-z0-9_]{22,}`,
+		`\bglpat-[A-Za-z0-9_-]{20,}`,
+		`\blin_api_[A-Za-z0-9]{20,}`,
+		`\bxox[abprs]-[A-Za-z0-9-]{10,}`,
+		`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+		`\bAIza[0-9A-Za-z_-]{35}`,
+		`(?i)\bbearer\s+[A-Za-z0-9._~+/-]{20,}=*`,
+	},
+	RedactionPresetEmails: {
+		`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`,
+	},
+	RedactionPresetPrivateIPs: {
+		`\b10(?:\.\d{1,3}){3}\b`,
+		`\b192\.168(?:\.\d{1,3}){2}\b`,
+		`\b172\.(?:1[6-9]|2\d|3[01])(?:\.\d{1,3}){2}\b`,
+	},
+}
+
+// RedactionPresets returns the names of the built-in redaction presets.
+func RedactionPresets() []string {
+	names := make([]string, 0, len(redactionPresets))
+	for name := range redactionPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type redactionRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// Redactor masks sensitive values in event text. Each match is replaced with
+// [redacted:<name>], where name is the preset or pattern that matched.
+type Redactor struct {
+	rules []redactionRule
+}
+
+// NewRedactor builds a Redactor from preset names and named regular
+// expressions. It returns nil when there is nothing to redact.
+func NewRedactor(presets []string, patterns map[string]string) (*Redactor, error) {
+	redactor := &Redactor{}
+	for _, preset := range presets {
+		name := strings.TrimSpace(preset)
+		expressions, ok := redactionPresets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown redaction preset %q (supported: %s)", preset, strings.Join(RedactionPresets(), ", "))
+		}
+		for _, expression := range expressions {
+			redactor.rules = append(redactor.rules, redactionRule{pattern: regexp.MustCompile(expression), replacement: redactionMarker(name)})
+		}
+	}
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pattern, err := regexp.Compile(patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %q: %w", name, err)
+		}
+		if pattern.MatchString("") {
+			return nil, fmt.Errorf("redaction pattern %q must not match empty text", name)
+		}
+		redactor.rules = append(redactor.rules, redactionRule{pattern: pattern, replacement: redactionMarker(strings.TrimSpace(name))})
+	}
+	if len(redactor.rules) == 0 {
+		return nil, nil
+	}
+	return redactor, nil
+}
+
+func redactionMarker(name string) string {
+	return "[redacted:" + strings.ReplaceAll(name, "$", "$$") + "]"
+}
+
+// Redact masks every match in text.
+func (r *Redactor) Redact(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+	for _, rule := range r.rules {
+		text = rule.pattern.ReplaceAllString(text, rule.replacement)
+	}
+	return text
+}
+
+// RedactEvent masks the title, message and metadata values of an event
+// without touching the caller's metadata map.
+func (r *Redactor) RedactEvent(event Event) Event {
+	if r == nil {
+		return event
+	}
+	event.TaskTitle = r.Redact(event.TaskTitle)
+	event.Message = r.Redact(event.Message)
+	if len(event.Metadata) > 0 {
+		redacted := make(map[string]string, len(event.Metadata))
+		for key, value := range event.Metadata {
+			redacted[key] = r.Redact(value)
+		}
+		event.Metadata = redacted
+	}
+	return event
+}
+
+// RedactingEventSink redacts events before passing them to another sink.
+type RedactingEventSink struct {
+	sink     EventSink
+	redactor *Redactor
+}
+
+// NewRedactingEventSink wraps sink with redactor. A nil redactor returns sink
+// unchanged.
+func NewRedactingEventSink(sink EventSink, redactor *Redactor) EventSink {
+	if sink == nil || redactor == nil {
+		return sink
+	}
+	return &RedactingEventSink{sink: sink, redactor: redactor}
+}
+
+func (s *RedactingEventSink) Emit(ctx context.Context, event Event) error {
+	return s.sink.Emit(ctx, s.redactor.RedactEvent(event))
+}
@@ -0,0 +1,82 @@
+package contracts
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ReviewCriteriaArtifactKey holds the JSON-encoded per-criterion review results.
+const ReviewCriteriaArtifactKey = "review_criteria"
+
+const (
+	ReviewCriterionPass = "pass"
+	ReviewCriterionFail = "fail"
+)
+
+// reviewCriterionLinePattern matches `REVIEW_CRITERION: AC1 pass - note`. The
+// note stops at quotes and backslashes so the pattern also works on
+// JSON-encoded transcripts where newlines are escaped.
+var reviewCriterionLinePattern = regexp.MustCompile(`(?i)REVIEW_CRITERION:\s*\[?([A-Za-z]+[0-9]+)\]?\s*[:=]?\s*(pass|fail)\b[ \t]*(?:[-:][ \t]*)?([^\n\r"\\]*)`)
+
+type ReviewCriterionResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Note   string `json:"note,omitempty"`
+}
+
+// ParseReviewCriteria extracts REVIEW_CRITERION lines from review output. When
+// a criterion is reported more than once the last report wins.
+func ParseReviewCriteria(text string) []ReviewCriterionResult {
+	results := []ReviewCriterionResult{}
+	indexByID := map[string]int{}
+	for _, match := range reviewCriterionLinePattern.FindAllStringSubmatch(text, -1) {
+		result := ReviewCriterionResult{
+			ID:     strings.ToUpper(match[1]),
+			Status: strings.ToLower(match[2]),
+			Note:   strings.Join(strings.Fields(match[3]), " "),
+		}
+		if idx, ok := indexByID[result.ID]; ok {
+			results[idx] = result
+			continue
+		}
+		indexByID[result.ID] = len(results)
+		results = append(results, result)
+	}
+	return results
+}
+
+func EncodeReviewCriteria(results []ReviewCriterionResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func DecodeReviewCriteria(raw string) []ReviewCriterionResult {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	results := []ReviewCriterionResult{}
+	if err := json.Unmarshal([]byte(raw), &results); err != nil {
+		return nil
+	}
+	return results
+}
+
+func reviewCriteriaFromLog(logPath string) string {
+	if strings.TrimSpace(logPath) == "" {
+		return ""
+	}
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		return ""
+	}
+	return EncodeReviewCriteria(ParseReviewCriteria(string(content)))
+}
@@ -0,0 +1,42 @@
+package contracts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseReviewCriteriaReadsPlainAndEscapedTranscripts(t *testing.T) {
+	text := "REVIEW_CRITERION: AC1 pass - tests cover parser\n" +
+		`{"text":"REVIEW_CRITERION: [ac2] fail: missing docs\nREVIEW_VERDICT: fail"}` + "\n" +
+		"REVIEW_CRITERION: AC1: fail - regression found\n"
+
+	results := ParseReviewCriteria(text)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 criteria, got %#v", results)
+	}
+	if results[0] != (ReviewCriterionResult{ID: "AC1", Status: ReviewCriterionFail, Note: "regression found"}) {
+		t.Fatalf("expected last AC1 report to win, got %#v", results[0])
+	}
+	if results[1] != (ReviewCriterionResult{ID: "AC2", Status: ReviewCriterionFail, Note: "missing docs"}) {
+		t.Fatalf("unexpected AC2 result %#v", results[1])
+	}
+}
+
+func TestReviewCriteriaRoundTripThroughArtifacts(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "review.jsonl")
+	if err := os.WriteFile(logPath, []byte("REVIEW_CRITERION: AC1 pass\nREVIEW_VERDICT: pass\n"), 0o644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	artifacts := BuildRunnerArtifacts("codex", RunnerRequest{Mode: RunnerModeReview}, RunnerResult{Status: RunnerResultCompleted, LogPath: logPath}, nil)
+	decoded := DecodeReviewCriteria(artifacts[ReviewCriteriaArtifactKey])
+	if len(decoded) != 1 || decoded[0].ID != "AC1" || decoded[0].Status != ReviewCriterionPass {
+		t.Fatalf("expected review criteria artifact, got %#v", artifacts)
+	}
+
+	implement := BuildRunnerArtifacts("codex", RunnerRequest{Mode: RunnerModeImplement}, RunnerResult{Status: RunnerResultCompleted, LogPath: logPath}, nil)
+	if _, ok := implement[ReviewCriteriaArtifactKey]; ok {
+		t.Fatalf("did not expect review criteria for implement runs, got %#v", implement)
+	}
+}
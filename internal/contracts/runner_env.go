@@ -0,0 +1,158 @@
+package contracts
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SecretMask replaces secret values in logs and events.
+const SecretMask = "***"
+
+// RunnerEnv returns env as KEY=value entries sorted by key, to append to the
+// environment of a runner or hook process.
+func RunnerEnv(env map[string]string) []string {
+	if len(env) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		if strings.TrimSpace(key) != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	entries := make([]string, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, key+"="+env[key])
+	}
+	return entries
+}
+
+// MaskSecrets replaces every occurrence of each secret in text with
+// SecretMask. Longer secrets are masked first so a secret containing another
+// is not left half visible.
+func MaskSecrets(text string, secrets []string) string {
+	if text == "" || len(secrets) == 0 {
+		return text
+	}
+	ordered := append([]string(nil), secrets...)
+	sort.SliceStable(ordered, func(i, j int) bool { return len(ordered[i]) > len(ordered[j]) })
+	for _, secret := range ordered {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, SecretMask)
+		}
+	}
+	return text
+}
+
+// MaskEventSecrets masks secrets in the task title, message and metadata of
+// event without touching the caller's metadata map.
+func MaskEventSecrets(event Event, secrets []string) Event {
+	if len(secrets) == 0 {
+		return event
+	}
+	event.TaskTitle = MaskSecrets(event.TaskTitle, secrets)
+	event.Message = MaskSecrets(event.Message, secrets)
+	if len(event.Metadata) > 0 {
+		masked := make(map[string]string, len(event.Metadata))
+		for key, value := range event.Metadata {
+			masked[key] = MaskSecrets(value, secrets)
+		}
+		event.Metadata = masked
+	}
+	return event
+}
+
+// SecretMaskingEventSink masks secrets in events before passing them to
+// another sink.
+type SecretMaskingEventSink struct {
+	sink    EventSink
+	secrets []string
+}
+
+// NewSecretMaskingEventSink wraps sink so secrets never reach it. Without
+// secrets it returns sink unchanged.
+func NewSecretMaskingEventSink(sink EventSink, secrets []string) EventSink {
+	if sink == nil || len(secrets) == 0 {
+		return sink
+	}
+	return &SecretMaskingEventSink{sink: sink, secrets: secrets}
+}
+
+func (s *SecretMaskingEventSink) Emit(ctx context.Context, event Event) error {
+	return s.sink.Emit(ctx, MaskEventSecrets(event, s.secrets))
+}
+
+// CreateRunnerLog creates the runner log at path. When secrets are given
+// they are masked as the runner writes. Output that ends with the start of a
+// secret is held back until the next write shows whether the secret
+// follows, so a secret split across writes is still masked; Close writes
+// whatever is held back.
+func CreateRunnerLog(path string, secrets []string) (io.WriteCloser, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(secrets) == 0 {
+		return file, nil
+	}
+	return &secretMaskingLog{file: file, secrets: secrets}, nil
+}
+
+type secretMaskingLog struct {
+	mu      sync.Mutex
+	file    *os.File
+	secrets []string
+	pending []byte
+}
+
+func (l *secretMaskingLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = append(l.pending, p...)
+	end := len(l.pending) - partialSecretSuffix(l.pending, l.secrets)
+	if err := l.writeMasked(l.pending[:end]); err != nil {
+		return 0, err
+	}
+	l.pending = append(l.pending[:0], l.pending[end:]...)
+	return len(p), nil
+}
+
+func (l *secretMaskingLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.writeMasked(l.pending)
+	l.pending = nil
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (l *secretMaskingLog) writeMasked(chunk []byte) error {
+	if len(chunk) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(l.file, MaskSecrets(string(chunk), l.secrets))
+	return err
+}
+
+// partialSecretSuffix returns the length of the longest end of data that is
+// the start, but not the whole, of a secret.
+func partialSecretSuffix(data []byte, secrets []string) int {
+	longest := 0
+	for _, secret := range secrets {
+		for n := min(len(secret)-1, len(data)); n > longest; n-- {
+			if bytes.HasSuffix(data, []byte(secret[:n])) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
@@ -0,0 +1,62 @@
+package contracts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRunnerEnvSortsEntries(t *testing.T) {
+	got := RunnerEnv(map[string]string{"B": "2", "A": "1", " ": "skipped"})
+	if want := []string{"A=1", "B=2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if RunnerEnv(nil) != nil {
+		t.Fatalf("expected no entries for an empty env")
+	}
+}
+
+func TestMaskSecretsMasksLongestSecretFirst(t *testing.T) {
+	got := MaskSecrets("dsn=postgres://app:hunter2@db pass=hunter2", []string{"hunter2", "postgres://app:hunter2@db", ""})
+	if want := "dsn=*** pass=***"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestCreateRunnerLogMasksSecretsSplitAcrossWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "task.jsonl")
+	log, err := CreateRunnerLog(path, []string{"hunter2"})
+	if err != nil {
+		t.Fatalf("create log: %v", err)
+	}
+	for _, chunk := range []string{"pass=hun", "ter2\n", "h", "unt", "er2 again, then hun"} {
+		if _, err := log.Write([]byte(chunk)); err != nil {
+			t.Fatalf("write %q: %v", chunk, err)
+		}
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("close log: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	if want := "pass=***\n*** again, then hun"; string(content) != want {
+		t.Fatalf("expected %q, got %q", want, content)
+	}
+}
+
+func TestSecretMaskingEventSinkMasksMessageAndMetadata(t *testing.T) {
+	collected := &collectingEventSink{}
+	sink := NewSecretMaskingEventSink(collected, []string{"hunter2"})
+	metadata := map[string]string{"dsn": "postgres://app:hunter2@db"}
+	if err := sink.Emit(context.Background(), Event{Type: EventTypeRunnerOutput, Message: "echo hunter2", Metadata: metadata}); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+	got := collected.events[0]
+	if got.Message != "echo ***" || got.Metadata["dsn"] != "postgres://app:***@db" || metadata["dsn"] != "postgres://app:hunter2@db" {
+		t.Fatalf("expected a masked copy of the event, got %#v (caller metadata %v)", got, metadata)
+	}
+}
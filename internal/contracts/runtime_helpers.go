@@ -14,6 +14,7 @@ type BackendLogKind string
 const (
 	BackendLogStderr        BackendLogKind = "stderr"
 	BackendLogProtocolTrace BackendLogKind = "protocol"
+	BackendLogTerminal      BackendLogKind = "terminal"
 )
 
 type OutputEntryKind string
@@ -99,6 +100,21 @@ func BuildRunnerArtifacts(backend string, request RunnerRequest, result RunnerRe
 			artifacts["clone_path"] = clonePath
 		}
 	}
+	if request.Mode == RunnerModeReview {
+		if criteria := reviewCriteriaFromLog(result.LogPath); criteria != "" {
+			artifacts[ReviewCriteriaArtifactKey] = criteria
+		}
+		if summary := diffSummaryFromLog(result.LogPath); summary != "" {
+			artifacts[DiffSummaryArtifactKey] = summary
+		}
+	} else if request.Mode == RunnerModeImplement {
+		if followUps := followUpsFromLog(result.LogPath); followUps != "" {
+			artifacts[FollowUpsArtifactKey] = followUps
+		}
+	}
+	if paths := taskArtifactsFromLog(result.LogPath); paths != "" {
+		artifacts[TaskArtifactsArtifactKey] = paths
+	}
 	for key, value := range extras {
 		if strings.TrimSpace(key) == "" || strings.TrimSpace(value) == "" {
 			continue
@@ -139,6 +155,8 @@ func BackendLogSidecarPath(logPath string, kind BackendLogKind) string {
 	switch kind {
 	case BackendLogProtocolTrace:
 		return base + ".protocol.log"
+	case BackendLogTerminal:
+		return base + ".terminal.log"
 	case BackendLogStderr:
 		fallthrough
 	default:
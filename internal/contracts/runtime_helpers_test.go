@@ -103,6 +103,9 @@ func TestBackendLogSidecarPathSupportsStderrAndProtocolTrace(t *testing.T) {
 	if got := BackendLogSidecarPath(logPath, BackendLogProtocolTrace); got != "/tmp/runner/task-1.protocol.log" {
 		t.Fatalf("unexpected protocol path %q", got)
 	}
+	if got := BackendLogSidecarPath(logPath, BackendLogTerminal); got != "/tmp/runner/task-1.terminal.log" {
+		t.Fatalf("unexpected terminal path %q", got)
+	}
 }
 
 func TestBackendLogSidecarPathReturnsEmptyForUnsetLogPath(t *testing.T) {
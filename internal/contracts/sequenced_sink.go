@@ -0,0 +1,34 @@
+package contracts
+
+import (
+	"context"
+	"sync"
+)
+
+// SequencedEventSink stamps every event with the run ID and a monotonic
+// sequence number before passing it on. Events are forwarded under the lock,
+// so each downstream sink sees them in sequence order.
+type SequencedEventSink struct {
+	sink  EventSink
+	runID string
+	mu    sync.Mutex
+	next  uint64
+}
+
+func NewSequencedEventSink(runID string, sink EventSink) *SequencedEventSink {
+	return &SequencedEventSink{sink: sink, runID: runID}
+}
+
+func (s *SequencedEventSink) Emit(ctx context.Context, event Event) error {
+	if s == nil || s.sink == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next++
+	event.Seq = s.next
+	if event.RunID == "" {
+		event.RunID = s.runID
+	}
+	return s.sink.Emit(ctx, event)
+}
@@ -0,0 +1,48 @@
+package contracts
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSequencedEventSinkStampsRunIDAndMonotonicSeq(t *testing.T) {
+	collector := &collectingEventSink{}
+	sink := NewSequencedEventSink("run-1", collector)
+
+	for _, eventType := range []EventType{EventTypeRunStarted, EventTypeTaskStarted, EventTypeTaskFinished} {
+		if err := sink.Emit(context.Background(), Event{Type: eventType}); err != nil {
+			t.Fatalf("emit: %v", err)
+		}
+	}
+
+	if len(collector.events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(collector.events))
+	}
+	for i, event := range collector.events {
+		if event.Seq != uint64(i+1) || event.RunID != "run-1" {
+			t.Fatalf("event %d: expected seq=%d run_id=run-1, got seq=%d run_id=%q", i, i+1, event.Seq, event.RunID)
+		}
+	}
+}
+
+func TestEventJSONLRoundTripsRunIDAndSeq(t *testing.T) {
+	line, err := MarshalEventJSONL(Event{Type: EventTypeTaskStarted, TaskID: "t-1", RunID: "run-1", Seq: 42})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	event, err := ParseEventJSONLLine([]byte(line))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if event.RunID != "run-1" || event.Seq != 42 {
+		t.Fatalf("expected run_id and seq to round-trip, got %#v", event)
+	}
+
+	unsequenced, err := MarshalEventJSONL(Event{Type: EventTypeTaskStarted})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if event, err := ParseEventJSONLLine([]byte(unsequenced)); err != nil || event.Seq != 0 || event.RunID != "" {
+		t.Fatalf("expected unsequenced event to stay unsequenced, got %#v err=%v", event, err)
+	}
+}
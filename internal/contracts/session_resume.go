@@ -0,0 +1,56 @@
+package contracts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SessionIDArtifactKey holds the backend conversation an implement run used:
+// the ACP session, the codex thread or the claude session.
+const SessionIDArtifactKey = "session_id"
+
+// Request metadata set by the loop when resume_sessions is enabled.
+// ResumableSessionMetadataKey asks backends to keep the session available
+// after the run; the resume keys ask them to continue an interrupted session
+// instead of starting a new one.
+const (
+	ResumableSessionMetadataKey = "resume_sessions"
+	ResumeSessionIDMetadataKey  = "resume_session_id"
+	ResumePromptMetadataKey     = "resume_prompt"
+)
+
+// ResumableSessionRequested reports whether the run's session may be resumed
+// by a later request.
+func ResumableSessionRequested(request RunnerRequest) bool {
+	if request.Metadata == nil {
+		return false
+	}
+	return strings.TrimSpace(request.Metadata[ResumableSessionMetadataKey]) == "true"
+}
+
+// ResumeSessionFromRequest returns the session to resume and the prompt to
+// continue it with. ok is false when the request starts a fresh session.
+func ResumeSessionFromRequest(request RunnerRequest) (sessionID string, prompt string, ok bool) {
+	if request.Metadata == nil {
+		return "", "", false
+	}
+	sessionID = strings.TrimSpace(request.Metadata[ResumeSessionIDMetadataKey])
+	if sessionID == "" {
+		return "", "", false
+	}
+	prompt = strings.TrimSpace(request.Metadata[ResumePromptMetadataKey])
+	if prompt == "" {
+		prompt = BuildResumePrompt("")
+	}
+	return sessionID, prompt, true
+}
+
+// BuildResumePrompt is the message sent to a resumed session. The session
+// already holds the task prompt, so it only explains why the run stopped.
+func BuildResumePrompt(reason string) string {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		reason = "the runner process stopped"
+	}
+	return fmt.Sprintf("Your previous run on this task was interrupted (%s). Continue from where you left off: check the current state of the repository, finish the remaining work and do not redo steps that are already complete.", reason)
+}
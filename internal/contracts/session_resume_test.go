@@ -0,0 +1,35 @@
+package contracts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResumeSessionFromRequest(t *testing.T) {
+	if _, _, ok := ResumeSessionFromRequest(RunnerRequest{Metadata: map[string]string{"log_path": "x"}}); ok {
+		t.Fatalf("expected fresh session without resume metadata")
+	}
+
+	sessionID, prompt, ok := ResumeSessionFromRequest(RunnerRequest{Metadata: map[string]string{
+		ResumeSessionIDMetadataKey: " sess-1 ",
+		ResumePromptMetadataKey:    "keep going",
+	}})
+	if !ok || sessionID != "sess-1" || prompt != "keep going" {
+		t.Fatalf("unexpected resume %q %q %v", sessionID, prompt, ok)
+	}
+
+	_, prompt, ok = ResumeSessionFromRequest(RunnerRequest{Metadata: map[string]string{ResumeSessionIDMetadataKey: "sess-1"}})
+	if !ok || !strings.Contains(prompt, "the runner process stopped") {
+		t.Fatalf("expected default resume prompt, got %q", prompt)
+	}
+}
+
+func TestBuildResumePromptNamesInterruption(t *testing.T) {
+	prompt := BuildResumePrompt("runner timed out after 10m0s")
+	if !strings.Contains(prompt, "interrupted (runner timed out after 10m0s)") {
+		t.Fatalf("expected reason in prompt, got %q", prompt)
+	}
+	if !strings.Contains(prompt, "Continue from where you left off") {
+		t.Fatalf("expected continuation instruction, got %q", prompt)
+	}
+}
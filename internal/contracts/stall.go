@@ -0,0 +1,100 @@
+package contracts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StallCategory says why a runner stopped making progress.
+type StallCategory string
+
+const (
+	// StallCategoryQuestion means the agent is waiting for an answer.
+	StallCategoryQuestion StallCategory = "question"
+	// StallCategoryWaitingOnTool means the agent is blocked on a tool call or
+	// a permission decision.
+	StallCategoryWaitingOnTool StallCategory = "waiting_on_tool"
+	// StallCategoryRateLimit means the model provider is throttling requests.
+	StallCategoryRateLimit StallCategory = "rate_limit"
+	// StallCategorySilence means the runner produced no output for no known
+	// reason.
+	StallCategorySilence StallCategory = "silence"
+)
+
+// StallCategories lists the categories in the order they are documented.
+var StallCategories = []StallCategory{
+	StallCategoryQuestion,
+	StallCategoryWaitingOnTool,
+	StallCategoryRateLimit,
+	StallCategorySilence,
+}
+
+// Artifact keys set by backends whose watchdog classifies stalls.
+// StallDetailArtifactKey keeps the backend-specific classification when it
+// differs from the category.
+const (
+	StallCategoryArtifactKey = "stall_category"
+	StallDetailArtifactKey   = "stall_detail"
+)
+
+// StallPolicy is what the loop does with a task whose run stalled.
+type StallPolicy string
+
+const (
+	// StallPolicyBlock blocks the task.
+	StallPolicyBlock StallPolicy = "block"
+	// StallPolicyRetry reruns the implement pass within the retry budget.
+	StallPolicyRetry StallPolicy = "retry"
+	// StallPolicyNudge reruns the task once with a nudge prompt.
+	StallPolicyNudge StallPolicy = "nudge"
+	// StallPolicyExtendTimeout reruns the task with a doubled watchdog
+	// timeout within the retry budget.
+	StallPolicyExtendTimeout StallPolicy = "extend_timeout"
+)
+
+// NormalizeStallCategory maps backend watchdog classifications onto the stall
+// taxonomy. Unknown non-empty values are treated as silence.
+func NormalizeStallCategory(raw string) StallCategory {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "":
+		return ""
+	case string(StallCategoryQuestion):
+		return StallCategoryQuestion
+	case string(StallCategoryWaitingOnTool), "permission", "tool":
+		return StallCategoryWaitingOnTool
+	case string(StallCategoryRateLimit), "rate_limited", "throttled":
+		return StallCategoryRateLimit
+	default:
+		return StallCategorySilence
+	}
+}
+
+// StallCategoryFromArtifacts returns the stall category of a runner result,
+// or "" when the run did not stall.
+func StallCategoryFromArtifacts(artifacts map[string]string) StallCategory {
+	if artifacts == nil {
+		return ""
+	}
+	return NormalizeStallCategory(artifacts[StallCategoryArtifactKey])
+}
+
+// ParseStallCategory accepts only the taxonomy names, for config validation.
+func ParseStallCategory(raw string) (StallCategory, error) {
+	value := StallCategory(strings.ToLower(strings.TrimSpace(raw)))
+	for _, category := range StallCategories {
+		if value == category {
+			return category, nil
+		}
+	}
+	return "", fmt.Errorf("unknown stall category %q (expected question, waiting_on_tool, rate_limit or silence)", strings.TrimSpace(raw))
+}
+
+// ParseStallPolicy validates a configured stall policy.
+func ParseStallPolicy(raw string) (StallPolicy, error) {
+	switch value := StallPolicy(strings.ToLower(strings.TrimSpace(raw))); value {
+	case StallPolicyBlock, StallPolicyRetry, StallPolicyNudge, StallPolicyExtendTimeout:
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown stall policy %q (expected block, retry, nudge or extend_timeout)", strings.TrimSpace(raw))
+	}
+}
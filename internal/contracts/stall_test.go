@@ -0,0 +1,38 @@
+package contracts
+
+import "testing"
+
+func TestNormalizeStallCategoryMapsWatchdogClassifications(t *testing.T) {
+	cases := map[string]StallCategory{
+		"":                    "",
+		"question":            StallCategoryQuestion,
+		"permission":          StallCategoryWaitingOnTool,
+		"waiting_on_tool":     StallCategoryWaitingOnTool,
+		"rate_limit":          StallCategoryRateLimit,
+		"no_output":           StallCategorySilence,
+		"idle_transport_open": StallCategorySilence,
+	}
+	for raw, want := range cases {
+		if got := NormalizeStallCategory(raw); got != want {
+			t.Fatalf("NormalizeStallCategory(%q) = %q, want %q", raw, got, want)
+		}
+	}
+	if got := StallCategoryFromArtifacts(map[string]string{StallCategoryArtifactKey: "permission"}); got != StallCategoryWaitingOnTool {
+		t.Fatalf("expected waiting_on_tool from artifacts, got %q", got)
+	}
+}
+
+func TestParseStallCategoryAndPolicy(t *testing.T) {
+	if category, err := ParseStallCategory(" Rate_Limit "); err != nil || category != StallCategoryRateLimit {
+		t.Fatalf("expected rate_limit, got %q err=%v", category, err)
+	}
+	if _, err := ParseStallCategory("no_output"); err == nil {
+		t.Fatalf("expected config to reject watchdog-specific category")
+	}
+	if policy, err := ParseStallPolicy("extend_timeout"); err != nil || policy != StallPolicyExtendTimeout {
+		t.Fatalf("expected extend_timeout, got %q err=%v", policy, err)
+	}
+	if _, err := ParseStallPolicy("ignore"); err == nil {
+		t.Fatalf("expected unknown policy to fail")
+	}
+}
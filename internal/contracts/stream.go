@@ -2,6 +2,7 @@ package contracts
 
 import (
 	"bufio"
+	"compress/gzip"
 	"encoding/json"
 	"io"
 	"strings"
@@ -28,7 +29,10 @@ func (s *EventStream) Write(event Event) error {
 	return err
 }
 
+// EventDecoder reads NDJSON events. Gzipped input, such as a compressed
+// event log segment, is detected and decompressed transparently.
 type EventDecoder struct {
+	reader  io.Reader
 	scanner *bufio.Scanner
 }
 
@@ -36,11 +40,36 @@ func NewEventDecoder(reader io.Reader) *EventDecoder {
 	if reader == nil {
 		return &EventDecoder{}
 	}
-	return &EventDecoder{scanner: bufio.NewScanner(reader)}
+	return &EventDecoder{reader: reader}
+}
+
+// init sniffs the input on the first read so constructing a decoder over a
+// live stream never blocks.
+func (d *EventDecoder) init() error {
+	buffered := bufio.NewReader(d.reader)
+	d.reader = nil
+	if magic, _ := buffered.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return err
+		}
+		d.scanner = bufio.NewScanner(gz)
+		return nil
+	}
+	d.scanner = bufio.NewScanner(buffered)
+	return nil
 }
 
 func (d *EventDecoder) Next() (Event, error) {
-	if d == nil || d.scanner == nil {
+	if d == nil {
+		return Event{}, io.EOF
+	}
+	if d.scanner == nil && d.reader != nil {
+		if err := d.init(); err != nil {
+			return Event{}, err
+		}
+	}
+	if d.scanner == nil {
 		return Event{}, io.EOF
 	}
 	for {
@@ -74,6 +103,8 @@ func ParseEventJSONLLine(line []byte) (Event, error) {
 		Message   string            `json:"message"`
 		Metadata  map[string]string `json:"metadata"`
 		TS        string            `json:"ts"`
+		RunID     string            `json:"run_id"`
+		Seq       uint64            `json:"seq"`
 	}
 	if err := json.Unmarshal(line, &payload); err != nil {
 		return Event{}, err
@@ -97,5 +128,7 @@ func ParseEventJSONLLine(line []byte) (Event, error) {
 		Message:   payload.Message,
 		Metadata:  payload.Metadata,
 		Timestamp: timestamp,
+		RunID:     payload.RunID,
+		Seq:       payload.Seq,
 	}, nil
 }
@@ -2,6 +2,7 @@ package contracts
 
 import (
 	"bytes"
+	"compress/gzip"
 	"io"
 	"testing"
 	"time"
@@ -36,3 +37,35 @@ func TestEventStreamRoundTripNDJSON(t *testing.T) {
 		t.Fatalf("expected EOF after one event, got %v", err)
 	}
 }
+
+func TestEventDecoderReadsGzippedInput(t *testing.T) {
+	plain := &bytes.Buffer{}
+	stream := NewEventStream(plain)
+	for _, taskID := range []string{"task-1", "task-2"} {
+		if err := stream.Write(Event{Type: EventTypeTaskStarted, TaskID: taskID, Timestamp: time.Date(2026, 2, 10, 2, 0, 0, 0, time.UTC)}); err != nil {
+			t.Fatalf("write event: %v", err)
+		}
+	}
+	compressed := &bytes.Buffer{}
+	writer := gzip.NewWriter(compressed)
+	if _, err := writer.Write(plain.Bytes()); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close gzip writer: %v", err)
+	}
+
+	decoder := NewEventDecoder(compressed)
+	for _, want := range []string{"task-1", "task-2"} {
+		event, err := decoder.Next()
+		if err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		if event.TaskID != want {
+			t.Fatalf("expected %s, got %#v", want, event)
+		}
+	}
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
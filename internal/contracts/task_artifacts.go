@@ -0,0 +1,72 @@
+package contracts
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// TaskArtifactsArtifactKey holds the JSON-encoded paths of files a run
+// registered for collection, relative to the task's clone or absolute.
+// Adapters may set it directly; otherwise it is filled from `ARTIFACT:`
+// lines in the runner log.
+const TaskArtifactsArtifactKey = "task_artifacts"
+
+// taskArtifactLinePattern matches `ARTIFACT: <path>`. Like
+// followUpLinePattern it stops at quotes and backslashes so it also works on
+// JSON-encoded transcripts.
+var taskArtifactLinePattern = regexp.MustCompile(`\bARTIFACT:[ \t]*([^\s"\\]+)`)
+
+// ParseTaskArtifacts extracts ARTIFACT lines from runner or hook output.
+// Paths are de-duplicated and placeholders such as `<path>` are ignored.
+func ParseTaskArtifacts(text string) []string {
+	paths := []string{}
+	seen := map[string]struct{}{}
+	for _, match := range taskArtifactLinePattern.FindAllStringSubmatch(text, -1) {
+		path := strings.TrimSpace(match[1])
+		if path == "" || strings.HasPrefix(path, "<") {
+			continue
+		}
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		seen[path] = struct{}{}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func EncodeTaskArtifacts(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(paths)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func DecodeTaskArtifacts(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	paths := []string{}
+	if err := json.Unmarshal([]byte(raw), &paths); err != nil {
+		return nil
+	}
+	return paths
+}
+
+func taskArtifactsFromLog(logPath string) string {
+	if strings.TrimSpace(logPath) == "" {
+		return ""
+	}
+	content, err := os.ReadFile(logPath)
+	if err != nil {
+		return ""
+	}
+	return EncodeTaskArtifacts(ParseTaskArtifacts(string(content)))
+}
@@ -0,0 +1,36 @@
+package contracts
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseTaskArtifactsReadsPlainAndEscapedTranscripts(t *testing.T) {
+	text := "ARTIFACT: reports/junit.xml\n" +
+		`{"text":"ARTIFACT: coverage.out\nDone."}` + "\n" +
+		"ARTIFACT: <path>\n" +
+		"ARTIFACT: reports/junit.xml\n" +
+		"artifact: lowercase/is-ignored.txt\n"
+
+	got := ParseTaskArtifacts(text)
+	if !reflect.DeepEqual(got, []string{"reports/junit.xml", "coverage.out"}) {
+		t.Fatalf("unexpected artifacts %#v", got)
+	}
+}
+
+func TestTaskArtifactsRoundTripThroughRunnerArtifacts(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "review.jsonl")
+	if err := os.WriteFile(logPath, []byte("ran tests\nARTIFACT: build/test-report.html\n"), 0o644); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+
+	artifacts := BuildRunnerArtifacts("codex", RunnerRequest{Mode: RunnerModeReview}, RunnerResult{Status: RunnerResultCompleted, LogPath: logPath}, nil)
+	if got := DecodeTaskArtifacts(artifacts[TaskArtifactsArtifactKey]); !reflect.DeepEqual(got, []string{"build/test-report.html"}) {
+		t.Fatalf("expected task artifacts from the log, got %#v", artifacts)
+	}
+	if DecodeTaskArtifacts("not json") != nil {
+		t.Fatalf("expected malformed artifacts to decode as nil")
+	}
+}
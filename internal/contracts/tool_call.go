@@ -0,0 +1,136 @@
+package contracts
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	ToolCallStatusPending    = "pending"
+	ToolCallStatusInProgress = "in_progress"
+	ToolCallStatusCompleted  = "completed"
+	ToolCallStatusFailed     = "failed"
+)
+
+// ToolCall describes a tool invocation reported by an agent backend. Backends
+// attach it to runner_cmd_started/runner_cmd_finished progress metadata under
+// tool_call_id, kind, title, status and locations (comma separated), using the
+// ACP tool kinds (read, edit, delete, move, search, execute, think, fetch,
+// other).
+type ToolCall struct {
+	ID        string
+	Kind      string
+	Title     string
+	Status    string
+	Locations []string
+}
+
+// ApplyToolCallMetadata adds the non-empty tool call fields to metadata.
+func ApplyToolCallMetadata(metadata map[string]string, call ToolCall) map[string]string {
+	metadata = setMetadataValue(metadata, "tool_call_id", call.ID)
+	metadata = setMetadataValue(metadata, "kind", call.Kind)
+	metadata = setMetadataValue(metadata, "title", call.Title)
+	metadata = setMetadataValue(metadata, "status", call.Status)
+	locations := make([]string, 0, len(call.Locations))
+	for _, location := range call.Locations {
+		if trimmed := strings.TrimSpace(location); trimmed != "" {
+			locations = append(locations, trimmed)
+		}
+	}
+	return setMetadataValue(metadata, "locations", strings.Join(locations, ","))
+}
+
+// ToolCallFromMetadata reads a tool call back from event metadata. It reports
+// false when the metadata carries no tool call id.
+func ToolCallFromMetadata(metadata map[string]string) (ToolCall, bool) {
+	id := strings.TrimSpace(metadata["tool_call_id"])
+	if id == "" {
+		return ToolCall{}, false
+	}
+	call := ToolCall{
+		ID:     id,
+		Kind:   strings.TrimSpace(metadata["kind"]),
+		Title:  strings.TrimSpace(metadata["title"]),
+		Status: strings.TrimSpace(metadata["status"]),
+	}
+	for _, location := range strings.Split(metadata["locations"], ",") {
+		if trimmed := strings.TrimSpace(location); trimmed != "" {
+			call.Locations = append(call.Locations, trimmed)
+		}
+	}
+	return call, true
+}
+
+var toolCallVerbs = map[string]string{
+	"read":    "reading",
+	"edit":    "editing",
+	"delete":  "deleting",
+	"move":    "moving",
+	"search":  "searching",
+	"execute": "running",
+	"think":   "thinking",
+	"fetch":   "fetching",
+}
+
+// DescribeToolCall renders a short human label such as "editing
+// internal/agent/loop.go". Locations inside root are shown relative to it.
+func DescribeToolCall(call ToolCall, root string) string {
+	target := ""
+	if len(call.Locations) > 0 {
+		target = relativeToolPath(call.Locations[0], root)
+		if extra := len(call.Locations) - 1; extra > 0 {
+			target += " (+" + strconv.Itoa(extra) + " more)"
+		}
+	}
+	if target == "" {
+		target = strings.TrimSpace(call.Title)
+	}
+	verb := toolCallVerbs[strings.ToLower(strings.TrimSpace(call.Kind))]
+	switch {
+	case verb != "" && target != "":
+		return verb + " " + target
+	case verb != "":
+		return verb
+	default:
+		return target
+	}
+}
+
+func relativeToolPath(path string, root string) string {
+	path = strings.TrimSpace(path)
+	root = strings.TrimSpace(root)
+	if path == "" || root == "" || !filepath.IsAbs(path) {
+		return path
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
+
+// ToolCallCommand returns the command line of an ACP tool call that runs a
+// command. Agents send it in the raw input as {"command": "go test ./..."}
+// or as an argv list; execute calls without one name the command in their
+// title. Other tool calls return "".
+func ToolCallCommand(kind string, title string, rawInput []byte) string {
+	var input struct {
+		Command json.RawMessage `json:"command"`
+	}
+	if len(rawInput) > 0 && json.Unmarshal(rawInput, &input) == nil && len(input.Command) > 0 {
+		var command string
+		if json.Unmarshal(input.Command, &command) == nil {
+			return strings.TrimSpace(command)
+		}
+		var argv []string
+		if json.Unmarshal(input.Command, &argv) == nil {
+			return strings.TrimSpace(strings.Join(argv, " "))
+		}
+	}
+	if strings.EqualFold(strings.TrimSpace(kind), "execute") {
+		return strings.Trim(title, "` ")
+	}
+	return ""
+}
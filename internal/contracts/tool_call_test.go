@@ -0,0 +1,42 @@
+package contracts
+
+import "testing"
+
+func TestToolCallMetadataRoundTrip(t *testing.T) {
+	metadata := ApplyToolCallMetadata(map[string]string{"session_id": "s-1"}, ToolCall{
+		ID:        "call-1",
+		Kind:      "edit",
+		Title:     "Edit file",
+		Status:    ToolCallStatusInProgress,
+		Locations: []string{"/repo/a.go", " ", "/repo/b.go"},
+	})
+	if metadata["session_id"] != "s-1" || metadata["locations"] != "/repo/a.go,/repo/b.go" {
+		t.Fatalf("unexpected metadata %#v", metadata)
+	}
+	call, ok := ToolCallFromMetadata(metadata)
+	if !ok || call.ID != "call-1" || call.Kind != "edit" || call.Title != "Edit file" || call.Status != "in_progress" || len(call.Locations) != 2 {
+		t.Fatalf("unexpected tool call %#v ok=%t", call, ok)
+	}
+	if _, ok := ToolCallFromMetadata(map[string]string{"kind": "thinking"}); ok {
+		t.Fatalf("expected metadata without tool_call_id to be ignored")
+	}
+}
+
+func TestDescribeToolCall(t *testing.T) {
+	cases := []struct {
+		name string
+		call ToolCall
+		want string
+	}{
+		{name: "edit within root", call: ToolCall{Kind: "edit", Title: "Edit", Locations: []string{"/repo/internal/agent/loop.go"}}, want: "editing internal/agent/loop.go"},
+		{name: "several locations", call: ToolCall{Kind: "read", Locations: []string{"/repo/a.go", "/repo/b.go", "/repo/c.go"}}, want: "reading a.go (+2 more)"},
+		{name: "outside root", call: ToolCall{Kind: "read", Locations: []string{"/etc/hosts"}}, want: "reading /etc/hosts"},
+		{name: "execute title", call: ToolCall{Kind: "execute", Title: "go test ./..."}, want: "running go test ./..."},
+		{name: "unknown kind", call: ToolCall{Kind: "other", Title: "mcp: lookup"}, want: "mcp: lookup"},
+	}
+	for _, tc := range cases {
+		if got := DescribeToolCall(tc.call, "/repo"); got != tc.want {
+			t.Fatalf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package contracts
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ToolPolicy restricts the commands an agent may run. A pattern is a command
+// name, optionally followed by its leading arguments: "docker" matches every
+// docker call and "go test" matches `go test ./...` but not `go build`.
+type ToolPolicy struct {
+	// Allow, when set, lists the only commands the agent may run.
+	Allow []string
+	// Deny lists commands that never run, even when Allow matches them.
+	Deny []string
+}
+
+// IsZero reports whether the policy restricts nothing.
+func (p ToolPolicy) IsZero() bool {
+	return len(p.Allow) == 0 && len(p.Deny) == 0
+}
+
+// CheckCommand reports whether a shell command line may run and why. Every
+// command of a list or pipeline is checked, and `sh -c` scripts are checked
+// by their contents.
+func (p ToolPolicy) CheckCommand(command string) (bool, string) {
+	commands := shellCommands(command)
+	for _, words := range commands {
+		if pattern, ok := matchToolPattern(p.Deny, words); ok {
+			return false, "denied by tool policy: " + pattern
+		}
+	}
+	if len(p.Allow) == 0 {
+		return true, "no tool allowlist"
+	}
+	if len(commands) == 0 {
+		return false, "no command to match against the tool allowlist"
+	}
+	allowed := []string{}
+	for _, words := range commands {
+		pattern, ok := matchToolPattern(p.Allow, words)
+		if !ok {
+			return false, "not in tool allowlist: " + strings.Join(words, " ")
+		}
+		allowed = append(allowed, pattern)
+	}
+	return true, "allowed by tool policy: " + strings.Join(allowed, ", ")
+}
+
+// ToolPatternWords splits a pattern into its command name and leading
+// arguments.
+func ToolPatternWords(pattern string) []string {
+	return strings.Fields(pattern)
+}
+
+func matchToolPattern(patterns []string, words []string) (string, bool) {
+	for _, pattern := range patterns {
+		patternWords := ToolPatternWords(pattern)
+		if len(patternWords) == 0 || len(patternWords) > len(words) {
+			continue
+		}
+		if patternWords[0] != filepath.Base(words[0]) {
+			continue
+		}
+		matched := true
+		for i := 1; i < len(patternWords); i++ {
+			if patternWords[i] != words[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return strings.Join(patternWords, " "), true
+		}
+	}
+	return "", false
+}
+
+// shellCommandWrappers run the command that follows them.
+var shellCommandWrappers = map[string]struct{}{"sudo": {}, "env": {}, "exec": {}, "command": {}, "nohup": {}, "time": {}}
+
+// shellCommands splits a command line into the words of each command it
+// runs. It is a rough split, not a shell parser: quotes are dropped from
+// words, and env assignments and wrappers such as sudo are skipped.
+func shellCommands(command string) [][]string {
+	for _, operator := range []string{"&&", "||", ";", "|", "&", "\n", "$(", "`", "(", ")"} {
+		command = strings.ReplaceAll(command, operator, "\n")
+	}
+	commands := [][]string{}
+	for _, segment := range strings.Split(command, "\n") {
+		words := []string{}
+		for _, word := range strings.Fields(segment) {
+			if word = strings.Trim(word, `"'`); word != "" {
+				words = append(words, word)
+			}
+		}
+		for len(words) > 0 {
+			if _, ok := shellCommandWrappers[filepath.Base(words[0])]; ok || isEnvAssignment(words[0]) {
+				words = words[1:]
+				continue
+			}
+			break
+		}
+		if len(words) == 0 {
+			continue
+		}
+		switch filepath.Base(words[0]) {
+		case "sh", "bash", "zsh", "dash":
+			if len(words) > 2 && strings.HasPrefix(words[1], "-") && !strings.HasPrefix(words[1], "--") && strings.Contains(words[1], "c") {
+				commands = append(commands, shellCommands(strings.Join(words[2:], " "))...)
+				continue
+			}
+		}
+		commands = append(commands, words)
+	}
+	return commands
+}
+
+func isEnvAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
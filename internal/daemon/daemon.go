@@ -0,0 +1,450 @@
+// Package daemon queues yolo-agent runs submitted by several tenants and
+// runs them within per-tenant quotas. It is the core of yolo-agentd; how a
+// run is executed is left to the Executor.
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RunStatus is the state of a run in the daemon queue.
+type RunStatus string
+
+const (
+	RunStatusQueued    RunStatus = "queued"
+	RunStatusRunning   RunStatus = "running"
+	RunStatusSucceeded RunStatus = "succeeded"
+	RunStatusFailed    RunStatus = "failed"
+	RunStatusCanceled  RunStatus = "canceled"
+)
+
+// Finished reports whether the run will not change any more.
+func (s RunStatus) Finished() bool {
+	return s == RunStatusSucceeded || s == RunStatusFailed || s == RunStatusCanceled
+}
+
+var (
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrRunNotFound   = errors.New("run not found")
+	ErrRunFinished   = errors.New("run already finished")
+)
+
+const (
+	runFileName    = "run.json"
+	eventsFileName = "events.jsonl"
+	logFileName    = "output.log"
+)
+
+// Quota bounds what one tenant may use. Zero values mean no bound.
+type Quota struct {
+	// MaxActiveRuns caps the tenant's runs executing at once.
+	MaxActiveRuns int
+	// MaxQueuedRuns caps the tenant's runs waiting to start.
+	MaxQueuedRuns int
+	// MaxConcurrency caps the task concurrency of each run.
+	MaxConcurrency int
+	// MaxDuration stops a run that executes for longer.
+	MaxDuration time.Duration
+}
+
+// RunRequest asks for a run of the tasks under Root in Repo.
+type RunRequest struct {
+	Tenant      string `json:"tenant"`
+	Repo        string `json:"repo"`
+	Root        string `json:"root"`
+	Profile     string `json:"profile,omitempty"`
+	Concurrency int    `json:"concurrency,omitempty"`
+}
+
+// Run is a submitted run and its progress.
+type Run struct {
+	ID string `json:"id"`
+	RunRequest
+	Status      RunStatus  `json:"status"`
+	SubmittedAt time.Time  `json:"submitted_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// Executor executes run until it finishes or ctx ends. Dir is the run's own
+// directory, holding its events file (EventsPath) and output log (LogPath).
+type Executor func(ctx context.Context, run Run, dir string) error
+
+// ManagerOptions configures a Manager.
+type ManagerOptions struct {
+	// StateDir keeps one directory per run, so runs survive a restart.
+	StateDir string
+	// MaxActiveRuns caps the runs executing at once across tenants;
+	// 0 means 1.
+	MaxActiveRuns int
+	// Quotas holds the quota of each tenant; tenants without one are
+	// bounded only by MaxActiveRuns.
+	Quotas  map[string]Quota
+	Execute Executor
+	Now     func() time.Time
+}
+
+type activeRun struct {
+	cancel context.CancelFunc
+	// reason is why the daemon canceled the run; empty while it runs.
+	reason RunStatus
+}
+
+// Manager queues runs and executes them in submission order, skipping runs
+// whose tenant is at its MaxActiveRuns.
+type Manager struct {
+	options ManagerOptions
+
+	mu      sync.Mutex
+	runs    map[string]*Run
+	queue   []string
+	active  map[string]*activeRun
+	closed  bool
+	ctx     context.Context
+	stop    context.CancelFunc
+	running sync.WaitGroup
+}
+
+// NewManager loads the runs kept in options.StateDir. Runs that were
+// executing when the daemon stopped are queued again. Nothing executes until
+// Start.
+func NewManager(options ManagerOptions) (*Manager, error) {
+	if strings.TrimSpace(options.StateDir) == "" {
+		return nil, errors.New("daemon state dir is required")
+	}
+	if options.Execute == nil {
+		return nil, errors.New("daemon executor is required")
+	}
+	if options.MaxActiveRuns <= 0 {
+		options.MaxActiveRuns = 1
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	if err := os.MkdirAll(filepath.Join(options.StateDir, "runs"), 0o755); err != nil {
+		return nil, fmt.Errorf("create daemon state dir: %w", err)
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	m := &Manager{
+		options: options,
+		runs:    map[string]*Run{},
+		active:  map[string]*activeRun{},
+		ctx:     ctx,
+		stop:    stop,
+	}
+	if err := m.load(); err != nil {
+		stop()
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Manager) load() error {
+	entries, err := os.ReadDir(filepath.Join(m.options.StateDir, "runs"))
+	if err != nil {
+		return fmt.Errorf("read daemon state dir: %w", err)
+	}
+	queued := []*Run{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(m.runDir(entry.Name()), runFileName))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("read run %s: %w", entry.Name(), err)
+		}
+		run := &Run{}
+		if err := json.Unmarshal(raw, run); err != nil {
+			return fmt.Errorf("read run %s: %w", entry.Name(), err)
+		}
+		if run.Status == RunStatusRunning {
+			run.Status = RunStatusQueued
+			run.StartedAt = nil
+			if err := m.save(run); err != nil {
+				return err
+			}
+		}
+		m.runs[run.ID] = run
+		if run.Status == RunStatusQueued {
+			queued = append(queued, run)
+		}
+	}
+	sort.SliceStable(queued, func(i, j int) bool { return queued[i].SubmittedAt.Before(queued[j].SubmittedAt) })
+	for _, run := range queued {
+		m.queue = append(m.queue, run.ID)
+	}
+	return nil
+}
+
+// Start executes queued runs.
+func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.schedule()
+}
+
+// Submit queues a run for request.Tenant. It fails with ErrQuotaExceeded
+// when the tenant already has MaxQueuedRuns waiting or asks for more
+// concurrency than MaxConcurrency.
+func (m *Manager) Submit(request RunRequest) (Run, error) {
+	request.Tenant = strings.TrimSpace(request.Tenant)
+	request.Repo = strings.TrimSpace(request.Repo)
+	request.Root = strings.TrimSpace(request.Root)
+	request.Profile = strings.TrimSpace(request.Profile)
+	if request.Repo == "" || request.Root == "" {
+		return Run{}, errors.New("repo and root are required")
+	}
+	if request.Concurrency < 0 {
+		return Run{}, errors.New("concurrency must be greater than or equal to 0")
+	}
+	quota := m.options.Quotas[request.Tenant]
+	if quota.MaxConcurrency > 0 {
+		if request.Concurrency > quota.MaxConcurrency {
+			return Run{}, fmt.Errorf("%w: concurrency %d is above max_concurrency %d", ErrQuotaExceeded, request.Concurrency, quota.MaxConcurrency)
+		}
+		if request.Concurrency == 0 {
+			request.Concurrency = quota.MaxConcurrency
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return Run{}, errors.New("daemon is shutting down")
+	}
+	if quota.MaxQueuedRuns > 0 && m.countLocked(request.Tenant, RunStatusQueued) >= quota.MaxQueuedRuns {
+		return Run{}, fmt.Errorf("%w: %d runs already queued (max_queued_runs)", ErrQuotaExceeded, quota.MaxQueuedRuns)
+	}
+	now := m.options.Now().UTC()
+	run := &Run{ID: newRunID(now), RunRequest: request, Status: RunStatusQueued, SubmittedAt: now}
+	if err := os.MkdirAll(m.runDir(run.ID), 0o755); err != nil {
+		return Run{}, fmt.Errorf("create run dir: %w", err)
+	}
+	if err := m.save(run); err != nil {
+		return Run{}, err
+	}
+	m.runs[run.ID] = run
+	m.queue = append(m.queue, run.ID)
+	m.schedule()
+	return *m.runs[run.ID], nil
+}
+
+// Get returns the run with id.
+func (m *Manager) Get(id string) (Run, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run, ok := m.runs[id]
+	if !ok {
+		return Run{}, ErrRunNotFound
+	}
+	return *run, nil
+}
+
+// List returns the runs of tenant, or of every tenant when tenant is empty,
+// newest first.
+func (m *Manager) List(tenant string) []Run {
+	m.mu.Lock()
+	runs := make([]Run, 0, len(m.runs))
+	for _, run := range m.runs {
+		if tenant == "" || run.Tenant == tenant {
+			runs = append(runs, *run)
+		}
+	}
+	m.mu.Unlock()
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].SubmittedAt.Equal(runs[j].SubmittedAt) {
+			return runs[i].SubmittedAt.After(runs[j].SubmittedAt)
+		}
+		return runs[i].ID > runs[j].ID
+	})
+	return runs
+}
+
+// Cancel removes a queued run from the queue or stops an executing one.
+func (m *Manager) Cancel(id string) (Run, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run, ok := m.runs[id]
+	if !ok {
+		return Run{}, ErrRunNotFound
+	}
+	switch {
+	case run.Status.Finished():
+		return *run, ErrRunFinished
+	case run.Status == RunStatusQueued:
+		m.dequeue(id)
+		m.finish(run, RunStatusCanceled, "")
+	default:
+		if active := m.active[id]; active != nil && active.reason == "" {
+			active.reason = RunStatusCanceled
+			active.cancel()
+		}
+	}
+	return *run, nil
+}
+
+// EventsPath is the events file of run id.
+func (m *Manager) EventsPath(id string) string {
+	return filepath.Join(m.runDir(id), eventsFileName)
+}
+
+// LogPath is the output log of run id.
+func (m *Manager) LogPath(id string) string {
+	return filepath.Join(m.runDir(id), logFileName)
+}
+
+// Shutdown stops executing runs and waits for them until ctx ends. The
+// stopped runs are queued again, so the next daemon restarts them.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	for _, active := range m.active {
+		if active.reason == "" {
+			active.reason = RunStatusQueued
+		}
+	}
+	m.stop()
+	m.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// schedule starts queued runs while there is room. Callers hold m.mu.
+func (m *Manager) schedule() {
+	if m.closed {
+		return
+	}
+	for i := 0; i < len(m.queue) && len(m.active) < m.options.MaxActiveRuns; {
+		run := m.runs[m.queue[i]]
+		quota := m.options.Quotas[run.Tenant]
+		if quota.MaxActiveRuns > 0 && m.countLocked(run.Tenant, RunStatusRunning) >= quota.MaxActiveRuns {
+			i++
+			continue
+		}
+		m.queue = append(m.queue[:i], m.queue[i+1:]...)
+		m.startLocked(run, quota)
+	}
+}
+
+func (m *Manager) startLocked(run *Run, quota Quota) {
+	now := m.options.Now().UTC()
+	run.Status = RunStatusRunning
+	run.StartedAt = &now
+	run.Error = ""
+	_ = m.save(run)
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if quota.MaxDuration > 0 {
+		ctx, cancel = context.WithTimeout(m.ctx, quota.MaxDuration)
+	} else {
+		ctx, cancel = context.WithCancel(m.ctx)
+	}
+	active := &activeRun{cancel: cancel}
+	m.active[run.ID] = active
+	snapshot := *run
+	m.running.Add(1)
+	go func() {
+		defer m.running.Done()
+		err := m.options.Execute(ctx, snapshot, m.runDir(snapshot.ID))
+		deadline := errors.Is(ctx.Err(), context.DeadlineExceeded)
+		cancel()
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.active, snapshot.ID)
+		switch {
+		case active.reason == RunStatusQueued:
+			run.Status = RunStatusQueued
+			run.StartedAt = nil
+			_ = m.save(run)
+		case active.reason == RunStatusCanceled:
+			m.finish(run, RunStatusCanceled, "")
+		case deadline:
+			m.finish(run, RunStatusFailed, fmt.Sprintf("stopped after max_duration %s", quota.MaxDuration))
+		case err != nil:
+			m.finish(run, RunStatusFailed, err.Error())
+		default:
+			m.finish(run, RunStatusSucceeded, "")
+		}
+		m.schedule()
+	}()
+}
+
+func (m *Manager) finish(run *Run, status RunStatus, reason string) {
+	now := m.options.Now().UTC()
+	run.Status = status
+	run.FinishedAt = &now
+	run.Error = reason
+	_ = m.save(run)
+}
+
+func (m *Manager) dequeue(id string) {
+	for i, queued := range m.queue {
+		if queued == id {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+func (m *Manager) countLocked(tenant string, status RunStatus) int {
+	count := 0
+	for _, run := range m.runs {
+		if run.Tenant == tenant && run.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+func (m *Manager) runDir(id string) string {
+	return filepath.Join(m.options.StateDir, "runs", id)
+}
+
+// save writes run.json through a temporary file so a crash never leaves a
+// partial record.
+func (m *Manager) save(run *Run) error {
+	raw, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode run %s: %w", run.ID, err)
+	}
+	path := filepath.Join(m.runDir(run.ID), runFileName)
+	if err := os.WriteFile(path+".tmp", raw, 0o644); err != nil {
+		return fmt.Errorf("save run %s: %w", run.ID, err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("save run %s: %w", run.ID, err)
+	}
+	return nil
+}
+
+func newRunID(now time.Time) string {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return "run-" + now.Format("20060102T150405") + "-" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,223 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingExecutor records started runs and keeps each one executing until
+// it is released or its context ends.
+type blockingExecutor struct {
+	mu       sync.Mutex
+	started  []string
+	releases map[string]chan error
+}
+
+func newBlockingExecutor() *blockingExecutor {
+	return &blockingExecutor{releases: map[string]chan error{}}
+}
+
+// channel returns the release channel of run id, creating it for whichever
+// of execute and release comes first.
+func (e *blockingExecutor) channel(id string) chan error {
+	if _, ok := e.releases[id]; !ok {
+		e.releases[id] = make(chan error, 1)
+	}
+	return e.releases[id]
+}
+
+func (e *blockingExecutor) execute(ctx context.Context, run Run, _ string) error {
+	e.mu.Lock()
+	e.started = append(e.started, run.ID)
+	release := e.channel(run.ID)
+	e.mu.Unlock()
+	select {
+	case err := <-release:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *blockingExecutor) release(id string, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.channel(id) <- err
+}
+
+func (e *blockingExecutor) startedRuns() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.started...)
+}
+
+func newTestManager(t *testing.T, options ManagerOptions) *Manager {
+	t.Helper()
+	if options.StateDir == "" {
+		options.StateDir = t.TempDir()
+	}
+	manager, err := NewManager(options)
+	if err != nil {
+		t.Fatalf("new manager: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = manager.Shutdown(ctx)
+	})
+	manager.Start()
+	return manager
+}
+
+func waitForStatus(t *testing.T, manager *Manager, id string, status RunStatus) Run {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		run, err := manager.Get(id)
+		if err != nil {
+			t.Fatalf("get %s: %v", id, err)
+		}
+		if run.Status == status {
+			return run
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run %s is %s, expected %s", id, run.Status, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func submit(t *testing.T, manager *Manager, tenant string) Run {
+	t.Helper()
+	run, err := manager.Submit(RunRequest{Tenant: tenant, Repo: "/srv/" + tenant, Root: "root-1"})
+	if err != nil {
+		t.Fatalf("submit for %s: %v", tenant, err)
+	}
+	return run
+}
+
+func TestManagerRunsWithinActiveLimits(t *testing.T) {
+	executor := newBlockingExecutor()
+	manager := newTestManager(t, ManagerOptions{
+		MaxActiveRuns: 2,
+		Quotas:        map[string]Quota{"platform": {MaxActiveRuns: 1}},
+		Execute:       executor.execute,
+	})
+
+	first := submit(t, manager, "platform")
+	second := submit(t, manager, "platform")
+	other := submit(t, manager, "web")
+	third := submit(t, manager, "web")
+
+	waitForStatus(t, manager, first.ID, RunStatusRunning)
+	waitForStatus(t, manager, other.ID, RunStatusRunning)
+	if run, _ := manager.Get(second.ID); run.Status != RunStatusQueued {
+		t.Fatalf("expected the tenant quota to hold the second platform run, got %s", run.Status)
+	}
+	if run, _ := manager.Get(third.ID); run.Status != RunStatusQueued {
+		t.Fatalf("expected the daemon limit to hold the second web run, got %s", run.Status)
+	}
+
+	executor.release(first.ID, nil)
+	waitForStatus(t, manager, first.ID, RunStatusSucceeded)
+	waitForStatus(t, manager, second.ID, RunStatusRunning)
+	executor.release(other.ID, errors.New("exit status 1"))
+	if run := waitForStatus(t, manager, other.ID, RunStatusFailed); run.Error != "exit status 1" {
+		t.Fatalf("expected the executor error on the failed run, got %q", run.Error)
+	}
+	waitForStatus(t, manager, third.ID, RunStatusRunning)
+}
+
+func TestManagerRejectsRunsOverQuota(t *testing.T) {
+	executor := newBlockingExecutor()
+	manager := newTestManager(t, ManagerOptions{
+		Quotas:  map[string]Quota{"platform": {MaxQueuedRuns: 1, MaxConcurrency: 4}},
+		Execute: executor.execute,
+	})
+
+	if _, err := manager.Submit(RunRequest{Tenant: "platform", Repo: "/srv/api", Root: "root-1", Concurrency: 8}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected concurrency above the quota to be rejected, got %v", err)
+	}
+	running := submit(t, manager, "platform")
+	if running.Concurrency != 4 {
+		t.Fatalf("expected the run to default to max_concurrency, got %d", running.Concurrency)
+	}
+	waitForStatus(t, manager, running.ID, RunStatusRunning)
+	submit(t, manager, "platform")
+	if _, err := manager.Submit(RunRequest{Tenant: "platform", Repo: "/srv/api", Root: "root-2"}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected a second queued run to be rejected, got %v", err)
+	}
+}
+
+func TestManagerCancelsQueuedAndRunningRuns(t *testing.T) {
+	executor := newBlockingExecutor()
+	manager := newTestManager(t, ManagerOptions{Execute: executor.execute})
+
+	running := submit(t, manager, "platform")
+	queued := submit(t, manager, "platform")
+	waitForStatus(t, manager, running.ID, RunStatusRunning)
+
+	if run, err := manager.Cancel(queued.ID); err != nil || run.Status != RunStatusCanceled {
+		t.Fatalf("expected the queued run to be canceled, got %#v err=%v", run, err)
+	}
+	if _, err := manager.Cancel(running.ID); err != nil {
+		t.Fatalf("cancel running run: %v", err)
+	}
+	waitForStatus(t, manager, running.ID, RunStatusCanceled)
+	if _, err := manager.Cancel(running.ID); !errors.Is(err, ErrRunFinished) {
+		t.Fatalf("expected canceling a finished run to fail, got %v", err)
+	}
+	if _, err := manager.Cancel("run-missing"); !errors.Is(err, ErrRunNotFound) {
+		t.Fatalf("expected an unknown run to fail, got %v", err)
+	}
+	if started := executor.startedRuns(); len(started) != 1 {
+		t.Fatalf("expected the canceled queued run never to start, started %v", started)
+	}
+}
+
+func TestManagerStopsRunsAfterMaxDuration(t *testing.T) {
+	executor := newBlockingExecutor()
+	manager := newTestManager(t, ManagerOptions{
+		Quotas:  map[string]Quota{"platform": {MaxDuration: 20 * time.Millisecond}},
+		Execute: executor.execute,
+	})
+	run := waitForStatus(t, manager, submit(t, manager, "platform").ID, RunStatusFailed)
+	if run.Error != "stopped after max_duration 20ms" {
+		t.Fatalf("unexpected error %q", run.Error)
+	}
+}
+
+func TestManagerRequeuesRunsStoppedByShutdown(t *testing.T) {
+	stateDir := t.TempDir()
+	executor := newBlockingExecutor()
+	manager, err := NewManager(ManagerOptions{StateDir: stateDir, Execute: executor.execute})
+	if err != nil {
+		t.Fatalf("new manager: %v", err)
+	}
+	manager.Start()
+	running := submit(t, manager, "platform")
+	queued := submit(t, manager, "platform")
+	waitForStatus(t, manager, running.ID, RunStatusRunning)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := manager.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	restarted := newBlockingExecutor()
+	reloaded := newTestManager(t, ManagerOptions{StateDir: stateDir, Execute: restarted.execute})
+	waitForStatus(t, reloaded, running.ID, RunStatusRunning)
+	if run, _ := reloaded.Get(queued.ID); run.Status != RunStatusQueued {
+		t.Fatalf("expected the queued run to stay queued behind the restarted run, got %s", run.Status)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(restarted.startedRuns()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if started := restarted.startedRuns(); len(started) != 1 || started[0] != running.ID {
+		t.Fatalf("expected the interrupted run to start first, started %v", started)
+	}
+}
@@ -39,6 +39,9 @@ type ExecutorWorkerOptions struct {
 	RetryDelay         time.Duration
 	PreExecutionHook   func(context.Context, contracts.RunnerRequest, int)
 	PostExecutionHook  func(context.Context, contracts.RunnerRequest, contracts.RunnerResult, error, int)
+	// Redactor masks sensitive values in monitor events before they are
+	// published; nil publishes them as is.
+	Redactor *contracts.Redactor
 }
 
 type ExecutorWorker struct {
@@ -67,6 +70,7 @@ type ExecutorWorker struct {
 	preExecutionHook   func(context.Context, contracts.RunnerRequest, int)
 	postExecutionHook  func(context.Context, contracts.RunnerRequest, contracts.RunnerResult, error, int)
 	clock              func() time.Time
+	redactor           *contracts.Redactor
 	activeLoad         int64
 	serviceMu          sync.Mutex
 	servicePending     map[string]chan ServiceResponsePayload
@@ -115,6 +119,7 @@ func NewExecutorWorker(cfg ExecutorWorkerOptions) *ExecutorWorker {
 		retryDelay:         cfg.RetryDelay,
 		preExecutionHook:   cfg.PreExecutionHook,
 		postExecutionHook:  cfg.PostExecutionHook,
+		redactor:           cfg.Redactor,
 		clock: func() time.Time {
 			if cfg.Clock != nil {
 				return cfg.Clock().UTC()
@@ -513,7 +518,7 @@ func (w *ExecutorWorker) progressForwarder(ctx context.Context, taskID string, a
 			Metadata:  cloneProgressMetadata,
 			Timestamp: eventTime,
 		}
-		progressEnv, envelopeErr := NewEventEnvelope(EventTypeMonitorEvent, w.ID(), "", MonitorEventPayload{Event: event})
+		progressEnv, envelopeErr := NewEventEnvelope(EventTypeMonitorEvent, w.ID(), "", MonitorEventPayload{Event: w.redactor.RedactEvent(event)})
 		if envelopeErr != nil {
 			return
 		}
@@ -542,7 +547,7 @@ func (w *ExecutorWorker) emitRunnerEvent(ctx context.Context, taskID string, eve
 		Metadata:  metadata,
 		Timestamp: time.Now().UTC(),
 	}
-	eventEnvelope, envelopeErr := NewEventEnvelope(EventTypeMonitorEvent, w.ID(), "", MonitorEventPayload{Event: event})
+	eventEnvelope, envelopeErr := NewEventEnvelope(EventTypeMonitorEvent, w.ID(), "", MonitorEventPayload{Event: w.redactor.RedactEvent(event)})
 	if envelopeErr != nil {
 		return
 	}
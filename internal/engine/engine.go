@@ -172,6 +172,7 @@ func (e *TaskEngine) GetNextAvailable(graph *contracts.TaskGraph) []contracts.Ta
 			})
 		}
 	}
+	contracts.SortTaskSummaries(available)
 	return available
 }
 
@@ -278,6 +279,75 @@ func (e *TaskEngine) UpdateTaskStatus(graph *contracts.TaskGraph, taskID string,
 	return nil
 }
 
+// UpdateGraph brings graph up to date with tree in place when tree has the
+// same tasks, parents and relations the graph was built from, updating the
+// fields, statuses and priorities of the tasks that changed. It reports
+// false, leaving graph untouched, when the shape differs and the graph has to
+// be rebuilt with BuildGraph.
+func (e *TaskEngine) UpdateGraph(graph *contracts.TaskGraph, tree *contracts.TaskTree) (bool, error) {
+	if graph == nil || tree == nil {
+		return false, nil
+	}
+	rootID := strings.TrimSpace(tree.Root.ID)
+	if rootID == "" || rootID != graph.RootID {
+		return false, nil
+	}
+	tasks, err := normalizeTasks(tree, rootID)
+	if err != nil {
+		return false, err
+	}
+	if len(tasks) != len(graph.Nodes) {
+		return false, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	edges := make(map[string]struct{}, len(graph.Edges))
+	for _, edge := range graph.Edges {
+		edges[edgeKey(edge.Type, edge.FromID, edge.ToID)] = struct{}{}
+	}
+	seen := make(map[string]struct{}, len(edges))
+	for taskID, task := range tasks {
+		if graph.Nodes[taskID] == nil {
+			return false, nil
+		}
+		parentID := strings.TrimSpace(task.ParentID)
+		if taskID == rootID || parentID == "" {
+			continue
+		}
+		key := edgeKey(contracts.RelationParent, parentID, taskID)
+		if _, ok := edges[key]; !ok {
+			return false, nil
+		}
+		seen[key] = struct{}{}
+	}
+	for _, relation := range tree.Relations {
+		key := edgeKey(relation.Type, strings.TrimSpace(relation.FromID), strings.TrimSpace(relation.ToID))
+		if _, ok := edges[key]; !ok {
+			return false, nil
+		}
+		seen[key] = struct{}{}
+	}
+	if len(seen) != len(edges) {
+		return false, nil
+	}
+
+	for taskID, task := range tasks {
+		node := graph.Nodes[taskID]
+		if node.Parent != nil {
+			task.ParentID = node.Parent.ID
+		}
+		if sameTask(node.Task, task) {
+			continue
+		}
+		node.Task = task
+		node.Status = task.Status
+		node.Priority = taskPriority(task)
+	}
+	return true, nil
+}
+
 func (e *TaskEngine) IsComplete(graph *contracts.TaskGraph) bool {
 	if graph == nil || len(graph.Nodes) == 0 {
 		return true
@@ -353,6 +353,77 @@ func TestTaskEngineBuildGraphPerformance1000TasksUnder100ms(t *testing.T) {
 	}
 }
 
+func TestTaskEngineUpdateGraphPatchesChangedTasksInPlace(t *testing.T) {
+	engine := NewTaskEngine()
+	tree := &contracts.TaskTree{
+		Root: contracts.Task{ID: "root", Status: contracts.TaskStatusOpen},
+		Tasks: map[string]contracts.Task{
+			"root": {ID: "root", Status: contracts.TaskStatusOpen},
+			"a":    {ID: "a", Title: "A", Status: contracts.TaskStatusOpen, ParentID: "root"},
+			"b":    {ID: "b", Title: "B", Status: contracts.TaskStatusOpen, ParentID: "root"},
+		},
+		Relations: []contracts.TaskRelation{
+			{FromID: "root", ToID: "a", Type: contracts.RelationParent},
+			{FromID: "b", ToID: "a", Type: contracts.RelationDependsOn},
+		},
+	}
+	graph, err := engine.BuildGraph(tree)
+	if err != nil {
+		t.Fatalf("BuildGraph() error = %v", err)
+	}
+	nodeB := graph.Nodes["b"]
+
+	tree.Tasks["a"] = contracts.Task{ID: "a", Title: "A", Status: contracts.TaskStatusClosed, ParentID: "root"}
+	tree.Tasks["b"] = contracts.Task{ID: "b", Title: "B renamed", Status: contracts.TaskStatusOpen, ParentID: "root", Metadata: map[string]string{"priority": "3"}}
+	updated, err := engine.UpdateGraph(graph, tree)
+	if err != nil || !updated {
+		t.Fatalf("UpdateGraph() = %v, %v; want true, nil", updated, err)
+	}
+	if graph.Nodes["b"] != nodeB || nodeB.Task.Title != "B renamed" || nodeB.Priority != 3 {
+		t.Fatalf("expected node b patched in place, got %#v", nodeB)
+	}
+	if got := summaryIDs(engine.GetNextAvailable(graph)); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Fatalf("GetNextAvailable() = %v, want [b]", got)
+	}
+}
+
+func TestTaskEngineUpdateGraphReportsShapeChanges(t *testing.T) {
+	engine := NewTaskEngine()
+	newTree := func() *contracts.TaskTree {
+		return &contracts.TaskTree{
+			Root: contracts.Task{ID: "root", Status: contracts.TaskStatusOpen},
+			Tasks: map[string]contracts.Task{
+				"root": {ID: "root", Status: contracts.TaskStatusOpen},
+				"a":    {ID: "a", Status: contracts.TaskStatusOpen, ParentID: "root"},
+				"b":    {ID: "b", Status: contracts.TaskStatusOpen, ParentID: "root"},
+			},
+		}
+	}
+	cases := map[string]func(tree *contracts.TaskTree){
+		"new task": func(tree *contracts.TaskTree) {
+			tree.Tasks["c"] = contracts.Task{ID: "c", Status: contracts.TaskStatusOpen, ParentID: "root"}
+		},
+		"moved task": func(tree *contracts.TaskTree) {
+			tree.Tasks["b"] = contracts.Task{ID: "b", Status: contracts.TaskStatusOpen, ParentID: "a"}
+		},
+		"new dependency": func(tree *contracts.TaskTree) {
+			tree.Relations = append(tree.Relations, contracts.TaskRelation{FromID: "b", ToID: "a", Type: contracts.RelationDependsOn})
+		},
+	}
+	for name, change := range cases {
+		graph, err := engine.BuildGraph(newTree())
+		if err != nil {
+			t.Fatalf("BuildGraph() error = %v", err)
+		}
+		tree := newTree()
+		change(tree)
+		updated, err := engine.UpdateGraph(graph, tree)
+		if err != nil || updated {
+			t.Fatalf("%s: UpdateGraph() = %v, %v; want false, nil", name, updated, err)
+		}
+	}
+}
+
 func TestTaskEngineGetNextAvailableReturnsDependencySatisfiedOpenTasks(t *testing.T) {
 	engine := NewTaskEngine()
 	tree := &contracts.TaskTree{
@@ -0,0 +1,396 @@
+// Package escalation hands tasks that stay blocked to a human: once a task
+// has been blocked for the policy's delay, the tracker issue is assigned to
+// them, labeled and announced on a webhook with the triage reason and the
+// runner logs.
+package escalation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// DefaultLabel is added to escalated tasks when the policy names no label.
+const DefaultLabel = "needs-human"
+
+// StateRelPath is where blocked tasks awaiting escalation are kept, relative
+// to the repository root, so a later run or `yolo-agent escalate` can pick
+// them up.
+const StateRelPath = ".yolo-runner/escalations.json"
+
+// Policy configures escalation. A zero After disables it.
+type Policy struct {
+	// After is how long a task stays blocked before it is escalated.
+	After time.Duration
+	// Assignee is the tracker login the task is assigned to. Empty leaves
+	// the assignees alone.
+	Assignee string
+	// Label overrides DefaultLabel.
+	Label string
+	// WebhookURL receives a JSON notification. Its "text" field makes it
+	// usable as a Slack incoming webhook.
+	WebhookURL string
+}
+
+// Enabled reports whether blocked tasks are escalated.
+func (p Policy) Enabled() bool {
+	return p.After > 0
+}
+
+// LabelOrDefault is Label, or DefaultLabel when it is not set.
+func (p Policy) LabelOrDefault() string {
+	if label := strings.TrimSpace(p.Label); label != "" {
+		return label
+	}
+	return DefaultLabel
+}
+
+// StatusFunc reads a task's current tracker status.
+type StatusFunc func(ctx context.Context, taskID string) (contracts.TaskStatus, error)
+
+// Task is a blocked task awaiting escalation. The step flags let a failed
+// escalation retry only the steps that did not go through.
+type Task struct {
+	TaskID         string    `json:"task_id"`
+	Title          string    `json:"title,omitempty"`
+	TriageCategory string    `json:"triage_category,omitempty"`
+	TriageReason   string    `json:"triage_reason,omitempty"`
+	BlockedAt      time.Time `json:"blocked_at"`
+	Logs           []string  `json:"logs,omitempty"`
+	Assigned       bool      `json:"assigned,omitempty"`
+	Labeled        bool      `json:"labeled,omitempty"`
+	Notified       bool      `json:"notified,omitempty"`
+}
+
+// Result reports one escalated task.
+type Result struct {
+	Task
+	// Actions lists the steps taken by this call, e.g. "labeled needs-human".
+	Actions []string
+	// Notes lists the steps skipped because the tracker lacks support.
+	Notes []string
+}
+
+type state struct {
+	Tasks map[string]Task `json:"tasks"`
+}
+
+// Escalator is an event sink that tracks blocked tasks and escalates them
+// once the policy's delay has passed. Tasks are kept in a state file so an
+// escalation survives the run that blocked the task.
+type Escalator struct {
+	policy    Policy
+	statePath string
+	tracker   any
+	status    StatusFunc
+	client    *http.Client
+	now       func() time.Time
+
+	mu      sync.Mutex
+	blocked map[string]Task
+	logs    map[string][]string
+}
+
+// New loads the tasks awaiting escalation from statePath. tracker is
+// checked for contracts.TaskAssigner and contracts.TaskLabeler; status, when
+// set, confirms a task is still blocked before it is escalated.
+func New(policy Policy, statePath string, tracker any, status StatusFunc) (*Escalator, error) {
+	e := &Escalator{
+		policy:    policy,
+		statePath: statePath,
+		tracker:   tracker,
+		status:    status,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		now:       time.Now,
+		blocked:   map[string]Task{},
+		logs:      map[string][]string{},
+	}
+	raw, err := os.ReadFile(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return e, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read escalation state: %w", err)
+	}
+	loaded := state{}
+	if err := json.Unmarshal(raw, &loaded); err != nil {
+		return nil, fmt.Errorf("read escalation state %s: %w", statePath, err)
+	}
+	for id, task := range loaded.Tasks {
+		e.blocked[id] = task
+	}
+	return e, nil
+}
+
+// Emit records the log paths of a task's runners and when the task became
+// blocked. A task that starts again or gets another status is no longer
+// awaiting escalation.
+func (e *Escalator) Emit(_ context.Context, event contracts.Event) error {
+	taskID := strings.TrimSpace(event.TaskID)
+	if taskID == "" {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch event.Type {
+	case contracts.EventTypeTaskStarted:
+		delete(e.logs, taskID)
+		return e.forgetLocked(taskID)
+	case contracts.EventTypeRunnerStarted:
+		if path := strings.TrimSpace(event.Metadata["log_path"]); path != "" && !contains(e.logs[taskID], path) {
+			e.logs[taskID] = append(e.logs[taskID], path)
+		}
+		return nil
+	case contracts.EventTypeTaskFinished, contracts.EventTypeTaskStatusSet:
+		if event.Message != string(contracts.TaskStatusBlocked) {
+			delete(e.logs, taskID)
+			return e.forgetLocked(taskID)
+		}
+		if _, ok := e.blocked[taskID]; ok {
+			return nil
+		}
+		blockedAt := event.Timestamp
+		if blockedAt.IsZero() {
+			blockedAt = e.now().UTC()
+		}
+		e.blocked[taskID] = Task{
+			TaskID:         taskID,
+			Title:          event.TaskTitle,
+			TriageCategory: string(contracts.FailureCategoryFromMetadata(event.Metadata)),
+			TriageReason:   event.Metadata["triage_reason"],
+			BlockedAt:      blockedAt,
+			Logs:           append([]string(nil), e.logs[taskID]...),
+		}
+		delete(e.logs, taskID)
+		return e.saveLocked()
+	default:
+		return nil
+	}
+}
+
+func (e *Escalator) forgetLocked(taskID string) error {
+	if _, ok := e.blocked[taskID]; !ok {
+		return nil
+	}
+	delete(e.blocked, taskID)
+	return e.saveLocked()
+}
+
+// Pending lists the tasks awaiting escalation, oldest first.
+func (e *Escalator) Pending() []Task {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	tasks := make([]Task, 0, len(e.blocked))
+	for _, task := range e.blocked {
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].BlockedAt.Equal(tasks[j].BlockedAt) {
+			return tasks[i].BlockedAt.Before(tasks[j].BlockedAt)
+		}
+		return tasks[i].TaskID < tasks[j].TaskID
+	})
+	return tasks
+}
+
+// EscalateDue escalates every task blocked for at least the policy's delay.
+// Tasks that are no longer blocked in the tracker are dropped. A task whose
+// escalation fails is kept and retried on the next call.
+func (e *Escalator) EscalateDue(ctx context.Context) ([]Result, error) {
+	if !e.policy.Enabled() {
+		return nil, nil
+	}
+	results := []Result{}
+	var errs []error
+	for _, task := range e.Pending() {
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		if e.now().Sub(task.BlockedAt) < e.policy.After {
+			continue
+		}
+		if e.status != nil {
+			status, err := e.status(ctx, task.TaskID)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("escalate %s: read status: %w", task.TaskID, err))
+				continue
+			}
+			if status != contracts.TaskStatusBlocked {
+				e.update(task.TaskID, nil)
+				continue
+			}
+		}
+		result, err := e.escalate(ctx, task)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("escalate %s: %w", task.TaskID, err))
+			e.update(task.TaskID, &result.Task)
+			continue
+		}
+		e.update(task.TaskID, nil)
+		results = append(results, result)
+	}
+	e.mu.Lock()
+	saveErr := e.saveLocked()
+	e.mu.Unlock()
+	return results, errors.Join(append(errs, saveErr)...)
+}
+
+// update stores task's progress, or drops the task when task is nil. A task
+// that started again meanwhile is left alone.
+func (e *Escalator) update(taskID string, task *Task) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.blocked[taskID]; !ok {
+		return
+	}
+	if task == nil {
+		delete(e.blocked, taskID)
+		return
+	}
+	e.blocked[taskID] = *task
+}
+
+func (e *Escalator) escalate(ctx context.Context, task Task) (Result, error) {
+	result := Result{Task: task}
+	if assignee := strings.TrimSpace(e.policy.Assignee); assignee != "" && !result.Assigned {
+		if assigner, ok := e.tracker.(contracts.TaskAssigner); ok {
+			if err := assigner.AssignTask(ctx, task.TaskID, assignee); err != nil {
+				return result, fmt.Errorf("assign to %s: %w", assignee, err)
+			}
+			result.Actions = append(result.Actions, "assigned to "+assignee)
+		} else {
+			result.Notes = append(result.Notes, "tracker does not support assigning tasks")
+		}
+		result.Assigned = true
+	}
+	if !result.Labeled {
+		if labeler, ok := e.tracker.(contracts.TaskLabeler); ok {
+			if err := labeler.AddTaskLabels(ctx, task.TaskID, []string{e.policy.LabelOrDefault()}); err != nil {
+				return result, fmt.Errorf("label %s: %w", e.policy.LabelOrDefault(), err)
+			}
+			result.Actions = append(result.Actions, "labeled "+e.policy.LabelOrDefault())
+		} else {
+			result.Notes = append(result.Notes, "tracker does not support labeling tasks")
+		}
+		result.Labeled = true
+	}
+	if strings.TrimSpace(e.policy.WebhookURL) != "" && !result.Notified {
+		if err := e.notify(ctx, task); err != nil {
+			return result, fmt.Errorf("notify: %w", err)
+		}
+		result.Actions = append(result.Actions, "notified")
+		result.Notified = true
+	}
+	return result, nil
+}
+
+type notification struct {
+	Text           string    `json:"text"`
+	TaskID         string    `json:"task_id"`
+	TaskTitle      string    `json:"task_title,omitempty"`
+	TriageCategory string    `json:"triage_category,omitempty"`
+	TriageReason   string    `json:"triage_reason,omitempty"`
+	BlockedAt      time.Time `json:"blocked_at"`
+	Assignee       string    `json:"assignee,omitempty"`
+	Label          string    `json:"label"`
+	Logs           []string  `json:"logs,omitempty"`
+}
+
+func (e *Escalator) notify(ctx context.Context, task Task) error {
+	payload, err := json.Marshal(notification{
+		Text:           NotificationText(task, e.now()),
+		TaskID:         task.TaskID,
+		TaskTitle:      task.Title,
+		TriageCategory: task.TriageCategory,
+		TriageReason:   task.TriageReason,
+		BlockedAt:      task.BlockedAt,
+		Assignee:       strings.TrimSpace(e.policy.Assignee),
+		Label:          e.policy.LabelOrDefault(),
+		Logs:           task.Logs,
+	})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, e.policy.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := e.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("webhook returned status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// NotificationText renders the human-readable notification for task.
+func NotificationText(task Task, now time.Time) string {
+	title := task.TaskID
+	if strings.TrimSpace(task.Title) != "" {
+		title += " " + strings.TrimSpace(task.Title)
+	}
+	lines := []string{fmt.Sprintf("Task %s needs a human: blocked for %s.", title, now.Sub(task.BlockedAt).Round(time.Minute))}
+	if category := strings.TrimSpace(task.TriageCategory); category != "" {
+		lines = append(lines, "Triage category: "+category)
+	}
+	if reason := strings.TrimSpace(task.TriageReason); reason != "" {
+		lines = append(lines, "Triage reason: "+reason)
+	}
+	if len(task.Logs) > 0 {
+		lines = append(lines, "Logs:")
+		for _, path := range task.Logs {
+			lines = append(lines, "- "+path)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *Escalator) saveLocked() error {
+	if len(e.blocked) == 0 {
+		if err := os.Remove(e.statePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("write escalation state: %w", err)
+		}
+		return nil
+	}
+	raw, err := json.MarshalIndent(state{Tasks: e.blocked}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("write escalation state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(e.statePath), 0o755); err != nil {
+		return fmt.Errorf("write escalation state: %w", err)
+	}
+	tmp := e.statePath + ".tmp"
+	if err := os.WriteFile(tmp, append(raw, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write escalation state: %w", err)
+	}
+	if err := os.Rename(tmp, e.statePath); err != nil {
+		return fmt.Errorf("write escalation state: %w", err)
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
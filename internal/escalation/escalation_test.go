@@ -0,0 +1,194 @@
+package escalation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+type fakeTracker struct {
+	assigned map[string]string
+	labels   map[string][]string
+	labelErr error
+}
+
+func newFakeTracker() *fakeTracker {
+	return &fakeTracker{assigned: map[string]string{}, labels: map[string][]string{}}
+}
+
+func (t *fakeTracker) AssignTask(_ context.Context, taskID string, assignee string) error {
+	t.assigned[taskID] = assignee
+	return nil
+}
+
+func (t *fakeTracker) AddTaskLabels(_ context.Context, taskID string, labels []string) error {
+	if t.labelErr != nil {
+		return t.labelErr
+	}
+	t.labels[taskID] = append(t.labels[taskID], labels...)
+	return nil
+}
+
+func blockTask(t *testing.T, e *Escalator, taskID string, blockedAt time.Time) {
+	t.Helper()
+	events := []contracts.Event{
+		{Type: contracts.EventTypeTaskStarted, TaskID: taskID},
+		{Type: contracts.EventTypeRunnerStarted, TaskID: taskID, Metadata: map[string]string{"log_path": "runner-logs/" + taskID + "/implement.jsonl"}},
+		{Type: contracts.EventTypeRunnerStarted, TaskID: taskID, Metadata: map[string]string{"log_path": "runner-logs/" + taskID + "/implement.jsonl"}},
+		{Type: contracts.EventTypeTaskFinished, TaskID: taskID, TaskTitle: "Task " + taskID, Message: string(contracts.TaskStatusBlocked), Metadata: map[string]string{"triage_reason": "tests keep failing", "triage_category": "quality_gate"}, Timestamp: blockedAt},
+	}
+	for _, event := range events {
+		if err := e.Emit(context.Background(), event); err != nil {
+			t.Fatalf("emit %s: %v", event.Type, err)
+		}
+	}
+}
+
+func TestEscalateDueAssignsLabelsAndNotifiesAfterDelay(t *testing.T) {
+	var received notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decode notification: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	tracker := newFakeTracker()
+	statePath := filepath.Join(t.TempDir(), "escalations.json")
+	e, err := New(Policy{After: 30 * time.Minute, Assignee: "alice", WebhookURL: server.URL}, statePath, tracker, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	e.now = func() time.Time { return testNow }
+	blockTask(t, e, "t-1", testNow.Add(-45*time.Minute))
+	blockTask(t, e, "t-2", testNow.Add(-10*time.Minute))
+
+	results, err := e.EscalateDue(context.Background())
+	if err != nil {
+		t.Fatalf("escalate: %v", err)
+	}
+	if len(results) != 1 || results[0].TaskID != "t-1" || strings.Join(results[0].Actions, ", ") != "assigned to alice, labeled needs-human, notified" {
+		t.Fatalf("expected only t-1 escalated, got %#v", results)
+	}
+	if tracker.assigned["t-1"] != "alice" || strings.Join(tracker.labels["t-1"], ",") != DefaultLabel {
+		t.Fatalf("expected t-1 assigned and labeled, got %#v %#v", tracker.assigned, tracker.labels)
+	}
+	if received.TaskID != "t-1" || received.TriageReason != "tests keep failing" || received.TriageCategory != "quality_gate" || len(received.Logs) != 1 {
+		t.Fatalf("unexpected notification %#v", received)
+	}
+	if !strings.Contains(received.Text, "blocked for 45m0s") || !strings.Contains(received.Text, "Triage category: quality_gate") || !strings.Contains(received.Text, "- runner-logs/t-1/implement.jsonl") {
+		t.Fatalf("unexpected notification text %q", received.Text)
+	}
+	if pending := e.Pending(); len(pending) != 1 || pending[0].TaskID != "t-2" {
+		t.Fatalf("expected t-2 still pending, got %#v", pending)
+	}
+}
+
+func TestEscalatorKeepsBlockedTasksAcrossRuns(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), ".yolo-runner", "escalations.json")
+	first, err := New(Policy{After: time.Minute}, statePath, nil, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	blockTask(t, first, "t-1", testNow)
+
+	second, err := New(Policy{After: time.Minute}, statePath, nil, nil)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	pending := second.Pending()
+	if len(pending) != 1 || pending[0].TriageReason != "tests keep failing" || !pending[0].BlockedAt.Equal(testNow) {
+		t.Fatalf("expected the blocked task reloaded, got %#v", pending)
+	}
+	if err := second.Emit(context.Background(), contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "t-1"}); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+	if len(second.Pending()) != 0 {
+		t.Fatalf("expected a restarted task forgotten")
+	}
+	if _, err := os.Stat(statePath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the empty state file removed, got %v", err)
+	}
+}
+
+func TestEscalateDueDropsTasksNoLongerBlocked(t *testing.T) {
+	tracker := newFakeTracker()
+	status := func(context.Context, string) (contracts.TaskStatus, error) {
+		return contracts.TaskStatusOpen, nil
+	}
+	e, err := New(Policy{After: time.Minute}, filepath.Join(t.TempDir(), "escalations.json"), tracker, status)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	blockTask(t, e, "t-1", time.Now().Add(-time.Hour))
+
+	results, err := e.EscalateDue(context.Background())
+	if err != nil || len(results) != 0 {
+		t.Fatalf("expected nothing escalated, got %#v, %v", results, err)
+	}
+	if len(tracker.labels) != 0 || len(e.Pending()) != 0 {
+		t.Fatalf("expected the unblocked task dropped without labels")
+	}
+}
+
+func TestEscalateDueRetriesOnlyFailedSteps(t *testing.T) {
+	tracker := newFakeTracker()
+	tracker.labelErr = errors.New("rate limited")
+	e, err := New(Policy{After: time.Minute, Assignee: "alice"}, filepath.Join(t.TempDir(), "escalations.json"), tracker, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	blockTask(t, e, "t-1", time.Now().Add(-time.Hour))
+
+	if _, err := e.EscalateDue(context.Background()); err == nil || !strings.Contains(err.Error(), "rate limited") {
+		t.Fatalf("expected the label error, got %v", err)
+	}
+	if pending := e.Pending(); len(pending) != 1 || !pending[0].Assigned || pending[0].Labeled {
+		t.Fatalf("expected the task kept with its assignment recorded, got %#v", pending)
+	}
+	tracker.labelErr = nil
+	tracker.assigned = map[string]string{}
+	results, err := e.EscalateDue(context.Background())
+	if err != nil || len(results) != 1 {
+		t.Fatalf("expected the retry to escalate, got %#v, %v", results, err)
+	}
+	if len(tracker.assigned) != 0 || len(tracker.labels["t-1"]) != 1 {
+		t.Fatalf("expected only the label retried, got %#v %#v", tracker.assigned, tracker.labels)
+	}
+}
+
+func TestEscalateDueNotesUnsupportedTrackerSteps(t *testing.T) {
+	e, err := New(Policy{After: time.Minute, Assignee: "alice"}, filepath.Join(t.TempDir(), "escalations.json"), struct{}{}, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	blockTask(t, e, "t-1", time.Now().Add(-time.Hour))
+
+	results, err := e.EscalateDue(context.Background())
+	if err != nil || len(results) != 1 || len(results[0].Notes) != 2 {
+		t.Fatalf("expected the escalation to note both unsupported steps, got %#v, %v", results, err)
+	}
+}
+
+func TestPolicyDefaults(t *testing.T) {
+	if (Policy{Assignee: "alice"}).Enabled() {
+		t.Fatalf("expected a policy without a delay to be disabled")
+	}
+	if got := (Policy{}).LabelOrDefault(); got != DefaultLabel {
+		t.Fatalf("expected default label, got %q", got)
+	}
+	if got := (Policy{Label: "triage"}).LabelOrDefault(); got != "triage" {
+		t.Fatalf("expected configured label, got %q", got)
+	}
+}
@@ -0,0 +1,407 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	checkRunName = "yolo-agent"
+	// maxCheckRunSummary is GitHub's limit on a check run's output summary.
+	maxCheckRunSummary = 65535
+	// checkRunQueueSize bounds the events waiting for the worker. A run
+	// emits a handful of check events per task, so it only fills up when
+	// GitHub stops answering.
+	checkRunQueueSize = 256
+
+	checkRunInProgress = "in_progress"
+	checkRunCompleted  = "completed"
+
+	checkConclusionSuccess        = "success"
+	checkConclusionFailure        = "failure"
+	checkConclusionActionRequired = "action_required"
+	checkConclusionCancelled      = "cancelled"
+)
+
+// CheckRunSink reports a run and each task it works on as GitHub check runs
+// on one commit, so the commit and its pull requests show the agent's
+// progress. Check runs start in progress and are completed with a
+// conclusion when the task or run finishes. Other events are ignored.
+//
+// Emit only queues the event: a worker makes the GitHub calls so the loop
+// never waits on them, and API failures are written as warnings instead of
+// failing the emit. Close flushes the queue.
+type CheckRunSink struct {
+	manager  *TaskManager
+	headSHA  string
+	warnings io.Writer
+
+	mu      sync.Mutex
+	queue   chan contracts.Event
+	closed  bool
+	dropped int
+	done    chan struct{}
+
+	// Only the worker touches these.
+	run      *checkRun
+	tasks    map[string]*checkRun
+	disabled bool
+}
+
+type checkRun struct {
+	id        int64
+	name      string
+	completed bool
+}
+
+type checkRunOutput struct {
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+}
+
+type checkRunRequest struct {
+	Name        string          `json:"name,omitempty"`
+	HeadSHA     string          `json:"head_sha,omitempty"`
+	ExternalID  string          `json:"external_id,omitempty"`
+	Status      string          `json:"status,omitempty"`
+	Conclusion  string          `json:"conclusion,omitempty"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	Output      *checkRunOutput `json:"output,omitempty"`
+}
+
+var _ contracts.EventSink = (*CheckRunSink)(nil)
+
+// NewCheckRunSink reports check runs on headSHA in the repository of cfg and
+// writes API failures to warnings. The token needs the checks:write
+// permission, which GitHub grants to GitHub Apps and Actions tokens but not
+// to personal access tokens.
+func NewCheckRunSink(cfg Config, headSHA string, warnings io.Writer) (*CheckRunSink, error) {
+	headSHA = strings.TrimSpace(headSHA)
+	if headSHA == "" {
+		return nil, errors.New("github check runs need a head commit SHA")
+	}
+	manager, err := NewTaskManager(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newCheckRunSink(manager, headSHA, warnings), nil
+}
+
+func newCheckRunSink(manager *TaskManager, headSHA string, warnings io.Writer) *CheckRunSink {
+	if warnings == nil {
+		warnings = io.Discard
+	}
+	s := &CheckRunSink{
+		manager:  manager,
+		headSHA:  headSHA,
+		warnings: warnings,
+		queue:    make(chan contracts.Event, checkRunQueueSize),
+		done:     make(chan struct{}),
+		tasks:    map[string]*checkRun{},
+	}
+	go s.work()
+	return s
+}
+
+// Emit queues run and task lifecycle events for the worker and ignores the
+// rest. It never waits on GitHub and never fails.
+func (s *CheckRunSink) Emit(_ context.Context, event contracts.Event) error {
+	if s == nil || s.manager == nil || !isCheckRunEvent(event.Type) {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	select {
+	case s.queue <- event:
+	default:
+		s.dropped++
+	}
+	return nil
+}
+
+// Close waits for the queued events to reach GitHub. Events emitted after
+// Close are ignored.
+func (s *CheckRunSink) Close() {
+	if s == nil || s.manager == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	dropped := s.dropped
+	s.mu.Unlock()
+	<-s.done
+	if dropped > 0 {
+		fmt.Fprintf(s.warnings, "warning: GitHub check runs skipped %d events because GitHub fell behind\n", dropped)
+	}
+}
+
+func isCheckRunEvent(eventType contracts.EventType) bool {
+	switch eventType {
+	case contracts.EventTypeRunStarted, contracts.EventTypeTaskStarted, contracts.EventTypeReviewStarted,
+		contracts.EventTypeTaskFinished, contracts.EventTypeRunFinished:
+		return true
+	}
+	return false
+}
+
+func (s *CheckRunSink) work() {
+	defer close(s.done)
+	for event := range s.queue {
+		if s.disabled {
+			continue
+		}
+		err := s.report(context.Background(), event)
+		switch {
+		case err == nil:
+		case errors.Is(err, errCheckRunsForbidden):
+			// Every later call fails the same way, so warn once and stop.
+			s.disabled = true
+			fmt.Fprintf(s.warnings, "warning: GitHub check runs disabled: %v; the token needs checks:write, which personal access tokens cannot have (use a GitHub App or Actions token)\n", err)
+		default:
+			fmt.Fprintf(s.warnings, "warning: %v\n", err)
+		}
+	}
+}
+
+func (s *CheckRunSink) report(ctx context.Context, event contracts.Event) error {
+	at := event.Timestamp
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+	switch event.Type {
+	case contracts.EventTypeRunStarted:
+		run, err := s.create(ctx, checkRunName, event.RunID, at, checkRunOutput{
+			Title:   "Running tasks under " + event.TaskID,
+			Summary: "yolo-agent is working through the tasks under " + event.TaskID + ".",
+		})
+		if err != nil {
+			return err
+		}
+		s.run = run
+	case contracts.EventTypeTaskStarted:
+		taskID := strings.TrimSpace(event.TaskID)
+		if taskID == "" {
+			return nil
+		}
+		if check, ok := s.tasks[taskID]; ok && !check.completed {
+			return nil
+		}
+		check, err := s.create(ctx, taskCheckRunName(event), taskID, at, checkRunOutput{
+			Title:   "Implementing",
+			Summary: taskCheckRunSummary(event, ""),
+		})
+		if err != nil {
+			return err
+		}
+		s.tasks[taskID] = check
+	case contracts.EventTypeReviewStarted:
+		check, ok := s.tasks[strings.TrimSpace(event.TaskID)]
+		if !ok || check.completed {
+			return nil
+		}
+		return s.update(ctx, check, checkRunRequest{Output: &checkRunOutput{Title: "Reviewing", Summary: taskCheckRunSummary(event, "")}})
+	case contracts.EventTypeTaskFinished:
+		check, ok := s.tasks[strings.TrimSpace(event.TaskID)]
+		if !ok || check.completed {
+			return nil
+		}
+		conclusion, title := taskCheckRunConclusion(contracts.TaskStatus(event.Message))
+		return s.complete(ctx, check, conclusion, at, checkRunOutput{Title: title, Summary: taskCheckRunSummary(event, event.Metadata["triage_reason"])})
+	case contracts.EventTypeRunFinished:
+		var errs error
+		for _, taskID := range sortedTaskIDs(s.tasks) {
+			check := s.tasks[taskID]
+			if check.completed {
+				continue
+			}
+			errs = errors.Join(errs, s.complete(ctx, check, checkConclusionCancelled, at, checkRunOutput{
+				Title:   "Did not finish",
+				Summary: "The run ended before task " + taskID + " finished.",
+			}))
+		}
+		if s.run == nil || s.run.completed {
+			return errs
+		}
+		conclusion, title := runCheckRunConclusion(event.Metadata)
+		return errors.Join(errs, s.complete(ctx, s.run, conclusion, at, checkRunOutput{Title: title, Summary: runCheckRunSummary(event.Metadata)}))
+	}
+	return nil
+}
+
+func (s *CheckRunSink) create(ctx context.Context, name string, externalID string, startedAt time.Time, output checkRunOutput) (*checkRun, error) {
+	requestURL := buildCheckRunsURL(s.manager.apiEndpoint, s.manager.owner, s.manager.repo)
+	statusCode, body, err := s.manager.doGitHubJSON(ctx, http.MethodPost, requestURL, checkRunRequest{
+		Name:       name,
+		HeadSHA:    s.headSHA,
+		ExternalID: externalID,
+		Status:     checkRunInProgress,
+		StartedAt:  &startedAt,
+		Output:     limitCheckRunOutput(output),
+	}, maxReadResponseSize)
+	if err != nil {
+		return nil, fmt.Errorf("create GitHub check run %q: %w", name, err)
+	}
+	if statusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("create GitHub check run %q: %w", name, checkRunStatusError(statusCode, body))
+	}
+	created := struct {
+		ID int64 `json:"id"`
+	}{}
+	if err := json.Unmarshal(body, &created); err != nil || created.ID <= 0 {
+		return nil, fmt.Errorf("create GitHub check run %q: response has no check run ID", name)
+	}
+	return &checkRun{id: created.ID, name: name}, nil
+}
+
+func (s *CheckRunSink) complete(ctx context.Context, check *checkRun, conclusion string, completedAt time.Time, output checkRunOutput) error {
+	check.completed = true
+	return s.update(ctx, check, checkRunRequest{
+		Status:      checkRunCompleted,
+		Conclusion:  conclusion,
+		CompletedAt: &completedAt,
+		Output:      &output,
+	})
+}
+
+func (s *CheckRunSink) update(ctx context.Context, check *checkRun, request checkRunRequest) error {
+	if request.Output != nil {
+		request.Output = limitCheckRunOutput(*request.Output)
+	}
+	requestURL := buildCheckRunsURL(s.manager.apiEndpoint, s.manager.owner, s.manager.repo) + "/" + strconv.FormatInt(check.id, 10)
+	statusCode, body, err := s.manager.doGitHubJSON(ctx, http.MethodPatch, requestURL, request, maxReadResponseSize)
+	if err != nil {
+		return fmt.Errorf("update GitHub check run %q: %w", check.name, err)
+	}
+	if statusCode >= http.StatusBadRequest {
+		return fmt.Errorf("update GitHub check run %q: %w", check.name, checkRunStatusError(statusCode, body))
+	}
+	return nil
+}
+
+// errCheckRunsForbidden marks a 403 from the checks API, which GitHub
+// answers when the token lacks checks:write.
+var errCheckRunsForbidden = errors.New("token cannot write check runs")
+
+func checkRunStatusError(statusCode int, body []byte) error {
+	err := fmt.Errorf("request failed with status %d: %s", statusCode, firstAPIError(body))
+	if statusCode == http.StatusForbidden {
+		return fmt.Errorf("%w: %w", errCheckRunsForbidden, err)
+	}
+	return err
+}
+
+func taskCheckRunName(event contracts.Event) string {
+	name := checkRunName + ": " + event.TaskID
+	if title := strings.TrimSpace(event.TaskTitle); title != "" {
+		name += " " + title
+	}
+	return name
+}
+
+func taskCheckRunConclusion(status contracts.TaskStatus) (string, string) {
+	switch status {
+	case contracts.TaskStatusClosed:
+		return checkConclusionSuccess, "Completed"
+	case contracts.TaskStatusBlocked:
+		return checkConclusionActionRequired, "Blocked"
+	default:
+		return checkConclusionFailure, "Failed"
+	}
+}
+
+// taskCheckRunSummary describes the task, the reason it finished as it did
+// and the event metadata as a Markdown list.
+func taskCheckRunSummary(event contracts.Event, reason string) string {
+	lines := []string{"Task " + event.TaskID}
+	if title := strings.TrimSpace(event.TaskTitle); title != "" {
+		lines[0] += ": " + title
+	}
+	if reason = strings.TrimSpace(reason); reason != "" {
+		lines = append(lines, "", reason)
+	}
+	keys := make([]string, 0, len(event.Metadata))
+	for key := range event.Metadata {
+		if key != "triage_reason" && strings.TrimSpace(event.Metadata[key]) != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		lines = append(lines, "")
+	}
+	for _, key := range keys {
+		lines = append(lines, "- "+key+": "+event.Metadata[key])
+	}
+	return strings.Join(lines, "\n")
+}
+
+func runCheckRunConclusion(metadata map[string]string) (string, string) {
+	switch metadata["status"] {
+	case "interrupted":
+		return checkConclusionCancelled, "Interrupted"
+	case "failed":
+		return checkConclusionFailure, "Run failed"
+	}
+	switch {
+	case countMetadata(metadata, "failed") > 0:
+		return checkConclusionFailure, fmt.Sprintf("Failed tasks: %d", countMetadata(metadata, "failed"))
+	case countMetadata(metadata, "blocked") > 0:
+		return checkConclusionActionRequired, fmt.Sprintf("Blocked tasks: %d", countMetadata(metadata, "blocked"))
+	}
+	return checkConclusionSuccess, fmt.Sprintf("Completed tasks: %d", countMetadata(metadata, "completed"))
+}
+
+func runCheckRunSummary(metadata map[string]string) string {
+	lines := []string{}
+	for _, key := range []string{"completed", "blocked", "failed", "skipped"} {
+		lines = append(lines, fmt.Sprintf("- %s: %d", key, countMetadata(metadata, key)))
+	}
+	if reason := strings.TrimSpace(metadata["error"]); reason != "" {
+		lines = append(lines, "", reason)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func countMetadata(metadata map[string]string, key string) int {
+	count, _ := strconv.Atoi(strings.TrimSpace(metadata[key]))
+	return count
+}
+
+func limitCheckRunOutput(output checkRunOutput) *checkRunOutput {
+	if len(output.Summary) > maxCheckRunSummary {
+		output.Summary = strings.ToValidUTF8(output.Summary[:maxCheckRunSummary-3], "") + "..."
+	}
+	return &output
+}
+
+func sortedTaskIDs(tasks map[string]*checkRun) []string {
+	ids := make([]string, 0, len(tasks))
+	for id := range tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func buildCheckRunsURL(apiEndpoint string, owner string, repo string) string {
+	return strings.TrimRight(apiEndpoint, "/") + "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo) + "/check-runs"
+}
@@ -0,0 +1,161 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+type recordedCheckRunRequest struct {
+	method  string
+	path    string
+	payload checkRunRequest
+}
+
+func TestCheckRunSinkReportsRunAndTasksFromLoopEvents(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	requests := []recordedCheckRunRequest{}
+	nextID := 100
+	manager := newGitHubTestManager(t, func(t *testing.T, r *http.Request, w http.ResponseWriter) {
+		t.Helper()
+		payload := checkRunRequest{}
+		decodeJSONRequest(t, r, &payload)
+		mu.Lock()
+		requests = append(requests, recordedCheckRunRequest{method: r.Method, path: r.URL.Path, payload: payload})
+		nextID++
+		id := nextID
+		mu.Unlock()
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		_, _ = fmt.Fprintf(w, `{"id":%d}`, id)
+	})
+	sink := newCheckRunSink(manager, "abc123", nil)
+	at := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	events := []contracts.Event{
+		{Type: contracts.EventTypeRunStarted, TaskID: "1", RunID: "r-1", Timestamp: at},
+		{Type: contracts.EventTypeTaskStarted, TaskID: "2", TaskTitle: "Add login", Timestamp: at},
+		{Type: contracts.EventTypeTaskStarted, TaskID: "3", TaskTitle: "Fix nav", Timestamp: at},
+		{Type: contracts.EventTypeTaskStarted, TaskID: "4", TaskTitle: "Interrupted", Timestamp: at},
+		{Type: contracts.EventTypeRunnerOutput, TaskID: "2", Message: "ignored", Timestamp: at},
+		{Type: contracts.EventTypeReviewStarted, TaskID: "2", Timestamp: at},
+		{Type: contracts.EventTypeTaskFinished, TaskID: "2", TaskTitle: "Add login", Message: "closed", Timestamp: at.Add(time.Minute)},
+		{Type: contracts.EventTypeTaskFinished, TaskID: "3", TaskTitle: "Fix nav", Message: "blocked", Metadata: map[string]string{"triage_reason": "runner timeout", "triage_status": "blocked"}, Timestamp: at.Add(time.Minute)},
+		{Type: contracts.EventTypeRunFinished, TaskID: "1", Metadata: map[string]string{"status": "completed", "completed": "1", "blocked": "1", "failed": "0"}, Timestamp: at.Add(2 * time.Minute)},
+	}
+	for _, event := range events {
+		if err := sink.Emit(context.Background(), event); err != nil {
+			t.Fatalf("Emit(%s) returned error: %v", event.Type, err)
+		}
+	}
+	sink.Close()
+
+	want := []struct {
+		method     string
+		path       string
+		name       string
+		status     string
+		conclusion string
+		title      string
+	}{
+		{http.MethodPost, "/repos/egv/yolo-runner/check-runs", "yolo-agent", "in_progress", "", "Running tasks under 1"},
+		{http.MethodPost, "/repos/egv/yolo-runner/check-runs", "yolo-agent: 2 Add login", "in_progress", "", "Implementing"},
+		{http.MethodPost, "/repos/egv/yolo-runner/check-runs", "yolo-agent: 3 Fix nav", "in_progress", "", "Implementing"},
+		{http.MethodPost, "/repos/egv/yolo-runner/check-runs", "yolo-agent: 4 Interrupted", "in_progress", "", "Implementing"},
+		{http.MethodPatch, "/repos/egv/yolo-runner/check-runs/102", "", "", "", "Reviewing"},
+		{http.MethodPatch, "/repos/egv/yolo-runner/check-runs/102", "", "completed", "success", "Completed"},
+		{http.MethodPatch, "/repos/egv/yolo-runner/check-runs/103", "", "completed", "action_required", "Blocked"},
+		{http.MethodPatch, "/repos/egv/yolo-runner/check-runs/104", "", "completed", "cancelled", "Did not finish"},
+		{http.MethodPatch, "/repos/egv/yolo-runner/check-runs/101", "", "completed", "action_required", "Blocked tasks: 1"},
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("expected %d check run requests, got %#v", len(want), requests)
+	}
+	for i, request := range requests {
+		got := request.payload
+		title := ""
+		if got.Output != nil {
+			title = got.Output.Title
+		}
+		if request.method != want[i].method || request.path != want[i].path || got.Name != want[i].name || got.Status != want[i].status || got.Conclusion != want[i].conclusion || title != want[i].title {
+			t.Fatalf("request %d: expected %+v, got %s %s %#v", i, want[i], request.method, request.path, got)
+		}
+		if request.method == http.MethodPost && got.HeadSHA != "abc123" {
+			t.Fatalf("request %d: expected head SHA abc123, got %q", i, got.HeadSHA)
+		}
+	}
+	blocked := requests[6].payload.Output.Summary
+	if blocked != "Task 3: Fix nav\n\nrunner timeout\n\n- triage_status: blocked" {
+		t.Fatalf("unexpected blocked task summary %q", blocked)
+	}
+}
+
+func TestCheckRunSinkWarnsOnceAndStopsWhenTokenCannotWriteChecks(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	calls := 0
+	manager := newGitHubTestManager(t, func(t *testing.T, r *http.Request, w http.ResponseWriter) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message":"Resource not accessible by personal access token"}`))
+	})
+	var warnings bytes.Buffer
+	sink := newCheckRunSink(manager, "abc123", &warnings)
+	for _, event := range []contracts.Event{
+		{Type: contracts.EventTypeRunStarted, TaskID: "1"},
+		{Type: contracts.EventTypeTaskStarted, TaskID: "2"},
+		{Type: contracts.EventTypeRunFinished, TaskID: "1"},
+	} {
+		if err := sink.Emit(context.Background(), event); err != nil {
+			t.Fatalf("expected Emit to leave API errors to the warnings, got %v", err)
+		}
+	}
+	sink.Close()
+
+	if calls != 1 {
+		t.Fatalf("expected the sink to stop after the first 403, got %d calls", calls)
+	}
+	got := warnings.String()
+	if strings.Count(got, "warning:") != 1 || !strings.Contains(got, "status 403: Resource not accessible by personal access token") || !strings.Contains(got, "checks:write") {
+		t.Fatalf("expected one warning naming the missing permission, got %q", got)
+	}
+}
+
+func TestCheckRunSinkEmitDoesNotWaitOnGitHub(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	manager := newGitHubTestManager(t, func(t *testing.T, r *http.Request, w http.ResponseWriter) {
+		<-release
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	})
+	sink := newCheckRunSink(manager, "abc123", nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = sink.Emit(context.Background(), contracts.Event{Type: contracts.EventTypeRunStarted, TaskID: "1"})
+		for i := 0; i < 2*checkRunQueueSize; i++ {
+			_ = sink.Emit(context.Background(), contracts.Event{Type: contracts.EventTypeRunnerOutput, TaskID: "2"})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Emit blocked on a GitHub call")
+	}
+	close(release)
+	sink.Close()
+}
@@ -0,0 +1,49 @@
+package github
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// etagCache remembers the last successful body of each GET so repeated polls
+// can be sent as conditional requests. GitHub does not count 304 responses
+// against the primary rate limit, which keeps per-iteration tree reads cheap
+// on large repositories.
+type etagCache struct {
+	mu      sync.Mutex
+	entries map[string]etagEntry
+}
+
+type etagEntry struct {
+	etag string
+	body []byte
+}
+
+func newETagCache() *etagCache {
+	return &etagCache{entries: map[string]etagEntry{}}
+}
+
+func (c *etagCache) lookup(requestURL string) (etagEntry, bool) {
+	if c == nil {
+		return etagEntry{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[requestURL]
+	return entry, ok
+}
+
+func (c *etagCache) store(requestURL string, headers http.Header, body []byte) {
+	if c == nil {
+		return
+	}
+	etag := strings.TrimSpace(headers.Get("ETag"))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if etag == "" {
+		delete(c.entries, requestURL)
+		return
+	}
+	c.entries[requestURL] = etagEntry{etag: etag, body: append([]byte(nil), body...)}
+}
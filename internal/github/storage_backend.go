@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/egv/yolo-runner/v2/internal/contracts"
 )
@@ -17,6 +18,11 @@ type StorageBackend struct {
 }
 
 var _ contracts.StorageBackend = (*StorageBackend)(nil)
+var _ contracts.TaskCreator = (*StorageBackend)(nil)
+var _ contracts.TaskCommenter = (*StorageBackend)(nil)
+var _ contracts.TaskCommentReader = (*StorageBackend)(nil)
+var _ contracts.TaskAssigner = (*StorageBackend)(nil)
+var _ contracts.TaskLabeler = (*StorageBackend)(nil)
 
 func NewStorageBackend(cfg Config) (*StorageBackend, error) {
 	manager, err := NewTaskManager(cfg)
@@ -36,18 +42,13 @@ func (b *StorageBackend) GetTaskTree(ctx context.Context, rootID string) (*contr
 		return nil, err
 	}
 
-	rootIssue, err := b.manager.fetchIssue(ctx, rootNumber)
+	rootIssue, allIssues, err := b.manager.fetchIssueWithRepository(ctx, rootNumber)
 	if err != nil {
 		return nil, err
 	}
 	if rootIssue == nil {
 		return nil, fmt.Errorf("root task %q not found", rootID)
 	}
-
-	allIssues, err := b.manager.fetchRepositoryIssues(ctx)
-	if err != nil {
-		return nil, err
-	}
 	if !issueSliceContainsNumber(allIssues, rootNumber) {
 		allIssues = append(allIssues, *rootIssue)
 	}
@@ -135,7 +136,7 @@ func (b *StorageBackend) GetTask(ctx context.Context, taskID string) (*contracts
 		return nil, err
 	}
 
-	issue, err := b.manager.fetchIssue(ctx, issueNumber)
+	issue, allIssues, err := b.manager.fetchIssueWithRepository(ctx, issueNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -143,11 +144,6 @@ func (b *StorageBackend) GetTask(ctx context.Context, taskID string) (*contracts
 		return nil, fmt.Errorf("task %q not found", taskID)
 	}
 
-	allIssues, err := b.manager.fetchRepositoryIssues(ctx)
-	if err != nil {
-		return nil, err
-	}
-
 	task := b.taskFromIssuePayload(*issue, allIssues)
 	return &task, nil
 }
@@ -169,6 +165,41 @@ func (b *StorageBackend) SetTaskData(ctx context.Context, taskID string, data ma
 	return b.manager.SetTaskData(ctx, taskID, data)
 }
 
+func (b *StorageBackend) AddTaskComment(ctx context.Context, taskID string, body string) error {
+	if b == nil || b.manager == nil {
+		return fmt.Errorf("github storage backend is not initialized")
+	}
+	return b.manager.AddTaskComment(ctx, taskID, body)
+}
+
+func (b *StorageBackend) TaskComments(ctx context.Context, taskID string, since time.Time) ([]contracts.TaskComment, error) {
+	if b == nil || b.manager == nil {
+		return nil, fmt.Errorf("github storage backend is not initialized")
+	}
+	return b.manager.TaskComments(ctx, taskID, since)
+}
+
+func (b *StorageBackend) AssignTask(ctx context.Context, taskID string, assignee string) error {
+	if b == nil || b.manager == nil {
+		return fmt.Errorf("github storage backend is not initialized")
+	}
+	return b.manager.AssignTask(ctx, taskID, assignee)
+}
+
+func (b *StorageBackend) AddTaskLabels(ctx context.Context, taskID string, labels []string) error {
+	if b == nil || b.manager == nil {
+		return fmt.Errorf("github storage backend is not initialized")
+	}
+	return b.manager.AddTaskLabels(ctx, taskID, labels)
+}
+
+func (b *StorageBackend) CreateTask(ctx context.Context, request contracts.TaskCreateRequest) (string, error) {
+	if b == nil || b.manager == nil {
+		return "", fmt.Errorf("github storage backend is not initialized")
+	}
+	return b.manager.CreateTask(ctx, request)
+}
+
 func (b *StorageBackend) PersistTaskStatusChange(ctx context.Context, taskID string, status contracts.TaskStatus) error {
 	if b == nil || b.stateStore == nil {
 		return nil
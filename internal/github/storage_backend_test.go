@@ -254,6 +254,8 @@ func TestStorageBackendBacksOffWhenRateLimitApproaching(t *testing.T) {
 			w.Header().Set("X-RateLimit-Reset", "1700000003")
 			_, _ = w.Write([]byte(`{"number":60,"title":"Task 60","body":"","state":"open","labels":[]}`))
 		case "/repos/egv/yolo-runner/issues":
+			w.Header().Set("X-RateLimit-Remaining", "1")
+			w.Header().Set("X-RateLimit-Reset", "1700000003")
 			_, _ = w.Write([]byte(`[{"number":60,"title":"Task 60","body":"","state":"open","labels":[]}]`))
 		default:
 			t.Fatalf("unexpected request path %q", r.URL.Path)
@@ -440,6 +440,8 @@ func newStorageEngineFixture(t *testing.T, issues []githubIssuePayload) *storage
 		Token:       "ghp_test",
 		APIEndpoint: fixture.server.URL,
 		HTTPClient:  fixture.server.Client(),
+		// The fixture replays hundreds of writes in well under a second.
+		RequestsPerHour: 1 << 30,
 	})
 	if err != nil {
 		t.Fatalf("build storage backend: %v", err)
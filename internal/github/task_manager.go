@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -23,6 +24,12 @@ const (
 	maxReadResponseSize  = 8 << 20
 	issuesPerPage        = 100
 	maxRateLimitBackoff  = 30 * time.Second
+	taskDataCommentTag   = "<!-- yolo-runner-task-data -->"
+
+	// DefaultRequestsPerHour matches GitHub's primary REST quota for a
+	// personal access token.
+	DefaultRequestsPerHour = 5000
+	requestBurst           = 100
 )
 
 type HTTPClient interface {
@@ -36,6 +43,8 @@ type Config struct {
 	APIEndpoint string
 	HTTPClient  HTTPClient
 	StatePath   string
+	// RequestsPerHour caps outgoing API calls. Zero uses DefaultRequestsPerHour.
+	RequestsPerHour int
 }
 
 type TaskManager struct {
@@ -46,6 +55,8 @@ type TaskManager struct {
 	client      HTTPClient
 	now         func() time.Time
 	sleep       func(time.Duration)
+	limiter     *rate.Limiter
+	etags       *etagCache
 }
 
 type githubIssuePayload struct {
@@ -65,6 +76,14 @@ type githubLabelPayload struct {
 	Name string `json:"name"`
 }
 
+type githubCommentPayload struct {
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	User      *struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
 func NewTaskManager(cfg Config) (*TaskManager, error) {
 	owner := strings.TrimSpace(cfg.Owner)
 	if owner == "" {
@@ -103,9 +122,18 @@ func NewTaskManager(cfg Config) (*TaskManager, error) {
 		client:      client,
 		now:         time.Now,
 		sleep:       time.Sleep,
+		limiter:     newRequestLimiter(cfg.RequestsPerHour),
+		etags:       newETagCache(),
 	}, nil
 }
 
+func newRequestLimiter(requestsPerHour int) *rate.Limiter {
+	if requestsPerHour <= 0 {
+		requestsPerHour = DefaultRequestsPerHour
+	}
+	return rate.NewLimiter(rate.Limit(float64(requestsPerHour)/time.Hour.Seconds()), requestBurst)
+}
+
 func (m *TaskManager) NextTasks(ctx context.Context, parentID string) ([]contracts.TaskSummary, error) {
 	rootNumber, err := parseIssueNumber(parentID, "parent task ID")
 	if err != nil {
@@ -160,7 +188,7 @@ func (m *TaskManager) GetTask(ctx context.Context, taskID string) (contracts.Tas
 		return contracts.Task{}, err
 	}
 
-	issue, err := m.fetchIssue(ctx, issueNumber)
+	issue, issues, err := m.fetchIssueWithRepository(ctx, issueNumber)
 	if err != nil {
 		return contracts.Task{}, err
 	}
@@ -168,11 +196,6 @@ func (m *TaskManager) GetTask(ctx context.Context, taskID string) (contracts.Tas
 		return contracts.Task{}, nil
 	}
 
-	issues, err := m.fetchRepositoryIssues(ctx)
-	if err != nil {
-		return contracts.Task{}, err
-	}
-
 	metadata := map[string]string{}
 	if deps := dependencyIDsForIssue(*issue, issues); len(deps) > 0 {
 		metadata["dependencies"] = strings.Join(deps, ",")
@@ -241,7 +264,7 @@ func (m *TaskManager) SetTaskData(ctx context.Context, taskID string, data map[s
 	}
 	sort.Strings(keys)
 	commentLines := make([]string, 0, len(keys)+1)
-	commentLines = append(commentLines, "<!-- yolo-runner-task-data -->")
+	commentLines = append(commentLines, taskDataCommentTag)
 	for _, key := range keys {
 		commentLines = append(commentLines, key+"="+entries[key])
 	}
@@ -260,6 +283,188 @@ func (m *TaskManager) SetTaskData(ctx context.Context, taskID string, data map[s
 	return nil
 }
 
+// AddTaskComment posts body as a comment on the task's issue.
+func (m *TaskManager) AddTaskComment(ctx context.Context, taskID string, body string) error {
+	issueNumber, err := parseIssueNumber(taskID, "task ID")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil
+	}
+	requestURL := buildIssueCommentsURL(m.apiEndpoint, m.owner, m.repo, issueNumber)
+	statusCode, responseBody, err := m.doGitHubJSON(ctx, http.MethodPost, requestURL, map[string]string{"body": body}, maxReadResponseSize)
+	if err != nil {
+		return fmt.Errorf("comment on GitHub issue %d: %w", issueNumber, err)
+	}
+	if statusCode >= http.StatusBadRequest {
+		return fmt.Errorf("comment on GitHub issue %d: request failed with status %d: %s", issueNumber, statusCode, firstAPIError(responseBody))
+	}
+	return nil
+}
+
+// TaskComments lists the comments on the task's issue updated at or after
+// since, leaving out the task data comments SetTaskData posts.
+func (m *TaskManager) TaskComments(ctx context.Context, taskID string, since time.Time) ([]contracts.TaskComment, error) {
+	issueNumber, err := parseIssueNumber(taskID, "task ID")
+	if err != nil {
+		return nil, err
+	}
+	comments := []contracts.TaskComment{}
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("per_page", strconv.Itoa(issuesPerPage))
+		query.Set("page", strconv.Itoa(page))
+		if !since.IsZero() {
+			query.Set("since", since.UTC().Format(time.RFC3339))
+		}
+		requestURL := buildIssueCommentsURL(m.apiEndpoint, m.owner, m.repo, issueNumber) + "?" + query.Encode()
+		statusCode, body, err := m.doGitHubGET(ctx, requestURL, maxReadResponseSize)
+		if err != nil {
+			return nil, fmt.Errorf("list GitHub issue %d comments: %w", issueNumber, err)
+		}
+		if statusCode >= http.StatusBadRequest {
+			return nil, fmt.Errorf("list GitHub issue %d comments: request failed with status %d: %s", issueNumber, statusCode, firstAPIError(body))
+		}
+		pageComments := []githubCommentPayload{}
+		if strings.TrimSpace(string(body)) != "" {
+			if err := json.Unmarshal(body, &pageComments); err != nil {
+				return nil, fmt.Errorf("list GitHub issue %d comments: cannot parse response: %w", issueNumber, err)
+			}
+		}
+		for _, comment := range pageComments {
+			if strings.HasPrefix(strings.TrimSpace(comment.Body), taskDataCommentTag) {
+				continue
+			}
+			author := ""
+			if comment.User != nil {
+				author = comment.User.Login
+			}
+			comments = append(comments, contracts.TaskComment{Author: author, Body: comment.Body, CreatedAt: comment.CreatedAt})
+		}
+		if len(pageComments) < issuesPerPage {
+			break
+		}
+	}
+	return comments, nil
+}
+
+// AssignTask adds assignee, a GitHub login, to the task's issue assignees.
+func (m *TaskManager) AssignTask(ctx context.Context, taskID string, assignee string) error {
+	issueNumber, err := parseIssueNumber(taskID, "task ID")
+	if err != nil {
+		return err
+	}
+	assignee = strings.TrimPrefix(strings.TrimSpace(assignee), "@")
+	if assignee == "" {
+		return errors.New("assignee is required")
+	}
+	requestURL := buildIssueURL(m.apiEndpoint, m.owner, m.repo, issueNumber) + "/assignees"
+	statusCode, body, err := m.doGitHubJSON(ctx, http.MethodPost, requestURL, map[string][]string{"assignees": {assignee}}, maxReadResponseSize)
+	if err != nil {
+		return fmt.Errorf("assign GitHub issue %d: %w", issueNumber, err)
+	}
+	if statusCode >= http.StatusBadRequest {
+		return fmt.Errorf("assign GitHub issue %d: request failed with status %d: %s", issueNumber, statusCode, firstAPIError(body))
+	}
+	return nil
+}
+
+// AddTaskLabels adds labels to the task's issue. GitHub creates labels the
+// repository does not have yet.
+func (m *TaskManager) AddTaskLabels(ctx context.Context, taskID string, labels []string) error {
+	issueNumber, err := parseIssueNumber(taskID, "task ID")
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		if label = strings.TrimSpace(label); label != "" {
+			names = append(names, label)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	requestURL := buildIssueURL(m.apiEndpoint, m.owner, m.repo, issueNumber) + "/labels"
+	statusCode, body, err := m.doGitHubJSON(ctx, http.MethodPost, requestURL, map[string][]string{"labels": names}, maxReadResponseSize)
+	if err != nil {
+		return fmt.Errorf("label GitHub issue %d: %w", issueNumber, err)
+	}
+	if statusCode >= http.StatusBadRequest {
+		return fmt.Errorf("label GitHub issue %d: request failed with status %d: %s", issueNumber, statusCode, firstAPIError(body))
+	}
+	return nil
+}
+
+// CreateTask opens a new issue, records dependencies as a "Depends on:" body
+// line and attaches the issue to its parent through the sub-issues API.
+func (m *TaskManager) CreateTask(ctx context.Context, request contracts.TaskCreateRequest) (string, error) {
+	title := strings.TrimSpace(request.Title)
+	if title == "" {
+		return "", errors.New("task title is required")
+	}
+	parentNumber := 0
+	if parentID := strings.TrimSpace(request.ParentID); parentID != "" {
+		number, err := parseIssueNumber(parentID, "parent task ID")
+		if err != nil {
+			return "", err
+		}
+		parentNumber = number
+	}
+	dependencyRefs := make([]string, 0, len(request.DependsOn))
+	for _, depID := range request.DependsOn {
+		if strings.TrimSpace(depID) == "" {
+			continue
+		}
+		depNumber, err := parseIssueNumber(depID, "dependency task ID")
+		if err != nil {
+			return "", err
+		}
+		dependencyRefs = append(dependencyRefs, "#"+strconv.Itoa(depNumber))
+	}
+
+	body := strings.TrimSpace(request.Description)
+	if len(dependencyRefs) > 0 {
+		if body != "" {
+			body += "\n\n"
+		}
+		body += "Depends on: " + strings.Join(dependencyRefs, ", ")
+	}
+
+	requestURL := strings.TrimRight(m.apiEndpoint, "/") + "/repos/" + url.PathEscape(m.owner) + "/" + url.PathEscape(m.repo) + "/issues"
+	statusCode, responseBody, err := m.doGitHubJSON(ctx, http.MethodPost, requestURL, map[string]string{"title": title, "body": body}, maxReadResponseSize)
+	if err != nil {
+		return "", fmt.Errorf("create GitHub issue %q: %w", title, err)
+	}
+	if statusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("create GitHub issue %q: request failed with status %d: %s", title, statusCode, firstAPIError(responseBody))
+	}
+	var created struct {
+		ID     int64 `json:"id"`
+		Number int   `json:"number"`
+	}
+	if err := json.Unmarshal(responseBody, &created); err != nil {
+		return "", fmt.Errorf("create GitHub issue %q: cannot parse response: %w", title, err)
+	}
+	if created.Number <= 0 {
+		return "", fmt.Errorf("create GitHub issue %q: response has no issue number", title)
+	}
+	taskID := strconv.Itoa(created.Number)
+
+	if parentNumber > 0 {
+		subIssuesURL := buildIssueURL(m.apiEndpoint, m.owner, m.repo, parentNumber) + "/sub_issues"
+		statusCode, responseBody, err := m.doGitHubJSON(ctx, http.MethodPost, subIssuesURL, map[string]int64{"sub_issue_id": created.ID}, maxReadResponseSize)
+		if err != nil {
+			return taskID, fmt.Errorf("attach GitHub issue %d to parent %d: %w", created.Number, parentNumber, err)
+		}
+		if statusCode >= http.StatusBadRequest {
+			return taskID, fmt.Errorf("attach GitHub issue %d to parent %d: request failed with status %d: %s", created.Number, parentNumber, statusCode, firstAPIError(responseBody))
+		}
+	}
+	return taskID, nil
+}
+
 func (m *TaskManager) fetchRepositoryIssues(ctx context.Context) ([]githubIssuePayload, error) {
 	issues := []githubIssuePayload{}
 	for page := 1; ; page++ {
@@ -293,6 +498,27 @@ func (m *TaskManager) fetchRepositoryIssues(ctx context.Context) ([]githubIssueP
 	return issues, nil
 }
 
+// fetchIssueWithRepository resolves an issue from the repository listing,
+// which callers need anyway for dependency and parent lookups, and only falls
+// back to a direct read when the listing does not contain it.
+func (m *TaskManager) fetchIssueWithRepository(ctx context.Context, issueNumber int) (*githubIssuePayload, []githubIssuePayload, error) {
+	issues, err := m.fetchRepositoryIssues(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range issues {
+		if issues[i].Number == issueNumber {
+			issue := issues[i]
+			return &issue, issues, nil
+		}
+	}
+	issue, err := m.fetchIssue(ctx, issueNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	return issue, issues, nil
+}
+
 func (m *TaskManager) fetchIssue(ctx context.Context, issueNumber int) (*githubIssuePayload, error) {
 	requestURL := buildIssueURL(m.apiEndpoint, m.owner, m.repo, issueNumber)
 	statusCode, body, err := m.doGitHubGET(ctx, requestURL, maxReadResponseSize)
@@ -350,6 +576,18 @@ func (m *TaskManager) doGitHubJSON(ctx context.Context, method string, requestUR
 		if payload != nil {
 			req.Header.Set("Content-Type", "application/json")
 		}
+		cached, haveCached := etagEntry{}, false
+		if method == http.MethodGet {
+			cached, haveCached = m.etags.lookup(requestURL)
+			if haveCached {
+				req.Header.Set("If-None-Match", cached.etag)
+			}
+		}
+		if m.limiter != nil {
+			if err := m.limiter.Wait(ctx); err != nil {
+				return 0, nil, fmt.Errorf("request failed: %w", err)
+			}
+		}
 
 		resp, err := m.client.Do(req)
 		if err != nil {
@@ -369,6 +607,14 @@ func (m *TaskManager) doGitHubJSON(ctx context.Context, method string, requestUR
 			continue
 		}
 		m.maybeBackoffForRateLimit(resp.Header)
+		if method == http.MethodGet {
+			if resp.StatusCode == http.StatusNotModified && haveCached {
+				return http.StatusOK, cached.body, nil
+			}
+			if resp.StatusCode == http.StatusOK {
+				m.etags.store(requestURL, resp.Header, body)
+			}
+		}
 		return resp.StatusCode, body, nil
 	}
 }
@@ -611,8 +857,14 @@ func probeRepository(ctx context.Context, client HTTPClient, endpoint string, ow
 	}
 
 	var probe struct {
-		FullName string `json:"full_name"`
-		Message  string `json:"message"`
+		FullName    string `json:"full_name"`
+		Message     string `json:"message"`
+		Permissions *struct {
+			Admin    bool `json:"admin"`
+			Maintain bool `json:"maintain"`
+			Push     bool `json:"push"`
+			Triage   bool `json:"triage"`
+		} `json:"permissions"`
 	}
 	if len(strings.TrimSpace(string(body))) > 0 {
 		if err := json.Unmarshal(body, &probe); err != nil {
@@ -635,9 +887,29 @@ func probeRepository(ctx context.Context, client HTTPClient, endpoint string, ow
 		return fmt.Errorf("probe failed: expected repository %q, got %q", expected, strings.TrimSpace(probe.FullName))
 	}
 
+	// Classic tokens list their scopes in X-OAuth-Scopes; fine-grained
+	// tokens and app tokens send no header and are judged by the
+	// repository permissions alone.
+	if scopes, ok := resp.Header["X-Oauth-Scopes"]; ok && !hasIssueWriteScope(strings.Join(scopes, ",")) {
+		return fmt.Errorf("token scope check failed: token has scopes %q but needs repo (or public_repo for a public repository) to update issues", strings.TrimSpace(strings.Join(scopes, ",")))
+	}
+	if probe.Permissions != nil && !probe.Permissions.Admin && !probe.Permissions.Maintain && !probe.Permissions.Push && !probe.Permissions.Triage {
+		return fmt.Errorf("token scope check failed: token can only read %s; it needs triage or write access to update issues", expected)
+	}
+
 	return nil
 }
 
+func hasIssueWriteScope(header string) bool {
+	for _, scope := range strings.Split(header, ",") {
+		switch strings.TrimSpace(scope) {
+		case "repo", "public_repo":
+			return true
+		}
+	}
+	return false
+}
+
 func firstProbeError(message string, fallback string) string {
 	if strings.TrimSpace(message) != "" {
 		return strings.TrimSpace(message)
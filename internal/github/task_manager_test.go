@@ -97,6 +97,70 @@ func TestNewTaskManagerWrapsProbeAuthErrors(t *testing.T) {
 	}
 }
 
+func TestNewTaskManagerRejectsClassicTokenWithoutRepoScope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-OAuth-Scopes", "read:org, gist")
+		_, _ = w.Write([]byte(`{"full_name":"egv/yolo-runner","permissions":{"admin":true,"push":true,"pull":true}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := NewTaskManager(Config{
+		Owner:       "egv",
+		Repo:        "yolo-runner",
+		Token:       "ghp_test",
+		APIEndpoint: server.URL,
+		HTTPClient:  server.Client(),
+	})
+	if err == nil {
+		t.Fatalf("expected scope check failure")
+	}
+	if !strings.Contains(err.Error(), "token scope check failed") || !strings.Contains(err.Error(), "read:org, gist") {
+		t.Fatalf("expected scope check details, got %q", err.Error())
+	}
+}
+
+func TestNewTaskManagerRejectsReadOnlyRepositoryAccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"full_name":"egv/yolo-runner","permissions":{"admin":false,"maintain":false,"push":false,"triage":false,"pull":true}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := NewTaskManager(Config{
+		Owner:       "egv",
+		Repo:        "yolo-runner",
+		Token:       "github_pat_test",
+		APIEndpoint: server.URL,
+		HTTPClient:  server.Client(),
+	})
+	if err == nil {
+		t.Fatalf("expected read-only access to fail")
+	}
+	if !strings.Contains(err.Error(), "triage or write access") {
+		t.Fatalf("expected permission details, got %q", err.Error())
+	}
+}
+
+func TestNewTaskManagerAcceptsRepoScopedToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-OAuth-Scopes", "repo, read:org")
+		_, _ = w.Write([]byte(`{"full_name":"egv/yolo-runner","permissions":{"triage":true,"pull":true}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	if _, err := NewTaskManager(Config{
+		Owner:       "egv",
+		Repo:        "yolo-runner",
+		Token:       "ghp_test",
+		APIEndpoint: server.URL,
+		HTTPClient:  server.Client(),
+	}); err != nil {
+		t.Fatalf("expected repo-scoped token to pass, got %v", err)
+	}
+}
+
 func TestTaskManagerNextTasksFiltersUnsatisfiedDependenciesAndSortsByPriority(t *testing.T) {
 	t.Parallel()
 
@@ -233,6 +297,59 @@ func TestTaskManagerGetTaskMapsIssueDetailsAndDependencyMetadata(t *testing.T) {
 	}
 }
 
+func TestTaskManagerReusesListedIssueAndSendsConditionalRequests(t *testing.T) {
+	t.Parallel()
+
+	listRequests := 0
+	notModified := 0
+	manager := newGitHubTestManager(t, func(t *testing.T, r *http.Request, w http.ResponseWriter) {
+		t.Helper()
+		switch r.URL.Path {
+		case "/repos/egv/yolo-runner/issues":
+			listRequests++
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(`[{"number":8,"title":"Task 8","body":"","state":"open","labels":[]}]`))
+		default:
+			t.Fatalf("unexpected request path %q", r.URL.Path)
+		}
+	})
+
+	for i := 0; i < 3; i++ {
+		task, err := manager.GetTask(context.Background(), "8")
+		if err != nil {
+			t.Fatalf("GetTask returned error: %v", err)
+		}
+		if task.Title != "Task 8" {
+			t.Fatalf("expected cached body to be reused, got %#v", task)
+		}
+	}
+	if listRequests != 3 || notModified != 2 {
+		t.Fatalf("expected 2 of 3 list reads to be conditional, got %d reads and %d not-modified", listRequests, notModified)
+	}
+}
+
+func TestTaskManagerWaitsOnRequestLimiter(t *testing.T) {
+	t.Parallel()
+
+	manager := newGitHubTestManager(t, func(t *testing.T, r *http.Request, w http.ResponseWriter) {
+		t.Helper()
+		_, _ = w.Write([]byte(`[]`))
+	})
+	manager.limiter = newRequestLimiter(1)
+	manager.limiter.SetBurst(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := manager.fetchRepositoryIssues(ctx); err == nil || !strings.Contains(err.Error(), "rate") {
+		t.Fatalf("expected limiter to hold the request back, got %v", err)
+	}
+}
+
 func TestTaskManagerSetTaskStatusUpdatesIssueStateForLifecycle(t *testing.T) {
 	t.Parallel()
 
@@ -380,6 +497,168 @@ func TestTaskManagerSetTaskDataRetriesSecondaryRateLimit(t *testing.T) {
 	}
 }
 
+func TestTaskManagerAddTaskCommentPostsIssueComment(t *testing.T) {
+	t.Parallel()
+
+	written := ""
+	manager := newGitHubTestManager(t, func(t *testing.T, r *http.Request, w http.ResponseWriter) {
+		t.Helper()
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/egv/yolo-runner/issues/8/comments" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var payload struct {
+			Body string `json:"body"`
+		}
+		decodeJSONRequest(t, r, &payload)
+		written = payload.Body
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	})
+
+	if err := manager.AddTaskComment(context.Background(), "8", "yolo-runner: landed\ncommit: abc123"); err != nil {
+		t.Fatalf("AddTaskComment returned error: %v", err)
+	}
+	if written != "yolo-runner: landed\ncommit: abc123" {
+		t.Fatalf("unexpected comment body %q", written)
+	}
+}
+
+func TestTaskManagerTaskCommentsListsIssueCommentsSince(t *testing.T) {
+	t.Parallel()
+
+	since := ""
+	manager := newGitHubTestManager(t, func(t *testing.T, r *http.Request, w http.ResponseWriter) {
+		t.Helper()
+		if r.Method != http.MethodGet || r.URL.Path != "/repos/egv/yolo-runner/issues/8/comments" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		since = r.URL.Query().Get("since")
+		_, _ = w.Write([]byte(`[
+			{"body":"<!-- yolo-runner-task-data -->\ntriage_status=blocked","created_at":"2026-03-01T12:01:00Z","user":{"login":"bot"}},
+			{"body":"Please keep the old flag working","created_at":"2026-03-01T12:02:00Z","user":{"login":"alice"}}
+		]`))
+	})
+
+	comments, err := manager.TaskComments(context.Background(), "8", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("TaskComments returned error: %v", err)
+	}
+	if since != "2026-03-01T12:00:00Z" {
+		t.Fatalf("expected since query parameter, got %q", since)
+	}
+	if len(comments) != 1 || comments[0].Author != "alice" || comments[0].Body != "Please keep the old flag working" || !comments[0].CreatedAt.Equal(time.Date(2026, 3, 1, 12, 2, 0, 0, time.UTC)) {
+		t.Fatalf("expected only the human comment, got %#v", comments)
+	}
+}
+
+func TestTaskManagerAssignsAndLabelsIssue(t *testing.T) {
+	t.Parallel()
+
+	requests := []string{}
+	var assigned struct {
+		Assignees []string `json:"assignees"`
+	}
+	var labeled struct {
+		Labels []string `json:"labels"`
+	}
+	manager := newGitHubTestManager(t, func(t *testing.T, r *http.Request, w http.ResponseWriter) {
+		t.Helper()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/repos/egv/yolo-runner/issues/8/assignees":
+			decodeJSONRequest(t, r, &assigned)
+		case "/repos/egv/yolo-runner/issues/8/labels":
+			decodeJSONRequest(t, r, &labeled)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number":8}`))
+	})
+
+	if err := manager.AssignTask(context.Background(), "8", "@alice"); err != nil {
+		t.Fatalf("AssignTask returned error: %v", err)
+	}
+	if err := manager.AddTaskLabels(context.Background(), "8", []string{"needs-human", " "}); err != nil {
+		t.Fatalf("AddTaskLabels returned error: %v", err)
+	}
+	if len(requests) != 2 || requests[0] != "POST /repos/egv/yolo-runner/issues/8/assignees" || requests[1] != "POST /repos/egv/yolo-runner/issues/8/labels" {
+		t.Fatalf("unexpected requests %#v", requests)
+	}
+	if len(assigned.Assignees) != 1 || assigned.Assignees[0] != "alice" {
+		t.Fatalf("unexpected assignees %#v", assigned.Assignees)
+	}
+	if len(labeled.Labels) != 1 || labeled.Labels[0] != "needs-human" {
+		t.Fatalf("unexpected labels %#v", labeled.Labels)
+	}
+}
+
+func TestTaskManagerCreateTaskCreatesSubIssueWithDependencies(t *testing.T) {
+	t.Parallel()
+
+	requests := []string{}
+	var created struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}
+	var attached struct {
+		SubIssueID int64 `json:"sub_issue_id"`
+	}
+	manager := newGitHubTestManager(t, func(t *testing.T, r *http.Request, w http.ResponseWriter) {
+		t.Helper()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/repos/egv/yolo-runner/issues":
+			decodeJSONRequest(t, r, &created)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":9001,"number":42}`))
+		case "/repos/egv/yolo-runner/issues/7/sub_issues":
+			decodeJSONRequest(t, r, &attached)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"number":7}`))
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	taskID, err := manager.CreateTask(context.Background(), contracts.TaskCreateRequest{
+		ParentID:    "7",
+		Title:       "Add endpoint",
+		Description: "Expose the API.",
+		DependsOn:   []string{"40", "41"},
+	})
+	if err != nil {
+		t.Fatalf("CreateTask returned error: %v", err)
+	}
+	if taskID != "42" {
+		t.Fatalf("expected task ID 42, got %q", taskID)
+	}
+	wantRequests := []string{"POST /repos/egv/yolo-runner/issues", "POST /repos/egv/yolo-runner/issues/7/sub_issues"}
+	if strings.Join(requests, "|") != strings.Join(wantRequests, "|") {
+		t.Fatalf("unexpected requests %#v", requests)
+	}
+	if created.Title != "Add endpoint" || created.Body != "Expose the API.\n\nDepends on: #40, #41" {
+		t.Fatalf("unexpected issue payload %#v", created)
+	}
+	if attached.SubIssueID != 9001 {
+		t.Fatalf("expected sub_issue_id 9001, got %d", attached.SubIssueID)
+	}
+}
+
+func TestTaskManagerCreateTaskRejectsNonNumericDependency(t *testing.T) {
+	t.Parallel()
+
+	manager := newGitHubTestManager(t, func(t *testing.T, r *http.Request, w http.ResponseWriter) {
+		t.Helper()
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+
+	_, err := manager.CreateTask(context.Background(), contracts.TaskCreateRequest{Title: "A", DependsOn: []string{"abc"}})
+	if err == nil {
+		t.Fatalf("expected error for non-numeric dependency")
+	}
+}
+
 func decodeJSONRequest(t *testing.T, r *http.Request, out any) {
 	t.Helper()
 
@@ -25,9 +25,12 @@ type CommandSpec struct {
 	Binary string
 	Args   []string
 	Env    []string
-	Dir    string
-	Stdout io.Writer
-	Stderr io.Writer
+	// Secrets holds the values of Env entries that are secrets, so a remote
+	// executor can keep them off command lines and manifests.
+	Secrets []string
+	Dir     string
+	Stdout  io.Writer
+	Stderr  io.Writer
 }
 
 type CommandRunner interface {
@@ -84,14 +87,14 @@ func (a *CLIRunnerAdapter) Run(ctx context.Context, request contracts.RunnerRequ
 		return contracts.RunnerResult{}, err
 	}
 
-	stdoutFile, err := os.Create(logPath)
+	stdoutFile, err := contracts.CreateRunnerLog(logPath, request.Secrets)
 	if err != nil {
 		return contracts.RunnerResult{}, err
 	}
 	defer stdoutFile.Close()
 
 	stderrPath := contracts.BackendLogSidecarPath(logPath, contracts.BackendLogStderr)
-	stderrFile, err := os.Create(stderrPath)
+	stderrFile, err := contracts.CreateRunnerLog(stderrPath, request.Secrets)
 	if err != nil {
 		return contracts.RunnerResult{}, err
 	}
@@ -119,11 +122,13 @@ func (a *CLIRunnerAdapter) Run(ctx context.Context, request contracts.RunnerRequ
 	defer cancel()
 
 	runErr := a.runner.Run(runCtx, CommandSpec{
-		Binary: a.binary,
-		Args:   a.buildArgs(request),
-		Dir:    request.RepoRoot,
-		Stdout: stdoutWriter,
-		Stderr: stderrWriter,
+		Binary:  a.binary,
+		Args:    a.buildArgs(request),
+		Env:     contracts.RunnerEnv(request.Env),
+		Secrets: request.Secrets,
+		Dir:     request.RepoRoot,
+		Stdout:  stdoutWriter,
+		Stderr:  stderrWriter,
 	})
 	stdoutWriter.Flush()
 	stderrWriter.Flush()
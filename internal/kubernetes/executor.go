@@ -0,0 +1,451 @@
+// Package kubernetes runs runner commands as Kubernetes Jobs instead of
+// local processes, so task workers can scale beyond one machine. Jobs are
+// created and watched with kubectl, which brings its own kubeconfig and
+// login. The task's clone reaches the pod through a shared volume that is
+// mounted both on the orchestrator and in the pod.
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMountPath is where the workspace volume is mounted in the pod.
+	DefaultMountPath = "/workspace"
+	// DefaultStartTimeout bounds how long a pod may take to start running.
+	DefaultStartTimeout = 5 * time.Minute
+
+	// jobTTL removes finished Jobs the executor failed to delete.
+	jobTTL = 10 * time.Minute
+	// exitStatusTimeout bounds the wait for the pod's exit status after its
+	// logs end.
+	exitStatusTimeout  = time.Minute
+	statusPollInterval = time.Second
+	deleteTimeout      = 30 * time.Second
+	managedByLabel     = "app.kubernetes.io/managed-by"
+	managedByValue     = "yolo-runner"
+	containerName      = "runner"
+)
+
+// Config describes the pods the executor starts.
+type Config struct {
+	// Kubectl is the kubectl executable; "kubectl" from PATH by default.
+	Kubectl string
+	// Context and Namespace select the cluster; empty uses kubectl's
+	// current context and namespace.
+	Context   string
+	Namespace string
+	// Image must contain the runner binaries.
+	Image           string
+	ImagePullPolicy string
+	ServiceAccount  string
+	// Requests and Limits are container resources such as cpu and memory.
+	Requests map[string]string
+	Limits   map[string]string
+	// EnvFromSecrets names Secrets whose keys become environment variables,
+	// typically the backend's API keys.
+	EnvFromSecrets []string
+	Workspace      Workspace
+	// StartTimeout bounds how long a pod may take to start running.
+	StartTimeout time.Duration
+}
+
+// Workspace is a PersistentVolumeClaim mounted at LocalPath on the
+// orchestrator and at MountPath in the pod. Commands must run under
+// LocalPath, and paths under it are rewritten to MountPath.
+type Workspace struct {
+	Claim     string
+	LocalPath string
+	MountPath string
+}
+
+// Command is one runner invocation.
+type Command struct {
+	Binary string
+	Args   []string
+	// Env holds KEY=value entries added to the pod's environment.
+	Env []string
+	// Secrets holds the values of Env entries that are secrets. Their
+	// entries go in a Secret that lives as long as the Job.
+	Secrets []string
+	Dir     string
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+// KubectlFunc runs kubectl with args.
+type KubectlFunc func(ctx context.Context, stdin io.Reader, stdout io.Writer, stderr io.Writer, args ...string) error
+
+// Executor runs Commands as Kubernetes Jobs.
+type Executor struct {
+	config  Config
+	kubectl KubectlFunc
+	newName func(dir string) string
+}
+
+// NewExecutor returns an Executor that runs kubectl from config.Kubectl.
+func NewExecutor(config Config) (*Executor, error) {
+	binary := strings.TrimSpace(config.Kubectl)
+	if binary == "" {
+		binary = "kubectl"
+	}
+	return NewExecutorWithKubectl(config, func(ctx context.Context, stdin io.Reader, stdout io.Writer, stderr io.Writer, args ...string) error {
+		cmd := exec.CommandContext(ctx, binary, args...)
+		cmd.Stdin = stdin
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		return cmd.Run()
+	})
+}
+
+// NewExecutorWithKubectl returns an Executor that runs kubectl with run.
+func NewExecutorWithKubectl(config Config, run KubectlFunc) (*Executor, error) {
+	config.Image = strings.TrimSpace(config.Image)
+	if config.Image == "" {
+		return nil, errors.New("kubernetes executor requires an image")
+	}
+	config.Workspace.Claim = strings.TrimSpace(config.Workspace.Claim)
+	if config.Workspace.Claim == "" {
+		return nil, errors.New("kubernetes executor requires a workspace claim")
+	}
+	if !filepath.IsAbs(config.Workspace.LocalPath) {
+		return nil, fmt.Errorf("kubernetes workspace local path must be absolute, got %q", config.Workspace.LocalPath)
+	}
+	config.Workspace.LocalPath = filepath.Clean(config.Workspace.LocalPath)
+	if strings.TrimSpace(config.Workspace.MountPath) == "" {
+		config.Workspace.MountPath = DefaultMountPath
+	}
+	if !strings.HasPrefix(config.Workspace.MountPath, "/") {
+		return nil, fmt.Errorf("kubernetes workspace mount path must be absolute, got %q", config.Workspace.MountPath)
+	}
+	config.Workspace.MountPath = strings.TrimRight(config.Workspace.MountPath, "/")
+	if config.StartTimeout <= 0 {
+		config.StartTimeout = DefaultStartTimeout
+	}
+	return &Executor{config: config, kubectl: run, newName: newJobName}, nil
+}
+
+// Run creates a Job for command, streams the pod's logs to command.Stdout
+// until the container exits, and deletes the Job. A nonzero exit status is
+// returned as an error, and canceling ctx deletes the Job. Secret Env
+// entries reach the pod through a Secret that is deleted with the Job.
+func (e *Executor) Run(ctx context.Context, command Command) error {
+	stdout := writerOrDiscard(command.Stdout)
+	stderr := writerOrDiscard(command.Stderr)
+	name := e.newName(command.Dir)
+	manifest, err := e.Manifest(name, command)
+	if err != nil {
+		return err
+	}
+	secret, err := e.SecretManifest(name, command)
+	if err != nil {
+		return err
+	}
+	if secret != nil {
+		// The Secret must exist before the pod starts, and is deleted after
+		// the Job.
+		var createErr bytes.Buffer
+		if err := e.kubectl(ctx, bytes.NewReader(secret), io.Discard, &createErr, e.args("create", "-f", "-")...); err != nil {
+			return fmt.Errorf("create kubernetes secret %s: %w: %s", name, err, strings.TrimSpace(createErr.String()))
+		}
+		defer e.deleteObject("secret", name)
+	}
+	var uid, createErr bytes.Buffer
+	if err := e.kubectl(ctx, bytes.NewReader(manifest), &uid, &createErr, e.args("create", "-f", "-", "--output", "jsonpath={.metadata.uid}")...); err != nil {
+		return fmt.Errorf("create kubernetes job %s: %w: %s", name, err, strings.TrimSpace(createErr.String()))
+	}
+	defer e.deleteObject("job", name)
+	if secret != nil {
+		e.adoptSecret(ctx, name, strings.TrimSpace(uid.String()))
+	}
+	fmt.Fprintf(stderr, "kubernetes: started job %s\n", name)
+
+	logsErr := e.kubectl(ctx, nil, stdout, stderr, e.args("logs", "--follow", "job/"+name, "--pod-running-timeout="+e.config.StartTimeout.String())...)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	wait := exitStatusTimeout
+	if logsErr != nil {
+		// The pod did not start, or the log stream broke; only take an exit
+		// status that is already there.
+		wait = 0
+	}
+	code, err := e.exitCode(ctx, name, wait)
+	if err != nil {
+		if logsErr != nil {
+			return fmt.Errorf("kubernetes job %s: %w", name, logsErr)
+		}
+		return fmt.Errorf("kubernetes job %s: %w", name, err)
+	}
+	if code != 0 {
+		return fmt.Errorf("kubernetes job %s: exit status %d", name, code)
+	}
+	return nil
+}
+
+// Manifest returns the Job that runs command, as JSON.
+func (e *Executor) Manifest(name string, command Command) ([]byte, error) {
+	workspace := e.config.Workspace
+	dir, ok := e.podPath(command.Dir)
+	if !ok {
+		return nil, fmt.Errorf("kubernetes executor cannot run in %s: it is outside the workspace %s", command.Dir, workspace.LocalPath)
+	}
+	args := make([]string, 0, len(command.Args))
+	for _, arg := range command.Args {
+		args = append(args, e.rewritePaths(arg))
+	}
+	env := []map[string]any{}
+	for _, entry := range e.podEnv(command) {
+		if entry.secret {
+			env = append(env, map[string]any{
+				"name":      entry.key,
+				"valueFrom": map[string]any{"secretKeyRef": map[string]string{"name": name, "key": entry.key}},
+			})
+			continue
+		}
+		env = append(env, map[string]any{"name": entry.key, "value": entry.value})
+	}
+	container := map[string]any{
+		"name":         containerName,
+		"image":        e.config.Image,
+		"command":      append([]string{command.Binary}, args...),
+		"workingDir":   dir,
+		"env":          env,
+		"volumeMounts": []map[string]any{{"name": "workspace", "mountPath": workspace.MountPath}},
+	}
+	if policy := strings.TrimSpace(e.config.ImagePullPolicy); policy != "" {
+		container["imagePullPolicy"] = policy
+	}
+	if len(e.config.Requests) > 0 || len(e.config.Limits) > 0 {
+		resources := map[string]map[string]string{}
+		if len(e.config.Requests) > 0 {
+			resources["requests"] = e.config.Requests
+		}
+		if len(e.config.Limits) > 0 {
+			resources["limits"] = e.config.Limits
+		}
+		container["resources"] = resources
+	}
+	if len(e.config.EnvFromSecrets) > 0 {
+		envFrom := make([]map[string]any, 0, len(e.config.EnvFromSecrets))
+		for _, secret := range e.config.EnvFromSecrets {
+			envFrom = append(envFrom, map[string]any{"secretRef": map[string]string{"name": secret}})
+		}
+		container["envFrom"] = envFrom
+	}
+	podSpec := map[string]any{
+		"restartPolicy": "Never",
+		"containers":    []map[string]any{container},
+		"volumes": []map[string]any{{
+			"name":                  "workspace",
+			"persistentVolumeClaim": map[string]string{"claimName": workspace.Claim},
+		}},
+	}
+	if account := strings.TrimSpace(e.config.ServiceAccount); account != "" {
+		podSpec["serviceAccountName"] = account
+	}
+	labels := map[string]string{managedByLabel: managedByValue}
+	job := map[string]any{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata":   map[string]any{"name": name, "labels": labels},
+		"spec": map[string]any{
+			"backoffLimit":            0,
+			"ttlSecondsAfterFinished": int(jobTTL.Seconds()),
+			"template": map[string]any{
+				"metadata": map[string]any{"labels": labels},
+				"spec":     podSpec,
+			},
+		},
+	}
+	return json.Marshal(job)
+}
+
+// SecretManifest returns the Secret that holds command's secret Env
+// entries, as JSON, or nil when it has none. The Secret has the Job's name,
+// and the Job's env reads its keys.
+func (e *Executor) SecretManifest(name string, command Command) ([]byte, error) {
+	data := map[string]string{}
+	for _, entry := range e.podEnv(command) {
+		if !entry.secret {
+			continue
+		}
+		if !secretKeyPattern.MatchString(entry.key) {
+			return nil, fmt.Errorf("kubernetes executor cannot pass secret %s: it is not a valid secret key", entry.key)
+		}
+		data[entry.key] = entry.value
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]any{"name": name, "labels": map[string]string{managedByLabel: managedByValue}},
+		"type":       "Opaque",
+		"stringData": data,
+	})
+}
+
+type podEnvEntry struct {
+	key    string
+	value  string
+	secret bool
+}
+
+// podEnv is command's Env as the pod sees it, with the entries whose value
+// is one of command.Secrets marked secret.
+func (e *Executor) podEnv(command Command) []podEnvEntry {
+	secrets := map[string]bool{}
+	for _, secret := range command.Secrets {
+		if secret != "" {
+			secrets[secret] = true
+		}
+	}
+	entries := []podEnvEntry{}
+	for _, entry := range command.Env {
+		key, value, ok := strings.Cut(entry, "=")
+		// PATH points at local directories, such as the tool policy shims,
+		// that the pod does not have.
+		if !ok || strings.TrimSpace(key) == "" || key == "PATH" {
+			continue
+		}
+		if secrets[value] {
+			entries = append(entries, podEnvEntry{key: key, value: value, secret: true})
+			continue
+		}
+		entries = append(entries, podEnvEntry{key: key, value: e.rewritePaths(value)})
+	}
+	return entries
+}
+
+// adoptSecret makes the Job the owner of its Secret, so the Job's TTL
+// removes the Secret too when the executor dies before deleting it. It is
+// best effort: Run deletes the Secret itself.
+func (e *Executor) adoptSecret(ctx context.Context, name string, jobUID string) {
+	if jobUID == "" {
+		return
+	}
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{"ownerReferences": []map[string]any{{
+			"apiVersion": "batch/v1",
+			"kind":       "Job",
+			"name":       name,
+			"uid":        jobUID,
+		}}},
+	})
+	if err != nil {
+		return
+	}
+	_ = e.kubectl(ctx, nil, io.Discard, io.Discard, e.args("patch", "secret", name, "--type", "merge", "--patch", string(patch))...)
+}
+
+// exitCode polls the exit status of the Job's container for up to wait.
+func (e *Executor) exitCode(ctx context.Context, name string, wait time.Duration) (int, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		var out, errOut bytes.Buffer
+		err := e.kubectl(ctx, nil, &out, &errOut, e.args("get", "pods", "--selector", "job-name="+name, "--output", "jsonpath={.items[0].status.containerStatuses[0].state.terminated.exitCode}")...)
+		if err != nil {
+			return 0, fmt.Errorf("read exit status: %w: %s", err, strings.TrimSpace(errOut.String()))
+		}
+		if raw := strings.TrimSpace(out.String()); raw != "" {
+			code, err := strconv.Atoi(raw)
+			if err != nil {
+				return 0, fmt.Errorf("read exit status: unexpected %q", raw)
+			}
+			return code, nil
+		}
+		if !time.Now().Before(deadline) {
+			return 0, errors.New("container has not exited")
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(statusPollInterval):
+		}
+	}
+}
+
+// deleteObject removes the Job, with its pod, or the Secret named name. It
+// runs after ctx may have been canceled, so it uses its own timeout.
+func (e *Executor) deleteObject(kind string, name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), deleteTimeout)
+	defer cancel()
+	_ = e.kubectl(ctx, nil, io.Discard, io.Discard, e.args("delete", kind, name, "--ignore-not-found", "--wait=false", "--cascade=background")...)
+}
+
+func (e *Executor) args(args ...string) []string {
+	global := []string{}
+	if kubeContext := strings.TrimSpace(e.config.Context); kubeContext != "" {
+		global = append(global, "--context", kubeContext)
+	}
+	if namespace := strings.TrimSpace(e.config.Namespace); namespace != "" {
+		global = append(global, "--namespace", namespace)
+	}
+	return append(global, args...)
+}
+
+// podPath maps a local path under the workspace to the pod.
+func (e *Executor) podPath(local string) (string, bool) {
+	if !filepath.IsAbs(local) {
+		return "", false
+	}
+	rel, err := filepath.Rel(e.config.Workspace.LocalPath, local)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	if rel == "." {
+		return e.config.Workspace.MountPath, true
+	}
+	return e.config.Workspace.MountPath + "/" + filepath.ToSlash(rel), true
+}
+
+// rewritePaths replaces workspace paths inside value with their pod paths.
+func (e *Executor) rewritePaths(value string) string {
+	local := e.config.Workspace.LocalPath
+	if value == local {
+		return e.config.Workspace.MountPath
+	}
+	return strings.ReplaceAll(value, local+"/", e.config.Workspace.MountPath+"/")
+}
+
+var (
+	jobNameInvalid   = regexp.MustCompile(`[^a-z0-9-]+`)
+	secretKeyPattern = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+)
+
+// newJobName is "yolo-<dir base>-<random>", within the 63 characters a Job
+// name may have.
+func newJobName(dir string) string {
+	base := strings.Trim(jobNameInvalid.ReplaceAllString(strings.ToLower(filepath.Base(dir)), "-"), "-")
+	if len(base) > 40 {
+		base = strings.Trim(base[:40], "-")
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	if base == "" {
+		return "yolo-" + hex.EncodeToString(suffix)
+	}
+	return "yolo-" + base + "-" + hex.EncodeToString(suffix)
+}
+
+func writerOrDiscard(w io.Writer) io.Writer {
+	if w == nil {
+		return io.Discard
+	}
+	return w
+}
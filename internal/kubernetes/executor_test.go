@@ -0,0 +1,327 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeKubectl answers kubectl calls by their verb and records them.
+type fakeKubectl struct {
+	mu       sync.Mutex
+	calls    [][]string
+	manifest []byte
+	secret   []byte
+	logs     string
+	logsErr  error
+	exitCode string
+	// logsStarted is closed when kubectl logs is called; a non-nil
+	// logsRelease keeps logs running until it is closed or ctx ends.
+	logsStarted chan struct{}
+	logsRelease chan struct{}
+}
+
+func (f *fakeKubectl) run(ctx context.Context, stdin io.Reader, stdout io.Writer, _ io.Writer, args ...string) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, append([]string(nil), args...))
+	f.mu.Unlock()
+	verb := ""
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--context" || args[i] == "--namespace" {
+			i++
+			continue
+		}
+		verb = args[i]
+		break
+	}
+	switch verb {
+	case "create":
+		content, _ := io.ReadAll(stdin)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if bytes.Contains(content, []byte(`"kind":"Secret"`)) {
+			f.secret = content
+			return nil
+		}
+		f.manifest = content
+		_, _ = io.WriteString(stdout, "job-uid-1")
+	case "logs":
+		if f.logsStarted != nil {
+			close(f.logsStarted)
+		}
+		_, _ = io.WriteString(stdout, f.logs)
+		if f.logsRelease != nil {
+			select {
+			case <-f.logsRelease:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return f.logsErr
+	case "get":
+		_, _ = io.WriteString(stdout, f.exitCode)
+	}
+	return nil
+}
+
+func (f *fakeKubectl) verbs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	verbs := []string{}
+	for _, call := range f.calls {
+		verbs = append(verbs, call[4])
+	}
+	return verbs
+}
+
+func newTestExecutor(t *testing.T, kubectl *fakeKubectl) *Executor {
+	t.Helper()
+	executor, err := NewExecutorWithKubectl(Config{
+		Context:        "prod",
+		Namespace:      "yolo",
+		Image:          "ghcr.io/acme/worker:1",
+		ServiceAccount: "yolo-worker",
+		Requests:       map[string]string{"cpu": "1", "memory": "2Gi"},
+		Limits:         map[string]string{"memory": "4Gi"},
+		EnvFromSecrets: []string{"agent-keys"},
+		Workspace:      Workspace{Claim: "yolo-clones", LocalPath: "/srv/repo/"},
+	}, kubectl.run)
+	if err != nil {
+		t.Fatalf("new executor: %v", err)
+	}
+	executor.newName = func(string) string { return "yolo-task-1-abcd" }
+	return executor
+}
+
+func TestExecutorRunsCommandAsJob(t *testing.T) {
+	kubectl := &fakeKubectl{logs: "{\"type\":\"done\"}\n", exitCode: "0"}
+	executor := newTestExecutor(t, kubectl)
+	var stdout, stderr bytes.Buffer
+	err := executor.Run(context.Background(), Command{
+		Binary: "codex",
+		Args:   []string{"exec", "--output=/srv/repo/.yolo-runner/clones/task-1/out.json", "fix it"},
+		Env:    []string{"PATH=/tmp/shims:/usr/bin", "YOLO_TASK=task-1", "CACHE=/srv/repo/.cache"},
+		Dir:    "/srv/repo/.yolo-runner/clones/task-1",
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if stdout.String() != "{\"type\":\"done\"}\n" {
+		t.Fatalf("expected the pod logs on stdout, got %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "started job yolo-task-1-abcd") {
+		t.Fatalf("expected the job name on stderr, got %q", stderr.String())
+	}
+	if verbs := strings.Join(kubectl.verbs(), ","); verbs != "create,logs,get,delete" {
+		t.Fatalf("unexpected kubectl calls %s", verbs)
+	}
+	if first := kubectl.calls[0]; strings.Join(first[:4], " ") != "--context prod --namespace yolo" {
+		t.Fatalf("expected context and namespace on every call, got %v", first)
+	}
+
+	job := struct {
+		Spec struct {
+			BackoffLimit int `json:"backoffLimit"`
+			Template     struct {
+				Spec struct {
+					ServiceAccountName string `json:"serviceAccountName"`
+					RestartPolicy      string `json:"restartPolicy"`
+					Containers         []struct {
+						Image      string              `json:"image"`
+						Command    []string            `json:"command"`
+						WorkingDir string              `json:"workingDir"`
+						Env        []map[string]string `json:"env"`
+						Resources  struct {
+							Requests map[string]string `json:"requests"`
+							Limits   map[string]string `json:"limits"`
+						} `json:"resources"`
+						EnvFrom []struct {
+							SecretRef struct {
+								Name string `json:"name"`
+							} `json:"secretRef"`
+						} `json:"envFrom"`
+						VolumeMounts []struct {
+							MountPath string `json:"mountPath"`
+						} `json:"volumeMounts"`
+					} `json:"containers"`
+					Volumes []struct {
+						PersistentVolumeClaim struct {
+							ClaimName string `json:"claimName"`
+						} `json:"persistentVolumeClaim"`
+					} `json:"volumes"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}{}
+	if err := json.Unmarshal(kubectl.manifest, &job); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	pod := job.Spec.Template.Spec
+	container := pod.Containers[0]
+	if job.Spec.BackoffLimit != 0 || pod.RestartPolicy != "Never" || pod.ServiceAccountName != "yolo-worker" {
+		t.Fatalf("unexpected job spec %s", kubectl.manifest)
+	}
+	if container.WorkingDir != "/workspace/.yolo-runner/clones/task-1" {
+		t.Fatalf("expected the clone under the mount path, got %q", container.WorkingDir)
+	}
+	wantCommand := "codex exec --output=/workspace/.yolo-runner/clones/task-1/out.json fix it"
+	if got := strings.Join(container.Command, " "); got != wantCommand {
+		t.Fatalf("expected %q, got %q", wantCommand, got)
+	}
+	if len(container.Env) != 2 || container.Env[0]["name"] != "YOLO_TASK" || container.Env[1]["value"] != "/workspace/.cache" {
+		t.Fatalf("expected PATH dropped and workspace paths rewritten, got %v", container.Env)
+	}
+	if container.Resources.Requests["memory"] != "2Gi" || container.Resources.Limits["memory"] != "4Gi" {
+		t.Fatalf("unexpected resources %+v", container.Resources)
+	}
+	if len(container.EnvFrom) != 1 || container.EnvFrom[0].SecretRef.Name != "agent-keys" {
+		t.Fatalf("unexpected envFrom %+v", container.EnvFrom)
+	}
+	if container.VolumeMounts[0].MountPath != "/workspace" || pod.Volumes[0].PersistentVolumeClaim.ClaimName != "yolo-clones" {
+		t.Fatalf("expected the workspace claim mounted at /workspace, got %s", kubectl.manifest)
+	}
+}
+
+func TestExecutorPassesSecretsThroughAJobSecret(t *testing.T) {
+	kubectl := &fakeKubectl{exitCode: "0"}
+	err := newTestExecutor(t, kubectl).Run(context.Background(), Command{
+		Binary:  "agent",
+		Env:     []string{"OPENAI_API_KEY=sk-live-123", "YOLO_TASK=task-1"},
+		Secrets: []string{"sk-live-123"},
+		Dir:     "/srv/repo",
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if verbs := strings.Join(kubectl.verbs(), ","); verbs != "create,create,patch,logs,get,delete,delete" {
+		t.Fatalf("unexpected kubectl calls %s", verbs)
+	}
+	if strings.Contains(string(kubectl.manifest), "sk-live-123") {
+		t.Fatalf("expected the secret value kept out of the job manifest, got %s", kubectl.manifest)
+	}
+	job := struct {
+		Spec struct {
+			Template struct {
+				Spec struct {
+					Containers []struct {
+						Env []struct {
+							Name      string `json:"name"`
+							Value     string `json:"value"`
+							ValueFrom struct {
+								SecretKeyRef struct {
+									Name string `json:"name"`
+									Key  string `json:"key"`
+								} `json:"secretKeyRef"`
+							} `json:"valueFrom"`
+						} `json:"env"`
+					} `json:"containers"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}{}
+	if err := json.Unmarshal(kubectl.manifest, &job); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	env := job.Spec.Template.Spec.Containers[0].Env
+	if len(env) != 2 || env[0].ValueFrom.SecretKeyRef.Name != "yolo-task-1-abcd" || env[0].ValueFrom.SecretKeyRef.Key != "OPENAI_API_KEY" || env[1].Value != "task-1" {
+		t.Fatalf("expected the secret read from the job's secret, got %+v", env)
+	}
+	secret := struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		StringData map[string]string `json:"stringData"`
+	}{}
+	if err := json.Unmarshal(kubectl.secret, &secret); err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+	if secret.Metadata.Name != "yolo-task-1-abcd" || len(secret.StringData) != 1 || secret.StringData["OPENAI_API_KEY"] != "sk-live-123" {
+		t.Fatalf("unexpected secret %s", kubectl.secret)
+	}
+	patch := strings.Join(kubectl.calls[2], " ")
+	if !strings.Contains(patch, "secret yolo-task-1-abcd") || !strings.Contains(patch, `"uid":"job-uid-1"`) {
+		t.Fatalf("expected the job to own its secret, got %s", patch)
+	}
+	if last := kubectl.calls[len(kubectl.calls)-1]; strings.Join(last[4:6], " ") != "delete secret" {
+		t.Fatalf("expected the secret deleted after the job, got %v", last)
+	}
+}
+
+func TestExecutorReportsExitStatus(t *testing.T) {
+	kubectl := &fakeKubectl{exitCode: "3"}
+	err := newTestExecutor(t, kubectl).Run(context.Background(), Command{Binary: "agent", Dir: "/srv/repo"})
+	if err == nil || err.Error() != "kubernetes job yolo-task-1-abcd: exit status 3" {
+		t.Fatalf("expected the exit status, got %v", err)
+	}
+}
+
+func TestExecutorReportsPodsThatNeverStart(t *testing.T) {
+	kubectl := &fakeKubectl{logsErr: errors.New("timed out waiting for the condition")}
+	err := newTestExecutor(t, kubectl).Run(context.Background(), Command{Binary: "agent", Dir: "/srv/repo"})
+	if err == nil || !strings.Contains(err.Error(), "timed out waiting for the condition") {
+		t.Fatalf("expected the logs error, got %v", err)
+	}
+	if verbs := kubectl.verbs(); verbs[len(verbs)-1] != "delete" {
+		t.Fatalf("expected the job to be deleted, got %v", verbs)
+	}
+}
+
+func TestExecutorDeletesJobWhenCanceled(t *testing.T) {
+	kubectl := &fakeKubectl{logsStarted: make(chan struct{}), logsRelease: make(chan struct{})}
+	executor := newTestExecutor(t, kubectl)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- executor.Run(ctx, Command{Binary: "agent", Dir: "/srv/repo"})
+	}()
+	<-kubectl.logsStarted
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+	if verbs := strings.Join(kubectl.verbs(), ","); verbs != "create,logs,delete" {
+		t.Fatalf("unexpected kubectl calls %s", verbs)
+	}
+}
+
+func TestExecutorRejectsDirsOutsideWorkspace(t *testing.T) {
+	kubectl := &fakeKubectl{}
+	err := newTestExecutor(t, kubectl).Run(context.Background(), Command{Binary: "agent", Dir: "/srv/repository"})
+	if err == nil || !strings.Contains(err.Error(), "outside the workspace /srv/repo") {
+		t.Fatalf("expected a workspace error, got %v", err)
+	}
+	if len(kubectl.verbs()) != 0 {
+		t.Fatalf("expected no kubectl calls, got %v", kubectl.verbs())
+	}
+}
+
+func TestNewExecutorValidatesConfig(t *testing.T) {
+	tests := []struct {
+		config  Config
+		wantErr string
+	}{
+		{config: Config{Workspace: Workspace{Claim: "c", LocalPath: "/srv"}}, wantErr: "requires an image"},
+		{config: Config{Image: "i", Workspace: Workspace{LocalPath: "/srv"}}, wantErr: "requires a workspace claim"},
+		{config: Config{Image: "i", Workspace: Workspace{Claim: "c", LocalPath: "srv"}}, wantErr: "local path must be absolute"},
+		{config: Config{Image: "i", Workspace: Workspace{Claim: "c", LocalPath: "/srv", MountPath: "workspace"}}, wantErr: "mount path must be absolute"},
+	}
+	for _, tc := range tests {
+		if _, err := NewExecutorWithKubectl(tc.config, nil); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Fatalf("expected %q, got %v", tc.wantErr, err)
+		}
+	}
+}
+
+func TestNewJobNameIsAValidName(t *testing.T) {
+	name := newJobName("/srv/repo/.yolo-runner/clones/YR_42.Feature/With_A_Very_Long_Name_That_Keeps_Going_On")
+	if len(name) > 63 || !strings.HasPrefix(name, "yolo-") || jobNameInvalid.MatchString(name) || strings.Contains(name, "--") {
+		t.Fatalf("invalid job name %q", name)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/egv/yolo-runner/v2/internal/contracts"
 )
@@ -14,6 +15,9 @@ type StorageBackend struct {
 }
 
 var _ contracts.StorageBackend = (*StorageBackend)(nil)
+var _ contracts.TaskCreator = (*StorageBackend)(nil)
+var _ contracts.TaskCommenter = (*StorageBackend)(nil)
+var _ contracts.TaskCommentReader = (*StorageBackend)(nil)
 
 func NewStorageBackend(cfg Config) (*StorageBackend, error) {
 	manager, err := NewTaskManager(cfg)
@@ -66,3 +70,24 @@ func (b *StorageBackend) PersistTaskStatusChange(context.Context, string, contra
 func (b *StorageBackend) PersistTaskDataChange(context.Context, string, map[string]string) error {
 	return nil
 }
+
+func (b *StorageBackend) AddTaskComment(ctx context.Context, taskID string, body string) error {
+	if b == nil || b.manager == nil {
+		return fmt.Errorf("linear storage backend is not initialized")
+	}
+	return b.manager.AddTaskComment(ctx, taskID, body)
+}
+
+func (b *StorageBackend) TaskComments(ctx context.Context, taskID string, since time.Time) ([]contracts.TaskComment, error) {
+	if b == nil || b.manager == nil {
+		return nil, fmt.Errorf("linear storage backend is not initialized")
+	}
+	return b.manager.TaskComments(ctx, taskID, since)
+}
+
+func (b *StorageBackend) CreateTask(ctx context.Context, request contracts.TaskCreateRequest) (string, error) {
+	if b == nil || b.manager == nil {
+		return "", fmt.Errorf("linear storage backend is not initialized")
+	}
+	return b.manager.CreateTask(ctx, request)
+}
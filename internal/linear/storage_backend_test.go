@@ -87,10 +87,8 @@ func TestStorageBackendGetTaskTreeReturnsHierarchyWithDependencies(t *testing.T)
     }
   }
 }`))
-		case strings.Contains(query, "ReadIssueChildren") && strings.Contains(query, `issue(id: "iss-child")`):
-			_, _ = w.Write([]byte(`{"data":{"issue":{"children":{"nodes":[]}}}}`))
-		case strings.Contains(query, "ReadIssueChildren") && strings.Contains(query, `issue(id: "iss-dep")`):
-			_, _ = w.Write([]byte(`{"data":{"issue":{"children":{"nodes":[]}}}}`))
+		case strings.Contains(query, "ReadIssueChildren") && strings.Contains(query, `c0: issue(id: "iss-child")`) && strings.Contains(query, `c1: issue(id: "iss-dep")`):
+			_, _ = w.Write([]byte(`{"data":{"c0":{"children":{"nodes":[]}},"c1":{"children":{"nodes":[]}}}}`))
 		case strings.Contains(query, "ReadIssue {") && strings.Contains(query, `issue(id: "iss-root")`):
 			_, _ = w.Write([]byte(`{
   "data": {
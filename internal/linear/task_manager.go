@@ -8,11 +8,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -21,6 +23,11 @@ const (
 	maxReadResponseBytes     = 8 << 20
 	projectIssuesPageSize    = 250
 	projectRelationsPageSize = 25
+	issueChildrenBatchSize   = 20
+
+	// DefaultRequestsPerHour matches Linear's per-key API request quota.
+	DefaultRequestsPerHour = 1500
+	requestBurst           = 100
 )
 
 type HTTPClient interface {
@@ -32,6 +39,8 @@ type Config struct {
 	Token      string
 	Endpoint   string
 	HTTPClient HTTPClient
+	// RequestsPerHour caps outgoing GraphQL calls. Zero uses DefaultRequestsPerHour.
+	RequestsPerHour int
 }
 
 type taskManagerGraphQLError struct {
@@ -43,16 +52,23 @@ type TaskManager struct {
 	token     string
 	endpoint  string
 	client    HTTPClient
+	limiter   *rate.Limiter
 }
 
 type linearProjectPayload struct {
 	ID     string `json:"id"`
 	Name   string `json:"name"`
 	Issues struct {
-		Nodes []linearIssuePayload `json:"nodes"`
+		Nodes    []linearIssuePayload `json:"nodes"`
+		PageInfo *linearPageInfo      `json:"pageInfo"`
 	} `json:"issues"`
 }
 
+type linearPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
 type linearIssuePayload struct {
 	ID          string `json:"id"`
 	Title       string `json:"title"`
@@ -118,9 +134,17 @@ func NewTaskManager(cfg Config) (*TaskManager, error) {
 		token:     token,
 		endpoint:  endpoint,
 		client:    client,
+		limiter:   newRequestLimiter(cfg.RequestsPerHour),
 	}, nil
 }
 
+func newRequestLimiter(requestsPerHour int) *rate.Limiter {
+	if requestsPerHour <= 0 {
+		requestsPerHour = DefaultRequestsPerHour
+	}
+	return rate.NewLimiter(rate.Limit(float64(requestsPerHour)/time.Hour.Seconds()), requestBurst)
+}
+
 func (m *TaskManager) NextTasks(ctx context.Context, parentID string) ([]contracts.TaskSummary, error) {
 	parentID = strings.TrimSpace(parentID)
 	if parentID == "" {
@@ -377,6 +401,184 @@ func (m *TaskManager) SetTaskData(ctx context.Context, taskID string, data map[s
 	return nil
 }
 
+// AddTaskComment posts body as a comment on the Linear issue.
+func (m *TaskManager) AddTaskComment(ctx context.Context, taskID string, body string) error {
+	taskID = strings.TrimSpace(taskID)
+	if taskID == "" {
+		return errors.New("task ID is required")
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil
+	}
+	mutation := fmt.Sprintf(`mutation CreateIssueLifecycleComment {
+  commentCreate(input: { issueId: %s, body: %s }) {
+    success
+  }
+}`, graphQLQuote(taskID), graphQLQuote(body))
+	var payload struct {
+		CommentCreate struct {
+			Success bool `json:"success"`
+		} `json:"commentCreate"`
+	}
+	if err := m.runGraphQLQuery(ctx, mutation, &payload); err != nil {
+		return fmt.Errorf("comment on Linear issue %q: %w", taskID, err)
+	}
+	if !payload.CommentCreate.Success {
+		return fmt.Errorf("comment on Linear issue %q: unsuccessful mutation", taskID)
+	}
+	return nil
+}
+
+// taskDataCommentPattern matches the key=value comments SetTaskData posts.
+var taskDataCommentPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*=`)
+
+// TaskComments lists the comments on the issue updated at or after since,
+// leaving out the key=value comments SetTaskData posts. Only the 100 most
+// recent comments are read.
+func (m *TaskManager) TaskComments(ctx context.Context, taskID string, since time.Time) ([]contracts.TaskComment, error) {
+	taskID = strings.TrimSpace(taskID)
+	if taskID == "" {
+		return nil, errors.New("task ID is required")
+	}
+	filter := ""
+	if !since.IsZero() {
+		filter = fmt.Sprintf(", filter: { updatedAt: { gte: %s } }", graphQLQuote(since.UTC().Format(time.RFC3339Nano)))
+	}
+	query := fmt.Sprintf(`query ReadIssueComments {
+  issue(id: %s) {
+    comments(first: 100%s) {
+      nodes {
+        body
+        createdAt
+        user { name }
+      }
+    }
+  }
+}`, graphQLQuote(taskID), filter)
+	var payload struct {
+		Issue *struct {
+			Comments struct {
+				Nodes []struct {
+					Body      string    `json:"body"`
+					CreatedAt time.Time `json:"createdAt"`
+					User      *struct {
+						Name string `json:"name"`
+					} `json:"user"`
+				} `json:"nodes"`
+			} `json:"comments"`
+		} `json:"issue"`
+	}
+	if err := m.runGraphQLQuery(ctx, query, &payload); err != nil {
+		return nil, fmt.Errorf("list Linear issue %q comments: %w", taskID, err)
+	}
+	comments := []contracts.TaskComment{}
+	if payload.Issue == nil {
+		return comments, nil
+	}
+	for _, node := range payload.Issue.Comments.Nodes {
+		if taskDataCommentPattern.MatchString(strings.TrimSpace(node.Body)) {
+			continue
+		}
+		author := ""
+		if node.User != nil {
+			author = node.User.Name
+		}
+		comments = append(comments, contracts.TaskComment{Author: author, Body: node.Body, CreatedAt: node.CreatedAt})
+	}
+	sort.SliceStable(comments, func(i, j int) bool { return comments[i].CreatedAt.Before(comments[j].CreatedAt) })
+	return comments, nil
+}
+
+// CreateTask creates a sub-issue of request.ParentID in the parent's team and
+// links each dependency with a "blocks" relation from the dependency.
+func (m *TaskManager) CreateTask(ctx context.Context, request contracts.TaskCreateRequest) (string, error) {
+	title := strings.TrimSpace(request.Title)
+	if title == "" {
+		return "", errors.New("task title is required")
+	}
+	parentID := strings.TrimSpace(request.ParentID)
+	if parentID == "" {
+		return "", errors.New("parent task ID is required to create a Linear issue")
+	}
+
+	teamQuery := fmt.Sprintf(`query ReadIssueTeamForCreate {
+  issue(id: %s) {
+    id
+    team {
+      id
+    }
+  }
+}`, graphQLQuote(parentID))
+	var teamPayload struct {
+		Issue *struct {
+			ID   string `json:"id"`
+			Team *struct {
+				ID string `json:"id"`
+			} `json:"team"`
+		} `json:"issue"`
+	}
+	if err := m.runGraphQLQuery(ctx, teamQuery, &teamPayload); err != nil {
+		return "", fmt.Errorf("query Linear issue %q team: %w", parentID, err)
+	}
+	if teamPayload.Issue == nil {
+		return "", fmt.Errorf("cannot create Linear issue under %q: parent issue not found", parentID)
+	}
+	if teamPayload.Issue.Team == nil || strings.TrimSpace(teamPayload.Issue.Team.ID) == "" {
+		return "", fmt.Errorf("cannot create Linear issue under %q: parent issue has no team", parentID)
+	}
+	if strings.TrimSpace(teamPayload.Issue.ID) != "" {
+		parentID = strings.TrimSpace(teamPayload.Issue.ID)
+	}
+
+	mutation := fmt.Sprintf(`mutation CreateIssueForTask {
+  issueCreate(input: { teamId: %s, parentId: %s, title: %s, description: %s }) {
+    success
+    issue {
+      id
+    }
+  }
+}`, graphQLQuote(teamPayload.Issue.Team.ID), graphQLQuote(parentID), graphQLQuote(title), graphQLQuote(strings.TrimSpace(request.Description)))
+	var createPayload struct {
+		IssueCreate struct {
+			Success bool `json:"success"`
+			Issue   *struct {
+				ID string `json:"id"`
+			} `json:"issue"`
+		} `json:"issueCreate"`
+	}
+	if err := m.runGraphQLQuery(ctx, mutation, &createPayload); err != nil {
+		return "", fmt.Errorf("create Linear issue %q: %w", title, err)
+	}
+	if !createPayload.IssueCreate.Success || createPayload.IssueCreate.Issue == nil || strings.TrimSpace(createPayload.IssueCreate.Issue.ID) == "" {
+		return "", fmt.Errorf("create Linear issue %q: unsuccessful mutation", title)
+	}
+	taskID := strings.TrimSpace(createPayload.IssueCreate.Issue.ID)
+
+	for _, depID := range request.DependsOn {
+		depID = strings.TrimSpace(depID)
+		if depID == "" || depID == taskID {
+			continue
+		}
+		relationMutation := fmt.Sprintf(`mutation CreateIssueDependency {
+  issueRelationCreate(input: { issueId: %s, relatedIssueId: %s, type: blocks }) {
+    success
+  }
+}`, graphQLQuote(depID), graphQLQuote(taskID))
+		var relationPayload struct {
+			IssueRelationCreate struct {
+				Success bool `json:"success"`
+			} `json:"issueRelationCreate"`
+		}
+		if err := m.runGraphQLQuery(ctx, relationMutation, &relationPayload); err != nil {
+			return taskID, fmt.Errorf("link Linear issue %q dependency %q: %w", taskID, depID, err)
+		}
+		if !relationPayload.IssueRelationCreate.Success {
+			return taskID, fmt.Errorf("link Linear issue %q dependency %q: unsuccessful mutation", taskID, depID)
+		}
+	}
+	return taskID, nil
+}
+
 func probeViewer(ctx context.Context, client HTTPClient, endpoint string, token string) error {
 	reqBody := struct {
 		Query string `json:"query"`
@@ -715,17 +917,27 @@ func buildIssueTaskGraph(root *linearIssuePayload) (TaskGraph, map[string]contra
 	return TaskGraph{RootID: rootID, Nodes: nodes}, statusByID, nil
 }
 
+// fetchProject reads the whole project backlog, following the issues
+// connection cursor so projects larger than one page are not truncated.
 func (m *TaskManager) fetchProject(ctx context.Context, projectID string) (*linearProjectPayload, error) {
 	projectID = strings.TrimSpace(projectID)
 	if projectID == "" {
 		return nil, nil
 	}
 
-	query := fmt.Sprintf(`query ReadProjectBacklog {
+	var project *linearProjectPayload
+	cursor := ""
+	for {
+		afterClause := ""
+		if cursor != "" {
+			afterClause = ", after: " + graphQLQuote(cursor)
+		}
+		query := fmt.Sprintf(`query ReadProjectBacklog {
   project(id: %s) {
     id
     name
-    issues(first: %d) {
+    issues(first: %d%s) {
+      pageInfo { hasNextPage endCursor }
       nodes {
         id
         title
@@ -744,18 +956,33 @@ func (m *TaskManager) fetchProject(ctx context.Context, projectID string) (*line
     }
   }
 }`,
-		graphQLQuote(projectID),
-		projectIssuesPageSize,
-		projectRelationsPageSize,
-	)
+			graphQLQuote(projectID),
+			projectIssuesPageSize,
+			afterClause,
+			projectRelationsPageSize,
+		)
 
-	var payload struct {
-		Project *linearProjectPayload `json:"project"`
-	}
-	if err := m.runGraphQLQuery(ctx, query, &payload); err != nil {
-		return nil, fmt.Errorf("query Linear project backlog %q: %w", projectID, err)
+		var payload struct {
+			Project *linearProjectPayload `json:"project"`
+		}
+		if err := m.runGraphQLQuery(ctx, query, &payload); err != nil {
+			return nil, fmt.Errorf("query Linear project backlog %q: %w", projectID, err)
+		}
+		if payload.Project == nil {
+			return project, nil
+		}
+		pageInfo := payload.Project.Issues.PageInfo
+		if project == nil {
+			project = payload.Project
+		} else {
+			project.Issues.Nodes = append(project.Issues.Nodes, payload.Project.Issues.Nodes...)
+		}
+		if pageInfo == nil || !pageInfo.HasNextPage || strings.TrimSpace(pageInfo.EndCursor) == "" || pageInfo.EndCursor == cursor {
+			project.Issues.PageInfo = nil
+			return project, nil
+		}
+		cursor = pageInfo.EndCursor
 	}
-	return payload.Project, nil
 }
 
 func (m *TaskManager) fetchIssue(ctx context.Context, issueID string) (*linearIssuePayload, error) {
@@ -802,25 +1029,99 @@ func (m *TaskManager) fetchIssueSubtree(ctx context.Context, issueID string) (*l
 	return issue, nil
 }
 
+// populateIssueChildren walks the subtree breadth-first so each level costs
+// one query per issueChildrenBatchSize issues instead of one per issue.
 func (m *TaskManager) populateIssueChildren(ctx context.Context, issue *linearIssuePayload) error {
 	if issue == nil {
 		return nil
 	}
-	children, err := m.fetchIssueChildren(ctx, issue.ID)
-	if err != nil {
-		return err
-	}
-	issue.Children = &struct {
-		Nodes []linearIssuePayload `json:"nodes"`
-	}{Nodes: children}
-	for i := range issue.Children.Nodes {
-		if err := m.populateIssueChildren(ctx, &issue.Children.Nodes[i]); err != nil {
-			return err
+	level := []*linearIssuePayload{issue}
+	for len(level) > 0 {
+		next := []*linearIssuePayload{}
+		for start := 0; start < len(level); start += issueChildrenBatchSize {
+			end := start + issueChildrenBatchSize
+			if end > len(level) {
+				end = len(level)
+			}
+			batch := level[start:end]
+			ids := make([]string, 0, len(batch))
+			for _, parent := range batch {
+				ids = append(ids, parent.ID)
+			}
+			childrenByID, err := m.fetchIssueChildrenBatch(ctx, ids)
+			if err != nil {
+				return err
+			}
+			for _, parent := range batch {
+				parent.Children = &struct {
+					Nodes []linearIssuePayload `json:"nodes"`
+				}{Nodes: childrenByID[parent.ID]}
+				for i := range parent.Children.Nodes {
+					next = append(next, &parent.Children.Nodes[i])
+				}
+			}
 		}
+		level = next
 	}
 	return nil
 }
 
+func (m *TaskManager) fetchIssueChildrenBatch(ctx context.Context, issueIDs []string) (map[string][]linearIssuePayload, error) {
+	childrenByID := make(map[string][]linearIssuePayload, len(issueIDs))
+	if len(issueIDs) == 1 {
+		children, err := m.fetchIssueChildren(ctx, issueIDs[0])
+		if err != nil {
+			return nil, err
+		}
+		childrenByID[issueIDs[0]] = children
+		return childrenByID, nil
+	}
+
+	var query strings.Builder
+	query.WriteString("query ReadIssueChildren {\n")
+	for i, issueID := range issueIDs {
+		fmt.Fprintf(&query, `  c%d: issue(id: %s) {
+    id
+    children(first: %d) {
+      nodes {
+        id
+        title
+        description
+        priority
+        project { id }
+        parent { id }
+        state { type name }
+        relations(first: %d) {
+          nodes {
+            type
+            relatedIssue { id }
+          }
+        }
+      }
+    }
+  }
+`, i, graphQLQuote(strings.TrimSpace(issueID)), projectIssuesPageSize, projectRelationsPageSize)
+	}
+	query.WriteString("}")
+
+	var payload map[string]*struct {
+		Children *struct {
+			Nodes []linearIssuePayload `json:"nodes"`
+		} `json:"children"`
+	}
+	if err := m.runGraphQLQuery(ctx, query.String(), &payload); err != nil {
+		return nil, fmt.Errorf("query Linear issue children %s: %w", strings.Join(issueIDs, ", "), err)
+	}
+	for i, issueID := range issueIDs {
+		entry := payload[fmt.Sprintf("c%d", i)]
+		if entry == nil || entry.Children == nil {
+			continue
+		}
+		childrenByID[issueID] = entry.Children.Nodes
+	}
+	return childrenByID, nil
+}
+
 func (m *TaskManager) fetchIssueChildren(ctx context.Context, issueID string) ([]linearIssuePayload, error) {
 	issueID = strings.TrimSpace(issueID)
 	if issueID == "" {
@@ -887,6 +1188,11 @@ func (m *TaskManager) runGraphQLQuery(ctx context.Context, query string, out any
 	}
 	req.Header.Set("Authorization", m.token)
 	req.Header.Set("Content-Type", "application/json")
+	if m.limiter != nil {
+		if err := m.limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("GraphQL request failed: %w", err)
+		}
+	}
 
 	resp, err := m.client.Do(req)
 	if err != nil {
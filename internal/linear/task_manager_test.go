@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/egv/yolo-runner/v2/internal/contracts"
 	enginepkg "github.com/egv/yolo-runner/v2/internal/engine"
@@ -211,6 +212,84 @@ func TestTaskManagerFetchProjectCapsRelationPageSizeBelowLinearComplexityLimit(t
 	}
 }
 
+func TestTaskManagerFetchProjectFollowsIssuePages(t *testing.T) {
+	t.Parallel()
+
+	queries := []string{}
+	manager := newLinearTestManager(t, func(t *testing.T, query string, w http.ResponseWriter) {
+		t.Helper()
+		queries = append(queries, query)
+		if strings.Contains(query, `after: "cursor-1"`) {
+			_, _ = w.Write([]byte(`{"data":{"project":{"id":"proj-1","name":"Roadmap","issues":{"pageInfo":{"hasNextPage":false,"endCursor":"cursor-2"},"nodes":[{"id":"iss-2"}]}}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"project":{"id":"proj-1","name":"Roadmap","issues":{"pageInfo":{"hasNextPage":true,"endCursor":"cursor-1"},"nodes":[{"id":"iss-1"}]}}}}`))
+	})
+
+	project, err := manager.fetchProject(context.Background(), "proj-1")
+	if err != nil {
+		t.Fatalf("fetchProject returned error: %v", err)
+	}
+	if len(queries) != 2 {
+		t.Fatalf("expected two paged queries, got %d", len(queries))
+	}
+	if len(project.Issues.Nodes) != 2 || project.Issues.Nodes[0].ID != "iss-1" || project.Issues.Nodes[1].ID != "iss-2" {
+		t.Fatalf("expected issues from both pages, got %#v", project.Issues.Nodes)
+	}
+}
+
+func TestTaskManagerBatchesIssueChildrenPerLevel(t *testing.T) {
+	t.Parallel()
+
+	childQueries := 0
+	manager := newLinearTestManager(t, func(t *testing.T, query string, w http.ResponseWriter) {
+		t.Helper()
+		if !strings.Contains(query, "ReadIssueChildren") {
+			t.Fatalf("unexpected query: %q", query)
+		}
+		childQueries++
+		switch {
+		case strings.Contains(query, `issue(id: "iss-root")`):
+			_, _ = w.Write([]byte(`{"data":{"issue":{"children":{"nodes":[{"id":"iss-a"},{"id":"iss-b"},{"id":"iss-c"}]}}}}`))
+		case strings.Contains(query, `c0: issue(id: "iss-a")`) && strings.Contains(query, `c2: issue(id: "iss-c")`):
+			_, _ = w.Write([]byte(`{"data":{"c0":{"children":{"nodes":[]}},"c1":{"children":{"nodes":[{"id":"iss-b1"}]}},"c2":{"children":{"nodes":[]}}}}`))
+		case strings.Contains(query, `issue(id: "iss-b1")`):
+			_, _ = w.Write([]byte(`{"data":{"issue":{"children":{"nodes":[]}}}}`))
+		default:
+			t.Fatalf("unexpected query: %q", query)
+		}
+	})
+
+	root := &linearIssuePayload{ID: "iss-root"}
+	if err := manager.populateIssueChildren(context.Background(), root); err != nil {
+		t.Fatalf("populateIssueChildren returned error: %v", err)
+	}
+	if childQueries != 3 {
+		t.Fatalf("expected one children query per level, got %d", childQueries)
+	}
+	b := childNodes(root)[1]
+	if len(childNodes(&b)) != 1 || childNodes(&b)[0].ID != "iss-b1" {
+		t.Fatalf("expected batched children to be attached to iss-b, got %#v", b.Children)
+	}
+}
+
+func TestTaskManagerWaitsOnRequestLimiter(t *testing.T) {
+	t.Parallel()
+
+	manager := newLinearTestManager(t, func(t *testing.T, query string, w http.ResponseWriter) {
+		t.Helper()
+		_, _ = w.Write([]byte(`{"data":{"issue":null}}`))
+	})
+	manager.limiter = newRequestLimiter(1)
+	manager.limiter.SetBurst(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := manager.fetchIssue(ctx, "iss-1"); err == nil || !strings.Contains(err.Error(), "rate") {
+		t.Fatalf("expected limiter to hold the request back, got %v", err)
+	}
+}
+
 func TestTaskManagerNextTasksReturnsOpenLeafParentIssueWhenNoChildren(t *testing.T) {
 	t.Parallel()
 
@@ -512,6 +591,92 @@ func TestTaskManagerSetTaskDataWritesSortedIssueComments(t *testing.T) {
 	}
 }
 
+func TestTaskManagerAddTaskCommentCreatesIssueComment(t *testing.T) {
+	t.Parallel()
+
+	queries := []string{}
+	manager := newLinearTestManager(t, func(t *testing.T, query string, w http.ResponseWriter) {
+		t.Helper()
+		queries = append(queries, query)
+		_, _ = w.Write([]byte(`{"data":{"commentCreate":{"success":true}}}`))
+	})
+
+	if err := manager.AddTaskComment(context.Background(), "iss-7", "yolo-runner: blocked\ntriage reason: timeout"); err != nil {
+		t.Fatalf("AddTaskComment returned error: %v", err)
+	}
+	if len(queries) != 1 || !strings.Contains(queries[0], `commentCreate(input: { issueId: "iss-7", body: "yolo-runner: blocked\ntriage reason: timeout" })`) {
+		t.Fatalf("unexpected comment mutation %#v", queries)
+	}
+}
+
+func TestTaskManagerTaskCommentsListsIssueCommentsSince(t *testing.T) {
+	t.Parallel()
+
+	queries := []string{}
+	manager := newLinearTestManager(t, func(t *testing.T, query string, w http.ResponseWriter) {
+		t.Helper()
+		queries = append(queries, query)
+		_, _ = w.Write([]byte(`{"data":{"issue":{"comments":{"nodes":[
+			{"body":"Also update the docs","createdAt":"2026-03-01T12:03:00Z","user":{"name":"Bob"}},
+			{"body":"triage_status=blocked","createdAt":"2026-03-01T12:02:00Z","user":{"name":"Bot"}},
+			{"body":"Please keep the old flag working","createdAt":"2026-03-01T12:01:00Z","user":{"name":"Alice"}}
+		]}}}}`))
+	})
+
+	comments, err := manager.TaskComments(context.Background(), "iss-7", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("TaskComments returned error: %v", err)
+	}
+	if len(queries) != 1 || !strings.Contains(queries[0], `issue(id: "iss-7")`) || !strings.Contains(queries[0], `filter: { updatedAt: { gte: "2026-03-01T12:00:00Z" } }`) {
+		t.Fatalf("unexpected comments query %#v", queries)
+	}
+	if len(comments) != 2 || comments[0].Author != "Alice" || comments[1].Body != "Also update the docs" {
+		t.Fatalf("expected the human comments oldest first, got %#v", comments)
+	}
+}
+
+func TestTaskManagerCreateTaskCreatesSubIssueAndBlockingRelations(t *testing.T) {
+	t.Parallel()
+
+	queries := []string{}
+	manager := newLinearTestManager(t, func(t *testing.T, query string, w http.ResponseWriter) {
+		t.Helper()
+		queries = append(queries, query)
+		switch {
+		case strings.Contains(query, "ReadIssueTeamForCreate"):
+			_, _ = w.Write([]byte(`{"data":{"issue":{"id":"iss-root","team":{"id":"team-1"}}}}`))
+		case strings.Contains(query, "CreateIssueForTask"):
+			_, _ = w.Write([]byte(`{"data":{"issueCreate":{"success":true,"issue":{"id":"iss-new"}}}}`))
+		case strings.Contains(query, "CreateIssueDependency"):
+			_, _ = w.Write([]byte(`{"data":{"issueRelationCreate":{"success":true}}}`))
+		default:
+			t.Fatalf("unexpected query %q", query)
+		}
+	})
+
+	taskID, err := manager.CreateTask(context.Background(), contracts.TaskCreateRequest{
+		ParentID:    "iss-root",
+		Title:       "Add endpoint",
+		Description: "Expose the API.",
+		DependsOn:   []string{"iss-1"},
+	})
+	if err != nil {
+		t.Fatalf("CreateTask returned error: %v", err)
+	}
+	if taskID != "iss-new" {
+		t.Fatalf("expected task ID iss-new, got %q", taskID)
+	}
+	if len(queries) != 3 {
+		t.Fatalf("expected 3 GraphQL requests, got %d", len(queries))
+	}
+	if !strings.Contains(queries[1], `teamId: "team-1", parentId: "iss-root", title: "Add endpoint", description: "Expose the API."`) {
+		t.Fatalf("unexpected issueCreate mutation %q", queries[1])
+	}
+	if !strings.Contains(queries[2], `issueId: "iss-1", relatedIssueId: "iss-new", type: blocks`) {
+		t.Fatalf("unexpected relation mutation %q", queries[2])
+	}
+}
+
 func TestTaskManagerGetTaskTreeTreatsOpenRootWithTerminalChildrenAsComplete(t *testing.T) {
 	t.Parallel()
 
@@ -566,10 +731,8 @@ func TestTaskManagerGetTaskTreeTreatsOpenRootWithTerminalChildrenAsComplete(t *t
     }
   }
 }`))
-		case strings.Contains(query, "ReadIssueChildren") && strings.Contains(query, `issue(id: "iss-closed")`):
-			_, _ = w.Write([]byte(`{"data":{"issue":{"children":{"nodes":[]}}}}`))
-		case strings.Contains(query, "ReadIssueChildren") && strings.Contains(query, `issue(id: "iss-failed")`):
-			_, _ = w.Write([]byte(`{"data":{"issue":{"children":{"nodes":[]}}}}`))
+		case strings.Contains(query, "ReadIssueChildren") && strings.Contains(query, `c0: issue(id: "iss-closed")`) && strings.Contains(query, `c1: issue(id: "iss-failed")`):
+			_, _ = w.Write([]byte(`{"data":{"c0":{"children":{"nodes":[]}},"c1":{"children":{"nodes":[]}}}}`))
 		default:
 			t.Fatalf("unexpected query: %q", query)
 		}
@@ -0,0 +1,184 @@
+package notion
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const defaultPriority = 2
+
+// PropertyMapping names the database properties yolo-agent reads. Empty
+// entries use the names of Notion's task database template: the title
+// property, "Status", "Parent item", "Priority" and "Blocked by".
+type PropertyMapping struct {
+	Title        string
+	Status       string
+	Parent       string
+	Priority     string
+	Dependencies string
+}
+
+// StateMapping names the status option written for each task status. Empty
+// entries use the options of Notion's default status property; Blocked and
+// Failed fall back to the Open option.
+type StateMapping struct {
+	Open       string
+	InProgress string
+	Blocked    string
+	Failed     string
+	Closed     string
+}
+
+var (
+	closedStates     = []string{"done", "complete", "completed", "closed", "archived"}
+	inProgressStates = []string{"in progress", "doing", "started"}
+	blockedStates    = []string{"blocked"}
+
+	priorityNumberPattern = regexp.MustCompile(`(?i)^p\s*([0-9]+)$`)
+)
+
+func (m PropertyMapping) withDefaults() PropertyMapping {
+	m.Title = strings.TrimSpace(m.Title)
+	m.Status = strings.TrimSpace(m.Status)
+	m.Parent = strings.TrimSpace(m.Parent)
+	m.Priority = strings.TrimSpace(m.Priority)
+	m.Dependencies = strings.TrimSpace(m.Dependencies)
+	if m.Status == "" {
+		m.Status = "Status"
+	}
+	if m.Parent == "" {
+		m.Parent = "Parent item"
+	}
+	if m.Priority == "" {
+		m.Priority = "Priority"
+	}
+	if m.Dependencies == "" {
+		m.Dependencies = "Blocked by"
+	}
+	return m
+}
+
+func (m StateMapping) withDefaults() StateMapping {
+	m.Open = strings.TrimSpace(m.Open)
+	m.InProgress = strings.TrimSpace(m.InProgress)
+	m.Blocked = strings.TrimSpace(m.Blocked)
+	m.Failed = strings.TrimSpace(m.Failed)
+	m.Closed = strings.TrimSpace(m.Closed)
+	if m.Open == "" {
+		m.Open = "Not started"
+	}
+	if m.InProgress == "" {
+		m.InProgress = "In progress"
+	}
+	if m.Closed == "" {
+		m.Closed = "Done"
+	}
+	return m
+}
+
+func (m StateMapping) optionForStatus(status contracts.TaskStatus) (string, bool) {
+	switch status {
+	case contracts.TaskStatusOpen:
+		return m.Open, true
+	case contracts.TaskStatusInProgress:
+		return m.InProgress, true
+	case contracts.TaskStatusClosed:
+		return m.Closed, true
+	case contracts.TaskStatusBlocked:
+		if m.Blocked != "" {
+			return m.Blocked, true
+		}
+		return m.Open, true
+	case contracts.TaskStatusFailed:
+		if m.Failed != "" {
+			return m.Failed, true
+		}
+		return m.Open, true
+	default:
+		return "", false
+	}
+}
+
+// taskStatus maps a status option to a task status. Configured options win
+// over common option names; unknown options are treated as open.
+func (m StateMapping) taskStatus(option string) contracts.TaskStatus {
+	option = strings.TrimSpace(option)
+	switch {
+	case m.Closed != "" && strings.EqualFold(option, m.Closed):
+		return contracts.TaskStatusClosed
+	case m.Blocked != "" && strings.EqualFold(option, m.Blocked):
+		return contracts.TaskStatusBlocked
+	case m.Failed != "" && strings.EqualFold(option, m.Failed):
+		return contracts.TaskStatusFailed
+	case m.InProgress != "" && strings.EqualFold(option, m.InProgress):
+		return contracts.TaskStatusInProgress
+	case containsFold(closedStates, option):
+		return contracts.TaskStatusClosed
+	case containsFold(inProgressStates, option):
+		return contracts.TaskStatusInProgress
+	case containsFold(blockedStates, option):
+		return contracts.TaskStatusBlocked
+	default:
+		return contracts.TaskStatusOpen
+	}
+}
+
+// normalizePriority converts a select option such as "P1" or "High", or a
+// number, to scheduler ordering where lower is more urgent.
+func normalizePriority(property propertyPayload) int {
+	if property.Number != nil {
+		if *property.Number < 0 {
+			return defaultPriority
+		}
+		return int(*property.Number)
+	}
+	name := strings.ToLower(strings.TrimSpace(property.optionName()))
+	if match := priorityNumberPattern.FindStringSubmatch(name); match != nil {
+		if value, err := strconv.Atoi(match[1]); err == nil {
+			return value
+		}
+	}
+	switch name {
+	case "urgent", "critical", "highest":
+		return 0
+	case "high":
+		return 1
+	case "medium", "normal":
+		return 2
+	case "low", "lowest":
+		return 3
+	default:
+		return defaultPriority
+	}
+}
+
+// normalizePageID returns the dashed lowercase form of a Notion page ID, so
+// IDs copied from page URLs match IDs returned by the API.
+func normalizePageID(raw string) string {
+	trimmed := strings.TrimSpace(raw)
+	if idx := strings.LastIndexAny(trimmed, "-/"); idx >= 0 && len(trimmed)-idx-1 == 32 {
+		trimmed = trimmed[idx+1:]
+	}
+	compact := strings.ToLower(strings.ReplaceAll(trimmed, "-", ""))
+	if len(compact) != 32 {
+		return strings.ToLower(trimmed)
+	}
+	for _, r := range compact {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return strings.ToLower(trimmed)
+		}
+	}
+	return compact[0:8] + "-" + compact[8:12] + "-" + compact[12:16] + "-" + compact[16:20] + "-" + compact[20:32]
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package notion
+
+import (
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestNormalizePageIDAcceptsURLsAndCompactIDs(t *testing.T) {
+	want := "0123abcd-0123-4567-89ab-0123456789ab"
+	for _, raw := range []string{
+		"0123abcd0123456789ab0123456789ab",
+		"0123ABCD-0123-4567-89AB-0123456789AB",
+		"https://www.notion.so/acme/Fix-login-0123abcd0123456789ab0123456789ab",
+	} {
+		if got := normalizePageID(raw); got != want {
+			t.Fatalf("normalizePageID(%q) = %q, want %q", raw, got, want)
+		}
+	}
+	if got := normalizePageID(" "); got != "" {
+		t.Fatalf("expected empty ID to stay empty, got %q", got)
+	}
+}
+
+func TestStateMappingTaskStatus(t *testing.T) {
+	states := StateMapping{Failed: "Needs help"}.withDefaults()
+	for option, want := range map[string]contracts.TaskStatus{
+		"Not started": contracts.TaskStatusOpen,
+		"In progress": contracts.TaskStatusInProgress,
+		"Done":        contracts.TaskStatusClosed,
+		"Blocked":     contracts.TaskStatusBlocked,
+		"needs help":  contracts.TaskStatusFailed,
+		"Backlog":     contracts.TaskStatusOpen,
+	} {
+		if got := states.taskStatus(option); got != want {
+			t.Fatalf("option %q: expected %q, got %q", option, want, got)
+		}
+	}
+	if option, _ := states.optionForStatus(contracts.TaskStatusBlocked); option != "Not started" {
+		t.Fatalf("expected blocked to fall back to the open option, got %q", option)
+	}
+}
+
+func TestNormalizePriority(t *testing.T) {
+	three := 3.0
+	for _, tc := range []struct {
+		property propertyPayload
+		want     int
+	}{
+		{propertyPayload{Select: &namedOptionPayload{Name: "P1"}}, 1},
+		{propertyPayload{Select: &namedOptionPayload{Name: "Urgent"}}, 0},
+		{propertyPayload{Select: &namedOptionPayload{Name: "Low"}}, 3},
+		{propertyPayload{Number: &three}, 3},
+		{propertyPayload{}, defaultPriority},
+	} {
+		if got := normalizePriority(tc.property); got != tc.want {
+			t.Fatalf("normalizePriority(%#v) = %d, want %d", tc.property, got, tc.want)
+		}
+	}
+}
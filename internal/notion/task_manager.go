@@ -0,0 +1,744 @@
+package notion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	defaultAPIEndpoint   = "https://api.notion.com/v1"
+	notionVersion        = "2022-06-28"
+	maxReadResponseSize  = 8 << 20
+	pageSize             = 100
+	maxRichTextLength    = 2000
+	maxRateLimitBackoff  = 30 * time.Second
+	maxRateLimitAttempts = 3
+)
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Config selects a Notion database. Token is an internal integration secret;
+// the database must be shared with that integration.
+type Config struct {
+	DatabaseID  string
+	Token       string
+	Properties  PropertyMapping
+	States      StateMapping
+	APIEndpoint string
+	HTTPClient  HTTPClient
+}
+
+// TaskManager reads tasks from the pages of a Notion database. The parent
+// relation property builds the task tree and the dependencies relation
+// property orders siblings.
+type TaskManager struct {
+	databaseID  string
+	token       string
+	apiEndpoint string
+	properties  PropertyMapping
+	states      StateMapping
+	statusType  string
+	hasPriority bool
+	hasDeps     bool
+	client      HTTPClient
+	sleep       func(time.Duration)
+}
+
+type databasePayload struct {
+	ID         string                            `json:"id"`
+	Properties map[string]databasePropertySchema `json:"properties"`
+}
+
+type databasePropertySchema struct {
+	Type string `json:"type"`
+}
+
+type pagePayload struct {
+	ID         string                     `json:"id"`
+	Archived   bool                       `json:"archived"`
+	InTrash    bool                       `json:"in_trash"`
+	Properties map[string]propertyPayload `json:"properties"`
+}
+
+type propertyPayload struct {
+	Type     string                `json:"type"`
+	Title    []richTextPayload     `json:"title"`
+	RichText []richTextPayload     `json:"rich_text"`
+	Status   *namedOptionPayload   `json:"status"`
+	Select   *namedOptionPayload   `json:"select"`
+	Relation []relationItemPayload `json:"relation"`
+	Number   *float64              `json:"number"`
+}
+
+type richTextPayload struct {
+	PlainText string `json:"plain_text"`
+}
+
+type namedOptionPayload struct {
+	Name string `json:"name"`
+}
+
+type relationItemPayload struct {
+	ID string `json:"id"`
+}
+
+func NewTaskManager(cfg Config) (*TaskManager, error) {
+	databaseID := normalizePageID(cfg.DatabaseID)
+	if databaseID == "" {
+		return nil, errors.New("notion database ID is required")
+	}
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		return nil, errors.New("notion integration token is required")
+	}
+	endpoint := strings.TrimRight(strings.TrimSpace(cfg.APIEndpoint), "/")
+	if endpoint == "" {
+		endpoint = defaultAPIEndpoint
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+
+	manager := &TaskManager{
+		databaseID:  databaseID,
+		token:       token,
+		apiEndpoint: endpoint,
+		properties:  cfg.Properties.withDefaults(),
+		states:      cfg.States.withDefaults(),
+		client:      client,
+		sleep:       time.Sleep,
+	}
+	if err := manager.loadSchema(context.Background(), cfg.Properties); err != nil {
+		return nil, fmt.Errorf("notion auth validation failed: %w", err)
+	}
+	return manager, nil
+}
+
+// loadSchema checks that the mapped properties exist with a usable type.
+// Priority and dependencies are optional unless configured explicitly.
+func (m *TaskManager) loadSchema(ctx context.Context, configured PropertyMapping) error {
+	statusCode, body, err := m.doJSON(ctx, http.MethodGet, m.apiEndpoint+"/databases/"+url.PathEscape(m.databaseID), nil)
+	if err != nil {
+		return fmt.Errorf("probe request failed: %w", err)
+	}
+	if statusCode >= http.StatusBadRequest {
+		return fmt.Errorf("probe failed with status %d: %s", statusCode, firstAPIError(body))
+	}
+	var database databasePayload
+	if err := json.Unmarshal(body, &database); err != nil {
+		return fmt.Errorf("cannot parse probe response: %w", err)
+	}
+
+	if m.properties.Title == "" {
+		for name, schema := range database.Properties {
+			if schema.Type == "title" {
+				m.properties.Title = name
+			}
+		}
+	}
+	if schema, ok := database.Properties[m.properties.Title]; !ok || schema.Type != "title" {
+		return fmt.Errorf("database property %q must be the title property", m.properties.Title)
+	}
+	schema, ok := database.Properties[m.properties.Status]
+	if !ok || (schema.Type != "status" && schema.Type != "select") {
+		return fmt.Errorf("database property %q must be a status or select property", m.properties.Status)
+	}
+	m.statusType = schema.Type
+	if schema, ok := database.Properties[m.properties.Parent]; !ok || schema.Type != "relation" {
+		return fmt.Errorf("database property %q must be a relation property", m.properties.Parent)
+	}
+	if schema, ok := database.Properties[m.properties.Priority]; ok && (schema.Type == "select" || schema.Type == "number") {
+		m.hasPriority = true
+	} else if strings.TrimSpace(configured.Priority) != "" {
+		return fmt.Errorf("database property %q must be a select or number property", m.properties.Priority)
+	}
+	if schema, ok := database.Properties[m.properties.Dependencies]; ok && schema.Type == "relation" {
+		m.hasDeps = true
+	} else if strings.TrimSpace(configured.Dependencies) != "" {
+		return fmt.Errorf("database property %q must be a relation property", m.properties.Dependencies)
+	}
+	return nil
+}
+
+func (m *TaskManager) NextTasks(ctx context.Context, parentID string) ([]contracts.TaskSummary, error) {
+	rootID := normalizePageID(parentID)
+	if rootID == "" {
+		return nil, errors.New("parent task ID is required")
+	}
+	pages, err := m.queryDatabase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statusByID := make(map[string]contracts.TaskStatus, len(pages))
+	var root *pagePayload
+	children := []pagePayload{}
+	for i, page := range pages {
+		statusByID[page.ID] = m.statusOf(page)
+		if page.ID == rootID {
+			root = &pages[i]
+		}
+		if m.parentOf(page) == rootID {
+			children = append(children, page)
+		}
+	}
+	if root == nil {
+		return nil, nil
+	}
+	sort.Slice(children, func(i int, j int) bool {
+		left, right := m.priorityOf(children[i]), m.priorityOf(children[j])
+		if left != right {
+			return left < right
+		}
+		return children[i].ID < children[j].ID
+	})
+
+	tasks := make([]contracts.TaskSummary, 0, len(children))
+	for _, child := range children {
+		if statusByID[child.ID] != contracts.TaskStatusOpen {
+			continue
+		}
+		if !dependenciesClosed(m.dependenciesOf(child), statusByID) {
+			continue
+		}
+		tasks = append(tasks, m.summaryOf(child))
+	}
+	if len(tasks) > 0 || len(children) > 0 {
+		return tasks, nil
+	}
+	if statusByID[root.ID] != contracts.TaskStatusOpen || !dependenciesClosed(m.dependenciesOf(*root), statusByID) {
+		return nil, nil
+	}
+	return []contracts.TaskSummary{m.summaryOf(*root)}, nil
+}
+
+func (m *TaskManager) GetTask(ctx context.Context, taskID string) (contracts.Task, error) {
+	id := normalizePageID(taskID)
+	if id == "" {
+		return contracts.Task{}, errors.New("task ID is required")
+	}
+	page, err := m.fetchPage(ctx, id)
+	if err != nil {
+		return contracts.Task{}, err
+	}
+	if page == nil {
+		return contracts.Task{}, nil
+	}
+	task := m.taskFromPage(*page)
+	task.Description, err = m.fetchPageText(ctx, id)
+	if err != nil {
+		return contracts.Task{}, err
+	}
+	return task, nil
+}
+
+// GetTaskTree returns the root page and every page below it through the
+// parent relation.
+func (m *TaskManager) GetTaskTree(ctx context.Context, rootID string) (*contracts.TaskTree, error) {
+	id := normalizePageID(rootID)
+	if id == "" {
+		return nil, errors.New("root task ID is required")
+	}
+	pages, err := m.queryDatabase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byParent := map[string][]pagePayload{}
+	var root *pagePayload
+	for i, page := range pages {
+		if page.ID == id {
+			root = &pages[i]
+		}
+		if parent := m.parentOf(page); parent != "" {
+			byParent[parent] = append(byParent[parent], page)
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("root task %q not found", rootID)
+	}
+
+	tasks := map[string]contracts.Task{}
+	rootTask := m.taskFromPage(*root)
+	rootTask.ParentID = ""
+	tasks[root.ID] = rootTask
+	queue := []string{root.ID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range byParent[current] {
+			if _, seen := tasks[child.ID]; seen {
+				continue
+			}
+			tasks[child.ID] = m.taskFromPage(child)
+			queue = append(queue, child.ID)
+		}
+	}
+
+	relations := []contracts.TaskRelation{}
+	for _, task := range tasks {
+		if task.ParentID != "" {
+			relations = append(relations, contracts.TaskRelation{FromID: task.ParentID, ToID: task.ID, Type: contracts.RelationParent})
+		}
+		for _, depID := range splitDependencies(task.Metadata["dependencies"]) {
+			if _, ok := tasks[depID]; !ok {
+				continue
+			}
+			relations = append(relations,
+				contracts.TaskRelation{FromID: task.ID, ToID: depID, Type: contracts.RelationDependsOn},
+				contracts.TaskRelation{FromID: depID, ToID: task.ID, Type: contracts.RelationBlocks},
+			)
+		}
+	}
+	sort.Slice(relations, func(i int, j int) bool {
+		if relations[i].Type != relations[j].Type {
+			return relations[i].Type < relations[j].Type
+		}
+		if relations[i].FromID != relations[j].FromID {
+			return relations[i].FromID < relations[j].FromID
+		}
+		return relations[i].ToID < relations[j].ToID
+	})
+
+	return &contracts.TaskTree{Root: rootTask, Tasks: tasks, Relations: relations}, nil
+}
+
+// SetTaskStatus sets the status property to the option mapped for status.
+func (m *TaskManager) SetTaskStatus(ctx context.Context, taskID string, status contracts.TaskStatus) error {
+	id := normalizePageID(taskID)
+	if id == "" {
+		return errors.New("task ID is required")
+	}
+	option, ok := m.states.optionForStatus(status)
+	if !ok {
+		return fmt.Errorf("unsupported task status %q", status)
+	}
+	payload := map[string]any{
+		"properties": map[string]any{
+			m.properties.Status: map[string]any{m.statusType: map[string]string{"name": option}},
+		},
+	}
+	statusCode, body, err := m.doJSON(ctx, http.MethodPatch, m.apiEndpoint+"/pages/"+url.PathEscape(id), payload)
+	if err != nil {
+		return fmt.Errorf("update Notion page %s status %q: %w", id, status, err)
+	}
+	if statusCode >= http.StatusBadRequest {
+		return fmt.Errorf("update Notion page %s status %q: request failed with status %d: %s", id, status, statusCode, firstAPIError(body))
+	}
+	return nil
+}
+
+// SetTaskData appends data to the page as a run note.
+func (m *TaskManager) SetTaskData(ctx context.Context, taskID string, data map[string]string) error {
+	keys := make([]string, 0, len(data))
+	entries := map[string]string{}
+	for key, value := range data {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if _, ok := entries[key]; !ok {
+			keys = append(keys, key)
+		}
+		entries[key] = value
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys)+1)
+	lines = append(lines, "yolo-runner task data")
+	for _, key := range keys {
+		lines = append(lines, key+"="+entries[key])
+	}
+	if err := m.appendNote(ctx, taskID, strings.Join(lines, "\n")); err != nil {
+		return fmt.Errorf("write Notion page %s task data %q: %w", normalizePageID(taskID), keys[0], err)
+	}
+	return nil
+}
+
+// AddTaskComment appends body to the page as a run note.
+func (m *TaskManager) AddTaskComment(ctx context.Context, taskID string, body string) error {
+	if strings.TrimSpace(body) == "" {
+		return nil
+	}
+	if err := m.appendNote(ctx, taskID, body); err != nil {
+		return fmt.Errorf("append note to Notion page %s: %w", normalizePageID(taskID), err)
+	}
+	return nil
+}
+
+// CreateTask adds a page to the database, linked to its parent and
+// dependencies through the mapped relation properties.
+func (m *TaskManager) CreateTask(ctx context.Context, request contracts.TaskCreateRequest) (string, error) {
+	title := strings.TrimSpace(request.Title)
+	if title == "" {
+		return "", errors.New("task title is required")
+	}
+	properties := map[string]any{
+		m.properties.Title:  map[string]any{"title": richText(title)},
+		m.properties.Status: map[string]any{m.statusType: map[string]string{"name": m.states.Open}},
+	}
+	if parentID := normalizePageID(request.ParentID); parentID != "" {
+		properties[m.properties.Parent] = map[string]any{"relation": []relationItemPayload{{ID: parentID}}}
+	}
+	if m.hasDeps {
+		deps := []relationItemPayload{}
+		for _, depID := range request.DependsOn {
+			if depID = normalizePageID(depID); depID != "" {
+				deps = append(deps, relationItemPayload{ID: depID})
+			}
+		}
+		if len(deps) > 0 {
+			properties[m.properties.Dependencies] = map[string]any{"relation": deps}
+		}
+	}
+	payload := map[string]any{
+		"parent":     map[string]string{"database_id": m.databaseID},
+		"properties": properties,
+	}
+	if description := strings.TrimSpace(request.Description); description != "" {
+		payload["children"] = paragraphBlocks(description)
+	}
+
+	statusCode, body, err := m.doJSON(ctx, http.MethodPost, m.apiEndpoint+"/pages", payload)
+	if err != nil {
+		return "", fmt.Errorf("create Notion page %q: %w", title, err)
+	}
+	if statusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("create Notion page %q: request failed with status %d: %s", title, statusCode, firstAPIError(body))
+	}
+	var created pagePayload
+	if err := json.Unmarshal(body, &created); err != nil {
+		return "", fmt.Errorf("create Notion page %q: cannot parse response: %w", title, err)
+	}
+	if strings.TrimSpace(created.ID) == "" {
+		return "", fmt.Errorf("create Notion page %q: response has no page id", title)
+	}
+	return normalizePageID(created.ID), nil
+}
+
+func (m *TaskManager) taskFromPage(page pagePayload) contracts.Task {
+	metadata := map[string]string{}
+	if deps := m.dependenciesOf(page); len(deps) > 0 {
+		metadata["dependencies"] = strings.Join(deps, ",")
+	}
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+	return contracts.Task{
+		ID:       page.ID,
+		Title:    m.titleOf(page),
+		Status:   m.statusOf(page),
+		ParentID: m.parentOf(page),
+		Metadata: metadata,
+	}
+}
+
+func (m *TaskManager) summaryOf(page pagePayload) contracts.TaskSummary {
+	priority := m.priorityOf(page)
+	return contracts.TaskSummary{ID: page.ID, Title: m.titleOf(page), Priority: &priority}
+}
+
+func (m *TaskManager) titleOf(page pagePayload) string {
+	title := strings.TrimSpace(plainText(page.Properties[m.properties.Title].Title))
+	if title == "" {
+		return page.ID
+	}
+	return title
+}
+
+func (m *TaskManager) statusOf(page pagePayload) contracts.TaskStatus {
+	if page.Archived || page.InTrash {
+		return contracts.TaskStatusClosed
+	}
+	return m.states.taskStatus(page.Properties[m.properties.Status].optionName())
+}
+
+func (m *TaskManager) parentOf(page pagePayload) string {
+	relation := page.Properties[m.properties.Parent].Relation
+	if len(relation) == 0 {
+		return ""
+	}
+	return normalizePageID(relation[0].ID)
+}
+
+func (m *TaskManager) dependenciesOf(page pagePayload) []string {
+	if !m.hasDeps {
+		return nil
+	}
+	deps := []string{}
+	for _, item := range page.Properties[m.properties.Dependencies].Relation {
+		if id := normalizePageID(item.ID); id != "" && id != page.ID {
+			deps = append(deps, id)
+		}
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+func (m *TaskManager) priorityOf(page pagePayload) int {
+	if !m.hasPriority {
+		return defaultPriority
+	}
+	return normalizePriority(page.Properties[m.properties.Priority])
+}
+
+func (p propertyPayload) optionName() string {
+	if p.Status != nil {
+		return p.Status.Name
+	}
+	if p.Select != nil {
+		return p.Select.Name
+	}
+	return ""
+}
+
+// queryDatabase returns every page of the database with normalized IDs.
+func (m *TaskManager) queryDatabase(ctx context.Context) ([]pagePayload, error) {
+	pages := []pagePayload{}
+	cursor := ""
+	for {
+		payload := map[string]any{"page_size": pageSize}
+		if cursor != "" {
+			payload["start_cursor"] = cursor
+		}
+		requestURL := m.apiEndpoint + "/databases/" + url.PathEscape(m.databaseID) + "/query"
+		statusCode, body, err := m.doJSON(ctx, http.MethodPost, requestURL, payload)
+		if err != nil {
+			return nil, fmt.Errorf("query Notion database: %w", err)
+		}
+		if statusCode >= http.StatusBadRequest {
+			return nil, fmt.Errorf("query Notion database: request failed with status %d: %s", statusCode, firstAPIError(body))
+		}
+		var result struct {
+			Results    []pagePayload `json:"results"`
+			HasMore    bool          `json:"has_more"`
+			NextCursor string        `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("query Notion database: cannot parse response: %w", err)
+		}
+		for _, page := range result.Results {
+			page.ID = normalizePageID(page.ID)
+			pages = append(pages, page)
+		}
+		if !result.HasMore || result.NextCursor == "" {
+			return pages, nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+func (m *TaskManager) fetchPage(ctx context.Context, id string) (*pagePayload, error) {
+	statusCode, body, err := m.doJSON(ctx, http.MethodGet, m.apiEndpoint+"/pages/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("query Notion page %s: %w", id, err)
+	}
+	if statusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if statusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("query Notion page %s: request failed with status %d: %s", id, statusCode, firstAPIError(body))
+	}
+	var page pagePayload
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("query Notion page %s: cannot parse response: %w", id, err)
+	}
+	page.ID = normalizePageID(page.ID)
+	if page.ID == "" {
+		page.ID = id
+	}
+	return &page, nil
+}
+
+// fetchPageText returns the plain text of the page's top-level blocks, which
+// is where Notion keeps a task's description.
+func (m *TaskManager) fetchPageText(ctx context.Context, id string) (string, error) {
+	requestURL := m.apiEndpoint + "/blocks/" + url.PathEscape(id) + "/children?page_size=" + strconv.Itoa(pageSize)
+	statusCode, body, err := m.doJSON(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("query Notion page %s content: %w", id, err)
+	}
+	if statusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("query Notion page %s content: request failed with status %d: %s", id, statusCode, firstAPIError(body))
+	}
+	var result struct {
+		Results []map[string]json.RawMessage `json:"results"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("query Notion page %s content: cannot parse response: %w", id, err)
+	}
+	lines := []string{}
+	for _, block := range result.Results {
+		var blockType string
+		if err := json.Unmarshal(block["type"], &blockType); err != nil {
+			continue
+		}
+		var content struct {
+			RichText []richTextPayload `json:"rich_text"`
+		}
+		if err := json.Unmarshal(block[blockType], &content); err != nil || len(content.RichText) == 0 {
+			continue
+		}
+		lines = append(lines, plainText(content.RichText))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (m *TaskManager) appendNote(ctx context.Context, taskID string, text string) error {
+	id := normalizePageID(taskID)
+	if id == "" {
+		return errors.New("task ID is required")
+	}
+	requestURL := m.apiEndpoint + "/blocks/" + url.PathEscape(id) + "/children"
+	statusCode, body, err := m.doJSON(ctx, http.MethodPatch, requestURL, map[string]any{"children": paragraphBlocks(text)})
+	if err != nil {
+		return err
+	}
+	if statusCode >= http.StatusBadRequest {
+		return fmt.Errorf("request failed with status %d: %s", statusCode, firstAPIError(body))
+	}
+	return nil
+}
+
+// doJSON sends an authenticated request and retries when Notion answers 429.
+func (m *TaskManager) doJSON(ctx context.Context, method string, requestURL string, payload any) (int, []byte, error) {
+	var requestBody []byte
+	if payload != nil {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return 0, nil, fmt.Errorf("cannot encode request body: %w", err)
+		}
+		requestBody = body
+	}
+	for attempt := 0; ; attempt++ {
+		var bodyReader io.Reader
+		if requestBody != nil {
+			bodyReader = bytes.NewReader(requestBody)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, requestURL, bodyReader)
+		if err != nil {
+			return 0, nil, fmt.Errorf("cannot build request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+m.token)
+		req.Header.Set("Notion-Version", notionVersion)
+		if requestBody != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := m.client.Do(req)
+		if err != nil {
+			return 0, nil, fmt.Errorf("request failed: %w", err)
+		}
+		body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxReadResponseSize))
+		_ = resp.Body.Close()
+		if readErr != nil {
+			return 0, nil, fmt.Errorf("cannot read response: %w", readErr)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxRateLimitAttempts {
+			m.sleep(retryAfter(resp.Header, attempt))
+			continue
+		}
+		return resp.StatusCode, body, nil
+	}
+}
+
+func retryAfter(headers http.Header, attempt int) time.Duration {
+	wait := time.Duration(attempt+1) * time.Second
+	if seconds, err := strconv.Atoi(strings.TrimSpace(headers.Get("Retry-After"))); err == nil && seconds > 0 {
+		wait = time.Duration(seconds) * time.Second
+	}
+	if wait > maxRateLimitBackoff {
+		wait = maxRateLimitBackoff
+	}
+	return wait
+}
+
+// paragraphBlocks splits text into paragraph blocks within Notion's rich
+// text length limit.
+func paragraphBlocks(text string) []map[string]any {
+	blocks := []map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		runes := []rune(line)
+		for len(runes) > maxRichTextLength {
+			blocks = append(blocks, paragraphBlock(string(runes[:maxRichTextLength])))
+			runes = runes[maxRichTextLength:]
+		}
+		blocks = append(blocks, paragraphBlock(string(runes)))
+	}
+	return blocks
+}
+
+func paragraphBlock(text string) map[string]any {
+	return map[string]any{
+		"object":    "block",
+		"type":      "paragraph",
+		"paragraph": map[string]any{"rich_text": richText(text)},
+	}
+}
+
+func richText(text string) []map[string]any {
+	if text == "" {
+		return []map[string]any{}
+	}
+	return []map[string]any{{"type": "text", "text": map[string]string{"content": text}}}
+}
+
+func plainText(parts []richTextPayload) string {
+	var builder strings.Builder
+	for _, part := range parts {
+		builder.WriteString(part.PlainText)
+	}
+	return builder.String()
+}
+
+func splitDependencies(raw string) []string {
+	deps := []string{}
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			deps = append(deps, part)
+		}
+	}
+	return deps
+}
+
+func dependenciesClosed(dependencies []string, statusByID map[string]contracts.TaskStatus) bool {
+	for _, depID := range dependencies {
+		status, ok := statusByID[depID]
+		if !ok {
+			continue
+		}
+		if status != contracts.TaskStatusClosed {
+			return false
+		}
+	}
+	return true
+}
+
+func firstAPIError(body []byte) string {
+	bodyText := strings.TrimSpace(string(body))
+	if bodyText == "" {
+		return "unknown error"
+	}
+	var payload struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && strings.TrimSpace(payload.Message) != "" {
+		return strings.TrimSpace(payload.Message)
+	}
+	return bodyText
+}
@@ -0,0 +1,294 @@
+package notion
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	rootPageID = "11111111-1111-1111-1111-111111111111"
+	pageA      = "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+	pageB      = "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb"
+	pageC      = "cccccccc-cccc-cccc-cccc-cccccccccccc"
+)
+
+func TestNewTaskManagerRequiresDatabaseAndToken(t *testing.T) {
+	if _, err := NewTaskManager(Config{Token: "secret"}); err == nil || !strings.Contains(err.Error(), "database") {
+		t.Fatalf("expected database validation error, got %v", err)
+	}
+	if _, err := NewTaskManager(Config{DatabaseID: "db"}); err == nil || !strings.Contains(err.Error(), "token") {
+		t.Fatalf("expected token validation error, got %v", err)
+	}
+}
+
+func TestNewTaskManagerValidatesPropertyMapping(t *testing.T) {
+	server := newFakeNotion(t)
+
+	if _, err := server.manager(t, Config{Properties: PropertyMapping{Parent: "Epic"}}); err == nil || !strings.Contains(err.Error(), `"Epic" must be a relation property`) {
+		t.Fatalf("expected missing parent relation to fail, got %v", err)
+	}
+	if _, err := server.manager(t, Config{Properties: PropertyMapping{Priority: "Name"}}); err == nil || !strings.Contains(err.Error(), "select or number") {
+		t.Fatalf("expected explicit priority with wrong type to fail, got %v", err)
+	}
+	manager, err := server.manager(t, Config{})
+	if err != nil {
+		t.Fatalf("expected default mapping to validate, got %v", err)
+	}
+	if manager.properties.Title != "Name" || manager.statusType != "status" || !manager.hasPriority || !manager.hasDeps {
+		t.Fatalf("unexpected resolved schema %#v", manager)
+	}
+	if server.lastVersion != notionVersion || server.lastAuth != "Bearer secret" {
+		t.Fatalf("expected notion headers, got version %q auth %q", server.lastVersion, server.lastAuth)
+	}
+}
+
+func TestTaskManagerNextTasksUsesParentRelationDependenciesAndPriority(t *testing.T) {
+	server := newFakeNotion(t)
+	server.pages = []pagePayload{
+		page(rootPageID, "Launch", "In progress", "", "", nil),
+		page(pageA, "Write docs", "Not started", rootPageID, "Low", []string{pageC}),
+		page(pageB, "Fix login", "Not started", rootPageID, "P0", nil),
+		page(pageC, "Design", "Not started", rootPageID, "High", nil),
+	}
+	manager, err := server.manager(t, Config{})
+	if err != nil {
+		t.Fatalf("build manager: %v", err)
+	}
+
+	tasks, err := manager.NextTasks(context.Background(), strings.ReplaceAll(rootPageID, "-", ""))
+	if err != nil {
+		t.Fatalf("next tasks: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != pageB || tasks[1].ID != pageC {
+		t.Fatalf("expected P0 then High task without open dependencies, got %#v", tasks)
+	}
+}
+
+func TestTaskManagerGetTaskTreeAndGetTask(t *testing.T) {
+	server := newFakeNotion(t)
+	server.pages = []pagePayload{
+		page(rootPageID, "Launch", "Not started", "", "", nil),
+		page(pageA, "Write docs", "Blocked", rootPageID, "", []string{pageB}),
+		page(pageB, "Fix login", "Done", pageC, "", nil),
+		page(pageC, "Auth", "Not started", rootPageID, "", nil),
+	}
+	server.blocks = `{"results":[{"type":"paragraph","paragraph":{"rich_text":[{"plain_text":"Update the "},{"plain_text":"README"}]}},{"type":"divider","divider":{}},{"type":"to_do","to_do":{"rich_text":[{"plain_text":"mention config"}]}}]}`
+	manager, err := server.manager(t, Config{})
+	if err != nil {
+		t.Fatalf("build manager: %v", err)
+	}
+
+	tree, err := manager.GetTaskTree(context.Background(), rootPageID)
+	if err != nil {
+		t.Fatalf("task tree: %v", err)
+	}
+	if len(tree.Tasks) != 4 || tree.Tasks[pageB].ParentID != pageC || tree.Tasks[pageB].Status != contracts.TaskStatusClosed {
+		t.Fatalf("unexpected tree %#v", tree.Tasks)
+	}
+	if tree.Tasks[pageA].Status != contracts.TaskStatusBlocked {
+		t.Fatalf("expected Blocked option to map to blocked, got %q", tree.Tasks[pageA].Status)
+	}
+	found := false
+	for _, relation := range tree.Relations {
+		if relation == (contracts.TaskRelation{FromID: pageA, ToID: pageB, Type: contracts.RelationDependsOn}) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected dependency relation, got %#v", tree.Relations)
+	}
+
+	task, err := manager.GetTask(context.Background(), pageA)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.Title != "Write docs" || task.Description != "Update the README\nmention config" || task.Metadata["dependencies"] != pageB {
+		t.Fatalf("unexpected task %#v", task)
+	}
+}
+
+func TestTaskManagerSetTaskStatusWritesMappedOption(t *testing.T) {
+	server := newFakeNotion(t)
+	manager, err := server.manager(t, Config{States: StateMapping{Blocked: "Waiting"}})
+	if err != nil {
+		t.Fatalf("build manager: %v", err)
+	}
+
+	if err := manager.SetTaskStatus(context.Background(), pageA, contracts.TaskStatusBlocked); err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	if err := manager.SetTaskStatus(context.Background(), pageA, contracts.TaskStatusClosed); err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	want := []string{
+		`{"properties":{"Status":{"status":{"name":"Waiting"}}}}`,
+		`{"properties":{"Status":{"status":{"name":"Done"}}}}`,
+	}
+	if len(server.pageUpdates) != 2 || server.pageUpdates[0] != want[0] || server.pageUpdates[1] != want[1] {
+		t.Fatalf("unexpected page updates %#v", server.pageUpdates)
+	}
+}
+
+func TestTaskManagerAppendsRunNotesToPage(t *testing.T) {
+	server := newFakeNotion(t)
+	manager, err := server.manager(t, Config{})
+	if err != nil {
+		t.Fatalf("build manager: %v", err)
+	}
+
+	if err := manager.SetTaskData(context.Background(), pageA, map[string]string{"b": "2", "a": "1"}); err != nil {
+		t.Fatalf("set task data: %v", err)
+	}
+	if err := manager.AddTaskComment(context.Background(), pageA, "yolo-runner: landed\n"+strings.Repeat("x", maxRichTextLength+1)); err != nil {
+		t.Fatalf("add comment: %v", err)
+	}
+	if got := server.appended[pageA]; len(got) != 6 || got[0] != "yolo-runner task data" || got[1] != "a=1" || got[3] != "yolo-runner: landed" || len(got[5]) != 1 {
+		t.Fatalf("unexpected appended notes %#v", got)
+	}
+}
+
+func TestTaskManagerCreateTaskLinksParentAndDependencies(t *testing.T) {
+	server := newFakeNotion(t)
+	manager, err := server.manager(t, Config{})
+	if err != nil {
+		t.Fatalf("build manager: %v", err)
+	}
+
+	id, err := manager.CreateTask(context.Background(), contracts.TaskCreateRequest{ParentID: rootPageID, Title: "Follow-up", Description: "details", DependsOn: []string{pageA}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if id != pageC {
+		t.Fatalf("expected created page id, got %q", id)
+	}
+	created := server.created
+	for _, want := range []string{`"database_id":"` + normalizePageID(databaseID) + `"`, `"Parent item":{"relation":[{"id":"` + rootPageID + `"}]}`, `"Blocked by":{"relation":[{"id":"` + pageA + `"}]}`, `"content":"details"`} {
+		if !strings.Contains(created, want) {
+			t.Fatalf("expected %s in create payload %s", want, created)
+		}
+	}
+}
+
+const databaseID = "dddddddddddddddddddddddddddddddd"
+
+type fakeNotion struct {
+	mu          sync.Mutex
+	server      *httptest.Server
+	pages       []pagePayload
+	blocks      string
+	pageUpdates []string
+	appended    map[string][]string
+	created     string
+	lastAuth    string
+	lastVersion string
+}
+
+func newFakeNotion(t *testing.T) *fakeNotion {
+	t.Helper()
+	f := &fakeNotion{appended: map[string][]string{}, blocks: `{"results":[]}`}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeNotion) manager(t *testing.T, cfg Config) (*TaskManager, error) {
+	t.Helper()
+	cfg.DatabaseID = databaseID
+	cfg.Token = "secret"
+	cfg.APIEndpoint = f.server.URL
+	cfg.HTTPClient = f.server.Client()
+	return NewTaskManager(cfg)
+}
+
+func (f *fakeNotion) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastAuth = r.Header.Get("Authorization")
+	f.lastVersion = r.Header.Get("Notion-Version")
+	dashedDatabase := normalizePageID(databaseID)
+	path := r.URL.Path
+	switch {
+	case path == "/databases/"+dashedDatabase:
+		_, _ = w.Write([]byte(`{"id":"` + dashedDatabase + `","properties":{"Name":{"type":"title"},"Status":{"type":"status"},"Parent item":{"type":"relation"},"Priority":{"type":"select"},"Blocked by":{"type":"relation"}}}`))
+	case path == "/databases/"+dashedDatabase+"/query":
+		_ = json.NewEncoder(w).Encode(map[string]any{"results": f.pages, "has_more": false})
+	case path == "/pages" && r.Method == http.MethodPost:
+		var body json.RawMessage
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		f.created = string(body)
+		_, _ = w.Write([]byte(`{"id":"` + strings.ReplaceAll(pageC, "-", "") + `"}`))
+	case strings.HasPrefix(path, "/pages/"):
+		id := strings.TrimPrefix(path, "/pages/")
+		if r.Method == http.MethodPatch {
+			var body json.RawMessage
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			f.pageUpdates = append(f.pageUpdates, string(body))
+			_, _ = w.Write([]byte(`{"id":"` + id + `"}`))
+			return
+		}
+		for _, page := range f.pages {
+			if page.ID == id {
+				_ = json.NewEncoder(w).Encode(page)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case strings.HasPrefix(path, "/blocks/") && strings.HasSuffix(path, "/children"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/blocks/"), "/children")
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(f.blocks))
+			return
+		}
+		var body struct {
+			Children []struct {
+				Paragraph struct {
+					RichText []struct {
+						Text struct {
+							Content string `json:"content"`
+						} `json:"text"`
+					} `json:"rich_text"`
+				} `json:"paragraph"`
+			} `json:"children"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		for _, child := range body.Children {
+			text := ""
+			for _, part := range child.Paragraph.RichText {
+				text += part.Text.Content
+			}
+			f.appended[id] = append(f.appended[id], text)
+		}
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"object":"error","message":"not found"}`))
+	}
+}
+
+func page(id string, title string, status string, parent string, priority string, blockedBy []string) pagePayload {
+	properties := map[string]propertyPayload{
+		"Name":   {Type: "title", Title: []richTextPayload{{PlainText: title}}},
+		"Status": {Type: "status", Status: &namedOptionPayload{Name: status}},
+	}
+	parentRelation := []relationItemPayload{}
+	if parent != "" {
+		parentRelation = append(parentRelation, relationItemPayload{ID: parent})
+	}
+	properties["Parent item"] = propertyPayload{Type: "relation", Relation: parentRelation}
+	if priority != "" {
+		properties["Priority"] = propertyPayload{Type: "select", Select: &namedOptionPayload{Name: priority}}
+	}
+	deps := []relationItemPayload{}
+	for _, dep := range blockedBy {
+		deps = append(deps, relationItemPayload{ID: dep})
+	}
+	properties["Blocked by"] = propertyPayload{Type: "relation", Relation: deps}
+	return pagePayload{ID: id, Properties: properties}
+}
@@ -0,0 +1,49 @@
+package ollama
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/contracts/conformance"
+)
+
+func TestRunnerAdapterConformance(t *testing.T) {
+	conformance.RunAgentRunnerSuite(t, conformance.Config{
+		Backend: "ollama",
+		Model:   "qwen2.5-coder:14b",
+		NewAdapter: func(t *testing.T, scenario conformance.Scenario) contracts.AgentRunner {
+			t.Helper()
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				reply := func(content string) {
+					_, _ = fmt.Fprintf(w, `{"message":{"role":"assistant","content":%q},"done":true}`, content)
+				}
+				switch scenario {
+				case conformance.ScenarioSuccess:
+					reply("working line")
+				case conformance.ScenarioReviewPass:
+					reply("REVIEW_VERDICT: pass")
+				case conformance.ScenarioReviewFail:
+					reply("REVIEW_VERDICT: fail")
+				case conformance.ScenarioTimeoutError:
+					<-r.Context().Done()
+				case conformance.ScenarioContextTimeoutNoErr:
+					time.Sleep(30 * time.Millisecond)
+					reply("still working")
+				case conformance.ScenarioFailure:
+					w.WriteHeader(http.StatusInternalServerError)
+					_, _ = fmt.Fprintf(w, `{"error":%q}`, conformance.FailureReason)
+				default:
+					t.Errorf("unsupported scenario %q", scenario)
+				}
+			}))
+			t.Cleanup(server.Close)
+			return NewRunnerAdapter(server.URL, 0, server.Client())
+		},
+	})
+}
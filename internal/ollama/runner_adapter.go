@@ -0,0 +1,288 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// DefaultEndpoint is where a local Ollama listens by default.
+const DefaultEndpoint = "http://127.0.0.1:11434"
+
+const defaultMaxTurns = 40
+
+var structuredReviewVerdictLinePattern = regexp.MustCompile(`(?i)^\s*REVIEW_VERDICT\s*:\s*(pass|fail)(?:\s*DONE)?\s*$`)
+var structuredReviewFailFeedbackLinePattern = regexp.MustCompile(`(?i)^\s*REVIEW_(?:FAIL_)?FEEDBACK\s*:\s*(.+?)\s*$`)
+
+const systemPrompt = `You are a coding agent working in the repository at %s.
+Use the tools to inspect files, change them and run commands such as builds and tests.
+Paths are relative to the repository root. Do not ask questions; make reasonable assumptions.
+When the task is done, reply with a short summary of what you changed and call no more tools.`
+
+// RunnerAdapter implements contracts.AgentRunner with a small agent harness
+// that runs entirely against an Ollama server: the model is offered file and
+// shell tools through /api/chat and the harness executes its tool calls in
+// the task clone until the model answers without one.
+type RunnerAdapter struct {
+	endpoint string
+	maxTurns int
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewRunnerAdapter returns an AgentRunner for the Ollama server at endpoint,
+// or DefaultEndpoint when endpoint is empty. maxTurns bounds the chat turns
+// per run; zero or less uses the default of 40.
+func NewRunnerAdapter(endpoint string, maxTurns int, client *http.Client) *RunnerAdapter {
+	endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if maxTurns <= 0 {
+		maxTurns = defaultMaxTurns
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &RunnerAdapter{endpoint: endpoint, maxTurns: maxTurns, client: client, now: time.Now}
+}
+
+var _ contracts.AgentRunner = (*RunnerAdapter)(nil)
+
+func (a *RunnerAdapter) Run(ctx context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if a == nil {
+		return contracts.RunnerResult{}, errors.New("nil ollama runner adapter")
+	}
+	if a.now == nil {
+		a.now = time.Now
+	}
+
+	startedAt := a.now().UTC()
+	logPath := resolveLogPath(request)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return contracts.RunnerResult{}, err
+	}
+	logFile, err := contracts.CreateRunnerLog(logPath, request.Secrets)
+	if err != nil {
+		return contracts.RunnerResult{}, err
+	}
+	defer logFile.Close()
+
+	runCtx, cancel := contracts.WithOptionalTimeout(ctx, request.Timeout)
+	defer cancel()
+
+	answer, runErr := a.converse(runCtx, request, logFile)
+	runErr = contracts.FinalizeRunError(runCtx, runErr)
+
+	finishedAt := a.now().UTC()
+	result := contracts.NormalizeBackendRunnerResult(startedAt, finishedAt, request, runErr, nil)
+	result.LogPath = logPath
+	extras := map[string]string{}
+	if request.Mode == contracts.RunnerModeReview && result.Status == contracts.RunnerResultCompleted {
+		if verdict, ok := lastStructuredVerdictLine(answer); ok {
+			extras["review_verdict"] = verdict
+			result.ReviewReady = verdict == "pass"
+			if verdict == "fail" {
+				if feedback, ok := lastStructuredReviewFailFeedbackLine(answer); ok {
+					extras["review_fail_feedback"] = feedback
+				}
+			}
+		}
+	}
+	result.Artifacts = contracts.BuildRunnerArtifacts("ollama", request, result, extras)
+	return result, nil
+}
+
+type chatMessage struct {
+	Role      string         `json:"role"`
+	Content   string         `json:"content"`
+	ToolCalls []chatToolCall `json:"tool_calls,omitempty"`
+	ToolName  string         `json:"tool_name,omitempty"`
+}
+
+type chatToolCall struct {
+	Function struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	} `json:"function"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Tools    []toolSpec    `json:"tools"`
+	Stream   bool          `json:"stream"`
+}
+
+type chatResponse struct {
+	Message chatMessage `json:"message"`
+	Error   string      `json:"error"`
+}
+
+// converse runs the chat loop and returns the model's final answer.
+func (a *RunnerAdapter) converse(ctx context.Context, request contracts.RunnerRequest, log io.Writer) (string, error) {
+	model := strings.TrimSpace(request.Model)
+	if model == "" {
+		return "", errors.New("ollama backend requires a model")
+	}
+	messages := []chatMessage{
+		{Role: "system", Content: fmt.Sprintf(systemPrompt, request.RepoRoot)},
+		{Role: "user", Content: request.Prompt},
+	}
+	for turn := 0; turn < a.maxTurns; turn++ {
+		reply, err := a.chat(ctx, chatRequest{Model: model, Messages: messages, Tools: toolSpecs, Stream: false})
+		if err != nil {
+			return "", err
+		}
+		messages = append(messages, reply)
+		a.logEntry(log, map[string]any{"role": "assistant", "content": reply.Content, "tool_calls": len(reply.ToolCalls)})
+		for _, line := range strings.Split(reply.Content, "\n") {
+			a.emit(request, "stdout", line)
+		}
+		if len(reply.ToolCalls) == 0 {
+			return reply.Content, nil
+		}
+		for _, call := range reply.ToolCalls {
+			name := call.Function.Name
+			summary := describeToolCall(name, call.Function.Arguments)
+			a.emitCommand(request, contracts.EventTypeRunnerCommandStarted, name, summary)
+			output, toolErr := runTool(ctx, request.RepoRoot, name, call.Function.Arguments)
+			if toolErr != nil {
+				output = "error: " + toolErr.Error()
+			}
+			a.emitCommand(request, contracts.EventTypeRunnerCommandFinished, name, summary)
+			a.logEntry(log, map[string]any{"role": "tool", "tool": name, "arguments": call.Function.Arguments, "content": output})
+			messages = append(messages, chatMessage{Role: "tool", ToolName: name, Content: output})
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+	}
+	return "", fmt.Errorf("ollama agent did not finish within %d turns", a.maxTurns)
+}
+
+func (a *RunnerAdapter) chat(ctx context.Context, payload chatRequest) (chatMessage, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return chatMessage{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return chatMessage{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return chatMessage{}, err
+	}
+	defer resp.Body.Close()
+	var reply chatResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&reply)
+	if resp.StatusCode != http.StatusOK {
+		if strings.TrimSpace(reply.Error) != "" {
+			return chatMessage{}, fmt.Errorf("ollama chat returned %d: %s", resp.StatusCode, reply.Error)
+		}
+		return chatMessage{}, fmt.Errorf("ollama chat returned %d", resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return chatMessage{}, fmt.Errorf("decode ollama chat reply: %w", decodeErr)
+	}
+	if strings.TrimSpace(reply.Error) != "" {
+		return chatMessage{}, errors.New(reply.Error)
+	}
+	reply.Message.Role = "assistant"
+	return reply.Message, nil
+}
+
+func (a *RunnerAdapter) logEntry(log io.Writer, entry map[string]any) {
+	entry["timestamp"] = a.now().UTC().Format(time.RFC3339)
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	_, _ = log.Write(append(line, '\n'))
+}
+
+func (a *RunnerAdapter) emit(request contracts.RunnerRequest, source string, line string) {
+	if request.OnProgress == nil {
+		return
+	}
+	if progress, ok := contracts.NewRunnerOutputProgress(source, line, a.now().UTC()); ok {
+		request.OnProgress(progress)
+	}
+}
+
+func (a *RunnerAdapter) emitCommand(request contracts.RunnerRequest, eventType contracts.EventType, tool string, summary string) {
+	if request.OnProgress == nil {
+		return
+	}
+	request.OnProgress(contracts.RunnerProgress{
+		Type:      string(eventType),
+		Message:   summary,
+		Metadata:  map[string]string{"tool": tool},
+		Timestamp: a.now().UTC(),
+	})
+}
+
+func resolveLogPath(request contracts.RunnerRequest) string {
+	if request.Metadata != nil {
+		if path := strings.TrimSpace(request.Metadata["log_path"]); path != "" {
+			return path
+		}
+	}
+	if strings.TrimSpace(request.RepoRoot) != "" && strings.TrimSpace(request.TaskID) != "" {
+		return filepath.Join(request.RepoRoot, "runner-logs", "ollama", request.TaskID+".jsonl")
+	}
+	if strings.TrimSpace(request.TaskID) != "" {
+		return filepath.Join("runner-logs", "ollama", request.TaskID+".jsonl")
+	}
+	return filepath.Join("runner-logs", "ollama", "runner.jsonl")
+}
+
+func lastStructuredVerdictLine(text string) (string, bool) {
+	normalized := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+	lastVerdict := ""
+	found := false
+	for _, line := range strings.Split(normalized, "\n") {
+		matches := structuredReviewVerdictLinePattern.FindStringSubmatch(line)
+		if len(matches) < 2 {
+			continue
+		}
+		lastVerdict = strings.ToLower(matches[1])
+		found = true
+	}
+	return lastVerdict, found
+}
+
+func lastStructuredReviewFailFeedbackLine(text string) (string, bool) {
+	normalized := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+	lastFeedback := ""
+	found := false
+	for _, line := range strings.Split(normalized, "\n") {
+		matches := structuredReviewFailFeedbackLinePattern.FindStringSubmatch(line)
+		if len(matches) < 2 {
+			continue
+		}
+		candidate := strings.Join(strings.Fields(matches[1]), " ")
+		if candidate == "" {
+			continue
+		}
+		lastFeedback = candidate
+		found = true
+	}
+	return lastFeedback, found
+}
@@ -0,0 +1,121 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// scriptedOllama answers /api/chat with the given assistant messages in
+// order and records the requests it received.
+func scriptedOllama(t *testing.T, replies ...string) (*httptest.Server, *[]chatRequest) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		requests []chatRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.NotFound(w, r)
+			return
+		}
+		var payload chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode chat request: %v", err)
+		}
+		mu.Lock()
+		turn := len(requests)
+		requests = append(requests, payload)
+		mu.Unlock()
+		if turn >= len(replies) {
+			t.Errorf("unexpected chat turn %d", turn)
+			return
+		}
+		_, _ = w.Write([]byte(`{"message":` + replies[turn] + `,"done":true}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestRunnerAdapterExecutesToolCallsUntilModelAnswers(t *testing.T) {
+	repoRoot := t.TempDir()
+	server, requests := scriptedOllama(t,
+		`{"role":"assistant","content":"","tool_calls":[{"function":{"name":"write_file","arguments":{"path":"hello.txt","content":"hi\n"}}}]}`,
+		`{"role":"assistant","content":"","tool_calls":[{"function":{"name":"run_command","arguments":{"command":"cat hello.txt"}}},{"function":{"name":"read_file","arguments":{"path":"../outside.txt"}}}]}`,
+		`{"role":"assistant","content":"Added hello.txt."}`,
+	)
+	progress := []contracts.RunnerProgress{}
+
+	result, err := NewRunnerAdapter(server.URL, 0, server.Client()).Run(context.Background(), contracts.RunnerRequest{
+		TaskID:     "task-1",
+		Mode:       contracts.RunnerModeImplement,
+		Model:      "qwen2.5-coder:14b",
+		Prompt:     "Add hello.txt",
+		RepoRoot:   repoRoot,
+		OnProgress: func(p contracts.RunnerProgress) { progress = append(progress, p) },
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if result.Status != contracts.RunnerResultCompleted || result.Artifacts["backend"] != "ollama" {
+		t.Fatalf("expected completed ollama result, got %#v", result)
+	}
+	if content, err := os.ReadFile(filepath.Join(repoRoot, "hello.txt")); err != nil || string(content) != "hi\n" {
+		t.Fatalf("expected hello.txt to be written, got %q err=%v", content, err)
+	}
+	if len(*requests) != 3 {
+		t.Fatalf("expected three chat turns, got %d", len(*requests))
+	}
+	first := (*requests)[0]
+	if first.Model != "qwen2.5-coder:14b" || first.Stream || len(first.Tools) != len(toolSpecs) {
+		t.Fatalf("unexpected first chat request %#v", first)
+	}
+	last := (*requests)[2].Messages
+	if got := last[len(last)-2]; got.Role != "tool" || got.ToolName != "run_command" || got.Content != "exit code 0\nhi\n" {
+		t.Fatalf("expected command output as tool message, got %#v", got)
+	}
+	if got := last[len(last)-1]; got.ToolName != "read_file" || !strings.Contains(got.Content, "outside the repository") {
+		t.Fatalf("expected path outside the clone to be refused, got %#v", got)
+	}
+
+	got := []string{}
+	for _, p := range progress {
+		got = append(got, p.Type+":"+p.Message)
+	}
+	want := "runner_cmd_started:write_file hello.txt|runner_cmd_finished:write_file hello.txt|" +
+		"runner_cmd_started:cat hello.txt|runner_cmd_finished:cat hello.txt|" +
+		"runner_cmd_started:read_file ../outside.txt|runner_cmd_finished:read_file ../outside.txt|" +
+		"runner_output:Added hello.txt."
+	if strings.Join(got, "|") != want {
+		t.Fatalf("unexpected progress %q", strings.Join(got, "|"))
+	}
+	if _, err := os.Stat(result.LogPath); err != nil {
+		t.Fatalf("expected log at %s: %v", result.LogPath, err)
+	}
+}
+
+func TestRunnerAdapterStopsAfterMaxTurns(t *testing.T) {
+	call := `{"role":"assistant","content":"","tool_calls":[{"function":{"name":"list_files","arguments":{"path":"."}}}]}`
+	server, _ := scriptedOllama(t, call, call)
+
+	result, err := NewRunnerAdapter(server.URL, 2, server.Client()).Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "task-1",
+		Mode:     contracts.RunnerModeImplement,
+		Model:    "qwen2.5-coder:14b",
+		RepoRoot: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if result.Status != contracts.RunnerResultFailed || result.Reason != "ollama agent did not finish within 2 turns" {
+		t.Fatalf("expected failed result after max turns, got %#v", result)
+	}
+}
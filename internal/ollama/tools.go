@@ -0,0 +1,166 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxToolOutput bounds what a tool call returns to the model, since local
+// models have small context windows.
+const maxToolOutput = 32 * 1024
+
+type toolSpec struct {
+	Type     string       `json:"type"`
+	Function toolFunction `json:"function"`
+}
+
+type toolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+func newToolSpec(name string, description string, params map[string]string) toolSpec {
+	properties := map[string]any{}
+	required := []string{}
+	for param, about := range params {
+		properties[param] = map[string]string{"type": "string", "description": about}
+		required = append(required, param)
+	}
+	sort.Strings(required)
+	return toolSpec{Type: "function", Function: toolFunction{
+		Name:        name,
+		Description: description,
+		Parameters:  map[string]any{"type": "object", "properties": properties, "required": required},
+	}}
+}
+
+var toolSpecs = []toolSpec{
+	newToolSpec("list_files", "List the entries of a directory.", map[string]string{"path": "Directory relative to the repository root; use . for the root."}),
+	newToolSpec("read_file", "Read a text file.", map[string]string{"path": "File relative to the repository root."}),
+	newToolSpec("write_file", "Create or replace a file with the given content.", map[string]string{"path": "File relative to the repository root.", "content": "The complete new file content."}),
+	newToolSpec("run_command", "Run a shell command in the repository root and return its exit code and output.", map[string]string{"command": "The shell command to run."}),
+}
+
+// runTool executes one tool call inside repoRoot. Errors are reported back
+// to the model rather than failing the run.
+func runTool(ctx context.Context, repoRoot string, name string, args map[string]any) (string, error) {
+	switch name {
+	case "list_files":
+		path, err := resolveToolPath(repoRoot, toolArg(args, "path"))
+		if err != nil {
+			return "", err
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return "", err
+		}
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			if entry.IsDir() {
+				names = append(names, entry.Name()+"/")
+				continue
+			}
+			names = append(names, entry.Name())
+		}
+		return truncateToolOutput(strings.Join(names, "\n")), nil
+	case "read_file":
+		path, err := resolveToolPath(repoRoot, toolArg(args, "path"))
+		if err != nil {
+			return "", err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return truncateToolOutput(string(content)), nil
+	case "write_file":
+		path, err := resolveToolPath(repoRoot, toolArg(args, "path"))
+		if err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return "", err
+		}
+		content := toolArg(args, "content")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("wrote %d bytes", len(content)), nil
+	case "run_command":
+		command := strings.TrimSpace(toolArg(args, "command"))
+		if command == "" {
+			return "", errors.New("command is required")
+		}
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Dir = repoRoot
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		exitCode := 0
+		if err := cmd.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				return "", err
+			}
+			exitCode = exitErr.ExitCode()
+		}
+		return truncateToolOutput(fmt.Sprintf("exit code %d\n%s", exitCode, output.String())), nil
+	default:
+		return "", fmt.Errorf("unknown tool %q", name)
+	}
+}
+
+// resolveToolPath resolves a model-supplied path against repoRoot and
+// rejects paths that leave it.
+func resolveToolPath(repoRoot string, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		raw = "."
+	}
+	path := filepath.Clean(filepath.Join(repoRoot, raw))
+	if filepath.IsAbs(raw) {
+		path = filepath.Clean(raw)
+	}
+	rel, err := filepath.Rel(filepath.Clean(repoRoot), path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside the repository", raw)
+	}
+	return path, nil
+}
+
+func toolArg(args map[string]any, name string) string {
+	switch value := args[name].(type) {
+	case string:
+		return value
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func describeToolCall(name string, args map[string]any) string {
+	switch name {
+	case "run_command":
+		return toolArg(args, "command")
+	case "list_files", "read_file", "write_file":
+		return name + " " + toolArg(args, "path")
+	default:
+		return name
+	}
+}
+
+func truncateToolOutput(output string) string {
+	if len(output) <= maxToolOutput {
+		return output
+	}
+	return output[:maxToolOutput] + "\n[output truncated]"
+}
@@ -22,12 +22,15 @@ type ACPDecision string
 const (
 	ACPDecisionAllow  ACPDecision = "allow"
 	ACPDecisionDecide ACPDecision = "decide"
+	ACPDecisionDeny   ACPDecision = "deny"
 )
 
 type ACPHandler struct {
 	issueID string
 	logPath string
 	logger  func(string, string, string, string, string, string, string) error
+	// tools denies tool calls that run commands the policy rejects.
+	tools contracts.ToolPolicy
 }
 
 func NewACPHandler(issueID string, logPath string, logger func(string, string, string, string, string, string, string) error) *ACPHandler {
@@ -41,6 +44,20 @@ func (h *ACPHandler) HandlePermission(ctx context.Context, requestID string, sco
 	return ACPDecisionAllow
 }
 
+// HandleToolPermission applies the tool policy to a tool call that runs
+// command and leaves every other call to HandlePermission.
+func (h *ACPHandler) HandleToolPermission(ctx context.Context, requestID string, scope string, command string) ACPDecision {
+	if h != nil && command != "" && !h.tools.IsZero() {
+		if allowed, reason := h.tools.CheckCommand(command); !allowed {
+			if h.logger != nil {
+				_ = h.logger(h.logPath, h.issueID, "permission", string(ACPDecisionDeny), reason, scope, scope)
+			}
+			return ACPDecisionDeny
+		}
+	}
+	return h.HandlePermission(ctx, requestID, scope)
+}
+
 func (h *ACPHandler) HandleQuestion(ctx context.Context, requestID string, prompt string) string {
 	if h != nil && h.logger != nil {
 		_ = h.logger(h.logPath, h.issueID, "question", "decide yourself", "retry", prompt, prompt)
@@ -347,7 +364,12 @@ func (c *acpClient) RequestPermission(ctx context.Context, params *acp.RequestPe
 
 	decision := ACPDecisionAllow
 	if c != nil && c.handler != nil {
-		decision = c.handler.HandlePermission(ctx, string(params.ToolCall.ToolCallId), params.ToolCall.Title)
+		kind := ""
+		if params.ToolCall.Kind != nil {
+			kind = string(*params.ToolCall.Kind)
+		}
+		command := contracts.ToolCallCommand(kind, params.ToolCall.Title, params.ToolCall.RawInput)
+		decision = c.handler.HandleToolPermission(ctx, string(params.ToolCall.ToolCallId), params.ToolCall.Title, command)
 	}
 
 	if c != nil {
@@ -878,3 +878,35 @@ func TestACPClientRequestPermissionDoesNotEmitApprovalEventForQuestions(t *testi
 		}
 	}
 }
+
+func TestACPClientDeniesCommandsRejectedByToolPolicy(t *testing.T) {
+	var gotDecision, gotReason string
+	handler := NewACPHandler("issue-1", "log", func(_ string, _ string, _ string, decision string, reason string, _ string, _ string) error {
+		gotDecision = decision
+		gotReason = reason
+		return nil
+	})
+	handler.tools = contracts.ToolPolicy{Deny: []string{"docker"}}
+	client := &acpClient{handler: handler}
+
+	response, err := client.RequestPermission(context.Background(), &acp.RequestPermissionRequest{
+		ToolCall: acp.ToolCallUpdate{
+			ToolCallId: acp.ToolCallId("tool-1"),
+			Title:      "Run docker",
+			Kind:       acp.ToolKindPtr(acp.ToolKindExecute),
+			RawInput:   json.RawMessage(`{"command":"docker run alpine"}`),
+		},
+		Options: []acp.PermissionOption{
+			{Kind: acp.PermissionOptionKindAllowOnce, Name: "Allow", OptionId: acp.PermissionOptionId("allow")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Outcome.GetSelected() != nil {
+		t.Fatalf("expected the call to be refused, got %#v", response.Outcome)
+	}
+	if gotDecision != string(ACPDecisionDeny) || gotReason != "denied by tool policy: docker" {
+		t.Fatalf("expected logged tool policy denial, got %q (%q)", gotDecision, gotReason)
+	}
+}
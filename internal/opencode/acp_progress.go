@@ -24,7 +24,7 @@ func NormalizeACPProgressNotification(notification *acp.SessionNotification) (co
 	if toolCall := update.GetToolcall(); toolCall != nil {
 		progressType := toolCallProgressType(toolCall.Status)
 		message := strings.TrimSpace(toolCall.Title)
-		metadata := toolCallMetadata(sessionID, string(toolCall.ToolCallId), toolCall.Kind, toolCall.Status)
+		metadata := toolCallMetadata(sessionID, string(toolCall.ToolCallId), message, toolCall.Kind, toolCall.Status, toolCall.Locations)
 		return contracts.RunnerProgress{
 			Type:      string(progressType),
 			Message:   message,
@@ -36,7 +36,7 @@ func NormalizeACPProgressNotification(notification *acp.SessionNotification) (co
 	if toolUpdate := update.GetToolcallupdate(); toolUpdate != nil {
 		progressType := toolCallProgressType(toolUpdate.Status)
 		message := strings.TrimSpace(toolUpdate.Title)
-		metadata := toolCallMetadata(sessionID, string(toolUpdate.ToolCallId), toolUpdate.Kind, toolUpdate.Status)
+		metadata := toolCallMetadata(sessionID, string(toolUpdate.ToolCallId), message, toolUpdate.Kind, toolUpdate.Status, toolUpdate.Locations)
 		return contracts.RunnerProgress{
 			Type:      string(progressType),
 			Message:   message,
@@ -77,11 +77,23 @@ func NormalizeACPProgressNotification(notification *acp.SessionNotification) (co
 		}, true
 	}
 
-	if update.GetPlan() != nil {
+	if plan := update.GetPlan(); plan != nil {
+		entries := make([]contracts.PlanEntry, 0, len(plan.Entries))
+		for _, entry := range plan.Entries {
+			entries = append(entries, contracts.PlanEntry{
+				Content:  strings.TrimSpace(entry.Content),
+				Priority: string(entry.Priority),
+				Status:   string(entry.Status),
+			})
+		}
+		metadata := contracts.PlanUpdatedMetadata(entries)
+		if sessionID != "" {
+			metadata["session_id"] = sessionID
+		}
 		return contracts.RunnerProgress{
-			Type:      string(contracts.EventTypeRunnerProgress),
-			Message:   "plan",
-			Metadata:  sessionMetadata(sessionID),
+			Type:      string(contracts.EventTypePlanUpdated),
+			Message:   contracts.PlanUpdatedMessage(entries),
+			Metadata:  metadata,
 			Timestamp: now,
 		}, true
 	}
@@ -101,24 +113,18 @@ func toolCallProgressType(status *acp.ToolCallStatus) contracts.EventType {
 	}
 }
 
-func toolCallMetadata(sessionID string, toolCallID string, kind *acp.ToolKind, status *acp.ToolCallStatus) map[string]string {
-	metadata := map[string]string{}
-	if sessionID != "" {
-		metadata["session_id"] = sessionID
-	}
-	if toolCallID != "" {
-		metadata["tool_call_id"] = toolCallID
-	}
+func toolCallMetadata(sessionID string, toolCallID string, title string, kind *acp.ToolKind, status *acp.ToolCallStatus, locations []acp.ToolCallLocation) map[string]string {
+	call := contracts.ToolCall{ID: toolCallID, Title: title}
 	if kind != nil {
-		if k := strings.TrimSpace(string(*kind)); k != "" {
-			metadata["kind"] = k
-		}
+		call.Kind = string(*kind)
 	}
 	if status != nil {
-		if s := strings.TrimSpace(string(*status)); s != "" {
-			metadata["status"] = s
-		}
+		call.Status = string(*status)
+	}
+	for _, location := range locations {
+		call.Locations = append(call.Locations, location.Path)
 	}
+	metadata := contracts.ApplyToolCallMetadata(sessionMetadata(sessionID), call)
 	if len(metadata) == 0 {
 		return nil
 	}
@@ -153,6 +153,32 @@ func TestNormalizeACPProgressNotificationToolCallIncludesMetadata(t *testing.T)
 	}
 }
 
+func TestNormalizeACPProgressNotificationToolCallIncludesTitleAndLocations(t *testing.T) {
+	notification := &acp.SessionNotification{
+		SessionId: "sess-42",
+		Update: acp.NewSessionUpdateToolCall(
+			acp.ToolCallId("tc-7"),
+			"Edit loop.go",
+			acp.ToolKindPtr(acp.ToolKindEdit),
+			acp.ToolCallStatusPtr(acp.ToolCallStatusInProgress),
+			[]acp.ToolCallLocation{{Path: "/repo/internal/agent/loop.go"}, {Path: "/repo/internal/agent/loop_test.go"}},
+			nil,
+		),
+	}
+
+	progress, ok := NormalizeACPProgressNotification(notification)
+	if !ok {
+		t.Fatalf("expected ok=true")
+	}
+	call, ok := contracts.ToolCallFromMetadata(progress.Metadata)
+	if !ok {
+		t.Fatalf("expected tool call metadata, got %#v", progress.Metadata)
+	}
+	if call.Title != "Edit loop.go" || call.Kind != "edit" || call.Status != "in_progress" || len(call.Locations) != 2 || call.Locations[0] != "/repo/internal/agent/loop.go" {
+		t.Fatalf("unexpected tool call %#v", call)
+	}
+}
+
 func TestNormalizeACPProgressNotificationAgentMessageIsRunnerOutput(t *testing.T) {
 	notification := &acp.SessionNotification{
 		SessionId: "sess-1",
@@ -240,18 +266,28 @@ func TestNormalizeACPProgressNotificationToolCallUpdateInProgressIsCommandStarte
 	}
 }
 
-func TestNormalizeACPProgressNotificationPlanIsRunnerProgress(t *testing.T) {
+func TestNormalizeACPProgressNotificationPlanIsPlanUpdated(t *testing.T) {
 	notification := &acp.SessionNotification{
 		SessionId: "sess-1",
-		Update:    acp.NewSessionUpdatePlan(nil),
+		Update: acp.NewSessionUpdatePlan([]acp.PlanEntry{
+			{Content: "write tests", Priority: acp.PlanEntryPriorityHigh, Status: acp.PlanEntryStatusCompleted},
+			{Content: "implement", Priority: acp.PlanEntryPriorityMedium, Status: acp.PlanEntryStatusInProgress},
+		}),
 	}
 
 	progress, ok := NormalizeACPProgressNotification(notification)
 	if !ok {
 		t.Fatalf("expected ok=true for plan update")
 	}
-	if progress.Type != string(contracts.EventTypeRunnerProgress) {
-		t.Fatalf("expected %q, got %q", contracts.EventTypeRunnerProgress, progress.Type)
+	if progress.Type != string(contracts.EventTypePlanUpdated) {
+		t.Fatalf("expected %q, got %q", contracts.EventTypePlanUpdated, progress.Type)
+	}
+	if progress.Message != "plan 1/2 completed" || progress.Metadata["session_id"] != "sess-1" {
+		t.Fatalf("unexpected plan progress %#v", progress)
+	}
+	entries := contracts.PlanEntriesFromMetadata(progress.Metadata)
+	if len(entries) != 2 || entries[1].Content != "implement" || entries[1].Status != "in_progress" || entries[0].Priority != "high" {
+		t.Fatalf("unexpected plan entries %#v", entries)
 	}
 }
 
@@ -45,6 +45,8 @@ type watchdogRuntimeConfig struct {
 	Timeout        time.Duration
 	Interval       time.Duration
 	OpenCodeLogDir string
+	Hooks          []string
+	HookTimeout    time.Duration
 }
 
 type watchdogRuntimeConfigContextKey struct{}
@@ -56,6 +58,50 @@ func withWatchdogRuntimeConfig(ctx context.Context, config watchdogRuntimeConfig
 	return context.WithValue(ctx, watchdogRuntimeConfigContextKey{}, config)
 }
 
+type taskEnvContextKey struct{}
+
+// withTaskEnv carries the extra environment of a task to the opencode process
+// started by RunWithACPAndUpdates.
+func withTaskEnv(ctx context.Context, env map[string]string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(env) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, taskEnvContextKey{}, env)
+}
+
+type toolPolicyContextKey struct{}
+
+// withToolPolicy carries the tool policy of a task to the ACP client started
+// by RunWithACPAndUpdates.
+func withToolPolicy(ctx context.Context, policy contracts.ToolPolicy) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if policy.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, toolPolicyContextKey{}, policy)
+}
+
+func toolPolicyFromContext(ctx context.Context) contracts.ToolPolicy {
+	if ctx == nil {
+		return contracts.ToolPolicy{}
+	}
+	policy, _ := ctx.Value(toolPolicyContextKey{}).(contracts.ToolPolicy)
+	return policy
+}
+
+func taskEnvFromContext(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	env, _ := ctx.Value(taskEnvContextKey{}).(map[string]string)
+	return env
+}
+
 func watchdogRuntimeConfigFromContext(ctx context.Context) watchdogRuntimeConfig {
 	if ctx == nil {
 		return watchdogRuntimeConfig{}
@@ -187,7 +233,7 @@ func RunWithACPAndUpdates(ctx context.Context, issueID string, repoRoot string,
 	if len(command) > 0 {
 		args = command
 	}
-	env := BuildEnv(nil, configRoot, configDir, model)
+	env := BuildEnv(taskEnvFromContext(ctx), configRoot, configDir, model)
 	process, err := runner.Start(args, env, logPath)
 	if err != nil {
 		return err
@@ -219,6 +265,7 @@ func RunWithACPAndUpdates(ctx context.Context, issueID string, repoRoot string,
 					Context:     context,
 				})
 			})
+			handler.tools = toolPolicyFromContext(ctx)
 			aggregator := NewAgentMessageAggregator()
 			emitUpdate := func(line string) {
 				if line == "" {
@@ -267,6 +314,15 @@ func RunWithACPAndUpdates(ctx context.Context, issueID string, repoRoot string,
 	if strings.TrimSpace(watchdogRuntime.OpenCodeLogDir) != "" {
 		watchdogConfig.OpenCodeLogDir = watchdogRuntime.OpenCodeLogDir
 	}
+	if len(watchdogRuntime.Hooks) > 0 {
+		watchdogConfig.Hooks = watchdogRuntime.Hooks
+		watchdogConfig.HookTimeout = watchdogRuntime.HookTimeout
+		watchdogConfig.HookDir = repoRoot
+		watchdogConfig.HookEnv = map[string]string{"YOLO_WATCHDOG_TASK_ID": issueID}
+		for key, value := range taskEnvFromContext(ctx) {
+			watchdogConfig.HookEnv[key] = value
+		}
+	}
 	watchdog := NewWatchdog(watchdogConfig)
 
 	// Add timeout mechanism for detecting stuck OpenCode processes and initialization failures
@@ -471,6 +527,13 @@ func (p *waitOnceProcess) Wait() error {
 	return p.waitErr
 }
 
+func (p *waitOnceProcess) Pid() int {
+	if identified, ok := p.Process.(processIdentifier); ok {
+		return identified.Pid()
+	}
+	return 0
+}
+
 func writeConsoleLine(out io.Writer, line string) {
 	if out == nil || line == "" {
 		return
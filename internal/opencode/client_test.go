@@ -652,3 +652,36 @@ func TestForwardACPRequestLineForUpdates(t *testing.T) {
 		t.Fatalf("expected permission request forwarded once, got %#v", seen)
 	}
 }
+
+func TestRunWithACPAndUpdatesPassesTaskEnvFromContext(t *testing.T) {
+	tempDir := t.TempDir()
+	homeDir := filepath.Join(tempDir, "home")
+	if err := os.MkdirAll(homeDir, 0o755); err != nil {
+		t.Fatalf("mkdir home: %v", err)
+	}
+	defaultHomeDir = func() (string, error) { return homeDir, nil }
+	t.Cleanup(func() { defaultHomeDir = os.UserHomeDir })
+
+	var capturedEnv map[string]string
+	runner := RunnerFunc(func(args []string, env map[string]string, stdoutPath string) (Process, error) {
+		capturedEnv = env
+		proc := newFakeProcess()
+		close(proc.waitCh)
+		return proc, nil
+	})
+	acpClient := ACPClientFunc(func(ctx context.Context, issueID string, logPath string) error {
+		return nil
+	})
+
+	ctx := withTaskEnv(context.Background(), map[string]string{"DB_PASSWORD": "hunter2"})
+	logPath := filepath.Join(tempDir, "runner-logs", "opencode", "issue-1.jsonl")
+	if err := RunWithACPAndUpdates(ctx, "issue-1", tempDir, "prompt", "", "", "", logPath, runner, acpClient, nil); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	if capturedEnv["DB_PASSWORD"] != "hunter2" {
+		t.Fatalf("expected task env to reach the opencode process, got %v", capturedEnv)
+	}
+	if capturedEnv["CI"] != "true" {
+		t.Fatalf("expected opencode defaults kept alongside task env")
+	}
+}
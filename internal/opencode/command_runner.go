@@ -38,6 +38,13 @@ func (p commandProcess) Wait() error {
 	return err
 }
 
+func (p commandProcess) Pid() int {
+	if p.cmd.Process == nil {
+		return 0
+	}
+	return p.cmd.Process.Pid
+}
+
 func (p commandProcess) Kill() error {
 	if p.cmd.Process == nil {
 		return nil
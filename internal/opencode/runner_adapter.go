@@ -85,6 +85,8 @@ func (a *CLIRunnerAdapter) Run(ctx context.Context, request contracts.RunnerRequ
 	runCtx, cancel := contracts.WithOptionalTimeout(ctx, request.Timeout)
 	defer cancel()
 	runCtx = withWatchdogRuntimeConfig(runCtx, watchdogRuntimeConfigFromMetadata(request.Metadata))
+	runCtx = withTaskEnv(runCtx, request.Env)
+	runCtx = withToolPolicy(runCtx, request.ToolPolicy)
 	builtCommand := a.buildCommand(request, command)
 	err := run(runCtx, request.TaskID, request.RepoRoot, request.Prompt, request.Model, a.configRoot, a.configDir, logPath, a.runner, a.acpClient, func(line string) {
 		if progress == nil {
@@ -181,8 +183,12 @@ func buildRunnerArtifacts(request contracts.RunnerRequest, result contracts.Runn
 
 	var stallErr *StallError
 	if errors.As(runErr, &stallErr) {
-		if strings.TrimSpace(stallErr.Category) != "" {
-			extras["stall_category"] = stallErr.Category
+		if raw := strings.TrimSpace(stallErr.Category); raw != "" {
+			category := string(contracts.NormalizeStallCategory(raw))
+			extras[contracts.StallCategoryArtifactKey] = category
+			if raw != category {
+				extras[contracts.StallDetailArtifactKey] = raw
+			}
 		}
 		if strings.TrimSpace(stallErr.SessionID) != "" {
 			extras["session_id"] = stallErr.SessionID
@@ -196,6 +202,12 @@ func buildRunnerArtifacts(request contracts.RunnerRequest, result contracts.Runn
 		if strings.TrimSpace(stallErr.TailPath) != "" {
 			extras["opencode_tail_path"] = stallErr.TailPath
 		}
+		if strings.TrimSpace(stallErr.HookOutputPath) != "" {
+			extras["watchdog_hook_output"] = stallErr.HookOutputPath
+		}
+		if stallErr.HookFailures > 0 {
+			extras["watchdog_hook_failures"] = strconv.Itoa(stallErr.HookFailures)
+		}
 	}
 	return contracts.BuildRunnerArtifacts("opencode", request, result, extras)
 }
@@ -218,6 +230,7 @@ func watchdogRuntimeConfigFromMetadata(metadata map[string]string) watchdogRunti
 	if raw := strings.TrimSpace(metadata[watchdogLogDirMetadataKey]); raw != "" {
 		config.OpenCodeLogDir = raw
 	}
+	config.Hooks, config.HookTimeout = contracts.WatchdogHooksFromMetadata(metadata)
 	return config
 }
 
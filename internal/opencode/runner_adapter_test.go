@@ -609,4 +609,21 @@ func TestBuildRunnerArtifactsIncludesStallDiagnostics(t *testing.T) {
 	if artifacts["last_output_age"] != "42s" {
 		t.Fatalf("expected last_output_age artifact, got %#v", artifacts)
 	}
+	if _, ok := artifacts["stall_detail"]; ok {
+		t.Fatalf("expected no stall detail when category is already normalized, got %#v", artifacts)
+	}
+}
+
+func TestBuildRunnerArtifactsNormalizesStallCategory(t *testing.T) {
+	err := &StallError{Category: stallPermission}
+	result := contracts.RunnerResult{Status: contracts.RunnerResultBlocked, Reason: err.Error()}
+	request := contracts.RunnerRequest{Mode: contracts.RunnerModeImplement}
+
+	artifacts := buildRunnerArtifacts(request, result, err, "/tmp/run.jsonl")
+	if artifacts["stall_category"] != string(contracts.StallCategoryWaitingOnTool) {
+		t.Fatalf("expected waiting_on_tool stall category, got %#v", artifacts)
+	}
+	if artifacts["stall_detail"] != stallPermission {
+		t.Fatalf("expected raw watchdog category as stall detail, got %#v", artifacts)
+	}
 }
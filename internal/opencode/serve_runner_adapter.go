@@ -51,6 +51,7 @@ func (a *ServeRunnerAdapter) Run(ctx context.Context, request contracts.RunnerRe
 		TaskID:   request.TaskID,
 		Backend:  "opencode-serve",
 		RepoRoot: request.RepoRoot,
+		Env:      request.Env,
 		LogPath:  request.Metadata["log_path"],
 		Metadata: metadata,
 	}
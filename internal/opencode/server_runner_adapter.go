@@ -0,0 +1,450 @@
+package opencode
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// DefaultServerURL is where `opencode serve` listens by default.
+const DefaultServerURL = "http://127.0.0.1:4096"
+
+// errServerSessionNotFound reports that the server no longer knows a
+// session, e.g. because it was restarted since the session was created.
+var errServerSessionNotFound = errors.New("opencode server session not found")
+
+// ServerRunnerAdapter implements contracts.AgentRunner against an opencode
+// server that is already running, instead of launching opencode per task.
+// Sessions are kept per task and mode and reused by later runs, so a
+// review retry or a re-implementation continues the earlier conversation.
+type ServerRunnerAdapter struct {
+	baseURL string
+	client  *http.Client
+
+	mu       sync.Mutex
+	sessions map[string]string
+}
+
+// NewServerRunnerAdapter returns an AgentRunner for the opencode server at
+// baseURL, or DefaultServerURL when baseURL is empty. A nil client uses
+// http.DefaultClient.
+func NewServerRunnerAdapter(baseURL string, client *http.Client) *ServerRunnerAdapter {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		baseURL = DefaultServerURL
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &ServerRunnerAdapter{baseURL: baseURL, client: client, sessions: map[string]string{}}
+}
+
+var _ contracts.AgentRunner = (*ServerRunnerAdapter)(nil)
+
+func (a *ServerRunnerAdapter) Run(ctx context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if a == nil {
+		return contracts.RunnerResult{}, errors.New("nil opencode server runner adapter")
+	}
+	startedAt := time.Now().UTC()
+	logPath := ""
+	if request.Metadata != nil {
+		logPath = request.Metadata["log_path"]
+	}
+	if logPath == "" && request.RepoRoot != "" && request.TaskID != "" {
+		logPath = filepath.Join(request.RepoRoot, "runner-logs", "opencode-server", request.TaskID+".jsonl")
+	}
+
+	runCtx, cancel := contracts.WithOptionalTimeout(ctx, request.Timeout)
+	defer cancel()
+
+	sessionID, runErr := a.execute(runCtx, request, logPath)
+	runErr = contracts.FinalizeRunError(runCtx, runErr)
+
+	result := contracts.NormalizeBackendRunnerResult(startedAt, time.Now().UTC(), request, runErr, nil)
+	result.Artifacts = buildRunnerArtifacts(request, result, runErr, logPath)
+	result.Artifacts["backend"] = "opencode-server"
+	if sessionID != "" {
+		result.Artifacts["session_id"] = sessionID
+	}
+	result.LogPath = logPath
+	if result.Status == contracts.RunnerResultCompleted && request.Mode == contracts.RunnerModeReview {
+		result.ReviewReady = hasStructuredPassVerdict(logPath)
+	}
+	return result, nil
+}
+
+func (a *ServerRunnerAdapter) execute(ctx context.Context, request contracts.RunnerRequest, logPath string) (string, error) {
+	if err := a.checkHealth(ctx); err != nil {
+		return "", fmt.Errorf("opencode server at %s is not reachable: %w", a.baseURL, err)
+	}
+	var logFile io.Writer = io.Discard
+	if logPath != "" {
+		if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+			return "", err
+		}
+		file, err := contracts.CreateRunnerLog(logPath, request.Secrets)
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+		logFile = file
+	}
+
+	sessionID, err := a.session(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	reply, err := a.prompt(ctx, request, sessionID)
+	if errors.Is(err, errServerSessionNotFound) {
+		a.forgetSession(request)
+		if sessionID, err = a.session(ctx, request); err != nil {
+			return "", err
+		}
+		reply, err = a.prompt(ctx, request, sessionID)
+	}
+	if err != nil {
+		return sessionID, err
+	}
+	writeServerReplyLog(logFile, reply)
+	if reply.Info.Error != nil {
+		return sessionID, errors.New(reply.Info.Error.reason())
+	}
+	return sessionID, nil
+}
+
+func (a *ServerRunnerAdapter) checkHealth(ctx context.Context) error {
+	resp, err := a.do(ctx, http.MethodGet, resolveServeHealthURL(a.baseURL), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func serverSessionKey(request contracts.RunnerRequest) string {
+	return request.TaskID + "/" + string(request.Mode)
+}
+
+// session returns the task's session for this mode, creating it on the
+// first run.
+func (a *ServerRunnerAdapter) session(ctx context.Context, request contracts.RunnerRequest) (string, error) {
+	key := serverSessionKey(request)
+	a.mu.Lock()
+	id := a.sessions[key]
+	a.mu.Unlock()
+	if id != "" {
+		return id, nil
+	}
+
+	body, err := json.Marshal(map[string]string{"title": strings.TrimSpace(request.TaskID + " " + string(request.Mode))})
+	if err != nil {
+		return "", err
+	}
+	resp, err := a.do(ctx, http.MethodPost, a.url("/session", request.RepoRoot), body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("create session returned %d", resp.StatusCode)
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("decode created session: %w", err)
+	}
+	if strings.TrimSpace(created.ID) == "" {
+		return "", errors.New("create session returned no session id")
+	}
+	a.mu.Lock()
+	a.sessions[key] = created.ID
+	a.mu.Unlock()
+	return created.ID, nil
+}
+
+func (a *ServerRunnerAdapter) forgetSession(request contracts.RunnerRequest) {
+	a.mu.Lock()
+	delete(a.sessions, serverSessionKey(request))
+	a.mu.Unlock()
+}
+
+type serverMessageReply struct {
+	Info struct {
+		Error *serverMessageError `json:"error"`
+	} `json:"info"`
+	Parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"parts"`
+}
+
+type serverMessageError struct {
+	Name string `json:"name"`
+	Data struct {
+		Message string `json:"message"`
+	} `json:"data"`
+}
+
+func (e *serverMessageError) reason() string {
+	if message := strings.TrimSpace(e.Data.Message); message != "" {
+		return message
+	}
+	if name := strings.TrimSpace(e.Name); name != "" {
+		return name
+	}
+	return "opencode server reported an error"
+}
+
+// prompt sends the prompt to the session while following its events.
+func (a *ServerRunnerAdapter) prompt(ctx context.Context, request contracts.RunnerRequest, sessionID string) (serverMessageReply, error) {
+	events, err := a.subscribe(ctx, request.RepoRoot)
+	if err != nil {
+		return serverMessageReply{}, err
+	}
+	eventsCtx, stopEvents := context.WithCancel(ctx)
+	var eventsDone sync.WaitGroup
+	eventsDone.Add(1)
+	go func() {
+		defer eventsDone.Done()
+		a.followEvents(eventsCtx, events, sessionID, request.OnProgress)
+	}()
+	defer func() {
+		stopEvents()
+		eventsDone.Wait()
+	}()
+
+	reply, err := a.sendMessage(ctx, request, sessionID)
+	if ctx.Err() != nil {
+		a.abortSession(sessionID, request.RepoRoot)
+		return serverMessageReply{}, ctx.Err()
+	}
+	return reply, err
+}
+
+// sendMessage posts the prompt and blocks until the assistant's reply is
+// complete.
+func (a *ServerRunnerAdapter) sendMessage(ctx context.Context, request contracts.RunnerRequest, sessionID string) (serverMessageReply, error) {
+	payload := map[string]any{
+		"parts": []map[string]string{{"type": "text", "text": request.Prompt}},
+	}
+	if providerID, modelID, ok := strings.Cut(strings.TrimSpace(request.Model), "/"); ok && providerID != "" && modelID != "" {
+		payload["model"] = map[string]string{"providerID": providerID, "modelID": modelID}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return serverMessageReply{}, err
+	}
+	resp, err := a.do(ctx, http.MethodPost, a.url("/session/"+url.PathEscape(sessionID)+"/message", request.RepoRoot), body)
+	if err != nil {
+		return serverMessageReply{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return serverMessageReply{}, errServerSessionNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return serverMessageReply{}, fmt.Errorf("send message returned %d", resp.StatusCode)
+	}
+	var reply serverMessageReply
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return serverMessageReply{}, fmt.Errorf("decode message reply: %w", err)
+	}
+	return reply, nil
+}
+
+// abortSession stops a prompt that is still running on the server after
+// the run was cancelled or timed out. The session itself is kept.
+func (a *ServerRunnerAdapter) abortSession(sessionID string, repoRoot string) {
+	if sessionID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := a.do(ctx, http.MethodPost, a.url("/session/"+url.PathEscape(sessionID)+"/abort", repoRoot), nil)
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+}
+
+// subscribe opens the server's event stream before the prompt is sent, so
+// no permission request can be missed.
+func (a *ServerRunnerAdapter) subscribe(ctx context.Context, repoRoot string) (io.ReadCloser, error) {
+	resp, err := a.do(ctx, http.MethodGet, a.url("/event", repoRoot), nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("event stream returned %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// followEvents forwards the session's tool activity as runner progress and
+// allows its permission requests until ctx ends.
+func (a *ServerRunnerAdapter) followEvents(ctx context.Context, events io.ReadCloser, sessionID string, onProgress func(contracts.RunnerProgress)) {
+	stop := context.AfterFunc(ctx, func() { _ = events.Close() })
+	defer stop()
+	defer events.Close()
+
+	scanner := bufio.NewScanner(events)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	reported := map[string]string{}
+	for {
+		event, ok, err := decodeNextSSEFrame(scanner)
+		if err != nil || !ok {
+			return
+		}
+		if serverEventSessionID(event) != sessionID {
+			continue
+		}
+		if permission, ok := DetectServeEventPermissionRequest(event); ok {
+			_ = RespondServePermissionRequest(ctx, a.client, a.baseURL, permission)
+			continue
+		}
+		if onProgress == nil {
+			continue
+		}
+		if progress, ok := serverToolProgress(event, reported); ok {
+			onProgress(progress)
+		}
+	}
+}
+
+type serverEvent struct {
+	Type       string `json:"type"`
+	Properties struct {
+		SessionID string `json:"sessionID"`
+		Info      struct {
+			SessionID string `json:"sessionID"`
+		} `json:"info"`
+		Part struct {
+			ID        string `json:"id"`
+			SessionID string `json:"sessionID"`
+			Type      string `json:"type"`
+			Tool      string `json:"tool"`
+			State     struct {
+				Status string `json:"status"`
+				Title  string `json:"title"`
+			} `json:"state"`
+		} `json:"part"`
+	} `json:"properties"`
+}
+
+func decodeServerEvent(event contracts.SSEEvent) (serverEvent, bool) {
+	var payload serverEvent
+	if err := json.Unmarshal([]byte(strings.TrimSpace(event.Data)), &payload); err != nil {
+		return serverEvent{}, false
+	}
+	return payload, true
+}
+
+// serverEventSessionID returns the session an event belongs to; the server
+// streams events for all of its sessions.
+func serverEventSessionID(event contracts.SSEEvent) string {
+	payload, ok := decodeServerEvent(event)
+	if !ok {
+		return ""
+	}
+	for _, id := range []string{payload.Properties.SessionID, payload.Properties.Part.SessionID, payload.Properties.Info.SessionID} {
+		if id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// serverToolProgress turns a tool part update into progress, once per tool
+// call and state.
+func serverToolProgress(event contracts.SSEEvent, reported map[string]string) (contracts.RunnerProgress, bool) {
+	payload, ok := decodeServerEvent(event)
+	if !ok || payload.Type != "message.part.updated" || payload.Properties.Part.Type != "tool" {
+		return contracts.RunnerProgress{}, false
+	}
+	part := payload.Properties.Part
+	var progressType contracts.EventType
+	switch part.State.Status {
+	case "running":
+		progressType = contracts.EventTypeRunnerCommandStarted
+	case "completed", "error":
+		progressType = contracts.EventTypeRunnerCommandFinished
+	default:
+		return contracts.RunnerProgress{}, false
+	}
+	if reported[part.ID] == part.State.Status {
+		return contracts.RunnerProgress{}, false
+	}
+	reported[part.ID] = part.State.Status
+	message := part.Tool
+	if title := strings.TrimSpace(part.State.Title); title != "" {
+		message += " " + title
+	}
+	return contracts.RunnerProgress{
+		Type:      string(progressType),
+		Message:   message,
+		Metadata:  map[string]string{"tool": part.Tool, "status": part.State.Status},
+		Timestamp: time.Now().UTC(),
+	}, true
+}
+
+// writeServerReplyLog records the reply's text in the agent_message form
+// the structured review verdict is read from.
+func writeServerReplyLog(w io.Writer, reply serverMessageReply) {
+	for _, part := range reply.Parts {
+		if part.Type != "text" || strings.TrimSpace(part.Text) == "" {
+			continue
+		}
+		line, err := json.Marshal(map[string]string{
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+			"message":   "agent_message " + strconv.Quote(part.Text),
+		})
+		if err != nil {
+			continue
+		}
+		_, _ = w.Write(append(line, '\n'))
+	}
+}
+
+func (a *ServerRunnerAdapter) url(path string, directory string) string {
+	endpoint := a.baseURL + path
+	if strings.TrimSpace(directory) != "" {
+		endpoint += "?directory=" + url.QueryEscape(directory)
+	}
+	return endpoint
+}
+
+func (a *ServerRunnerAdapter) do(ctx context.Context, method string, endpoint string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return a.client.Do(req)
+}
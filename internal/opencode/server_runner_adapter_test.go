@@ -0,0 +1,182 @@
+package opencode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// fakeOpencodeServer serves the parts of the opencode server API the
+// server runner adapter uses. Each message publishes a permission request
+// and a tool update, and replies once the permission was answered.
+type fakeOpencodeServer struct {
+	mu          sync.Mutex
+	created     int
+	directories []string
+	messages    []map[string]any
+	staleOnce   bool
+	replyText   string
+	events      chan string
+	permitted   chan string
+}
+
+func newFakeOpencodeServer(t *testing.T, replyText string) (*fakeOpencodeServer, *httptest.Server) {
+	t.Helper()
+	fake := &fakeOpencodeServer{replyText: replyText, events: make(chan string, 16), permitted: make(chan string, 16)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /global/health", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"healthy":true}`))
+	})
+	mux.HandleFunc("POST /session", func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		fake.created++
+		id := fmt.Sprintf("ses-%d", fake.created)
+		fake.directories = append(fake.directories, r.URL.Query().Get("directory"))
+		fake.mu.Unlock()
+		_, _ = fmt.Fprintf(w, `{"id":%q}`, id)
+	})
+	mux.HandleFunc("POST /session/{id}/message", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		fake.mu.Lock()
+		stale := fake.staleOnce
+		fake.staleOnce = false
+		fake.mu.Unlock()
+		if stale {
+			http.NotFound(w, r)
+			return
+		}
+		payload := map[string]any{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		fake.mu.Lock()
+		fake.messages = append(fake.messages, payload)
+		fake.mu.Unlock()
+
+		fake.events <- `{"type":"message.part.updated","properties":{"part":{"id":"p-0","sessionID":"other","type":"tool","tool":"bash","state":{"status":"running"}}}}`
+		fake.events <- fmt.Sprintf(`{"type":"permission.requested","properties":{"id":"perm-1","sessionID":%q,"toolName":"bash","options":[{"kind":"allow_once","optionId":"once"}]}}`, id)
+		select {
+		case <-fake.permitted:
+		case <-r.Context().Done():
+			return
+		}
+		for _, status := range []string{"running", "running", "completed"} {
+			fake.events <- fmt.Sprintf(`{"type":"message.part.updated","properties":{"part":{"id":"p-1","sessionID":%q,"type":"tool","tool":"bash","state":{"status":%q,"title":"go test ./..."}}}}`, id, status)
+		}
+		time.Sleep(50 * time.Millisecond)
+		_, _ = fmt.Fprintf(w, `{"info":{"id":"msg-1"},"parts":[{"type":"step-start"},{"type":"text","text":%q}]}`, fake.replyText)
+	})
+	mux.HandleFunc("POST /permission/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fake.permitted <- r.PathValue("id")
+	})
+	mux.HandleFunc("GET /event", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "data: {\"type\":\"server.connected\",\"properties\":{}}\n\n")
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case data := <-fake.events:
+				_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func TestServerRunnerAdapterReusesSessionAcrossRuns(t *testing.T) {
+	fake, server := newFakeOpencodeServer(t, "Looks good.\nREVIEW_VERDICT: pass\n")
+	adapter := NewServerRunnerAdapter(server.URL, server.Client())
+	repoRoot := t.TempDir()
+	progress := []contracts.RunnerProgress{}
+	request := contracts.RunnerRequest{
+		TaskID:     "task-1",
+		Mode:       contracts.RunnerModeReview,
+		Model:      "anthropic/claude-sonnet-4",
+		Prompt:     "Review the change",
+		RepoRoot:   repoRoot,
+		Timeout:    10 * time.Second,
+		OnProgress: func(p contracts.RunnerProgress) { progress = append(progress, p) },
+	}
+
+	for run := 0; run < 2; run++ {
+		result, err := adapter.Run(context.Background(), request)
+		if err != nil {
+			t.Fatalf("run %d failed: %v", run, err)
+		}
+		if result.Status != contracts.RunnerResultCompleted || !result.ReviewReady {
+			t.Fatalf("run %d: expected completed review-ready result, got %#v", run, result)
+		}
+		if result.Artifacts["session_id"] != "ses-1" || result.Artifacts["backend"] != "opencode-server" || result.Artifacts["review_verdict"] != "pass" {
+			t.Fatalf("run %d: unexpected artifacts %#v", run, result.Artifacts)
+		}
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.created != 1 {
+		t.Fatalf("expected one session for both runs, got %d", fake.created)
+	}
+	if fake.directories[0] != repoRoot {
+		t.Fatalf("expected session in %q, got %q", repoRoot, fake.directories[0])
+	}
+	model, _ := fake.messages[0]["model"].(map[string]any)
+	if model["providerID"] != "anthropic" || model["modelID"] != "claude-sonnet-4" {
+		t.Fatalf("unexpected model %#v", fake.messages[0]["model"])
+	}
+	got := []string{}
+	for _, p := range progress {
+		got = append(got, p.Type+":"+p.Message)
+	}
+	want := "runner_cmd_started:bash go test ./...|runner_cmd_finished:bash go test ./..."
+	if strings.Join(got, "|") != want+"|"+want {
+		t.Fatalf("unexpected progress %q", strings.Join(got, "|"))
+	}
+}
+
+func TestServerRunnerAdapterRecreatesSessionUnknownToServer(t *testing.T) {
+	fake, server := newFakeOpencodeServer(t, "done")
+	adapter := NewServerRunnerAdapter(server.URL, server.Client())
+	request := contracts.RunnerRequest{TaskID: "task-1", Mode: contracts.RunnerModeImplement, Prompt: "go", RepoRoot: t.TempDir(), Timeout: 10 * time.Second}
+	if _, err := adapter.Run(context.Background(), request); err != nil {
+		t.Fatalf("first run failed: %v", err)
+	}
+	fake.mu.Lock()
+	fake.staleOnce = true
+	fake.mu.Unlock()
+
+	result, err := adapter.Run(context.Background(), request)
+	if err != nil {
+		t.Fatalf("second run failed: %v", err)
+	}
+	if result.Status != contracts.RunnerResultCompleted || result.Artifacts["session_id"] != "ses-2" {
+		t.Fatalf("expected completed run on a new session, got %#v", result)
+	}
+}
+
+func TestServerRunnerAdapterFailsWhenServerIsUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	result, err := NewServerRunnerAdapter(server.URL, nil).Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "task-1",
+		Mode:     contracts.RunnerModeImplement,
+		RepoRoot: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if result.Status != contracts.RunnerResultFailed || !strings.Contains(result.Reason, "not reachable") {
+		t.Fatalf("expected unreachable server to fail the run, got %#v", result)
+	}
+}
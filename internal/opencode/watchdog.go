@@ -29,8 +29,11 @@ const (
 	stallPermission        = "permission"
 	stallQuestion          = "question"
 	stallNoOutput          = "no_output"
+	stallRateLimit         = "rate_limit"
 )
 
+var rateLimitLogMarkers = []string{"rate limit", "rate_limit", "ratelimit", "too many requests", "status=429"}
+
 type Process interface {
 	Wait() error
 	Kill() error
@@ -49,9 +52,15 @@ type WatchdogConfig struct {
 	IdleTransportGrace time.Duration
 	CompletionGrace    time.Duration
 	TailLines          int
-	Now                func() time.Time
-	After              func(time.Duration) <-chan time.Time
-	NewTicker          func(time.Duration) WatchdogTicker
+	// Hooks are shell commands run before a stalled backend is killed;
+	// they run in HookDir with HookEnv and are bounded by HookTimeout.
+	Hooks       []string
+	HookTimeout time.Duration
+	HookDir     string
+	HookEnv     map[string]string
+	Now         func() time.Time
+	After       func(time.Duration) <-chan time.Time
+	NewTicker   func(time.Duration) WatchdogTicker
 }
 
 type realWatchdogTicker struct{ ticker *time.Ticker }
@@ -71,6 +80,9 @@ type StallError struct {
 	LastOutputAge time.Duration
 	Tail          []string
 	TailPath      string
+	// HookOutputPath holds the combined output of the watchdog hooks.
+	HookOutputPath string
+	HookFailures   int
 }
 
 func (err *StallError) Error() string {
@@ -95,6 +107,9 @@ func (err *StallError) Error() string {
 	} else if len(err.Tail) > 0 {
 		parts = append(parts, "opencode_tail="+strings.Join(err.Tail, " | "))
 	}
+	if err.HookOutputPath != "" {
+		parts = append(parts, "watchdog_hook_output="+err.HookOutputPath)
+	}
 	return strings.Join(parts, " ")
 }
 
@@ -189,6 +204,10 @@ func (watchdog *Watchdog) Monitor(process Process) error {
 
 			if stall, ok := classifyIdleTransportOpen(config, currentTime, lastOutput); ok {
 				if currentTime.Sub(lastOutput) >= idleTransportGrace || currentSize == lastSize {
+					runStallHooks(config, stall, process)
+					if err, done := checkDone(); done {
+						return err
+					}
 					if err := process.Kill(); err != nil {
 						return err
 					}
@@ -219,6 +238,11 @@ func (watchdog *Watchdog) Monitor(process Process) error {
 			}
 
 			stall := classifyStall(config, currentTime, lastOutput)
+			runStallHooks(config, stall, process)
+			// A hook may have taken long enough for the backend to finish.
+			if err, done := checkDone(); done {
+				return err
+			}
 
 			if err := process.Kill(); err != nil {
 				return err
@@ -253,18 +277,7 @@ func classifyIdleTransportOpen(config WatchdogConfig, now time.Time, lastOutput
 func classifyStall(config WatchdogConfig, now time.Time, lastOutput time.Time) *StallError {
 	latestLog := latestLogPath(config.OpenCodeLogDir)
 	lines := tailLines(latestLog, config.TailLines)
-	category := stallNoOutput
-	for _, line := range lines {
-		lower := strings.ToLower(line)
-		if strings.Contains(lower, "service=question") || strings.Contains(lower, "permission=question") || strings.Contains(lower, "service=provider") {
-			category = stallQuestion
-			break
-		}
-		if strings.Contains(lower, "service=permission") || strings.Contains(lower, "permission=doom_loop") {
-			category = stallPermission
-			break
-		}
-	}
+	category := classifyStallLines(lines)
 	stall := &StallError{
 		Category:      category,
 		OpenCodeLog:   latestLog,
@@ -281,6 +294,36 @@ func classifyStall(config WatchdogConfig, now time.Time, lastOutput time.Time) *
 	return stall
 }
 
+func classifyStallLines(lines []string) string {
+	if hasRateLimitLogLine(lines) {
+		return stallRateLimit
+	}
+	for _, line := range lines {
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "service=question") || strings.Contains(lower, "permission=question") || strings.Contains(lower, "service=provider") {
+			return stallQuestion
+		}
+		if strings.Contains(lower, "service=permission") || strings.Contains(lower, "permission=doom_loop") {
+			return stallPermission
+		}
+	}
+	return stallNoOutput
+}
+
+// hasRateLimitLogLine reports provider throttling anywhere in the tail; it
+// wins over the question match that provider log lines would otherwise get.
+func hasRateLimitLogLine(lines []string) bool {
+	for _, line := range lines {
+		lower := strings.ToLower(line)
+		for _, marker := range rateLimitLogMarkers {
+			if strings.Contains(lower, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func writeTailFile(logPath string, lines []string) (string, error) {
 	if logPath == "" || len(lines) == 0 {
 		return "", nil
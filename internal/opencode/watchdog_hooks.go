@@ -0,0 +1,82 @@
+package opencode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const defaultWatchdogHookTimeout = time.Minute
+
+// processIdentifier is implemented by processes that know the OS pid of the
+// backend, so watchdog hooks can inspect or signal it.
+type processIdentifier interface {
+	Pid() int
+}
+
+// runStallHooks runs each configured hook with sh -c after the watchdog
+// classified a stall and before it kills the backend, e.g. to send SIGQUIT to
+// $YOLO_WATCHDOG_PID for a goroutine dump. Hooks see the stall in
+// YOLO_WATCHDOG_* variables. Their combined output is written next to the
+// runner log; a failing hook is recorded there and does not stop the kill.
+func runStallHooks(config WatchdogConfig, stall *StallError, process Process) {
+	if len(config.Hooks) == 0 || stall == nil {
+		return
+	}
+	timeout := config.HookTimeout
+	if timeout <= 0 {
+		timeout = defaultWatchdogHookTimeout
+	}
+	env := make(map[string]string, len(config.HookEnv)+6)
+	for key, value := range config.HookEnv {
+		env[key] = value
+	}
+	env["YOLO_WATCHDOG_CATEGORY"] = stall.Category
+	env["YOLO_WATCHDOG_RUNNER_LOG"] = stall.LogPath
+	env["YOLO_WATCHDOG_BACKEND_LOG"] = stall.OpenCodeLog
+	env["YOLO_WATCHDOG_SESSION_ID"] = stall.SessionID
+	env["YOLO_WATCHDOG_LAST_OUTPUT_AGE"] = stall.LastOutputAge.Round(time.Second).String()
+	if identified, ok := process.(processIdentifier); ok && identified.Pid() > 0 {
+		env["YOLO_WATCHDOG_PID"] = strconv.Itoa(identified.Pid())
+	}
+
+	var report strings.Builder
+	for _, command := range config.Hooks {
+		command = strings.TrimSpace(command)
+		if command == "" {
+			continue
+		}
+		hookCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		cmd := exec.CommandContext(hookCtx, "sh", "-c", command)
+		cmd.Dir = config.HookDir
+		cmd.Env = append(os.Environ(), contracts.RunnerEnv(env)...)
+		cmd.WaitDelay = time.Second
+		output, err := cmd.CombinedOutput()
+		if err != nil && errors.Is(hookCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		cancel()
+		fmt.Fprintf(&report, "$ %s\n%s", command, output)
+		if len(output) > 0 && output[len(output)-1] != '\n' {
+			report.WriteString("\n")
+		}
+		if err != nil {
+			stall.HookFailures++
+			fmt.Fprintf(&report, "hook failed: %v\n", err)
+		}
+	}
+	if config.LogPath == "" || report.Len() == 0 {
+		return
+	}
+	path := config.LogPath + ".watchdog-hooks.txt"
+	if err := os.WriteFile(path, []byte(report.String()), 0o644); err == nil {
+		stall.HookOutputPath = path
+	}
+}
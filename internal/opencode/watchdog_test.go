@@ -273,6 +273,17 @@ func TestWatchdogTimeoutClassifiesProviderPromptAsQuestion(t *testing.T) {
 	}
 }
 
+func TestClassifyStallDetectsProviderRateLimit(t *testing.T) {
+	logDir := filepath.Join(t.TempDir(), "opencode", "log")
+	writeFile(t, filepath.Join(logDir, "latest.log"), "INFO service=provider status=waiting_for_auth\nERROR service=provider status=429 msg=\"Too Many Requests\"\n")
+
+	now := time.Now()
+	stall := classifyStall(WatchdogConfig{OpenCodeLogDir: logDir, TailLines: 20}, now, now.Add(-time.Minute))
+	if stall.Category != stallRateLimit {
+		t.Fatalf("expected %q category, got %q", stallRateLimit, stall.Category)
+	}
+}
+
 func TestWatchdogNoOutputIncludesLastOutputAge(t *testing.T) {
 	tempDir := t.TempDir()
 	runnerLog := filepath.Join(tempDir, "runner-logs", "opencode", "issue-2.jsonl")
@@ -682,3 +693,48 @@ func TestWatchdogPrefersWaitResultOverStallKillWhenWaitCompletesDuringGrace(t *t
 		t.Fatalf("timed out waiting for watchdog")
 	}
 }
+
+func TestWatchdogRunsHooksBeforeKillingStalledProcess(t *testing.T) {
+	tempDir := t.TempDir()
+	runnerLog := filepath.Join(tempDir, "runner-logs", "opencode", "issue-3.jsonl")
+	writeFile(t, runnerLog, "")
+	oldTime := time.Now().Add(-2 * time.Second)
+	if err := os.Chtimes(runnerLog, oldTime, oldTime); err != nil {
+		t.Fatalf("chtimes runner log: %v", err)
+	}
+
+	proc := newFakeProcess()
+	watchdog := NewWatchdog(WatchdogConfig{
+		LogPath:        runnerLog,
+		OpenCodeLogDir: filepath.Join(tempDir, "opencode", "log"),
+		Timeout:        20 * time.Millisecond,
+		Interval:       5 * time.Millisecond,
+		Hooks: []string{
+			`[ -n "$(ls)" ] && echo "stall=$YOLO_WATCHDOG_CATEGORY task=$YOLO_WATCHDOG_TASK_ID"`,
+			"exit 3",
+		},
+		HookDir: tempDir,
+		HookEnv: map[string]string{"YOLO_WATCHDOG_TASK_ID": "issue-3"},
+	})
+
+	err := watchdog.Monitor(proc)
+	var stall *StallError
+	if !errors.As(err, &stall) {
+		t.Fatalf("expected StallError, got %v", err)
+	}
+	if !proc.killed {
+		t.Fatalf("expected the stalled process to be killed after the hooks")
+	}
+	if stall.HookFailures != 1 || stall.HookOutputPath != runnerLog+".watchdog-hooks.txt" {
+		t.Fatalf("expected one failed hook and its output path, got %#v", stall)
+	}
+	output, readErr := os.ReadFile(stall.HookOutputPath)
+	if readErr != nil {
+		t.Fatalf("read hook output: %v", readErr)
+	}
+	for _, want := range []string{"stall=no_output task=issue-3", "$ exit 3", "hook failed: exit status 3"} {
+		if !strings.Contains(string(output), want) {
+			t.Fatalf("expected %q in hook output, got %q", want, output)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+type TemplateKind string
+
+const (
+	TemplateImplement   TemplateKind = "implement"
+	TemplateReview      TemplateKind = "review"
+	TemplateRemediation TemplateKind = "remediation"
+)
+
+// DefaultTemplateDir holds per-kind overrides discovered without explicit config,
+// e.g. .yolo-runner/prompts/implement.tmpl.
+const DefaultTemplateDir = ".yolo-runner/prompts"
+
+var templateKinds = []TemplateKind{TemplateImplement, TemplateReview, TemplateRemediation}
+
+// TemplateData is the value passed to prompt templates.
+type TemplateData struct {
+	Task contracts.Task
+	Mode string
+	// ReviewPhase is spec or quality when review runs in two phases, and
+	// empty for the single review.
+	ReviewPhase string
+	Default     string
+	Retry       RetryContext
+	Repo        RepoContext
+}
+
+type RetryContext struct {
+	ReviewAttempt      int
+	ReviewFeedback     string
+	CompletionAttempt  int
+	CompletionFeedback string
+	MergeBranch        string
+	MergeFailure       string
+	// LandingStrategy is merge or rebase_ff for merge-conflict remediation.
+	LandingStrategy string
+}
+
+type RepoContext struct {
+	Root     string
+	ParentID string
+	Backend  string
+	Model    string
+	TDDMode  bool
+	// Context is the gathered repository context (docs excerpts, recent
+	// commits, touched areas), empty when context injection is disabled.
+	Context string
+}
+
+// Templates holds the parsed prompt overrides keyed by kind.
+type Templates struct {
+	byKind map[TemplateKind]*template.Template
+	paths  map[TemplateKind]string
+}
+
+// LoadTemplates parses prompt overrides for repoRoot. Explicit paths win over
+// files in DefaultTemplateDir; relative paths resolve against repoRoot. It
+// returns nil when no template is configured or discovered.
+func LoadTemplates(repoRoot string, paths map[TemplateKind]string) (*Templates, error) {
+	loaded := &Templates{byKind: map[TemplateKind]*template.Template{}, paths: map[TemplateKind]string{}}
+	for _, kind := range templateKinds {
+		path := strings.TrimSpace(paths[kind])
+		explicit := path != ""
+		if !explicit {
+			path = filepath.Join(DefaultTemplateDir, string(kind)+".tmpl")
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(repoRoot, path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if !explicit && errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("cannot read %s prompt template %s: %w", kind, path, err)
+		}
+		parsed, err := template.New(string(kind)).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s prompt template %s: %w", kind, path, err)
+		}
+		loaded.byKind[kind] = parsed
+		loaded.paths[kind] = path
+	}
+	if len(loaded.byKind) == 0 {
+		return nil, nil
+	}
+	return loaded, nil
+}
+
+func (t *Templates) Has(kind TemplateKind) bool {
+	if t == nil {
+		return false
+	}
+	_, ok := t.byKind[kind]
+	return ok
+}
+
+// Path returns the file a template kind was loaded from.
+func (t *Templates) Path(kind TemplateKind) string {
+	if t == nil {
+		return ""
+	}
+	return t.paths[kind]
+}
+
+// Render executes the template for kind. The boolean result is false when no
+// override exists, in which case callers should keep their built-in prompt.
+func (t *Templates) Render(kind TemplateKind, data TemplateData) (string, bool, error) {
+	if !t.Has(kind) {
+		return "", false, nil
+	}
+	var out bytes.Buffer
+	if err := t.byKind[kind].Execute(&out, data); err != nil {
+		return "", true, fmt.Errorf("render %s prompt template %s: %w", kind, t.paths[kind], err)
+	}
+	return strings.TrimSpace(out.String()), true, nil
+}
@@ -0,0 +1,98 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func writePromptTemplate(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir template dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+}
+
+func TestLoadTemplatesReturnsNilWithoutOverrides(t *testing.T) {
+	templates, err := LoadTemplates(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if templates != nil {
+		t.Fatalf("expected nil templates, got %#v", templates)
+	}
+	if _, ok, err := templates.Render(TemplateImplement, TemplateData{}); ok || err != nil {
+		t.Fatalf("expected nil templates to skip rendering, got ok=%t err=%v", ok, err)
+	}
+}
+
+func TestLoadTemplatesDiscoversDefaultDirAndPrefersConfiguredPath(t *testing.T) {
+	repoRoot := t.TempDir()
+	writePromptTemplate(t, filepath.Join(repoRoot, DefaultTemplateDir, "implement.tmpl"), "default {{.Task.ID}}")
+	writePromptTemplate(t, filepath.Join(repoRoot, DefaultTemplateDir, "review.tmpl"), "review {{.Task.ID}} in {{.Repo.Root}}")
+	writePromptTemplate(t, filepath.Join(repoRoot, "house", "implement.tmpl"), "House rules for {{.Task.ID}} (retry {{.Retry.ReviewAttempt}}: {{.Retry.ReviewFeedback}})\n{{.Default}}")
+
+	templates, err := LoadTemplates(repoRoot, map[TemplateKind]string{TemplateImplement: "house/implement.tmpl"})
+	if err != nil {
+		t.Fatalf("load templates: %v", err)
+	}
+	if templates.Has(TemplateRemediation) {
+		t.Fatalf("did not expect remediation template")
+	}
+	data := TemplateData{
+		Task:    contracts.Task{ID: "t-1"},
+		Default: "BUILT-IN",
+		Retry:   RetryContext{ReviewAttempt: 2, ReviewFeedback: "add tests"},
+		Repo:    RepoContext{Root: "/repo"},
+	}
+	rendered, ok, err := templates.Render(TemplateImplement, data)
+	if err != nil || !ok {
+		t.Fatalf("expected implement render, got ok=%t err=%v", ok, err)
+	}
+	if rendered != "House rules for t-1 (retry 2: add tests)\nBUILT-IN" {
+		t.Fatalf("unexpected implement prompt %q", rendered)
+	}
+	rendered, _, err = templates.Render(TemplateReview, data)
+	if err != nil || rendered != "review t-1 in /repo" {
+		t.Fatalf("unexpected review prompt %q (err=%v)", rendered, err)
+	}
+	if !strings.HasSuffix(templates.Path(TemplateImplement), filepath.Join("house", "implement.tmpl")) {
+		t.Fatalf("expected configured path to win, got %q", templates.Path(TemplateImplement))
+	}
+}
+
+func TestLoadTemplatesRejectsMissingConfiguredFile(t *testing.T) {
+	_, err := LoadTemplates(t.TempDir(), map[TemplateKind]string{TemplateReview: "missing.tmpl"})
+	if err == nil || !strings.Contains(err.Error(), "cannot read review prompt template") {
+		t.Fatalf("expected read error, got %v", err)
+	}
+}
+
+func TestLoadTemplatesRejectsInvalidSyntax(t *testing.T) {
+	repoRoot := t.TempDir()
+	writePromptTemplate(t, filepath.Join(repoRoot, DefaultTemplateDir, "remediation.tmpl"), "{{.Task.ID")
+
+	_, err := LoadTemplates(repoRoot, nil)
+	if err == nil || !strings.Contains(err.Error(), "cannot parse remediation prompt template") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}
+
+func TestRenderReportsUnknownFields(t *testing.T) {
+	repoRoot := t.TempDir()
+	writePromptTemplate(t, filepath.Join(repoRoot, DefaultTemplateDir, "implement.tmpl"), "{{.Task.Missing}}")
+
+	templates, err := LoadTemplates(repoRoot, nil)
+	if err != nil {
+		t.Fatalf("load templates: %v", err)
+	}
+	if _, _, err := templates.Render(TemplateImplement, TemplateData{}); err == nil {
+		t.Fatalf("expected render error for unknown field")
+	}
+}
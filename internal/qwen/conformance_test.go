@@ -0,0 +1,49 @@
+package qwen
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/contracts/conformance"
+)
+
+func TestCLIRunnerAdapterConformance(t *testing.T) {
+	conformance.RunAgentRunnerSuite(t, conformance.Config{
+		Backend: "qwen",
+		Model:   "qwen3-coder-plus",
+		NewAdapter: func(t *testing.T, scenario conformance.Scenario) contracts.AgentRunner {
+			t.Helper()
+			return NewCLIRunnerAdapter("qwen-bin", commandRunnerFunc(func(_ context.Context, spec CommandSpec) error {
+				switch scenario {
+				case conformance.ScenarioSuccess:
+					_, _ = io.WriteString(spec.Stdout, assistantTextLine("working line"))
+					_, _ = io.WriteString(spec.Stderr, "warn line\n")
+					return nil
+				case conformance.ScenarioReviewPass:
+					_, _ = io.WriteString(spec.Stdout, assistantTextLine("REVIEW_VERDICT: pass"))
+					return nil
+				case conformance.ScenarioReviewFail:
+					_, _ = io.WriteString(spec.Stdout, assistantTextLine("REVIEW_VERDICT: fail"))
+					return nil
+				case conformance.ScenarioTimeoutError:
+					_, _ = io.WriteString(spec.Stdout, assistantTextLine("still working"))
+					return context.DeadlineExceeded
+				case conformance.ScenarioContextTimeoutNoErr:
+					_, _ = io.WriteString(spec.Stdout, assistantTextLine("still working"))
+					time.Sleep(30 * time.Millisecond)
+					return nil
+				case conformance.ScenarioFailure:
+					_, _ = io.WriteString(spec.Stderr, "boom\n")
+					return errors.New(conformance.FailureReason)
+				default:
+					t.Fatalf("unsupported scenario %q", scenario)
+					return nil
+				}
+			}))
+		},
+	})
+}
@@ -0,0 +1,257 @@
+package qwen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func assistantTextLine(text string) string {
+	line, _ := json.Marshal(map[string]any{
+		"type":    "assistant",
+		"message": map[string]any{"content": []map[string]any{{"type": "text", "text": text}}},
+	})
+	return string(line) + "\n"
+}
+
+func TestCLIRunnerAdapterImplementsContract(t *testing.T) {
+	var _ contracts.AgentRunner = (*CLIRunnerAdapter)(nil)
+}
+
+func TestCLIRunnerAdapterRunsQwenAndStreamsSessionLogProgress(t *testing.T) {
+	repoRoot := t.TempDir()
+	var gotSpec CommandSpec
+	updates := []contracts.RunnerProgress{}
+	adapter := NewCLIRunnerAdapter("qwen-bin", commandRunnerFunc(func(_ context.Context, spec CommandSpec) error {
+		gotSpec = spec
+		_, _ = io.WriteString(spec.Stdout, `{"type":"system","subtype":"init","session_id":"sess-1","model":"qwen3-coder-plus"}`+"\n")
+		_, _ = io.WriteString(spec.Stdout, `{"type":"assistant","session_id":"sess-1","message":{"content":[{"type":"text","text":"running tests"},{"type":"tool_use","id":"call-1","name":"run_shell_command","input":{"command":"go test ./..."}}]}}`+"\n")
+		_, _ = io.WriteString(spec.Stdout, `{"type":"user","session_id":"sess-1","message":{"content":[{"type":"tool_result","tool_use_id":"call-1","is_error":false,"content":"ok"}]}}`+"\n")
+		_, _ = io.WriteString(spec.Stdout, `{"type":"result","subtype":"success","is_error":false,"result":"done","session_id":"sess-1"}`+"\n")
+		_, _ = io.WriteString(spec.Stderr, "warn line\n")
+		return nil
+	}))
+
+	result, err := adapter.Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "t-1",
+		RepoRoot: repoRoot,
+		Prompt:   "implement feature",
+		Model:    "qwen3-coder-plus",
+		OnProgress: func(progress contracts.RunnerProgress) {
+			updates = append(updates, progress)
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != contracts.RunnerResultCompleted {
+		t.Fatalf("expected completed status, got %s", result.Status)
+	}
+	if gotSpec.Binary != "qwen-bin" {
+		t.Fatalf("expected binary qwen-bin, got %q", gotSpec.Binary)
+	}
+	expectedArgs := []string{"--output-format", "stream-json", "--yolo", "--model", "qwen3-coder-plus", "--prompt", "implement feature"}
+	if !reflect.DeepEqual(gotSpec.Args, expectedArgs) {
+		t.Fatalf("unexpected args: %#v", gotSpec.Args)
+	}
+	if gotSpec.Dir != repoRoot {
+		t.Fatalf("expected command dir %q, got %q", repoRoot, gotSpec.Dir)
+	}
+	expectedLogPath := filepath.Join(repoRoot, "runner-logs", "qwen", "t-1.jsonl")
+	if result.LogPath != expectedLogPath {
+		t.Fatalf("expected log path %q, got %q", expectedLogPath, result.LogPath)
+	}
+	if result.Artifacts["backend"] != "qwen" {
+		t.Fatalf("expected backend artifact qwen, got %q", result.Artifacts["backend"])
+	}
+	if result.Artifacts["session_id"] != "sess-1" {
+		t.Fatalf("expected session_id artifact sess-1, got %#v", result.Artifacts)
+	}
+
+	types := []string{}
+	for _, update := range updates {
+		types = append(types, update.Type)
+	}
+	expectedTypes := []string{"runner_output", "runner_cmd_started", "runner_cmd_finished", "runner_output"}
+	if !reflect.DeepEqual(types, expectedTypes) {
+		t.Fatalf("unexpected progress types: %#v (%#v)", types, updates)
+	}
+	if updates[0].Message != "running tests" {
+		t.Fatalf("unexpected assistant update: %#v", updates[0])
+	}
+	if updates[1].Message != "run_shell_command go test ./..." || updates[1].Metadata["tool"] != "run_shell_command" {
+		t.Fatalf("unexpected tool start update: %#v", updates[1])
+	}
+	if updates[2].Metadata["tool_call_id"] != "call-1" || updates[2].Metadata["status"] != "completed" {
+		t.Fatalf("unexpected tool finish update: %#v", updates[2])
+	}
+	if updates[3].Message != "stderr: warn line" {
+		t.Fatalf("unexpected stderr update: %#v", updates[3])
+	}
+
+	stdoutContent, err := os.ReadFile(result.LogPath)
+	if err != nil {
+		t.Fatalf("read stdout log: %v", err)
+	}
+	if !strings.Contains(string(stdoutContent), `"session_id":"sess-1"`) {
+		t.Fatalf("expected stdout log to contain the session log, got %q", string(stdoutContent))
+	}
+	stderrPath := strings.TrimSuffix(result.LogPath, ".jsonl") + ".stderr.log"
+	stderrContent, err := os.ReadFile(stderrPath)
+	if err != nil {
+		t.Fatalf("read stderr log: %v", err)
+	}
+	if !strings.Contains(string(stderrContent), "warn line") {
+		t.Fatalf("expected stderr log to contain output, got %q", string(stderrContent))
+	}
+}
+
+func TestCLIRunnerAdapterBuildsCommandFromConfiguredArgsTemplate(t *testing.T) {
+	repoRoot := t.TempDir()
+	var gotSpec CommandSpec
+	adapter := NewCLIRunnerAdapter("qwen-bin", commandRunnerFunc(func(_ context.Context, spec CommandSpec) error {
+		gotSpec = spec
+		return nil
+	}), "--backend={{backend}}", "--model", "{{model}}", "--prompt", "{{prompt}}", "--task-id={{task_id}}", "--repo={{repo_root}}", "--mode={{mode}}")
+
+	_, err := adapter.Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "task-1",
+		RepoRoot: repoRoot,
+		Prompt:   "implement feature",
+		Model:    "qwen3-coder-plus",
+		Mode:     contracts.RunnerModeImplement,
+		Metadata: map[string]string{"backend": "qwen"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"--backend=qwen", "--model", "qwen3-coder-plus", "--prompt", "implement feature", "--task-id=task-1", "--repo=" + repoRoot, "--mode=implement"}
+	if !reflect.DeepEqual(gotSpec.Args, expected) {
+		t.Fatalf("unexpected templated args: %#v", gotSpec.Args)
+	}
+}
+
+func TestCLIRunnerAdapterReadsReviewVerdictFromFinalResult(t *testing.T) {
+	adapter := NewCLIRunnerAdapter("qwen-bin", commandRunnerFunc(func(_ context.Context, spec CommandSpec) error {
+		_, _ = io.WriteString(spec.Stdout, `{"type":"result","subtype":"success","is_error":false,"result":"Looks good.\nREVIEW_VERDICT: pass"}`+"\n")
+		return nil
+	}))
+
+	result, err := adapter.Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "t-review",
+		RepoRoot: t.TempDir(),
+		Prompt:   "review",
+		Mode:     contracts.RunnerModeReview,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != contracts.RunnerResultCompleted {
+		t.Fatalf("expected completed status, got %s", result.Status)
+	}
+	if !result.ReviewReady {
+		t.Fatalf("expected ReviewReady=true for pass verdict")
+	}
+}
+
+func TestCLIRunnerAdapterExtractsStructuredReviewFailFeedback(t *testing.T) {
+	adapter := NewCLIRunnerAdapter("qwen-bin", commandRunnerFunc(func(_ context.Context, spec CommandSpec) error {
+		_, _ = io.WriteString(spec.Stdout, assistantTextLine("REVIEW_VERDICT: fail\nREVIEW_FAIL_FEEDBACK: missing e2e assertion for retry path"))
+		return nil
+	}))
+
+	result, err := adapter.Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "t-review",
+		RepoRoot: t.TempDir(),
+		Prompt:   "review",
+		Mode:     contracts.RunnerModeReview,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ReviewReady {
+		t.Fatalf("expected ReviewReady=false for fail verdict")
+	}
+	if result.Artifacts["review_verdict"] != "fail" {
+		t.Fatalf("expected review_verdict=fail artifact, got %#v", result.Artifacts)
+	}
+	if result.Artifacts["review_fail_feedback"] != "missing e2e assertion for retry path" {
+		t.Fatalf("expected review_fail_feedback artifact, got %#v", result.Artifacts)
+	}
+}
+
+func TestCLIRunnerAdapterFailsOnErrorResult(t *testing.T) {
+	adapter := NewCLIRunnerAdapter("qwen-bin", commandRunnerFunc(func(_ context.Context, spec CommandSpec) error {
+		_, _ = io.WriteString(spec.Stdout, `{"type":"result","subtype":"error_max_turns","is_error":true}`+"\n")
+		return nil
+	}))
+
+	result, err := adapter.Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "t-fail",
+		RepoRoot: t.TempDir(),
+		Prompt:   "implement",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != contracts.RunnerResultFailed {
+		t.Fatalf("expected failed status, got %s", result.Status)
+	}
+	if !strings.Contains(result.Reason, "error_max_turns") {
+		t.Fatalf("expected reason to name the error result, got %q", result.Reason)
+	}
+}
+
+func TestCLIRunnerAdapterMapsTimeoutToBlocked(t *testing.T) {
+	adapter := NewCLIRunnerAdapter("qwen-bin", commandRunnerFunc(func(_ context.Context, spec CommandSpec) error {
+		_, _ = io.WriteString(spec.Stdout, assistantTextLine("still working"))
+		return context.DeadlineExceeded
+	}))
+
+	result, err := adapter.Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "t-timeout",
+		RepoRoot: t.TempDir(),
+		Prompt:   "implement",
+		Timeout:  10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != contracts.RunnerResultBlocked {
+		t.Fatalf("expected blocked status, got %s", result.Status)
+	}
+	if !strings.Contains(result.Reason, "timeout") {
+		t.Fatalf("expected timeout reason, got %q", result.Reason)
+	}
+}
+
+func TestCLIRunnerAdapterMapsGenericErrorToFailed(t *testing.T) {
+	adapter := NewCLIRunnerAdapter("qwen-bin", commandRunnerFunc(func(_ context.Context, spec CommandSpec) error {
+		_, _ = io.WriteString(spec.Stderr, "boom\n")
+		return errors.New("qwen failed")
+	}))
+
+	result, err := adapter.Run(context.Background(), contracts.RunnerRequest{
+		TaskID:   "t-fail",
+		RepoRoot: t.TempDir(),
+		Prompt:   "implement",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != contracts.RunnerResultFailed {
+		t.Fatalf("expected failed status, got %s", result.Status)
+	}
+	if !strings.Contains(result.Reason, "qwen failed") {
+		t.Fatalf("expected failure reason to contain qwen failed, got %q", result.Reason)
+	}
+}
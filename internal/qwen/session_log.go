@@ -0,0 +1,176 @@
+package qwen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// streamMessage is one line of Qwen Code's stream-json session log:
+//
+//	{"type":"system","subtype":"init","session_id":"...","model":"..."}
+//	{"type":"assistant","message":{"content":[{"type":"text","text":"..."},{"type":"tool_use","id":"...","name":"run_shell_command","input":{...}}]}}
+//	{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"...","is_error":false}]}}
+//	{"type":"result","subtype":"success","is_error":false,"result":"...","session_id":"..."}
+type streamMessage struct {
+	Type      string `json:"type"`
+	Subtype   string `json:"subtype"`
+	SessionID string `json:"session_id"`
+	IsError   bool   `json:"is_error"`
+	Result    string `json:"result"`
+	Message   struct {
+		Content []streamContent `json:"content"`
+	} `json:"message"`
+}
+
+type streamContent struct {
+	Type      string         `json:"type"`
+	Text      string         `json:"text"`
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Input     map[string]any `json:"input"`
+	ToolUseID string         `json:"tool_use_id"`
+	IsError   bool           `json:"is_error"`
+}
+
+func parseStreamMessage(line string) (streamMessage, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return streamMessage{}, false
+	}
+	var message streamMessage
+	if err := json.Unmarshal([]byte(line), &message); err != nil {
+		return streamMessage{}, false
+	}
+	return message, message.Type != ""
+}
+
+// streamSession accumulates what a run needs from the session log: the
+// session id, the assistant's text for the review verdict and the final
+// result.
+type streamSession struct {
+	mu        sync.Mutex
+	sessionID string
+	assistant strings.Builder
+	tools     map[string]string
+	result    *streamMessage
+}
+
+// observe records message and returns the progress events it stands for.
+func (s *streamSession) observe(message streamMessage, now time.Time) []contracts.RunnerProgress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if message.SessionID != "" {
+		s.sessionID = message.SessionID
+	}
+	progress := []contracts.RunnerProgress{}
+	switch message.Type {
+	case "assistant":
+		for _, content := range message.Message.Content {
+			switch content.Type {
+			case "text":
+				s.assistant.WriteString(content.Text)
+				if !strings.HasSuffix(content.Text, "\n") {
+					s.assistant.WriteString("\n")
+				}
+				for _, line := range strings.Split(content.Text, "\n") {
+					if update, ok := contracts.NewRunnerOutputProgress("stdout", normalizeLine(line), now); ok {
+						progress = append(progress, update)
+					}
+				}
+			case "tool_use":
+				summary := describeToolUse(content)
+				if s.tools == nil {
+					s.tools = map[string]string{}
+				}
+				s.tools[content.ID] = summary
+				progress = append(progress, contracts.RunnerProgress{
+					Type:      string(contracts.EventTypeRunnerCommandStarted),
+					Message:   summary,
+					Metadata:  map[string]string{"tool": content.Name, "tool_call_id": content.ID},
+					Timestamp: now,
+				})
+			}
+		}
+	case "user":
+		for _, content := range message.Message.Content {
+			if content.Type != "tool_result" {
+				continue
+			}
+			summary, ok := s.tools[content.ToolUseID]
+			if !ok {
+				continue
+			}
+			delete(s.tools, content.ToolUseID)
+			status := "completed"
+			if content.IsError {
+				status = "failed"
+			}
+			progress = append(progress, contracts.RunnerProgress{
+				Type:      string(contracts.EventTypeRunnerCommandFinished),
+				Message:   summary,
+				Metadata:  map[string]string{"tool_call_id": content.ToolUseID, "status": status},
+				Timestamp: now,
+			})
+		}
+	case "result":
+		result := message
+		s.result = &result
+	}
+	return progress
+}
+
+// observeText records a line that is not part of the session log, such as
+// output from a CLI configured for --output-format text.
+func (s *streamSession) observeText(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.assistant.WriteString(line)
+	s.assistant.WriteString("\n")
+}
+
+func (s *streamSession) id() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessionID
+}
+
+// text returns the assistant's messages followed by the final result, the
+// text the review verdict is read from.
+func (s *streamSession) text() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	text := s.assistant.String()
+	if s.result != nil && s.result.Result != "" && !strings.Contains(text, s.result.Result) {
+		text += s.result.Result + "\n"
+	}
+	return text
+}
+
+// err reports a session that ended with an error result, since the CLI
+// may still exit zero.
+func (s *streamSession) err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.result == nil || (!s.result.IsError && !strings.HasPrefix(s.result.Subtype, "error")) {
+		return nil
+	}
+	if reason := strings.TrimSpace(s.result.Result); reason != "" {
+		return errors.New(reason)
+	}
+	return fmt.Errorf("qwen session ended with %s", s.result.Subtype)
+}
+
+func describeToolUse(content streamContent) string {
+	for _, key := range []string{"command", "file_path", "absolute_path", "path", "pattern"} {
+		if value, ok := content.Input[key].(string); ok && strings.TrimSpace(value) != "" {
+			return content.Name + " " + normalizeLine(value)
+		}
+	}
+	return content.Name
+}
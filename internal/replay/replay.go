@@ -0,0 +1,294 @@
+// Package replay records what a backend runner did for each task into
+// fixture files and serves the recordings back, so loop and TUI changes can
+// be tested deterministically without calling model APIs.
+//
+// A fixture holds one runner run: the prompt the backend read, the progress
+// it streamed, its raw output log, and the result it exited with. Fixtures
+// live in <dir>/<task>/<mode>-<attempt>.json, with the output log next to
+// them as <mode>-<attempt>.log.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// Fixture is one recorded runner run.
+type Fixture struct {
+	TaskID  string               `json:"task_id"`
+	Mode    contracts.RunnerMode `json:"mode"`
+	Attempt int                  `json:"attempt"`
+	Model   string               `json:"model,omitempty"`
+	// Prompt is what the backend was given on its command line or stdin.
+	Prompt   string           `json:"prompt"`
+	Progress []ProgressRecord `json:"progress,omitempty"`
+	Result   ResultRecord     `json:"result"`
+	// Error is the error the runner returned alongside Result, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// ProgressRecord is one progress update, Offset after the run started.
+type ProgressRecord struct {
+	Offset   time.Duration     `json:"offset_ns"`
+	Type     string            `json:"type"`
+	Message  string            `json:"message,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// ResultRecord is the runner result without its timestamps and log path,
+// which are filled in again on replay.
+type ResultRecord struct {
+	Status      contracts.RunnerResultStatus `json:"status"`
+	Reason      string                       `json:"reason,omitempty"`
+	Artifacts   map[string]string            `json:"artifacts,omitempty"`
+	ReviewReady bool                         `json:"review_ready,omitempty"`
+	Duration    time.Duration                `json:"duration_ns"`
+	HasLog      bool                         `json:"has_log,omitempty"`
+}
+
+// attempts counts the runs of each task and mode, numbering fixtures from 1.
+type attempts struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (a *attempts) next(taskID string, mode contracts.RunnerMode) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.counts == nil {
+		a.counts = map[string]int{}
+	}
+	key := taskID + "\x00" + string(mode)
+	a.counts[key]++
+	return a.counts[key]
+}
+
+// Recorder wraps a runner and writes a fixture for each of its runs.
+type Recorder struct {
+	runner   contracts.AgentRunner
+	dir      string
+	now      func() time.Time
+	attempts attempts
+}
+
+// NewRecorder records the runs of runner into dir.
+func NewRecorder(runner contracts.AgentRunner, dir string) *Recorder {
+	return &Recorder{runner: runner, dir: dir, now: time.Now}
+}
+
+func (r *Recorder) Run(ctx context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	if r == nil || r.runner == nil {
+		return contracts.RunnerResult{}, errors.New("nil replay recorder")
+	}
+	fixture := Fixture{
+		TaskID:  request.TaskID,
+		Mode:    request.Mode,
+		Attempt: r.attempts.next(request.TaskID, request.Mode),
+		Model:   request.Model,
+		Prompt:  request.Prompt,
+	}
+	started := r.now()
+	var mu sync.Mutex
+	onProgress := request.OnProgress
+	request.OnProgress = func(progress contracts.RunnerProgress) {
+		mu.Lock()
+		fixture.Progress = append(fixture.Progress, ProgressRecord{
+			Offset:   r.now().Sub(started),
+			Type:     progress.Type,
+			Message:  progress.Message,
+			Metadata: copyMetadata(progress.Metadata),
+		})
+		mu.Unlock()
+		if onProgress != nil {
+			onProgress(progress)
+		}
+	}
+
+	result, err := r.runner.Run(ctx, request)
+
+	mu.Lock()
+	defer mu.Unlock()
+	fixture.Result = ResultRecord{
+		Status:      result.Status,
+		Reason:      result.Reason,
+		Artifacts:   copyMetadata(result.Artifacts),
+		ReviewReady: result.ReviewReady,
+		Duration:    r.now().Sub(started),
+	}
+	if err != nil {
+		fixture.Error = err.Error()
+	}
+	if saveErr := r.save(fixture, result.LogPath); saveErr != nil && err == nil {
+		err = fmt.Errorf("record fixture for task %s: %w", request.TaskID, saveErr)
+	}
+	return result, err
+}
+
+func (r *Recorder) save(fixture Fixture, logPath string) error {
+	base := FixturePath(r.dir, fixture.TaskID, fixture.Mode, fixture.Attempt)
+	if err := os.MkdirAll(filepath.Dir(base), 0o755); err != nil {
+		return err
+	}
+	if strings.TrimSpace(logPath) != "" {
+		if output, err := os.ReadFile(logPath); err == nil {
+			if err := os.WriteFile(logFixturePath(base), output, 0o644); err != nil {
+				return err
+			}
+			fixture.Result.HasLog = true
+		}
+	}
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(base, append(data, '\n'), 0o644)
+}
+
+// Runner serves recorded fixtures back in place of a backend. The n-th run
+// of a task in a mode gets the n-th fixture recorded for it.
+type Runner struct {
+	dir      string
+	realtime bool
+	now      func() time.Time
+	sleep    func(context.Context, time.Duration) error
+	attempts attempts
+}
+
+// NewRunner replays the fixtures in dir.
+func NewRunner(dir string) *Runner {
+	return &Runner{dir: dir, now: time.Now, sleep: sleepContext}
+}
+
+// WithRealtime spaces progress updates as they were recorded instead of
+// sending them at once, e.g. to watch a replay in the TUI.
+func (r *Runner) WithRealtime(realtime bool) *Runner {
+	r.realtime = realtime
+	return r
+}
+
+func (r *Runner) Run(ctx context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	if r == nil {
+		return contracts.RunnerResult{}, errors.New("nil replay runner")
+	}
+	attempt := r.attempts.next(request.TaskID, request.Mode)
+	path := FixturePath(r.dir, request.TaskID, request.Mode, attempt)
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return contracts.RunnerResult{}, fmt.Errorf("no replay fixture for task %s %s attempt %d (%s)", request.TaskID, request.Mode, attempt, path)
+		}
+		return contracts.RunnerResult{}, err
+	}
+
+	started := r.now()
+	var elapsed time.Duration
+	for _, progress := range fixture.Progress {
+		if r.realtime && progress.Offset > elapsed {
+			if err := r.sleep(ctx, progress.Offset-elapsed); err != nil {
+				return contracts.RunnerResult{}, err
+			}
+			elapsed = progress.Offset
+		}
+		if request.OnProgress != nil {
+			request.OnProgress(contracts.RunnerProgress{
+				Type:      progress.Type,
+				Message:   progress.Message,
+				Metadata:  copyMetadata(progress.Metadata),
+				Timestamp: started.Add(progress.Offset),
+			})
+		}
+	}
+	if r.realtime && fixture.Result.Duration > elapsed {
+		if err := r.sleep(ctx, fixture.Result.Duration-elapsed); err != nil {
+			return contracts.RunnerResult{}, err
+		}
+	}
+
+	result := contracts.RunnerResult{
+		Status:      fixture.Result.Status,
+		Reason:      fixture.Result.Reason,
+		Artifacts:   copyMetadata(fixture.Result.Artifacts),
+		ReviewReady: fixture.Result.ReviewReady,
+		StartedAt:   started,
+		FinishedAt:  started.Add(fixture.Result.Duration),
+	}
+	if fixture.Result.HasLog {
+		result.LogPath = logFixturePath(path)
+	}
+	if fixture.Error != "" {
+		return result, errors.New(fixture.Error)
+	}
+	return result, nil
+}
+
+// FixturePath is where the fixture of a task's attempt-th run in mode lives.
+func FixturePath(dir string, taskID string, mode contracts.RunnerMode, attempt int) string {
+	modeName := string(mode)
+	if modeName == "" {
+		modeName = string(contracts.RunnerModeImplement)
+	}
+	return filepath.Join(dir, sanitizeName(taskID), fmt.Sprintf("%s-%03d.json", sanitizeName(modeName), attempt))
+}
+
+// LoadFixture reads one fixture file.
+func LoadFixture(path string) (Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Fixture{}, err
+	}
+	fixture := Fixture{}
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return Fixture{}, fmt.Errorf("parse replay fixture %s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+func logFixturePath(fixturePath string) string {
+	return strings.TrimSuffix(fixturePath, ".json") + ".log"
+}
+
+func sanitizeName(name string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '-'
+		}
+	}, strings.TrimSpace(name))
+	if strings.Trim(sanitized, ".-") == "" {
+		return "task"
+	}
+	return sanitized
+}
+
+func copyMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		copied[key] = value
+	}
+	return copied
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
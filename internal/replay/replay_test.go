@@ -0,0 +1,127 @@
+package replay
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+type scriptedRunner struct {
+	logDir string
+	calls  int
+}
+
+func (r *scriptedRunner) Run(_ context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	r.calls++
+	request.OnProgress(contracts.RunnerProgress{Type: "runner_output", Message: "attempt output"})
+	logPath := filepath.Join(r.logDir, "runner.jsonl")
+	if err := os.WriteFile(logPath, []byte("raw cli output\n"), 0o644); err != nil {
+		return contracts.RunnerResult{}, err
+	}
+	if r.calls == 1 {
+		return contracts.RunnerResult{Status: contracts.RunnerResultFailed, Reason: "tests fail", LogPath: logPath}, nil
+	}
+	return contracts.RunnerResult{Status: contracts.RunnerResultCompleted, LogPath: logPath, Artifacts: map[string]string{"session_id": "s-1"}}, nil
+}
+
+func TestRecorderWritesFixturesThatRunnerReplaysInOrder(t *testing.T) {
+	dir := t.TempDir()
+	backend := &scriptedRunner{logDir: t.TempDir()}
+	recorder := NewRecorder(backend, dir)
+	seen := []string{}
+	request := contracts.RunnerRequest{
+		TaskID: "task/1",
+		Mode:   contracts.RunnerModeImplement,
+		Prompt: "implement it",
+		OnProgress: func(progress contracts.RunnerProgress) {
+			seen = append(seen, progress.Message)
+		},
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := recorder.Run(context.Background(), request); err != nil {
+			t.Fatalf("record run %d: %v", i, err)
+		}
+	}
+	if len(seen) != 2 {
+		t.Fatalf("expected progress passed through while recording, got %v", seen)
+	}
+	fixture, err := LoadFixture(FixturePath(dir, "task/1", contracts.RunnerModeImplement, 1))
+	if err != nil {
+		t.Fatalf("load fixture: %v", err)
+	}
+	if fixture.Prompt != "implement it" || fixture.Result.Status != contracts.RunnerResultFailed || len(fixture.Progress) != 1 || !fixture.Result.HasLog {
+		t.Fatalf("unexpected first fixture: %#v", fixture)
+	}
+
+	replayer := NewRunner(dir)
+	replayed := []string{}
+	request.OnProgress = func(progress contracts.RunnerProgress) {
+		replayed = append(replayed, progress.Message)
+	}
+	first, err := replayer.Run(context.Background(), request)
+	if err != nil {
+		t.Fatalf("replay first: %v", err)
+	}
+	if first.Status != contracts.RunnerResultFailed || first.Reason != "tests fail" {
+		t.Fatalf("expected the first recorded result, got %#v", first)
+	}
+	output, err := os.ReadFile(first.LogPath)
+	if err != nil || string(output) != "raw cli output\n" {
+		t.Fatalf("expected the recorded output log, got %q (%v)", output, err)
+	}
+	second, err := replayer.Run(context.Background(), request)
+	if err != nil {
+		t.Fatalf("replay second: %v", err)
+	}
+	if second.Status != contracts.RunnerResultCompleted || second.Artifacts["session_id"] != "s-1" {
+		t.Fatalf("expected the second recorded result, got %#v", second)
+	}
+	if len(replayed) != 2 || replayed[0] != "attempt output" {
+		t.Fatalf("expected recorded progress replayed, got %v", replayed)
+	}
+	if backend.calls != 2 {
+		t.Fatalf("expected replay not to call the backend, got %d calls", backend.calls)
+	}
+	if _, err := replayer.Run(context.Background(), request); err == nil || !strings.Contains(err.Error(), "no replay fixture") {
+		t.Fatalf("expected a missing fixture error for a third run, got %v", err)
+	}
+}
+
+func TestRunnerRealtimeSpacesProgressAsRecorded(t *testing.T) {
+	dir := t.TempDir()
+	path := FixturePath(dir, "t-1", contracts.RunnerModeReview, 1)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fixture := `{"task_id":"t-1","mode":"review","attempt":1,"progress":[{"offset_ns":1000000000,"type":"runner_output","message":"a"},{"offset_ns":3000000000,"type":"runner_output","message":"b"}],"result":{"status":"completed","review_ready":true,"duration_ns":4000000000},"error":"backend exited 1"}`
+	if err := os.WriteFile(path, []byte(fixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	slept := []time.Duration{}
+	replayer := NewRunner(dir).WithRealtime(true)
+	replayer.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	result, err := replayer.Run(context.Background(), contracts.RunnerRequest{TaskID: "t-1", Mode: contracts.RunnerModeReview})
+	if err == nil || err.Error() != "backend exited 1" {
+		t.Fatalf("expected the recorded error, got %v", err)
+	}
+	if !result.ReviewReady || result.FinishedAt.Sub(result.StartedAt) != 4*time.Second {
+		t.Fatalf("unexpected replayed result: %#v", result)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, time.Second}
+	if len(slept) != len(want) {
+		t.Fatalf("expected sleeps %v, got %v", want, slept)
+	}
+	for i := range want {
+		if slept[i] != want[i] {
+			t.Fatalf("expected sleeps %v, got %v", want, slept)
+		}
+	}
+}
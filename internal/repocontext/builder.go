@@ -0,0 +1,228 @@
+// Package repocontext gathers repository context (docs excerpts, recent commit
+// titles, and the layout of areas a task mentions) for injection into prompts.
+package repocontext
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	DefaultRecentCommits = 10
+	DefaultTreeDepth     = 2
+	DefaultMaxBytes      = 12000
+	DefaultFileMaxBytes  = 4000
+
+	maxTreeEntries = 60
+	truncatedMark  = "\n...[truncated]"
+)
+
+var DefaultFiles = []string{"README.md", "CONTRIBUTING.md", "ARCHITECTURE.md", "docs/architecture.md"}
+
+var pathTokenPattern = regexp.MustCompile("[A-Za-z0-9_.\\-]+(?:/[A-Za-z0-9_.\\-]+)+/?")
+
+type Options struct {
+	// Files lists repo-relative documents to excerpt; nil uses DefaultFiles.
+	Files         []string
+	RecentCommits int
+	TreeDepth     int
+	MaxBytes      int
+	FileMaxBytes  int
+}
+
+type section struct {
+	title string
+	body  string
+}
+
+// Builder renders prompt context for tasks. Repo-wide sections are gathered
+// once and reused for every task in the run.
+type Builder struct {
+	repoRoot string
+	options  Options
+	runGit   func(ctx context.Context, dir string, args ...string) (string, error)
+
+	once   sync.Once
+	static []section
+}
+
+func New(repoRoot string, options Options) *Builder {
+	if options.Files == nil {
+		options.Files = DefaultFiles
+	}
+	if options.RecentCommits < 0 {
+		options.RecentCommits = 0
+	}
+	if options.TreeDepth <= 0 {
+		options.TreeDepth = DefaultTreeDepth
+	}
+	if options.MaxBytes <= 0 {
+		options.MaxBytes = DefaultMaxBytes
+	}
+	if options.FileMaxBytes <= 0 {
+		options.FileMaxBytes = DefaultFileMaxBytes
+	}
+	return &Builder{repoRoot: repoRoot, options: options, runGit: runGit}
+}
+
+// BuildPromptContext returns the context block for task, capped at MaxBytes.
+// Missing files and git failures drop the affected section.
+func (b *Builder) BuildPromptContext(ctx context.Context, task contracts.Task) string {
+	if b == nil || strings.TrimSpace(b.repoRoot) == "" {
+		return ""
+	}
+	b.once.Do(func() {
+		// The result is shared by every later task, so a cancelled first
+		// caller must not leave the run without commit history.
+		b.static = b.collectStatic(context.WithoutCancel(ctx))
+	})
+
+	sections := append([]section{}, b.static...)
+	if tree := b.touchedAreas(task); tree != "" {
+		sections = append(sections, section{title: "Touched Areas", body: tree})
+	}
+	return renderSections(sections, b.options.MaxBytes)
+}
+
+func (b *Builder) collectStatic(ctx context.Context) []section {
+	sections := []section{}
+	for _, name := range b.options.Files {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(b.repoRoot, name))
+		if err != nil {
+			continue
+		}
+		body := truncate(strings.TrimSpace(string(content)), b.options.FileMaxBytes)
+		if body == "" {
+			continue
+		}
+		sections = append(sections, section{title: name, body: body})
+	}
+	if b.options.RecentCommits > 0 && b.runGit != nil {
+		output, err := b.runGit(ctx, b.repoRoot, "log", "--no-color", "--format=%s", "-n", fmt.Sprintf("%d", b.options.RecentCommits))
+		if err == nil && strings.TrimSpace(output) != "" {
+			lines := []string{}
+			for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, "- "+line)
+				}
+			}
+			sections = append(sections, section{title: "Recent Commits", body: strings.Join(lines, "\n")})
+		}
+	}
+	return sections
+}
+
+// touchedAreas lists the directory trees for repo paths referenced in the task
+// title or description.
+func (b *Builder) touchedAreas(task contracts.Task) string {
+	seen := map[string]struct{}{}
+	dirs := []string{}
+	for _, token := range pathTokenPattern.FindAllString(task.Title+"\n"+task.Description, -1) {
+		// Keep leading dots (.github) and drop sentence-ending periods.
+		rel := filepath.Clean(strings.TrimRight(strings.TrimPrefix(token, "./"), "."))
+		if rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(b.repoRoot, rel))
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			rel = filepath.Dir(rel)
+		}
+		if _, ok := seen[rel]; ok {
+			continue
+		}
+		seen[rel] = struct{}{}
+		dirs = append(dirs, rel)
+	}
+	sort.Strings(dirs)
+
+	blocks := []string{}
+	for _, dir := range dirs {
+		entries := []string{dir + "/"}
+		walkTree(filepath.Join(b.repoRoot, dir), 1, b.options.TreeDepth, &entries)
+		blocks = append(blocks, strings.Join(entries, "\n"))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func walkTree(dir string, depth int, maxDepth int, entries *[]string) {
+	if depth > maxDepth || len(*entries) >= maxTreeEntries {
+		return
+	}
+	children, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	indent := strings.Repeat("  ", depth)
+	for _, child := range children {
+		if strings.HasPrefix(child.Name(), ".") {
+			continue
+		}
+		if len(*entries) >= maxTreeEntries {
+			*entries = append(*entries, indent+"...")
+			return
+		}
+		if child.IsDir() {
+			*entries = append(*entries, indent+child.Name()+"/")
+			walkTree(filepath.Join(dir, child.Name()), depth+1, maxDepth, entries)
+			continue
+		}
+		*entries = append(*entries, indent+child.Name())
+	}
+}
+
+func renderSections(sections []section, budget int) string {
+	parts := []string{}
+	remaining := budget
+	for _, s := range sections {
+		header := "### " + s.title + "\n"
+		if remaining <= len(header)+len(truncatedMark) {
+			break
+		}
+		body := truncate(s.body, remaining-len(header))
+		parts = append(parts, header+body)
+		remaining -= len(header) + len(body) + 2
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func truncate(value string, limit int) string {
+	if limit <= 0 || len(value) <= limit {
+		return value
+	}
+	cut := limit - len(truncatedMark)
+	if cut <= 0 {
+		return ""
+	}
+	// Avoid splitting a multi-byte rune.
+	for cut > 0 && !isRuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut] + truncatedMark
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	return string(output), err
+}
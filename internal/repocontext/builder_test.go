@@ -0,0 +1,125 @@
+package repocontext
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func writeRepoFile(t *testing.T, root string, rel string, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", rel, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", rel, err)
+	}
+}
+
+func TestBuildPromptContextIncludesDocsCommitsAndTouchedAreas(t *testing.T) {
+	root := t.TempDir()
+	writeRepoFile(t, root, "README.md", "# Project\nUse make test.")
+	writeRepoFile(t, root, "CONTRIBUTING.md", "Run gofmt.")
+	writeRepoFile(t, root, "internal/agent/loop.go", "package agent")
+	writeRepoFile(t, root, "internal/agent/sub/x.go", "package sub")
+
+	builder := New(root, Options{RecentCommits: 2})
+	gitCalls := 0
+	builder.runGit = func(_ context.Context, dir string, args ...string) (string, error) {
+		gitCalls++
+		if dir != root || args[0] != "log" {
+			t.Fatalf("unexpected git call %s %v", dir, args)
+		}
+		return "Fix scheduler\nAdd dry-run plan\n", nil
+	}
+
+	got := builder.BuildPromptContext(context.Background(), contracts.Task{
+		ID:          "t-1",
+		Description: "Update internal/agent/loop.go and docs/missing.md.",
+	})
+	for _, needle := range []string{
+		"### README.md\n# Project\nUse make test.",
+		"### CONTRIBUTING.md\nRun gofmt.",
+		"### Recent Commits\n- Fix scheduler\n- Add dry-run plan",
+		"### Touched Areas\ninternal/agent/\n  loop.go\n  sub/\n    x.go",
+	} {
+		if !strings.Contains(got, needle) {
+			t.Fatalf("expected context to include %q, got %q", needle, got)
+		}
+	}
+	if strings.Contains(got, "docs/missing.md") {
+		t.Fatalf("did not expect missing paths in touched areas, got %q", got)
+	}
+
+	builder.BuildPromptContext(context.Background(), contracts.Task{ID: "t-2"})
+	if gitCalls != 1 {
+		t.Fatalf("expected repo-wide context to be cached per run, got %d git calls", gitCalls)
+	}
+}
+
+func TestBuildPromptContextKeepsDotDirectories(t *testing.T) {
+	root := t.TempDir()
+	writeRepoFile(t, root, ".github/workflows/ci.yml", "on: push")
+	writeRepoFile(t, root, "github/workflows/other.yml", "on: push")
+
+	builder := New(root, Options{Files: []string{}})
+	got := builder.BuildPromptContext(context.Background(), contracts.Task{
+		ID:          "t-1",
+		Description: "Fix ./.github/workflows/ci.yml.",
+	})
+	if !strings.Contains(got, "### Touched Areas\n.github/workflows/\n  ci.yml") || strings.Contains(got, "other.yml") {
+		t.Fatalf("expected .github/workflows in touched areas, got %q", got)
+	}
+}
+
+func TestBuildPromptContextIgnoresFirstCallerCancellation(t *testing.T) {
+	root := t.TempDir()
+	builder := New(root, Options{Files: []string{}, RecentCommits: 1})
+	builder.runGit = func(ctx context.Context, _ string, _ ...string) (string, error) {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		return "Fix scheduler\n", nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	builder.BuildPromptContext(ctx, contracts.Task{ID: "t-1"})
+	if got := builder.BuildPromptContext(context.Background(), contracts.Task{ID: "t-2"}); !strings.Contains(got, "- Fix scheduler") {
+		t.Fatalf("expected commit history despite the first caller's cancellation, got %q", got)
+	}
+}
+
+func TestBuildPromptContextRespectsSizeBudget(t *testing.T) {
+	root := t.TempDir()
+	writeRepoFile(t, root, "README.md", strings.Repeat("r", 500))
+	writeRepoFile(t, root, "CONTRIBUTING.md", strings.Repeat("c", 500))
+
+	builder := New(root, Options{MaxBytes: 300, FileMaxBytes: 200})
+	builder.runGit = func(context.Context, string, ...string) (string, error) {
+		return "", errors.New("not a git repository")
+	}
+
+	got := builder.BuildPromptContext(context.Background(), contracts.Task{ID: "t-1"})
+	if len(got) > 300 {
+		t.Fatalf("expected context within 300 bytes, got %d", len(got))
+	}
+	if !strings.Contains(got, "### README.md") || !strings.Contains(got, truncatedMark) {
+		t.Fatalf("expected truncated README excerpt, got %q", got)
+	}
+	if strings.Contains(got, "Recent Commits") {
+		t.Fatalf("expected git failure to drop commit section, got %q", got)
+	}
+}
+
+func TestBuildPromptContextEmptyWithoutRepoRoot(t *testing.T) {
+	if got := New("", Options{}).BuildPromptContext(context.Background(), contracts.Task{ID: "t-1"}); got != "" {
+		t.Fatalf("expected empty context, got %q", got)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package retention
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processRunning reports whether a process with pid exists. EPERM means it
+// exists but belongs to another user.
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
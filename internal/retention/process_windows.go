@@ -0,0 +1,16 @@
+//go:build windows
+
+package retention
+
+import "os"
+
+// processRunning reports whether a process with pid exists; on Windows
+// FindProcess fails for one that does not.
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = process.Release()
+	return true
+}
@@ -0,0 +1,338 @@
+// Package retention caps the disk space taken by yolo-agent's runner logs,
+// task clones and collected artifacts, deleting the least recently modified
+// entries first.
+package retention
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMinAge protects entries modified this recently, which may belong to
+// a run that is still going.
+const DefaultMinAge = time.Hour
+
+// Policy caps one area. Zero values mean no cap.
+type Policy struct {
+	MaxBytes int64
+	MaxAge   time.Duration
+}
+
+func (p Policy) enabled() bool {
+	return p.MaxBytes > 0 || p.MaxAge > 0
+}
+
+// Config holds the policy of every area.
+type Config struct {
+	RunnerLogs Policy
+	Clones     Policy
+	Artifacts  Policy
+	// MinAge overrides DefaultMinAge when set.
+	MinAge time.Duration
+}
+
+// MinAgeOrDefault is MinAge, or DefaultMinAge when it is not set.
+func (c Config) MinAgeOrDefault() time.Duration {
+	if c.MinAge > 0 {
+		return c.MinAge
+	}
+	return DefaultMinAge
+}
+
+// Enabled reports whether any area has a cap.
+func (c Config) Enabled() bool {
+	return c.RunnerLogs.enabled() || c.Clones.enabled() || c.Artifacts.enabled()
+}
+
+// Areas lists the areas of the repository at repoRoot with their policies.
+func (c Config) Areas(repoRoot string) []Area {
+	return []Area{
+		RunnerLogsArea(repoRoot, c.RunnerLogs),
+		ClonesArea(repoRoot, c.Clones),
+		ArtifactsArea(repoRoot, c.Artifacts),
+	}
+}
+
+// Entry is one unit of deletion: a log file or a task's directory. ModTime
+// is the latest modification anywhere in it.
+type Entry struct {
+	Path    string
+	Bytes   int64
+	ModTime time.Time
+}
+
+// Area is a directory whose entries retention manages.
+type Area struct {
+	Name   string
+	Root   string
+	Policy Policy
+	list   func(root string) ([]Entry, error)
+	// removeEmptyParents removes directories left empty by deleted files.
+	removeEmptyParents bool
+}
+
+// RunnerLogsArea covers the files under runner-logs/, one entry per file.
+// The events log and its rotated segments, which agent.event_log rotates,
+// the monitor snapshot and the artifacts directory are left out.
+func RunnerLogsArea(repoRoot string, policy Policy) Area {
+	return Area{Name: "runner-logs", Root: filepath.Join(repoRoot, "runner-logs"), Policy: policy, list: listRunnerLogs, removeEmptyParents: true}
+}
+
+// ClonesArea covers the task clones under .yolo-runner/clones/, one entry
+// per task. The clone pool's and the overlay strategy's own directories,
+// which start with a dot, and the clones of runs that MarkRunLive marked
+// are left to the run that owns them.
+func ClonesArea(repoRoot string, policy Policy) Area {
+	return Area{Name: "clones", Root: clonesRoot(repoRoot), Policy: policy, list: listClones}
+}
+
+func clonesRoot(repoRoot string) string {
+	return filepath.Join(repoRoot, ".yolo-runner", "clones")
+}
+
+// runsDir holds a pid file per live run, named after its run ID, next to
+// the clones.
+func runsDir(clonesRoot string) string {
+	return filepath.Join(clonesRoot, ".runs")
+}
+
+// MarkRunLive records that this process runs runID, whose clones under
+// repoRoot are named <task>@<runID>, so retention leaves them alone for as
+// long as the process lives. The returned func removes the mark.
+func MarkRunLive(repoRoot string, runID string) (func(), error) {
+	dir := runsDir(clonesRoot(repoRoot))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, runID+".pid")
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		return nil, err
+	}
+	return func() { _ = os.Remove(path) }, nil
+}
+
+// ArtifactsArea covers runner-logs/artifacts/, one entry per task.
+func ArtifactsArea(repoRoot string, policy Policy) Area {
+	return Area{Name: "artifacts", Root: filepath.Join(repoRoot, "runner-logs", "artifacts"), Policy: policy, list: listTaskDirs}
+}
+
+// Options controls a Clean.
+type Options struct {
+	Now time.Time
+	// MinAge protects entries modified within it.
+	MinAge time.Duration
+	// All removes every entry older than MinAge regardless of the policy.
+	All bool
+	// DryRun reports what would be removed without removing it.
+	DryRun bool
+}
+
+// Result reports what Clean found and removed in one area.
+type Result struct {
+	Area    string
+	Entries int
+	Bytes   int64
+	Removed []Entry
+	Freed   int64
+}
+
+// Clean removes the area's least recently modified entries until it is
+// within its size cap, plus every entry older than its max age. Entries
+// modified within MinAge are kept even when the area stays over its cap.
+// A failed removal does not stop the others; the errors are returned
+// together.
+func Clean(area Area, options Options) (Result, error) {
+	result := Result{Area: area.Name}
+	entries, err := area.list(area.Root)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return result, nil
+		}
+		return result, err
+	}
+	now := options.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ModTime.Before(entries[j].ModTime) })
+	result.Entries = len(entries)
+	for _, entry := range entries {
+		result.Bytes += entry.Bytes
+	}
+
+	remaining := result.Bytes
+	var errs []error
+	for _, entry := range entries {
+		age := now.Sub(entry.ModTime)
+		if age < options.MinAge {
+			continue
+		}
+		remove := options.All ||
+			(area.Policy.MaxAge > 0 && age > area.Policy.MaxAge) ||
+			(area.Policy.MaxBytes > 0 && remaining > area.Policy.MaxBytes)
+		if !remove {
+			continue
+		}
+		if !options.DryRun {
+			if err := os.RemoveAll(entry.Path); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		remaining -= entry.Bytes
+		result.Removed = append(result.Removed, entry)
+		result.Freed += entry.Bytes
+	}
+	if area.removeEmptyParents && !options.DryRun {
+		for _, entry := range result.Removed {
+			removeEmptyParents(area.Root, entry.Path)
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+func listRunnerLogs(root string) ([]Entry, error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, err
+	}
+	entries := []Entry{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return ignoreVanished(err)
+		}
+		if d.IsDir() {
+			if path != root && filepath.Dir(path) == root && d.Name() == "artifacts" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Dir(path) == root && isRunManagedLog(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return ignoreVanished(err)
+		}
+		entries = append(entries, Entry{Path: path, Bytes: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	return entries, err
+}
+
+func isRunManagedLog(name string) bool {
+	return strings.HasPrefix(name, "agent.events.jsonl") || name == "monitor-snapshot.json"
+}
+
+func listTaskDirs(root string) ([]Entry, error) {
+	children, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	entries := []Entry{}
+	for _, child := range children {
+		if strings.HasPrefix(child.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(root, child.Name())
+		entry, err := measure(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// listClones lists the task clones, leaving out those of live runs.
+func listClones(root string) ([]Entry, error) {
+	entries, err := listTaskDirs(root)
+	if err != nil {
+		return nil, err
+	}
+	live := map[string]bool{}
+	kept := entries[:0]
+	for _, entry := range entries {
+		name := filepath.Base(entry.Path)
+		at := strings.LastIndex(name, "@")
+		if at < 0 {
+			kept = append(kept, entry)
+			continue
+		}
+		runID := name[at+1:]
+		alive, checked := live[runID]
+		if !checked {
+			alive = runLive(root, runID)
+			live[runID] = alive
+		}
+		if !alive {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, nil
+}
+
+// runLive reports whether the process that marked runID is still running.
+func runLive(clonesRoot string, runID string) bool {
+	content, err := os.ReadFile(filepath.Join(runsDir(clonesRoot), runID+".pid"))
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil || pid <= 0 {
+		return false
+	}
+	return processRunning(pid)
+}
+
+// measure sums the sizes under path without following symlinks and finds
+// the latest modification.
+func measure(path string) (Entry, error) {
+	entry := Entry{Path: path}
+	err := filepath.WalkDir(path, func(walked string, d fs.DirEntry, err error) error {
+		if err != nil && walked != path {
+			return ignoreVanished(err)
+		}
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return ignoreVanished(err)
+		}
+		if !d.IsDir() {
+			entry.Bytes += info.Size()
+		}
+		if info.ModTime().After(entry.ModTime) {
+			entry.ModTime = info.ModTime()
+		}
+		return nil
+	})
+	return entry, err
+}
+
+// ignoreVanished skips files a running task removed during the walk.
+func ignoreVanished(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// removeEmptyParents removes the directories between root and path that
+// are now empty, keeping root itself.
+func removeEmptyParents(root string, path string) {
+	for dir := filepath.Dir(path); dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
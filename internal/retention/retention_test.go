@@ -0,0 +1,192 @@
+package retention
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestCleanRemovesLeastRecentlyModifiedClonesUntilUnderCap(t *testing.T) {
+	repoRoot := t.TempDir()
+	clones := filepath.Join(repoRoot, ".yolo-runner", "clones")
+	writeTaskDir(t, clones, "t-old", 100, testNow.Add(-3*time.Hour))
+	writeTaskDir(t, clones, "t-mid", 100, testNow.Add(-2*time.Hour))
+	writeTaskDir(t, clones, "t-new", 100, testNow.Add(-90*time.Minute))
+
+	result, err := Clean(ClonesArea(repoRoot, Policy{MaxBytes: 150}), Options{Now: testNow})
+	if err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if result.Entries != 3 || result.Bytes != 300 || result.Freed != 200 || len(result.Removed) != 2 {
+		t.Fatalf("unexpected result %#v", result)
+	}
+	assertExists(t, filepath.Join(clones, "t-new"), true)
+	assertExists(t, filepath.Join(clones, "t-mid"), false)
+	assertExists(t, filepath.Join(clones, "t-old"), false)
+}
+
+func TestCleanRemovesEntriesOlderThanMaxAge(t *testing.T) {
+	repoRoot := t.TempDir()
+	artifacts := filepath.Join(repoRoot, "runner-logs", "artifacts")
+	writeTaskDir(t, artifacts, "t-old", 10, testNow.Add(-10*24*time.Hour))
+	writeTaskDir(t, artifacts, "t-new", 10, testNow.Add(-2*time.Hour))
+
+	result, err := Clean(ArtifactsArea(repoRoot, Policy{MaxAge: 7 * 24 * time.Hour}), Options{Now: testNow})
+	if err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if len(result.Removed) != 1 || result.Removed[0].Path != filepath.Join(artifacts, "t-old") {
+		t.Fatalf("expected only the old artifacts removed, got %#v", result.Removed)
+	}
+	assertExists(t, filepath.Join(artifacts, "t-new"), true)
+}
+
+func TestCleanKeepsRecentlyModifiedEntriesOverCap(t *testing.T) {
+	repoRoot := t.TempDir()
+	clones := filepath.Join(repoRoot, ".yolo-runner", "clones")
+	writeTaskDir(t, clones, "t-running", 100, testNow.Add(-10*time.Minute))
+	writeTaskDir(t, clones, ".pool", 100, testNow.Add(-48*time.Hour))
+
+	result, err := Clean(ClonesArea(repoRoot, Policy{MaxBytes: 1}), Options{Now: testNow, MinAge: DefaultMinAge, All: true})
+	if err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if len(result.Removed) != 0 || result.Entries != 1 {
+		t.Fatalf("expected the running clone kept and the pool skipped, got %#v", result)
+	}
+	assertExists(t, filepath.Join(clones, ".pool"), true)
+}
+
+func TestCleanKeepsClonesOfLiveRuns(t *testing.T) {
+	repoRoot := t.TempDir()
+	clones := filepath.Join(repoRoot, ".yolo-runner", "clones")
+	old := testNow.Add(-48 * time.Hour)
+	writeTaskDir(t, clones, "t-1@run-live", 100, old)
+	writeTaskDir(t, clones, "t-2@run-dead", 100, old)
+	writeTaskDir(t, clones, "t-3", 100, old)
+	unmark, err := MarkRunLive(repoRoot, "run-live")
+	if err != nil {
+		t.Fatalf("mark run live: %v", err)
+	}
+	// A pid file left by a run that crashed.
+	exited := exec.Command(os.Args[0], "-test.run=^$")
+	if err := exited.Run(); err != nil {
+		t.Fatalf("run exited process: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(clones, ".runs", "run-dead.pid"), []byte(strconv.Itoa(exited.Process.Pid)+"\n"), 0o644); err != nil {
+		t.Fatalf("write pid file: %v", err)
+	}
+
+	result, err := Clean(ClonesArea(repoRoot, Policy{}), Options{Now: testNow, All: true})
+	if err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if result.Entries != 2 || len(result.Removed) != 2 {
+		t.Fatalf("expected only the clones of runs that are gone removed, got %#v", result)
+	}
+	assertExists(t, filepath.Join(clones, "t-1@run-live"), true)
+	assertExists(t, filepath.Join(clones, "t-2@run-dead"), false)
+
+	unmark()
+	writeTaskDir(t, clones, "t-1@run-live", 100, old)
+	if result, err := Clean(ClonesArea(repoRoot, Policy{}), Options{Now: testNow, All: true}); err != nil || len(result.Removed) != 1 {
+		t.Fatalf("expected the clone removed once its run ended, got %#v, %v", result, err)
+	}
+}
+
+func TestCleanRunnerLogsSkipsRunManagedFilesAndRemovesEmptyDirs(t *testing.T) {
+	repoRoot := t.TempDir()
+	logs := filepath.Join(repoRoot, "runner-logs")
+	old := testNow.Add(-48 * time.Hour)
+	taskLog := filepath.Join(logs, "epic-1", "t-1", "codex", "t-1.jsonl")
+	for _, path := range []string{
+		taskLog,
+		filepath.Join(logs, "agent.events.jsonl"),
+		filepath.Join(logs, "agent.events.jsonl.20260220T000000Z.gz"),
+		filepath.Join(logs, "monitor-snapshot.json"),
+		filepath.Join(logs, "artifacts", "t-1", "report.txt"),
+	} {
+		writeAgedFile(t, path, 10, old)
+	}
+
+	result, err := Clean(RunnerLogsArea(repoRoot, Policy{}), Options{Now: testNow, All: true})
+	if err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if len(result.Removed) != 1 || result.Removed[0].Path != taskLog {
+		t.Fatalf("expected only the task log removed, got %#v", result.Removed)
+	}
+	assertExists(t, filepath.Join(logs, "epic-1"), false)
+	assertExists(t, filepath.Join(logs, "agent.events.jsonl"), true)
+	assertExists(t, filepath.Join(logs, "artifacts", "t-1", "report.txt"), true)
+}
+
+func TestCleanDryRunKeepsFiles(t *testing.T) {
+	repoRoot := t.TempDir()
+	clones := filepath.Join(repoRoot, ".yolo-runner", "clones")
+	writeTaskDir(t, clones, "t-1", 100, testNow.Add(-48*time.Hour))
+
+	result, err := Clean(ClonesArea(repoRoot, Policy{}), Options{Now: testNow, All: true, DryRun: true})
+	if err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if result.Freed != 100 {
+		t.Fatalf("expected dry run to report 100 bytes, got %#v", result)
+	}
+	assertExists(t, filepath.Join(clones, "t-1"), true)
+}
+
+func TestCleanIgnoresMissingArea(t *testing.T) {
+	result, err := Clean(ClonesArea(t.TempDir(), Policy{MaxBytes: 1}), Options{Now: testNow})
+	if err != nil || result.Entries != 0 {
+		t.Fatalf("expected an empty result for a missing area, got %#v, %v", result, err)
+	}
+}
+
+func TestConfigEnabled(t *testing.T) {
+	if (Config{MinAge: time.Minute}).Enabled() {
+		t.Fatalf("expected no caps to be disabled")
+	}
+	if !(Config{Clones: Policy{MaxBytes: 1}}).Enabled() {
+		t.Fatalf("expected a clones cap to enable retention")
+	}
+	if got := (Config{}).MinAgeOrDefault(); got != DefaultMinAge {
+		t.Fatalf("expected default min age, got %s", got)
+	}
+}
+
+func writeTaskDir(t *testing.T, root string, name string, size int, modTime time.Time) {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	writeAgedFile(t, filepath.Join(dir, "file.txt"), size, modTime)
+	if err := os.Chtimes(dir, modTime, modTime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+}
+
+func writeAgedFile(t *testing.T, path string, size int, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+}
+
+func assertExists(t *testing.T, path string, expected bool) {
+	t.Helper()
+	_, err := os.Stat(path)
+	if exists := err == nil; exists != expected {
+		t.Fatalf("expected %s exists=%v, got err=%v", path, expected, err)
+	}
+}
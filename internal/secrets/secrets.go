@@ -0,0 +1,162 @@
+// Package secrets resolves secret references in config values that would
+// otherwise name an environment variable, so tokens can live in a secrets
+// manager instead of the environment of a shared runner. References are
+// read with the provider's CLI, which brings its own login, and cached in
+// memory for the life of the process. Values are never written anywhere.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported reference schemes.
+const (
+	// SchemeVault reads `vault:<path>#<field>` with `vault kv get`.
+	SchemeVault = "vault"
+	// SchemeAWSSecretsManager reads `aws-sm:<secret-id>[#<json-key>]` with
+	// `aws secretsmanager get-secret-value`. With a key, the secret string
+	// is decoded as a JSON object and the key's value is returned.
+	SchemeAWSSecretsManager = "aws-sm"
+	// SchemeOnePassword reads `op://<vault>/<item>/<field>` with `op read`.
+	SchemeOnePassword = "op"
+)
+
+// DefaultTimeout bounds a single provider CLI call.
+const DefaultTimeout = 30 * time.Second
+
+// CommandFunc runs a provider CLI and returns its stdout.
+type CommandFunc func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// IsReference reports whether value is a secret reference rather than the
+// name of an environment variable.
+func IsReference(value string) bool {
+	scheme, _, ok := strings.Cut(strings.TrimSpace(value), ":")
+	if !ok {
+		return false
+	}
+	switch scheme {
+	case SchemeVault, SchemeAWSSecretsManager, SchemeOnePassword:
+		return true
+	}
+	return false
+}
+
+// Resolver resolves secret references and caches the values.
+type Resolver struct {
+	run     CommandFunc
+	timeout time.Duration
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewResolver returns a Resolver that runs the provider CLIs from PATH.
+func NewResolver() *Resolver {
+	return NewResolverWithCommand(runCommand)
+}
+
+// NewResolverWithCommand returns a Resolver that runs provider CLIs with run.
+func NewResolverWithCommand(run CommandFunc) *Resolver {
+	return &Resolver{run: run, timeout: DefaultTimeout, cache: map[string]string{}}
+}
+
+// Resolve returns the value of a secret reference. A value that was already
+// resolved is served from the cache. Errors never include the value.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if value, ok := r.cache[ref]; ok {
+		return value, nil
+	}
+	name, args, field, err := providerCommand(ref)
+	if err != nil {
+		return "", err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	runCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	output, err := r.run(runCtx, name, args...)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve %s: %s failed: %w", ref, name, err)
+	}
+	value := strings.TrimRight(string(output), "\r\n")
+	if field != "" {
+		value, err = jsonField(value, field)
+		if err != nil {
+			return "", fmt.Errorf("cannot resolve %s: %w", ref, err)
+		}
+	}
+	if strings.TrimSpace(value) == "" {
+		return "", fmt.Errorf("cannot resolve %s: the secret is empty", ref)
+	}
+	r.cache[ref] = value
+	return value, nil
+}
+
+// providerCommand returns the CLI call that reads ref and, for aws-sm, the
+// JSON key to pick from its output.
+func providerCommand(ref string) (name string, args []string, field string, err error) {
+	scheme, rest, _ := strings.Cut(ref, ":")
+	switch scheme {
+	case SchemeVault:
+		path, key, ok := strings.Cut(rest, "#")
+		if strings.TrimSpace(path) == "" || !ok || strings.TrimSpace(key) == "" {
+			return "", nil, "", fmt.Errorf("invalid secret reference %q: want vault:<path>#<field>", ref)
+		}
+		return "vault", []string{"kv", "get", "-field=" + key, path}, "", nil
+	case SchemeAWSSecretsManager:
+		secretID, key, _ := strings.Cut(rest, "#")
+		if strings.TrimSpace(secretID) == "" {
+			return "", nil, "", fmt.Errorf("invalid secret reference %q: want aws-sm:<secret-id>[#<json-key>]", ref)
+		}
+		return "aws", []string{"secretsmanager", "get-secret-value", "--secret-id", secretID, "--query", "SecretString", "--output", "text"}, key, nil
+	case SchemeOnePassword:
+		if !strings.HasPrefix(rest, "//") || strings.Count(strings.Trim(rest, "/"), "/") < 2 {
+			return "", nil, "", fmt.Errorf("invalid secret reference %q: want op://<vault>/<item>/<field>", ref)
+		}
+		return "op", []string{"read", "--no-newline", ref}, "", nil
+	}
+	return "", nil, "", fmt.Errorf("unsupported secret reference %q", ref)
+}
+
+func jsonField(secretString string, key string) (string, error) {
+	values := map[string]any{}
+	if err := json.Unmarshal([]byte(secretString), &values); err != nil {
+		return "", fmt.Errorf("the secret is not a JSON object, so key %q cannot be read", key)
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", fmt.Errorf("the secret has no key %q", key)
+	}
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// runCommand runs a provider CLI. A failure carries the last line of its
+// stderr, which is where the CLIs explain a failed login or lookup.
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+			return nil, fmt.Errorf("%w: %s", err, last)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
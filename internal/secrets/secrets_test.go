@@ -0,0 +1,118 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type recordedCall struct {
+	name string
+	args []string
+}
+
+func fakeCommand(calls *[]recordedCall, output string, err error) CommandFunc {
+	return func(_ context.Context, name string, args ...string) ([]byte, error) {
+		*calls = append(*calls, recordedCall{name: name, args: args})
+		return []byte(output), err
+	}
+}
+
+func TestIsReference(t *testing.T) {
+	for value, want := range map[string]bool{
+		"LINEAR_TOKEN":                 false,
+		"vault:secret/yolo#token":      true,
+		"aws-sm:prod/yolo":             true,
+		"op://Shared/Linear/api-token": true,
+		"https://example.com":          false,
+		"":                             false,
+	} {
+		if got := IsReference(value); got != want {
+			t.Fatalf("IsReference(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestResolverRunsProviderCLIs(t *testing.T) {
+	cases := []struct {
+		ref    string
+		output string
+		want   string
+		call   recordedCall
+	}{
+		{
+			ref:    "vault:secret/yolo#linear_token",
+			output: "lin-123\n",
+			want:   "lin-123",
+			call:   recordedCall{name: "vault", args: []string{"kv", "get", "-field=linear_token", "secret/yolo"}},
+		},
+		{
+			ref:    "aws-sm:prod/yolo#github",
+			output: `{"github":"ghp-456","linear":"lin-123"}` + "\n",
+			want:   "ghp-456",
+			call:   recordedCall{name: "aws", args: []string{"secretsmanager", "get-secret-value", "--secret-id", "prod/yolo", "--query", "SecretString", "--output", "text"}},
+		},
+		{
+			ref:    "op://Shared/Linear/api-token",
+			output: "lin-789",
+			want:   "lin-789",
+			call:   recordedCall{name: "op", args: []string{"read", "--no-newline", "op://Shared/Linear/api-token"}},
+		},
+	}
+	for _, tc := range cases {
+		calls := []recordedCall{}
+		resolver := NewResolverWithCommand(fakeCommand(&calls, tc.output, nil))
+		got, err := resolver.Resolve(context.Background(), tc.ref)
+		if err != nil {
+			t.Fatalf("Resolve(%q): %v", tc.ref, err)
+		}
+		if got != tc.want {
+			t.Fatalf("Resolve(%q) = %q, want %q", tc.ref, got, tc.want)
+		}
+		if len(calls) != 1 || !reflect.DeepEqual(calls[0], tc.call) {
+			t.Fatalf("Resolve(%q) ran %#v, want %#v", tc.ref, calls, tc.call)
+		}
+	}
+}
+
+func TestResolverCachesValues(t *testing.T) {
+	calls := []recordedCall{}
+	resolver := NewResolverWithCommand(fakeCommand(&calls, "lin-123", nil))
+	for i := 0; i < 2; i++ {
+		if _, err := resolver.Resolve(context.Background(), "vault:secret/yolo#token"); err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+	}
+	if len(calls) != 1 {
+		t.Fatalf("expected one CLI call, got %d", len(calls))
+	}
+}
+
+func TestResolverErrorsNeverIncludeTheSecret(t *testing.T) {
+	calls := []recordedCall{}
+	resolver := NewResolverWithCommand(fakeCommand(&calls, `{"linear":"lin-123"}`, nil))
+	_, err := resolver.Resolve(context.Background(), "aws-sm:prod/yolo#github")
+	if err == nil || !strings.Contains(err.Error(), `has no key "github"`) || strings.Contains(err.Error(), "lin-123") {
+		t.Fatalf("expected missing key error without the secret, got %v", err)
+	}
+
+	resolver = NewResolverWithCommand(fakeCommand(&calls, "", errors.New("exit status 2: permission denied")))
+	_, err = resolver.Resolve(context.Background(), "vault:secret/yolo#token")
+	if err == nil || !strings.Contains(err.Error(), "vault failed: exit status 2: permission denied") {
+		t.Fatalf("expected CLI failure, got %v", err)
+	}
+}
+
+func TestResolverRejectsMalformedReferences(t *testing.T) {
+	resolver := NewResolverWithCommand(func(context.Context, string, ...string) ([]byte, error) {
+		t.Fatalf("expected no CLI call for a malformed reference")
+		return nil, nil
+	})
+	for _, ref := range []string{"vault:secret/yolo", "aws-sm:", "op:Shared/Linear"} {
+		if _, err := resolver.Resolve(context.Background(), ref); err == nil || !strings.Contains(err.Error(), "invalid secret reference") {
+			t.Fatalf("Resolve(%q): expected invalid reference error, got %v", ref, err)
+		}
+	}
+}
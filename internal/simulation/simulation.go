@@ -0,0 +1,309 @@
+// Package simulation generates synthetic task graphs and a runner that
+// pretends to work on them, so the scheduler, event pipeline and TUI can be
+// load-tested at scale without a tracker or a model backend.
+//
+// Everything is derived from Config.Seed: the same config produces the same
+// graph, and each task's durations and outcomes depend only on the seed, the
+// task and the attempt, not on which worker picks it up when.
+package simulation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// RootID is the ID of the generated root task.
+const RootID = "sim-root"
+
+const maxDepth = 10
+
+// Config shapes the generated graph and the simulated runs.
+type Config struct {
+	// Tasks is the number of runnable leaf tasks.
+	Tasks int
+	// Depth is the number of levels below the root; levels above the
+	// leaves are epics. 1 puts every task directly under the root.
+	Depth int
+	// DependencyRate is the chance that a task depends on an earlier task.
+	DependencyRate float64
+	// FailureRate and BlockRate are the chances that an implement run
+	// fails or blocks.
+	FailureRate float64
+	BlockRate   float64
+	// ReviewFailRate is the chance that a review returns a fail verdict.
+	ReviewFailRate float64
+	// MinDuration and MaxDuration bound how long each run takes.
+	MinDuration time.Duration
+	MaxDuration time.Duration
+	// OutputLines is how many runner_output updates each run emits.
+	OutputLines int
+	Seed        int64
+}
+
+// DefaultConfig is 1000 tasks three levels deep with a few failures.
+func DefaultConfig() Config {
+	return Config{
+		Tasks:          1000,
+		Depth:          3,
+		DependencyRate: 0.2,
+		FailureRate:    0.02,
+		BlockRate:      0.02,
+		ReviewFailRate: 0.05,
+		MinDuration:    200 * time.Millisecond,
+		MaxDuration:    2 * time.Second,
+		OutputLines:    5,
+		Seed:           1,
+	}
+}
+
+// Validate rejects configs that cannot generate a graph.
+func (c Config) Validate() error {
+	switch {
+	case c.Tasks <= 0:
+		return errors.New("simulation tasks must be greater than 0")
+	case c.Depth <= 0 || c.Depth > maxDepth:
+		return fmt.Errorf("simulation depth must be between 1 and %d", maxDepth)
+	case c.MinDuration < 0 || c.MaxDuration < c.MinDuration:
+		return fmt.Errorf("simulation durations must satisfy 0 <= min (%s) <= max (%s)", c.MinDuration, c.MaxDuration)
+	case c.OutputLines < 0:
+		return errors.New("simulation output lines must not be negative")
+	}
+	for name, rate := range map[string]float64{
+		"dependency rate":  c.DependencyRate,
+		"failure rate":     c.FailureRate,
+		"block rate":       c.BlockRate,
+		"review fail rate": c.ReviewFailRate,
+	} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("simulation %s must be between 0 and 1, got %g", name, rate)
+		}
+	}
+	if c.FailureRate+c.BlockRate > 1 {
+		return errors.New("simulation failure rate plus block rate must not exceed 1")
+	}
+	return nil
+}
+
+// Storage is an in-memory tracker holding a generated task graph. Status and
+// data writes are kept in memory.
+type Storage struct {
+	mu        sync.Mutex
+	tasks     map[string]contracts.Task
+	relations []contracts.TaskRelation
+}
+
+// NewStorage generates the graph described by cfg.
+func NewStorage(cfg Config) (*Storage, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	random := rand.New(rand.NewSource(cfg.Seed))
+	storage := &Storage{tasks: map[string]contracts.Task{
+		RootID: {ID: RootID, Title: "Simulated run", Status: contracts.TaskStatusOpen},
+	}}
+	// Each level above the leaves splits the tasks into about the same
+	// number of groups, so every epic has a similar fan-out.
+	fanOut := 1
+	for fanOut < cfg.Tasks && pow(fanOut, cfg.Depth) < cfg.Tasks {
+		fanOut++
+	}
+	for i := 0; i < cfg.Tasks; i++ {
+		parentID := RootID
+		for level := 1; level < cfg.Depth; level++ {
+			group := i / pow(fanOut, cfg.Depth-level)
+			epicID := fmt.Sprintf("sim-e%d-%d", level, group)
+			if _, ok := storage.tasks[epicID]; !ok {
+				storage.add(contracts.Task{ID: epicID, Title: fmt.Sprintf("Simulated epic %d.%d", level, group), Status: contracts.TaskStatusOpen, ParentID: parentID})
+			}
+			parentID = epicID
+		}
+		taskID := fmt.Sprintf("sim-%d", i)
+		storage.add(contracts.Task{ID: taskID, Title: fmt.Sprintf("Simulated task %d", i), Status: contracts.TaskStatusOpen, ParentID: parentID})
+		if i > 0 && random.Float64() < cfg.DependencyRate {
+			dependency := fmt.Sprintf("sim-%d", random.Intn(i))
+			storage.relations = append(storage.relations, contracts.TaskRelation{FromID: taskID, ToID: dependency, Type: contracts.RelationDependsOn})
+		}
+	}
+	return storage, nil
+}
+
+func (s *Storage) add(task contracts.Task) {
+	s.tasks[task.ID] = task
+	s.relations = append(s.relations, contracts.TaskRelation{FromID: task.ParentID, ToID: task.ID, Type: contracts.RelationParent})
+}
+
+func (s *Storage) GetTaskTree(_ context.Context, rootID string) (*contracts.TaskTree, error) {
+	if rootID != RootID {
+		return nil, fmt.Errorf("simulated task tree has root %q, not %q", RootID, rootID)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tree := &contracts.TaskTree{
+		Root:      s.tasks[RootID],
+		Tasks:     make(map[string]contracts.Task, len(s.tasks)),
+		Relations: append([]contracts.TaskRelation(nil), s.relations...),
+	}
+	for id, task := range s.tasks {
+		tree.Tasks[id] = copyTask(task)
+	}
+	return tree, nil
+}
+
+func (s *Storage) GetTask(_ context.Context, taskID string) (*contracts.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[taskID]
+	if !ok {
+		return nil, fmt.Errorf("task %q not found", taskID)
+	}
+	task = copyTask(task)
+	return &task, nil
+}
+
+func (s *Storage) SetTaskStatus(_ context.Context, taskID string, status contracts.TaskStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[taskID]
+	if !ok {
+		return fmt.Errorf("task %q not found", taskID)
+	}
+	task.Status = status
+	s.tasks[taskID] = task
+	return nil
+}
+
+func (s *Storage) SetTaskData(_ context.Context, taskID string, data map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[taskID]
+	if !ok {
+		return fmt.Errorf("task %q not found", taskID)
+	}
+	task = copyTask(task)
+	if task.Metadata == nil {
+		task.Metadata = map[string]string{}
+	}
+	for key, value := range data {
+		task.Metadata[key] = value
+	}
+	s.tasks[taskID] = task
+	return nil
+}
+
+// Runner pretends to implement and review simulated tasks.
+type Runner struct {
+	cfg   Config
+	sleep func(context.Context, time.Duration) error
+
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+// NewRunner simulates runs with the durations and outcome rates of cfg.
+func NewRunner(cfg Config) *Runner {
+	return &Runner{cfg: cfg, sleep: sleepContext, attempts: map[string]int{}}
+}
+
+func (r *Runner) Run(ctx context.Context, request contracts.RunnerRequest) (contracts.RunnerResult, error) {
+	if r == nil {
+		return contracts.RunnerResult{}, errors.New("nil simulation runner")
+	}
+	r.mu.Lock()
+	key := request.TaskID + "\x00" + string(request.Mode)
+	r.attempts[key]++
+	attempt := r.attempts[key]
+	r.mu.Unlock()
+
+	random := rand.New(rand.NewSource(runSeed(r.cfg.Seed, request.TaskID, request.Mode, attempt)))
+	duration := r.cfg.MinDuration
+	if spread := r.cfg.MaxDuration - r.cfg.MinDuration; spread > 0 {
+		duration += time.Duration(random.Int63n(int64(spread) + 1))
+	}
+	outcome := random.Float64()
+
+	started := time.Now()
+	lines := r.cfg.OutputLines
+	step := duration
+	if lines > 0 {
+		step = duration / time.Duration(lines+1)
+	}
+	for line := 1; line <= lines; line++ {
+		if err := r.sleep(ctx, step); err != nil {
+			return contracts.RunnerResult{}, err
+		}
+		if request.OnProgress != nil {
+			request.OnProgress(contracts.RunnerProgress{
+				Type:      "runner_output",
+				Message:   fmt.Sprintf("simulated %s output %d/%d for %s", request.Mode, line, lines, request.TaskID),
+				Timestamp: time.Now().UTC(),
+			})
+		}
+	}
+	if err := r.sleep(ctx, duration-step*time.Duration(lines)); err != nil {
+		return contracts.RunnerResult{}, err
+	}
+
+	result := contracts.RunnerResult{Status: contracts.RunnerResultCompleted, StartedAt: started, FinishedAt: time.Now()}
+	switch {
+	case request.Mode == contracts.RunnerModeReview:
+		result.ReviewReady = true
+		result.Artifacts = map[string]string{"review_verdict": "pass"}
+		if outcome < r.cfg.ReviewFailRate {
+			result.Artifacts = map[string]string{"review_verdict": "fail", "review_fail_feedback": "simulated review failure"}
+		}
+	case outcome < r.cfg.FailureRate:
+		result.Status = contracts.RunnerResultFailed
+		result.Reason = "simulated failure"
+	case outcome < r.cfg.FailureRate+r.cfg.BlockRate:
+		result.Status = contracts.RunnerResultBlocked
+		result.Reason = "simulated blocker"
+	}
+	return result, nil
+}
+
+// runSeed mixes the run's identity into the config seed.
+func runSeed(seed int64, taskID string, mode contracts.RunnerMode, attempt int) int64 {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%d\x00%s\x00%s\x00%d", seed, taskID, mode, attempt)
+	return int64(hash.Sum64())
+}
+
+func pow(base int, exponent int) int {
+	result := 1
+	for i := 0; i < exponent; i++ {
+		result *= base
+	}
+	return result
+}
+
+func copyTask(task contracts.Task) contracts.Task {
+	if task.Metadata != nil {
+		metadata := make(map[string]string, len(task.Metadata))
+		for key, value := range task.Metadata {
+			metadata[key] = value
+		}
+		task.Metadata = metadata
+	}
+	return task
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,141 @@
+package simulation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/agent"
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/engine"
+)
+
+func TestNewStorageGeneratesSameGraphForSameSeed(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Tasks = 100
+	first, err := NewStorage(cfg)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	second, err := NewStorage(cfg)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	tree, err := first.GetTaskTree(context.Background(), RootID)
+	if err != nil {
+		t.Fatalf("tree: %v", err)
+	}
+	other, _ := second.GetTaskTree(context.Background(), RootID)
+	if len(tree.Relations) != len(other.Relations) {
+		t.Fatalf("expected the same relations for the same seed, got %d and %d", len(tree.Relations), len(other.Relations))
+	}
+	for i := range tree.Relations {
+		if tree.Relations[i] != other.Relations[i] {
+			t.Fatalf("expected the same relations for the same seed, got %#v and %#v", tree.Relations[i], other.Relations[i])
+		}
+	}
+
+	graph, err := engine.NewTaskEngine().BuildGraph(tree)
+	if err != nil {
+		t.Fatalf("build graph: %v", err)
+	}
+	leaves, deepest := 0, 0
+	for _, node := range graph.Nodes {
+		if len(node.Children) == 0 {
+			leaves++
+		}
+		if node.Depth > deepest {
+			deepest = node.Depth
+		}
+	}
+	if leaves != cfg.Tasks || deepest != cfg.Depth {
+		t.Fatalf("expected %d leaves %d levels deep, got %d leaves %d deep", cfg.Tasks, cfg.Depth, leaves, deepest)
+	}
+}
+
+func TestConfigValidateRejectsImpossibleShapes(t *testing.T) {
+	for name, mutate := range map[string]func(*Config){
+		"no tasks":       func(c *Config) { c.Tasks = 0 },
+		"too deep":       func(c *Config) { c.Depth = maxDepth + 1 },
+		"rate above one": func(c *Config) { c.DependencyRate = 1.5 },
+		"rates sum":      func(c *Config) { c.FailureRate, c.BlockRate = 0.6, 0.6 },
+		"min above max":  func(c *Config) { c.MinDuration = 3 * time.Second },
+	} {
+		cfg := DefaultConfig()
+		mutate(&cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Fatalf("%s: expected config to be rejected", name)
+		}
+	}
+}
+
+func TestRunnerOutcomeDependsOnlyOnSeedTaskAndAttempt(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FailureRate = 0.5
+	cfg.OutputLines = 3
+	runs := func() []contracts.RunnerResultStatus {
+		runner := NewRunner(cfg)
+		runner.sleep = func(context.Context, time.Duration) error { return nil }
+		statuses := []contracts.RunnerResultStatus{}
+		for i := 0; i < 20; i++ {
+			lines := 0
+			result, err := runner.Run(context.Background(), contracts.RunnerRequest{
+				TaskID:     "sim-1",
+				Mode:       contracts.RunnerModeImplement,
+				OnProgress: func(contracts.RunnerProgress) { lines++ },
+			})
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if lines != cfg.OutputLines {
+				t.Fatalf("expected %d output lines, got %d", cfg.OutputLines, lines)
+			}
+			statuses = append(statuses, result.Status)
+		}
+		return statuses
+	}
+	first, second := runs(), runs()
+	failed := 0
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("expected attempt %d to repeat its outcome, got %s and %s", i+1, first[i], second[i])
+		}
+		if first[i] == contracts.RunnerResultFailed {
+			failed++
+		}
+	}
+	if failed == 0 || failed == len(first) {
+		t.Fatalf("expected a mix of outcomes at a 50%% failure rate, got %d of %d failed", failed, len(first))
+	}
+}
+
+func TestLoopRunsThousandSimulatedTasksOnThirtyTwoWorkers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("load test")
+	}
+	cfg := DefaultConfig()
+	cfg.MinDuration, cfg.MaxDuration = 0, time.Millisecond
+	cfg.OutputLines = 1
+	// A failed task would strand its dependents and stall the run.
+	cfg.FailureRate, cfg.BlockRate, cfg.ReviewFailRate = 0, 0, 0
+	storage, err := NewStorage(cfg)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	loop := agent.NewLoopWithTaskEngine(storage, engine.NewTaskEngine(), NewRunner(cfg), nil, agent.LoopOptions{
+		ParentID:      RootID,
+		Concurrency:   32,
+		MaxRetries:    1,
+		RepoRoot:      t.TempDir(),
+		RequireReview: true,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	summary, err := loop.Run(ctx)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if summary.Completed != cfg.Tasks {
+		t.Fatalf("expected every simulated task to complete, got %#v", summary)
+	}
+}
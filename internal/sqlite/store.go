@@ -0,0 +1,610 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+
+	_ "modernc.org/sqlite"
+)
+
+const schemaVersion = 1
+
+const schema = `
+CREATE TABLE IF NOT EXISTS tasks (
+	id          TEXT PRIMARY KEY,
+	title       TEXT NOT NULL DEFAULT '',
+	description TEXT NOT NULL DEFAULT '',
+	status      TEXT NOT NULL DEFAULT 'open',
+	parent_id   TEXT NOT NULL DEFAULT '',
+	updated_at  TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS tasks_parent_id ON tasks(parent_id);
+CREATE TABLE IF NOT EXISTS task_relations (
+	from_id TEXT NOT NULL,
+	to_id   TEXT NOT NULL,
+	type    TEXT NOT NULL,
+	PRIMARY KEY (from_id, to_id, type)
+);
+CREATE TABLE IF NOT EXISTS task_metadata (
+	task_id TEXT NOT NULL,
+	key     TEXT NOT NULL,
+	value   TEXT NOT NULL,
+	PRIMARY KEY (task_id, key)
+);
+CREATE TABLE IF NOT EXISTS task_history (
+	seq         INTEGER PRIMARY KEY AUTOINCREMENT,
+	task_id     TEXT NOT NULL,
+	kind        TEXT NOT NULL,
+	payload     TEXT NOT NULL,
+	recorded_at TEXT NOT NULL,
+	synced_at   TEXT
+);
+CREATE INDEX IF NOT EXISTS task_history_pending ON task_history(synced_at, seq);
+`
+
+// ChangeKind identifies a local write recorded in the task history.
+type ChangeKind string
+
+const (
+	ChangeStatus  ChangeKind = "status"
+	ChangeData    ChangeKind = "data"
+	ChangeComment ChangeKind = "comment"
+)
+
+// Change is one recorded local write. Pending changes have a zero SyncedAt.
+type Change struct {
+	Seq        int64
+	TaskID     string
+	Kind       ChangeKind
+	Status     contracts.TaskStatus
+	Data       map[string]string
+	Comment    string
+	RecordedAt time.Time
+	SyncedAt   time.Time
+}
+
+type changePayload struct {
+	Status  contracts.TaskStatus `json:"status,omitempty"`
+	Data    map[string]string    `json:"data,omitempty"`
+	Comment string               `json:"comment,omitempty"`
+}
+
+// Store is a contracts.StorageBackend on a local SQLite database. Status,
+// data and comment writes are recorded in a history table so Sync can replay
+// them on the tracker the tasks were imported from.
+type Store struct {
+	db       *sql.DB
+	now      func() time.Time
+	creator  contracts.TaskCreator
+	reader   contracts.TaskCommentReader
+	assigner contracts.TaskAssigner
+	labeler  contracts.TaskLabeler
+
+	// syncMu serializes Sync so background and final flushes never replay
+	// the same change twice.
+	syncMu sync.Mutex
+}
+
+var _ contracts.StorageBackend = (*Store)(nil)
+var _ contracts.TaskCreator = (*Store)(nil)
+var _ contracts.TaskCommenter = (*Store)(nil)
+var _ contracts.TaskChangeReader = (*Store)(nil)
+var _ contracts.TaskCommentReader = (*Store)(nil)
+
+// Open opens or creates the store at path.
+func Open(path string) (*Store, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, errors.New("sqlite store path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create sqlite store directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite store %s: %w", path, err)
+	}
+	// A single connection keeps writes ordered and avoids SQLITE_BUSY between
+	// the engine and the background syncer.
+	db.SetMaxOpenConns(1)
+	store := &Store{db: db, now: time.Now}
+	if err := store.migrate(context.Background()); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("open sqlite store %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// WithTaskCreator forwards CreateTask to creator, usually the tracker, so new
+// tasks get tracker IDs. Created tasks are added to the store immediately.
+func (s *Store) WithTaskCreator(creator contracts.TaskCreator) *Store {
+	s.creator = creator
+	return s
+}
+
+// WithTaskCommentReader forwards TaskComments to reader, usually the
+// tracker. Comments are not stored.
+func (s *Store) WithTaskCommentReader(reader contracts.TaskCommentReader) *Store {
+	s.reader = reader
+	return s
+}
+
+func (s *Store) TaskComments(ctx context.Context, taskID string, since time.Time) ([]contracts.TaskComment, error) {
+	if s.reader == nil {
+		return nil, errors.New("tracker does not support reading comments")
+	}
+	return s.reader.TaskComments(ctx, taskID, since)
+}
+
+// WithTaskAssigner forwards AssignTask to assigner, usually the tracker.
+// Assignees are not stored.
+func (s *Store) WithTaskAssigner(assigner contracts.TaskAssigner) *Store {
+	s.assigner = assigner
+	return s
+}
+
+func (s *Store) AssignTask(ctx context.Context, taskID string, assignee string) error {
+	if s.assigner == nil {
+		return errors.New("tracker does not support assigning tasks")
+	}
+	return s.assigner.AssignTask(ctx, taskID, assignee)
+}
+
+// WithTaskLabeler forwards AddTaskLabels to labeler, usually the tracker.
+// Labels are not stored.
+func (s *Store) WithTaskLabeler(labeler contracts.TaskLabeler) *Store {
+	s.labeler = labeler
+	return s
+}
+
+func (s *Store) AddTaskLabels(ctx context.Context, taskID string, labels []string) error {
+	if s.labeler == nil {
+		return errors.New("tracker does not support labeling tasks")
+	}
+	return s.labeler.AddTaskLabels(ctx, taskID, labels)
+}
+
+func (s *Store) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (s *Store) migrate(ctx context.Context) error {
+	var version int
+	if err := s.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > schemaVersion {
+		return fmt.Errorf("schema version %d is newer than supported version %d", version, schemaVersion)
+	}
+	if _, err := s.db.ExecContext(ctx, schema); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", schemaVersion))
+	return err
+}
+
+func (s *Store) GetTaskTree(ctx context.Context, rootID string) (*contracts.TaskTree, error) {
+	rootID = strings.TrimSpace(rootID)
+	if rootID == "" {
+		return nil, errors.New("root ID is required")
+	}
+	rows, err := s.db.QueryContext(ctx, `
+WITH RECURSIVE subtree(id) AS (
+	SELECT id FROM tasks WHERE id = ?
+	UNION
+	SELECT tasks.id FROM tasks JOIN subtree ON tasks.parent_id = subtree.id
+)
+SELECT id, title, description, status, parent_id FROM tasks WHERE id IN subtree ORDER BY id`, rootID)
+	if err != nil {
+		return nil, err
+	}
+	tasks := map[string]contracts.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		tasks[task.ID] = task
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+	root, ok := tasks[rootID]
+	if !ok {
+		return nil, fmt.Errorf("task %q not found", rootID)
+	}
+	root.ParentID = ""
+	tasks[rootID] = root
+
+	if err := s.loadMetadata(ctx, tasks); err != nil {
+		return nil, err
+	}
+	stored, err := s.loadRelations(ctx, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := sortedIDs(tasks)
+	relations := []contracts.TaskRelation{}
+	missingByTask := map[string][]string{}
+	missingSeen := map[string]struct{}{}
+	for _, id := range ids {
+		task := tasks[id]
+		if id != rootID && task.ParentID != "" {
+			relations = append(relations, contracts.TaskRelation{FromID: task.ParentID, ToID: id, Type: contracts.RelationParent})
+		}
+	}
+	for _, relation := range stored {
+		if relation.Type == contracts.RelationDependsOn {
+			if _, ok := tasks[relation.ToID]; !ok {
+				missingByTask[relation.FromID] = append(missingByTask[relation.FromID], relation.ToID)
+				missingSeen[relation.ToID] = struct{}{}
+				continue
+			}
+		}
+		if _, ok := tasks[relation.ToID]; !ok {
+			continue
+		}
+		relations = append(relations, relation)
+	}
+	missing := make([]string, 0, len(missingSeen))
+	for id := range missingSeen {
+		missing = append(missing, id)
+	}
+	sort.Strings(missing)
+
+	tree := &contracts.TaskTree{Root: tasks[rootID], Tasks: tasks, Relations: relations}
+	if len(missing) > 0 {
+		tree.MissingDependencyIDs = missing
+		tree.MissingDependenciesByTask = missingByTask
+	}
+	return tree, nil
+}
+
+// TaskChangesSince returns the tasks under rootID updated at or after cursor,
+// a timestamp returned by an earlier call, with their parent relations and
+// the dependencies they declare on other tasks in the tree.
+func (s *Store) TaskChangesSince(ctx context.Context, rootID string, cursor string) (*contracts.TaskChanges, error) {
+	rootID = strings.TrimSpace(rootID)
+	if rootID == "" {
+		return nil, errors.New("root ID is required")
+	}
+	changes := &contracts.TaskChanges{Tasks: map[string]contracts.Task{}, Cursor: s.timestamp()}
+	if strings.TrimSpace(cursor) == "" {
+		return changes, nil
+	}
+	since, err := time.Parse(time.RFC3339Nano, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid change cursor %q: %w", cursor, err)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+WITH RECURSIVE subtree(id) AS (
+	SELECT id FROM tasks WHERE id = ?
+	UNION
+	SELECT tasks.id FROM tasks JOIN subtree ON tasks.parent_id = subtree.id
+)
+SELECT id, title, description, status, parent_id, updated_at FROM tasks WHERE id IN subtree ORDER BY id`, rootID)
+	if err != nil {
+		return nil, err
+	}
+	inTree := map[string]struct{}{}
+	for rows.Next() {
+		var task contracts.Task
+		var status, updatedRaw string
+		if err := rows.Scan(&task.ID, &task.Title, &task.Description, &status, &task.ParentID, &updatedRaw); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		inTree[task.ID] = struct{}{}
+		// Stored timestamps drop trailing zeros, so they are compared as
+		// times rather than as strings.
+		if updated, err := time.Parse(time.RFC3339Nano, updatedRaw); err == nil && updated.Before(since) {
+			continue
+		}
+		task.Status = contracts.TaskStatus(status)
+		if task.ID == rootID {
+			task.ParentID = ""
+		}
+		changes.Tasks[task.ID] = task
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+	if len(changes.Tasks) == 0 {
+		return changes, nil
+	}
+	if err := s.loadMetadata(ctx, changes.Tasks); err != nil {
+		return nil, err
+	}
+	stored, err := s.loadRelations(ctx, changes.Tasks)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range sortedIDs(changes.Tasks) {
+		if task := changes.Tasks[id]; id != rootID && task.ParentID != "" {
+			changes.Relations = append(changes.Relations, contracts.TaskRelation{FromID: task.ParentID, ToID: id, Type: contracts.RelationParent})
+		}
+	}
+	for _, relation := range stored {
+		if _, ok := inTree[relation.ToID]; ok {
+			changes.Relations = append(changes.Relations, relation)
+		}
+	}
+	return changes, nil
+}
+
+func (s *Store) GetTask(ctx context.Context, taskID string) (*contracts.Task, error) {
+	taskID = strings.TrimSpace(taskID)
+	row := s.db.QueryRowContext(ctx, `SELECT id, title, description, status, parent_id FROM tasks WHERE id = ?`, taskID)
+	task, err := scanTask(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("task %q not found", taskID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	tasks := map[string]contracts.Task{taskID: task}
+	if err := s.loadMetadata(ctx, tasks); err != nil {
+		return nil, err
+	}
+	task = tasks[taskID]
+	return &task, nil
+}
+
+func (s *Store) SetTaskStatus(ctx context.Context, taskID string, status contracts.TaskStatus) error {
+	return s.write(ctx, taskID, ChangeStatus, changePayload{Status: status}, func(tx *sql.Tx, now string) error {
+		_, err := tx.ExecContext(ctx, `UPDATE tasks SET status = ?, updated_at = ? WHERE id = ?`, string(status), now, taskID)
+		return err
+	})
+}
+
+func (s *Store) SetTaskData(ctx context.Context, taskID string, data map[string]string) error {
+	return s.write(ctx, taskID, ChangeData, changePayload{Data: data}, func(tx *sql.Tx, now string) error {
+		for key, value := range data {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO task_metadata (task_id, key, value) VALUES (?, ?, ?)
+ON CONFLICT (task_id, key) DO UPDATE SET value = excluded.value`, taskID, key, value); err != nil {
+				return err
+			}
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE tasks SET updated_at = ? WHERE id = ?`, now, taskID)
+		return err
+	})
+}
+
+// AddTaskComment records the comment for the next Sync; comments are not
+// shown by GetTask.
+func (s *Store) AddTaskComment(ctx context.Context, taskID string, body string) error {
+	if strings.TrimSpace(body) == "" {
+		return nil
+	}
+	return s.write(ctx, taskID, ChangeComment, changePayload{Comment: body}, nil)
+}
+
+// CreateTask creates the task through the configured creator and adds it to
+// the store under the returned ID.
+func (s *Store) CreateTask(ctx context.Context, request contracts.TaskCreateRequest) (string, error) {
+	if s.creator == nil {
+		return "", errors.New("tracker does not support creating tasks")
+	}
+	id, err := s.creator.CreateTask(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	task := contracts.Task{
+		ID:          id,
+		Title:       request.Title,
+		Description: request.Description,
+		Status:      contracts.TaskStatusOpen,
+		ParentID:    strings.TrimSpace(request.ParentID),
+	}
+	if len(request.DependsOn) > 0 {
+		task.Metadata = map[string]string{"dependencies": strings.Join(request.DependsOn, ",")}
+	}
+	relations := make([]contracts.TaskRelation, 0, len(request.DependsOn))
+	for _, dep := range request.DependsOn {
+		relations = append(relations, contracts.TaskRelation{FromID: id, ToID: dep, Type: contracts.RelationDependsOn})
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := importTask(ctx, tx, task, relations, s.timestamp()); err != nil {
+		_ = tx.Rollback()
+		return "", fmt.Errorf("store created task %q: %w", id, err)
+	}
+	return id, tx.Commit()
+}
+
+// write applies a local change and records it in the history in one
+// transaction.
+func (s *Store) write(ctx context.Context, taskID string, kind ChangeKind, payload changePayload, apply func(tx *sql.Tx, now string) error) error {
+	taskID = strings.TrimSpace(taskID)
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// Taken inside the transaction so timestamps follow commit order, which
+	// TaskChangesSince relies on.
+	now := s.timestamp()
+	var exists int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks WHERE id = ?`, taskID).Scan(&exists); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if exists == 0 {
+		_ = tx.Rollback()
+		return fmt.Errorf("task %q not found", taskID)
+	}
+	if apply != nil {
+		if err := apply(tx, now); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO task_history (task_id, kind, payload, recorded_at) VALUES (?, ?, ?, ?)`, taskID, string(kind), string(encoded), now); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// History returns the recorded changes for taskID, oldest first. An empty
+// taskID returns the history of every task.
+func (s *Store) History(ctx context.Context, taskID string) ([]Change, error) {
+	query := `SELECT seq, task_id, kind, payload, recorded_at, COALESCE(synced_at, '') FROM task_history`
+	args := []any{}
+	if taskID = strings.TrimSpace(taskID); taskID != "" {
+		query += ` WHERE task_id = ?`
+		args = append(args, taskID)
+	}
+	return s.queryChanges(ctx, query+` ORDER BY seq`, args...)
+}
+
+// PendingChanges returns the changes not yet replayed on the tracker, oldest
+// first.
+func (s *Store) PendingChanges(ctx context.Context) ([]Change, error) {
+	return s.queryChanges(ctx, `SELECT seq, task_id, kind, payload, recorded_at, '' FROM task_history WHERE synced_at IS NULL ORDER BY seq`)
+}
+
+func (s *Store) queryChanges(ctx context.Context, query string, args ...any) ([]Change, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	changes := []Change{}
+	for rows.Next() {
+		var (
+			change              Change
+			kind, payload       string
+			recorded, syncedRaw string
+		)
+		if err := rows.Scan(&change.Seq, &change.TaskID, &kind, &payload, &recorded, &syncedRaw); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		var decoded changePayload
+		if err := json.Unmarshal([]byte(payload), &decoded); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("decode history entry %d: %w", change.Seq, err)
+		}
+		change.Kind = ChangeKind(kind)
+		change.Status = decoded.Status
+		change.Data = decoded.Data
+		change.Comment = decoded.Comment
+		change.RecordedAt, _ = time.Parse(time.RFC3339Nano, recorded)
+		if syncedRaw != "" {
+			change.SyncedAt, _ = time.Parse(time.RFC3339Nano, syncedRaw)
+		}
+		changes = append(changes, change)
+	}
+	return changes, closeRows(rows)
+}
+
+func (s *Store) loadMetadata(ctx context.Context, tasks map[string]contracts.Task) error {
+	filter, args := idFilter(tasks)
+	rows, err := s.db.QueryContext(ctx, `SELECT task_id, key, value FROM task_metadata WHERE task_id `+filter, args...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var taskID, key, value string
+		if err := rows.Scan(&taskID, &key, &value); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		task, ok := tasks[taskID]
+		if !ok {
+			continue
+		}
+		if task.Metadata == nil {
+			task.Metadata = map[string]string{}
+		}
+		task.Metadata[key] = value
+		tasks[taskID] = task
+	}
+	return closeRows(rows)
+}
+
+func (s *Store) loadRelations(ctx context.Context, tasks map[string]contracts.Task) ([]contracts.TaskRelation, error) {
+	filter, args := idFilter(tasks)
+	rows, err := s.db.QueryContext(ctx, `SELECT from_id, to_id, type FROM task_relations WHERE from_id `+filter+` ORDER BY from_id, type, to_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	relations := []contracts.TaskRelation{}
+	for rows.Next() {
+		var relation contracts.TaskRelation
+		var relationType string
+		if err := rows.Scan(&relation.FromID, &relation.ToID, &relationType); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		relation.Type = contracts.RelationType(relationType)
+		relations = append(relations, relation)
+	}
+	return relations, closeRows(rows)
+}
+
+func (s *Store) timestamp() string {
+	return s.now().UTC().Format(time.RFC3339Nano)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row rowScanner) (contracts.Task, error) {
+	var task contracts.Task
+	var status string
+	if err := row.Scan(&task.ID, &task.Title, &task.Description, &status, &task.ParentID); err != nil {
+		return contracts.Task{}, err
+	}
+	task.Status = contracts.TaskStatus(status)
+	return task, nil
+}
+
+func closeRows(rows *sql.Rows) error {
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	return rows.Close()
+}
+
+// idFilter returns an "IN (...)" clause and its arguments for the task IDs.
+func idFilter(tasks map[string]contracts.Task) (string, []any) {
+	ids := sortedIDs(tasks)
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return "IN (" + strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",") + ")", args
+}
+
+func sortedIDs(tasks map[string]contracts.Task) []string {
+	ids := make([]string, 0, len(tasks))
+	for id := range tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,277 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestStoreImportRoundTripsTaskTree(t *testing.T) {
+	store := openTestStore(t)
+	tree := sampleTree()
+	if err := store.Import(context.Background(), tree); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	got, err := store.GetTaskTree(context.Background(), "root")
+	if err != nil {
+		t.Fatalf("get task tree: %v", err)
+	}
+	if len(got.Tasks) != 4 {
+		t.Fatalf("expected 4 tasks, got %#v", got.Tasks)
+	}
+	if got.Tasks["t-2"].Metadata["priority"] != "1" || got.Tasks["t-2"].Status != contracts.TaskStatusBlocked {
+		t.Fatalf("expected metadata and status to round-trip, got %#v", got.Tasks["t-2"])
+	}
+	if got.Tasks["orphan"].ParentID != "root" {
+		t.Fatalf("expected task without in-tree parent to hang off the root, got %q", got.Tasks["orphan"].ParentID)
+	}
+	for _, want := range []contracts.TaskRelation{
+		{FromID: "root", ToID: "t-1", Type: contracts.RelationParent},
+		{FromID: "t-2", ToID: "t-1", Type: contracts.RelationDependsOn},
+		{FromID: "t-1", ToID: "t-2", Type: contracts.RelationBlocks},
+	} {
+		if !hasRelation(got.Relations, want) {
+			t.Fatalf("expected relation %#v in %#v", want, got.Relations)
+		}
+	}
+	if !reflect.DeepEqual(got.MissingDependencyIDs, []string{"ext-1"}) || !reflect.DeepEqual(got.MissingDependenciesByTask["t-1"], []string{"ext-1"}) {
+		t.Fatalf("expected missing dependency to round-trip, got %#v %#v", got.MissingDependencyIDs, got.MissingDependenciesByTask)
+	}
+}
+
+func TestStoreReimportReplacesTrackerFields(t *testing.T) {
+	store := openTestStore(t)
+	if err := store.Import(context.Background(), sampleTree()); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	tree := sampleTree()
+	t2 := tree.Tasks["t-2"]
+	t2.Title = "Renamed"
+	t2.Metadata = nil
+	tree.Tasks["t-2"] = t2
+	tree.Relations = nil
+	tree.MissingDependencyIDs = nil
+	tree.MissingDependenciesByTask = nil
+	if err := store.Import(context.Background(), tree); err != nil {
+		t.Fatalf("reimport: %v", err)
+	}
+
+	task, err := store.GetTask(context.Background(), "t-2")
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.Title != "Renamed" || len(task.Metadata) != 0 {
+		t.Fatalf("expected reimport to replace title and metadata, got %#v", task)
+	}
+	got, err := store.GetTaskTree(context.Background(), "root")
+	if err != nil {
+		t.Fatalf("get task tree: %v", err)
+	}
+	if hasRelation(got.Relations, contracts.TaskRelation{FromID: "t-2", ToID: "t-1", Type: contracts.RelationDependsOn}) || len(got.MissingDependencyIDs) != 0 {
+		t.Fatalf("expected reimport to drop stale dependencies, got %#v", got)
+	}
+}
+
+func TestStoreWritesRecordHistory(t *testing.T) {
+	store := openTestStore(t)
+	if err := store.Import(context.Background(), sampleTree()); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	if err := store.SetTaskStatus(context.Background(), "t-1", contracts.TaskStatusInProgress); err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	if err := store.SetTaskData(context.Background(), "t-1", map[string]string{"triage_status": "blocked"}); err != nil {
+		t.Fatalf("set data: %v", err)
+	}
+	if err := store.AddTaskComment(context.Background(), "t-1", "started"); err != nil {
+		t.Fatalf("add comment: %v", err)
+	}
+
+	task, err := store.GetTask(context.Background(), "t-1")
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.Status != contracts.TaskStatusInProgress || task.Metadata["triage_status"] != "blocked" || task.Metadata["priority"] != "2" {
+		t.Fatalf("expected local writes to apply, got %#v", task)
+	}
+	history, err := store.History(context.Background(), "t-1")
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 3 || history[0].Kind != ChangeStatus || history[0].Status != contracts.TaskStatusInProgress || history[1].Data["triage_status"] != "blocked" || history[2].Comment != "started" {
+		t.Fatalf("unexpected history %#v", history)
+	}
+	if history[0].RecordedAt.IsZero() || !history[0].SyncedAt.IsZero() {
+		t.Fatalf("expected recorded, unsynced change, got %#v", history[0])
+	}
+
+	if err := store.SetTaskStatus(context.Background(), "missing", contracts.TaskStatusClosed); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected unknown task to fail, got %v", err)
+	}
+}
+
+func TestStorePersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "tasks.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := store.Import(context.Background(), sampleTree()); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if err := store.SetTaskStatus(context.Background(), "t-1", contracts.TaskStatusClosed); err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	t.Cleanup(func() { _ = reopened.Close() })
+	task, err := reopened.GetTask(context.Background(), "t-1")
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.Status != contracts.TaskStatusClosed {
+		t.Fatalf("expected status to persist, got %q", task.Status)
+	}
+	pending, err := reopened.PendingChanges(context.Background())
+	if err != nil {
+		t.Fatalf("pending: %v", err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("expected pending change to persist, got %#v", pending)
+	}
+}
+
+func TestStoreCreateTaskUsesCreatorID(t *testing.T) {
+	store := openTestStore(t)
+	if err := store.Import(context.Background(), sampleTree()); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if _, err := store.CreateTask(context.Background(), contracts.TaskCreateRequest{ParentID: "root", Title: "x"}); err == nil {
+		t.Fatalf("expected create without creator to fail")
+	}
+
+	creator := &fakeTracker{nextID: "gh-42"}
+	store.WithTaskCreator(creator)
+	id, err := store.CreateTask(context.Background(), contracts.TaskCreateRequest{ParentID: "root", Title: "Follow-up", DependsOn: []string{"t-1"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if id != "gh-42" || len(creator.created) != 1 {
+		t.Fatalf("expected tracker-assigned ID, got %q (%#v)", id, creator.created)
+	}
+	tree, err := store.GetTaskTree(context.Background(), "root")
+	if err != nil {
+		t.Fatalf("get task tree: %v", err)
+	}
+	if tree.Tasks["gh-42"].Status != contracts.TaskStatusOpen || !hasRelation(tree.Relations, contracts.TaskRelation{FromID: "gh-42", ToID: "t-1", Type: contracts.RelationDependsOn}) {
+		t.Fatalf("expected created task in tree, got %#v", tree)
+	}
+}
+
+func TestStoreTaskChangesSinceListsUpdatedAndNewTasks(t *testing.T) {
+	store := openTestStore(t)
+	clock := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time {
+		clock = clock.Add(500 * time.Millisecond)
+		return clock
+	}
+	if err := store.Import(context.Background(), sampleTree()); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	start, err := store.TaskChangesSince(context.Background(), "root", "")
+	if err != nil {
+		t.Fatalf("changes: %v", err)
+	}
+	if len(start.Tasks) != 0 || start.Cursor == "" {
+		t.Fatalf("expected only a cursor for an empty cursor, got %#v", start)
+	}
+
+	if err := store.SetTaskStatus(context.Background(), "t-1", contracts.TaskStatusInProgress); err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	store.WithTaskCreator(&fakeTracker{nextID: "gh-42"})
+	if _, err := store.CreateTask(context.Background(), contracts.TaskCreateRequest{ParentID: "root", Title: "Follow-up", DependsOn: []string{"t-1"}}); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	changes, err := store.TaskChangesSince(context.Background(), "root", start.Cursor)
+	if err != nil {
+		t.Fatalf("changes: %v", err)
+	}
+	if len(changes.Tasks) != 2 || changes.Tasks["t-1"].Status != contracts.TaskStatusInProgress || changes.Tasks["t-1"].Metadata["priority"] != "2" {
+		t.Fatalf("expected t-1 and the new task, got %#v", changes.Tasks)
+	}
+	for _, want := range []contracts.TaskRelation{
+		{FromID: "root", ToID: "gh-42", Type: contracts.RelationParent},
+		{FromID: "gh-42", ToID: "t-1", Type: contracts.RelationDependsOn},
+		{FromID: "t-1", ToID: "t-2", Type: contracts.RelationBlocks},
+	} {
+		if !hasRelation(changes.Relations, want) {
+			t.Fatalf("expected relation %#v in %#v", want, changes.Relations)
+		}
+	}
+
+	again, err := store.TaskChangesSince(context.Background(), "root", changes.Cursor)
+	if err != nil {
+		t.Fatalf("changes: %v", err)
+	}
+	if len(again.Tasks) != 0 {
+		t.Fatalf("expected no changes since the last cursor, got %#v", again.Tasks)
+	}
+	if _, err := store.TaskChangesSince(context.Background(), "root", "yesterday"); err == nil {
+		t.Fatalf("expected an invalid cursor to fail")
+	}
+}
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := Open(filepath.Join(t.TempDir(), "tasks.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func sampleTree() *contracts.TaskTree {
+	root := contracts.Task{ID: "root", Title: "Roadmap", Status: contracts.TaskStatusOpen}
+	return &contracts.TaskTree{
+		Root: root,
+		Tasks: map[string]contracts.Task{
+			"root":   root,
+			"t-1":    {ID: "t-1", Title: "First", Status: contracts.TaskStatusOpen, ParentID: "root", Metadata: map[string]string{"priority": "2"}},
+			"t-2":    {ID: "t-2", Title: "Second", Description: "after first", Status: contracts.TaskStatusBlocked, ParentID: "root", Metadata: map[string]string{"priority": "1", "dependencies": "t-1"}},
+			"orphan": {ID: "orphan", Title: "Pulled in", Status: contracts.TaskStatusOpen, ParentID: "elsewhere"},
+		},
+		Relations: []contracts.TaskRelation{
+			{FromID: "root", ToID: "t-1", Type: contracts.RelationParent},
+			{FromID: "root", ToID: "t-2", Type: contracts.RelationParent},
+			{FromID: "t-2", ToID: "t-1", Type: contracts.RelationDependsOn},
+			{FromID: "t-1", ToID: "t-2", Type: contracts.RelationBlocks},
+		},
+		MissingDependencyIDs:      []string{"ext-1"},
+		MissingDependenciesByTask: map[string][]string{"t-1": {"ext-1"}},
+	}
+}
+
+func hasRelation(relations []contracts.TaskRelation, want contracts.TaskRelation) bool {
+	for _, relation := range relations {
+		if relation == want {
+			return true
+		}
+	}
+	return false
+}
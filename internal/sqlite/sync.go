@@ -0,0 +1,190 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// DefaultSyncInterval is how often a Syncer replays pending changes.
+const DefaultSyncInterval = 5 * time.Second
+
+// Import writes a task tree into the store, replacing the stored title,
+// description, status, parent, metadata and dependencies of every task in
+// the tree. History is left untouched, so call Sync first when local changes
+// must reach the tracker before it is read again.
+func (s *Store) Import(ctx context.Context, tree *contracts.TaskTree) error {
+	if tree == nil {
+		return errors.New("task tree is required")
+	}
+	rootID := strings.TrimSpace(tree.Root.ID)
+	if rootID == "" {
+		return errors.New("task tree root ID is required")
+	}
+	tasks := make(map[string]contracts.Task, len(tree.Tasks)+1)
+	for id, task := range tree.Tasks {
+		tasks[id] = task
+	}
+	if _, ok := tasks[rootID]; !ok {
+		tasks[rootID] = tree.Root
+	}
+	relationsByTask := map[string][]contracts.TaskRelation{}
+	for _, relation := range tree.Relations {
+		if relation.Type == contracts.RelationParent {
+			continue
+		}
+		if _, ok := tasks[relation.FromID]; !ok {
+			continue
+		}
+		relationsByTask[relation.FromID] = append(relationsByTask[relation.FromID], relation)
+	}
+	for taskID, deps := range tree.MissingDependenciesByTask {
+		for _, dep := range deps {
+			relationsByTask[taskID] = append(relationsByTask[taskID], contracts.TaskRelation{FromID: taskID, ToID: dep, Type: contracts.RelationDependsOn})
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	now := s.timestamp()
+	for _, id := range sortedIDs(tasks) {
+		task := tasks[id]
+		task.ID = id
+		parentID := strings.TrimSpace(task.ParentID)
+		if id == rootID {
+			parentID = strings.TrimSpace(tree.Root.ParentID)
+		} else if _, ok := tasks[parentID]; !ok {
+			// Tasks pulled into the tree without an in-tree parent hang
+			// off the root so GetTaskTree returns them again.
+			parentID = rootID
+		}
+		task.ParentID = parentID
+		if err := importTask(ctx, tx, task, relationsByTask[id], now); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("import task %q: %w", id, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ImportFrom reads the tree under rootID from source and imports it.
+func (s *Store) ImportFrom(ctx context.Context, source contracts.StorageBackend, rootID string) error {
+	tree, err := source.GetTaskTree(ctx, rootID)
+	if err != nil {
+		return fmt.Errorf("read task tree %q: %w", rootID, err)
+	}
+	return s.Import(ctx, tree)
+}
+
+func importTask(ctx context.Context, tx *sql.Tx, task contracts.Task, relations []contracts.TaskRelation, now string) error {
+	status := task.Status
+	if status == "" {
+		status = contracts.TaskStatusOpen
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO tasks (id, title, description, status, parent_id, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET title = excluded.title, description = excluded.description, status = excluded.status, parent_id = excluded.parent_id, updated_at = excluded.updated_at`,
+		task.ID, task.Title, task.Description, string(status), task.ParentID, now); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM task_metadata WHERE task_id = ?`, task.ID); err != nil {
+		return err
+	}
+	for key, value := range task.Metadata {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO task_metadata (task_id, key, value) VALUES (?, ?, ?)`, task.ID, key, value); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM task_relations WHERE from_id = ?`, task.ID); err != nil {
+		return err
+	}
+	for _, relation := range relations {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO task_relations (from_id, to_id, type) VALUES (?, ?, ?)`, relation.FromID, relation.ToID, string(relation.Type)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync replays pending changes on target in the order they were recorded and
+// marks each one synced. It stops at the first failure so later changes never
+// overtake earlier ones, and returns how many changes were replayed. Comments
+// are dropped when target cannot take them.
+func (s *Store) Sync(ctx context.Context, target contracts.StorageBackend) (int, error) {
+	if target == nil {
+		return 0, errors.New("sync target is required")
+	}
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	pending, err := s.PendingChanges(ctx)
+	if err != nil {
+		return 0, err
+	}
+	commenter, _ := target.(contracts.TaskCommenter)
+	synced := 0
+	for _, change := range pending {
+		var err error
+		switch change.Kind {
+		case ChangeStatus:
+			err = target.SetTaskStatus(ctx, change.TaskID, change.Status)
+		case ChangeData:
+			err = target.SetTaskData(ctx, change.TaskID, change.Data)
+		case ChangeComment:
+			if commenter != nil {
+				err = commenter.AddTaskComment(ctx, change.TaskID, change.Comment)
+			}
+		default:
+			err = fmt.Errorf("unknown change kind %q", change.Kind)
+		}
+		if err != nil {
+			return synced, fmt.Errorf("sync %s change %d for task %q: %w", change.Kind, change.Seq, change.TaskID, err)
+		}
+		if _, err := s.db.ExecContext(ctx, `UPDATE task_history SET synced_at = ? WHERE seq = ?`, s.timestamp(), change.Seq); err != nil {
+			return synced, err
+		}
+		synced++
+	}
+	return synced, nil
+}
+
+// Syncer replays a store's pending changes on a tracker in the background so
+// the engine only waits on local writes.
+type Syncer struct {
+	store    *Store
+	target   contracts.StorageBackend
+	interval time.Duration
+	onError  func(error)
+}
+
+// NewSyncer returns a Syncer that syncs every interval (DefaultSyncInterval
+// when zero) and reports failures to onError.
+func NewSyncer(store *Store, target contracts.StorageBackend, interval time.Duration, onError func(error)) *Syncer {
+	if interval <= 0 {
+		interval = DefaultSyncInterval
+	}
+	return &Syncer{store: store, target: target, interval: interval, onError: onError}
+}
+
+// Run syncs until ctx is done. Failed changes stay pending and are retried on
+// the next tick.
+func (s *Syncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.store.Sync(ctx, s.target); err != nil && ctx.Err() == nil && s.onError != nil {
+				s.onError(err)
+			}
+		}
+	}
+}
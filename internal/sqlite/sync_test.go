@@ -0,0 +1,164 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestStoreImportFromReadsTrackerTree(t *testing.T) {
+	store := openTestStore(t)
+	tracker := &fakeTracker{tree: sampleTree()}
+
+	if err := store.ImportFrom(context.Background(), tracker, "root"); err != nil {
+		t.Fatalf("import from: %v", err)
+	}
+	task, err := store.GetTask(context.Background(), "t-2")
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.Description != "after first" {
+		t.Fatalf("expected imported task, got %#v", task)
+	}
+
+	tracker.treeErr = errors.New("api down")
+	if err := store.ImportFrom(context.Background(), tracker, "root"); err == nil {
+		t.Fatalf("expected tracker failure to surface")
+	}
+}
+
+func TestStoreSyncReplaysChangesInOrderAndStopsAtFailure(t *testing.T) {
+	store := openTestStore(t)
+	if err := store.Import(context.Background(), sampleTree()); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	mustWrite(t, store.SetTaskStatus(context.Background(), "t-1", contracts.TaskStatusInProgress))
+	mustWrite(t, store.AddTaskComment(context.Background(), "t-1", "working"))
+	mustWrite(t, store.SetTaskStatus(context.Background(), "t-1", contracts.TaskStatusClosed))
+	mustWrite(t, store.SetTaskData(context.Background(), "t-2", map[string]string{"k": "v"}))
+
+	tracker := &fakeTracker{failStatus: contracts.TaskStatusClosed}
+	synced, err := store.Sync(context.Background(), tracker)
+	if err == nil || synced != 2 {
+		t.Fatalf("expected sync to stop at the failing change after 2, got %d %v", synced, err)
+	}
+	pending, err := store.PendingChanges(context.Background())
+	if err != nil {
+		t.Fatalf("pending: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Status != contracts.TaskStatusClosed {
+		t.Fatalf("expected failed change to stay pending, got %#v", pending)
+	}
+
+	tracker.failStatus = ""
+	synced, err = store.Sync(context.Background(), tracker)
+	if err != nil || synced != 2 {
+		t.Fatalf("expected remaining changes to sync, got %d %v", synced, err)
+	}
+	want := []string{"status t-1 in_progress", "comment t-1 working", "status t-1 closed", "data t-2 k=v"}
+	if len(tracker.calls) != len(want) {
+		t.Fatalf("expected calls %v, got %v", want, tracker.calls)
+	}
+	for i := range want {
+		if tracker.calls[i] != want[i] {
+			t.Fatalf("expected calls %v, got %v", want, tracker.calls)
+		}
+	}
+	if pending, _ := store.PendingChanges(context.Background()); len(pending) != 0 {
+		t.Fatalf("expected nothing pending, got %#v", pending)
+	}
+}
+
+func TestSyncerRunsInBackground(t *testing.T) {
+	store := openTestStore(t)
+	if err := store.Import(context.Background(), sampleTree()); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	tracker := &fakeTracker{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewSyncer(store, tracker, time.Millisecond, nil).Run(ctx)
+		close(done)
+	}()
+
+	mustWrite(t, store.SetTaskStatus(context.Background(), "t-1", contracts.TaskStatusClosed))
+	deadline := time.Now().Add(2 * time.Second)
+	for len(tracker.snapshotCalls()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	if calls := tracker.snapshotCalls(); len(calls) != 1 || calls[0] != "status t-1 closed" {
+		t.Fatalf("expected background sync of status change, got %v", calls)
+	}
+}
+
+func mustWrite(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+type fakeTracker struct {
+	mu         sync.Mutex
+	tree       *contracts.TaskTree
+	treeErr    error
+	failStatus contracts.TaskStatus
+	calls      []string
+	nextID     string
+	created    []contracts.TaskCreateRequest
+}
+
+func (f *fakeTracker) GetTaskTree(context.Context, string) (*contracts.TaskTree, error) {
+	if f.treeErr != nil {
+		return nil, f.treeErr
+	}
+	return f.tree, nil
+}
+
+func (f *fakeTracker) GetTask(context.Context, string) (*contracts.Task, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeTracker) SetTaskStatus(_ context.Context, taskID string, status contracts.TaskStatus) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failStatus != "" && status == f.failStatus {
+		return errors.New("tracker unavailable")
+	}
+	f.calls = append(f.calls, "status "+taskID+" "+string(status))
+	return nil
+}
+
+func (f *fakeTracker) SetTaskData(_ context.Context, taskID string, data map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, value := range data {
+		f.calls = append(f.calls, "data "+taskID+" "+key+"="+value)
+	}
+	return nil
+}
+
+func (f *fakeTracker) AddTaskComment(_ context.Context, taskID string, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "comment "+taskID+" "+body)
+	return nil
+}
+
+func (f *fakeTracker) CreateTask(_ context.Context, request contracts.TaskCreateRequest) (string, error) {
+	f.created = append(f.created, request)
+	return f.nextID, nil
+}
+
+func (f *fakeTracker) snapshotCalls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.calls...)
+}
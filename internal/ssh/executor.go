@@ -0,0 +1,354 @@
+// Package ssh runs runner commands on a remote host over SSH, so heavy
+// builds happen on a build server while orchestration stays local. The
+// local clone, including uncommitted changes, is pushed to a repository on
+// the host with git before each command, and the command's changes are
+// fetched back and applied to the local working tree afterwards. The ssh
+// and git executables bring their own keys and config.
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	syncRef   = "refs/yolo/sync"
+	resultRef = "refs/yolo/result"
+	// fetchedRef holds the fetched result in the local clone.
+	fetchedRef = "refs/yolo/remote-result"
+	// envFilePath holds the command's environment on the host, relative to
+	// its repository. It is inside .git, where checkout, clean and add
+	// leave it alone.
+	envFilePath = ".git/yolo-runner.env"
+	// syncName and syncEmail sign the sync commits on both ends.
+	syncName  = "yolo-runner"
+	syncEmail = "yolo-runner@localhost"
+)
+
+// Config describes the remote host.
+type Config struct {
+	// SSH and Git are the local executables; "ssh" and "git" from PATH by
+	// default.
+	SSH string
+	Git string
+	// Host is the ssh destination, such as builder@build-1.example.com.
+	Host         string
+	Port         int
+	IdentityFile string
+	// Options are extra ssh -o options such as StrictHostKeyChecking=yes.
+	Options []string
+	// RemoteDir is the absolute directory on the host that holds one
+	// repository per local clone.
+	RemoteDir string
+}
+
+// Command is one runner invocation.
+type Command struct {
+	Binary string
+	Args   []string
+	// Env holds KEY=value entries added to the remote environment.
+	Env []string
+	// Secrets holds the values of Env entries that are secrets. Run keeps
+	// every Env entry off command lines, secret or not.
+	Secrets []string
+	Dir     string
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+// CommandFunc runs a local process.
+type CommandFunc func(ctx context.Context, env []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, name string, args ...string) error
+
+// Executor runs Commands on the remote host.
+type Executor struct {
+	config Config
+	run    CommandFunc
+	// remoteURL is the git URL of a repository on the host.
+	remoteURL func(dir string) string
+	// remoteShell runs a shell script on the host.
+	remoteShell func(ctx context.Context, script string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+}
+
+// NewExecutor returns an Executor that runs ssh and git from config.
+func NewExecutor(config Config) (*Executor, error) {
+	return NewExecutorWithCommand(config, func(ctx context.Context, env []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, name string, args ...string) error {
+		cmd := exec.CommandContext(ctx, name, args...)
+		if len(env) > 0 {
+			cmd.Env = append(os.Environ(), env...)
+		}
+		cmd.Stdin = stdin
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		return cmd.Run()
+	})
+}
+
+// NewExecutorWithCommand returns an Executor that runs local processes
+// with run.
+func NewExecutorWithCommand(config Config, run CommandFunc) (*Executor, error) {
+	config.Host = strings.TrimSpace(config.Host)
+	if config.Host == "" || strings.HasPrefix(config.Host, "-") {
+		return nil, fmt.Errorf("ssh executor requires a host, got %q", config.Host)
+	}
+	if !path.IsAbs(config.RemoteDir) {
+		return nil, fmt.Errorf("ssh remote dir must be an absolute path, got %q", config.RemoteDir)
+	}
+	config.RemoteDir = path.Clean(config.RemoteDir)
+	if config.Port < 0 || config.Port > 65535 {
+		return nil, fmt.Errorf("ssh port must be between 0 and 65535, got %d", config.Port)
+	}
+	if strings.TrimSpace(config.SSH) == "" {
+		config.SSH = "ssh"
+	}
+	if strings.TrimSpace(config.Git) == "" {
+		config.Git = "git"
+	}
+	executor := &Executor{config: config, run: run}
+	executor.remoteURL = func(dir string) string {
+		return config.Host + ":" + dir
+	}
+	executor.remoteShell = func(ctx context.Context, script string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+		args := append(executor.sshArgs(), config.Host, "--", script)
+		return run(ctx, nil, stdin, stdout, stderr, config.SSH, args...)
+	}
+	return executor, nil
+}
+
+// Run pushes command.Dir to the host, runs the command there with its
+// output streamed to command.Stdout and command.Stderr, and applies the
+// changes it made to command.Dir. Changes are applied even when the command
+// fails, but not when ctx is canceled.
+func (e *Executor) Run(ctx context.Context, command Command) error {
+	stdout := writerOrDiscard(command.Stdout)
+	stderr := writerOrDiscard(command.Stderr)
+	localDir, err := filepath.Abs(command.Dir)
+	if err != nil {
+		return err
+	}
+	remoteDir := path.Join(e.config.RemoteDir, remoteDirName(localDir))
+
+	snapshot, err := e.snapshot(ctx, localDir)
+	if err != nil {
+		return fmt.Errorf("snapshot %s: %w", localDir, err)
+	}
+	// The environment goes over stdin into a file only the ssh user can
+	// read, so its values stay off the command lines on both ends.
+	var prepareErr bytes.Buffer
+	envFile := path.Join(remoteDir, envFilePath)
+	prepare := "mkdir -p " + shellQuote(remoteDir) + " && git -C " + shellQuote(remoteDir) + " init -q" +
+		" && umask 077 && rm -f " + shellQuote(envFile) + " && cat > " + shellQuote(envFile)
+	if err := e.remoteShell(ctx, prepare, strings.NewReader(e.envScript(localDir, remoteDir, command)), io.Discard, &prepareErr); err != nil {
+		return fmt.Errorf("prepare %s on %s: %w: %s", remoteDir, e.config.Host, err, strings.TrimSpace(prepareErr.String()))
+	}
+	if _, err := e.git(ctx, localDir, nil, "push", "--quiet", "--force", e.remoteURL(remoteDir), snapshot+":"+syncRef); err != nil {
+		return fmt.Errorf("push %s to %s: %w", localDir, e.config.Host, err)
+	}
+	fmt.Fprintf(stderr, "ssh: running in %s:%s\n", e.config.Host, remoteDir)
+
+	// The open stdin lets the remote script notice that ssh went away and
+	// stop the command.
+	stdin, hold, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = hold.Close() })
+	runErr := e.remoteShell(ctx, e.runScript(localDir, remoteDir, command), stdin, stdout, stderr)
+	stop()
+	_ = hold.Close()
+	_ = stdin.Close()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err := e.applyResult(ctx, localDir, remoteDir, snapshot); err != nil {
+		if runErr != nil {
+			return fmt.Errorf("%w (and %v)", runErr, err)
+		}
+		return fmt.Errorf("apply changes from %s: %w", e.config.Host, err)
+	}
+	return runErr
+}
+
+// snapshot commits the working tree of dir, untracked files included, to
+// a dangling commit without touching its index or branches.
+func (e *Executor) snapshot(ctx context.Context, dir string) (string, error) {
+	indexPath, err := e.git(ctx, dir, nil, "rev-parse", "--git-path", "index")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(indexPath) {
+		indexPath = filepath.Join(dir, indexPath)
+	}
+	tempIndex, err := os.CreateTemp("", "yolo-ssh-index-")
+	if err != nil {
+		return "", err
+	}
+	tempIndex.Close()
+	defer os.Remove(tempIndex.Name())
+	if content, err := os.ReadFile(indexPath); err == nil {
+		if err := os.WriteFile(tempIndex.Name(), content, 0o600); err != nil {
+			return "", err
+		}
+	} else {
+		_ = os.Remove(tempIndex.Name())
+	}
+	env := []string{"GIT_INDEX_FILE=" + tempIndex.Name()}
+	if _, err := e.git(ctx, dir, env, "add", "--all"); err != nil {
+		return "", err
+	}
+	tree, err := e.git(ctx, dir, env, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	return e.git(ctx, dir, syncIdentityEnv(), "commit-tree", tree, "-p", "HEAD", "-m", "yolo-runner ssh sync")
+}
+
+// envScript exports command.Env for the remote shell that sources it.
+func (e *Executor) envScript(localDir string, remoteDir string, command Command) string {
+	var script strings.Builder
+	for _, entry := range command.Env {
+		key, value, ok := strings.Cut(entry, "=")
+		// PATH points at local directories, such as the tool policy shims,
+		// that the host does not have.
+		if !ok || !envNamePattern.MatchString(key) || key == "PATH" {
+			continue
+		}
+		script.WriteString("export " + key + "=" + shellQuote(e.rewritePaths(value, localDir, remoteDir)) + "\n")
+	}
+	return script.String()
+}
+
+// runScript checks out the pushed snapshot, runs the command with the
+// environment from envFilePath, which it deletes, and commits whatever it
+// changed to resultRef. A watcher stops the command when ssh closes stdin.
+func (e *Executor) runScript(localDir string, remoteDir string, command Command) string {
+	load := ". ./" + envFilePath + " && rm -f ./" + envFilePath + ` && exec "$@"`
+	words := []string{"sh", "-c", shellQuote(load), "sh", shellQuote(command.Binary)}
+	for _, arg := range command.Args {
+		words = append(words, shellQuote(e.rewritePaths(arg, localDir, remoteDir)))
+	}
+	commit := "git -c user.name=" + syncName + " -c user.email=" + syncEmail + " commit -q --no-verify --allow-empty -m 'yolo-runner ssh result'"
+	return strings.Join([]string{
+		"cd " + shellQuote(remoteDir) + " || exit 1",
+		// Background jobs read /dev/null unless stdin is redirected, so the
+		// watcher reads ssh's stdin through fd 3.
+		"exec 3<&0",
+		"git checkout -q -f --detach " + syncRef + " && git clean -q -fd || exit 1",
+		// setsid, where the host has it, puts the command in its own
+		// process group, so the watcher stops its children too.
+		"if command -v setsid >/dev/null 2>&1; then setsid " + strings.Join(words, " ") + " </dev/null & else " + strings.Join(words, " ") + " </dev/null & fi",
+		"pid=$!",
+		"{ cat <&3 >/dev/null; kill -TERM -$pid || kill -TERM $pid; } >/dev/null 2>&1 &",
+		"watcher=$!",
+		"wait $pid",
+		"status=$?",
+		"kill $watcher 2>/dev/null",
+		"rm -f ./" + envFilePath,
+		"{ git add --all && " + commit + " && git update-ref " + resultRef + " HEAD; } >/dev/null 2>&1",
+		"exit $status",
+	}, "\n")
+}
+
+// applyResult fetches the commit the run left on the host and applies its
+// difference from snapshot to the working tree of localDir.
+func (e *Executor) applyResult(ctx context.Context, localDir string, remoteDir string, snapshot string) error {
+	if _, err := e.git(ctx, localDir, nil, "fetch", "--quiet", "--no-tags", e.remoteURL(remoteDir), "+"+resultRef+":"+fetchedRef); err != nil {
+		return err
+	}
+	diff, err := e.git(ctx, localDir, nil, "diff", "--binary", "--no-color", "--no-ext-diff", snapshot, fetchedRef)
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		return nil
+	}
+	var applyErr bytes.Buffer
+	if err := e.run(ctx, nil, strings.NewReader(diff+"\n"), io.Discard, &applyErr, e.config.Git, "-C", localDir, "apply", "--whitespace=nowarn"); err != nil {
+		return fmt.Errorf("git apply: %w: %s", err, strings.TrimSpace(applyErr.String()))
+	}
+	return nil
+}
+
+// git runs git in dir and returns its trimmed stdout.
+func (e *Executor) git(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	env = append(append([]string(nil), env...), "GIT_SSH_COMMAND="+e.gitSSHCommand())
+	if err := e.run(ctx, env, nil, &stdout, &stderr, e.config.Git, append([]string{"-C", dir}, args...)...); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// sshArgs are the ssh options from Config, shared by ssh and git.
+func (e *Executor) sshArgs() []string {
+	args := []string{"-o", "BatchMode=yes"}
+	if e.config.Port > 0 {
+		args = append(args, "-p", strconv.Itoa(e.config.Port))
+	}
+	if identity := strings.TrimSpace(e.config.IdentityFile); identity != "" {
+		args = append(args, "-i", identity)
+	}
+	for _, option := range e.config.Options {
+		if option = strings.TrimSpace(option); option != "" {
+			args = append(args, "-o", option)
+		}
+	}
+	return args
+}
+
+func (e *Executor) gitSSHCommand() string {
+	words := []string{shellQuote(e.config.SSH)}
+	for _, arg := range e.sshArgs() {
+		words = append(words, shellQuote(arg))
+	}
+	return strings.Join(words, " ")
+}
+
+// rewritePaths replaces localDir inside value with remoteDir.
+func (e *Executor) rewritePaths(value string, localDir string, remoteDir string) string {
+	if value == localDir {
+		return remoteDir
+	}
+	return strings.ReplaceAll(value, localDir+"/", remoteDir+"/")
+}
+
+func syncIdentityEnv() []string {
+	return []string{
+		"GIT_AUTHOR_NAME=" + syncName, "GIT_AUTHOR_EMAIL=" + syncEmail,
+		"GIT_COMMITTER_NAME=" + syncName, "GIT_COMMITTER_EMAIL=" + syncEmail,
+	}
+}
+
+var (
+	envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	dirNameInvalid = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// remoteDirName is the base name of the local clone, which is the task ID
+// for task clones.
+func remoteDirName(localDir string) string {
+	name := strings.Trim(dirNameInvalid.ReplaceAllString(filepath.Base(localDir), "-"), ".-")
+	if name == "" {
+		return "workspace"
+	}
+	return name
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func writerOrDiscard(w io.Writer) io.Writer {
+	if w == nil {
+		return io.Discard
+	}
+	return w
+}
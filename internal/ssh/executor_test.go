@@ -0,0 +1,222 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runLocal(ctx context.Context, env []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+func gitIn(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+// newLocalHostExecutor returns an Executor whose "host" is a directory on
+// this machine: scripts run with sh and git talks to plain paths.
+func newLocalHostExecutor(t *testing.T) (*Executor, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	remoteRoot := t.TempDir()
+	executor, err := NewExecutorWithCommand(Config{Host: "build-1", RemoteDir: remoteRoot}, runLocal)
+	if err != nil {
+		t.Fatalf("new executor: %v", err)
+	}
+	executor.remoteURL = func(dir string) string { return dir }
+	executor.remoteShell = func(ctx context.Context, script string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+		return runLocal(ctx, nil, stdin, stdout, stderr, "sh", "-c", script)
+	}
+	return executor, remoteRoot
+}
+
+func newLocalClone(t *testing.T) string {
+	t.Helper()
+	clone := filepath.Join(t.TempDir(), "task-1")
+	if err := os.MkdirAll(clone, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	gitIn(t, clone, "init", "-q")
+	writeFile(t, filepath.Join(clone, "main.go"), "package main\n")
+	writeFile(t, filepath.Join(clone, "old.txt"), "old\n")
+	gitIn(t, clone, "add", "--all")
+	gitIn(t, clone, "commit", "-q", "-m", "initial")
+	return clone
+}
+
+func TestExecutorRunsOnHostAndAppliesChanges(t *testing.T) {
+	executor, remoteRoot := newLocalHostExecutor(t)
+	clone := newLocalClone(t)
+	writeFile(t, filepath.Join(clone, "main.go"), "package main\n\n// local edit\n")
+	writeFile(t, filepath.Join(clone, "notes.txt"), "untracked\n")
+	head := gitIn(t, clone, "rev-parse", "HEAD")
+
+	var stdout, stderr bytes.Buffer
+	script := `grep -q "local edit" main.go && cat notes.txt && echo "$MESSAGE" > result.txt && rm old.txt && echo "$1" >&2`
+	err := executor.Run(context.Background(), Command{
+		Binary: "sh",
+		Args:   []string{"-c", script, "sh", clone + "/main.go"},
+		Env:    []string{"PATH=/local/shims", "MESSAGE=it's done"},
+		Dir:    clone,
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	if err != nil {
+		t.Fatalf("run: %v (stderr: %s)", err, stderr.String())
+	}
+	if stdout.String() != "untracked\n" {
+		t.Fatalf("expected the remote output on stdout, got %q", stdout.String())
+	}
+	remoteDir := filepath.Join(remoteRoot, "task-1")
+	if !strings.Contains(stderr.String(), "ssh: running in build-1:"+remoteDir) || !strings.Contains(stderr.String(), remoteDir+"/main.go") {
+		t.Fatalf("expected the remote dir and rewritten path on stderr, got %q", stderr.String())
+	}
+	if content, _ := os.ReadFile(filepath.Join(clone, "result.txt")); string(content) != "it's done\n" {
+		t.Fatalf("expected the remote change to be applied, got %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(clone, "old.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected the remote deletion to be applied, got %v", err)
+	}
+	if gitIn(t, clone, "rev-parse", "HEAD") != head {
+		t.Fatalf("expected the local branch to stay where it was")
+	}
+	if status := gitIn(t, clone, "status", "--porcelain"); !strings.Contains(status, "?? notes.txt") || !strings.Contains(status, "M main.go") {
+		t.Fatalf("expected local changes to stay uncommitted, got %q", status)
+	}
+}
+
+func TestExecutorKeepsEnvOffCommandLines(t *testing.T) {
+	executor, remoteRoot := newLocalHostExecutor(t)
+	clone := newLocalClone(t)
+	scripts := []string{}
+	remoteShell := executor.remoteShell
+	executor.remoteShell = func(ctx context.Context, script string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+		scripts = append(scripts, script)
+		return remoteShell(ctx, script, stdin, stdout, stderr)
+	}
+	err := executor.Run(context.Background(), Command{
+		Binary:  "sh",
+		Args:    []string{"-c", `echo "$API_KEY" > key.txt`},
+		Env:     []string{"API_KEY=sk-live-123"},
+		Secrets: []string{"sk-live-123"},
+		Dir:     clone,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, script := range scripts {
+		if strings.Contains(script, "sk-live-123") {
+			t.Fatalf("expected the env value kept off the ssh command line, got %q", script)
+		}
+	}
+	if content, _ := os.ReadFile(filepath.Join(clone, "key.txt")); string(content) != "sk-live-123\n" {
+		t.Fatalf("expected the command to see its env, got %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(remoteRoot, "task-1", envFilePath)); !os.IsNotExist(err) {
+		t.Fatalf("expected the env file deleted after the run, got %v", err)
+	}
+}
+
+func TestExecutorAppliesChangesOfFailedCommands(t *testing.T) {
+	executor, _ := newLocalHostExecutor(t)
+	clone := newLocalClone(t)
+	err := executor.Run(context.Background(), Command{Binary: "sh", Args: []string{"-c", "echo partial > partial.txt; exit 3"}, Dir: clone})
+	if err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Fatalf("expected the exit status, got %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(clone, "partial.txt")); string(content) != "partial\n" {
+		t.Fatalf("expected the partial change to be applied, got %q", content)
+	}
+}
+
+func TestExecutorStopsRemoteCommandWhenCanceled(t *testing.T) {
+	executor, remoteRoot := newLocalHostExecutor(t)
+	clone := newLocalClone(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- executor.Run(ctx, Command{Binary: "sh", Args: []string{"-c", "touch started; sleep 30"}, Dir: clone})
+	}()
+	started := filepath.Join(remoteRoot, "task-1", "started")
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if _, err := os.Stat(started); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("remote command never started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("expected cancellation, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("run did not return after cancel")
+	}
+	if _, err := os.Stat(filepath.Join(clone, "started")); !os.IsNotExist(err) {
+		t.Fatalf("expected no changes applied after cancel, got %v", err)
+	}
+}
+
+func TestNewExecutorValidatesConfig(t *testing.T) {
+	tests := []struct {
+		config  Config
+		wantErr string
+	}{
+		{config: Config{RemoteDir: "/srv/yolo"}, wantErr: "requires a host"},
+		{config: Config{Host: "-oProxyCommand=x", RemoteDir: "/srv/yolo"}, wantErr: "requires a host"},
+		{config: Config{Host: "build-1", RemoteDir: "yolo"}, wantErr: "absolute path"},
+		{config: Config{Host: "build-1", RemoteDir: "/srv/yolo", Port: 70000}, wantErr: "port"},
+	}
+	for _, tc := range tests {
+		if _, err := NewExecutorWithCommand(tc.config, nil); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Fatalf("expected %q, got %v", tc.wantErr, err)
+		}
+	}
+}
+
+func TestExecutorPassesSSHOptionsToSSHAndGit(t *testing.T) {
+	executor, err := NewExecutorWithCommand(Config{Host: "builder@build-1", Port: 2222, IdentityFile: "/keys/id", Options: []string{"StrictHostKeyChecking=yes"}, RemoteDir: "/srv/yolo"}, nil)
+	if err != nil {
+		t.Fatalf("new executor: %v", err)
+	}
+	want := "'ssh' '-o' 'BatchMode=yes' '-p' '2222' '-i' '/keys/id' '-o' 'StrictHostKeyChecking=yes'"
+	if got := executor.gitSSHCommand(); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	if got := executor.remoteURL("/srv/yolo/task-1"); got != "builder@build-1:/srv/yolo/task-1" {
+		t.Fatalf("unexpected remote url %s", got)
+	}
+}
@@ -15,6 +15,8 @@ type StorageBackend struct {
 }
 
 var _ contracts.StorageBackend = (*StorageBackend)(nil)
+var _ contracts.TaskCreator = (*StorageBackend)(nil)
+var _ contracts.TaskCommenter = (*StorageBackend)(nil)
 
 func NewStorageBackend(runner Runner) *StorageBackend {
 	return NewStorageBackendWithPersister(runner, noopTaskStatePersister{})
@@ -75,6 +77,20 @@ func (b *StorageBackend) SetTaskData(ctx context.Context, taskID string, data ma
 	return b.manager.SetTaskData(ctx, taskID, data)
 }
 
+func (b *StorageBackend) AddTaskComment(ctx context.Context, taskID string, body string) error {
+	if b == nil || b.manager == nil {
+		return fmt.Errorf("tk storage backend is not initialized")
+	}
+	return b.manager.AddTaskComment(ctx, taskID, body)
+}
+
+func (b *StorageBackend) CreateTask(ctx context.Context, request contracts.TaskCreateRequest) (string, error) {
+	if b == nil || b.manager == nil {
+		return "", fmt.Errorf("tk storage backend is not initialized")
+	}
+	return b.manager.CreateTask(ctx, request)
+}
+
 func (b *StorageBackend) PersistTaskStatusChange(ctx context.Context, taskID string, status contracts.TaskStatus) error {
 	if b == nil || b.statePersister == nil {
 		return nil
@@ -245,6 +245,51 @@ func (m *TaskManager) SetTaskData(_ context.Context, taskID string, data map[str
 	return nil
 }
 
+// AddTaskComment appends body to the ticket as a tk note.
+func (m *TaskManager) AddTaskComment(_ context.Context, taskID string, body string) error {
+	if strings.TrimSpace(body) == "" {
+		return nil
+	}
+	if _, err := m.runner.Run("tk", "add-note", taskID, body); err != nil {
+		return fmt.Errorf("add tk note to %s: %w", taskID, err)
+	}
+	return nil
+}
+
+// CreateTask creates a tk ticket and records its dependencies with `tk dep`.
+func (m *TaskManager) CreateTask(_ context.Context, request contracts.TaskCreateRequest) (string, error) {
+	title := strings.TrimSpace(request.Title)
+	if title == "" {
+		return "", fmt.Errorf("task title is required")
+	}
+	args := []string{"tk", "create", title, "-t", "task"}
+	if description := strings.TrimSpace(request.Description); description != "" {
+		args = append(args, "-d", description)
+	}
+	if parentID := strings.TrimSpace(request.ParentID); parentID != "" {
+		args = append(args, "--parent", parentID)
+	}
+	out, err := m.runner.Run(args...)
+	if err != nil {
+		return "", fmt.Errorf("create tk ticket %q: %w", title, err)
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("create tk ticket %q: no ticket ID in output", title)
+	}
+	taskID := fields[len(fields)-1]
+	for _, depID := range request.DependsOn {
+		depID = strings.TrimSpace(depID)
+		if depID == "" || depID == taskID {
+			continue
+		}
+		if _, err := m.runner.Run("tk", "dep", taskID, depID); err != nil {
+			return taskID, fmt.Errorf("add tk dependency %s -> %s: %w", taskID, depID, err)
+		}
+	}
+	return taskID, nil
+}
+
 func (m *TaskManager) isTerminal(taskID string) bool {
 	if taskID == "" {
 		return false
@@ -27,6 +27,53 @@ func TestTaskManagerSetTaskDataUsesAddNote(t *testing.T) {
 	}
 }
 
+func TestTaskManagerAddTaskCommentUsesAddNote(t *testing.T) {
+	r := &fakeRunner{responses: map[string]string{}}
+	m := NewTaskManager(r)
+
+	if err := m.AddTaskComment(context.Background(), "t-1", "yolo-runner: started"); err != nil {
+		t.Fatalf("add comment failed: %v", err)
+	}
+	if !r.called("tk add-note t-1 yolo-runner: started") {
+		t.Fatalf("expected add-note call, got %v", r.calls)
+	}
+}
+
+func TestTaskManagerCreateTaskCreatesTicketAndDependencies(t *testing.T) {
+	r := &fakeRunner{responses: map[string]string{"tk create": "root.3\n"}}
+	m := NewTaskManager(r)
+
+	taskID, err := m.CreateTask(context.Background(), contracts.TaskCreateRequest{
+		ParentID:    "root",
+		Title:       "Wire API",
+		Description: "Expose the endpoint",
+		DependsOn:   []string{"root.1", " ", "root.2"},
+	})
+	if err != nil {
+		t.Fatalf("create task failed: %v", err)
+	}
+	if taskID != "root.3" {
+		t.Fatalf("expected created ID root.3, got %q", taskID)
+	}
+	want := []string{
+		"tk create Wire API -t task -d Expose the endpoint --parent root",
+		"tk dep root.3 root.1",
+		"tk dep root.3 root.2",
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Fatalf("unexpected calls: %#v", r.calls)
+	}
+}
+
+func TestTaskManagerCreateTaskRequiresTicketID(t *testing.T) {
+	r := &fakeRunner{responses: map[string]string{}}
+	m := NewTaskManager(r)
+
+	if _, err := m.CreateTask(context.Background(), contracts.TaskCreateRequest{Title: "A"}); err == nil || !strings.Contains(err.Error(), "no ticket ID") {
+		t.Fatalf("expected missing ticket ID error, got %v", err)
+	}
+}
+
 func TestTaskManagerNextTasksMapsReadyResults(t *testing.T) {
 	r := &fakeRunner{responses: map[string]string{
 		"tk query": `{"id":"root","status":"open","type":"epic","priority":0}` + "\n" +
@@ -0,0 +1,276 @@
+package trackercache
+
+import "github.com/egv/yolo-runner/v2/internal/contracts"
+
+type (
+	tracker   = contracts.StorageBackend
+	creator   = contracts.TaskCreator
+	commenter = contracts.TaskCommenter
+	reader    = contracts.TaskCommentReader
+	assigner  = contracts.TaskAssigner
+	labeler   = contracts.TaskLabeler
+)
+
+// Backend returns the cache as a storage backend that implements the optional
+// tracker interfaces only when the wrapped backend does, so callers that check
+// for a capability do not call operations the tracker rejects.
+func (b *StorageBackend) Backend() contracts.StorageBackend {
+	mask := 0
+	if _, ok := b.inner.(creator); ok {
+		mask |= 1
+	}
+	if _, ok := b.inner.(commenter); ok {
+		mask |= 2
+	}
+	if _, ok := b.inner.(reader); ok {
+		mask |= 4
+	}
+	if _, ok := b.inner.(assigner); ok {
+		mask |= 8
+	}
+	if _, ok := b.inner.(labeler); ok {
+		mask |= 16
+	}
+	return withCapabilities[mask](b)
+}
+
+// withCapabilities is indexed by the mask built in Backend. Each struct
+// promotes only the methods of the interfaces it embeds.
+var withCapabilities = [32]func(*StorageBackend) tracker{
+	func(b *StorageBackend) tracker { return struct{ tracker }{b} },
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+		}{b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			commenter
+		}{b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			commenter
+		}{b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			reader
+		}{b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			reader
+		}{b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			commenter
+			reader
+		}{b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			commenter
+			reader
+		}{b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			assigner
+		}{b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			assigner
+		}{b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			commenter
+			assigner
+		}{b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			commenter
+			assigner
+		}{b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			reader
+			assigner
+		}{b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			reader
+			assigner
+		}{b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			commenter
+			reader
+			assigner
+		}{b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			commenter
+			reader
+			assigner
+		}{b, b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			labeler
+		}{b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			labeler
+		}{b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			commenter
+			labeler
+		}{b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			commenter
+			labeler
+		}{b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			reader
+			labeler
+		}{b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			reader
+			labeler
+		}{b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			commenter
+			reader
+			labeler
+		}{b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			commenter
+			reader
+			labeler
+		}{b, b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			assigner
+			labeler
+		}{b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			assigner
+			labeler
+		}{b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			commenter
+			assigner
+			labeler
+		}{b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			commenter
+			assigner
+			labeler
+		}{b, b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			reader
+			assigner
+			labeler
+		}{b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			reader
+			assigner
+			labeler
+		}{b, b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			commenter
+			reader
+			assigner
+			labeler
+		}{b, b, b, b, b}
+	},
+	func(b *StorageBackend) tracker {
+		return struct {
+			tracker
+			creator
+			commenter
+			reader
+			assigner
+			labeler
+		}{b, b, b, b, b, b}
+	},
+}
@@ -0,0 +1,450 @@
+package trackercache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	DefaultTTL           = 30 * time.Second
+	DefaultFlushInterval = 2 * time.Second
+	DefaultMaxBackoff    = time.Minute
+)
+
+// Options tunes the cache. Zero values use the defaults above.
+type Options struct {
+	// TTL is how long a task tree snapshot is served before the tracker is
+	// read again.
+	TTL time.Duration
+	// FlushInterval is how often queued writes are flushed while the tracker
+	// is healthy. Failed flushes back off exponentially up to MaxBackoff.
+	FlushInterval time.Duration
+	MaxBackoff    time.Duration
+	// OnError reports flush failures and reads served from a stale snapshot.
+	OnError func(error)
+	Now     func() time.Time
+}
+
+type writeKind int
+
+const (
+	writeStatus writeKind = iota
+	writeData
+	writeComment
+)
+
+type queuedWrite struct {
+	kind    writeKind
+	taskID  string
+	status  contracts.TaskStatus
+	data    map[string]string
+	comment string
+}
+
+type treeSnapshot struct {
+	tree      *contracts.TaskTree
+	fetchedAt time.Time
+}
+
+type taskStatePersister interface {
+	PersistTaskStatusChange(ctx context.Context, taskID string, status contracts.TaskStatus) error
+	PersistTaskDataChange(ctx context.Context, taskID string, data map[string]string) error
+}
+
+// StorageBackend is a write-behind cache over a tracker storage backend. The
+// engine computes NextTasks from GetTaskTree, so serving the tree from a
+// snapshot keeps scheduling passes off the tracker API. Status, data and
+// comment writes apply to the snapshot at once and are queued for the
+// tracker; when the tracker is unreachable the last snapshot keeps serving.
+type StorageBackend struct {
+	inner contracts.StorageBackend
+	opts  Options
+
+	mu      sync.Mutex
+	trees   map[string]*treeSnapshot
+	tasks   map[string]contracts.Task
+	queue   []queuedWrite
+	offline bool
+
+	// ioMu keeps tracker reads and flushes from interleaving, so a fetched
+	// tree never predates a write that was already removed from the queue.
+	ioMu sync.Mutex
+	wake chan struct{}
+}
+
+var _ contracts.StorageBackend = (*StorageBackend)(nil)
+var _ contracts.TaskCreator = (*StorageBackend)(nil)
+var _ contracts.TaskCommenter = (*StorageBackend)(nil)
+var _ contracts.TaskCommentReader = (*StorageBackend)(nil)
+var _ contracts.TaskAssigner = (*StorageBackend)(nil)
+var _ contracts.TaskLabeler = (*StorageBackend)(nil)
+
+func NewStorageBackend(inner contracts.StorageBackend, opts Options) *StorageBackend {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultTTL
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+	if opts.MaxBackoff < opts.FlushInterval {
+		opts.MaxBackoff = DefaultMaxBackoff
+		if opts.MaxBackoff < opts.FlushInterval {
+			opts.MaxBackoff = opts.FlushInterval
+		}
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &StorageBackend{
+		inner: inner,
+		opts:  opts,
+		trees: map[string]*treeSnapshot{},
+		tasks: map[string]contracts.Task{},
+		wake:  make(chan struct{}, 1),
+	}
+}
+
+// Offline reports whether the last tracker read failed and a snapshot was
+// served instead.
+func (b *StorageBackend) Offline() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.offline
+}
+
+// Pending returns the number of writes not yet flushed to the tracker.
+func (b *StorageBackend) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.queue)
+}
+
+func (b *StorageBackend) GetTaskTree(ctx context.Context, rootID string) (*contracts.TaskTree, error) {
+	b.mu.Lock()
+	snapshot := b.trees[rootID]
+	if snapshot != nil && b.opts.Now().Sub(snapshot.fetchedAt) < b.opts.TTL {
+		tree := cloneTree(snapshot.tree)
+		b.mu.Unlock()
+		return tree, nil
+	}
+	b.mu.Unlock()
+
+	b.ioMu.Lock()
+	tree, err := b.inner.GetTaskTree(ctx, rootID)
+	if err != nil {
+		b.ioMu.Unlock()
+		return b.staleTree(rootID, err)
+	}
+	tree = cloneTree(tree)
+	b.mu.Lock()
+	for _, write := range b.queue {
+		applyToTree(tree, write)
+	}
+	b.trees[rootID] = &treeSnapshot{tree: tree, fetchedAt: b.opts.Now()}
+	for id, task := range tree.Tasks {
+		b.tasks[id] = task
+	}
+	b.offline = false
+	result := cloneTree(tree)
+	b.mu.Unlock()
+	b.ioMu.Unlock()
+	return result, nil
+}
+
+func (b *StorageBackend) staleTree(rootID string, cause error) (*contracts.TaskTree, error) {
+	b.mu.Lock()
+	snapshot := b.trees[rootID]
+	if snapshot == nil {
+		b.mu.Unlock()
+		return nil, cause
+	}
+	b.offline = true
+	tree := cloneTree(snapshot.tree)
+	b.mu.Unlock()
+	b.report(fmt.Errorf("tracker read failed, serving cached task tree %q: %w", rootID, cause))
+	return tree, nil
+}
+
+// GetTask serves tasks seen in a cached tree or an earlier read, and falls
+// back to them when the tracker read fails.
+func (b *StorageBackend) GetTask(ctx context.Context, taskID string) (*contracts.Task, error) {
+	b.mu.Lock()
+	cached, ok := b.tasks[taskID]
+	b.mu.Unlock()
+	if ok {
+		task := cloneTask(cached)
+		return &task, nil
+	}
+
+	b.ioMu.Lock()
+	defer b.ioMu.Unlock()
+	task, err := b.inner.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil {
+		return nil, nil
+	}
+	fetched := cloneTask(*task)
+	b.mu.Lock()
+	for _, write := range b.queue {
+		if write.taskID == taskID {
+			applyToTask(&fetched, write)
+		}
+	}
+	b.tasks[taskID] = fetched
+	b.mu.Unlock()
+	result := cloneTask(fetched)
+	return &result, nil
+}
+
+func (b *StorageBackend) SetTaskStatus(_ context.Context, taskID string, status contracts.TaskStatus) error {
+	b.enqueue(queuedWrite{kind: writeStatus, taskID: taskID, status: status})
+	return nil
+}
+
+func (b *StorageBackend) SetTaskData(_ context.Context, taskID string, data map[string]string) error {
+	b.enqueue(queuedWrite{kind: writeData, taskID: taskID, data: cloneMap(data)})
+	return nil
+}
+
+// AddTaskComment queues the comment; it is dropped at flush time when the
+// tracker cannot take comments.
+func (b *StorageBackend) AddTaskComment(_ context.Context, taskID string, body string) error {
+	b.enqueue(queuedWrite{kind: writeComment, taskID: taskID, comment: body})
+	return nil
+}
+
+// TaskComments goes straight to the tracker; comments are not cached.
+func (b *StorageBackend) TaskComments(ctx context.Context, taskID string, since time.Time) ([]contracts.TaskComment, error) {
+	reader, ok := b.inner.(contracts.TaskCommentReader)
+	if !ok {
+		return nil, errors.New("tracker does not support reading comments")
+	}
+	b.ioMu.Lock()
+	defer b.ioMu.Unlock()
+	return reader.TaskComments(ctx, taskID, since)
+}
+
+// AssignTask goes straight to the tracker; assignees are not cached.
+func (b *StorageBackend) AssignTask(ctx context.Context, taskID string, assignee string) error {
+	assigner, ok := b.inner.(contracts.TaskAssigner)
+	if !ok {
+		return errors.New("tracker does not support assigning tasks")
+	}
+	b.ioMu.Lock()
+	defer b.ioMu.Unlock()
+	return assigner.AssignTask(ctx, taskID, assignee)
+}
+
+// AddTaskLabels goes straight to the tracker; labels are not cached.
+func (b *StorageBackend) AddTaskLabels(ctx context.Context, taskID string, labels []string) error {
+	labeler, ok := b.inner.(contracts.TaskLabeler)
+	if !ok {
+		return errors.New("tracker does not support labeling tasks")
+	}
+	b.ioMu.Lock()
+	defer b.ioMu.Unlock()
+	return labeler.AddTaskLabels(ctx, taskID, labels)
+}
+
+// CreateTask goes straight to the tracker so the task gets a tracker ID. Cached
+// trees are dropped so the next read includes it.
+func (b *StorageBackend) CreateTask(ctx context.Context, request contracts.TaskCreateRequest) (string, error) {
+	creator, ok := b.inner.(contracts.TaskCreator)
+	if !ok {
+		return "", errors.New("tracker does not support creating tasks")
+	}
+	b.ioMu.Lock()
+	defer b.ioMu.Unlock()
+	id, err := creator.CreateTask(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	b.mu.Lock()
+	b.trees = map[string]*treeSnapshot{}
+	b.mu.Unlock()
+	return id, nil
+}
+
+func (b *StorageBackend) enqueue(write queuedWrite) {
+	b.mu.Lock()
+	b.queue = append(b.queue, write)
+	for _, snapshot := range b.trees {
+		applyToTree(snapshot.tree, write)
+	}
+	if task, ok := b.tasks[write.taskID]; ok {
+		applyToTask(&task, write)
+		b.tasks[write.taskID] = task
+	}
+	b.mu.Unlock()
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Flush writes queued changes to the tracker in order and stops at the first
+// failure, leaving it and later writes queued.
+func (b *StorageBackend) Flush(ctx context.Context) error {
+	b.ioMu.Lock()
+	defer b.ioMu.Unlock()
+	for {
+		b.mu.Lock()
+		if len(b.queue) == 0 {
+			b.mu.Unlock()
+			return nil
+		}
+		write := b.queue[0]
+		b.mu.Unlock()
+
+		if err := b.apply(ctx, write); err != nil {
+			return fmt.Errorf("flush task %q: %w", write.taskID, err)
+		}
+
+		b.mu.Lock()
+		b.queue = b.queue[1:]
+		b.mu.Unlock()
+	}
+}
+
+func (b *StorageBackend) apply(ctx context.Context, write queuedWrite) error {
+	persister, _ := b.inner.(taskStatePersister)
+	switch write.kind {
+	case writeStatus:
+		if err := b.inner.SetTaskStatus(ctx, write.taskID, write.status); err != nil {
+			return err
+		}
+		if persister != nil {
+			return persister.PersistTaskStatusChange(ctx, write.taskID, write.status)
+		}
+	case writeData:
+		if err := b.inner.SetTaskData(ctx, write.taskID, write.data); err != nil {
+			return err
+		}
+		if persister != nil {
+			return persister.PersistTaskDataChange(ctx, write.taskID, write.data)
+		}
+	case writeComment:
+		if commenter, ok := b.inner.(contracts.TaskCommenter); ok {
+			return commenter.AddTaskComment(ctx, write.taskID, write.comment)
+		}
+	}
+	return nil
+}
+
+// Run flushes queued writes until ctx is done, right after each write and
+// every FlushInterval otherwise. After a failure it waits twice as long
+// before retrying, up to MaxBackoff.
+func (b *StorageBackend) Run(ctx context.Context) {
+	delay := b.opts.FlushInterval
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.wake:
+			if delay > b.opts.FlushInterval {
+				// Backing off: new writes wait for the retry timer.
+				continue
+			}
+		case <-timer.C:
+		}
+		if err := b.Flush(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			b.report(err)
+			delay *= 2
+			if delay > b.opts.MaxBackoff {
+				delay = b.opts.MaxBackoff
+			}
+		} else {
+			delay = b.opts.FlushInterval
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(delay)
+	}
+}
+
+func (b *StorageBackend) report(err error) {
+	if b.opts.OnError != nil {
+		b.opts.OnError(err)
+	}
+}
+
+func applyToTree(tree *contracts.TaskTree, write queuedWrite) {
+	if tree == nil {
+		return
+	}
+	if task, ok := tree.Tasks[write.taskID]; ok {
+		applyToTask(&task, write)
+		tree.Tasks[write.taskID] = task
+	}
+	if tree.Root.ID == write.taskID {
+		applyToTask(&tree.Root, write)
+	}
+}
+
+func applyToTask(task *contracts.Task, write queuedWrite) {
+	switch write.kind {
+	case writeStatus:
+		task.Status = write.status
+	case writeData:
+		if task.Metadata == nil {
+			task.Metadata = map[string]string{}
+		}
+		for key, value := range write.data {
+			task.Metadata[key] = value
+		}
+	}
+}
+
+func cloneTree(tree *contracts.TaskTree) *contracts.TaskTree {
+	if tree == nil {
+		return nil
+	}
+	cloned := &contracts.TaskTree{
+		Root:                 cloneTask(tree.Root),
+		Tasks:                make(map[string]contracts.Task, len(tree.Tasks)),
+		Relations:            append([]contracts.TaskRelation(nil), tree.Relations...),
+		MissingDependencyIDs: append([]string(nil), tree.MissingDependencyIDs...),
+	}
+	for id, task := range tree.Tasks {
+		cloned.Tasks[id] = cloneTask(task)
+	}
+	if tree.MissingDependenciesByTask != nil {
+		cloned.MissingDependenciesByTask = make(map[string][]string, len(tree.MissingDependenciesByTask))
+		for id, deps := range tree.MissingDependenciesByTask {
+			cloned.MissingDependenciesByTask[id] = append([]string(nil), deps...)
+		}
+	}
+	return cloned
+}
+
+func cloneTask(task contracts.Task) contracts.Task {
+	task.Metadata = cloneMap(task.Metadata)
+	return task
+}
+
+func cloneMap(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	cloned := make(map[string]string, len(values))
+	for key, value := range values {
+		cloned[key] = value
+	}
+	return cloned
+}
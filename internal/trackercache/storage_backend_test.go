@@ -0,0 +1,328 @@
+package trackercache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestStorageBackendServesTreeFromSnapshotUntilTTL(t *testing.T) {
+	tracker := newFakeTracker()
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	cache := NewStorageBackend(tracker, Options{TTL: time.Minute, Now: clock.Now})
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.GetTaskTree(context.Background(), "root"); err != nil {
+			t.Fatalf("get tree: %v", err)
+		}
+	}
+	if got := tracker.count("tree"); got != 1 {
+		t.Fatalf("expected one tracker read within TTL, got %d", got)
+	}
+	clock.advance(time.Minute)
+	if _, err := cache.GetTaskTree(context.Background(), "root"); err != nil {
+		t.Fatalf("get tree: %v", err)
+	}
+	if got := tracker.count("tree"); got != 2 {
+		t.Fatalf("expected re-read after TTL, got %d", got)
+	}
+	if _, err := cache.GetTask(context.Background(), "t-1"); err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got := tracker.count("task"); got != 0 {
+		t.Fatalf("expected GetTask to be served from the tree snapshot, got %d reads", got)
+	}
+}
+
+func TestStorageBackendQueuesWritesAndOverlaysThem(t *testing.T) {
+	tracker := newFakeTracker()
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	cache := NewStorageBackend(tracker, Options{TTL: time.Minute, Now: clock.Now})
+	if _, err := cache.GetTaskTree(context.Background(), "root"); err != nil {
+		t.Fatalf("get tree: %v", err)
+	}
+
+	if err := cache.SetTaskStatus(context.Background(), "t-1", contracts.TaskStatusClosed); err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	if err := cache.SetTaskData(context.Background(), "t-1", map[string]string{"k": "v"}); err != nil {
+		t.Fatalf("set data: %v", err)
+	}
+	if len(tracker.snapshotCalls()) != 0 || cache.Pending() != 2 {
+		t.Fatalf("expected writes to be queued, tracker calls %v pending %d", tracker.snapshotCalls(), cache.Pending())
+	}
+	tree, _ := cache.GetTaskTree(context.Background(), "root")
+	if tree.Tasks["t-1"].Status != contracts.TaskStatusClosed || tree.Tasks["t-1"].Metadata["k"] != "v" {
+		t.Fatalf("expected cached tree to reflect queued writes, got %#v", tree.Tasks["t-1"])
+	}
+
+	// A fresh tracker read still shows queued writes until they flush.
+	clock.advance(time.Minute)
+	tree, _ = cache.GetTaskTree(context.Background(), "root")
+	if tree.Tasks["t-1"].Status != contracts.TaskStatusClosed {
+		t.Fatalf("expected queued status to overlay re-read tree, got %q", tree.Tasks["t-1"].Status)
+	}
+
+	if err := cache.Flush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	want := []string{"status t-1 closed", "persist t-1 closed", "data t-1 k=v"}
+	if got := tracker.snapshotCalls(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected flushed writes %v, got %v", want, got)
+	}
+	if cache.Pending() != 0 {
+		t.Fatalf("expected empty queue after flush")
+	}
+}
+
+func TestStorageBackendServesStaleSnapshotWhileTrackerIsDown(t *testing.T) {
+	tracker := newFakeTracker()
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	var reported []error
+	cache := NewStorageBackend(tracker, Options{TTL: time.Second, Now: clock.Now, OnError: func(err error) { reported = append(reported, err) }})
+
+	tracker.setDown(true)
+	if _, err := cache.GetTaskTree(context.Background(), "root"); err == nil {
+		t.Fatalf("expected first read without snapshot to fail")
+	}
+	tracker.setDown(false)
+	if _, err := cache.GetTaskTree(context.Background(), "root"); err != nil {
+		t.Fatalf("get tree: %v", err)
+	}
+
+	tracker.setDown(true)
+	clock.advance(time.Minute)
+	tree, err := cache.GetTaskTree(context.Background(), "root")
+	if err != nil || tree.Tasks["t-1"].Title != "Task" {
+		t.Fatalf("expected stale snapshot while offline, got %#v %v", tree, err)
+	}
+	if !cache.Offline() || len(reported) != 1 {
+		t.Fatalf("expected offline state to be reported, offline=%v reported=%v", cache.Offline(), reported)
+	}
+	if err := cache.SetTaskStatus(context.Background(), "t-1", contracts.TaskStatusClosed); err != nil {
+		t.Fatalf("expected offline write to queue, got %v", err)
+	}
+	if err := cache.Flush(context.Background()); err == nil || cache.Pending() != 1 {
+		t.Fatalf("expected failed flush to keep write queued, err=%v pending=%d", err, cache.Pending())
+	}
+
+	tracker.setDown(false)
+	if err := cache.Flush(context.Background()); err != nil {
+		t.Fatalf("flush after recovery: %v", err)
+	}
+	clock.advance(time.Minute)
+	if _, err := cache.GetTaskTree(context.Background(), "root"); err != nil || cache.Offline() {
+		t.Fatalf("expected recovery to clear offline state, err=%v", err)
+	}
+}
+
+func TestStorageBackendFlushForwardsPersistenceAndComments(t *testing.T) {
+	tracker := newFakeTracker()
+	cache := NewStorageBackend(tracker, Options{})
+
+	_ = cache.SetTaskStatus(context.Background(), "t-1", contracts.TaskStatusInProgress)
+	_ = cache.AddTaskComment(context.Background(), "t-1", "started")
+	if err := cache.Flush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	want := []string{"status t-1 in_progress", "persist t-1 in_progress", "comment t-1 started"}
+	if got := tracker.snapshotCalls(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestStorageBackendRunFlushesInBackground(t *testing.T) {
+	tracker := newFakeTracker()
+	cache := NewStorageBackend(tracker, Options{FlushInterval: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		cache.Run(ctx)
+		close(done)
+	}()
+
+	_ = cache.SetTaskStatus(context.Background(), "t-1", contracts.TaskStatusClosed)
+	deadline := time.Now().Add(2 * time.Second)
+	for cache.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	if got := tracker.snapshotCalls(); len(got) == 0 || got[0] != "status t-1 closed" {
+		t.Fatalf("expected write to flush without waiting for the interval, got %v", got)
+	}
+}
+
+func TestStorageBackendReportsOnlyTrackerCapabilities(t *testing.T) {
+	backend := NewStorageBackend(newFakeTracker(), Options{}).Backend()
+	if _, ok := backend.(contracts.TaskCommenter); !ok {
+		t.Fatalf("expected comments to be supported like the tracker")
+	}
+	if _, ok := backend.(contracts.TaskCreator); ok {
+		t.Fatalf("expected no task creation when the tracker cannot create tasks")
+	}
+	if _, ok := backend.(contracts.TaskCommentReader); ok {
+		t.Fatalf("expected no comment reading when the tracker cannot read comments")
+	}
+	if _, ok := backend.(contracts.TaskAssigner); ok {
+		t.Fatalf("expected no assigning when the tracker cannot assign tasks")
+	}
+	if _, ok := backend.(contracts.TaskLabeler); ok {
+		t.Fatalf("expected no labeling when the tracker cannot label tasks")
+	}
+
+	tracker := &creatingTracker{fakeTracker: newFakeTracker()}
+	cache := NewStorageBackend(tracker, Options{TTL: time.Minute})
+	creator, ok := cache.Backend().(contracts.TaskCreator)
+	if !ok {
+		t.Fatalf("expected task creation to be forwarded")
+	}
+	if _, err := cache.GetTaskTree(context.Background(), "root"); err != nil {
+		t.Fatalf("get tree: %v", err)
+	}
+	if _, err := creator.CreateTask(context.Background(), contracts.TaskCreateRequest{ParentID: "root", Title: "New"}); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if _, err := cache.GetTaskTree(context.Background(), "root"); err != nil {
+		t.Fatalf("get tree: %v", err)
+	}
+	if got := tracker.count("tree"); got != 2 {
+		t.Fatalf("expected the created task to drop the cached tree, got %d reads", got)
+	}
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type fakeTracker struct {
+	mu     sync.Mutex
+	down   bool
+	reads  map[string]int
+	calls  []string
+	tasks  map[string]contracts.Task
+	rootID string
+}
+
+func newFakeTracker() *fakeTracker {
+	return &fakeTracker{
+		reads:  map[string]int{},
+		rootID: "root",
+		tasks: map[string]contracts.Task{
+			"root": {ID: "root", Title: "Root", Status: contracts.TaskStatusOpen},
+			"t-1":  {ID: "t-1", Title: "Task", Status: contracts.TaskStatusOpen, ParentID: "root"},
+		},
+	}
+}
+
+func (f *fakeTracker) setDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+func (f *fakeTracker) count(kind string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reads[kind]
+}
+
+func (f *fakeTracker) snapshotCalls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.calls...)
+}
+
+func (f *fakeTracker) GetTaskTree(context.Context, string) (*contracts.TaskTree, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads["tree"]++
+	if f.down {
+		return nil, errors.New("tracker unavailable")
+	}
+	tasks := map[string]contracts.Task{}
+	for id, task := range f.tasks {
+		tasks[id] = task
+	}
+	return &contracts.TaskTree{Root: tasks[f.rootID], Tasks: tasks}, nil
+}
+
+func (f *fakeTracker) GetTask(_ context.Context, taskID string) (*contracts.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads["task"]++
+	if f.down {
+		return nil, errors.New("tracker unavailable")
+	}
+	task := f.tasks[taskID]
+	return &task, nil
+}
+
+func (f *fakeTracker) SetTaskStatus(_ context.Context, taskID string, status contracts.TaskStatus) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return errors.New("tracker unavailable")
+	}
+	f.calls = append(f.calls, "status "+taskID+" "+string(status))
+	return nil
+}
+
+func (f *fakeTracker) SetTaskData(_ context.Context, taskID string, data map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return errors.New("tracker unavailable")
+	}
+	for key, value := range data {
+		f.calls = append(f.calls, "data "+taskID+" "+key+"="+value)
+	}
+	return nil
+}
+
+func (f *fakeTracker) AddTaskComment(_ context.Context, taskID string, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "comment "+taskID+" "+body)
+	return nil
+}
+
+func (f *fakeTracker) PersistTaskStatusChange(_ context.Context, taskID string, status contracts.TaskStatus) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "persist "+taskID+" "+string(status))
+	return nil
+}
+
+func (f *fakeTracker) PersistTaskDataChange(context.Context, string, map[string]string) error {
+	return nil
+}
+
+type creatingTracker struct {
+	*fakeTracker
+}
+
+func (f *creatingTracker) CreateTask(_ context.Context, request contracts.TaskCreateRequest) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tasks["t-2"] = contracts.Task{ID: "t-2", Title: request.Title, Status: contracts.TaskStatusOpen, ParentID: request.ParentID}
+	return "t-2", nil
+}
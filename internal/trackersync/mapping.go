@@ -0,0 +1,74 @@
+package trackersync
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// MappedTask links a source task to its mirror in the target tracker. Status
+// is the status both sides agreed on after the last sync; it is how a run
+// tells which side changed since then.
+type MappedTask struct {
+	TargetID string               `json:"target_id"`
+	Status   contracts.TaskStatus `json:"status"`
+}
+
+// Mapping is the persisted source-to-target ID map for one pair of trackers.
+type Mapping struct {
+	Tasks map[string]MappedTask `json:"tasks"`
+}
+
+// LoadMapping reads the mapping at path. A missing file yields an empty mapping.
+func LoadMapping(path string) (*Mapping, error) {
+	mapping := &Mapping{Tasks: map[string]MappedTask{}}
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return mapping, nil
+		}
+		return nil, fmt.Errorf("read sync mapping %q: %w", path, err)
+	}
+	if len(strings.TrimSpace(string(payload))) == 0 {
+		return mapping, nil
+	}
+	if err := json.Unmarshal(payload, mapping); err != nil {
+		return nil, fmt.Errorf("parse sync mapping %q: %w", path, err)
+	}
+	if mapping.Tasks == nil {
+		mapping.Tasks = map[string]MappedTask{}
+	}
+	return mapping, nil
+}
+
+// Save writes the mapping to path, creating parent directories as needed.
+func (m *Mapping) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create sync mapping directory for %q: %w", path, err)
+	}
+	payload, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode sync mapping %q: %w", path, err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, append(payload, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write sync mapping %q: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("write sync mapping %q: %w", path, err)
+	}
+	return nil
+}
+
+// TargetID returns the target ID mapped to sourceID, if any.
+func (m *Mapping) TargetID(sourceID string) (string, bool) {
+	entry, ok := m.Tasks[sourceID]
+	if !ok || strings.TrimSpace(entry.TargetID) == "" {
+		return "", false
+	}
+	return entry.TargetID, true
+}
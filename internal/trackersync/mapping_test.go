@@ -0,0 +1,53 @@
+package trackersync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestLoadMappingReturnsEmptyMappingForMissingFile(t *testing.T) {
+	mapping, err := LoadMapping(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("load mapping: %v", err)
+	}
+	if mapping.Tasks == nil || len(mapping.Tasks) != 0 {
+		t.Fatalf("expected empty mapping, got %#v", mapping)
+	}
+}
+
+func TestMappingSaveRoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync", "tk-github.json")
+	mapping := &Mapping{Tasks: map[string]MappedTask{
+		"task-1": {TargetID: "42", Status: contracts.TaskStatusClosed},
+	}}
+	if err := mapping.Save(path); err != nil {
+		t.Fatalf("save mapping: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("expected temp file to be renamed away, stat err=%v", err)
+	}
+
+	loaded, err := LoadMapping(path)
+	if err != nil {
+		t.Fatalf("load mapping: %v", err)
+	}
+	if id, ok := loaded.TargetID("task-1"); !ok || id != "42" || loaded.Tasks["task-1"].Status != contracts.TaskStatusClosed {
+		t.Fatalf("unexpected loaded mapping %#v", loaded)
+	}
+	if _, ok := loaded.TargetID("task-2"); ok {
+		t.Fatalf("expected unmapped task to report no target")
+	}
+}
+
+func TestLoadMappingRejectsInvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.json")
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	if _, err := LoadMapping(path); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
@@ -0,0 +1,371 @@
+// Package trackersync mirrors a task tree from one tracker into another and
+// keeps task statuses in step between the two.
+package trackersync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+type ActionKind string
+
+const (
+	// ActionCreate is a source task created in the target tracker.
+	ActionCreate ActionKind = "create"
+	// ActionPushStatus is a source status change copied to the target.
+	ActionPushStatus ActionKind = "push_status"
+	// ActionPullStatus is a target status change copied back to the source.
+	ActionPullStatus ActionKind = "pull_status"
+	// ActionConflict is a task whose status changed on both sides; the target
+	// status wins and is copied back to the source.
+	ActionConflict ActionKind = "conflict"
+)
+
+// Action records one change made (or, in dry-run mode, planned) by Mirror.
+type Action struct {
+	Kind     ActionKind
+	SourceID string
+	TargetID string
+	Status   contracts.TaskStatus
+}
+
+type Options struct {
+	// TargetRootID links the source root to an existing target task instead of
+	// creating a new one. It is ignored once the root is mapped.
+	TargetRootID string
+	// DryRun reports the actions a sync would take without writing to either
+	// tracker or the mapping.
+	DryRun bool
+	// Save persists the mapping. Mirror calls it after every created task so an
+	// interrupted run does not create duplicates next time.
+	Save func(*Mapping) error
+}
+
+// Mirror publishes the source tree rooted at rootID to target, creating tasks
+// that are not in mapping yet, then reconciles statuses of mapped tasks in
+// both directions.
+func Mirror(ctx context.Context, source contracts.StorageBackend, target contracts.StorageBackend, rootID string, mapping *Mapping, opts Options) ([]Action, error) {
+	if source == nil || target == nil {
+		return nil, errors.New("source and target trackers are required")
+	}
+	if mapping == nil {
+		return nil, errors.New("sync mapping is required")
+	}
+	if mapping.Tasks == nil {
+		mapping.Tasks = map[string]MappedTask{}
+	}
+	rootID = strings.TrimSpace(rootID)
+	if rootID == "" {
+		return nil, errors.New("root task ID is required")
+	}
+
+	tree, err := source.GetTaskTree(ctx, rootID)
+	if err != nil {
+		return nil, fmt.Errorf("read source tree %q: %w", rootID, err)
+	}
+	if tree == nil {
+		return nil, fmt.Errorf("source root task %q not found", rootID)
+	}
+	tasks := tree.Tasks
+	if tasks == nil {
+		tasks = map[string]contracts.Task{}
+	}
+	if _, ok := tasks[rootID]; !ok {
+		tasks[rootID] = tree.Root
+	}
+
+	m := &mirror{
+		source:  source,
+		target:  target,
+		mapping: mapping,
+		opts:    opts,
+		planned: map[string]struct{}{},
+	}
+	if err := m.mapRoot(ctx, tasks[rootID]); err != nil {
+		return m.actions, err
+	}
+	if err := m.createMissing(ctx, tree, rootID); err != nil {
+		return m.actions, err
+	}
+	if err := m.reconcileStatuses(ctx, tasks, rootID); err != nil {
+		return m.actions, err
+	}
+	return m.actions, nil
+}
+
+type mirror struct {
+	source  contracts.StorageBackend
+	target  contracts.StorageBackend
+	mapping *Mapping
+	opts    Options
+	actions []Action
+	// planned holds source IDs a dry run would create, so their children are
+	// reported as creatable too.
+	planned map[string]struct{}
+}
+
+func (m *mirror) mapRoot(ctx context.Context, root contracts.Task) error {
+	if _, ok := m.mapping.TargetID(root.ID); ok {
+		return nil
+	}
+	targetRootID := strings.TrimSpace(m.opts.TargetRootID)
+	if targetRootID == "" {
+		return m.create(ctx, root, "", nil)
+	}
+	if m.opts.DryRun {
+		return nil
+	}
+	// Leaving Status empty lets the first reconcile push the source status.
+	m.mapping.Tasks[root.ID] = MappedTask{TargetID: targetRootID}
+	return m.save()
+}
+
+// createMissing creates unmapped tasks parents-first and, where possible,
+// after the tasks they depend on so dependency links can be carried over.
+func (m *mirror) createMissing(ctx context.Context, tree *contracts.TaskTree, rootID string) error {
+	pending := make([]string, 0, len(tree.Tasks))
+	for id := range tree.Tasks {
+		if id == rootID || m.isMapped(id) {
+			continue
+		}
+		pending = append(pending, id)
+	}
+	sort.Strings(pending)
+
+	for len(pending) > 0 {
+		remaining := pending[:0]
+		progressed := false
+		for _, id := range pending {
+			task := tree.Tasks[id]
+			parentID := sourceParentID(tree, task, rootID)
+			deps := dependencyIDs(tree, id)
+			if !m.isMapped(parentID) || !m.allMapped(deps) {
+				remaining = append(remaining, id)
+				continue
+			}
+			if err := m.create(ctx, task, parentID, deps); err != nil {
+				return err
+			}
+			progressed = true
+		}
+		pending = remaining
+		if !progressed && len(pending) > 0 {
+			// A dependency cycle or a parent outside the tree: create the first
+			// task with whatever links are already mapped.
+			id := pending[0]
+			task := tree.Tasks[id]
+			if err := m.create(ctx, task, sourceParentID(tree, task, rootID), dependencyIDs(tree, id)); err != nil {
+				return err
+			}
+			pending = pending[1:]
+		}
+	}
+	return nil
+}
+
+func (m *mirror) create(ctx context.Context, task contracts.Task, sourceParentID string, deps []string) error {
+	action := Action{Kind: ActionCreate, SourceID: task.ID, Status: task.Status}
+	if m.opts.DryRun {
+		m.planned[task.ID] = struct{}{}
+		m.actions = append(m.actions, action)
+		return nil
+	}
+	creator, ok := m.target.(contracts.TaskCreator)
+	if !ok {
+		return errors.New("target tracker does not support creating tasks")
+	}
+	request := contracts.TaskCreateRequest{Title: task.Title, Description: task.Description}
+	if sourceParentID != "" {
+		request.ParentID, _ = m.mapping.TargetID(sourceParentID)
+	}
+	for _, depID := range deps {
+		if targetDepID, ok := m.mapping.TargetID(depID); ok {
+			request.DependsOn = append(request.DependsOn, targetDepID)
+		}
+	}
+	targetID, err := creator.CreateTask(ctx, request)
+	if err != nil {
+		return fmt.Errorf("create target task for %q: %w", task.ID, err)
+	}
+	status := normalizeStatus(task.Status)
+	if status != contracts.TaskStatusOpen {
+		if err := m.target.SetTaskStatus(ctx, targetID, status); err != nil {
+			return fmt.Errorf("set target task %q status %q: %w", targetID, status, err)
+		}
+	}
+	m.mapping.Tasks[task.ID] = MappedTask{TargetID: targetID, Status: status}
+	action.TargetID = targetID
+	action.Status = status
+	m.actions = append(m.actions, action)
+	return m.save()
+}
+
+func (m *mirror) reconcileStatuses(ctx context.Context, tasks map[string]contracts.Task, rootID string) error {
+	targetRootID, ok := m.mapping.TargetID(rootID)
+	if !ok {
+		return nil
+	}
+	targetTree, err := m.target.GetTaskTree(ctx, targetRootID)
+	if err != nil {
+		return fmt.Errorf("read target tree %q: %w", targetRootID, err)
+	}
+	targetTasks := map[string]contracts.Task{}
+	if targetTree != nil {
+		targetTasks[targetRootID] = targetTree.Root
+		for id, task := range targetTree.Tasks {
+			targetTasks[id] = task
+		}
+	}
+
+	ids := make([]string, 0, len(tasks))
+	for id := range tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	changed := false
+	for _, id := range ids {
+		entry, ok := m.mapping.Tasks[id]
+		if !ok || entry.TargetID == "" {
+			continue
+		}
+		targetTask, ok := targetTasks[entry.TargetID]
+		if !ok {
+			fetched, err := m.target.GetTask(ctx, entry.TargetID)
+			if err != nil {
+				return fmt.Errorf("read target task %q: %w", entry.TargetID, err)
+			}
+			if fetched == nil {
+				continue
+			}
+			targetTask = *fetched
+		}
+
+		sourceStatus := normalizeStatus(tasks[id].Status)
+		targetStatus := normalizeStatus(targetTask.Status)
+		last := entry.Status
+		if last == "" {
+			last = targetStatus
+		}
+
+		var action *Action
+		switch {
+		case sourceStatus == targetStatus:
+		case targetStatus == last:
+			action = &Action{Kind: ActionPushStatus, SourceID: id, TargetID: entry.TargetID, Status: sourceStatus}
+		case sourceStatus == last:
+			action = &Action{Kind: ActionPullStatus, SourceID: id, TargetID: entry.TargetID, Status: targetStatus}
+		default:
+			action = &Action{Kind: ActionConflict, SourceID: id, TargetID: entry.TargetID, Status: targetStatus}
+		}
+		if action != nil {
+			m.actions = append(m.actions, *action)
+			if m.opts.DryRun {
+				continue
+			}
+			if action.Kind == ActionPushStatus {
+				if err := m.target.SetTaskStatus(ctx, entry.TargetID, action.Status); err != nil {
+					return fmt.Errorf("set target task %q status %q: %w", entry.TargetID, action.Status, err)
+				}
+			} else {
+				if err := m.source.SetTaskStatus(ctx, id, action.Status); err != nil {
+					return fmt.Errorf("set source task %q status %q: %w", id, action.Status, err)
+				}
+			}
+		}
+		if m.opts.DryRun {
+			continue
+		}
+		resolved := sourceStatus
+		if action != nil {
+			resolved = action.Status
+		}
+		if entry.Status != resolved {
+			entry.Status = resolved
+			m.mapping.Tasks[id] = entry
+			changed = true
+		}
+	}
+	if changed {
+		return m.save()
+	}
+	return nil
+}
+
+func (m *mirror) isMapped(sourceID string) bool {
+	if _, ok := m.mapping.TargetID(sourceID); ok {
+		return true
+	}
+	_, ok := m.planned[sourceID]
+	return ok
+}
+
+func (m *mirror) allMapped(sourceIDs []string) bool {
+	for _, id := range sourceIDs {
+		if !m.isMapped(id) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *mirror) save() error {
+	if m.opts.DryRun || m.opts.Save == nil {
+		return nil
+	}
+	return m.opts.Save(m.mapping)
+}
+
+func normalizeStatus(status contracts.TaskStatus) contracts.TaskStatus {
+	if status == "" {
+		return contracts.TaskStatusOpen
+	}
+	return status
+}
+
+func sourceParentID(tree *contracts.TaskTree, task contracts.Task, rootID string) string {
+	parentID := strings.TrimSpace(task.ParentID)
+	if parentID == "" || parentID == task.ID {
+		return rootID
+	}
+	if _, ok := tree.Tasks[parentID]; !ok {
+		return rootID
+	}
+	return parentID
+}
+
+// dependencyIDs lists in-tree tasks that taskID depends on, from both relation
+// edges and the "dependencies" metadata key.
+func dependencyIDs(tree *contracts.TaskTree, taskID string) []string {
+	seen := map[string]struct{}{}
+	deps := []string{}
+	add := func(id string) {
+		id = strings.TrimSpace(id)
+		if id == "" || id == taskID {
+			return
+		}
+		if _, ok := tree.Tasks[id]; !ok {
+			return
+		}
+		if _, ok := seen[id]; ok {
+			return
+		}
+		seen[id] = struct{}{}
+		deps = append(deps, id)
+	}
+	for _, relation := range tree.Relations {
+		if relation.Type == contracts.RelationDependsOn && relation.FromID == taskID {
+			add(relation.ToID)
+		}
+	}
+	for _, id := range strings.Split(tree.Tasks[taskID].Metadata["dependencies"], ",") {
+		add(id)
+	}
+	sort.Strings(deps)
+	return deps
+}
@@ -0,0 +1,252 @@
+package trackersync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+func TestMirrorCreatesTreeInTargetParentsAndDependenciesFirst(t *testing.T) {
+	source := newMemoryTracker("tk")
+	source.add(contracts.Task{ID: "root", Title: "Root", Status: contracts.TaskStatusOpen})
+	source.add(contracts.Task{ID: "a", Title: "A", ParentID: "root", Status: contracts.TaskStatusOpen, Metadata: map[string]string{"dependencies": "b"}})
+	source.add(contracts.Task{ID: "b", Title: "B", ParentID: "root", Status: contracts.TaskStatusClosed})
+	source.add(contracts.Task{ID: "c", Title: "C", ParentID: "b", Status: contracts.TaskStatusOpen})
+	target := newMemoryTracker("gh")
+	mapping := &Mapping{}
+	saves := 0
+
+	actions, err := Mirror(context.Background(), source, target, "root", mapping, Options{Save: func(*Mapping) error {
+		saves++
+		return nil
+	}})
+	if err != nil {
+		t.Fatalf("mirror: %v", err)
+	}
+	if got := actionKinds(actions); got != "create root|create b|create c|create a" {
+		t.Fatalf("unexpected actions %q", got)
+	}
+	if saves != 4 {
+		t.Fatalf("expected mapping saved after each create, got %d", saves)
+	}
+	targetA := target.tasks[mapping.Tasks["a"].TargetID]
+	targetB := mapping.Tasks["b"].TargetID
+	if targetA.ParentID != mapping.Tasks["root"].TargetID || targetA.Metadata["dependencies"] != targetB {
+		t.Fatalf("expected parent and dependency links to be mapped, got %#v", targetA)
+	}
+	if target.tasks[mapping.Tasks["c"].TargetID].ParentID != targetB {
+		t.Fatalf("expected nested parent to be mapped")
+	}
+	if target.tasks[targetB].Status != contracts.TaskStatusClosed {
+		t.Fatalf("expected created task to carry source status")
+	}
+
+	actions, err = Mirror(context.Background(), source, target, "root", mapping, Options{})
+	if err != nil || len(actions) != 0 {
+		t.Fatalf("expected second sync to be a no-op, got %v %v", actions, err)
+	}
+}
+
+func TestMirrorReconcilesStatusesInBothDirections(t *testing.T) {
+	source := newMemoryTracker("tk")
+	source.add(contracts.Task{ID: "root", Title: "Root"})
+	source.add(contracts.Task{ID: "a", Title: "A", ParentID: "root"})
+	source.add(contracts.Task{ID: "b", Title: "B", ParentID: "root"})
+	source.add(contracts.Task{ID: "c", Title: "C", ParentID: "root"})
+	target := newMemoryTracker("gh")
+	mapping := &Mapping{}
+	if _, err := Mirror(context.Background(), source, target, "root", mapping, Options{}); err != nil {
+		t.Fatalf("initial mirror: %v", err)
+	}
+
+	source.setStatus("a", contracts.TaskStatusBlocked)
+	target.setStatus(mapping.Tasks["b"].TargetID, contracts.TaskStatusClosed)
+	source.setStatus("c", contracts.TaskStatusInProgress)
+	target.setStatus(mapping.Tasks["c"].TargetID, contracts.TaskStatusClosed)
+
+	actions, err := Mirror(context.Background(), source, target, "root", mapping, Options{})
+	if err != nil {
+		t.Fatalf("mirror: %v", err)
+	}
+	if got := actionKinds(actions); got != "push_status a|pull_status b|conflict c" {
+		t.Fatalf("unexpected actions %q", got)
+	}
+	if target.tasks[mapping.Tasks["a"].TargetID].Status != contracts.TaskStatusBlocked {
+		t.Fatalf("expected source status pushed to target")
+	}
+	if source.tasks["b"].Status != contracts.TaskStatusClosed || source.tasks["c"].Status != contracts.TaskStatusClosed {
+		t.Fatalf("expected target statuses to flow back to source")
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if mapping.Tasks[id].Status != source.tasks[id].Status {
+			t.Fatalf("expected mapping to record agreed status for %s, got %#v", id, mapping.Tasks[id])
+		}
+	}
+}
+
+func TestMirrorDryRunDoesNotWrite(t *testing.T) {
+	source := newMemoryTracker("tk")
+	source.add(contracts.Task{ID: "root", Title: "Root"})
+	source.add(contracts.Task{ID: "a", Title: "A", ParentID: "root"})
+	target := newMemoryTracker("gh")
+	target.add(contracts.Task{ID: "gh-9", Title: "Existing"})
+	mapping := &Mapping{}
+
+	actions, err := Mirror(context.Background(), source, target, "root", mapping, Options{TargetRootID: "gh-9", DryRun: true})
+	if err != nil {
+		t.Fatalf("mirror: %v", err)
+	}
+	if got := actionKinds(actions); got != "create a" {
+		t.Fatalf("unexpected planned actions %q", got)
+	}
+	if len(mapping.Tasks) != 0 || len(target.tasks) != 1 {
+		t.Fatalf("expected dry run to leave mapping and target untouched")
+	}
+}
+
+func TestMirrorLinksExistingTargetRoot(t *testing.T) {
+	source := newMemoryTracker("tk")
+	source.add(contracts.Task{ID: "root", Title: "Root", Status: contracts.TaskStatusInProgress})
+	source.add(contracts.Task{ID: "a", Title: "A", ParentID: "root"})
+	target := newMemoryTracker("gh")
+	target.add(contracts.Task{ID: "gh-9", Title: "Existing", Status: contracts.TaskStatusOpen})
+	mapping := &Mapping{}
+
+	actions, err := Mirror(context.Background(), source, target, "root", mapping, Options{TargetRootID: "gh-9"})
+	if err != nil {
+		t.Fatalf("mirror: %v", err)
+	}
+	if got := actionKinds(actions); got != "create a|push_status root" {
+		t.Fatalf("unexpected actions %q", got)
+	}
+	if target.tasks[mapping.Tasks["a"].TargetID].ParentID != "gh-9" {
+		t.Fatalf("expected child created under existing target root")
+	}
+	if target.tasks["gh-9"].Status != contracts.TaskStatusInProgress {
+		t.Fatalf("expected source root status pushed on first sync")
+	}
+}
+
+func TestMirrorRequiresTaskCreatorOnTarget(t *testing.T) {
+	source := newMemoryTracker("tk")
+	source.add(contracts.Task{ID: "root", Title: "Root"})
+	target := readOnlyTracker{newMemoryTracker("gh")}
+
+	_, err := Mirror(context.Background(), source, target, "root", &Mapping{}, Options{})
+	if err == nil || !strings.Contains(err.Error(), "does not support creating tasks") {
+		t.Fatalf("expected creator error, got %v", err)
+	}
+}
+
+func actionKinds(actions []Action) string {
+	parts := make([]string, 0, len(actions))
+	for _, action := range actions {
+		parts = append(parts, string(action.Kind)+" "+action.SourceID)
+	}
+	return strings.Join(parts, "|")
+}
+
+type memoryTracker struct {
+	prefix string
+	next   int
+	tasks  map[string]contracts.Task
+}
+
+func newMemoryTracker(prefix string) *memoryTracker {
+	return &memoryTracker{prefix: prefix, tasks: map[string]contracts.Task{}}
+}
+
+func (m *memoryTracker) add(task contracts.Task) {
+	if task.Status == "" {
+		task.Status = contracts.TaskStatusOpen
+	}
+	m.tasks[task.ID] = task
+}
+
+func (m *memoryTracker) setStatus(id string, status contracts.TaskStatus) {
+	task := m.tasks[id]
+	task.Status = status
+	m.tasks[id] = task
+}
+
+func (m *memoryTracker) GetTaskTree(_ context.Context, rootID string) (*contracts.TaskTree, error) {
+	root, ok := m.tasks[rootID]
+	if !ok {
+		return nil, fmt.Errorf("root %q not found", rootID)
+	}
+	tasks := map[string]contracts.Task{rootID: root}
+	ids := make([]string, 0, len(m.tasks))
+	for id := range m.tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for changed := true; changed; {
+		changed = false
+		for _, id := range ids {
+			task := m.tasks[id]
+			if _, in := tasks[id]; in {
+				continue
+			}
+			if _, parentIn := tasks[task.ParentID]; parentIn {
+				tasks[id] = task
+				changed = true
+			}
+		}
+	}
+	return &contracts.TaskTree{Root: root, Tasks: tasks}, nil
+}
+
+func (m *memoryTracker) GetTask(_ context.Context, taskID string) (*contracts.Task, error) {
+	task, ok := m.tasks[taskID]
+	if !ok {
+		return nil, nil
+	}
+	return &task, nil
+}
+
+func (m *memoryTracker) SetTaskStatus(_ context.Context, taskID string, status contracts.TaskStatus) error {
+	if _, ok := m.tasks[taskID]; !ok {
+		return fmt.Errorf("task %q not found", taskID)
+	}
+	m.setStatus(taskID, status)
+	return nil
+}
+
+func (m *memoryTracker) SetTaskData(context.Context, string, map[string]string) error {
+	return nil
+}
+
+func (m *memoryTracker) CreateTask(_ context.Context, request contracts.TaskCreateRequest) (string, error) {
+	m.next++
+	id := fmt.Sprintf("%s-%d", m.prefix, m.next)
+	task := contracts.Task{ID: id, Title: request.Title, Description: request.Description, ParentID: request.ParentID}
+	if len(request.DependsOn) > 0 {
+		task.Metadata = map[string]string{"dependencies": strings.Join(request.DependsOn, ",")}
+	}
+	m.add(task)
+	return id, nil
+}
+
+type readOnlyTracker struct {
+	inner *memoryTracker
+}
+
+func (r readOnlyTracker) GetTaskTree(ctx context.Context, rootID string) (*contracts.TaskTree, error) {
+	return r.inner.GetTaskTree(ctx, rootID)
+}
+
+func (r readOnlyTracker) GetTask(ctx context.Context, taskID string) (*contracts.Task, error) {
+	return r.inner.GetTask(ctx, taskID)
+}
+
+func (r readOnlyTracker) SetTaskStatus(ctx context.Context, taskID string, status contracts.TaskStatus) error {
+	return r.inner.SetTaskStatus(ctx, taskID, status)
+}
+
+func (r readOnlyTracker) SetTaskData(ctx context.Context, taskID string, data map[string]string) error {
+	return r.inner.SetTaskData(ctx, taskID, data)
+}
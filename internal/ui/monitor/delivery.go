@@ -0,0 +1,56 @@
+package monitor
+
+import "github.com/egv/yolo-runner/v2/internal/contracts"
+
+// maxTrackedGaps bounds how many missing sequence numbers are remembered per
+// run so a badly lossy stream cannot grow the tracker without limit.
+const maxTrackedGaps = 1024
+
+// deliveryTracker follows event sequence numbers per run to detect events
+// lost or repeated between the agent and the monitor.
+type deliveryTracker struct {
+	runs       map[string]*runDelivery
+	missing    int
+	duplicates int
+}
+
+type runDelivery struct {
+	last uint64
+	gaps map[uint64]struct{}
+}
+
+// accept records event and reports whether it should be applied. Unsequenced
+// events are always accepted; repeats of an already seen sequence number are
+// counted and rejected.
+func (t *deliveryTracker) accept(event contracts.Event) bool {
+	if event.Seq == 0 {
+		return true
+	}
+	if t.runs == nil {
+		t.runs = map[string]*runDelivery{}
+	}
+	run := t.runs[event.RunID]
+	if run == nil {
+		run = &runDelivery{gaps: map[uint64]struct{}{}}
+		t.runs[event.RunID] = run
+	}
+	switch {
+	case event.Seq > run.last:
+		for seq := run.last + 1; seq < event.Seq; seq++ {
+			t.missing++
+			if len(run.gaps) < maxTrackedGaps {
+				run.gaps[seq] = struct{}{}
+			}
+		}
+		run.last = event.Seq
+		return true
+	default:
+		if _, late := run.gaps[event.Seq]; late {
+			delete(run.gaps, event.Seq)
+			t.missing--
+			return true
+		}
+		t.duplicates++
+		return false
+	}
+}
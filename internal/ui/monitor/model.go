@@ -32,7 +32,12 @@ type Model struct {
 	workers            map[string]workerLane
 	landing            map[string]landingState
 	triage             map[string]triageState
+	flakyTests         map[string][]string
 	queueFilter        string
+	delivery           deliveryTracker
+	// runEstimate is when the latest run_estimate expects the run to be
+	// done; it is cleared when the run finishes.
+	runEstimate time.Time
 }
 
 type Snapshot struct {
@@ -44,14 +49,19 @@ type PerformanceSnapshot struct {
 	TotalPanelRows     int
 	VisiblePanelRows   int
 	PanelRowsTruncated bool
+	// MissingEvents and DuplicateEvents count sequence gaps and repeats seen
+	// in the event stream.
+	MissingEvents   int
+	DuplicateEvents int
 }
 
 type UIState struct {
 	CurrentTask       string
 	Phase             string
 	LastOutputAge     string
+	ETA               string
 	StatusSummary     string
-	StatusMetrics     statusMetrics
+	StatusMetrics     statusMetrics `json:"-"`
 	CompletedCount    int
 	TotalCount        int
 	StatusBar         []string
@@ -92,6 +102,7 @@ type UIWorkerSummary struct {
 	LastActivityAge  string
 	Severity         string
 	RecentTaskEvents []string
+	PlanChecklist    []string
 }
 
 type RunState struct {
@@ -120,6 +131,8 @@ type TaskState struct {
 	Stage                contracts.TaskStage
 	LastMessage          string
 	LastUpdateAt         time.Time
+	StartedAt            time.Time
+	FinishedAt           time.Time
 	CommandStartedCount  int
 	CommandFinishedCount int
 	OutputCount          int
@@ -133,6 +146,13 @@ type TaskState struct {
 	LastCommandStarted   string
 	LastCommandSummary   string
 	LastSeverity         string
+	Plan                 []contracts.PlanEntry
+	ActiveToolCallID     string
+	ActiveToolCall       string
+	// EstimatedDuration is how long the task was expected to take when it
+	// started; EstimatedCompletion is zero without an estimate.
+	EstimatedDuration   time.Duration
+	EstimatedCompletion time.Time
 }
 
 type workerLane struct {
@@ -152,6 +172,7 @@ type triageState struct {
 	taskID    string
 	taskTitle string
 	status    string
+	category  string
 	reason    string
 }
 
@@ -184,6 +205,7 @@ func NewModel(now func() time.Time) *Model {
 		workers:        map[string]workerLane{},
 		landing:        map[string]landingState{},
 		triage:         map[string]triageState{},
+		flakyTests:     map[string][]string{},
 		queueFilter:    queueFilterAll,
 	}
 }
@@ -221,6 +243,8 @@ func (m *Model) PerformanceSnapshot() PerformanceSnapshot {
 		TotalPanelRows:     len(rows),
 		VisiblePanelRows:   visible,
 		PanelRowsTruncated: m.panelRowsTruncated || len(rows) > visible,
+		MissingEvents:      m.delivery.missing,
+		DuplicateEvents:    m.delivery.duplicates,
 	}
 }
 
@@ -270,6 +294,9 @@ func (m *Model) HandleKey(key string) {
 }
 
 func (m *Model) Apply(event contracts.Event) {
+	if !m.delivery.accept(event) {
+		return
+	}
 	m.eventCount++
 	if event.TaskID != "" {
 		m.currentTask = event.TaskID
@@ -356,16 +383,28 @@ func (m *Model) Apply(event contracts.Event) {
 		if taskID != "" {
 			status := normalizeTriageStatus(event.Metadata["triage_status"])
 			reason := strings.TrimSpace(event.Metadata["triage_reason"])
+			if flaky := strings.TrimSpace(event.Metadata["qc_flaky_tests"]); flaky != "" {
+				m.flakyTests[taskID] = strings.Split(flaky, ",")
+			}
 			if status != "" || reason != "" {
 				m.triage[taskID] = triageState{
 					taskID:    taskID,
 					taskTitle: strings.TrimSpace(event.TaskTitle),
 					status:    status,
+					category:  string(contracts.FailureCategoryFromMetadata(event.Metadata)),
 					reason:    reason,
 				}
 			}
 		}
 	}
+	if event.Type == contracts.EventTypeRunEstimate {
+		if completion, err := time.Parse(time.RFC3339, strings.TrimSpace(event.Metadata["estimated_completion"])); err == nil {
+			m.runEstimate = completion
+		}
+	}
+	if event.Type == contracts.EventTypeRunFinished {
+		m.runEstimate = time.Time{}
+	}
 	if event.Type == contracts.EventTypeRunStarted {
 		m.root.RunID = strings.TrimSpace(event.Metadata["root_id"])
 		if !event.Timestamp.IsZero() {
@@ -426,6 +465,7 @@ func applyDerivedTaskEvent(task *TaskState, event contracts.Event) {
 	if stage, ok := deriveTaskStage(event.Type); ok {
 		task.Stage = stage
 	}
+	applyToolCallEvent(task, event)
 	if lifecycleEventTypes[event.Type] {
 		task.StatusBuf = contracts.AppendStatusEntry(task.StatusBuf, contracts.StatusEntry{
 			EventType: string(event.Type),
@@ -433,6 +473,15 @@ func applyDerivedTaskEvent(task *TaskState, event contracts.Event) {
 		})
 	}
 	switch event.Type {
+	case contracts.EventTypeTaskStarted:
+		// Retries emit task_started again; the task's duration spans them all.
+		if task.StartedAt.IsZero() {
+			task.StartedAt = event.Timestamp
+		}
+		if completion, err := time.Parse(time.RFC3339, strings.TrimSpace(event.Metadata["estimated_completion"])); err == nil {
+			task.EstimatedCompletion = completion
+			task.EstimatedDuration, _ = time.ParseDuration(strings.TrimSpace(event.Metadata["estimated_duration"]))
+		}
 	case contracts.EventTypeRunnerCommandStarted:
 		task.CommandStartedCount++
 		task.LastCommandStarted = strings.TrimSpace(event.Message)
@@ -466,6 +515,14 @@ func applyDerivedTaskEvent(task *TaskState, event contracts.Event) {
 		case lastOutputAge != "":
 			task.LastMessage = "heartbeat: last output " + lastOutputAge
 		}
+	case contracts.EventTypePlanUpdated:
+		task.Plan = contracts.PlanEntriesFromMetadata(event.Metadata)
+	case contracts.EventTypeRateLimited:
+		task.WarningCount++
+		task.WarningActive = true
+		task.LastSeverity = "warning"
+		task.LastMessage = "rate limited: resumes in " + strings.TrimSpace(event.Metadata["backoff"])
+		task.WarningBuf = contracts.AppendWarningEntry(task.WarningBuf, contracts.WarningEntry{Message: event.Message})
 	case contracts.EventTypeRunnerWarning:
 		task.WarningCount++
 		task.WarningActive = true
@@ -481,6 +538,7 @@ func applyDerivedTaskEvent(task *TaskState, event contracts.Event) {
 			task.LastMessage = strings.TrimSpace(event.Message) + " | " + reason
 		}
 	case contracts.EventTypeTaskFinished:
+		task.FinishedAt = event.Timestamp
 		task.TerminalStatus = strings.TrimSpace(event.Message)
 		task.LastSeverity = severityFromTerminalStatus(task.TerminalStatus)
 		if reason := strings.TrimSpace(event.Metadata["triage_reason"]); reason != "" {
@@ -489,6 +547,30 @@ func applyDerivedTaskEvent(task *TaskState, event contracts.Event) {
 	}
 }
 
+// applyToolCallEvent tracks the tool call a backend is currently running from
+// tool call metadata, e.g. "editing internal/agent/loop.go".
+func applyToolCallEvent(task *TaskState, event contracts.Event) {
+	call, ok := contracts.ToolCallFromMetadata(event.Metadata)
+	if !ok {
+		return
+	}
+	switch call.Status {
+	case contracts.ToolCallStatusCompleted, contracts.ToolCallStatusFailed:
+		if call.ID == task.ActiveToolCallID {
+			task.ActiveToolCallID = ""
+			task.ActiveToolCall = ""
+		}
+		return
+	}
+	if call.Kind == "" {
+		return
+	}
+	if label := contracts.DescribeToolCall(call, event.ClonePath); label != "" {
+		task.ActiveToolCallID = call.ID
+		task.ActiveToolCall = label
+	}
+}
+
 func (m *Model) Snapshot() Snapshot {
 	workers := map[string]WorkerState{}
 	for id, worker := range m.root.Workers {
@@ -512,6 +594,7 @@ func (m *Model) UIState() UIState {
 		CurrentTask:       renderCurrentTask(m.currentTask, m.currentTitle),
 		Phase:             emptyAsNA(m.phase),
 		LastOutputAge:     ageSince(m.now(), m.lastOutputAt),
+		ETA:               timeUntil(m.now(), m.runEstimate),
 		StatusSummary:     fmt.Sprintf("⏱ %s  %s  ✅%d  🟡%d  ❌%d/%d  👷 %d%%  📦 %d  ⚡ %s", metrics.runtime, metrics.activity, metrics.completed, metrics.blocked, metrics.failed, metrics.total, metrics.workerUtilization, metrics.queueDepth, metrics.throughput),
 		StatusMetrics:     metrics,
 		CompletedCount:    metrics.completed,
@@ -578,6 +661,9 @@ func (m *Model) uiWorkerSummaries() []UIWorkerSummary {
 			lastEvent = emptyAsNA(task.RunnerPhase)
 		}
 		recent := []string{}
+		if active := strings.TrimSpace(task.ActiveToolCall); active != "" {
+			recent = append(recent, "🔧 "+active)
+		}
 		if summary := strings.TrimSpace(task.LastCommandSummary); summary != "" {
 			recent = append(recent, "🛠 "+summary)
 		}
@@ -600,11 +686,32 @@ func (m *Model) uiWorkerSummaries() []UIWorkerSummary {
 			LastActivityAge:  ageSince(m.now(), task.LastUpdateAt),
 			Severity:         deriveTaskSeverity(task),
 			RecentTaskEvents: recent,
+			PlanChecklist:    renderPlanChecklist(task.Plan),
 		})
 	}
 	return workers
 }
 
+// renderPlanChecklist shows the agent's latest plan as "[x]" done, "[~]" in
+// progress and "[ ]" pending entries.
+func renderPlanChecklist(entries []contracts.PlanEntry) []string {
+	if len(entries) == 0 {
+		return nil
+	}
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		mark := "[ ]"
+		switch entry.Status {
+		case contracts.PlanEntryCompleted:
+			mark = "[x]"
+		case contracts.PlanEntryInProgress:
+			mark = "[~]"
+		}
+		lines = append(lines, mark+" "+strings.TrimSpace(entry.Content))
+	}
+	return lines
+}
+
 func ageSince(now time.Time, when time.Time) string {
 	if when.IsZero() {
 		return "n/a"
@@ -616,6 +723,19 @@ func ageSince(now time.Time, when time.Time) string {
 	return fmt.Sprintf("%ds", seconds)
 }
 
+// timeUntil renders the time left until when, or "" when when is unset. An
+// estimate that has passed shows as 0s rather than a negative time.
+func timeUntil(now time.Time, when time.Time) string {
+	if when.IsZero() {
+		return ""
+	}
+	left := when.Sub(now).Round(time.Second)
+	if left < 0 {
+		left = 0
+	}
+	return left.String()
+}
+
 func (m *Model) View() string {
 	age := "n/a"
 	if !m.lastOutputAt.IsZero() {
@@ -883,7 +1003,11 @@ func panelWindow(total int, cursor int, viewportHeight int) (int, int) {
 
 func renderPerformance(perf PerformanceSnapshot) []string {
 	line := fmt.Sprintf("- history_size=%d panel_rows=%d/%d truncated=%t", perf.HistorySize, perf.VisiblePanelRows, perf.TotalPanelRows, perf.PanelRowsTruncated)
-	return []string{line}
+	lines := []string{line}
+	if perf.MissingEvents > 0 || perf.DuplicateEvents > 0 {
+		lines = append(lines, fmt.Sprintf("- events missing=%d duplicates=%d", perf.MissingEvents, perf.DuplicateEvents))
+	}
+	return lines
 }
 
 func sortedWorkerIDs(workers map[string]WorkerState) []string {
@@ -1372,6 +1496,9 @@ func renderTaskDetails(task TaskState) []string {
 	if task.TerminalStatus != "" {
 		lines = append(lines, "  terminal="+task.TerminalStatus)
 	}
+	if !task.EstimatedCompletion.IsZero() {
+		lines = append(lines, fmt.Sprintf("  estimate=%s eta=%s", task.EstimatedDuration, task.EstimatedCompletion.UTC().Format(time.RFC3339)))
+	}
 	return lines
 }
 
@@ -1531,6 +1658,9 @@ func renderTriage(triage map[string]triageState) []string {
 		status := emptyAsNA(entry.status)
 		reason := strings.TrimSpace(entry.reason)
 		line := "- " + renderCurrentTask(entry.taskID, entry.taskTitle) + " => " + status
+		if entry.category != "" {
+			line += " [" + entry.category + "]"
+		}
 		if reason != "" {
 			line += " | " + reason
 		}
@@ -1,7 +1,10 @@
 package monitor
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -77,15 +80,16 @@ func TestModelNormalizesTriagePanelData(t *testing.T) {
 		TaskID:    "task-1",
 		TaskTitle: "First",
 		Metadata: map[string]string{
-			"triage_status": " Failed ",
-			"triage_reason": "  lint failed  ",
+			"triage_status":   " Failed ",
+			"triage_reason":   "  lint failed  ",
+			"triage_category": "quality_gate",
 		},
 		Timestamp: now.Add(-2 * time.Second),
 	})
 
 	view := model.View()
 	assertContains(t, view, "Triage:")
-	assertContains(t, view, "task-1 - First => failed | lint failed")
+	assertContains(t, view, "task-1 - First => failed [quality_gate] | lint failed")
 }
 
 func TestModelStoresRunParametersFromRunStartedEvent(t *testing.T) {
@@ -310,6 +314,64 @@ func TestModelSurfacesActiveOpencodeProgressDuringHeartbeat(t *testing.T) {
 	}
 }
 
+func TestModelKeepsLatestAgentPlanAsChecklist(t *testing.T) {
+	now := time.Date(2026, 2, 10, 12, 6, 45, 0, time.UTC)
+	model := NewModel(func() time.Time { return now })
+
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-5", TaskTitle: "Planned", WorkerID: "worker-1", Timestamp: now})
+	model.Apply(contracts.Event{Type: contracts.EventTypePlanUpdated, TaskID: "task-5", WorkerID: "worker-1", Message: "plan 0/2 completed", Timestamp: now, Metadata: contracts.PlanUpdatedMetadata([]contracts.PlanEntry{
+		{Content: "read code", Status: contracts.PlanEntryInProgress},
+		{Content: "write fix", Status: contracts.PlanEntryPending},
+	})})
+	model.Apply(contracts.Event{Type: contracts.EventTypePlanUpdated, TaskID: "task-5", WorkerID: "worker-1", Message: "plan 1/3 completed", Timestamp: now, Metadata: contracts.PlanUpdatedMetadata([]contracts.PlanEntry{
+		{Content: "read code", Status: contracts.PlanEntryCompleted},
+		{Content: "write fix", Status: contracts.PlanEntryInProgress},
+		{Content: "run tests", Status: contracts.PlanEntryPending},
+	})})
+
+	worker := model.UIState().WorkerSummaries[0]
+	want := []string{"[x] read code", "[~] write fix", "[ ] run tests"}
+	if strings.Join(worker.PlanChecklist, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected latest plan checklist %#v, got %#v", want, worker.PlanChecklist)
+	}
+}
+
+func TestModelShowsActiveToolCallFromMetadata(t *testing.T) {
+	now := time.Date(2026, 2, 10, 12, 6, 50, 0, time.UTC)
+	model := NewModel(func() time.Time { return now })
+
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-6", WorkerID: "worker-1", Timestamp: now})
+	model.Apply(contracts.Event{
+		Type:      contracts.EventTypeRunnerCommandStarted,
+		TaskID:    "task-6",
+		WorkerID:  "worker-1",
+		ClonePath: "/clones/task-6",
+		Message:   "Edit loop.go",
+		Metadata:  contracts.ApplyToolCallMetadata(nil, contracts.ToolCall{ID: "call-1", Kind: "edit", Status: "in_progress", Locations: []string{"/clones/task-6/internal/agent/loop.go"}}),
+		Timestamp: now,
+	})
+
+	worker := model.UIState().WorkerSummaries[0]
+	if len(worker.RecentTaskEvents) == 0 || worker.RecentTaskEvents[0] != "🔧 editing internal/agent/loop.go" {
+		t.Fatalf("expected active tool call in recent events, got %#v", worker.RecentTaskEvents)
+	}
+
+	model.Apply(contracts.Event{
+		Type:      contracts.EventTypeRunnerCommandFinished,
+		TaskID:    "task-6",
+		WorkerID:  "worker-1",
+		Message:   "Edit loop.go",
+		Metadata:  contracts.ApplyToolCallMetadata(nil, contracts.ToolCall{ID: "call-1", Status: "completed"}),
+		Timestamp: now,
+	})
+	worker = model.UIState().WorkerSummaries[0]
+	for _, item := range worker.RecentTaskEvents {
+		if strings.HasPrefix(item, "🔧") {
+			t.Fatalf("expected finished tool call to be cleared, got %#v", worker.RecentTaskEvents)
+		}
+	}
+}
+
 func TestModelDerivesWarningLifecycleAsActiveThenResolved(t *testing.T) {
 	now := time.Date(2026, 2, 10, 12, 7, 0, 0, time.UTC)
 	model := NewModel(func() time.Time { return now })
@@ -334,6 +396,21 @@ func TestModelDerivesWarningLifecycleAsActiveThenResolved(t *testing.T) {
 	}
 }
 
+func TestModelMarksRateLimitedTaskAsWarning(t *testing.T) {
+	now := time.Date(2026, 2, 10, 12, 7, 30, 0, time.UTC)
+	model := NewModel(func() time.Time { return now })
+
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-4", TaskTitle: "Fourth", WorkerID: "worker-1", Timestamp: now.Add(-5 * time.Second)})
+	model.Apply(contracts.Event{Type: contracts.EventTypeRateLimited, TaskID: "task-4", WorkerID: "worker-1", Message: "provider rate limit; pausing dispatch for 30s", Metadata: map[string]string{"backoff": "30s"}, Timestamp: now})
+	task := model.Snapshot().Root.Tasks["task-4"]
+	if !task.WarningActive || task.WarningCount != 1 {
+		t.Fatalf("expected rate limit to count as an active warning, got %#v", task)
+	}
+	if task.LastMessage != "rate limited: resumes in 30s" {
+		t.Fatalf("expected rate limit message, got %q", task.LastMessage)
+	}
+}
+
 func TestModelRendersStatusBarMetrics(t *testing.T) {
 	now := time.Date(2026, 2, 10, 12, 8, 0, 0, time.UTC)
 	model := NewModel(func() time.Time { return now })
@@ -1282,3 +1359,160 @@ func TestStageIcon(t *testing.T) {
 		})
 	}
 }
+
+func TestModelDetectsSequenceGapsAndDropsDuplicates(t *testing.T) {
+	now := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	model := NewModel(func() time.Time { return now })
+
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1", RunID: "run-1", Seq: 1, Timestamp: now})
+	model.Apply(contracts.Event{Type: contracts.EventTypeRunnerStarted, TaskID: "task-1", RunID: "run-1", Seq: 4, Timestamp: now})
+	model.Apply(contracts.Event{Type: contracts.EventTypeRunnerFinished, TaskID: "task-1", RunID: "run-1", Seq: 4, Timestamp: now})
+
+	perf := model.PerformanceSnapshot()
+	if perf.MissingEvents != 2 || perf.DuplicateEvents != 1 {
+		t.Fatalf("expected 2 missing and 1 duplicate, got %#v", perf)
+	}
+	assertContains(t, model.View(), "Phase: runner_started")
+	assertContains(t, model.View(), "- events missing=2 duplicates=1")
+
+	model.Apply(contracts.Event{Type: contracts.EventTypeRunnerOutput, TaskID: "task-1", RunID: "run-1", Seq: 2, Timestamp: now})
+	if perf := model.PerformanceSnapshot(); perf.MissingEvents != 1 || perf.DuplicateEvents != 1 {
+		t.Fatalf("expected late event to fill one gap, got %#v", perf)
+	}
+
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-2", RunID: "run-2", Seq: 1, Timestamp: now})
+	if perf := model.PerformanceSnapshot(); perf.MissingEvents != 1 || perf.DuplicateEvents != 1 {
+		t.Fatalf("expected a new run to start its own sequence, got %#v", perf)
+	}
+}
+
+func TestModelOmitsDeliveryLineForUnsequencedEvents(t *testing.T) {
+	now := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	model := NewModel(func() time.Time { return now })
+
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1", Timestamp: now})
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1", Timestamp: now})
+
+	if strings.Contains(model.View(), "- events missing=") {
+		t.Fatalf("did not expect delivery line for unsequenced events:\n%s", model.View())
+	}
+}
+
+func TestWriteStateSnapshotRoundTripsAggregatedState(t *testing.T) {
+	now := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	model := NewModel(func() time.Time { return now })
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1", TaskTitle: "First", WorkerID: "worker-0", Timestamp: now})
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: "task-1", TaskTitle: "First", WorkerID: "worker-0", Message: "completed", Timestamp: now})
+
+	path := filepath.Join(t.TempDir(), "state", "snapshot.json")
+	if err := WriteStateSnapshot(path, model.StateSnapshot()); err != nil {
+		t.Fatalf("write snapshot: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read snapshot: %v", err)
+	}
+	var decoded StateSnapshot
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decode snapshot: %v", err)
+	}
+	if !decoded.GeneratedAt.Equal(now) || decoded.EventCount != 2 {
+		t.Fatalf("unexpected snapshot header: %#v", decoded)
+	}
+	if decoded.Counts.Completed != 1 || decoded.Counts.Total != 1 {
+		t.Fatalf("expected one completed task, got %#v", decoded.Counts)
+	}
+	if decoded.State.Phase != "task_finished" || len(decoded.State.WorkerSummaries) != 1 {
+		t.Fatalf("expected UI state in snapshot, got %#v", decoded.State)
+	}
+	if strings.Contains(string(data), "StatusMetrics") {
+		t.Fatalf("did not expect unexported status metrics in snapshot:\n%s", data)
+	}
+}
+
+func TestFinalSummaryTabulatesTasksOutcomesAndDurations(t *testing.T) {
+	start := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	model := NewModel(func() time.Time { return start })
+	model.Apply(contracts.Event{Type: contracts.EventTypeRunStarted, Metadata: map[string]string{"root_id": "root-1"}, Timestamp: start})
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1", TaskTitle: "First", WorkerID: "worker-0", Timestamp: start})
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-2", TaskTitle: "Second", WorkerID: "worker-1", Timestamp: start.Add(time.Second)})
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: "task-1", Message: "completed", Timestamp: start.Add(90 * time.Second)})
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: "task-2", Message: "failed", Timestamp: start.Add(2 * time.Minute)})
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: "task-2", Metadata: map[string]string{"triage_status": "failed", "triage_reason": "tests failed"}, Timestamp: start.Add(2 * time.Minute)})
+
+	summary := model.FinalSummary()
+	if strings.Contains(summary, "\x1b[") {
+		t.Fatalf("expected ANSI-free summary, got %q", summary)
+	}
+	for _, want := range []string{
+		"root      root-1",
+		"duration  2m0s",
+		"completed  1",
+		"failed     1",
+		"task-1  First   worker-0  completed  1m30s",
+		"task-2  Second  worker-1  failed     1m59s",
+		"- task-2: tests failed",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Fatalf("expected %q in summary:\n%s", want, summary)
+		}
+	}
+	if strings.Index(summary, "task-1  First") > strings.Index(summary, "task-2  Second") {
+		t.Fatalf("expected tasks in start order:\n%s", summary)
+	}
+}
+
+func TestModelTracksTaskAndRunEstimates(t *testing.T) {
+	start := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	now := start.Add(2 * time.Minute)
+	model := NewModel(func() time.Time { return now })
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1", TaskTitle: "First", WorkerID: "worker-0", Metadata: map[string]string{"task_type": "bug", "estimated_duration": "10m0s", "estimated_completion": "2026-02-10T12:10:00Z"}, Timestamp: start})
+	model.Apply(contracts.Event{Type: contracts.EventTypeRunEstimate, Metadata: map[string]string{"estimated_remaining": "15m0s", "estimated_completion": "2026-02-10T12:15:00Z"}, Timestamp: start})
+
+	state := model.UIState()
+	if state.ETA != "13m0s" {
+		t.Fatalf("expected 13m left until the run estimate, got %q", state.ETA)
+	}
+	if !strings.Contains(strings.Join(state.TaskDetails, "\n"), "estimate=10m0s eta=2026-02-10T12:10:00Z") {
+		t.Fatalf("expected the task estimate in details, got %#v", state.TaskDetails)
+	}
+	summary := model.FinalSummary()
+	if !strings.Contains(summary, "eta       2026-02-10T12:15:00Z") || !strings.Contains(summary, "unfinished  -         10m0s") {
+		t.Fatalf("expected run and task estimates in summary:\n%s", summary)
+	}
+
+	model.Apply(contracts.Event{Type: contracts.EventTypeRunFinished, Timestamp: now})
+	if state := model.UIState(); state.ETA != "" {
+		t.Fatalf("expected no ETA once the run finished, got %q", state.ETA)
+	}
+}
+
+func TestFinalSummaryListsFlakyTestsSeparately(t *testing.T) {
+	now := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	model := NewModel(func() time.Time { return now })
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1", TaskTitle: "First", Timestamp: now})
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskDataUpdated, TaskID: "task-1", Metadata: map[string]string{"qc_gate_status": "passed", "qc_flaky_tests": "TestRetry,TestTimeout"}, Timestamp: now})
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskFinished, TaskID: "task-1", Message: "completed", Timestamp: now.Add(time.Minute)})
+
+	summary := model.FinalSummary()
+	if !strings.Contains(summary, "Flaky tests\n- task-1: TestRetry, TestTimeout") {
+		t.Fatalf("expected flaky tests section:\n%s", summary)
+	}
+	if strings.Contains(summary, "Triage") {
+		t.Fatalf("did not expect flaky tests under triage:\n%s", summary)
+	}
+}
+
+func TestFinalSummaryMarksUnfinishedTasks(t *testing.T) {
+	now := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	model := NewModel(func() time.Time { return now })
+	model.Apply(contracts.Event{Type: contracts.EventTypeTaskStarted, TaskID: "task-1", TaskTitle: strings.Repeat("long title ", 10), Timestamp: now})
+
+	summary := model.FinalSummary()
+	if !strings.Contains(summary, "unfinished  -") {
+		t.Fatalf("expected unfinished task without duration:\n%s", summary)
+	}
+	if strings.Contains(summary, strings.Repeat("long title ", 5)) || !strings.Contains(summary, "...") {
+		t.Fatalf("expected long title to be truncated:\n%s", summary)
+	}
+}
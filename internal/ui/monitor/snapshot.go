@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StateSnapshot is the monitor's aggregated state in a serializable form, so
+// external tools can poll the current run state without replaying events.
+type StateSnapshot struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	EventCount  int          `json:"event_count"`
+	Counts      StatusCounts `json:"counts"`
+	State       UIState      `json:"state"`
+}
+
+// StatusCounts are the numeric status bar metrics.
+type StatusCounts struct {
+	Completed         int `json:"completed"`
+	InProgress        int `json:"in_progress"`
+	Blocked           int `json:"blocked"`
+	Failed            int `json:"failed"`
+	Total             int `json:"total"`
+	QueueDepth        int `json:"queue_depth"`
+	WorkerUtilization int `json:"worker_utilization"`
+	MissingEvents     int `json:"missing_events"`
+	DuplicateEvents   int `json:"duplicate_events"`
+}
+
+func (m *Model) StateSnapshot() StateSnapshot {
+	state := m.UIState()
+	metrics := state.StatusMetrics
+	return StateSnapshot{
+		GeneratedAt: m.now().UTC(),
+		EventCount:  m.eventCount,
+		Counts: StatusCounts{
+			Completed:         metrics.completed,
+			InProgress:        metrics.inProgress,
+			Blocked:           metrics.blocked,
+			Failed:            metrics.failed,
+			Total:             metrics.total,
+			QueueDepth:        metrics.queueDepth,
+			WorkerUtilization: metrics.workerUtilization,
+			MissingEvents:     m.delivery.missing,
+			DuplicateEvents:   m.delivery.duplicates,
+		},
+		State: state,
+	}
+}
+
+// WriteStateSnapshot writes snapshot as JSON to path. The file is replaced
+// atomically, so readers never see a partial snapshot.
+func WriteStateSnapshot(path string, snapshot StateSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
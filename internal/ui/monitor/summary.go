@@ -0,0 +1,154 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const summaryTitleWidth = 48
+
+// FinalSummary renders the run as plain-text tables of tasks, outcomes and
+// durations. It carries no ANSI styling, so it reads the same in CI logs and
+// PR comments.
+func (m *Model) FinalSummary() string {
+	tasks := m.summaryTasks()
+	outcomes := map[string]int{}
+	for _, task := range tasks {
+		outcomes[summaryOutcome(task)]++
+	}
+
+	out := &strings.Builder{}
+	fmt.Fprintln(out, "Run Summary")
+	run := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(run, "root\t%s\n", emptyAsNA(m.root.RunID))
+	fmt.Fprintf(run, "tasks\t%d\n", len(tasks))
+	fmt.Fprintf(run, "duration\t%s\n", formatSummaryDuration(m.runStartedAt, m.lastOutputAt))
+	fmt.Fprintf(run, "events\t%d\n", m.eventCount)
+	if !m.runEstimate.IsZero() {
+		fmt.Fprintf(run, "eta\t%s\n", m.runEstimate.UTC().Format(time.RFC3339))
+	}
+	if m.delivery.missing > 0 || m.delivery.duplicates > 0 {
+		fmt.Fprintf(run, "delivery\tmissing=%d duplicates=%d\n", m.delivery.missing, m.delivery.duplicates)
+	}
+	_ = run.Flush()
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Outcomes")
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "OUTCOME\tTASKS")
+	names := make([]string, 0, len(outcomes))
+	for name := range outcomes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(table, "%s\t%d\n", name, outcomes[name])
+	}
+	_ = table.Flush()
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Tasks")
+	table = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "TASK\tTITLE\tWORKER\tOUTCOME\tDURATION\tESTIMATE\tREVIEWS\tWARNINGS")
+	for _, task := range tasks {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\n",
+			task.TaskID,
+			truncateSummaryField(task.Title, summaryTitleWidth),
+			emptyAsNA(task.WorkerID),
+			summaryOutcome(task),
+			formatSummaryDuration(task.StartedAt, task.FinishedAt),
+			formatSummaryEstimate(task),
+			task.ReviewCount,
+			task.WarningCount,
+		)
+	}
+	_ = table.Flush()
+
+	notes := []string{}
+	for _, task := range tasks {
+		if triage, ok := m.triage[task.TaskID]; ok && triage.reason != "" {
+			notes = append(notes, fmt.Sprintf("- %s: %s", task.TaskID, singleLineSummaryField(triage.reason)))
+		}
+	}
+	if len(notes) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Triage")
+		fmt.Fprintln(out, strings.Join(notes, "\n"))
+	}
+
+	flaky := []string{}
+	for _, task := range tasks {
+		if tests := m.flakyTests[task.TaskID]; len(tests) > 0 {
+			flaky = append(flaky, fmt.Sprintf("- %s: %s", task.TaskID, strings.Join(tests, ", ")))
+		}
+	}
+	if len(flaky) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Flaky tests")
+		fmt.Fprintln(out, strings.Join(flaky, "\n"))
+	}
+	return out.String()
+}
+
+// summaryTasks returns the run's tasks in start order, leaving out the root
+// the run itself is reported under.
+func (m *Model) summaryTasks() []TaskState {
+	tasks := make([]TaskState, 0, len(m.root.Tasks))
+	for _, task := range m.root.Tasks {
+		if task.TaskID == m.root.RunID {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		left, right := tasks[i].StartedAt, tasks[j].StartedAt
+		if !left.Equal(right) {
+			if left.IsZero() || right.IsZero() {
+				return !left.IsZero()
+			}
+			return left.Before(right)
+		}
+		return tasks[i].TaskID < tasks[j].TaskID
+	})
+	return tasks
+}
+
+func summaryOutcome(task TaskState) string {
+	if status := normalizeTerminalStatus(task.TerminalStatus); status != "" {
+		return status
+	}
+	if task.StartedAt.IsZero() {
+		return "not_started"
+	}
+	return "unfinished"
+}
+
+func formatSummaryDuration(start time.Time, end time.Time) string {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return "-"
+	}
+	return end.Sub(start).Round(time.Second).String()
+}
+
+func formatSummaryEstimate(task TaskState) string {
+	if task.EstimatedCompletion.IsZero() {
+		return "-"
+	}
+	return task.EstimatedDuration.String()
+}
+
+func truncateSummaryField(value string, width int) string {
+	value = singleLineSummaryField(value)
+	runes := []rune(value)
+	if len(runes) <= width {
+		return emptyAsNA(value)
+	}
+	return string(runes[:width-3]) + "..."
+}
+
+func singleLineSummaryField(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,82 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts/conformance"
+)
+
+func TestVCSAdapterConformance(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	conformance.RunVCSSuite(t, conformance.VCSConfig{
+		Backend: "git",
+		NewVCS:  newVCSConformanceFixture,
+	})
+}
+
+func newVCSConformanceFixture(t *testing.T) conformance.VCSFixture {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "yolo")
+	t.Setenv("GIT_AUTHOR_EMAIL", "yolo@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "yolo")
+	t.Setenv("GIT_COMMITTER_EMAIL", "yolo@example.com")
+
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	repo := t.TempDir()
+	dirGit(t, "", "init", "--bare", "--initial-branch=main", origin)
+	dirGit(t, repo, "init", "--initial-branch=main")
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("seed\n"), 0o644); err != nil {
+		t.Fatalf("write seed file: %v", err)
+	}
+	dirGit(t, repo, "add", ".")
+	dirGit(t, repo, "commit", "-m", "seed")
+	dirGit(t, repo, "remote", "add", "origin", origin)
+	dirGit(t, repo, "push", "-u", "origin", "main")
+
+	return conformance.VCSFixture{
+		VCS: NewVCSAdapter(dirRunner{dir: repo}),
+		WriteFile: func(t *testing.T, path string, content string) {
+			t.Helper()
+			if err := os.WriteFile(filepath.Join(repo, path), []byte(content), 0o644); err != nil {
+				t.Fatalf("write %s: %v", path, err)
+			}
+		},
+		ReadMain: func(t *testing.T, path string) (string, bool) {
+			return showFile(repo, "main", path)
+		},
+		ReadRemoteMain: func(t *testing.T, path string) (string, bool) {
+			return showFile(origin, "main", path)
+		},
+	}
+}
+
+type dirRunner struct {
+	dir string
+}
+
+func (r dirRunner) Run(name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = r.dir
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func dirGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	if out, err := (dirRunner{dir: dir}).Run("git", args...); err != nil {
+		t.Fatalf("git %v failed: %v (%s)", args, err, out)
+	}
+}
+
+func showFile(repo string, ref string, path string) (string, bool) {
+	out, err := (dirRunner{dir: repo}).Run("git", "show", ref+":"+path)
+	if err != nil {
+		return "", false
+	}
+	return out, true
+}
@@ -4,22 +4,50 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+const (
+	defaultRemote     = "origin"
+	defaultMainBranch = "main"
 )
 
 type VCSAdapter struct {
-	runner Runner
+	runner     Runner
+	remote     string
+	mainBranch string
+	runID      string
+	// localMain makes landing methods check out main without syncing it from
+	// the remote; see OnLocalMain.
+	localMain bool
 }
 
 func NewVCSAdapter(runner Runner) *VCSAdapter {
-	return &VCSAdapter{runner: runner}
+	return &VCSAdapter{runner: runner, remote: defaultRemote, mainBranch: defaultMainBranch}
+}
+
+// WithRemote sets the remote and branch that EnsureMain syncs from and
+// PushMain pushes to. Empty values keep origin and main.
+func (a *VCSAdapter) WithRemote(remote string, branch string) *VCSAdapter {
+	if remote = strings.TrimSpace(remote); remote != "" {
+		a.remote = remote
+	}
+	if branch = strings.TrimSpace(branch); branch != "" {
+		a.mainBranch = branch
+	}
+	return a
 }
 
+// EnsureMain checks out the main branch and fast-forwards it from the
+// remote. A diverged but clean main is reset to the remote branch.
 func (a *VCSAdapter) EnsureMain(context.Context) error {
-	if _, err := a.runGit("checkout", "main"); err != nil {
+	if _, err := a.runGit("checkout", a.mainBranch); err != nil {
 		return err
 	}
-	if _, err := a.runGit("pull", "--ff-only", "origin", "main"); err != nil {
+	if _, err := a.runGit("pull", "--ff-only", a.remote, a.mainBranch); err != nil {
 		if !isNonFastForwardPullError(err) {
 			return err
 		}
@@ -30,18 +58,28 @@ func (a *VCSAdapter) EnsureMain(context.Context) error {
 		if dirty {
 			return err
 		}
-		if _, fetchErr := a.runGit("fetch", "origin", "main"); fetchErr != nil {
+		if _, fetchErr := a.runGit("fetch", a.remote, a.mainBranch); fetchErr != nil {
 			return errors.Join(err, fetchErr)
 		}
-		if _, resetErr := a.runGit("reset", "--hard", "origin/main"); resetErr != nil {
+		if _, resetErr := a.runGit("reset", "--hard", a.remote+"/"+a.mainBranch); resetErr != nil {
 			return errors.Join(err, resetErr)
 		}
 	}
 	return nil
 }
 
+// WithRunID names task branches task/<task>@<runID>, so runs overlapping on
+// one repo keep their branches apart. Empty keeps task/<task>.
+func (a *VCSAdapter) WithRunID(runID string) *VCSAdapter {
+	a.runID = strings.TrimSpace(runID)
+	return a
+}
+
 func (a *VCSAdapter) CreateTaskBranch(ctx context.Context, taskID string) (string, error) {
 	branch := "task/" + taskID
+	if a.runID != "" {
+		branch += "@" + a.runID
+	}
 	if err := a.EnsureMain(ctx); err != nil {
 		return "", err
 	}
@@ -64,23 +102,134 @@ func (a *VCSAdapter) CommitAll(_ context.Context, message string) (string, error
 }
 
 func (a *VCSAdapter) MergeToMain(ctx context.Context, sourceBranch string) error {
-	if err := a.EnsureMain(ctx); err != nil {
+	return a.merge(ctx, "merge", "--no-ff", sourceBranch)
+}
+
+// MergeToMainWithMessage merges like MergeToMain but uses message for the
+// merge commit instead of git's default.
+func (a *VCSAdapter) MergeToMainWithMessage(ctx context.Context, sourceBranch string, message string) error {
+	return a.merge(ctx, "merge", "--no-ff", "-m", message, sourceBranch)
+}
+
+// RebaseAndFastForwardMain rebases sourceBranch onto the updated main and
+// fast-forwards main to it. A failed rebase is aborted and main is checked
+// out again, so the clone is left as it was.
+func (a *VCSAdapter) RebaseAndFastForwardMain(ctx context.Context, sourceBranch string) error {
+	if err := a.landingMain(ctx); err != nil {
+		return err
+	}
+	if _, err := a.runGit("checkout", sourceBranch); err != nil {
+		return err
+	}
+	if _, err := a.runGit("rebase", a.mainBranch); err != nil {
+		_, _ = a.runGit("rebase", "--abort")
+		_, _ = a.runGit("checkout", a.mainBranch)
+		return err
+	}
+	if _, err := a.runGit("checkout", a.mainBranch); err != nil {
+		return err
+	}
+	_, err := a.runGit("merge", "--ff-only", sourceBranch)
+	return err
+}
+
+// SquashToMain squashes sourceBranch into a single commit on the updated
+// main. A conflicting squash is reset so main is left clean.
+func (a *VCSAdapter) SquashToMain(ctx context.Context, sourceBranch string, message string) error {
+	if err := a.landingMain(ctx); err != nil {
+		return err
+	}
+	if _, err := a.runGit("merge", "--squash", sourceBranch); err != nil {
+		_, _ = a.runGit("reset", "--merge")
 		return err
 	}
-	if _, err := a.runGit("merge", "--no-ff", sourceBranch); err != nil {
+	if _, err := a.runGit("commit", "-m", message); err != nil && !isNoChangesCommitError(err) {
+		_, _ = a.runGit("reset", "--merge")
+		return err
+	}
+	return nil
+}
+
+func (a *VCSAdapter) merge(ctx context.Context, args ...string) error {
+	if err := a.landingMain(ctx); err != nil {
+		return err
+	}
+	if _, err := a.runGit(args...); err != nil {
 		_, _ = a.runGit("merge", "--abort")
 		return err
 	}
 	return nil
 }
 
+// landingMain checks out main for a landing, syncing it from the remote
+// unless the adapter came from OnLocalMain.
+func (a *VCSAdapter) landingMain(ctx context.Context) error {
+	if a.localMain {
+		_, err := a.runGit("checkout", a.mainBranch)
+		return err
+	}
+	return a.EnsureMain(ctx)
+}
+
+// OnLocalMain returns a copy of the adapter whose landing methods leave the
+// local main as it is, so a batch of landings synced once by EnsureMain is
+// not reset when the remote moves between them.
+func (a *VCSAdapter) OnLocalMain() contracts.VCS {
+	local := *a
+	local.localMain = true
+	return &local
+}
+
+// MainHead returns the commit the local main points at.
+func (a *VCSAdapter) MainHead(context.Context) (string, error) {
+	out, err := a.runGit("rev-parse", a.mainBranch)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// IsOnMain reports whether sha is reachable from the local main.
+func (a *VCSAdapter) IsOnMain(_ context.Context, sha string) (bool, error) {
+	out, err := a.runGit("merge-base", sha, a.mainBranch)
+	if err != nil {
+		return false, err
+	}
+	full, err := a.runGit("rev-parse", sha)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) == strings.TrimSpace(full), nil
+}
+
+// ResetMain checks out main and resets it to the remote branch, dropping
+// landings that were not pushed.
+func (a *VCSAdapter) ResetMain(context.Context) error {
+	if _, err := a.runGit("checkout", a.mainBranch); err != nil {
+		return err
+	}
+	if _, err := a.runGit("fetch", a.remote, a.mainBranch); err != nil {
+		return err
+	}
+	_, err := a.runGit("reset", "--hard", a.remote+"/"+a.mainBranch)
+	return err
+}
+
 func (a *VCSAdapter) PushBranch(_ context.Context, branch string) error {
-	_, err := a.runGit("push", "-u", "origin", branch)
+	_, err := a.runGit("push", "-u", a.remote, branch)
+	return err
+}
+
+// FetchBranch copies branch from the repository at repoPath into this one,
+// overwriting a stale local copy.
+func (a *VCSAdapter) FetchBranch(_ context.Context, repoPath string, branch string) error {
+	ref := "refs/heads/" + branch
+	_, err := a.runGit("fetch", repoPath, "+"+ref+":"+ref)
 	return err
 }
 
 func (a *VCSAdapter) PushMain(context.Context) error {
-	_, err := a.runGit("push", "origin", "main")
+	_, err := a.runGit("push", a.remote, a.mainBranch)
 	return err
 }
 
@@ -108,6 +257,106 @@ func isNoChangesCommitError(err error) bool {
 	return strings.Contains(message, "nothing to commit") || strings.Contains(message, "no changes added to commit")
 }
 
+// ChangedFiles lists the files the current branch changed relative to main,
+// counting commits, staged and unstaged edits and untracked files. Renames
+// list both paths.
+func (a *VCSAdapter) ChangedFiles(context.Context) ([]string, error) {
+	seen := map[string]struct{}{}
+	files := []string{}
+	for _, args := range [][]string{
+		{"diff", "--name-only", "--no-renames", a.mainBranch + "...HEAD"},
+		{"diff", "--name-only", "--no-renames", "HEAD"},
+		{"ls-files", "--others", "--exclude-standard"},
+	} {
+		out, err := a.runGit(args...)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(out, "\n") {
+			file := strings.TrimSpace(line)
+			if file == "" {
+				continue
+			}
+			if _, ok := seen[file]; ok {
+				continue
+			}
+			seen[file] = struct{}{}
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// DiffStats reports per-file line counts between the merge base with main
+// and the working tree, new files included.
+func (a *VCSAdapter) DiffStats(context.Context) ([]contracts.FileDiffStat, error) {
+	base, err := a.taskDiffBase()
+	if err != nil {
+		return nil, err
+	}
+	out, err := a.runGit("diff", "--numstat", "--no-renames", base)
+	if err != nil {
+		return nil, err
+	}
+	stats := []contracts.FileDiffStat{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		stat := contracts.FileDiffStat{Path: fields[2]}
+		if fields[0] == "-" && fields[1] == "-" {
+			stat.Binary = true
+		} else {
+			stat.Added, _ = strconv.Atoi(fields[0])
+			stat.Deleted, _ = strconv.Atoi(fields[1])
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// Diff returns the zero-context unified diff between the merge base with
+// main and the working tree, new files included.
+func (a *VCSAdapter) Diff(context.Context) (string, error) {
+	base, err := a.taskDiffBase()
+	if err != nil {
+		return "", err
+	}
+	return a.runGit("diff", "--no-color", "--no-renames", "--unified=0", base)
+}
+
+// ReadBaseFile returns path as of the merge base of HEAD with main, or ""
+// when it did not exist there.
+func (a *VCSAdapter) ReadBaseFile(_ context.Context, path string) (string, error) {
+	base, err := a.runGit("merge-base", a.mainBranch, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	content, err := a.runGit("show", strings.TrimSpace(base)+":"+path)
+	if err != nil {
+		message := err.Error()
+		if strings.Contains(message, "does not exist in") || strings.Contains(message, "exists on disk, but not in") {
+			return "", nil
+		}
+		return "", err
+	}
+	return content, nil
+}
+
+// taskDiffBase returns the merge base of HEAD with main. Untracked files are
+// recorded with "git add --intent-to-add" so diffs against it include them.
+func (a *VCSAdapter) taskDiffBase() (string, error) {
+	base, err := a.runGit("merge-base", a.mainBranch, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if _, err := a.runGit("add", "--all", "--intent-to-add"); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(base), nil
+}
+
 func (a *VCSAdapter) runGit(args ...string) (string, error) {
 	out, err := a.runner.Run("git", args...)
 	if err == nil {
@@ -33,6 +33,39 @@ func TestEnsureMainChecksOutAndFastForwardsMain(t *testing.T) {
 	}
 }
 
+func TestEnsureMainSyncsFromConfiguredRemoteBranch(t *testing.T) {
+	r := &fakeRunner{}
+	a := NewVCSAdapter(r).WithRemote("upstream", "develop")
+
+	if _, err := a.CreateTaskBranch(context.Background(), "task-1"); err != nil {
+		t.Fatalf("create branch failed: %v", err)
+	}
+	if err := a.PushMain(context.Background()); err != nil {
+		t.Fatalf("push main failed: %v", err)
+	}
+	want := []call{
+		{name: "git", args: []string{"checkout", "develop"}},
+		{name: "git", args: []string{"pull", "--ff-only", "upstream", "develop"}},
+		{name: "git", args: []string{"checkout", "-b", "task/task-1"}},
+		{name: "git", args: []string{"push", "upstream", "develop"}},
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Fatalf("unexpected call sequence: got %#v want %#v", r.calls, want)
+	}
+}
+
+func TestWithRemoteKeepsDefaultsForEmptyValues(t *testing.T) {
+	r := &fakeRunner{}
+	a := NewVCSAdapter(r).WithRemote(" ", "")
+
+	if err := a.EnsureMain(context.Background()); err != nil {
+		t.Fatalf("ensure main failed: %v", err)
+	}
+	if !reflect.DeepEqual(r.calls[1], call{name: "git", args: []string{"pull", "--ff-only", "origin", "main"}}) {
+		t.Fatalf("expected origin/main defaults, got %#v", r.calls)
+	}
+}
+
 func TestEnsureMainIncludesGitOutputInCheckoutFailure(t *testing.T) {
 	r := &fakeRunner{
 		output: "error: Your local changes to the following files would be overwritten by checkout",
@@ -130,6 +163,19 @@ func TestCreateTaskBranchFromMain(t *testing.T) {
 	}
 }
 
+func TestCreateTaskBranchSuffixesRunID(t *testing.T) {
+	r := &fakeRunner{}
+	a := NewVCSAdapter(r).WithRunID("20261016T101500Z-0a1b2c3d")
+
+	branch, err := a.CreateTaskBranch(context.Background(), "task-123")
+	if err != nil {
+		t.Fatalf("create task branch failed: %v", err)
+	}
+	if branch != "task/task-123@20261016T101500Z-0a1b2c3d" {
+		t.Fatalf("unexpected branch name: %q", branch)
+	}
+}
+
 func TestCreateTaskBranchFallsBackToCheckoutExistingBranch(t *testing.T) {
 	r := &branchExistsRunner{}
 	a := NewVCSAdapter(r)
@@ -178,6 +224,21 @@ func TestMergeAndPushMain(t *testing.T) {
 	}
 }
 
+func TestMergeToMainWithMessageSetsMergeCommitMessage(t *testing.T) {
+	r := &fakeRunner{}
+	a := NewVCSAdapter(r)
+
+	if err := a.MergeToMainWithMessage(context.Background(), "task/task-123", "Merge task-123: Fix login"); err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	if len(r.calls) != 3 {
+		t.Fatalf("expected 3 calls, got %#v", r.calls)
+	}
+	if !reflect.DeepEqual(r.calls[2], call{name: "git", args: []string{"merge", "--no-ff", "-m", "Merge task-123: Fix login", "task/task-123"}}) {
+		t.Fatalf("unexpected merge call: %#v", r.calls[2])
+	}
+}
+
 func TestMergeToMainAbortsMergeOnConflict(t *testing.T) {
 	r := &sequenceRunner{responses: []sequenceResponse{
 		{output: "", err: nil},
@@ -203,6 +264,95 @@ func TestMergeToMainAbortsMergeOnConflict(t *testing.T) {
 	}
 }
 
+func TestRebaseAndFastForwardMainKeepsHistoryLinear(t *testing.T) {
+	r := &fakeRunner{}
+	a := NewVCSAdapter(r)
+
+	if err := a.RebaseAndFastForwardMain(context.Background(), "task/task-123"); err != nil {
+		t.Fatalf("rebase landing failed: %v", err)
+	}
+	want := []call{
+		{name: "git", args: []string{"checkout", "main"}},
+		{name: "git", args: []string{"pull", "--ff-only", "origin", "main"}},
+		{name: "git", args: []string{"checkout", "task/task-123"}},
+		{name: "git", args: []string{"rebase", "main"}},
+		{name: "git", args: []string{"checkout", "main"}},
+		{name: "git", args: []string{"merge", "--ff-only", "task/task-123"}},
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Fatalf("unexpected call sequence: got %#v want %#v", r.calls, want)
+	}
+}
+
+func TestRebaseAndFastForwardMainAbortsRebaseOnConflict(t *testing.T) {
+	r := &sequenceRunner{responses: []sequenceResponse{
+		{output: "", err: nil},
+		{output: "", err: nil},
+		{output: "", err: nil},
+		{output: "CONFLICT (content): Merge conflict in app.go\nerror: could not apply 1a2b3c4... task", err: errors.New("exit status 1")},
+		{output: "", err: nil},
+		{output: "", err: nil},
+	}}
+	a := NewVCSAdapter(r)
+
+	err := a.RebaseAndFastForwardMain(context.Background(), "task/task-123")
+	if err == nil || !strings.Contains(err.Error(), "could not apply") {
+		t.Fatalf("expected rebase failure with git output, got %v", err)
+	}
+	want := []call{
+		{name: "git", args: []string{"checkout", "main"}},
+		{name: "git", args: []string{"pull", "--ff-only", "origin", "main"}},
+		{name: "git", args: []string{"checkout", "task/task-123"}},
+		{name: "git", args: []string{"rebase", "main"}},
+		{name: "git", args: []string{"rebase", "--abort"}},
+		{name: "git", args: []string{"checkout", "main"}},
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Fatalf("unexpected call sequence: got %#v want %#v", r.calls, want)
+	}
+}
+
+func TestSquashToMainCommitsBranchAsOneCommit(t *testing.T) {
+	r := &fakeRunner{}
+	a := NewVCSAdapter(r)
+
+	if err := a.SquashToMain(context.Background(), "task/task-123", "task-123: Fix login"); err != nil {
+		t.Fatalf("squash landing failed: %v", err)
+	}
+	want := []call{
+		{name: "git", args: []string{"checkout", "main"}},
+		{name: "git", args: []string{"pull", "--ff-only", "origin", "main"}},
+		{name: "git", args: []string{"merge", "--squash", "task/task-123"}},
+		{name: "git", args: []string{"commit", "-m", "task-123: Fix login"}},
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Fatalf("unexpected call sequence: got %#v want %#v", r.calls, want)
+	}
+}
+
+func TestSquashToMainResetsOnConflict(t *testing.T) {
+	r := &sequenceRunner{responses: []sequenceResponse{
+		{output: "", err: nil},
+		{output: "", err: nil},
+		{output: "CONFLICT (content): Merge conflict in app.go\nSquash commit -- not updating HEAD", err: errors.New("exit status 1")},
+		{output: "", err: nil},
+	}}
+	a := NewVCSAdapter(r)
+
+	if err := a.SquashToMain(context.Background(), "task/task-123", "task-123: Fix login"); err == nil {
+		t.Fatal("expected squash failure")
+	}
+	want := []call{
+		{name: "git", args: []string{"checkout", "main"}},
+		{name: "git", args: []string{"pull", "--ff-only", "origin", "main"}},
+		{name: "git", args: []string{"merge", "--squash", "task/task-123"}},
+		{name: "git", args: []string{"reset", "--merge"}},
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Fatalf("unexpected call sequence: got %#v want %#v", r.calls, want)
+	}
+}
+
 func TestPushBranch(t *testing.T) {
 	r := &fakeRunner{}
 	a := NewVCSAdapter(r)
@@ -214,6 +364,165 @@ func TestPushBranch(t *testing.T) {
 	assertVCSCall(t, r.calls, call{name: "git", args: []string{"push", "-u", "origin", "task/task-123"}})
 }
 
+func TestFetchBranchCopiesBranchFromAnotherRepository(t *testing.T) {
+	r := &fakeRunner{}
+	a := NewVCSAdapter(r)
+	var _ contracts.BranchFetcher = a
+
+	if err := a.FetchBranch(context.Background(), "/clones/task-123", "task/task-123"); err != nil {
+		t.Fatalf("fetch branch failed: %v", err)
+	}
+
+	assertVCSCall(t, r.calls, call{name: "git", args: []string{"fetch", "/clones/task-123", "+refs/heads/task/task-123:refs/heads/task/task-123"}})
+}
+
+func TestOnLocalMainLandsWithoutSyncingMain(t *testing.T) {
+	r := &fakeRunner{}
+	a := NewVCSAdapter(r)
+	var _ contracts.BatchLander = a
+
+	local := a.OnLocalMain()
+	if err := local.MergeToMain(context.Background(), "task/a"); err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	if err := local.(contracts.SquashLander).SquashToMain(context.Background(), "task/b", "b"); err != nil {
+		t.Fatalf("squash failed: %v", err)
+	}
+	if err := a.ResetMain(context.Background()); err != nil {
+		t.Fatalf("reset main failed: %v", err)
+	}
+	want := []call{
+		{name: "git", args: []string{"checkout", "main"}},
+		{name: "git", args: []string{"merge", "--no-ff", "task/a"}},
+		{name: "git", args: []string{"checkout", "main"}},
+		{name: "git", args: []string{"merge", "--squash", "task/b"}},
+		{name: "git", args: []string{"commit", "-m", "b"}},
+		{name: "git", args: []string{"checkout", "main"}},
+		{name: "git", args: []string{"fetch", "origin", "main"}},
+		{name: "git", args: []string{"reset", "--hard", "origin/main"}},
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Fatalf("unexpected call sequence: got %#v want %#v", r.calls, want)
+	}
+}
+
+func TestIsOnMainComparesMergeBaseWithCommit(t *testing.T) {
+	r := &fakeRunner{output: "abc123\n"}
+	a := NewVCSAdapter(r)
+
+	onMain, err := a.IsOnMain(context.Background(), "abc123")
+	if err != nil || !onMain {
+		t.Fatalf("expected commit to be on main, got %v err=%v", onMain, err)
+	}
+	want := []call{
+		{name: "git", args: []string{"merge-base", "abc123", "main"}},
+		{name: "git", args: []string{"rev-parse", "abc123"}},
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Fatalf("unexpected call sequence: got %#v want %#v", r.calls, want)
+	}
+}
+
+func TestChangedFilesCombinesBranchWorktreeAndUntrackedChanges(t *testing.T) {
+	r := &fakeRunner{output: "docs/a.md\ninternal/b.go\n"}
+	a := NewVCSAdapter(r).WithRemote("", "develop")
+	var _ contracts.ChangedFilesLister = a
+
+	files, err := a.ChangedFiles(context.Background())
+	if err != nil {
+		t.Fatalf("changed files failed: %v", err)
+	}
+	if !reflect.DeepEqual(files, []string{"docs/a.md", "internal/b.go"}) {
+		t.Fatalf("expected de-duplicated files, got %#v", files)
+	}
+	want := []call{
+		{name: "git", args: []string{"diff", "--name-only", "--no-renames", "develop...HEAD"}},
+		{name: "git", args: []string{"diff", "--name-only", "--no-renames", "HEAD"}},
+		{name: "git", args: []string{"ls-files", "--others", "--exclude-standard"}},
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Fatalf("unexpected calls %#v", r.calls)
+	}
+}
+
+func TestDiffStatsCountsLinesFromMergeBaseIncludingNewFiles(t *testing.T) {
+	r := &sequenceRunner{responses: []sequenceResponse{
+		{output: "abc123\n"},
+		{},
+		{output: "12\t3\tinternal/a.go\n-\t-\tassets/logo.png\n4\t0\tdocs/new.md\n"},
+	}}
+	a := NewVCSAdapter(r)
+	var _ contracts.DiffStatLister = a
+
+	stats, err := a.DiffStats(context.Background())
+	if err != nil {
+		t.Fatalf("diff stats failed: %v", err)
+	}
+	want := []contracts.FileDiffStat{
+		{Path: "internal/a.go", Added: 12, Deleted: 3},
+		{Path: "assets/logo.png", Binary: true},
+		{Path: "docs/new.md", Added: 4},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Fatalf("unexpected stats %#v", stats)
+	}
+	wantCalls := []call{
+		{name: "git", args: []string{"merge-base", "main", "HEAD"}},
+		{name: "git", args: []string{"add", "--all", "--intent-to-add"}},
+		{name: "git", args: []string{"diff", "--numstat", "--no-renames", "abc123"}},
+	}
+	if !reflect.DeepEqual(r.calls, wantCalls) {
+		t.Fatalf("unexpected calls %#v", r.calls)
+	}
+}
+
+func TestDiffReturnsZeroContextPatchFromMergeBase(t *testing.T) {
+	patch := "diff --git a/a.go b/a.go\n@@ -1,0 +2 @@\n+x := 1\n"
+	r := &sequenceRunner{responses: []sequenceResponse{{output: "abc123\n"}, {}, {output: patch}}}
+	a := NewVCSAdapter(r).WithRemote("", "develop")
+	var _ contracts.DiffReader = a
+
+	got, err := a.Diff(context.Background())
+	if err != nil || got != patch {
+		t.Fatalf("unexpected diff %q err=%v", got, err)
+	}
+	wantCalls := []call{
+		{name: "git", args: []string{"merge-base", "develop", "HEAD"}},
+		{name: "git", args: []string{"add", "--all", "--intent-to-add"}},
+		{name: "git", args: []string{"diff", "--no-color", "--no-renames", "--unified=0", "abc123"}},
+	}
+	if !reflect.DeepEqual(r.calls, wantCalls) {
+		t.Fatalf("unexpected calls %#v", r.calls)
+	}
+}
+
+func TestReadBaseFileShowsMergeBaseContentAndTreatsMissingAsEmpty(t *testing.T) {
+	r := &sequenceRunner{responses: []sequenceResponse{{output: "abc123\n"}, {output: "module example.com/app\n"}}}
+	a := NewVCSAdapter(r)
+	var _ contracts.BaseFileReader = a
+
+	content, err := a.ReadBaseFile(context.Background(), "go.mod")
+	if err != nil || content != "module example.com/app\n" {
+		t.Fatalf("unexpected content %q err=%v", content, err)
+	}
+	wantCalls := []call{
+		{name: "git", args: []string{"merge-base", "main", "HEAD"}},
+		{name: "git", args: []string{"show", "abc123:go.mod"}},
+	}
+	if !reflect.DeepEqual(r.calls, wantCalls) {
+		t.Fatalf("unexpected calls %#v", r.calls)
+	}
+
+	r = &sequenceRunner{responses: []sequenceResponse{
+		{output: "abc123\n"},
+		{output: "fatal: path 'web/package.json' does not exist in 'abc123'", err: errors.New("exit status 128")},
+	}}
+	content, err = NewVCSAdapter(r).ReadBaseFile(context.Background(), "web/package.json")
+	if err != nil || content != "" {
+		t.Fatalf("expected a new file to read as empty, got %q err=%v", content, err)
+	}
+}
+
 func TestCommitAll(t *testing.T) {
 	r := &fakeRunner{output: "abc123\n"}
 	a := NewVCSAdapter(r)
@@ -0,0 +1,141 @@
+// Package contracttest runs the contract tests the built-in backends,
+// trackers, VCS adapter and event sinks pass against implementations written
+// outside this repository, so a module registering them through
+// pkg/registry can check it meets the same expectations.
+//
+// Each suite is called from an ordinary Go test with a factory that builds a
+// fresh implementation per subtest:
+//
+//	func TestRunnerContract(t *testing.T) {
+//		contracttest.RunAgentRunnerSuite(t, contracttest.AgentRunnerConfig{
+//			Backend: "in-house",
+//			NewAdapter: func(t *testing.T, scenario contracttest.Scenario) registry.AgentRunner {
+//				return newRunner(fakeCLIFor(t, scenario))
+//			},
+//		})
+//	}
+//
+// The factories set up whatever the scenario needs, usually a fake CLI or
+// API server scripted to behave as the scenario describes.
+package contracttest
+
+import (
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+	"github.com/egv/yolo-runner/v2/internal/contracts/conformance"
+)
+
+// The contract types the suites exercise.
+type (
+	TaskManager = contracts.TaskManager
+	TaskSummary = contracts.TaskSummary
+	VCS         = contracts.VCS
+	EventSink   = contracts.EventSink
+	Event       = contracts.Event
+)
+
+// Scenario names how an AgentRunner's backend behaves in a subtest:
+//
+//   - ScenarioSuccess: the backend completes and streams at least one
+//     progress update with a type and message.
+//   - ScenarioReviewPass, ScenarioReviewFail: a review run whose structured
+//     verdict passes or fails.
+//   - ScenarioTimeoutError: the backend outlives the request timeout and the
+//     runner returns an error; the result must be blocked with a reason
+//     mentioning the timeout.
+//   - ScenarioContextTimeoutNoErr: as above, but the runner returns no
+//     error.
+//   - ScenarioFailure: the backend fails with an error containing
+//     FailureReason; the result must be failed with that reason.
+//
+// Every result must carry the "backend" and "status" artifacts.
+type Scenario = conformance.Scenario
+
+const (
+	ScenarioSuccess             = conformance.ScenarioSuccess
+	ScenarioReviewPass          = conformance.ScenarioReviewPass
+	ScenarioReviewFail          = conformance.ScenarioReviewFail
+	ScenarioTimeoutError        = conformance.ScenarioTimeoutError
+	ScenarioContextTimeoutNoErr = conformance.ScenarioContextTimeoutNoErr
+	ScenarioFailure             = conformance.ScenarioFailure
+	FailureReason               = conformance.FailureReason
+)
+
+type (
+	AdapterFactory    = conformance.AdapterFactory
+	AgentRunnerConfig = conformance.Config
+)
+
+// RunAgentRunnerSuite checks how a runner maps its backend's behavior in
+// each Scenario to a RunnerResult.
+func RunAgentRunnerSuite(t *testing.T, cfg AgentRunnerConfig) {
+	t.Helper()
+	conformance.RunAgentRunnerSuite(t, cfg)
+}
+
+// TaskManagerScenario names the tracker data a TaskManager is built over:
+//
+//   - TaskManagerScenarioTaskSelection: under "root", root.1 (priority 1)
+//     depends on the open dep.1, root.2 "Ready now" has priority 0 and
+//     root.3 "Lower priority" has priority 2. NextTasks("root") must return
+//     root.2 then root.3.
+//   - TaskManagerScenarioGetTaskDetails: open task t-1 "Task 1" with
+//     description "do work" depending on d-1 and d-2, which GetTask reports
+//     as the "dependencies" metadata "d-1,d-2".
+//   - TaskManagerScenarioTerminalStateTransitions: open root.1 (priority 1)
+//     and root.2 (priority 2) under "root". Failed and blocked root.1 must
+//     drop out of NextTasks and return when reopened.
+//   - TaskManagerScenarioStatusLifecycle: task t-1 must accept every status.
+//   - TaskManagerScenarioSetTaskData: task t-1 must accept a data map.
+//
+// The fixture's Assert hook can check what the manager wrote to the
+// tracker.
+type TaskManagerScenario = conformance.TaskManagerScenario
+
+const (
+	TaskManagerScenarioTaskSelection            = conformance.TaskManagerScenarioTaskSelection
+	TaskManagerScenarioGetTaskDetails           = conformance.TaskManagerScenarioGetTaskDetails
+	TaskManagerScenarioTerminalStateTransitions = conformance.TaskManagerScenarioTerminalStateTransitions
+	TaskManagerScenarioStatusLifecycle          = conformance.TaskManagerScenarioStatusLifecycle
+	TaskManagerScenarioSetTaskData              = conformance.TaskManagerScenarioSetTaskData
+)
+
+type (
+	TaskManagerFixture = conformance.TaskManagerFixture
+	TaskManagerFactory = conformance.TaskManagerFactory
+	TaskManagerConfig  = conformance.TaskManagerConfig
+)
+
+// RunTaskManagerSuite checks task selection, task details and status and
+// data writes against the data of each TaskManagerScenario.
+func RunTaskManagerSuite(t *testing.T, cfg TaskManagerConfig) {
+	t.Helper()
+	conformance.RunTaskManagerSuite(t, cfg)
+}
+
+type (
+	VCSFixture = conformance.VCSFixture
+	VCSFactory = conformance.VCSFactory
+	VCSConfig  = conformance.VCSConfig
+)
+
+// RunVCSSuite checks task branching, committing, merging to main and
+// pushing, each on a fresh repository from the factory.
+func RunVCSSuite(t *testing.T, cfg VCSConfig) {
+	t.Helper()
+	conformance.RunVCSSuite(t, cfg)
+}
+
+type (
+	EventSinkFixture = conformance.EventSinkFixture
+	EventSinkFactory = conformance.EventSinkFactory
+	EventSinkConfig  = conformance.EventSinkConfig
+)
+
+// RunEventSinkSuite checks that a sink delivers every event once, in order
+// and with its fields, including when workers emit concurrently.
+func RunEventSinkSuite(t *testing.T, cfg EventSinkConfig) {
+	t.Helper()
+	conformance.RunEventSinkSuite(t, cfg)
+}
@@ -0,0 +1,70 @@
+package contracttest_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/egv/yolo-runner/v2/pkg/contracttest"
+	"github.com/egv/yolo-runner/v2/pkg/registry"
+)
+
+type memorySink struct {
+	mu     sync.Mutex
+	events []contracttest.Event
+}
+
+func (s *memorySink) Emit(_ context.Context, event contracttest.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestRunEventSinkSuiteAcceptsExternalSink(t *testing.T) {
+	contracttest.RunEventSinkSuite(t, contracttest.EventSinkConfig{
+		Backend: "memory",
+		NewEventSink: func(t *testing.T) contracttest.EventSinkFixture {
+			sink := &memorySink{}
+			return contracttest.EventSinkFixture{
+				Sink: sink,
+				Events: func(t *testing.T) []contracttest.Event {
+					sink.mu.Lock()
+					defer sink.mu.Unlock()
+					return append([]contracttest.Event(nil), sink.events...)
+				},
+			}
+		},
+	})
+}
+
+// scriptedRunner stands in for an external backend that already behaves as
+// each scenario describes.
+type scriptedRunner struct {
+	scenario contracttest.Scenario
+}
+
+func (r scriptedRunner) Run(_ context.Context, request registry.RunnerRequest) (registry.RunnerResult, error) {
+	result := registry.RunnerResult{Status: registry.RunnerResultCompleted}
+	switch r.scenario {
+	case contracttest.ScenarioSuccess:
+		request.OnProgress(registry.RunnerProgress{Type: "runner_output", Message: "working"})
+	case contracttest.ScenarioReviewPass:
+		result.ReviewReady = true
+	case contracttest.ScenarioTimeoutError, contracttest.ScenarioContextTimeoutNoErr:
+		result.Status, result.Reason = registry.RunnerResultBlocked, "runner timeout"
+	case contracttest.ScenarioFailure:
+		result.Status, result.Reason = registry.RunnerResultFailed, contracttest.FailureReason
+	}
+	result.Artifacts = map[string]string{"backend": "scripted", "status": string(result.Status)}
+	return result, nil
+}
+
+func TestRunAgentRunnerSuiteAcceptsExternalRunner(t *testing.T) {
+	contracttest.RunAgentRunnerSuite(t, contracttest.AgentRunnerConfig{
+		Backend: "scripted",
+		NewAdapter: func(t *testing.T, scenario contracttest.Scenario) registry.AgentRunner {
+			return scriptedRunner{scenario: scenario}
+		},
+	})
+}
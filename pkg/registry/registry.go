@@ -0,0 +1,166 @@
+// Package registry lets Go modules outside this repository add runner
+// backends and trackers to yolo-agent without changing internal/.
+//
+// A module registers its implementations from an init function:
+//
+//	func init() {
+//		registry.RegisterBackend("in-house", func(cfg registry.BackendConfig) (registry.AgentRunner, error) {
+//			return newRunner(cfg.Binary, cfg.Config)
+//		})
+//		registry.RegisterTracker("jira", func(cfg registry.TrackerConfig) (registry.StorageBackend, error) {
+//			return newJiraBackend(cfg.Settings)
+//		})
+//	}
+//
+// and reaches yolo-agent as a Go plugin (go build -buildmode=plugin) listed
+// in YOLO_AGENT_PLUGINS. A registered backend is selected with
+// --agent-backend <name>, or by naming it as the adapter of a coding-agent
+// definition; a registered tracker is selected with tracker.type <name>.
+// Built-in backends and trackers take precedence over registered ones.
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/egv/yolo-runner/v2/internal/contracts"
+)
+
+// The contract types implementations are written against.
+type (
+	AgentRunner        = contracts.AgentRunner
+	RunnerRequest      = contracts.RunnerRequest
+	RunnerResult       = contracts.RunnerResult
+	RunnerResultStatus = contracts.RunnerResultStatus
+	RunnerProgress     = contracts.RunnerProgress
+	RunnerMode         = contracts.RunnerMode
+	StorageBackend     = contracts.StorageBackend
+	Task               = contracts.Task
+	TaskStatus         = contracts.TaskStatus
+	TaskTree           = contracts.TaskTree
+	TaskRelation       = contracts.TaskRelation
+	RelationType       = contracts.RelationType
+)
+
+const (
+	RunnerModeImplement = contracts.RunnerModeImplement
+	RunnerModeReview    = contracts.RunnerModeReview
+	RunnerModePlan      = contracts.RunnerModePlan
+
+	RunnerResultCompleted = contracts.RunnerResultCompleted
+	RunnerResultBlocked   = contracts.RunnerResultBlocked
+	RunnerResultFailed    = contracts.RunnerResultFailed
+
+	TaskStatusOpen       = contracts.TaskStatusOpen
+	TaskStatusInProgress = contracts.TaskStatusInProgress
+	TaskStatusBlocked    = contracts.TaskStatusBlocked
+	TaskStatusClosed     = contracts.TaskStatusClosed
+	TaskStatusFailed     = contracts.TaskStatusFailed
+
+	RelationParent    = contracts.RelationParent
+	RelationDependsOn = contracts.RelationDependsOn
+	RelationBlocks    = contracts.RelationBlocks
+)
+
+// BackendConfig is the coding-agent definition a backend is built from.
+// Without a definition file, only Name is set.
+type BackendConfig struct {
+	Name   string
+	Model  string
+	Binary string
+	Args   []string
+	Config map[string]any
+}
+
+// TrackerConfig is the tracker profile a tracker is built from. Settings
+// holds the profile's tracker.settings block.
+type TrackerConfig struct {
+	RepoRoot string
+	Profile  string
+	Type     string
+	Settings map[string]any
+}
+
+type BackendFactory func(BackendConfig) (AgentRunner, error)
+
+type TrackerFactory func(TrackerConfig) (StorageBackend, error)
+
+var (
+	mu       sync.RWMutex
+	backends = map[string]BackendFactory{}
+	trackers = map[string]TrackerFactory{}
+)
+
+// RegisterBackend makes a runner backend available under name. Like
+// database/sql.Register, it panics if name is empty, factory is nil or
+// name is already registered.
+func RegisterBackend(name string, factory BackendFactory) {
+	name = normalizeName(name)
+	mu.Lock()
+	defer mu.Unlock()
+	if name == "" || factory == nil {
+		panic("registry: RegisterBackend needs a name and a factory")
+	}
+	if _, dup := backends[name]; dup {
+		panic(fmt.Sprintf("registry: backend %q registered twice", name))
+	}
+	backends[name] = factory
+}
+
+// RegisterTracker makes a tracker type available under name. It panics
+// under the same conditions as RegisterBackend.
+func RegisterTracker(name string, factory TrackerFactory) {
+	name = normalizeName(name)
+	mu.Lock()
+	defer mu.Unlock()
+	if name == "" || factory == nil {
+		panic("registry: RegisterTracker needs a name and a factory")
+	}
+	if _, dup := trackers[name]; dup {
+		panic(fmt.Sprintf("registry: tracker %q registered twice", name))
+	}
+	trackers[name] = factory
+}
+
+func Backend(name string) (BackendFactory, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	factory, ok := backends[normalizeName(name)]
+	return factory, ok
+}
+
+func Tracker(name string) (TrackerFactory, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	factory, ok := trackers[normalizeName(name)]
+	return factory, ok
+}
+
+// Backends returns the registered backend names, sorted.
+func Backends() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return sortedNames(backends)
+}
+
+// Trackers returns the registered tracker names, sorted.
+func Trackers() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return sortedNames(trackers)
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func sortedNames[T any](factories map[string]T) []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type stubRunner struct{}
+
+func (stubRunner) Run(context.Context, RunnerRequest) (RunnerResult, error) {
+	return RunnerResult{Status: RunnerResultCompleted}, nil
+}
+
+func TestRegisterBackendNormalizesNameAndRejectsDuplicates(t *testing.T) {
+	RegisterBackend(" Registry-Test-Backend ", func(BackendConfig) (AgentRunner, error) { return stubRunner{}, nil })
+
+	factory, ok := Backend("registry-test-backend")
+	if !ok {
+		t.Fatalf("expected registered backend to be found")
+	}
+	runner, err := factory(BackendConfig{Name: "registry-test-backend"})
+	if err != nil || runner == nil {
+		t.Fatalf("expected factory to build runner, got %v %v", runner, err)
+	}
+	if !containsName(Backends(), "registry-test-backend") {
+		t.Fatalf("expected backend in %v", Backends())
+	}
+	assertPanics(t, func() {
+		RegisterBackend("registry-test-backend", func(BackendConfig) (AgentRunner, error) { return stubRunner{}, nil })
+	})
+	assertPanics(t, func() { RegisterBackend("", func(BackendConfig) (AgentRunner, error) { return stubRunner{}, nil }) })
+	assertPanics(t, func() { RegisterBackend("registry-test-nil", nil) })
+}
+
+func TestRegisterTrackerListsSortedNames(t *testing.T) {
+	RegisterTracker("registry-test-tracker-b", func(TrackerConfig) (StorageBackend, error) { return nil, nil })
+	RegisterTracker("registry-test-tracker-a", func(TrackerConfig) (StorageBackend, error) { return nil, nil })
+
+	if _, ok := Tracker("REGISTRY-TEST-TRACKER-A"); !ok {
+		t.Fatalf("expected tracker lookup to ignore case")
+	}
+	if _, ok := Tracker("registry-test-missing"); ok {
+		t.Fatalf("expected unknown tracker to be missing")
+	}
+	got := []string{}
+	for _, name := range Trackers() {
+		if name == "registry-test-tracker-a" || name == "registry-test-tracker-b" {
+			got = append(got, name)
+		}
+	}
+	if !reflect.DeepEqual(got, []string{"registry-test-tracker-a", "registry-test-tracker-b"}) {
+		t.Fatalf("expected sorted tracker names, got %v", Trackers())
+	}
+	assertPanics(t, func() {
+		RegisterTracker("registry-test-tracker-a", func(TrackerConfig) (StorageBackend, error) { return nil, nil })
+	})
+}
+
+func containsName(names []string, want string) bool {
+	for _, name := range names {
+		if name == want {
+			return true
+		}
+	}
+	return false
+}
+
+func assertPanics(t *testing.T, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic")
+		}
+	}()
+	fn()
+}